@@ -10,14 +10,24 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/supergiant/control/pkg/acme"
 	"github.com/supergiant/control/pkg/controlplane"
+	"github.com/supergiant/control/pkg/eventbus"
+	"github.com/supergiant/control/pkg/gitops"
+	"github.com/supergiant/control/pkg/idempotency"
+	"github.com/supergiant/control/pkg/ldap"
 	"github.com/supergiant/control/pkg/proxy"
+	"github.com/supergiant/control/pkg/queue"
+	"github.com/supergiant/control/pkg/retention"
+	"github.com/supergiant/control/pkg/settings"
+	"github.com/supergiant/control/pkg/sglog"
+	"github.com/supergiant/control/pkg/tracing"
 )
 
 var (
 	version       = "unstable"
 	addr          = flag.String("address", "0.0.0.0", "network interface to attach server to")
-	port          = flag.Int("port", 0, "secure tcp port to listen to for incoming HTTPS requests. Provide server certificates with -cert-file and -key-file flags")
+	port          = flag.Int("port", 0, "secure tcp port to listen to for incoming HTTPS requests. Provide server certificates with -cert-file and -key-file flags or obtain them with -acme-domains")
 	insecurePort  = flag.Int("insecure-port", 8080, "tcp port to listen for incoming HTTP requests. if -port is set this flag will be ignored")
 	certFile      = flag.String("cert-file", "", "file containing server x509 certificate")
 	keyFile       = flag.String("key-file", "", "file containing x509 private key matching --cert-file")
@@ -31,6 +41,56 @@ var (
 	//TODO: rewrite to single flag port-range
 	ProxiesPortRangeFrom = flag.Int("proxies-port-from", 60200, "first tcp port in a range of binding reverse proxies for service apps")
 	ProxiesPortRangeTo   = flag.Int("proxies-port-to", 60250, "last tcp port in a range of binding reverse proxies for service apps")
+	gitopsRepo           = flag.String("gitops-repo", "", "git repository of cluster specs to reconcile clusters from, gitops mode is off when empty")
+	gitopsBranch         = flag.String("gitops-branch", "master", "branch of the gitops repository")
+	gitopsPath           = flag.String("gitops-path", "", "directory of cluster specs in the gitops repository")
+	gitopsInterval       = flag.Duration("gitops-interval", time.Minute, "interval between syncs of the gitops repository")
+	gitopsDryRun         = flag.Bool("gitops-dry-run", false, "report changes of the gitops repository without applying them")
+	taskRetentionAge     = flag.Duration("task-retention-age", 0, "age of finished tasks and their logs to prune, tasks are kept forever when it's 0")
+	taskRetentionCount   = flag.Int("task-retention-count", 0, "number of the newest finished tasks to keep, all tasks are kept when it's 0")
+	taskArchive          = flag.String("task-archive", "", "url of the archive of pruned tasks, e.g. file:///var/lib/control/archive or s3://bucket/tasks?region=us-east-1")
+	tracingEndpoint      = flag.String("tracing-endpoint", "", "address of the OpenCensus agent or OpenTelemetry collector to export traces to, e.g. localhost:55678, tracing is off when empty")
+	tracingServiceName   = flag.String("tracing-service-name", "supergiant-control", "service name of exported traces")
+	tracingSampleRate    = flag.Float64("tracing-sample-rate", 1, "probability of tracing a request")
+	natsURL              = flag.String("nats-url", "", "url of the NATS server to publish lifecycle events of clusters to as CloudEvents, e.g. nats://nats:4222, it's off when empty")
+	natsSubject          = flag.String("nats-subject", eventbus.DefaultNATSSubject, "subject prefix of events published to NATS, the event type is appended")
+	kafkaURL             = flag.String("kafka-rest-url", "", "url of the Kafka REST proxy to publish lifecycle events of clusters to as CloudEvents, e.g. http://kafka-rest:8082, it's off when empty")
+	kafkaTopic           = flag.String("kafka-topic", eventbus.DefaultKafkaTopic, "Kafka topic of published events")
+	eventsSource         = flag.String("events-source", eventbus.DefaultSource, "source of published CloudEvents, it tells installs of control apart")
+	grpcPort             = flag.Int("grpc-port", 0, "port of the gRPC API, it isn't served when 0")
+	shutdownTimeout      = flag.Duration("shutdown-timeout", time.Minute*5, "time running steps have to finish on shutdown before they are cancelled, interrupted tasks are resumed on restart")
+	rateLimit            = flag.Float64("rate-limit", 0, "requests per second of each user and token to the API, requests aren't limited when it's 0")
+	rateLimitBurst       = flag.Int("rate-limit-burst", 20, "requests of each user and token that are allowed above the rate limit")
+	maxClusters          = flag.Int("max-clusters", 0, "clusters of each user, it's unlimited when 0")
+	maxProvisions        = flag.Int("max-concurrent-provisions", 0, "clusters each user provisions at once, it's unlimited when 0")
+	provisionWorkers     = flag.Int("provision-workers", queue.DefaultWorkers, "clusters provisioned at once, provisioning of other clusters waits in the queue")
+	idempotencyTTL       = flag.Duration("idempotency-ttl", idempotency.DefaultTTL, "time responses of requests with Idempotency-Key headers are replayed to retries")
+	ldapURL              = flag.String("ldap-url", "", "url of the LDAP or Active Directory server that authenticates users that aren't local, e.g. ldaps://ldap.example.com, LDAP is off when empty")
+	ldapStartTLS         = flag.Bool("ldap-start-tls", false, "upgrade ldap:// connections with StartTLS")
+	ldapInsecure         = flag.Bool("ldap-insecure-skip-verify", false, "don't verify the certificate of the LDAP server")
+	ldapBindDN           = flag.String("ldap-bind-dn", "", "DN of the service account that searches users and groups, searches are anonymous when empty")
+	ldapBindPassword     = flag.String("ldap-bind-password", "", "password of the LDAP service account")
+	ldapBaseDN           = flag.String("ldap-base-dn", "", "DN of the subtree of users, e.g. ou=people,dc=example,dc=com")
+	ldapUserFilter       = flag.String("ldap-user-filter", ldap.DefaultUserFilter, "filter of a user by login, e.g. (sAMAccountName=%s) for Active Directory")
+	ldapGroupBaseDN      = flag.String("ldap-group-base-dn", "", "DN of the subtree of groups, it's the base DN of users when empty")
+	ldapGroupFilter      = flag.String("ldap-group-filter", ldap.DefaultGroupFilter, "filter of groups by DN of a member")
+	ldapNestedGroups     = flag.Bool("ldap-nested-groups", false, "resolve groups that are members of groups")
+	ldapGroupRoles       = flag.String("ldap-group-roles", "", "roles of groups, e.g. admin=cn=admins,ou=groups,dc=example,dc=com;view=cn=devs,ou=groups,dc=example,dc=com")
+	ldapDefaultRole      = flag.String("ldap-default-role", "", "role of LDAP users that aren't in groups with roles, such users can't log in when empty")
+	ldapPoolSize         = flag.Int("ldap-pool-size", ldap.DefaultPoolSize, "idle connections to the LDAP server that are kept")
+	twoFactorRoles       = flag.String("two-factor-roles", "", "comma separated roles of users that need second factors for password logins, e.g. admin,edit")
+	catalogFile          = flag.String("catalog", "", "yaml file of the application catalog that replaces the built-in one")
+	versionFeed          = flag.String("version-feed", "", "url of the JSON matrix of supported kubernetes versions that replaces the built-in one, e.g. https://example.com/versions.json")
+	versionFeedInterval  = flag.Duration("version-feed-interval", 24*time.Hour, "interval between fetches of the version feed")
+	pluginsDir           = flag.String("plugins-dir", "", "directory of executables of step plugins that are started on startup, plugins are off when empty")
+	chaosMode            = flag.Bool("chaos-mode", false, "allow admins to inject failures and hangs into workflow steps with /admin/faults, don't turn it on in production")
+	acmeDomains          = flag.String("acme-domains", "", "comma separated domains of the certificate of the secure port that is obtained with ACME, e.g. control.example.com, ACME is off when empty")
+	acmeEmail            = flag.String("acme-email", "", "contact email of the ACME account")
+	acmeDirectory        = flag.String("acme-directory", acme.DefaultDirectoryURL, "directory url of the ACME server")
+	acmeChallenge        = flag.String("acme-challenge", "http-01", "ACME challenge type [http-01 dns-01]")
+	acmeDNSAccount       = flag.String("acme-dns-account", "", "cloud account whose DNS zones publish dns-01 challenges")
+	acmeHTTPAddr         = flag.String("acme-http-address", acme.DefaultHTTPAddr, "address that serves http-01 challenges and redirects other requests to https")
+	acmeRenewBefore      = flag.Duration("acme-renew-before", acme.DefaultRenewBefore, "time before expiry the certificate is renewed")
 	pprofListenStr       = flag.String("pprofListenStr", "",
 		"pprof listen str host:port")
 )
@@ -38,27 +98,100 @@ var (
 func main() {
 	flag.Parse()
 
-	configureLogging(*logLevel, *logFormat)
+	sglog.Configure(*logLevel, *logFormat)
+
+	groupRoles, err := ldap.ParseGroupRoles(*ldapGroupRoles)
+	if err != nil {
+		logrus.Fatalf("ldap-group-roles: %v", err)
+	}
 
 	cfg := &controlplane.Config{
-		Addr:          *addr,
-		Port:          *port,
-		InsecurePort:  *insecurePort,
-		CertFile:      *certFile,
-		KeyFile:       *keyFile,
-		StorageMode:   *storageMode,
-		StorageURI:    *storageURI,
-		TemplatesDir:  *templatesDir,
-		LogDir:        *logDir,
-		ReadTimeout:   time.Second * 60,
-		WriteTimeout:  time.Second * 300,
-		IdleTimeout:   time.Second * 120,
-		SpawnInterval: time.Second * time.Duration(*spawnInterval),
+		Addr:             *addr,
+		Port:             *port,
+		InsecurePort:     *insecurePort,
+		GRPCPort:         *grpcPort,
+		CertFile:         *certFile,
+		KeyFile:          *keyFile,
+		StorageMode:      *storageMode,
+		StorageURI:       *storageURI,
+		TemplatesDir:     *templatesDir,
+		LogDir:           *logDir,
+		ReadTimeout:      time.Second * 60,
+		WriteTimeout:     time.Second * 300,
+		IdleTimeout:      time.Second * 120,
+		ShutdownTimeout:  *shutdownTimeout,
+		IdempotencyTTL:   *idempotencyTTL,
+		ProvisionWorkers: *provisionWorkers,
+		SpawnInterval:    time.Second * time.Duration(*spawnInterval),
+
+		RateLimit: settings.RateLimit{
+			RequestsPerSecond: *rateLimit,
+			Burst:             *rateLimitBurst,
+		},
+		Quotas: settings.Quotas{
+			MaxClusters:             *maxClusters,
+			MaxConcurrentProvisions: *maxProvisions,
+		},
 
 		PprofListenStr: *pprofListenStr,
 
 		ProxiesPortRange: proxy.PortRange{int32(*ProxiesPortRangeFrom), int32(*ProxiesPortRangeTo)},
 		Version:          version,
+
+		GitOps: gitops.Config{
+			Repo:     *gitopsRepo,
+			Branch:   *gitopsBranch,
+			Path:     *gitopsPath,
+			Interval: *gitopsInterval,
+			DryRun:   *gitopsDryRun,
+		},
+		TaskRetention: retention.Policy{
+			MaxAge:   *taskRetentionAge,
+			MaxCount: *taskRetentionCount,
+			Archive:  *taskArchive,
+		},
+		Tracing: tracing.Config{
+			Endpoint:    *tracingEndpoint,
+			ServiceName: *tracingServiceName,
+			SampleRate:  *tracingSampleRate,
+		},
+		Events: eventbus.Config{
+			NATSURL:     *natsURL,
+			NATSSubject: *natsSubject,
+			KafkaURL:    *kafkaURL,
+			KafkaTopic:  *kafkaTopic,
+			Source:      *eventsSource,
+		},
+		TwoFactorRoles: list(*twoFactorRoles),
+		LDAP: ldap.Config{
+			URL:                *ldapURL,
+			StartTLS:           *ldapStartTLS,
+			InsecureSkipVerify: *ldapInsecure,
+			BindDN:             *ldapBindDN,
+			BindPassword:       *ldapBindPassword,
+			BaseDN:             *ldapBaseDN,
+			UserFilter:         *ldapUserFilter,
+			GroupBaseDN:        *ldapGroupBaseDN,
+			GroupFilter:        *ldapGroupFilter,
+			NestedGroups:       *ldapNestedGroups,
+			GroupRoles:         groupRoles,
+			DefaultRole:        *ldapDefaultRole,
+			PoolSize:           *ldapPoolSize,
+		},
+		CatalogFile:         *catalogFile,
+		VersionFeed:         *versionFeed,
+		VersionFeedInterval: *versionFeedInterval,
+		PluginsDir:          *pluginsDir,
+		ChaosMode:           *chaosMode,
+		ACME: acme.Config{
+			Domains:      list(*acmeDomains),
+			Email:        *acmeEmail,
+			DirectoryURL: *acmeDirectory,
+			Challenge:    *acmeChallenge,
+			DNSAccount:   *acmeDNSAccount,
+			HTTPAddr:     *acmeHTTPAddr,
+			RenewBefore:  *acmeRenewBefore,
+		},
 	}
 
 	server, err := controlplane.New(cfg)
@@ -79,21 +212,13 @@ func main() {
 	server.Start()
 }
 
-// TODO: create sglog package
-func configureLogging(level, format string) {
-	l, err := logrus.ParseLevel(level)
-	if err != nil {
-		// set logLevel to INFO by default
-		l = logrus.InfoLevel
-	}
-	logrus.SetLevel(l)
-
-	switch strings.TrimSpace(format) {
-	case "json":
-		logrus.SetFormatter(&logrus.JSONFormatter{})
-	default:
-		logrus.SetFormatter(&logrus.TextFormatter{
-			FullTimestamp: true,
-		})
+// list splits the comma separated list, e.g. of roles or domains.
+func list(value string) []string {
+	var items []string
+	for _, r := range strings.Split(value, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			items = append(items, r)
+		}
 	}
+	return items
 }
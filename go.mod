@@ -14,7 +14,7 @@ replace (
 )
 
 require (
-	contrib.go.opencensus.io/exporter/ocagent v0.4.4 // indirect
+	contrib.go.opencensus.io/exporter/ocagent v0.4.4
 	github.com/Azure/azure-sdk-for-go v31.1.0+incompatible
 	github.com/Azure/go-autorest v11.4.0+incompatible
 	github.com/Masterminds/semver v1.4.2 // indirect
@@ -66,9 +66,11 @@ require (
 	github.com/opencontainers/go-digest v1.0.0-rc1 // indirect
 	github.com/pborman/uuid v0.0.0-20170612153648-e790cca94e6c
 	github.com/pkg/errors v0.8.0
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/rakyll/statik v0.1.6
 	github.com/sirupsen/logrus v1.2.0
 	github.com/soheilhy/cmux v0.1.4 // indirect
+	github.com/spf13/pflag v1.0.3
 	github.com/stretchr/testify v1.3.0
 	github.com/supergiant/capacity v0.0.0-20190513092134-fa714465dd86
 	github.com/technosophos/moniker v0.0.0-20180509230615-a5dbd03a2245
@@ -76,6 +78,7 @@ require (
 	github.com/ugorji/go v1.1.5-pre // indirect
 	github.com/xiang90/probing v0.0.0-20190116061207-43a291ad63a2 // indirect
 	go.etcd.io/bbolt v1.3.3 // indirect
+	go.opencensus.io v0.19.2
 	go.uber.org/atomic v1.4.0 // indirect
 	go.uber.org/multierr v1.1.0 // indirect
 	go.uber.org/zap v1.10.0
@@ -88,6 +91,7 @@ require (
 	golang.org/x/time v0.0.0-20181108054448-85acf8d2951c // indirect
 	google.golang.org/api v0.3.0
 	google.golang.org/genproto v0.0.0-20190321212433-e79c0c59cdb5 // indirect
+	google.golang.org/grpc v1.19.0
 	gopkg.in/asaskevich/govalidator.v8 v8.0.0-20171111151018-521b25f4b05f
 	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
 	gopkg.in/inf.v0 v0.9.1 // indirect
@@ -104,5 +108,5 @@ require (
 	k8s.io/kube-proxy v0.0.0-20190703212322-69d540a3479c // indirect
 	k8s.io/kubelet v0.0.0-20190704010802-f16c4cee528c // indirect
 	k8s.io/kubernetes v0.0.0-00010101000000-000000000000
-	sigs.k8s.io/yaml v1.1.0 // indirect
+	sigs.k8s.io/yaml v1.1.0
 )
@@ -10,9 +10,12 @@ import (
 	"github.com/sirupsen/logrus"
 	"gopkg.in/asaskevich/govalidator.v8"
 
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/labels"
 	"github.com/supergiant/control/pkg/message"
 	"github.com/supergiant/control/pkg/model"
 	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
 	"github.com/supergiant/control/pkg/util"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
@@ -21,6 +24,7 @@ import (
 type Handler struct {
 	validator util.CloudAccountValidator
 	service   *Service
+	regions   regionsCache
 }
 
 func NewHandler(service *Service) *Handler {
@@ -36,6 +40,8 @@ func (h *Handler) Register(r *mux.Router) {
 	r.HandleFunc("/accounts/{accountName}", h.Get).Methods(http.MethodGet)
 	r.HandleFunc("/accounts/{accountName}", h.Update).Methods(http.MethodPut)
 	r.HandleFunc("/accounts/{accountName}", h.Delete).Methods(http.MethodDelete)
+	r.HandleFunc("/accounts/{accountName}/team", api.AdminOnly(h.SetTeam)).Methods(http.MethodPut)
+	r.HandleFunc("/accounts/{accountName}/labels", h.SetLabels).Methods(http.MethodPut)
 	r.HandleFunc("/accounts/{accountName}/regions", h.GetRegions).Methods(http.MethodGet)
 	r.HandleFunc("/accounts/{accountName}/regions/{region}/az", h.GetAZs).Methods(http.MethodGet)
 	r.HandleFunc("/accounts/{accountName}/regions/{region}/az/{az}/types", h.GetTypes).Methods(http.MethodGet)
@@ -56,6 +62,16 @@ func (h *Handler) Create(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err = labels.Validate(account.Labels); err != nil {
+		message.SendValidationFailed(rw, sgerrors.WithField(err, "labels"))
+		return
+	}
+
+	if account.Team, err = api.ScopeFrom(r.Context()).TeamFor(account.Team); err != nil {
+		message.SendValidationFailed(rw, err)
+		return
+	}
+
 	// Check account data for validity
 	if err := h.validator.ValidateCredentials(account); err != nil {
 		logrus.Errorf("error validating credentials %v", err)
@@ -83,8 +99,22 @@ func (h *Handler) Create(rw http.ResponseWriter, r *http.Request) {
 
 // ListAll retrieves all cloud accounts
 func (h *Handler) ListAll(rw http.ResponseWriter, r *http.Request) {
-	accounts, err := h.service.GetAll(r.Context())
+	opts, err := storage.ParseListOptions(r.URL.Query())
+	if err != nil {
+		message.SendValidationFailed(rw, err)
+		return
+	}
+
+	if scope := api.ScopeFrom(r.Context()); !scope.Admin {
+		opts.Filter = scope.Filter
+	}
+
+	accounts, next, err := h.service.List(r.Context(), opts)
 	if err != nil {
+		if err == storage.ErrInvalidContinue {
+			message.SendValidationFailed(rw, err)
+			return
+		}
 		if sgerrors.IsNotFound(err) {
 			message.SendNotFound(rw, "accounts", err)
 			return
@@ -94,6 +124,9 @@ func (h *Handler) ListAll(rw http.ResponseWriter, r *http.Request) {
 		message.SendUnknownError(rw, err)
 		return
 	}
+	if next != "" {
+		rw.Header().Set(storage.ContinueHeader, next)
+	}
 	if err := json.NewEncoder(rw).Encode(accounts); err != nil {
 		logrus.Errorf("account handler: list all %v", err)
 		message.SendUnknownError(rw, err)
@@ -136,11 +169,35 @@ func (h *Handler) Update(rw http.ResponseWriter, r *http.Request) {
 		message.SendValidationFailed(rw, err)
 		return
 	}
+	if err = labels.Validate(account.Labels); err != nil {
+		message.SendValidationFailed(rw, sgerrors.WithField(err, "labels"))
+		return
+	}
+
+	// teams of accounts are changed by admins
+	existing, err := h.service.Get(r.Context(), account.Name)
+	if err != nil && !sgerrors.IsNotFound(err) {
+		message.SendUnknownError(rw, err)
+		return
+	}
+	if existing != nil {
+		if !api.ScopeFrom(r.Context()).AllowsLabeled(existing.Team, existing.Labels) {
+			message.SendNotFound(rw, "account", sgerrors.ErrNotFound)
+			return
+		}
+		account.Team = existing.Team
+		// labels grant access to other teams, members of other teams can't change them
+		if !api.ScopeFrom(r.Context()).Allows(existing.Team) {
+			account.Labels = existing.Labels
+		}
+	}
+
 	if err := h.service.Update(r.Context(), account); err != nil {
 		logrus.Errorf("account handler: update: %v", err)
 		message.SendUnknownError(rw, err)
 		return
 	}
+	h.regions.forget(account.Name)
 }
 
 // Delete cloud account
@@ -157,6 +214,7 @@ func (h *Handler) Delete(rw http.ResponseWriter, r *http.Request) {
 		message.SendUnknownError(rw, err)
 		return
 	}
+	h.regions.forget(accountName)
 }
 
 func (h *Handler) GetRegions(w http.ResponseWriter, r *http.Request) {
@@ -166,6 +224,14 @@ func (h *Handler) GetRegions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if aggregate := h.regions.get(accountName); aggregate != nil {
+		if err := json.NewEncoder(w).Encode(aggregate); err != nil {
+			logrus.Errorf("clouds: get regions: %v", err)
+			message.SendUnknownError(w, err)
+		}
+		return
+	}
+
 	acc, err := h.service.Get(r.Context(), accountName)
 	if err != nil {
 		if sgerrors.IsNotFound(err) {
@@ -191,6 +257,7 @@ func (h *Handler) GetRegions(w http.ResponseWriter, r *http.Request) {
 		message.SendUnknownError(w, err)
 		return
 	}
+	h.regions.put(accountName, aggregate)
 
 	if err := json.NewEncoder(w).Encode(aggregate); err != nil {
 		logrus.Errorf("clouds: get regions: %v", err)
@@ -15,9 +15,11 @@ import (
 	"github.com/stretchr/testify/require"
 	"gopkg.in/asaskevich/govalidator.v8"
 
+	"github.com/supergiant/control/pkg/api"
 	"github.com/supergiant/control/pkg/clouds"
 	"github.com/supergiant/control/pkg/model"
 	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
 	"github.com/supergiant/control/pkg/testutils"
 	"github.com/supergiant/control/pkg/util"
 )
@@ -251,7 +253,7 @@ func TestHandler_Register(t *testing.T) {
 	r := mux.NewRouter()
 	h := Handler{}
 	h.Register(r)
-	expectedRouteCount := 8
+	expectedRouteCount := 10
 	routes := []*mux.Route{}
 
 	walkFn := func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
@@ -282,10 +284,12 @@ func TestNewHandler(t *testing.T) {
 
 func TestHandler_ListAll(t *testing.T) {
 	testCases := []struct {
+		query                string
 		mockResp             [][]byte
 		serviceErr           error
 		expectedAccountCount int
 		expectedCode         int
+		expectedContinue     bool
 	}{
 		{
 			mockResp:             [][]byte{},
@@ -305,6 +309,22 @@ func TestHandler_ListAll(t *testing.T) {
 			expectedAccountCount: 1,
 			expectedCode:         http.StatusOK,
 		},
+		{
+			query:        "?limit=-1",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			query:        "?continue=invalid",
+			mockResp:     [][]byte{[]byte(`{}`)},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			query:                "?limit=1&fieldSelector=provider=aws",
+			mockResp:             [][]byte{[]byte(`{"provider":"aws"}`), []byte(`{"provider":"gce"}`), []byte(`{"provider":"aws"}`)},
+			expectedAccountCount: 1,
+			expectedCode:         http.StatusOK,
+			expectedContinue:     true,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -316,7 +336,7 @@ func TestHandler_ListAll(t *testing.T) {
 		router := mux.NewRouter()
 		e.Register(router)
 		rec := httptest.NewRecorder()
-		req, _ := http.NewRequest(http.MethodGet, "/accounts", nil)
+		req, _ := http.NewRequest(http.MethodGet, "/accounts"+testCase.query, nil)
 
 		router.ServeHTTP(rec, req)
 
@@ -325,6 +345,13 @@ func TestHandler_ListAll(t *testing.T) {
 				testCase.expectedCode, rec.Code)
 			continue
 		}
+
+		if rec.Code == http.StatusOK {
+			accounts := make([]model.CloudAccount, 0)
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&accounts))
+			require.Len(t, accounts, testCase.expectedAccountCount)
+			require.Equal(t, testCase.expectedContinue, rec.Header().Get(storage.ContinueHeader) != "")
+		}
 	}
 }
 
@@ -610,3 +637,40 @@ func TestHandler_GetTypes(t *testing.T) {
 		}
 	}
 }
+
+func TestHandler_ScopeMiddleware(t *testing.T) {
+	h, m := fixtures()
+	body, _ := json.Marshal(model.CloudAccount{Name: "test", Provider: clouds.AWS, Team: "b"})
+	m.On("Get", mock.Anything, mock.Anything, "test").Return(body, nil)
+	m.On("Get", mock.Anything, mock.Anything, "other").Return(nil, sgerrors.ErrNotFound)
+	m.On("Put", mock.Anything, mock.Anything, "test", mock.Anything).Return(nil)
+
+	router := mux.NewRouter()
+	router.Use(h.ScopeMiddleware)
+	h.Register(router)
+
+	for _, tc := range []struct {
+		scope          api.Scope
+		method         string
+		url            string
+		body           string
+		expectedStatus int
+	}{
+		{api.Scope{Teams: []string{"a"}}, http.MethodGet, "/accounts/test", "", http.StatusNotFound},
+		{api.Scope{Teams: []string{"b"}}, http.MethodGet, "/accounts/test", "", http.StatusOK},
+		{api.Scope{Teams: []string{"a"}}, http.MethodPut, "/accounts/other",
+			`{"name":"test","provider":"aws","credentials":{},"team":"a"}`, http.StatusNotFound},
+		{api.Scope{Teams: []string{"b"}}, http.MethodPut, "/accounts/test/team", `{"team":"a"}`, http.StatusForbidden},
+		{api.Scope{Admin: true}, http.MethodPut, "/accounts/test/team", `{"team":"a"}`, http.StatusOK},
+	} {
+		req := httptest.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+		req = req.WithContext(api.WithScope(req.Context(), tc.scope))
+		if tc.scope.Admin {
+			req = req.WithContext(api.WithIdentity(req.Context(), api.Identity{Login: "root"}))
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, tc.expectedStatus, rr.Code, tc.method+" "+tc.url)
+	}
+}
@@ -3,6 +3,7 @@ package account
 import (
 	"context"
 	"github.com/supergiant/control/pkg/clouds/gcesdk"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -21,8 +22,10 @@ import (
 
 	"github.com/supergiant/control/pkg/clouds"
 	"github.com/supergiant/control/pkg/clouds/digitaloceansdk"
+	"github.com/supergiant/control/pkg/clouds/fake"
 	"github.com/supergiant/control/pkg/model"
 	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/tracing"
 	"github.com/supergiant/control/pkg/util"
 	"github.com/supergiant/control/pkg/util/strset"
 	"github.com/supergiant/control/pkg/workflows/steps"
@@ -92,6 +95,8 @@ func NewRegionsGetter(account *model.CloudAccount, config *steps.Config) (Region
 		return NewGCEFinder(account, config)
 	case clouds.Azure:
 		return NewAzureFinder(account, config)
+	case clouds.Fake:
+		return FakeFinder{}, nil
 	}
 	return nil, ErrUnsupportedProvider
 }
@@ -420,8 +425,10 @@ func NewAzureFinder(acc *model.CloudAccount, cfg *steps.Config) (*AzureFinder, e
 
 	sclient := subscription.NewSubscriptionsClient()
 	sclient.Authorizer = token
+	sclient.Sender = tracing.Client("azure", nil)
 	skusclient := skus.NewResourceSkusClient(cfg.AzureConfig.SubscriptionID)
 	skusclient.Authorizer = token
+	skusclient.Sender = tracing.Client("azure", nil)
 
 	return &AzureFinder{
 		subscriptionID:      cfg.AzureConfig.SubscriptionID,
@@ -584,3 +591,35 @@ func hasRestriction(s skus.ResourceSku, restr skus.ResourceSkuRestrictionsType)
 	}
 	return false
 }
+
+// FakeFinder lists regions and sizes of the fake cloud, every size is
+// available in every region.
+type FakeFinder struct{}
+
+func (FakeFinder) GetRegions(context.Context) (*RegionSizes, error) {
+	names := make([]string, 0, len(fake.Sizes))
+	nodeSizes := make(map[string]interface{}, len(fake.Sizes))
+	for name, s := range fake.Sizes {
+		names = append(names, name)
+		nodeSizes[name] = Size{
+			RAM: strconv.Itoa(s.RAM),
+			CPU: strconv.Itoa(s.CPU),
+		}
+	}
+	sort.Strings(names)
+
+	regions := make([]*Region, 0, len(fake.Regions))
+	for _, r := range fake.Regions {
+		regions = append(regions, &Region{
+			ID:             r,
+			Name:           r,
+			AvailableSizes: names,
+		})
+	}
+
+	return &RegionSizes{
+		Provider: clouds.Fake,
+		Regions:  regions,
+		Sizes:    nodeSizes,
+	}, nil
+}
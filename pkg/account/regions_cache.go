@@ -0,0 +1,68 @@
+package account
+
+import (
+	"sync"
+	"time"
+)
+
+// regionsCache keeps regions of accounts to save calls to cloud APIs,
+// caching is off when the ttl is 0.
+type regionsCache struct {
+	m       sync.Mutex
+	ttl     time.Duration
+	entries map[string]regionsEntry
+}
+
+type regionsEntry struct {
+	regions   *RegionSizes
+	expiresAt time.Time
+}
+
+func (c *regionsCache) get(accountName string) *RegionSizes {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	e, ok := c.entries[accountName]
+	if !ok || time.Now().After(e.expiresAt) {
+		return nil
+	}
+
+	return e.regions
+}
+
+func (c *regionsCache) put(accountName string, regions *RegionSizes) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.ttl <= 0 {
+		return
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]regionsEntry)
+	}
+	c.entries[accountName] = regionsEntry{
+		regions:   regions,
+		expiresAt: time.Now().Add(c.ttl),
+	}
+}
+
+func (c *regionsCache) forget(accountName string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	delete(c.entries, accountName)
+}
+
+func (c *regionsCache) setTTL(ttl time.Duration) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.ttl = ttl
+	c.entries = nil
+}
+
+// SetRegionsCacheTTL changes how long regions of accounts are cached,
+// cached regions are dropped.
+func (h *Handler) SetRegionsCacheTTL(ttl time.Duration) {
+	h.regions.setTTL(ttl)
+}
@@ -0,0 +1,30 @@
+package account
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegionsCache(t *testing.T) {
+	c := &regionsCache{}
+	regions := &RegionSizes{}
+
+	// caching is off by default
+	c.put("aws", regions)
+	require.Nil(t, c.get("aws"))
+
+	c.setTTL(time.Hour)
+	c.put("aws", regions)
+	require.Equal(t, regions, c.get("aws"))
+	require.Nil(t, c.get("do"))
+
+	c.forget("aws")
+	require.Nil(t, c.get("aws"))
+
+	c.setTTL(time.Nanosecond)
+	c.put("aws", regions)
+	time.Sleep(time.Millisecond)
+	require.Nil(t, c.get("aws"))
+}
@@ -50,6 +50,27 @@ func (s *Service) GetAll(ctx context.Context) ([]model.CloudAccount, error) {
 	return accounts, nil
 }
 
+// List returns a page of cloud accounts and the continue token of the next one
+func (s *Service) List(ctx context.Context, opts storage.ListOptions) ([]model.CloudAccount, string, error) {
+	accounts := make([]model.CloudAccount, 0)
+	res, next, err := storage.List(ctx, s.repository, s.storagePrefix, opts)
+	if err != nil {
+		return accounts, "", err
+	}
+	for _, v := range res {
+		ca := new(model.CloudAccount)
+		err = json.NewDecoder(bytes.NewReader(v)).Decode(ca)
+		if err != nil {
+			logrus.Warningf("failed to convert stored data to cloud account struct")
+			logrus.Debugf("corrupted data: %s", string(v))
+			continue
+		}
+		accounts = append(accounts, *ca)
+	}
+
+	return accounts, next, nil
+}
+
 // Get retrieves a user by it's accountName, returns nil if not found
 func (s *Service) Get(ctx context.Context, accountName string) (*model.CloudAccount, error) {
 	res, err := s.repository.Get(ctx, s.storagePrefix, accountName)
@@ -0,0 +1,32 @@
+package account
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// SizesIn returns machine sizes that are available to the account in the
+// region, the region isn't found if the provider doesn't have it.
+func SizesIn(ctx context.Context, acc *model.CloudAccount, region string) ([]string, error) {
+	getter, err := NewRegionsGetter(acc, &steps.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	regions, err := getter.GetRegions(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get regions of %s", acc.Provider)
+	}
+	for _, r := range regions.Regions {
+		if r.ID == region {
+			return r.AvailableSizes, nil
+		}
+	}
+
+	return nil, errors.Wrapf(sgerrors.ErrNotFound, "region %s", region)
+}
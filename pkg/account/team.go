@@ -0,0 +1,104 @@
+package account
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/labels"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// ScopeMiddleware hides accounts of other teams from requests to routes of
+// accounts, they are not found for users that aren't members of their teams.
+func (h *Handler) ScopeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accountName, ok := mux.Vars(r)["accountName"]
+		scope := api.ScopeFrom(r.Context())
+		if !ok || scope.Admin {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// handlers respond to errors
+		account, err := h.service.Get(r.Context(), accountName)
+		if err == nil && !scope.AllowsLabeled(account.Team, account.Labels) {
+			message.SendNotFound(w, "account", sgerrors.ErrNotFound)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SetTeam moves the account to another team.
+func (h *Handler) SetTeam(rw http.ResponseWriter, r *http.Request) {
+	accountName := mux.Vars(r)["accountName"]
+	req := &api.TeamRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		message.SendInvalidJSON(rw, err)
+		return
+	}
+
+	account, err := h.service.Get(r.Context(), accountName)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(rw, "account", err)
+			return
+		}
+		message.SendUnknownError(rw, err)
+		return
+	}
+
+	account.Team = req.Team
+	if err = h.service.Update(r.Context(), account); err != nil {
+		message.SendUnknownError(rw, err)
+		return
+	}
+
+	if err = json.NewEncoder(rw).Encode(req); err != nil {
+		message.SendUnknownError(rw, err)
+	}
+}
+
+// SetLabels replaces labels of the account.
+func (h *Handler) SetLabels(rw http.ResponseWriter, r *http.Request) {
+	accountName := mux.Vars(r)["accountName"]
+	req := &api.LabelsRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		message.SendInvalidJSON(rw, err)
+		return
+	}
+	if err := labels.Validate(req.Labels); err != nil {
+		message.SendValidationFailed(rw, sgerrors.WithField(err, "labels"))
+		return
+	}
+
+	account, err := h.service.Get(r.Context(), accountName)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(rw, "account", err)
+			return
+		}
+		message.SendUnknownError(rw, err)
+		return
+	}
+	// labels grant access to other teams, members of other teams can't change them
+	if !api.ScopeFrom(r.Context()).Allows(account.Team) {
+		http.Error(rw, "labels are changed by members of the team", http.StatusForbidden)
+		return
+	}
+
+	account.Labels = req.Labels
+	if err = h.service.Update(r.Context(), account); err != nil {
+		message.SendUnknownError(rw, err)
+		return
+	}
+
+	if err = json.NewEncoder(rw).Encode(req); err != nil {
+		message.SendUnknownError(rw, err)
+	}
+}
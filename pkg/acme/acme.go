@@ -0,0 +1,484 @@
+// Package acme obtains and renews the certificate of the control API from
+// ACME servers, e.g. Let's Encrypt. Domains are validated with HTTP-01
+// challenges served on port 80 or with DNS-01 challenges in DNS zones of a
+// stored cloud account.
+package acme
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
+)
+
+const (
+	DefaultDirectoryURL       = "https://acme-v02.api.letsencrypt.org/directory"
+	DefaultStoragePrefix      = "/supergiant/acme/"
+	DefaultHTTPAddr           = ":80"
+	DefaultRenewBefore        = 30 * 24 * time.Hour
+	DefaultPropagationTimeout = 2 * time.Minute
+
+	challengePath  = "/.well-known/acme-challenge/"
+	accountKey     = "account"
+	certificateKey = "certificate"
+
+	checkInterval = 12 * time.Hour
+	retryInterval = time.Minute
+)
+
+// Config of certificates, ACME is off when there are no domains.
+type Config struct {
+	// Domains of the certificate, the first one is its common name.
+	// Wildcard domains are validated with DNS-01 challenges only.
+	Domains []string
+	// Email of the account, the ACME server sends expiry notices to it.
+	Email        string
+	DirectoryURL string
+	// Challenge is http-01 or dns-01, it's http-01 by default.
+	Challenge string
+	// DNSAccount is the name of the cloud account whose DNS zones get
+	// records of DNS-01 challenges.
+	DNSAccount string
+	// HTTPAddr serves HTTP-01 challenges, other requests are redirected to
+	// HTTPS. ACME servers connect to port 80.
+	HTTPAddr string
+	// RenewBefore the expiry of the certificate.
+	RenewBefore time.Duration
+}
+
+// Enabled reports whether the certificate is obtained with ACME.
+func (c Config) Enabled() bool {
+	return len(c.Domains) > 0
+}
+
+// DNSChallenge reports whether domains are validated with DNS records.
+func (c Config) DNSChallenge() bool {
+	return c.Challenge == challengeDNS
+}
+
+type account struct {
+	// Key is the PEM EC private key of the account.
+	Key []byte `json:"key"`
+	URL string `json:"url"`
+}
+
+type certificate struct {
+	// Chain of PEM certificates, the leaf is first.
+	Chain []byte `json:"chain"`
+	Key   []byte `json:"key"`
+}
+
+// Manager keeps the certificate in storage and renews it before it expires.
+type Manager struct {
+	cfg        Config
+	repository storage.Interface
+	dns        DNSProvider
+	http       *http.Client
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+	leaf *x509.Certificate
+
+	// key authorizations of tokens of pending HTTP-01 challenges
+	tokensMu sync.RWMutex
+	tokens   map[string]string
+
+	lookupTXT          func(string) ([]string, error)
+	pollInterval       time.Duration
+	propagationTimeout time.Duration
+	now                func() time.Time
+}
+
+// NewManager returns a manager of the certificate, dns is required for
+// DNS-01 challenges.
+func NewManager(cfg Config, repository storage.Interface, dns DNSProvider) (*Manager, error) {
+	if !cfg.Enabled() {
+		return nil, errors.New("no domains")
+	}
+	if cfg.DirectoryURL == "" {
+		cfg.DirectoryURL = DefaultDirectoryURL
+	}
+	if cfg.Challenge == "" {
+		cfg.Challenge = challengeHTTP
+	}
+	if cfg.HTTPAddr == "" {
+		cfg.HTTPAddr = DefaultHTTPAddr
+	}
+	if cfg.RenewBefore == 0 {
+		cfg.RenewBefore = DefaultRenewBefore
+	}
+
+	switch cfg.Challenge {
+	case challengeHTTP:
+		for _, d := range cfg.Domains {
+			if strings.HasPrefix(d, "*.") {
+				return nil, errors.Errorf("wildcard domain %s needs dns-01 challenges", d)
+			}
+		}
+	case challengeDNS:
+		if dns == nil {
+			return nil, errors.New("dns-01 challenges need a DNS provider")
+		}
+	default:
+		return nil, errors.Errorf("unknown challenge %s", cfg.Challenge)
+	}
+
+	return &Manager{
+		cfg:                cfg,
+		repository:         repository,
+		dns:                dns,
+		http:               &http.Client{Timeout: time.Second * 30},
+		tokens:             make(map[string]string),
+		lookupTXT:          net.LookupTXT,
+		pollInterval:       time.Second * 2,
+		propagationTimeout: DefaultPropagationTimeout,
+		now:                time.Now,
+	}, nil
+}
+
+// GetCertificate returns the current certificate to TLS handshakes.
+func (m *Manager) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.cert == nil {
+		return nil, errors.New("acme: certificate isn't obtained yet")
+	}
+	return m.cert, nil
+}
+
+// HTTPHandler serves HTTP-01 challenges and passes other requests to the
+// fallback, they are redirected to HTTPS when it's nil.
+func (m *Manager) HTTPHandler(fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, challengePath) {
+			if fallback != nil {
+				fallback.ServeHTTP(w, r)
+				return
+			}
+			host := r.Host
+			if h, _, err := net.SplitHostPort(host); err == nil {
+				host = h
+			}
+			http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+			return
+		}
+
+		m.tokensMu.RLock()
+		keyAuth, ok := m.tokens[strings.TrimPrefix(r.URL.Path, challengePath)]
+		m.tokensMu.RUnlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(keyAuth))
+	})
+}
+
+// Run renews the certificate until the context is done, failed attempts
+// are retried sooner.
+func (m *Manager) Run(ctx context.Context) {
+	retry := retryInterval
+	for {
+		interval := checkInterval
+		if err := m.Ensure(ctx); err != nil {
+			logrus.Errorf("acme: %v", err)
+			interval = retry
+			if retry *= 2; retry > checkInterval {
+				retry = checkInterval
+			}
+		} else {
+			retry = retryInterval
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Ensure loads the stored certificate and obtains a new one when it
+// expires soon or doesn't cover the domains.
+func (m *Manager) Ensure(ctx context.Context) error {
+	m.mu.RLock()
+	loaded := m.cert != nil
+	m.mu.RUnlock()
+
+	if !loaded {
+		if err := m.load(ctx); err != nil {
+			return err
+		}
+	}
+	if !m.due() {
+		return nil
+	}
+
+	logrus.Infof("acme: obtain certificate of %s", strings.Join(m.cfg.Domains, ", "))
+	return m.obtain(ctx)
+}
+
+func (m *Manager) load(ctx context.Context) error {
+	data, err := m.repository.Get(ctx, DefaultStoragePrefix, certificateKey)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrap(err, "read certificate")
+	}
+	if data == nil {
+		return nil
+	}
+
+	c := &certificate{}
+	if err = json.Unmarshal(data, c); err != nil {
+		return errors.Wrap(err, "unmarshal certificate")
+	}
+	return m.set(c)
+}
+
+func (m *Manager) set(c *certificate) error {
+	cert, err := tls.X509KeyPair(c.Chain, c.Key)
+	if err != nil {
+		return errors.Wrap(err, "parse certificate")
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return errors.Wrap(err, "parse certificate")
+	}
+	cert.Leaf = leaf
+
+	m.mu.Lock()
+	m.cert, m.leaf = &cert, leaf
+	m.mu.Unlock()
+
+	return nil
+}
+
+// due reports whether the certificate needs to be obtained.
+func (m *Manager) due() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.leaf == nil || m.now().Add(m.cfg.RenewBefore).After(m.leaf.NotAfter) {
+		return true
+	}
+	for _, d := range m.cfg.Domains {
+		if m.leaf.VerifyHostname(strings.Replace(d, "*", "wildcard", 1)) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manager) obtain(ctx context.Context) error {
+	c, err := m.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	o, orderURL, err := c.newOrder(ctx, m.cfg.Domains)
+	if err != nil {
+		return err
+	}
+	for _, url := range o.Authorizations {
+		if err = m.authorize(ctx, c, url); err != nil {
+			return err
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: m.cfg.Domains[0]},
+		DNSNames: m.cfg.Domains,
+	}, key)
+	if err != nil {
+		return errors.Wrap(err, "create csr")
+	}
+
+	certURL, err := c.finalize(ctx, orderURL, o, csr)
+	if err != nil {
+		return err
+	}
+	chain, err := c.certificate(ctx, certURL)
+	if err != nil {
+		return err
+	}
+
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	cert := &certificate{
+		Chain: chain,
+		Key:   pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}),
+	}
+	if err = m.set(cert); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(cert)
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(m.repository.Put(ctx, DefaultStoragePrefix, certificateKey, data), "save certificate")
+}
+
+// authorize solves a challenge of the authorization unless it's valid.
+func (m *Manager) authorize(ctx context.Context, c *client, url string) error {
+	a, err := c.authorization(ctx, url)
+	if err != nil {
+		return err
+	}
+	if a.Status == statusValid {
+		return nil
+	}
+
+	var ch *challenge
+	for i := range a.Challenges {
+		if a.Challenges[i].Type == m.cfg.Challenge {
+			ch = &a.Challenges[i]
+		}
+	}
+	if ch == nil {
+		return errors.Errorf("server doesn't offer %s challenges of %s", m.cfg.Challenge, a.Identifier.Value)
+	}
+
+	keyAuth := ch.Token + "." + thumbprint(&c.key.PublicKey)
+	cleanup, err := m.present(ctx, a.Identifier.Value, ch.Token, keyAuth)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if err = c.accept(ctx, *ch); err != nil {
+		return err
+	}
+	return c.waitAuthorization(ctx, url)
+}
+
+// present publishes the key authorization of the challenge and returns
+// the function that removes it.
+func (m *Manager) present(ctx context.Context, domain, token, keyAuth string) (func(), error) {
+	if m.cfg.Challenge == challengeHTTP {
+		m.tokensMu.Lock()
+		m.tokens[token] = keyAuth
+		m.tokensMu.Unlock()
+
+		return func() {
+			m.tokensMu.Lock()
+			delete(m.tokens, token)
+			m.tokensMu.Unlock()
+		}, nil
+	}
+
+	sum := sha256.Sum256([]byte(keyAuth))
+	fqdn, value := "_acme-challenge."+domain, b64(sum[:])
+	if err := m.dns.SetTXT(ctx, fqdn, value); err != nil {
+		return nil, errors.Wrapf(err, "set TXT record %s", fqdn)
+	}
+	cleanup := func() {
+		if err := m.dns.DeleteTXT(context.Background(), fqdn, value); err != nil {
+			logrus.Errorf("acme: delete TXT record %s: %v", fqdn, err)
+		}
+	}
+
+	m.waitPropagation(ctx, fqdn, value)
+	return cleanup, nil
+}
+
+// waitPropagation waits until resolvers return the record, the ACME server
+// may see it sooner than local resolvers so it's validated anyway.
+func (m *Manager) waitPropagation(ctx context.Context, fqdn, value string) {
+	deadline := m.now().Add(m.propagationTimeout)
+	for m.now().Before(deadline) {
+		values, _ := m.lookupTXT(fqdn)
+		for _, v := range values {
+			if v == value {
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(m.pollInterval):
+		}
+	}
+	logrus.Warnf("acme: TXT record %s isn't propagated after %s", fqdn, m.propagationTimeout)
+}
+
+// client returns the client of the stored account, the account is
+// registered on first use.
+func (m *Manager) client(ctx context.Context) (*client, error) {
+	acc := &account{}
+	data, err := m.repository.Get(ctx, DefaultStoragePrefix, accountKey)
+	if err != nil && !sgerrors.IsNotFound(err) {
+		return nil, errors.Wrap(err, "read account")
+	}
+	if len(data) > 0 {
+		if err = json.Unmarshal(data, acc); err != nil {
+			return nil, errors.Wrap(err, "unmarshal account")
+		}
+	}
+
+	var key *ecdsa.PrivateKey
+	if block, _ := pem.Decode(acc.Key); block != nil {
+		if key, err = x509.ParseECPrivateKey(block.Bytes); err != nil {
+			return nil, errors.Wrap(err, "parse account key")
+		}
+	} else {
+		if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+			return nil, err
+		}
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		acc = &account{Key: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})}
+	}
+
+	c := newClient(m.cfg.DirectoryURL, key, acc.URL, m.http)
+	c.pollInterval = m.pollInterval
+	if err = c.discover(ctx); err != nil {
+		return nil, err
+	}
+	if c.kid != "" {
+		return c, nil
+	}
+
+	if err = c.register(ctx, m.cfg.Email); err != nil {
+		return nil, err
+	}
+	acc.URL = c.kid
+	if data, err = json.Marshal(acc); err != nil {
+		return nil, err
+	}
+	if err = m.repository.Put(ctx, DefaultStoragePrefix, accountKey, data); err != nil {
+		return nil, errors.Wrap(err, "save account")
+	}
+	return c, nil
+}
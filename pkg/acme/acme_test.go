@@ -0,0 +1,419 @@
+package acme
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/storage/memory"
+)
+
+// fakeACME is an ACME server that validates challenges with the handler
+// of the manager or records of the DNS provider.
+type fakeACME struct {
+	t   *testing.T
+	srv *httptest.Server
+	// http serves HTTP-01 challenges
+	http http.Handler
+	dns  *fakeDNS
+
+	mu        sync.Mutex
+	nonce     int
+	nonces    map[string]bool
+	badNonce  bool
+	accounts  map[string]*ecdsa.PublicKey
+	orders    int
+	domains   []string
+	authz     map[string]*authorization
+	finalized bool
+
+	caKey  *ecdsa.PrivateKey
+	caCert *x509.Certificate
+	leaf   []byte
+}
+
+func newFakeACME(t *testing.T) *fakeACME {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fake ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour * 24 * 365),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	f := &fakeACME{
+		t:        t,
+		nonces:   make(map[string]bool),
+		accounts: make(map[string]*ecdsa.PublicKey),
+		authz:    make(map[string]*authorization),
+		caKey:    caKey,
+		caCert:   caCert,
+	}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.serve))
+	return f
+}
+
+func (f *fakeACME) url(path string) string {
+	return f.srv.URL + path
+}
+
+func (f *fakeACME) newNonce(w http.ResponseWriter) {
+	f.nonce++
+	n := fmt.Sprintf("nonce-%d", f.nonce)
+	f.nonces[n] = true
+	w.Header().Set(nonceHeader, n)
+}
+
+func (f *fakeACME) problem(w http.ResponseWriter, status int, typ string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Error{Status: status, Type: typ, Detail: typ})
+}
+
+func (f *fakeACME) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/directory" {
+		json.NewEncoder(w).Encode(directory{
+			NewNonce:   f.url("/nonce"),
+			NewAccount: f.url("/account"),
+			NewOrder:   f.url("/order"),
+		})
+		return
+	}
+	f.newNonce(w)
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	payload, ok := f.verify(w, r)
+	if !ok {
+		return
+	}
+
+	switch path := r.URL.Path; {
+	case path == "/account":
+		w.Header().Set("Location", f.url("/account/1"))
+		w.WriteHeader(http.StatusCreated)
+	case path == "/order":
+		req := struct {
+			Identifiers []identifier `json:"identifiers"`
+		}{}
+		require.NoError(f.t, json.Unmarshal(payload, &req))
+		f.orders++
+		f.finalized = false
+		f.domains = nil
+		f.authz = make(map[string]*authorization)
+		o := &order{Status: statusPending, Finalize: f.url("/finalize")}
+		for i, id := range req.Identifiers {
+			f.domains = append(f.domains, id.Value)
+			url := f.url(fmt.Sprintf("/authz/%d", i))
+			value := strings.TrimPrefix(id.Value, "*.")
+			f.authz[url] = &authorization{
+				Status:     statusPending,
+				Identifier: identifier{Type: "dns", Value: value},
+				Challenges: []challenge{
+					{Type: challengeHTTP, URL: f.url(fmt.Sprintf("/chall/%d/http", i)), Token: fmt.Sprintf("token-%d", i), Status: statusPending},
+					{Type: challengeDNS, URL: f.url(fmt.Sprintf("/chall/%d/dns", i)), Token: fmt.Sprintf("token-%d", i), Status: statusPending},
+				},
+			}
+			o.Authorizations = append(o.Authorizations, url)
+		}
+		w.Header().Set("Location", f.url("/order/1"))
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(o)
+	case strings.HasPrefix(path, "/authz/"):
+		json.NewEncoder(w).Encode(f.authz[f.url(path)])
+	case strings.HasPrefix(path, "/chall/"):
+		var i int
+		var typ string
+		fmt.Sscanf(path, "/chall/%d/%s", &i, &typ)
+		a := f.authz[f.url(fmt.Sprintf("/authz/%d", i))]
+		a.Status = statusInvalid
+		if f.validate(a, typ) {
+			a.Status = statusValid
+		}
+		json.NewEncoder(w).Encode(a.Challenges[0])
+	case path == "/order/1":
+		json.NewEncoder(w).Encode(f.order())
+	case path == "/finalize":
+		req := struct {
+			CSR string `json:"csr"`
+		}{}
+		require.NoError(f.t, json.Unmarshal(payload, &req))
+		der, err := base64.RawURLEncoding.DecodeString(req.CSR)
+		require.NoError(f.t, err)
+		csr, err := x509.ParseCertificateRequest(der)
+		require.NoError(f.t, err)
+		require.Equal(f.t, f.domains, csr.DNSNames)
+		f.issue(csr)
+		f.finalized = true
+		json.NewEncoder(w).Encode(f.order())
+	case path == "/cert":
+		w.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: f.leaf}))
+		w.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: f.caCert.Raw}))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeACME) order() *order {
+	o := &order{Status: statusReady, Finalize: f.url("/finalize")}
+	for _, a := range f.authz {
+		if a.Status != statusValid {
+			o.Status = a.Status
+		}
+	}
+	if f.finalized {
+		o.Status = statusValid
+		o.Certificate = f.url("/cert")
+	}
+	return o
+}
+
+// verify checks the signature, nonce and URL of the request.
+func (f *fakeACME) verify(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	require.Equal(f.t, contentType, r.Header.Get("Content-Type"))
+	msg := &jws{}
+	require.NoError(f.t, json.NewDecoder(r.Body).Decode(msg))
+
+	data, err := base64.RawURLEncoding.DecodeString(msg.Protected)
+	require.NoError(f.t, err)
+	header := &protectedHeader{}
+	require.NoError(f.t, json.Unmarshal(data, header))
+	require.Equal(f.t, "ES256", header.Alg)
+	require.Equal(f.t, f.url(r.URL.Path), header.URL)
+
+	if f.badNonce {
+		f.badNonce = false
+		f.problem(w, http.StatusBadRequest, badNonce)
+		return nil, false
+	}
+	require.True(f.t, f.nonces[header.Nonce], "unknown nonce %s", header.Nonce)
+	delete(f.nonces, header.Nonce)
+
+	var key *ecdsa.PublicKey
+	if r.URL.Path == "/account" {
+		require.NotNil(f.t, header.JWK)
+		x, _ := base64.RawURLEncoding.DecodeString(header.JWK.X)
+		y, _ := base64.RawURLEncoding.DecodeString(header.JWK.Y)
+		key = &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		f.accounts[f.url("/account/1")] = key
+	} else {
+		require.Nil(f.t, header.JWK)
+		key = f.accounts[header.KID]
+		require.NotNil(f.t, key, "unknown account %s", header.KID)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(msg.Signature)
+	require.NoError(f.t, err)
+	require.Len(f.t, sig, 64)
+	hash := sha256.Sum256([]byte(msg.Protected + "." + msg.Payload))
+	require.True(f.t, ecdsa.Verify(key, hash[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])),
+		"invalid signature")
+
+	payload, err := base64.RawURLEncoding.DecodeString(msg.Payload)
+	require.NoError(f.t, err)
+	return payload, true
+}
+
+func (f *fakeACME) validate(a *authorization, typ string) bool {
+	keyAuth := a.Challenges[0].Token + "." + thumbprint(f.accounts[f.url("/account/1")])
+	if typ == "http" {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://"+a.Identifier.Value+challengePath+a.Challenges[0].Token, nil)
+		f.http.ServeHTTP(rec, req)
+		return rec.Code == http.StatusOK && rec.Body.String() == keyAuth
+	}
+
+	sum := sha256.Sum256([]byte(keyAuth))
+	return f.dns.has("_acme-challenge."+a.Identifier.Value, b64(sum[:]))
+}
+
+func (f *fakeACME) issue(csr *x509.CertificateRequest) {
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(int64(f.orders + 1)),
+		Subject:      pkix.Name{CommonName: csr.DNSNames[0]},
+		DNSNames:     csr.DNSNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour * 24 * 90),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, f.caCert, csr.PublicKey, f.caKey)
+	require.NoError(f.t, err)
+	f.leaf = der
+}
+
+type fakeDNS struct {
+	mu      sync.Mutex
+	records map[string]string
+}
+
+func (d *fakeDNS) SetTXT(_ context.Context, fqdn, value string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.records[fqdn] = value
+	return nil
+}
+
+func (d *fakeDNS) DeleteTXT(_ context.Context, fqdn, value string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.records[fqdn] == value {
+		delete(d.records, fqdn)
+	}
+	return nil
+}
+
+func (d *fakeDNS) has(fqdn, value string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.records[fqdn] == value
+}
+
+func (d *fakeDNS) lookupTXT(fqdn string) ([]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return []string{d.records[fqdn]}, nil
+}
+
+func newTestManager(t *testing.T, f *fakeACME, cfg Config, repo *memory.InMemoryRepository) *Manager {
+	cfg.DirectoryURL = f.url("/directory")
+	var provider DNSProvider
+	if f.dns != nil {
+		provider = f.dns
+	}
+	m, err := NewManager(cfg, repo, provider)
+	require.NoError(t, err)
+	m.pollInterval = time.Millisecond
+	if f.dns != nil {
+		m.lookupTXT = f.dns.lookupTXT
+	}
+	f.http = m.HTTPHandler(nil)
+	return m
+}
+
+func TestManagerHTTPChallenge(t *testing.T) {
+	f := newFakeACME(t)
+	defer f.srv.Close()
+	repo := memory.NewInMemoryRepository()
+	cfg := Config{Domains: []string{"control.example.com", "sg.example.com"}, Email: "ops@example.com"}
+	m := newTestManager(t, f, cfg, repo)
+
+	_, err := m.GetCertificate(&tls.ClientHelloInfo{})
+	require.Error(t, err)
+
+	f.badNonce = true
+	require.NoError(t, m.Ensure(context.Background()))
+	cert, err := m.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	require.Equal(t, cfg.Domains, cert.Leaf.DNSNames)
+	require.Len(t, cert.Certificate, 2)
+	require.Empty(t, m.tokens)
+
+	// the certificate is valid for long
+	require.NoError(t, m.Ensure(context.Background()))
+	require.Equal(t, 1, f.orders)
+
+	// restarts load the certificate and renew it before it expires
+	m = newTestManager(t, f, cfg, repo)
+	require.NoError(t, m.Ensure(context.Background()))
+	require.Equal(t, 1, f.orders)
+	m.now = func() time.Time { return time.Now().Add(time.Hour * 24 * 70) }
+	require.NoError(t, m.Ensure(context.Background()))
+	require.Equal(t, 2, f.orders)
+	require.Len(t, f.accounts, 1)
+
+	// changed domains are obtained
+	cfg.Domains = append(cfg.Domains, "new.example.com")
+	m = newTestManager(t, f, cfg, repo)
+	require.NoError(t, m.Ensure(context.Background()))
+	require.Equal(t, 3, f.orders)
+}
+
+func TestManagerDNSChallenge(t *testing.T) {
+	f := newFakeACME(t)
+	defer f.srv.Close()
+	f.dns = &fakeDNS{records: make(map[string]string)}
+	cfg := Config{Domains: []string{"*.example.com"}, Challenge: "dns-01"}
+
+	_, err := NewManager(cfg, memory.NewInMemoryRepository(), nil)
+	require.Error(t, err)
+
+	m := newTestManager(t, f, cfg, memory.NewInMemoryRepository())
+	require.NoError(t, m.Ensure(context.Background()))
+	cert, err := m.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	require.NoError(t, cert.Leaf.VerifyHostname("control.example.com"))
+	require.Empty(t, f.dns.records)
+}
+
+func TestManagerInvalidChallenge(t *testing.T) {
+	f := newFakeACME(t)
+	defer f.srv.Close()
+	m := newTestManager(t, f, Config{Domains: []string{"control.example.com"}}, memory.NewInMemoryRepository())
+	// challenges aren't served
+	f.http = http.NotFoundHandler()
+
+	require.Error(t, m.Ensure(context.Background()))
+	_, err := m.GetCertificate(&tls.ClientHelloInfo{})
+	require.Error(t, err)
+}
+
+func TestNewManager(t *testing.T) {
+	_, err := NewManager(Config{}, nil, nil)
+	require.Error(t, err)
+	_, err = NewManager(Config{Domains: []string{"*.example.com"}}, nil, nil)
+	require.Error(t, err)
+	_, err = NewManager(Config{Domains: []string{"example.com"}, Challenge: "tls-alpn-01"}, nil, nil)
+	require.Error(t, err)
+}
+
+func TestHTTPHandler(t *testing.T) {
+	m, err := NewManager(Config{Domains: []string{"example.com"}}, nil, nil)
+	require.NoError(t, err)
+	m.tokens["abc"] = "abc.key"
+	h := m.HTTPHandler(nil)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.com/.well-known/acme-challenge/abc", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body, _ := ioutil.ReadAll(rec.Body)
+	require.Equal(t, "abc.key", string(body))
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.com/.well-known/acme-challenge/other", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.com:80/v1/api/kubes?limit=1", nil))
+	require.Equal(t, http.StatusMovedPermanently, rec.Code)
+	require.Equal(t, "https://example.com/v1/api/kubes?limit=1", rec.Header().Get("Location"))
+}
@@ -0,0 +1,371 @@
+package acme
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	statusPending = "pending"
+	statusReady   = "ready"
+	statusValid   = "valid"
+	statusInvalid = "invalid"
+
+	challengeHTTP = "http-01"
+	challengeDNS  = "dns-01"
+
+	contentType = "application/jose+json"
+	nonceHeader = "Replay-Nonce"
+	badNonce    = "urn:ietf:params:acme:error:badNonce"
+)
+
+// Error is a problem document of the ACME server, RFC 7807.
+type Error struct {
+	Status int    `json:"status"`
+	Type   string `json:"type"`
+	Detail string `json:"detail"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("acme: %d %s: %s", e.Status, e.Type, e.Detail)
+}
+
+type directory struct {
+	NewNonce   string `json:"newNonce"`
+	NewAccount string `json:"newAccount"`
+	NewOrder   string `json:"newOrder"`
+}
+
+type identifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type order struct {
+	Status         string       `json:"status"`
+	Identifiers    []identifier `json:"identifiers"`
+	Authorizations []string     `json:"authorizations"`
+	Finalize       string       `json:"finalize"`
+	Certificate    string       `json:"certificate,omitempty"`
+	Error          *Error       `json:"error,omitempty"`
+}
+
+type authorization struct {
+	Status     string      `json:"status"`
+	Identifier identifier  `json:"identifier"`
+	Challenges []challenge `json:"challenges"`
+	Wildcard   bool        `json:"wildcard,omitempty"`
+}
+
+type challenge struct {
+	Type   string `json:"type"`
+	URL    string `json:"url"`
+	Status string `json:"status"`
+	Token  string `json:"token"`
+	Error  *Error `json:"error,omitempty"`
+}
+
+// client talks to an ACME server with the account key, RFC 8555.
+type client struct {
+	directoryURL string
+	key          *ecdsa.PrivateKey
+	// kid is the URL of the account
+	kid  string
+	http *http.Client
+	// pollInterval of orders and authorizations that are processed
+	pollInterval time.Duration
+
+	dir    directory
+	mu     sync.Mutex
+	nonces []string
+}
+
+func newClient(directoryURL string, key *ecdsa.PrivateKey, kid string, httpClient *http.Client) *client {
+	return &client{
+		directoryURL: directoryURL,
+		key:          key,
+		kid:          kid,
+		http:         httpClient,
+		pollInterval: time.Second * 2,
+	}
+}
+
+func (c *client) discover(ctx context.Context) error {
+	req, err := http.NewRequest(http.MethodGet, c.directoryURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.Wrap(err, "get directory")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&c.dir); err != nil {
+		return errors.Wrap(err, "decode directory")
+	}
+	return nil
+}
+
+// register creates the account or finds the existing account of the key.
+func (c *client) register(ctx context.Context, email string) error {
+	account := struct {
+		Contact              []string `json:"contact,omitempty"`
+		TermsOfServiceAgreed bool     `json:"termsOfServiceAgreed"`
+	}{
+		TermsOfServiceAgreed: true,
+	}
+	if email != "" {
+		account.Contact = []string{"mailto:" + email}
+	}
+
+	resp, err := c.post(ctx, c.dir.NewAccount, account, nil)
+	if err != nil {
+		return errors.Wrap(err, "register account")
+	}
+	c.kid = resp.Header.Get("Location")
+	if c.kid == "" {
+		return errors.New("register account: no account URL")
+	}
+	return nil
+}
+
+// newOrder returns the order of the certificate of domains and its URL.
+func (c *client) newOrder(ctx context.Context, domains []string) (*order, string, error) {
+	req := struct {
+		Identifiers []identifier `json:"identifiers"`
+	}{}
+	for _, d := range domains {
+		req.Identifiers = append(req.Identifiers, identifier{Type: "dns", Value: d})
+	}
+
+	o := &order{}
+	resp, err := c.post(ctx, c.dir.NewOrder, req, o)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "new order")
+	}
+	return o, resp.Header.Get("Location"), nil
+}
+
+func (c *client) authorization(ctx context.Context, url string) (*authorization, error) {
+	a := &authorization{}
+	if _, err := c.post(ctx, url, nil, a); err != nil {
+		return nil, errors.Wrap(err, "get authorization")
+	}
+	return a, nil
+}
+
+// accept tells the server the challenge is ready to be validated.
+func (c *client) accept(ctx context.Context, ch challenge) error {
+	if _, err := c.post(ctx, ch.URL, struct{}{}, nil); err != nil {
+		return errors.Wrapf(err, "accept challenge %s", ch.Type)
+	}
+	return nil
+}
+
+// waitAuthorization polls the authorization until it's valid.
+func (c *client) waitAuthorization(ctx context.Context, url string) error {
+	for {
+		a, err := c.authorization(ctx, url)
+		if err != nil {
+			return err
+		}
+
+		switch a.Status {
+		case statusValid:
+			return nil
+		case statusPending, "processing":
+		default:
+			for _, ch := range a.Challenges {
+				if ch.Error != nil {
+					return errors.Wrapf(ch.Error, "authorization of %s is %s", a.Identifier.Value, a.Status)
+				}
+			}
+			return errors.Errorf("authorization of %s is %s", a.Identifier.Value, a.Status)
+		}
+
+		if err = c.sleep(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// finalize submits the DER CSR and returns the URL of the certificate once
+// the order is valid.
+func (c *client) finalize(ctx context.Context, orderURL string, o *order, csr []byte) (string, error) {
+	// orders are ready once their authorizations are valid
+	for {
+		if _, err := c.post(ctx, orderURL, nil, o); err != nil {
+			return "", errors.Wrap(err, "get order")
+		}
+		if o.Status != statusPending {
+			break
+		}
+		if err := c.sleep(ctx); err != nil {
+			return "", err
+		}
+	}
+	if o.Status == statusValid {
+		return o.Certificate, nil
+	}
+	if o.Status != statusReady {
+		return "", errors.Errorf("order is %s", o.Status)
+	}
+
+	req := struct {
+		CSR string `json:"csr"`
+	}{b64(csr)}
+	if _, err := c.post(ctx, o.Finalize, req, o); err != nil {
+		return "", errors.Wrap(err, "finalize order")
+	}
+
+	for {
+		switch o.Status {
+		case statusValid:
+			return o.Certificate, nil
+		case statusInvalid:
+			if o.Error != nil {
+				return "", errors.Wrap(o.Error, "order is invalid")
+			}
+			return "", errors.New("order is invalid")
+		}
+
+		if err := c.sleep(ctx); err != nil {
+			return "", err
+		}
+		if _, err := c.post(ctx, orderURL, nil, o); err != nil {
+			return "", errors.Wrap(err, "get order")
+		}
+	}
+}
+
+// certificate downloads the PEM chain.
+func (c *client) certificate(ctx context.Context, url string) ([]byte, error) {
+	resp, err := c.post(ctx, url, nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "download certificate")
+	}
+	return ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// post sends the signed payload, responses are decoded into out if it's
+// set. Bodies of other responses are read into the returned response.
+func (c *client) post(ctx context.Context, url string, payload, out interface{}) (*http.Response, error) {
+	for retry := true; ; retry = false {
+		nonce, err := c.nonce(ctx)
+		if err != nil {
+			return nil, err
+		}
+		body, err := sign(c.key, c.kid, nonce, url, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", contentType)
+		resp, err := c.http.Do(req.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		c.saveNonce(resp)
+
+		if resp.StatusCode >= http.StatusBadRequest {
+			err := responseError(resp)
+			resp.Body.Close()
+			// nonces expire, the request is retried once with a fresh one
+			if e, ok := err.(*Error); ok && e.Type == badNonce && retry {
+				continue
+			}
+			return nil, err
+		}
+
+		data, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+		if out != nil {
+			if err = json.Unmarshal(data, out); err != nil {
+				return nil, errors.Wrapf(err, "decode response of %s", url)
+			}
+		}
+		return resp, nil
+	}
+}
+
+func (c *client) nonce(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	if n := len(c.nonces); n > 0 {
+		nonce := c.nonces[n-1]
+		c.nonces = c.nonces[:n-1]
+		c.mu.Unlock()
+		return nonce, nil
+	}
+	c.mu.Unlock()
+
+	req, err := http.NewRequest(http.MethodHead, c.dir.NewNonce, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", errors.Wrap(err, "new nonce")
+	}
+	resp.Body.Close()
+
+	nonce := resp.Header.Get(nonceHeader)
+	if nonce == "" {
+		return "", errors.New("new nonce: no nonce")
+	}
+	return nonce, nil
+}
+
+func (c *client) saveNonce(resp *http.Response) {
+	if nonce := resp.Header.Get(nonceHeader); nonce != "" {
+		c.mu.Lock()
+		c.nonces = append(c.nonces, nonce)
+		c.mu.Unlock()
+	}
+}
+
+func (c *client) sleep(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(c.pollInterval):
+		return nil
+	}
+}
+
+func responseError(resp *http.Response) error {
+	data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	e := &Error{}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/problem+json") && json.Unmarshal(data, e) == nil {
+		if e.Status == 0 {
+			e.Status = resp.StatusCode
+		}
+		return e
+	}
+	return &Error{
+		Status: resp.StatusCode,
+		Detail: strings.TrimSpace(string(data)),
+	}
+}
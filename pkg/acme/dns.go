@@ -0,0 +1,50 @@
+package acme
+
+import (
+	"context"
+
+	"github.com/supergiant/control/pkg/dns"
+	"github.com/supergiant/control/pkg/model"
+)
+
+// recordTTL of TXT records of challenges, they live for minutes.
+const recordTTL = 60
+
+// DNSProvider publishes TXT records of DNS-01 challenges, names are fully
+// qualified without the trailing dot.
+type DNSProvider interface {
+	SetTXT(ctx context.Context, fqdn, value string) error
+	DeleteTXT(ctx context.Context, fqdn, value string) error
+}
+
+// NewDNSProvider returns the provider of DNS zones of the cloud account.
+func NewDNSProvider(account *model.CloudAccount) (DNSProvider, error) {
+	p, err := dns.New(account)
+	if err != nil {
+		return nil, err
+	}
+
+	return txtRecords{p}, nil
+}
+
+// txtRecords publishes challenges with a DNS provider.
+type txtRecords struct {
+	dns.Provider
+}
+
+func (t txtRecords) SetTXT(ctx context.Context, fqdn, value string) error {
+	return t.Upsert(ctx, txtRecord(fqdn, value))
+}
+
+func (t txtRecords) DeleteTXT(ctx context.Context, fqdn, value string) error {
+	return t.Delete(ctx, txtRecord(fqdn, value))
+}
+
+func txtRecord(fqdn, value string) dns.Record {
+	return dns.Record{
+		Name:   fqdn,
+		Type:   dns.TypeTXT,
+		TTL:    recordTTL,
+		Values: []string{value},
+	}
+}
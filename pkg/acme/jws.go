@@ -0,0 +1,111 @@
+package acme
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// jws is a flattened JSON web signature of a request, RFC 7515.
+type jws struct {
+	Protected string `json:"protected"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+type jwk struct {
+	Crv string `json:"crv"`
+	Kty string `json:"kty"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type protectedHeader struct {
+	Alg   string `json:"alg"`
+	Nonce string `json:"nonce"`
+	URL   string `json:"url"`
+	// JWK is of requests that create accounts, other requests have the
+	// KID of the account.
+	JWK *jwk   `json:"jwk,omitempty"`
+	KID string `json:"kid,omitempty"`
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// publicJWK returns the JWK of the P-256 key.
+func publicJWK(key *ecdsa.PublicKey) *jwk {
+	size := (key.Curve.Params().BitSize + 7) / 8
+	return &jwk{
+		Crv: key.Curve.Params().Name,
+		Kty: "EC",
+		X:   b64(pad(key.X, size)),
+		Y:   b64(pad(key.Y, size)),
+	}
+}
+
+// thumbprint of the key, RFC 7638, members are in lexicographic order.
+func thumbprint(key *ecdsa.PublicKey) string {
+	k := publicJWK(key)
+	sum := sha256.Sum256([]byte(fmt.Sprintf(`{"crv":"%s","kty":"EC","x":"%s","y":"%s"}`, k.Crv, k.X, k.Y)))
+	return b64(sum[:])
+}
+
+// sign returns the JWS of the payload, payload is nil for POST-as-GET
+// requests. kid is empty when accounts are created.
+func sign(key *ecdsa.PrivateKey, kid, nonce, url string, payload interface{}) ([]byte, error) {
+	if key.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("unsupported curve %s", key.Curve.Params().Name)
+	}
+
+	header := protectedHeader{
+		Alg:   "ES256",
+		Nonce: nonce,
+		URL:   url,
+		KID:   kid,
+	}
+	if kid == "" {
+		header.JWK = publicJWK(&key.PublicKey)
+	}
+	protected, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	if payload != nil {
+		if body, err = json.Marshal(payload); err != nil {
+			return nil, err
+		}
+	}
+
+	msg := jws{
+		Protected: b64(protected),
+		Payload:   b64(body),
+	}
+	hash := crypto.SHA256.New()
+	hash.Write([]byte(msg.Protected + "." + msg.Payload))
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	msg.Signature = b64(append(pad(r, 32), pad(s, 32)...))
+
+	return json.Marshal(msg)
+}
+
+// pad returns big endian bytes of the number left padded to the size.
+func pad(n *big.Int, size int) []byte {
+	b := n.Bytes()
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
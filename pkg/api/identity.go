@@ -0,0 +1,29 @@
+package api
+
+import "context"
+
+type contextKey int
+
+const (
+	identityKey contextKey = iota
+	scopeKey
+)
+
+// Identity is the control user a request is made on behalf of.
+type Identity struct {
+	Login string
+	Role  string
+	// Session of the token, tokens issued before sessions don't have it.
+	Session string
+}
+
+// WithIdentity returns a copy of the context that carries the identity.
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey, id)
+}
+
+// IdentityFrom returns the identity stored in the context by the auth middleware.
+func IdentityFrom(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey).(Identity)
+	return id, ok
+}
@@ -1,20 +1,31 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
 
 	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/user"
 )
 
 type TokenValidater interface {
 	Validate(string) (jwt.MapClaims, error)
 }
 
+// SessionChecker returns an error when the session of a token is revoked.
+type SessionChecker interface {
+	Check(ctx context.Context, id string) error
+}
+
 type Middleware struct {
 	TokenService TokenValidater
+	// Sessions are checked when they are set.
+	Sessions SessionChecker
 }
 
 func (m *Middleware) AuthMiddleware(next http.Handler) http.Handler {
@@ -35,26 +46,76 @@ func (m *Middleware) AuthMiddleware(next http.Handler) http.Handler {
 			}
 		}
 
-		claims, err := m.TokenService.Validate(tokenString)
-
+		id, err := m.Identify(tokenString)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusForbidden)
 			return
 		}
 
-		userId, ok := claims["user_id"].(string)
-		if !ok {
-			http.Error(w, sgerrors.ErrInvalidCredentials.Error(), http.StatusForbidden)
+		next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), id)))
+	})
+}
+
+// Identify validates the token and returns the identity of its user.
+func (m *Middleware) Identify(tokenString string) (Identity, error) {
+	claims, err := m.TokenService.Validate(tokenString)
+
+	if err != nil {
+		return Identity{}, err
+	}
+
+	userId, ok := claims["user_id"].(string)
+	if !ok {
+		return Identity{}, sgerrors.ErrInvalidCredentials
+	}
+
+	if len(userId) == 0 {
+		return Identity{}, errors.New("unknown user")
+	}
+
+	// tokens issued before roles were introduced don't have a role claim
+	role, _ := claims["role"].(string)
+	session, _ := claims["session_id"].(string)
+	if session != "" && m.Sessions != nil {
+		if err = m.Sessions.Check(context.Background(), session); err != nil {
+			return Identity{}, err
+		}
+	}
+
+	return Identity{
+		Login:   userId,
+		Role:    role,
+		Session: session,
+	}, nil
+}
+
+// AdminOnly rejects requests of users that aren't admins, users created
+// before roles were introduced are admins.
+func AdminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := IdentityFrom(r.Context())
+		if !ok || (id.Role != "" && id.Role != user.RoleAdmin) {
+			http.Error(w, "admin role is required", http.StatusForbidden)
 			return
 		}
 
-		if len(userId) == 0 {
-			http.Error(w, "unknown user", http.StatusForbidden)
+		next(w, r)
+	}
+}
+
+// SelfOrAdmin allows requests of admins and of the user of the login
+// variable of the route.
+func SelfOrAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := IdentityFrom(r.Context())
+		admin := ok && (id.Role == "" || id.Role == user.RoleAdmin)
+		if !ok || !admin && id.Login != mux.Vars(r)["login"] {
+			http.Error(w, "users can manage only themselves", http.StatusForbidden)
 			return
 		}
 
-		next.ServeHTTP(w, r)
-	})
+		next(w, r)
+	}
 }
 
 func ContentTypeJSON(next http.Handler) http.Handler {
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -12,6 +13,7 @@ import (
 	"github.com/gorilla/mux"
 
 	sgjwt "github.com/supergiant/control/pkg/jwt"
+	"github.com/supergiant/control/pkg/sgerrors"
 )
 
 func TestAuthMiddleware(t *testing.T) {
@@ -195,6 +197,78 @@ func TestAuthMiddleware(t *testing.T) {
 	}
 }
 
+func TestAuthMiddlewareIdentity(t *testing.T) {
+	ts := sgjwt.NewTokenService(60, []byte("secret"))
+	tokenString, err := ts.Issue("login", "view")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenString)
+
+	var id Identity
+	md := Middleware{
+		TokenService: ts,
+	}
+	md.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ = IdentityFrom(r.Context())
+	})).ServeHTTP(httptest.NewRecorder(), req)
+
+	if id.Login != "login" || id.Role != "view" {
+		t.Errorf("wrong identity %+v", id)
+	}
+}
+
+type sessions map[string]bool
+
+func (s sessions) Check(_ context.Context, id string) error {
+	if !s[id] {
+		return sgerrors.ErrSessionRevoked
+	}
+	return nil
+}
+
+func TestAuthMiddlewareSessions(t *testing.T) {
+	ts := sgjwt.NewTokenService(60, []byte("secret"))
+	md := Middleware{
+		TokenService: ts,
+		Sessions:     sessions{"active": true},
+	}
+
+	for _, testCase := range []struct {
+		session      string
+		expectedCode int
+	}{
+		{"active", http.StatusOK},
+		{"revoked", http.StatusForbidden},
+		// tokens issued before sessions
+		{"", http.StatusOK},
+	} {
+		tokenString, err := ts.IssueSession("login", "view", testCase.session)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req, _ := http.NewRequest(http.MethodGet, "", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenString)
+		rec := httptest.NewRecorder()
+
+		var id Identity
+		md.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, _ = IdentityFrom(r.Context())
+		})).ServeHTTP(rec, req)
+
+		if rec.Code != testCase.expectedCode {
+			t.Errorf("session %q: wrong response code expected %d actual %d",
+				testCase.session, testCase.expectedCode, rec.Code)
+		}
+		if rec.Code == http.StatusOK && id.Session != testCase.session {
+			t.Errorf("wrong session of identity %+v", id)
+		}
+	}
+}
+
 type testHandler struct {
 	called bool
 }
@@ -220,3 +294,30 @@ func TestContentTypeJSON(t *testing.T) {
 		t.Error("json middleware was not called")
 	}
 }
+
+func TestSelfOrAdmin(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{login}/totp", SelfOrAdmin(func(http.ResponseWriter, *http.Request) {}))
+
+	for _, tc := range []struct {
+		id           *Identity
+		expectedCode int
+	}{
+		{&Identity{Login: "bob", Role: "view"}, http.StatusOK},
+		{&Identity{Login: "alice", Role: "edit"}, http.StatusForbidden},
+		{&Identity{Login: "alice", Role: "admin"}, http.StatusOK},
+		{&Identity{Login: "root"}, http.StatusOK},
+		{nil, http.StatusForbidden},
+	} {
+		req, _ := http.NewRequest(http.MethodGet, "/users/bob/totp", nil)
+		if tc.id != nil {
+			req = req.WithContext(WithIdentity(req.Context(), *tc.id))
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != tc.expectedCode {
+			t.Errorf("%+v: expected %d actual %d", tc.id, tc.expectedCode, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/labels"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/user"
+)
+
+// ErrTeamNotAllowed is returned when users assign resources to teams they
+// aren't members of.
+var ErrTeamNotAllowed = errors.New("user is not a member of the team")
+
+// TeamLister looks up teams of users.
+type TeamLister interface {
+	TeamsOf(ctx context.Context, login string) ([]string, error)
+}
+
+// SelectorLister looks up label selectors of teams of users, it's
+// optionally implemented by team listers.
+type SelectorLister interface {
+	SelectorsOf(ctx context.Context, login string) ([]map[string]string, error)
+}
+
+// Scope is the set of teams whose resources a request can see. Resources
+// without a team are shared with all users, admins see all resources.
+// Resources of other teams are visible if their labels match one of
+// Selectors.
+type Scope struct {
+	Admin     bool
+	Teams     []string
+	Selectors []map[string]string
+}
+
+// Allows reports whether resources of the team are visible.
+func (s Scope) Allows(team string) bool {
+	if s.Admin || team == "" {
+		return true
+	}
+	for _, t := range s.Teams {
+		if t == team {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsLabeled reports whether the resource of the team with the labels
+// is visible.
+func (s Scope) AllowsLabeled(team string, resourceLabels map[string]string) bool {
+	if s.Allows(team) {
+		return true
+	}
+	for _, selector := range s.Selectors {
+		if len(selector) > 0 && labels.Matches(resourceLabels, selector) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter selects stored resources of visible teams, it's a filter of
+// storage list options.
+func (s Scope) Filter(value []byte) bool {
+	if s.Admin {
+		return true
+	}
+
+	r := struct {
+		Team   string            `json:"team"`
+		Labels map[string]string `json:"labels"`
+	}{}
+	if err := json.Unmarshal(value, &r); err != nil {
+		return false
+	}
+	return s.AllowsLabeled(r.Team, r.Labels)
+}
+
+// TeamFor returns the team of a new resource, resources of members of a
+// single team belong to it by default.
+func (s Scope) TeamFor(team string) (string, error) {
+	if team == "" && !s.Admin && len(s.Teams) == 1 {
+		return s.Teams[0], nil
+	}
+	if !s.Allows(team) {
+		return "", errors.Wrap(ErrTeamNotAllowed, team)
+	}
+	return team, nil
+}
+
+// WithScope returns a copy of the context that carries the scope.
+func WithScope(ctx context.Context, s Scope) context.Context {
+	return context.WithValue(ctx, scopeKey, s)
+}
+
+// ScopeFrom returns the scope of the request, contexts without a scope
+// e.g. of background jobs see all resources.
+func ScopeFrom(ctx context.Context) Scope {
+	s, ok := ctx.Value(scopeKey).(Scope)
+	if !ok {
+		return Scope{Admin: true}
+	}
+	return s
+}
+
+// ScopeOf returns the scope of the identity.
+func ScopeOf(ctx context.Context, teams TeamLister, id Identity) (Scope, error) {
+	if id.Role == "" || id.Role == user.RoleAdmin {
+		return Scope{Admin: true}, nil
+	}
+
+	names, err := teams.TeamsOf(ctx, id.Login)
+	if err != nil {
+		return Scope{}, err
+	}
+	s := Scope{Teams: names}
+
+	if selectors, ok := teams.(SelectorLister); ok {
+		if s.Selectors, err = selectors.SelectorsOf(ctx, id.Login); err != nil {
+			return Scope{}, err
+		}
+	}
+	return s, nil
+}
+
+// TeamScope stores scopes of users in contexts of requests, it follows the
+// auth middleware.
+func TeamScope(teams TeamLister) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, ok := IdentityFrom(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			s, err := ScopeOf(r.Context(), teams, id)
+			if err != nil {
+				logrus.Errorf("teams of %s: %v", id.Login, err)
+				message.SendUnknownError(w, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithScope(r.Context(), s)))
+		})
+	}
+}
+
+// TeamRequest moves a resource to a team, resources are shared when the
+// team is empty.
+type TeamRequest struct {
+	Team string `json:"team"`
+}
+
+// LabelsRequest replaces labels of a resource.
+type LabelsRequest struct {
+	Labels map[string]string `json:"labels"`
+}
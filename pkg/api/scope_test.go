@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/user"
+)
+
+type teamLister map[string][]string
+
+func (t teamLister) TeamsOf(ctx context.Context, login string) ([]string, error) {
+	if login == "broken" {
+		return nil, errors.New("error")
+	}
+	return t[login], nil
+}
+
+func TestScope(t *testing.T) {
+	s := Scope{Teams: []string{"a", "b"}}
+	require.True(t, s.Allows("a"))
+	require.True(t, s.Allows(""))
+	require.False(t, s.Allows("c"))
+	require.True(t, Scope{Admin: true}.Allows("c"))
+
+	require.True(t, s.Filter([]byte(`{"team":"b"}`)))
+	require.True(t, s.Filter([]byte(`{"name":"shared"}`)))
+	require.False(t, s.Filter([]byte(`{"team":"c"}`)))
+	require.False(t, s.Filter([]byte(`{`)))
+
+	// resources of other teams are visible by labels
+	s.Selectors = []map[string]string{{"env": "staging"}}
+	require.True(t, s.AllowsLabeled("c", map[string]string{"env": "staging", "tier": "web"}))
+	require.False(t, s.AllowsLabeled("c", map[string]string{"env": "prod"}))
+	require.True(t, s.Filter([]byte(`{"team":"c","labels":{"env":"staging"}}`)))
+	require.False(t, Scope{Selectors: []map[string]string{{}}}.AllowsLabeled("c", nil))
+	s.Selectors = nil
+
+	team, err := s.TeamFor("")
+	require.NoError(t, err)
+	require.Empty(t, team)
+	_, err = s.TeamFor("c")
+	require.Equal(t, ErrTeamNotAllowed, errors.Cause(err))
+
+	team, err = Scope{Teams: []string{"a"}}.TeamFor("")
+	require.NoError(t, err)
+	require.Equal(t, "a", team)
+
+	require.True(t, ScopeFrom(context.Background()).Admin)
+}
+
+func TestTeamScope(t *testing.T) {
+	teams := teamLister{"user": {"a"}}
+	var scope Scope
+	h := TeamScope(teams)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope = ScopeFrom(r.Context())
+	}))
+
+	for _, tc := range []struct {
+		id     Identity
+		status int
+		scope  Scope
+	}{
+		{Identity{Login: "root"}, http.StatusOK, Scope{Admin: true}},
+		{Identity{Login: "admin", Role: user.RoleAdmin}, http.StatusOK, Scope{Admin: true}},
+		{Identity{Login: "user", Role: user.RoleEdit}, http.StatusOK, Scope{Teams: []string{"a"}}},
+		{Identity{Login: "broken", Role: user.RoleView}, http.StatusInternalServerError, Scope{}},
+	} {
+		scope = Scope{}
+		req := httptest.NewRequest(http.MethodGet, "/kubes", nil)
+		req = req.WithContext(WithIdentity(req.Context(), tc.id))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		require.Equal(t, tc.status, rec.Code, tc.id.Login)
+		require.Equal(t, tc.scope, scope, tc.id.Login)
+	}
+}
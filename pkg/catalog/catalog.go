@@ -0,0 +1,308 @@
+// Package catalog presents curated helm charts as applications that are
+// installed into managed clusters with values that suit their cloud provider.
+package catalog
+
+import (
+	"context"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/helm/pkg/repo"
+	"sigs.k8s.io/yaml"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// Repo is a helm repository charts of the catalog are sourced from.
+type Repo struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// ProviderDefaults are values that depend on the cloud provider of a cluster.
+type ProviderDefaults struct {
+	// StorageClass of persistent volumes, the default class of the cluster
+	// is used when empty.
+	StorageClass string `json:"storageClass,omitempty"`
+	// LoadBalancerAnnotations of services of the LoadBalancer type.
+	LoadBalancerAnnotations map[string]string `json:"loadBalancerAnnotations,omitempty"`
+}
+
+// App is a curated chart of a catalog repository.
+type App struct {
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	Category     string `json:"category"`
+	RepoName     string `json:"repoName"`
+	ChartName    string `json:"chartName"`
+	ChartVersion string `json:"chartVersion,omitempty"`
+	Namespace    string `json:"namespace"`
+	// Values are the default values of the chart in YAML.
+	Values string `json:"values,omitempty"`
+	// StorageClassValues and LoadBalancerValues are dotted paths of values
+	// that are set to the provider defaults, e.g. persistence.storageClass.
+	StorageClassValues []string `json:"storageClassValues,omitempty"`
+	LoadBalancerValues []string `json:"loadBalancerValues,omitempty"`
+	// ProviderValues are merged over the defaults on clusters of the provider.
+	ProviderValues map[clouds.Name]string `json:"providerValues,omitempty"`
+}
+
+// Catalog is a set of applications and repositories of their charts.
+type Catalog struct {
+	Repos     []Repo                           `json:"repos"`
+	Providers map[clouds.Name]ProviderDefaults `json:"providers"`
+	Apps      []App                            `json:"apps"`
+}
+
+// Default returns the built-in catalog of charts of the stable repository.
+func Default() *Catalog {
+	return &Catalog{
+		Repos: []Repo{
+			{Name: "stable", URL: "https://kubernetes-charts.storage.googleapis.com"},
+		},
+		Providers: map[clouds.Name]ProviderDefaults{
+			clouds.AWS: {
+				StorageClass:            "gp2",
+				LoadBalancerAnnotations: clouds.LoadBalancerAnnotations(clouds.AWS),
+			},
+			clouds.GCE: {
+				StorageClass: "default",
+			},
+			clouds.DigitalOcean: {
+				LoadBalancerAnnotations: clouds.LoadBalancerAnnotations(clouds.DigitalOcean),
+			},
+		},
+		Apps: []App{
+			{
+				Name:               "nginx-ingress",
+				Description:        "Ingress controller that uses nginx as a reverse proxy",
+				Category:           "networking",
+				RepoName:           "stable",
+				ChartName:          "nginx-ingress",
+				Namespace:          "ingress-nginx",
+				Values:             "controller:\n  service:\n    type: LoadBalancer\n",
+				LoadBalancerValues: []string{"controller.service.annotations"},
+			},
+			{
+				Name:               "prometheus-operator",
+				Description:        "Prometheus, Alertmanager and Grafana managed by the Prometheus operator",
+				Category:           "monitoring",
+				RepoName:           "stable",
+				ChartName:          "prometheus-operator",
+				Namespace:          "monitoring",
+				StorageClassValues: []string{"prometheus.prometheusSpec.storageSpec.volumeClaimTemplate.spec.storageClassName"},
+			},
+			{
+				Name:               "grafana",
+				Description:        "Dashboards of metrics and logs",
+				Category:           "monitoring",
+				RepoName:           "stable",
+				ChartName:          "grafana",
+				Namespace:          "monitoring",
+				Values:             "persistence:\n  enabled: true\n",
+				StorageClassValues: []string{"persistence.storageClassName"},
+			},
+			{
+				Name:               "postgresql",
+				Description:        "PostgreSQL database",
+				Category:           "database",
+				RepoName:           "stable",
+				ChartName:          "postgresql",
+				Namespace:          "default",
+				StorageClassValues: []string{"persistence.storageClass"},
+			},
+			{
+				Name:               "redis",
+				Description:        "Redis key-value store",
+				Category:           "database",
+				RepoName:           "stable",
+				ChartName:          "redis",
+				Namespace:          "default",
+				StorageClassValues: []string{"master.persistence.storageClass", "slave.persistence.storageClass"},
+			},
+		},
+	}
+}
+
+// Load reads the catalog from the YAML or JSON file.
+func Load(path string) (*Catalog, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Catalog{}
+	if err = yaml.Unmarshal(data, c); err != nil {
+		return nil, errors.Wrapf(err, "decode %s", path)
+	}
+	if err = c.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "catalog %s", path)
+	}
+	return c, nil
+}
+
+// Validate checks apps have unique names and use repositories of the catalog.
+func (c *Catalog) Validate() error {
+	repos := make(map[string]bool)
+	for _, r := range c.Repos {
+		if r.Name == "" || r.URL == "" {
+			return errors.New("repos need names and urls")
+		}
+		repos[r.Name] = true
+	}
+
+	names := make(map[string]bool)
+	for _, app := range c.Apps {
+		if app.Name == "" || app.ChartName == "" {
+			return errors.New("apps need names and charts")
+		}
+		if names[app.Name] {
+			return errors.Errorf("app %s is defined twice", app.Name)
+		}
+		names[app.Name] = true
+		if !repos[app.RepoName] {
+			return errors.Errorf("app %s: unknown repo %q", app.Name, app.RepoName)
+		}
+		if _, err := parseValues(app.Values); err != nil {
+			return errors.Wrapf(err, "app %s", app.Name)
+		}
+		for provider, values := range app.ProviderValues {
+			if _, err := parseValues(values); err != nil {
+				return errors.Wrapf(err, "app %s: %s values", app.Name, provider)
+			}
+		}
+	}
+	return nil
+}
+
+// List returns apps sorted by name, all apps are returned if the category
+// is empty.
+func (c *Catalog) List(category string) []App {
+	apps := make([]App, 0, len(c.Apps))
+	for _, app := range c.Apps {
+		if category == "" || app.Category == category {
+			apps = append(apps, app)
+		}
+	}
+	sort.Slice(apps, func(i, j int) bool {
+		return apps[i].Name < apps[j].Name
+	})
+	return apps
+}
+
+// Get returns the app by name.
+func (c *Catalog) Get(name string) (*App, error) {
+	for i := range c.Apps {
+		if c.Apps[i].Name == name {
+			return &c.Apps[i], nil
+		}
+	}
+	return nil, errors.Wrapf(sgerrors.ErrNotFound, "app %s", name)
+}
+
+// Values returns the values of the app on clusters of the provider, the
+// overrides in YAML take precedence over the defaults.
+func (c *Catalog) Values(app *App, provider clouds.Name, overrides string) (string, error) {
+	values, err := parseValues(app.Values)
+	if err != nil {
+		return "", errors.Wrap(err, "app values")
+	}
+
+	defaults := c.Providers[provider]
+	if defaults.StorageClass != "" {
+		for _, path := range app.StorageClassValues {
+			setValue(values, path, defaults.StorageClass)
+		}
+	}
+	if len(defaults.LoadBalancerAnnotations) > 0 {
+		annotations := make(map[string]interface{}, len(defaults.LoadBalancerAnnotations))
+		for k, v := range defaults.LoadBalancerAnnotations {
+			annotations[k] = v
+		}
+		for _, path := range app.LoadBalancerValues {
+			setValue(values, path, annotations)
+		}
+	}
+
+	for _, layer := range []string{app.ProviderValues[provider], overrides} {
+		v, err := parseValues(layer)
+		if err != nil {
+			return "", errors.Wrapf(sgerrors.ErrInvalidJson, "values: %v", err)
+		}
+		merge(values, v)
+	}
+
+	if len(values) == 0 {
+		return "", nil
+	}
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// repoCreator adds helm repositories.
+type repoCreator interface {
+	CreateRepo(ctx context.Context, e *repo.Entry) (*model.RepositoryInfo, error)
+}
+
+// EnsureRepos adds repositories of the catalog that haven't been added yet.
+func (c *Catalog) EnsureRepos(ctx context.Context, svc repoCreator) {
+	for _, r := range c.Repos {
+		_, err := svc.CreateRepo(ctx, &repo.Entry{Name: r.Name, URL: r.URL})
+		if err != nil {
+			if !sgerrors.IsAlreadyExists(err) {
+				logrus.Errorf("catalog: add %q helm repository: %v", r.Name, err)
+			}
+			continue
+		}
+		logrus.Infof("catalog: helm repository has been added: %s", r.Name)
+	}
+}
+
+func parseValues(s string) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	if strings.TrimSpace(s) == "" {
+		return values, nil
+	}
+	if err := yaml.Unmarshal([]byte(s), &values); err != nil {
+		return nil, err
+	}
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return values, nil
+}
+
+// setValue sets the value of the dotted path, missing tables are created.
+func setValue(values map[string]interface{}, path string, value interface{}) {
+	keys := strings.Split(path, ".")
+	for _, k := range keys[:len(keys)-1] {
+		next, ok := values[k].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			values[k] = next
+		}
+		values = next
+	}
+	values[keys[len(keys)-1]] = value
+}
+
+// merge copies src into dst, tables are merged and other values replaced.
+func merge(dst, src map[string]interface{}) {
+	for k, v := range src {
+		srcTable, ok := v.(map[string]interface{})
+		dstTable, isTable := dst[k].(map[string]interface{})
+		if ok && isTable {
+			merge(dstTable, srcTable)
+			continue
+		}
+		dst[k] = v
+	}
+}
@@ -0,0 +1,150 @@
+package catalog
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"k8s.io/helm/pkg/repo"
+	"sigs.k8s.io/yaml"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+func TestDefault(t *testing.T) {
+	c := Default()
+	require.NoError(t, c.Validate())
+	require.NotEmpty(t, c.List(""))
+	require.Len(t, c.List("database"), 2)
+
+	_, err := c.Get("nginx-ingress")
+	require.NoError(t, err)
+	_, err = c.Get("unknown")
+	require.True(t, sgerrors.IsNotFound(err))
+}
+
+func TestValues(t *testing.T) {
+	c := Default()
+	app := &App{
+		Name:               "app",
+		Values:             "persistence:\n  enabled: true\nservice:\n  type: LoadBalancer\n",
+		StorageClassValues: []string{"persistence.storageClass"},
+		LoadBalancerValues: []string{"service.annotations"},
+		ProviderValues: map[clouds.Name]string{
+			clouds.GCE: "service:\n  type: NodePort\n",
+		},
+	}
+
+	tcs := []struct {
+		name      string
+		provider  clouds.Name
+		overrides string
+		expected  map[string]interface{}
+	}{
+		{
+			name:     "aws",
+			provider: clouds.AWS,
+			expected: map[string]interface{}{
+				"persistence": map[string]interface{}{"enabled": true, "storageClass": "gp2"},
+				"service": map[string]interface{}{
+					"type":        "LoadBalancer",
+					"annotations": map[string]interface{}{"service.beta.kubernetes.io/aws-load-balancer-type": "nlb"},
+				},
+			},
+		},
+		{
+			name:     "provider values",
+			provider: clouds.GCE,
+			expected: map[string]interface{}{
+				"persistence": map[string]interface{}{"enabled": true, "storageClass": "default"},
+				"service":     map[string]interface{}{"type": "NodePort"},
+			},
+		},
+		{
+			name:      "overrides",
+			provider:  clouds.OpenStack,
+			overrides: "persistence:\n  enabled: false\nreplicas: 2\n",
+			expected: map[string]interface{}{
+				"persistence": map[string]interface{}{"enabled": false},
+				"service":     map[string]interface{}{"type": "LoadBalancer"},
+				"replicas":    float64(2),
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		out, err := c.Values(app, tc.provider, tc.overrides)
+		require.NoError(t, err, tc.name)
+
+		values := map[string]interface{}{}
+		require.NoError(t, yaml.Unmarshal([]byte(out), &values), tc.name)
+		require.Equal(t, tc.expected, values, tc.name)
+	}
+
+	_, err := c.Values(app, clouds.AWS, "[")
+	require.Equal(t, sgerrors.ErrInvalidJson, errors.Cause(err))
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "catalog")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "catalog.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+repos:
+- name: charts
+  url: https://charts.example.com
+providers:
+  aws:
+    storageClass: io1
+apps:
+- name: db
+  repoName: charts
+  chartName: mysql
+  storageClassValues: [persistence.storageClass]
+`), 0600))
+
+	c, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, "io1", c.Providers[clouds.AWS].StorageClass)
+	require.Len(t, c.Apps, 1)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+repos: []
+apps:
+- name: db
+  repoName: charts
+  chartName: mysql
+`), 0600))
+	_, err = Load(path)
+	require.Error(t, err)
+}
+
+type fakeRepos struct {
+	created []string
+	err     error
+}
+
+func (f *fakeRepos) CreateRepo(ctx context.Context, e *repo.Entry) (*model.RepositoryInfo, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.created = append(f.created, e.Name)
+	return &model.RepositoryInfo{}, nil
+}
+
+func TestEnsureRepos(t *testing.T) {
+	repos := &fakeRepos{}
+	Default().EnsureRepos(context.Background(), repos)
+	require.Equal(t, []string{"stable"}, repos.created)
+
+	// existing repositories are skipped
+	Default().EnsureRepos(context.Background(), &fakeRepos{err: sgerrors.ErrAlreadyExists})
+}
@@ -0,0 +1,172 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/kube"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type kubeGetter interface {
+	Get(ctx context.Context, name string) (*model.Kube, error)
+}
+
+// installer runs the workflow that installs a chart into the cluster.
+type installer interface {
+	InstallApp(ctx context.Context, k *model.Kube, app steps.InstallAppConfig, workflow string) (string, error)
+}
+
+// Details of an app are its values on clusters of the provider.
+type Details struct {
+	App
+	Provider      clouds.Name `json:"provider,omitempty"`
+	DefaultValues string      `json:"defaultValues"`
+}
+
+// InstallRequest overrides the release name, namespace, chart version and
+// values of the app.
+type InstallRequest struct {
+	Name         string `json:"name"`
+	Namespace    string `json:"namespace"`
+	ChartVersion string `json:"chartVersion"`
+	Values       string `json:"values"`
+}
+
+// Handler serves the catalog and installs its apps.
+type Handler struct {
+	catalog   *Catalog
+	kubes     kubeGetter
+	installer installer
+}
+
+// NewHandler creates a Handler of the catalog.
+func NewHandler(c *Catalog, kubes kubeGetter, i installer) *Handler {
+	return &Handler{
+		catalog:   c,
+		kubes:     kubes,
+		installer: i,
+	}
+}
+
+func (h *Handler) Register(r *mux.Router) {
+	r.HandleFunc("/catalog", h.listApps).Methods(http.MethodGet)
+	r.HandleFunc("/catalog/{app}", h.getApp).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/catalog/{app}", h.installApp).Methods(http.MethodPost)
+}
+
+func (h *Handler) listApps(w http.ResponseWriter, r *http.Request) {
+	if err := json.NewEncoder(w).Encode(h.catalog.List(r.URL.Query().Get("category"))); err != nil {
+		logrus.Errorf("catalog: list apps: write response: %v", err)
+	}
+}
+
+// getApp returns the app with default values of the provider of the query.
+func (h *Handler) getApp(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["app"]
+	app, err := h.catalog.Get(name)
+	if err != nil {
+		message.SendNotFound(w, name, err)
+		return
+	}
+
+	provider := clouds.Name(r.URL.Query().Get("provider"))
+	values, err := h.catalog.Values(app, provider, "")
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(Details{
+		App:           *app,
+		Provider:      provider,
+		DefaultValues: values,
+	}); err != nil {
+		logrus.Errorf("catalog: get app %s: write response: %v", name, err)
+	}
+}
+
+// installApp starts the workflow that installs the app and waits for its
+// workloads to become ready.
+func (h *Handler) installApp(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID, name := vars["kubeID"], vars["app"]
+
+	req := &InstallRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	app, err := h.catalog.Get(name)
+	if err != nil {
+		message.SendNotFound(w, name, err)
+		return
+	}
+	k, err := h.kubes.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	values, err := h.catalog.Values(app, k.Provider, req.Values)
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	cfg := steps.InstallAppConfig{
+		Name:         firstOf(req.Name, app.Name),
+		Namespace:    firstOf(req.Namespace, app.Namespace),
+		ChartName:    app.ChartName,
+		ChartVersion: firstOf(req.ChartVersion, app.ChartVersion),
+		RepoName:     app.RepoName,
+		Values:       values,
+	}
+	taskID, err := h.installer.InstallApp(r.Context(), k, cfg, workflows.InstallCatalogApp)
+	if err != nil {
+		if errors.Cause(err) == kube.ErrNotOperational {
+			message.SendValidationFailed(w, err)
+			return
+		}
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, app.ChartName, err)
+			return
+		}
+		logrus.Errorf("catalog: install %s to %s: %v", name, kubeID, err)
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	if err = json.NewEncoder(w).Encode(struct {
+		TaskID string `json:"taskId"`
+	}{
+		TaskID: taskID,
+	}); err != nil {
+		logrus.Errorf("catalog: install %s to %s: write response: %v", name, kubeID, err)
+	}
+}
+
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,143 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/kube"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type fakeKubes struct {
+	kube *model.Kube
+}
+
+func (f *fakeKubes) Get(ctx context.Context, name string) (*model.Kube, error) {
+	if f.kube == nil || f.kube.ID != name {
+		return nil, sgerrors.ErrNotFound
+	}
+	return f.kube, nil
+}
+
+type fakeInstaller struct {
+	app      steps.InstallAppConfig
+	workflow string
+	err      error
+}
+
+func (f *fakeInstaller) InstallApp(ctx context.Context, k *model.Kube, app steps.InstallAppConfig, workflow string) (string, error) {
+	f.app, f.workflow = app, workflow
+	return "task", f.err
+}
+
+func TestHandler_listApps(t *testing.T) {
+	router := mux.NewRouter()
+	NewHandler(Default(), &fakeKubes{}, &fakeInstaller{}).Register(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/catalog?category=monitoring", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	apps := []App{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&apps))
+	require.Len(t, apps, 2)
+	require.Equal(t, "grafana", apps[0].Name)
+}
+
+func TestHandler_getApp(t *testing.T) {
+	router := mux.NewRouter()
+	NewHandler(Default(), &fakeKubes{}, &fakeInstaller{}).Register(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/catalog/grafana?provider=aws", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	details := &Details{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(details))
+	require.Equal(t, clouds.AWS, details.Provider)
+	require.Contains(t, details.DefaultValues, "storageClassName: gp2")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/catalog/unknown", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandler_installApp(t *testing.T) {
+	k := &model.Kube{ID: "kube", Provider: clouds.AWS}
+
+	tcs := []struct {
+		name           string
+		path           string
+		body           string
+		installErr     error
+		expectedStatus int
+	}{
+		{
+			name:           "invalid json",
+			path:           "/kubes/kube/catalog/grafana",
+			body:           "{",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown app",
+			path:           "/kubes/kube/catalog/unknown",
+			body:           "{}",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "unknown kube",
+			path:           "/kubes/other/catalog/grafana",
+			body:           "{}",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid values",
+			path:           "/kubes/kube/catalog/grafana",
+			body:           `{"values":"["}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "not operational",
+			path:           "/kubes/kube/catalog/grafana",
+			body:           "{}",
+			installErr:     kube.ErrNotOperational,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "installed",
+			path:           "/kubes/kube/catalog/grafana",
+			body:           `{"namespace":"tools","values":"replicas: 2"}`,
+			expectedStatus: http.StatusAccepted,
+		},
+	}
+
+	for _, tc := range tcs {
+		i := &fakeInstaller{err: tc.installErr}
+		router := mux.NewRouter()
+		NewHandler(Default(), &fakeKubes{kube: k}, i).Register(router)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tc.path, bytes.NewBufferString(tc.body)))
+		require.Equal(t, tc.expectedStatus, w.Code, tc.name)
+
+		if w.Code == http.StatusAccepted {
+			require.Equal(t, workflows.InstallCatalogApp, i.workflow)
+			require.Equal(t, "grafana", i.app.Name)
+			require.Equal(t, "tools", i.app.Namespace)
+			require.Equal(t, "stable", i.app.RepoName)
+			require.Contains(t, i.app.Values, "replicas: 2")
+			require.Contains(t, i.app.Values, "storageClassName: gp2")
+		}
+	}
+}
@@ -13,12 +13,12 @@ const (
 	GCE          Name = "gce"
 	Azure        Name = "azure"
 	OpenStack    Name = "openstack"
+	// Fake keeps machines in memory, it runs workflows without a cloud.
+	Fake Name = "fake"
 
 	Unknown Name = "unknown"
 )
 
-var versions = []string{"1.11.5", "1.12.7", "1.13.7", "1.14.3", "1.15.1"}
-
 func ToProvider(name string) (Name, error) {
 	switch name {
 	case string(AWS):
@@ -31,19 +31,12 @@ func ToProvider(name string) (Name, error) {
 		return GCE, nil
 	case string(OpenStack):
 		return OpenStack, nil
+	case string(Fake):
+		return Fake, nil
 	}
 	return Unknown, errors.New("invalid provider")
 }
 
-func GetVersions() []string {
-	if versions == nil {
-		return nil
-	}
-	c := make([]string, len(versions))
-	copy(c, versions)
-	return c
-}
-
 const (
 	OSUser = "supergiant"
 
@@ -84,6 +77,9 @@ const (
 	TagClusterID         = "supergiant.io/cluster-id"
 	TagNodeName          = "Name"
 	TagKubernetesCluster = "KubernetesCluster"
+	// versions of software that is installed on baked images
+	TagK8SVersion    = "supergiant.io/k8s-version"
+	TagDockerVersion = "supergiant.io/docker-version"
 
 	AWSAccessKeyID              = "access_key"
 	AWSSecretKey                = "secret_key"
@@ -7,6 +7,7 @@ import (
 	"github.com/supergiant/control/pkg/clouds"
 	"github.com/supergiant/control/pkg/model"
 	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/tracing"
 )
 
 type TokenSource struct {
@@ -50,5 +51,5 @@ func (s *SDK) GetClient() *godo.Client {
 		AccessToken: s.accessToken,
 	}
 	oauthClient := oauth2.NewClient(oauth2.NoContext, token)
-	return godo.NewClient(oauthClient)
+	return godo.NewClient(tracing.Client("digitalocean", oauthClient))
 }
@@ -0,0 +1,154 @@
+package fake
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Size of fake machines.
+type Size struct {
+	CPU int
+	// RAM in MiB
+	RAM int
+}
+
+// Regions and sizes of the fake cloud.
+var (
+	Regions = []string{"fake-east-1", "fake-west-1"}
+	Sizes   = map[string]Size{
+		"fake-small":  {CPU: 2, RAM: 4096},
+		"fake-medium": {CPU: 4, RAM: 8192},
+		"fake-large":  {CPU: 8, RAM: 16384},
+	}
+)
+
+// Instance is a machine of the fake cloud.
+type Instance struct {
+	ID        string
+	Name      string
+	KubeID    string
+	Region    string
+	Size      string
+	Master    bool
+	PublicIP  string
+	PrivateIP string
+	CreatedAt time.Time
+}
+
+// Cloud keeps instances and load balancers in memory. IDs and addresses are
+// assigned sequentially, so the same requests get the same resources.
+type Cloud struct {
+	m     sync.Mutex
+	seq   int
+	byKey map[string]*Instance
+	lbs   map[string]string
+}
+
+// Default is the cloud shared by fake accounts of the process.
+var Default = New()
+
+func New() *Cloud {
+	return &Cloud{
+		byKey: make(map[string]*Instance),
+		lbs:   make(map[string]string),
+	}
+}
+
+// Create starts an instance, the existing instance is returned when the
+// cluster already has one with the name.
+func (c *Cloud) Create(kubeID, name, region, size string, master bool) *Instance {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if i, ok := c.byKey[key(kubeID, name)]; ok {
+		copied := *i
+		return &copied
+	}
+
+	c.seq++
+	i := &Instance{
+		ID:     fmt.Sprintf("fake-%06d", c.seq),
+		Name:   name,
+		KubeID: kubeID,
+		Region: region,
+		Size:   size,
+		Master: master,
+		// 198.18.0.0/15 is reserved for benchmarking, it's never routed
+		PublicIP:  address("198.18", c.seq),
+		PrivateIP: address("10.0", c.seq),
+		CreatedAt: time.Now().UTC(),
+	}
+	c.byKey[key(kubeID, name)] = i
+
+	copied := *i
+	return &copied
+}
+
+// Delete removes the instance of the cluster and tells whether it existed.
+func (c *Cloud) Delete(kubeID, name string) bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	_, ok := c.byKey[key(kubeID, name)]
+	delete(c.byKey, key(kubeID, name))
+	return ok
+}
+
+// DeleteCluster removes instances and the load balancer of the cluster, it
+// returns the number of removed instances.
+func (c *Cloud) DeleteCluster(kubeID string) int {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	n := 0
+	for k, i := range c.byKey {
+		if i.KubeID == kubeID {
+			delete(c.byKey, k)
+			n++
+		}
+	}
+	delete(c.lbs, kubeID)
+	return n
+}
+
+// Instances returns instances of the cluster ordered by name.
+func (c *Cloud) Instances(kubeID string) []Instance {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	instances := make([]Instance, 0)
+	for _, i := range c.byKey {
+		if i.KubeID == kubeID {
+			instances = append(instances, *i)
+		}
+	}
+	sort.Slice(instances, func(a, b int) bool {
+		return instances[a].Name < instances[b].Name
+	})
+	return instances
+}
+
+// LoadBalancer returns the address of the cluster load balancer, it's
+// created on the first call.
+func (c *Cloud) LoadBalancer(kubeID string) string {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if ip, ok := c.lbs[kubeID]; ok {
+		return ip
+	}
+
+	c.seq++
+	c.lbs[kubeID] = address("198.19", c.seq)
+	return c.lbs[kubeID]
+}
+
+func key(kubeID, name string) string {
+	return kubeID + "/" + name
+}
+
+func address(prefix string, n int) string {
+	return fmt.Sprintf("%s.%d.%d", prefix, n/254%256, n%254+1)
+}
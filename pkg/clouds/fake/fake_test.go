@@ -0,0 +1,39 @@
+package fake
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloud(t *testing.T) {
+	c := New()
+
+	master := c.Create("kube", "master-1", "fake-east-1", "fake-small", true)
+	require.Equal(t, "fake-000001", master.ID)
+	require.Equal(t, "198.18.0.2", master.PublicIP)
+	require.Equal(t, "10.0.0.2", master.PrivateIP)
+	require.Equal(t, master.ID, c.Create("kube", "master-1", "fake-east-1", "fake-small", true).ID,
+		"the same machine must be returned")
+
+	node := c.Create("kube", "node-1", "fake-east-1", "fake-small", false)
+	require.Equal(t, "fake-000002", node.ID)
+	c.Create("other", "node-1", "fake-east-1", "fake-small", false)
+
+	lb := c.LoadBalancer("kube")
+	require.Equal(t, "198.19.0.5", lb)
+	require.Equal(t, lb, c.LoadBalancer("kube"))
+
+	instances := c.Instances("kube")
+	require.Len(t, instances, 2)
+	require.Equal(t, "master-1", instances[0].Name)
+
+	require.True(t, c.Delete("kube", "node-1"))
+	require.False(t, c.Delete("kube", "node-1"))
+	require.Len(t, c.Instances("kube"), 1)
+
+	require.Equal(t, 1, c.DeleteCluster("kube"))
+	require.Empty(t, c.Instances("kube"))
+	require.Len(t, c.Instances("other"), 1)
+	require.NotEqual(t, lb, c.LoadBalancer("kube"), "the load balancer must be deleted with the cluster")
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
 	"google.golang.org/api/compute/v1"
 
 	"google.golang.org/api/option"
 
+	"github.com/supergiant/control/pkg/tracing"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -18,7 +21,13 @@ func GetClient(ctx context.Context, config steps.GCEConfig) (*compute.Service, e
 		return nil, errors.Wrapf(err, "Error marshalling service account")
 	}
 
-	opts := option.WithCredentialsJSON(data)
+	creds, err := google.CredentialsFromJSON(ctx, data, compute.CloudPlatformScope, compute.ComputeScope)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error reading service account")
+	}
+
+	opts := option.WithHTTPClient(tracing.Client("gce", oauth2.NewClient(ctx, creds.TokenSource)))
 
 	computeService, err := compute.NewService(ctx, opts)
 
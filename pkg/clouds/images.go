@@ -0,0 +1,83 @@
+package clouds
+
+import (
+	"sort"
+)
+
+// canonicalOwner is the AWS account that publishes Ubuntu images.
+const canonicalOwner = "099720109477"
+
+// ImageFamily names a release of an operating system, it's resolved to the
+// latest image of the release when a machine is created.
+type ImageFamily struct {
+	// AWSOwner and AWSName filter AMIs, the newest match is used.
+	AWSOwner string
+	AWSName  string
+	// GCEProject keeps the GCEFamily, GCE returns its latest image.
+	GCEProject string
+	GCEFamily  string
+	// DigitalOceanSlug always points to the latest image of the release.
+	DigitalOceanSlug string
+}
+
+var imageFamilies = map[string]ImageFamily{
+	"ubuntu-16.04-lts": {
+		AWSOwner:         canonicalOwner,
+		AWSName:          "ubuntu/images/hvm-ssd/ubuntu-xenial-16.04-amd64-server-*",
+		GCEProject:       "ubuntu-os-cloud",
+		GCEFamily:        "ubuntu-1604-lts",
+		DigitalOceanSlug: "ubuntu-16-04-x64",
+	},
+	"ubuntu-18.04-lts": {
+		AWSOwner:         canonicalOwner,
+		AWSName:          "ubuntu/images/hvm-ssd/ubuntu-bionic-18.04-amd64-server-*",
+		GCEProject:       "ubuntu-os-cloud",
+		GCEFamily:        "ubuntu-1804-lts",
+		DigitalOceanSlug: "ubuntu-18-04-x64",
+	},
+	"ubuntu-20.04-lts": {
+		AWSOwner:         canonicalOwner,
+		AWSName:          "ubuntu/images/hvm-ssd/ubuntu-focal-20.04-amd64-server-*",
+		GCEProject:       "ubuntu-os-cloud",
+		GCEFamily:        "ubuntu-2004-lts",
+		DigitalOceanSlug: "ubuntu-20-04-x64",
+	},
+	"ubuntu-22.04-lts": {
+		AWSOwner:         canonicalOwner,
+		AWSName:          "ubuntu/images/hvm-ssd/ubuntu-jammy-22.04-amd64-server-*",
+		GCEProject:       "ubuntu-os-cloud",
+		GCEFamily:        "ubuntu-2204-lts",
+		DigitalOceanSlug: "ubuntu-22-04-x64",
+	},
+}
+
+// FindImageFamily returns the image family with the name.
+func FindImageFamily(name string) (ImageFamily, bool) {
+	f, ok := imageFamilies[name]
+	return f, ok
+}
+
+// ImageFamilies returns names of the known image families.
+func ImageFamilies() []string {
+	names := make([]string, 0, len(imageFamilies))
+	for name := range imageFamilies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Supports reports whether images of the family can be found on the provider.
+func (f ImageFamily) Supports(provider Name) bool {
+	switch provider {
+	case AWS:
+		return f.AWSName != ""
+	case GCE:
+		return f.GCEFamily != ""
+	case DigitalOcean:
+		return f.DigitalOceanSlug != ""
+	case Fake:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,18 @@
+package clouds
+
+// LoadBalancerAnnotations returns annotations of services of the LoadBalancer
+// type that suit the provider, network load balancers are used on AWS and
+// TCP ones on DigitalOcean. GCE needs none of them.
+func LoadBalancerAnnotations(provider Name) map[string]string {
+	switch provider {
+	case AWS:
+		return map[string]string{
+			"service.beta.kubernetes.io/aws-load-balancer-type": "nlb",
+		}
+	case DigitalOcean:
+		return map[string]string{
+			"service.beta.kubernetes.io/do-loadbalancer-protocol": "tcp",
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package clusterspec
+
+import (
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+)
+
+// Change is a node group with a number of machines that differs from the cluster.
+type Change struct {
+	Group   string              `json:"group"`
+	Role    model.Role          `json:"role"`
+	Size    string              `json:"size"`
+	Current int                 `json:"current"`
+	Desired int                 `json:"desired"`
+	Machine profile.NodeProfile `json:"-"`
+}
+
+// Diff compares node groups of the definition with the current ones,
+// groups are matched by role and machine size.
+func Diff(desired, current *Cluster) []Change {
+	type key struct {
+		role model.Role
+		size string
+	}
+
+	counts := make(map[key]int)
+	for _, g := range current.Spec.NodeGroups {
+		counts[key{g.Role, Size(g.Machine)}] += g.Count
+	}
+
+	var changes []Change
+	for _, g := range desired.Spec.NodeGroups {
+		k := key{g.Role, Size(g.Machine)}
+		if counts[k] != g.Count {
+			changes = append(changes, Change{
+				Group:   g.Name,
+				Role:    g.Role,
+				Size:    k.size,
+				Current: counts[k],
+				Desired: g.Count,
+				Machine: g.Machine,
+			})
+		}
+		delete(counts, k)
+	}
+
+	// groups that aren't defined anymore
+	for _, g := range current.Spec.NodeGroups {
+		k := key{g.Role, Size(g.Machine)}
+		if n, ok := counts[k]; ok {
+			changes = append(changes, Change{
+				Group:   g.Name,
+				Role:    g.Role,
+				Size:    k.size,
+				Current: n,
+			})
+			delete(counts, k)
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,360 @@
+// Package clusterspec is a declarative definition of a cluster that can be
+// kept in a repository and applied to control to create or reconcile it.
+package clusterspec
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+)
+
+const (
+	APIVersion = "control.supergiant.io/v1"
+	Kind       = "Cluster"
+)
+
+// Cluster is a definition of a cluster:
+//
+//	apiVersion: control.supergiant.io/v1
+//	kind: Cluster
+//	metadata:
+//	  name: prod
+//	spec:
+//	  account: aws-prod
+//	  profile:
+//	    provider: aws
+//	    region: us-east-1
+//	    K8SVersion: 1.14.1
+//	  nodeGroups:
+//	  - name: masters
+//	    role: master
+//	    count: 3
+//	    machine:
+//	      size: m4.large
+//	  - name: workers
+//	    role: node
+//	    count: 5
+//	    machine:
+//	      size: m4.xlarge
+//	  addons:
+//	  - dashboard
+type Cluster struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Metadata   Metadata `json:"metadata"`
+	Spec       Spec     `json:"spec"`
+}
+
+type Metadata struct {
+	Name string `json:"name"`
+	// ID is set for clusters exported from control.
+	ID string `json:"id,omitempty"`
+}
+
+type Spec struct {
+	// Account is a name of the cloud account the cluster is created with.
+	Account string `json:"account"`
+	// Profile holds cluster settings, machines are defined by node groups.
+	Profile    profile.Profile `json:"profile"`
+	NodeGroups []NodeGroup     `json:"nodeGroups"`
+	Addons     []string        `json:"addons,omitempty"`
+}
+
+// NodeGroup is a number of machines of the same role and machine profile.
+type NodeGroup struct {
+	Name    string              `json:"name"`
+	Role    model.Role          `json:"role"`
+	Count   int                 `json:"count"`
+	Machine profile.NodeProfile `json:"machine"`
+}
+
+// Parse reads a YAML or JSON definition of the cluster.
+func Parse(data []byte) (*Cluster, error) {
+	c := &Cluster{}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, errors.Wrap(err, "parse cluster spec")
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Validate checks the definition is complete.
+func (c *Cluster) Validate() error {
+	if c.APIVersion != APIVersion || c.Kind != Kind {
+		return errors.Errorf("unsupported spec %s %s, %s %s is expected",
+			c.APIVersion, c.Kind, APIVersion, Kind)
+	}
+	if c.Metadata.Name == "" {
+		return errors.New("cluster name is required")
+	}
+	if c.Spec.Account == "" {
+		return errors.New("cloud account is required")
+	}
+
+	names := make(map[string]bool, len(c.Spec.NodeGroups))
+	masters := 0
+	for _, g := range c.Spec.NodeGroups {
+		if g.Name == "" || names[g.Name] {
+			return errors.Errorf("node group name %q must be unique", g.Name)
+		}
+		names[g.Name] = true
+
+		if g.Role != model.RoleMaster && g.Role != model.RoleNode {
+			return errors.Errorf("node group %s: unknown role %q", g.Name, g.Role)
+		}
+		if g.Count < 0 {
+			return errors.Errorf("node group %s: count must not be negative", g.Name)
+		}
+		if g.Role == model.RoleMaster {
+			masters += g.Count
+		}
+	}
+	if masters == 0 {
+		return errors.New("at least one master is required")
+	}
+
+	return nil
+}
+
+// Marshal writes the definition as YAML, settings that aren't set are left out.
+func (c *Cluster) Marshal() ([]byte, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+	if err = json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(prune(v))
+}
+
+// Profile returns the cluster profile with machine profiles of node groups.
+func (c *Cluster) Profile() profile.Profile {
+	p := c.Spec.Profile
+	p.Addons = c.Spec.Addons
+	p.MasterProfiles = nil
+	p.NodesProfiles = nil
+
+	for _, g := range c.Spec.NodeGroups {
+		for i := 0; i < g.Count; i++ {
+			machine := make(profile.NodeProfile, len(g.Machine))
+			for k, v := range g.Machine {
+				machine[k] = v
+			}
+
+			if g.Role == model.RoleMaster {
+				p.MasterProfiles = append(p.MasterProfiles, machine)
+			} else {
+				p.NodesProfiles = append(p.NodesProfiles, machine)
+			}
+		}
+	}
+
+	return p
+}
+
+// FromKube describes the cluster, machines of the same role and size make
+// a node group with the machine profile they have been created from.
+func FromKube(k *model.Kube, p *profile.Profile) *Cluster {
+	spec := *p
+	// groups hold machine profiles, the rest is kept by control
+	spec.ID = ""
+	spec.MasterProfiles = nil
+	spec.NodesProfiles = nil
+	spec.Addons = nil
+	spec.StaticAuth = profile.StaticAuth{}
+	spec.User = ""
+	spec.Password = ""
+	spec.PublicKey = ""
+	spec.ExternalCA.Key = ""
+	spec.ExternalCA.Vault.Token = ""
+
+	c := &Cluster{
+		APIVersion: APIVersion,
+		Kind:       Kind,
+		Metadata: Metadata{
+			Name: k.Name,
+			ID:   k.ID,
+		},
+		Spec: Spec{
+			Account: k.AccountName,
+			Profile: spec,
+			Addons:  k.Addons,
+		},
+	}
+
+	machines := make([]*model.Machine, 0, len(k.Masters)+len(k.Nodes))
+	for _, m := range k.Masters {
+		machines = append(machines, m)
+	}
+	for _, m := range k.Nodes {
+		machines = append(machines, m)
+	}
+	if len(machines) == 0 {
+		c.Spec.NodeGroups = groupsOf(p)
+		return c
+	}
+
+	sort.Slice(machines, func(i, j int) bool {
+		return machines[i].Name < machines[j].Name
+	})
+
+	index := make(map[string]int)
+	for _, m := range machines {
+		role := m.Role
+		if role == "" {
+			_, isMaster := k.Masters[m.Name]
+			role = model.ToRole(isMaster)
+		}
+
+		key := string(role) + "/" + m.Size
+		if i, ok := index[key]; ok {
+			c.Spec.NodeGroups[i].Count++
+			continue
+		}
+
+		index[key] = len(c.Spec.NodeGroups)
+		c.Spec.NodeGroups = append(c.Spec.NodeGroups, NodeGroup{
+			Role:    role,
+			Count:   1,
+			Machine: machineProfile(p, role, m.Size),
+		})
+	}
+
+	sort.SliceStable(c.Spec.NodeGroups, func(i, j int) bool {
+		return c.Spec.NodeGroups[i].Role == model.RoleMaster && c.Spec.NodeGroups[j].Role != model.RoleMaster
+	})
+	nameGroups(c.Spec.NodeGroups)
+
+	return c
+}
+
+// groupsOf makes node groups of machine profiles of a cluster that hasn't got machines yet.
+func groupsOf(p *profile.Profile) []NodeGroup {
+	var groups []NodeGroup
+	for _, roleProfiles := range []struct {
+		role     model.Role
+		profiles []profile.NodeProfile
+	}{
+		{model.RoleMaster, p.MasterProfiles},
+		{model.RoleNode, p.NodesProfiles},
+	} {
+	profiles:
+		for _, np := range roleProfiles.profiles {
+			for i := range groups {
+				if groups[i].Role == roleProfiles.role && reflect.DeepEqual(groups[i].Machine, np) {
+					groups[i].Count++
+					continue profiles
+				}
+			}
+			groups = append(groups, NodeGroup{
+				Role:    roleProfiles.role,
+				Count:   1,
+				Machine: np,
+			})
+		}
+	}
+	nameGroups(groups)
+
+	return groups
+}
+
+func nameGroups(groups []NodeGroup) {
+	counts := make(map[model.Role]int)
+	for _, g := range groups {
+		counts[g.Role]++
+	}
+
+	seen := make(map[model.Role]int)
+	for i := range groups {
+		role := groups[i].Role
+		seen[role]++
+
+		name := "masters"
+		if role == model.RoleNode {
+			name = "workers"
+		}
+		if counts[role] > 1 {
+			name = fmt.Sprintf("%s-%d", name, seen[role])
+		}
+		groups[i].Name = name
+	}
+}
+
+// machineProfile returns a machine profile of the role with the size.
+func machineProfile(p *profile.Profile, role model.Role, size string) profile.NodeProfile {
+	profiles := p.NodesProfiles
+	if role == model.RoleMaster {
+		profiles = p.MasterProfiles
+	}
+
+	for _, np := range profiles {
+		if Size(np) == size {
+			return np
+		}
+	}
+
+	return profile.NodeProfile{"size": size}
+}
+
+// Size returns a machine size of the machine profile.
+func Size(np profile.NodeProfile) string {
+	if size := np["size"]; size != "" {
+		return size
+	}
+
+	return np["vmSize"]
+}
+
+// prune removes empty values from decoded JSON.
+func prune(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			if value = prune(value); isEmpty(value) {
+				delete(v, k)
+				continue
+			}
+			v[k] = value
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = prune(v[i])
+		}
+	}
+
+	return v
+}
+
+func isEmpty(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+
+	return false
+}
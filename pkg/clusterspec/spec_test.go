@@ -0,0 +1,124 @@
+package clusterspec
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+)
+
+const testSpec = `
+apiVersion: control.supergiant.io/v1
+kind: Cluster
+metadata:
+  name: prod
+spec:
+  account: aws-prod
+  profile:
+    provider: aws
+    region: us-east-1
+    K8SVersion: 1.14.1
+  nodeGroups:
+  - name: masters
+    role: master
+    count: 1
+    machine:
+      size: m4.large
+  - name: workers
+    role: node
+    count: 2
+    machine:
+      size: m4.xlarge
+  addons:
+  - dashboard
+`
+
+func TestParse(t *testing.T) {
+	c, err := Parse([]byte(testSpec))
+	require.NoError(t, err)
+
+	p := c.Profile()
+	require.Equal(t, clouds.AWS, p.Provider)
+	require.Equal(t, "1.14.1", p.K8SVersion)
+	require.Len(t, p.MasterProfiles, 1)
+	require.Len(t, p.NodesProfiles, 2)
+	require.Equal(t, "m4.xlarge", p.NodesProfiles[1]["size"])
+	require.Equal(t, []string{"dashboard"}, p.Addons)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		spec string
+	}{
+		{"kind", "apiVersion: v1\nkind: Pod"},
+		{"name", "apiVersion: control.supergiant.io/v1\nkind: Cluster\nspec:\n  account: a"},
+		{"masters", "apiVersion: control.supergiant.io/v1\nkind: Cluster\nmetadata:\n  name: a\nspec:\n  account: a"},
+		{"role", strings.Replace(testSpec, "role: node", "role: worker", 1)},
+	} {
+		_, err := Parse([]byte(tc.spec))
+		require.Error(t, err, tc.name)
+	}
+}
+
+func TestFromKube(t *testing.T) {
+	p := &profile.Profile{
+		ID:             "profile-1",
+		Provider:       clouds.AWS,
+		Password:       "secret",
+		MasterProfiles: []profile.NodeProfile{{"size": "m4.large", "image": "ami-1"}},
+		NodesProfiles:  []profile.NodeProfile{{"size": "m4.xlarge"}},
+	}
+	k := &model.Kube{
+		ID:          "kube-1",
+		Name:        "prod",
+		AccountName: "aws-prod",
+		Masters: map[string]*model.Machine{
+			"master-1": {Name: "master-1", Role: model.RoleMaster, Size: "m4.large"},
+		},
+		Nodes: map[string]*model.Machine{
+			"node-1": {Name: "node-1", Role: model.RoleNode, Size: "m4.xlarge"},
+			"node-2": {Name: "node-2", Role: model.RoleNode, Size: "m4.xlarge"},
+			"node-3": {Name: "node-3", Role: model.RoleNode, Size: "m4.2xlarge"},
+		},
+	}
+
+	c := FromKube(k, p)
+	require.Equal(t, []NodeGroup{
+		{Name: "masters", Role: model.RoleMaster, Count: 1, Machine: profile.NodeProfile{"size": "m4.large", "image": "ami-1"}},
+		{Name: "workers-1", Role: model.RoleNode, Count: 2, Machine: profile.NodeProfile{"size": "m4.xlarge"}},
+		{Name: "workers-2", Role: model.RoleNode, Count: 1, Machine: profile.NodeProfile{"size": "m4.2xlarge"}},
+	}, c.Spec.NodeGroups)
+
+	data, err := c.Marshal()
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "secret")
+	require.NotContains(t, string(data), "profile-1")
+	require.NotContains(t, string(data), "dockerVersion")
+
+	parsed, err := Parse(data)
+	require.NoError(t, err)
+	require.Equal(t, c.Spec.NodeGroups, parsed.Spec.NodeGroups)
+	require.Equal(t, "kube-1", parsed.Metadata.ID)
+}
+
+func TestDiff(t *testing.T) {
+	current := &Cluster{Spec: Spec{NodeGroups: []NodeGroup{
+		{Name: "masters", Role: model.RoleMaster, Count: 1, Machine: profile.NodeProfile{"size": "m4.large"}},
+		{Name: "workers-1", Role: model.RoleNode, Count: 2, Machine: profile.NodeProfile{"size": "m4.xlarge"}},
+		{Name: "workers-2", Role: model.RoleNode, Count: 1, Machine: profile.NodeProfile{"size": "m4.2xlarge"}},
+	}}}
+	desired := &Cluster{Spec: Spec{NodeGroups: []NodeGroup{
+		{Name: "masters", Role: model.RoleMaster, Count: 1, Machine: profile.NodeProfile{"size": "m4.large"}},
+		{Name: "workers", Role: model.RoleNode, Count: 4, Machine: profile.NodeProfile{"size": "m4.xlarge"}},
+	}}}
+
+	require.Equal(t, []Change{
+		{Group: "workers", Role: model.RoleNode, Size: "m4.xlarge", Current: 2, Desired: 4, Machine: profile.NodeProfile{"size": "m4.xlarge"}},
+		{Group: "workers-2", Role: model.RoleNode, Size: "m4.2xlarge", Current: 1},
+	}, Diff(desired, current))
+}
@@ -0,0 +1,258 @@
+package controlplane
+
+import (
+	"net/http"
+
+	"k8s.io/helm/pkg/repo"
+
+	"github.com/supergiant/control/pkg/account"
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/catalog"
+	"github.com/supergiant/control/pkg/fleet"
+	"github.com/supergiant/control/pkg/images"
+	"github.com/supergiant/control/pkg/kube"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/openapi"
+	"github.com/supergiant/control/pkg/pki"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/provisioner"
+	"github.com/supergiant/control/pkg/queue"
+	"github.com/supergiant/control/pkg/revision"
+	"github.com/supergiant/control/pkg/settings"
+	"github.com/supergiant/control/pkg/snapshot"
+	"github.com/supergiant/control/pkg/timeline"
+	"github.com/supergiant/control/pkg/user"
+	"github.com/supergiant/control/pkg/versions"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const apiPrefix = "/v1/api"
+
+// listParams are query parameters of list endpoints, next pages are
+// requested with the X-Continue header of responses.
+var listParams = []openapi.Parameter{
+	{Name: "limit", Description: "Maximum number of items", Schema: &openapi.Schema{Type: "integer"}},
+	{Name: "continue", Description: "Token of the next page", Schema: &openapi.Schema{Type: "string"}},
+	{Name: "sort", Description: "Fields to sort by, e.g. name,-state", Schema: &openapi.Schema{Type: "string"}},
+	{Name: "fieldSelector", Description: "Field values, e.g. state=operational,cloudSpec.region=fra1", Schema: &openapi.Schema{Type: "string"}},
+	{Name: "labelSelector", Description: "Label values, e.g. env=prod", Schema: &openapi.Schema{Type: "string"}},
+}
+
+var eventParams = []openapi.Parameter{
+	{Name: "from", Description: "RFC3339 time of the earliest event", Schema: &openapi.Schema{Type: "string"}},
+	{Name: "to", Description: "RFC3339 time of the latest event", Schema: &openapi.Schema{Type: "string"}},
+	{Name: "source", Description: "Source of events, control or kubernetes", Schema: &openapi.Schema{Type: "string"}},
+}
+
+var resolveParams = []openapi.Parameter{
+	{Name: "var", Description: "Variable of the profile, e.g. env=prod, may be repeated", Schema: &openapi.Schema{Type: "string"}},
+}
+
+var diffParams = []openapi.Parameter{
+	{Name: "from", Description: "Number of the older revision, the one before to by default", Schema: &openapi.Schema{Type: "integer"}},
+	{Name: "to", Description: "Number of the newer revision, the latest by default", Schema: &openapi.Schema{Type: "integer"}},
+}
+
+var statusPageParams = []openapi.Parameter{
+	{Name: "token", Description: "Token of the status page, it may be a bearer token instead", Schema: &openapi.Schema{Type: "string"}},
+}
+
+var refreshParams = []openapi.Parameter{
+	{Name: "refresh", Description: "Run the check instead of returning the last report", Schema: &openapi.Schema{Type: "boolean"}},
+}
+
+var capacityPlanParams = []openapi.Parameter{
+	{Name: "labelSelector", Description: "Labels of clusters, e.g. env=prod", Schema: &openapi.Schema{Type: "string"}},
+	{Name: "format", Description: "json or csv, json by default", Schema: &openapi.Schema{Type: "string"}},
+}
+
+var deleteParams = []openapi.Parameter{
+	{Name: "mode", Description: "graceful releases load balancers and volumes of workloads first, force deletes the infrastructure right away", Schema: &openapi.Schema{Type: "string"}},
+	{Name: "stepTimeout", Description: "Timeout of graceful steps, e.g. 5m, the deletion is forced when it's exceeded", Schema: &openapi.Schema{Type: "string"}},
+	{Name: "force", Description: "Remove the kube even if the deletion fails", Schema: &openapi.Schema{Type: "boolean"}},
+}
+
+var versionDriftParams = []openapi.Parameter{
+	{Name: "labelSelector", Description: "Labels of clusters, e.g. env=prod", Schema: &openapi.Schema{Type: "string"}},
+}
+
+var upgradeParams = []openapi.Parameter{
+	{Name: "strategy", Description: "inPlace upgrades masters one by one, blueGreen replaces them with new masters of the next version", Schema: &openapi.Schema{Type: "string"}},
+}
+
+var imageParams = []openapi.Parameter{
+	{Name: "provider", Description: "Provider of images, e.g. aws", Schema: &openapi.Schema{Type: "string"}},
+	{Name: "region", Description: "Region of images, e.g. us-east-1", Schema: &openapi.Schema{Type: "string"}},
+}
+
+// apiOperations are documented operations of the REST API, routes that
+// aren't described here are in the specification without models.
+var apiOperations = []struct {
+	method string
+	path   string
+	doc    openapi.Doc
+}{
+	{http.MethodPost, "/auth", openapi.Doc{Summary: "Issue a token", Tags: []string{"users"}, Request: user.AuthRequest{}}},
+	{http.MethodPost, "/root", openapi.Doc{Summary: "Register the root user", Tags: []string{"users"}, Request: user.User{}}},
+	{http.MethodGet, "/status/{kubeID}", openapi.Doc{Summary: "Get the status page of a cluster", Tags: []string{"status"}, Query: statusPageParams, Response: kube.ClusterStatus{}}},
+	{http.MethodPost, "/auth/totp", openapi.Doc{Summary: "Enroll a second factor with the password", Tags: []string{"users"}, Request: user.AuthRequest{}, Response: user.Enrollment{}}},
+	{http.MethodPost, apiPrefix + "/users", openapi.Doc{Summary: "Create a user", Request: user.User{}}},
+	{http.MethodGet, apiPrefix + "/users", openapi.Doc{Summary: "List users", Query: listParams, Response: []user.User{}}},
+	{http.MethodGet, apiPrefix + "/users/{login}/totp", openapi.Doc{Summary: "Get the second factor status", Response: user.TwoFactorStatus{}}},
+	{http.MethodPost, apiPrefix + "/users/{login}/totp", openapi.Doc{Summary: "Enroll a second factor", Response: user.Enrollment{}}},
+	{http.MethodPut, apiPrefix + "/users/{login}/totp", openapi.Doc{Summary: "Confirm the second factor", Request: user.CodeRequest{}, Response: user.TwoFactorStatus{}}},
+	{http.MethodDelete, apiPrefix + "/users/{login}/totp", openapi.Doc{Summary: "Disable the second factor", Request: user.CodeRequest{}}},
+	{http.MethodPost, apiPrefix + "/users/{login}/totp/reset", openapi.Doc{Summary: "Reset the second factor of a user"}},
+	{http.MethodPost, "/auth/password", openapi.Doc{Summary: "Change the password", Tags: []string{"users"}, Request: user.PasswordRequest{}}},
+	{http.MethodGet, apiPrefix + "/sessions", openapi.Doc{Summary: "List sessions of all users", Query: listParams, Response: []user.Session{}}},
+	{http.MethodGet, apiPrefix + "/users/{login}/sessions", openapi.Doc{Summary: "List sessions of a user", Query: listParams, Response: []user.Session{}}},
+	{http.MethodDelete, apiPrefix + "/users/{login}/sessions", openapi.Doc{Summary: "Revoke sessions of a user"}},
+	{http.MethodDelete, apiPrefix + "/users/{login}/sessions/{id}", openapi.Doc{Summary: "Revoke a session"}},
+	{http.MethodGet, apiPrefix + "/teams", openapi.Doc{Summary: "List teams", Query: listParams, Response: []user.Team{}}},
+	{http.MethodPost, apiPrefix + "/teams", openapi.Doc{Summary: "Create a team", Request: user.Team{}, Response: user.Team{}}},
+	{http.MethodGet, apiPrefix + "/teams/{name}", openapi.Doc{Summary: "Get a team", Response: user.Team{}}},
+	{http.MethodPut, apiPrefix + "/teams/{name}", openapi.Doc{Summary: "Update a team", Request: user.Team{}, Response: user.Team{}}},
+	{http.MethodDelete, apiPrefix + "/teams/{name}", openapi.Doc{Summary: "Delete a team"}},
+
+	{http.MethodGet, apiPrefix + "/accounts", openapi.Doc{Summary: "List cloud accounts", Query: listParams, Response: []model.CloudAccount{}}},
+	{http.MethodPost, apiPrefix + "/accounts", openapi.Doc{Summary: "Create a cloud account", Request: model.CloudAccount{}}},
+	{http.MethodGet, apiPrefix + "/accounts/{accountName}", openapi.Doc{Summary: "Get a cloud account", Response: model.CloudAccount{}}},
+	{http.MethodPut, apiPrefix + "/accounts/{accountName}", openapi.Doc{Summary: "Update a cloud account", Request: model.CloudAccount{}}},
+	{http.MethodDelete, apiPrefix + "/accounts/{accountName}", openapi.Doc{Summary: "Delete a cloud account"}},
+	{http.MethodPut, apiPrefix + "/accounts/{accountName}/team", openapi.Doc{Summary: "Move a cloud account to a team", Request: api.TeamRequest{}, Response: api.TeamRequest{}}},
+	{http.MethodPut, apiPrefix + "/accounts/{accountName}/labels", openapi.Doc{Summary: "Set labels of a cloud account", Request: api.LabelsRequest{}, Response: api.LabelsRequest{}}},
+	{http.MethodGet, apiPrefix + "/accounts/{accountName}/regions", openapi.Doc{Summary: "List regions and machine sizes", Response: account.RegionSizes{}}},
+	{http.MethodGet, apiPrefix + "/accounts/{accountName}/regions/{region}/az", openapi.Doc{Summary: "List availability zones", Response: []string{}}},
+	{http.MethodGet, apiPrefix + "/accounts/{accountName}/regions/{region}/az/{az}/types", openapi.Doc{Summary: "List machine types", Response: []string{}}},
+
+	{http.MethodGet, apiPrefix + "/kubeprofiles", openapi.Doc{Summary: "List kube profiles", Query: listParams, Response: []profile.Profile{}}},
+	{http.MethodPost, apiPrefix + "/kubeprofiles", openapi.Doc{Summary: "Create a kube profile", Request: profile.Profile{}}},
+	{http.MethodGet, apiPrefix + "/kubeprofiles/{id}", openapi.Doc{Summary: "Get a kube profile", Response: profile.Profile{}}},
+	{http.MethodGet, apiPrefix + "/kubeprofiles/{id}/resolved", openapi.Doc{Summary: "Get a kube profile with its bases and variables resolved", Query: resolveParams, Response: profile.Profile{}}},
+	{http.MethodPut, apiPrefix + "/kubeprofiles/{id}/team", openapi.Doc{Summary: "Move a kube profile to a team", Request: api.TeamRequest{}, Response: api.TeamRequest{}}},
+	{http.MethodPut, apiPrefix + "/kubeprofiles/{id}/labels", openapi.Doc{Summary: "Set labels of a kube profile", Request: api.LabelsRequest{}, Response: api.LabelsRequest{}}},
+	{http.MethodGet, apiPrefix + "/kubeprofiles/{id}/revisions", openapi.Doc{Summary: "List revisions of a kube profile", Response: []revision.Revision{}}},
+	{http.MethodGet, apiPrefix + "/kubeprofiles/{id}/revisions/{number}", openapi.Doc{Summary: "Get a revision of a kube profile", Response: revision.Revision{}}},
+	{http.MethodPost, apiPrefix + "/kubeprofiles/{id}/revisions/{number}/revert", openapi.Doc{Summary: "Revert a kube profile to a revision", Response: profile.Profile{}}},
+	{http.MethodGet, apiPrefix + "/kubeprofiles/{id}/diff", openapi.Doc{Summary: "Compare revisions of a kube profile", Query: diffParams, Response: revision.Diff{}}},
+
+	{http.MethodGet, apiPrefix + "/images", openapi.Doc{Summary: "List baked images", Query: imageParams, Response: []images.Image{}}},
+	{http.MethodGet, apiPrefix + "/images/{id}", openapi.Doc{Summary: "Get a baked image", Response: images.Image{}}},
+	{http.MethodPost, apiPrefix + "/kubes/{kubeID}/images", openapi.Doc{Summary: "Bake an image with the software of the kube nodes", Request: kube.BakeRequest{}, Response: images.Image{}}},
+
+	{http.MethodPost, apiPrefix + "/provision", openapi.Doc{Summary: "Provision a kube", Tags: []string{"kubes"}, Request: provisioner.ProvisionRequest{}, Response: provisioner.ProvisionResponse{}}},
+
+	{http.MethodGet, apiPrefix + "/kubes", openapi.Doc{Summary: "List kubes", Query: listParams, Response: []model.Kube{}}},
+	{http.MethodPost, apiPrefix + "/kubes", openapi.Doc{Summary: "Create a kube record", Request: model.Kube{}}},
+	{http.MethodGet, apiPrefix + "/kubes/capacity/plan", openapi.Doc{Summary: "Get requested and allocatable resources of clusters and node groups", Query: capacityPlanParams, Response: kube.CapacityPlanReport{}}},
+	{http.MethodGet, apiPrefix + "/kubes/versions/drift", openapi.Doc{Summary: "Compare versions of node components and addons of clusters against the catalog", Query: versionDriftParams, Response: kube.VersionDriftReport{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}", openapi.Doc{Summary: "Get a kube", Response: model.Kube{}}},
+	{http.MethodDelete, apiPrefix + "/kubes/{kubeID}", openapi.Doc{Summary: "Delete a kube", Query: deleteParams}},
+	{http.MethodPut, apiPrefix + "/kubes/{kubeID}/team", openapi.Doc{Summary: "Move a kube to a team", Request: api.TeamRequest{}, Response: api.TeamRequest{}}},
+	{http.MethodPut, apiPrefix + "/kubes/{kubeID}/labels", openapi.Doc{Summary: "Set labels of a kube", Request: api.LabelsRequest{}, Response: api.LabelsRequest{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/tasks", openapi.Doc{Summary: "List tasks of a kube", Query: listParams}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/nodes", openapi.Doc{Summary: "List nodes", Query: listParams}},
+	{http.MethodPost, apiPrefix + "/kubes/{kubeID}/machines", openapi.Doc{Summary: "Add machines", Request: []profile.NodeProfile{}, Response: []string{}}},
+	{http.MethodPost, apiPrefix + "/kubes/{kubeID}/nodes", openapi.Doc{Summary: "Add machines", Request: []profile.NodeProfile{}, Response: []string{}}},
+	{http.MethodPost, apiPrefix + "/kubes/{kubeID}/spot", openapi.Doc{Summary: "Add spot machines", Request: kube.SpotRequest{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/certs/{cname}", openapi.Doc{Summary: "Get certificates", Response: kube.Bundle{}}},
+	{http.MethodPost, apiPrefix + "/kubes/{kubeID}/releases", openapi.Doc{Summary: "Install a helm release", Request: steps.InstallAppConfig{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/releases", openapi.Doc{Summary: "List helm releases", Response: []model.ReleaseInfo{}}},
+	{http.MethodDelete, apiPrefix + "/kubes/{kubeID}/releases/{releaseName}", openapi.Doc{Summary: "Delete a helm release", Response: model.ReleaseInfo{}}},
+	{http.MethodGet, apiPrefix + "/catalog", openapi.Doc{Summary: "List apps of the catalog", Response: []catalog.App{}}},
+	{http.MethodGet, apiPrefix + "/catalog/{app}", openapi.Doc{Summary: "Get an app of the catalog", Response: catalog.Details{}}},
+	{http.MethodPost, apiPrefix + "/kubes/{kubeID}/catalog/{app}", openapi.Doc{Summary: "Install an app of the catalog", Request: catalog.InstallRequest{}}},
+	{http.MethodGet, apiPrefix + "/versions", openapi.Doc{Summary: "Get the matrix of supported kubernetes versions", Response: versions.Matrix{}}},
+	{http.MethodGet, apiPrefix + "/versions/{version}", openapi.Doc{Summary: "Get components of a kubernetes version", Response: versions.Release{}}},
+	{http.MethodPost, apiPrefix + "/fleet/actions", openapi.Doc{Summary: "Run an action across clusters", Request: fleet.Request{}, Response: fleet.Run{}}},
+	{http.MethodGet, apiPrefix + "/fleet/actions", openapi.Doc{Summary: "List runs of fleet actions", Response: []fleet.Run{}}},
+	{http.MethodGet, apiPrefix + "/fleet/actions/{id}", openapi.Doc{Summary: "Get a run of a fleet action with results of clusters", Response: fleet.Run{}}},
+	{http.MethodPost, apiPrefix + "/kubes/{kubeID}/releases/{releaseName}/preview", openapi.Doc{Summary: "Preview a helm release upgrade", Request: kube.ReleaseUpgradeInput{}, Response: kube.ReleaseDiff{}}},
+	{http.MethodPatch, apiPrefix + "/kubes/{kubeID}", openapi.Doc{Summary: "Upgrade a kube to the next version", Query: upgradeParams}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/upgrade/preflight", openapi.Doc{Summary: "Scan for APIs removed by the upgrade", Response: kube.DeprecationReport{}}},
+	{http.MethodPut, apiPrefix + "/kubes/{kubeID}/backup", openapi.Doc{Summary: "Install Velero for backups", Request: steps.VeleroConfig{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/backup", openapi.Doc{Summary: "Get backup settings", Response: model.Backup{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/backups", openapi.Doc{Summary: "List backups", Response: []kube.BackupInfo{}}},
+	{http.MethodPost, apiPrefix + "/kubes/{kubeID}/backups", openapi.Doc{Summary: "Create a backup", Request: kube.BackupInput{}, Response: kube.BackupInfo{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/backups/schedules", openapi.Doc{Summary: "List backup schedules", Response: []kube.ScheduleInfo{}}},
+	{http.MethodPost, apiPrefix + "/kubes/{kubeID}/backups/schedules", openapi.Doc{Summary: "Create a backup schedule", Request: kube.ScheduleInput{}, Response: kube.ScheduleInfo{}}},
+	{http.MethodDelete, apiPrefix + "/kubes/{kubeID}/backups/schedules/{name}", openapi.Doc{Summary: "Delete a backup schedule"}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/restores", openapi.Doc{Summary: "List restores", Response: []kube.RestoreInfo{}}},
+	{http.MethodPost, apiPrefix + "/kubes/{kubeID}/restores", openapi.Doc{Summary: "Restore a backup", Request: kube.RestoreInput{}, Response: kube.RestoreInfo{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/bootstraptokens", openapi.Doc{Summary: "List outstanding bootstrap tokens", Response: []kube.BootstrapTokenInfo{}}},
+	{http.MethodDelete, apiPrefix + "/kubes/{kubeID}/bootstraptokens/{tokenID}", openapi.Doc{Summary: "Revoke a bootstrap token"}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/services", openapi.Doc{Summary: "List exposed services", Response: []kube.ServiceInfo{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/budget", openapi.Doc{Summary: "Get the budget", Response: kube.BudgetStatus{}}},
+	{http.MethodPut, apiPrefix + "/kubes/{kubeID}/budget", openapi.Doc{Summary: "Set the budget", Request: model.Budget{}, Response: model.Budget{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/spec/revisions", openapi.Doc{Summary: "List applied specs", Response: []revision.Revision{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/spec/revisions/{number}", openapi.Doc{Summary: "Get an applied spec", Response: revision.Revision{}}},
+	{http.MethodPost, apiPrefix + "/kubes/{kubeID}/spec/revisions/{number}/revert", openapi.Doc{Summary: "Apply a previous spec again", Response: kube.SpecResult{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/spec/diff", openapi.Doc{Summary: "Compare applied specs", Query: diffParams, Response: revision.Diff{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/drift", openapi.Doc{Summary: "Get the drift report", Response: kube.DriftReport{}}},
+	{http.MethodPut, apiPrefix + "/kubes/{kubeID}/drift", openapi.Doc{Summary: "Set the drift policy", Request: model.DriftPolicy{}, Response: model.DriftPolicy{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/firewall", openapi.Doc{Summary: "List ingress rules of security groups", Response: kube.FirewallRules{}}},
+	{http.MethodPost, apiPrefix + "/kubes/{kubeID}/firewall", openapi.Doc{Summary: "Add an ingress rule to a security group", Request: model.FirewallRule{}, Response: model.FirewallRule{}}},
+	{http.MethodDelete, apiPrefix + "/kubes/{kubeID}/firewall/{name}", openapi.Doc{Summary: "Remove an ingress rule from a security group"}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/inventory", openapi.Doc{Summary: "Get machines that control, the cloud and kubernetes disagree on", Query: refreshParams, Response: kube.InventoryReport{}}},
+	{http.MethodPost, apiPrefix + "/kubes/{kubeID}/inventory/{name}/{action}", openapi.Doc{Summary: "Adopt or clean up a machine of the inventory report", Response: kube.InventoryItem{}}},
+	{http.MethodPost, apiPrefix + "/kubes/{kubeID}/endpoint/dns", openapi.Doc{Summary: "Point a DNS name of the API server certificate to the API endpoint", Request: kube.EndpointName{}, Response: []string{}}},
+	{http.MethodDelete, apiPrefix + "/kubes/{kubeID}/endpoint/dns/{name}", openapi.Doc{Summary: "Delete the DNS record of the API endpoint"}},
+	{http.MethodPost, apiPrefix + "/kubes/{kubeID}/statuspage", openapi.Doc{Summary: "Enable the status page with a new token", Response: kube.StatusPageToken{}}},
+	{http.MethodDelete, apiPrefix + "/kubes/{kubeID}/statuspage", openapi.Doc{Summary: "Disable the status page"}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/machines/{nodename}/console", openapi.Doc{Summary: "Get the console output of a machine from its cloud provider, it's attached to diagnostics of the failed task of the machine", Response: kube.ConsoleOutput{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/health", openapi.Doc{Summary: "Get problem conditions of nodes and repairs they call for", Response: kube.KubeHealth{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/compliance", openapi.Doc{Summary: "Get the compliance report", Response: kube.ComplianceReport{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/ssh/audit", openapi.Doc{Summary: "Get ssh keys authorized on machines", Response: kube.SSHKeyAudit{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/ssh/operatorkeys", openapi.Doc{Summary: "List operator ssh keys", Response: []model.OperatorKey{}}},
+	{http.MethodPost, apiPrefix + "/kubes/{kubeID}/ssh/operatorkeys", openapi.Doc{Summary: "Authorize an operator ssh key on machines", Request: kube.OperatorKeyRequest{}, Response: kube.OperatorKeyTasks{}}},
+	{http.MethodDelete, apiPrefix + "/kubes/{kubeID}/ssh/operatorkeys/{name}", openapi.Doc{Summary: "Revoke an operator ssh key on machines", Response: kube.OperatorKeyTasks{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/ospatch", openapi.Doc{Summary: "Get OS patching settings", Response: model.OSPatch{}}},
+	{http.MethodPut, apiPrefix + "/kubes/{kubeID}/ospatch", openapi.Doc{Summary: "Set OS patching settings", Request: model.OSPatch{}, Response: model.OSPatch{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/maintenance", openapi.Doc{Summary: "Get the maintenance window", Response: model.MaintenanceWindow{}}},
+	{http.MethodPut, apiPrefix + "/kubes/{kubeID}/maintenance", openapi.Doc{Summary: "Set the maintenance window", Request: model.MaintenanceWindow{}, Response: model.MaintenanceWindow{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/channel", openapi.Doc{Summary: "Get the release channel subscription", Response: model.ReleaseChannel{}}},
+	{http.MethodPut, apiPrefix + "/kubes/{kubeID}/channel", openapi.Doc{Summary: "Subscribe to a release channel or pause its upgrades", Request: model.ReleaseChannel{}, Response: model.ReleaseChannel{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/etcd/maintenance", openapi.Doc{Summary: "Get etcd maintenance settings", Response: model.EtcdMaintenance{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/schedules", openapi.Doc{Summary: "List scheduled workflows with their last runs", Response: []model.ScheduledTask{}}},
+	{http.MethodPost, apiPrefix + "/kubes/{kubeID}/schedules", openapi.Doc{Summary: "Schedule a workflow on a cron expression", Request: model.ScheduledTask{}, Response: model.ScheduledTask{}}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/schedules/{name}", openapi.Doc{Summary: "Get a scheduled workflow with its last run", Response: model.ScheduledTask{}}},
+	{http.MethodPut, apiPrefix + "/kubes/{kubeID}/schedules/{name}", openapi.Doc{Summary: "Change a scheduled workflow", Request: model.ScheduledTask{}, Response: model.ScheduledTask{}}},
+	{http.MethodDelete, apiPrefix + "/kubes/{kubeID}/schedules/{name}", openapi.Doc{Summary: "Delete a scheduled workflow"}},
+	{http.MethodGet, apiPrefix + "/kubes/{kubeID}/events", openapi.Doc{Summary: "Get the cluster event timeline", Query: eventParams, Response: []timeline.Event{}}},
+	{http.MethodPut, apiPrefix + "/kubes/{kubeID}/etcd/maintenance", openapi.Doc{Summary: "Set etcd maintenance settings", Request: model.EtcdMaintenance{}, Response: model.EtcdMaintenance{}}},
+
+	{http.MethodGet, apiPrefix + "/tasks/{id}", openapi.Doc{Summary: "Get a task", Response: workflows.Task{}}},
+	{http.MethodPost, apiPrefix + "/tasks/{id}/restart", openapi.Doc{Summary: "Restart a task", Response: workflows.TaskResponse{}}},
+
+	{http.MethodPost, apiPrefix + "/helm/repositories", openapi.Doc{Summary: "Add a helm repository", Request: repo.Entry{}}},
+	{http.MethodPost, apiPrefix + "/pki/csr", openapi.Doc{Summary: "Create a certificate signing request", Response: pki.CSR{}}},
+	{http.MethodGet, apiPrefix + "/pki/csr/{id}", openapi.Doc{Summary: "Get a certificate signing request", Response: pki.CSR{}}},
+
+	{http.MethodGet, apiPrefix + "/admin/settings", openapi.Doc{Summary: "Get runtime settings", Response: settings.Settings{}}},
+	{http.MethodPut, apiPrefix + "/admin/settings", openapi.Doc{Summary: "Update runtime settings", Request: settings.Settings{}, Response: settings.Settings{}}},
+	{http.MethodPut, apiPrefix + "/admin/features/{name}", openapi.Doc{Summary: "Turn a feature on or off", Request: settings.FeatureRequest{}}},
+	{http.MethodPost, apiPrefix + "/admin/snapshot", openapi.Doc{Summary: "Export the state of control, secrets are sealed with the passphrase", Request: snapshot.ExportRequest{}, Response: snapshot.Snapshot{}}},
+	{http.MethodPost, apiPrefix + "/admin/snapshot/restore", openapi.Doc{Summary: "Restore a snapshot into a fresh install", Request: snapshot.RestoreRequest{}, Response: snapshot.RestoreResponse{}}},
+	{http.MethodGet, apiPrefix + "/admin/queue", openapi.Doc{Summary: "List queued provisioning jobs with counts by state", Response: queue.Stats{}}},
+	{http.MethodGet, apiPrefix + "/admin/faults", openapi.Doc{Summary: "List faults injected into workflow steps in chaos mode", Response: []workflows.Fault{}}},
+	{http.MethodPut, apiPrefix + "/admin/faults/{step}", openapi.Doc{Summary: "Inject a fault into a workflow step", Request: workflows.Fault{}, Response: workflows.Fault{}}},
+	{http.MethodDelete, apiPrefix + "/admin/faults/{step}", openapi.Doc{Summary: "Remove the fault of a workflow step"}},
+	{http.MethodDelete, apiPrefix + "/admin/faults", openapi.Doc{Summary: "Remove all faults"}},
+}
+
+// apiDocs returns the generator of the specification of the REST API.
+func apiDocs(version string) *openapi.Generator {
+	g := openapi.NewGenerator(openapi.Info{
+		Title:   "Supergiant Control",
+		Version: version,
+	}, message.Message{})
+	g.Secure(apiPrefix)
+
+	for _, op := range apiOperations {
+		g.Describe(op.method, op.path, op.doc)
+	}
+
+	return g
+}
@@ -0,0 +1,76 @@
+package controlplane
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/account"
+	"github.com/supergiant/control/pkg/catalog"
+	"github.com/supergiant/control/pkg/fleet"
+	"github.com/supergiant/control/pkg/images"
+	"github.com/supergiant/control/pkg/kube"
+	"github.com/supergiant/control/pkg/pki"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/provisioner"
+	"github.com/supergiant/control/pkg/queue"
+	"github.com/supergiant/control/pkg/settings"
+	"github.com/supergiant/control/pkg/sghelm"
+	"github.com/supergiant/control/pkg/snapshot"
+	"github.com/supergiant/control/pkg/user"
+	"github.com/supergiant/control/pkg/versions"
+	"github.com/supergiant/control/pkg/workflows"
+)
+
+func TestAPIDocs(t *testing.T) {
+	router := mux.NewRouter()
+	userHandler := user.NewHandler(nil, nil)
+	router.HandleFunc("/auth", userHandler.Authenticate).Methods(http.MethodPost)
+	router.HandleFunc("/root", userHandler.RegisterRootUser).Methods(http.MethodPost)
+
+	protectedAPI := router.PathPrefix(apiPrefix).Subrouter()
+	protectedAPI.HandleFunc("/users", userHandler.Create).Methods(http.MethodPost)
+	protectedAPI.HandleFunc("/users", userHandler.List).Methods(http.MethodGet)
+	allow := func(h http.HandlerFunc) http.HandlerFunc { return h }
+	user.NewTeamHandler(nil).Register(protectedAPI, allow)
+	userHandler.RegisterTwoFactor(router, protectedAPI, allow, allow)
+	router.HandleFunc("/auth/password", userHandler.ChangePassword).Methods(http.MethodPost)
+	userHandler.RegisterSessions(protectedAPI, allow, allow)
+	account.NewHandler(nil).Register(protectedAPI)
+	profile.NewHandler(nil).Register(protectedAPI)
+	provisioner.NewHandler(nil, nil, nil, nil).Register(protectedAPI)
+	kubeHandler := kube.NewHandler(nil, nil, nil, nil, nil, nil, nil, nil, "")
+	kubeHandler.Register(protectedAPI)
+	kubeHandler.RegisterPublic(router)
+	workflows.NewTaskHandler(nil, nil, nil, "").Register(protectedAPI)
+	workflows.NewFaultHandler().Register(protectedAPI)
+	sghelm.NewHandler(nil).Register(protectedAPI)
+	pki.NewHandler(nil).Register(protectedAPI)
+	settings.NewHandler(nil).Register(protectedAPI)
+	catalog.NewHandler(nil, nil, nil).Register(protectedAPI)
+	images.NewHandler(nil).Register(protectedAPI)
+	queue.NewHandler(nil).Register(protectedAPI)
+	snapshot.NewHandler(nil).Register(protectedAPI)
+	fleet.NewHandler(nil).Register(protectedAPI)
+	versions.NewHandler(nil).Register(protectedAPI)
+
+	doc, err := apiDocs("test").Generate(router)
+	require.NoError(t, err)
+
+	// documented operations exist
+	for _, op := range apiOperations {
+		item, ok := doc.Paths[op.path]
+		require.True(t, ok, op.path)
+		spec, ok := item[strings.ToLower(op.method)]
+		require.True(t, ok, op.method+" "+op.path)
+		require.Equal(t, op.doc.Summary, spec.Summary)
+		require.Equal(t, op.doc.Request != nil, spec.RequestBody != nil, op.path)
+	}
+
+	require.Contains(t, doc.Components.Schemas, "model.Kube")
+	require.Contains(t, doc.Components.Schemas, "profile.Profile")
+	require.Contains(t, doc.Components.Schemas, "workflows.Task")
+}
@@ -5,9 +5,11 @@ import (
 	"crypto/tls"
 	"fmt"
 	"github.com/supergiant/control/pkg/workflows/steps/helm"
+	"net"
 	"net/http"
 	_ "net/http/pprof"
 	"net/url"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -16,87 +18,233 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rakyll/statik/fs"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"k8s.io/helm/pkg/repo"
 
 	"github.com/supergiant/control/pkg/account"
+	"github.com/supergiant/control/pkg/acme"
 	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/catalog"
+	"github.com/supergiant/control/pkg/eventbus"
+	"github.com/supergiant/control/pkg/fleet"
+	"github.com/supergiant/control/pkg/gitops"
+	"github.com/supergiant/control/pkg/grpcapi"
+	"github.com/supergiant/control/pkg/idempotency"
+	"github.com/supergiant/control/pkg/images"
 	"github.com/supergiant/control/pkg/jwt"
 	"github.com/supergiant/control/pkg/kube"
+	"github.com/supergiant/control/pkg/ldap"
+	"github.com/supergiant/control/pkg/openapi"
+	"github.com/supergiant/control/pkg/pki"
+	"github.com/supergiant/control/pkg/plugins"
 	"github.com/supergiant/control/pkg/profile"
 	"github.com/supergiant/control/pkg/provisioner"
 	"github.com/supergiant/control/pkg/proxy"
+	"github.com/supergiant/control/pkg/queue"
+	"github.com/supergiant/control/pkg/ratelimit"
+	"github.com/supergiant/control/pkg/retention"
 	sshRunner "github.com/supergiant/control/pkg/runner/ssh"
+	"github.com/supergiant/control/pkg/settings"
 	"github.com/supergiant/control/pkg/sgerrors"
 	"github.com/supergiant/control/pkg/sghelm"
+	"github.com/supergiant/control/pkg/sglog"
+	"github.com/supergiant/control/pkg/snapshot"
 	"github.com/supergiant/control/pkg/storage"
 	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/timeline"
+	"github.com/supergiant/control/pkg/tracing"
 	"github.com/supergiant/control/pkg/user"
+	"github.com/supergiant/control/pkg/versions"
 	"github.com/supergiant/control/pkg/workflows"
 	"github.com/supergiant/control/pkg/workflows/steps/amazon"
 	"github.com/supergiant/control/pkg/workflows/steps/apply"
 	"github.com/supergiant/control/pkg/workflows/steps/authorizedkeys"
 	"github.com/supergiant/control/pkg/workflows/steps/azure"
 	"github.com/supergiant/control/pkg/workflows/steps/bootstraptoken"
+	"github.com/supergiant/control/pkg/workflows/steps/canary"
 	"github.com/supergiant/control/pkg/workflows/steps/certificates"
+	"github.com/supergiant/control/pkg/workflows/steps/certmanager"
+	"github.com/supergiant/control/pkg/workflows/steps/chrony"
 	"github.com/supergiant/control/pkg/workflows/steps/cloudcontroller"
 	"github.com/supergiant/control/pkg/workflows/steps/clustercheck"
 	"github.com/supergiant/control/pkg/workflows/steps/cni"
+	"github.com/supergiant/control/pkg/workflows/steps/compliance"
 	"github.com/supergiant/control/pkg/workflows/steps/configmap"
+	"github.com/supergiant/control/pkg/workflows/steps/coredns"
 	"github.com/supergiant/control/pkg/workflows/steps/dashboard"
 	"github.com/supergiant/control/pkg/workflows/steps/digitalocean"
 	"github.com/supergiant/control/pkg/workflows/steps/docker"
 	"github.com/supergiant/control/pkg/workflows/steps/downloadk8sbinary"
 	"github.com/supergiant/control/pkg/workflows/steps/drain"
+	"github.com/supergiant/control/pkg/workflows/steps/etcd"
 	"github.com/supergiant/control/pkg/workflows/steps/evacuate"
+	"github.com/supergiant/control/pkg/workflows/steps/externaldns"
+	"github.com/supergiant/control/pkg/workflows/steps/fake"
 	"github.com/supergiant/control/pkg/workflows/steps/gce"
+	"github.com/supergiant/control/pkg/workflows/steps/gracefuldelete"
+	"github.com/supergiant/control/pkg/workflows/steps/hardening"
+	"github.com/supergiant/control/pkg/workflows/steps/ingress"
 	"github.com/supergiant/control/pkg/workflows/steps/install_app"
 	"github.com/supergiant/control/pkg/workflows/steps/kubeadm"
 	"github.com/supergiant/control/pkg/workflows/steps/kubelet"
 	"github.com/supergiant/control/pkg/workflows/steps/network"
+	"github.com/supergiant/control/pkg/workflows/steps/nodeproblemdetector"
+	"github.com/supergiant/control/pkg/workflows/steps/ospatch"
 	"github.com/supergiant/control/pkg/workflows/steps/poststart"
 	"github.com/supergiant/control/pkg/workflows/steps/prometheus"
+	"github.com/supergiant/control/pkg/workflows/steps/registrycredentials"
+	"github.com/supergiant/control/pkg/workflows/steps/runtimeupgrade"
 	"github.com/supergiant/control/pkg/workflows/steps/ssh"
+	"github.com/supergiant/control/pkg/workflows/steps/sshkeys"
 	"github.com/supergiant/control/pkg/workflows/steps/storageclass"
+	"github.com/supergiant/control/pkg/workflows/steps/sysctl"
 	"github.com/supergiant/control/pkg/workflows/steps/tiller"
 	"github.com/supergiant/control/pkg/workflows/steps/uncordon"
 	"github.com/supergiant/control/pkg/workflows/steps/upgrade"
+	"github.com/supergiant/control/pkg/workflows/steps/velero"
+	"github.com/supergiant/control/pkg/workflows/steps/waitfor"
+	"github.com/supergiant/control/pkg/workflows/steps/windows"
 	_ "github.com/supergiant/control/statik"
 )
 
 type Server struct {
 	server http.Server
 	cfg    *Config
+	// grpcServer serves the gRPC API if its port is set
+	grpcServer *grpc.Server
+
+	stopTracing func()
+	// stopEvents publishes buffered lifecycle events and stops publishers
+	stopEvents func()
+	// plugins implement custom workflow steps, they are killed on shutdown
+	plugins *plugins.Manager
+	// certManager renews the certificate of the HTTPS port with ACME,
+	// challengeServer serves its HTTP-01 challenges.
+	certManager     *acme.Manager
+	challengeServer *http.Server
+	// stopped is closed when shutdown is finished
+	stopped chan struct{}
 }
 
+const (
+	defaultShutdownTimeout = time.Minute * 5
+	// tokenTTL is the lifetime of tokens and their sessions.
+	tokenTTL = 24 * time.Hour
+)
+
 func (srv *Server) Start() {
 	logrus.Infof("configuratino: %+v", srv.cfg)
 	logrus.Infof("supergiant is listening on %s", srv.server.Addr)
 
+	if srv.grpcServer != nil {
+		go srv.serveGRPC()
+	}
+	if srv.certManager != nil {
+		srv.serveACME()
+	}
+
 	var err error
-	if srv.server.TLSConfig != nil {
+	if srv.certManager != nil {
+		err = srv.server.ListenAndServeTLS("", "")
+	} else if srv.server.TLSConfig != nil {
 		err = srv.server.ListenAndServeTLS(srv.cfg.CertFile, srv.cfg.KeyFile)
 	} else {
 		err = srv.server.ListenAndServe()
 	}
+	if err == http.ErrServerClosed {
+		// running tasks are being stopped
+		<-srv.stopped
+		return
+	}
 	if err != nil {
 		logrus.Error(err)
 	}
 }
 
+func (srv *Server) serveGRPC() {
+	addr := fmt.Sprintf("%s:%d", srv.cfg.Addr, srv.cfg.GRPCPort)
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		logrus.Errorf("grpc: listen %s: %v", addr, err)
+		return
+	}
+
+	logrus.Infof("gRPC API is listening on %s", addr)
+	if err := srv.grpcServer.Serve(l); err != nil {
+		logrus.Errorf("grpc: %v", err)
+	}
+}
+
+// serveACME obtains and renews the certificate, HTTP-01 challenges are
+// served on their own port.
+func (srv *Server) serveACME() {
+	go srv.certManager.Run(context.Background())
+	if srv.cfg.ACME.DNSChallenge() {
+		return
+	}
+
+	addr := srv.cfg.ACME.HTTPAddr
+	if addr == "" {
+		addr = acme.DefaultHTTPAddr
+	}
+	srv.challengeServer = &http.Server{
+		Addr:    addr,
+		Handler: srv.certManager.HTTPHandler(nil),
+	}
+	go func() {
+		logrus.Infof("ACME challenges are served on %s", addr)
+		if err := srv.challengeServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logrus.Errorf("acme: %v", err)
+		}
+	}()
+}
+
+// Shutdown stops accepting requests and new tasks, running tasks are
+// interrupted after their current steps and can be resumed after restart.
+// Steps are cancelled when they don't finish within the shutdown timeout.
 func (srv *Server) Shutdown() {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*1)
+	defer close(srv.stopped)
+
+	timeout := srv.cfg.ShutdownTimeout
+	if timeout == 0 {
+		timeout = defaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 	err := srv.server.Shutdown(ctx)
 
 	if err != nil {
 		logrus.Error(err)
 	}
+	if srv.challengeServer != nil {
+		srv.challengeServer.Close()
+	}
+	if srv.grpcServer != nil {
+		// log streams never end by themselves
+		srv.grpcServer.Stop()
+	}
+
+	if err := workflows.Shutdown(ctx); err != nil {
+		logrus.Errorf("stop tasks: %v", err)
+	}
+
+	srv.plugins.Close()
+
+	if srv.stopEvents != nil {
+		srv.stopEvents()
+	}
+	if srv.stopTracing != nil {
+		srv.stopTracing()
+	}
 }
 
 // Config is the server configuration
 type Config struct {
 	Port         int
 	InsecurePort int
+	// GRPCPort of the gRPC API, it isn't served when it's 0.
+	GRPCPort     int
 	CertFile     string
 	KeyFile      string
 	Addr         string
@@ -110,44 +258,147 @@ type Config struct {
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	IdleTimeout  time.Duration
+	// ShutdownTimeout is how long running steps have to finish on shutdown.
+	ShutdownTimeout time.Duration
+	// ProvisionWorkers is how many clusters are provisioned at once, the
+	// rest wait in the queue.
+	ProvisionWorkers int
+	// IdempotencyTTL is how long responses of requests with idempotency
+	// keys are replayed to retries, a day by default.
+	IdempotencyTTL time.Duration
+	// RateLimit and Quotas of users are defaults of runtime settings.
+	RateLimit settings.RateLimit
+	Quotas    settings.Quotas
 
 	PprofListenStr string
 
 	ProxiesPortRange proxy.PortRange
 
+	// GitOps reconciles clusters from specs of the repository if it's set.
+	GitOps gitops.Config
+
+	// TaskRetention prunes finished tasks if it's enabled.
+	TaskRetention retention.Policy
+
+	// Tracing exports spans of requests, tasks and cloud API calls if its endpoint is set.
+	Tracing tracing.Config
+
+	// Events publishes lifecycle events of clusters to NATS or Kafka if
+	// their URLs are set.
+	Events eventbus.Config
+
+	// LDAP authenticates users that aren't local if its URL is set.
+	LDAP ldap.Config
+	// TwoFactorRoles need second factors of password logins by default.
+	TwoFactorRoles []string
+
+	// CatalogFile replaces the built-in application catalog if it's set.
+	CatalogFile string
+
+	// VersionFeed is a URL of the JSON matrix of supported kubernetes
+	// versions, it replaces the built-in one on every VersionFeedInterval.
+	VersionFeed         string
+	VersionFeedInterval time.Duration
+
+	// PluginsDir keeps executables of step plugins, they are started on
+	// startup and their steps are registered alongside built-in steps.
+	PluginsDir string
+
+	// ChaosMode allows injecting faults into workflow steps, it's meant
+	// for testing rollback, retry and resume of tasks.
+	ChaosMode bool
+
+	// ACME obtains the certificate of the HTTPS port if its domains are set,
+	// CertFile and KeyFile aren't needed then.
+	ACME acme.Config
+
 	Version string
 }
 
+// idempotentRoutes provision, scale and delete clusters, their retries with
+// the same idempotency key are handled once.
+var idempotentRoutes = []idempotency.Route{
+	{Method: http.MethodPost, Path: apiPrefix + "/kubes"},
+	{Method: http.MethodDelete, Path: apiPrefix + "/kubes/{kubeID}"},
+	{Method: http.MethodPost, Path: apiPrefix + "/kubes/{kubeID}/nodes"},
+	{Method: http.MethodDelete, Path: apiPrefix + "/kubes/{kubeID}/nodes/{nodename}"},
+	{Method: http.MethodPost, Path: apiPrefix + "/kubes/{kubeID}/machines"},
+	{Method: http.MethodDelete, Path: apiPrefix + "/kubes/{kubeID}/machines/{nodename}"},
+	{Method: http.MethodPost, Path: apiPrefix + "/kubes/{kubeID}/spot"},
+}
+
 func New(cfg *Config) (*Server, error) {
 	if err := validate(cfg); err != nil {
 		return nil, err
 	}
 
-	r, err := configureApplication(cfg)
+	r, grpcAPI, certManager, err := configureApplication(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var stopTracing func()
+	if cfg.Tracing.Endpoint != "" {
+		if stopTracing, err = tracing.Init(cfg.Tracing); err != nil {
+			return nil, errors.Wrap(err, "tracing")
+		}
+		r.Use(tracing.Middleware)
+	}
+
+	srv, err := NewServer(r, cfg)
 	if err != nil {
 		return nil, err
 	}
+	srv.stopTracing = stopTracing
+	if cfg.Events.Enabled() {
+		if srv.stopEvents, err = eventbus.Init(cfg.Events); err != nil {
+			return nil, errors.Wrap(err, "events")
+		}
+	}
+	if cfg.PluginsDir != "" {
+		if srv.plugins, err = plugins.Load(context.Background(), cfg.PluginsDir); err != nil {
+			return nil, errors.Wrap(err, "plugins")
+		}
+	}
+	if certManager != nil {
+		srv.certManager = certManager
+		srv.server.TLSConfig.GetCertificate = certManager.GetCertificate
+	}
 
-	return NewServer(r, cfg)
+	if cfg.GRPCPort != 0 {
+		var opts []grpc.ServerOption
+		if srv.server.TLSConfig != nil {
+			opts = append(opts, grpc.Creds(credentials.NewTLS(srv.server.TLSConfig)))
+		}
+		srv.grpcServer = grpcAPI.GRPCServer(opts...)
+	}
+
+	return srv, nil
 }
 
 func NewServer(router *mux.Router, cfg *Config) (*Server, error) {
 	headersOk := handlers.AllowedHeaders([]string{
 		"Access-Control-Request-Headers",
 		"Authorization",
+		idempotency.Header,
 	})
 	methodsOk := handlers.AllowedMethods([]string{
 		http.MethodGet,
 		http.MethodHead,
 		http.MethodPost,
 		http.MethodPut,
+		http.MethodPatch,
 		http.MethodOptions,
 		http.MethodDelete,
 	})
 
 	port := cfg.InsecurePort
 	var tlsCfg *tls.Config
-	if cfg.Port != 0 {
+	if cfg.Port != 0 && cfg.ACME.Enabled() {
+		// the certificate is set by the ACME manager
+		port = cfg.Port
+		tlsCfg = &tls.Config{}
+	} else if cfg.Port != 0 {
 		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
 		if err != nil {
 			return nil, errors.Wrap(err, "load server certificates")
@@ -160,7 +411,8 @@ func NewServer(router *mux.Router, cfg *Config) (*Server, error) {
 	}
 
 	return &Server{
-		cfg: cfg,
+		cfg:     cfg,
+		stopped: make(chan struct{}),
 		server: http.Server{
 			Handler:      handlers.CORS(headersOk, methodsOk)(handlers.RecoveryHandler(handlers.PrintRecoveryStack(true))(router)),
 			Addr:         fmt.Sprintf("%s:%d", cfg.Addr, port),
@@ -176,11 +428,14 @@ func validate(cfg *Config) error {
 	if cfg.SpawnInterval == 0 {
 		return errors.New("spawn interval must not be 0")
 	}
+	if cfg.ACME.Enabled() && cfg.Port == 0 {
+		return errors.New("acme needs the secure port")
+	}
 
 	return nil
 }
 
-func configureApplication(cfg *Config) (*mux.Router, error) {
+func configureApplication(cfg *Config) (*mux.Router, *grpcapi.Server, *acme.Manager, error) {
 	//TODO will work for now, but we should revisit ETCD configuration later
 	router := mux.NewRouter()
 
@@ -188,7 +443,7 @@ func configureApplication(cfg *Config) (*mux.Router, error) {
 	repository, err := storage.GetStorage(cfg.StorageMode, cfg.StorageURI)
 
 	if err != nil {
-		return nil, errors.Wrapf(err, "get storage type %s uri %s",
+		return nil, nil, nil, errors.Wrapf(err, "get storage type %s uri %s",
 			cfg.StorageMode, cfg.StorageURI)
 	}
 
@@ -196,27 +451,57 @@ func configureApplication(cfg *Config) (*mux.Router, error) {
 	accountHandler := account.NewHandler(accountService)
 	accountHandler.Register(protectedAPI)
 
+	var certManager *acme.Manager
+	if cfg.ACME.Enabled() {
+		if certManager, err = newCertManager(cfg.ACME, repository, accountService); err != nil {
+			return nil, nil, nil, errors.Wrap(err, "acme")
+		}
+	}
+
+	sglog.NewHandler().Register(protectedAPI)
+
 	//TODO Add generation of jwt token
-	jwtService := jwt.NewTokenService(86400, []byte("test"))
+	jwtService := jwt.NewTokenService(int64(tokenTTL.Seconds()), []byte("test"))
 	userService := user.NewService(user.DefaultStoragePrefix, repository)
 	userHandler := user.NewHandler(userService, jwtService)
+	if cfg.LDAP.URL != "" {
+		ldapProvider, err := ldap.New(cfg.LDAP)
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "ldap")
+		}
+		userHandler.AddProvider(ldapProvider)
+	}
 
 	router.HandleFunc("/version", NewVersionHandler(cfg.Version))
 	router.HandleFunc("/auth", userHandler.Authenticate).Methods(http.MethodPost)
 	router.HandleFunc("/root", userHandler.RegisterRootUser).Methods(http.MethodPost)
 	router.HandleFunc("/coldstart", userHandler.IsColdStart).Methods(http.MethodGet)
-	protectedAPI.HandleFunc("/users", userHandler.Create).Methods(http.MethodPost)
+	protectedAPI.HandleFunc("/users", api.AdminOnly(userHandler.Create)).Methods(http.MethodPost)
+	protectedAPI.HandleFunc("/users", api.AdminOnly(userHandler.List)).Methods(http.MethodGet)
+	twoFactor := user.NewTwoFactorService(user.DefaultTOTPStoragePrefix, repository)
+	userHandler.SetTwoFactor(twoFactor)
+	userHandler.RegisterTwoFactor(router, protectedAPI, api.SelfOrAdmin, api.AdminOnly)
+	router.HandleFunc("/auth/password", userHandler.ChangePassword).Methods(http.MethodPost)
+	sessionService := user.NewSessionService(user.DefaultSessionStoragePrefix, repository, tokenTTL)
+	userHandler.SetSessions(sessionService)
+	userHandler.RegisterSessions(protectedAPI, api.SelfOrAdmin, api.AdminOnly)
+
+	teamService := user.NewTeamService(user.DefaultTeamStoragePrefix, repository)
+	user.NewTeamHandler(teamService).Register(protectedAPI, api.AdminOnly)
 
 	profileService := profile.NewService(profile.DefaultKubeProfilePreifx, repository)
 	kubeProfileHandler := profile.NewHandler(profileService)
 	kubeProfileHandler.Register(protectedAPI)
 
+	images.NewHandler(images.NewService(images.DefaultStoragePrefix, repository)).Register(protectedAPI)
+
 	// Read templates first and then initialize workflows with steps that uses these templates
 	if err := templatemanager.Init(cfg.TemplatesDir); err != nil {
-		return nil, errors.Wrap(err, "templatemanager: init")
+		return nil, nil, nil, errors.Wrap(err, "templatemanager: init")
 	}
 
 	digitalocean.Init()
+	fake.Init()
 	certificates.Init()
 	authorizedkeys.Init()
 	cni.Init()
@@ -231,22 +516,44 @@ func configureApplication(cfg *Config) (*mux.Router, error) {
 	cloudcontroller.Init()
 	prometheus.Init()
 	dashboard.Init()
+	externaldns.Init()
+	certmanager.Init()
+	nodeproblemdetector.Init()
 	gce.Init(accountService)
 	storageclass.Init()
+	coredns.Init()
+	ingress.Init()
 	drain.Init()
 	kubeadm.Init()
 	bootstraptoken.Init()
+	bootstraptoken.InitNode()
 	configmap.Init()
 	upgrade.Init()
 	uncordon.Init()
+	waitfor.Init()
 	evacuate.Init()
 	install_app.Init()
 	helm.Init()
+	hardening.Init()
+	gracefuldelete.Init()
+	compliance.Init()
+	sysctl.Init()
+	chrony.Init()
+	registrycredentials.Init()
+	ospatch.Init()
+	runtimeupgrade.Init()
+	canary.Init()
+	etcd.Init()
+	sshkeys.Init()
+	windows.Init()
+	velero.Init()
 
 	amazon.InitFindAMI(amazon.GetEC2)
+	amazon.InitFindWindowsAMI(amazon.GetEC2)
 	amazon.InitImportKeyPair(amazon.GetEC2)
 	amazon.InitCreateInstanceProfiles(amazon.GetIAM)
 	amazon.InitCreateMachine(amazon.GetEC2)
+	amazon.InitCreateImage(amazon.GetEC2)
 	amazon.InitCreateSecurityGroups(amazon.GetEC2)
 	amazon.InitCreateVPC(amazon.GetEC2)
 	amazon.InitCreateSubnet(amazon.GetEC2, accountService)
@@ -265,28 +572,45 @@ func configureApplication(cfg *Config) (*mux.Router, error) {
 	amazon.InitDeleteKeyPair(amazon.GetEC2)
 	amazon.InitCreateLoadBalancer(amazon.GetELB)
 	amazon.InitDeleteLoadBalancer(amazon.GetELB)
+	amazon.InitDeleteServiceLoadBalancers(amazon.GetELB)
+	amazon.InitDeleteOrphanedResources(amazon.GetEC2)
+	amazon.InitVerifyClusterDeleted(amazon.GetEC2, amazon.GetELB)
 	amazon.InitRegisterInstance(amazon.GetELB)
+	amazon.InitDeregisterInstance(amazon.GetELB)
 	amazon.InitImportClusterStep(amazon.GetEC2)
 	amazon.InitImportSubnetDescriber(amazon.GetEC2)
 	amazon.InitImportInternetGatewayStep(amazon.GetEC2)
 	amazon.InitImportRouteTablesStep(amazon.GetEC2)
 	amazon.InitCreateTagsStep(amazon.GetEC2)
+	amazon.InitAttachStaticIP(amazon.GetEC2)
+	amazon.InitApplyFirewallRules(amazon.GetEC2)
 	apply.Init()
 	azure.Init()
 
 	workflows.Init()
+	workflows.InitStepStats(repository)
+	if cfg.ChaosMode {
+		logrus.Warn("chaos mode is on, faults can be injected into workflow steps")
+		workflows.EnableFaults(true)
+		workflows.NewFaultHandler().Register(protectedAPI)
+	}
 
 	taskHandler := workflows.NewTaskHandler(repository, sshRunner.NewRunner, accountService, cfg.LogDir)
 	taskHandler.Register(protectedAPI)
+	if n, err := taskHandler.ResumeInterrupted(context.Background()); err != nil {
+		logrus.Errorf("resume interrupted tasks: %v", err)
+	} else if n > 0 {
+		logrus.Infof("resumed %d interrupted tasks", n)
+	}
 
 	helmService, err := sghelm.NewService(repository)
 	if err != nil {
-		return nil, errors.Wrap(err, "new helm service")
+		return nil, nil, nil, errors.Wrap(err, "new helm service")
 	}
 	if coldstart, err := userService.IsColdStart(context.Background()); err == nil && coldstart {
 		go ensureHelmRepositories(helmService)
 	} else if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	helmHandler := sghelm.NewHandler(helmService)
@@ -294,6 +618,7 @@ func configureApplication(cfg *Config) (*mux.Router, error) {
 
 	kubeService := kube.NewService(kube.DefaultStoragePrefix,
 		repository, helmService)
+	taskHandler.SetKubes(kubeService)
 
 	taskProvisioner := provisioner.NewProvisioner(repository,
 		kubeService,
@@ -301,6 +626,54 @@ func configureApplication(cfg *Config) (*mux.Router, error) {
 	provisionHandler := provisioner.NewHandler(kubeService, accountService,
 		profileService, taskProvisioner)
 	provisionHandler.Register(protectedAPI)
+	quotas := ratelimit.NewQuotas(kubeService)
+	provisionHandler.SetQuotas(quotas)
+	provisionHandler.SetProfiles(profileService.Get)
+	provisionQueue := queue.New(repository, cfg.ProvisionWorkers)
+	// queued clusters are provisioned after restart
+	provisionQueue.HoldWhile(workflows.ShuttingDown)
+	taskProvisioner.UseQueue(provisionQueue, profileService.Get, accountService)
+	queue.NewHandler(provisionQueue).Register(protectedAPI)
+	go provisionQueue.Run(context.Background())
+	limiter := ratelimit.NewLimiter()
+
+	settingsManager := settings.NewManager(repository, settings.Settings{
+		SpawnInterval: cfg.SpawnInterval.String(),
+		SSHRetry: settings.Retry{
+			Attempts: sshRunner.DefaultDialAttempts,
+			Interval: sshRunner.DefaultDialInterval.String(),
+		},
+		RateLimit:      cfg.RateLimit,
+		Quotas:         cfg.Quotas,
+		TwoFactorRoles: cfg.TwoFactorRoles,
+		PasswordPolicy: user.DefaultPasswordPolicy,
+		Lockout:        user.DefaultLockoutPolicy,
+		Features: map[string]bool{
+			settings.FeatureTerminals:   true,
+			settings.FeatureStatusPages: true,
+		},
+	})
+	if err := settingsManager.Load(context.Background()); err != nil {
+		logrus.Errorf("runtime settings are reset to defaults: %v", err)
+	}
+	settingsManager.OnChange(func(s settings.Settings) {
+		for component, level := range s.LogLevels {
+			sglog.SetLevel(component, level)
+		}
+		taskProvisioner.SetSpawnInterval(settings.Duration(s.SpawnInterval))
+		sshRunner.SetDialRetry(s.SSHRetry.Attempts, settings.Duration(s.SSHRetry.Interval))
+		accountHandler.SetRegionsCacheTTL(settings.Duration(s.RegionsCacheTTL))
+		limiter.SetLimit(s.RateLimit)
+		quotas.Set(s.Quotas)
+		twoFactor.SetRequiredRoles(s.TwoFactorRoles)
+		userService.SetPasswordPolicy(s.PasswordPolicy)
+		userHandler.SetLockoutPolicy(s.Lockout)
+	})
+	settings.NewHandler(settingsManager).Register(protectedAPI)
+	snapshot.NewHandler(snapshot.NewService(repository)).Register(protectedAPI)
+
+	pkiHandler := pki.NewHandler(pki.NewCSRService(repository))
+	pkiHandler.Register(protectedAPI)
 	apiProxy := proxy.NewReverseProxyContainer(cfg.ProxiesPortRange,
 		logrus.New().WithField("component", "proxy"))
 
@@ -308,11 +681,63 @@ func configureApplication(cfg *Config) (*mux.Router, error) {
 		profileService, taskProvisioner, taskProvisioner, helmService,
 		repository, apiProxy, cfg.LogDir)
 	kubeHandler.Register(protectedAPI)
+	kubeHandler.RegisterPublic(router)
+	kubeHandler.SetFeatureFlags(settingsManager.Enabled)
+	kubeHandler.SetQuotas(quotas)
+	settingsManager.OnChange(func(s settings.Settings) {
+		kubeHandler.SetNotificationRoutes(s.Notifications)
+	})
+	go kube.NewOSPatchScheduler(kubeService, kubeHandler.StartOSPatch).Run(context.Background())
+	go kube.NewEtcdMaintenanceScheduler(kubeService, kubeHandler.StartEtcdMaintenance).Run(context.Background())
+	go kube.NewWorkflowScheduler(kubeHandler).Run(context.Background())
+	go kube.NewCostMonitor(kubeHandler).Run(context.Background())
+	go kube.NewDriftMonitor(kubeHandler).Run(context.Background())
+	go kube.NewInventoryReconciler(kubeHandler).Run(context.Background())
+	go kube.NewNodeProblemMonitor(kubeHandler).Run(context.Background())
+	go kube.NewChannelScheduler(kubeHandler, versions.Default()).Run(context.Background())
+	go kube.NewCSRApprover(kubeService, timeline.NewService(timeline.DefaultStoragePrefix, repository)).Run(context.Background())
+
+	appCatalog := catalog.Default()
+	if cfg.CatalogFile != "" {
+		if appCatalog, err = catalog.Load(cfg.CatalogFile); err != nil {
+			return nil, nil, nil, errors.Wrap(err, "load catalog")
+		}
+	}
+	go appCatalog.EnsureRepos(context.Background(), helmService)
+	catalog.NewHandler(appCatalog, kubeService, kubeHandler).Register(protectedAPI)
+	fleet.NewHandler(fleet.NewService(fleet.DefaultStoragePrefix, repository, kubeService, kubeHandler, appCatalog)).Register(protectedAPI)
+
+	versions.NewHandler(versions.Default()).Register(protectedAPI)
+	if cfg.VersionFeed != "" {
+		go versions.Default().Watch(context.Background(), cfg.VersionFeed, cfg.VersionFeedInterval)
+	}
+
+	if cfg.TaskRetention.Enabled() {
+		pruner, err := retention.NewPruner(repository, cfg.LogDir, cfg.TaskRetention)
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "task retention")
+		}
+		go pruner.Run(context.Background())
+	}
+
+	if cfg.GitOps.Repo != "" {
+		if cfg.GitOps.Dir == "" {
+			cfg.GitOps.Dir = filepath.Join(cfg.LogDir, "gitops")
+		}
+		gitopsController := gitops.NewController(cfg.GitOps, kubeHandler)
+		gitopsController.Register(protectedAPI)
+		go gitopsController.Run(context.Background())
+	}
 
 	authMiddleware := api.Middleware{
 		TokenService: jwtService,
+		Sessions:     sessionService,
 	}
-	protectedAPI.Use(authMiddleware.AuthMiddleware, api.ContentTypeJSON)
+	idempotencyStore := idempotency.NewStore(repository, cfg.IdempotencyTTL, idempotentRoutes...)
+	go idempotencyStore.Run(context.Background())
+	protectedAPI.Use(authMiddleware.AuthMiddleware, api.TeamScope(teamService),
+		kubeHandler.ScopeMiddleware, accountHandler.ScopeMiddleware, limiter.Middleware,
+		idempotencyStore.Middleware, api.ContentTypeJSON)
 
 	if cfg.PprofListenStr != "" {
 		go func() {
@@ -321,10 +746,34 @@ func configureApplication(cfg *Config) (*mux.Router, error) {
 		}()
 	}
 
+	openapi.NewHandler(apiDocs(cfg.Version), router).Register(router)
+
 	if err := serveUI(cfg, router); err != nil {
-		return nil, err
+		return nil, nil, nil, err
+	}
+
+	grpcAPI := grpcapi.NewServer(kubeService, accountService, profileService,
+		repository, cfg.LogDir, &authMiddleware)
+	grpcAPI.SetTeams(teamService)
+
+	return router, grpcAPI, certManager, nil
+}
+
+// newCertManager creates the ACME manager, DNS-01 challenges are published
+// with the DNS zones of the cloud account.
+func newCertManager(cfg acme.Config, repository storage.Interface, accounts *account.Service) (*acme.Manager, error) {
+	var dns acme.DNSProvider
+	if cfg.DNSChallenge() {
+		acc, err := accounts.Get(context.Background(), cfg.DNSAccount)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get account %s", cfg.DNSAccount)
+		}
+		if dns, err = acme.NewDNSProvider(acc); err != nil {
+			return nil, errors.Wrapf(err, "dns provider of %s", cfg.DNSAccount)
+		}
 	}
-	return router, nil
+
+	return acme.NewManager(cfg, repository, dns)
 }
 
 func ensureHelmRepositories(svc sghelm.Servicer) {
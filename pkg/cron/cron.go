@@ -0,0 +1,138 @@
+// Package cron parses standard five field cron expressions
+// (minute hour day-of-month month day-of-week).
+package cron
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// day of month and day of week are OR'ed when both are restricted,
+	// a field that starts with * like */2 isn't restricted
+	domAny, dowAny bool
+}
+
+// Parse parses a cron expression like "30 2 * * 6" or "0 */6 * * 1-5".
+func Parse(spec string) (*Schedule, error) {
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, errors.Errorf("cron expression %q must have %d fields", spec, len(fields))
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, part := range parts {
+		b, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "cron expression %q", spec)
+		}
+		bits[i] = b
+	}
+
+	return &Schedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: strings.HasPrefix(parts[2], "*"),
+		dowAny: strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+// Next returns the first matching time after t with a minute precision,
+// zero time is returned if nothing matches within five years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case !has(s.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !has(s.hour, t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !has(s.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom, dow := has(s.dom, t.Day()), has(s.dow, int(t.Weekday()))
+
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+
+	return dom || dow
+}
+
+func parseField(s string, f field) (uint64, error) {
+	var bits uint64
+
+	for _, item := range strings.Split(s, ",") {
+		step := 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(item[i+1:]); err != nil || step < 1 {
+				return 0, errors.Errorf("invalid %s step %s", f.name, item)
+			}
+			item = item[:i]
+		}
+
+		lo, hi := f.min, f.max
+		if item != "*" {
+			var err error
+			bounds := strings.SplitN(item, "-", 2)
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, errors.Errorf("invalid %s %s", f.name, item)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, errors.Errorf("invalid %s %s", f.name, item)
+				}
+			}
+		}
+
+		if lo < f.min || hi > f.max || lo > hi {
+			return 0, errors.Errorf("%s %s is out of range %d-%d", f.name, item, f.min, f.max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+func has(bits uint64, v int) bool {
+	return bits&(1<<uint(v)) != 0
+}
@@ -0,0 +1,80 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		spec   string
+		hasErr bool
+	}{
+		{"* * * * *", false},
+		{"30 2 * * 6", false},
+		{"0 */6 1,15 * 1-5", false},
+		{"* * * *", true},
+		{"60 * * * *", true},
+		{"* * 0 * *", true},
+		{"*/0 * * * *", true},
+		{"5-1 * * * *", true},
+		{"a * * * *", true},
+	}
+
+	for _, testCase := range testCases {
+		_, err := Parse(testCase.spec)
+
+		if (err != nil) != testCase.hasErr {
+			t.Errorf("spec %q: unexpected error %v", testCase.spec, err)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// Wednesday
+	now := time.Date(2019, time.July, 17, 10, 15, 30, 0, time.UTC)
+
+	testCases := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2019, time.July, 17, 10, 16, 0, 0, time.UTC)},
+		{"30 2 * * 6", time.Date(2019, time.July, 20, 2, 30, 0, 0, time.UTC)},
+		{"0 */6 * * *", time.Date(2019, time.July, 17, 12, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2019, time.August, 1, 0, 0, 0, 0, time.UTC)},
+		// day of month or day of week
+		{"0 0 20 * 4", time.Date(2019, time.July, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		// day of month and day of week when a field is a step of *
+		{"0 3 */2 * 1", time.Date(2019, time.July, 29, 3, 0, 0, 0, time.UTC)},
+		{"0 3 1 * */2", time.Date(2019, time.August, 1, 3, 0, 0, 0, time.UTC)},
+	}
+
+	for _, testCase := range testCases {
+		s, err := Parse(testCase.spec)
+
+		if err != nil {
+			t.Fatalf("spec %q: unexpected error %v", testCase.spec, err)
+		}
+
+		if next := s.Next(now); !next.Equal(testCase.expected) {
+			t.Errorf("spec %q: expected %v actual %v", testCase.spec, testCase.expected, next)
+		}
+	}
+}
+
+func TestNextStepOfAny(t *testing.T) {
+	s, err := Parse("0 3 */2 * 1")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	next := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 30; i++ {
+		next = s.Next(next)
+
+		if next.Weekday() != time.Monday || next.Day()%2 != 1 {
+			t.Fatalf("expected odd day Monday actual %v", next)
+		}
+	}
+}
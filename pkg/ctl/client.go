@@ -0,0 +1,329 @@
+package ctl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/snapshot"
+	"github.com/supergiant/control/pkg/storage"
+	"github.com/supergiant/control/pkg/user"
+)
+
+const apiPrefix = "/v1/api"
+
+// APIError is an error response of control, Code tells errors apart,
+// e.g. an exceeded quota from invalid credentials.
+type APIError struct {
+	StatusCode int
+	Message    string
+	Code       sgerrors.ErrorCode
+	Retryable  bool
+	Field      string
+	Step       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s (%d)", e.Message, e.StatusCode)
+}
+
+// Client calls the REST API of control with a token of the user.
+type Client struct {
+	server string
+	token  string
+	http   *http.Client
+}
+
+func NewClient(server, token string) *Client {
+	return &Client{
+		server: strings.TrimSuffix(server, "/"),
+		token:  token,
+		http: &http.Client{
+			Timeout: time.Minute,
+		},
+	}
+}
+
+// Login returns a token of the user, code is the second factor if the user
+// has one.
+func Login(ctx context.Context, server string, req user.AuthRequest) (string, error) {
+	c := NewClient(server, "")
+	resp, err := c.send(ctx, http.MethodPost, "/auth", "application/json", req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	token := resp.Header.Get("Authorization")
+	if token == "" {
+		return "", errors.New("control hasn't returned a token")
+	}
+	return token, nil
+}
+
+// ListKubes returns all kubes of the user, pages are followed.
+func (c *Client) ListKubes(ctx context.Context) ([]model.Kube, error) {
+	kubes := make([]model.Kube, 0)
+	next := ""
+	for {
+		path := apiPrefix + "/kubes"
+		if next != "" {
+			path += "?continue=" + url.QueryEscape(next)
+		}
+
+		page := make([]model.Kube, 0)
+		resp, err := c.send(ctx, http.MethodGet, path, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		err = decode(resp, &page)
+		if err != nil {
+			return nil, err
+		}
+		kubes = append(kubes, page...)
+
+		if next = resp.Header.Get(storage.ContinueHeader); next == "" {
+			return kubes, nil
+		}
+	}
+}
+
+func (c *Client) GetKube(ctx context.Context, kubeID string) (*model.Kube, error) {
+	k := &model.Kube{}
+	return k, c.do(ctx, http.MethodGet, apiPrefix+"/kubes/"+url.PathEscape(kubeID), nil, k)
+}
+
+func (c *Client) DeleteKube(ctx context.Context, kubeID string) error {
+	return c.do(ctx, http.MethodDelete, apiPrefix+"/kubes/"+url.PathEscape(kubeID), nil, nil)
+}
+
+// Provision creates the cluster of the provision request, out is decoded
+// from the response.
+func (c *Client) Provision(ctx context.Context, req interface{}, dryRun bool, out interface{}) error {
+	path := apiPrefix + "/provision"
+	if dryRun {
+		path += "?dryRun=true"
+	}
+	return c.do(ctx, http.MethodPost, path, req, out)
+}
+
+// GetSpec returns the YAML spec of the cluster.
+func (c *Client) GetSpec(ctx context.Context, kubeID string) ([]byte, error) {
+	resp, err := c.send(ctx, http.MethodGet, apiPrefix+"/kubes/"+url.PathEscape(kubeID)+"/spec", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// ApplySpec reconciles the cluster of the YAML spec, out is decoded from
+// the response.
+func (c *Client) ApplySpec(ctx context.Context, spec []byte, dryRun bool, out interface{}) error {
+	path := apiPrefix + "/kubes"
+	if dryRun {
+		path += "?dryRun=true"
+	}
+	resp, err := c.send(ctx, http.MethodPost, path, "application/yaml", spec)
+	if err != nil {
+		return err
+	}
+	return decode(resp, out)
+}
+
+// Kubeconfig issues a kubeconfig of the user with the ttl, the default ttl
+// of control is used when it's 0.
+func (c *Client) Kubeconfig(ctx context.Context, kubeID string, ttl time.Duration) ([]byte, error) {
+	path := apiPrefix + "/kubes/" + url.PathEscape(kubeID) + "/kubeconfig"
+	if ttl > 0 {
+		path += "?ttl=" + url.QueryEscape(ttl.String())
+	}
+
+	resp, err := c.send(ctx, http.MethodGet, path, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (c *Client) GetTask(ctx context.Context, taskID string, out interface{}) error {
+	return c.do(ctx, http.MethodGet, apiPrefix+"/tasks/"+url.PathEscape(taskID), nil, out)
+}
+
+func (c *Client) ListProfiles(ctx context.Context) ([]profile.Profile, error) {
+	profiles := make([]profile.Profile, 0)
+	return profiles, c.do(ctx, http.MethodGet, apiPrefix+"/kubeprofiles", nil, &profiles)
+}
+
+// ExportSnapshot returns the snapshot of the state of control, secrets are
+// sealed with the passphrase.
+func (c *Client) ExportSnapshot(ctx context.Context, passphrase string) ([]byte, error) {
+	resp, err := c.send(ctx, http.MethodPost, apiPrefix+"/admin/snapshot", "application/json",
+		snapshot.ExportRequest{Passphrase: passphrase})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// RestoreSnapshot restores the JSON snapshot into a fresh install.
+func (c *Client) RestoreSnapshot(ctx context.Context, snap []byte, passphrase string) (*snapshot.RestoreResponse, error) {
+	req := struct {
+		Passphrase string          `json:"passphrase"`
+		Snapshot   json.RawMessage `json:"snapshot"`
+	}{passphrase, snap}
+	resp := &snapshot.RestoreResponse{}
+	return resp, c.do(ctx, http.MethodPost, apiPrefix+"/admin/snapshot/restore", req, resp)
+}
+
+// TailLogs writes lines of the task log to out until the context is done
+// or the connection is closed.
+func (c *Client) TailLogs(ctx context.Context, taskID string, out io.Writer) error {
+	u, err := url.Parse(c.server + apiPrefix + "/tasks/" + url.PathEscape(taskID) + "/logs")
+	if err != nil {
+		return errors.Wrap(err, "parse server url")
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+c.token)
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, u.String(), header)
+	if err != nil {
+		if resp != nil {
+			return responseError(resp)
+		}
+		return errors.Wrap(err, "connect to the log stream")
+	}
+	defer conn.Close()
+
+	// the stream doesn't end by itself
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	for {
+		_, line, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil || websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				return nil
+			}
+			return errors.Wrap(err, "read log")
+		}
+		if _, err = fmt.Fprintln(out, string(line)); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	contentType := ""
+	if in != nil {
+		contentType = "application/json"
+	}
+	resp, err := c.send(ctx, method, path, contentType, in)
+	if err != nil {
+		return err
+	}
+	return decode(resp, out)
+}
+
+// send returns the response of successful requests, responses of errors
+// are returned as APIError.
+func (c *Client) send(ctx context.Context, method, path, contentType string, in interface{}) (*http.Response, error) {
+	var body io.Reader
+	switch v := in.(type) {
+	case nil:
+	case []byte:
+		body = bytes.NewReader(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshal request")
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.server+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		return nil, responseError(resp)
+	}
+	return resp, nil
+}
+
+func decode(resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return errors.Wrap(json.NewDecoder(resp.Body).Decode(out), "decode response")
+}
+
+// responseError returns the user message of control errors, handlers that
+// respond with plain text errors are supported too.
+func responseError(resp *http.Response) error {
+	data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<16))
+
+	msg := message.Message{}
+	if err := json.Unmarshal(data, &msg); err == nil && (msg.UserMessage != "" || msg.DevMessage != "") {
+		text := msg.UserMessage
+		if msg.DevMessage != "" && msg.DevMessage != msg.UserMessage {
+			text = strings.TrimSpace(text + ": " + msg.DevMessage)
+		}
+		return &APIError{
+			StatusCode: resp.StatusCode,
+			Message:    strings.TrimPrefix(text, ": "),
+			Code:       msg.ErrorCode,
+			Retryable:  msg.Retryable,
+			Field:      msg.Field,
+			Step:       msg.Step,
+		}
+	}
+
+	text := strings.TrimSpace(string(data))
+	if text == "" {
+		text = http.StatusText(resp.StatusCode)
+	}
+	return &APIError{StatusCode: resp.StatusCode, Message: text}
+}
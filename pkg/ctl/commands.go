@@ -0,0 +1,597 @@
+package ctl
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"gopkg.in/asaskevich/govalidator.v8"
+	"sigs.k8s.io/yaml"
+
+	"github.com/supergiant/control/pkg/clusterspec"
+	"github.com/supergiant/control/pkg/kube"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/provisioner"
+	"github.com/supergiant/control/pkg/user"
+	"github.com/supergiant/control/pkg/workflows"
+)
+
+// NewRootCommand returns the tree of commands of supergiantctl.
+func NewRootCommand() *Command {
+	root := &Command{
+		Name:  "supergiantctl",
+		Short: "supergiantctl manages clusters of supergiant control",
+	}
+
+	return root.Add(
+		loginCommand(),
+		(&Command{
+			Name:  "kubes",
+			Short: "Manage kubes",
+		}).Add(
+			listKubesCommand(),
+			getKubeCommand(),
+			createKubeCommand(),
+			deleteKubeCommand(),
+			scaleKubeCommand(),
+			kubeconfigCommand(),
+		),
+		(&Command{
+			Name:  "tasks",
+			Short: "Inspect tasks",
+		}).Add(
+			getTaskCommand(),
+			taskLogsCommand(),
+		),
+		(&Command{
+			Name:  "profiles",
+			Short: "Manage kube profiles",
+		}).Add(
+			listProfilesCommand(),
+			validateProfileCommand(),
+		),
+		(&Command{
+			Name:  "snapshot",
+			Short: "Back up and restore the state of control",
+		}).Add(
+			exportSnapshotCommand(),
+			restoreSnapshotCommand(),
+		),
+	)
+}
+
+func loginCommand() *Command {
+	req := user.AuthRequest{}
+	return &Command{
+		Name:  "login",
+		Short: "Log in and save the token for later commands",
+		Flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&req.Login, "user", "", "login of the user")
+			fs.StringVar(&req.Password, "password", "", "password of the user, it's read from stdin when empty")
+			fs.StringVar(&req.Code, "code", "", "code of the second factor")
+		},
+		Run: func(c *Context, args []string) error {
+			if req.Login == "" {
+				return errors.New("--user is required")
+			}
+			if req.Password == "" {
+				fmt.Fprint(c.Stderr, "Password: ")
+				line, err := bufio.NewReader(c.Stdin).ReadString('\n')
+				if err != nil && line == "" {
+					return errors.Wrap(err, "read password")
+				}
+				req.Password = strings.TrimRight(line, "\r\n")
+			}
+
+			cfg, err := loadConfig(c.configPath)
+			if err != nil {
+				return err
+			}
+			server := firstNonEmpty(c.server, os.Getenv(ServerEnv), cfg.Server, defaultServer)
+
+			token, err := Login(c, server, req)
+			if err != nil {
+				return err
+			}
+			if err = saveConfig(c.configPath, &Config{Server: server, Token: token}); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(c.Stdout, "Logged in to %s as %s\n", server, req.Login)
+			return nil
+		},
+	}
+}
+
+func listKubesCommand() *Command {
+	return &Command{
+		Name:  "list",
+		Short: "List kubes",
+		Run: func(c *Context, args []string) error {
+			client, err := c.Client()
+			if err != nil {
+				return err
+			}
+			kubes, err := client.ListKubes(c)
+			if err != nil {
+				return err
+			}
+
+			return c.print(kubes, func() table {
+				t := table{{"ID", "NAME", "PROVIDER", "REGION", "STATE", "VERSION", "MASTERS", "NODES"}}
+				for _, k := range kubes {
+					t = append(t, []string{k.ID, k.Name, string(k.Provider), orNone(k.Region),
+						string(k.State), orNone(k.K8SVersion), strconv.Itoa(len(k.Masters)), strconv.Itoa(len(k.Nodes))})
+				}
+				return t
+			})
+		},
+	}
+}
+
+func getKubeCommand() *Command {
+	return &Command{
+		Name:  "get",
+		Args:  "KUBE_ID",
+		Short: "Show a kube and its machines",
+		NArgs: 1,
+		Run: func(c *Context, args []string) error {
+			client, err := c.Client()
+			if err != nil {
+				return err
+			}
+			k, err := client.GetKube(c, args[0])
+			if err != nil {
+				return err
+			}
+
+			return c.print(k, func() table {
+				t := table{{"MACHINE", "ROLE", "SIZE", "STATE", "PUBLIC IP", "PRIVATE IP"}}
+				for _, m := range machinesOf(k) {
+					t = append(t, []string{m.Name, string(m.Role), orNone(m.Size), string(m.State),
+						orNone(m.PublicIp), orNone(m.PrivateIp)})
+				}
+				return t
+			})
+		},
+	}
+}
+
+func createKubeCommand() *Command {
+	var file string
+	var dryRun bool
+	return &Command{
+		Name:  "create",
+		Short: "Provision a kube of the JSON or YAML provision request",
+		Flags: func(fs *pflag.FlagSet) {
+			fs.StringVarP(&file, "file", "f", "", "file of the provision request, - reads stdin")
+			fs.BoolVar(&dryRun, "dry-run", false, "show the plan and the cost without provisioning")
+		},
+		Run: func(c *Context, args []string) error {
+			req, err := readDocument(c, file)
+			if err != nil {
+				return err
+			}
+			client, err := c.Client()
+			if err != nil {
+				return err
+			}
+
+			if dryRun {
+				plan := &provisioner.ProvisionPlan{}
+				if err = client.Provision(c, req, true, plan); err != nil {
+					return err
+				}
+				return c.print(plan, func() table {
+					cost := "<unknown>"
+					if plan.Cost != nil {
+						cost = fmt.Sprintf("%.2f %s", plan.Cost.MonthlyCost, plan.Cost.Currency)
+					}
+					return table{
+						{"NAME", "MASTERS", "NODES", "MONTHLY COST"},
+						{plan.ClusterName, strconv.Itoa(plan.Masters), strconv.Itoa(plan.Nodes), cost},
+					}
+				})
+			}
+
+			resp := &provisioner.ProvisionResponse{}
+			if err = client.Provision(c, req, false, resp); err != nil {
+				return err
+			}
+			return c.print(resp, func() table {
+				return tasksTable(resp.ClusterID, resp.Tasks)
+			})
+		},
+	}
+}
+
+func deleteKubeCommand() *Command {
+	return &Command{
+		Name:  "delete",
+		Args:  "KUBE_ID",
+		Short: "Delete a kube and its cloud resources",
+		NArgs: 1,
+		Run: func(c *Context, args []string) error {
+			client, err := c.Client()
+			if err != nil {
+				return err
+			}
+			if err = client.DeleteKube(c, args[0]); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(c.Stdout, "kube %s is being deleted\n", args[0])
+			return nil
+		},
+	}
+}
+
+func scaleKubeCommand() *Command {
+	var dryRun bool
+	return &Command{
+		Name:  "scale",
+		Args:  "KUBE_ID NODE_GROUP COUNT",
+		Short: "Set the number of machines of a node group",
+		NArgs: 3,
+		Flags: func(fs *pflag.FlagSet) {
+			fs.BoolVar(&dryRun, "dry-run", false, "show changes without applying them")
+		},
+		Run: func(c *Context, args []string) error {
+			count, err := strconv.Atoi(args[2])
+			if err != nil || count < 0 {
+				return errors.Errorf("count %q must be a non-negative number", args[2])
+			}
+			client, err := c.Client()
+			if err != nil {
+				return err
+			}
+
+			data, err := client.GetSpec(c, args[0])
+			if err != nil {
+				return err
+			}
+			spec, err := clusterspec.Parse(data)
+			if err != nil {
+				return errors.Wrap(err, "parse spec")
+			}
+			if err = setGroupCount(spec, args[1], count); err != nil {
+				return err
+			}
+			if data, err = spec.Marshal(); err != nil {
+				return err
+			}
+
+			res := &kube.SpecResult{}
+			if err = client.ApplySpec(c, data, dryRun, res); err != nil {
+				return err
+			}
+			return c.print(res, func() table {
+				t := table{{"GROUP", "ROLE", "SIZE", "CURRENT", "DESIRED", "STATUS"}}
+				for _, changes := range []struct {
+					status  string
+					changes []clusterspec.Change
+				}{{"applied", res.Changes}, {"pending", res.Pending}} {
+					if dryRun && changes.status == "applied" {
+						changes.status = "planned"
+					}
+					for _, ch := range changes.changes {
+						t = append(t, []string{ch.Group, string(ch.Role), ch.Size,
+							strconv.Itoa(ch.Current), strconv.Itoa(ch.Desired), changes.status})
+					}
+				}
+				return t
+			})
+		},
+	}
+}
+
+func kubeconfigCommand() *Command {
+	var file string
+	var ttl time.Duration
+	return &Command{
+		Name:  "kubeconfig",
+		Args:  "KUBE_ID",
+		Short: "Issue a kubeconfig of the user",
+		NArgs: 1,
+		Flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&file, "file", "", "file to write the kubeconfig to, it's printed when empty")
+			fs.DurationVar(&ttl, "ttl", 0, "lifetime of the credentials, the default of control when 0")
+		},
+		Run: func(c *Context, args []string) error {
+			client, err := c.Client()
+			if err != nil {
+				return err
+			}
+			data, err := client.Kubeconfig(c, args[0], ttl)
+			if err != nil {
+				return err
+			}
+
+			if file == "" {
+				_, err = c.Stdout.Write(data)
+				return err
+			}
+			if err = ioutil.WriteFile(file, data, 0600); err != nil {
+				return errors.Wrap(err, "write kubeconfig")
+			}
+			fmt.Fprintf(c.Stdout, "kubeconfig of %s has been written to %s\n", args[0], file)
+			return nil
+		},
+	}
+}
+
+func getTaskCommand() *Command {
+	return &Command{
+		Name:  "get",
+		Args:  "TASK_ID",
+		Short: "Show steps of a task",
+		NArgs: 1,
+		Run: func(c *Context, args []string) error {
+			client, err := c.Client()
+			if err != nil {
+				return err
+			}
+			task := &workflows.Task{}
+			if err = client.GetTask(c, args[0], task); err != nil {
+				return err
+			}
+
+			return c.print(task, func() table {
+				t := table{{"STEP", "STATUS", "CODE", "ERROR"}}
+				for _, s := range task.StepStatuses {
+					code := ""
+					if s.Error != nil {
+						code = strconv.Itoa(int(s.Error.Code))
+					}
+					t = append(t, []string{s.StepName, string(s.Status), code, s.ErrMsg})
+				}
+				return t
+			})
+		},
+	}
+}
+
+func taskLogsCommand() *Command {
+	return &Command{
+		Name:  "logs",
+		Args:  "TASK_ID",
+		Short: "Follow the log of a task until it's interrupted",
+		NArgs: 1,
+		Run: func(c *Context, args []string) error {
+			client, err := c.Client()
+			if err != nil {
+				return err
+			}
+			return client.TailLogs(c, args[0], c.Stdout)
+		},
+	}
+}
+
+func listProfilesCommand() *Command {
+	return &Command{
+		Name:  "list",
+		Short: "List kube profiles",
+		Run: func(c *Context, args []string) error {
+			client, err := c.Client()
+			if err != nil {
+				return err
+			}
+			profiles, err := client.ListProfiles(c)
+			if err != nil {
+				return err
+			}
+
+			return c.print(profiles, func() table {
+				t := table{{"ID", "PROVIDER", "REGION", "VERSION", "MASTERS", "NODES"}}
+				for _, p := range profiles {
+					t = append(t, []string{p.ID, string(p.Provider), orNone(p.Region), orNone(p.K8SVersion),
+						strconv.Itoa(len(p.MasterProfiles)), strconv.Itoa(len(p.NodesProfiles))})
+				}
+				return t
+			})
+		},
+	}
+}
+
+func validateProfileCommand() *Command {
+	var file string
+	return &Command{
+		Name:  "validate",
+		Short: "Validate a JSON or YAML kube profile like control does on creation",
+		Flags: func(fs *pflag.FlagSet) {
+			fs.StringVarP(&file, "file", "f", "", "file of the profile, - reads stdin")
+		},
+		Run: func(c *Context, args []string) error {
+			data, err := readDocument(c, file)
+			if err != nil {
+				return err
+			}
+
+			p := &profile.Profile{}
+			if err = yaml.Unmarshal(data, p); err != nil {
+				return errors.Wrap(err, "decode profile")
+			}
+			// control assigns IDs of new profiles
+			if p.ID == "" {
+				p.ID = "new"
+			}
+			if _, err = govalidator.ValidateStruct(p); err != nil {
+				return errors.Wrap(err, "profile is invalid")
+			}
+			if err = p.Validate(); err != nil {
+				return errors.Wrap(err, "profile is invalid")
+			}
+
+			fmt.Fprintln(c.Stdout, "profile is valid")
+			return nil
+		},
+	}
+}
+
+func exportSnapshotCommand() *Command {
+	var file, passphrase string
+	return &Command{
+		Name:  "export",
+		Short: "Export accounts, kubes, profiles, users and tasks of control",
+		Flags: func(fs *pflag.FlagSet) {
+			fs.StringVarP(&file, "file", "f", "", "file to write the snapshot to, it's printed when empty")
+			fs.StringVar(&passphrase, "passphrase", "", "passphrase that seals secrets, it's read from stdin when empty")
+		},
+		Run: func(c *Context, args []string) error {
+			client, err := c.Client()
+			if err != nil {
+				return err
+			}
+			if passphrase == "" {
+				if passphrase, err = readSecret(c, "Passphrase"); err != nil {
+					return err
+				}
+			}
+			data, err := client.ExportSnapshot(c, passphrase)
+			if err != nil {
+				return err
+			}
+
+			if file == "" {
+				_, err = c.Stdout.Write(data)
+				return err
+			}
+			if err = ioutil.WriteFile(file, data, 0600); err != nil {
+				return errors.Wrap(err, "write snapshot")
+			}
+			fmt.Fprintf(c.Stdout, "snapshot has been written to %s\n", file)
+			return nil
+		},
+	}
+}
+
+func restoreSnapshotCommand() *Command {
+	var file, passphrase string
+	return &Command{
+		Name:  "restore",
+		Short: "Restore a snapshot into a fresh install of control",
+		Flags: func(fs *pflag.FlagSet) {
+			fs.StringVarP(&file, "file", "f", "", "file of the snapshot")
+			fs.StringVar(&passphrase, "passphrase", "", "passphrase of the snapshot, it's read from stdin when empty")
+		},
+		Run: func(c *Context, args []string) error {
+			if file == "" {
+				return errors.New("--file is required")
+			}
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				return errors.Wrap(err, "read snapshot")
+			}
+			client, err := c.Client()
+			if err != nil {
+				return err
+			}
+			if passphrase == "" {
+				if passphrase, err = readSecret(c, "Passphrase"); err != nil {
+					return err
+				}
+			}
+
+			resp, err := client.RestoreSnapshot(c, data, passphrase)
+			if err != nil {
+				return err
+			}
+
+			return c.print(resp, func() table {
+				t := table{{"SECTION", "RESTORED"}}
+				names := make([]string, 0, len(resp.Restored))
+				for name := range resp.Restored {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					t = append(t, []string{name, strconv.Itoa(resp.Restored[name])})
+				}
+				return t
+			})
+		},
+	}
+}
+
+// readSecret reads a line of stdin after the prompt.
+func readSecret(c *Context, prompt string) (string, error) {
+	fmt.Fprint(c.Stderr, prompt+": ")
+	line, err := bufio.NewReader(c.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", errors.Wrapf(err, "read %s", strings.ToLower(prompt))
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readDocument reads the JSON or YAML file and returns it in JSON.
+func readDocument(c *Context, file string) ([]byte, error) {
+	if file == "" {
+		return nil, errors.New("--file is required")
+	}
+
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = ioutil.ReadAll(c.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "read file")
+	}
+
+	data, err = yaml.YAMLToJSON(data)
+	return data, errors.Wrapf(err, "decode %s", file)
+}
+
+func setGroupCount(spec *clusterspec.Cluster, group string, count int) error {
+	names := make([]string, 0, len(spec.Spec.NodeGroups))
+	for i, g := range spec.Spec.NodeGroups {
+		if g.Name == group {
+			spec.Spec.NodeGroups[i].Count = count
+			return nil
+		}
+		names = append(names, g.Name)
+	}
+	return errors.Errorf("kube has no node group %q, node groups: %s", group, strings.Join(names, ", "))
+}
+
+func machinesOf(k *model.Kube) []*model.Machine {
+	machines := make([]*model.Machine, 0, len(k.Masters)+len(k.Nodes))
+	for _, m := range k.Masters {
+		machines = append(machines, m)
+	}
+	for _, m := range k.Nodes {
+		machines = append(machines, m)
+	}
+	sort.Slice(machines, func(i, j int) bool {
+		if machines[i].Role != machines[j].Role {
+			return machines[i].Role == model.RoleMaster
+		}
+		return machines[i].Name < machines[j].Name
+	})
+	return machines
+}
+
+func tasksTable(kubeID string, tasks map[string][]string) table {
+	t := table{{"KUBE", "TASK", "ROLE"}}
+	roles := make([]string, 0, len(tasks))
+	for role := range tasks {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	for _, role := range roles {
+		for _, id := range tasks[role] {
+			t = append(t, []string{kubeID, id, role})
+		}
+	}
+	return t
+}
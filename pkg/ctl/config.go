@@ -0,0 +1,60 @@
+package ctl
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// Config is saved by login, so later commands don't need flags.
+type Config struct {
+	Server string `json:"server"`
+	Token  string `json:"token"`
+}
+
+func defaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".supergiant", "ctl.json")
+}
+
+func loadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "read config")
+	}
+
+	if err = json.Unmarshal(data, cfg); err != nil {
+		return nil, errors.Wrapf(err, "decode config %s", path)
+	}
+	return cfg, nil
+}
+
+// saveConfig writes the config readable only by the user, it has the token.
+func saveConfig(path string, cfg *Config) error {
+	if path == "" {
+		return errors.New("config path is unknown, set --config")
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.Wrap(err, "create config directory")
+	}
+	return errors.Wrap(ioutil.WriteFile(path, data, 0600), "write config")
+}
@@ -0,0 +1,213 @@
+// Package ctl implements supergiantctl, the command line client of the
+// control API. Commands are nested like kubes list, flags of the parent
+// commands are accepted by their subcommands.
+package ctl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+const (
+	ServerEnv = "SUPERGIANT_SERVER"
+	TokenEnv  = "SUPERGIANT_TOKEN"
+
+	defaultServer = "http://localhost:8080"
+)
+
+// ErrUsage is returned for invalid arguments, usage of the command is
+// printed then.
+var ErrUsage = errors.New("invalid usage")
+
+// Command is a command of the CLI, commands with subcommands don't run
+// by themselves.
+type Command struct {
+	Name string
+	// Args of the command in the usage, e.g. KUBE_ID.
+	Args  string
+	Short string
+	// NArgs is the number of arguments the command takes, any number
+	// when it's negative.
+	NArgs    int
+	Flags    func(fs *pflag.FlagSet)
+	Run      func(c *Context, args []string) error
+	Commands []*Command
+
+	parent *Command
+}
+
+// Context of a command run.
+type Context struct {
+	context.Context
+
+	Stdout io.Writer
+	Stderr io.Writer
+	Stdin  io.Reader
+	Format string
+
+	server     string
+	token      string
+	configPath string
+}
+
+// Client returns the client of the API, the server and the token are
+// taken from flags, the environment and the config saved by login in
+// that order.
+func (c *Context) Client() (*Client, error) {
+	cfg, err := loadConfig(c.configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	server := firstNonEmpty(c.server, os.Getenv(ServerEnv), cfg.Server, defaultServer)
+	token := firstNonEmpty(c.token, os.Getenv(TokenEnv), cfg.Token)
+	if token == "" {
+		return nil, errors.New("no token, log in with supergiantctl login or set " + TokenEnv)
+	}
+
+	return NewClient(server, token), nil
+}
+
+// Main runs the command of the arguments and returns the exit code.
+func Main(ctx context.Context, args []string, in io.Reader, out, errOut io.Writer) int {
+	if err := Execute(ctx, NewRootCommand(), args, in, out, errOut); err != nil {
+		if err != ErrUsage {
+			fmt.Fprintln(errOut, "Error:", err)
+		}
+		return 1
+	}
+	return 0
+}
+
+// Execute finds the command of the arguments and runs it.
+func Execute(ctx context.Context, root *Command, args []string, in io.Reader, out, errOut io.Writer) error {
+	cmd := root
+	for len(args) > 0 {
+		sub := cmd.find(args[0])
+		if sub == nil {
+			break
+		}
+		cmd, args = sub, args[1:]
+	}
+
+	c := &Context{
+		Context: ctx,
+		Stdout:  out,
+		Stderr:  errOut,
+		Stdin:   in,
+	}
+	fs := pflag.NewFlagSet(cmd.path(), pflag.ContinueOnError)
+	fs.SetOutput(errOut)
+	fs.StringVar(&c.server, "server", "", "url of control, $"+ServerEnv+" or "+defaultServer+" by default")
+	fs.StringVar(&c.token, "token", "", "API token, $"+TokenEnv+" or the token of the last login by default")
+	fs.StringVarP(&c.Format, "output", "o", formatTable, "output format [table json yaml]")
+	fs.StringVar(&c.configPath, "config", defaultConfigPath(), "file of the server and the token saved by login")
+	for p := cmd; p != nil; p = p.parent {
+		if p.Flags != nil {
+			p.Flags(fs)
+		}
+	}
+	fs.Usage = func() {
+		cmd.usage(errOut, fs)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if err == pflag.ErrHelp {
+			return nil
+		}
+		return ErrUsage
+	}
+	args = fs.Args()
+
+	if cmd.Run == nil {
+		if len(args) > 0 {
+			fmt.Fprintf(errOut, "Error: unknown command %q for %q\n\n", args[0], cmd.path())
+		}
+		fs.Usage()
+		return ErrUsage
+	}
+	if cmd.NArgs >= 0 && len(args) != cmd.NArgs {
+		fmt.Fprintf(errOut, "Error: %q takes %d arguments, %d are given\n\n", cmd.path(), cmd.NArgs, len(args))
+		fs.Usage()
+		return ErrUsage
+	}
+	switch c.Format {
+	case formatTable, formatJSON, formatYAML:
+	default:
+		return errors.Errorf("unknown output format %q", c.Format)
+	}
+
+	return cmd.Run(c, args)
+}
+
+// Add adds subcommands to the command.
+func (c *Command) Add(commands ...*Command) *Command {
+	for _, sub := range commands {
+		sub.parent = c
+		c.Commands = append(c.Commands, sub)
+	}
+	return c
+}
+
+func (c *Command) find(name string) *Command {
+	for _, sub := range c.Commands {
+		if sub.Name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+func (c *Command) path() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.path() + " " + c.Name
+}
+
+func (c *Command) usage(w io.Writer, fs *pflag.FlagSet) {
+	fmt.Fprintln(w, c.Short)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Usage:")
+	if len(c.Commands) > 0 {
+		fmt.Fprintf(w, "  %s COMMAND [flags]\n", c.path())
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Commands:")
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, sub := range c.Commands {
+			fmt.Fprintf(tw, "  %s\t%s\n", sub.Name, sub.Short)
+		}
+		tw.Flush()
+	} else {
+		fmt.Fprintf(w, "  %s\n", strings.Join(nonEmpty(c.path(), c.Args, "[flags]"), " "))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprint(w, fs.FlagUsages())
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func nonEmpty(values ...string) []string {
+	var res []string
+	for _, v := range values {
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
@@ -0,0 +1,310 @@
+package ctl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clusterspec"
+	"github.com/supergiant/control/pkg/kube"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/snapshot"
+	"github.com/supergiant/control/pkg/storage"
+	"github.com/supergiant/control/pkg/user"
+)
+
+const testToken = "secret"
+
+const testSpec = `apiVersion: control.supergiant.io/v1
+kind: Cluster
+metadata:
+  name: prod
+  id: kube1
+spec:
+  account: aws
+  profile: {}
+  nodeGroups:
+  - name: masters
+    role: master
+    count: 1
+  - name: workers
+    role: node
+    count: 2
+`
+
+// fakeControl serves the API used by supergiantctl.
+func fakeControl(t *testing.T) *httptest.Server {
+	r := mux.NewRouter()
+	r.HandleFunc("/auth", func(w http.ResponseWriter, r *http.Request) {
+		req := user.AuthRequest{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.Login != "root" || req.Password != "1234" {
+			http.Error(w, "invalid credentials", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Authorization", testToken)
+	})
+
+	api := r.PathPrefix(apiPrefix).Subrouter()
+	api.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer "+testToken {
+				http.Error(w, "invalid token", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	api.HandleFunc("/kubes", func(w http.ResponseWriter, r *http.Request) {
+		// kubes are returned in two pages
+		if r.URL.Query().Get("continue") == "" {
+			w.Header().Set(storage.ContinueHeader, "page2")
+			json.NewEncoder(w).Encode([]model.Kube{{ID: "kube1", Name: "prod", Provider: "aws", State: model.StateOperational}})
+			return
+		}
+		json.NewEncoder(w).Encode([]model.Kube{{ID: "kube2", Name: "dev", Provider: "fake", State: model.StateProvisioning}})
+	}).Methods(http.MethodGet)
+	api.HandleFunc("/kubes", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "application/yaml", r.Header.Get("Content-Type"))
+		data, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		c, err := clusterspec.Parse(data)
+		require.NoError(t, err)
+		require.Equal(t, 5, c.Spec.NodeGroups[1].Count)
+
+		json.NewEncoder(w).Encode(kube.SpecResult{
+			ClusterID: "kube1",
+			Changes:   []clusterspec.Change{{Group: "workers", Role: model.RoleNode, Size: "m4.large", Current: 2, Desired: 5}},
+		})
+	}).Methods(http.MethodPost)
+	api.HandleFunc("/kubes/{kubeID}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["kubeID"] != "kube1" {
+			message.SendNotFound(w, mux.Vars(r)["kubeID"], sgerrors.ErrNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(model.Kube{ID: "kube1", Name: "prod", Masters: map[string]*model.Machine{
+			"m1": {Name: "prod-master-1", Role: model.RoleMaster, State: model.MachineStateActive, PublicIp: "1.2.3.4"},
+		}})
+	}).Methods(http.MethodGet)
+	api.HandleFunc("/kubes/{kubeID}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}).Methods(http.MethodDelete)
+	api.HandleFunc("/kubes/{kubeID}/spec", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write([]byte(testSpec))
+	}).Methods(http.MethodGet)
+	api.HandleFunc("/kubes/{kubeID}/kubeconfig", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "1h0m0s", r.URL.Query().Get("ttl"))
+		w.Write([]byte("apiVersion: v1\nkind: Config\n"))
+	}).Methods(http.MethodGet)
+	api.HandleFunc("/admin/snapshot", func(w http.ResponseWriter, r *http.Request) {
+		req := snapshot.ExportRequest{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "passphrase", req.Passphrase)
+		w.Write([]byte(`{"version":1,"sections":[]}`))
+	}).Methods(http.MethodPost)
+	api.HandleFunc("/admin/snapshot/restore", func(w http.ResponseWriter, r *http.Request) {
+		req := snapshot.RestoreRequest{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "passphrase", req.Passphrase)
+		require.Equal(t, snapshot.Version, req.Snapshot.Version)
+		json.NewEncoder(w).Encode(snapshot.RestoreResponse{Restored: map[string]int{"kubes": 2, "accounts": 1}})
+	}).Methods(http.MethodPost)
+	api.HandleFunc("/tasks/{id}/logs", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		for _, line := range []string{"[step1] - started", "[step1] - success"} {
+			require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(line)))
+		}
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	}).Methods(http.MethodGet)
+
+	return httptest.NewServer(r)
+}
+
+func run(t *testing.T, stdin string, args ...string) (string, string, error) {
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	err := Execute(context.Background(), NewRootCommand(), args, strings.NewReader(stdin), out, errOut)
+	return out.String(), errOut.String(), err
+}
+
+func TestLogin(t *testing.T) {
+	srv := fakeControl(t)
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "ctl")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	config := filepath.Join(dir, "ctl.json")
+
+	_, _, err = run(t, "", "login", "--server", srv.URL, "--config", config, "--user", "root", "--password", "wrong")
+	require.EqualError(t, err, "invalid credentials (403)")
+
+	out, _, err := run(t, "1234\n", "login", "--server", srv.URL, "--config", config, "--user", "root")
+	require.NoError(t, err)
+	require.Contains(t, out, "Logged in to "+srv.URL)
+
+	info, err := os.Stat(config)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// the saved server and token are used by later commands
+	out, _, err = run(t, "", "kubes", "list", "--config", config)
+	require.NoError(t, err)
+	require.Contains(t, out, "kube2")
+}
+
+func TestKubesList(t *testing.T) {
+	srv := fakeControl(t)
+	defer srv.Close()
+
+	out, _, err := run(t, "", "kubes", "list", "--server", srv.URL, "--token", testToken, "--config", "")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3)
+	require.True(t, strings.HasPrefix(lines[0], "ID"), out)
+	require.Contains(t, lines[1], "prod")
+	require.Contains(t, lines[2], "dev")
+
+	out, _, err = run(t, "", "kubes", "list", "-o", "json", "--server", srv.URL, "--token", testToken, "--config", "")
+	require.NoError(t, err)
+	kubes := make([]model.Kube, 0)
+	require.NoError(t, json.Unmarshal([]byte(out), &kubes))
+	require.Len(t, kubes, 2)
+
+	out, _, err = run(t, "", "kubes", "list", "-o", "yaml", "--server", srv.URL, "--token", testToken, "--config", "")
+	require.NoError(t, err)
+	require.Contains(t, out, "name: prod")
+
+	_, _, err = run(t, "", "kubes", "list", "--server", srv.URL, "--token", "wrong", "--config", "")
+	require.EqualError(t, err, "invalid token (403)")
+}
+
+func TestKubesGet(t *testing.T) {
+	srv := fakeControl(t)
+	defer srv.Close()
+
+	out, _, err := run(t, "", "kubes", "get", "kube1", "--server", srv.URL, "--token", testToken, "--config", "")
+	require.NoError(t, err)
+	require.Contains(t, out, "prod-master-1")
+	require.Contains(t, out, "1.2.3.4")
+
+	_, _, err = run(t, "", "kubes", "get", "missing", "--server", srv.URL, "--token", testToken, "--config", "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "(404)")
+	require.Equal(t, sgerrors.NotFound, err.(*APIError).Code)
+}
+
+func TestKubesScale(t *testing.T) {
+	srv := fakeControl(t)
+	defer srv.Close()
+
+	out, _, err := run(t, "", "kubes", "scale", "kube1", "workers", "5", "--server", srv.URL, "--token", testToken, "--config", "")
+	require.NoError(t, err)
+	require.Contains(t, out, "workers")
+	require.Contains(t, out, "applied")
+
+	_, _, err = run(t, "", "kubes", "scale", "kube1", "gpu", "5", "--server", srv.URL, "--token", testToken, "--config", "")
+	require.EqualError(t, err, `kube has no node group "gpu", node groups: masters, workers`)
+
+	_, _, err = run(t, "", "kubes", "scale", "kube1", "workers", "many", "--server", srv.URL, "--token", testToken, "--config", "")
+	require.Error(t, err)
+}
+
+func TestKubesDeleteAndKubeconfig(t *testing.T) {
+	srv := fakeControl(t)
+	defer srv.Close()
+
+	out, _, err := run(t, "", "kubes", "delete", "kube1", "--server", srv.URL, "--token", testToken, "--config", "")
+	require.NoError(t, err)
+	require.Equal(t, "kube kube1 is being deleted\n", out)
+
+	dir, err := ioutil.TempDir("", "ctl")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "kubeconfig")
+
+	_, _, err = run(t, "", "kubes", "kubeconfig", "kube1", "--ttl", "1h", "--file", file,
+		"--server", srv.URL, "--token", testToken, "--config", "")
+	require.NoError(t, err)
+	data, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "kind: Config")
+}
+
+func TestTasksLogs(t *testing.T) {
+	srv := fakeControl(t)
+	defer srv.Close()
+
+	out, _, err := run(t, "", "tasks", "logs", "task1", "--server", srv.URL, "--token", testToken, "--config", "")
+	require.NoError(t, err)
+	require.Equal(t, "[step1] - started\n[step1] - success\n", out)
+}
+
+func TestSnapshot(t *testing.T) {
+	srv := fakeControl(t)
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "ctl")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "snapshot.json")
+
+	// the passphrase is read from stdin
+	_, errOut, err := run(t, "passphrase\n", "snapshot", "export", "-f", file,
+		"--server", srv.URL, "--token", testToken, "--config", "")
+	require.NoError(t, err)
+	require.Equal(t, "Passphrase: ", errOut)
+
+	out, _, err := run(t, "", "snapshot", "restore", "-f", file, "--passphrase", "passphrase",
+		"--server", srv.URL, "--token", testToken, "--config", "")
+	require.NoError(t, err)
+	require.Equal(t, "SECTION    RESTORED\naccounts   1\nkubes      2\n", out)
+}
+
+func TestProfilesValidate(t *testing.T) {
+	out, _, err := run(t, `{"provider": "aws", "region": "us-east-1", "K8SVersion": "1.14.1"}`,
+		"profiles", "validate", "-f", "-", "--config", "")
+	require.NoError(t, err)
+	require.Equal(t, "profile is valid\n", out)
+
+	_, _, err = run(t, "{not a profile", "profiles", "validate", "-f", "-", "--config", "")
+	require.Error(t, err)
+
+	_, _, err = run(t, "", "profiles", "validate", "--config", "")
+	require.EqualError(t, err, "--file is required")
+}
+
+func TestUsage(t *testing.T) {
+	_, errOut, err := run(t, "", "kubes")
+	require.Equal(t, ErrUsage, err)
+	require.Contains(t, errOut, "scale")
+
+	_, errOut, err = run(t, "", "kubes", "get")
+	require.Equal(t, ErrUsage, err)
+	require.Contains(t, errOut, "takes 1 arguments")
+
+	_, errOut, err = run(t, "", "kubes", "unknown")
+	require.Equal(t, ErrUsage, err)
+	require.Contains(t, errOut, `unknown command "unknown"`)
+
+	_, _, err = run(t, "", "kubes", "list", "-o", "xml", "--token", testToken)
+	require.EqualError(t, err, `unknown output format "xml"`)
+
+	_, _, err = run(t, "", "kubes", "list", "--config", "", "--token", "")
+	require.Error(t, err, "a token is required")
+}
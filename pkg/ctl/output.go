@@ -0,0 +1,59 @@
+package ctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+// table is rows of the table output, the first row is the header.
+type table [][]string
+
+// print writes v in the output format of the command, rows builds the table
+// of v for the table format.
+func (c *Context) print(v interface{}, rows func() table) error {
+	switch c.Format {
+	case formatJSON:
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(c.Stdout, string(data))
+		return err
+	case formatYAML:
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = c.Stdout.Write(data)
+		return err
+	}
+
+	return writeTable(c.Stdout, rows())
+}
+
+func writeTable(w io.Writer, t table) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 3, ' ', 0)
+	for _, row := range t {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// orNone shows empty values in tables.
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
@@ -0,0 +1,189 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+)
+
+const (
+	azureEndpoint   = "https://management.azure.com"
+	azureAPIVersion = "2018-05-01"
+)
+
+// azure changes record sets of DNS zones of the subscription with the REST
+// API of Azure Resource Manager, the DNS client of its SDK isn't vendored.
+type azure struct {
+	endpoint      string
+	subscription  string
+	resourceGroup string
+	authorizer    autorest.Authorizer
+	http          *http.Client
+}
+
+func newAzure(creds map[string]string) (*azure, error) {
+	a, err := auth.NewClientCredentialsConfig(
+		creds[clouds.AzureClientID],
+		creds[clouds.AzureClientSecret],
+		creds[clouds.AzureTenantID],
+	).Authorizer()
+	if err != nil {
+		return nil, errors.Wrap(err, "azure authorizer")
+	}
+
+	return &azure{
+		endpoint:      azureEndpoint,
+		subscription:  creds[clouds.AzureSubscriptionID],
+		resourceGroup: creds[AzureResourceGroup],
+		authorizer:    a,
+		http:          &http.Client{Timeout: time.Second * 30},
+	}, nil
+}
+
+type azureZones struct {
+	Value []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"value"`
+	NextLink string `json:"nextLink"`
+}
+
+type azureRecordSet struct {
+	Properties azureRecordProperties `json:"properties"`
+}
+
+type azureRecordProperties struct {
+	TTL         int64             `json:"TTL"`
+	ARecords    []azureARecord    `json:"ARecords,omitempty"`
+	CNAMERecord *azureCNAMERecord `json:"CNAMERecord,omitempty"`
+	TXTRecords  []azureTXTRecord  `json:"TXTRecords,omitempty"`
+}
+
+type azureARecord struct {
+	IPv4Address string `json:"ipv4Address"`
+}
+
+type azureCNAMERecord struct {
+	CNAME string `json:"cname"`
+}
+
+type azureTXTRecord struct {
+	Value []string `json:"value"`
+}
+
+func (a *azure) Upsert(ctx context.Context, r Record) error {
+	u, err := a.recordURL(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	set := azureRecordSet{Properties: azureRecordProperties{TTL: ttlOf(r)}}
+	switch r.Type {
+	case TypeA:
+		for _, v := range r.Values {
+			set.Properties.ARecords = append(set.Properties.ARecords, azureARecord{v})
+		}
+	case TypeCNAME:
+		if len(r.Values) != 1 {
+			return errors.Errorf("cname %s needs a single value", r.Name)
+		}
+		set.Properties.CNAMERecord = &azureCNAMERecord{r.Values[0]}
+	case TypeTXT:
+		for _, v := range r.Values {
+			set.Properties.TXTRecords = append(set.Properties.TXTRecords, azureTXTRecord{[]string{v}})
+		}
+	default:
+		return errors.Errorf("unsupported record type %s", r.Type)
+	}
+
+	body, err := json.Marshal(set)
+	if err != nil {
+		return err
+	}
+	return a.do(ctx, http.MethodPut, u, body, nil)
+}
+
+func (a *azure) Delete(ctx context.Context, r Record) error {
+	u, err := a.recordURL(ctx, r)
+	if err != nil {
+		return err
+	}
+	return a.do(ctx, http.MethodDelete, u, nil, nil)
+}
+
+// recordURL returns the url of the record set of the record.
+func (a *azure) recordURL(ctx context.Context, r Record) (string, error) {
+	ids := make(map[string]string)
+	names := make([]string, 0)
+
+	u := a.endpoint + "/subscriptions/" + a.subscription
+	if a.resourceGroup != "" {
+		u += "/resourceGroups/" + a.resourceGroup
+	}
+	u += "/providers/Microsoft.Network/dnszones?api-version=" + azureAPIVersion
+	for u != "" {
+		zones := &azureZones{}
+		if err := a.do(ctx, http.MethodGet, u, nil, zones); err != nil {
+			return "", errors.Wrap(err, "list dns zones")
+		}
+		for _, z := range zones.Value {
+			ids[z.Name] = z.ID
+			names = append(names, z.Name)
+		}
+		u = zones.NextLink
+	}
+
+	zone, ok := ZoneOf(r.Name, names)
+	if !ok {
+		return "", errors.Errorf("no dns zone of %s", r.Name)
+	}
+
+	return a.endpoint + ids[zone] + "/" + r.Type + "/" + relativeName(r.Name, zone) +
+		"?api-version=" + azureAPIVersion, nil
+}
+
+func (a *azure) do(ctx context.Context, method, u string, body []byte, out interface{}) error {
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if req, err = autorest.Prepare(req, a.authorizer.WithAuthorization()); err != nil {
+		return errors.Wrap(err, "authorize request")
+	}
+
+	resp, err := a.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusNotFound && method == http.MethodDelete {
+		return nil
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return errors.Errorf("azure dns: %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	if out != nil {
+		return json.Unmarshal(data, out)
+	}
+	return nil
+}
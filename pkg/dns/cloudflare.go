@@ -0,0 +1,213 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const cloudflareEndpoint = "https://api.cloudflare.com/client/v4"
+
+// cloudflare keeps a record of every value, zones are the ones the api
+// token has access to.
+type cloudflare struct {
+	endpoint string
+	token    string
+	http     *http.Client
+}
+
+func newCloudflare(token string) *cloudflare {
+	return &cloudflare{
+		endpoint: cloudflareEndpoint,
+		token:    token,
+		http:     &http.Client{Timeout: time.Second * 30},
+	}
+}
+
+// cloudflareResponse is an envelope of responses of the api.
+type cloudflareResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+	Result     json.RawMessage `json:"result"`
+	ResultInfo struct {
+		Page       int `json:"page"`
+		TotalPages int `json:"total_pages"`
+	} `json:"result_info"`
+}
+
+type cloudflareRecord struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int64  `json:"ttl"`
+}
+
+// Upsert creates records of missing values and deletes records of values
+// that aren't in the record anymore.
+func (c *cloudflare) Upsert(ctx context.Context, r Record) error {
+	zoneID, err := c.zone(ctx, r.Name)
+	if err != nil {
+		return err
+	}
+
+	existing, err := c.records(ctx, zoneID, r)
+	if err != nil {
+		return err
+	}
+	found := make(map[string]bool, len(existing))
+	for _, rec := range existing {
+		if !contains(r.Values, rec.Content) || found[rec.Content] || rec.TTL != ttlOf(r) {
+			if err = c.do(ctx, http.MethodDelete, "/zones/"+zoneID+"/dns_records/"+rec.ID, nil, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		found[rec.Content] = true
+	}
+
+	for _, v := range r.Values {
+		if found[v] {
+			continue
+		}
+		body, err := json.Marshal(cloudflareRecord{
+			Type:    r.Type,
+			Name:    r.Name,
+			Content: v,
+			TTL:     ttlOf(r),
+		})
+		if err != nil {
+			return err
+		}
+		if err = c.do(ctx, http.MethodPost, "/zones/"+zoneID+"/dns_records", body, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes records of the values, all records of the name and the
+// type if there are none.
+func (c *cloudflare) Delete(ctx context.Context, r Record) error {
+	zoneID, err := c.zone(ctx, r.Name)
+	if err != nil {
+		return err
+	}
+
+	existing, err := c.records(ctx, zoneID, r)
+	if err != nil {
+		return err
+	}
+	for _, rec := range existing {
+		if len(r.Values) > 0 && !contains(r.Values, rec.Content) {
+			continue
+		}
+		if err = c.do(ctx, http.MethodDelete, "/zones/"+zoneID+"/dns_records/"+rec.ID, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *cloudflare) records(ctx context.Context, zoneID string, r Record) ([]cloudflareRecord, error) {
+	var records []cloudflareRecord
+	for page := 1; ; page++ {
+		var batch []cloudflareRecord
+		q := url.Values{"type": {r.Type}, "name": {r.Name}, "page": {fmt.Sprint(page)}, "per_page": {"100"}}
+		resp, err := c.get(ctx, "/zones/"+zoneID+"/dns_records?"+q.Encode(), &batch)
+		if err != nil {
+			return nil, errors.Wrapf(err, "list records of %s", r.Name)
+		}
+		records = append(records, batch...)
+		if resp.ResultInfo.Page >= resp.ResultInfo.TotalPages {
+			return records, nil
+		}
+	}
+}
+
+// zone returns the id of the zone of the name.
+func (c *cloudflare) zone(ctx context.Context, fqdn string) (string, error) {
+	ids := make(map[string]string)
+	names := make([]string, 0)
+	for page := 1; ; page++ {
+		var zones []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		}
+		resp, err := c.get(ctx, fmt.Sprintf("/zones?page=%d&per_page=50", page), &zones)
+		if err != nil {
+			return "", errors.Wrap(err, "list zones")
+		}
+		for _, z := range zones {
+			ids[z.Name] = z.ID
+			names = append(names, z.Name)
+		}
+		if resp.ResultInfo.Page >= resp.ResultInfo.TotalPages {
+			break
+		}
+	}
+
+	zone, ok := ZoneOf(fqdn, names)
+	if !ok {
+		return "", errors.Errorf("no zone of %s", fqdn)
+	}
+	return ids[zone], nil
+}
+
+func (c *cloudflare) get(ctx context.Context, path string, result interface{}) (*cloudflareResponse, error) {
+	resp := &cloudflareResponse{}
+	if err := c.do(ctx, http.MethodGet, path, nil, resp); err != nil {
+		return nil, err
+	}
+	return resp, json.Unmarshal(resp.Result, result)
+}
+
+func (c *cloudflare) do(ctx context.Context, method, path string, body []byte, out *cloudflareResponse) error {
+	req, err := http.NewRequest(method, c.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	envelope := &cloudflareResponse{}
+	if err = json.Unmarshal(data, envelope); err != nil || !envelope.Success {
+		msgs := make([]string, 0, len(envelope.Errors))
+		for _, e := range envelope.Errors {
+			msgs = append(msgs, e.Message)
+		}
+		if len(msgs) == 0 {
+			msgs = append(msgs, strings.TrimSpace(string(data)))
+		}
+		return errors.Errorf("cloudflare: %s: %s", resp.Status, strings.Join(msgs, "; "))
+	}
+	if out != nil {
+		*out = *envelope
+	}
+	return nil
+}
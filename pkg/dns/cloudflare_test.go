@@ -0,0 +1,62 @@
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloudflare(t *testing.T) {
+	var (
+		created []cloudflareRecord
+		deleted []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/zones" && r.URL.Query().Get("page") == "1":
+			w.Write([]byte(`{"success":true,"result":[{"id":"z1","name":"example.com"}],"result_info":{"page":1,"total_pages":2}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/zones":
+			w.Write([]byte(`{"success":true,"result":[{"id":"z2","name":"sub.example.com"}],"result_info":{"page":2,"total_pages":2}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/zones/z2/dns_records":
+			require.Equal(t, "api.sub.example.com", r.URL.Query().Get("name"))
+			w.Write([]byte(`{"success":true,"result":[
+				{"id":"r1","type":"A","name":"api.sub.example.com","content":"10.0.0.1","ttl":300},
+				{"id":"r2","type":"A","name":"api.sub.example.com","content":"10.0.0.9","ttl":300}
+			],"result_info":{"page":1,"total_pages":1}}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/zones/z2/dns_records":
+			rec := cloudflareRecord{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&rec))
+			created = append(created, rec)
+			w.Write([]byte(`{"success":true,"result":{}}`))
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/zones/z2/dns_records/"):
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/zones/z2/dns_records/"))
+			w.Write([]byte(`{"success":true,"result":{}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"success":false,"errors":[{"message":"not found"}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := newCloudflare("token")
+	c.endpoint = srv.URL
+
+	rec := Record{Name: "api.sub.example.com", Type: TypeA, Values: []string{"10.0.0.1", "10.0.0.2"}}
+	require.NoError(t, c.Upsert(context.Background(), rec))
+	require.Equal(t, []string{"r2"}, deleted)
+	require.Equal(t, []cloudflareRecord{{Type: TypeA, Name: "api.sub.example.com", Content: "10.0.0.2", TTL: DefaultTTL}}, created)
+
+	deleted = nil
+	require.NoError(t, c.Delete(context.Background(), Record{Name: "api.sub.example.com", Type: TypeA}))
+	require.Equal(t, []string{"r1", "r2"}, deleted)
+
+	err := c.Upsert(context.Background(), Record{Name: "api.other.com", Type: TypeA, Values: []string{"10.0.0.1"}})
+	require.Error(t, err)
+}
@@ -0,0 +1,136 @@
+package dns
+
+import (
+	"context"
+
+	"github.com/digitalocean/godo"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+
+	"github.com/supergiant/control/pkg/clouds/digitaloceansdk"
+	"github.com/supergiant/control/pkg/tracing"
+)
+
+// digitalOcean keeps a record of every value, records are relative to
+// domains of the account.
+type digitalOcean struct {
+	domains godo.DomainsService
+}
+
+func newDigitalOcean(token string) *digitalOcean {
+	ts := &digitaloceansdk.TokenSource{AccessToken: token}
+	return &digitalOcean{
+		domains: godo.NewClient(tracing.Client("digitalocean",
+			oauth2.NewClient(context.Background(), ts))).Domains,
+	}
+}
+
+// Upsert creates records of missing values and deletes records of values
+// that aren't in the record anymore.
+func (d *digitalOcean) Upsert(ctx context.Context, r Record) error {
+	zone, name, err := d.zone(ctx, r.Name)
+	if err != nil {
+		return err
+	}
+
+	existing, err := d.records(ctx, zone, name, r.Type)
+	if err != nil {
+		return err
+	}
+	found := make(map[string]bool, len(existing))
+	for _, rec := range existing {
+		if !contains(r.Values, rec.Data) || found[rec.Data] || rec.TTL != int(ttlOf(r)) {
+			if _, err = d.domains.DeleteRecord(ctx, zone, rec.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		found[rec.Data] = true
+	}
+
+	for _, v := range r.Values {
+		if found[fqdnValue(r.Type, v)] || found[v] {
+			continue
+		}
+		_, _, err = d.domains.CreateRecord(ctx, zone, &godo.DomainRecordEditRequest{
+			Type: r.Type,
+			Name: name,
+			Data: fqdnValue(r.Type, v),
+			TTL:  int(ttlOf(r)),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes records of the values, all records of the name and the
+// type if there are none.
+func (d *digitalOcean) Delete(ctx context.Context, r Record) error {
+	zone, name, err := d.zone(ctx, r.Name)
+	if err != nil {
+		return err
+	}
+
+	existing, err := d.records(ctx, zone, name, r.Type)
+	if err != nil {
+		return err
+	}
+	for _, rec := range existing {
+		if len(r.Values) > 0 && !contains(r.Values, rec.Data) {
+			continue
+		}
+		if _, err = d.domains.DeleteRecord(ctx, zone, rec.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *digitalOcean) records(ctx context.Context, zone, name, typ string) ([]godo.DomainRecord, error) {
+	var found []godo.DomainRecord
+	for page := 1; ; page++ {
+		records, resp, err := d.domains.Records(ctx, zone, &godo.ListOptions{Page: page, PerPage: 200})
+		if err != nil {
+			return nil, err
+		}
+		for _, rec := range records {
+			if rec.Type == typ && rec.Name == name {
+				found = append(found, rec)
+			}
+		}
+		if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+			return found, nil
+		}
+	}
+}
+
+// zone returns the domain of the name and the name relative to it.
+func (d *digitalOcean) zone(ctx context.Context, fqdn string) (string, string, error) {
+	domains, _, err := d.domains.List(ctx, &godo.ListOptions{PerPage: 200})
+	if err != nil {
+		return "", "", err
+	}
+
+	names := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		names = append(names, domain.Name)
+	}
+	zone, ok := ZoneOf(fqdn, names)
+	if !ok {
+		return "", "", errors.Errorf("no domain of %s", fqdn)
+	}
+	return zone, relativeName(fqdn, zone), nil
+}
+
+// contains reports whether the value is one of values, hostnames are
+// compared with and without the trailing dot.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value || fqdnValue(TypeCNAME, v) == value {
+			return true
+		}
+	}
+	return false
+}
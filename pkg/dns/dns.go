@@ -0,0 +1,179 @@
+// Package dns manages records of zones hosted by cloud DNS services,
+// providers are configured per cloud account.
+package dns
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// Providers of DNS zones.
+const (
+	Route53      = "route53"
+	Google       = "google"
+	Azure        = "azure"
+	DigitalOcean = "digitalocean"
+	Cloudflare   = "cloudflare"
+)
+
+// Credentials of providers that aren't cloud accounts, providers of clouds
+// take the credentials of their accounts.
+const (
+	CloudflareAPIToken = "apiToken"
+	// AzureResourceGroup limits azure zones to the resource group.
+	AzureResourceGroup = "resourceGroup"
+)
+
+// Types of records.
+const (
+	TypeA     = "A"
+	TypeCNAME = "CNAME"
+	TypeTXT   = "TXT"
+)
+
+// DefaultTTL of records that don't set it.
+const DefaultTTL = 300
+
+// Record is a set of values of the name and the type, names are fully
+// qualified without the trailing dot.
+type Record struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	TTL    int64    `json:"ttl"`
+	Values []string `json:"values"`
+}
+
+// Provider changes records of zones, the zone of a record is the longest
+// one its name belongs to.
+type Provider interface {
+	// Upsert creates the record or replaces its values.
+	Upsert(ctx context.Context, r Record) error
+	// Delete removes the record, providers that keep values as sets need
+	// the values it was set with.
+	Delete(ctx context.Context, r Record) error
+}
+
+// cloudProviders are DNS services of clouds.
+var cloudProviders = map[clouds.Name]string{
+	clouds.AWS:          Route53,
+	clouds.GCE:          Google,
+	clouds.Azure:        Azure,
+	clouds.DigitalOcean: DigitalOcean,
+}
+
+// SettingsOf returns the DNS provider of the account with its credentials.
+// Zones are in the DNS of the cloud of the account unless it sets another
+// provider, credentials of the account are used for the DNS of its cloud.
+func SettingsOf(acc *model.CloudAccount) (string, map[string]string, error) {
+	provider, creds := acc.DNS.Provider, acc.DNS.Credentials
+	if provider == "" {
+		provider = cloudProviders[acc.Provider]
+	}
+	if provider == "" {
+		return "", nil, errors.Wrapf(sgerrors.ErrUnsupportedProvider, "dns of %s", acc.Provider)
+	}
+	if len(creds) == 0 && provider == cloudProviders[acc.Provider] {
+		creds = acc.Credentials
+	}
+
+	var required []string
+	switch provider {
+	case Route53:
+		required = []string{clouds.AWSAccessKeyID, clouds.AWSSecretKey}
+	case Google:
+		required = []string{clouds.GCEProjectID, clouds.GCEPrivateKey, clouds.GCEClientEmail, clouds.GCETokenURI}
+	case Azure:
+		required = []string{clouds.AzureTenantID, clouds.AzureSubscriptionID, clouds.AzureClientID, clouds.AzureClientSecret}
+	case DigitalOcean:
+		required = []string{clouds.DigitalOceanAccessToken}
+	case Cloudflare:
+		required = []string{CloudflareAPIToken}
+	default:
+		return "", nil, errors.Wrapf(sgerrors.ErrUnsupportedProvider, "dns provider %q", provider)
+	}
+	for _, key := range required {
+		if creds[key] == "" {
+			return "", nil, errors.Wrapf(sgerrors.ErrInvalidCredentials, "%s dns: %s is missing", provider, key)
+		}
+	}
+
+	return provider, creds, nil
+}
+
+// New returns the DNS provider of the account.
+func New(acc *model.CloudAccount) (Provider, error) {
+	provider, creds, err := SettingsOf(acc)
+	if err != nil {
+		return nil, err
+	}
+
+	switch provider {
+	case Route53:
+		return newRoute53(creds[clouds.AWSAccessKeyID], creds[clouds.AWSSecretKey])
+	case Google:
+		return newGoogle(creds)
+	case Azure:
+		return newAzure(creds)
+	case DigitalOcean:
+		return newDigitalOcean(creds[clouds.DigitalOceanAccessToken]), nil
+	}
+	return newCloudflare(creds[CloudflareAPIToken]), nil
+}
+
+// RecordFor returns the record of the name that points to the target, an A
+// record for ip addresses and a CNAME for hostnames.
+func RecordFor(name, target string) Record {
+	r := Record{
+		Name:   strings.TrimSuffix(name, "."),
+		Type:   TypeCNAME,
+		TTL:    DefaultTTL,
+		Values: []string{strings.TrimSuffix(target, ".")},
+	}
+	if ip := net.ParseIP(target); ip != nil && ip.To4() != nil {
+		r.Type = TypeA
+	}
+	return r
+}
+
+// ZoneOf returns the longest zone the name belongs to.
+func ZoneOf(fqdn string, zones []string) (string, bool) {
+	best := ""
+	for _, z := range zones {
+		z = strings.TrimSuffix(z, ".")
+		if (fqdn == z || strings.HasSuffix(fqdn, "."+z)) && len(z) > len(best) {
+			best = z
+		}
+	}
+	return best, best != ""
+}
+
+// relativeName returns the name relative to the zone, @ for the apex.
+func relativeName(fqdn, zone string) string {
+	if fqdn == zone {
+		return "@"
+	}
+	return strings.TrimSuffix(fqdn, "."+zone)
+}
+
+func ttlOf(r Record) int64 {
+	if r.TTL > 0 {
+		return r.TTL
+	}
+	return DefaultTTL
+}
+
+// fqdnValue adds the trailing dot to hostnames of CNAME records, providers
+// that keep them fully qualified need it.
+func fqdnValue(typ, value string) string {
+	if typ == TypeCNAME && !strings.HasSuffix(value, ".") {
+		return value + "."
+	}
+	return value
+}
@@ -0,0 +1,64 @@
+package dns
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+func TestZoneOf(t *testing.T) {
+	zones := []string{"example.com.", "sub.example.com.", "other.com."}
+
+	zone, ok := ZoneOf("_acme-challenge.a.sub.example.com", zones)
+	require.True(t, ok)
+	require.Equal(t, "sub.example.com", zone)
+	zone, ok = ZoneOf("_acme-challenge.example.com", zones)
+	require.True(t, ok)
+	require.Equal(t, "example.com", zone)
+	_, ok = ZoneOf("_acme-challenge.notexample.com", zones)
+	require.False(t, ok)
+}
+
+func TestSettingsOf(t *testing.T) {
+	awsCreds := map[string]string{clouds.AWSAccessKeyID: "key", clouds.AWSSecretKey: "secret"}
+
+	provider, creds, err := SettingsOf(&model.CloudAccount{Provider: clouds.AWS, Credentials: awsCreds})
+	require.NoError(t, err)
+	require.Equal(t, Route53, provider)
+	require.Equal(t, awsCreds, creds)
+
+	provider, creds, err = SettingsOf(&model.CloudAccount{
+		Provider:    clouds.AWS,
+		Credentials: awsCreds,
+		DNS:         model.AccountDNS{Provider: Cloudflare, Credentials: map[string]string{CloudflareAPIToken: "token"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, Cloudflare, provider)
+	require.Equal(t, "token", creds[CloudflareAPIToken])
+
+	// credentials of the account aren't credentials of other providers
+	_, _, err = SettingsOf(&model.CloudAccount{
+		Provider:    clouds.AWS,
+		Credentials: awsCreds,
+		DNS:         model.AccountDNS{Provider: Google},
+	})
+	require.Equal(t, sgerrors.ErrInvalidCredentials, errors.Cause(err))
+
+	_, _, err = SettingsOf(&model.CloudAccount{Provider: clouds.AWS, DNS: model.AccountDNS{Provider: "bind"}})
+	require.True(t, sgerrors.IsUnsupportedProvider(errors.Cause(err)))
+
+	_, _, err = SettingsOf(&model.CloudAccount{Provider: clouds.OpenStack})
+	require.True(t, sgerrors.IsUnsupportedProvider(errors.Cause(err)))
+}
+
+func TestRecordFor(t *testing.T) {
+	require.Equal(t, Record{Name: "api.example.com", Type: TypeA, TTL: DefaultTTL, Values: []string{"10.0.0.1"}},
+		RecordFor("api.example.com.", "10.0.0.1"))
+	require.Equal(t, Record{Name: "api.example.com", Type: TypeCNAME, TTL: DefaultTTL, Values: []string{"lb.elb.amazonaws.com"}},
+		RecordFor("api.example.com", "lb.elb.amazonaws.com."))
+}
@@ -0,0 +1,113 @@
+package dns
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2/jwt"
+	clouddns "google.golang.org/api/dns/v1"
+	"google.golang.org/api/googleapi"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/tracing"
+)
+
+// google changes record sets of Cloud DNS managed zones of the project.
+type google struct {
+	svc     *clouddns.Service
+	project string
+}
+
+func newGoogle(creds map[string]string) (*google, error) {
+	conf := jwt.Config{
+		Email:      creds[clouds.GCEClientEmail],
+		PrivateKey: []byte(creds[clouds.GCEPrivateKey]),
+		Scopes:     []string{clouddns.NdevClouddnsReadwriteScope},
+		TokenURL:   creds[clouds.GCETokenURI],
+	}
+	svc, err := clouddns.New(tracing.Client("gce", conf.Client(context.Background())))
+	if err != nil {
+		return nil, errors.Wrap(err, "cloud dns")
+	}
+
+	return &google{
+		svc:     svc,
+		project: creds[clouds.GCEProjectID],
+	}, nil
+}
+
+// Upsert replaces the record set of the name and the type in one change.
+func (g *google) Upsert(ctx context.Context, r Record) error {
+	zone, err := g.zone(ctx, r.Name)
+	if err != nil {
+		return err
+	}
+
+	change := &clouddns.Change{Additions: []*clouddns.ResourceRecordSet{g.recordSet(r)}}
+	existing, err := g.svc.ResourceRecordSets.List(g.project, zone).
+		Name(r.Name + ".").Type(r.Type).Context(ctx).Do()
+	if err != nil {
+		return err
+	}
+	change.Deletions = existing.Rrsets
+
+	_, err = g.svc.Changes.Create(g.project, zone, change).Context(ctx).Do()
+	return err
+}
+
+func (g *google) Delete(ctx context.Context, r Record) error {
+	zone, err := g.zone(ctx, r.Name)
+	if err != nil {
+		return err
+	}
+
+	change := &clouddns.Change{Deletions: []*clouddns.ResourceRecordSet{g.recordSet(r)}}
+	_, err = g.svc.Changes.Create(g.project, zone, change).Context(ctx).Do()
+	if e, ok := err.(*googleapi.Error); ok && e.Code == 404 {
+		return nil
+	}
+	return err
+}
+
+func (g *google) recordSet(r Record) *clouddns.ResourceRecordSet {
+	values := make([]string, 0, len(r.Values))
+	for _, v := range r.Values {
+		if r.Type == TypeTXT {
+			v = strconv.Quote(v)
+		}
+		values = append(values, fqdnValue(r.Type, v))
+	}
+
+	return &clouddns.ResourceRecordSet{
+		Name:    r.Name + ".",
+		Type:    r.Type,
+		Ttl:     ttlOf(r),
+		Rrdatas: values,
+	}
+}
+
+// zone returns the name of the managed zone of the record.
+func (g *google) zone(ctx context.Context, fqdn string) (string, error) {
+	zones, err := g.svc.ManagedZones.List(g.project).Context(ctx).Do()
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, 0, len(zones.ManagedZones))
+	for _, z := range zones.ManagedZones {
+		names = append(names, z.DnsName)
+	}
+	zone, ok := ZoneOf(fqdn, names)
+	if !ok {
+		return "", errors.Errorf("no managed zone of %s", fqdn)
+	}
+
+	for _, z := range zones.ManagedZones {
+		if strings.TrimSuffix(z.DnsName, ".") == zone {
+			return z.Name, nil
+		}
+	}
+	return "", errors.Errorf("no managed zone of %s", fqdn)
+}
@@ -0,0 +1,178 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+const (
+	route53Endpoint = "https://route53.amazonaws.com/2013-04-01"
+	route53Xmlns    = "https://route53.amazonaws.com/doc/2013-04-01/"
+)
+
+// route53 changes records with the REST API of Route 53, its SDK client
+// isn't vendored.
+type route53 struct {
+	endpoint string
+	signer   *v4.Signer
+	http     *http.Client
+}
+
+func newRoute53(keyID, secret string) (*route53, error) {
+	if keyID == "" || secret == "" {
+		return nil, sgerrors.ErrInvalidCredentials
+	}
+
+	return &route53{
+		endpoint: route53Endpoint,
+		signer:   v4.NewSigner(credentials.NewStaticCredentials(keyID, secret, "")),
+		http:     &http.Client{Timeout: time.Second * 30},
+	}, nil
+}
+
+type hostedZones struct {
+	HostedZones []struct {
+		ID   string `xml:"Id"`
+		Name string `xml:"Name"`
+	} `xml:"HostedZones>HostedZone"`
+	IsTruncated bool   `xml:"IsTruncated"`
+	NextMarker  string `xml:"NextMarker"`
+}
+
+type resourceRecordSet struct {
+	Name   string   `xml:"Name"`
+	Type   string   `xml:"Type"`
+	TTL    int64    `xml:"TTL"`
+	Values []string `xml:"ResourceRecords>ResourceRecord>Value"`
+}
+
+type change struct {
+	Action string            `xml:"Action"`
+	Set    resourceRecordSet `xml:"ResourceRecordSet"`
+}
+
+type changeRequest struct {
+	XMLName xml.Name `xml:"ChangeResourceRecordSetsRequest"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Changes []change `xml:"ChangeBatch>Changes>Change"`
+}
+
+func (r *route53) Upsert(ctx context.Context, rec Record) error {
+	return r.change(ctx, "UPSERT", rec)
+}
+
+func (r *route53) Delete(ctx context.Context, rec Record) error {
+	return r.change(ctx, "DELETE", rec)
+}
+
+func (r *route53) change(ctx context.Context, action string, rec Record) error {
+	zoneID, err := r.zone(ctx, rec.Name)
+	if err != nil {
+		return err
+	}
+
+	values := make([]string, 0, len(rec.Values))
+	for _, v := range rec.Values {
+		if rec.Type == TypeTXT {
+			v = strconv.Quote(v)
+		}
+		values = append(values, v)
+	}
+	req := changeRequest{
+		Xmlns: route53Xmlns,
+		Changes: []change{{
+			Action: action,
+			Set: resourceRecordSet{
+				Name:   rec.Name + ".",
+				Type:   rec.Type,
+				TTL:    ttlOf(rec),
+				Values: values,
+			},
+		}},
+	}
+	body, err := xml.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	return r.do(ctx, http.MethodPost, r.endpoint+zoneID+"/rrset", append([]byte(xml.Header), body...), nil)
+}
+
+// zone returns the ID of the hosted zone of the name, e.g. /hostedzone/Z1.
+func (r *route53) zone(ctx context.Context, fqdn string) (string, error) {
+	ids := make(map[string]string)
+	names := make([]string, 0)
+	for marker := ""; ; {
+		u := r.endpoint + "/hostedzone"
+		if marker != "" {
+			u += "?marker=" + url.QueryEscape(marker)
+		}
+
+		zones := &hostedZones{}
+		if err := r.do(ctx, http.MethodGet, u, nil, zones); err != nil {
+			return "", errors.Wrap(err, "list hosted zones")
+		}
+		for _, z := range zones.HostedZones {
+			ids[z.Name] = z.ID
+			names = append(names, z.Name)
+		}
+		if !zones.IsTruncated || zones.NextMarker == "" {
+			break
+		}
+		marker = zones.NextMarker
+	}
+
+	zone, ok := ZoneOf(fqdn, names)
+	if !ok {
+		return "", errors.Errorf("no hosted zone of %s", fqdn)
+	}
+	if id, ok := ids[zone+"."]; ok {
+		return id, nil
+	}
+	return ids[zone], nil
+}
+
+func (r *route53) do(ctx context.Context, method, u string, body []byte, out interface{}) error {
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if body != nil {
+		req.Header.Set("Content-Type", "text/xml")
+	}
+	if _, err = r.signer.Sign(req, bytes.NewReader(body), "route53", "us-east-1", time.Now()); err != nil {
+		return errors.Wrap(err, "sign request")
+	}
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return errors.Errorf("route53: %s: %s", resp.Status, bytes.TrimSpace(data))
+	}
+	if out != nil {
+		return xml.Unmarshal(data, out)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package dns
+
+import (
+	"context"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoute53(t *testing.T) {
+	var changes []changeRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/"))
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/hostedzone" && r.URL.Query().Get("marker") == "":
+			w.Write([]byte(`<ListHostedZonesResponse><HostedZones>
+				<HostedZone><Id>/hostedzone/Z1</Id><Name>example.com.</Name></HostedZone>
+				</HostedZones><IsTruncated>true</IsTruncated><NextMarker>Z2</NextMarker></ListHostedZonesResponse>`))
+		case r.Method == http.MethodGet && r.URL.Path == "/hostedzone":
+			w.Write([]byte(`<ListHostedZonesResponse><HostedZones>
+				<HostedZone><Id>/hostedzone/Z2</Id><Name>sub.example.com.</Name></HostedZone>
+				</HostedZones><IsTruncated>false</IsTruncated></ListHostedZonesResponse>`))
+		case r.Method == http.MethodPost && r.URL.Path == "/hostedzone/Z2/rrset":
+			data, _ := ioutil.ReadAll(r.Body)
+			req := changeRequest{}
+			require.NoError(t, xml.Unmarshal(data, &req))
+			changes = append(changes, req)
+			w.Write([]byte(`<ChangeResourceRecordSetsResponse/>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	r, err := newRoute53("key", "secret")
+	require.NoError(t, err)
+	r.endpoint = srv.URL
+
+	rec := Record{Name: "_acme-challenge.control.sub.example.com", Type: TypeTXT, TTL: 60, Values: []string{"value"}}
+	require.NoError(t, r.Upsert(context.Background(), rec))
+	require.NoError(t, r.Delete(context.Background(), rec))
+	require.Len(t, changes, 2)
+	require.Equal(t, "UPSERT", changes[0].Changes[0].Action)
+	require.Equal(t, "DELETE", changes[1].Changes[0].Action)
+	require.Equal(t, resourceRecordSet{
+		Name:   "_acme-challenge.control.sub.example.com.",
+		Type:   "TXT",
+		TTL:    60,
+		Values: []string{`"value"`},
+	}, changes[0].Changes[0].Set)
+
+	rec.Name = "_acme-challenge.other.com"
+	require.Error(t, r.Upsert(context.Background(), rec))
+}
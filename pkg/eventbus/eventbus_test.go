@@ -0,0 +1,180 @@
+package eventbus
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/timeline"
+)
+
+// natsMessage is a message published to the fake NATS server.
+type natsMessage struct {
+	subject string
+	connect natsConnect
+	event   CloudEvent
+}
+
+// fakeNATS speaks the core protocol, it closes the first connection after
+// the greeting when drop is set.
+func fakeNATS(t *testing.T, drop bool) (string, <-chan natsMessage) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	messages := make(chan natsMessage, 10)
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			fmt.Fprint(conn, "INFO {\"server_id\":\"fake\"}\r\n")
+			if drop {
+				drop = false
+				conn.Close()
+				continue
+			}
+			go serveNATS(conn, messages)
+		}
+	}()
+
+	return l.Addr().String(), messages
+}
+
+func serveNATS(conn net.Conn, messages chan<- natsMessage) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	connect := natsConnect{}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "CONNECT":
+			json.Unmarshal([]byte(strings.TrimPrefix(strings.TrimSpace(line), "CONNECT ")), &connect)
+			if connect.Pass == "wrong" {
+				fmt.Fprint(conn, "-ERR 'Authorization Violation'\r\n")
+				return
+			}
+		case "PING":
+			fmt.Fprint(conn, "PONG\r\n")
+		case "PUB":
+			n, _ := strconv.Atoi(fields[2])
+			data := make([]byte, n+2)
+			if _, err = io.ReadFull(r, data); err != nil {
+				return
+			}
+			msg := natsMessage{subject: fields[1], connect: connect}
+			json.Unmarshal(data[:n], &msg.event)
+			messages <- msg
+		}
+	}
+}
+
+func testEvent() *CloudEvent {
+	return &CloudEvent{
+		SpecVersion: SpecVersion,
+		ID:          "event-1",
+		Source:      DefaultSource,
+		Type:        TypePrefix + string(timeline.NodeRemoved),
+		Subject:     "kube-1",
+		Data:        &timeline.Event{KubeID: "kube-1", Type: timeline.NodeRemoved, Object: "node-1"},
+	}
+}
+
+func TestNATSPublisher(t *testing.T) {
+	addr, messages := fakeNATS(t, true)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	p, err := NewNATSPublisher("nats://root:secret@"+addr, "")
+	require.NoError(t, err)
+	defer p.Close()
+
+	// the connection is reopened by the next publish
+	require.Error(t, p.Publish(ctx, testEvent()))
+	require.NoError(t, p.Publish(ctx, testEvent()))
+
+	msg := <-messages
+	require.Equal(t, DefaultNATSSubject+".NodeRemoved", msg.subject)
+	require.Equal(t, "root", msg.connect.User)
+	require.Equal(t, "secret", msg.connect.Pass)
+	require.Equal(t, "event-1", msg.event.ID)
+	require.Equal(t, "node-1", msg.event.Data.Object)
+
+	wrong, err := NewNATSPublisher("nats://root:wrong@"+addr, "")
+	require.NoError(t, err)
+	require.EqualError(t, wrong.Publish(ctx, testEvent()), "connect: nats: Authorization Violation")
+
+	_, err = NewNATSPublisher("http://"+addr, "")
+	require.Error(t, err)
+}
+
+func TestKafkaPublisher(t *testing.T) {
+	fail := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/topics/"+DefaultKafkaTopic, r.URL.Path)
+		require.Equal(t, kafkaContentType, r.Header.Get("Content-Type"))
+		user, pass, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "root:secret", user+":"+pass)
+
+		req := kafkaRequest{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Len(t, req.Records, 1)
+		require.Equal(t, "kube-1", req.Records[0].Key)
+		require.Equal(t, "event-1", req.Records[0].Value.ID)
+
+		if fail {
+			w.Write([]byte(`{"offsets":[{"partition":null,"offset":null,"error_code":50301,"error":"leader not available"}]}`))
+			return
+		}
+		w.Write([]byte(`{"offsets":[{"partition":0,"offset":1,"error_code":null,"error":null}]}`))
+	}))
+	defer srv.Close()
+
+	p, err := NewKafkaPublisher(strings.Replace(srv.URL, "http://", "http://root:secret@", 1), "")
+	require.NoError(t, err)
+	require.NoError(t, p.Publish(context.Background(), testEvent()))
+
+	fail = true
+	require.EqualError(t, p.Publish(context.Background(), testEvent()), "kafka: leader not available")
+}
+
+func TestInit(t *testing.T) {
+	addr, messages := fakeNATS(t, false)
+	stop, err := Init(Config{NATSURL: "nats://" + addr, NATSSubject: "events", Source: "/control/test"})
+	require.NoError(t, err)
+
+	timeline.Export(&timeline.Event{KubeID: "kube-1", Type: timeline.TaskFailed, Object: "task-1"})
+	stop()
+
+	select {
+	case msg := <-messages:
+		require.Equal(t, "events.TaskFailed", msg.subject)
+		require.Equal(t, "/control/test", msg.event.Source)
+		require.Equal(t, TypePrefix+"TaskFailed", msg.event.Type)
+		require.Equal(t, "kube-1", msg.event.Subject)
+		require.Equal(t, "task-1", msg.event.Data.Object)
+		require.False(t, msg.event.Time.IsZero())
+	default:
+		t.Fatal("event hasn't been published before stop")
+	}
+
+	// stopped buses don't export events
+	timeline.Export(&timeline.Event{KubeID: "kube-1", Type: timeline.TaskFailed})
+	require.Len(t, messages, 0)
+}
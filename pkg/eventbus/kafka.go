@@ -0,0 +1,118 @@
+package eventbus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	kafkaContentType = "application/vnd.kafka.json.v2+json"
+	kafkaAccept      = "application/vnd.kafka.v2+json"
+)
+
+type kafkaRecord struct {
+	Key   string      `json:"key,omitempty"`
+	Value *CloudEvent `json:"value"`
+}
+
+type kafkaRequest struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaResponse struct {
+	Offsets []struct {
+		Partition int    `json:"partition"`
+		Offset    int64  `json:"offset"`
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+// KafkaPublisher produces events to a topic through the v2 API of the Kafka
+// REST proxy. Records are keyed by IDs of clusters, so events of a cluster
+// keep their order in a partition.
+type KafkaPublisher struct {
+	endpoint string
+	user     *url.Userinfo
+	http     *http.Client
+}
+
+func NewKafkaPublisher(rawURL, topic string) (*KafkaPublisher, error) {
+	u, err := parseURL(rawURL, "http", "https")
+	if err != nil {
+		return nil, err
+	}
+	if topic == "" {
+		topic = DefaultKafkaTopic
+	}
+
+	user := u.User
+	u.User = nil
+	return &KafkaPublisher{
+		endpoint: strings.TrimSuffix(u.String(), "/") + "/topics/" + url.PathEscape(topic),
+		user:     user,
+		http: &http.Client{
+			Timeout: time.Second * 30,
+		},
+	}, nil
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, e *CloudEvent) error {
+	data, err := json.Marshal(kafkaRequest{
+		Records: []kafkaRecord{{Key: e.Subject, Value: e}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "marshal event")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", kafkaContentType)
+	req.Header.Set("Accept", kafkaAccept)
+	if p.user != nil {
+		pass, _ := p.user.Password()
+		req.SetBasicAuth(p.user.Username(), pass)
+	}
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "produce")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return errors.Errorf("kafka rest proxy: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	out := kafkaResponse{}
+	if err = json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return errors.Wrap(err, "decode response")
+	}
+	for _, o := range out.Offsets {
+		switch {
+		case o.Error != "":
+			return errors.Errorf("kafka: %s", o.Error)
+		case o.ErrorCode != nil:
+			return errors.Errorf("kafka: error code %d", *o.ErrorCode)
+		}
+	}
+	return nil
+}
+
+// Close is a no-op, requests don't keep connections of their own.
+func (p *KafkaPublisher) Close() error {
+	return nil
+}
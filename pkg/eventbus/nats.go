@@ -0,0 +1,195 @@
+package eventbus
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const natsDialTimeout = time.Second * 10
+
+// natsConnect is the CONNECT message of the NATS client protocol.
+type natsConnect struct {
+	Verbose  bool   `json:"verbose"`
+	Pedantic bool   `json:"pedantic"`
+	Name     string `json:"name"`
+	Lang     string `json:"lang"`
+	User     string `json:"user,omitempty"`
+	Pass     string `json:"pass,omitempty"`
+	Token    string `json:"auth_token,omitempty"`
+}
+
+// NATSPublisher publishes events to subjects of a NATS server with the core
+// protocol. Publishes are confirmed with a PING, so events don't get lost in
+// the buffer of a broken connection, it's reopened by the next publish.
+type NATSPublisher struct {
+	url     *url.URL
+	subject string
+
+	m    sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func NewNATSPublisher(rawURL, subject string) (*NATSPublisher, error) {
+	u, err := parseURL(rawURL, "nats", "tls")
+	if err != nil {
+		return nil, err
+	}
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	if subject == "" {
+		subject = DefaultNATSSubject
+	}
+
+	return &NATSPublisher{
+		url:     u,
+		subject: subject,
+	}, nil
+}
+
+// Publish sends the event to the subject of its type, e.g.
+// supergiant.control.TaskFailed.
+func (p *NATSPublisher) Publish(ctx context.Context, e *CloudEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrap(err, "marshal event")
+	}
+	subject := p.subject + "." + strings.TrimPrefix(e.Type, TypePrefix)
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.conn == nil {
+		if err = p.connect(ctx); err != nil {
+			return err
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		p.conn.SetDeadline(deadline)
+	}
+
+	msg := fmt.Sprintf("PUB %s %d\r\n%s\r\nPING\r\n", subject, len(data), data)
+	if _, err = p.conn.Write([]byte(msg)); err != nil {
+		p.reset()
+		return errors.Wrap(err, "publish")
+	}
+	if err = p.waitPong(); err != nil {
+		p.reset()
+		return err
+	}
+	return nil
+}
+
+func (p *NATSPublisher) Close() error {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn, p.r = nil, nil
+	return err
+}
+
+// connect reads INFO of the server and authenticates with credentials of the
+// url, tls:// upgrades the connection after INFO like NATS clients do.
+func (p *NATSPublisher) connect(ctx context.Context) error {
+	dialer := &net.Dialer{Timeout: natsDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", p.url.Host)
+	if err != nil {
+		return errors.Wrapf(err, "dial %s", p.url.Host)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return errors.Wrap(err, "read server info")
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return errors.Errorf("unexpected greeting %q", strings.TrimSpace(line))
+	}
+
+	if p.url.Scheme == "tls" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: p.url.Hostname()})
+		if err = tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return errors.Wrap(err, "tls handshake")
+		}
+		conn, r = tlsConn, bufio.NewReader(tlsConn)
+	}
+
+	connect := natsConnect{
+		Name: "supergiant-control",
+		Lang: "go",
+	}
+	if user := p.url.User; user != nil {
+		if pass, ok := user.Password(); ok {
+			connect.User, connect.Pass = user.Username(), pass
+		} else {
+			connect.Token = user.Username()
+		}
+	}
+	data, err := json.Marshal(connect)
+	if err != nil {
+		conn.Close()
+		return errors.Wrap(err, "marshal connect")
+	}
+
+	p.conn, p.r = conn, r
+	if _, err = fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", data); err != nil {
+		p.reset()
+		return errors.Wrap(err, "connect")
+	}
+	if err = p.waitPong(); err != nil {
+		p.reset()
+		return errors.Wrap(err, "connect")
+	}
+	return nil
+}
+
+// waitPong reads messages of the server until the PONG, errors of the
+// server are returned.
+func (p *NATSPublisher) waitPong() error {
+	for {
+		line, err := p.r.ReadString('\n')
+		if err != nil {
+			return errors.Wrap(err, "read")
+		}
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err = p.conn.Write([]byte("PONG\r\n")); err != nil {
+				return errors.Wrap(err, "pong")
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.Errorf("nats: %s", strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")), "'"))
+		}
+	}
+}
+
+func (p *NATSPublisher) reset() {
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	p.conn, p.r = nil, nil
+}
@@ -0,0 +1,488 @@
+// Package fleet runs actions across a selection of clusters, a run is the
+// parent of workflow tasks of every selected cluster and reports their
+// aggregated result.
+package fleet
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/catalog"
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/labels"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/statuses"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const DefaultStoragePrefix = "/supergiant/fleet/"
+
+const (
+	// UpgradeApp installs or upgrades an app of the catalog.
+	UpgradeApp = "upgradeApp"
+	// RotateCertificates reissues certificates of cluster components.
+	RotateCertificates = "rotateCertificates"
+	// ApplyManifest applies a yaml manifest with kubectl.
+	ApplyManifest = "applyManifest"
+	// HealthCheck reports whether all nodes are ready.
+	HealthCheck = "healthCheck"
+)
+
+// Skipped clusters aren't operational or don't support the action.
+const Skipped statuses.Status = "skipped"
+
+// ErrInvalidRequest is returned for requests without parameters of their
+// action or without a selector.
+var ErrInvalidRequest = errors.New("invalid fleet request")
+
+var (
+	pollInterval = 10 * time.Second
+	runTimeout   = 2 * time.Hour
+)
+
+// Executor starts actions on a single cluster, it's implemented by the kube
+// handler.
+type Executor interface {
+	InstallApp(ctx context.Context, k *model.Kube, app steps.InstallAppConfig, workflow string) (string, error)
+	RotateCertificates(ctx context.Context, k *model.Kube) ([]string, error)
+	ApplyManifest(ctx context.Context, k *model.Kube, manifest string) (string, error)
+	CheckHealth(ctx context.Context, k *model.Kube) (string, error)
+}
+
+type kubeLister interface {
+	ListAll(ctx context.Context) ([]model.Kube, error)
+}
+
+// Selector selects clusters of the run, clusters have to match all of its
+// fields. All has to be set to select every cluster.
+type Selector struct {
+	All      bool              `json:"all,omitempty"`
+	Team     string            `json:"team,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	KubeIDs  []string          `json:"kubeIds,omitempty"`
+	Provider clouds.Name       `json:"provider,omitempty"`
+}
+
+func (s Selector) empty() bool {
+	return !s.All && s.Team == "" && len(s.Labels) == 0 && len(s.KubeIDs) == 0 && s.Provider == ""
+}
+
+func (s Selector) matches(k model.Kube) bool {
+	if s.Team != "" && k.Team != s.Team {
+		return false
+	}
+	if !labels.Matches(k.Labels, s.Labels) {
+		return false
+	}
+	if s.Provider != "" && k.Provider != s.Provider {
+		return false
+	}
+	if len(s.KubeIDs) > 0 {
+		for _, id := range s.KubeIDs {
+			if id == k.ID {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Request is an action to run across the selected clusters. App, its chart
+// version and values are used by app upgrades, Manifest is applied by the
+// manifest action.
+type Request struct {
+	Action       string   `json:"action"`
+	Selector     Selector `json:"selector"`
+	App          string   `json:"app,omitempty"`
+	ChartVersion string   `json:"chartVersion,omitempty"`
+	Values       string   `json:"values,omitempty"`
+	Manifest     string   `json:"manifest,omitempty"`
+}
+
+// Validate checks the request has the parameters of its action.
+func (r Request) Validate() error {
+	switch r.Action {
+	case UpgradeApp:
+		if r.App == "" {
+			return errors.Wrap(ErrInvalidRequest, "app is required")
+		}
+	case ApplyManifest:
+		if strings.TrimSpace(r.Manifest) == "" {
+			return errors.Wrap(ErrInvalidRequest, "manifest is required")
+		}
+	case RotateCertificates, HealthCheck:
+	default:
+		return errors.Wrapf(ErrInvalidRequest, "unknown action %q", r.Action)
+	}
+
+	if r.Selector.empty() {
+		return errors.Wrap(ErrInvalidRequest, "selector matches all clusters, set all to run the action everywhere")
+	}
+	return nil
+}
+
+// Result is the outcome of the action on a cluster, TaskIDs are its child
+// tasks.
+type Result struct {
+	KubeID   string            `json:"kubeId"`
+	KubeName string            `json:"kubeName"`
+	Team     string            `json:"team,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Status   statuses.Status   `json:"status"`
+	TaskIDs  []string          `json:"taskIds,omitempty"`
+	Message  string            `json:"message,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// Summary counts clusters of the run by their result.
+type Summary struct {
+	Total     int `json:"total"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Skipped   int `json:"skipped"`
+}
+
+// Run is the parent task of the action, it succeeds when the action has
+// succeeded on every cluster that hasn't been skipped.
+type Run struct {
+	ID         string          `json:"id"`
+	Request    Request         `json:"request"`
+	User       string          `json:"user,omitempty"`
+	Status     statuses.Status `json:"status"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	FinishedAt time.Time       `json:"finishedAt,omitempty"`
+	Clusters   []Result        `json:"clusters"`
+	Summary    Summary         `json:"summary"`
+}
+
+// Service starts runs and keeps their reports.
+type Service struct {
+	prefix     string
+	repository storage.Interface
+	kubes      kubeLister
+	executor   Executor
+	catalog    *catalog.Catalog
+}
+
+func NewService(prefix string, repository storage.Interface, kubes kubeLister, executor Executor, c *catalog.Catalog) *Service {
+	return &Service{
+		prefix:     prefix,
+		repository: repository,
+		kubes:      kubes,
+		executor:   executor,
+		catalog:    c,
+	}
+}
+
+// Start runs the action on the selected clusters in background and returns
+// the run, clusters that aren't visible to the user are never selected.
+func (s *Service) Start(ctx context.Context, req Request) (*Run, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if req.Action == UpgradeApp {
+		if _, err := s.catalog.Get(req.App); err != nil {
+			return nil, err
+		}
+	}
+
+	kubes, err := s.kubes.ListAll(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "list kubes")
+	}
+
+	scope := api.ScopeFrom(ctx)
+	run := &Run{
+		ID:        uuid.New(),
+		Request:   req,
+		Status:    statuses.Executing,
+		CreatedAt: time.Now(),
+		Clusters:  make([]Result, 0),
+	}
+	if id, ok := api.IdentityFrom(ctx); ok {
+		run.User = id.Login
+	}
+
+	selected := make([]model.Kube, 0)
+	for _, k := range kubes {
+		if !scope.AllowsLabeled(k.Team, k.Labels) || !req.Selector.matches(k) {
+			continue
+		}
+		selected = append(selected, k)
+		run.Clusters = append(run.Clusters, Result{
+			KubeID:   k.ID,
+			KubeName: k.Name,
+			Team:     k.Team,
+			Labels:   k.Labels,
+			Status:   statuses.Todo,
+		})
+	}
+	if len(selected) == 0 {
+		return nil, errors.Wrap(sgerrors.ErrNotFound, "no clusters match the selector")
+	}
+	run.summarize()
+
+	if err = s.save(ctx, run); err != nil {
+		return nil, err
+	}
+	started := *run
+	started.Clusters = append([]Result(nil), run.Clusters...)
+
+	// the run outlives the request
+	go s.execute(context.Background(), run, selected)
+
+	return &started, nil
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*Run, error) {
+	data, err := s.repository.Get(ctx, s.prefix, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get fleet run %s", id)
+	}
+	run := &Run{}
+	return run, errors.Wrap(json.Unmarshal(data, run), "unmarshal")
+}
+
+// ListAll returns runs, the newest ones first.
+func (s *Service) ListAll(ctx context.Context) ([]Run, error) {
+	rawRuns, err := s.repository.GetAll(ctx, s.prefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "get fleet runs")
+	}
+
+	runs := make([]Run, 0, len(rawRuns))
+	for _, data := range rawRuns {
+		run := Run{}
+		if err = json.Unmarshal(data, &run); err != nil {
+			logrus.Warnf("fleet: decode run: %v", err)
+			continue
+		}
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].CreatedAt.After(runs[j].CreatedAt)
+	})
+
+	return runs, nil
+}
+
+func (s *Service) save(ctx context.Context, run *Run) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return errors.Wrap(err, "marshal")
+	}
+	return errors.Wrap(s.repository.Put(ctx, s.prefix, run.ID, data), "save fleet run")
+}
+
+// execute starts the action on every cluster and waits for their tasks.
+func (s *Service) execute(ctx context.Context, run *Run, kubes []model.Kube) {
+	for i := range kubes {
+		s.dispatch(ctx, run.Request, &kubes[i], &run.Clusters[i])
+		run.summarize()
+		if err := s.save(ctx, run); err != nil {
+			logrus.Errorf("fleet: run %s: %v", run.ID, err)
+		}
+	}
+
+	deadline := time.Now().Add(runTimeout)
+	for run.pending() {
+		if time.Now().After(deadline) {
+			for i := range run.Clusters {
+				if r := &run.Clusters[i]; r.Status == statuses.Executing {
+					r.Status, r.Error = statuses.Error, "timed out waiting for tasks"
+				}
+			}
+			break
+		}
+
+		time.Sleep(pollInterval)
+		changed := false
+		for i := range run.Clusters {
+			if r := &run.Clusters[i]; r.Status == statuses.Executing {
+				changed = s.poll(ctx, r) || changed
+			}
+		}
+		if changed {
+			run.summarize()
+			if err := s.save(ctx, run); err != nil {
+				logrus.Errorf("fleet: run %s: %v", run.ID, err)
+			}
+		}
+	}
+
+	run.summarize()
+	run.FinishedAt = time.Now()
+	run.Status = statuses.Success
+	if run.Summary.Failed > 0 {
+		run.Status = statuses.Error
+	}
+	if err := s.save(ctx, run); err != nil {
+		logrus.Errorf("fleet: run %s: %v", run.ID, err)
+	}
+	logrus.Infof("fleet: run %s of %s has finished: %d succeeded, %d failed, %d skipped", run.ID,
+		run.Request.Action, run.Summary.Succeeded, run.Summary.Failed, run.Summary.Skipped)
+}
+
+// dispatch starts the action on the cluster, health checks are done at once,
+// other actions leave the result executing until their tasks finish.
+func (s *Service) dispatch(ctx context.Context, req Request, k *model.Kube, r *Result) {
+	if k.State != model.StateOperational {
+		r.Status, r.Message = Skipped, "cluster is "+string(k.State)
+		return
+	}
+
+	var (
+		ids []string
+		err error
+	)
+	switch req.Action {
+	case UpgradeApp:
+		var cfg steps.InstallAppConfig
+		if cfg, err = s.appConfig(req, k); err != nil {
+			break
+		}
+		var id string
+		if id, err = s.executor.InstallApp(ctx, k, cfg, workflows.InstallCatalogApp); err == nil {
+			ids = []string{id}
+		}
+	case RotateCertificates:
+		ids, err = s.executor.RotateCertificates(ctx, k)
+	case ApplyManifest:
+		var id string
+		if id, err = s.executor.ApplyManifest(ctx, k, req.Manifest); err == nil {
+			ids = []string{id}
+		}
+	case HealthCheck:
+		r.Message, err = s.executor.CheckHealth(ctx, k)
+		if err == nil {
+			r.Status = statuses.Success
+			return
+		}
+	}
+
+	if err != nil {
+		r.Status, r.Error = statuses.Error, err.Error()
+		return
+	}
+	r.TaskIDs = ids
+	r.Status = statuses.Executing
+	if len(ids) == 0 {
+		r.Status, r.Message = Skipped, "cluster has no machines to run the action on"
+	}
+}
+
+func (s *Service) appConfig(req Request, k *model.Kube) (steps.InstallAppConfig, error) {
+	app, err := s.catalog.Get(req.App)
+	if err != nil {
+		return steps.InstallAppConfig{}, err
+	}
+	values, err := s.catalog.Values(app, k.Provider, req.Values)
+	if err != nil {
+		return steps.InstallAppConfig{}, err
+	}
+
+	version := req.ChartVersion
+	if version == "" {
+		version = app.ChartVersion
+	}
+	return steps.InstallAppConfig{
+		Name:         app.Name,
+		Namespace:    app.Namespace,
+		ChartName:    app.ChartName,
+		ChartVersion: version,
+		RepoName:     app.RepoName,
+		Values:       values,
+		Upgrade:      true,
+	}, nil
+}
+
+// poll updates the result from statuses of its tasks and reports whether
+// it has finished.
+func (s *Service) poll(ctx context.Context, r *Result) bool {
+	for _, id := range r.TaskIDs {
+		data, err := s.repository.Get(ctx, workflows.Prefix, id)
+		if err != nil {
+			if sgerrors.IsNotFound(err) {
+				r.Status, r.Error = statuses.Error, "task "+id+" is not found"
+				return true
+			}
+			logrus.Warnf("fleet: get task %s: %v", id, err)
+			return false
+		}
+
+		task := struct {
+			Status statuses.Status  `json:"status"`
+			Error  *sgerrors.Detail `json:"error"`
+		}{}
+		if err = json.Unmarshal(data, &task); err != nil {
+			logrus.Warnf("fleet: decode task %s: %v", id, err)
+			return false
+		}
+
+		switch task.Status {
+		case statuses.Success:
+		case statuses.Error, statuses.Cancelled:
+			r.Status, r.Error = statuses.Error, "task "+id+" is "+string(task.Status)
+			if task.Error != nil {
+				r.Error += ": " + task.Error.Message
+			}
+			return true
+		default:
+			return false
+		}
+	}
+
+	r.Status = statuses.Success
+	return true
+}
+
+// Visible drops results of clusters the scope doesn't allow, runs without
+// visible clusters aren't visible either.
+func (run *Run) Visible(scope api.Scope) bool {
+	clusters := make([]Result, 0, len(run.Clusters))
+	for _, r := range run.Clusters {
+		if scope.AllowsLabeled(r.Team, r.Labels) {
+			clusters = append(clusters, r)
+		}
+	}
+	if len(clusters) < len(run.Clusters) {
+		run.Clusters = clusters
+		run.summarize()
+	}
+	return len(clusters) > 0
+}
+
+func (run *Run) pending() bool {
+	for _, r := range run.Clusters {
+		if r.Status == statuses.Executing || r.Status == statuses.Todo {
+			return true
+		}
+	}
+	return false
+}
+
+func (run *Run) summarize() {
+	run.Summary = Summary{Total: len(run.Clusters)}
+	for _, r := range run.Clusters {
+		switch r.Status {
+		case statuses.Success:
+			run.Summary.Succeeded++
+		case statuses.Error:
+			run.Summary.Failed++
+		case Skipped:
+			run.Summary.Skipped++
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package fleet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/catalog"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/storage"
+	"github.com/supergiant/control/pkg/storage/memory"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/statuses"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type fakeKubes []model.Kube
+
+func (f fakeKubes) ListAll(context.Context) ([]model.Kube, error) {
+	return f, nil
+}
+
+type fakeExecutor struct {
+	repo storage.Interface
+	apps []steps.InstallAppConfig
+}
+
+func (f *fakeExecutor) task(id string, status statuses.Status) string {
+	data, _ := json.Marshal(workflows.Task{ID: id, Status: status})
+	f.repo.Put(context.Background(), workflows.Prefix, id, data)
+	return id
+}
+
+func (f *fakeExecutor) InstallApp(_ context.Context, k *model.Kube, app steps.InstallAppConfig, _ string) (string, error) {
+	f.apps = append(f.apps, app)
+	if k.ID == "broken" {
+		return f.task("install-"+k.ID, statuses.Error), nil
+	}
+	return f.task("install-"+k.ID, statuses.Success), nil
+}
+
+func (f *fakeExecutor) RotateCertificates(_ context.Context, k *model.Kube) ([]string, error) {
+	return []string{f.task("master-"+k.ID, statuses.Success), f.task("node-"+k.ID, statuses.Success)}, nil
+}
+
+func (f *fakeExecutor) ApplyManifest(context.Context, *model.Kube, string) (string, error) {
+	return "", errors.New("apply failed")
+}
+
+func (f *fakeExecutor) CheckHealth(_ context.Context, k *model.Kube) (string, error) {
+	if k.ID == "broken" {
+		return "", errors.New("1/2 nodes are ready")
+	}
+	return "2/2 nodes are ready", nil
+}
+
+func waitRun(t *testing.T, svc *Service, id string) *Run {
+	for i := 0; i < 100; i++ {
+		run, err := svc.Get(context.Background(), id)
+		require.NoError(t, err)
+		if run.Status != statuses.Executing {
+			return run
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("run %s hasn't finished", id)
+	return nil
+}
+
+func TestService_Start(t *testing.T) {
+	pollInterval = time.Millisecond
+	repo := memory.NewInMemoryRepository()
+	kubes := fakeKubes{
+		{ID: "prod", Name: "prod", Team: "ops", State: model.StateOperational},
+		{ID: "broken", Name: "broken", Team: "ops", State: model.StateOperational},
+		{ID: "new", Name: "new", Team: "ops", State: model.StateProvisioning},
+		{ID: "dev", Name: "dev", Team: "dev", State: model.StateOperational, Labels: map[string]string{"env": "prod"}},
+	}
+	executor := &fakeExecutor{repo: repo}
+	svc := NewService(DefaultStoragePrefix, repo, kubes, executor, catalog.Default())
+	ctx := context.Background()
+
+	_, err := svc.Start(ctx, Request{Action: HealthCheck})
+	require.Equal(t, ErrInvalidRequest, errors.Cause(err))
+	_, err = svc.Start(ctx, Request{Action: UpgradeApp, Selector: Selector{All: true}})
+	require.Equal(t, ErrInvalidRequest, errors.Cause(err))
+
+	app := catalog.Default().Apps[0]
+	run, err := svc.Start(ctx, Request{Action: UpgradeApp, App: app.Name, Selector: Selector{Team: "ops"}})
+	require.NoError(t, err)
+	require.Len(t, run.Clusters, 3)
+
+	run = waitRun(t, svc, run.ID)
+	require.Equal(t, statuses.Error, run.Status)
+	require.Equal(t, Summary{Total: 3, Succeeded: 1, Failed: 1, Skipped: 1}, run.Summary)
+	require.Equal(t, []string{"install-prod"}, run.Clusters[0].TaskIDs)
+	require.Equal(t, Skipped, run.Clusters[2].Status)
+	require.True(t, executor.apps[0].Upgrade)
+	require.Equal(t, app.ChartName, executor.apps[0].ChartName)
+
+	run, err = svc.Start(ctx, Request{Action: RotateCertificates, Selector: Selector{KubeIDs: []string{"prod", "dev"}}})
+	require.NoError(t, err)
+	run = waitRun(t, svc, run.ID)
+	require.Equal(t, statuses.Success, run.Status)
+	require.Equal(t, []string{"master-dev", "node-dev"}, run.Clusters[1].TaskIDs)
+
+	// clusters of other teams aren't selected
+	scoped := api.WithScope(ctx, api.Scope{Teams: []string{"dev"}})
+	run, err = svc.Start(scoped, Request{Action: HealthCheck, Selector: Selector{All: true}})
+	require.NoError(t, err)
+	run = waitRun(t, svc, run.ID)
+	require.Len(t, run.Clusters, 1)
+	require.Equal(t, "2/2 nodes are ready", run.Clusters[0].Message)
+
+	// clusters of other teams are selected by labels matching selectors of the team
+	scoped = api.WithScope(ctx, api.Scope{Teams: []string{"qa"}, Selectors: []map[string]string{{"env": "prod"}}})
+	run, err = svc.Start(scoped, Request{Action: HealthCheck, Selector: Selector{Labels: map[string]string{"env": "prod"}}})
+	require.NoError(t, err)
+	require.Len(t, run.Clusters, 1)
+	require.Equal(t, "dev", run.Clusters[0].KubeID)
+
+	run, err = svc.Start(ctx, Request{Action: ApplyManifest, Manifest: "kind: Namespace", Selector: Selector{KubeIDs: []string{"prod"}}})
+	require.NoError(t, err)
+	run = waitRun(t, svc, run.ID)
+	require.Equal(t, statuses.Error, run.Status)
+	require.Equal(t, "apply failed", run.Clusters[0].Error)
+}
+
+func TestHandler(t *testing.T) {
+	pollInterval = time.Millisecond
+	repo := memory.NewInMemoryRepository()
+	kubes := fakeKubes{
+		{ID: "prod", Team: "ops", State: model.StateOperational},
+		{ID: "broken", Team: "ops", State: model.StateOperational},
+	}
+	svc := NewService(DefaultStoragePrefix, repo, kubes, &fakeExecutor{repo: repo}, catalog.Default())
+
+	router := mux.NewRouter()
+	NewHandler(svc).Register(router)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/fleet/actions",
+		bytes.NewBufferString(`{"action": "healthCheck", "selector": {"team": "ops"}}`)))
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	run := &Run{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(run))
+	waitRun(t, svc, run.ID)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fleet/actions/"+run.ID, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(run))
+	require.Equal(t, Summary{Total: 2, Succeeded: 1, Failed: 1}, run.Summary)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/fleet/actions",
+		bytes.NewBufferString(`{"action": "reboot", "selector": {"all": true}}`)))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// runs of clusters of other teams aren't visible
+	req := httptest.NewRequest(http.MethodGet, "/fleet/actions", nil)
+	req = req.WithContext(api.WithScope(req.Context(), api.Scope{Teams: []string{"dev"}}))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	runs := []Run{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&runs))
+	require.Empty(t, runs)
+}
@@ -0,0 +1,102 @@
+package fleet
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// Handler starts fleet actions and reports their runs.
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+func (h *Handler) Register(r *mux.Router) {
+	r.HandleFunc("/fleet/actions", h.startAction).Methods(http.MethodPost)
+	r.HandleFunc("/fleet/actions", h.listRuns).Methods(http.MethodGet)
+	r.HandleFunc("/fleet/actions/{id}", h.getRun).Methods(http.MethodGet)
+}
+
+// startAction runs the action on the selected clusters, the run is returned
+// at once and its report is updated as child tasks finish.
+func (h *Handler) startAction(w http.ResponseWriter, r *http.Request) {
+	req := Request{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	run, err := h.service.Start(r.Context(), req)
+	if err != nil {
+		if errors.Cause(err) == ErrInvalidRequest {
+			message.SendValidationFailed(w, err)
+			return
+		}
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, req.Action, err)
+			return
+		}
+		logrus.Errorf("fleet: start %s: %v", req.Action, err)
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	if err = json.NewEncoder(w).Encode(run); err != nil {
+		logrus.Errorf("fleet: start %s: write response: %v", req.Action, err)
+	}
+}
+
+func (h *Handler) listRuns(w http.ResponseWriter, r *http.Request) {
+	runs, err := h.service.ListAll(r.Context())
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	scope := api.ScopeFrom(r.Context())
+	res := make([]Run, 0, len(runs))
+	for _, run := range runs {
+		if run.Visible(scope) {
+			res = append(res, run)
+		}
+	}
+
+	if err = json.NewEncoder(w).Encode(res); err != nil {
+		logrus.Errorf("fleet: list runs: write response: %v", err)
+	}
+}
+
+func (h *Handler) getRun(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	run, err := h.service.Get(r.Context(), id)
+	if err == nil && !run.Visible(api.ScopeFrom(r.Context())) {
+		err = errors.Wrapf(sgerrors.ErrNotFound, "fleet run %s", id)
+	}
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, id, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(run); err != nil {
+		logrus.Errorf("fleet: get run %s: write response: %v", id, err)
+	}
+}
@@ -0,0 +1,243 @@
+// Package gitops keeps clusters in sync with cluster specs of a git repository.
+package gitops
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/clusterspec"
+	"github.com/supergiant/control/pkg/kube"
+)
+
+const defaultInterval = time.Minute
+
+// Config of the repository with cluster specs.
+type Config struct {
+	Repo   string
+	Branch string
+	// Path is a directory of the repository with spec files, its root when empty.
+	Path     string
+	Interval time.Duration
+	// Dir is a local directory the repository is cloned to.
+	Dir string
+	// DryRun reports changes without applying them.
+	DryRun bool
+}
+
+type applier interface {
+	ApplySpec(ctx context.Context, c *clusterspec.Cluster, dryRun bool) (*kube.SpecResult, error)
+}
+
+type gitFn func(ctx context.Context, dir string, args ...string) (string, error)
+
+// Status is a result of the last sync of the repository.
+type Status struct {
+	Repo     string          `json:"repo"`
+	Branch   string          `json:"branch"`
+	Revision string          `json:"revision"`
+	SyncedAt time.Time       `json:"syncedAt"`
+	Error    string          `json:"error,omitempty"`
+	Clusters []ClusterStatus `json:"clusters"`
+}
+
+// ClusterStatus is a result of applying a spec file.
+type ClusterStatus struct {
+	File   string           `json:"file"`
+	Name   string           `json:"name,omitempty"`
+	Result *kube.SpecResult `json:"result,omitempty"`
+	Error  string           `json:"error,omitempty"`
+}
+
+// Controller pulls the repository and applies cluster specs found in it.
+type Controller struct {
+	cfg   Config
+	apply applier
+	git   gitFn
+
+	// syncing guards the working copy
+	syncing sync.Mutex
+
+	m      sync.RWMutex
+	status Status
+}
+
+func NewController(cfg Config, a applier) *Controller {
+	if cfg.Branch == "" {
+		cfg.Branch = "master"
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = defaultInterval
+	}
+
+	return &Controller{
+		cfg:   cfg,
+		apply: a,
+		git:   git,
+		status: Status{
+			Repo:   cfg.Repo,
+			Branch: cfg.Branch,
+		},
+	}
+}
+
+// Run syncs the repository until the context is done.
+func (c *Controller) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.Sync(ctx); err != nil {
+			logrus.Errorf("gitops: sync %s: %v", c.cfg.Repo, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Status returns a result of the last sync.
+func (c *Controller) Status() Status {
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	return c.status
+}
+
+// Sync pulls the repository and applies every spec of it.
+func (c *Controller) Sync(ctx context.Context) error {
+	c.syncing.Lock()
+	defer c.syncing.Unlock()
+
+	status := Status{
+		Repo:     c.cfg.Repo,
+		Branch:   c.cfg.Branch,
+		SyncedAt: time.Now(),
+	}
+	defer func() {
+		c.m.Lock()
+		c.status = status
+		c.m.Unlock()
+	}()
+
+	revision, err := c.pull(ctx)
+	if err != nil {
+		status.Error = err.Error()
+		return err
+	}
+	status.Revision = revision
+
+	files, err := specFiles(filepath.Join(c.cfg.Dir, c.cfg.Path))
+	if err != nil {
+		status.Error = err.Error()
+		return err
+	}
+
+	for _, file := range files {
+		cs := c.applyFile(ctx, file)
+		if rel, err := filepath.Rel(c.cfg.Dir, file); err == nil {
+			cs.File = rel
+		}
+		status.Clusters = append(status.Clusters, cs)
+	}
+
+	return nil
+}
+
+func (c *Controller) applyFile(ctx context.Context, file string) ClusterStatus {
+	cs := ClusterStatus{File: file}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		cs.Error = err.Error()
+		return cs
+	}
+
+	spec, err := clusterspec.Parse(data)
+	if err != nil {
+		cs.Error = err.Error()
+		return cs
+	}
+	cs.Name = spec.Metadata.Name
+
+	cs.Result, err = c.apply.ApplySpec(ctx, spec, c.cfg.DryRun)
+	if err != nil {
+		logrus.Errorf("gitops: apply %s: %v", file, err)
+		cs.Error = err.Error()
+	}
+
+	return cs
+}
+
+// pull clones the repository or updates the working copy to the branch head.
+func (c *Controller) pull(ctx context.Context) (string, error) {
+	if _, err := os.Stat(filepath.Join(c.cfg.Dir, ".git")); os.IsNotExist(err) {
+		if _, err := c.git(ctx, "", "clone", "--depth", "1", "--branch", c.cfg.Branch, c.cfg.Repo, c.cfg.Dir); err != nil {
+			return "", errors.Wrap(err, "clone")
+		}
+	} else {
+		if _, err := c.git(ctx, c.cfg.Dir, "fetch", "--depth", "1", "origin", c.cfg.Branch); err != nil {
+			return "", errors.Wrap(err, "fetch")
+		}
+		if _, err := c.git(ctx, c.cfg.Dir, "reset", "--hard", "FETCH_HEAD"); err != nil {
+			return "", errors.Wrap(err, "reset")
+		}
+	}
+
+	revision, err := c.git(ctx, c.cfg.Dir, "rev-parse", "HEAD")
+	return strings.TrimSpace(revision), errors.Wrap(err, "rev-parse")
+}
+
+// specFiles returns yaml files of the directory and its subdirectories.
+func specFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch filepath.Ext(path) {
+		case ".yaml", ".yml":
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+
+	return files, errors.Wrapf(err, "read specs of %s", dir)
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// fail instead of waiting for credentials
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrapf(err, "git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
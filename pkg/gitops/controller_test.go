@@ -0,0 +1,144 @@
+package gitops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clusterspec"
+	"github.com/supergiant/control/pkg/kube"
+)
+
+const testSpec = `apiVersion: control.supergiant.io/v1
+kind: Cluster
+metadata:
+  name: %s
+spec:
+  account: do
+  nodeGroups:
+  - name: masters
+    role: master
+    count: 1
+    machine:
+      size: s-2vcpu-4gb
+`
+
+type fakeApplier struct {
+	applied []string
+	dryRun  bool
+}
+
+func (a *fakeApplier) ApplySpec(ctx context.Context, c *clusterspec.Cluster, dryRun bool) (*kube.SpecResult, error) {
+	a.applied = append(a.applied, c.Metadata.Name)
+	a.dryRun = dryRun
+	if c.Metadata.Name == "broken" {
+		return nil, errors.New("no account")
+	}
+	return &kube.SpecResult{ClusterID: c.Metadata.Name + "-id"}, nil
+}
+
+// testRepo makes a repository with the files committed.
+func testRepo(t *testing.T, files map[string]string) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "gitops-repo")
+	require.NoError(t, err)
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"checkout", "-q", "-b", "master"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "specs"},
+	} {
+		_, err := git(context.Background(), dir, args...)
+		require.NoError(t, err)
+	}
+
+	return dir
+}
+
+func TestController_Sync(t *testing.T) {
+	repo := testRepo(t, map[string]string{
+		"clusters/prod.yaml":   fmt.Sprintf(testSpec, "prod"),
+		"clusters/broken.yml":  fmt.Sprintf(testSpec, "broken"),
+		"clusters/invalid.yml": "kind: Pod",
+		"clusters/README.md":   "specs",
+		"other/dev.yaml":       fmt.Sprintf(testSpec, "dev"),
+	})
+	defer os.RemoveAll(repo)
+
+	dir, err := ioutil.TempDir("", "gitops")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	a := &fakeApplier{}
+	c := NewController(Config{
+		Repo:   repo,
+		Path:   "clusters",
+		Dir:    filepath.Join(dir, "repo"),
+		DryRun: true,
+	}, a)
+
+	// the second sync updates the working copy
+	for i := 0; i < 2; i++ {
+		require.NoError(t, c.Sync(context.Background()))
+	}
+
+	require.Equal(t, []string{"broken", "prod", "broken", "prod"}, a.applied)
+	require.True(t, a.dryRun)
+
+	status := c.Status()
+	require.Len(t, status.Revision, 40)
+	require.Empty(t, status.Error)
+	require.Len(t, status.Clusters, 3)
+	require.Equal(t, "clusters/broken.yml", status.Clusters[0].File)
+	require.Equal(t, "no account", status.Clusters[0].Error)
+	require.NotEmpty(t, status.Clusters[1].Error)
+	require.Equal(t, "prod-id", status.Clusters[2].Result.ClusterID)
+}
+
+func TestController_SyncError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gitops")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := NewController(Config{
+		Repo: filepath.Join(dir, "missing"),
+		Dir:  filepath.Join(dir, "repo"),
+	}, &fakeApplier{})
+	c.git = func(context.Context, string, ...string) (string, error) {
+		return "", errors.New("repository not found")
+	}
+
+	require.Error(t, c.Sync(context.Background()))
+
+	router := mux.NewRouter()
+	c.Register(router)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/gitops/status", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	status := Status{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&status))
+	require.Contains(t, status.Error, "repository not found")
+	require.Equal(t, "master", status.Branch)
+}
@@ -0,0 +1,31 @@
+package gitops
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/supergiant/control/pkg/message"
+)
+
+func (c *Controller) Register(r *mux.Router) {
+	r.HandleFunc("/gitops/status", c.getStatus).Methods(http.MethodGet)
+	r.HandleFunc("/gitops/sync", c.syncNow).Methods(http.MethodPost)
+}
+
+func (c *Controller) getStatus(w http.ResponseWriter, r *http.Request) {
+	if err := json.NewEncoder(w).Encode(c.Status()); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// syncNow syncs the repository without waiting for the interval, e.g. on a push webhook.
+func (c *Controller) syncNow(w http.ResponseWriter, r *http.Request) {
+	// errors are reported by the status
+	c.Sync(r.Context())
+
+	if err := json.NewEncoder(w).Encode(c.Status()); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
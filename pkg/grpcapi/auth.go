@@ -0,0 +1,83 @@
+package grpcapi
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/supergiant/control/pkg/api"
+)
+
+// Authenticator returns the identity of the user of a token.
+type Authenticator interface {
+	Identify(token string) (api.Identity, error)
+}
+
+// authenticate checks the token of the "authorization: Bearer <token>"
+// metadata like the REST API does with the header, requests are scoped by
+// teams of the user when teams are set.
+func authenticate(ctx context.Context, auth Authenticator, teams api.TeamLister) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "authorization token is required")
+	}
+
+	ts := strings.Split(values[0], " ")
+	if len(ts) <= 1 {
+		return nil, status.Error(codes.Unauthenticated, "authorization token is required")
+	}
+
+	id, err := auth.Identify(ts[1])
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	ctx = api.WithIdentity(ctx, id)
+	if teams == nil {
+		return ctx, nil
+	}
+
+	scope, err := api.ScopeOf(ctx, teams, id)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return api.WithScope(ctx, scope), nil
+}
+
+func unaryAuth(auth Authenticator, teams api.TeamLister) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(ctx, auth, teams)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func streamAuth(auth Authenticator, teams api.TeamLister) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), auth, teams)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &identifiedStream{ss, ctx})
+	}
+}
+
+// identifiedStream carries the identity of the user in its context.
+type identifiedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *identifiedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,1533 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: control.proto
+
+package controlpb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type Machine struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name                 string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Role                 string   `protobuf:"bytes,3,opt,name=role,proto3" json:"role,omitempty"`
+	State                string   `protobuf:"bytes,4,opt,name=state,proto3" json:"state,omitempty"`
+	Provider             string   `protobuf:"bytes,5,opt,name=provider,proto3" json:"provider,omitempty"`
+	Region               string   `protobuf:"bytes,6,opt,name=region,proto3" json:"region,omitempty"`
+	AvailabilityZone     string   `protobuf:"bytes,7,opt,name=availability_zone,json=availabilityZone,proto3" json:"availability_zone,omitempty"`
+	Size                 string   `protobuf:"bytes,8,opt,name=size,proto3" json:"size,omitempty"`
+	PublicIp             string   `protobuf:"bytes,9,opt,name=public_ip,json=publicIp,proto3" json:"public_ip,omitempty"`
+	PrivateIp            string   `protobuf:"bytes,10,opt,name=private_ip,json=privateIp,proto3" json:"private_ip,omitempty"`
+	TaskId               string   `protobuf:"bytes,11,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	CreatedAt            int64    `protobuf:"varint,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Machine) Reset()         { *m = Machine{} }
+func (m *Machine) String() string { return proto.CompactTextString(m) }
+func (*Machine) ProtoMessage()    {}
+func (*Machine) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{0}
+}
+
+func (m *Machine) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Machine.Unmarshal(m, b)
+}
+func (m *Machine) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Machine.Marshal(b, m, deterministic)
+}
+func (m *Machine) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Machine.Merge(m, src)
+}
+func (m *Machine) XXX_Size() int {
+	return xxx_messageInfo_Machine.Size(m)
+}
+func (m *Machine) XXX_DiscardUnknown() {
+	xxx_messageInfo_Machine.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Machine proto.InternalMessageInfo
+
+func (m *Machine) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Machine) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Machine) GetRole() string {
+	if m != nil {
+		return m.Role
+	}
+	return ""
+}
+
+func (m *Machine) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *Machine) GetProvider() string {
+	if m != nil {
+		return m.Provider
+	}
+	return ""
+}
+
+func (m *Machine) GetRegion() string {
+	if m != nil {
+		return m.Region
+	}
+	return ""
+}
+
+func (m *Machine) GetAvailabilityZone() string {
+	if m != nil {
+		return m.AvailabilityZone
+	}
+	return ""
+}
+
+func (m *Machine) GetSize() string {
+	if m != nil {
+		return m.Size
+	}
+	return ""
+}
+
+func (m *Machine) GetPublicIp() string {
+	if m != nil {
+		return m.PublicIp
+	}
+	return ""
+}
+
+func (m *Machine) GetPrivateIp() string {
+	if m != nil {
+		return m.PrivateIp
+	}
+	return ""
+}
+
+func (m *Machine) GetTaskId() string {
+	if m != nil {
+		return m.TaskId
+	}
+	return ""
+}
+
+func (m *Machine) GetCreatedAt() int64 {
+	if m != nil {
+		return m.CreatedAt
+	}
+	return 0
+}
+
+type Kube struct {
+	Id              string     `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name            string     `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	State           string     `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	Provider        string     `protobuf:"bytes,4,opt,name=provider,proto3" json:"provider,omitempty"`
+	AccountName     string     `protobuf:"bytes,5,opt,name=account_name,json=accountName,proto3" json:"account_name,omitempty"`
+	Region          string     `protobuf:"bytes,6,opt,name=region,proto3" json:"region,omitempty"`
+	ProfileId       string     `protobuf:"bytes,7,opt,name=profile_id,json=profileId,proto3" json:"profile_id,omitempty"`
+	K8SVersion      string     `protobuf:"bytes,8,opt,name=k8s_version,json=k8sVersion,proto3" json:"k8s_version,omitempty"`
+	OperatingSystem string     `protobuf:"bytes,9,opt,name=operating_system,json=operatingSystem,proto3" json:"operating_system,omitempty"`
+	ExternalDnsName string     `protobuf:"bytes,10,opt,name=external_dns_name,json=externalDnsName,proto3" json:"external_dns_name,omitempty"`
+	Masters         []*Machine `protobuf:"bytes,11,rep,name=masters,proto3" json:"masters,omitempty"`
+	Nodes           []*Machine `protobuf:"bytes,12,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	// task_ids of tasks that provision the kube
+	TaskIds              []string `protobuf:"bytes,13,rep,name=task_ids,json=taskIds,proto3" json:"task_ids,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Kube) Reset()         { *m = Kube{} }
+func (m *Kube) String() string { return proto.CompactTextString(m) }
+func (*Kube) ProtoMessage()    {}
+func (*Kube) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{1}
+}
+
+func (m *Kube) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Kube.Unmarshal(m, b)
+}
+func (m *Kube) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Kube.Marshal(b, m, deterministic)
+}
+func (m *Kube) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Kube.Merge(m, src)
+}
+func (m *Kube) XXX_Size() int {
+	return xxx_messageInfo_Kube.Size(m)
+}
+func (m *Kube) XXX_DiscardUnknown() {
+	xxx_messageInfo_Kube.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Kube proto.InternalMessageInfo
+
+func (m *Kube) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Kube) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Kube) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *Kube) GetProvider() string {
+	if m != nil {
+		return m.Provider
+	}
+	return ""
+}
+
+func (m *Kube) GetAccountName() string {
+	if m != nil {
+		return m.AccountName
+	}
+	return ""
+}
+
+func (m *Kube) GetRegion() string {
+	if m != nil {
+		return m.Region
+	}
+	return ""
+}
+
+func (m *Kube) GetProfileId() string {
+	if m != nil {
+		return m.ProfileId
+	}
+	return ""
+}
+
+func (m *Kube) GetK8SVersion() string {
+	if m != nil {
+		return m.K8SVersion
+	}
+	return ""
+}
+
+func (m *Kube) GetOperatingSystem() string {
+	if m != nil {
+		return m.OperatingSystem
+	}
+	return ""
+}
+
+func (m *Kube) GetExternalDnsName() string {
+	if m != nil {
+		return m.ExternalDnsName
+	}
+	return ""
+}
+
+func (m *Kube) GetMasters() []*Machine {
+	if m != nil {
+		return m.Masters
+	}
+	return nil
+}
+
+func (m *Kube) GetNodes() []*Machine {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
+func (m *Kube) GetTaskIds() []string {
+	if m != nil {
+		return m.TaskIds
+	}
+	return nil
+}
+
+type ListKubesRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListKubesRequest) Reset()         { *m = ListKubesRequest{} }
+func (m *ListKubesRequest) String() string { return proto.CompactTextString(m) }
+func (*ListKubesRequest) ProtoMessage()    {}
+func (*ListKubesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{2}
+}
+
+func (m *ListKubesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListKubesRequest.Unmarshal(m, b)
+}
+func (m *ListKubesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListKubesRequest.Marshal(b, m, deterministic)
+}
+func (m *ListKubesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListKubesRequest.Merge(m, src)
+}
+func (m *ListKubesRequest) XXX_Size() int {
+	return xxx_messageInfo_ListKubesRequest.Size(m)
+}
+func (m *ListKubesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListKubesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListKubesRequest proto.InternalMessageInfo
+
+type ListKubesResponse struct {
+	Kubes                []*Kube  `protobuf:"bytes,1,rep,name=kubes,proto3" json:"kubes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListKubesResponse) Reset()         { *m = ListKubesResponse{} }
+func (m *ListKubesResponse) String() string { return proto.CompactTextString(m) }
+func (*ListKubesResponse) ProtoMessage()    {}
+func (*ListKubesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{3}
+}
+
+func (m *ListKubesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListKubesResponse.Unmarshal(m, b)
+}
+func (m *ListKubesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListKubesResponse.Marshal(b, m, deterministic)
+}
+func (m *ListKubesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListKubesResponse.Merge(m, src)
+}
+func (m *ListKubesResponse) XXX_Size() int {
+	return xxx_messageInfo_ListKubesResponse.Size(m)
+}
+func (m *ListKubesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListKubesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListKubesResponse proto.InternalMessageInfo
+
+func (m *ListKubesResponse) GetKubes() []*Kube {
+	if m != nil {
+		return m.Kubes
+	}
+	return nil
+}
+
+type GetKubeRequest struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetKubeRequest) Reset()         { *m = GetKubeRequest{} }
+func (m *GetKubeRequest) String() string { return proto.CompactTextString(m) }
+func (*GetKubeRequest) ProtoMessage()    {}
+func (*GetKubeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{4}
+}
+
+func (m *GetKubeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetKubeRequest.Unmarshal(m, b)
+}
+func (m *GetKubeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetKubeRequest.Marshal(b, m, deterministic)
+}
+func (m *GetKubeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetKubeRequest.Merge(m, src)
+}
+func (m *GetKubeRequest) XXX_Size() int {
+	return xxx_messageInfo_GetKubeRequest.Size(m)
+}
+func (m *GetKubeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetKubeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetKubeRequest proto.InternalMessageInfo
+
+func (m *GetKubeRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+// CloudAccount is returned without credentials.
+type CloudAccount struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Provider             string   `protobuf:"bytes,2,opt,name=provider,proto3" json:"provider,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CloudAccount) Reset()         { *m = CloudAccount{} }
+func (m *CloudAccount) String() string { return proto.CompactTextString(m) }
+func (*CloudAccount) ProtoMessage()    {}
+func (*CloudAccount) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{5}
+}
+
+func (m *CloudAccount) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CloudAccount.Unmarshal(m, b)
+}
+func (m *CloudAccount) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CloudAccount.Marshal(b, m, deterministic)
+}
+func (m *CloudAccount) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CloudAccount.Merge(m, src)
+}
+func (m *CloudAccount) XXX_Size() int {
+	return xxx_messageInfo_CloudAccount.Size(m)
+}
+func (m *CloudAccount) XXX_DiscardUnknown() {
+	xxx_messageInfo_CloudAccount.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CloudAccount proto.InternalMessageInfo
+
+func (m *CloudAccount) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *CloudAccount) GetProvider() string {
+	if m != nil {
+		return m.Provider
+	}
+	return ""
+}
+
+type ListAccountsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListAccountsRequest) Reset()         { *m = ListAccountsRequest{} }
+func (m *ListAccountsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListAccountsRequest) ProtoMessage()    {}
+func (*ListAccountsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{6}
+}
+
+func (m *ListAccountsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListAccountsRequest.Unmarshal(m, b)
+}
+func (m *ListAccountsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListAccountsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListAccountsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListAccountsRequest.Merge(m, src)
+}
+func (m *ListAccountsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListAccountsRequest.Size(m)
+}
+func (m *ListAccountsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListAccountsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListAccountsRequest proto.InternalMessageInfo
+
+type ListAccountsResponse struct {
+	Accounts             []*CloudAccount `protobuf:"bytes,1,rep,name=accounts,proto3" json:"accounts,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *ListAccountsResponse) Reset()         { *m = ListAccountsResponse{} }
+func (m *ListAccountsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListAccountsResponse) ProtoMessage()    {}
+func (*ListAccountsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{7}
+}
+
+func (m *ListAccountsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListAccountsResponse.Unmarshal(m, b)
+}
+func (m *ListAccountsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListAccountsResponse.Marshal(b, m, deterministic)
+}
+func (m *ListAccountsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListAccountsResponse.Merge(m, src)
+}
+func (m *ListAccountsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListAccountsResponse.Size(m)
+}
+func (m *ListAccountsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListAccountsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListAccountsResponse proto.InternalMessageInfo
+
+func (m *ListAccountsResponse) GetAccounts() []*CloudAccount {
+	if m != nil {
+		return m.Accounts
+	}
+	return nil
+}
+
+type GetAccountRequest struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetAccountRequest) Reset()         { *m = GetAccountRequest{} }
+func (m *GetAccountRequest) String() string { return proto.CompactTextString(m) }
+func (*GetAccountRequest) ProtoMessage()    {}
+func (*GetAccountRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{8}
+}
+
+func (m *GetAccountRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetAccountRequest.Unmarshal(m, b)
+}
+func (m *GetAccountRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetAccountRequest.Marshal(b, m, deterministic)
+}
+func (m *GetAccountRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetAccountRequest.Merge(m, src)
+}
+func (m *GetAccountRequest) XXX_Size() int {
+	return xxx_messageInfo_GetAccountRequest.Size(m)
+}
+func (m *GetAccountRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetAccountRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetAccountRequest proto.InternalMessageInfo
+
+func (m *GetAccountRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type NodeProfile struct {
+	Settings             map[string]string `protobuf:"bytes,1,rep,name=settings,proto3" json:"settings,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *NodeProfile) Reset()         { *m = NodeProfile{} }
+func (m *NodeProfile) String() string { return proto.CompactTextString(m) }
+func (*NodeProfile) ProtoMessage()    {}
+func (*NodeProfile) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{9}
+}
+
+func (m *NodeProfile) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeProfile.Unmarshal(m, b)
+}
+func (m *NodeProfile) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeProfile.Marshal(b, m, deterministic)
+}
+func (m *NodeProfile) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeProfile.Merge(m, src)
+}
+func (m *NodeProfile) XXX_Size() int {
+	return xxx_messageInfo_NodeProfile.Size(m)
+}
+func (m *NodeProfile) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeProfile.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeProfile proto.InternalMessageInfo
+
+func (m *NodeProfile) GetSettings() map[string]string {
+	if m != nil {
+		return m.Settings
+	}
+	return nil
+}
+
+type Profile struct {
+	Id                   string         `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Provider             string         `protobuf:"bytes,2,opt,name=provider,proto3" json:"provider,omitempty"`
+	Region               string         `protobuf:"bytes,3,opt,name=region,proto3" json:"region,omitempty"`
+	Zone                 string         `protobuf:"bytes,4,opt,name=zone,proto3" json:"zone,omitempty"`
+	Arch                 string         `protobuf:"bytes,5,opt,name=arch,proto3" json:"arch,omitempty"`
+	OperatingSystem      string         `protobuf:"bytes,6,opt,name=operating_system,json=operatingSystem,proto3" json:"operating_system,omitempty"`
+	K8SVersion           string         `protobuf:"bytes,7,opt,name=k8s_version,json=k8sVersion,proto3" json:"k8s_version,omitempty"`
+	DockerVersion        string         `protobuf:"bytes,8,opt,name=docker_version,json=dockerVersion,proto3" json:"docker_version,omitempty"`
+	HelmVersion          string         `protobuf:"bytes,9,opt,name=helm_version,json=helmVersion,proto3" json:"helm_version,omitempty"`
+	NetworkProvider      string         `protobuf:"bytes,10,opt,name=network_provider,json=networkProvider,proto3" json:"network_provider,omitempty"`
+	RbacEnabled          bool           `protobuf:"varint,11,opt,name=rbac_enabled,json=rbacEnabled,proto3" json:"rbac_enabled,omitempty"`
+	MasterProfiles       []*NodeProfile `protobuf:"bytes,12,rep,name=master_profiles,json=masterProfiles,proto3" json:"master_profiles,omitempty"`
+	NodeProfiles         []*NodeProfile `protobuf:"bytes,13,rep,name=node_profiles,json=nodeProfiles,proto3" json:"node_profiles,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *Profile) Reset()         { *m = Profile{} }
+func (m *Profile) String() string { return proto.CompactTextString(m) }
+func (*Profile) ProtoMessage()    {}
+func (*Profile) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{10}
+}
+
+func (m *Profile) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Profile.Unmarshal(m, b)
+}
+func (m *Profile) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Profile.Marshal(b, m, deterministic)
+}
+func (m *Profile) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Profile.Merge(m, src)
+}
+func (m *Profile) XXX_Size() int {
+	return xxx_messageInfo_Profile.Size(m)
+}
+func (m *Profile) XXX_DiscardUnknown() {
+	xxx_messageInfo_Profile.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Profile proto.InternalMessageInfo
+
+func (m *Profile) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Profile) GetProvider() string {
+	if m != nil {
+		return m.Provider
+	}
+	return ""
+}
+
+func (m *Profile) GetRegion() string {
+	if m != nil {
+		return m.Region
+	}
+	return ""
+}
+
+func (m *Profile) GetZone() string {
+	if m != nil {
+		return m.Zone
+	}
+	return ""
+}
+
+func (m *Profile) GetArch() string {
+	if m != nil {
+		return m.Arch
+	}
+	return ""
+}
+
+func (m *Profile) GetOperatingSystem() string {
+	if m != nil {
+		return m.OperatingSystem
+	}
+	return ""
+}
+
+func (m *Profile) GetK8SVersion() string {
+	if m != nil {
+		return m.K8SVersion
+	}
+	return ""
+}
+
+func (m *Profile) GetDockerVersion() string {
+	if m != nil {
+		return m.DockerVersion
+	}
+	return ""
+}
+
+func (m *Profile) GetHelmVersion() string {
+	if m != nil {
+		return m.HelmVersion
+	}
+	return ""
+}
+
+func (m *Profile) GetNetworkProvider() string {
+	if m != nil {
+		return m.NetworkProvider
+	}
+	return ""
+}
+
+func (m *Profile) GetRbacEnabled() bool {
+	if m != nil {
+		return m.RbacEnabled
+	}
+	return false
+}
+
+func (m *Profile) GetMasterProfiles() []*NodeProfile {
+	if m != nil {
+		return m.MasterProfiles
+	}
+	return nil
+}
+
+func (m *Profile) GetNodeProfiles() []*NodeProfile {
+	if m != nil {
+		return m.NodeProfiles
+	}
+	return nil
+}
+
+type ListProfilesRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListProfilesRequest) Reset()         { *m = ListProfilesRequest{} }
+func (m *ListProfilesRequest) String() string { return proto.CompactTextString(m) }
+func (*ListProfilesRequest) ProtoMessage()    {}
+func (*ListProfilesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{11}
+}
+
+func (m *ListProfilesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListProfilesRequest.Unmarshal(m, b)
+}
+func (m *ListProfilesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListProfilesRequest.Marshal(b, m, deterministic)
+}
+func (m *ListProfilesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListProfilesRequest.Merge(m, src)
+}
+func (m *ListProfilesRequest) XXX_Size() int {
+	return xxx_messageInfo_ListProfilesRequest.Size(m)
+}
+func (m *ListProfilesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListProfilesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListProfilesRequest proto.InternalMessageInfo
+
+type ListProfilesResponse struct {
+	Profiles             []*Profile `protobuf:"bytes,1,rep,name=profiles,proto3" json:"profiles,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *ListProfilesResponse) Reset()         { *m = ListProfilesResponse{} }
+func (m *ListProfilesResponse) String() string { return proto.CompactTextString(m) }
+func (*ListProfilesResponse) ProtoMessage()    {}
+func (*ListProfilesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{12}
+}
+
+func (m *ListProfilesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListProfilesResponse.Unmarshal(m, b)
+}
+func (m *ListProfilesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListProfilesResponse.Marshal(b, m, deterministic)
+}
+func (m *ListProfilesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListProfilesResponse.Merge(m, src)
+}
+func (m *ListProfilesResponse) XXX_Size() int {
+	return xxx_messageInfo_ListProfilesResponse.Size(m)
+}
+func (m *ListProfilesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListProfilesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListProfilesResponse proto.InternalMessageInfo
+
+func (m *ListProfilesResponse) GetProfiles() []*Profile {
+	if m != nil {
+		return m.Profiles
+	}
+	return nil
+}
+
+type GetProfileRequest struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetProfileRequest) Reset()         { *m = GetProfileRequest{} }
+func (m *GetProfileRequest) String() string { return proto.CompactTextString(m) }
+func (*GetProfileRequest) ProtoMessage()    {}
+func (*GetProfileRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{13}
+}
+
+func (m *GetProfileRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetProfileRequest.Unmarshal(m, b)
+}
+func (m *GetProfileRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetProfileRequest.Marshal(b, m, deterministic)
+}
+func (m *GetProfileRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetProfileRequest.Merge(m, src)
+}
+func (m *GetProfileRequest) XXX_Size() int {
+	return xxx_messageInfo_GetProfileRequest.Size(m)
+}
+func (m *GetProfileRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetProfileRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetProfileRequest proto.InternalMessageInfo
+
+func (m *GetProfileRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type StepStatus struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Status               string   `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	ErrorMessage         string   `protobuf:"bytes,3,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StepStatus) Reset()         { *m = StepStatus{} }
+func (m *StepStatus) String() string { return proto.CompactTextString(m) }
+func (*StepStatus) ProtoMessage()    {}
+func (*StepStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{14}
+}
+
+func (m *StepStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StepStatus.Unmarshal(m, b)
+}
+func (m *StepStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StepStatus.Marshal(b, m, deterministic)
+}
+func (m *StepStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StepStatus.Merge(m, src)
+}
+func (m *StepStatus) XXX_Size() int {
+	return xxx_messageInfo_StepStatus.Size(m)
+}
+func (m *StepStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_StepStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StepStatus proto.InternalMessageInfo
+
+func (m *StepStatus) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *StepStatus) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+func (m *StepStatus) GetErrorMessage() string {
+	if m != nil {
+		return m.ErrorMessage
+	}
+	return ""
+}
+
+type Task struct {
+	Id                   string        `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type                 string        `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Status               string        `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	Steps                []*StepStatus `protobuf:"bytes,4,rep,name=steps,proto3" json:"steps,omitempty"`
+	CreatedAt            int64         `protobuf:"varint,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *Task) Reset()         { *m = Task{} }
+func (m *Task) String() string { return proto.CompactTextString(m) }
+func (*Task) ProtoMessage()    {}
+func (*Task) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{15}
+}
+
+func (m *Task) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Task.Unmarshal(m, b)
+}
+func (m *Task) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Task.Marshal(b, m, deterministic)
+}
+func (m *Task) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Task.Merge(m, src)
+}
+func (m *Task) XXX_Size() int {
+	return xxx_messageInfo_Task.Size(m)
+}
+func (m *Task) XXX_DiscardUnknown() {
+	xxx_messageInfo_Task.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Task proto.InternalMessageInfo
+
+func (m *Task) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Task) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *Task) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+func (m *Task) GetSteps() []*StepStatus {
+	if m != nil {
+		return m.Steps
+	}
+	return nil
+}
+
+func (m *Task) GetCreatedAt() int64 {
+	if m != nil {
+		return m.CreatedAt
+	}
+	return 0
+}
+
+type GetTaskRequest struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetTaskRequest) Reset()         { *m = GetTaskRequest{} }
+func (m *GetTaskRequest) String() string { return proto.CompactTextString(m) }
+func (*GetTaskRequest) ProtoMessage()    {}
+func (*GetTaskRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{16}
+}
+
+func (m *GetTaskRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTaskRequest.Unmarshal(m, b)
+}
+func (m *GetTaskRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTaskRequest.Marshal(b, m, deterministic)
+}
+func (m *GetTaskRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTaskRequest.Merge(m, src)
+}
+func (m *GetTaskRequest) XXX_Size() int {
+	return xxx_messageInfo_GetTaskRequest.Size(m)
+}
+func (m *GetTaskRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTaskRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTaskRequest proto.InternalMessageInfo
+
+func (m *GetTaskRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type LogRequest struct {
+	TaskId string `protobuf:"bytes,1,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	// unsubscribe stops sending logs of the task.
+	Unsubscribe          bool     `protobuf:"varint,2,opt,name=unsubscribe,proto3" json:"unsubscribe,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LogRequest) Reset()         { *m = LogRequest{} }
+func (m *LogRequest) String() string { return proto.CompactTextString(m) }
+func (*LogRequest) ProtoMessage()    {}
+func (*LogRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{17}
+}
+
+func (m *LogRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LogRequest.Unmarshal(m, b)
+}
+func (m *LogRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LogRequest.Marshal(b, m, deterministic)
+}
+func (m *LogRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LogRequest.Merge(m, src)
+}
+func (m *LogRequest) XXX_Size() int {
+	return xxx_messageInfo_LogRequest.Size(m)
+}
+func (m *LogRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_LogRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LogRequest proto.InternalMessageInfo
+
+func (m *LogRequest) GetTaskId() string {
+	if m != nil {
+		return m.TaskId
+	}
+	return ""
+}
+
+func (m *LogRequest) GetUnsubscribe() bool {
+	if m != nil {
+		return m.Unsubscribe
+	}
+	return false
+}
+
+type LogLine struct {
+	TaskId               string   `protobuf:"bytes,1,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	Text                 string   `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LogLine) Reset()         { *m = LogLine{} }
+func (m *LogLine) String() string { return proto.CompactTextString(m) }
+func (*LogLine) ProtoMessage()    {}
+func (*LogLine) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{18}
+}
+
+func (m *LogLine) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LogLine.Unmarshal(m, b)
+}
+func (m *LogLine) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LogLine.Marshal(b, m, deterministic)
+}
+func (m *LogLine) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LogLine.Merge(m, src)
+}
+func (m *LogLine) XXX_Size() int {
+	return xxx_messageInfo_LogLine.Size(m)
+}
+func (m *LogLine) XXX_DiscardUnknown() {
+	xxx_messageInfo_LogLine.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LogLine proto.InternalMessageInfo
+
+func (m *LogLine) GetTaskId() string {
+	if m != nil {
+		return m.TaskId
+	}
+	return ""
+}
+
+func (m *LogLine) GetText() string {
+	if m != nil {
+		return m.Text
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*Machine)(nil), "supergiant.control.v1.Machine")
+	proto.RegisterType((*Kube)(nil), "supergiant.control.v1.Kube")
+	proto.RegisterType((*ListKubesRequest)(nil), "supergiant.control.v1.ListKubesRequest")
+	proto.RegisterType((*ListKubesResponse)(nil), "supergiant.control.v1.ListKubesResponse")
+	proto.RegisterType((*GetKubeRequest)(nil), "supergiant.control.v1.GetKubeRequest")
+	proto.RegisterType((*CloudAccount)(nil), "supergiant.control.v1.CloudAccount")
+	proto.RegisterType((*ListAccountsRequest)(nil), "supergiant.control.v1.ListAccountsRequest")
+	proto.RegisterType((*ListAccountsResponse)(nil), "supergiant.control.v1.ListAccountsResponse")
+	proto.RegisterType((*GetAccountRequest)(nil), "supergiant.control.v1.GetAccountRequest")
+	proto.RegisterType((*NodeProfile)(nil), "supergiant.control.v1.NodeProfile")
+	proto.RegisterMapType((map[string]string)(nil), "supergiant.control.v1.NodeProfile.SettingsEntry")
+	proto.RegisterType((*Profile)(nil), "supergiant.control.v1.Profile")
+	proto.RegisterType((*ListProfilesRequest)(nil), "supergiant.control.v1.ListProfilesRequest")
+	proto.RegisterType((*ListProfilesResponse)(nil), "supergiant.control.v1.ListProfilesResponse")
+	proto.RegisterType((*GetProfileRequest)(nil), "supergiant.control.v1.GetProfileRequest")
+	proto.RegisterType((*StepStatus)(nil), "supergiant.control.v1.StepStatus")
+	proto.RegisterType((*Task)(nil), "supergiant.control.v1.Task")
+	proto.RegisterType((*GetTaskRequest)(nil), "supergiant.control.v1.GetTaskRequest")
+	proto.RegisterType((*LogRequest)(nil), "supergiant.control.v1.LogRequest")
+	proto.RegisterType((*LogLine)(nil), "supergiant.control.v1.LogLine")
+}
+
+func init() { proto.RegisterFile("control.proto", fileDescriptor_0c5120591600887d) }
+
+var fileDescriptor_0c5120591600887d = []byte{
+	// 1096 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x57, 0xfd, 0x6e, 0x1b, 0x45,
+	0x10, 0xd7, 0xf9, 0x23, 0x67, 0x8f, 0xed, 0x7c, 0x2c, 0x69, 0x39, 0x5c, 0x01, 0xce, 0x45, 0x55,
+	0x4d, 0x23, 0x45, 0x6d, 0x41, 0x10, 0x15, 0x09, 0x54, 0x4a, 0x89, 0xa2, 0x3a, 0x55, 0x75, 0x41,
+	0x45, 0x2a, 0x82, 0xd3, 0xda, 0xb7, 0x38, 0x27, 0x9f, 0x6f, 0x8f, 0xdd, 0xb5, 0x49, 0xfa, 0x24,
+	0x88, 0x37, 0xe1, 0x3f, 0xde, 0x85, 0x07, 0xe0, 0x15, 0xd0, 0x7e, 0x5d, 0xce, 0xae, 0xcf, 0xf1,
+	0x7f, 0xb7, 0xbf, 0xf9, 0xd8, 0x99, 0xd9, 0xf9, 0xcd, 0xd8, 0xd0, 0x19, 0xd1, 0x54, 0x30, 0x9a,
+	0x1c, 0x67, 0x8c, 0x0a, 0x8a, 0xee, 0xf0, 0x59, 0x46, 0xd8, 0x38, 0xc6, 0xa9, 0x38, 0xb6, 0x92,
+	0xf9, 0x63, 0xff, 0x9f, 0x0a, 0xb8, 0xe7, 0x78, 0x74, 0x19, 0xa7, 0x04, 0x6d, 0x43, 0x25, 0x8e,
+	0x3c, 0xa7, 0xe7, 0xf4, 0x9b, 0x41, 0x25, 0x8e, 0x10, 0x82, 0x5a, 0x8a, 0xa7, 0xc4, 0xab, 0x28,
+	0x44, 0x7d, 0x4b, 0x8c, 0xd1, 0x84, 0x78, 0x55, 0x8d, 0xc9, 0x6f, 0xb4, 0x0f, 0x75, 0x2e, 0xb0,
+	0x20, 0x5e, 0x4d, 0x81, 0xfa, 0x80, 0xba, 0xd0, 0xc8, 0x18, 0x9d, 0xc7, 0x11, 0x61, 0x5e, 0x5d,
+	0x09, 0xf2, 0x33, 0xba, 0x0b, 0x5b, 0x8c, 0x8c, 0x63, 0x9a, 0x7a, 0x5b, 0x4a, 0x62, 0x4e, 0xe8,
+	0x08, 0xf6, 0xf0, 0x1c, 0xc7, 0x09, 0x1e, 0xc6, 0x49, 0x2c, 0xae, 0xc3, 0x77, 0x34, 0x25, 0x9e,
+	0xab, 0x54, 0x76, 0x8b, 0x82, 0xb7, 0x34, 0x55, 0xa1, 0xf0, 0xf8, 0x1d, 0xf1, 0x1a, 0x3a, 0x14,
+	0xf9, 0x8d, 0xee, 0x41, 0x33, 0x9b, 0x0d, 0x93, 0x78, 0x14, 0xc6, 0x99, 0xd7, 0x34, 0xb7, 0x2a,
+	0xe0, 0x2c, 0x43, 0x1f, 0x03, 0x64, 0x2c, 0x9e, 0x63, 0x41, 0xa4, 0x14, 0x94, 0xb4, 0x69, 0x90,
+	0xb3, 0x0c, 0x7d, 0x08, 0xae, 0xc0, 0x7c, 0x12, 0xc6, 0x91, 0xd7, 0xd2, 0x51, 0xc9, 0xe3, 0x59,
+	0x24, 0xed, 0x46, 0x8c, 0x60, 0x41, 0xa2, 0x10, 0x0b, 0xaf, 0xdd, 0x73, 0xfa, 0xd5, 0xa0, 0x69,
+	0x90, 0x67, 0xc2, 0xff, 0xbb, 0x0a, 0xb5, 0x97, 0xb3, 0xe1, 0x66, 0xf5, 0xcb, 0x6b, 0x55, 0x2d,
+	0xab, 0x55, 0x6d, 0xa9, 0x56, 0x07, 0xd0, 0xc6, 0xa3, 0x11, 0x9d, 0xa5, 0x22, 0x54, 0xde, 0x74,
+	0x2d, 0x5b, 0x06, 0x7b, 0x25, 0x9d, 0x96, 0x95, 0x53, 0x25, 0x4c, 0x7f, 0x8b, 0x13, 0x22, 0x93,
+	0x72, 0x6d, 0xc2, 0x0a, 0x39, 0x8b, 0xd0, 0xa7, 0xd0, 0x9a, 0x9c, 0xf0, 0x70, 0x4e, 0x18, 0x97,
+	0xb6, 0xba, 0x8e, 0x30, 0x39, 0xe1, 0x6f, 0x34, 0x82, 0x3e, 0x83, 0x5d, 0x9a, 0x11, 0x86, 0x45,
+	0x9c, 0x8e, 0x43, 0x7e, 0xcd, 0x05, 0x99, 0x9a, 0xa2, 0xee, 0xe4, 0xf8, 0x85, 0x82, 0xd1, 0x43,
+	0xd8, 0x23, 0x57, 0x82, 0xb0, 0x14, 0x27, 0x61, 0x94, 0x72, 0x1d, 0xaa, 0x2e, 0xf1, 0x8e, 0x15,
+	0x7c, 0x9f, 0x72, 0x15, 0xee, 0x09, 0xb8, 0x53, 0xcc, 0x05, 0x61, 0xdc, 0x6b, 0xf5, 0xaa, 0xfd,
+	0xd6, 0x93, 0x4f, 0x8e, 0x57, 0x36, 0xe7, 0xb1, 0x69, 0xcc, 0xc0, 0xaa, 0xa3, 0x2f, 0xa0, 0x9e,
+	0xd2, 0x88, 0x70, 0xaf, 0xbd, 0x91, 0x9d, 0x56, 0x46, 0x1f, 0x41, 0xc3, 0x3c, 0x2c, 0xf7, 0x3a,
+	0xbd, 0x6a, 0xbf, 0x19, 0xb8, 0xfa, 0x65, 0xb9, 0x8f, 0x60, 0x77, 0x10, 0x73, 0x21, 0x9f, 0x8f,
+	0x07, 0xe4, 0xf7, 0x19, 0xe1, 0xc2, 0xff, 0x01, 0xf6, 0x0a, 0x18, 0xcf, 0x68, 0xca, 0x09, 0x7a,
+	0x0c, 0xf5, 0x89, 0x04, 0x3c, 0x47, 0xdd, 0x7c, 0xaf, 0xe4, 0x66, 0x69, 0x14, 0x68, 0x4d, 0xbf,
+	0x07, 0xdb, 0xa7, 0x44, 0xb9, 0x31, 0x9e, 0x97, 0x1b, 0xc4, 0xff, 0x06, 0xda, 0xcf, 0x13, 0x3a,
+	0x8b, 0x9e, 0xe9, 0xb7, 0xcc, 0x1b, 0xc6, 0x29, 0x34, 0x4c, 0xb1, 0x35, 0x2a, 0x8b, 0xad, 0xe1,
+	0xdf, 0x81, 0x0f, 0x64, 0xa4, 0xc6, 0x3c, 0x4f, 0xe0, 0x27, 0xd8, 0x5f, 0x84, 0x4d, 0x0e, 0xdf,
+	0x42, 0xc3, 0x74, 0x8d, 0x4d, 0xe3, 0xb0, 0x24, 0x8d, 0x62, 0x54, 0x41, 0x6e, 0xe4, 0x3f, 0x80,
+	0xbd, 0x53, 0x62, 0xfd, 0xda, 0xa4, 0x56, 0x04, 0xed, 0xff, 0xe9, 0x40, 0xeb, 0x15, 0x8d, 0xc8,
+	0x6b, 0xdd, 0x6b, 0x68, 0x00, 0x0d, 0x4e, 0x84, 0x6c, 0x17, 0x7b, 0xf3, 0xa3, 0x92, 0x9b, 0x0b,
+	0x56, 0xc7, 0x17, 0xc6, 0xe4, 0x45, 0x2a, 0xd8, 0x75, 0x90, 0x7b, 0xe8, 0x7e, 0x0d, 0x9d, 0x05,
+	0x11, 0xda, 0x85, 0xea, 0x84, 0x5c, 0x9b, 0x08, 0xe4, 0xa7, 0xa4, 0xd9, 0x1c, 0x27, 0x33, 0xcb,
+	0x3d, 0x7d, 0x78, 0x5a, 0x39, 0x71, 0xfc, 0xff, 0xaa, 0xe0, 0xda, 0xb0, 0x96, 0x09, 0xbb, 0xa6,
+	0xd6, 0x05, 0x8e, 0x55, 0x17, 0x38, 0x86, 0xa0, 0xa6, 0xa6, 0x94, 0xa6, 0xad, 0xfa, 0x96, 0x18,
+	0x66, 0xa3, 0x4b, 0x43, 0x55, 0xf5, 0xbd, 0x92, 0x4b, 0x5b, 0xab, 0xb9, 0xb4, 0xc4, 0x4b, 0xf7,
+	0x3d, 0x5e, 0xde, 0x87, 0xed, 0x88, 0x8e, 0x26, 0x84, 0x2d, 0x71, 0xb7, 0xa3, 0x51, 0xab, 0x76,
+	0x00, 0xed, 0x4b, 0x92, 0x4c, 0x73, 0x25, 0x4d, 0xdd, 0x96, 0xc4, 0x0a, 0x0c, 0x4f, 0x89, 0xf8,
+	0x83, 0xb2, 0x49, 0x98, 0x67, 0x6e, 0x58, 0x6b, 0xf0, 0xd7, 0x85, 0x39, 0xc4, 0x86, 0x78, 0x14,
+	0x92, 0x14, 0x0f, 0x13, 0xa2, 0x67, 0x64, 0x23, 0x68, 0x49, 0xec, 0x85, 0x86, 0xd0, 0x4b, 0xd8,
+	0xd1, 0x4c, 0x0d, 0xcd, 0x90, 0xb1, 0x44, 0xf5, 0x6f, 0x7f, 0xed, 0x60, 0x5b, 0x9b, 0x9a, 0x23,
+	0x47, 0xa7, 0xd0, 0x91, 0xf4, 0xbd, 0x71, 0xd5, 0xd9, 0xd8, 0x55, 0x3b, 0xbd, 0x39, 0x70, 0xcb,
+	0x12, 0x7b, 0xb6, 0x2c, 0x09, 0x60, 0x7f, 0x11, 0x36, 0x2c, 0x79, 0xaa, 0x9a, 0x40, 0x5f, 0xe9,
+	0xac, 0x1d, 0x33, 0xf6, 0xba, 0x5c, 0xdf, 0x3f, 0x54, 0x04, 0xb1, 0x78, 0x09, 0xeb, 0x7f, 0x01,
+	0xb8, 0x10, 0x24, 0xbb, 0x10, 0x58, 0xcc, 0xf8, 0x4a, 0xce, 0xdf, 0x85, 0x2d, 0xae, 0xa4, 0xa6,
+	0x0b, 0xcd, 0x09, 0x1d, 0x42, 0x87, 0x30, 0x46, 0x59, 0x38, 0x25, 0x9c, 0xe3, 0xb1, 0x5d, 0x22,
+	0x6d, 0x05, 0x9e, 0x6b, 0xcc, 0xff, 0xcb, 0x81, 0xda, 0x8f, 0x98, 0x4f, 0x56, 0xad, 0x23, 0x71,
+	0x9d, 0xe5, 0xeb, 0x48, 0x7e, 0x17, 0x6e, 0xaa, 0x2e, 0xdc, 0xf4, 0x95, 0x5c, 0x53, 0x24, 0xe3,
+	0x5e, 0x4d, 0x55, 0xe0, 0xa0, 0xa4, 0x02, 0x37, 0x79, 0x04, 0x5a, 0x7f, 0x69, 0x57, 0xd6, 0x97,
+	0x77, 0xa5, 0x9e, 0x89, 0x32, 0xbc, 0xb2, 0xea, 0x9c, 0x02, 0x0c, 0xe8, 0xd8, 0x4a, 0x0b, 0x3b,
+	0xd9, 0x59, 0xd8, 0xc9, 0x3d, 0x68, 0xcd, 0x52, 0x3e, 0x1b, 0xf2, 0x11, 0x8b, 0x87, 0x3a, 0xa7,
+	0x46, 0x50, 0x84, 0xfc, 0x2f, 0xc1, 0x1d, 0xd0, 0xf1, 0x40, 0xfe, 0xb0, 0x29, 0xf5, 0x22, 0x4b,
+	0x42, 0xae, 0x44, 0x5e, 0x12, 0x72, 0x25, 0x9e, 0xfc, 0x5b, 0x07, 0xf7, 0xb9, 0x4e, 0x11, 0xfd,
+	0x0a, 0xcd, 0x7c, 0x15, 0xa0, 0x07, 0x25, 0x45, 0x58, 0x5e, 0x20, 0xdd, 0xfe, 0xed, 0x8a, 0xa6,
+	0xd7, 0xce, 0xc1, 0x35, 0x2b, 0x02, 0xdd, 0x2f, 0x31, 0x5a, 0x5c, 0x21, 0xdd, 0x75, 0x8b, 0x07,
+	0x8d, 0xa1, 0x5d, 0x1c, 0xfc, 0xe8, 0xe1, 0x9a, 0x40, 0x96, 0x96, 0x46, 0xf7, 0x68, 0x23, 0x5d,
+	0x13, 0xf7, 0xcf, 0x00, 0x37, 0x8b, 0x00, 0xf5, 0xcb, 0x43, 0x5f, 0xdc, 0x15, 0xdd, 0x4d, 0xf6,
+	0x8d, 0xcd, 0x22, 0x1f, 0x04, 0xeb, 0xb2, 0x58, 0x22, 0x75, 0xf7, 0x68, 0x23, 0x5d, 0x93, 0xc5,
+	0x1b, 0x95, 0x85, 0x81, 0xd7, 0x65, 0xb1, 0x48, 0xe8, 0xee, 0x2d, 0xf3, 0xc0, 0xbc, 0xaa, 0xe2,
+	0xe0, 0x9a, 0x57, 0x2d, 0x90, 0xa0, 0xf4, 0x55, 0x95, 0x8f, 0x0b, 0x39, 0x2f, 0x18, 0xc1, 0xd3,
+	0x01, 0x1d, 0x73, 0x54, 0x46, 0xc5, 0x1b, 0xd2, 0x94, 0xc6, 0x67, 0xe8, 0xd0, 0x77, 0x1e, 0x39,
+	0xdf, 0xb5, 0xde, 0x36, 0x8d, 0x24, 0x1b, 0x0e, 0xb7, 0xd4, 0x5f, 0x84, 0xcf, 0xff, 0x1f, 0x00,
+	0x9c, 0xc8, 0x0c, 0xd6, 0x33, 0x0c, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// ControlClient is the client API for Control service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ControlClient interface {
+	ListKubes(ctx context.Context, in *ListKubesRequest, opts ...grpc.CallOption) (*ListKubesResponse, error)
+	GetKube(ctx context.Context, in *GetKubeRequest, opts ...grpc.CallOption) (*Kube, error)
+	ListAccounts(ctx context.Context, in *ListAccountsRequest, opts ...grpc.CallOption) (*ListAccountsResponse, error)
+	GetAccount(ctx context.Context, in *GetAccountRequest, opts ...grpc.CallOption) (*CloudAccount, error)
+	ListProfiles(ctx context.Context, in *ListProfilesRequest, opts ...grpc.CallOption) (*ListProfilesResponse, error)
+	GetProfile(ctx context.Context, in *GetProfileRequest, opts ...grpc.CallOption) (*Profile, error)
+	GetTask(ctx context.Context, in *GetTaskRequest, opts ...grpc.CallOption) (*Task, error)
+	// StreamLogs sends lines of logs of the tasks the client is subscribed to,
+	// subscriptions are changed by sending requests over the stream.
+	StreamLogs(ctx context.Context, opts ...grpc.CallOption) (Control_StreamLogsClient, error)
+}
+
+type controlClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewControlClient(cc *grpc.ClientConn) ControlClient {
+	return &controlClient{cc}
+}
+
+func (c *controlClient) ListKubes(ctx context.Context, in *ListKubesRequest, opts ...grpc.CallOption) (*ListKubesResponse, error) {
+	out := new(ListKubesResponse)
+	err := c.cc.Invoke(ctx, "/supergiant.control.v1.Control/ListKubes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) GetKube(ctx context.Context, in *GetKubeRequest, opts ...grpc.CallOption) (*Kube, error) {
+	out := new(Kube)
+	err := c.cc.Invoke(ctx, "/supergiant.control.v1.Control/GetKube", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) ListAccounts(ctx context.Context, in *ListAccountsRequest, opts ...grpc.CallOption) (*ListAccountsResponse, error) {
+	out := new(ListAccountsResponse)
+	err := c.cc.Invoke(ctx, "/supergiant.control.v1.Control/ListAccounts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) GetAccount(ctx context.Context, in *GetAccountRequest, opts ...grpc.CallOption) (*CloudAccount, error) {
+	out := new(CloudAccount)
+	err := c.cc.Invoke(ctx, "/supergiant.control.v1.Control/GetAccount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) ListProfiles(ctx context.Context, in *ListProfilesRequest, opts ...grpc.CallOption) (*ListProfilesResponse, error) {
+	out := new(ListProfilesResponse)
+	err := c.cc.Invoke(ctx, "/supergiant.control.v1.Control/ListProfiles", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) GetProfile(ctx context.Context, in *GetProfileRequest, opts ...grpc.CallOption) (*Profile, error) {
+	out := new(Profile)
+	err := c.cc.Invoke(ctx, "/supergiant.control.v1.Control/GetProfile", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) GetTask(ctx context.Context, in *GetTaskRequest, opts ...grpc.CallOption) (*Task, error) {
+	out := new(Task)
+	err := c.cc.Invoke(ctx, "/supergiant.control.v1.Control/GetTask", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) StreamLogs(ctx context.Context, opts ...grpc.CallOption) (Control_StreamLogsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Control_serviceDesc.Streams[0], "/supergiant.control.v1.Control/StreamLogs", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &controlStreamLogsClient{stream}
+	return x, nil
+}
+
+type Control_StreamLogsClient interface {
+	Send(*LogRequest) error
+	Recv() (*LogLine, error)
+	grpc.ClientStream
+}
+
+type controlStreamLogsClient struct {
+	grpc.ClientStream
+}
+
+func (x *controlStreamLogsClient) Send(m *LogRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *controlStreamLogsClient) Recv() (*LogLine, error) {
+	m := new(LogLine)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ControlServer is the server API for Control service.
+type ControlServer interface {
+	ListKubes(context.Context, *ListKubesRequest) (*ListKubesResponse, error)
+	GetKube(context.Context, *GetKubeRequest) (*Kube, error)
+	ListAccounts(context.Context, *ListAccountsRequest) (*ListAccountsResponse, error)
+	GetAccount(context.Context, *GetAccountRequest) (*CloudAccount, error)
+	ListProfiles(context.Context, *ListProfilesRequest) (*ListProfilesResponse, error)
+	GetProfile(context.Context, *GetProfileRequest) (*Profile, error)
+	GetTask(context.Context, *GetTaskRequest) (*Task, error)
+	// StreamLogs sends lines of logs of the tasks the client is subscribed to,
+	// subscriptions are changed by sending requests over the stream.
+	StreamLogs(Control_StreamLogsServer) error
+}
+
+func RegisterControlServer(s *grpc.Server, srv ControlServer) {
+	s.RegisterService(&_Control_serviceDesc, srv)
+}
+
+func _Control_ListKubes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListKubesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).ListKubes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/supergiant.control.v1.Control/ListKubes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).ListKubes(ctx, req.(*ListKubesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_GetKube_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetKubeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).GetKube(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/supergiant.control.v1.Control/GetKube",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).GetKube(ctx, req.(*GetKubeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_ListAccounts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAccountsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).ListAccounts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/supergiant.control.v1.Control/ListAccounts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).ListAccounts(ctx, req.(*ListAccountsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_GetAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).GetAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/supergiant.control.v1.Control/GetAccount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).GetAccount(ctx, req.(*GetAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_ListProfiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListProfilesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).ListProfiles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/supergiant.control.v1.Control/ListProfiles",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).ListProfiles(ctx, req.(*ListProfilesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_GetProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetProfileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).GetProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/supergiant.control.v1.Control/GetProfile",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).GetProfile(ctx, req.(*GetProfileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_GetTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).GetTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/supergiant.control.v1.Control/GetTask",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).GetTask(ctx, req.(*GetTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_StreamLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ControlServer).StreamLogs(&controlStreamLogsServer{stream})
+}
+
+type Control_StreamLogsServer interface {
+	Send(*LogLine) error
+	Recv() (*LogRequest, error)
+	grpc.ServerStream
+}
+
+type controlStreamLogsServer struct {
+	grpc.ServerStream
+}
+
+func (x *controlStreamLogsServer) Send(m *LogLine) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *controlStreamLogsServer) Recv() (*LogRequest, error) {
+	m := new(LogRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var _Control_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "supergiant.control.v1.Control",
+	HandlerType: (*ControlServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListKubes",
+			Handler:    _Control_ListKubes_Handler,
+		},
+		{
+			MethodName: "GetKube",
+			Handler:    _Control_GetKube_Handler,
+		},
+		{
+			MethodName: "ListAccounts",
+			Handler:    _Control_ListAccounts_Handler,
+		},
+		{
+			MethodName: "GetAccount",
+			Handler:    _Control_GetAccount_Handler,
+		},
+		{
+			MethodName: "ListProfiles",
+			Handler:    _Control_ListProfiles_Handler,
+		},
+		{
+			MethodName: "GetProfile",
+			Handler:    _Control_GetProfile_Handler,
+		},
+		{
+			MethodName: "GetTask",
+			Handler:    _Control_GetTask_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamLogs",
+			Handler:       _Control_StreamLogs_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "control.proto",
+}
@@ -0,0 +1,116 @@
+package grpcapi
+
+import (
+	"sort"
+
+	"github.com/supergiant/control/pkg/grpcapi/controlpb"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/workflows"
+)
+
+func toKube(k *model.Kube) *controlpb.Kube {
+	out := &controlpb.Kube{
+		Id:              k.ID,
+		Name:            k.Name,
+		State:           string(k.State),
+		Provider:        string(k.Provider),
+		AccountName:     k.AccountName,
+		Region:          k.Region,
+		ProfileId:       k.ProfileID,
+		K8SVersion:      k.K8SVersion,
+		OperatingSystem: k.OperatingSystem,
+		ExternalDnsName: k.ExternalDNSName,
+		Masters:         toMachines(k.Masters),
+		Nodes:           toMachines(k.Nodes),
+	}
+	for _, ids := range k.Tasks {
+		out.TaskIds = append(out.TaskIds, ids...)
+	}
+	sort.Strings(out.TaskIds)
+
+	return out
+}
+
+// toMachines returns machines ordered by name.
+func toMachines(machines map[string]*model.Machine) []*controlpb.Machine {
+	out := make([]*controlpb.Machine, 0, len(machines))
+	for _, m := range machines {
+		if m == nil {
+			continue
+		}
+		out = append(out, &controlpb.Machine{
+			Id:               m.ID,
+			Name:             m.Name,
+			Role:             string(m.Role),
+			State:            string(m.State),
+			Provider:         string(m.Provider),
+			Region:           m.Region,
+			AvailabilityZone: m.AvailabilityZone,
+			Size:             m.Size,
+			PublicIp:         m.PublicIp,
+			PrivateIp:        m.PrivateIp,
+			TaskId:           m.TaskID,
+			CreatedAt:        m.CreatedAt,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+
+	return out
+}
+
+func toAccount(acc *model.CloudAccount) *controlpb.CloudAccount {
+	return &controlpb.CloudAccount{
+		Name:     acc.Name,
+		Provider: string(acc.Provider),
+	}
+}
+
+func toProfile(p *profile.Profile) *controlpb.Profile {
+	return &controlpb.Profile{
+		Id:              p.ID,
+		Provider:        string(p.Provider),
+		Region:          p.Region,
+		Zone:            p.Zone,
+		Arch:            p.Arch,
+		OperatingSystem: p.OperatingSystem,
+		K8SVersion:      p.K8SVersion,
+		DockerVersion:   p.DockerVersion,
+		HelmVersion:     p.HelmVersion,
+		NetworkProvider: p.NetworkProvider,
+		RbacEnabled:     p.RBACEnabled,
+		MasterProfiles:  toNodeProfiles(p.MasterProfiles),
+		NodeProfiles:    toNodeProfiles(p.NodesProfiles),
+	}
+}
+
+func toNodeProfiles(profiles []profile.NodeProfile) []*controlpb.NodeProfile {
+	out := make([]*controlpb.NodeProfile, 0, len(profiles))
+	for _, p := range profiles {
+		out = append(out, &controlpb.NodeProfile{
+			Settings: p,
+		})
+	}
+
+	return out
+}
+
+func toTask(t *workflows.Task) *controlpb.Task {
+	out := &controlpb.Task{
+		Id:        t.ID,
+		Type:      t.Type,
+		Status:    string(t.Status),
+		CreatedAt: t.CreatedAt.Unix(),
+	}
+	for _, s := range t.StepStatuses {
+		out.Steps = append(out.Steps, &controlpb.StepStatus{
+			Name:         s.StepName,
+			Status:       string(s.Status),
+			ErrorMessage: s.ErrMsg,
+		})
+	}
+
+	return out
+}
@@ -0,0 +1,291 @@
+// Package grpcapi serves the gRPC API of control, it exposes the same kubes,
+// accounts, profiles and tasks as the REST API. Protobuf definitions are in
+// the proto directory, clients are generated from them with make proto.
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/hpcloud/tail"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/grpcapi/controlpb"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
+	"github.com/supergiant/control/pkg/workflows"
+)
+
+type kubeService interface {
+	Get(ctx context.Context, kubeID string) (*model.Kube, error)
+	ListAll(ctx context.Context) ([]model.Kube, error)
+}
+
+type accountService interface {
+	Get(ctx context.Context, accountName string) (*model.CloudAccount, error)
+	GetAll(ctx context.Context) ([]model.CloudAccount, error)
+}
+
+type profileService interface {
+	Get(ctx context.Context, profileID string) (*profile.Profile, error)
+	GetAll(ctx context.Context) ([]profile.Profile, error)
+}
+
+// Server implements the Control gRPC service.
+type Server struct {
+	kubes    kubeService
+	accounts accountService
+	profiles profileService
+	tasks    storage.Interface
+	tailLog  func(taskID string) (*tail.Tail, error)
+	auth     Authenticator
+	teams    api.TeamLister
+}
+
+func NewServer(kubes kubeService, accounts accountService, profiles profileService,
+	tasks storage.Interface, logDir string, auth Authenticator) *Server {
+	return &Server{
+		kubes:    kubes,
+		accounts: accounts,
+		profiles: profiles,
+		tasks:    tasks,
+		tailLog:  workflows.TailLogFunc(logDir),
+		auth:     auth,
+	}
+}
+
+// SetTeams scopes kubes, accounts and profiles by teams of users.
+func (s *Server) SetTeams(teams api.TeamLister) {
+	s.teams = teams
+}
+
+// GRPCServer returns a gRPC server that serves the API to authenticated users.
+func (s *Server) GRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.UnaryInterceptor(unaryAuth(s.auth, s.teams)),
+		grpc.StreamInterceptor(streamAuth(s.auth, s.teams)))
+	srv := grpc.NewServer(opts...)
+	controlpb.RegisterControlServer(srv, s)
+
+	return srv
+}
+
+func (s *Server) ListKubes(ctx context.Context, req *controlpb.ListKubesRequest) (*controlpb.ListKubesResponse, error) {
+	kubes, err := s.kubes.ListAll(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	scope := api.ScopeFrom(ctx)
+	resp := &controlpb.ListKubesResponse{}
+	for i := range kubes {
+		if !scope.AllowsLabeled(kubes[i].Team, kubes[i].Labels) {
+			continue
+		}
+		resp.Kubes = append(resp.Kubes, toKube(&kubes[i]))
+	}
+
+	return resp, nil
+}
+
+func (s *Server) GetKube(ctx context.Context, req *controlpb.GetKubeRequest) (*controlpb.Kube, error) {
+	k, err := s.kubes.Get(ctx, req.Id)
+	if err == nil && !api.ScopeFrom(ctx).AllowsLabeled(k.Team, k.Labels) {
+		err = sgerrors.ErrNotFound
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toKube(k), nil
+}
+
+func (s *Server) ListAccounts(ctx context.Context, req *controlpb.ListAccountsRequest) (*controlpb.ListAccountsResponse, error) {
+	accounts, err := s.accounts.GetAll(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	scope := api.ScopeFrom(ctx)
+	resp := &controlpb.ListAccountsResponse{}
+	for i := range accounts {
+		if !scope.AllowsLabeled(accounts[i].Team, accounts[i].Labels) {
+			continue
+		}
+		resp.Accounts = append(resp.Accounts, toAccount(&accounts[i]))
+	}
+
+	return resp, nil
+}
+
+func (s *Server) GetAccount(ctx context.Context, req *controlpb.GetAccountRequest) (*controlpb.CloudAccount, error) {
+	acc, err := s.accounts.Get(ctx, req.Name)
+	if err == nil && !api.ScopeFrom(ctx).AllowsLabeled(acc.Team, acc.Labels) {
+		err = sgerrors.ErrNotFound
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toAccount(acc), nil
+}
+
+func (s *Server) ListProfiles(ctx context.Context, req *controlpb.ListProfilesRequest) (*controlpb.ListProfilesResponse, error) {
+	profiles, err := s.profiles.GetAll(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	scope := api.ScopeFrom(ctx)
+	resp := &controlpb.ListProfilesResponse{}
+	for i := range profiles {
+		if !scope.AllowsLabeled(profiles[i].Team, profiles[i].Labels) {
+			continue
+		}
+		resp.Profiles = append(resp.Profiles, toProfile(&profiles[i]))
+	}
+
+	return resp, nil
+}
+
+func (s *Server) GetProfile(ctx context.Context, req *controlpb.GetProfileRequest) (*controlpb.Profile, error) {
+	p, err := s.profiles.Get(ctx, req.Id)
+	if err == nil && !api.ScopeFrom(ctx).AllowsLabeled(p.Team, p.Labels) {
+		err = sgerrors.ErrNotFound
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toProfile(p), nil
+}
+
+func (s *Server) GetTask(ctx context.Context, req *controlpb.GetTaskRequest) (*controlpb.Task, error) {
+	task, err := s.getTask(ctx, req.Id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toTask(task), nil
+}
+
+// getTask returns the stored task, tasks of kubes of other teams are not
+// found.
+func (s *Server) getTask(ctx context.Context, id string) (*workflows.Task, error) {
+	data, err := s.tasks.Get(ctx, workflows.Prefix, id)
+	if err != nil {
+		return nil, err
+	}
+
+	task := &workflows.Task{}
+	if err = json.Unmarshal(data, task); err != nil {
+		return nil, err
+	}
+
+	ok, err := workflows.InScope(ctx, s.kubes, task)
+	if err == nil && !ok {
+		err = sgerrors.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// StreamLogs follows logs of the tasks the client subscribes to until the
+// client closes the stream.
+func (s *Server) StreamLogs(stream controlpb.Control_StreamLogsServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	reqs := make(chan *controlpb.LogRequest)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case reqs <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	lines := make(chan *controlpb.LogLine)
+	tails := make(map[string]*tail.Tail)
+	defer func() {
+		for _, t := range tails {
+			t.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-recvErr:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case req := <-reqs:
+			if t, ok := tails[req.TaskId]; ok {
+				if req.Unsubscribe {
+					t.Stop()
+					delete(tails, req.TaskId)
+				}
+				continue
+			}
+			if req.Unsubscribe {
+				continue
+			}
+
+			if _, err := s.getTask(ctx, req.TaskId); err != nil {
+				return toStatus(err)
+			}
+			t, err := s.tailLog(req.TaskId)
+			if os.IsNotExist(err) {
+				return status.Errorf(codes.NotFound, "no logs of task %s", req.TaskId)
+			}
+			if err != nil {
+				return toStatus(err)
+			}
+			tails[req.TaskId] = t
+			go forwardLines(ctx, req.TaskId, t, lines)
+		case line := <-lines:
+			if err := stream.Send(line); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func forwardLines(ctx context.Context, taskID string, t *tail.Tail, lines chan<- *controlpb.LogLine) {
+	for line := range t.Lines {
+		select {
+		case lines <- &controlpb.LogLine{TaskId: taskID, Text: line.Text}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func toStatus(err error) error {
+	if sgerrors.IsNotFound(err) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+
+	return status.Error(codes.Internal, err.Error())
+}
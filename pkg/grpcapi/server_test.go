@@ -0,0 +1,223 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/grpcapi/controlpb"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage/memory"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/statuses"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type fakeKubes struct {
+	kubes []model.Kube
+}
+
+func (f *fakeKubes) Get(ctx context.Context, kubeID string) (*model.Kube, error) {
+	for i := range f.kubes {
+		if f.kubes[i].ID == kubeID {
+			return &f.kubes[i], nil
+		}
+	}
+	return nil, sgerrors.ErrNotFound
+}
+
+func (f *fakeKubes) ListAll(ctx context.Context) ([]model.Kube, error) {
+	return f.kubes, nil
+}
+
+type fakeAccounts struct{}
+
+func (fakeAccounts) Get(ctx context.Context, accountName string) (*model.CloudAccount, error) {
+	return &model.CloudAccount{
+		Name:        accountName,
+		Provider:    "aws",
+		Credentials: map[string]string{"secret_key": "secret"},
+	}, nil
+}
+
+func (fakeAccounts) GetAll(ctx context.Context) ([]model.CloudAccount, error) {
+	return nil, errors.New("storage is down")
+}
+
+type fakeProfiles struct{}
+
+func (fakeProfiles) Get(ctx context.Context, profileID string) (*profile.Profile, error) {
+	return &profile.Profile{
+		ID:             profileID,
+		MasterProfiles: []profile.NodeProfile{{"size": "m4.large"}},
+	}, nil
+}
+
+func (fakeProfiles) GetAll(ctx context.Context) ([]profile.Profile, error) {
+	return nil, nil
+}
+
+type fakeAuth struct{}
+
+func (fakeAuth) Identify(token string) (api.Identity, error) {
+	if token != "valid" {
+		return api.Identity{}, sgerrors.ErrInvalidCredentials
+	}
+	return api.Identity{Login: "user"}, nil
+}
+
+func setup(t *testing.T, logDir string) (controlpb.ControlClient, func()) {
+	repo := memory.NewInMemoryRepository()
+	task, err := json.Marshal(&workflows.Task{
+		ID:     "task-1",
+		Type:   workflows.ProvisionMaster,
+		Status: statuses.Success,
+		StepStatuses: []workflows.StepStatus{
+			{StepName: "ssh", Status: statuses.Success},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, repo.Put(context.Background(), workflows.Prefix, "task-1", task))
+
+	kubes := &fakeKubes{kubes: []model.Kube{{
+		ID:    "kube-1",
+		Name:  "test",
+		State: model.StateOperational,
+		Masters: map[string]*model.Machine{
+			"m2": {ID: "m2", Name: "master-2"},
+			"m1": {ID: "m1", Name: "master-1"},
+		},
+		Tasks: map[string][]string{"master": {"task-1"}},
+	}}}
+	srv := NewServer(kubes, fakeAccounts{}, fakeProfiles{}, repo, logDir, fakeAuth{}).GRPCServer()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go srv.Serve(l)
+
+	conn, err := grpc.Dial(l.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+
+	return controlpb.NewControlClient(conn), func() {
+		conn.Close()
+		srv.Stop()
+	}
+}
+
+func authorized() context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer valid")
+}
+
+func TestServer(t *testing.T) {
+	client, stop := setup(t, "")
+	defer stop()
+
+	_, err := client.ListKubes(context.Background(), &controlpb.ListKubesRequest{})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	kubes, err := client.ListKubes(authorized(), &controlpb.ListKubesRequest{})
+	require.NoError(t, err)
+	require.Len(t, kubes.Kubes, 1)
+	require.Equal(t, "operational", kubes.Kubes[0].State)
+	require.Equal(t, "master-1", kubes.Kubes[0].Masters[0].Name)
+	require.Equal(t, []string{"task-1"}, kubes.Kubes[0].TaskIds)
+
+	_, err = client.GetKube(authorized(), &controlpb.GetKubeRequest{Id: "unknown"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	acc, err := client.GetAccount(authorized(), &controlpb.GetAccountRequest{Name: "aws"})
+	require.NoError(t, err)
+	require.Equal(t, "aws", acc.Provider)
+
+	_, err = client.ListAccounts(authorized(), &controlpb.ListAccountsRequest{})
+	require.Equal(t, codes.Internal, status.Code(err))
+
+	p, err := client.GetProfile(authorized(), &controlpb.GetProfileRequest{Id: "profile-1"})
+	require.NoError(t, err)
+	require.Equal(t, "m4.large", p.MasterProfiles[0].Settings["size"])
+
+	task, err := client.GetTask(authorized(), &controlpb.GetTaskRequest{Id: "task-1"})
+	require.NoError(t, err)
+	require.Equal(t, "success", task.Status)
+	require.Equal(t, "ssh", task.Steps[0].Name)
+
+	_, err = client.GetTask(authorized(), &controlpb.GetTaskRequest{Id: "task-2"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestStreamLogs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "grpcapi")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, util.MakeFileName("task-1")), []byte("line 1\nline 2\n"), 0644))
+
+	client, stop := setup(t, dir)
+	defer stop()
+
+	stream, err := client.StreamLogs(context.Background())
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	stream, err = client.StreamLogs(authorized())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&controlpb.LogRequest{TaskId: "task-1"}))
+
+	for _, text := range []string{"line 1", "line 2"} {
+		line, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, "task-1", line.TaskId)
+		require.Equal(t, text, line.Text)
+	}
+
+	require.NoError(t, stream.Send(&controlpb.LogRequest{TaskId: "task-2"}))
+	_, err = stream.Recv()
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+type fakeTeams map[string][]string
+
+func (f fakeTeams) TeamsOf(ctx context.Context, login string) ([]string, error) {
+	return f[login], nil
+}
+
+func TestServerTeams(t *testing.T) {
+	kubes := &fakeKubes{kubes: []model.Kube{{ID: "kube-a", Team: "a"}, {ID: "kube-b", Team: "b"}}}
+	repo := memory.NewInMemoryRepository()
+	task, err := json.Marshal(&workflows.Task{ID: "task-b", Config: &steps.Config{Kube: model.Kube{ID: "kube-b"}}})
+	require.NoError(t, err)
+	require.NoError(t, repo.Put(context.Background(), workflows.Prefix, "task-b", task))
+	s := NewServer(kubes, fakeAccounts{}, fakeProfiles{}, repo, "", fakeAuth{})
+
+	md := metadata.Pairs("authorization", "Bearer valid")
+	ctx, err := authenticate(metadata.NewIncomingContext(context.Background(), md), fakeAuth{}, fakeTeams{"user": {"a"}})
+	require.NoError(t, err)
+	require.True(t, api.ScopeFrom(ctx).Admin)
+
+	ctx = api.WithScope(context.Background(), api.Scope{Teams: []string{"a"}})
+	resp, err := s.ListKubes(ctx, &controlpb.ListKubesRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Kubes, 1)
+	require.Equal(t, "kube-a", resp.Kubes[0].Id)
+
+	_, err = s.GetKube(ctx, &controlpb.GetKubeRequest{Id: "kube-b"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = s.GetTask(ctx, &controlpb.GetTaskRequest{Id: "task-b"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
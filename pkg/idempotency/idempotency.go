@@ -0,0 +1,334 @@
+// Package idempotency replays responses of retried requests that provision,
+// scale and delete clusters, clients send the same Idempotency-Key header
+// with retries of a request so it's handled once, e.g. a cluster is
+// provisioned once after a client timeout.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/redact"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
+)
+
+const (
+	// Header is the request header with the idempotency key.
+	Header = "Idempotency-Key"
+	// ReplayedHeader is set on responses that are replayed.
+	ReplayedHeader = "Idempotent-Replayed"
+
+	DefaultStoragePrefix = "/supergiant/idempotency/"
+	DefaultTTL           = time.Hour * 24
+
+	// InProgressTimeout is how long a record of a request that is handled
+	// blocks the key, records of instances that crashed while handling the
+	// request are taken over after it.
+	InProgressTimeout = time.Minute * 10
+
+	maxKeyLength  = 255
+	purgeInterval = time.Hour
+)
+
+// Route is a method and a path template of a route of the router, e.g.
+// POST /v1/api/kubes.
+type Route struct {
+	Method string
+	Path   string
+}
+
+// record is a stored request fingerprint and the response to replay, the
+// response is empty while the request is handled. Bodies with secrets aren't
+// kept, only their status is replayed.
+type record struct {
+	Fingerprint string      `json:"fingerprint"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	Done        bool        `json:"done"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+// Store keeps records of requests with idempotency keys for the TTL.
+type Store struct {
+	repo   storage.Interface
+	prefix string
+	ttl    time.Duration
+	routes map[Route]bool
+
+	// keys of requests that are handled
+	mu       sync.Mutex
+	inflight map[string]bool
+
+	now func() time.Time
+}
+
+// NewStore returns a store of records with the TTL of requests to the routes.
+func NewStore(repo storage.Interface, ttl time.Duration, routes ...Route) *Store {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	s := &Store{
+		repo:     repo,
+		prefix:   DefaultStoragePrefix,
+		ttl:      ttl,
+		routes:   make(map[Route]bool, len(routes)),
+		inflight: make(map[string]bool),
+		now:      time.Now,
+	}
+	for _, route := range routes {
+		s.routes[route] = true
+	}
+
+	return s
+}
+
+// Middleware handles requests to routes of the store with idempotency keys
+// once, retries with the same key get the response of the first request.
+// Keys are scoped by users, a key reused for another request is rejected.
+// It follows route matching, so it's used by routers.
+func (s *Store) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(Header)
+		if key == "" || !s.handles(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxKeyLength {
+			message.SendMessage(w, message.New("Idempotency key is too long", "", sgerrors.ValidationFailed, ""),
+				http.StatusBadRequest)
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			message.SendInvalidJSON(w, err)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		id, _ := api.IdentityFrom(r.Context())
+		name := hash(id.Login, key)
+		fingerprint := hash(r.Method, r.URL.RequestURI(), string(body))
+
+		if !s.lock(name) {
+			sendInProgress(w)
+			return
+		}
+		defer s.unlock(name)
+
+		rec, err := s.get(r.Context(), name)
+		if err != nil {
+			logrus.Errorf("idempotency: get %s: %v", name, err)
+			message.SendUnknownError(w, err)
+			return
+		}
+		if rec != nil {
+			switch {
+			case rec.Fingerprint != fingerprint:
+				message.SendMessage(w, message.New("Idempotency key is used for another request",
+					"", sgerrors.ValidationFailed, ""), http.StatusUnprocessableEntity)
+			case !rec.Done:
+				// another instance handles the request
+				sendInProgress(w)
+			default:
+				replay(w, rec)
+			}
+			return
+		}
+
+		rec = &record{
+			Fingerprint: fingerprint,
+			CreatedAt:   s.now(),
+		}
+		if err = s.put(r.Context(), name, rec); err != nil {
+			logrus.Errorf("idempotency: put %s: %v", name, err)
+			message.SendUnknownError(w, err)
+			return
+		}
+
+		rw := &recorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r)
+
+		// requests that failed on the server side can be retried
+		if rw.status >= http.StatusInternalServerError {
+			if err = s.repo.Delete(context.Background(), s.prefix, name); err != nil {
+				logrus.Errorf("idempotency: delete %s: %v", name, err)
+			}
+			return
+		}
+
+		rec.Done = true
+		rec.Status = rw.status
+		rec.Header = http.Header{"Content-Type": w.Header()["Content-Type"]}
+		if body := rw.body.String(); redact.String(body) == body {
+			rec.Body = rw.body.Bytes()
+		}
+		if err = s.put(context.Background(), name, rec); err != nil {
+			logrus.Errorf("idempotency: put %s: %v", name, err)
+		}
+	})
+}
+
+// Run deletes expired records until the context is done.
+func (s *Store) Run(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.purge(ctx); err != nil {
+				logrus.Errorf("idempotency: purge expired records: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Store) purge(ctx context.Context) error {
+	names := make([]string, 0)
+	lister, ok := s.repo.(storage.Lister)
+	if !ok {
+		// records are deleted when keys are used again
+		return nil
+	}
+
+	err := lister.ListFrom(ctx, s.prefix, "", 0, func(name string, value []byte) {
+		rec := &record{}
+		if err := json.Unmarshal(value, rec); err != nil || s.expired(rec) {
+			names = append(names, name)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if err = s.repo.Delete(ctx, s.prefix, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) get(ctx context.Context, name string) (*record, error) {
+	data, err := s.repo.Get(ctx, s.prefix, name)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	rec := &record{}
+	if err = json.Unmarshal(data, rec); err != nil || s.expired(rec) {
+		return nil, nil
+	}
+
+	return rec, nil
+}
+
+func (s *Store) put(ctx context.Context, name string, rec *record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Put(ctx, s.prefix, name, data)
+}
+
+// expired reports whether the record has outlived the TTL or it's a record
+// of a request that has never finished.
+func (s *Store) expired(rec *record) bool {
+	age := s.now().Sub(rec.CreatedAt)
+	return age > s.ttl || !rec.Done && age > InProgressTimeout
+}
+
+func (s *Store) handles(r *http.Request) bool {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return false
+	}
+	path, err := route.GetPathTemplate()
+	if err != nil {
+		return false
+	}
+
+	return s.routes[Route{Method: r.Method, Path: path}]
+}
+
+func (s *Store) lock(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.inflight[name] {
+		return false
+	}
+	s.inflight[name] = true
+
+	return true
+}
+
+func (s *Store) unlock(name string) {
+	s.mu.Lock()
+	delete(s.inflight, name)
+	s.mu.Unlock()
+}
+
+func replay(w http.ResponseWriter, rec *record) {
+	for k, v := range rec.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(rec.Status)
+	w.Write(rec.Body)
+}
+
+func sendInProgress(w http.ResponseWriter) {
+	message.SendMessage(w, message.New("Request with the idempotency key is in progress",
+		"", sgerrors.EntityAlreadyExists, ""), http.StatusConflict)
+}
+
+func hash(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorder keeps the response written to the client.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
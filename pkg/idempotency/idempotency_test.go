@@ -0,0 +1,210 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/storage/memory"
+)
+
+type counter struct {
+	calls  int
+	status int
+}
+
+func (c *counter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.calls++
+	body, _ := ioutil.ReadAll(r.Body)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(c.status)
+	fmt.Fprintf(w, `{"call":%d,"body":%q}`, c.calls, body)
+}
+
+var testRoutes = []Route{
+	{Method: http.MethodPost, Path: "/kubes"},
+	{Method: http.MethodDelete, Path: "/kubes"},
+}
+
+// handler serves next at /kubes and /kubes/{kubeID}/statuspage, only
+// the first one is a route of the store.
+func handler(s *Store, next http.Handler) http.Handler {
+	router := mux.NewRouter()
+	router.Handle("/kubes", next)
+	router.Handle("/kubes/{kubeID}/statuspage", next)
+	router.Use(s.Middleware)
+	return router
+}
+
+func do(h http.Handler, login, method, key, body string) *httptest.ResponseRecorder {
+	return doURL(h, login, method, "/kubes", key, body)
+}
+
+func doURL(h http.Handler, login, method, url, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(Header, key)
+	}
+	req = req.WithContext(api.WithIdentity(req.Context(), api.Identity{Login: login}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestMiddleware(t *testing.T) {
+	now := time.Now()
+	s := NewStore(memory.NewInMemoryRepository(), time.Hour, testRoutes...)
+	s.now = func() time.Time { return now }
+
+	next := &counter{status: http.StatusAccepted}
+	h := handler(s, next)
+
+	first := do(h, "user", http.MethodPost, "key", "kube")
+	require.Equal(t, http.StatusAccepted, first.Code)
+	require.Empty(t, first.Header().Get(ReplayedHeader))
+
+	retry := do(h, "user", http.MethodPost, "key", "kube")
+	require.Equal(t, http.StatusAccepted, retry.Code)
+	require.Equal(t, "true", retry.Header().Get(ReplayedHeader))
+	require.Equal(t, "application/json", retry.Header().Get("Content-Type"))
+	require.Equal(t, first.Body.String(), retry.Body.String())
+	require.Equal(t, 1, next.calls)
+
+	// another request with the key
+	require.Equal(t, http.StatusUnprocessableEntity, do(h, "user", http.MethodPost, "key", "other").Code)
+	require.Equal(t, http.StatusUnprocessableEntity, do(h, "user", http.MethodDelete, "key", "kube").Code)
+
+	// keys of other users and requests without keys
+	require.Equal(t, http.StatusAccepted, do(h, "admin", http.MethodPost, "key", "kube").Code)
+	require.Equal(t, http.StatusAccepted, do(h, "user", http.MethodPost, "", "kube").Code)
+	require.Equal(t, http.StatusAccepted, do(h, "user", http.MethodGet, "key", "").Code)
+	require.Equal(t, 4, next.calls)
+
+	// expired records
+	now = now.Add(time.Hour * 2)
+	require.Empty(t, do(h, "user", http.MethodPost, "key", "kube").Header().Get(ReplayedHeader))
+	require.Equal(t, 5, next.calls)
+
+	require.Equal(t, http.StatusBadRequest, do(h, "user", http.MethodPost, strings.Repeat("k", 256), "kube").Code)
+}
+
+func TestMiddlewareServerError(t *testing.T) {
+	s := NewStore(memory.NewInMemoryRepository(), 0, testRoutes...)
+	next := &counter{status: http.StatusInternalServerError}
+	h := handler(s, next)
+
+	require.Equal(t, http.StatusInternalServerError, do(h, "user", http.MethodPost, "key", "kube").Code)
+
+	// failed requests are retried
+	next.status = http.StatusOK
+	rec := do(h, "user", http.MethodPost, "key", "kube")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Empty(t, rec.Header().Get(ReplayedHeader))
+	require.Equal(t, 2, next.calls)
+}
+
+func TestMiddlewareInProgress(t *testing.T) {
+	s := NewStore(memory.NewInMemoryRepository(), 0, testRoutes...)
+	started, release := make(chan struct{}), make(chan struct{})
+	h := handler(s, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		do(h, "user", http.MethodPost, "key", "kube")
+		close(done)
+	}()
+
+	<-started
+	require.Equal(t, http.StatusConflict, do(h, "user", http.MethodPost, "key", "kube").Code)
+	close(release)
+	<-done
+}
+
+func TestPurge(t *testing.T) {
+	now := time.Now()
+	repo := memory.NewInMemoryRepository()
+	s := NewStore(repo, time.Hour, testRoutes...)
+	s.now = func() time.Time { return now }
+	h := handler(s, &counter{status: http.StatusOK})
+
+	do(h, "user", http.MethodPost, "old", "kube")
+	now = now.Add(time.Minute * 90)
+	do(h, "user", http.MethodPost, "new", "kube")
+
+	require.NoError(t, s.purge(context.Background()))
+	values, err := repo.GetAll(context.Background(), DefaultStoragePrefix)
+	require.NoError(t, err)
+
+	records := 0
+	for _, v := range values {
+		if len(v) > 0 {
+			records++
+		}
+	}
+	require.Equal(t, 1, records)
+}
+
+func TestMiddlewareRoutes(t *testing.T) {
+	s := NewStore(memory.NewInMemoryRepository(), 0, testRoutes...)
+	next := &counter{status: http.StatusOK}
+	h := handler(s, next)
+
+	// other routes are handled on every request
+	for i := 0; i < 2; i++ {
+		rec := doURL(h, "user", http.MethodPost, "/kubes/kube-1/statuspage", "key", "")
+		require.Empty(t, rec.Header().Get(ReplayedHeader))
+	}
+	require.Equal(t, 2, next.calls)
+}
+
+func TestMiddlewareSecrets(t *testing.T) {
+	s := NewStore(memory.NewInMemoryRepository(), 0, testRoutes...)
+	calls := 0
+	h := handler(s, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"token":"s3cr3t"}`)
+	}))
+
+	require.Equal(t, `{"token":"s3cr3t"}`, do(h, "user", http.MethodPost, "key", "kube").Body.String())
+
+	// the status is replayed without the body
+	retry := do(h, "user", http.MethodPost, "key", "kube")
+	require.Equal(t, http.StatusCreated, retry.Code)
+	require.Equal(t, "true", retry.Header().Get(ReplayedHeader))
+	require.Empty(t, retry.Body.String())
+	require.Equal(t, 1, calls)
+}
+
+func TestMiddlewareStaleInProgress(t *testing.T) {
+	now := time.Now()
+	s := NewStore(memory.NewInMemoryRepository(), 0, testRoutes...)
+	s.now = func() time.Time { return now }
+	next := &counter{status: http.StatusOK}
+	h := handler(s, next)
+
+	// a record of an instance that crashed while handling the request
+	id := hash("user", "key")
+	fingerprint := hash(http.MethodPost, "/kubes", "kube")
+	require.NoError(t, s.put(context.Background(), id, &record{Fingerprint: fingerprint, CreatedAt: now}))
+	require.Equal(t, http.StatusConflict, do(h, "user", http.MethodPost, "key", "kube").Code)
+
+	now = now.Add(InProgressTimeout + time.Minute)
+	require.Equal(t, http.StatusOK, do(h, "user", http.MethodPost, "key", "kube").Code)
+	require.Equal(t, 1, next.calls)
+}
@@ -0,0 +1,93 @@
+package images
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// Handler exposes the catalog of baked images, images are baked by
+// clusters.
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+func (h *Handler) Register(r *mux.Router) {
+	r.HandleFunc("/images", h.listImages).Methods(http.MethodGet)
+	r.HandleFunc("/images/{id}", h.getImage).Methods(http.MethodGet)
+	r.HandleFunc("/images/{id}", api.AdminOnly(h.deleteImage)).Methods(http.MethodDelete)
+}
+
+// listImages returns images of the catalog, the provider and region query
+// parameters select images that can be used by a cluster.
+func (h *Handler) listImages(w http.ResponseWriter, r *http.Request) {
+	images, err := h.service.ListAll(r.Context())
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	provider := clouds.Name(r.URL.Query().Get("provider"))
+	region := r.URL.Query().Get("region")
+	res := make([]Image, 0, len(images))
+	for _, img := range images {
+		if (provider == "" || img.Provider == provider) && (region == "" || img.Region == region) {
+			res = append(res, img)
+		}
+	}
+
+	if err = json.NewEncoder(w).Encode(res); err != nil {
+		logrus.Errorf("images: list images: write response: %s", err)
+	}
+}
+
+func (h *Handler) getImage(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	img, err := h.service.Get(r.Context(), id)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, id, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(img); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) deleteImage(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	if _, err := h.service.Get(r.Context(), id); err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, id, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,109 @@
+// Package images keeps the catalog of machine images baked by control, the
+// images have the software of nodes installed and can be pinned by node
+// profiles.
+package images
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/storage"
+)
+
+const DefaultStoragePrefix = "/supergiant/images/"
+
+type State string
+
+const (
+	StateBaking    State = "baking"
+	StateAvailable State = "available"
+	StateFailed    State = "failed"
+)
+
+// Image is an entry of the catalog.
+type Image struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Provider    clouds.Name `json:"provider"`
+	Region      string      `json:"region"`
+	AccountName string      `json:"accountName"`
+	// ImageID of the provider, e.g. an AMI ID, it's set when the image is
+	// available and is the image of node profiles that use the image.
+	ImageID       string `json:"imageId,omitempty"`
+	K8SVersion    string `json:"K8SVersion"`
+	DockerVersion string `json:"dockerVersion"`
+	State         State  `json:"state"`
+	// KubeID is the cluster the image was baked in, TaskID is the bake task.
+	KubeID    string    `json:"kubeId"`
+	TaskID    string    `json:"taskId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Service keeps images of the catalog.
+type Service struct {
+	prefix     string
+	repository storage.Interface
+}
+
+func NewService(prefix string, repository storage.Interface) *Service {
+	return &Service{
+		prefix:     prefix,
+		repository: repository,
+	}
+}
+
+// Save creates or updates the image.
+func (s *Service) Save(ctx context.Context, img *Image) error {
+	data, err := json.Marshal(img)
+	if err != nil {
+		return errors.Wrap(err, "marshal")
+	}
+	return errors.Wrap(s.repository.Put(ctx, s.prefix, img.ID, data), "save image")
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*Image, error) {
+	data, err := s.repository.Get(ctx, s.prefix, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get image %s", id)
+	}
+	img := &Image{}
+	return img, errors.Wrap(json.Unmarshal(data, img), "unmarshal")
+}
+
+// ListAll returns images of the catalog, the newest ones first.
+func (s *Service) ListAll(ctx context.Context) ([]Image, error) {
+	rawImages, err := s.repository.GetAll(ctx, s.prefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "get images")
+	}
+
+	images := make([]Image, 0, len(rawImages))
+	for _, data := range rawImages {
+		if len(data) == 0 {
+			continue
+		}
+		img := Image{}
+		if err = json.Unmarshal(data, &img); err != nil {
+			logrus.Warnf("images: decode image: %v", err)
+			continue
+		}
+		images = append(images, img)
+	}
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].CreatedAt.After(images[j].CreatedAt)
+	})
+
+	return images, nil
+}
+
+// Delete removes the image from the catalog, the image of the provider
+// isn't deregistered.
+func (s *Service) Delete(ctx context.Context, id string) error {
+	return errors.Wrap(s.repository.Delete(ctx, s.prefix, id), "delete image")
+}
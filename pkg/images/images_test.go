@@ -0,0 +1,86 @@
+package images
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage/memory"
+	"github.com/supergiant/control/pkg/user"
+)
+
+func TestService(t *testing.T) {
+	svc := NewService(DefaultStoragePrefix, memory.NewInMemoryRepository())
+	ctx := context.Background()
+
+	now := time.Now()
+	require.NoError(t, svc.Save(ctx, &Image{ID: "old", Provider: clouds.AWS, CreatedAt: now.Add(-time.Hour)}))
+	require.NoError(t, svc.Save(ctx, &Image{ID: "new", Provider: clouds.AWS, CreatedAt: now}))
+
+	images, err := svc.ListAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+	require.Equal(t, "new", images[0].ID)
+
+	img, err := svc.Get(ctx, "old")
+	require.NoError(t, err)
+	img.State = StateAvailable
+	require.NoError(t, svc.Save(ctx, img))
+
+	img, err = svc.Get(ctx, "old")
+	require.NoError(t, err)
+	require.Equal(t, StateAvailable, img.State)
+
+	require.NoError(t, svc.Delete(ctx, "old"))
+	_, err = svc.Get(ctx, "old")
+	require.True(t, sgerrors.IsNotFound(err))
+}
+
+func TestHandler(t *testing.T) {
+	svc := NewService(DefaultStoragePrefix, memory.NewInMemoryRepository())
+	ctx := context.Background()
+	require.NoError(t, svc.Save(ctx, &Image{ID: "aws", Provider: clouds.AWS, Region: "us-east-1"}))
+	require.NoError(t, svc.Save(ctx, &Image{ID: "gce", Provider: clouds.GCE, Region: "us-east1"}))
+
+	router := mux.NewRouter()
+	NewHandler(svc).Register(router)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/images?provider=aws", nil)
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	images := []Image{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&images))
+	require.Len(t, images, 1)
+	require.Equal(t, "aws", images[0].ID)
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/images/unknown", nil)
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	// only admins remove images
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodDelete, "/images/gce", nil)
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodDelete, "/images/gce", nil)
+	req = req.WithContext(api.WithIdentity(req.Context(), api.Identity{Login: "root", Role: user.RoleAdmin}))
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	_, err := svc.Get(ctx, "gce")
+	require.True(t, sgerrors.IsNotFound(err))
+}
@@ -22,14 +22,25 @@ func NewTokenService(tokenTTL int64, secret []byte) *TokenService {
 	}
 }
 
-func (ts TokenService) Issue(userId string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS512, jwt.MapClaims{
+func (ts TokenService) Issue(userId, role string) (string, error) {
+	return ts.IssueSession(userId, role, "")
+}
+
+// IssueSession returns a token with the session_id claim, the session is
+// checked when tokens are validated.
+func (ts TokenService) IssueSession(userId, role, sessionID string) (string, error) {
+	claims := jwt.MapClaims{
 		// TODO(stgleb): Pass list of access here
 		"accesses":   []string{"edit", "view"},
 		"user_id":    userId,
+		"role":       role,
 		"issued_at":  time.Now().Unix(),
 		"expires_at": time.Now().Unix() + ts.tokenTTL,
-	})
+	}
+	if sessionID != "" {
+		claims["session_id"] = sessionID
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS512, claims)
 
 	tokenString, err := token.SignedString(ts.secretKey)
 
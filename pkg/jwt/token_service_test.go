@@ -33,7 +33,7 @@ func TestTokenService(t *testing.T) {
 
 	userId := "user_id"
 
-	tokenString, err := ts.Issue(userId)
+	tokenString, err := ts.Issue(userId, "view")
 
 	if err != nil {
 		t.Error(err)
@@ -51,6 +51,10 @@ func TestTokenService(t *testing.T) {
 		t.Errorf("user_id not found in token claims")
 		return
 	}
+
+	if role := claims["role"]; role != "view" {
+		t.Errorf("wrong role in token claims %v", role)
+	}
 }
 
 func TestTokenService_ValidateErrExpiredNotFound(t *testing.T) {
@@ -86,7 +90,7 @@ func TestTokenService_ValidateExpired(t *testing.T) {
 		secret,
 	}
 
-	token, _ := ts.Issue(userId)
+	token, _ := ts.Issue(userId, "view")
 
 	claims, err := ts.Validate(token)
 
@@ -0,0 +1,118 @@
+package kube
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/kubeconfig"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/proxy"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+const proxyGroupPrefix = "supergiant:proxy:"
+
+func newAPIProxy(cfg *rest.Config) (http.Handler, error) {
+	return proxy.NewAPIProxy(cfg, logrus.WithField("component", "api-proxy"))
+}
+
+// proxyAPI forwards requests to the cluster API server, so kubectl can use
+// <control>/v1/api/kubes/{kubeID}/proxy as the server address with a control
+// token. Requests are made on behalf of the control user with permissions
+// of the user role.
+func (h *Handler) proxyAPI(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	id, ok := api.IdentityFrom(r.Context())
+	if !ok {
+		http.Error(w, "unknown user", http.StatusForbidden)
+		return
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	cfg, err := h.impersonatedConfig(k, id)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	p, err := h.newAPIProxy(cfg)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	prefix := "/kubes/" + kubeID + "/proxy"
+	r.URL.Path = r.URL.Path[strings.Index(r.URL.Path, prefix)+len(prefix):]
+	p.ServeHTTP(w, r)
+}
+
+// impersonatedConfig returns a rest config that makes requests on behalf of the user.
+func (h *Handler) impersonatedConfig(k *model.Kube, id api.Identity) (*rest.Config, error) {
+	role := identityRole(id)
+	if err := h.ensureProxyBinding(k, role); err != nil {
+		return nil, err
+	}
+
+	cfg, err := kubeconfig.NewConfigFor(k)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Impersonate = rest.ImpersonationConfig{
+		UserName: id.Login,
+		Groups:   []string{proxyGroupPrefix + role},
+	}
+
+	return cfg, nil
+}
+
+// ensureProxyBinding binds the proxy group of the role to its cluster role once per cluster.
+func (h *Handler) ensureProxyBinding(k *model.Kube, role string) error {
+	key := k.ID + "/" + role
+	if _, ok := h.proxyBindings.Load(key); ok {
+		return nil
+	}
+
+	group := proxyGroupPrefix + role
+	err := h.bindClusterRole(k, &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: group,
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     clusterRoles[role],
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				APIGroup: rbacv1.GroupName,
+				Kind:     rbacv1.GroupKind,
+				Name:     group,
+			},
+		},
+	})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	h.proxyBindings.Store(key, struct{}{})
+	return nil
+}
@@ -0,0 +1,91 @@
+package kube
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/pki"
+	"github.com/supergiant/control/pkg/user"
+)
+
+func TestHandler_proxyAPI(t *testing.T) {
+	ca, err := pki.NewCAPair(nil)
+	require.NoError(t, err)
+
+	svc := new(kubeServiceMock)
+	svc.On(serviceGet, mock.Anything, "kube").Return(&model.Kube{
+		ID:              "kube",
+		Name:            "kube",
+		ExternalDNSName: "kube.example.com",
+		APIServerPort:   443,
+		Auth: model.Auth{
+			CACert: string(ca.Cert),
+		},
+	}, nil)
+
+	h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+	var bindings []*rbacv1.ClusterRoleBinding
+	h.bindClusterRole = func(_ *model.Kube, b *rbacv1.ClusterRoleBinding) error {
+		bindings = append(bindings, b)
+		return apierrors.NewAlreadyExists(schema.GroupResource{}, b.Name)
+	}
+
+	var cfg *rest.Config
+	var paths []string
+	h.newAPIProxy = func(c *rest.Config) (http.Handler, error) {
+		cfg = c
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			paths = append(paths, r.URL.Path)
+		}), nil
+	}
+
+	router := mux.NewRouter()
+	h.Register(router)
+
+	for _, path := range []string{
+		"/kubes/kube/proxy/api/v1/namespaces/default/pods/app/exec",
+		"/kubes/kube/proxy/apis/apps/v1/deployments",
+	} {
+		req, err := http.NewRequest(http.MethodPost, path, nil)
+		require.NoError(t, err)
+		req = req.WithContext(api.WithIdentity(req.Context(), api.Identity{
+			Login: "alice",
+			Role:  user.RoleView,
+		}))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+	}
+
+	require.Equal(t, []string{
+		"/api/v1/namespaces/default/pods/app/exec",
+		"/apis/apps/v1/deployments",
+	}, paths)
+	require.Equal(t, "https://kube.example.com:443", cfg.Host)
+	require.Equal(t, "alice", cfg.Impersonate.UserName)
+	require.Equal(t, []string{"supergiant:proxy:view"}, cfg.Impersonate.Groups)
+
+	require.Len(t, bindings, 1, "binding must be created once")
+	require.Equal(t, "view", bindings[0].RoleRef.Name)
+	require.Equal(t, "supergiant:proxy:view", bindings[0].Subjects[0].Name)
+
+	// requests without a control user are rejected
+	req, err := http.NewRequest(http.MethodGet, "/kubes/kube/proxy/api", nil)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusForbidden, rr.Code)
+}
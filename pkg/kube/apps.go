@@ -0,0 +1,71 @@
+package kube
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// InstallApp starts the workflow that installs the chart on the master of
+// the cluster and returns the ID of its task.
+func (h *Handler) InstallApp(ctx context.Context, k *model.Kube, app steps.InstallAppConfig, workflow string) (string, error) {
+	if k.State != model.StateOperational {
+		return "", ErrNotOperational
+	}
+
+	config, err := h.newKubeConfig(ctx, k)
+	if err != nil {
+		return "", err
+	}
+
+	if app.ChartRef, err = h.chartGetter.GetChartRef(ctx, app.RepoName, app.ChartName, app.ChartVersion); err != nil {
+		return "", errors.Wrapf(err, "get chart %s/%s", app.RepoName, app.ChartName)
+	}
+	config.InstallAppConfig = app
+
+	return h.runOnMaster(ctx, k, config, workflow)
+}
+
+// runOnMaster runs the workflow on a master of the cluster in background,
+// the task is saved to tasks of the cluster.
+func (h *Handler) runOnMaster(ctx context.Context, k *model.Kube, config *steps.Config, workflow string) (string, error) {
+	master := config.GetMaster()
+	if master == nil {
+		return "", errors.Wrapf(sgerrors.ErrNotFound, "master of kube %s", k.ID)
+	}
+	config.Node = *master
+
+	task, err := workflows.NewTask(config, workflow, h.repo)
+	if err != nil {
+		return "", errors.Wrap(err, "new task")
+	}
+
+	if k.Tasks == nil {
+		k.Tasks = make(map[string][]string)
+	}
+	k.Tasks[workflow] = append(k.Tasks[workflow], task.ID)
+	if err = h.svc.Create(ctx, k); err != nil {
+		return "", errors.Wrapf(err, "update kube %s", k.ID)
+	}
+
+	go func() {
+		writer, err := h.getWriter(util.MakeFileName(task.ID))
+		if err != nil {
+			logrus.Errorf("%s on kube %s: get writer: %v", workflow, k.ID, err)
+			return
+		}
+
+		if err = <-task.Run(context.Background(), *task.Config, writer); err != nil {
+			logrus.Errorf("%s on kube %s: task %s: %v", workflow, k.ID, task.ID, err)
+		}
+	}()
+
+	return task.ID, nil
+}
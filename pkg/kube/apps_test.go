@@ -0,0 +1,21 @@
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestHandler_InstallAppNotOperational(t *testing.T) {
+	h := &Handler{}
+	k := &model.Kube{ID: "kube", State: model.StateProvisioning}
+
+	_, err := h.InstallApp(context.Background(), k, steps.InstallAppConfig{}, workflows.InstallCatalogApp)
+	require.Equal(t, ErrNotOperational, errors.Cause(err))
+}
@@ -0,0 +1,646 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/cron"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/velero"
+)
+
+const (
+	veleroAPIVersion = "velero.io/v1"
+
+	veleroBackups   = "backups"
+	veleroSchedules = "schedules"
+	veleroRestores  = "restores"
+	veleroLocations = "backupstoragelocations"
+
+	// storageLocationLabel marks backups of the storage location.
+	storageLocationLabel = "velero.io/storage-location"
+)
+
+var ErrBackupsDisabled = errors.New("velero isn't installed to the cluster")
+
+// BackupSpec selects resources of a backup, it's the spec of Velero backups.
+type BackupSpec struct {
+	IncludedNamespaces []string `json:"includedNamespaces,omitempty"`
+	ExcludedNamespaces []string `json:"excludedNamespaces,omitempty"`
+	// TTL is a duration the backup is kept for, e.g. 720h.
+	TTL             string `json:"ttl,omitempty"`
+	StorageLocation string `json:"storageLocation,omitempty"`
+}
+
+// BackupInput is a backup that is taken right away.
+type BackupInput struct {
+	Name string `json:"name"`
+	BackupSpec
+}
+
+// ScheduleInput is a backup that is taken on the cron schedule.
+type ScheduleInput struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+	BackupSpec
+}
+
+// RestoreInput restores the backup, backups of other clusters are restored
+// when the source kube is set.
+type RestoreInput struct {
+	Name               string            `json:"name"`
+	BackupName         string            `json:"backupName"`
+	SourceKubeID       string            `json:"sourceKubeId,omitempty"`
+	IncludedNamespaces []string          `json:"includedNamespaces,omitempty"`
+	ExcludedNamespaces []string          `json:"excludedNamespaces,omitempty"`
+	NamespaceMapping   map[string]string `json:"namespaceMapping,omitempty"`
+}
+
+type BackupInfo struct {
+	Name string `json:"name"`
+	BackupSpec
+	Phase      string       `json:"phase"`
+	Started    *metav1.Time `json:"started,omitempty"`
+	Completed  *metav1.Time `json:"completed,omitempty"`
+	Expiration *metav1.Time `json:"expiration,omitempty"`
+	Errors     int          `json:"errors"`
+	Warnings   int          `json:"warnings"`
+}
+
+type ScheduleInfo struct {
+	Name       string `json:"name"`
+	Schedule   string `json:"schedule"`
+	BackupSpec `json:"template"`
+	Phase      string       `json:"phase"`
+	LastBackup *metav1.Time `json:"lastBackup,omitempty"`
+}
+
+type RestoreInfo struct {
+	Name               string            `json:"name"`
+	BackupName         string            `json:"backupName"`
+	IncludedNamespaces []string          `json:"includedNamespaces,omitempty"`
+	ExcludedNamespaces []string          `json:"excludedNamespaces,omitempty"`
+	NamespaceMapping   map[string]string `json:"namespaceMapping,omitempty"`
+	Phase              string            `json:"phase"`
+	Errors             int               `json:"errors"`
+	Warnings           int               `json:"warnings"`
+}
+
+// veleroObject is a custom resource of Velero.
+type veleroObject struct {
+	metav1.TypeMeta `json:",inline"`
+	Metadata        metav1.ObjectMeta `json:"metadata"`
+	Spec            interface{}       `json:"spec"`
+}
+
+type backupObject struct {
+	Metadata metav1.ObjectMeta `json:"metadata"`
+	Spec     BackupSpec        `json:"spec"`
+	Status   struct {
+		Phase               string       `json:"phase"`
+		StartTimestamp      *metav1.Time `json:"startTimestamp"`
+		CompletionTimestamp *metav1.Time `json:"completionTimestamp"`
+		Expiration          *metav1.Time `json:"expiration"`
+		Errors              int          `json:"errors"`
+		Warnings            int          `json:"warnings"`
+	} `json:"status"`
+}
+
+type scheduleSpec struct {
+	Schedule string     `json:"schedule"`
+	Template BackupSpec `json:"template"`
+}
+
+type scheduleObject struct {
+	Metadata metav1.ObjectMeta `json:"metadata"`
+	Spec     scheduleSpec      `json:"spec"`
+	Status   struct {
+		Phase      string       `json:"phase"`
+		LastBackup *metav1.Time `json:"lastBackup"`
+	} `json:"status"`
+}
+
+type restoreSpec struct {
+	BackupName         string            `json:"backupName"`
+	IncludedNamespaces []string          `json:"includedNamespaces,omitempty"`
+	ExcludedNamespaces []string          `json:"excludedNamespaces,omitempty"`
+	NamespaceMapping   map[string]string `json:"namespaceMapping,omitempty"`
+}
+
+type restoreObject struct {
+	Metadata metav1.ObjectMeta `json:"metadata"`
+	Spec     restoreSpec       `json:"spec"`
+	Status   struct {
+		Phase    string `json:"phase"`
+		Errors   int    `json:"errors"`
+		Warnings int    `json:"warnings"`
+	} `json:"status"`
+}
+
+// StartVeleroInstall installs Velero that backs up the cluster to the bucket,
+// the backup settings are saved to the kube.
+func (h *Handler) StartVeleroInstall(ctx context.Context, k *model.Kube, cfg steps.VeleroConfig) (string, error) {
+	if k.State != model.StateOperational {
+		return "", ErrNotOperational
+	}
+
+	if cfg.Prefix == "" {
+		cfg.Prefix = k.ID
+	}
+	if cfg.Version == "" {
+		cfg.Version = velero.DefaultVersion
+	}
+	if _, err := velero.LocationOf(k.Provider, cfg.Backup); err != nil {
+		return "", err
+	}
+	// spaces keys aren't a part of the cloud account
+	if k.Provider == clouds.DigitalOcean && (cfg.AccessKey == "" || cfg.SecretKey == "") {
+		return "", errors.Wrap(sgerrors.ErrInvalidJson, "access and secret keys of spaces are required")
+	}
+
+	config, err := h.newKubeConfig(ctx, k)
+	if err != nil {
+		return "", err
+	}
+
+	acc, err := h.accountService.Get(ctx, k.AccountName)
+	if err != nil {
+		return "", errors.Wrapf(err, "get cloud account %s", k.AccountName)
+	}
+	if err = util.FillCloudAccountCredentials(acc, config); err != nil {
+		return "", errors.Wrap(err, "fill cloud account credentials")
+	}
+	config.VeleroConfig = cfg
+
+	k.Backup = cfg.Backup
+	return h.runOnMaster(ctx, k, config, workflows.InstallVelero)
+}
+
+func (h *Handler) installVelero(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	cfg := steps.VeleroConfig{}
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	taskID, err := h.StartVeleroInstall(r.Context(), k, cfg)
+	if err != nil {
+		switch cause := errors.Cause(err); {
+		case cause == ErrNotOperational, cause == sgerrors.ErrInvalidJson, cause == sgerrors.ErrUnsupportedProvider:
+			message.SendValidationFailed(w, err)
+		case sgerrors.IsNotFound(err):
+			message.SendNotFound(w, k.AccountName, err)
+		default:
+			logrus.Errorf("install velero to kube %s: %v", kubeID, err)
+			message.SendUnknownError(w, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	if err = json.NewEncoder(w).Encode(map[string]string{"taskId": taskID}); err != nil {
+		logrus.Errorf("install velero to kube %s: write response: %v", kubeID, err)
+	}
+}
+
+func (h *Handler) getBackupConfig(w http.ResponseWriter, r *http.Request) {
+	k, ok := h.getKubeOrSend(w, r)
+	if !ok {
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(k.Backup); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) listBackups(w http.ResponseWriter, r *http.Request) {
+	k, ok := h.getBackupKube(w, r)
+	if !ok {
+		return
+	}
+
+	list := struct {
+		Items []backupObject `json:"items"`
+	}{}
+	if err := h.listVelero(r.Context(), k.ID, veleroBackups, &list); err != nil {
+		sendResourceError(w, veleroBackups, err)
+		return
+	}
+
+	backups := make([]BackupInfo, 0, len(list.Items))
+	for _, b := range list.Items {
+		backups = append(backups, b.info())
+	}
+	if err := json.NewEncoder(w).Encode(backups); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) createBackup(w http.ResponseWriter, r *http.Request) {
+	inp := &BackupInput{}
+	if err := json.NewDecoder(r.Body).Decode(inp); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+	if inp.Name == "" {
+		inp.Name = timestampedName("backup")
+	}
+	if err := validateBackup(inp.Name, inp.BackupSpec); err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	k, ok := h.getBackupKube(w, r)
+	if !ok {
+		return
+	}
+
+	created := backupObject{}
+	if err := h.createVelero(r.Context(), k.ID, veleroBackups, "Backup", inp.Name, inp.BackupSpec, &created); err != nil {
+		sendResourceError(w, inp.Name, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(created.info()); err != nil {
+		logrus.Errorf("create backup %s of kube %s: write response: %v", inp.Name, k.ID, err)
+	}
+}
+
+func (h *Handler) listSchedules(w http.ResponseWriter, r *http.Request) {
+	k, ok := h.getBackupKube(w, r)
+	if !ok {
+		return
+	}
+
+	list := struct {
+		Items []scheduleObject `json:"items"`
+	}{}
+	if err := h.listVelero(r.Context(), k.ID, veleroSchedules, &list); err != nil {
+		sendResourceError(w, veleroSchedules, err)
+		return
+	}
+
+	schedules := make([]ScheduleInfo, 0, len(list.Items))
+	for _, s := range list.Items {
+		schedules = append(schedules, s.info())
+	}
+	if err := json.NewEncoder(w).Encode(schedules); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) createSchedule(w http.ResponseWriter, r *http.Request) {
+	inp := &ScheduleInput{}
+	if err := json.NewDecoder(r.Body).Decode(inp); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+	if err := validateBackup(inp.Name, inp.BackupSpec); err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+	if _, err := cron.Parse(inp.Schedule); err != nil {
+		message.SendValidationFailed(w, errors.Wrapf(err, "schedule %q", inp.Schedule))
+		return
+	}
+
+	k, ok := h.getBackupKube(w, r)
+	if !ok {
+		return
+	}
+
+	created := scheduleObject{}
+	spec := scheduleSpec{Schedule: inp.Schedule, Template: inp.BackupSpec}
+	if err := h.createVelero(r.Context(), k.ID, veleroSchedules, "Schedule", inp.Name, spec, &created); err != nil {
+		sendResourceError(w, inp.Name, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(created.info()); err != nil {
+		logrus.Errorf("create backup schedule %s of kube %s: write response: %v", inp.Name, k.ID, err)
+	}
+}
+
+func (h *Handler) deleteSchedule(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	k, ok := h.getBackupKube(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.svc.DeleteResource(r.Context(), k.ID, veleroSchedules, velero.Namespace, name, false); err != nil {
+		sendResourceError(w, name, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) listRestores(w http.ResponseWriter, r *http.Request) {
+	k, ok := h.getBackupKube(w, r)
+	if !ok {
+		return
+	}
+
+	list := struct {
+		Items []restoreObject `json:"items"`
+	}{}
+	if err := h.listVelero(r.Context(), k.ID, veleroRestores, &list); err != nil {
+		sendResourceError(w, veleroRestores, err)
+		return
+	}
+
+	restores := make([]RestoreInfo, 0, len(list.Items))
+	for _, rs := range list.Items {
+		restores = append(restores, rs.info())
+	}
+	if err := json.NewEncoder(w).Encode(restores); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// createRestore restores the backup to the cluster. Backups of another
+// cluster are read from its bucket, the target cluster gets a read-only
+// storage location of the bucket and a copy of the backup.
+func (h *Handler) createRestore(w http.ResponseWriter, r *http.Request) {
+	inp := &RestoreInput{}
+	if err := json.NewDecoder(r.Body).Decode(inp); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+	if inp.Name == "" {
+		inp.Name = timestampedName("restore")
+	}
+	if err := validateNames(inp.Name, inp.BackupName); err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	k, ok := h.getBackupKube(w, r)
+	if !ok {
+		return
+	}
+
+	if inp.SourceKubeID != "" && inp.SourceKubeID != k.ID {
+		source, err := h.svc.Get(r.Context(), inp.SourceKubeID)
+		if err != nil {
+			if sgerrors.IsNotFound(err) {
+				message.SendNotFound(w, inp.SourceKubeID, err)
+				return
+			}
+			message.SendUnknownError(w, err)
+			return
+		}
+
+		if err = h.importBackup(r.Context(), k, source, inp.BackupName); err != nil {
+			if cause := errors.Cause(err); cause == ErrBackupsDisabled || cause == sgerrors.ErrUnsupportedProvider {
+				message.SendValidationFailed(w, err)
+				return
+			}
+			sendResourceError(w, inp.BackupName, err)
+			return
+		}
+	}
+
+	created := restoreObject{}
+	spec := restoreSpec{
+		BackupName:         inp.BackupName,
+		IncludedNamespaces: inp.IncludedNamespaces,
+		ExcludedNamespaces: inp.ExcludedNamespaces,
+		NamespaceMapping:   inp.NamespaceMapping,
+	}
+	if err := h.createVelero(r.Context(), k.ID, veleroRestores, "Restore", inp.Name, spec, &created); err != nil {
+		sendResourceError(w, inp.Name, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(created.info()); err != nil {
+		logrus.Errorf("create restore %s of kube %s: write response: %v", inp.Name, k.ID, err)
+	}
+}
+
+// importBackup makes the backup of the source cluster available to the
+// target cluster. Velero of the target cluster reads the bucket of the
+// source with its own credentials so both clusters run on the same provider.
+func (h *Handler) importBackup(ctx context.Context, target, source *model.Kube, backupName string) error {
+	if source.Backup.Bucket == "" {
+		return errors.Wrapf(ErrBackupsDisabled, "source kube %s", source.ID)
+	}
+	if source.Provider != target.Provider {
+		return errors.Wrapf(sgerrors.ErrUnsupportedProvider, "restore of %s backups to %s", source.Provider, target.Provider)
+	}
+
+	loc, err := velero.LocationOf(source.Provider, source.Backup)
+	if err != nil {
+		return err
+	}
+
+	location := "kube-" + strings.ToLower(source.ID)
+	spec := map[string]interface{}{
+		"provider": loc.Provider,
+		"objectStorage": map[string]string{
+			"bucket": source.Backup.Bucket,
+			"prefix": source.Backup.Prefix,
+		},
+		"config":     loc.Config,
+		"accessMode": "ReadOnly",
+	}
+	err = h.createVelero(ctx, target.ID, veleroLocations, "BackupStorageLocation", location, spec, nil)
+	if err != nil && !apierrors.IsAlreadyExists(errors.Cause(err)) {
+		return errors.Wrapf(err, "create storage location %s", location)
+	}
+
+	// the backup is copied instead of waiting for the periodic sync of
+	// the new location, its status is kept for the restore to accept it
+	raw, err := h.svc.GetKubeResources(ctx, source.ID, veleroBackups, velero.Namespace, backupName)
+	if err != nil {
+		return errors.Wrapf(err, "get backup %s of kube %s", backupName, source.ID)
+	}
+	backup := map[string]interface{}{}
+	if err = json.Unmarshal(raw, &backup); err != nil {
+		return errors.Wrapf(err, "decode backup %s", backupName)
+	}
+	backupSpec, _ := backup["spec"].(map[string]interface{})
+	if backupSpec == nil {
+		backupSpec = map[string]interface{}{}
+	}
+	backupSpec["storageLocation"] = location
+
+	obj := map[string]interface{}{
+		"apiVersion": veleroAPIVersion,
+		"kind":       "Backup",
+		"metadata": metav1.ObjectMeta{
+			Name:      backupName,
+			Namespace: velero.Namespace,
+			Labels:    map[string]string{storageLocationLabel: location},
+		},
+		"spec":   backupSpec,
+		"status": backup["status"],
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	_, err = h.svc.CreateResource(ctx, target.ID, veleroBackups, velero.Namespace, data, false)
+	if err != nil && !apierrors.IsAlreadyExists(errors.Cause(err)) {
+		return errors.Wrapf(err, "copy backup %s", backupName)
+	}
+	return nil
+}
+
+// getBackupKube returns the kube that has Velero installed.
+func (h *Handler) getBackupKube(w http.ResponseWriter, r *http.Request) (*model.Kube, bool) {
+	k, ok := h.getKubeOrSend(w, r)
+	if !ok {
+		return nil, false
+	}
+	if k.Backup.Bucket == "" {
+		message.SendValidationFailed(w, errors.Wrapf(ErrBackupsDisabled, "kube %s", k.ID))
+		return nil, false
+	}
+	return k, true
+}
+
+func (h *Handler) getKubeOrSend(w http.ResponseWriter, r *http.Request) (*model.Kube, bool) {
+	kubeID := mux.Vars(r)["kubeID"]
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return nil, false
+		}
+		message.SendUnknownError(w, err)
+		return nil, false
+	}
+	return k, true
+}
+
+func (h *Handler) listVelero(ctx context.Context, kubeID, resource string, list interface{}) error {
+	raw, err := h.svc.ListResources(ctx, kubeID, resource, ResourceListOptions{Namespace: velero.Namespace})
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(json.Unmarshal(raw, list), "decode %s", resource)
+}
+
+// createVelero creates the Velero object in its namespace, the created
+// object is decoded to out if it isn't nil.
+func (h *Handler) createVelero(ctx context.Context, kubeID, resource, kind, name string, spec, out interface{}) error {
+	data, err := json.Marshal(veleroObject{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: veleroAPIVersion,
+			Kind:       kind,
+		},
+		Metadata: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: velero.Namespace,
+		},
+		Spec: spec,
+	})
+	if err != nil {
+		return err
+	}
+
+	raw, err := h.svc.CreateResource(ctx, kubeID, resource, velero.Namespace, data, false)
+	if err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	return errors.Wrapf(json.Unmarshal(raw, out), "decode %s", resource)
+}
+
+func validateBackup(name string, spec BackupSpec) error {
+	if err := validateNames(name); err != nil {
+		return err
+	}
+	if spec.TTL != "" {
+		if _, err := time.ParseDuration(spec.TTL); err != nil {
+			return errors.Wrapf(err, "ttl %q", spec.TTL)
+		}
+	}
+	return nil
+}
+
+func validateNames(names ...string) error {
+	for _, name := range names {
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return errors.Errorf("name %q: %s", name, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+func timestampedName(prefix string) string {
+	return fmt.Sprintf("%s-%s", prefix, time.Now().UTC().Format("20060102150405"))
+}
+
+func (b backupObject) info() BackupInfo {
+	return BackupInfo{
+		Name:       b.Metadata.Name,
+		BackupSpec: b.Spec,
+		Phase:      b.Status.Phase,
+		Started:    b.Status.StartTimestamp,
+		Completed:  b.Status.CompletionTimestamp,
+		Expiration: b.Status.Expiration,
+		Errors:     b.Status.Errors,
+		Warnings:   b.Status.Warnings,
+	}
+}
+
+func (s scheduleObject) info() ScheduleInfo {
+	return ScheduleInfo{
+		Name:       s.Metadata.Name,
+		Schedule:   s.Spec.Schedule,
+		BackupSpec: s.Spec.Template,
+		Phase:      s.Status.Phase,
+		LastBackup: s.Status.LastBackup,
+	}
+}
+
+func (rs restoreObject) info() RestoreInfo {
+	return RestoreInfo{
+		Name:               rs.Metadata.Name,
+		BackupName:         rs.Spec.BackupName,
+		IncludedNamespaces: rs.Spec.IncludedNamespaces,
+		ExcludedNamespaces: rs.Spec.ExcludedNamespaces,
+		NamespaceMapping:   rs.Spec.NamespaceMapping,
+		Phase:              rs.Status.Phase,
+		Errors:             rs.Status.Errors,
+		Warnings:           rs.Status.Warnings,
+	}
+}
@@ -0,0 +1,141 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/velero"
+)
+
+func backupKube(id string) *model.Kube {
+	return &model.Kube{
+		ID:       id,
+		Provider: clouds.AWS,
+		State:    model.StateOperational,
+		Backup: model.Backup{
+			Bucket: "backups",
+			Prefix: id,
+			Region: "us-east-1",
+		},
+	}
+}
+
+func TestHandler_StartVeleroInstallValidation(t *testing.T) {
+	h := &Handler{}
+
+	k := backupKube("kube-1")
+	k.State = model.StateProvisioning
+	_, err := h.StartVeleroInstall(context.Background(), k, steps.VeleroConfig{})
+	require.Equal(t, ErrNotOperational, errors.Cause(err))
+
+	k = backupKube("kube-1")
+	_, err = h.StartVeleroInstall(context.Background(), k, steps.VeleroConfig{})
+	require.Equal(t, sgerrors.ErrInvalidJson, errors.Cause(err), "bucket is required")
+
+	k.Provider = clouds.DigitalOcean
+	_, err = h.StartVeleroInstall(context.Background(), k, steps.VeleroConfig{
+		Backup: model.Backup{Bucket: "backups", Region: "nyc3"},
+	})
+	require.Equal(t, sgerrors.ErrInvalidJson, errors.Cause(err), "spaces keys are required")
+}
+
+func TestHandler_createBackup(t *testing.T) {
+	svc := new(kubeServiceMock)
+	svc.On("Get", mock.Anything, "kube-1").Return(backupKube("kube-1"), nil)
+	svc.On("CreateResource", mock.Anything, "kube-1", veleroBackups, velero.Namespace, mock.Anything, false).
+		Return([]byte(`{"metadata":{"name":"daily"},"spec":{"ttl":"24h0m0s"},"status":{"phase":"New"}}`), nil)
+	h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+	rr := protectionRequest(h, http.MethodPost, "/kubes/kube-1/backups", `{"name":"daily","ttl":"24h"}`)
+	require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+
+	info := BackupInfo{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&info))
+	require.Equal(t, "daily", info.Name)
+	require.Equal(t, "New", info.Phase)
+
+	obj := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(svc.Calls[1].Arguments.Get(4).([]byte), &obj))
+	require.Equal(t, "Backup", obj["kind"])
+	require.Equal(t, "24h", obj["spec"].(map[string]interface{})["ttl"])
+
+	rr = protectionRequest(h, http.MethodPost, "/kubes/kube-1/backups", `{"name":"Daily!"}`)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestHandler_backupsDisabled(t *testing.T) {
+	svc := new(kubeServiceMock)
+	svc.On("Get", mock.Anything, "kube-1").Return(&model.Kube{ID: "kube-1"}, nil)
+	h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+	rr := protectionRequest(h, http.MethodGet, "/kubes/kube-1/backups", "")
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	svc.AssertNotCalled(t, "ListResources", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandler_listSchedules(t *testing.T) {
+	svc := new(kubeServiceMock)
+	svc.On("Get", mock.Anything, "kube-1").Return(backupKube("kube-1"), nil)
+	svc.On("ListResources", mock.Anything, "kube-1", veleroSchedules, ResourceListOptions{Namespace: velero.Namespace}).
+		Return([]byte(`{"items":[{"metadata":{"name":"nightly"},"spec":{"schedule":"0 1 * * *","template":{"includedNamespaces":["default"]}},"status":{"phase":"Enabled"}}]}`), nil)
+	h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+	rr := protectionRequest(h, http.MethodGet, "/kubes/kube-1/backups/schedules", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	schedules := make([]ScheduleInfo, 0)
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&schedules))
+	require.Len(t, schedules, 1)
+	require.Equal(t, "0 1 * * *", schedules[0].Schedule)
+	require.Equal(t, []string{"default"}, schedules[0].IncludedNamespaces)
+
+	rr = protectionRequest(h, http.MethodPost, "/kubes/kube-1/backups/schedules", `{"name":"nightly","schedule":"every night"}`)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestHandler_createRestoreFromOtherKube(t *testing.T) {
+	svc := new(kubeServiceMock)
+	svc.On("Get", mock.Anything, "kube-1").Return(backupKube("kube-1"), nil)
+	svc.On("Get", mock.Anything, "kube-2").Return(backupKube("kube-2"), nil)
+	svc.On("CreateResource", mock.Anything, "kube-1", veleroLocations, velero.Namespace, mock.Anything, false).
+		Return(nil, errors.Wrap(apierrors.NewAlreadyExists(schema.GroupResource{}, "kube-kube-2"), "create"))
+	svc.On("GetKubeResources", mock.Anything, "kube-2", veleroBackups, velero.Namespace, "daily").
+		Return([]byte(`{"metadata":{"name":"daily","uid":"1"},"spec":{"storageLocation":"default"},"status":{"phase":"Completed"}}`), nil)
+	svc.On("CreateResource", mock.Anything, "kube-1", veleroBackups, velero.Namespace, mock.Anything, false).
+		Return([]byte(`{}`), nil)
+	svc.On("CreateResource", mock.Anything, "kube-1", veleroRestores, velero.Namespace, mock.Anything, false).
+		Return([]byte(`{"metadata":{"name":"restore"},"spec":{"backupName":"daily"}}`), nil)
+	h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+	rr := protectionRequest(h, http.MethodPost, "/kubes/kube-1/restores",
+		`{"name":"restore","backupName":"daily","sourceKubeId":"kube-2"}`)
+	require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+
+	var backup map[string]interface{}
+	for _, c := range svc.Calls {
+		if c.Method == "CreateResource" && c.Arguments.String(2) == veleroBackups {
+			require.NoError(t, json.Unmarshal(c.Arguments.Get(4).([]byte), &backup))
+		}
+	}
+	require.NotNil(t, backup, "backup must be copied")
+	require.Equal(t, "kube-kube-2", backup["spec"].(map[string]interface{})["storageLocation"])
+	require.Equal(t, "Completed", backup["status"].(map[string]interface{})["phase"])
+	require.NotContains(t, backup["metadata"], "uid")
+
+	source := backupKube("kube-2")
+	source.Provider = clouds.GCE
+	err := h.importBackup(context.Background(), backupKube("kube-1"), source, "daily")
+	require.Equal(t, sgerrors.ErrUnsupportedProvider, errors.Cause(err))
+}
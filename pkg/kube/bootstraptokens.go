@@ -0,0 +1,114 @@
+package kube
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
+	bootstraputil "k8s.io/cluster-bootstrap/token/util"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// BootstrapTokenInfo describes an outstanding bootstrap token, the token
+// secret is never returned.
+type BootstrapTokenInfo struct {
+	ID          string     `json:"id"`
+	Description string     `json:"description,omitempty"`
+	Expiration  *time.Time `json:"expiration,omitempty"`
+	Expired     bool       `json:"expired"`
+	Usages      []string   `json:"usages,omitempty"`
+	Groups      []string   `json:"groups,omitempty"`
+}
+
+func (h *Handler) listBootstrapTokens(w http.ResponseWriter, r *http.Request) {
+	k, ok := h.getKubeOrSend(w, r)
+	if !ok {
+		return
+	}
+
+	raw, err := h.svc.ListResources(r.Context(), k.ID, "secrets", ResourceListOptions{
+		Namespace:     metav1.NamespaceSystem,
+		FieldSelector: "type=" + string(corev1.SecretTypeBootstrapToken),
+	})
+	if err != nil {
+		sendResourceError(w, "secrets", err)
+		return
+	}
+
+	list := corev1.SecretList{}
+	if err = json.Unmarshal(raw, &list); err != nil {
+		message.SendUnknownError(w, errors.Wrap(err, "decode secrets"))
+		return
+	}
+
+	now := time.Now()
+	tokens := make([]BootstrapTokenInfo, 0, len(list.Items))
+	for _, secret := range list.Items {
+		tokens = append(tokens, bootstrapTokenInfo(secret, now))
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].ID < tokens[j].ID
+	})
+
+	if err = json.NewEncoder(w).Encode(tokens); err != nil {
+		logrus.Errorf("list bootstrap tokens of kube %s: write response: %v", k.ID, err)
+	}
+}
+
+func (h *Handler) revokeBootstrapToken(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["tokenID"]
+	if !bootstraputil.IsValidBootstrapTokenID(id) {
+		message.SendValidationFailed(w, errors.Wrapf(sgerrors.ErrInvalidJson, "token id %q", id))
+		return
+	}
+
+	k, ok := h.getKubeOrSend(w, r)
+	if !ok {
+		return
+	}
+
+	name := bootstraputil.BootstrapTokenSecretName(id)
+	if _, err := h.svc.DeleteResource(r.Context(), k.ID, "secrets", metav1.NamespaceSystem, name, false); err != nil {
+		sendResourceError(w, name, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func bootstrapTokenInfo(secret corev1.Secret, now time.Time) BootstrapTokenInfo {
+	data := func(key string) string {
+		return string(secret.Data[key])
+	}
+
+	info := BootstrapTokenInfo{
+		ID:          data(bootstrapapi.BootstrapTokenIDKey),
+		Description: data(bootstrapapi.BootstrapTokenDescriptionKey),
+	}
+	if info.ID == "" {
+		info.ID = strings.TrimPrefix(secret.Name, bootstrapapi.BootstrapTokenSecretPrefix)
+	}
+	if exp, err := time.Parse(time.RFC3339, data(bootstrapapi.BootstrapTokenExpirationKey)); err == nil {
+		info.Expiration = &exp
+		info.Expired = exp.Before(now)
+	}
+	for key, value := range secret.Data {
+		if strings.HasPrefix(key, bootstrapapi.BootstrapTokenUsagePrefix) && string(value) == "true" {
+			info.Usages = append(info.Usages, strings.TrimPrefix(key, bootstrapapi.BootstrapTokenUsagePrefix))
+		}
+	}
+	sort.Strings(info.Usages)
+	if groups := data(bootstrapapi.BootstrapTokenExtraGroupsKey); groups != "" {
+		info.Groups = strings.Split(groups, ",")
+	}
+	return info
+}
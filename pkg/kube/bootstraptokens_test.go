@@ -0,0 +1,52 @@
+package kube
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/model"
+)
+
+// secrets data is base64 encoded: abcdef, 2019-01-01T00:00:00Z, true
+const bootstrapSecrets = `{"items":[{"metadata":{"name":"bootstrap-token-abcdef"},"type":"bootstrap.kubernetes.io/token",
+"data":{"token-id":"YWJjZGVm","token-secret":"MDEyMzQ1Njc4OWFiY2RlZg==","expiration":"MjAxOS0wMS0wMVQwMDowMDowMFo=",
+"usage-bootstrap-authentication":"dHJ1ZQ=="}}]}`
+
+func TestHandler_listBootstrapTokens(t *testing.T) {
+	svc := new(kubeServiceMock)
+	svc.On("Get", mock.Anything, "kube-1").Return(&model.Kube{ID: "kube-1"}, nil)
+	svc.On("ListResources", mock.Anything, "kube-1", "secrets", ResourceListOptions{
+		Namespace:     "kube-system",
+		FieldSelector: "type=bootstrap.kubernetes.io/token",
+	}).Return([]byte(bootstrapSecrets), nil)
+	h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+	rr := protectionRequest(h, http.MethodGet, "/kubes/kube-1/bootstraptokens", "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.NotContains(t, rr.Body.String(), "0123456789abcdef")
+
+	tokens := make([]BootstrapTokenInfo, 0)
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&tokens))
+	require.Len(t, tokens, 1)
+	require.Equal(t, "abcdef", tokens[0].ID)
+	require.True(t, tokens[0].Expired)
+	require.Equal(t, []string{"authentication"}, tokens[0].Usages)
+}
+
+func TestHandler_revokeBootstrapToken(t *testing.T) {
+	svc := new(kubeServiceMock)
+	svc.On("Get", mock.Anything, "kube-1").Return(&model.Kube{ID: "kube-1"}, nil)
+	svc.On("DeleteResource", mock.Anything, "kube-1", "secrets", "kube-system", "bootstrap-token-abcdef", false).
+		Return([]byte(`{}`), nil)
+	h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+	rr := protectionRequest(h, http.MethodDelete, "/kubes/kube-1/bootstraptokens/abcdef", "")
+	require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+
+	rr = protectionRequest(h, http.MethodDelete, "/kubes/kube-1/bootstraptokens/ABC", "")
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,263 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/labels"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/pricing"
+	"github.com/supergiant/control/pkg/settings"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+const (
+	costCheckInterval = time.Hour
+	alertTimeout      = 10 * time.Second
+)
+
+var alertClient = &http.Client{Timeout: alertTimeout}
+
+func (h *Handler) getBudget(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	status := BudgetStatus{Budget: k.Budget}
+	if p, err := h.projectCost(r.Context(), k); err == nil {
+		status.Projection = p
+		status.Exceeded = k.Budget.Monthly > 0 && p.Projected > k.Budget.Monthly
+	} else {
+		logrus.Debugf("kubes: %s cluster: project cost: %v", k.ID, err)
+	}
+
+	if err = json.NewEncoder(w).Encode(status); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) setBudget(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	budget := model.Budget{}
+	if err := json.NewDecoder(r.Body).Decode(&budget); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	if err := validateBudget(budget); err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	// a changed limit is alerted again in the same month
+	if budget.Monthly == k.Budget.Monthly {
+		budget.AlertedMonth = k.Budget.AlertedMonth
+	} else {
+		budget.AlertedMonth = ""
+	}
+	k.Budget = budget
+
+	if err := h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(k.Budget); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func validateBudget(b model.Budget) error {
+	if b.Monthly < 0 {
+		return errors.New("budget must not be negative")
+	}
+
+	for _, u := range []string{b.WebhookURL, b.SlackWebhookURL} {
+		if u == "" {
+			continue
+		}
+		if parsed, err := url.Parse(u); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			return errors.Errorf("invalid webhook url %q", u)
+		}
+	}
+
+	return nil
+}
+
+// checkBudget alerts once a month when the projected spend of the cluster
+// exceeds its budget.
+func (h *Handler) checkBudget(ctx context.Context, k *model.Kube) error {
+	if k.Budget.Monthly <= 0 {
+		return nil
+	}
+
+	p, err := h.projectCost(ctx, k)
+	if err != nil {
+		return errors.Wrap(err, "project cost")
+	}
+	if p.Projected <= k.Budget.Monthly || k.Budget.AlertedMonth == p.Month {
+		return nil
+	}
+
+	alert := BudgetAlert{
+		KubeID:      k.ID,
+		KubeName:    k.Name,
+		Month:       p.Month,
+		Budget:      k.Budget.Monthly,
+		MonthToDate: p.MonthToDate,
+		Projected:   p.Projected,
+		Currency:    pricing.Currency,
+	}
+	logrus.Warnf("kubes: %s cluster: projected spend %.2f %s exceeds the budget %.2f",
+		k.ID, p.Projected, pricing.Currency, k.Budget.Monthly)
+
+	webhooks, slackWebhooks := h.alertTargets(k, k.Budget.WebhookURL, k.Budget.SlackWebhookURL)
+	for _, u := range webhooks {
+		if err := h.postAlert(u, alert); err != nil {
+			return errors.Wrap(err, "budget webhook")
+		}
+	}
+	if len(slackWebhooks) > 0 {
+		text := fmt.Sprintf("Cluster %s is projected to spend %.2f %s in %s, over its budget of %.2f %s (%.2f %s so far)",
+			k.Name, p.Projected, pricing.Currency, p.Month, k.Budget.Monthly, pricing.Currency, p.MonthToDate, pricing.Currency)
+		for _, u := range slackWebhooks {
+			if err := h.postAlert(u, map[string]string{"text": text}); err != nil {
+				return errors.Wrap(err, "slack webhook")
+			}
+		}
+	}
+
+	k.Budget.AlertedMonth = p.Month
+	return h.svc.Create(ctx, k)
+}
+
+// SetNotificationRoutes changes routes that alerts of kubes are sent to in
+// addition to webhooks of the kubes.
+func (h *Handler) SetNotificationRoutes(routes []settings.NotificationRoute) {
+	h.routesMu.Lock()
+	defer h.routesMu.Unlock()
+
+	h.routes = routes
+}
+
+// alertTargets returns webhooks and slack webhooks of the kube and of routes
+// that match its labels, each of them once.
+func (h *Handler) alertTargets(k *model.Kube, webhook, slackWebhook string) ([]string, []string) {
+	h.routesMu.RLock()
+	defer h.routesMu.RUnlock()
+
+	webhooks, slackWebhooks := appendURL(nil, webhook), appendURL(nil, slackWebhook)
+	for _, r := range h.routes {
+		if labels.Matches(k.Labels, r.Selector) {
+			webhooks = appendURL(webhooks, r.WebhookURL)
+			slackWebhooks = appendURL(slackWebhooks, r.SlackWebhookURL)
+		}
+	}
+
+	return webhooks, slackWebhooks
+}
+
+func appendURL(urls []string, u string) []string {
+	if u == "" {
+		return urls
+	}
+	for _, existing := range urls {
+		if existing == u {
+			return urls
+		}
+	}
+	return append(urls, u)
+}
+
+func postAlert(url string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := alertClient.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return errors.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
+
+// CostMonitor collects utilization of node groups and checks budgets of clusters.
+type CostMonitor struct {
+	h        *Handler
+	interval time.Duration
+}
+
+func NewCostMonitor(h *Handler) *CostMonitor {
+	return &CostMonitor{
+		h:        h,
+		interval: costCheckInterval,
+	}
+}
+
+// Run checks clusters until the context is done.
+func (m *CostMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.check(ctx, now)
+		}
+	}
+}
+
+func (m *CostMonitor) check(ctx context.Context, now time.Time) {
+	kubes, err := m.h.svc.ListAll(ctx)
+	if err != nil {
+		logrus.Errorf("cost monitor: list kubes: %v", err)
+		return
+	}
+
+	eachOperationalKube(kubes, func(k *model.Kube) {
+		if err := m.h.collectUtilization(ctx, k, now); err != nil {
+			logrus.Debugf("cost monitor: kube %s: collect utilization: %v", k.ID, err)
+		}
+		if err := m.h.checkBudget(ctx, k); err != nil {
+			logrus.Errorf("cost monitor: kube %s: %v", k.ID, err)
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package kube
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/pricing"
+	"github.com/supergiant/control/pkg/settings"
+)
+
+func TestHandler_setBudget(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		body           string
+		alertedMonth   string
+		expectedStatus int
+	}{
+		{"invalid json", `{`, "", http.StatusBadRequest},
+		{"negative budget", `{"monthly":-1}`, "", http.StatusBadRequest},
+		{"invalid webhook", `{"monthly":100,"webhookUrl":"ftp://hooks"}`, "", http.StatusBadRequest},
+		{"same limit", `{"monthly":100,"slackWebhookUrl":"https://hooks.slack.com/x"}`, "2019-05", http.StatusOK},
+		{"new limit", `{"monthly":200}`, "", http.StatusOK},
+	} {
+		k := &model.Kube{ID: "kube-1", Budget: model.Budget{Monthly: 100, AlertedMonth: "2019-05"}}
+		svc := new(kubeServiceMock)
+		svc.On("Get", mock.Anything, "kube-1").Return(k, nil)
+		svc.On("Create", mock.Anything, mock.Anything).Return(nil)
+		h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+		router := mux.NewRouter()
+		h.Register(router)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/kubes/kube-1/budget", strings.NewReader(tc.body)))
+
+		require.Equal(t, tc.expectedStatus, rr.Code, tc.name)
+		if tc.expectedStatus == http.StatusOK {
+			require.Equal(t, tc.alertedMonth, k.Budget.AlertedMonth, tc.name)
+		}
+	}
+}
+
+func TestHandler_checkBudget(t *testing.T) {
+	projection := &pricing.Projection{Month: "2019-05", MonthToDate: 40, Projected: 120}
+
+	for _, tc := range []struct {
+		name         string
+		budget       model.Budget
+		expectedURLs []string
+	}{
+		{"no budget", model.Budget{WebhookURL: "http://hook"}, nil},
+		{"under budget", model.Budget{Monthly: 150, WebhookURL: "http://hook"}, nil},
+		{"already alerted", model.Budget{Monthly: 100, WebhookURL: "http://hook", AlertedMonth: "2019-05"}, nil},
+		{"over budget", model.Budget{Monthly: 100, WebhookURL: "http://hook", SlackWebhookURL: "http://slack",
+			AlertedMonth: "2019-04"}, []string{"http://hook", "http://slack"}},
+	} {
+		k := &model.Kube{ID: "kube-1", Budget: tc.budget}
+		svc := new(kubeServiceMock)
+		svc.On("Create", mock.Anything, k).Return(nil)
+
+		var urls []string
+		h := &Handler{
+			svc: svc,
+			projectCost: func(context.Context, *model.Kube) (*pricing.Projection, error) {
+				return projection, nil
+			},
+			postAlert: func(url string, payload interface{}) error {
+				urls = append(urls, url)
+				if alert, ok := payload.(BudgetAlert); ok {
+					require.Equal(t, 120.0, alert.Projected)
+				}
+				return nil
+			},
+		}
+
+		require.NoError(t, h.checkBudget(context.Background(), k), tc.name)
+		require.Equal(t, tc.expectedURLs, urls, tc.name)
+		if tc.expectedURLs != nil {
+			require.Equal(t, "2019-05", k.Budget.AlertedMonth)
+			svc.AssertCalled(t, "Create", mock.Anything, k)
+		}
+	}
+}
+
+func TestHandler_alertTargets(t *testing.T) {
+	h := &Handler{}
+	h.SetNotificationRoutes([]settings.NotificationRoute{
+		{Name: "oncall", Selector: map[string]string{"env": "prod"}, WebhookURL: "http://oncall", SlackWebhookURL: "http://slack"},
+		{Name: "all", WebhookURL: "http://hook"},
+	})
+
+	webhooks, slackWebhooks := h.alertTargets(&model.Kube{Labels: map[string]string{"env": "prod"}}, "http://hook", "")
+	require.Equal(t, []string{"http://hook", "http://oncall"}, webhooks)
+	require.Equal(t, []string{"http://slack"}, slackWebhooks)
+
+	webhooks, slackWebhooks = h.alertTargets(&model.Kube{}, "", "")
+	require.Equal(t, []string{"http://hook"}, webhooks)
+	require.Empty(t, slackWebhooks)
+}
+
+func TestPostAlert(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		body = string(data)
+		if strings.Contains(body, "fail") {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	require.NoError(t, postAlert(srv.URL, map[string]string{"text": "over budget"}))
+	require.Equal(t, `{"text":"over budget"}`, body)
+	require.Error(t, postAlert(srv.URL, map[string]string{"text": "fail"}))
+}
@@ -0,0 +1,256 @@
+package kube
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+)
+
+const (
+	defaultExpiryWithin = 30 * 24 * time.Hour
+	certProbeTimeout    = 5 * time.Second
+	kubeletPort         = 10250
+	// certProbeWorkers is how many endpoints are probed at once.
+	certProbeWorkers = 16
+	// certProbeTTL is how long certificates read from an endpoint are reused.
+	certProbeTTL = time.Minute
+)
+
+// certificatesExpiry lists certificates of all clusters that expire within a period.
+// Serving certificates are read from apiserver and kubelet endpoints, the rest
+// of them are kept on the control side.
+func (h *Handler) certificatesExpiry(w http.ResponseWriter, r *http.Request) {
+	within := defaultExpiryWithin
+	if v := r.URL.Query().Get("within"); v != "" {
+		var err error
+		if within, err = time.ParseDuration(v); err != nil || within < 0 {
+			message.SendValidationFailed(w, errors.Errorf("invalid duration %q", v))
+			return
+		}
+	}
+
+	kubes, err := h.visibleKubes(r.Context())
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	var (
+		m       sync.Mutex
+		wg      sync.WaitGroup
+		workers = make(chan struct{}, certProbeWorkers)
+		now     = time.Now().UTC()
+		expires = make([]CertificateExpiry, 0)
+	)
+	add := func(k *model.Kube, name, machine string, cert *x509.Certificate) {
+		if cert.NotAfter.After(now.Add(within)) {
+			return
+		}
+
+		m.Lock()
+		expires = append(expires, CertificateExpiry{
+			KubeID:   k.ID,
+			KubeName: k.Name,
+			Name:     name,
+			Machine:  machine,
+			Subject:  cert.Subject.CommonName,
+			NotAfter: cert.NotAfter,
+			DaysLeft: int(cert.NotAfter.Sub(now).Hours() / 24),
+			Expired:  cert.NotAfter.Before(now),
+		})
+		m.Unlock()
+	}
+
+	for i := range kubes {
+		k := &kubes[i]
+
+		for name, data := range map[string]string{
+			"ca":      k.Auth.CACert,
+			"etcd-ca": k.Auth.EtcdCACert,
+			"admin":   k.Auth.AdminCert,
+		} {
+			if cert := parseCertPEM(data); cert != nil {
+				add(k, name, "", cert)
+			}
+		}
+
+		for _, cred := range h.kubeconfigCredentials(r, k.ID) {
+			if cred.Revoked || cred.ExpiresAt.After(now.Add(within)) {
+				continue
+			}
+			m.Lock()
+			expires = append(expires, CertificateExpiry{
+				KubeID:   k.ID,
+				KubeName: k.Name,
+				Name:     "kubeconfig " + cred.ID,
+				Subject:  cred.User,
+				NotAfter: cred.ExpiresAt,
+				DaysLeft: int(cred.ExpiresAt.Sub(now).Hours() / 24),
+				Expired:  cred.ExpiresAt.Before(now),
+			})
+			m.Unlock()
+		}
+
+		for _, machine := range append(machineList(k.Masters), machineList(k.Nodes)...) {
+			ports := map[string]string{
+				"kubelet": strconv.Itoa(kubeletPort),
+			}
+			if _, ok := k.Masters[machine.Name]; ok {
+				ports["apiserver"] = strconv.FormatInt(k.APIServerPort, 10)
+			}
+
+			for name, port := range ports {
+				wg.Add(1)
+				workers <- struct{}{}
+				go func(k *model.Kube, name, machine, addr string) {
+					defer func() {
+						<-workers
+						wg.Done()
+					}()
+
+					certs, err := h.cachedProbeCerts(addr)
+					if err != nil || len(certs) == 0 {
+						logrus.Debugf("kubes: %s cluster: read %s certificate of %s: %v", k.ID, name, machine, err)
+						return
+					}
+					add(k, name, machine, certs[0])
+				}(k, name, machine.Name, net.JoinHostPort(machine.PublicIp, port))
+			}
+		}
+	}
+	wg.Wait()
+
+	sort.Slice(expires, func(i, j int) bool {
+		return expires[i].NotAfter.Before(expires[j].NotAfter)
+	})
+
+	if err = json.NewEncoder(w).Encode(expires); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) kubeconfigCredentials(r *http.Request, kubeID string) []*KubeconfigCredential {
+	data, err := h.repo.GetAll(r.Context(), credentialsPrefix(kubeID))
+	if err != nil {
+		logrus.Debugf("kubes: %s cluster: list kubeconfig credentials: %v", kubeID, err)
+		return nil
+	}
+
+	creds := make([]*KubeconfigCredential, 0, len(data))
+	for _, raw := range data {
+		cred := &KubeconfigCredential{}
+		if err := json.Unmarshal(raw, cred); err == nil {
+			creds = append(creds, cred)
+		}
+	}
+	return creds
+}
+
+func machineList(machines map[string]*model.Machine) []*model.Machine {
+	list := make([]*model.Machine, 0, len(machines))
+	for _, m := range machines {
+		if m != nil && m.PublicIp != "" {
+			list = append(list, m)
+		}
+	}
+	return list
+}
+
+func parseCertPEM(data string) *x509.Certificate {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil
+	}
+	return cert
+}
+
+// certProbeCache keeps certificates read from endpoints, failures are kept
+// too, otherwise each unreachable endpoint takes certProbeTimeout again.
+type certProbeCache struct {
+	m       sync.Mutex
+	entries map[string]certProbe
+}
+
+type certProbe struct {
+	certs     []*x509.Certificate
+	err       error
+	expiresAt time.Time
+}
+
+func (c *certProbeCache) get(addr string) (certProbe, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	p, ok := c.entries[addr]
+	if !ok || time.Now().After(p.expiresAt) {
+		return certProbe{}, false
+	}
+
+	return p, true
+}
+
+func (c *certProbeCache) put(addr string, p certProbe) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	now := time.Now()
+	if c.entries == nil {
+		c.entries = make(map[string]certProbe)
+	}
+	// entries of removed machines don't pile up
+	for a, e := range c.entries {
+		if now.After(e.expiresAt) {
+			delete(c.entries, a)
+		}
+	}
+	c.entries[addr] = p
+}
+
+// cachedProbeCerts returns certificates served on the address, they are
+// read again once certProbeTTL has passed.
+func (h *Handler) cachedProbeCerts(addr string) ([]*x509.Certificate, error) {
+	if p, ok := h.certProbes.get(addr); ok {
+		return p.certs, p.err
+	}
+
+	certs, err := h.probeCerts(addr)
+	h.certProbes.put(addr, certProbe{
+		certs:     certs,
+		err:       err,
+		expiresAt: time.Now().Add(certProbeTTL),
+	})
+
+	return certs, err
+}
+
+// probeCerts returns certificates served on the address,
+// they are only read, so the chain isn't verified.
+func probeCerts(addr string) ([]*x509.Certificate, error) {
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: certProbeTimeout}, "tcp", addr, &tls.Config{
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dial %s: %v", addr, err)
+	}
+	defer conn.Close()
+
+	return conn.ConnectionState().PeerCertificates, nil
+}
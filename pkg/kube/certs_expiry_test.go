@@ -0,0 +1,158 @@
+package kube
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/pki"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/testutils"
+)
+
+func TestHandler_certificatesExpiry(t *testing.T) {
+	ca, err := pki.NewCAPair(nil)
+	require.Nil(t, err)
+
+	cred, err := json.Marshal(&KubeconfigCredential{
+		ID:        "cred",
+		User:      "user",
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.Nil(t, err)
+
+	kubes := []model.Kube{
+		{
+			ID:            "kube",
+			APIServerPort: 443,
+			Auth: model.Auth{
+				CACert: string(ca.Cert),
+			},
+			Masters: map[string]*model.Machine{
+				"master-1": {Name: "master-1", PublicIp: "10.0.0.1"},
+			},
+			Nodes: map[string]*model.Machine{
+				"node-1": {Name: "node-1", PublicIp: "10.0.0.2"},
+			},
+		},
+	}
+
+	tcs := []struct {
+		within       string
+		serviceError error
+
+		expectedStatus  int
+		expectedErrCode sgerrors.ErrorCode
+		expectedNames   []string
+	}{
+		{ // TC#1
+			within:          "month",
+			expectedStatus:  http.StatusBadRequest,
+			expectedErrCode: sgerrors.ValidationFailed,
+		},
+		{ // TC#2
+			serviceError:   errors.New("list error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{ // TC#3
+			expectedStatus: http.StatusOK,
+			expectedNames:  []string{"kubeconfig cred", "apiserver"},
+		},
+		{ // TC#4
+			within:         "87600h",
+			expectedStatus: http.StatusOK,
+			expectedNames:  []string{"kubeconfig cred", "apiserver", "ca"},
+		},
+	}
+
+	for i, tc := range tcs {
+		svc := new(kubeServiceMock)
+		svc.On(serviceListAll, mock.Anything).Return(kubes, tc.serviceError)
+
+		repo := new(testutils.MockStorage)
+		repo.On(testutils.StorageGetAll, mock.Anything, mock.Anything).Return([][]byte{cred}, nil)
+
+		h := NewHandler(svc, nil, nil, nil, nil, nil, repo, nil, "")
+		h.probeCerts = func(addr string) ([]*x509.Certificate, error) {
+			// only apiserver of the master answers
+			if addr != "10.0.0.1:443" {
+				return nil, sgerrors.ErrNotFound
+			}
+			return []*x509.Certificate{{NotAfter: time.Now().Add(24 * time.Hour)}}, nil
+		}
+
+		req, err := http.NewRequest(http.MethodGet, "/kubes/certificates/expiry?within="+tc.within, nil)
+		require.Equalf(t, nil, err, "TC#%d: create request: %v", i+1, err)
+		rr := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		h.Register(router)
+		router.ServeHTTP(rr, req)
+
+		require.Equalf(t, tc.expectedStatus, rr.Code, "TC#%d", i+1)
+
+		if tc.expectedStatus == http.StatusOK {
+			var expires []CertificateExpiry
+			err = json.NewDecoder(rr.Body).Decode(&expires)
+			require.Equalf(t, nil, err, "TC#%d", i+1)
+
+			names := make([]string, 0, len(expires))
+			for _, e := range expires {
+				names = append(names, e.Name)
+			}
+			require.Equalf(t, tc.expectedNames, names, "TC#%d", i+1)
+		}
+	}
+}
+
+func TestHandler_certificatesExpiryProbes(t *testing.T) {
+	k := model.Kube{ID: "kube", Nodes: make(map[string]*model.Machine)}
+	for i := 0; i < certProbeWorkers*3; i++ {
+		name := fmt.Sprintf("node-%d", i)
+		k.Nodes[name] = &model.Machine{Name: name, PublicIp: fmt.Sprintf("10.0.1.%d", i)}
+	}
+
+	svc := new(kubeServiceMock)
+	svc.On(serviceListAll, mock.Anything).Return([]model.Kube{k}, nil)
+	repo := new(testutils.MockStorage)
+	repo.On(testutils.StorageGetAll, mock.Anything, mock.Anything).Return([][]byte{}, nil)
+
+	h := NewHandler(svc, nil, nil, nil, nil, nil, repo, nil, "")
+	var running, maxRunning, probes int32
+	h.probeCerts = func(addr string) ([]*x509.Certificate, error) {
+		atomic.AddInt32(&probes, 1)
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond * 5)
+		return nil, sgerrors.ErrNotFound
+	}
+
+	router := mux.NewRouter()
+	h.Register(router)
+	for i := 0; i < 2; i++ {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/kubes/certificates/expiry", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+	}
+
+	require.True(t, maxRunning <= certProbeWorkers, "%d probes at once", maxRunning)
+	// failures are cached as well
+	require.Equal(t, int32(len(k.Nodes)), probes)
+}
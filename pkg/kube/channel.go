@@ -0,0 +1,305 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/timeline"
+	"github.com/supergiant/control/pkg/versions"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// Events of channel notices.
+const (
+	ChannelUpgradeScheduled = "scheduled"
+	ChannelUpgradeStarted   = "started"
+	ChannelUpgradeFailed    = "failed"
+)
+
+// ChannelNotice is posted to notification routes of the cluster about patch
+// upgrades of its release channel.
+type ChannelNotice struct {
+	KubeID   string `json:"kubeId"`
+	KubeName string `json:"kubeName"`
+	Channel  string `json:"channel"`
+	Event    string `json:"event"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	// Opens is the start of the maintenance window the upgrade waits for.
+	Opens *time.Time `json:"opens,omitempty"`
+	Error string     `json:"error,omitempty"`
+}
+
+func (h *Handler) getReleaseChannel(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(k.Channel); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// setReleaseChannel subscribes the cluster to the channel of its minor
+// version or pauses its upgrades, an empty name unsubscribes the cluster.
+func (h *Handler) setReleaseChannel(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	channel := model.ReleaseChannel{}
+	if err := json.NewDecoder(r.Body).Decode(&channel); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = checkChannel(channel.Name, k.K8SVersion); err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	if channel.Name == k.Channel.Name {
+		channel.Pending = k.Channel.Pending
+	} else {
+		channel.Pending = ""
+	}
+	channel.LastUpgrade = k.Channel.LastUpgrade
+	k.Channel = channel
+
+	if err = h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(k.Channel); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// checkChannel checks the channel is in the catalog and follows the minor
+// version of the cluster, channels don't upgrade to other minor versions.
+func checkChannel(name, version string) error {
+	if name == "" {
+		return nil
+	}
+
+	minor, _, err := versions.ParseChannel(name)
+	if err != nil {
+		return err
+	}
+	if _, err = versions.Default().ChannelVersion(name); err != nil {
+		return err
+	}
+
+	current, err := semver.NewVersion(version)
+	if err != nil {
+		return errors.Wrapf(versions.ErrUnsupported, "invalid version of the kube %q", version)
+	}
+	if fmt.Sprintf("%d.%d", current.Major(), current.Minor()) != minor {
+		return errors.Wrapf(versions.ErrUnsupported, "channel %q doesn't follow kubernetes %s of the kube", name, version)
+	}
+	return nil
+}
+
+// ChannelScheduler upgrades clusters to patch versions of their release
+// channels in their maintenance windows. Upgrades are scheduled when the
+// version catalog changes and checked on every interval.
+type ChannelScheduler struct {
+	h       *Handler
+	catalog *versions.Catalog
+	upgrade func(context.Context, *model.Kube, string) (map[string]string, error)
+
+	interval time.Duration
+	changed  chan struct{}
+}
+
+func NewChannelScheduler(h *Handler, catalog *versions.Catalog) *ChannelScheduler {
+	s := &ChannelScheduler{
+		h:       h,
+		catalog: catalog,
+		// scheduled upgrades upgrade masters in place
+		upgrade: func(ctx context.Context, k *model.Kube, version string) (map[string]string, error) {
+			return h.UpgradeVersion(ctx, k, version, steps.UpgradeInPlace)
+		},
+		interval: defaultScheduleCheckInterval,
+		changed:  make(chan struct{}, 1),
+	}
+	catalog.OnChange(func(versions.Matrix) {
+		select {
+		case s.changed <- struct{}{}:
+		default:
+		}
+	})
+	return s
+}
+
+// Run checks subscribed clusters until the context is done.
+func (s *ChannelScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.changed:
+			s.check(ctx, time.Now())
+		case now := <-ticker.C:
+			s.check(ctx, now)
+		}
+	}
+}
+
+func (s *ChannelScheduler) check(ctx context.Context, now time.Time) {
+	kubes, err := s.h.svc.ListAll(ctx)
+	if err != nil {
+		logrus.Errorf("channel scheduler: list kubes: %v", err)
+		return
+	}
+
+	for i := range kubes {
+		k := &kubes[i]
+		if k.Channel.Name == "" || k.Channel.Paused || k.State != model.StateOperational {
+			continue
+		}
+		if err := s.checkKube(ctx, k, now); err != nil {
+			logrus.Errorf("channel scheduler: kube %s: %v", k.ID, err)
+		}
+	}
+}
+
+func (s *ChannelScheduler) checkKube(ctx context.Context, k *model.Kube, now time.Time) error {
+	target, err := s.catalog.ChannelVersion(k.Channel.Name)
+	if err != nil {
+		return err
+	}
+	if !newerVersion(target, k.K8SVersion) {
+		if k.Channel.Pending == "" {
+			return nil
+		}
+		k.Channel.Pending = ""
+		return s.h.svc.Create(ctx, k)
+	}
+
+	if k.Channel.Pending != target {
+		k.Channel.Pending = target
+		if err = s.h.svc.Create(ctx, k); err != nil {
+			return err
+		}
+
+		notice := s.notice(k, ChannelUpgradeScheduled, target)
+		if !k.Maintenance.Contains(now) {
+			opens := k.Maintenance.Next(now)
+			notice.Opens = &opens
+		}
+		s.h.recordEvent(ctx, &timeline.Event{
+			KubeID:  k.ID,
+			Type:    timeline.UpgradeScheduled,
+			Message: fmt.Sprintf("upgrade from %s to %s of the %s channel has been scheduled", k.K8SVersion, target, k.Channel.Name),
+		})
+		s.notify(k, notice)
+	}
+
+	if !k.Maintenance.Contains(now) {
+		return nil
+	}
+	if err = s.catalog.CheckUpgrade(k.K8SVersion, target, k.Networking.Provider); err != nil {
+		return err
+	}
+
+	// save the upgrade first, a failed upgrade is scheduled again
+	k.Channel.Pending = ""
+	k.Channel.LastUpgrade = now.Unix()
+	if err = s.h.svc.Create(ctx, k); err != nil {
+		return err
+	}
+
+	logrus.Infof("channel scheduler: upgrade kube %s from %s to %s", k.ID, k.K8SVersion, target)
+	if _, err = s.upgrade(ctx, k, target); err != nil {
+		notice := s.notice(k, ChannelUpgradeFailed, target)
+		notice.Error = err.Error()
+		s.notify(k, notice)
+		return errors.Wrapf(err, "upgrade to %s", target)
+	}
+	s.notify(k, s.notice(k, ChannelUpgradeStarted, target))
+	return nil
+}
+
+func (s *ChannelScheduler) notice(k *model.Kube, event, target string) ChannelNotice {
+	return ChannelNotice{
+		KubeID:   k.ID,
+		KubeName: k.Name,
+		Channel:  k.Channel.Name,
+		Event:    event,
+		From:     k.K8SVersion,
+		To:       target,
+	}
+}
+
+// notify posts the notice to notification routes that match the cluster.
+func (s *ChannelScheduler) notify(k *model.Kube, notice ChannelNotice) {
+	webhooks, slackWebhooks := s.h.alertTargets(k, "", "")
+	for _, u := range webhooks {
+		if err := s.h.postAlert(u, notice); err != nil {
+			logrus.Warnf("channel scheduler: kube %s: webhook: %v", k.ID, err)
+		}
+	}
+
+	if len(slackWebhooks) == 0 {
+		return
+	}
+	status := "been scheduled"
+	switch notice.Event {
+	case ChannelUpgradeStarted:
+		status = "started"
+	case ChannelUpgradeFailed:
+		status = "failed: " + notice.Error
+	}
+	text := fmt.Sprintf("Upgrade of cluster %s from %s to %s of the %s channel has %s",
+		k.Name, notice.From, notice.To, notice.Channel, status)
+	for _, u := range slackWebhooks {
+		if err := s.h.postAlert(u, map[string]string{"text": text}); err != nil {
+			logrus.Warnf("channel scheduler: kube %s: slack webhook: %v", k.ID, err)
+		}
+	}
+}
+
+// newerVersion reports whether the version a is newer than b.
+func newerVersion(a, b string) bool {
+	va, err := semver.NewVersion(a)
+	if err != nil {
+		return false
+	}
+	vb, err := semver.NewVersion(b)
+	if err != nil {
+		return false
+	}
+	return va.GreaterThan(vb)
+}
@@ -0,0 +1,106 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/settings"
+	"github.com/supergiant/control/pkg/storage/memory"
+	"github.com/supergiant/control/pkg/timeline"
+	"github.com/supergiant/control/pkg/versions"
+)
+
+func TestHandler_setReleaseChannel(t *testing.T) {
+	tcs := []struct {
+		description  string
+		body         string
+		expectedCode int
+	}{
+		{"invalid json", `{`, http.StatusBadRequest},
+		{"unknown channel", `{"name": "1.14 beta"}`, http.StatusBadRequest},
+		{"other minor version", `{"name": "1.15 stable"}`, http.StatusBadRequest},
+		{"subscribe", `{"name": "1.14 rapid"}`, http.StatusOK},
+		{"unsubscribe", `{"name": ""}`, http.StatusOK},
+	}
+
+	for _, tc := range tcs {
+		svc := new(kubeServiceMock)
+		svc.On(serviceGet, mock.Anything, "kube-1").Return(&model.Kube{ID: "kube-1", K8SVersion: "1.14.1"}, nil)
+		svc.On(serviceCreate, mock.Anything, mock.Anything).Return(nil)
+		h := &Handler{svc: svc}
+
+		router := mux.NewRouter()
+		router.HandleFunc("/kubes/{kubeID}/channel", h.setReleaseChannel)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/kubes/kube-1/channel", bytes.NewBufferString(tc.body)))
+		require.Equalf(t, tc.expectedCode, rec.Code, tc.description)
+	}
+}
+
+func TestChannelScheduler_check(t *testing.T) {
+	// saturday
+	now := time.Date(2019, time.July, 20, 3, 0, 30, 0, time.UTC)
+	matrix := versions.Builtin()
+	matrix.Releases[3].Channels = map[string]string{versions.Stable: "1.14.1"}
+	catalog := versions.NewCatalog(matrix)
+
+	kubes := []model.Kube{
+		{ID: "unsubscribed", State: model.StateOperational, K8SVersion: "1.14.1"},
+		{ID: "paused", State: model.StateOperational, K8SVersion: "1.14.1", Channel: model.ReleaseChannel{Name: "1.14 rapid", Paused: true}},
+		{ID: "stable", State: model.StateOperational, K8SVersion: "1.14.1", Channel: model.ReleaseChannel{Name: "1.14 stable"}},
+		{ID: "rapid", State: model.StateOperational, K8SVersion: "1.14.1", Channel: model.ReleaseChannel{Name: "1.14 rapid"}},
+		{
+			ID: "window", State: model.StateOperational, K8SVersion: "1.14.1",
+			Labels:      map[string]string{"env": "prod"},
+			Channel:     model.ReleaseChannel{Name: "1.14 rapid"},
+			Maintenance: model.MaintenanceWindow{Days: []string{"sun"}, Start: "02:00", End: "06:00"},
+		},
+	}
+	svc := new(kubeServiceMock)
+	svc.On(serviceListAll, mock.Anything).Return(kubes, nil)
+	svc.On(serviceCreate, mock.Anything, mock.Anything).Return(nil)
+
+	var notices []ChannelNotice
+	h := &Handler{
+		svc:      svc,
+		timeline: timeline.NewService(timeline.DefaultStoragePrefix, memory.NewInMemoryRepository()),
+		postAlert: func(url string, payload interface{}) error {
+			notices = append(notices, payload.(ChannelNotice))
+			return nil
+		},
+	}
+	h.SetNotificationRoutes([]settings.NotificationRoute{{Selector: map[string]string{"env": "prod"}, WebhookURL: "http://oncall"}})
+
+	s := NewChannelScheduler(h, catalog)
+	upgraded := map[string]string{}
+	s.upgrade = func(_ context.Context, k *model.Kube, version string) (map[string]string, error) {
+		upgraded[k.ID] = version
+		return nil, nil
+	}
+
+	s.check(context.Background(), now)
+	require.Equal(t, map[string]string{"rapid": "1.14.3"}, upgraded)
+
+	// the upgrade waits for the window, routes are notified it's scheduled
+	require.Len(t, notices, 1)
+	require.Equal(t, ChannelUpgradeScheduled, notices[0].Event)
+	require.Equal(t, "1.14.3", notices[0].To)
+	require.Equal(t, time.Date(2019, time.July, 21, 2, 0, 0, 0, time.UTC), notices[0].Opens.UTC())
+
+	// updates of the catalog trigger checks
+	require.NoError(t, catalog.Set(versions.Builtin()))
+	select {
+	case <-s.changed:
+	default:
+		t.Fatal("catalog change isn't signaled")
+	}
+}
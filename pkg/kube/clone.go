@@ -0,0 +1,197 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"gopkg.in/asaskevich/govalidator.v8"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/clusterspec"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// CloneRequest describes a copy of the cluster, the region and account
+// of the cluster are used when they are empty.
+type CloneRequest struct {
+	Name        string `json:"name" valid:"required,matches(^[A-Za-z0-9-]+$)"`
+	Region      string `json:"region" valid:"-"`
+	Zone        string `json:"zone" valid:"-"`
+	AccountName string `json:"accountName" valid:"-"`
+}
+
+// regionalSettings are cloud specific settings of resources that exist
+// in the region of the cluster only.
+var regionalSettings = []string{"vpcid", "vpccidr", "routeTableId", "internetGatewayId",
+	"nodesSecurityGroupID", "mastersSecurityGroupID", "subnets", "networkName", "subnetLink", "networkLink"}
+
+// cloneKube provisions a new cluster with the topology, profile and addons of the cluster.
+func (h *Handler) cloneKube(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	req := &CloneRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+	if ok, err := govalidator.ValidateStruct(req); !ok {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	p, err := h.profileSvc.Get(r.Context(), k.ProfileID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, k.ProfileID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	kubes, err := h.svc.ListAll(r.Context())
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+	for _, existing := range kubes {
+		if existing.Name == req.Name {
+			message.SendAlreadyExists(w, req.Name, sgerrors.ErrAlreadyExists)
+			return
+		}
+	}
+
+	if req.AccountName != "" && req.AccountName != k.AccountName {
+		acc, err := h.accountService.Get(r.Context(), req.AccountName)
+		if err != nil && !sgerrors.IsNotFound(err) {
+			message.SendUnknownError(w, err)
+			return
+		}
+		// accounts of other teams look like missing ones
+		if err != nil || !api.ScopeFrom(r.Context()).AllowsLabeled(acc.Team, acc.Labels) {
+			message.SendValidationFailed(w, fmt.Errorf("%s account not found", req.AccountName))
+			return
+		}
+		if acc.Provider != p.Provider {
+			message.SendValidationFailed(w, errors.Errorf("%s account is for %s, the cluster runs on %s",
+				acc.Name, acc.Provider, p.Provider))
+			return
+		}
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryRun"))
+	res, err := h.createFromSpec(r.Context(), cloneSpec(k, p, req), dryRun)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, req.Name, err)
+			return
+		}
+		if errors.Cause(err) == api.ErrTeamNotAllowed {
+			message.SendValidationFailed(w, err)
+			return
+		}
+		if sgerrors.IsQuotaExceeded(err) {
+			message.SendQuotaExceeded(w, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if !dryRun {
+		w.WriteHeader(http.StatusAccepted)
+	}
+	if err = json.NewEncoder(w).Encode(res); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// cloneSpec describes a new cluster with node groups of the cluster machines
+// and its profile. Credentials of the cluster aren't copied, resources of
+// the cluster region are dropped when the clone is created in another region.
+func cloneSpec(k *model.Kube, p *profile.Profile, req *CloneRequest) *clusterspec.Cluster {
+	c := clusterspec.FromKube(k, p)
+	c.Metadata = clusterspec.Metadata{Name: req.Name}
+	if req.AccountName != "" {
+		c.Spec.Account = req.AccountName
+	}
+
+	spec := *p
+	spec.ID = ""
+	spec.MasterProfiles = nil
+	spec.NodesProfiles = nil
+	spec.StaticAuth = profile.StaticAuth{}
+	spec.User = ""
+	spec.Password = ""
+	spec.ExternalCA.CSRID = ""
+	spec.ExternalCA.SignedCert = ""
+	// clones belong to the team of the cluster
+	spec.Team = k.Team
+	if req.Zone != "" {
+		spec.Zone = req.Zone
+	}
+
+	if req.Region != "" && req.Region != p.Region {
+		spec.Region = req.Region
+		spec.Zone = req.Zone
+		spec.Subnets = nil
+
+		settings := make(profile.CloudSpecificSettings, len(p.CloudSpecificSettings))
+		for key, value := range p.CloudSpecificSettings {
+			settings[key] = value
+		}
+		for _, key := range regionalSettings {
+			delete(settings, key)
+		}
+		spec.CloudSpecificSettings = settings
+
+		for i := range c.Spec.NodeGroups {
+			c.Spec.NodeGroups[i].Machine = regionalMachine(c.Spec.NodeGroups[i].Machine, p.Region, req.Region)
+		}
+	}
+	c.Spec.Profile = spec
+
+	return c
+}
+
+// regionalMachine moves the machine profile to the region, zones keep their
+// suffixes, e.g. us-east-1a becomes eu-west-1a, and images are looked up
+// in the new region.
+func regionalMachine(np profile.NodeProfile, from, to string) profile.NodeProfile {
+	moved := make(profile.NodeProfile, len(np))
+	for key, value := range np {
+		switch key {
+		case "region", "location":
+			value = to
+		case "availabilityZone":
+			if from != "" && strings.HasPrefix(value, from) {
+				value = to + strings.TrimPrefix(value, from)
+			} else {
+				continue
+			}
+		case "image", "windowsImage":
+			continue
+		}
+		moved[key] = value
+	}
+
+	return moved
+}
@@ -0,0 +1,152 @@
+package kube
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage/memory"
+	"github.com/supergiant/control/pkg/workflows"
+)
+
+func TestHandler_cloneKube(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		url            string
+		body           string
+		scope          *api.Scope
+		expectedStatus int
+	}{
+		{"no name", "/kubes/kube-1/clone", `{}`, nil, http.StatusBadRequest},
+		{"name taken", "/kubes/kube-1/clone", `{"name":"test"}`, nil, http.StatusConflict},
+		{"not found", "/kubes/kube-2/clone", `{"name":"staging"}`, nil, http.StatusNotFound},
+		{"other provider", "/kubes/kube-1/clone", `{"name":"staging","accountName":"aws"}`, nil, http.StatusBadRequest},
+		{"other team account", "/kubes/kube-1/clone?dryRun=true", `{"name":"staging","accountName":"do-b"}`,
+			&api.Scope{Teams: []string{"team-a"}}, http.StatusBadRequest},
+		{"team account", "/kubes/kube-1/clone?dryRun=true", `{"name":"staging","accountName":"do-b"}`,
+			&api.Scope{Teams: []string{"team-b"}}, http.StatusOK},
+		{"dry run", "/kubes/kube-1/clone?dryRun=true", `{"name":"staging"}`, nil, http.StatusOK},
+		{"clone", "/kubes/kube-1/clone", `{"name":"staging"}`, nil, http.StatusAccepted},
+	} {
+		k := specKube()
+		k.Addons = []string{"dashboard"}
+		svc := new(kubeServiceMock)
+		svc.On("Get", mock.Anything, "kube-1").Return(k, nil)
+		svc.On("Get", mock.Anything, "kube-2").Return(nil, sgerrors.ErrNotFound)
+		svc.On("ListAll", mock.Anything).Return([]model.Kube{*k}, nil)
+		accounts := new(accServiceMock)
+		accounts.On("Get", mock.Anything, "do").Return(&model.CloudAccount{
+			Name:        "do",
+			Provider:    clouds.DigitalOcean,
+			Credentials: map[string]string{},
+		}, nil)
+		accounts.On("Get", mock.Anything, "do-b").Return(&model.CloudAccount{
+			Name:     "do-b",
+			Provider: clouds.DigitalOcean,
+			Team:     "team-b",
+		}, nil)
+		accounts.On("Get", mock.Anything, "aws").Return(&model.CloudAccount{
+			Name:     "aws",
+			Provider: clouds.AWS,
+		}, nil)
+		profiles := new(mockProfileService)
+		profiles.On("Get", mock.Anything, "profile-1").Return(&profile.Profile{
+			Provider:  clouds.DigitalOcean,
+			Region:    "fra1",
+			PublicKey: "ssh-rsa key",
+			User:      "admin",
+			Password:  "secret",
+			MasterProfiles: []profile.NodeProfile{
+				{"size": "s-2vcpu-4gb"},
+			},
+			NodesProfiles: []profile.NodeProfile{
+				{"size": "s-2vcpu-4gb"},
+			},
+		}, nil)
+		profiles.On("Create", mock.Anything, mock.Anything).Return(nil)
+		provisioner := new(mockProvisioner)
+		provisioner.On("ProvisionCluster", mock.Anything, mock.Anything, mock.Anything).
+			Return(map[string][]*workflows.Task{
+				"master": {{ID: "task-1"}},
+			}, nil)
+		h := NewHandler(svc, accounts, profiles, nil, provisioner, nil, memory.NewInMemoryRepository(), nil, "")
+
+		router := mux.NewRouter()
+		h.Register(router)
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, tc.url, strings.NewReader(tc.body))
+		if tc.scope != nil {
+			req = req.WithContext(api.WithScope(req.Context(), *tc.scope))
+		}
+		router.ServeHTTP(rr, req)
+		require.Equal(t, tc.expectedStatus, rr.Code, tc.name+": "+rr.Body.String())
+		if tc.expectedStatus != http.StatusAccepted {
+			continue
+		}
+
+		res := &SpecResult{}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(res))
+		require.True(t, res.Created, tc.name)
+		require.Equal(t, []string{"task-1"}, res.Tasks["master"], tc.name)
+
+		p := provisioner.Calls[0].Arguments.Get(1).(*profile.Profile)
+		require.Len(t, p.MasterProfiles, 1, tc.name)
+		require.Len(t, p.NodesProfiles, 1, tc.name)
+		require.Equal(t, []string{"dashboard"}, p.Addons, tc.name)
+		require.Equal(t, "ssh-rsa key", p.PublicKey, tc.name)
+		require.Empty(t, p.Password, tc.name)
+	}
+}
+
+func TestCloneSpecRegion(t *testing.T) {
+	k := &model.Kube{
+		Name:        "prod",
+		AccountName: "aws",
+		Masters: map[string]*model.Machine{
+			"master-1": {Name: "master-1", Role: model.RoleMaster, Size: "m4.large"},
+		},
+	}
+	p := &profile.Profile{
+		Provider: clouds.AWS,
+		Region:   "us-east-1",
+		Zone:     "us-east-1a",
+		CloudSpecificSettings: profile.CloudSpecificSettings{
+			"vpcid":          "vpc-1",
+			"keypairName":    "prod",
+			"mastersVolSize": "50",
+		},
+		MasterProfiles: []profile.NodeProfile{{
+			"size":             "m4.large",
+			"availabilityZone": "us-east-1b",
+			"image":            "ami-1",
+		}},
+	}
+
+	c := cloneSpec(k, p, &CloneRequest{Name: "staging", Region: "eu-west-1"})
+	require.Equal(t, "staging", c.Metadata.Name)
+	require.Equal(t, "aws", c.Spec.Account)
+	require.Equal(t, "eu-west-1", c.Spec.Profile.Region)
+	require.Empty(t, c.Spec.Profile.Zone)
+	require.Equal(t, profile.CloudSpecificSettings{
+		"keypairName":    "prod",
+		"mastersVolSize": "50",
+	}, c.Spec.Profile.CloudSpecificSettings)
+	require.Equal(t, profile.NodeProfile{
+		"size":             "m4.large",
+		"availabilityZone": "eu-west-1b",
+	}, c.Spec.NodeGroups[0].Machine)
+	// the source profile is left as is
+	require.Equal(t, "vpc-1", p.CloudSpecificSettings["vpcid"])
+	require.Equal(t, "ami-1", p.MasterProfiles[0]["image"])
+}
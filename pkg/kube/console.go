@@ -0,0 +1,208 @@
+package kube
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/clouds/gcesdk"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/redact"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/statuses"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	// consoleDiagnostic is the name of the console output in diagnostics of
+	// the task.
+	consoleDiagnostic = "console"
+	// maxConsoleOutput is how much of the end of the output is kept, the
+	// failure of a bootstrap is at the end.
+	maxConsoleOutput = 64 << 10
+)
+
+// ConsoleOutput is the output of the machine console collected from its
+// cloud provider, it works for machines that can't be reached with ssh.
+type ConsoleOutput struct {
+	Machine    string `json:"machine"`
+	InstanceID string `json:"instanceId"`
+	Output     string `json:"output"`
+	// TaskID is set when the output has been attached to diagnostics of
+	// the failed task of the machine.
+	TaskID      string    `json:"taskId,omitempty"`
+	CollectedAt time.Time `json:"collectedAt"`
+}
+
+// getConsoleOutput returns the console output of the machine, the output is
+// attached to the task that has created the machine when the task has failed.
+func (h *Handler) getConsoleOutput(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID, nodeName := vars["kubeID"], vars["nodename"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	m := k.Masters[nodeName]
+	if m == nil {
+		m = k.Nodes[nodeName]
+	}
+	if m == nil {
+		message.SendNotFound(w, nodeName, sgerrors.ErrNotFound)
+		return
+	}
+
+	acc, err := h.accountService.Get(r.Context(), k.AccountName)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, k.AccountName, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	output, err := h.consoleOutput(r.Context(), k, acc, m)
+	if err != nil {
+		switch cause := errors.Cause(err); {
+		case sgerrors.IsUnsupportedProvider(cause):
+			message.SendValidationFailed(w, err)
+		case cause == sgerrors.ErrInvalidCredentials:
+			message.SendInvalidCredentials(w, err)
+		case sgerrors.IsNotFound(cause):
+			message.SendNotFound(w, nodeName, err)
+		default:
+			logrus.Errorf("kubes: %s cluster: console output of %s: %v", kubeID, nodeName, err)
+			message.SendUnknownError(w, err)
+		}
+		return
+	}
+
+	resp := ConsoleOutput{
+		Machine:     m.Name,
+		InstanceID:  m.ID,
+		Output:      redact.String(tail(output, maxConsoleOutput)),
+		CollectedAt: time.Now().UTC(),
+	}
+
+	if failed, err := h.isFailedTask(r.Context(), m.TaskID); err != nil {
+		logrus.Warnf("kubes: %s cluster: get task %s of %s: %v", kubeID, m.TaskID, nodeName, err)
+	} else if failed {
+		err = workflows.AttachDiagnostic(r.Context(), h.repo, m.TaskID, workflows.Diagnostic{
+			Name:        consoleDiagnostic,
+			Content:     resp.Output,
+			CollectedAt: resp.CollectedAt,
+		})
+		if err != nil {
+			logrus.Warnf("kubes: %s cluster: attach console output to task %s: %v", kubeID, m.TaskID, err)
+		} else {
+			resp.TaskID = m.TaskID
+		}
+	}
+
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// isFailedTask reports whether the stored task has failed, machines that
+// have been added before tasks were recorded with them have no task.
+func (h *Handler) isFailedTask(ctx context.Context, taskID string) (bool, error) {
+	if taskID == "" {
+		return false, nil
+	}
+
+	data, err := h.repo.Get(ctx, workflows.Prefix, taskID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	task := workflows.Task{}
+	if err = json.Unmarshal(data, &task); err != nil {
+		return false, err
+	}
+	return task.Status == statuses.Error, nil
+}
+
+// consoleOutputOf reads the console output of the instance of the machine,
+// the serial port 1 is read on GCE.
+func consoleOutputOf(ctx context.Context, k *model.Kube, acc *model.CloudAccount, m *model.Machine) (string, error) {
+	switch k.Provider {
+	case clouds.AWS:
+		if m.ID == "" {
+			return "", errors.Wrapf(sgerrors.ErrNotFound, "instance of machine %s", m.Name)
+		}
+		svc, err := ec2For(k, acc)
+		if err != nil {
+			return "", err
+		}
+		out, err := svc.GetConsoleOutputWithContext(ctx, &ec2.GetConsoleOutputInput{
+			InstanceId: aws.String(m.ID),
+		})
+		if err != nil {
+			return "", errors.Wrapf(err, "get console output of %s", m.ID)
+		}
+		data, err := base64.StdEncoding.DecodeString(aws.StringValue(out.Output))
+		if err != nil {
+			return "", errors.Wrapf(err, "decode console output of %s", m.ID)
+		}
+		return string(data), nil
+	case clouds.GCE:
+		config := &steps.Config{}
+		if err := util.FillCloudAccountCredentials(acc, config); err != nil {
+			return "", errors.Wrap(err, "fill cloud account credentials")
+		}
+		svc, err := gcesdk.GetClient(ctx, config.GCEConfig)
+		if err != nil {
+			return "", errors.Wrap(sgerrors.ErrInvalidCredentials, err.Error())
+		}
+		// region of gce machines is the zone
+		zone := m.AvailabilityZone
+		if zone == "" {
+			zone = m.Region
+		}
+		out, err := svc.Instances.GetSerialPortOutput(config.GCEConfig.ProjectID, zone, m.Name).
+			Port(1).Context(ctx).Do()
+		if err != nil {
+			return "", errors.Wrapf(err, "get serial port output of %s", m.Name)
+		}
+		return out.Contents, nil
+	}
+
+	return "", errors.Wrapf(sgerrors.ErrUnsupportedProvider, "%s", k.Provider)
+}
+
+// tail returns the end of the output from the first whole line.
+func tail(output string, max int) string {
+	if len(output) <= max {
+		return output
+	}
+	output = output[len(output)-max:]
+	if i := strings.IndexByte(output, '\n'); i >= 0 {
+		output = output[i+1:]
+	}
+	return output
+}
@@ -0,0 +1,99 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage/memory"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/statuses"
+)
+
+func TestHandler_getConsoleOutput(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewInMemoryRepository()
+
+	k := specKube()
+	k.Nodes["node-1"].ID = "i-1"
+	k.Nodes["node-1"].TaskID = "task-1"
+	k.Masters["master-1"].TaskID = "task-2"
+	svc := new(kubeServiceMock)
+	svc.On("Get", mock.Anything, "kube-1").Return(k, nil)
+	svc.On("Get", mock.Anything, mock.Anything).Return(nil, sgerrors.ErrNotFound)
+	accounts := new(accServiceMock)
+	accounts.On("Get", mock.Anything, "do").Return(&model.CloudAccount{Provider: clouds.DigitalOcean}, nil)
+	h := NewHandler(svc, accounts, nil, nil, nil, nil, repo, nil, "")
+
+	for id, status := range map[string]statuses.Status{"task-1": statuses.Error, "task-2": statuses.Success} {
+		task, err := json.Marshal(workflows.Task{ID: id, Type: workflows.NodeTask, Status: status})
+		require.NoError(t, err)
+		require.NoError(t, repo.Put(ctx, workflows.Prefix, id, task))
+	}
+
+	h.consoleOutput = func(_ context.Context, _ *model.Kube, _ *model.CloudAccount, m *model.Machine) (string, error) {
+		if m.Name == "master-1" {
+			return "", errors.Wrapf(sgerrors.ErrUnsupportedProvider, "%s", clouds.DigitalOcean)
+		}
+		return "cloud-init: password=hunter2\n[FAILED] kubelet\n", nil
+	}
+
+	router := mux.NewRouter()
+	h.Register(router)
+	do := func(url string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, url, nil))
+		return rr
+	}
+
+	rr := do("/kubes/kube-1/machines/node-1/console")
+	require.Equal(t, http.StatusOK, rr.Code)
+	out := ConsoleOutput{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&out))
+	require.Equal(t, "node-1", out.Machine)
+	require.Equal(t, "i-1", out.InstanceID)
+	require.Equal(t, "task-1", out.TaskID)
+	require.Contains(t, out.Output, "[FAILED] kubelet")
+	require.NotContains(t, out.Output, "hunter2")
+
+	data, err := repo.Get(ctx, workflows.Prefix, "task-1")
+	require.NoError(t, err)
+	task := workflows.Task{}
+	require.NoError(t, json.Unmarshal(data, &task))
+	require.Len(t, task.Diagnostics, 1)
+	require.Equal(t, "console", task.Diagnostics[0].Name)
+	require.Equal(t, out.Output, task.Diagnostics[0].Content)
+
+	// unsupported providers and unknown machines
+	require.Equal(t, http.StatusBadRequest, do("/kubes/kube-1/machines/master-1/console").Code)
+	require.Equal(t, http.StatusNotFound, do("/kubes/kube-1/machines/node-2/console").Code)
+	require.Equal(t, http.StatusNotFound, do("/kubes/kube-2/machines/node-1/console").Code)
+
+	// only failed tasks get diagnostics
+	k.Masters["master-1"].ID = "i-2"
+	h.consoleOutput = func(context.Context, *model.Kube, *model.CloudAccount, *model.Machine) (string, error) {
+		return "ok", nil
+	}
+	rr = do("/kubes/kube-1/machines/master-1/console")
+	require.Equal(t, http.StatusOK, rr.Code)
+	out = ConsoleOutput{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&out))
+	require.Empty(t, out.TaskID)
+}
+
+func TestTail(t *testing.T) {
+	require.Equal(t, "short", tail("short", 10))
+	require.Equal(t, "line 3\n", tail("line 1\nline 2\nline 3\n", 10))
+	require.Equal(t, strings.Repeat("x", 4), tail(strings.Repeat("x", 8), 4))
+}
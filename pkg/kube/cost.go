@@ -0,0 +1,41 @@
+package kube
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// getCost reports a monthly cost of cluster machines grouped by role and size
+// along with the amount they have cost so far.
+func (h *Handler) getCost(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	est, err := h.estimateCost(r.Context(), k)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, string(k.Provider), err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(est); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
@@ -0,0 +1,51 @@
+package kube
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/pricing"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+func TestHandler_getCost(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		provider       clouds.Name
+		getErr         error
+		expectedStatus int
+	}{
+		{"kube not found", clouds.AWS, sgerrors.ErrNotFound, http.StatusNotFound},
+		{"no price catalog", clouds.OpenStack, nil, http.StatusNotFound},
+		{"success", clouds.DigitalOcean, nil, http.StatusOK},
+	} {
+		k := &model.Kube{
+			ID:       "kube-1",
+			Provider: tc.provider,
+			Nodes: map[string]*model.Machine{
+				"node-1": {Name: "node-1", Role: model.RoleNode, Size: "s-2vcpu-4gb"},
+			},
+		}
+		svc := new(kubeServiceMock)
+		svc.On("Get", mock.Anything, "kube-1").Return(k, tc.getErr)
+		h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+		rr := metricsRequest(h, "/kubes/kube-1/cost")
+		require.Equal(t, tc.expectedStatus, rr.Code, tc.name)
+		if tc.expectedStatus != http.StatusOK {
+			continue
+		}
+
+		est := &pricing.Estimate{}
+		require.Nil(t, json.NewDecoder(rr.Body).Decode(est))
+		require.Len(t, est.Groups, 1)
+		require.Equal(t, 1, est.Groups[0].Count)
+		require.NotZero(t, est.MonthlyCost)
+	}
+}
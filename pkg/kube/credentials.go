@@ -0,0 +1,333 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	rbacv1client "k8s.io/client-go/kubernetes/typed/rbac/v1"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/kubeconfig"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/pki"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/user"
+)
+
+const (
+	defaultKubeconfigTTL = 8 * time.Hour
+	maxKubeconfigTTL     = 24 * time.Hour
+
+	// credentialGroupPrefix prefixes a kubernetes group that is unique for
+	// every issued certificate, deleting its binding revokes the certificate.
+	credentialGroupPrefix = "supergiant:kubeconfig:"
+)
+
+// clusterRoles maps control user roles to default kubernetes cluster roles.
+var clusterRoles = map[string]string{
+	user.RoleAdmin: "cluster-admin",
+	user.RoleEdit:  "edit",
+	user.RoleView:  "view",
+}
+
+// issueKubeconfig mints a client certificate for the requesting user
+// with permissions of the user role.
+func (h *Handler) issueKubeconfig(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+
+	id, ok := api.IdentityFrom(r.Context())
+	if !ok {
+		http.Error(w, "unknown user", http.StatusForbidden)
+		return
+	}
+
+	ttl := defaultKubeconfigTTL
+	if v := r.URL.Query().Get("ttl"); v != "" {
+		var err error
+		if ttl, err = time.ParseDuration(v); err != nil || ttl <= 0 || ttl > maxKubeconfigTTL {
+			message.SendValidationFailed(w, errors.Errorf("ttl must be a duration up to %s", maxKubeconfigTTL))
+			return
+		}
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	// imported clusters don't share the CA key with control
+	if k.Auth.CAKey == "" {
+		message.SendNotFound(w, "cluster CA key", sgerrors.ErrNotFound)
+		return
+	}
+
+	cred := &KubeconfigCredential{
+		ID:       uuid.New(),
+		KubeID:   k.ID,
+		User:     id.Login,
+		Role:     identityRole(id),
+		IssuedAt: time.Now().UTC(),
+	}
+	cred.ExpiresAt = cred.IssuedAt.Add(ttl)
+	group := credentialGroupPrefix + cred.ID
+
+	pair, err := pki.NewUserPairWithTTL(id.Login, []string{group}, ttl, &pki.PairPEM{
+		Cert: []byte(k.Auth.CACert),
+		Key:  []byte(k.Auth.CAKey),
+	})
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	cert, err := pki.Decode(pair)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+	cred.Serial = cert.Cert.SerialNumber.String()
+
+	err = h.bindClusterRole(k, &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: group,
+			Labels: map[string]string{
+				"supergiant.io/user": id.Login,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     clusterRoles[cred.Role],
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				APIGroup: rbacv1.GroupName,
+				Kind:     rbacv1.GroupKind,
+				Name:     group,
+			},
+		},
+	})
+	if err != nil {
+		message.SendUnknownError(w, errors.Wrap(err, "bind cluster role"))
+		return
+	}
+
+	if err := h.saveCredential(r, cred); err != nil {
+		if err := h.unbindClusterRole(k, group); err != nil {
+			logrus.Errorf("kubes: %s cluster: delete binding %s: %v", kubeID, group, err)
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	cfg, err := kubeconfig.UserKubeConfig(k, id.Login, pair.Cert, pair.Key)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	data, err := encodeKubeconfig(cfg)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	w.Header().Set("Kubeconfig-Id", cred.ID)
+	if _, err = w.Write(data); err != nil {
+		logrus.Errorf("kubes: %s cluster: issue kubeconfig: write response: %s", kubeID, err)
+	}
+}
+
+// listKubeconfigs returns credentials issued for a cluster including revoked ones,
+// users other than admins see only their own credentials.
+func (h *Handler) listKubeconfigs(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+
+	id, ok := api.IdentityFrom(r.Context())
+	if !ok {
+		http.Error(w, "unknown user", http.StatusForbidden)
+		return
+	}
+
+	data, err := h.repo.GetAll(r.Context(), credentialsPrefix(kubeID))
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	creds := make([]*KubeconfigCredential, 0, len(data))
+	for _, raw := range data {
+		cred := &KubeconfigCredential{}
+		if err := json.Unmarshal(raw, cred); err != nil {
+			message.SendUnknownError(w, err)
+			return
+		}
+
+		if identityRole(id) == user.RoleAdmin || cred.User == id.Login {
+			creds = append(creds, cred)
+		}
+	}
+
+	sort.Slice(creds, func(i, j int) bool {
+		return creds[i].IssuedAt.Before(creds[j].IssuedAt)
+	})
+
+	if err = json.NewEncoder(w).Encode(creds); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// revokeKubeconfig removes permissions of an issued certificate.
+func (h *Handler) revokeKubeconfig(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+	credID := vars["credentialID"]
+
+	id, ok := api.IdentityFrom(r.Context())
+	if !ok {
+		http.Error(w, "unknown user", http.StatusForbidden)
+		return
+	}
+
+	raw, err := h.repo.Get(r.Context(), credentialsPrefix(kubeID), credID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, credID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	cred := &KubeconfigCredential{}
+	if err := json.Unmarshal(raw, cred); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if identityRole(id) != user.RoleAdmin && cred.User != id.Login {
+		http.Error(w, "revoke credentials of another user not allowed", http.StatusForbidden)
+		return
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := h.unbindClusterRole(k, credentialGroupPrefix+cred.ID); err != nil {
+		message.SendUnknownError(w, errors.Wrap(err, "delete cluster role binding"))
+		return
+	}
+
+	cred.Revoked = true
+	if err := h.saveCredential(r, cred); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(cred); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) saveCredential(r *http.Request, cred *KubeconfigCredential) error {
+	data, err := json.Marshal(cred)
+	if err != nil {
+		return errors.Wrap(err, "marshal")
+	}
+
+	return h.repo.Put(r.Context(), credentialsPrefix(cred.KubeID), cred.ID, data)
+}
+
+func credentialsPrefix(kubeID string) string {
+	return KubeconfigStoragePrefix + kubeID + "/"
+}
+
+func identityRole(id api.Identity) string {
+	u := user.User{Role: id.Role}
+	return u.GetRole()
+}
+
+// secretsVisible reports whether the request may see keys and credentials
+// of clusters, they are shown to admins only.
+func secretsVisible(ctx context.Context) bool {
+	id, ok := api.IdentityFrom(ctx)
+	return ok && identityRole(id) == user.RoleAdmin
+}
+
+// withoutSecrets returns a copy of the kube without keys of its certificate
+// authorities, the admin credentials and credentials of machines, users get
+// credentials of their role with issueKubeconfig.
+func withoutSecrets(k *model.Kube) *model.Kube {
+	c := *k
+	c.Password = ""
+	c.BootstrapToken = ""
+	c.Auth = model.Auth{
+		Username:   k.Auth.Username,
+		ParentCert: k.Auth.ParentCert,
+		CACert:     k.Auth.CACert,
+		CACertHash: k.Auth.CACertHash,
+		EtcdCACert: k.Auth.EtcdCACert,
+	}
+	c.SSHConfig.BootstrapPrivateKey = ""
+	c.SSHConfig.SudoPassword = ""
+	c.WinRMConfig.Password = ""
+	c.StatusPage.TokenHash = ""
+	return &c
+}
+
+func bindClusterRole(k *model.Kube, binding *rbacv1.ClusterRoleBinding) error {
+	c, err := rbacClient(k)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.ClusterRoleBindings().Create(binding)
+	return err
+}
+
+func unbindClusterRole(k *model.Kube, name string) error {
+	c, err := rbacClient(k)
+	if err != nil {
+		return err
+	}
+
+	err = c.ClusterRoleBindings().Delete(name, &metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func rbacClient(k *model.Kube) (rbacv1client.RbacV1Interface, error) {
+	cfg, err := kubeconfig.NewConfigFor(k)
+	if err != nil {
+		return nil, errors.Wrap(err, "build kubernetes rest config")
+	}
+
+	return rbacv1client.NewForConfig(cfg)
+}
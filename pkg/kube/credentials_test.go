@@ -0,0 +1,249 @@
+package kube
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/pki"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/user"
+)
+
+func TestHandler_issueKubeconfig(t *testing.T) {
+	ca, err := pki.NewCAPair(nil)
+	require.Nil(t, err, "create CA")
+
+	tcs := []struct {
+		identity *api.Identity
+		ttl      string
+
+		serviceKube  *model.Kube
+		serviceError error
+		bindError    error
+
+		expectedStatus  int
+		expectedErrCode sgerrors.ErrorCode
+		expectedRole    string
+	}{
+		{ // TC#1
+			expectedStatus: http.StatusForbidden,
+		},
+		{ // TC#2
+			identity:        &api.Identity{Login: "user"},
+			ttl:             "720h",
+			expectedStatus:  http.StatusBadRequest,
+			expectedErrCode: sgerrors.ValidationFailed,
+		},
+		{ // TC#3
+			identity:        &api.Identity{Login: "user"},
+			serviceError:    sgerrors.ErrNotFound,
+			expectedStatus:  http.StatusNotFound,
+			expectedErrCode: sgerrors.NotFound,
+		},
+		{ // TC#4
+			identity:        &api.Identity{Login: "user"},
+			serviceKube:     &model.Kube{ID: "imported"},
+			expectedStatus:  http.StatusNotFound,
+			expectedErrCode: sgerrors.NotFound,
+		},
+		{ // TC#5
+			identity: &api.Identity{Login: "user", Role: user.RoleView},
+			serviceKube: &model.Kube{
+				ID:              "kube",
+				ExternalDNSName: "kube.example.com",
+				APIServerPort:   443,
+				Auth: model.Auth{
+					CACert: string(ca.Cert),
+					CAKey:  string(ca.Key),
+				},
+			},
+			bindError:      sgerrors.ErrNotFound,
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{ // TC#6
+			identity: &api.Identity{Login: "user", Role: user.RoleView},
+			ttl:      "1h",
+			serviceKube: &model.Kube{
+				ID:              "kube",
+				Name:            "kube",
+				ExternalDNSName: "kube.example.com",
+				APIServerPort:   443,
+				Auth: model.Auth{
+					CACert: string(ca.Cert),
+					CAKey:  string(ca.Key),
+				},
+			},
+			expectedStatus: http.StatusOK,
+			expectedRole:   "view",
+		},
+	}
+
+	for i, tc := range tcs {
+		svc := new(kubeServiceMock)
+		svc.On(serviceGet, mock.Anything, mock.Anything).Return(tc.serviceKube, tc.serviceError)
+
+		repo := new(testutils.MockStorage)
+		repo.On(testutils.StoragePut, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+		h := NewHandler(svc, nil, nil, nil, nil, nil, repo, nil, "")
+
+		var role string
+		h.bindClusterRole = func(_ *model.Kube, b *rbacv1.ClusterRoleBinding) error {
+			role = b.RoleRef.Name
+			return tc.bindError
+		}
+
+		req, err := http.NewRequest(http.MethodGet, "/kubes/kube/kubeconfig?ttl="+tc.ttl, nil)
+		require.Equalf(t, nil, err, "TC#%d: create request: %v", i+1, err)
+		if tc.identity != nil {
+			req = req.WithContext(api.WithIdentity(req.Context(), *tc.identity))
+		}
+		rr := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		h.Register(router)
+		router.ServeHTTP(rr, req)
+
+		require.Equalf(t, tc.expectedStatus, rr.Code, "TC#%d", i+1)
+
+		if tc.expectedErrCode != sgerrors.ErrorCode(0) {
+			m := new(message.Message)
+			err = json.NewDecoder(rr.Body).Decode(m)
+			require.Equalf(t, nil, err, "TC#%d", i+1)
+			require.Equalf(t, tc.expectedErrCode, m.ErrorCode, "TC#%d", i+1)
+		}
+
+		if tc.expectedStatus == http.StatusOK {
+			require.Equalf(t, tc.expectedRole, role, "TC#%d", i+1)
+			require.NotEmptyf(t, rr.Header().Get("Kubeconfig-Id"), "TC#%d", i+1)
+
+			cfg, err := clientcmd.Load(rr.Body.Bytes())
+			require.Equalf(t, nil, err, "TC#%d: load kubeconfig", i+1)
+			require.Equalf(t, "user@kube", cfg.CurrentContext, "TC#%d", i+1)
+		}
+	}
+}
+
+func TestHandler_revokeKubeconfig(t *testing.T) {
+	cred, err := json.Marshal(&KubeconfigCredential{
+		ID:     "cred",
+		KubeID: "kube",
+		User:   "owner",
+		Role:   user.RoleEdit,
+	})
+	require.Nil(t, err)
+
+	tcs := []struct {
+		identity  *api.Identity
+		repoData  []byte
+		repoError error
+
+		expectedStatus int
+		expectUnbind   bool
+	}{
+		{ // TC#1
+			expectedStatus: http.StatusForbidden,
+		},
+		{ // TC#2
+			identity:       &api.Identity{Login: "owner"},
+			repoError:      sgerrors.ErrNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{ // TC#3
+			identity:       &api.Identity{Login: "other", Role: user.RoleEdit},
+			repoData:       cred,
+			expectedStatus: http.StatusForbidden,
+		},
+		{ // TC#4
+			identity:       &api.Identity{Login: "owner", Role: user.RoleEdit},
+			repoData:       cred,
+			expectedStatus: http.StatusOK,
+			expectUnbind:   true,
+		},
+		{ // TC#5
+			identity:       &api.Identity{Login: "root", Role: user.RoleAdmin},
+			repoData:       cred,
+			expectedStatus: http.StatusOK,
+			expectUnbind:   true,
+		},
+	}
+
+	for i, tc := range tcs {
+		svc := new(kubeServiceMock)
+		svc.On(serviceGet, mock.Anything, mock.Anything).Return(&model.Kube{ID: "kube"}, nil)
+
+		repo := new(testutils.MockStorage)
+		repo.On(testutils.StorageGet, mock.Anything, mock.Anything, mock.Anything).Return(tc.repoData, tc.repoError)
+		repo.On(testutils.StoragePut, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+		h := NewHandler(svc, nil, nil, nil, nil, nil, repo, nil, "")
+
+		var unbound string
+		h.unbindClusterRole = func(_ *model.Kube, name string) error {
+			unbound = name
+			return nil
+		}
+
+		req, err := http.NewRequest(http.MethodDelete, "/kubes/kube/kubeconfigs/cred", nil)
+		require.Equalf(t, nil, err, "TC#%d: create request: %v", i+1, err)
+		if tc.identity != nil {
+			req = req.WithContext(api.WithIdentity(req.Context(), *tc.identity))
+		}
+		rr := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		h.Register(router)
+		router.ServeHTTP(rr, req)
+
+		require.Equalf(t, tc.expectedStatus, rr.Code, "TC#%d", i+1)
+
+		if tc.expectUnbind {
+			require.Equalf(t, credentialGroupPrefix+"cred", unbound, "TC#%d", i+1)
+
+			got := new(KubeconfigCredential)
+			err = json.NewDecoder(rr.Body).Decode(got)
+			require.Equalf(t, nil, err, "TC#%d", i+1)
+			require.Truef(t, got.Revoked, "TC#%d", i+1)
+		}
+	}
+}
+
+func TestHandler_getKubeSecrets(t *testing.T) {
+	k := specKube()
+	k.Auth = model.Auth{CACert: "ca-cert", CAKey: "ca-key", AdminCert: "admin-cert", AdminKey: "admin-key", EtcdCAKey: "etcd-key"}
+	k.SSHConfig.BootstrapPrivateKey = "ssh-key"
+	svc := new(kubeServiceMock)
+	svc.On(serviceGet, mock.Anything, "kube-1").Return(k, nil)
+	h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+	router := mux.NewRouter()
+	h.Register(router)
+	for role, visible := range map[string]bool{user.RoleAdmin: true, user.RoleEdit: false, user.RoleView: false} {
+		req := httptest.NewRequest(http.MethodGet, "/kubes/kube-1", nil)
+		req = req.WithContext(api.WithIdentity(req.Context(), api.Identity{Login: "alice", Role: role}))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code, role)
+
+		got := model.Kube{}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		require.Equal(t, "ca-cert", got.Auth.CACert, role)
+		for _, secret := range []string{got.Auth.CAKey, got.Auth.AdminCert, got.Auth.AdminKey, got.Auth.EtcdCAKey, got.SSHConfig.BootstrapPrivateKey} {
+			require.Equal(t, visible, secret != "", role)
+		}
+	}
+	// the stored kube keeps its secrets
+	require.Equal(t, "ca-key", k.Auth.CAKey)
+}
@@ -0,0 +1,223 @@
+package kube
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	certificates "k8s.io/api/certificates/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	certificatesclient "k8s.io/client-go/kubernetes/typed/certificates/v1beta1"
+
+	"github.com/supergiant/control/pkg/kubeconfig"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/timeline"
+)
+
+const (
+	csrApproveInterval = 15 * time.Second
+
+	nodeUserPrefix = "system:node:"
+	nodesGroup     = "system:nodes"
+
+	// csrApproveReason is set on conditions of approved requests.
+	csrApproveReason = "SupergiantApprove"
+)
+
+// CSRApprover approves kubelet serving certificate requests of machines that
+// supergiant provisioned, requests of unknown nodes or addresses are left
+// pending for an operator to decide.
+type CSRApprover struct {
+	svc       Interface
+	timeline  *timeline.Service
+	interval  time.Duration
+	newClient func(k *model.Kube) (certificatesclient.CertificatesV1beta1Interface, error)
+}
+
+func NewCSRApprover(svc Interface, events *timeline.Service) *CSRApprover {
+	return &CSRApprover{
+		svc:       svc,
+		timeline:  events,
+		interval:  csrApproveInterval,
+		newClient: kubeconfig.CertificatesClient,
+	}
+}
+
+// Run approves requests until the context is done.
+func (a *CSRApprover) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.check(ctx)
+		}
+	}
+}
+
+func (a *CSRApprover) check(ctx context.Context) {
+	kubes, err := a.svc.ListAll(ctx)
+	if err != nil {
+		logrus.Errorf("csr approver: list kubes: %v", err)
+		return
+	}
+
+	for i := range kubes {
+		k := &kubes[i]
+		// nodes join while clusters are provisioned or upgraded as well
+		if k.State == model.StateDeleting || k.State == model.StateFailed || len(k.Masters) == 0 {
+			continue
+		}
+
+		if err := a.approve(ctx, k); err != nil {
+			logrus.Debugf("csr approver: kube %s: %v", k.ID, err)
+		}
+	}
+}
+
+func (a *CSRApprover) approve(ctx context.Context, k *model.Kube) error {
+	client, err := a.newClient(k)
+	if err != nil {
+		return errors.Wrap(err, "get certificates client")
+	}
+
+	list, err := client.CertificateSigningRequests().List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "list certificate signing requests")
+	}
+
+	for i := range list.Items {
+		csr := &list.Items[i]
+		if len(csr.Status.Conditions) > 0 || !isServingCSR(csr) {
+			continue
+		}
+
+		if err := validateServingCSR(k, csr); err != nil {
+			logrus.Warnf("csr approver: kube %s: leave %s pending: %v", k.ID, csr.Name, err)
+			continue
+		}
+
+		csr.Status.Conditions = append(csr.Status.Conditions, certificates.CertificateSigningRequestCondition{
+			Type:    certificates.CertificateApproved,
+			Reason:  csrApproveReason,
+			Message: "kubelet serving certificate of a machine provisioned by supergiant",
+		})
+		if _, err := client.CertificateSigningRequests().UpdateApproval(csr); err != nil {
+			return errors.Wrapf(err, "approve %s", csr.Name)
+		}
+		logrus.Infof("csr approver: kube %s: approved %s of %s", k.ID, csr.Name, csr.Spec.Username)
+
+		node := strings.TrimPrefix(csr.Spec.Username, nodeUserPrefix)
+		if err := a.timeline.Record(ctx, &timeline.Event{
+			KubeID:  k.ID,
+			Type:    timeline.CertificateRotated,
+			Object:  node,
+			Message: fmt.Sprintf("kubelet serving certificate of %s has been issued", node),
+		}); err != nil {
+			logrus.Warnf("csr approver: kube %s: record event: %v", k.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// isServingCSR tells whether a node requests a serving certificate, client
+// certificates of nodes are approved by the controller manager.
+func isServingCSR(csr *certificates.CertificateSigningRequest) bool {
+	if !strings.HasPrefix(csr.Spec.Username, nodeUserPrefix) {
+		return false
+	}
+	for _, u := range csr.Spec.Usages {
+		if u == certificates.UsageServerAuth {
+			return true
+		}
+	}
+	return false
+}
+
+// validateServingCSR checks that the request comes from a machine of the kube
+// and names only addresses of that machine.
+func validateServingCSR(k *model.Kube, csr *certificates.CertificateSigningRequest) error {
+	nodeName := strings.TrimPrefix(csr.Spec.Username, nodeUserPrefix)
+	if !hasString(csr.Spec.Groups, nodesGroup) {
+		return errors.Errorf("%s isn't in the %s group", csr.Spec.Username, nodesGroup)
+	}
+	for _, u := range csr.Spec.Usages {
+		switch u {
+		case certificates.UsageDigitalSignature, certificates.UsageKeyEncipherment, certificates.UsageServerAuth:
+		default:
+			return errors.Errorf("unexpected usage %q", u)
+		}
+	}
+
+	m, _ := machineFor(k, nodeName)
+	if m == nil {
+		return errors.Errorf("node %s isn't a machine of the kube", nodeName)
+	}
+
+	block, _ := pem.Decode(csr.Spec.Request)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return errors.New("request isn't a PEM encoded certificate request")
+	}
+	req, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return errors.Wrap(err, "parse certificate request")
+	}
+	if err = req.CheckSignature(); err != nil {
+		return errors.Wrap(err, "check signature")
+	}
+
+	if req.Subject.CommonName != csr.Spec.Username {
+		return errors.Errorf("common name %q doesn't match the user %s", req.Subject.CommonName, csr.Spec.Username)
+	}
+	if len(req.Subject.Organization) != 1 || req.Subject.Organization[0] != nodesGroup {
+		return errors.Errorf("organization %v isn't %s", req.Subject.Organization, nodesGroup)
+	}
+	if len(req.EmailAddresses) > 0 || len(req.URIs) > 0 {
+		return errors.New("email and uri names aren't allowed")
+	}
+
+	for _, ip := range req.IPAddresses {
+		if s := ip.String(); s != m.PrivateIp && s != m.PublicIp {
+			return errors.Errorf("address %s doesn't belong to the machine %s", s, m.Name)
+		}
+	}
+	for _, name := range req.DNSNames {
+		if !isMachineHostname(m, nodeName, name) {
+			return errors.Errorf("name %s doesn't belong to the machine %s", name, m.Name)
+		}
+	}
+
+	return nil
+}
+
+// isMachineHostname tells whether the name is the node name or a host name
+// the cloud derives from the machine addresses, e.g. ip-10-0-0-1.ec2.internal.
+func isMachineHostname(m *model.Machine, nodeName, name string) bool {
+	if strings.EqualFold(name, nodeName) || strings.EqualFold(name, m.Name) {
+		return true
+	}
+
+	host := strings.SplitN(name, ".", 2)[0]
+	if m.PrivateIp != "" && host == ip2Host(m.PrivateIp) {
+		return true
+	}
+	return m.PublicIp != "" && host == fmt.Sprintf("ec2-%s", strings.Replace(m.PublicIp, ".", "-", -1))
+}
+
+func hasString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
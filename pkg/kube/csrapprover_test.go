@@ -0,0 +1,115 @@
+package kube
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	certificates "k8s.io/api/certificates/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	certificatesclient "k8s.io/client-go/kubernetes/typed/certificates/v1beta1"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/storage/memory"
+	"github.com/supergiant/control/pkg/timeline"
+)
+
+func servingCSR(t *testing.T, name, node string, ips []string, dnsNames ...string) *certificates.CertificateSigningRequest {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tpl := &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: nodeUserPrefix + node, Organization: []string{nodesGroup}},
+		DNSNames: dnsNames,
+	}
+	for _, ip := range ips {
+		tpl.IPAddresses = append(tpl.IPAddresses, net.ParseIP(ip))
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, tpl, key)
+	require.NoError(t, err)
+
+	return &certificates.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: certificates.CertificateSigningRequestSpec{
+			Request:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}),
+			Username: nodeUserPrefix + node,
+			Groups:   []string{nodesGroup, "system:authenticated"},
+			Usages: []certificates.KeyUsage{
+				certificates.UsageDigitalSignature,
+				certificates.UsageKeyEncipherment,
+				certificates.UsageServerAuth,
+			},
+		},
+	}
+}
+
+func csrKube() *model.Kube {
+	return &model.Kube{
+		ID:      "kube-1",
+		State:   model.StateOperational,
+		Masters: map[string]*model.Machine{"master-1": {Name: "master-1", PrivateIp: "10.0.0.1"}},
+		Nodes:   map[string]*model.Machine{"node-1": {Name: "node-1", PrivateIp: "10.0.0.2", PublicIp: "1.2.3.4"}},
+	}
+}
+
+func TestValidateServingCSR(t *testing.T) {
+	k := csrKube()
+
+	require.NoError(t, validateServingCSR(k, servingCSR(t, "ok", "node-1", []string{"10.0.0.2", "1.2.3.4"}, "node-1")))
+	require.NoError(t, validateServingCSR(k, servingCSR(t, "aws", "ip-10-0-0-2.ec2.internal", []string{"10.0.0.2"},
+		"ip-10-0-0-2.ec2.internal", "ec2-1-2-3-4.compute-1.amazonaws.com")))
+
+	require.Error(t, validateServingCSR(k, servingCSR(t, "unknown", "node-2", []string{"10.0.0.2"})))
+	require.Error(t, validateServingCSR(k, servingCSR(t, "ip", "node-1", []string{"10.0.0.1"})))
+	require.Error(t, validateServingCSR(k, servingCSR(t, "dns", "node-1", nil, "kubernetes.default")))
+
+	csr := servingCSR(t, "cn", "node-1", nil)
+	csr.Spec.Username = nodeUserPrefix + "master-1"
+	require.Error(t, validateServingCSR(k, csr), "common name must match the user")
+
+	csr = servingCSR(t, "usage", "node-1", nil)
+	csr.Spec.Usages = append(csr.Spec.Usages, certificates.UsageClientAuth)
+	require.Error(t, validateServingCSR(k, csr))
+}
+
+func TestCSRApprover_check(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		servingCSR(t, "node-1", "node-1", []string{"10.0.0.2"}, "node-1"),
+		servingCSR(t, "foreign", "node-9", []string{"10.0.0.9"}),
+	)
+
+	svc := new(kubeServiceMock)
+	svc.On("ListAll", mock.Anything).Return([]model.Kube{*csrKube()}, nil)
+	events := timeline.NewService(timeline.DefaultStoragePrefix, memory.NewInMemoryRepository())
+	a := NewCSRApprover(svc, events)
+	a.newClient = func(*model.Kube) (certificatesclient.CertificatesV1beta1Interface, error) {
+		return clientset.CertificatesV1beta1(), nil
+	}
+
+	a.check(context.Background())
+
+	csr, err := clientset.CertificatesV1beta1().CertificateSigningRequests().Get("node-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, csr.Status.Conditions, 1)
+	require.Equal(t, certificates.CertificateApproved, csr.Status.Conditions[0].Type)
+
+	csr, err = clientset.CertificatesV1beta1().CertificateSigningRequests().Get("foreign", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Empty(t, csr.Status.Conditions)
+
+	recorded, err := events.List(context.Background(), csrKube().ID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, recorded, 1)
+	require.Equal(t, timeline.CertificateRotated, recorded[0].Type)
+	require.Equal(t, "node-1", recorded[0].Object)
+}
@@ -0,0 +1,351 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/Masterminds/semver"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/helm/pkg/helm"
+	"k8s.io/helm/pkg/releaseutil"
+	"sigs.k8s.io/yaml"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/versions"
+)
+
+const (
+	// SourceLive are objects of the cluster, their api version is taken from
+	// the last applied configuration.
+	SourceLive = "live"
+	// SourceRelease are manifests of helm releases.
+	SourceRelease = "release"
+
+	lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+)
+
+// RemovedAPI is an api version of a kind that stops being served.
+type RemovedAPI struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// Resource is a name of the resource in any version, it's used to list objects.
+	Resource    string `json:"-"`
+	RemovedIn   string `json:"removedIn"`
+	Replacement string `json:"replacement"`
+}
+
+// removedAPIs are api versions removed by kubernetes releases.
+var removedAPIs = []RemovedAPI{
+	{"extensions/v1beta1", "Deployment", "deployments", "1.16.0", "apps/v1"},
+	{"apps/v1beta1", "Deployment", "deployments", "1.16.0", "apps/v1"},
+	{"apps/v1beta2", "Deployment", "deployments", "1.16.0", "apps/v1"},
+	{"extensions/v1beta1", "DaemonSet", "daemonsets", "1.16.0", "apps/v1"},
+	{"apps/v1beta2", "DaemonSet", "daemonsets", "1.16.0", "apps/v1"},
+	{"extensions/v1beta1", "ReplicaSet", "replicasets", "1.16.0", "apps/v1"},
+	{"apps/v1beta2", "ReplicaSet", "replicasets", "1.16.0", "apps/v1"},
+	{"apps/v1beta1", "StatefulSet", "statefulsets", "1.16.0", "apps/v1"},
+	{"apps/v1beta2", "StatefulSet", "statefulsets", "1.16.0", "apps/v1"},
+	{"extensions/v1beta1", "NetworkPolicy", "networkpolicies", "1.16.0", "networking.k8s.io/v1"},
+	{"extensions/v1beta1", "PodSecurityPolicy", "podsecuritypolicies", "1.16.0", "policy/v1beta1"},
+	{"scheduling.k8s.io/v1alpha1", "PriorityClass", "priorityclasses", "1.17.0", "scheduling.k8s.io/v1"},
+	{"scheduling.k8s.io/v1beta1", "PriorityClass", "priorityclasses", "1.17.0", "scheduling.k8s.io/v1"},
+	{"extensions/v1beta1", "Ingress", "ingresses", "1.22.0", "networking.k8s.io/v1"},
+	{"networking.k8s.io/v1beta1", "Ingress", "ingresses", "1.22.0", "networking.k8s.io/v1"},
+	{"apiextensions.k8s.io/v1beta1", "CustomResourceDefinition", "customresourcedefinitions", "1.22.0", "apiextensions.k8s.io/v1"},
+	{"admissionregistration.k8s.io/v1beta1", "MutatingWebhookConfiguration", "mutatingwebhookconfigurations", "1.22.0", "admissionregistration.k8s.io/v1"},
+	{"admissionregistration.k8s.io/v1beta1", "ValidatingWebhookConfiguration", "validatingwebhookconfigurations", "1.22.0", "admissionregistration.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "ClusterRole", "clusterroles", "1.22.0", "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "ClusterRoleBinding", "clusterrolebindings", "1.22.0", "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "Role", "roles", "1.22.0", "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "RoleBinding", "rolebindings", "1.22.0", "rbac.authorization.k8s.io/v1"},
+	{"batch/v1beta1", "CronJob", "cronjobs", "1.25.0", "batch/v1"},
+	{"policy/v1beta1", "PodDisruptionBudget", "poddisruptionbudgets", "1.25.0", "policy/v1"},
+	{"policy/v1beta1", "PodSecurityPolicy", "podsecuritypolicies", "1.25.0", ""},
+	{"autoscaling/v2beta1", "HorizontalPodAutoscaler", "horizontalpodautoscalers", "1.25.0", "autoscaling/v2"},
+}
+
+// DeprecatedUsage is an object that uses an api version removed by the
+// target version of the cluster.
+type DeprecatedUsage struct {
+	RemovedAPI
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	// Source of the object, it's the name of the release for release manifests.
+	Source  string `json:"source"`
+	Release string `json:"release,omitempty"`
+}
+
+// DeprecationReport is the result of the scan for removed api versions.
+type DeprecationReport struct {
+	CurrentVersion string            `json:"currentVersion"`
+	TargetVersion  string            `json:"targetVersion"`
+	Usages         []DeprecatedUsage `json:"usages"`
+	// Warnings are sources that haven't been scanned.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Blocking tells whether the upgrade breaks objects of the report.
+func (r *DeprecationReport) Blocking() bool {
+	return r != nil && len(r.Usages) > 0
+}
+
+// removedBy returns api versions removed by the target version that are
+// served by the current version.
+func removedBy(current, target string) ([]RemovedAPI, error) {
+	from, err := semver.NewVersion(current)
+	if err != nil {
+		return nil, errors.Wrapf(sgerrors.ErrInvalidJson, "version %q", current)
+	}
+	to, err := semver.NewVersion(target)
+	if err != nil {
+		return nil, errors.Wrapf(sgerrors.ErrInvalidJson, "version %q", target)
+	}
+
+	removed := make([]RemovedAPI, 0)
+	for _, api := range removedAPIs {
+		v := semver.MustParse(api.RemovedIn)
+		if from.LessThan(v) && !to.LessThan(v) {
+			removed = append(removed, api)
+		}
+	}
+	return removed, nil
+}
+
+// ScanDeprecatedAPIs looks for objects of the cluster and manifests of helm
+// releases that use api versions removed by the target version.
+func (s Service) ScanDeprecatedAPIs(ctx context.Context, kubeID, targetVersion string) (*DeprecationReport, error) {
+	kube, err := s.Get(ctx, kubeID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get kube")
+	}
+
+	removed, err := removedBy(kube.K8SVersion, targetVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DeprecationReport{
+		CurrentVersion: kube.K8SVersion,
+		TargetVersion:  targetVersion,
+		Usages:         make([]DeprecatedUsage, 0),
+	}
+	if len(removed) == 0 {
+		return report, nil
+	}
+
+	index := make(map[string]RemovedAPI, len(removed))
+	for _, api := range removed {
+		index[api.APIVersion+"/"+api.Kind] = api
+	}
+
+	live, err := s.scanLiveObjects(kube, removed, index)
+	if err != nil {
+		return nil, err
+	}
+	report.Usages = append(report.Usages, live...)
+
+	releases, err := s.scanReleases(kube, index)
+	if err != nil {
+		// clusters may have no tiller
+		logrus.Debugf("deprecations: kube %s: %v", kube.ID, err)
+		report.Warnings = append(report.Warnings, fmt.Sprintf("helm releases haven't been scanned: %v", err))
+	}
+	report.Usages = append(report.Usages, releases...)
+
+	sort.Slice(report.Usages, func(i, j int) bool {
+		a, b := report.Usages[i], report.Usages[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Source < b.Source
+	})
+	return report, nil
+}
+
+type manifestObject struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name        string            `json:"name"`
+		Namespace   string            `json:"namespace"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+}
+
+// scanLiveObjects lists objects of kinds of the removed apis in the served
+// version, the version of an object is the one it was applied with.
+func (s Service) scanLiveObjects(kube *model.Kube, removed []RemovedAPI, index map[string]RemovedAPI) ([]DeprecatedUsage, error) {
+	lists, err := s.serverResources(kube)
+	if err != nil {
+		return nil, err
+	}
+
+	usages := make([]DeprecatedUsage, 0)
+	scanned := make(map[string]bool)
+	for _, api := range removed {
+		if scanned[api.Resource] {
+			continue
+		}
+		scanned[api.Resource] = true
+
+		gv, name, ok := findResource(lists, api.Resource)
+		if !ok {
+			continue
+		}
+		client, err := s.clientForGroupFn(kube, gv)
+		if err != nil {
+			return nil, errors.Wrap(err, "get kube client")
+		}
+		raw, err := client.Get().Resource(name).DoRaw()
+		if err != nil {
+			return nil, errors.Wrapf(err, "list %s", name)
+		}
+
+		list := struct {
+			Items []manifestObject `json:"items"`
+		}{}
+		if err = json.Unmarshal(raw, &list); err != nil {
+			return nil, errors.Wrapf(err, "decode %s", name)
+		}
+		for _, item := range list.Items {
+			applied := manifestObject{}
+			if err := json.Unmarshal([]byte(item.Metadata.Annotations[lastAppliedAnnotation]), &applied); err != nil {
+				continue
+			}
+			if match, ok := index[applied.APIVersion+"/"+applied.Kind]; ok {
+				usages = append(usages, DeprecatedUsage{
+					RemovedAPI: match,
+					Name:       item.Metadata.Name,
+					Namespace:  item.Metadata.Namespace,
+					Source:     SourceLive,
+				})
+			}
+		}
+	}
+	return usages, nil
+}
+
+// scanReleases checks manifests of deployed helm releases, the next upgrade
+// of a release fails if its previous manifest has a removed api version.
+func (s Service) scanReleases(kube *model.Kube, index map[string]RemovedAPI) ([]DeprecatedUsage, error) {
+	kprx, err := s.helmClient(kube)
+	if err != nil {
+		return nil, errors.Wrap(err, "build helm proxy")
+	}
+	res, err := kprx.ListReleases(helm.ReleaseListStatuses(releaseStatuses()))
+	if err != nil {
+		return nil, errors.Wrap(err, "list releases")
+	}
+
+	usages := make([]DeprecatedUsage, 0)
+	for _, rls := range res.GetReleases() {
+		for _, doc := range releaseutil.SplitManifests(rls.GetManifest()) {
+			obj := manifestObject{}
+			if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+				continue
+			}
+			match, ok := index[obj.APIVersion+"/"+obj.Kind]
+			if !ok {
+				continue
+			}
+			ns := obj.Metadata.Namespace
+			if ns == "" {
+				ns = rls.GetNamespace()
+			}
+			usages = append(usages, DeprecatedUsage{
+				RemovedAPI: match,
+				Name:       obj.Metadata.Name,
+				Namespace:  ns,
+				Source:     SourceRelease,
+				Release:    rls.GetName(),
+			})
+		}
+	}
+	return usages, nil
+}
+
+// getUpgradePreflight returns the report of removed api versions used by the
+// cluster, the version is the next minor version by default.
+func (h *Handler) getUpgradePreflight(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	version := r.URL.Query().Get("version")
+	if version == "" {
+		version = findNextMinorVersion(k.K8SVersion, versions.Default().Versions())
+	}
+	if version == "" {
+		message.SendValidationFailed(w, errors.Errorf("can't upgrade from version %s", k.K8SVersion))
+		return
+	}
+
+	report, err := h.svc.ScanDeprecatedAPIs(r.Context(), kubeID, version)
+	if err != nil {
+		if errors.Cause(err) == sgerrors.ErrInvalidJson {
+			message.SendValidationFailed(w, err)
+			return
+		}
+		sendResourceError(w, kubeID, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(report); err != nil {
+		logrus.Errorf("upgrade preflight of kube %s: write response: %v", kubeID, err)
+	}
+}
+
+// checkUpgradePreflight blocks the upgrade of the cluster that uses removed
+// api versions unless it's forced, the report is sent when it's blocked.
+func (h *Handler) checkUpgradePreflight(w http.ResponseWriter, r *http.Request, k *model.Kube, version string) bool {
+	force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+
+	report, err := h.svc.ScanDeprecatedAPIs(r.Context(), k.ID, version)
+	if err != nil {
+		if force {
+			logrus.Warnf("upgrade kube %s to %s: skip preflight: %v", k.ID, version, err)
+			return true
+		}
+		message.SendUnknownError(w, errors.Wrap(err, "upgrade preflight"))
+		return false
+	}
+
+	if !report.Blocking() {
+		return true
+	}
+	for _, u := range report.Usages {
+		logrus.Warnf("upgrade kube %s to %s: %s %s/%s uses %s removed in %s",
+			k.ID, version, u.Kind, u.Namespace, u.Name, u.APIVersion, u.RemovedIn)
+	}
+	if force {
+		return true
+	}
+
+	w.WriteHeader(http.StatusConflict)
+	if err = json.NewEncoder(w).Encode(report); err != nil {
+		logrus.Errorf("upgrade preflight of kube %s: write response: %v", k.ID, err)
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package kube
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/helm/pkg/proto/hapi/release"
+	"k8s.io/helm/pkg/proto/hapi/services"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/sghelm/proxy"
+	"github.com/supergiant/control/pkg/testutils"
+)
+
+const deploymentsList = `{"items":[
+{"metadata":{"name":"old","namespace":"default","annotations":{
+  "kubectl.kubernetes.io/last-applied-configuration":"{\"apiVersion\":\"extensions/v1beta1\",\"kind\":\"Deployment\"}"}}},
+{"metadata":{"name":"new","namespace":"default","annotations":{
+  "kubectl.kubernetes.io/last-applied-configuration":"{\"apiVersion\":\"apps/v1\",\"kind\":\"Deployment\"}"}}},
+{"metadata":{"name":"created","namespace":"default"}}
+]}`
+
+const releaseManifest = `---
+# Source: app/templates/daemonset.yaml
+apiVersion: extensions/v1beta1
+kind: DaemonSet
+metadata:
+  name: agent
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: agent
+`
+
+func TestRemovedBy(t *testing.T) {
+	removed, err := removedBy("1.15.1", "1.16.0")
+	require.NoError(t, err)
+	require.NotEmpty(t, removed)
+	for _, api := range removed {
+		require.Equal(t, "1.16.0", api.RemovedIn)
+	}
+
+	removed, err = removedBy("1.16.3", "1.16.8")
+	require.NoError(t, err)
+	require.Empty(t, removed)
+
+	_, err = removedBy("", "1.16.0")
+	require.Equal(t, sgerrors.ErrInvalidJson, errors.Cause(err))
+}
+
+func TestService_ScanDeprecatedAPIs(t *testing.T) {
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/apis/apps/v1/deployments" {
+			w.Write([]byte(deploymentsList))
+			return
+		}
+		w.Write([]byte(`{"items":[]}`))
+	}))
+	defer srv.Close()
+
+	storage := new(testutils.MockStorage)
+	storage.On("Get", mock.Anything, mock.Anything, mock.Anything).
+		Return([]byte(`{"id":"kube-1","K8SVersion":"1.15.1"}`), nil)
+
+	svc := Service{
+		storage: storage,
+		discoveryClientFn: func(k *model.Kube) (ServerResourceGetter, error) {
+			return &mockServerResourceGetter{resources: []*metav1.APIResourceList{{
+				GroupVersion: "apps/v1",
+				APIResources: []metav1.APIResource{
+					{Name: "deployments", Kind: "Deployment", Namespaced: true},
+					{Name: "daemonsets", Kind: "DaemonSet", Namespaced: true},
+				},
+			}}}, nil
+		},
+		clientForGroupFn: func(k *model.Kube, gv schema.GroupVersion) (rest.Interface, error) {
+			return rest.RESTClientFor(&rest.Config{
+				Host:    srv.URL,
+				APIPath: "/apis",
+				ContentConfig: rest.ContentConfig{
+					GroupVersion:         &gv,
+					NegotiatedSerializer: scheme.Codecs,
+				},
+			})
+		},
+		newHelmProxyFn: func(kube *model.Kube) (proxy.Interface, error) {
+			return &fakeHelmProxy{
+				listReleaseResp: &services.ListReleasesResponse{
+					Releases: []*release.Release{{Name: "agent", Namespace: "monitoring", Manifest: releaseManifest}},
+				},
+			}, nil
+		},
+	}
+
+	report, err := svc.ScanDeprecatedAPIs(context.Background(), "kube-1", "1.16.0")
+	require.NoError(t, err)
+	require.NotEmpty(t, path)
+	require.True(t, report.Blocking())
+	require.Empty(t, report.Warnings)
+	require.Len(t, report.Usages, 2)
+
+	require.Equal(t, "DaemonSet", report.Usages[0].Kind)
+	require.Equal(t, SourceRelease, report.Usages[0].Source)
+	require.Equal(t, "agent", report.Usages[0].Release)
+	require.Equal(t, "monitoring", report.Usages[0].Namespace)
+	require.Equal(t, "apps/v1", report.Usages[0].Replacement)
+
+	require.Equal(t, "Deployment", report.Usages[1].Kind)
+	require.Equal(t, SourceLive, report.Usages[1].Source)
+	require.Equal(t, "old", report.Usages[1].Name)
+
+	svc.newHelmProxyFn = func(*model.Kube) (proxy.Interface, error) {
+		return &fakeHelmProxy{err: errors.New("tiller not found")}, nil
+	}
+	report, err = svc.ScanDeprecatedAPIs(context.Background(), "kube-1", "1.16.0")
+	require.NoError(t, err)
+	require.Len(t, report.Usages, 1)
+	require.Len(t, report.Warnings, 1)
+}
+
+func TestHandler_checkUpgradePreflight(t *testing.T) {
+	k := &model.Kube{ID: "kube-1", K8SVersion: "1.15.1"}
+	svc := &kubeServiceMock{deprecations: &DeprecationReport{
+		Usages: []DeprecatedUsage{{RemovedAPI: removedAPIs[0], Name: "old", Source: SourceLive}},
+	}}
+	h := &Handler{svc: svc}
+
+	rr := httptest.NewRecorder()
+	require.False(t, h.checkUpgradePreflight(rr, httptest.NewRequest(http.MethodPatch, "/kubes/kube-1", nil), k, "1.16.0"))
+	require.Equal(t, http.StatusConflict, rr.Code)
+	require.Contains(t, rr.Body.String(), "extensions/v1beta1")
+
+	rr = httptest.NewRecorder()
+	require.True(t, h.checkUpgradePreflight(rr, httptest.NewRequest(http.MethodPatch, "/kubes/kube-1?force=true", nil), k, "1.16.0"))
+
+	svc.deprecations, svc.scanErr = nil, errors.New("unreachable")
+	rr = httptest.NewRecorder()
+	require.False(t, h.checkUpgradePreflight(rr, httptest.NewRequest(http.MethodPatch, "/kubes/kube-1", nil), k, "1.16.0"))
+	require.Equal(t, http.StatusInternalServerError, rr.Code)
+}
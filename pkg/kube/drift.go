@@ -0,0 +1,537 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/kubeconfig"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/kubelet"
+)
+
+const driftCheckInterval = time.Hour
+
+// Kinds of drift items.
+const (
+	DriftInstance      = "instance"
+	DriftInstanceType  = "instanceType"
+	DriftTag           = "tag"
+	DriftSecurityGroup = "securityGroup"
+	DriftNode          = "node"
+	DriftVersion       = "version"
+	DriftLabel         = "label"
+	DriftFirewallRule  = "firewallRule"
+)
+
+// cloudInstance is a machine as the cloud provider reports it.
+type cloudInstance struct {
+	ID    string
+	Name  string
+	Type  string
+	State string
+	Tags  map[string]string
+	Zone  string
+
+	PrivateIP string
+	PublicIP  string
+}
+
+// ingressRule allows traffic to a security group from the cidr.
+type ingressRule struct {
+	Protocol string
+	FromPort int64
+	ToPort   int64
+	CIDR     string
+}
+
+func (r ingressRule) String() string {
+	return fmt.Sprintf("%s %d-%d from %s", r.Protocol, r.FromPort, r.ToPort, r.CIDR)
+}
+
+// cloudInventory holds cloud resources of a cluster.
+type cloudInventory struct {
+	Instances map[string]cloudInstance
+	// Ingress rules of security groups by ids, a missing group has no entry.
+	SecurityGroups map[string][]ingressRule
+}
+
+func (h *Handler) getDrift(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	var report *DriftReport
+	if refresh, _ := strconv.ParseBool(r.URL.Query().Get("refresh")); refresh {
+		report = h.checkDrift(r.Context(), k)
+	} else if report, err = h.lastDriftReport(r.Context(), kubeID); err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(report); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) setDriftPolicy(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	policy := model.DriftPolicy{}
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	k.Drift = policy
+	if err = h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(k.Drift); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// checkDrift compares the cluster with its cloud resources and kubernetes
+// nodes, drift is remediated in the auto remediate mode. The report is saved.
+func (h *Handler) checkDrift(ctx context.Context, k *model.Kube) *DriftReport {
+	report := &DriftReport{
+		KubeID:    k.ID,
+		CheckedAt: time.Now(),
+	}
+
+	if inventory, err := h.inventoryOf(ctx, k); err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("cloud: %v", err))
+	} else {
+		report.Items = append(report.Items, cloudDrift(k, inventory)...)
+	}
+
+	if nodes, err := h.svc.ListNodes(ctx, k, ""); err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("kubernetes: %v", err))
+	} else {
+		report.Items = append(report.Items, nodeDrift(k, nodes)...)
+	}
+
+	report.Drifted = len(report.Items) > 0
+	if k.Drift.AutoRemediate {
+		h.remediateDrift(ctx, k, report)
+	}
+
+	if err := h.saveDriftReport(ctx, report); err != nil {
+		logrus.Errorf("drift: save report of cluster %s: %v", k.ID, err)
+	}
+
+	return report
+}
+
+func (h *Handler) inventoryOf(ctx context.Context, k *model.Kube) (*cloudInventory, error) {
+	if h.cloudInventory == nil {
+		return nil, errors.Wrapf(sgerrors.ErrUnsupportedProvider, "%s", k.Provider)
+	}
+
+	acc, err := h.accountService.Get(ctx, k.AccountName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get cloud account %s", k.AccountName)
+	}
+
+	return h.cloudInventory(ctx, k, acc)
+}
+
+// cloudDrift reports machines that are missing, stopped, resized or have lost
+// tags, security groups that have been deleted or opened to the world and
+// firewall rules that have been removed.
+func cloudDrift(k *model.Kube, inv *cloudInventory) []DriftItem {
+	var items []DriftItem
+
+	for _, m := range sortedMachines(k) {
+		if m.ID == "" || m.State != model.MachineStateActive {
+			continue
+		}
+
+		inst, ok := inv.Instances[m.ID]
+		if !ok || inst.State == "terminated" || inst.State == "shutting-down" {
+			items = append(items, DriftItem{
+				Kind:     DriftInstance,
+				Resource: m.Name,
+				Expected: "running",
+				Actual:   "missing",
+			})
+			continue
+		}
+
+		if inst.State != "running" {
+			items = append(items, DriftItem{
+				Kind:     DriftInstance,
+				Resource: m.Name,
+				Expected: "running",
+				Actual:   inst.State,
+			})
+		}
+		if m.Size != "" && inst.Type != m.Size {
+			items = append(items, DriftItem{
+				Kind:     DriftInstanceType,
+				Resource: m.Name,
+				Expected: m.Size,
+				Actual:   inst.Type,
+			})
+		}
+
+		for _, tag := range sortedTags(instanceTags(k, m)) {
+			if actual := inst.Tags[tag.key]; actual != tag.value {
+				items = append(items, DriftItem{
+					Kind:       DriftTag,
+					Resource:   m.Name + "/" + tag.key,
+					Expected:   tag.value,
+					Actual:     actual,
+					Remediable: true,
+				})
+			}
+		}
+	}
+
+	for _, groupID := range []string{
+		k.CloudSpec[clouds.AwsMastersSecGroupID],
+		k.CloudSpec[clouds.AwsNodesSecgroupID],
+	} {
+		if groupID == "" {
+			continue
+		}
+
+		rules, ok := inv.SecurityGroups[groupID]
+		if !ok {
+			items = append(items, DriftItem{
+				Kind:     DriftSecurityGroup,
+				Resource: groupID,
+				Expected: "exists",
+				Actual:   "missing",
+			})
+			continue
+		}
+
+		for _, rule := range rules {
+			if rule.CIDR == "0.0.0.0/0" && !worldOpen(k, rule) && firewallRuleFor(k, groupID, rule) == nil {
+				items = append(items, DriftItem{
+					Kind:     DriftSecurityGroup,
+					Resource: groupID,
+					Actual:   rule.String(),
+				})
+			}
+		}
+	}
+
+	// rules added through the API that have been removed in the cloud
+	for _, rule := range k.FirewallRules {
+		rules, ok := inv.SecurityGroups[securityGroupID(k, rule.Group)]
+		if !ok || hasIngressRule(rules, ingressRuleOf(rule)) {
+			continue
+		}
+		items = append(items, DriftItem{
+			Kind:       DriftFirewallRule,
+			Resource:   rule.Name,
+			Expected:   ingressRuleOf(rule).String(),
+			Actual:     "missing",
+			Remediable: true,
+		})
+	}
+
+	return items
+}
+
+// worldOpen reports whether the rule open to any address has been created by control.
+func worldOpen(k *model.Kube, rule ingressRule) bool {
+	if rule.Protocol != "tcp" || rule.FromPort != rule.ToPort {
+		return false
+	}
+
+	switch rule.FromPort {
+	case 22:
+		return true
+	case k.APIServerPort:
+		for _, addr := range k.ExposedAddresses {
+			if addr.CIDR == rule.CIDR {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// nodeDrift reports machines without nodes, nodes unknown to control and
+// nodes with other kubelet versions or role labels.
+func nodeDrift(k *model.Kube, nodes []corev1.Node) []DriftItem {
+	var items []DriftItem
+	seen := make(map[*model.Machine]bool)
+
+	for _, node := range nodes {
+		m, isMaster := machineFor(k, node.Name)
+		if m == nil {
+			items = append(items, DriftItem{
+				Kind:     DriftNode,
+				Resource: node.Name,
+				Actual:   "unknown",
+			})
+			continue
+		}
+		seen[m] = true
+
+		version := strings.TrimPrefix(node.Status.NodeInfo.KubeletVersion, "v")
+		if k.K8SVersion != "" && version != strings.TrimPrefix(k.K8SVersion, "v") {
+			items = append(items, DriftItem{
+				Kind:     DriftVersion,
+				Resource: node.Name,
+				Expected: k.K8SVersion,
+				Actual:   version,
+			})
+		}
+
+		role := string(model.ToRole(isMaster))
+		if actual := node.Labels[kubelet.LabelNodeRole]; actual != role {
+			items = append(items, DriftItem{
+				Kind:       DriftLabel,
+				Resource:   node.Name + "/" + kubelet.LabelNodeRole,
+				Expected:   role,
+				Actual:     actual,
+				Remediable: true,
+			})
+		}
+	}
+
+	for _, m := range sortedMachines(k) {
+		if m.State == model.MachineStateActive && !seen[m] {
+			items = append(items, DriftItem{
+				Kind:     DriftNode,
+				Resource: m.Name,
+				Expected: "ready",
+				Actual:   "missing",
+			})
+		}
+	}
+
+	return items
+}
+
+// remediateDrift restores instance tags, node labels and firewall rules.
+func (h *Handler) remediateDrift(ctx context.Context, k *model.Kube, report *DriftReport) {
+	var acc *model.CloudAccount
+
+	for i := range report.Items {
+		item := &report.Items[i]
+		if !item.Remediable {
+			continue
+		}
+
+		var err error
+		parts := strings.SplitN(item.Resource, "/", 2)
+		switch item.Kind {
+		case DriftTag:
+			m := findMachine(k, parts[0])
+			if m == nil {
+				continue
+			}
+			if acc == nil {
+				if acc, err = h.accountService.Get(ctx, k.AccountName); err != nil {
+					break
+				}
+			}
+			err = h.tagInstance(ctx, k, acc, m.ID, map[string]string{parts[1]: item.Expected})
+		case DriftFirewallRule:
+			rule := k.FirewallRule(item.Resource)
+			if rule == nil {
+				continue
+			}
+			if acc == nil {
+				if acc, err = h.accountService.Get(ctx, k.AccountName); err != nil {
+					break
+				}
+			}
+			err = h.applyFirewall(ctx, k, acc, steps.FirewallConfig{Authorize: []model.FirewallRule{*rule}})
+		case DriftLabel:
+			err = h.labelNode(k, parts[0], map[string]string{parts[1]: item.Expected})
+		}
+
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("remediate %s %s: %v", item.Kind, item.Resource, err))
+			continue
+		}
+		item.Remediated = true
+	}
+}
+
+func (h *Handler) lastDriftReport(ctx context.Context, kubeID string) (*DriftReport, error) {
+	data, err := h.repo.Get(ctx, DriftStoragePrefix, kubeID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DriftReport{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, errors.Wrap(err, "unmarshal")
+	}
+
+	return report, nil
+}
+
+func (h *Handler) saveDriftReport(ctx context.Context, report *DriftReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return errors.Wrap(err, "marshal")
+	}
+
+	return h.repo.Put(ctx, DriftStoragePrefix, report.KubeID, data)
+}
+
+// DriftMonitor checks operational clusters for drift periodically.
+type DriftMonitor struct {
+	h        *Handler
+	interval time.Duration
+}
+
+func NewDriftMonitor(h *Handler) *DriftMonitor {
+	return &DriftMonitor{
+		h:        h,
+		interval: driftCheckInterval,
+	}
+}
+
+// Run checks clusters until the context is done.
+func (m *DriftMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.check(ctx)
+		}
+	}
+}
+
+func (m *DriftMonitor) check(ctx context.Context) {
+	kubes, err := m.h.svc.ListAll(ctx)
+	if err != nil {
+		logrus.Errorf("drift monitor: list kubes: %v", err)
+		return
+	}
+
+	for i := range kubes {
+		if kubes[i].State != model.StateOperational {
+			continue
+		}
+
+		report := m.h.checkDrift(ctx, &kubes[i])
+		if report.Drifted {
+			logrus.Warnf("drift monitor: cluster %s has drifted: %d items", kubes[i].ID, len(report.Items))
+		}
+	}
+}
+
+type tag struct {
+	key, value string
+}
+
+// instanceTags are tags set on machines when they are created.
+func instanceTags(k *model.Kube, m *model.Machine) map[string]string {
+	return map[string]string{
+		clouds.TagClusterID:         k.ID,
+		clouds.TagKubernetesCluster: k.Name,
+		clouds.TagNodeName:          m.Name,
+	}
+}
+
+func sortedTags(tags map[string]string) []tag {
+	list := make([]tag, 0, len(tags))
+	for k, v := range tags {
+		list = append(list, tag{k, v})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].key < list[j].key
+	})
+
+	return list
+}
+
+func sortedMachines(k *model.Kube) []*model.Machine {
+	machines := make([]*model.Machine, 0, len(k.Masters)+len(k.Nodes))
+	for _, m := range k.Masters {
+		machines = append(machines, m)
+	}
+	for _, m := range k.Nodes {
+		machines = append(machines, m)
+	}
+	sort.Slice(machines, func(i, j int) bool {
+		return machines[i].Name < machines[j].Name
+	})
+
+	return machines
+}
+
+func findMachine(k *model.Kube, name string) *model.Machine {
+	if m, ok := k.Masters[name]; ok {
+		return m
+	}
+	return k.Nodes[name]
+}
+
+func labelNode(k *model.Kube, name string, labels map[string]string) error {
+	cfg, err := kubeconfig.NewConfigFor(k)
+	if err != nil {
+		return errors.Wrap(err, "build kubernetes rest config")
+	}
+	c, err := clientcorev1.NewForConfig(cfg)
+	if err != nil {
+		return errors.Wrap(err, "build kubernetes client")
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": labels},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Nodes().Patch(name, types.StrategicMergePatchType, patch)
+	return err
+}
@@ -0,0 +1,143 @@
+package kube
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/amazon"
+)
+
+func ec2For(k *model.Kube, acc *model.CloudAccount) (ec2iface.EC2API, error) {
+	if k.Provider != clouds.AWS {
+		return nil, errors.Wrapf(sgerrors.ErrUnsupportedProvider, "%s", k.Provider)
+	}
+
+	config := &steps.Config{}
+	if err := util.FillCloudAccountCredentials(acc, config); err != nil {
+		return nil, errors.Wrap(err, "fill cloud account credentials")
+	}
+	config.AWSConfig.Region = k.Region
+
+	svc, err := amazon.GetEC2(config.AWSConfig)
+	if err != nil {
+		return nil, errors.Wrap(sgerrors.ErrInvalidCredentials, err.Error())
+	}
+
+	return svc, nil
+}
+
+// cloudInventoryOf describes instances and security groups of the cluster.
+func cloudInventoryOf(ctx context.Context, k *model.Kube, acc *model.CloudAccount) (*cloudInventory, error) {
+	svc, err := ec2For(k, acc)
+	if err != nil {
+		return nil, err
+	}
+
+	return awsInventory(ctx, svc, k)
+}
+
+func awsInventory(ctx context.Context, svc ec2iface.EC2API, k *model.Kube) (*cloudInventory, error) {
+	inv := &cloudInventory{
+		Instances:      make(map[string]cloudInstance),
+		SecurityGroups: make(map[string][]ingressRule),
+	}
+
+	// instances that have lost the cluster tag are found by ids
+	var ids []*string
+	for _, m := range sortedMachines(k) {
+		if m.ID != "" {
+			ids = append(ids, aws.String(m.ID))
+		}
+	}
+	if len(ids) > 0 {
+		err := svc.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{
+			Filters: []*ec2.Filter{{
+				Name:   aws.String("instance-id"),
+				Values: ids,
+			}},
+		}, func(out *ec2.DescribeInstancesOutput, _ bool) bool {
+			for _, r := range out.Reservations {
+				for _, i := range r.Instances {
+					inst := cloudInstance{
+						ID:   aws.StringValue(i.InstanceId),
+						Type: aws.StringValue(i.InstanceType),
+						Tags: make(map[string]string, len(i.Tags)),
+					}
+					if i.State != nil {
+						inst.State = aws.StringValue(i.State.Name)
+					}
+					for _, t := range i.Tags {
+						inst.Tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+					}
+					inv.Instances[inst.ID] = inst
+				}
+			}
+			return true
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "describe instances")
+		}
+	}
+
+	var groupIDs []*string
+	for _, id := range []string{k.CloudSpec[clouds.AwsMastersSecGroupID], k.CloudSpec[clouds.AwsNodesSecgroupID]} {
+		if id != "" {
+			groupIDs = append(groupIDs, aws.String(id))
+		}
+	}
+	if len(groupIDs) == 0 {
+		return inv, nil
+	}
+
+	// unknown ids fail the request, groups are filtered instead
+	out, err := svc.DescribeSecurityGroupsWithContext(ctx, &ec2.DescribeSecurityGroupsInput{
+		Filters: []*ec2.Filter{{
+			Name:   aws.String("group-id"),
+			Values: groupIDs,
+		}},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "describe security groups")
+	}
+
+	for _, g := range out.SecurityGroups {
+		rules := make([]ingressRule, 0)
+		for _, p := range g.IpPermissions {
+			for _, r := range p.IpRanges {
+				rules = append(rules, ingressRule{
+					Protocol: aws.StringValue(p.IpProtocol),
+					FromPort: aws.Int64Value(p.FromPort),
+					ToPort:   aws.Int64Value(p.ToPort),
+					CIDR:     aws.StringValue(r.CidrIp),
+				})
+			}
+		}
+		inv.SecurityGroups[aws.StringValue(g.GroupId)] = rules
+	}
+
+	return inv, nil
+}
+
+func tagInstance(ctx context.Context, k *model.Kube, acc *model.CloudAccount, id string, tags map[string]string) error {
+	svc, err := ec2For(k, acc)
+	if err != nil {
+		return err
+	}
+
+	input := &ec2.CreateTagsInput{Resources: []*string{aws.String(id)}}
+	for _, t := range sortedTags(tags) {
+		input.Tags = append(input.Tags, &ec2.Tag{Key: aws.String(t.key), Value: aws.String(t.value)})
+	}
+
+	_, err = svc.CreateTagsWithContext(ctx, input)
+	return errors.Wrapf(err, "tag instance %s", id)
+}
@@ -0,0 +1,241 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/storage/memory"
+)
+
+func driftKube() *model.Kube {
+	return &model.Kube{
+		ID:            "kube-1",
+		Name:          "test",
+		AccountName:   "aws",
+		Provider:      clouds.AWS,
+		State:         model.StateOperational,
+		K8SVersion:    "1.14.1",
+		APIServerPort: 443,
+		ExposedAddresses: []profile.Addresses{
+			{CIDR: "0.0.0.0/0"},
+		},
+		CloudSpec: map[string]string{
+			clouds.AwsMastersSecGroupID: "sg-1",
+			clouds.AwsNodesSecgroupID:   "sg-2",
+		},
+		Masters: map[string]*model.Machine{
+			"master-1": {ID: "i-1", Name: "master-1", Size: "m4.large", State: model.MachineStateActive},
+		},
+		Nodes: map[string]*model.Machine{
+			"node-1": {ID: "i-2", Name: "node-1", Size: "m4.large", State: model.MachineStateActive},
+			"node-2": {ID: "i-3", Name: "node-2", Size: "m4.large", State: model.MachineStateActive},
+		},
+	}
+}
+
+func tagsOf(k *model.Kube, name string) map[string]string {
+	return map[string]string{
+		clouds.TagClusterID:         k.ID,
+		clouds.TagKubernetesCluster: k.Name,
+		clouds.TagNodeName:          name,
+	}
+}
+
+func TestCloudDrift(t *testing.T) {
+	k := driftKube()
+	inv := &cloudInventory{
+		Instances: map[string]cloudInstance{
+			"i-1": {ID: "i-1", Type: "m4.large", State: "running", Tags: tagsOf(k, "master-1")},
+			"i-2": {ID: "i-2", Type: "m4.xlarge", State: "stopped", Tags: map[string]string{
+				clouds.TagClusterID:         k.ID,
+				clouds.TagKubernetesCluster: k.Name,
+			}},
+		},
+		SecurityGroups: map[string][]ingressRule{
+			"sg-1": {
+				{Protocol: "tcp", FromPort: 22, ToPort: 22, CIDR: "0.0.0.0/0"},
+				{Protocol: "tcp", FromPort: 443, ToPort: 443, CIDR: "0.0.0.0/0"},
+				{Protocol: "-1", CIDR: "0.0.0.0/0"},
+			},
+		},
+	}
+
+	require.Equal(t, []DriftItem{
+		{Kind: DriftInstance, Resource: "node-1", Expected: "running", Actual: "stopped"},
+		{Kind: DriftInstanceType, Resource: "node-1", Expected: "m4.large", Actual: "m4.xlarge"},
+		{Kind: DriftTag, Resource: "node-1/Name", Expected: "node-1", Remediable: true},
+		{Kind: DriftInstance, Resource: "node-2", Expected: "running", Actual: "missing"},
+		{Kind: DriftSecurityGroup, Resource: "sg-1", Actual: "-1 0-0 from 0.0.0.0/0"},
+		{Kind: DriftSecurityGroup, Resource: "sg-2", Expected: "exists", Actual: "missing"},
+	}, cloudDrift(k, inv))
+}
+
+func TestNodeDrift(t *testing.T) {
+	k := driftKube()
+	nodes := []corev1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "master-1", Labels: map[string]string{"kubernetes.io/role": "master"}},
+			Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{KubeletVersion: "v1.14.1"}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{KubeletVersion: "v1.13.5"}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "manual"},
+		},
+	}
+
+	require.Equal(t, []DriftItem{
+		{Kind: DriftVersion, Resource: "node-1", Expected: "1.14.1", Actual: "1.13.5"},
+		{Kind: DriftLabel, Resource: "node-1/kubernetes.io/role", Expected: "node", Remediable: true},
+		{Kind: DriftNode, Resource: "manual", Actual: "unknown"},
+		{Kind: DriftNode, Resource: "node-2", Expected: "ready", Actual: "missing"},
+	}, nodeDrift(k, nodes))
+}
+
+func TestHandler_getDrift(t *testing.T) {
+	k := driftKube()
+	k.Drift.AutoRemediate = true
+	svc := new(kubeServiceMock)
+	svc.On("Get", mock.Anything, "kube-1").Return(k, nil)
+	svc.On("ListNodes", mock.Anything, k, "").Return([]corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "master-1"}},
+	}, nil)
+	accounts := new(accServiceMock)
+	accounts.On("Get", mock.Anything, "aws").Return(&model.CloudAccount{Provider: clouds.AWS}, nil)
+	repo := memory.NewInMemoryRepository()
+	h := NewHandler(svc, accounts, nil, nil, nil, nil, repo, nil, "")
+
+	h.cloudInventory = func(context.Context, *model.Kube, *model.CloudAccount) (*cloudInventory, error) {
+		return &cloudInventory{
+			Instances: map[string]cloudInstance{
+				"i-1": {ID: "i-1", Type: "m4.large", State: "running"},
+			},
+			SecurityGroups: map[string][]ingressRule{"sg-1": nil, "sg-2": nil},
+		}, nil
+	}
+	tagged := make(map[string]string)
+	h.tagInstance = func(_ context.Context, _ *model.Kube, _ *model.CloudAccount, id string, tags map[string]string) error {
+		for k, v := range tags {
+			tagged[id+"/"+k] = v
+		}
+		return nil
+	}
+	labeled := make(map[string]string)
+	h.labelNode = func(_ *model.Kube, name string, labels map[string]string) error {
+		for k, v := range labels {
+			labeled[name+"/"+k] = v
+		}
+		return nil
+	}
+
+	// there is no report before the first check
+	rr := metricsRequest(h, "/kubes/kube-1/drift")
+	require.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = metricsRequest(h, "/kubes/kube-1/drift?refresh=true")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	report := &DriftReport{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(report))
+	require.True(t, report.Drifted)
+	require.Empty(t, report.Errors)
+	require.Equal(t, map[string]string{
+		"i-1/" + clouds.TagClusterID:         "kube-1",
+		"i-1/" + clouds.TagKubernetesCluster: "test",
+		"i-1/" + clouds.TagNodeName:          "master-1",
+	}, tagged)
+	require.Equal(t, map[string]string{"master-1/kubernetes.io/role": "master"}, labeled)
+	for _, item := range report.Items {
+		require.Equal(t, item.Remediable, item.Remediated, item.Resource)
+	}
+
+	// the report is kept
+	rr = metricsRequest(h, "/kubes/kube-1/drift")
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+type fakeEC2 struct {
+	ec2iface.EC2API
+	instances []*ec2.Instance
+	groups    []*ec2.SecurityGroup
+}
+
+func (f *fakeEC2) DescribeInstancesPagesWithContext(_ aws.Context, _ *ec2.DescribeInstancesInput,
+	fn func(*ec2.DescribeInstancesOutput, bool) bool, _ ...request.Option) error {
+	fn(&ec2.DescribeInstancesOutput{
+		Reservations: []*ec2.Reservation{{Instances: f.instances}},
+	}, true)
+	return nil
+}
+
+func (f *fakeEC2) DescribeSecurityGroupsWithContext(aws.Context, *ec2.DescribeSecurityGroupsInput,
+	...request.Option) (*ec2.DescribeSecurityGroupsOutput, error) {
+	return &ec2.DescribeSecurityGroupsOutput{SecurityGroups: f.groups}, nil
+}
+
+func TestAWSInventory(t *testing.T) {
+	svc := &fakeEC2{
+		instances: []*ec2.Instance{{
+			InstanceId:   aws.String("i-1"),
+			InstanceType: aws.String("m4.large"),
+			State:        &ec2.InstanceState{Name: aws.String("running")},
+			Tags:         []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("master-1")}},
+		}},
+		groups: []*ec2.SecurityGroup{{
+			GroupId: aws.String("sg-1"),
+			IpPermissions: []*ec2.IpPermission{{
+				IpProtocol: aws.String("tcp"),
+				FromPort:   aws.Int64(22),
+				ToPort:     aws.Int64(22),
+				IpRanges:   []*ec2.IpRange{{CidrIp: aws.String("0.0.0.0/0")}},
+			}},
+		}},
+	}
+
+	inv, err := awsInventory(context.Background(), svc, driftKube())
+	require.NoError(t, err)
+	require.Equal(t, cloudInstance{
+		ID:    "i-1",
+		Type:  "m4.large",
+		State: "running",
+		Tags:  map[string]string{"Name": "master-1"},
+	}, inv.Instances["i-1"])
+	require.Equal(t, []ingressRule{{Protocol: "tcp", FromPort: 22, ToPort: 22, CIDR: "0.0.0.0/0"}}, inv.SecurityGroups["sg-1"])
+	_, ok := inv.SecurityGroups["sg-2"]
+	require.False(t, ok)
+}
+
+func TestCloudDrift_firewallRules(t *testing.T) {
+	k := driftKube()
+	k.Masters, k.Nodes = nil, nil
+	k.FirewallRules = []model.FirewallRule{
+		{Name: "office", Group: model.FirewallMasters, Protocol: "tcp", FromPort: 6443, ToPort: 6443, CIDR: "10.1.0.0/16"},
+		{Name: "nodeports", Group: model.FirewallNodes, Protocol: "tcp", FromPort: 30000, ToPort: 32767, CIDR: "0.0.0.0/0"},
+	}
+	inv := &cloudInventory{
+		SecurityGroups: map[string][]ingressRule{
+			"sg-1": nil,
+			"sg-2": {{Protocol: "tcp", FromPort: 30000, ToPort: 32767, CIDR: "0.0.0.0/0"}},
+		},
+	}
+
+	require.Equal(t, []DriftItem{
+		{Kind: DriftFirewallRule, Resource: "office", Expected: "tcp 6443-6443 from 10.1.0.0/16", Actual: "missing", Remediable: true},
+	}, cloudDrift(k, inv))
+}
@@ -0,0 +1,146 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/dns"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// EndpointName is a request body that adds a DNS name of the API endpoint.
+type EndpointName struct {
+	Name string `json:"name"`
+}
+
+// addEndpointName creates a record of the name that points to the external
+// endpoint of the API in the DNS provider of the cloud account. The name
+// must be a SAN of the API server certificate, clients can't verify it
+// otherwise.
+func (h *Handler) addEndpointName(w http.ResponseWriter, r *http.Request) {
+	req := EndpointName{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+	name := strings.TrimSuffix(strings.ToLower(req.Name), ".")
+
+	k, p, ok := h.endpointDNS(w, r)
+	if !ok {
+		return
+	}
+	if name == "" || !containsName(k.Certificates.APIServerSANs, name) {
+		message.SendValidationFailed(w, errors.Errorf("name %q isn't a SAN of the API server certificate", req.Name))
+		return
+	}
+
+	if err := p.Upsert(r.Context(), dns.RecordFor(name, k.ExternalDNSName)); err != nil {
+		message.SendUnknownError(w, errors.Wrapf(err, "create record %s", name))
+		return
+	}
+
+	if !containsName(k.EndpointNames, name) {
+		k.EndpointNames = append(k.EndpointNames, name)
+	}
+	if err := h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(k.EndpointNames); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// deleteEndpointName removes the record of the name from the DNS provider.
+func (h *Handler) deleteEndpointName(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSuffix(strings.ToLower(mux.Vars(r)["name"]), ".")
+
+	k, p, ok := h.endpointDNS(w, r)
+	if !ok {
+		return
+	}
+	if !containsName(k.EndpointNames, name) {
+		message.SendNotFound(w, name, sgerrors.ErrNotFound)
+		return
+	}
+
+	if err := p.Delete(r.Context(), dns.RecordFor(name, k.ExternalDNSName)); err != nil {
+		message.SendUnknownError(w, errors.Wrapf(err, "delete record %s", name))
+		return
+	}
+
+	names := k.EndpointNames[:0]
+	for _, n := range k.EndpointNames {
+		if n != name {
+			names = append(names, n)
+		}
+	}
+	k.EndpointNames = names
+	if err := h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// endpointDNS returns the kube of the request and the DNS provider of its
+// account, it writes the error response if it fails.
+func (h *Handler) endpointDNS(w http.ResponseWriter, r *http.Request) (*model.Kube, dns.Provider, bool) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return nil, nil, false
+		}
+		message.SendUnknownError(w, err)
+		return nil, nil, false
+	}
+	if k.ExternalDNSName == "" {
+		message.SendValidationFailed(w, errors.Errorf("kube %s has no external endpoint", kubeID))
+		return nil, nil, false
+	}
+
+	p, err := h.kubeDNS(r.Context(), k)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, k.AccountName, err)
+			return nil, nil, false
+		}
+		if sgerrors.IsUnsupportedProvider(errors.Cause(err)) || errors.Cause(err) == sgerrors.ErrInvalidCredentials {
+			message.SendValidationFailed(w, err)
+			return nil, nil, false
+		}
+		message.SendUnknownError(w, err)
+		return nil, nil, false
+	}
+	return k, p, true
+}
+
+// kubeDNS returns the DNS provider of the cloud account of the kube.
+func (h *Handler) kubeDNS(ctx context.Context, k *model.Kube) (dns.Provider, error) {
+	acc, err := h.accountService.Get(ctx, k.AccountName)
+	if err != nil {
+		return nil, err
+	}
+	return h.dnsProvider(acc)
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if strings.TrimSuffix(strings.ToLower(n), ".") == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package kube
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/dns"
+	"github.com/supergiant/control/pkg/model"
+)
+
+type fakeDNS struct {
+	records map[string]dns.Record
+}
+
+func (f *fakeDNS) Upsert(_ context.Context, r dns.Record) error {
+	f.records[r.Name] = r
+	return nil
+}
+
+func (f *fakeDNS) Delete(_ context.Context, r dns.Record) error {
+	delete(f.records, r.Name)
+	return nil
+}
+
+func TestHandler_endpointNames(t *testing.T) {
+	k := specKube()
+	k.ExternalDNSName = "10.0.0.1"
+	k.Certificates.APIServerSANs = []string{"api.example.com"}
+	svc := new(kubeServiceMock)
+	svc.On("Get", mock.Anything, "kube-1").Return(k, nil)
+	svc.On("Create", mock.Anything, mock.Anything).Return(nil)
+	accounts := new(accServiceMock)
+	accounts.On("Get", mock.Anything, "do").Return(&model.CloudAccount{Provider: clouds.DigitalOcean}, nil)
+	h := NewHandler(svc, accounts, nil, nil, nil, nil, nil, nil, "")
+	provider := &fakeDNS{records: map[string]dns.Record{}}
+	h.dnsProvider = func(*model.CloudAccount) (dns.Provider, error) {
+		return provider, nil
+	}
+
+	rr := protectionRequest(h, http.MethodPost, "/kubes/kube-1/endpoint/dns", `{"name":"other.example.com"}`)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = protectionRequest(h, http.MethodPost, "/kubes/kube-1/endpoint/dns", `{"name":"API.example.com."}`)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, []string{"api.example.com"}, k.EndpointNames)
+	require.Equal(t, dns.Record{Name: "api.example.com", Type: dns.TypeA, TTL: dns.DefaultTTL, Values: []string{"10.0.0.1"}},
+		provider.records["api.example.com"])
+
+	rr = protectionRequest(h, http.MethodDelete, "/kubes/kube-1/endpoint/dns/other.example.com", "")
+	require.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = protectionRequest(h, http.MethodDelete, "/kubes/kube-1/endpoint/dns/api.example.com", "")
+	require.Equal(t, http.StatusAccepted, rr.Code)
+	require.Empty(t, k.EndpointNames)
+	require.Empty(t, provider.records)
+
+	k.ExternalDNSName = ""
+	rr = protectionRequest(h, http.MethodPost, "/kubes/kube-1/endpoint/dns", `{"name":"api.example.com"}`)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
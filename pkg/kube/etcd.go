@@ -0,0 +1,329 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/cron"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/timeline"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows"
+)
+
+const (
+	defaultEtcdMaintenanceWindow = 60
+	defaultEtcdDefragThreshold   = 30
+	// etcd default backend quota
+	defaultEtcdQuotaBytes     = 2 << 30
+	defaultEtcdAlertThreshold = 80
+)
+
+// replaceEtcdMember recreates the etcd member of a master machine
+// with an empty data directory and fresh peer certificates.
+func (h *Handler) replaceEtcdMember(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+	nodeName := vars["nodename"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	n := k.Masters[nodeName]
+	if n == nil {
+		message.SendNotFound(w, nodeName, sgerrors.ErrNotFound)
+		return
+	}
+
+	// The new member gets its data from the rest of the cluster
+	if len(k.Masters) < 2 {
+		http.Error(w, "replace etcd member of the single master not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if k.State != model.StateOperational {
+		w.WriteHeader(http.StatusNoContent)
+		logrus.Infof("Cluster %s is not operational", k.ID)
+		return
+	}
+
+	config, err := h.newKubeConfig(r.Context(), k)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, k.ProfileID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	config.Node = *n
+	config.IsMaster = true
+	config.IsBootstrap = false
+
+	t, err := workflows.NewTask(config, workflows.EtcdReplace, h.repo)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	writer, err := h.getWriter(util.MakeFileName(t.ID))
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	h.recordEvent(r.Context(), &timeline.Event{
+		KubeID:  k.ID,
+		Type:    timeline.RepairStarted,
+		Object:  nodeName,
+		Message: fmt.Sprintf("etcd member of %s is being replaced", nodeName),
+	})
+
+	go func() {
+		if err := <-t.Run(context.Background(), *config, writer); err != nil {
+			logrus.Errorf("replace etcd member %s of cluster %s caused %v", nodeName, kubeID, err)
+		}
+	}()
+
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(map[string]string{nodeName: t.ID}); err != nil {
+		logrus.Errorf("Error encoding task map %v", err)
+	}
+}
+
+func (h *Handler) runEtcdMaintenance(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if !checkMaintenance(w, r, k) {
+		return
+	}
+
+	node2Task, err := h.StartEtcdMaintenance(r.Context(), k)
+	if err != nil {
+		if errors.Cause(err) == ErrNotOperational {
+			w.WriteHeader(http.StatusNoContent)
+			logrus.Infof("Cluster %s is not operational", k.ID)
+			return
+		}
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, k.ProfileID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(node2Task); err != nil {
+		logrus.Errorf("Error encoding task map %v", err)
+	}
+}
+
+func (h *Handler) getEtcdMaintenance(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(withEtcdDefaults(k.EtcdMaintenance)); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) setEtcdMaintenance(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+
+	cfg := model.EtcdMaintenance{}
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	if err := validateEtcdMaintenance(cfg); err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	// member statuses are reported by maintenance runs only
+	cfg.Members = k.EtcdMaintenance.Members
+	// count a new schedule from now on
+	cfg.LastRun = k.EtcdMaintenance.LastRun
+	if cfg.Schedule != k.EtcdMaintenance.Schedule {
+		cfg.LastRun = time.Now().Unix()
+	}
+	k.EtcdMaintenance = cfg
+
+	if err := h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(withEtcdDefaults(k.EtcdMaintenance)); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// StartEtcdMaintenance checks and defragments etcd members of cluster masters
+// one by one in background and returns a node name to a task id mapping.
+func (h *Handler) StartEtcdMaintenance(ctx context.Context, k *model.Kube) (map[string]string, error) {
+	if k.State != model.StateOperational {
+		return nil, ErrNotOperational
+	}
+
+	config, err := h.newKubeConfig(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := withEtcdDefaults(k.EtcdMaintenance)
+	config.EtcdMaintenanceConfig.DefragThreshold = settings.DefragThreshold
+
+	masters := *k
+	masters.Nodes = nil
+	tasks := h.makeMachineTasks(config, &masters, workflows.EtcdMaintenance)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(),
+			time.Duration(settings.Window)*time.Minute)
+		defer cancel()
+
+		if err := h.kubeProvisioner.MaintainEtcd(ctx, k, tasks, config); err != nil {
+			logrus.Errorf("etcd maintenance of cluster %s caused %v", k.ID, err)
+		}
+
+		if err := h.saveEtcdStatus(context.Background(), k.ID, tasks[workflows.MasterTask]); err != nil {
+			logrus.Errorf("save etcd status of cluster %s caused %v", k.ID, err)
+		}
+	}()
+
+	return mapNode2Task(tasks), nil
+}
+
+// saveEtcdStatus stores etcd member statuses reported by maintenance tasks
+// and alerts about members which db approaches the quota.
+func (h *Handler) saveEtcdStatus(ctx context.Context, kubeID string, tasks []*workflows.Task) error {
+	k, err := h.svc.Get(ctx, kubeID)
+	if err != nil {
+		return err
+	}
+
+	settings := withEtcdDefaults(k.EtcdMaintenance)
+	if k.EtcdMaintenance.Members == nil {
+		k.EtcdMaintenance.Members = make(map[string]model.EtcdMemberStatus)
+	}
+
+	for _, task := range tasks {
+		status := task.Config.EtcdMaintenanceConfig.Status
+		if status.CheckedAt == 0 {
+			continue
+		}
+
+		status.Alert = etcdAlert(status, settings)
+		if status.Alert != "" {
+			logrus.Warnf("cluster %s etcd member %s: %s", kubeID, task.Config.Node.Name, status.Alert)
+		}
+		k.EtcdMaintenance.Members[task.Config.Node.Name] = status
+	}
+
+	return h.svc.Create(ctx, k)
+}
+
+func etcdAlert(status model.EtcdMemberStatus, settings model.EtcdMaintenance) string {
+	if status.DBSize*100 < settings.QuotaBytes*int64(settings.AlertThreshold) {
+		return ""
+	}
+
+	return fmt.Sprintf("db size %d bytes is over %d%% of the %d bytes quota",
+		status.DBSize, settings.AlertThreshold, settings.QuotaBytes)
+}
+
+func withEtcdDefaults(cfg model.EtcdMaintenance) model.EtcdMaintenance {
+	if cfg.Window == 0 {
+		cfg.Window = defaultEtcdMaintenanceWindow
+	}
+	if cfg.DefragThreshold == 0 {
+		cfg.DefragThreshold = defaultEtcdDefragThreshold
+	}
+	if cfg.QuotaBytes == 0 {
+		cfg.QuotaBytes = defaultEtcdQuotaBytes
+	}
+	if cfg.AlertThreshold == 0 {
+		cfg.AlertThreshold = defaultEtcdAlertThreshold
+	}
+
+	return cfg
+}
+
+func validateEtcdMaintenance(cfg model.EtcdMaintenance) error {
+	if cfg.Schedule != "" {
+		if _, err := cron.Parse(cfg.Schedule); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Window < 0 || cfg.QuotaBytes < 0 {
+		return errors.New("window and quota must not be negative")
+	}
+
+	if cfg.DefragThreshold < 0 || cfg.DefragThreshold > 100 ||
+		cfg.AlertThreshold < 0 || cfg.AlertThreshold > 100 {
+		return errors.New("thresholds must be percents")
+	}
+
+	return nil
+}
+
+// NewEtcdMaintenanceScheduler creates a scheduler that starts etcd
+// maintenance of clusters according to their schedules.
+func NewEtcdMaintenanceScheduler(svc Interface, maintain func(context.Context, *model.Kube) (map[string]string, error)) *Scheduler {
+	return newScheduler(svc, "etcd maintenance", func(k *model.Kube) (string, *int64) {
+		return k.EtcdMaintenance.Schedule, &k.EtcdMaintenance.LastRun
+	}, maintain)
+}
@@ -0,0 +1,218 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestHandler_replaceEtcdMember(t *testing.T) {
+	tcs := []struct {
+		serviceKube  *model.Kube
+		serviceError error
+
+		expectedStatus  int
+		expectedErrCode sgerrors.ErrorCode
+	}{
+		{ // TC#1
+			serviceError:    sgerrors.ErrNotFound,
+			expectedStatus:  http.StatusNotFound,
+			expectedErrCode: sgerrors.NotFound,
+		},
+		{ // TC#2
+			serviceKube: &model.Kube{
+				ID: "kube",
+				Masters: map[string]*model.Machine{
+					"master-2": {Name: "master-2"},
+				},
+			},
+			expectedStatus:  http.StatusNotFound,
+			expectedErrCode: sgerrors.NotFound,
+		},
+		{ // TC#3
+			serviceKube: &model.Kube{
+				ID: "kube",
+				Masters: map[string]*model.Machine{
+					"master-1": {Name: "master-1"},
+				},
+			},
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{ // TC#4
+			serviceKube: &model.Kube{
+				ID:    "kube",
+				State: model.StateProvisioning,
+				Masters: map[string]*model.Machine{
+					"master-1": {Name: "master-1"},
+					"master-2": {Name: "master-2"},
+				},
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for i, tc := range tcs {
+		svc := new(kubeServiceMock)
+		svc.On(serviceGet, mock.Anything, mock.Anything).Return(tc.serviceKube, tc.serviceError)
+
+		h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+		req, err := http.NewRequest(http.MethodPost, "/kubes/kube/etcd/members/master-1/replace", nil)
+		require.Equalf(t, nil, err, "TC#%d: create request: %v", i+1, err)
+		rr := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		h.Register(router)
+		router.ServeHTTP(rr, req)
+
+		require.Equalf(t, tc.expectedStatus, rr.Code, "TC#%d", i+1)
+
+		if tc.expectedErrCode != sgerrors.ErrorCode(0) {
+			m := new(message.Message)
+			err = json.NewDecoder(rr.Body).Decode(m)
+			require.Equalf(t, nil, err, "TC#%d", i+1)
+			require.Equalf(t, tc.expectedErrCode, m.ErrorCode, "TC#%d", i+1)
+		}
+	}
+}
+
+func TestHandler_setEtcdMaintenance(t *testing.T) {
+	tcs := []struct {
+		body string
+
+		serviceKube  *model.Kube
+		serviceError error
+
+		expectedStatus  int
+		expectedErrCode sgerrors.ErrorCode
+	}{
+		{ // TC#1
+			body:            "{",
+			expectedStatus:  http.StatusBadRequest,
+			expectedErrCode: sgerrors.InvalidJSON,
+		},
+		{ // TC#2
+			body:            `{"schedule":"0 3 * *"}`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedErrCode: sgerrors.ValidationFailed,
+		},
+		{ // TC#3
+			body:            `{"schedule":"0 3 * * 0","alertThreshold":120}`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedErrCode: sgerrors.ValidationFailed,
+		},
+		{ // TC#4
+			body:            `{"schedule":"0 3 * * 0"}`,
+			serviceError:    sgerrors.ErrNotFound,
+			expectedStatus:  http.StatusNotFound,
+			expectedErrCode: sgerrors.NotFound,
+		},
+		{ // TC#5
+			body: `{"schedule":"0 3 * * 0","defragThreshold":50,"members":{"fake":{}}}`,
+			serviceKube: &model.Kube{
+				ID: "success",
+				EtcdMaintenance: model.EtcdMaintenance{
+					Members: map[string]model.EtcdMemberStatus{
+						"master-1": {DBSize: 1024},
+					},
+				},
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for i, tc := range tcs {
+		svc := new(kubeServiceMock)
+		svc.On(serviceGet, mock.Anything, mock.Anything).Return(tc.serviceKube, tc.serviceError)
+		svc.On(serviceCreate, mock.Anything, mock.Anything).Return(nil)
+
+		h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+		req, err := http.NewRequest(http.MethodPut, "/kubes/success/etcd/maintenance", bytes.NewBufferString(tc.body))
+		require.Equalf(t, nil, err, "TC#%d: create request: %v", i+1, err)
+		rr := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		h.Register(router)
+		router.ServeHTTP(rr, req)
+
+		require.Equalf(t, tc.expectedStatus, rr.Code, "TC#%d", i+1)
+
+		if tc.expectedErrCode != sgerrors.ErrorCode(0) {
+			m := new(message.Message)
+			err = json.NewDecoder(rr.Body).Decode(m)
+			require.Equalf(t, nil, err, "TC#%d", i+1)
+			require.Equalf(t, tc.expectedErrCode, m.ErrorCode, "TC#%d", i+1)
+			continue
+		}
+
+		cfg := model.EtcdMaintenance{}
+		err = json.NewDecoder(rr.Body).Decode(&cfg)
+		require.Equalf(t, nil, err, "TC#%d", i+1)
+		require.Equalf(t, 50, cfg.DefragThreshold, "TC#%d", i+1)
+		require.Equalf(t, defaultEtcdAlertThreshold, cfg.AlertThreshold, "TC#%d", i+1)
+		require.NotZerof(t, cfg.LastRun, "TC#%d: schedule must start from now", i+1)
+		require.Equalf(t, tc.serviceKube.EtcdMaintenance.Members, cfg.Members,
+			"TC#%d: member statuses must not be set by a user", i+1)
+	}
+}
+
+func TestHandler_saveEtcdStatus(t *testing.T) {
+	k := &model.Kube{
+		ID: "kube",
+		EtcdMaintenance: model.EtcdMaintenance{
+			QuotaBytes: 1000,
+		},
+	}
+
+	svc := new(kubeServiceMock)
+	svc.On(serviceGet, mock.Anything, mock.Anything).Return(k, nil)
+	svc.On(serviceCreate, mock.Anything, mock.Anything).Return(nil)
+
+	h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+	tasks := []*workflows.Task{
+		{
+			Config: &steps.Config{
+				Node: model.Machine{Name: "master-1"},
+				EtcdMaintenanceConfig: steps.EtcdMaintenanceConfig{
+					Status: model.EtcdMemberStatus{DBSize: 900, CheckedAt: 1},
+				},
+			},
+		},
+		{
+			Config: &steps.Config{
+				Node: model.Machine{Name: "master-2"},
+				EtcdMaintenanceConfig: steps.EtcdMaintenanceConfig{
+					Status: model.EtcdMemberStatus{DBSize: 100, CheckedAt: 1},
+				},
+			},
+		},
+		{
+			// a member which hasn't been checked
+			Config: &steps.Config{
+				Node: model.Machine{Name: "master-3"},
+			},
+		},
+	}
+
+	err := h.saveEtcdStatus(context.Background(), k.ID, tasks)
+	require.Nil(t, err)
+
+	require.Len(t, k.EtcdMaintenance.Members, 2)
+	require.NotEmpty(t, k.EtcdMaintenance.Members["master-1"].Alert)
+	require.Empty(t, k.EtcdMaintenance.Members["master-2"].Alert)
+}
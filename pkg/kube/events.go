@@ -0,0 +1,158 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/supergiant/control/pkg/kubeconfig"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/timeline"
+)
+
+// getEvents returns the timeline of the cluster within the from and to
+// RFC3339 bounds. Events of supergiant are merged with warning events of
+// kubernetes unless the source parameter selects one of them.
+func (h *Handler) getEvents(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+
+	from, err := parseTimeParam(r, "from")
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+	to, err := parseTimeParam(r, "to")
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	source := timeline.Source(r.URL.Query().Get("source"))
+	switch source {
+	case "", timeline.SourceControl, timeline.SourceKubernetes:
+	default:
+		message.SendValidationFailed(w, errors.Errorf("unknown source %q", source))
+		return
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	events := make([]timeline.Event, 0)
+	if source != timeline.SourceKubernetes {
+		if events, err = h.timeline.List(r.Context(), k.ID, from, to); err != nil {
+			message.SendUnknownError(w, err)
+			return
+		}
+	}
+
+	if source != timeline.SourceControl && len(k.Masters) > 0 {
+		// the timeline is still useful when the cluster is unreachable
+		warnings, err := h.kubeWarnings(k)
+		if err != nil {
+			logrus.Warnf("kubes: %s cluster: list warning events: %v", k.ID, err)
+		}
+		for _, e := range warnings {
+			if timeline.InRange(e.Time, from, to) {
+				events = append(events, e)
+			}
+		}
+	}
+
+	timeline.Sort(events)
+	if err = json.NewEncoder(w).Encode(events); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) recordEvent(ctx context.Context, e *timeline.Event) {
+	if err := h.timeline.Record(ctx, e); err != nil {
+		logrus.Warnf("kubes: %s cluster: record %s event: %v", e.KubeID, e.Type, err)
+	}
+}
+
+func kubeWarnings(k *model.Kube) ([]timeline.Event, error) {
+	client, err := kubeconfig.CoreV1Client(k)
+	if err != nil {
+		return nil, errors.Wrap(err, "build kubernetes client")
+	}
+
+	list, err := client.Events(metav1.NamespaceAll).List(metav1.ListOptions{
+		FieldSelector: "type=" + corev1.EventTypeWarning,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return warningEvents(k.ID, list.Items), nil
+}
+
+// warningEvents converts kubernetes events to the cluster timeline.
+func warningEvents(kubeID string, items []corev1.Event) []timeline.Event {
+	events := make([]timeline.Event, 0, len(items))
+	for _, item := range items {
+		if item.Type != corev1.EventTypeWarning {
+			continue
+		}
+
+		obj := item.InvolvedObject
+		parts := []string{strings.ToLower(obj.Kind)}
+		if obj.Namespace != "" {
+			parts = append(parts, obj.Namespace)
+		}
+		parts = append(parts, obj.Name)
+
+		events = append(events, timeline.Event{
+			ID:      string(item.UID),
+			KubeID:  kubeID,
+			Source:  timeline.SourceKubernetes,
+			Type:    timeline.Type(item.Reason),
+			Object:  strings.Join(parts, "/"),
+			Message: item.Message,
+			Time:    eventTime(item),
+		})
+	}
+	return events
+}
+
+// eventTime returns when the event has been seen last time.
+func eventTime(e corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.UTC()
+	case !e.EventTime.IsZero():
+		return e.EventTime.UTC()
+	}
+	return e.FirstTimestamp.UTC()
+}
+
+func parseTimeParam(r *http.Request, name string) (time.Time, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, errors.Errorf("%s must be RFC3339 time", name)
+	}
+	return t, nil
+}
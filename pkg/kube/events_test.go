@@ -0,0 +1,133 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/storage/memory"
+	"github.com/supergiant/control/pkg/timeline"
+)
+
+func TestHandler_getEvents(t *testing.T) {
+	start := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tcs := []struct {
+		query        string
+		warningsErr  error
+		expectedCode int
+		expected     []timeline.Type
+	}{
+		{ // TC#1
+			expectedCode: http.StatusOK,
+			expected:     []timeline.Type{timeline.ProvisionStarted, "FailedScheduling", timeline.ProvisionFinished},
+		},
+		{ // TC#2
+			query:        "?from=2019-06-01T12:01:00Z",
+			expectedCode: http.StatusOK,
+			expected:     []timeline.Type{"FailedScheduling", timeline.ProvisionFinished},
+		},
+		{ // TC#3
+			query:        "?source=control",
+			expectedCode: http.StatusOK,
+			expected:     []timeline.Type{timeline.ProvisionStarted, timeline.ProvisionFinished},
+		},
+		{ // TC#4
+			warningsErr:  errors.New("connection refused"),
+			expectedCode: http.StatusOK,
+			expected:     []timeline.Type{timeline.ProvisionStarted, timeline.ProvisionFinished},
+		},
+		{ // TC#5
+			query:        "?to=yesterday",
+			expectedCode: http.StatusBadRequest,
+		},
+		{ // TC#6
+			query:        "?source=cloud",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for i, tc := range tcs {
+		k := &model.Kube{ID: "kube", Masters: map[string]*model.Machine{"master-1": {}}}
+		svc := new(kubeServiceMock)
+		svc.On(serviceGet, mock.Anything, mock.Anything).Return(k, nil)
+
+		h := NewHandler(svc, nil, nil, nil, nil, nil, memory.NewInMemoryRepository(), nil, "")
+		h.recordEvent(context.Background(), &timeline.Event{KubeID: k.ID, Type: timeline.ProvisionStarted, Time: start})
+		h.recordEvent(context.Background(), &timeline.Event{KubeID: k.ID, Type: timeline.ProvisionFinished, Time: start.Add(2 * time.Minute)})
+		h.kubeWarnings = func(*model.Kube) ([]timeline.Event, error) {
+			if tc.warningsErr != nil {
+				return nil, tc.warningsErr
+			}
+			return []timeline.Event{{Source: timeline.SourceKubernetes, Type: "FailedScheduling", Time: start.Add(time.Minute)}}, nil
+		}
+
+		req, err := http.NewRequest(http.MethodGet, "/kubes/kube/events"+tc.query, nil)
+		require.Equalf(t, nil, err, "TC#%d: create request: %v", i+1, err)
+		rr := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		h.Register(router)
+		router.ServeHTTP(rr, req)
+
+		require.Equalf(t, tc.expectedCode, rr.Code, "TC#%d", i+1)
+		if tc.expectedCode != http.StatusOK {
+			continue
+		}
+
+		events := make([]timeline.Event, 0)
+		require.Nilf(t, json.NewDecoder(rr.Body).Decode(&events), "TC#%d", i+1)
+
+		types := make([]timeline.Type, 0, len(events))
+		for _, e := range events {
+			types = append(types, e.Type)
+		}
+		require.Equalf(t, tc.expected, types, "TC#%d", i+1)
+	}
+}
+
+func TestWarningEvents(t *testing.T) {
+	seen := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	events := warningEvents("kube", []corev1.Event{
+		{
+			ObjectMeta: metav1.ObjectMeta{UID: "1"},
+			InvolvedObject: corev1.ObjectReference{
+				Kind:      "Pod",
+				Namespace: "default",
+				Name:      "web",
+			},
+			Type:          corev1.EventTypeWarning,
+			Reason:        "BackOff",
+			Message:       "Back-off restarting failed container",
+			LastTimestamp: metav1.NewTime(seen),
+		},
+		{
+			InvolvedObject: corev1.ObjectReference{Kind: "Node", Name: "node-1"},
+			Type:           corev1.EventTypeNormal,
+			Reason:         "Starting",
+		},
+	})
+
+	require.Len(t, events, 1)
+	require.Equal(t, timeline.Event{
+		ID:      "1",
+		KubeID:  "kube",
+		Source:  timeline.SourceKubernetes,
+		Type:    "BackOff",
+		Object:  "pod/default/web",
+		Message: "Back-off restarting failed container",
+		Time:    seen,
+	}, events[0])
+}
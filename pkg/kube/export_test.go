@@ -0,0 +1,47 @@
+package kube
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+func TestHandler_exportTerraform(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		provider       clouds.Name
+		getErr         error
+		expectedStatus int
+	}{
+		{"kube not found", clouds.AWS, sgerrors.ErrNotFound, http.StatusNotFound},
+		{"unsupported provider", clouds.OpenStack, nil, http.StatusBadRequest},
+		{"success", clouds.DigitalOcean, nil, http.StatusOK},
+	} {
+		k := &model.Kube{
+			ID:       "kube-1",
+			Name:     "test",
+			Provider: tc.provider,
+			Nodes: map[string]*model.Machine{
+				"node-1": {ID: "1234", Name: "node-1", Role: model.RoleNode, Size: "s-2vcpu-4gb"},
+			},
+		}
+		svc := new(kubeServiceMock)
+		svc.On("Get", mock.Anything, "kube-1").Return(k, tc.getErr)
+		h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+		rr := metricsRequest(h, "/kubes/kube-1/export/terraform")
+		require.Equal(t, tc.expectedStatus, rr.Code, tc.name)
+		if tc.expectedStatus != http.StatusOK {
+			continue
+		}
+
+		require.Equal(t, `attachment; filename="test.tf"`, rr.Header().Get("Content-Disposition"))
+		require.Contains(t, rr.Body.String(), `resource "digitalocean_droplet" "node-1"`)
+	}
+}
@@ -0,0 +1,283 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"regexp"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/provider"
+)
+
+var firewallRuleName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// FirewallRules returns rules added through the API and whether security
+// groups still have them, other rules of the groups are listed as well.
+func (h *Handler) FirewallRules(ctx context.Context, k *model.Kube) (*FirewallRules, error) {
+	inv, err := h.inventoryOf(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &FirewallRules{
+		Rules: make([]FirewallRuleState, 0, len(k.FirewallRules)),
+		Other: make([]model.FirewallRule, 0),
+	}
+	for _, rule := range k.FirewallRules {
+		res.Rules = append(res.Rules, FirewallRuleState{
+			FirewallRule: rule,
+			Applied:      hasIngressRule(inv.SecurityGroups[securityGroupID(k, rule.Group)], ingressRuleOf(rule)),
+		})
+	}
+
+	for _, group := range []string{model.FirewallMasters, model.FirewallNodes} {
+		groupID := securityGroupID(k, group)
+		if groupID == "" {
+			continue
+		}
+		for _, rule := range inv.SecurityGroups[groupID] {
+			if firewallRuleFor(k, groupID, rule) != nil {
+				continue
+			}
+			res.Other = append(res.Other, model.FirewallRule{
+				Group:    group,
+				Protocol: rule.Protocol,
+				FromPort: rule.FromPort,
+				ToPort:   rule.ToPort,
+				CIDR:     rule.CIDR,
+			})
+		}
+	}
+
+	return res, nil
+}
+
+// AddFirewallRule authorizes the rule in the security group of the cluster
+// and saves it.
+func (h *Handler) AddFirewallRule(ctx context.Context, k *model.Kube, rule model.FirewallRule) (*model.FirewallRule, error) {
+	if err := validateFirewallRule(&rule); err != nil {
+		return nil, err
+	}
+	if k.FirewallRule(rule.Name) != nil {
+		return nil, errors.Wrapf(sgerrors.ErrAlreadyExists, "firewall rule %s", rule.Name)
+	}
+
+	if err := h.changeFirewall(ctx, k, steps.FirewallConfig{
+		Authorize: []model.FirewallRule{rule},
+	}); err != nil {
+		return nil, err
+	}
+
+	k.FirewallRules = append(k.FirewallRules, rule)
+	if err := h.svc.Create(ctx, k); err != nil {
+		return nil, errors.Wrapf(err, "update kube %s", k.ID)
+	}
+
+	return &rule, nil
+}
+
+// RemoveFirewallRule revokes the rule in the security group of the cluster
+// and deletes it.
+func (h *Handler) RemoveFirewallRule(ctx context.Context, k *model.Kube, name string) error {
+	rule := k.FirewallRule(name)
+	if rule == nil {
+		return errors.Wrapf(sgerrors.ErrNotFound, "firewall rule %s", name)
+	}
+
+	if err := h.changeFirewall(ctx, k, steps.FirewallConfig{
+		Revoke: []model.FirewallRule{*rule},
+	}); err != nil {
+		return err
+	}
+
+	rules := make([]model.FirewallRule, 0, len(k.FirewallRules))
+	for _, r := range k.FirewallRules {
+		if r.Name != name {
+			rules = append(rules, r)
+		}
+	}
+	k.FirewallRules = rules
+
+	return errors.Wrapf(h.svc.Create(ctx, k), "update kube %s", k.ID)
+}
+
+func (h *Handler) changeFirewall(ctx context.Context, k *model.Kube, firewall steps.FirewallConfig) error {
+	acc, err := h.accountService.Get(ctx, k.AccountName)
+	if err != nil {
+		return errors.Wrapf(err, "get cloud account %s", k.AccountName)
+	}
+
+	return h.applyFirewall(ctx, k, acc, firewall)
+}
+
+// applyFirewallRules runs the firewall step of the provider of the kube.
+func applyFirewallRules(ctx context.Context, k *model.Kube, acc *model.CloudAccount, firewall steps.FirewallConfig) error {
+	config := &steps.Config{
+		Provider:         k.Provider,
+		CloudAccountName: k.AccountName,
+		FirewallConfig:   firewall,
+	}
+	if err := util.LoadCloudSpecificDataFromKube(k, config); err != nil {
+		return errors.Wrap(err, "load cloud specific data")
+	}
+	if err := util.FillCloudAccountCredentials(acc, config); err != nil {
+		return errors.Wrap(err, "fill cloud account credentials")
+	}
+
+	return provider.ApplyFirewallRules{}.Run(ctx, ioutil.Discard, config)
+}
+
+func validateFirewallRule(rule *model.FirewallRule) error {
+	invalid := func(field, format string, args ...interface{}) error {
+		return sgerrors.WithField(errors.Wrapf(sgerrors.ErrInvalidJson, format, args...), field)
+	}
+
+	if !firewallRuleName.MatchString(rule.Name) {
+		return invalid("name", "invalid rule name %q", rule.Name)
+	}
+	if rule.Group != model.FirewallMasters && rule.Group != model.FirewallNodes {
+		return invalid("group", "group must be %s or %s", model.FirewallMasters, model.FirewallNodes)
+	}
+	if _, _, err := net.ParseCIDR(rule.CIDR); err != nil {
+		return invalid("cidr", "parse cidr: %v", err)
+	}
+
+	switch rule.Protocol {
+	case "tcp", "udp":
+		if rule.FromPort < 0 || rule.ToPort > 65535 || rule.FromPort > rule.ToPort {
+			return invalid("fromPort", "invalid port range %d-%d", rule.FromPort, rule.ToPort)
+		}
+	case "icmp":
+		// ports are the icmp type and code, -1 allows all of them
+		if rule.FromPort < -1 || rule.FromPort > 255 || rule.ToPort < -1 || rule.ToPort > 255 {
+			return invalid("fromPort", "invalid icmp type %d and code %d", rule.FromPort, rule.ToPort)
+		}
+	case "-1":
+		// all traffic has no ports, the cloud reports them as zeros
+		rule.FromPort, rule.ToPort = 0, 0
+	default:
+		return invalid("protocol", "protocol must be tcp, udp, icmp or -1")
+	}
+
+	return nil
+}
+
+func securityGroupID(k *model.Kube, group string) string {
+	switch group {
+	case model.FirewallMasters:
+		return k.CloudSpec[clouds.AwsMastersSecGroupID]
+	case model.FirewallNodes:
+		return k.CloudSpec[clouds.AwsNodesSecgroupID]
+	}
+	return ""
+}
+
+func ingressRuleOf(rule model.FirewallRule) ingressRule {
+	return ingressRule{
+		Protocol: rule.Protocol,
+		FromPort: rule.FromPort,
+		ToPort:   rule.ToPort,
+		CIDR:     rule.CIDR,
+	}
+}
+
+// firewallRuleFor returns the firewall rule of the kube that the rule of the
+// security group belongs to.
+func firewallRuleFor(k *model.Kube, groupID string, rule ingressRule) *model.FirewallRule {
+	for i := range k.FirewallRules {
+		if securityGroupID(k, k.FirewallRules[i].Group) == groupID && ingressRuleOf(k.FirewallRules[i]) == rule {
+			return &k.FirewallRules[i]
+		}
+	}
+	return nil
+}
+
+func hasIngressRule(rules []ingressRule, rule ingressRule) bool {
+	for _, r := range rules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) getFirewallRules(w http.ResponseWriter, r *http.Request) {
+	k, ok := h.getKubeOrSend(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := h.FirewallRules(r.Context(), k)
+	if err != nil {
+		sendFirewallError(w, k, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(res); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) addFirewallRule(w http.ResponseWriter, r *http.Request) {
+	rule := model.FirewallRule{}
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	k, ok := h.getKubeOrSend(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := h.AddFirewallRule(r.Context(), k, rule)
+	if err != nil {
+		sendFirewallError(w, k, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(res); err != nil {
+		logrus.Errorf("Error encoding firewall rule %v", err)
+	}
+}
+
+func (h *Handler) removeFirewallRule(w http.ResponseWriter, r *http.Request) {
+	k, ok := h.getKubeOrSend(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.RemoveFirewallRule(r.Context(), k, mux.Vars(r)["name"]); err != nil {
+		sendFirewallError(w, k, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func sendFirewallError(w http.ResponseWriter, k *model.Kube, err error) {
+	switch cause := errors.Cause(err); {
+	case cause == sgerrors.ErrInvalidJson, sgerrors.IsUnsupportedProvider(cause):
+		message.SendValidationFailed(w, err)
+	case cause == sgerrors.ErrAlreadyExists:
+		message.SendAlreadyExists(w, k.ID, err)
+	case sgerrors.IsNotFound(err):
+		message.SendNotFound(w, k.ID, err)
+	default:
+		logrus.Errorf("firewall of kube %s: %v", k.ID, err)
+		message.SendUnknownError(w, err)
+	}
+}
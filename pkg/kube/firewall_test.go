@@ -0,0 +1,86 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestHandler_firewallRules(t *testing.T) {
+	k := driftKube()
+	svc := new(kubeServiceMock)
+	svc.On("Get", mock.Anything, "kube-1").Return(k, nil)
+	svc.On("Create", mock.Anything, mock.Anything).Return(nil)
+	accounts := new(accServiceMock)
+	accounts.On("Get", mock.Anything, "aws").Return(&model.CloudAccount{Provider: clouds.AWS}, nil)
+	h := NewHandler(svc, accounts, nil, nil, nil, nil, nil, nil, "")
+
+	groups := map[string][]ingressRule{
+		"sg-1": {{Protocol: "tcp", FromPort: 22, ToPort: 22, CIDR: "0.0.0.0/0"}},
+		"sg-2": nil,
+	}
+	h.cloudInventory = func(context.Context, *model.Kube, *model.CloudAccount) (*cloudInventory, error) {
+		return &cloudInventory{SecurityGroups: groups}, nil
+	}
+	var applied []steps.FirewallConfig
+	h.applyFirewall = func(_ context.Context, _ *model.Kube, _ *model.CloudAccount, cfg steps.FirewallConfig) error {
+		applied = append(applied, cfg)
+		return nil
+	}
+
+	for _, body := range []string{
+		`{"name":"bad name","group":"masters","protocol":"tcp","fromPort":443,"toPort":443,"cidr":"10.0.0.0/8"}`,
+		`{"name":"office","group":"all","protocol":"tcp","fromPort":443,"toPort":443,"cidr":"10.0.0.0/8"}`,
+		`{"name":"office","group":"masters","protocol":"tcp","fromPort":443,"toPort":80,"cidr":"10.0.0.0/8"}`,
+		`{"name":"office","group":"masters","protocol":"tcp","fromPort":443,"toPort":443,"cidr":"10.0.0.0"}`,
+		`{"name":"office","group":"masters","protocol":"sctp","fromPort":443,"toPort":443,"cidr":"10.0.0.0/8"}`,
+	} {
+		rr := protectionRequest(h, http.MethodPost, "/kubes/kube-1/firewall", body)
+		require.Equal(t, http.StatusBadRequest, rr.Code, body)
+	}
+	require.Empty(t, applied)
+
+	office := model.FirewallRule{Name: "office", Group: model.FirewallMasters, Protocol: "tcp", FromPort: 443, ToPort: 443, CIDR: "10.0.0.0/8"}
+	rr := protectionRequest(h, http.MethodPost, "/kubes/kube-1/firewall",
+		`{"name":"office","group":"masters","protocol":"tcp","fromPort":443,"toPort":443,"cidr":"10.0.0.0/8"}`)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	require.Equal(t, []model.FirewallRule{office}, k.FirewallRules)
+	require.Equal(t, []steps.FirewallConfig{{Authorize: []model.FirewallRule{office}}}, applied)
+
+	rr = protectionRequest(h, http.MethodPost, "/kubes/kube-1/firewall",
+		`{"name":"office","group":"nodes","protocol":"-1","cidr":"10.0.0.0/8"}`)
+	require.Equal(t, http.StatusConflict, rr.Code)
+
+	// the rule has been removed in the console
+	rr = metricsRequest(h, "/kubes/kube-1/firewall")
+	require.Equal(t, http.StatusOK, rr.Code)
+	res := &FirewallRules{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(res))
+	require.Equal(t, []FirewallRuleState{{FirewallRule: office}}, res.Rules)
+	require.Equal(t, []model.FirewallRule{
+		{Group: model.FirewallMasters, Protocol: "tcp", FromPort: 22, ToPort: 22, CIDR: "0.0.0.0/0"},
+	}, res.Other)
+
+	groups["sg-1"] = append(groups["sg-1"], ingressRuleOf(office))
+	rr = metricsRequest(h, "/kubes/kube-1/firewall")
+	res = &FirewallRules{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(res))
+	require.Equal(t, []FirewallRuleState{{FirewallRule: office, Applied: true}}, res.Rules)
+	require.Len(t, res.Other, 1)
+
+	rr = protectionRequest(h, http.MethodDelete, "/kubes/kube-1/firewall/other", "")
+	require.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = protectionRequest(h, http.MethodDelete, "/kubes/kube-1/firewall/office", "")
+	require.Equal(t, http.StatusNoContent, rr.Code)
+	require.Empty(t, k.FirewallRules)
+	require.Equal(t, steps.FirewallConfig{Revoke: []model.FirewallRule{office}}, applied[1])
+}
@@ -0,0 +1,108 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/pki"
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/workflows"
+)
+
+// ErrUnhealthy is returned by health checks of clusters with nodes that
+// aren't ready.
+var ErrUnhealthy = errors.New("cluster is unhealthy")
+
+// RotateCertificates reissues component certificates on machines of the
+// cluster in background and returns IDs of their tasks. Certificates get
+// the kubeadm lifetime unless the cluster configures one.
+func (h *Handler) RotateCertificates(ctx context.Context, k *model.Kube) ([]string, error) {
+	if k.State != model.StateOperational {
+		return nil, ErrNotOperational
+	}
+	if k.RunnerType == runner.SSM {
+		return nil, errors.Wrapf(ErrNoSSH, "cluster %s is managed through ssm", k.ID)
+	}
+
+	config, err := h.newKubeConfig(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+	if config.Kube.Certificates.ValidityDays == 0 {
+		config.Kube.Certificates.ValidityDays = int(pki.DefaultCertValidity / (24 * time.Hour))
+	}
+
+	tasks := h.makeMachineTasks(config, k, workflows.CertificatesRotate)
+	ids := make([]string, 0, len(tasks[workflows.MasterTask])+len(tasks[workflows.NodeTask]))
+	for _, taskSet := range tasks {
+		for _, task := range taskSet {
+			ids = append(ids, task.ID)
+		}
+	}
+
+	go h.logAccessErrors(k.ID, "rotate certificates", tasks)
+
+	return ids, nil
+}
+
+// ApplyManifest applies the yaml manifest on a master of the cluster and
+// returns the ID of the task.
+func (h *Handler) ApplyManifest(ctx context.Context, k *model.Kube, manifest string) (string, error) {
+	if k.State != model.StateOperational {
+		return "", ErrNotOperational
+	}
+
+	config, err := h.newKubeConfig(ctx, k)
+	if err != nil {
+		return "", err
+	}
+	config.ApplyConfig.Data = manifest
+
+	return h.runOnMaster(ctx, k, config, workflows.ApplyYaml)
+}
+
+// CheckHealth reports how many nodes of the cluster are ready, clusters with
+// nodes that aren't ready or have problem conditions are unhealthy.
+func (h *Handler) CheckHealth(ctx context.Context, k *model.Kube) (string, error) {
+	if k.State != model.StateOperational {
+		return "", ErrNotOperational
+	}
+
+	nodes, err := h.svc.ListNodes(ctx, k, "")
+	if err != nil {
+		return "", errors.Wrap(err, "list nodes")
+	}
+
+	var notReady, problems []string
+	for _, node := range nodes {
+		if !nodeReady(node) {
+			notReady = append(notReady, node.Name)
+		} else if p := nodeProblems(node, time.Now()); len(p) > 0 {
+			problems = append(problems, fmt.Sprintf("%s(%s)", node.Name, problemTypes(p)))
+		}
+	}
+
+	summary := fmt.Sprintf("%d/%d nodes are ready", len(nodes)-len(notReady), len(nodes))
+	if len(nodes) == 0 || len(notReady) > 0 {
+		return summary, errors.Wrapf(ErrUnhealthy, "%s, not ready: %v", summary, notReady)
+	}
+	if len(problems) > 0 {
+		return summary, errors.Wrapf(ErrUnhealthy, "%s, problems: %v", summary, problems)
+	}
+
+	return summary, nil
+}
+
+func nodeReady(node corev1.Node) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
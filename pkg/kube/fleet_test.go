@@ -0,0 +1,35 @@
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/storage/memory"
+)
+
+func TestHandler_CheckHealth(t *testing.T) {
+	k := &model.Kube{ID: "kube-1", State: model.StateOperational}
+	nodes := inventoryNodes("node-1", "node-2")
+	nodes[0].Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}
+	svc := new(kubeServiceMock)
+	svc.On("ListNodes", mock.Anything, k, "").Return(nodes, nil)
+	h := NewHandler(svc, nil, nil, nil, nil, nil, memory.NewInMemoryRepository(), nil, "")
+
+	summary, err := h.CheckHealth(context.Background(), k)
+	require.Equal(t, "1/2 nodes are ready", summary)
+	require.Equal(t, ErrUnhealthy, errors.Cause(err))
+
+	nodes[1].Status.Conditions = nodes[0].Status.Conditions
+	summary, err = h.CheckHealth(context.Background(), k)
+	require.NoError(t, err)
+	require.Equal(t, "2/2 nodes are ready", summary)
+
+	_, err = h.CheckHealth(context.Background(), &model.Kube{State: model.StateProvisioning})
+	require.Equal(t, ErrNotOperational, err)
+}
@@ -2,12 +2,14 @@ package kube
 
 import (
 	"context"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -16,24 +18,38 @@ import (
 	"github.com/sirupsen/logrus"
 	"gopkg.in/asaskevich/govalidator.v8"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clientcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmddapi "k8s.io/client-go/tools/clientcmd/api"
 
+	"github.com/supergiant/control/pkg/api"
 	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/dns"
+	"github.com/supergiant/control/pkg/images"
 	"github.com/supergiant/control/pkg/kubeconfig"
+	"github.com/supergiant/control/pkg/labels"
 	"github.com/supergiant/control/pkg/message"
 	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/pricing"
 	"github.com/supergiant/control/pkg/profile"
 	"github.com/supergiant/control/pkg/proxy"
+	"github.com/supergiant/control/pkg/ratelimit"
+	"github.com/supergiant/control/pkg/revision"
+	"github.com/supergiant/control/pkg/settings"
 	"github.com/supergiant/control/pkg/sgerrors"
 	"github.com/supergiant/control/pkg/storage"
+	"github.com/supergiant/control/pkg/terminal"
+	"github.com/supergiant/control/pkg/timeline"
+	"github.com/supergiant/control/pkg/tracing"
 	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/versions"
 	"github.com/supergiant/control/pkg/workflows"
 	"github.com/supergiant/control/pkg/workflows/statuses"
 	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/gracefuldelete"
 )
 
 const (
@@ -62,12 +78,21 @@ type nodeProvisioner interface {
 }
 
 type kubeProvisioner interface {
+	ProvisionCluster(context.Context, *profile.Profile, *steps.Config) (map[string][]*workflows.Task, error)
 	RestartClusterProvisioning(ctx context.Context,
 		clusterProfile *profile.Profile,
 		config *steps.Config,
 		taskIdMap map[string][]string) error
 	UpgradeCluster(context.Context, string, *model.Kube,
 		map[string][]*workflows.Task, *steps.Config)
+	PatchCluster(context.Context, *model.Kube,
+		map[string][]*workflows.Task, *steps.Config) error
+	UpgradeRuntime(context.Context, *model.Kube,
+		map[string][]*workflows.Task, *steps.Config) error
+	MaintainEtcd(context.Context, *model.Kube,
+		map[string][]*workflows.Task, *steps.Config) error
+	RotateSSHKey(context.Context, *model.Kube, map[string][]*workflows.Task,
+		map[string][]*workflows.Task, *steps.Config) error
 }
 
 type ServiceInfo struct {
@@ -105,8 +130,11 @@ type Handler struct {
 	profileSvc      profileSvc
 	chartGetter     ChartRefGetter
 
-	repo    storage.Interface
-	proxies proxy.Container
+	repo      storage.Interface
+	proxies   proxy.Container
+	timeline  *timeline.Service
+	revisions *revision.Service
+	images    *images.Service
 
 	getWriter  func(string) (io.WriteCloser, error)
 	getMetrics func(string, *model.Kube) (*MetricResponse, error)
@@ -115,6 +143,44 @@ type Handler struct {
 	discoverHelmVersion func(kubeConfig *clientcmddapi.Config) (string, error)
 
 	listK8sServices func(*model.Kube, string) (*corev1.ServiceList, error)
+
+	bindClusterRole   func(*model.Kube, *rbacv1.ClusterRoleBinding) error
+	unbindClusterRole func(*model.Kube, string) error
+
+	probeCerts func(addr string) ([]*x509.Certificate, error)
+	certProbes certProbeCache
+
+	newAPIProxy   func(*rest.Config) (http.Handler, error)
+	proxyBindings sync.Map
+
+	nodeTerminal  func(context.Context, *model.Kube, *model.Machine, terminal.Size) (terminal.Stream, error)
+	podTerminal   func(*rest.Config, string, string, string, []string, terminal.Size) (terminal.Stream, error)
+	openRecording func(string) (io.ReadCloser, error)
+
+	estimateCost func(context.Context, *model.Kube) (*pricing.Estimate, error)
+	projectCost  func(context.Context, *model.Kube) (*pricing.Projection, error)
+	postAlert    func(url string, payload interface{}) error
+	// routesMu guards notification routes of runtime settings.
+	routesMu sync.RWMutex
+	routes   []settings.NotificationRoute
+	// featureEnabled reports whether the feature of runtime settings is on.
+	featureEnabled func(string) bool
+	// quotas limit clusters created from specs, clones and imports
+	quotas ratelimit.Reserver
+
+	cloudInventory func(context.Context, *model.Kube, *model.CloudAccount) (*cloudInventory, error)
+	tagInstance    func(context.Context, *model.Kube, *model.CloudAccount, string, map[string]string) error
+	applyFirewall  func(context.Context, *model.Kube, *model.CloudAccount, steps.FirewallConfig) error
+	labelNode      func(*model.Kube, string, map[string]string) error
+	kubeWarnings   func(*model.Kube) ([]timeline.Event, error)
+
+	clusterInstances  func(context.Context, *model.Kube, *model.CloudAccount) ([]cloudInstance, error)
+	terminateInstance func(context.Context, *model.Kube, *model.CloudAccount, cloudInstance) error
+	deleteNode        func(*model.Kube, string) error
+	consoleOutput     func(context.Context, *model.Kube, *model.CloudAccount, *model.Machine) (string, error)
+	taskPollInterval  time.Duration
+
+	dnsProvider func(*model.CloudAccount) (dns.Provider, error)
 }
 
 // NewHandler constructs a Handler for kubes.
@@ -137,6 +203,9 @@ func NewHandler(
 		profileSvc:      profileSvc,
 		chartGetter:     charGetter,
 		repo:            repo,
+		timeline:        timeline.NewService(timeline.DefaultStoragePrefix, repo),
+		revisions:       revision.NewService(revision.DefaultStoragePrefix, repo),
+		images:          images.NewService(images.DefaultStoragePrefix, repo),
 		getWriter:       util.GetWriterFunc(logDir),
 		getMetrics: func(metricURI string, k *model.Kube) (*MetricResponse, error) {
 			cfg, err := kubeconfig.NewConfigFor(k)
@@ -177,6 +246,52 @@ func NewHandler(
 		discoverK8SVersion:  discoverK8SVersion,
 		discoverHelmVersion: discoverHelmVersion,
 		proxies:             proxies,
+		bindClusterRole:     bindClusterRole,
+		unbindClusterRole:   unbindClusterRole,
+		probeCerts:          probeCerts,
+		newAPIProxy:         newAPIProxy,
+		nodeTerminal:        nodeTerminal,
+		podTerminal:         podTerminal,
+		openRecording:       openRecording(logDir),
+		estimateCost:        pricing.Default.EstimateKube,
+		projectCost:         pricing.Default.ProjectKube,
+		postAlert:           postAlert,
+		cloudInventory:      cloudInventoryOf,
+		tagInstance:         tagInstance,
+		applyFirewall:       applyFirewallRules,
+		labelNode:           labelNode,
+		kubeWarnings:        kubeWarnings,
+		clusterInstances:    clusterInstancesOf,
+		terminateInstance:   terminateInstance,
+		deleteNode:          deleteNode,
+		consoleOutput:       consoleOutputOf,
+		taskPollInterval:    taskPollInterval,
+		dnsProvider:         dns.New,
+		featureEnabled:      func(string) bool { return true },
+	}
+}
+
+// SetFeatureFlags makes features of kubes follow the flags, all features
+// are on without them.
+func (h *Handler) SetFeatureFlags(enabled func(feature string) bool) {
+	h.featureEnabled = enabled
+}
+
+// SetQuotas applies quotas of users to clusters created from specs,
+// clones and imports, they aren't limited if it's not set.
+func (h *Handler) SetQuotas(quotas ratelimit.Reserver) {
+	h.quotas = quotas
+}
+
+// feature serves requests only while the feature is on, the route looks
+// missing otherwise.
+func (h *Handler) feature(name string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.featureEnabled(name) {
+			message.SendNotFound(w, name, errors.Wrapf(sgerrors.ErrNotFound, "feature %s is turned off", name))
+			return
+		}
+		next(w, r)
 	}
 }
 
@@ -185,21 +300,35 @@ func (h *Handler) Register(r *mux.Router) {
 	r.HandleFunc("/kubes", h.createKube).Methods(http.MethodPost)
 	r.HandleFunc("/kubes", h.listKubes).Methods(http.MethodGet)
 	r.HandleFunc("/kubes/import", h.importKube).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/certificates/expiry", h.certificatesExpiry).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/metrics/capacity", h.getCapacity).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/capacity/plan", h.getCapacityPlan).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/versions/drift", h.getVersionDrift).Methods(http.MethodGet)
+	r.HandleFunc("/resources", h.searchResources).Methods(http.MethodGet)
 	r.HandleFunc("/kubes/{kubeID}", h.getKube).Methods(http.MethodGet)
 	r.HandleFunc("/kubes/{kubeID}", h.deleteKube).Methods(http.MethodDelete)
 
-	r.HandleFunc("/kubes/{kubeID}/users/{uname}/kubeconfig", h.getKubeconfig).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/users/{uname}/kubeconfig", api.AdminOnly(h.getKubeconfig)).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/kubeconfig", h.issueKubeconfig).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/kubeconfigs", h.listKubeconfigs).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/kubeconfigs/{credentialID}", h.revokeKubeconfig).Methods(http.MethodDelete)
 
 	r.HandleFunc("/kubes/{kubeID}/resources", h.listResources).Methods(http.MethodGet)
 	r.HandleFunc("/kubes/{kubeID}/resources/{resource}", h.getResource).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/resources/{resource}", h.createResource).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/resources/{resource}/{name}", h.getResource).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/resources/{resource}/{name}", h.patchResource).Methods(http.MethodPatch)
+	r.HandleFunc("/kubes/{kubeID}/resources/{resource}/{name}", h.deleteResource).Methods(http.MethodDelete)
 
 	r.HandleFunc("/kubes/{kubeID}/releases", h.installRelease).Methods(http.MethodPost)
 	r.HandleFunc("/kubes/{kubeID}/releases", h.listReleases).Methods(http.MethodGet)
 	r.HandleFunc("/kubes/{kubeID}/releases/{releaseName}", h.getRelease).Methods(http.MethodGet)
 	r.HandleFunc("/kubes/{kubeID}/releases/{releaseName}", h.deleteReleases).Methods(http.MethodDelete)
+	r.HandleFunc("/kubes/{kubeID}/releases/{releaseName}/preview", h.previewRelease).Methods(http.MethodPost)
 
 	r.HandleFunc("/kubes/{kubeID}/certs/{cname}", h.getCerts).Methods(http.MethodGet)
 	r.HandleFunc("/kubes/{kubeID}/tasks", h.getTasks).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/events", h.getEvents).Methods(http.MethodGet)
 
 	// DEPRECATED: has been moved to /kubes/{kubeID}/machines
 	r.HandleFunc("/kubes/{kubeID}/nodes", h.addMachine).Methods(http.MethodPost)
@@ -211,16 +340,90 @@ func (h *Handler) Register(r *mux.Router) {
 
 	r.HandleFunc("/kubes/{kubeID}/machines", h.addMachine).Methods(http.MethodPost)
 	r.HandleFunc("/kubes/{kubeID}/machines/{nodename}", h.deleteMachine).Methods(http.MethodDelete)
+	r.HandleFunc("/kubes/{kubeID}/machines/{nodename}/protection", h.setMachineProtection).Methods(http.MethodPut)
+	r.HandleFunc("/kubes/{kubeID}/machines/{nodename}/console", h.getConsoleOutput).Methods(http.MethodGet)
 
 	r.HandleFunc("/kubes/{kubeID}/spot", h.addSpotMachine).Methods(http.MethodPost)
 	r.HandleFunc("/kubes/{kubeID}/spot/{machineType}/price", h.spotMachinePrice).Methods(http.MethodGet)
 
 	r.HandleFunc("/kubes/{kubeID}/nodes/metrics", h.getNodesMetrics).Methods(http.MethodGet)
 	r.HandleFunc("/kubes/{kubeID}/metrics", h.getClusterMetrics).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/metrics/nodes", h.getNodeMetrics).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/metrics/pods", h.getPodMetrics).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/cost", h.getCost).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/budget", h.getBudget).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/budget", h.setBudget).Methods(http.MethodPut)
+	r.HandleFunc("/kubes/{kubeID}/recommendations", h.getRecommendations).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/spec", h.getSpec).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/spec/revisions", h.listSpecRevisions).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/spec/revisions/{number}", h.getSpecRevision).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/spec/revisions/{number}/revert", h.revertSpec).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/spec/diff", h.diffSpec).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/clone", h.cloneKube).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/images", h.bakeImage).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/drift", h.getDrift).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/drift", h.setDriftPolicy).Methods(http.MethodPut)
+	r.HandleFunc("/kubes/{kubeID}/schedules", h.listWorkflowSchedules).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/schedules", h.addWorkflowSchedule).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/schedules/{name}", h.getWorkflowSchedule).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/schedules/{name}", h.updateWorkflowSchedule).Methods(http.MethodPut)
+	r.HandleFunc("/kubes/{kubeID}/schedules/{name}", h.removeWorkflowSchedule).Methods(http.MethodDelete)
+	r.HandleFunc("/kubes/{kubeID}/firewall", h.getFirewallRules).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/firewall", h.addFirewallRule).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/firewall/{name}", h.removeFirewallRule).Methods(http.MethodDelete)
+	r.HandleFunc("/kubes/{kubeID}/inventory", h.getInventory).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/inventory/{name}/{action}", h.resolveInventoryItem).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/health", h.getKubeHealth).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/protection", h.setProtection).Methods(http.MethodPut)
+	r.HandleFunc("/kubes/{kubeID}/statuspage", h.feature(settings.FeatureStatusPages, h.enableStatusPage)).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/statuspage", h.disableStatusPage).Methods(http.MethodDelete)
+	r.HandleFunc("/kubes/{kubeID}/endpoint/dns", h.addEndpointName).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/endpoint/dns/{name}", h.deleteEndpointName).Methods(http.MethodDelete)
+	r.HandleFunc("/kubes/{kubeID}/export/terraform", h.exportTerraform).Methods(http.MethodGet)
 	r.HandleFunc("/kubes/{kubeID}/services", h.getServices).Methods(http.MethodGet)
 	r.HandleFunc("/kubes/{kubeID}/restart", h.restartKubeProvisioning).Methods(http.MethodPost)
 	r.HandleFunc("/kubes/{kubeID}", h.upgradeKube).Methods(http.MethodPatch)
+	r.HandleFunc("/kubes/{kubeID}/upgrade/preflight", h.getUpgradePreflight).Methods(http.MethodGet)
 	r.HandleFunc("/kubes/{kubeID}/apply", h.applyToKube).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/compliance", h.runComplianceCheck).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/compliance", h.getComplianceReport).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/ospatch", h.patchKube).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/ospatch", h.getOSPatchConfig).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/ospatch", h.setOSPatchConfig).Methods(http.MethodPut)
+	r.HandleFunc("/kubes/{kubeID}/runtime", h.upgradeRuntime).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/etcd/members/{nodename}/replace", h.replaceEtcdMember).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/etcd/maintenance", h.runEtcdMaintenance).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/etcd/maintenance", h.getEtcdMaintenance).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/etcd/maintenance", h.setEtcdMaintenance).Methods(http.MethodPut)
+	r.HandleFunc("/kubes/{kubeID}/maintenance", h.getMaintenanceWindow).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/maintenance", h.setMaintenanceWindow).Methods(http.MethodPut)
+	r.HandleFunc("/kubes/{kubeID}/channel", h.getReleaseChannel).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/channel", h.setReleaseChannel).Methods(http.MethodPut)
+	r.HandleFunc("/kubes/{kubeID}/ssh/rotate", h.rotateSSHKey).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/ssh/audit", h.auditSSHKeys).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/ssh/audit", h.getSSHKeyAudit).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/ssh/operatorkeys", h.listOperatorKeys).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/ssh/operatorkeys", h.addOperatorKey).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/ssh/operatorkeys/{name}", h.removeOperatorKey).Methods(http.MethodDelete)
+	r.HandleFunc("/kubes/{kubeID}/backup", h.installVelero).Methods(http.MethodPut)
+	r.HandleFunc("/kubes/{kubeID}/backup", h.getBackupConfig).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/backups", h.listBackups).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/backups", h.createBackup).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/backups/schedules", h.listSchedules).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/backups/schedules", h.createSchedule).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/backups/schedules/{name}", h.deleteSchedule).Methods(http.MethodDelete)
+	r.HandleFunc("/kubes/{kubeID}/restores", h.listRestores).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/restores", h.createRestore).Methods(http.MethodPost)
+	r.HandleFunc("/kubes/{kubeID}/bootstraptokens", h.listBootstrapTokens).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/bootstraptokens/{tokenID}", h.revokeBootstrapToken).Methods(http.MethodDelete)
+	r.HandleFunc("/kubes/{kubeID}/team", api.AdminOnly(h.setTeam)).Methods(http.MethodPut)
+	r.HandleFunc("/kubes/{kubeID}/labels", h.setLabels).Methods(http.MethodPut)
+
+	r.PathPrefix("/kubes/{kubeID}/proxy/").HandlerFunc(h.proxyAPI)
+	r.HandleFunc("/kubes/{kubeID}/machines/{nodename}/terminal", h.feature(settings.FeatureTerminals, h.openNodeTerminal)).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/namespaces/{namespace}/pods/{pod}/terminal", h.feature(settings.FeatureTerminals, h.openPodTerminal)).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/terminals", h.listTerminalSessions).Methods(http.MethodGet)
+	r.HandleFunc("/kubes/{kubeID}/terminals/{sessionID}/recording", h.getTerminalRecording).Methods(http.MethodGet)
 }
 
 func (h *Handler) getTasks(w http.ResponseWriter, r *http.Request) {
@@ -256,7 +459,7 @@ func (h *Handler) getTasks(w http.ResponseWriter, r *http.Request) {
 		StepStatuses []workflows.StepStatus `json:"stepsStatuses"`
 	}
 
-	resp := make([]taskDTO, 0, len(tasks))
+	resp := make([]interface{}, 0, len(tasks))
 
 	for _, task := range tasks {
 		resp = append(resp, taskDTO{
@@ -266,9 +469,7 @@ func (h *Handler) getTasks(w http.ResponseWriter, r *http.Request) {
 			StepStatuses: task.StepStatuses,
 		})
 	}
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	sendPage(w, r, resp)
 }
 
 func (h *Handler) createKube(w http.ResponseWriter, r *http.Request) {
@@ -284,6 +485,15 @@ func (h *Handler) createKube(w http.ResponseWriter, r *http.Request) {
 		message.SendValidationFailed(w, err)
 		return
 	}
+	if err = labels.Validate(newKube.Labels); err != nil {
+		message.SendValidationFailed(w, sgerrors.WithField(err, "labels"))
+		return
+	}
+
+	if newKube.Team, err = api.ScopeFrom(r.Context()).TeamFor(newKube.Team); err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
 
 	existingKube, err := h.svc.Get(r.Context(), newKube.ID)
 	if existingKube != nil {
@@ -347,18 +557,64 @@ func (h *Handler) getKube(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if !secretsVisible(r.Context()) {
+		k = withoutSecrets(k)
+	}
 	if err = json.NewEncoder(w).Encode(k); err != nil {
 		message.SendUnknownError(w, err)
 	}
 }
 
+// visibleKubes lists all kubes in the scope of the request, it's used by
+// reports across clusters.
+func (h *Handler) visibleKubes(ctx context.Context) ([]model.Kube, error) {
+	kubes, err := h.svc.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := api.ScopeFrom(ctx)
+	if scope.Admin {
+		return kubes, nil
+	}
+	visible := make([]model.Kube, 0, len(kubes))
+	for _, k := range kubes {
+		if scope.AllowsLabeled(k.Team, k.Labels) {
+			visible = append(visible, k)
+		}
+	}
+	return visible, nil
+}
+
 func (h *Handler) listKubes(w http.ResponseWriter, r *http.Request) {
-	kubes, err := h.svc.ListAll(r.Context())
+	opts, err := storage.ParseListOptions(r.URL.Query())
 	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	if scope := api.ScopeFrom(r.Context()); !scope.Admin {
+		opts.Filter = scope.Filter
+	}
+
+	kubes, next, err := h.svc.List(r.Context(), opts)
+	if err != nil {
+		if errors.Cause(err) == storage.ErrInvalidContinue {
+			message.SendValidationFailed(w, err)
+			return
+		}
 		message.SendUnknownError(w, err)
 		return
 	}
 
+	if next != "" {
+		w.Header().Set(storage.ContinueHeader, next)
+	}
+	if !secretsVisible(r.Context()) {
+		for i := range kubes {
+			kubes[i] = *withoutSecrets(&kubes[i])
+		}
+	}
 	if err = json.NewEncoder(w).Encode(kubes); err != nil {
 		message.SendUnknownError(w, err)
 	}
@@ -377,8 +633,10 @@ func (h *Handler) deleteKube(w http.ResponseWriter, r *http.Request) {
 		forceDelete, _ = strconv.ParseBool(forceString)
 	}
 
-	if err := h.nodeProvisioner.Cancel(kubeID); err != nil {
-		logrus.Debugf("cancel kube tasks error %v", err)
+	deleteConfig, err := parseDeleteConfig(r)
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
 	}
 
 	k, err := h.svc.Get(r.Context(), kubeID)
@@ -391,6 +649,14 @@ func (h *Handler) deleteKube(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !checkDelete(w, r, k, nil) {
+		return
+	}
+
+	if err := h.nodeProvisioner.Cancel(kubeID); err != nil {
+		logrus.Debugf("cancel kube tasks error %v", err)
+	}
+
 	acc, err := h.accountService.Get(r.Context(), k.AccountName)
 
 	if err != nil {
@@ -408,6 +674,11 @@ func (h *Handler) deleteKube(w http.ResponseWriter, r *http.Request) {
 		CloudAccountName: k.AccountName,
 		Masters:          steps.NewMap(k.Masters),
 		Nodes:            steps.NewMap(k.Nodes),
+		DeleteConfig:     deleteConfig,
+	}
+	// workloads of clusters that aren't running can't release anything
+	if k.State != model.StateOperational {
+		config.DeleteConfig.Mode = steps.DeleteForce
 	}
 
 	t, err := workflows.NewTask(config, workflows.DeleteCluster, h.repo)
@@ -449,7 +720,11 @@ func (h *Handler) deleteKube(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, _ := context.WithTimeout(context.Background(), time.Minute*10)
+	timeout := time.Minute * 10
+	if config.DeleteConfig.Mode == steps.DeleteGraceful {
+		timeout += deleteConfig.StepTimeout * 3
+	}
+	ctx, _ := context.WithTimeout(context.Background(), timeout)
 	errChan := t.Run(ctx, *config, writer)
 
 	go func(t *workflows.Task) {
@@ -473,11 +748,44 @@ func (h *Handler) deleteKube(w http.ResponseWriter, r *http.Request) {
 		if err := h.cleanUpKube(kubeID); err != nil {
 			logrus.Errorf("clean up kube %s caused %v", kubeID, err)
 		}
+		timeline.Export(&timeline.Event{
+			KubeID:  kubeID,
+			Type:    timeline.ClusterDeleted,
+			Message: fmt.Sprintf("cluster %s has been deleted", k.Name),
+		})
 	}(t)
 
 	w.WriteHeader(http.StatusAccepted)
 }
 
+// parseDeleteConfig reads the deletion mode and the timeout of graceful
+// steps, the deletion is graceful by default.
+func parseDeleteConfig(r *http.Request) (steps.DeleteConfig, error) {
+	cfg := steps.DeleteConfig{
+		Mode:        steps.DeleteGraceful,
+		StepTimeout: gracefuldelete.DefaultStepTimeout,
+	}
+
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "":
+	case steps.DeleteGraceful, steps.DeleteForce:
+		cfg.Mode = mode
+	default:
+		return cfg, errors.Errorf("unknown deletion mode %q, use %s or %s",
+			mode, steps.DeleteGraceful, steps.DeleteForce)
+	}
+
+	if s := r.URL.Query().Get("stepTimeout"); s != "" {
+		timeout, err := time.ParseDuration(s)
+		if err != nil || timeout <= 0 {
+			return cfg, errors.Errorf("invalid step timeout %q", s)
+		}
+		cfg.StepTimeout = timeout
+	}
+
+	return cfg, nil
+}
+
 func (h *Handler) getKubeconfig(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 
@@ -527,8 +835,22 @@ func (h *Handler) getResource(w http.ResponseWriter, r *http.Request) {
 	rs := vars["resource"]
 	ns := r.URL.Query().Get("namespace")
 	name := r.URL.Query().Get("name")
+	if vars["name"] != "" {
+		name = vars["name"]
+	}
 
-	rawResources, err := h.svc.GetKubeResources(r.Context(), kubeID, rs, ns, name)
+	opts, paginated, err := listOptions(r)
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	var rawResources []byte
+	if name == "" && paginated {
+		rawResources, err = h.svc.ListResources(r.Context(), kubeID, rs, opts)
+	} else {
+		rawResources, err = h.svc.GetKubeResources(r.Context(), kubeID, rs, ns, name)
+	}
 	if err != nil {
 		if sgerrors.IsNotFound(err) {
 			message.SendNotFound(w, kubeID, err)
@@ -585,9 +907,11 @@ func (h *Handler) listNodes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err = json.NewEncoder(w).Encode(nodes); err != nil {
-		message.SendUnknownError(w, err)
+	items := make([]interface{}, 0, len(nodes))
+	for _, node := range nodes {
+		items = append(items, node)
 	}
+	sendPage(w, r, items)
 }
 
 // Add node to working kube
@@ -650,7 +974,7 @@ func (h *Handler) addMachine(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, _ := context.WithTimeout(context.Background(), time.Minute*60)
+	ctx, _ := context.WithTimeout(tracing.Detach(r.Context()), time.Minute*60)
 	tasks, err := h.nodeProvisioner.ProvisionNodes(ctx, nodeProfiles,
 		k, config)
 
@@ -701,19 +1025,25 @@ func (h *Handler) deleteMachine(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// TODO(stgleb): check whether we will have quorum of master nodes if node is deleted.
-	if _, ok := k.Masters[nodeName]; ok {
-		http.Error(w, "delete master node not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	var n *model.Machine
+	machines, workflow := k.Nodes, workflows.DeleteNode
 
-	if n = k.Nodes[nodeName]; n == nil {
+	if n = k.Masters[nodeName]; n != nil {
+		// etcd quorum of the remaining masters is checked by the etcd member remove step
+		if len(k.Masters) < 2 {
+			http.Error(w, "delete the last master node not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		machines, workflow = k.Masters, workflows.DeleteMaster
+	} else if n = k.Nodes[nodeName]; n == nil {
 		http.NotFound(w, r)
 		return
 	}
 
+	if !checkDelete(w, r, k, n) {
+		return
+	}
+
 	acc, err := h.accountService.Get(r.Context(), k.AccountName)
 
 	if err != nil {
@@ -737,7 +1067,7 @@ func (h *Handler) deleteMachine(w http.ResponseWriter, r *http.Request) {
 		Masters:          steps.NewMap(k.Masters),
 	}
 
-	t, err := workflows.NewTask(config, workflows.DeleteNode, h.repo)
+	t, err := workflows.NewTask(config, workflow, h.repo)
 	if err != nil {
 		if sgerrors.IsNotFound(err) {
 			http.NotFound(w, r)
@@ -776,14 +1106,14 @@ func (h *Handler) deleteMachine(w http.ResponseWriter, r *http.Request) {
 	// Update cluster state when deletion completes
 	go func() {
 		// Set node to deleting state
-		nodeToDelete, ok := k.Nodes[nodeName]
+		nodeToDelete, ok := machines[nodeName]
 
 		if !ok {
 			logrus.Errorf("Node %s not found", nodeName)
 			return
 		}
 		nodeToDelete.State = model.MachineStateDeleting
-		k.Nodes[nodeName] = nodeToDelete
+		machines[nodeName] = nodeToDelete
 		err := h.svc.Create(context.Background(), k)
 
 		if err != nil {
@@ -794,10 +1124,23 @@ func (h *Handler) deleteMachine(w http.ResponseWriter, r *http.Request) {
 
 		if err != nil {
 			logrus.Errorf("delete node %s from cluster %s caused %v", nodeName, kubeID, err)
+
+			// Keep the master so deletion can be retried, its etcd member may be still in the cluster
+			if workflow == workflows.DeleteMaster {
+				nodeToDelete.State = model.MachineStateError
+				if err := h.svc.Create(context.Background(), k); err != nil {
+					logrus.Errorf("update cluster %s caused %v", kubeID, err)
+				}
+				return
+			}
 		}
 
 		// Delete node from cluster object
-		delete(k.Nodes, nodeName)
+		delete(machines, nodeName)
+		// Keep the static ip for the master that replaces it
+		if nodeToDelete.StaticIP != nil {
+			k.FreeStaticIPs = append(k.FreeStaticIPs, *nodeToDelete.StaticIP)
+		}
 		// Save cluster object to etcd
 		logrus.Infof("delete node %s from cluster %s", nodeName, kubeID)
 		err = h.svc.Create(context.Background(), k)
@@ -805,6 +1148,13 @@ func (h *Handler) deleteMachine(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			logrus.Errorf("update cluster %s caused %v", kubeID, err)
 		}
+
+		h.recordEvent(context.Background(), &timeline.Event{
+			KubeID:  kubeID,
+			Type:    timeline.NodeRemoved,
+			Object:  nodeName,
+			Message: fmt.Sprintf("%s %s has been removed from the cluster", n.Role, nodeName),
+		})
 	}()
 	w.WriteHeader(http.StatusAccepted)
 }
@@ -1358,6 +1708,11 @@ func (h *Handler) importKube(w http.ResponseWriter, r *http.Request) {
 		Profile          profile.Profile `json:"profile" valid:"-"`
 	}
 
+	if isSpec(r) {
+		h.importSpec(w, r)
+		return
+	}
+
 	var req importRequest
 	var err error
 
@@ -1391,6 +1746,11 @@ func (h *Handler) importKube(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Profile.Team, err = api.ScopeFrom(r.Context()).TeamFor(req.Profile.Team); err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
 	req.Profile.HelmVersion = helmVersion
 	config, err := steps.NewConfig(req.ClusterName, req.CloudAccountName, req.Profile)
 
@@ -1421,6 +1781,10 @@ func (h *Handler) importKube(w http.ResponseWriter, r *http.Request) {
 	}
 
 	cloudAccount, err := h.accountService.Get(r.Context(), req.CloudAccountName)
+	// accounts of other teams look like missing ones
+	if err == nil && !api.ScopeFrom(r.Context()).AllowsLabeled(cloudAccount.Team, cloudAccount.Labels) {
+		err = sgerrors.ErrNotFound
+	}
 
 	if err != nil {
 		if sgerrors.IsNotFound(err) {
@@ -1447,7 +1811,19 @@ func (h *Handler) importKube(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	owner, release, err := ratelimit.Reserve(r.Context(), h.quotas)
+	if err != nil {
+		if sgerrors.IsQuotaExceeded(err) {
+			message.SendQuotaExceeded(w, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+	defer release()
+
 	config.Kube.ID = clusterID
+	config.Kube.Owner = owner
 	config.IsBootstrap = true
 	config.Kube.SSHConfig.BootstrapPrivateKey = req.PrivateKey
 	config.Kube.SSHConfig.PublicKey = req.PublicKey
@@ -1564,58 +1940,189 @@ func (h *Handler) upgradeKube(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logrus.Debugf("Get cloud profile %s", k.ProfileID)
-	kubeProfile, err := h.profileSvc.Get(r.Context(), k.ProfileID)
+	if !checkMaintenance(w, r, k) {
+		return
+	}
 
+	nextVersion := findNextMinorVersion(k.K8SVersion, versions.Default().Versions())
+
+	if nextVersion == "" {
+		http.Error(w, fmt.Sprintf("can't upgrade from version %s", k.K8SVersion), http.StatusBadRequest)
+		return
+	}
+
+	if nextVersion == k.K8SVersion {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err = versions.Default().CheckUpgrade(k.K8SVersion, nextVersion, k.Networking.Provider); err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	strategy, err := parseUpgradeStrategy(r)
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	if !h.checkUpgradePreflight(w, r, k, nextVersion) {
+		return
+	}
+
+	node2TaskMap, err := h.UpgradeVersion(r.Context(), k, nextVersion, strategy)
 	if err != nil {
 		if sgerrors.IsNotFound(err) {
 			message.SendNotFound(w, k.ProfileID, err)
 			return
 		}
-
+		if sgerrors.IsProtected(err) {
+			message.SendProtected(w, k.Name, err)
+			return
+		}
+		if errors.Cause(err) == sgerrors.ErrInvalidJson {
+			message.SendValidationFailed(w, err)
+			return
+		}
 		message.SendUnknownError(w, err)
 		return
 	}
 
-	config, err := steps.NewConfigFromKube(kubeProfile, k)
+	// here we are ready for async part
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(node2TaskMap); err != nil {
+		logrus.Errorf("Error encoding task map %v", err)
+	}
+}
 
-	if err != nil {
-		logrus.Errorf("New config %v", err.Error())
-		message.SendUnknownError(w, err)
-		return
+// parseUpgradeStrategy returns the strategy of the strategy parameter,
+// masters are upgraded in place by default.
+func parseUpgradeStrategy(r *http.Request) (string, error) {
+	switch strategy := r.URL.Query().Get("strategy"); strategy {
+	case "":
+		return steps.UpgradeInPlace, nil
+	case steps.UpgradeInPlace, steps.UpgradeBlueGreen:
+		return strategy, nil
+	default:
+		return "", errors.Errorf("unknown upgrade strategy %q, use %s or %s",
+			strategy, steps.UpgradeInPlace, steps.UpgradeBlueGreen)
 	}
+}
 
-	// Load things specific to cloud provider
-	err = util.LoadCloudSpecificDataFromKube(k, config)
+// UpgradeVersion starts the upgrade of the cluster to the version in
+// background and returns IDs of tasks by machine names, tasks of masters
+// that replace old ones in the blue/green upgrade are returned by their
+// IDs. The version isn't checked against the catalog.
+func (h *Handler) UpgradeVersion(ctx context.Context, k *model.Kube, version, strategy string) (map[string]string, error) {
+	logrus.Debugf("Get cloud profile %s", k.ProfileID)
+	kubeProfile, err := h.profileSvc.Get(ctx, k.ProfileID)
+	if err != nil {
+		return nil, err
+	}
 
+	config, err := steps.NewConfigFromKube(kubeProfile, k)
 	if err != nil {
-		message.SendUnknownError(w, err)
-		return
+		return nil, errors.Wrap(err, "new config")
 	}
 
-	nextVersion := findNextMinorVersion(k.K8SVersion, clouds.GetVersions())
+	// Load things specific to cloud provider
+	if err = util.LoadCloudSpecificDataFromKube(k, config); err != nil {
+		return nil, errors.Wrap(err, "load cloud specific data")
+	}
 
-	if nextVersion == "" {
-		http.Error(w, fmt.Sprintf("can't upgrade from version %s", k.K8SVersion), http.StatusBadRequest)
-		return
+	if err = checkUpgradeCanary(kubeProfile.UpgradeCanary, k); err != nil {
+		return nil, err
 	}
+	config.UpgradeCanary = kubeProfile.UpgradeCanary
 
-	if nextVersion == k.K8SVersion {
-		w.WriteHeader(http.StatusNoContent)
-		return
+	config.UpgradeConfig.Strategy = strategy
+	if strategy == steps.UpgradeBlueGreen {
+		if err = checkBlueGreen(k); err != nil {
+			return nil, err
+		}
+
+		// new masters are created with the cloud account of the cluster
+		acc, err := h.accountService.Get(ctx, k.AccountName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get cloud account %s", k.AccountName)
+		}
+		if err = util.FillCloudAccountCredentials(acc, config); err != nil {
+			return nil, errors.Wrap(err, "fill cloud account credentials")
+		}
+		config.UpgradeConfig.MasterProfiles = replacementProfiles(kubeProfile.MasterProfiles, len(k.Masters))
 	}
 
-	config.Kube.K8SVersion = nextVersion
+	config.Kube.K8SVersion = version
 	tasks := h.makeUpgradeTasks(config, k)
 
-	go h.kubeProvisioner.UpgradeCluster(context.Background(), nextVersion, k, tasks, config)
-	node2TaskMap := mapNode2Task(tasks)
+	h.recordEvent(ctx, &timeline.Event{
+		KubeID:  k.ID,
+		Type:    timeline.UpgradeStarted,
+		Message: fmt.Sprintf("cluster upgrade from %s to %s has started", k.K8SVersion, version),
+	})
 
-	// here we are ready for async part
-	w.WriteHeader(http.StatusAccepted)
-	if err := json.NewEncoder(w).Encode(node2TaskMap); err != nil {
-		logrus.Errorf("Error encoding task map %v", err)
+	go h.kubeProvisioner.UpgradeCluster(context.Background(), version, k, tasks, config)
+	return mapNode2Task(tasks), nil
+}
+
+// checkUpgradeCanary checks that the cluster has nodes of the canary group,
+// otherwise all nodes would be upgraded without verification.
+func checkUpgradeCanary(canary profile.UpgradeCanary, k *model.Kube) error {
+	if !canary.Enabled || canary.NodeGroup == "" {
+		return nil
+	}
+
+	for _, node := range k.Nodes {
+		if node.NodeGroup == canary.NodeGroup && node.OperatingSystem != model.OSWindows {
+			return nil
+		}
 	}
+	return sgerrors.WithField(errors.Wrapf(sgerrors.ErrInvalidJson,
+		"cluster has no linux nodes of the canary group %s", canary.NodeGroup), "upgradeCanary")
+}
+
+// checkBlueGreen checks that masters of the cluster can be replaced: new
+// masters join with the certificate key of the cluster and old masters are
+// deleted.
+func checkBlueGreen(k *model.Kube) error {
+	if k.Auth.CertificateKey == "" {
+		return sgerrors.WithField(errors.Wrap(sgerrors.ErrInvalidJson,
+			"masters of the cluster can't be replaced, it has no certificate key"), "strategy")
+	}
+
+	switch k.Provider {
+	case clouds.AWS, clouds.GCE, clouds.DigitalOcean, clouds.Azure, clouds.Fake:
+	default:
+		return sgerrors.WithField(errors.Wrapf(sgerrors.ErrInvalidJson,
+			"blue/green upgrade isn't supported on %s", k.Provider), "strategy")
+	}
+
+	for _, m := range k.Masters {
+		if m.Protected {
+			return errors.Wrapf(sgerrors.ErrProtected, "machine %s", m.Name)
+		}
+		if m.State != model.MachineStateActive {
+			return sgerrors.WithField(errors.Wrapf(sgerrors.ErrInvalidJson,
+				"master %s is %s", m.Name, m.State), "strategy")
+		}
+	}
+
+	return nil
+}
+
+// replacementProfiles returns profiles of count masters, profiles of the
+// cluster profile are repeated when the cluster has more masters.
+func replacementProfiles(masterProfiles []profile.NodeProfile, count int) []profile.NodeProfile {
+	if len(masterProfiles) == 0 {
+		return nil
+	}
+
+	result := make([]profile.NodeProfile, 0, count)
+	for i := 0; i < count; i++ {
+		result = append(result, masterProfiles[i%len(masterProfiles)])
+	}
+	return result
 }
 
 func (h *Handler) makeUpgradeTasks(config *steps.Config, k *model.Kube) map[string][]*workflows.Task {
@@ -1623,25 +2130,34 @@ func (h *Handler) makeUpgradeTasks(config *steps.Config, k *model.Kube) map[stri
 	nodeTasks := make([]*workflows.Task, 0, len(k.Nodes))
 	//some clouds (e.g. AWS) requires running tasks before provisioning nodes (creating a VPC, Subnets, SecGroups, etc)
 
-	for _, masterMachine := range k.Masters {
-		masterTask, err := workflows.NewTask(config, workflows.Upgrade, h.repo)
-		if err != nil {
-			logrus.Errorf("Failed to set up task for %s workflow", workflows.ProvisionMaster)
-			continue
-		}
+	if config.UpgradeConfig.Strategy == steps.UpgradeBlueGreen {
+		masterTasks = h.makeReplacementTasks(config, len(k.Masters))
+	} else {
+		for _, masterMachine := range k.Masters {
+			masterTask, err := workflows.NewTask(config, workflows.Upgrade, h.repo)
+			if err != nil {
+				logrus.Errorf("Failed to set up task for %s workflow", workflows.ProvisionMaster)
+				continue
+			}
 
-		cfg := *config
-		cfg.Node = *masterMachine
-		cfg.IsMaster = true
-		cfg.IsBootstrap = false
-		masterTask.Config = &cfg
-		// Note(stgleb): Reuse task ID for machine provisioning that will allow to browse
-		// logs of machine upgrade without changes on the UI
-		masterTask.ID = masterMachine.TaskID
-		masterTasks = append(masterTasks, masterTask)
+			cfg := *config
+			cfg.Node = *masterMachine
+			cfg.IsMaster = true
+			cfg.IsBootstrap = false
+			masterTask.Config = &cfg
+			// Note(stgleb): Reuse task ID for machine provisioning that will allow to browse
+			// logs of machine upgrade without changes on the UI
+			masterTask.ID = masterMachine.TaskID
+			masterTasks = append(masterTasks, masterTask)
+		}
 	}
 
 	for _, nodeMachine := range k.Nodes {
+		// windows nodes are upgraded by replacing them
+		if nodeMachine.OperatingSystem == model.OSWindows {
+			continue
+		}
+
 		nodeTask, err := workflows.NewTask(config, workflows.Upgrade, h.repo)
 		if err != nil {
 			logrus.Errorf("Failed to set up task for %s workflow", workflows.ProvisionNode)
@@ -1666,6 +2182,27 @@ func (h *Handler) makeUpgradeTasks(config *steps.Config, k *model.Kube) map[stri
 	return taskMap
 }
 
+// makeReplacementTasks returns tasks of masters that replace count old
+// masters of the cluster in the blue/green upgrade.
+func (h *Handler) makeReplacementTasks(config *steps.Config, count int) []*workflows.Task {
+	tasks := make([]*workflows.Task, 0, count)
+	for i := 0; i < count; i++ {
+		cfg := *config
+		cfg.Node = model.Machine{}
+		cfg.IsMaster = true
+		cfg.IsBootstrap = false
+
+		task, err := workflows.NewTask(&cfg, workflows.ProvisionMaster, h.repo)
+		if err != nil {
+			logrus.Errorf("Failed to set up task for %s workflow", workflows.ProvisionMaster)
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks
+}
+
 func (h *Handler) applyToKube(w http.ResponseWriter, r *http.Request) {
 	var err error
 
@@ -1770,12 +2307,161 @@ func (h *Handler) applyToKube(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// runComplianceCheck runs kube-bench on a master node of the cluster and
+// saves the report when the task finishes.
+func (h *Handler) runComplianceCheck(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+
+	logrus.Debugf("Get kube %s", kubeID)
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if k.State != model.StateOperational {
+		w.WriteHeader(http.StatusNoContent)
+		logrus.Infof("Cluster %s is not operational", k.ID)
+		return
+	}
+
+	logrus.Debugf("Get cloud profile %s", k.ProfileID)
+	kubeProfile, err := h.profileSvc.Get(r.Context(), k.ProfileID)
+
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, k.ProfileID, err)
+			return
+		}
+
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	config, err := steps.NewConfigFromKube(kubeProfile, k)
+
+	if err != nil {
+		logrus.Errorf("New config %v", err.Error())
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	// Load things specific to cloud provider
+	err = util.LoadCloudSpecificDataFromKube(k, config)
+
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if master := config.GetMaster(); master != nil {
+		config.Node = *master
+		config.IsMaster = true
+	} else {
+		message.SendNotFound(w, "master node", sgerrors.ErrNotFound)
+		return
+	}
+
+	complianceTask, err := workflows.NewTask(config, workflows.Compliance, h.repo)
+
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	writer, err := h.getWriter(util.MakeFileName(complianceTask.ID))
+
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	complianceTask.Config = config
+	go func() {
+		err := <-complianceTask.Run(context.Background(), *config, writer)
+
+		report := &ComplianceReport{
+			KubeID:    kubeID,
+			TaskID:    complianceTask.ID,
+			Status:    statuses.Success,
+			CreatedAt: time.Now(),
+		}
+
+		if err != nil {
+			logrus.Errorf("compliance check for cluster %s caused %v", kubeID, err)
+			report.Status = statuses.Error
+			report.Error = err.Error()
+		} else {
+			report.Report = complianceTask.Config.ComplianceConfig.Report
+		}
+
+		if err := h.saveComplianceReport(context.Background(), report); err != nil {
+			logrus.Errorf("save compliance report for cluster %s caused %v", kubeID, err)
+		}
+	}()
+
+	w.WriteHeader(http.StatusAccepted)
+	err = json.NewEncoder(w).Encode(struct {
+		TaskID string `json:"taskId"`
+	}{
+		TaskID: complianceTask.ID,
+	})
+
+	if err != nil {
+		logrus.Errorf("Error encoding task id %v", err)
+	}
+}
+
+func (h *Handler) getComplianceReport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+
+	data, err := h.repo.Get(r.Context(), ComplianceStoragePrefix, kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	report := &ComplianceReport{}
+	if err := json.Unmarshal(data, report); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(report); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) saveComplianceReport(ctx context.Context, report *ComplianceReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return errors.Wrap(err, "marshal")
+	}
+
+	return h.repo.Put(ctx, ComplianceStoragePrefix, report.KubeID, data)
+}
+
 func mapNode2Task(taskMap map[string][]*workflows.Task) map[string]string {
 	node2Task := make(map[string]string)
 
 	for _, taskSet := range taskMap {
 		for _, task := range taskSet {
-			node2Task[task.Config.Node.Name] = task.ID
+			name := task.Config.Node.Name
+			// machines that haven't been created yet
+			if name == "" {
+				name = task.ID
+			}
+			node2Task[name] = task.ID
 		}
 	}
 
@@ -1801,6 +2487,9 @@ func createKube(config *steps.Config, state model.KubeState, profile profile.Pro
 		ExternalDNSName:        config.Kube.ExternalDNSName,
 		InternalDNSName:        config.Kube.ExternalDNSName,
 		ProfileID:              profile.ID,
+		Owner:                  config.Kube.Owner,
+		Team:                   profile.Team,
+		Labels:                 profile.Labels,
 		Auth:                   config.Kube.Auth,
 		Masters:                config.GetMasters(),
 		Nodes:                  config.GetNodes(),
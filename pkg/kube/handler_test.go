@@ -10,6 +10,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
@@ -17,20 +18,25 @@ import (
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/helm/pkg/proto/hapi/chart"
 	"k8s.io/helm/pkg/proto/hapi/release"
 
 	clientcmddapi "k8s.io/client-go/tools/clientcmd/api"
 
+	"github.com/supergiant/control/pkg/api"
 	"github.com/supergiant/control/pkg/clouds"
 	"github.com/supergiant/control/pkg/message"
 	"github.com/supergiant/control/pkg/model"
 	"github.com/supergiant/control/pkg/profile"
 	"github.com/supergiant/control/pkg/proxy"
 	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
 	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/user"
 	"github.com/supergiant/control/pkg/workflows"
 	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/gracefuldelete"
 )
 
 var (
@@ -83,10 +89,13 @@ func (m *getChartRefMock) GetChartRef(ctx context.Context, repoName, chartName,
 
 type kubeServiceMock struct {
 	mock.Mock
-	rls         *release.Release
-	rlsInfo     *model.ReleaseInfo
-	rlsInfoList []*model.ReleaseInfo
-	rlsErr      error
+	rls          *release.Release
+	rlsInfo      *model.ReleaseInfo
+	rlsInfoList  []*model.ReleaseInfo
+	rlsErr       error
+	rlsDiff      *ReleaseDiff
+	deprecations *DeprecationReport
+	scanErr      error
 }
 
 type accServiceMock struct {
@@ -122,6 +131,17 @@ func (m *mockProfileService) Create(ctx context.Context,
 	return args.Error(0)
 }
 
+func (m *mockProvisioner) ProvisionCluster(ctx context.Context,
+	clusterProfile *profile.Profile,
+	config *steps.Config) (map[string][]*workflows.Task, error) {
+	args := m.Called(ctx, clusterProfile, config)
+	val, ok := args.Get(0).(map[string][]*workflows.Task)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return val, args.Error(1)
+}
+
 func (m *mockProvisioner) RestartClusterProvisioning(ctx context.Context,
 	clusterProfile *profile.Profile,
 	config *steps.Config,
@@ -140,6 +160,30 @@ func (m *mockProvisioner) UpgradeCluster(ctx context.Context, nextVersion string
 	m.Called(ctx, nextVersion, tasks, config)
 }
 
+func (m *mockProvisioner) PatchCluster(ctx context.Context, k *model.Kube,
+	tasks map[string][]*workflows.Task, config *steps.Config) error {
+	args := m.Called(ctx, k, tasks, config)
+	return args.Error(0)
+}
+
+func (m *mockProvisioner) UpgradeRuntime(ctx context.Context, k *model.Kube,
+	tasks map[string][]*workflows.Task, config *steps.Config) error {
+	args := m.Called(ctx, k, tasks, config)
+	return args.Error(0)
+}
+
+func (m *mockProvisioner) MaintainEtcd(ctx context.Context, k *model.Kube,
+	tasks map[string][]*workflows.Task, config *steps.Config) error {
+	args := m.Called(ctx, k, tasks, config)
+	return args.Error(0)
+}
+
+func (m *mockProvisioner) RotateSSHKey(ctx context.Context, k *model.Kube,
+	addTasks, removeTasks map[string][]*workflows.Task, config *steps.Config) error {
+	args := m.Called(ctx, k, addTasks, removeTasks, config)
+	return args.Error(0)
+}
+
 type bufferCloser struct {
 	bytes.Buffer
 	err error
@@ -153,6 +197,7 @@ const (
 	serviceCreate            = "Create"
 	serviceGet               = "Get"
 	serviceListAll           = "ListAll"
+	serviceList              = "List"
 	serviceDelete            = "Delete"
 	serviceListKubeResources = "ListKubeResources"
 	serviceListNodes         = "ListNodes"
@@ -212,6 +257,15 @@ func (m *kubeServiceMock) ListAll(ctx context.Context) ([]model.Kube, error) {
 	return val, args.Error(1)
 }
 
+func (m *kubeServiceMock) List(ctx context.Context, opts storage.ListOptions) ([]model.Kube, string, error) {
+	args := m.Called(ctx, opts)
+	val, ok := args.Get(0).([]model.Kube)
+	if !ok {
+		return nil, "", args.Error(2)
+	}
+	return val, args.String(1), args.Error(2)
+}
+
 func (m *kubeServiceMock) Delete(ctx context.Context, name string) error {
 	args := m.Called(ctx, name)
 	return args.Error(0)
@@ -244,6 +298,42 @@ func (m *kubeServiceMock) GetKubeResources(ctx context.Context, kname, resource,
 	return val, args.Error(1)
 }
 
+func (m *kubeServiceMock) ListResources(ctx context.Context, kname, resource string, opts ResourceListOptions) ([]byte, error) {
+	args := m.Called(ctx, kname, resource, opts)
+	val, ok := args.Get(0).([]byte)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return val, args.Error(1)
+}
+
+func (m *kubeServiceMock) CreateResource(ctx context.Context, kname, resource, ns string, obj []byte, dryRun bool) ([]byte, error) {
+	args := m.Called(ctx, kname, resource, ns, obj, dryRun)
+	val, ok := args.Get(0).([]byte)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return val, args.Error(1)
+}
+
+func (m *kubeServiceMock) PatchResource(ctx context.Context, kname, resource, ns, name string, pt types.PatchType, patch []byte, dryRun bool) ([]byte, error) {
+	args := m.Called(ctx, kname, resource, ns, name, pt, patch, dryRun)
+	val, ok := args.Get(0).([]byte)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return val, args.Error(1)
+}
+
+func (m *kubeServiceMock) DeleteResource(ctx context.Context, kname, resource, ns, name string, dryRun bool) ([]byte, error) {
+	args := m.Called(ctx, kname, resource, ns, name, dryRun)
+	val, ok := args.Get(0).([]byte)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return val, args.Error(1)
+}
+
 func (m *kubeServiceMock) GetCerts(ctx context.Context, kname, cname string) (*Bundle, error) {
 	args := m.Called(ctx, kname, cname)
 	val, ok := args.Get(0).(*Bundle)
@@ -268,6 +358,17 @@ func (m *kubeServiceMock) DeleteRelease(ctx context.Context,
 	kname, rlsName string, purge bool) (*model.ReleaseInfo, error) {
 	return m.rlsInfo, m.rlsErr
 }
+func (m *kubeServiceMock) PreviewUpgrade(ctx context.Context,
+	kname, rlsName string, inp *ReleaseUpgradeInput) (*ReleaseDiff, error) {
+	return m.rlsDiff, m.rlsErr
+}
+
+func (m *kubeServiceMock) ScanDeprecatedAPIs(ctx context.Context, kname, targetVersion string) (*DeprecationReport, error) {
+	if m.deprecations == nil && m.scanErr == nil {
+		return &DeprecationReport{TargetVersion: targetVersion}, nil
+	}
+	return m.deprecations, m.scanErr
+}
 
 type mockContainter struct {
 	mock.Mock
@@ -467,7 +568,10 @@ func TestHandler_getKube(t *testing.T) {
 
 func TestHandler_listKubes(t *testing.T) {
 	tcs := []struct {
+		query        string
+		listOptions  storage.ListOptions
 		serviceKubes []model.Kube
+		serviceNext  string
 		serviceError error
 
 		expectedStatus  int
@@ -486,6 +590,33 @@ func TestHandler_listKubes(t *testing.T) {
 				},
 			},
 		},
+		{ // TC#3
+			query:           "?limit=many",
+			expectedStatus:  http.StatusBadRequest,
+			expectedErrCode: sgerrors.ValidationFailed,
+		},
+		{ // TC#4
+			query:           "?continue=invalid",
+			listOptions:     storage.ListOptions{Continue: "invalid"},
+			serviceError:    errors.Wrap(storage.ErrInvalidContinue, "storage: list"),
+			expectedStatus:  http.StatusBadRequest,
+			expectedErrCode: sgerrors.ValidationFailed,
+		},
+		{ // TC#5
+			query: "?limit=1&sort=-name&fieldSelector=state=operational",
+			listOptions: storage.ListOptions{
+				Limit:  1,
+				Sort:   []string{"-name"},
+				Fields: map[string]string{"state": "operational"},
+			},
+			expectedStatus: http.StatusOK,
+			serviceKubes: []model.Kube{
+				{
+					Name: "success",
+				},
+			},
+			serviceNext: "next",
+		},
 	}
 
 	for i, tc := range tcs {
@@ -499,10 +630,10 @@ func TestHandler_listKubes(t *testing.T) {
 			nil, nil, getChartMock, nil, nil, "")
 
 		// prepare
-		req, err := http.NewRequest(http.MethodGet, "/kubes", nil)
+		req, err := http.NewRequest(http.MethodGet, "/kubes"+tc.query, nil)
 		require.Equalf(t, nil, err, "TC#%d: create request: %v", i+1, err)
 
-		svc.On(serviceListAll, mock.Anything).Return(tc.serviceKubes, tc.serviceError)
+		svc.On(serviceList, mock.Anything, tc.listOptions).Return(tc.serviceKubes, tc.serviceNext, tc.serviceError)
 		rr := httptest.NewRecorder()
 
 		router := mux.NewRouter().SkipClean(true)
@@ -513,6 +644,7 @@ func TestHandler_listKubes(t *testing.T) {
 
 		// check
 		require.Equalf(t, tc.expectedStatus, rr.Code, "TC#%d", i+1)
+		require.Equalf(t, tc.serviceNext, rr.Header().Get(storage.ContinueHeader), "TC#%d", i+1)
 
 		if tc.expectedErrCode != sgerrors.ErrorCode(0) {
 			m := new(message.Message)
@@ -806,9 +938,11 @@ func TestHandler_listNodes(t *testing.T) {
 		svcNodes        []corev1.Node
 		svcGetErr       error
 		svcListNodesErr error
+		query           string
 
 		expectedStatus  int
 		expectedErrCode sgerrors.ErrorCode
+		expectedNodes   []string
 	}{
 		{
 			name:           "invalid kube",
@@ -840,6 +974,25 @@ func TestHandler_listNodes(t *testing.T) {
 			kubeID:         "13",
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name:   "list nodes by labels",
+			kubeID: "13",
+			svcNodes: []corev1.Node{
+				{ObjectMeta: metav1.ObjectMeta{Name: "b", Labels: map[string]string{"pool": "spot"}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "a"}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "c", Labels: map[string]string{"pool": "spot"}}},
+			},
+			query:          "?labelSelector=pool=spot&sort=-metadata.name",
+			expectedStatus: http.StatusOK,
+			expectedNodes:  []string{"c", "b"},
+		},
+		{
+			name:            "invalid limit",
+			kubeID:          "13",
+			query:           "?limit=none",
+			expectedStatus:  http.StatusBadRequest,
+			expectedErrCode: sgerrors.ValidationFailed,
+		},
 	}
 
 	for _, tc := range tcs {
@@ -853,7 +1006,7 @@ func TestHandler_listNodes(t *testing.T) {
 			nil, nil, getChartMock, nil, nil, "")
 
 		// prepare
-		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/kubes/%s/nodes", tc.kubeID), nil)
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/kubes/%s/nodes%s", tc.kubeID, tc.query), nil)
 		require.Equalf(t, nil, err, "TC %s: create request: %v", tc.name, err)
 
 		svc.On(serviceGet, mock.Anything, mock.Anything).Return(&model.Kube{}, tc.svcGetErr)
@@ -876,6 +1029,17 @@ func TestHandler_listNodes(t *testing.T) {
 
 			require.Equalf(t, tc.expectedErrCode, m.ErrorCode, "TC %s", tc.name)
 		}
+
+		if tc.expectedNodes != nil {
+			nodes := make([]corev1.Node, 0)
+			require.Nil(t, json.NewDecoder(rr.Body).Decode(&nodes), "TC %s", tc.name)
+
+			names := make([]string, 0, len(nodes))
+			for _, n := range nodes {
+				names = append(names, n.Name)
+			}
+			require.Equalf(t, tc.expectedNodes, names, "TC %s", tc.name)
+		}
 	}
 }
 
@@ -1183,10 +1347,41 @@ func TestDeleteNodeFromKube(t *testing.T) {
 			},
 			http.StatusAccepted,
 		},
+		{
+			"delete one of masters",
+			"test",
+			"test",
+			&model.Kube{
+				AccountName: "test",
+				Masters: map[string]*model.Machine{
+					"test": {
+						Name: "test",
+					},
+					"test2": {
+						Name: "test2",
+					},
+				},
+			},
+			nil,
+			"test",
+			&model.CloudAccount{
+				Name:     "test",
+				Provider: clouds.DigitalOcean,
+				Credentials: map[string]string{
+					"publicKey": "publicKey",
+				},
+			},
+			nil,
+			func(string) (io.WriteCloser, error) {
+				return &bufferCloser{}, nil
+			},
+			http.StatusAccepted,
+		},
 	}
 
 	workflows.Init()
 	workflows.RegisterWorkFlow(workflows.DeleteNode, []steps.Step{})
+	workflows.RegisterWorkFlow(workflows.DeleteMaster, []steps.Step{})
 
 	for _, testCase := range testCases {
 		t.Log(testCase.testName)
@@ -1767,6 +1962,7 @@ func TestHandler_getKubeconfig(t *testing.T) {
 	tcs := []struct {
 		kubeID   string
 		userName string
+		role     string
 
 		serviceResources []byte
 		serviceError     error
@@ -1801,6 +1997,12 @@ func TestHandler_getKubeconfig(t *testing.T) {
 			userName:       "uname",
 			expectedStatus: http.StatusOK,
 		},
+		{ // TC#5
+			kubeID:         "kubeconfig",
+			userName:       "uname",
+			role:           user.RoleView,
+			expectedStatus: http.StatusForbidden,
+		},
 	}
 
 	for i, tc := range tcs {
@@ -1815,6 +2017,11 @@ func TestHandler_getKubeconfig(t *testing.T) {
 		// prepare
 		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/kubes/%s/users/%s/kubeconfig", tc.kubeID, tc.userName), nil)
 		require.Equalf(t, nil, err, "TC#%d: create request: %v", i+1, err)
+		role := tc.role
+		if role == "" {
+			role = user.RoleAdmin
+		}
+		req = req.WithContext(api.WithIdentity(req.Context(), api.Identity{Login: "root", Role: role}))
 
 		svc.On(serviceKubeConfigFor, mock.Anything, tc.kubeID, tc.userName).Return(tc.serviceResources, tc.serviceError)
 		rr := httptest.NewRecorder()
@@ -2605,3 +2812,82 @@ func TestImportKube(t *testing.T) {
 		}
 	}
 }
+
+func TestParseUpgradeStrategy(t *testing.T) {
+	for _, tc := range []struct {
+		query    string
+		strategy string
+		err      bool
+	}{
+		{"", steps.UpgradeInPlace, false},
+		{"?strategy=blueGreen", steps.UpgradeBlueGreen, false},
+		{"?strategy=inPlace", steps.UpgradeInPlace, false},
+		{"?strategy=canary", "", true},
+	} {
+		r := httptest.NewRequest(http.MethodPatch, "/kubes/kube-1"+tc.query, nil)
+		strategy, err := parseUpgradeStrategy(r)
+		if tc.err {
+			require.Error(t, err, tc.query)
+			continue
+		}
+		require.NoError(t, err, tc.query)
+		require.Equal(t, tc.strategy, strategy, tc.query)
+	}
+}
+
+func TestCheckBlueGreen(t *testing.T) {
+	kube := func() *model.Kube {
+		return &model.Kube{
+			Provider: clouds.AWS,
+			Auth:     model.Auth{CertificateKey: "key"},
+			Masters: map[string]*model.Machine{
+				"master-1": {Name: "master-1", State: model.MachineStateActive},
+			},
+		}
+	}
+	require.NoError(t, checkBlueGreen(kube()))
+
+	k := kube()
+	k.Auth.CertificateKey = ""
+	require.Equal(t, sgerrors.ErrInvalidJson, errors.Cause(checkBlueGreen(k)))
+
+	k = kube()
+	k.Provider = clouds.OpenStack
+	require.Equal(t, sgerrors.ErrInvalidJson, errors.Cause(checkBlueGreen(k)))
+
+	k = kube()
+	k.Masters["master-1"].Protected = true
+	require.True(t, sgerrors.IsProtected(checkBlueGreen(k)))
+
+	k = kube()
+	k.Masters["master-1"].State = model.MachineStateError
+	require.Error(t, checkBlueGreen(k))
+
+	require.Len(t, replacementProfiles([]profile.NodeProfile{{"size": "a"}, {"size": "b"}}, 3), 3)
+	require.Nil(t, replacementProfiles(nil, 3))
+}
+
+func TestParseDeleteConfig(t *testing.T) {
+	for _, tc := range []struct {
+		query   string
+		mode    string
+		timeout time.Duration
+		err     bool
+	}{
+		{"", steps.DeleteGraceful, gracefuldelete.DefaultStepTimeout, false},
+		{"?mode=force", steps.DeleteForce, gracefuldelete.DefaultStepTimeout, false},
+		{"?mode=graceful&stepTimeout=90s", steps.DeleteGraceful, 90 * time.Second, false},
+		{"?mode=drain", "", 0, true},
+		{"?stepTimeout=-1m", "", 0, true},
+	} {
+		r := httptest.NewRequest(http.MethodDelete, "/kubes/kube-1"+tc.query, nil)
+		cfg, err := parseDeleteConfig(r)
+		if tc.err {
+			require.Error(t, err, tc.query)
+			continue
+		}
+		require.NoError(t, err, tc.query)
+		require.Equal(t, tc.mode, cfg.Mode, tc.query)
+		require.Equal(t, tc.timeout, cfg.StepTimeout, tc.query)
+	}
+}
@@ -0,0 +1,189 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/images"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps/provider"
+)
+
+// BakeRequest starts baking an image with the software of the cluster nodes.
+type BakeRequest struct {
+	Name string `json:"name"`
+	// Size of the temporary machine, the size of the first node profile
+	// by default.
+	Size string `json:"size"`
+}
+
+// StartBake creates a temporary machine from the cluster settings, installs
+// the software of nodes and saves the machine as an image of the catalog.
+func (h *Handler) StartBake(ctx context.Context, k *model.Kube, req BakeRequest) (*images.Image, error) {
+	if k.State != model.StateOperational {
+		return nil, ErrNotOperational
+	}
+	if !provider.SupportsImages(k.Provider) {
+		return nil, errors.Wrapf(sgerrors.ErrUnsupportedProvider, "bake images on %s", k.Provider)
+	}
+	if req.Name == "" {
+		return nil, sgerrors.WithField(errors.Wrap(sgerrors.ErrInvalidJson, "image name is required"), "name")
+	}
+
+	if req.Size == "" {
+		kubeProfile, err := h.profileSvc.Get(ctx, k.ProfileID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get profile %s", k.ProfileID)
+		}
+		if len(kubeProfile.NodesProfiles) > 0 {
+			req.Size = kubeProfile.NodesProfiles[0]["size"]
+		}
+		if req.Size == "" {
+			return nil, sgerrors.WithField(errors.Wrap(sgerrors.ErrInvalidJson, "machine size is required"), "size")
+		}
+	}
+
+	config, err := h.newKubeConfig(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+
+	acc, err := h.accountService.Get(ctx, k.AccountName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get cloud account %s", k.AccountName)
+	}
+	if err = util.FillCloudAccountCredentials(acc, config); err != nil {
+		return nil, errors.Wrap(err, "fill cloud account credentials")
+	}
+
+	config.IsMaster = false
+	config.Node = model.Machine{}
+	config.AWSConfig.InstanceType = req.Size
+	config.BakeConfig.Name = req.Name
+	// the temporary machine isn't a node of the cluster
+	nodeChan := make(chan model.Machine)
+	config.SetNodeChan(nodeChan)
+
+	task, err := workflows.NewTask(config, workflows.BakeImage, h.repo)
+	if err != nil {
+		return nil, errors.Wrap(err, "new task")
+	}
+	config.TaskID = task.ID
+
+	img := &images.Image{
+		ID:            task.ID,
+		Name:          req.Name,
+		Provider:      k.Provider,
+		Region:        k.Region,
+		AccountName:   k.AccountName,
+		K8SVersion:    k.K8SVersion,
+		DockerVersion: k.DockerVersion,
+		State:         images.StateBaking,
+		KubeID:        k.ID,
+		TaskID:        task.ID,
+		CreatedAt:     time.Now(),
+	}
+	if err = h.images.Save(ctx, img); err != nil {
+		return nil, err
+	}
+
+	if k.Tasks == nil {
+		k.Tasks = make(map[string][]string)
+	}
+	k.Tasks[workflows.BakeImage] = append(k.Tasks[workflows.BakeImage], task.ID)
+	if err = h.svc.Create(ctx, k); err != nil {
+		return nil, errors.Wrapf(err, "update kube %s", k.ID)
+	}
+
+	go func() {
+		for range nodeChan {
+		}
+	}()
+	go func(img images.Image) {
+		defer close(nodeChan)
+		h.bake(task, &img)
+	}(*img)
+
+	return img, nil
+}
+
+func (h *Handler) bake(task *workflows.Task, img *images.Image) {
+	ctx := context.Background()
+	writer, err := h.getWriter(util.MakeFileName(task.ID))
+	if err != nil {
+		logrus.Errorf("bake image %s: get writer: %v", img.Name, err)
+		img.State = images.StateFailed
+		if err = h.images.Save(ctx, img); err != nil {
+			logrus.Errorf("bake image %s: %v", img.Name, err)
+		}
+		return
+	}
+	defer writer.Close()
+
+	if err = <-task.Run(ctx, *task.Config, writer); err != nil {
+		logrus.Errorf("bake image %s: task %s: %v", img.Name, task.ID, err)
+		img.State = images.StateFailed
+		// the machine is left after a failed step
+		if task.Config.Node.ID != "" {
+			if err = (provider.StepDeleteMachine{}).Run(ctx, writer, task.Config); err != nil {
+				logrus.Errorf("bake image %s: delete machine %s: %v", img.Name, task.Config.Node.ID, err)
+			}
+		}
+	} else {
+		img.ImageID = task.Config.BakeConfig.ImageID
+		img.State = images.StateAvailable
+	}
+
+	if err = h.images.Save(ctx, img); err != nil {
+		logrus.Errorf("bake image %s: %v", img.Name, err)
+	}
+}
+
+func (h *Handler) bakeImage(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	req := BakeRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	img, err := h.StartBake(r.Context(), k, req)
+	if err != nil {
+		switch cause := errors.Cause(err); {
+		case cause == ErrNotOperational, cause == sgerrors.ErrInvalidJson, cause == sgerrors.ErrUnsupportedProvider:
+			message.SendValidationFailed(w, err)
+		case sgerrors.IsNotFound(err):
+			message.SendNotFound(w, k.AccountName, err)
+		default:
+			logrus.Errorf("bake image on kube %s: %v", kubeID, err)
+			message.SendUnknownError(w, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	if err = json.NewEncoder(w).Encode(img); err != nil {
+		logrus.Errorf("bake image on kube %s: write response: %v", kubeID, err)
+	}
+}
@@ -0,0 +1,106 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/images"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage/memory"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type bakeStep struct{}
+
+func (bakeStep) Run(_ context.Context, _ io.Writer, cfg *steps.Config) error {
+	cfg.BakeConfig.ImageID = "ami-" + cfg.BakeConfig.Name + "-" + cfg.AWSConfig.InstanceType
+	return nil
+}
+
+func (bakeStep) Name() string                                             { return "bake" }
+func (bakeStep) Description() string                                      { return "" }
+func (bakeStep) Depends() []string                                        { return nil }
+func (bakeStep) Rollback(context.Context, io.Writer, *steps.Config) error { return nil }
+
+func TestHandler_bakeImage(t *testing.T) {
+	workflows.Init()
+	workflows.RegisterWorkFlow(workflows.BakeImage, []steps.Step{bakeStep{}})
+
+	k := specKube()
+	k.ID = "aws"
+	k.AccountName = "aws"
+	k.Provider = clouds.AWS
+	k.Region = "us-east-1"
+	k.K8SVersion = "1.14.1"
+	pending := specKube()
+	pending.ID = "pending"
+	pending.Provider = clouds.AWS
+	pending.State = model.StateProvisioning
+
+	svc := new(kubeServiceMock)
+	svc.On("Get", mock.Anything, "aws").Return(k, nil)
+	svc.On("Get", mock.Anything, "kube-1").Return(specKube(), nil)
+	svc.On("Get", mock.Anything, "pending").Return(pending, nil)
+	svc.On("Get", mock.Anything, mock.Anything).Return(nil, sgerrors.ErrNotFound)
+	svc.On("Create", mock.Anything, mock.Anything).Return(nil)
+	accounts := new(accServiceMock)
+	accounts.On("Get", mock.Anything, "aws").Return(&model.CloudAccount{
+		Provider:    clouds.AWS,
+		Credentials: map[string]string{},
+	}, nil)
+	profiles := new(mockProfileService)
+	profiles.On("Get", mock.Anything, "profile-1").Return(&profile.Profile{
+		Provider:      clouds.AWS,
+		NodesProfiles: []profile.NodeProfile{{"size": "m4.large"}},
+	}, nil)
+	h := NewHandler(svc, accounts, profiles, nil, nil, nil, memory.NewInMemoryRepository(), nil, "")
+	h.getWriter = func(string) (io.WriteCloser, error) {
+		return &bufferCloser{}, nil
+	}
+
+	for _, tc := range []struct {
+		url  string
+		body string
+		code int
+	}{
+		{"/kubes/unknown/images", `{"name":"golden"}`, http.StatusNotFound},
+		{"/kubes/aws/images", `{`, http.StatusBadRequest},
+		{"/kubes/aws/images", `{}`, http.StatusBadRequest},
+		{"/kubes/kube-1/images", `{"name":"golden"}`, http.StatusBadRequest},
+		{"/kubes/pending/images", `{"name":"golden"}`, http.StatusBadRequest},
+	} {
+		rr := specRequest(h, http.MethodPost, tc.url, tc.body)
+		require.Equal(t, tc.code, rr.Code, tc.url+" "+tc.body)
+	}
+
+	rr := specRequest(h, http.MethodPost, "/kubes/aws/images", `{"name":"golden"}`)
+	require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+	img := &images.Image{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(img))
+	require.Equal(t, images.StateBaking, img.State)
+	require.Equal(t, "1.14.1", img.K8SVersion)
+	require.Contains(t, k.Tasks[workflows.BakeImage], img.TaskID)
+
+	var baked *images.Image
+	for i := 0; i < 100; i++ {
+		baked, _ = h.images.Get(context.Background(), img.ID)
+		if baked != nil && baked.State != images.StateBaking {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NotNil(t, baked)
+	require.Equal(t, images.StateAvailable, baked.State)
+	require.Equal(t, "ami-golden-m4.large", baked.ImageID)
+}
@@ -0,0 +1,524 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/timeline"
+	"github.com/supergiant/control/pkg/workflows/steps/kubelet"
+)
+
+const inventoryCheckInterval = 30 * time.Minute
+
+// Statuses of inventory items.
+const (
+	// InventoryMissing machines are kept by control, but their instances are
+	// gone or have never registered as kubernetes nodes.
+	InventoryMissing = "missing"
+	// InventoryUntracked nodes have joined the cluster without control.
+	InventoryUntracked = "untracked"
+	// InventoryOrphaned instances are tagged with the cluster, but they are
+	// neither kept by control nor registered as nodes.
+	InventoryOrphaned = "orphaned"
+)
+
+// Actions resolving inventory items.
+const (
+	// InventoryAdopt adds an untracked node to machines of the cluster.
+	InventoryAdopt = "adopt"
+	// InventoryCleanup removes the machine record, the node and the instance.
+	InventoryCleanup = "cleanup"
+)
+
+// inventoryEntry is an item with the resources it has been built from.
+type inventoryEntry struct {
+	item     InventoryItem
+	machine  *model.Machine
+	instance *cloudInstance
+	node     *corev1.Node
+}
+
+func (h *Handler) getInventory(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	var report *InventoryReport
+	if refresh, _ := strconv.ParseBool(r.URL.Query().Get("refresh")); refresh {
+		report, _ = h.checkInventory(r.Context(), k)
+	} else if report, err = h.lastInventoryReport(r.Context(), kubeID); err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(report); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// resolveInventoryItem adopts or cleans up a machine. The inventory is
+// reconciled again, so the action is never taken on a stale report.
+func (h *Handler) resolveInventoryItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID, name, action := vars["kubeID"], vars["name"], vars["action"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	report, entries := h.checkInventory(r.Context(), k)
+
+	var entry *inventoryEntry
+	for i := range entries {
+		if entries[i].item.Name == name {
+			entry = &entries[i]
+		}
+	}
+	if entry == nil {
+		message.SendNotFound(w, name, errors.Wrapf(sgerrors.ErrNotFound, "inventory item %s", name))
+		return
+	}
+	if !hasAction(entry.item, action) {
+		message.SendValidationFailed(w, errors.Errorf("%s item %s can't be resolved with %s, use one of %v",
+			entry.item.Status, name, action, entry.item.Actions))
+		return
+	}
+
+	target := entry.machine
+	if target == nil {
+		target = &model.Machine{Name: name}
+	}
+	if action == InventoryCleanup && !checkDelete(w, r, k, target) {
+		return
+	}
+
+	switch action {
+	case InventoryAdopt:
+		err = h.adoptMachine(r.Context(), k, entry)
+	case InventoryCleanup:
+		err = h.cleanupMachine(r.Context(), k, entry)
+	}
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	entry.item.Resolved = action
+	for i := range report.Items {
+		if report.Items[i].Name == name {
+			report.Items[i].Resolved = action
+		}
+	}
+	if err := h.saveInventoryReport(r.Context(), report); err != nil {
+		logrus.Errorf("inventory: save report of cluster %s: %v", k.ID, err)
+	}
+
+	if err = json.NewEncoder(w).Encode(entry.item); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// checkInventory compares machines of the cluster with instances of its
+// cloud account and kubernetes nodes. The report is saved.
+func (h *Handler) checkInventory(ctx context.Context, k *model.Kube) (*InventoryReport, []inventoryEntry) {
+	report := &InventoryReport{
+		KubeID:    k.ID,
+		CheckedAt: time.Now(),
+	}
+
+	instances, err := h.instancesOf(ctx, k)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("cloud: %v", err))
+	}
+
+	nodes, err := h.svc.ListNodes(ctx, k, "")
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("kubernetes: %v", err))
+	} else if nodes == nil {
+		nodes = []corev1.Node{}
+	}
+
+	entries := reconcileInventory(k, instances, nodes)
+	report.Items = make([]InventoryItem, 0, len(entries))
+	for _, e := range entries {
+		report.Items = append(report.Items, e.item)
+	}
+
+	if err := h.saveInventoryReport(ctx, report); err != nil {
+		logrus.Errorf("inventory: save report of cluster %s: %v", k.ID, err)
+	}
+
+	return report, entries
+}
+
+func (h *Handler) instancesOf(ctx context.Context, k *model.Kube) ([]cloudInstance, error) {
+	if h.clusterInstances == nil {
+		return nil, errors.Wrapf(sgerrors.ErrUnsupportedProvider, "%s", k.Provider)
+	}
+
+	acc, err := h.accountService.Get(ctx, k.AccountName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get cloud account %s", k.AccountName)
+	}
+
+	return h.clusterInstances(ctx, k, acc)
+}
+
+// reconcileInventory matches machines with instances by ids or names and with
+// nodes by names. Instances or nodes are nil when they couldn't be listed,
+// their checks are skipped then.
+func reconcileInventory(k *model.Kube, instances []cloudInstance, nodes []corev1.Node) []inventoryEntry {
+	var entries []inventoryEntry
+
+	instanceByID := make(map[string]*cloudInstance, len(instances))
+	instanceByName := make(map[string]*cloudInstance, len(instances))
+	instanceByIP := make(map[string]*cloudInstance, len(instances))
+	for i := range instances {
+		inst := &instances[i]
+		instanceByID[inst.ID] = inst
+		if inst.Name != "" {
+			instanceByName[inst.Name] = inst
+		}
+		if inst.PrivateIP != "" {
+			instanceByIP[inst.PrivateIP] = inst
+		}
+	}
+	nodeByName := make(map[string]*corev1.Node, len(nodes))
+	for i := range nodes {
+		nodeByName[nodes[i].Name] = &nodes[i]
+	}
+
+	seenInstances := make(map[*cloudInstance]bool)
+	seenNodes := make(map[*corev1.Node]bool)
+
+	for _, m := range sortedMachines(k) {
+		inst := instanceByID[m.ID]
+		if inst == nil {
+			inst = instanceByName[m.Name]
+		}
+		node := nodeByName[m.Name]
+		seenInstances[inst] = true
+		seenNodes[node] = true
+
+		// machines being created or deleted aren't expected to be complete
+		if m.State != model.MachineStateActive {
+			continue
+		}
+
+		entry := inventoryEntry{
+			item: InventoryItem{
+				Name:       m.Name,
+				Status:     InventoryMissing,
+				Role:       m.Role,
+				InstanceID: m.ID,
+				Actions:    []string{InventoryCleanup},
+			},
+			machine:  m,
+			instance: inst,
+			node:     node,
+		}
+		switch {
+		case instances != nil && inst == nil:
+			entry.item.Reason = "instance isn't found in the cloud"
+		case nodes != nil && node == nil:
+			entry.item.Reason = "instance isn't registered as a kubernetes node"
+			// etcd members of masters are removed by the master delete workflow
+			if m.Role == model.RoleMaster {
+				entry.item.Actions = nil
+			}
+		default:
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	for i := range nodes {
+		node := &nodes[i]
+		if seenNodes[node] {
+			continue
+		}
+
+		inst := instanceByName[node.Name]
+		if inst == nil {
+			inst = instanceByIP[nodeAddress(node, corev1.NodeInternalIP)]
+		}
+		seenInstances[inst] = true
+
+		entry := inventoryEntry{
+			item: InventoryItem{
+				Name:    node.Name,
+				Status:  InventoryUntracked,
+				Role:    nodeRole(node),
+				Reason:  "kubernetes node isn't tracked by control",
+				Actions: []string{InventoryAdopt, InventoryCleanup},
+			},
+			instance: inst,
+			node:     node,
+		}
+		if inst != nil {
+			entry.item.InstanceID = inst.ID
+		}
+		entries = append(entries, entry)
+	}
+
+	for i := range instances {
+		inst := &instances[i]
+		if seenInstances[inst] {
+			continue
+		}
+
+		name := inst.Name
+		if name == "" {
+			name = inst.ID
+		}
+		reason := "instance of the cluster isn't tracked by control or registered as a node"
+		if nodes == nil {
+			reason = "instance of the cluster isn't tracked by control"
+		}
+		entries = append(entries, inventoryEntry{
+			item: InventoryItem{
+				Name:       name,
+				Status:     InventoryOrphaned,
+				InstanceID: inst.ID,
+				Reason:     reason,
+				Actions:    []string{InventoryCleanup},
+			},
+			instance: inst,
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].item.Status != entries[j].item.Status {
+			return entries[i].item.Status < entries[j].item.Status
+		}
+		return entries[i].item.Name < entries[j].item.Name
+	})
+
+	return entries
+}
+
+// adoptMachine adds the untracked node to machines of the cluster.
+func (h *Handler) adoptMachine(ctx context.Context, k *model.Kube, e *inventoryEntry) error {
+	m := &model.Machine{
+		Name:      e.item.Name,
+		Role:      e.item.Role,
+		Provider:  k.Provider,
+		Region:    k.Region,
+		State:     model.MachineStateActive,
+		CreatedAt: time.Now().Unix(),
+	}
+	if e.node != nil {
+		m.PrivateIp = nodeAddress(e.node, corev1.NodeInternalIP)
+		m.PublicIp = nodeAddress(e.node, corev1.NodeExternalIP)
+		if !e.node.CreationTimestamp.IsZero() {
+			m.CreatedAt = e.node.CreationTimestamp.Unix()
+		}
+	}
+	if inst := e.instance; inst != nil {
+		m.ID = inst.ID
+		m.Size = inst.Type
+		m.AvailabilityZone = inst.Zone
+		if inst.PrivateIP != "" {
+			m.PrivateIp = inst.PrivateIP
+		}
+		if inst.PublicIP != "" {
+			m.PublicIp = inst.PublicIP
+		}
+	}
+
+	if m.Role == model.RoleMaster {
+		if k.Masters == nil {
+			k.Masters = make(map[string]*model.Machine)
+		}
+		k.Masters[m.Name] = m
+	} else {
+		m.Role = model.RoleNode
+		if k.Nodes == nil {
+			k.Nodes = make(map[string]*model.Machine)
+		}
+		k.Nodes[m.Name] = m
+	}
+
+	if err := h.svc.Create(ctx, k); err != nil {
+		return errors.Wrapf(err, "update cluster %s", k.ID)
+	}
+
+	h.recordEvent(ctx, &timeline.Event{
+		KubeID:  k.ID,
+		Type:    timeline.NodeAdded,
+		Object:  m.Name,
+		Message: fmt.Sprintf("%s %s has been adopted by control", m.Role, m.Name),
+	})
+
+	return nil
+}
+
+// cleanupMachine deletes the instance and the node of the item and removes
+// the machine record.
+func (h *Handler) cleanupMachine(ctx context.Context, k *model.Kube, e *inventoryEntry) error {
+	if e.instance != nil {
+		acc, err := h.accountService.Get(ctx, k.AccountName)
+		if err != nil {
+			return errors.Wrapf(err, "get cloud account %s", k.AccountName)
+		}
+		if err = h.terminateInstance(ctx, k, acc, *e.instance); err != nil {
+			return errors.Wrapf(err, "delete instance %s", e.instance.ID)
+		}
+	}
+
+	if e.node != nil {
+		if err := h.deleteNode(k, e.node.Name); err != nil {
+			return errors.Wrapf(err, "delete node %s", e.node.Name)
+		}
+	}
+
+	if e.machine == nil {
+		return nil
+	}
+
+	delete(k.Masters, e.machine.Name)
+	delete(k.Nodes, e.machine.Name)
+	if err := h.svc.Create(ctx, k); err != nil {
+		return errors.Wrapf(err, "update cluster %s", k.ID)
+	}
+
+	h.recordEvent(ctx, &timeline.Event{
+		KubeID:  k.ID,
+		Type:    timeline.NodeRemoved,
+		Object:  e.machine.Name,
+		Message: fmt.Sprintf("%s %s has been cleaned up: %s", e.machine.Role, e.machine.Name, e.item.Reason),
+	})
+
+	return nil
+}
+
+func (h *Handler) lastInventoryReport(ctx context.Context, kubeID string) (*InventoryReport, error) {
+	data, err := h.repo.Get(ctx, InventoryStoragePrefix, kubeID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &InventoryReport{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, errors.Wrap(err, "unmarshal")
+	}
+
+	return report, nil
+}
+
+func (h *Handler) saveInventoryReport(ctx context.Context, report *InventoryReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return errors.Wrap(err, "marshal")
+	}
+
+	return h.repo.Put(ctx, InventoryStoragePrefix, report.KubeID, data)
+}
+
+// InventoryReconciler reconciles machines of operational clusters periodically,
+// items are reported and have to be resolved by operators.
+type InventoryReconciler struct {
+	h        *Handler
+	interval time.Duration
+}
+
+func NewInventoryReconciler(h *Handler) *InventoryReconciler {
+	return &InventoryReconciler{
+		h:        h,
+		interval: inventoryCheckInterval,
+	}
+}
+
+// Run reconciles clusters until the context is done.
+func (rc *InventoryReconciler) Run(ctx context.Context) {
+	ticker := time.NewTicker(rc.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rc.reconcile(ctx)
+		}
+	}
+}
+
+func (rc *InventoryReconciler) reconcile(ctx context.Context) {
+	kubes, err := rc.h.svc.ListAll(ctx)
+	if err != nil {
+		logrus.Errorf("inventory reconciler: list kubes: %v", err)
+		return
+	}
+
+	for i := range kubes {
+		if kubes[i].State != model.StateOperational {
+			continue
+		}
+
+		report, _ := rc.h.checkInventory(ctx, &kubes[i])
+		if len(report.Items) > 0 {
+			logrus.Warnf("inventory reconciler: cluster %s has %d unreconciled machines",
+				kubes[i].ID, len(report.Items))
+		}
+	}
+}
+
+func hasAction(item InventoryItem, action string) bool {
+	for _, a := range item.Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+func nodeRole(node *corev1.Node) model.Role {
+	if node.Labels[kubelet.LabelNodeRole] == string(model.RoleMaster) {
+		return model.RoleMaster
+	}
+	if _, ok := node.Labels["node-role.kubernetes.io/master"]; ok {
+		return model.RoleMaster
+	}
+	return model.RoleNode
+}
+
+func nodeAddress(node *corev1.Node, typ corev1.NodeAddressType) string {
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == typ {
+			return addr.Address
+		}
+	}
+	return ""
+}
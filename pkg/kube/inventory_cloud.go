@@ -0,0 +1,242 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/digitalocean/godo"
+	"github.com/pkg/errors"
+	"google.golang.org/api/compute/v1"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/clouds/digitaloceansdk"
+	"github.com/supergiant/control/pkg/clouds/gcesdk"
+	"github.com/supergiant/control/pkg/kubeconfig"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// clusterInstancesOf lists instances that belong to the cluster, they are
+// found by the cluster tag on AWS and DigitalOcean and by names on GCE.
+func clusterInstancesOf(ctx context.Context, k *model.Kube, acc *model.CloudAccount) ([]cloudInstance, error) {
+	switch k.Provider {
+	case clouds.AWS:
+		svc, err := ec2For(k, acc)
+		if err != nil {
+			return nil, err
+		}
+		return awsClusterInstances(ctx, svc, k)
+	case clouds.GCE:
+		config := &steps.Config{}
+		if err := util.FillCloudAccountCredentials(acc, config); err != nil {
+			return nil, errors.Wrap(err, "fill cloud account credentials")
+		}
+		svc, err := gcesdk.GetClient(ctx, config.GCEConfig)
+		if err != nil {
+			return nil, errors.Wrap(sgerrors.ErrInvalidCredentials, err.Error())
+		}
+		return gceClusterInstances(ctx, svc, config.GCEConfig.ProjectID, k)
+	case clouds.DigitalOcean:
+		sdk, err := digitaloceansdk.NewFromAccount(acc)
+		if err != nil {
+			return nil, err
+		}
+		return doClusterInstances(ctx, sdk.GetClient().Droplets, k)
+	}
+
+	return nil, errors.Wrapf(sgerrors.ErrUnsupportedProvider, "%s", k.Provider)
+}
+
+func awsClusterInstances(ctx context.Context, svc ec2iface.EC2API, k *model.Kube) ([]cloudInstance, error) {
+	instances := make([]cloudInstance, 0)
+
+	err := svc.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{{
+			Name:   aws.String("tag:" + clouds.TagClusterID),
+			Values: aws.StringSlice([]string{k.ID}),
+		}},
+	}, func(out *ec2.DescribeInstancesOutput, _ bool) bool {
+		for _, r := range out.Reservations {
+			for _, i := range r.Instances {
+				inst := cloudInstance{
+					ID:        aws.StringValue(i.InstanceId),
+					Type:      aws.StringValue(i.InstanceType),
+					PrivateIP: aws.StringValue(i.PrivateIpAddress),
+					PublicIP:  aws.StringValue(i.PublicIpAddress),
+					Tags:      make(map[string]string, len(i.Tags)),
+				}
+				if i.State != nil {
+					inst.State = aws.StringValue(i.State.Name)
+				}
+				if i.Placement != nil {
+					inst.Zone = aws.StringValue(i.Placement.AvailabilityZone)
+				}
+				for _, t := range i.Tags {
+					inst.Tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+				}
+				inst.Name = inst.Tags[clouds.TagNodeName]
+
+				// terminated instances are kept listed for a while
+				if inst.State == ec2.InstanceStateNameTerminated || inst.State == ec2.InstanceStateNameShuttingDown {
+					continue
+				}
+				instances = append(instances, inst)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "describe instances")
+	}
+
+	return instances, nil
+}
+
+func gceClusterInstances(ctx context.Context, svc *compute.Service, project string, k *model.Kube) ([]cloudInstance, error) {
+	instances := make([]cloudInstance, 0)
+
+	// instances are named after the cluster by default, names of a naming
+	// template are only known for machines of the kube
+	filter := fmt.Sprintf(`name eq "%s-(master|node)-.*"`, regexp.QuoteMeta(strings.ToLower(k.Name)))
+	if k.Naming.Instance != "" {
+		names := make([]string, 0, len(k.Masters)+len(k.Nodes))
+		for _, machines := range []map[string]*model.Machine{k.Masters, k.Nodes} {
+			for _, m := range machines {
+				names = append(names, regexp.QuoteMeta(strings.ToLower(m.Name)))
+			}
+		}
+		filter = fmt.Sprintf(`name eq "(%s)"`, strings.Join(names, "|"))
+	}
+	err := svc.Instances.AggregatedList(project).Filter(filter).Pages(ctx,
+		func(list *compute.InstanceAggregatedList) error {
+			for _, scoped := range list.Items {
+				for _, i := range scoped.Instances {
+					inst := cloudInstance{
+						ID:    i.Name,
+						Name:  i.Name,
+						Type:  path.Base(i.MachineType),
+						State: i.Status,
+						Zone:  path.Base(i.Zone),
+						Tags:  make(map[string]string),
+					}
+					if len(i.NetworkInterfaces) > 0 {
+						inst.PrivateIP = i.NetworkInterfaces[0].NetworkIP
+						if len(i.NetworkInterfaces[0].AccessConfigs) > 0 {
+							inst.PublicIP = i.NetworkInterfaces[0].AccessConfigs[0].NatIP
+						}
+					}
+					if i.Metadata != nil {
+						for _, item := range i.Metadata.Items {
+							if item.Value != nil {
+								inst.Tags[item.Key] = *item.Value
+							}
+						}
+					}
+					instances = append(instances, inst)
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, errors.Wrap(err, "list instances")
+	}
+
+	return instances, nil
+}
+
+func doClusterInstances(ctx context.Context, svc godo.DropletsService, k *model.Kube) ([]cloudInstance, error) {
+	instances := make([]cloudInstance, 0)
+
+	opt := &godo.ListOptions{PerPage: 200}
+	for {
+		droplets, resp, err := svc.ListByTag(ctx, k.ID, opt)
+		if err != nil {
+			return nil, errors.Wrap(err, "list droplets")
+		}
+
+		for _, d := range droplets {
+			inst := cloudInstance{
+				ID:    strconv.Itoa(d.ID),
+				Name:  d.Name,
+				Type:  d.SizeSlug,
+				State: d.Status,
+				Tags:  make(map[string]string, len(d.Tags)),
+			}
+			if d.Region != nil {
+				inst.Zone = d.Region.Slug
+			}
+			inst.PrivateIP, _ = d.PrivateIPv4()
+			inst.PublicIP, _ = d.PublicIPv4()
+			for _, t := range d.Tags {
+				inst.Tags[t] = ""
+			}
+			instances = append(instances, inst)
+		}
+
+		if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+			return instances, nil
+		}
+		page, err := resp.Links.CurrentPage()
+		if err != nil {
+			return nil, errors.Wrap(err, "list droplets")
+		}
+		opt.Page = page + 1
+	}
+}
+
+// terminateInstance deletes the instance of the cluster.
+func terminateInstance(ctx context.Context, k *model.Kube, acc *model.CloudAccount, inst cloudInstance) error {
+	switch k.Provider {
+	case clouds.AWS:
+		svc, err := ec2For(k, acc)
+		if err != nil {
+			return err
+		}
+		_, err = svc.TerminateInstancesWithContext(ctx, &ec2.TerminateInstancesInput{
+			InstanceIds: []*string{aws.String(inst.ID)},
+		})
+		return errors.Wrapf(err, "terminate instance %s", inst.ID)
+	case clouds.GCE:
+		config := &steps.Config{}
+		if err := util.FillCloudAccountCredentials(acc, config); err != nil {
+			return errors.Wrap(err, "fill cloud account credentials")
+		}
+		svc, err := gcesdk.GetClient(ctx, config.GCEConfig)
+		if err != nil {
+			return errors.Wrap(sgerrors.ErrInvalidCredentials, err.Error())
+		}
+		_, err = svc.Instances.Delete(config.GCEConfig.ProjectID, inst.Zone, inst.Name).Context(ctx).Do()
+		return errors.Wrapf(err, "delete instance %s", inst.Name)
+	case clouds.DigitalOcean:
+		sdk, err := digitaloceansdk.NewFromAccount(acc)
+		if err != nil {
+			return err
+		}
+		id, err := strconv.Atoi(inst.ID)
+		if err != nil {
+			return errors.Wrapf(err, "droplet id %s", inst.ID)
+		}
+		_, err = sdk.GetClient().Droplets.Delete(ctx, id)
+		return errors.Wrapf(err, "delete droplet %s", inst.ID)
+	}
+
+	return errors.Wrapf(sgerrors.ErrUnsupportedProvider, "%s", k.Provider)
+}
+
+func deleteNode(k *model.Kube, name string) error {
+	c, err := kubeconfig.CoreV1Client(k)
+	if err != nil {
+		return errors.Wrap(err, "build kubernetes client")
+	}
+
+	return c.Nodes().Delete(name, nil)
+}
@@ -0,0 +1,194 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/storage/memory"
+)
+
+func inventoryNodes(names ...string) []corev1.Node {
+	nodes := make([]corev1.Node, 0, len(names))
+	for _, name := range names {
+		nodes = append(nodes, corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+	return nodes
+}
+
+func inventoryItems(entries []inventoryEntry) []InventoryItem {
+	items := make([]InventoryItem, 0, len(entries))
+	for _, e := range entries {
+		items = append(items, e.item)
+	}
+	return items
+}
+
+func TestReconcileInventory(t *testing.T) {
+	k := driftKube()
+	k.Masters["master-1"].Role = model.RoleMaster
+	k.Nodes["node-1"].Role = model.RoleNode
+	k.Nodes["node-2"].Role = model.RoleNode
+	k.Nodes["node-3"] = &model.Machine{ID: "i-9", Name: "node-3", State: model.MachineStateProvisioning}
+
+	instances := []cloudInstance{
+		{ID: "i-1", Name: "master-1"},
+		{ID: "i-2", Name: "node-1"},
+		{ID: "i-4", Name: "manual", PrivateIP: "10.0.0.4"},
+		{ID: "i-5"},
+		{ID: "i-9", Name: "node-3"},
+	}
+	nodes := inventoryNodes("node-1", "ip-10-0-0-4")
+	nodes[1].Status.Addresses = []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.4"}}
+
+	require.Equal(t, []InventoryItem{
+		{
+			Name: "master-1", Status: InventoryMissing, Role: model.RoleMaster, InstanceID: "i-1",
+			Reason: "instance isn't registered as a kubernetes node",
+		},
+		{
+			Name: "node-2", Status: InventoryMissing, Role: model.RoleNode, InstanceID: "i-3",
+			Reason: "instance isn't found in the cloud", Actions: []string{InventoryCleanup},
+		},
+		{
+			Name: "i-5", Status: InventoryOrphaned, InstanceID: "i-5",
+			Reason:  "instance of the cluster isn't tracked by control or registered as a node",
+			Actions: []string{InventoryCleanup},
+		},
+		{
+			Name: "ip-10-0-0-4", Status: InventoryUntracked, Role: model.RoleNode, InstanceID: "i-4",
+			Reason: "kubernetes node isn't tracked by control", Actions: []string{InventoryAdopt, InventoryCleanup},
+		},
+	}, inventoryItems(reconcileInventory(k, instances, nodes)))
+
+	// nodes couldn't be listed
+	require.Equal(t, []InventoryItem{
+		{
+			Name: "node-2", Status: InventoryMissing, Role: model.RoleNode, InstanceID: "i-3",
+			Reason: "instance isn't found in the cloud", Actions: []string{InventoryCleanup},
+		},
+		{
+			Name: "i-5", Status: InventoryOrphaned, InstanceID: "i-5",
+			Reason: "instance of the cluster isn't tracked by control", Actions: []string{InventoryCleanup},
+		},
+		{
+			Name: "manual", Status: InventoryOrphaned, InstanceID: "i-4",
+			Reason: "instance of the cluster isn't tracked by control", Actions: []string{InventoryCleanup},
+		},
+	}, inventoryItems(reconcileInventory(k, instances, nil)))
+}
+
+func inventoryRequest(h *Handler, method, url string) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	h.Register(router)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(method, url, nil))
+	return rr
+}
+
+func TestHandler_resolveInventoryItem(t *testing.T) {
+	k := driftKube()
+	svc := new(kubeServiceMock)
+	svc.On("Get", mock.Anything, "kube-1").Return(k, nil)
+	svc.On("ListNodes", mock.Anything, k, "").Return(inventoryNodes("master-1", "node-1", "manual"), nil)
+	svc.On("Create", mock.Anything, k).Return(nil)
+	accounts := new(accServiceMock)
+	accounts.On("Get", mock.Anything, "aws").Return(&model.CloudAccount{Provider: clouds.AWS}, nil)
+	h := NewHandler(svc, accounts, nil, nil, nil, nil, memory.NewInMemoryRepository(), nil, "")
+
+	h.clusterInstances = func(context.Context, *model.Kube, *model.CloudAccount) ([]cloudInstance, error) {
+		return []cloudInstance{
+			{ID: "i-1", Name: "master-1"},
+			{ID: "i-2", Name: "node-1"},
+			{ID: "i-4", Name: "manual", Type: "m4.large", Zone: "us-east-1a", PrivateIP: "10.0.0.4"},
+		}, nil
+	}
+	var terminated []string
+	h.terminateInstance = func(_ context.Context, _ *model.Kube, _ *model.CloudAccount, inst cloudInstance) error {
+		terminated = append(terminated, inst.ID)
+		return nil
+	}
+	h.deleteNode = func(*model.Kube, string) error {
+		t.Fatal("nodes mustn't be deleted")
+		return nil
+	}
+
+	rr := inventoryRequest(h, http.MethodGet, "/kubes/kube-1/inventory?refresh=true")
+	require.Equal(t, http.StatusOK, rr.Code)
+	report := &InventoryReport{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(report))
+	require.Len(t, report.Items, 2)
+
+	// missing machines can't be adopted
+	rr = inventoryRequest(h, http.MethodPost, "/kubes/kube-1/inventory/node-2/adopt")
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = inventoryRequest(h, http.MethodPost, "/kubes/kube-1/inventory/node-2/cleanup")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NotContains(t, k.Nodes, "node-2")
+	require.Empty(t, terminated)
+
+	rr = inventoryRequest(h, http.MethodPost, "/kubes/kube-1/inventory/manual/adopt")
+	require.Equal(t, http.StatusOK, rr.Code)
+	item := &InventoryItem{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(item))
+	require.Equal(t, InventoryAdopt, item.Resolved)
+	require.Equal(t, "i-4", k.Nodes["manual"].ID)
+	require.Equal(t, "m4.large", k.Nodes["manual"].Size)
+	require.Equal(t, "10.0.0.4", k.Nodes["manual"].PrivateIp)
+	require.Equal(t, model.MachineStateActive, k.Nodes["manual"].State)
+
+	rr = inventoryRequest(h, http.MethodGet, "/kubes/kube-1/inventory")
+	require.Equal(t, http.StatusOK, rr.Code)
+	report = &InventoryReport{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(report))
+	require.Len(t, report.Items, 1)
+	require.Equal(t, InventoryAdopt, report.Items[0].Resolved)
+
+	rr = inventoryRequest(h, http.MethodPost, "/kubes/kube-1/inventory/manual/cleanup")
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestAWSClusterInstances(t *testing.T) {
+	svc := &fakeEC2{
+		instances: []*ec2.Instance{
+			{
+				InstanceId:       aws.String("i-1"),
+				InstanceType:     aws.String("m4.large"),
+				PrivateIpAddress: aws.String("10.0.0.1"),
+				State:            &ec2.InstanceState{Name: aws.String("running")},
+				Placement:        &ec2.Placement{AvailabilityZone: aws.String("us-east-1a")},
+				Tags:             []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("master-1")}},
+			},
+			{
+				InstanceId: aws.String("i-2"),
+				State:      &ec2.InstanceState{Name: aws.String("terminated")},
+			},
+		},
+	}
+
+	instances, err := awsClusterInstances(context.Background(), svc, driftKube())
+	require.NoError(t, err)
+	require.Equal(t, []cloudInstance{{
+		ID:        "i-1",
+		Name:      "master-1",
+		Type:      "m4.large",
+		State:     "running",
+		Tags:      map[string]string{"Name": "master-1"},
+		Zone:      "us-east-1a",
+		PrivateIP: "10.0.0.1",
+	}}, instances)
+}
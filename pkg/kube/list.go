@@ -0,0 +1,46 @@
+package kube
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/storage"
+)
+
+// sendPage selects, orders and pages items that aren't in the storage by
+// list options of the request.
+func sendPage(w http.ResponseWriter, r *http.Request, items []interface{}) {
+	opts, err := storage.ParseListOptions(r.URL.Query())
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	values := make([][]byte, 0, len(items))
+	for _, item := range items {
+		v, err := json.Marshal(item)
+		if err != nil {
+			message.SendUnknownError(w, err)
+			return
+		}
+		values = append(values, v)
+	}
+
+	page, next, err := storage.Page(values, opts)
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	resp := make([]json.RawMessage, 0, len(page))
+	for _, v := range page {
+		resp = append(resp, v)
+	}
+	if next != "" {
+		w.Header().Set(storage.ContinueHeader, next)
+	}
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
@@ -0,0 +1,88 @@
+package kube
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+func (h *Handler) getMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(k.Maintenance); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) setMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+
+	window := model.MaintenanceWindow{}
+	if err := json.NewDecoder(r.Body).Decode(&window); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	if err := window.Validate(); err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	k.Maintenance = window
+	if err = h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(k.Maintenance); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// checkMaintenance rejects a disruptive workflow outside of the maintenance
+// window of the cluster, unless the force parameter of the request is set.
+func checkMaintenance(w http.ResponseWriter, r *http.Request, k *model.Kube) bool {
+	if force, _ := strconv.ParseBool(r.URL.Query().Get("force")); force {
+		return true
+	}
+
+	now := time.Now()
+	if k.Maintenance.Contains(now) {
+		return true
+	}
+
+	opens := k.Maintenance.Next(now)
+	message.SendOutsideMaintenance(w, opens, errors.Wrapf(sgerrors.ErrOutsideMaintenance,
+		"cluster %s, the window opens at %s", k.Name, opens.Format(time.RFC3339)))
+	return false
+}
@@ -0,0 +1,136 @@
+package kube
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+func TestHandler_setMaintenanceWindow(t *testing.T) {
+	tcs := []struct {
+		body string
+
+		serviceKube  *model.Kube
+		serviceError error
+
+		expectedStatus  int
+		expectedErrCode sgerrors.ErrorCode
+	}{
+		{ // TC#1
+			body:            "{",
+			expectedStatus:  http.StatusBadRequest,
+			expectedErrCode: sgerrors.InvalidJSON,
+		},
+		{ // TC#2
+			body:            `{"start":"02:00","end":"04:00","timezone":"Mars/Olympus"}`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedErrCode: sgerrors.ValidationFailed,
+		},
+		{ // TC#3
+			body:            `{"start":"02:00","end":"04:00"}`,
+			serviceError:    sgerrors.ErrNotFound,
+			expectedStatus:  http.StatusNotFound,
+			expectedErrCode: sgerrors.NotFound,
+		},
+		{ // TC#4
+			body:           `{"days":["sat"],"start":"02:00","end":"04:00","timezone":"Europe/Berlin"}`,
+			serviceKube:    &model.Kube{ID: "success"},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for i, tc := range tcs {
+		svc := new(kubeServiceMock)
+		svc.On(serviceGet, mock.Anything, mock.Anything).Return(tc.serviceKube, tc.serviceError)
+		svc.On(serviceCreate, mock.Anything, mock.Anything).Return(nil)
+
+		h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+		req, err := http.NewRequest(http.MethodPut, "/kubes/success/maintenance", bytes.NewBufferString(tc.body))
+		require.Equalf(t, nil, err, "TC#%d: create request: %v", i+1, err)
+		rr := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		h.Register(router)
+		router.ServeHTTP(rr, req)
+
+		require.Equalf(t, tc.expectedStatus, rr.Code, "TC#%d", i+1)
+
+		if tc.expectedErrCode != sgerrors.ErrorCode(0) {
+			m := new(message.Message)
+			err = json.NewDecoder(rr.Body).Decode(m)
+			require.Equalf(t, nil, err, "TC#%d", i+1)
+			require.Equalf(t, tc.expectedErrCode, m.ErrorCode, "TC#%d", i+1)
+			continue
+		}
+
+		window := model.MaintenanceWindow{}
+		require.NoErrorf(t, json.NewDecoder(rr.Body).Decode(&window), "TC#%d", i+1)
+		require.Equalf(t, "Europe/Berlin", window.Timezone, "TC#%d", i+1)
+		require.Equalf(t, window, tc.serviceKube.Maintenance, "TC#%d", i+1)
+	}
+}
+
+func TestHandler_patchKubeOutsideMaintenance(t *testing.T) {
+	// the window has opened a minute ago and lasts a minute
+	closed := time.Now().UTC().Add(-time.Minute)
+	k := &model.Kube{
+		ID:    "kube-1",
+		Name:  "kube-1",
+		State: model.StateOperational,
+		Maintenance: model.MaintenanceWindow{
+			Start: closed.Add(-time.Minute).Format("15:04"),
+			End:   closed.Format("15:04"),
+		},
+	}
+	svc := new(kubeServiceMock)
+	svc.On(serviceGet, mock.Anything, mock.Anything).Return(k, nil)
+
+	h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+	req, err := http.NewRequest(http.MethodPost, "/kubes/kube-1/ospatch", nil)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	h.Register(router)
+	router.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusConflict, rr.Code)
+	require.NotEmpty(t, rr.Header().Get("Retry-After"))
+
+	m := new(message.Message)
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(m))
+	require.Equal(t, sgerrors.OutsideMaintenance, m.ErrorCode)
+}
+
+func TestCheckMaintenance(t *testing.T) {
+	closed := time.Now().UTC().Add(-time.Minute)
+	k := &model.Kube{
+		Maintenance: model.MaintenanceWindow{
+			Start: closed.Add(-time.Minute).Format("15:04"),
+			End:   closed.Format("15:04"),
+		},
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/kubes/kube-1/runtime?force=true", nil)
+	require.True(t, checkMaintenance(httptest.NewRecorder(), r, k), "forced actions ignore the window")
+
+	r = httptest.NewRequest(http.MethodPost, "/kubes/kube-1/runtime", nil)
+	require.True(t, checkMaintenance(httptest.NewRecorder(), r, &model.Kube{}), "unset window is always open")
+
+	rr := httptest.NewRecorder()
+	require.False(t, checkMaintenance(rr, r, k))
+	require.Equal(t, http.StatusConflict, rr.Code)
+}
@@ -0,0 +1,250 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// resources of the metrics.k8s.io api served by metrics-server
+const (
+	nodeMetricsResource = "nodes.metrics.k8s.io"
+	podMetricsResource  = "pods.metrics.k8s.io"
+)
+
+// metricsList is a list of NodeMetrics or PodMetrics of metrics.k8s.io/v1beta1.
+type metricsList struct {
+	Items []struct {
+		Metadata   metav1.ObjectMeta   `json:"metadata"`
+		Timestamp  metav1.Time         `json:"timestamp"`
+		Usage      corev1.ResourceList `json:"usage"`
+		Containers []struct {
+			Usage corev1.ResourceList `json:"usage"`
+		} `json:"containers"`
+	} `json:"items"`
+}
+
+func (h *Handler) getNodeMetrics(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	nodes, err := h.nodeMetrics(r.Context(), k)
+	if err != nil {
+		sendMetricsError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(nodes); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// getPodMetrics reports pods filtered with namespace and label selector.
+func (h *Handler) getPodMetrics(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	opts, _, err := listOptions(r)
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	usage := &metricsList{}
+	if err = h.listKubeResources(r.Context(), k, podMetricsResource, opts, usage); err != nil {
+		sendMetricsError(w, err)
+		return
+	}
+	pods := &corev1.PodList{}
+	if err = h.listKubeResources(r.Context(), k, "pods", opts, pods); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	specs := make(map[string]*corev1.Pod, len(pods.Items))
+	for i := range pods.Items {
+		specs[pods.Items[i].Namespace+"/"+pods.Items[i].Name] = &pods.Items[i]
+	}
+
+	metrics := make([]PodMetrics, 0, len(usage.Items))
+	for _, item := range usage.Items {
+		m := PodMetrics{
+			Namespace: item.Metadata.Namespace,
+			Name:      item.Metadata.Name,
+			Timestamp: item.Timestamp.Time,
+		}
+		for _, c := range item.Containers {
+			m.CPUUsage += c.Usage.Cpu().MilliValue()
+			m.MemoryUsage += c.Usage.Memory().Value()
+		}
+
+		if pod, ok := specs[m.Namespace+"/"+m.Name]; ok {
+			m.Node = pod.Spec.NodeName
+			for _, c := range pod.Spec.Containers {
+				m.CPURequest += c.Resources.Requests.Cpu().MilliValue()
+				m.CPULimit += c.Resources.Limits.Cpu().MilliValue()
+				m.MemoryRequest += c.Resources.Requests.Memory().Value()
+				m.MemoryLimit += c.Resources.Limits.Memory().Value()
+			}
+		}
+		metrics = append(metrics, m)
+	}
+
+	sort.Slice(metrics, func(i, j int) bool {
+		if metrics[i].Namespace != metrics[j].Namespace {
+			return metrics[i].Namespace < metrics[j].Namespace
+		}
+		return metrics[i].Name < metrics[j].Name
+	})
+
+	if err = json.NewEncoder(w).Encode(metrics); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// getCapacity reports usage against capacity of all operational clusters,
+// clusters without metrics-server are counted with their capacity only.
+func (h *Handler) getCapacity(w http.ResponseWriter, r *http.Request) {
+	kubes, err := h.visibleKubes(r.Context())
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	var (
+		m      sync.Mutex
+		report = CapacityReport{
+			Clusters: make([]ClusterCapacity, 0),
+		}
+	)
+	eachOperationalKube(kubes, func(k *model.Kube) {
+		c := ClusterCapacity{
+			KubeID:   k.ID,
+			KubeName: k.Name,
+		}
+
+		nodes, err := h.nodeMetrics(r.Context(), k)
+		if err != nil {
+			c.Error = err.Error()
+		}
+		for _, n := range nodes {
+			c.add(ClusterCapacity{
+				Nodes:          1,
+				CPUUsage:       n.CPUUsage,
+				CPUCapacity:    n.CPUCapacity,
+				MemoryUsage:    n.MemoryUsage,
+				MemoryCapacity: n.MemoryCapacity,
+			})
+		}
+
+		m.Lock()
+		report.Clusters = append(report.Clusters, c)
+		report.Total.add(c)
+		m.Unlock()
+	})
+
+	sort.Slice(report.Clusters, func(i, j int) bool {
+		return report.Clusters[i].KubeName < report.Clusters[j].KubeName
+	})
+
+	if err = json.NewEncoder(w).Encode(report); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// nodeMetrics joins usage of the nodes with their allocatable resources.
+// Nodes are returned without usage when metrics-server isn't installed
+// along with the error.
+func (h *Handler) nodeMetrics(ctx context.Context, k *model.Kube) ([]NodeMetrics, error) {
+	nodes := &corev1.NodeList{}
+	if err := h.listKubeResources(ctx, k, "nodes", ResourceListOptions{}, nodes); err != nil {
+		return nil, err
+	}
+
+	usage := &metricsList{}
+	usageErr := h.listKubeResources(ctx, k, nodeMetricsResource, ResourceListOptions{}, usage)
+
+	used := make(map[string]int, len(usage.Items))
+	for i, item := range usage.Items {
+		used[item.Metadata.Name] = i
+	}
+
+	metrics := make([]NodeMetrics, 0, len(nodes.Items))
+	for _, node := range nodes.Items {
+		m := NodeMetrics{
+			Name:           node.Name,
+			CPUCapacity:    node.Status.Allocatable.Cpu().MilliValue(),
+			MemoryCapacity: node.Status.Allocatable.Memory().Value(),
+		}
+		if i, ok := used[node.Name]; ok {
+			m.CPUUsage = usage.Items[i].Usage.Cpu().MilliValue()
+			m.MemoryUsage = usage.Items[i].Usage.Memory().Value()
+			m.Timestamp = usage.Items[i].Timestamp.Time
+		}
+		m.CPUPercent = percent(m.CPUUsage, m.CPUCapacity)
+		m.MemoryPercent = percent(m.MemoryUsage, m.MemoryCapacity)
+		metrics = append(metrics, m)
+	}
+
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].Name < metrics[j].Name
+	})
+
+	return metrics, usageErr
+}
+
+func (c *ClusterCapacity) add(o ClusterCapacity) {
+	c.Nodes += o.Nodes
+	c.CPUUsage += o.CPUUsage
+	c.CPUCapacity += o.CPUCapacity
+	c.MemoryUsage += o.MemoryUsage
+	c.MemoryCapacity += o.MemoryCapacity
+	c.CPUPercent = percent(c.CPUUsage, c.CPUCapacity)
+	c.MemoryPercent = percent(c.MemoryUsage, c.MemoryCapacity)
+}
+
+func percent(usage, capacity int64) float64 {
+	if capacity == 0 {
+		return 0
+	}
+
+	return float64(usage*10000/capacity) / 100
+}
+
+// sendMetricsError tells clusters without metrics-server apart.
+func sendMetricsError(w http.ResponseWriter, err error) {
+	if sgerrors.IsNotFound(err) {
+		message.SendNotFound(w, "metrics-server", err)
+		return
+	}
+
+	message.SendUnknownError(w, err)
+}
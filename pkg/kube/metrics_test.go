@@ -0,0 +1,137 @@
+package kube
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+const (
+	testNodes = `{"items":[
+{"metadata":{"name":"node-2"},"status":{"allocatable":{"cpu":"2","memory":"4Gi"}}},
+{"metadata":{"name":"node-1"},"status":{"allocatable":{"cpu":"4","memory":"8Gi"}}}]}`
+	testNodeMetrics = `{"items":[
+{"metadata":{"name":"node-1"},"timestamp":"2019-05-01T10:00:00Z","usage":{"cpu":"1","memory":"2Gi"}},
+{"metadata":{"name":"node-2"},"timestamp":"2019-05-01T10:00:00Z","usage":{"cpu":"500m","memory":"1Gi"}}]}`
+	testPods = `{"items":[{"metadata":{"namespace":"default","name":"nginx"},"spec":{"nodeName":"node-1","containers":[
+{"name":"nginx","resources":{"requests":{"cpu":"100m","memory":"64Mi"},"limits":{"cpu":"200m","memory":"128Mi"}}},
+{"name":"sidecar","resources":{"requests":{"cpu":"50m"}}}]}}]}`
+	testPodMetrics = `{"items":[{"metadata":{"namespace":"default","name":"nginx"},"timestamp":"2019-05-01T10:00:00Z","containers":[
+{"name":"nginx","usage":{"cpu":"20m","memory":"32Mi"}},
+{"name":"sidecar","usage":{"cpu":"5m","memory":"16Mi"}}]}]}`
+)
+
+func metricsRequest(h *Handler, url string) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	h.Register(router)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, url, nil))
+	return rr
+}
+
+func TestHandler_getNodeMetrics(t *testing.T) {
+	k := &model.Kube{ID: "kube-1", State: model.StateOperational}
+
+	for _, tc := range []struct {
+		name           string
+		metricsErr     error
+		expectedStatus int
+	}{
+		{"success", nil, http.StatusOK},
+		{"no metrics-server", errors.Wrap(sgerrors.ErrNotFound, "resource"), http.StatusNotFound},
+	} {
+		svc := new(kubeServiceMock)
+		svc.On("Get", mock.Anything, "kube-1").Return(k, nil)
+		svc.On("ListResources", mock.Anything, "kube-1", "nodes", ResourceListOptions{}).
+			Return([]byte(testNodes), nil)
+		svc.On("ListResources", mock.Anything, "kube-1", nodeMetricsResource, ResourceListOptions{}).
+			Return([]byte(testNodeMetrics), tc.metricsErr)
+		h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+		rr := metricsRequest(h, "/kubes/kube-1/metrics/nodes")
+		require.Equal(t, tc.expectedStatus, rr.Code, tc.name)
+		if tc.expectedStatus != http.StatusOK {
+			continue
+		}
+
+		var nodes []NodeMetrics
+		require.Nil(t, json.NewDecoder(rr.Body).Decode(&nodes))
+		require.Len(t, nodes, 2)
+		require.Equal(t, "node-1", nodes[0].Name)
+		require.Equal(t, int64(1000), nodes[0].CPUUsage)
+		require.Equal(t, int64(4000), nodes[0].CPUCapacity)
+		require.Equal(t, 25.0, nodes[0].CPUPercent)
+		require.Equal(t, int64(8<<30), nodes[0].MemoryCapacity)
+		require.Equal(t, 25.0, nodes[0].MemoryPercent)
+		require.Equal(t, int64(500), nodes[1].CPUUsage)
+	}
+}
+
+func TestHandler_getPodMetrics(t *testing.T) {
+	opts := ResourceListOptions{Namespace: "default"}
+
+	svc := new(kubeServiceMock)
+	svc.On("Get", mock.Anything, "kube-1").Return(&model.Kube{ID: "kube-1"}, nil)
+	svc.On("ListResources", mock.Anything, "kube-1", podMetricsResource, opts).
+		Return([]byte(testPodMetrics), nil)
+	svc.On("ListResources", mock.Anything, "kube-1", "pods", opts).
+		Return([]byte(testPods), nil)
+	h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+	rr := metricsRequest(h, "/kubes/kube-1/metrics/pods?namespace=default")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var pods []PodMetrics
+	require.Nil(t, json.NewDecoder(rr.Body).Decode(&pods))
+	require.Len(t, pods, 1)
+	require.Equal(t, "node-1", pods[0].Node)
+	require.Equal(t, int64(25), pods[0].CPUUsage)
+	require.Equal(t, int64(150), pods[0].CPURequest)
+	require.Equal(t, int64(200), pods[0].CPULimit)
+	require.Equal(t, int64(48<<20), pods[0].MemoryUsage)
+	require.Equal(t, int64(64<<20), pods[0].MemoryRequest)
+}
+
+func TestHandler_getCapacity(t *testing.T) {
+	svc := new(kubeServiceMock)
+	svc.On("ListAll", mock.Anything).Return([]model.Kube{
+		{ID: "a", Name: "alpha", State: model.StateOperational},
+		{ID: "b", Name: "beta", State: model.StateOperational},
+	}, nil)
+	for _, id := range []string{"a", "b"} {
+		svc.On("ListResources", mock.Anything, id, "nodes", ResourceListOptions{}).
+			Return([]byte(testNodes), nil)
+	}
+	svc.On("ListResources", mock.Anything, "a", nodeMetricsResource, ResourceListOptions{}).
+		Return([]byte(testNodeMetrics), nil)
+	svc.On("ListResources", mock.Anything, "b", nodeMetricsResource, ResourceListOptions{}).
+		Return(nil, sgerrors.ErrNotFound)
+	h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+	rr := metricsRequest(h, "/kubes/metrics/capacity")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	report := &CapacityReport{}
+	require.Nil(t, json.NewDecoder(rr.Body).Decode(report))
+	require.Len(t, report.Clusters, 2)
+	require.Equal(t, "alpha", report.Clusters[0].KubeName)
+	require.Equal(t, int64(1500), report.Clusters[0].CPUUsage)
+	require.Equal(t, 25.0, report.Clusters[0].CPUPercent)
+	require.NotEmpty(t, report.Clusters[1].Error)
+	require.Equal(t, int64(6000), report.Clusters[1].CPUCapacity)
+
+	require.Equal(t, 4, report.Total.Nodes)
+	require.Equal(t, int64(12000), report.Total.CPUCapacity)
+	require.Equal(t, int64(1500), report.Total.CPUUsage)
+	require.Equal(t, 12.5, report.Total.CPUPercent)
+}
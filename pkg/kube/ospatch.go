@@ -0,0 +1,221 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/cron"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// ErrNotOperational is returned when an action requires an operational cluster.
+var ErrNotOperational = errors.New("cluster is not operational")
+
+func (h *Handler) patchKube(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if !checkMaintenance(w, r, k) {
+		return
+	}
+
+	node2Task, err := h.StartOSPatch(r.Context(), k)
+	if err != nil {
+		if errors.Cause(err) == ErrNotOperational {
+			w.WriteHeader(http.StatusNoContent)
+			logrus.Infof("Cluster %s is not operational", k.ID)
+			return
+		}
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, k.ProfileID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(node2Task); err != nil {
+		logrus.Errorf("Error encoding task map %v", err)
+	}
+}
+
+func (h *Handler) getOSPatchConfig(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(k.OSPatch); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) setOSPatchConfig(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+
+	cfg := model.OSPatch{}
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	if cfg.Schedule != "" {
+		if _, err := cron.Parse(cfg.Schedule); err != nil {
+			message.SendValidationFailed(w, err)
+			return
+		}
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	// count a new schedule from now on
+	cfg.LastRun = k.OSPatch.LastRun
+	if cfg.Schedule != k.OSPatch.Schedule {
+		cfg.LastRun = time.Now().Unix()
+	}
+	k.OSPatch = cfg
+
+	if err := h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(k.OSPatch); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// StartOSPatch runs package upgrades on cluster machines in background
+// and returns a node name to a task id mapping.
+func (h *Handler) StartOSPatch(ctx context.Context, k *model.Kube) (map[string]string, error) {
+	if k.State != model.StateOperational {
+		return nil, ErrNotOperational
+	}
+
+	config, err := h.newKubeConfig(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := h.makeMachineTasks(config, k, workflows.OSPatch)
+
+	go func() {
+		if err := h.kubeProvisioner.PatchCluster(context.Background(), k, tasks, config); err != nil {
+			logrus.Errorf("patch cluster %s caused %v", k.ID, err)
+		}
+	}()
+
+	return mapNode2Task(tasks), nil
+}
+
+// newKubeConfig builds a task config for an existing cluster.
+func (h *Handler) newKubeConfig(ctx context.Context, k *model.Kube) (*steps.Config, error) {
+	kubeProfile, err := h.profileSvc.Get(ctx, k.ProfileID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get profile %s", k.ProfileID)
+	}
+
+	config, err := steps.NewConfigFromKube(kubeProfile, k)
+	if err != nil {
+		return nil, errors.Wrap(err, "new config")
+	}
+
+	// Load things specific to cloud provider
+	if err = util.LoadCloudSpecificDataFromKube(k, config); err != nil {
+		return nil, errors.Wrap(err, "load cloud specific data")
+	}
+
+	// ssm runners talk to the cloud api on behalf of the cluster account
+	if k.RunnerType == runner.SSM {
+		acc, err := h.accountService.Get(ctx, k.AccountName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get cloud account %s", k.AccountName)
+		}
+
+		if err = util.FillCloudAccountCredentials(acc, config); err != nil {
+			return nil, errors.Wrap(err, "fill cloud account credentials")
+		}
+	}
+
+	return config, nil
+}
+
+// makeMachineTasks creates a workflow task for every cluster machine.
+func (h *Handler) makeMachineTasks(config *steps.Config, k *model.Kube, workflow string) map[string][]*workflows.Task {
+	taskMap := map[string][]*workflows.Task{}
+
+	for taskSet, machines := range map[string]map[string]*model.Machine{
+		workflows.MasterTask: k.Masters,
+		workflows.NodeTask:   k.Nodes,
+	} {
+		for _, machine := range machines {
+			// maintenance workflows run linux scripts
+			if machine.OperatingSystem == model.OSWindows {
+				continue
+			}
+
+			task, err := workflows.NewTask(config, workflow, h.repo)
+			if err != nil {
+				logrus.Errorf("Failed to set up task for %s workflow", workflow)
+				continue
+			}
+
+			cfg := *config
+			cfg.Node = *machine
+			cfg.IsMaster = taskSet == workflows.MasterTask
+			cfg.IsBootstrap = false
+			task.Config = &cfg
+			taskMap[taskSet] = append(taskMap[taskSet], task)
+		}
+	}
+
+	return taskMap
+}
+
+// NewOSPatchScheduler creates a scheduler that starts os patching
+// of clusters according to their schedules.
+func NewOSPatchScheduler(svc Interface, patch func(context.Context, *model.Kube) (map[string]string, error)) *Scheduler {
+	return newScheduler(svc, "os patch", func(k *model.Kube) (string, *int64) {
+		return k.OSPatch.Schedule, &k.OSPatch.LastRun
+	}, patch)
+}
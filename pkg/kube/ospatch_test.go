@@ -0,0 +1,165 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+func TestHandler_setOSPatchConfig(t *testing.T) {
+	tcs := []struct {
+		body string
+
+		serviceKube  *model.Kube
+		serviceError error
+
+		expectedStatus  int
+		expectedErrCode sgerrors.ErrorCode
+	}{
+		{ // TC#1
+			body:            "{",
+			expectedStatus:  http.StatusBadRequest,
+			expectedErrCode: sgerrors.InvalidJSON,
+		},
+		{ // TC#2
+			body:            `{"schedule":"0 3 * *"}`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedErrCode: sgerrors.ValidationFailed,
+		},
+		{ // TC#3
+			body:            `{"schedule":"0 3 * * 6"}`,
+			serviceError:    sgerrors.ErrNotFound,
+			expectedStatus:  http.StatusNotFound,
+			expectedErrCode: sgerrors.NotFound,
+		},
+		{ // TC#4
+			body: `{"schedule":"0 3 * * 6","livePatch":true}`,
+			serviceKube: &model.Kube{
+				ID: "success",
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for i, tc := range tcs {
+		svc := new(kubeServiceMock)
+		svc.On(serviceGet, mock.Anything, mock.Anything).Return(tc.serviceKube, tc.serviceError)
+		svc.On(serviceCreate, mock.Anything, mock.Anything).Return(nil)
+
+		h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+		req, err := http.NewRequest(http.MethodPut, "/kubes/success/ospatch", bytes.NewBufferString(tc.body))
+		require.Equalf(t, nil, err, "TC#%d: create request: %v", i+1, err)
+		rr := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		h.Register(router)
+		router.ServeHTTP(rr, req)
+
+		require.Equalf(t, tc.expectedStatus, rr.Code, "TC#%d", i+1)
+
+		if tc.expectedErrCode != sgerrors.ErrorCode(0) {
+			m := new(message.Message)
+			err = json.NewDecoder(rr.Body).Decode(m)
+			require.Equalf(t, nil, err, "TC#%d", i+1)
+			require.Equalf(t, tc.expectedErrCode, m.ErrorCode, "TC#%d", i+1)
+			continue
+		}
+
+		cfg := model.OSPatch{}
+		err = json.NewDecoder(rr.Body).Decode(&cfg)
+		require.Equalf(t, nil, err, "TC#%d", i+1)
+		require.Equalf(t, "0 3 * * 6", cfg.Schedule, "TC#%d", i+1)
+		require.Truef(t, cfg.LivePatch, "TC#%d", i+1)
+		require.NotZerof(t, cfg.LastRun, "TC#%d: schedule must start from now", i+1)
+	}
+}
+
+func TestOSPatchScheduler_check(t *testing.T) {
+	now := time.Date(2019, time.July, 20, 3, 0, 30, 0, time.UTC)
+	lastRun := now.Add(-time.Hour * 24).Unix()
+
+	tcs := []struct {
+		description string
+		kube        model.Kube
+		expectPatch bool
+	}{
+		{
+			description: "no schedule",
+			kube: model.Kube{
+				ID:    "1",
+				State: model.StateOperational,
+			},
+		},
+		{
+			description: "not operational",
+			kube: model.Kube{
+				ID:      "2",
+				State:   model.StateProvisioning,
+				OSPatch: model.OSPatch{Schedule: "0 3 * * *", LastRun: lastRun},
+			},
+		},
+		{
+			description: "not due",
+			kube: model.Kube{
+				ID:      "3",
+				State:   model.StateOperational,
+				OSPatch: model.OSPatch{Schedule: "0 4 * * *", LastRun: now.Unix()},
+			},
+		},
+		{
+			description: "due",
+			kube: model.Kube{
+				ID:      "4",
+				State:   model.StateOperational,
+				OSPatch: model.OSPatch{Schedule: "0 3 * * *", LastRun: lastRun},
+			},
+			expectPatch: true,
+		},
+		{
+			description: "outside of the maintenance window",
+			kube: model.Kube{
+				ID:          "5",
+				State:       model.StateOperational,
+				OSPatch:     model.OSPatch{Schedule: "0 3 * * *", LastRun: lastRun},
+				Maintenance: model.MaintenanceWindow{Days: []string{"sun"}, Start: "02:00", End: "06:00"},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		svc := new(kubeServiceMock)
+		svc.On(serviceListAll, mock.Anything).Return([]model.Kube{tc.kube}, nil)
+		svc.On(serviceCreate, mock.Anything, mock.Anything).Return(nil)
+
+		var patched *model.Kube
+		s := NewOSPatchScheduler(svc, func(ctx context.Context, k *model.Kube) (map[string]string, error) {
+			patched = k
+			return nil, nil
+		})
+		s.started = now.Add(-time.Minute)
+
+		s.check(context.Background(), now)
+
+		if !tc.expectPatch {
+			require.Nilf(t, patched, tc.description)
+			continue
+		}
+
+		require.NotNilf(t, patched, tc.description)
+		require.Equalf(t, now.Unix(), patched.OSPatch.LastRun, tc.description)
+		svc.AssertCalled(t, serviceCreate, mock.Anything, patched)
+	}
+}
@@ -0,0 +1,303 @@
+package kube
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/labels"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/storage"
+)
+
+// Formats of capacity plan reports.
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// instanceTypeLabels are labels cloud providers set to instance types of nodes.
+var instanceTypeLabels = []string{
+	"node.kubernetes.io/instance-type",
+	"beta.kubernetes.io/instance-type",
+}
+
+var capacityPlanColumns = []string{
+	"kube", "role", "size", "nodes",
+	"cpu_allocatable_m", "cpu_requests_m", "cpu_limits_m", "cpu_usage_m", "cpu_headroom_m",
+	"cpu_requests_percent", "cpu_limits_percent",
+	"memory_allocatable_bytes", "memory_requests_bytes", "memory_limits_bytes", "memory_usage_bytes",
+	"memory_headroom_bytes", "memory_requests_percent", "memory_limits_percent",
+	"overcommitted", "error",
+}
+
+// getCapacityPlan reports requests and limits against allocatable resources
+// of clusters selected by labels and of their node groups for capacity
+// reviews, format=csv exports it as a spreadsheet.
+func (h *Handler) getCapacityPlan(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = FormatJSON
+	}
+	if format != FormatJSON && format != FormatCSV {
+		message.SendValidationFailed(w, errors.Errorf("unknown format %q, use %s or %s", format, FormatJSON, FormatCSV))
+		return
+	}
+
+	opts, err := storage.ParseListOptions(r.URL.Query())
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	kubes, err := h.svc.ListAll(r.Context())
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	scope := api.ScopeFrom(r.Context())
+	selected := make([]model.Kube, 0, len(kubes))
+	for _, k := range kubes {
+		if scope.AllowsLabeled(k.Team, k.Labels) && labels.Matches(k.Labels, opts.Labels) {
+			selected = append(selected, k)
+		}
+	}
+
+	report := h.capacityPlan(r.Context(), selected)
+
+	if format == FormatCSV {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q",
+			"capacity-"+report.GeneratedAt.Format("2006-01-02")+".csv"))
+		if err = writeCapacityPlanCSV(w, report); err != nil {
+			logrus.Errorf("kubes: write capacity plan: %v", err)
+		}
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(report); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// capacityPlan builds plans of operational clusters, clusters without
+// metrics-server are planned without their usage.
+func (h *Handler) capacityPlan(ctx context.Context, kubes []model.Kube) *CapacityPlanReport {
+	var (
+		m      sync.Mutex
+		report = &CapacityPlanReport{
+			GeneratedAt: time.Now(),
+			Clusters:    make([]CapacityPlan, 0),
+			Groups:      make([]CapacityPlan, 0),
+		}
+	)
+	eachOperationalKube(kubes, func(k *model.Kube) {
+		cluster, groups := h.kubeCapacityPlan(ctx, k)
+
+		m.Lock()
+		report.Clusters = append(report.Clusters, cluster)
+		report.Groups = append(report.Groups, groups...)
+		report.Total.add(cluster)
+		m.Unlock()
+	})
+
+	sort.Slice(report.Clusters, func(i, j int) bool {
+		return report.Clusters[i].KubeName < report.Clusters[j].KubeName
+	})
+	sort.Slice(report.Groups, func(i, j int) bool {
+		a, b := report.Groups[i], report.Groups[j]
+		if a.KubeName != b.KubeName {
+			return a.KubeName < b.KubeName
+		}
+		if a.Role != b.Role {
+			return a.Role < b.Role
+		}
+		return a.Size < b.Size
+	})
+
+	return report
+}
+
+func (h *Handler) kubeCapacityPlan(ctx context.Context, k *model.Kube) (CapacityPlan, []CapacityPlan) {
+	cluster := CapacityPlan{
+		KubeID:   k.ID,
+		KubeName: k.Name,
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := h.listKubeResources(ctx, k, "nodes", ResourceListOptions{}, nodes); err != nil {
+		cluster.Error = err.Error()
+		return cluster, nil
+	}
+	pods := &corev1.PodList{}
+	if err := h.listKubeResources(ctx, k, "pods", ResourceListOptions{}, pods); err != nil {
+		cluster.Error = err.Error()
+		return cluster, nil
+	}
+	usage := &metricsList{}
+	if err := h.listKubeResources(ctx, k, nodeMetricsResource, ResourceListOptions{}, usage); err != nil {
+		cluster.Error = err.Error()
+	}
+
+	groups := planNodeGroups(k, nodes.Items, pods.Items, usage)
+	for _, g := range groups {
+		cluster.add(g)
+	}
+	return cluster, groups
+}
+
+// planNodeGroups sums resources of nodes of the same role and size, pods
+// that have terminated don't hold their requests anymore.
+func planNodeGroups(k *model.Kube, nodes []corev1.Node, pods []corev1.Pod, usage *metricsList) []CapacityPlan {
+	node := make(map[string]*CapacityPlan, len(nodes))
+	for _, n := range nodes {
+		node[n.Name] = &CapacityPlan{
+			Nodes:             1,
+			CPUAllocatable:    n.Status.Allocatable.Cpu().MilliValue(),
+			MemoryAllocatable: n.Status.Allocatable.Memory().Value(),
+		}
+	}
+
+	for _, pod := range pods {
+		p := node[pod.Spec.NodeName]
+		if p == nil || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, c := range pod.Spec.Containers {
+			p.CPURequests += c.Resources.Requests.Cpu().MilliValue()
+			p.CPULimits += c.Resources.Limits.Cpu().MilliValue()
+			p.MemoryRequests += c.Resources.Requests.Memory().Value()
+			p.MemoryLimits += c.Resources.Limits.Memory().Value()
+		}
+	}
+
+	for _, item := range usage.Items {
+		if p := node[item.Metadata.Name]; p != nil {
+			p.CPUUsage = item.Usage.Cpu().MilliValue()
+			p.MemoryUsage = item.Usage.Memory().Value()
+		}
+	}
+
+	groups := make(map[nodeGroup]*CapacityPlan)
+	for i := range nodes {
+		key := nodeGroupOf(k, &nodes[i])
+		g := groups[key]
+		if g == nil {
+			g = &CapacityPlan{
+				KubeID:   k.ID,
+				KubeName: k.Name,
+				Role:     key.role,
+				Size:     key.size,
+			}
+			groups[key] = g
+		}
+		g.add(*node[nodes[i].Name])
+	}
+
+	plans := make([]CapacityPlan, 0, len(groups))
+	for _, g := range groups {
+		plans = append(plans, *g)
+	}
+	sort.Slice(plans, func(i, j int) bool {
+		if plans[i].Role != plans[j].Role {
+			return plans[i].Role < plans[j].Role
+		}
+		return plans[i].Size < plans[j].Size
+	})
+
+	return plans
+}
+
+// nodeGroupOf finds the role and the size of the node by its machine, nodes
+// control doesn't track are grouped by their labels.
+func nodeGroupOf(k *model.Kube, node *corev1.Node) nodeGroup {
+	if m, isMaster := machineFor(k, node.Name); m != nil {
+		return nodeGroup{model.ToRole(isMaster), m.Size}
+	}
+
+	key := nodeGroup{role: nodeRole(node)}
+	for _, l := range instanceTypeLabels {
+		if size := node.Labels[l]; size != "" {
+			key.size = size
+			break
+		}
+	}
+	return key
+}
+
+func (p *CapacityPlan) add(o CapacityPlan) {
+	p.Nodes += o.Nodes
+	p.CPUAllocatable += o.CPUAllocatable
+	p.CPURequests += o.CPURequests
+	p.CPULimits += o.CPULimits
+	p.CPUUsage += o.CPUUsage
+	p.MemoryAllocatable += o.MemoryAllocatable
+	p.MemoryRequests += o.MemoryRequests
+	p.MemoryLimits += o.MemoryLimits
+	p.MemoryUsage += o.MemoryUsage
+
+	p.CPUHeadroom = p.CPUAllocatable - p.CPURequests
+	p.CPURequestsPercent = percent(p.CPURequests, p.CPUAllocatable)
+	p.CPULimitsPercent = percent(p.CPULimits, p.CPUAllocatable)
+	p.MemoryHeadroom = p.MemoryAllocatable - p.MemoryRequests
+	p.MemoryRequestsPercent = percent(p.MemoryRequests, p.MemoryAllocatable)
+	p.MemoryLimitsPercent = percent(p.MemoryLimits, p.MemoryAllocatable)
+	p.Overcommitted = p.CPULimits > p.CPUAllocatable || p.MemoryLimits > p.MemoryAllocatable
+}
+
+// writeCapacityPlanCSV writes a row of every cluster followed by rows of its
+// node groups, the last row is the total.
+func writeCapacityPlanCSV(w io.Writer, report *CapacityPlanReport) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(capacityPlanColumns); err != nil {
+		return err
+	}
+
+	for _, c := range report.Clusters {
+		if err := cw.Write(capacityPlanRow(c.KubeName, c)); err != nil {
+			return err
+		}
+		for _, g := range report.Groups {
+			if g.KubeID != c.KubeID {
+				continue
+			}
+			if err := cw.Write(capacityPlanRow(g.KubeName, g)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cw.Write(capacityPlanRow("total", report.Total)); err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func capacityPlanRow(kube string, p CapacityPlan) []string {
+	i := func(v int64) string { return strconv.FormatInt(v, 10) }
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+
+	return []string{
+		kube, string(p.Role), p.Size, strconv.Itoa(p.Nodes),
+		i(p.CPUAllocatable), i(p.CPURequests), i(p.CPULimits), i(p.CPUUsage), i(p.CPUHeadroom),
+		f(p.CPURequestsPercent), f(p.CPULimitsPercent),
+		i(p.MemoryAllocatable), i(p.MemoryRequests), i(p.MemoryLimits), i(p.MemoryUsage),
+		i(p.MemoryHeadroom), f(p.MemoryRequestsPercent), f(p.MemoryLimitsPercent),
+		strconv.FormatBool(p.Overcommitted), p.Error,
+	}
+}
@@ -0,0 +1,102 @@
+package kube
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+func capacityPlanHandler() *Handler {
+	svc := new(kubeServiceMock)
+	svc.On("ListAll", mock.Anything).Return([]model.Kube{
+		{
+			ID: "a", Name: "alpha", State: model.StateOperational,
+			Labels: map[string]string{"env": "prod"},
+			Nodes:  map[string]*model.Machine{"node-1": {Name: "node-1", Size: "m5.xlarge"}},
+		},
+		{ID: "b", Name: "beta", State: model.StateOperational, Labels: map[string]string{"env": "dev"}},
+	}, nil)
+	for _, id := range []string{"a", "b"} {
+		svc.On("ListResources", mock.Anything, id, "nodes", ResourceListOptions{}).
+			Return([]byte(testNodes), nil)
+		svc.On("ListResources", mock.Anything, id, "pods", ResourceListOptions{}).
+			Return([]byte(testPods), nil)
+	}
+	svc.On("ListResources", mock.Anything, "a", nodeMetricsResource, ResourceListOptions{}).
+		Return([]byte(testNodeMetrics), nil)
+	svc.On("ListResources", mock.Anything, "b", nodeMetricsResource, ResourceListOptions{}).
+		Return(nil, sgerrors.ErrNotFound)
+
+	return NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+}
+
+func TestHandler_getCapacityPlan(t *testing.T) {
+	rr := metricsRequest(capacityPlanHandler(), "/kubes/capacity/plan")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	report := &CapacityPlanReport{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(report))
+	require.Len(t, report.Clusters, 2)
+	require.Len(t, report.Groups, 3)
+
+	alpha := report.Clusters[0]
+	require.Equal(t, "alpha", alpha.KubeName)
+	require.Equal(t, 2, alpha.Nodes)
+	require.Equal(t, int64(6000), alpha.CPUAllocatable)
+	require.Equal(t, int64(150), alpha.CPURequests)
+	require.Equal(t, int64(5850), alpha.CPUHeadroom)
+	require.Equal(t, int64(1500), alpha.CPUUsage)
+	require.Equal(t, 2.5, alpha.CPURequestsPercent)
+	require.False(t, alpha.Overcommitted)
+	require.Empty(t, alpha.Error)
+
+	// node-1 is grouped by its machine, node-2 isn't tracked
+	require.Equal(t, model.RoleNode, report.Groups[0].Role)
+	require.Equal(t, "", report.Groups[0].Size)
+	require.Equal(t, int64(2000), report.Groups[0].CPUAllocatable)
+	require.Equal(t, "m5.xlarge", report.Groups[1].Size)
+	require.Equal(t, int64(64<<20), report.Groups[1].MemoryRequests)
+	require.Equal(t, int64(128<<20), report.Groups[1].MemoryLimits)
+
+	require.NotEmpty(t, report.Clusters[1].Error)
+	require.Equal(t, int64(6000), report.Clusters[1].CPUAllocatable)
+	require.Equal(t, 4, report.Total.Nodes)
+	require.Equal(t, int64(300), report.Total.CPURequests)
+}
+
+func TestHandler_getCapacityPlanCSV(t *testing.T) {
+	rr := metricsRequest(capacityPlanHandler(), "/kubes/capacity/plan?format=csv&labelSelector=env=prod")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Contains(t, rr.Header().Get("Content-Type"), "text/csv")
+	require.Contains(t, rr.Header().Get("Content-Disposition"), "attachment")
+
+	rows, err := csv.NewReader(rr.Body).ReadAll()
+	require.NoError(t, err)
+	// header, cluster, its groups and the total
+	require.Len(t, rows, 5)
+	require.Equal(t, capacityPlanColumns, rows[0])
+	require.Equal(t, []string{"alpha", "", "", "2", "6000", "150"}, rows[1][:6])
+	require.Equal(t, []string{"alpha", "node", "m5.xlarge", "1"}, rows[3][:4])
+	require.Equal(t, "total", rows[4][0])
+	require.Equal(t, "false", rows[4][len(capacityPlanColumns)-2])
+}
+
+func TestHandler_getCapacityPlanFormat(t *testing.T) {
+	rr := metricsRequest(capacityPlanHandler(), "/kubes/capacity/plan?format=xlsx")
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestCapacityPlan_overcommitted(t *testing.T) {
+	p := CapacityPlan{}
+	p.add(CapacityPlan{Nodes: 1, CPUAllocatable: 1000, CPULimits: 1500, MemoryAllocatable: 100, MemoryRequests: 50})
+	require.True(t, p.Overcommitted)
+	require.Equal(t, 150.0, p.CPULimitsPercent)
+	require.Equal(t, int64(50), p.MemoryHeadroom)
+}
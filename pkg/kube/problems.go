@@ -0,0 +1,348 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/timeline"
+)
+
+const nodeProblemCheckInterval = 5 * time.Minute
+
+// Problem conditions reported by node-problem-detector, kubelet pressure
+// conditions keep their kubernetes types.
+const (
+	ConditionKernelDeadlock            = "KernelDeadlock"
+	ConditionReadonlyFilesystem        = "ReadonlyFilesystem"
+	ConditionContainerRuntimeUnhealthy = "ContainerRuntimeUnhealthy"
+	ConditionKubeletUnhealthy          = "KubeletUnhealthy"
+	ConditionFrequentKubeletRestart    = "FrequentKubeletRestart"
+	ConditionFrequentDockerRestart     = "FrequentDockerRestart"
+	ConditionFrequentContainerdRestart = "FrequentContainerdRestart"
+	// ConditionNotReady is reported for nodes which Ready condition isn't true.
+	ConditionNotReady = "NotReady"
+)
+
+// Repair actions node problems call for, nodes with problems kubernetes
+// handles itself, e.g. disk pressure, aren't repaired.
+const (
+	RepairNone    = ""
+	RepairReboot  = "reboot"
+	RepairReplace = "replace"
+)
+
+// problemRepairs maps problem conditions to repair actions, conditions that
+// aren't listed are reported, but aren't repaired.
+var problemRepairs = map[string]string{
+	ConditionKernelDeadlock:               RepairReboot,
+	ConditionContainerRuntimeUnhealthy:    RepairReboot,
+	ConditionKubeletUnhealthy:             RepairReboot,
+	ConditionFrequentKubeletRestart:       RepairReboot,
+	ConditionFrequentDockerRestart:        RepairReboot,
+	ConditionFrequentContainerdRestart:    RepairReboot,
+	ConditionReadonlyFilesystem:           RepairReplace,
+	ConditionNotReady:                     RepairReplace,
+	string(corev1.NodeDiskPressure):       RepairNone,
+	string(corev1.NodeMemoryPressure):     RepairNone,
+	string(corev1.NodePIDPressure):        RepairNone,
+	string(corev1.NodeNetworkUnavailable): RepairNone,
+	string(corev1.NodeOutOfDisk):          RepairNone,
+}
+
+// NodeHealth is the state of a kubernetes node with the repair its problems
+// call for.
+type NodeHealth struct {
+	Name       string                `json:"name"`
+	Role       model.Role            `json:"role"`
+	Ready      bool                  `json:"ready"`
+	Conditions []model.NodeCondition `json:"conditions"`
+	Repair     string                `json:"repair,omitempty"`
+}
+
+// KubeHealth reports problems of nodes of the cluster.
+type KubeHealth struct {
+	KubeID    string       `json:"kubeId"`
+	CheckedAt time.Time    `json:"checkedAt"`
+	Healthy   bool         `json:"healthy"`
+	Nodes     []NodeHealth `json:"nodes"`
+}
+
+func (h *Handler) getKubeHealth(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	health, err := h.NodeHealth(r.Context(), k)
+	if err != nil {
+		if errors.Cause(err) == ErrNotOperational {
+			message.SendValidationFailed(w, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(health); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// NodeHealth checks conditions of nodes of the cluster, problems are saved
+// to machines of the nodes and new ones are recorded to the timeline. Repair
+// actions of the report are what auto-repair should take.
+func (h *Handler) NodeHealth(ctx context.Context, k *model.Kube) (*KubeHealth, error) {
+	if k.State != model.StateOperational {
+		return nil, ErrNotOperational
+	}
+
+	nodes, err := h.svc.ListNodes(ctx, k, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "list nodes")
+	}
+
+	now := time.Now()
+	health := &KubeHealth{
+		KubeID:    k.ID,
+		CheckedAt: now,
+		Healthy:   len(nodes) > 0,
+		Nodes:     make([]NodeHealth, 0, len(nodes)),
+	}
+
+	changed := false
+	for _, node := range nodes {
+		n := NodeHealth{
+			Name:       node.Name,
+			Role:       nodeRole(&node),
+			Ready:      nodeReady(node),
+			Conditions: nodeProblems(node, now),
+		}
+
+		if m := machineOf(k, node.Name); m != nil {
+			n.Role = m.Role
+			n.Conditions = keepSince(n.Conditions, m.Conditions)
+			for _, c := range newProblems(n.Conditions, m.Conditions) {
+				h.recordEvent(ctx, &timeline.Event{
+					KubeID:  k.ID,
+					Type:    timeline.NodeProblem,
+					Object:  node.Name,
+					Message: fmt.Sprintf("%s %s has %s: %s", n.Role, node.Name, c.Type, c.Message),
+				})
+			}
+			if !sameProblems(n.Conditions, m.Conditions) {
+				m.Conditions = n.Conditions
+				changed = true
+			}
+		}
+
+		n.Repair = RepairAction(n.Conditions)
+		if len(n.Conditions) > 0 {
+			health.Healthy = false
+		}
+		health.Nodes = append(health.Nodes, n)
+	}
+
+	if changed {
+		if err = h.svc.Create(ctx, k); err != nil {
+			return nil, errors.Wrapf(err, "update cluster %s", k.ID)
+		}
+	}
+
+	return health, nil
+}
+
+// RepairAction returns the most disruptive repair the problems call for.
+func RepairAction(conditions []model.NodeCondition) string {
+	repair := RepairNone
+	for _, c := range conditions {
+		switch problemRepairs[c.Type] {
+		case RepairReplace:
+			return RepairReplace
+		case RepairReboot:
+			repair = RepairReboot
+		}
+	}
+	return repair
+}
+
+// nodeProblems returns problem conditions of the node sorted by types.
+func nodeProblems(node corev1.Node, now time.Time) []model.NodeCondition {
+	var problems []model.NodeCondition
+	for _, c := range node.Status.Conditions {
+		typ := string(c.Type)
+		if c.Type == corev1.NodeReady {
+			if c.Status == corev1.ConditionTrue {
+				continue
+			}
+			typ = ConditionNotReady
+		} else if c.Status != corev1.ConditionTrue {
+			continue
+		}
+
+		since := now.Unix()
+		if !c.LastTransitionTime.IsZero() {
+			since = c.LastTransitionTime.Unix()
+		}
+		problems = append(problems, model.NodeCondition{
+			Type:    typ,
+			Reason:  c.Reason,
+			Message: c.Message,
+			Since:   since,
+		})
+	}
+	if !hasCondition(node, corev1.NodeReady) {
+		problems = append(problems, model.NodeCondition{
+			Type:    ConditionNotReady,
+			Message: "node hasn't reported its status",
+			Since:   now.Unix(),
+		})
+	}
+
+	sort.Slice(problems, func(i, j int) bool {
+		return problems[i].Type < problems[j].Type
+	})
+	return problems
+}
+
+// keepSince keeps when the problems were first observed, kubernetes resets
+// transition times of conditions that are updated by their reasons.
+func keepSince(problems, previous []model.NodeCondition) []model.NodeCondition {
+	for i := range problems {
+		for _, p := range previous {
+			if p.Type == problems[i].Type && p.Since < problems[i].Since {
+				problems[i].Since = p.Since
+			}
+		}
+	}
+	return problems
+}
+
+func newProblems(problems, previous []model.NodeCondition) []model.NodeCondition {
+	var found []model.NodeCondition
+	for _, c := range problems {
+		if !hasProblem(previous, c.Type) {
+			found = append(found, c)
+		}
+	}
+	return found
+}
+
+func sameProblems(a, b []model.NodeCondition) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func hasProblem(conditions []model.NodeCondition, typ string) bool {
+	for _, c := range conditions {
+		if c.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func hasCondition(node corev1.Node, typ corev1.NodeConditionType) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func problemTypes(conditions []model.NodeCondition) string {
+	types := make([]string, 0, len(conditions))
+	for _, c := range conditions {
+		types = append(types, c.Type)
+	}
+	return strings.Join(types, ",")
+}
+
+func machineOf(k *model.Kube, name string) *model.Machine {
+	if m := k.Masters[name]; m != nil {
+		return m
+	}
+	return k.Nodes[name]
+}
+
+// NodeProblemMonitor checks node conditions of operational clusters
+// periodically, so problems are kept on machines and show up on the timeline.
+type NodeProblemMonitor struct {
+	h        *Handler
+	interval time.Duration
+}
+
+func NewNodeProblemMonitor(h *Handler) *NodeProblemMonitor {
+	return &NodeProblemMonitor{
+		h:        h,
+		interval: nodeProblemCheckInterval,
+	}
+}
+
+// Run checks clusters until the context is done.
+func (m *NodeProblemMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.check(ctx)
+		}
+	}
+}
+
+func (m *NodeProblemMonitor) check(ctx context.Context) {
+	kubes, err := m.h.svc.ListAll(ctx)
+	if err != nil {
+		logrus.Errorf("node problem monitor: list kubes: %v", err)
+		return
+	}
+
+	for i := range kubes {
+		if kubes[i].State != model.StateOperational {
+			continue
+		}
+
+		health, err := m.h.NodeHealth(ctx, &kubes[i])
+		if err != nil {
+			logrus.Errorf("node problem monitor: kube %s: %v", kubes[i].ID, err)
+			continue
+		}
+		for _, n := range health.Nodes {
+			if len(n.Conditions) > 0 {
+				logrus.Warnf("node problem monitor: kube %s: node %s has problems %s",
+					kubes[i].ID, n.Name, problemTypes(n.Conditions))
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package kube
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/storage/memory"
+	"github.com/supergiant/control/pkg/timeline"
+)
+
+func TestRepairAction(t *testing.T) {
+	tcs := []struct {
+		types  []string
+		repair string
+	}{
+		{nil, RepairNone},
+		{[]string{string(corev1.NodeDiskPressure)}, RepairNone},
+		{[]string{"UnknownProblem"}, RepairNone},
+		{[]string{string(corev1.NodeMemoryPressure), ConditionKernelDeadlock}, RepairReboot},
+		{[]string{ConditionFrequentDockerRestart, ConditionReadonlyFilesystem}, RepairReplace},
+		{[]string{ConditionNotReady, ConditionContainerRuntimeUnhealthy}, RepairReplace},
+	}
+
+	for _, tc := range tcs {
+		var conditions []model.NodeCondition
+		for _, typ := range tc.types {
+			conditions = append(conditions, model.NodeCondition{Type: typ})
+		}
+		require.Equalf(t, tc.repair, RepairAction(conditions), "%v", tc.types)
+	}
+}
+
+func TestHandler_NodeHealth(t *testing.T) {
+	ready := corev1.NodeCondition{Type: corev1.NodeReady, Status: corev1.ConditionTrue}
+	nodes := inventoryNodes("master-1", "node-1", "node-2")
+	nodes[0].Status.Conditions = []corev1.NodeCondition{ready}
+	nodes[1].Status.Conditions = []corev1.NodeCondition{
+		ready,
+		{Type: ConditionKernelDeadlock, Status: corev1.ConditionTrue, Reason: "DockerHung", Message: "task docker:7 blocked"},
+		{Type: corev1.NodeDiskPressure, Status: corev1.ConditionFalse},
+	}
+
+	k := &model.Kube{
+		ID:      "kube-1",
+		State:   model.StateOperational,
+		Masters: map[string]*model.Machine{"master-1": {Name: "master-1", Role: model.RoleMaster}},
+		Nodes: map[string]*model.Machine{
+			"node-1": {Name: "node-1", Role: model.RoleNode},
+			"node-2": {Name: "node-2", Role: model.RoleNode, Conditions: []model.NodeCondition{{Type: ConditionNotReady, Since: 10}}},
+		},
+	}
+	svc := new(kubeServiceMock)
+	svc.On("ListNodes", mock.Anything, k, "").Return(nodes, nil)
+	svc.On(serviceCreate, mock.Anything, k).Return(nil)
+	events := timeline.NewService(timeline.DefaultStoragePrefix, memory.NewInMemoryRepository())
+	h := &Handler{svc: svc, timeline: events}
+
+	health, err := h.NodeHealth(context.Background(), k)
+	require.NoError(t, err)
+	require.False(t, health.Healthy)
+	require.Len(t, health.Nodes, 3)
+
+	require.Equal(t, model.RoleMaster, health.Nodes[0].Role)
+	require.True(t, health.Nodes[0].Ready)
+	require.Empty(t, health.Nodes[0].Conditions)
+	require.Equal(t, RepairNone, health.Nodes[0].Repair)
+
+	require.True(t, health.Nodes[1].Ready)
+	require.Len(t, health.Nodes[1].Conditions, 1)
+	require.Equal(t, ConditionKernelDeadlock, health.Nodes[1].Conditions[0].Type)
+	require.Equal(t, "DockerHung", health.Nodes[1].Conditions[0].Reason)
+	require.Equal(t, RepairReboot, health.Nodes[1].Repair)
+	require.Equal(t, health.Nodes[1].Conditions, k.Nodes["node-1"].Conditions)
+
+	require.False(t, health.Nodes[2].Ready)
+	require.Equal(t, RepairReplace, health.Nodes[2].Repair)
+	require.Equal(t, int64(10), k.Nodes["node-2"].Conditions[0].Since)
+	svc.AssertCalled(t, serviceCreate, mock.Anything, k)
+
+	// only new problems are recorded
+	recorded, err := events.List(context.Background(), k.ID, time.Time{}, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, recorded, 1)
+	require.Equal(t, timeline.NodeProblem, recorded[0].Type)
+	require.Equal(t, "node-1", recorded[0].Object)
+
+	_, err = h.NodeHealth(context.Background(), &model.Kube{State: model.StateProvisioning})
+	require.Equal(t, ErrNotOperational, err)
+}
+
+func TestHandler_CheckHealthProblems(t *testing.T) {
+	k := &model.Kube{ID: "kube-1", State: model.StateOperational}
+	nodes := inventoryNodes("node-1")
+	nodes[0].Status.Conditions = []corev1.NodeCondition{
+		{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+		{Type: ConditionReadonlyFilesystem, Status: corev1.ConditionTrue},
+	}
+	svc := new(kubeServiceMock)
+	svc.On("ListNodes", mock.Anything, k, "").Return(nodes, nil)
+	h := NewHandler(svc, nil, nil, nil, nil, nil, memory.NewInMemoryRepository(), nil, "")
+
+	summary, err := h.CheckHealth(context.Background(), k)
+	require.Equal(t, "1/1 nodes are ready", summary)
+	require.Equal(t, ErrUnhealthy, errors.Cause(err))
+	require.Contains(t, err.Error(), "node-1(ReadonlyFilesystem)")
+}
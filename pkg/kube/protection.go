@@ -0,0 +1,118 @@
+package kube
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// MachineProtection is a request body that sets deletion protection of a machine.
+type MachineProtection struct {
+	Protected bool `json:"protected"`
+}
+
+// setProtection updates deletion protection of the cluster.
+func (h *Handler) setProtection(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	protection := model.DeletionProtection{}
+	if err := json.NewDecoder(r.Body).Decode(&protection); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	k.Protection = protection
+	if err = h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(k.Protection); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// setMachineProtection updates deletion protection of the cluster machine.
+func (h *Handler) setMachineProtection(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID, nodeName := vars["kubeID"], vars["nodename"]
+
+	protection := MachineProtection{}
+	if err := json.NewDecoder(r.Body).Decode(&protection); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	m := k.Masters[nodeName]
+	if m == nil {
+		m = k.Nodes[nodeName]
+	}
+	if m == nil {
+		message.SendNotFound(w, nodeName, sgerrors.ErrNotFound)
+		return
+	}
+
+	m.Protected = protection.Protected
+	if err = h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(m); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// checkDelete rejects deletion of the cluster or its machine, m is nil when
+// the whole cluster is deleted. Clusters that require confirmation are only
+// deleted when the confirm parameter of the request is the cluster name.
+func checkDelete(w http.ResponseWriter, r *http.Request, k *model.Kube, m *model.Machine) bool {
+	if k.Protection.ConfirmDelete && r.URL.Query().Get("confirm") != k.Name {
+		message.SendValidationFailed(w, errors.Errorf("confirm parameter must be the cluster name %s", k.Name))
+		return false
+	}
+
+	switch {
+	case m != nil && m.Protected:
+		message.SendProtected(w, m.Name, errors.Wrapf(sgerrors.ErrProtected, "machine %s", m.Name))
+		return false
+	case m != nil:
+		return true
+	case k.Protection.Protected:
+		message.SendProtected(w, k.Name, errors.Wrapf(sgerrors.ErrProtected, "cluster %s", k.Name))
+		return false
+	}
+
+	if name := k.ProtectedMachine(); name != "" {
+		message.SendProtected(w, k.Name, errors.Wrapf(sgerrors.ErrProtected, "machine %s", name))
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,86 @@
+package kube
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+func protectionRequest(h *Handler, method, url, body string) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	h.Register(router)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(method, url, strings.NewReader(body)))
+	return rr
+}
+
+func TestHandler_deleteProtected(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		url            string
+		protection     model.DeletionProtection
+		nodeProtected  bool
+		expectedStatus int
+	}{
+		{"protected cluster", "/kubes/kube-1", model.DeletionProtection{Protected: true}, false, http.StatusConflict},
+		{"protected node of cluster", "/kubes/kube-1", model.DeletionProtection{}, true, http.StatusConflict},
+		{"protected node", "/kubes/kube-1/nodes/node-1", model.DeletionProtection{}, true, http.StatusConflict},
+		{"no confirmation", "/kubes/kube-1", model.DeletionProtection{ConfirmDelete: true}, false, http.StatusBadRequest},
+		{"wrong confirmation", "/kubes/kube-1/nodes/node-1?confirm=prod", model.DeletionProtection{ConfirmDelete: true}, false, http.StatusBadRequest},
+	} {
+		k := specKube()
+		k.Protection = tc.protection
+		k.Nodes["node-1"].Protected = tc.nodeProtected
+		svc := new(kubeServiceMock)
+		svc.On("Get", mock.Anything, "kube-1").Return(k, nil)
+		h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+		rr := protectionRequest(h, http.MethodDelete, tc.url, "")
+		require.Equal(t, tc.expectedStatus, rr.Code, tc.name)
+		svc.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	}
+}
+
+func TestHandler_setProtection(t *testing.T) {
+	k := specKube()
+	svc := new(kubeServiceMock)
+	svc.On("Get", mock.Anything, "kube-1").Return(k, nil)
+	svc.On("Create", mock.Anything, mock.Anything).Return(nil)
+	h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+	rr := protectionRequest(h, http.MethodPut, "/kubes/kube-1/protection",
+		`{"protected":true,"confirmDelete":true}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.True(t, k.Protection.Protected)
+	require.True(t, k.Protection.ConfirmDelete)
+
+	rr = protectionRequest(h, http.MethodPut, "/kubes/kube-1/machines/node-1/protection", `{"protected":true}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.True(t, k.Nodes["node-1"].Protected)
+	require.Equal(t, "node-1", k.ProtectedMachine())
+
+	rr = protectionRequest(h, http.MethodPut, "/kubes/kube-1/machines/node-2/protection", `{"protected":true}`)
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestCheckDeleteConfirmed(t *testing.T) {
+	k := specKube()
+	k.Protection.ConfirmDelete = true
+
+	rr := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodDelete, "/kubes/kube-1?confirm=test", nil)
+	require.True(t, checkDelete(rr, r, k, nil))
+
+	k.Protection.Protected = true
+	require.False(t, checkDelete(rr, r, k, nil))
+	require.Contains(t, rr.Body.String(), sgerrors.ErrProtected.Error())
+}
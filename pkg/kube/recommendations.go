@@ -0,0 +1,250 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/pricing"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+const (
+	utilizationWindow     = 7 * 24 * time.Hour
+	minUtilizationSamples = 24
+	// node groups which 95th percentile of cpu and memory usage
+	// stays under the threshold are underutilized
+	underutilizedPercent = 40
+	targetPercent        = 70
+
+	ActionRemoveNodes = "remove-nodes"
+	ActionDownsize    = "downsize"
+)
+
+type nodeGroup struct {
+	role model.Role
+	size string
+}
+
+// getRecommendations flags worker node groups that have been underutilized
+// for the collected period.
+func (h *Handler) getRecommendations(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	samples, err := h.utilizationSamples(r.Context(), k.ID)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	prices := make(map[nodeGroup]float64)
+	if est, err := h.estimateCost(r.Context(), k); err == nil {
+		for _, g := range est.Groups {
+			prices[nodeGroup{g.Role, g.Size}] = g.HourlyPrice
+		}
+	} else {
+		logrus.Debugf("kubes: %s cluster: estimate cost: %v", k.ID, err)
+	}
+
+	if err = json.NewEncoder(w).Encode(recommend(samples, prices)); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func recommend(samples []UtilizationSample, prices map[nodeGroup]float64) []Recommendation {
+	groups := make(map[nodeGroup][]UtilizationSample)
+	for _, s := range samples {
+		// masters are kept for etcd quorum
+		if s.Role != model.RoleNode {
+			continue
+		}
+		key := nodeGroup{s.Role, s.Size}
+		groups[key] = append(groups[key], s)
+	}
+
+	recommendations := make([]Recommendation, 0)
+	for key, group := range groups {
+		if len(group) < minUtilizationSamples {
+			continue
+		}
+
+		cpu := make([]float64, 0, len(group))
+		memory := make([]float64, 0, len(group))
+		for _, s := range group {
+			cpu = append(cpu, s.CPUPercent)
+			memory = append(memory, s.MemoryPercent)
+		}
+
+		rec := Recommendation{
+			Role:          key.role,
+			Size:          key.size,
+			Nodes:         group[len(group)-1].Nodes,
+			Samples:       len(group),
+			CPUPercent:    percentile(cpu, 95),
+			MemoryPercent: percentile(memory, 95),
+		}
+		peak := math.Max(rec.CPUPercent, rec.MemoryPercent)
+		if peak >= underutilizedPercent {
+			continue
+		}
+
+		rec.RecommendedNodes = int(math.Max(1, math.Ceil(float64(rec.Nodes)*peak/targetPercent)))
+		if rec.RecommendedNodes < rec.Nodes {
+			rec.Action = ActionRemoveNodes
+			rec.MonthlySavings = math.Round(float64(rec.Nodes-rec.RecommendedNodes)*
+				prices[key]*pricing.HoursPerMonth*100) / 100
+			rec.Reason = fmt.Sprintf("%d of %d nodes would run the load under %d%% of their capacity",
+				rec.RecommendedNodes, rec.Nodes, targetPercent)
+		} else {
+			rec.RecommendedNodes = rec.Nodes
+			rec.Action = ActionDownsize
+			rec.Reason = fmt.Sprintf("usage stays under %d%% of the %s capacity, a smaller size fits the load",
+				underutilizedPercent, key.size)
+		}
+		recommendations = append(recommendations, rec)
+	}
+
+	sort.Slice(recommendations, func(i, j int) bool {
+		return recommendations[i].Size < recommendations[j].Size
+	})
+
+	return recommendations
+}
+
+// collectUtilization stores a usage sample of every node group for the
+// utilization window.
+func (h *Handler) collectUtilization(ctx context.Context, k *model.Kube, now time.Time) error {
+	nodes, err := h.nodeMetrics(ctx, k)
+	if err != nil {
+		return err
+	}
+
+	usage := make(map[nodeGroup]*ClusterCapacity)
+	for _, n := range nodes {
+		m, isMaster := machineFor(k, n.Name)
+		if m == nil || n.Timestamp.IsZero() {
+			continue
+		}
+
+		key := nodeGroup{model.ToRole(isMaster), m.Size}
+		if usage[key] == nil {
+			usage[key] = &ClusterCapacity{}
+		}
+		usage[key].add(ClusterCapacity{
+			Nodes:          1,
+			CPUUsage:       n.CPUUsage,
+			CPUCapacity:    n.CPUCapacity,
+			MemoryUsage:    n.MemoryUsage,
+			MemoryCapacity: n.MemoryCapacity,
+		})
+	}
+	if len(usage) == 0 {
+		return nil
+	}
+
+	samples, err := h.utilizationSamples(ctx, k.ID)
+	if err != nil {
+		return err
+	}
+
+	kept := make([]UtilizationSample, 0, len(samples)+len(usage))
+	for _, s := range samples {
+		if now.Sub(time.Unix(s.Time, 0)) < utilizationWindow {
+			kept = append(kept, s)
+		}
+	}
+	for key, c := range usage {
+		kept = append(kept, UtilizationSample{
+			Time:          now.Unix(),
+			Role:          key.role,
+			Size:          key.size,
+			Nodes:         c.Nodes,
+			CPUPercent:    c.CPUPercent,
+			MemoryPercent: c.MemoryPercent,
+		})
+	}
+
+	data, err := json.Marshal(kept)
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrap(h.repo.Put(ctx, UtilizationStoragePrefix, k.ID, data), "save utilization")
+}
+
+func (h *Handler) utilizationSamples(ctx context.Context, kubeID string) ([]UtilizationSample, error) {
+	data, err := h.repo.Get(ctx, UtilizationStoragePrefix, kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "get utilization")
+	}
+
+	samples := make([]UtilizationSample, 0)
+	if len(data) == 0 {
+		return samples, nil
+	}
+
+	return samples, errors.Wrap(json.Unmarshal(data, &samples), "decode utilization")
+}
+
+// machineFor finds the machine of the kubernetes node, nodes of aws
+// clusters are named after private addresses of machines.
+func machineFor(k *model.Kube, nodeName string) (*model.Machine, bool) {
+	for _, isMaster := range []bool{true, false} {
+		machines := k.Nodes
+		if isMaster {
+			machines = k.Masters
+		}
+
+		for _, m := range machines {
+			if strings.EqualFold(m.Name, nodeName) {
+				return m, isMaster
+			}
+			if host := ip2Host(m.PrivateIp); m.PrivateIp != "" &&
+				(nodeName == host || strings.HasPrefix(nodeName, host+".")) {
+				return m, isMaster
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func percentile(values []float64, p int) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	i := int(math.Ceil(float64(len(sorted)*p)/100)) - 1
+	if i < 0 {
+		i = 0
+	}
+
+	return sorted[i]
+}
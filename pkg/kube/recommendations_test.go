@@ -0,0 +1,124 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/storage/memory"
+)
+
+func samples(role model.Role, size string, nodes, n int, cpu, memory float64) []UtilizationSample {
+	s := make([]UtilizationSample, 0, n)
+	for i := 0; i < n; i++ {
+		s = append(s, UtilizationSample{
+			Time:          int64(i * 3600),
+			Role:          role,
+			Size:          size,
+			Nodes:         nodes,
+			CPUPercent:    cpu,
+			MemoryPercent: memory,
+		})
+	}
+	return s
+}
+
+func TestRecommend(t *testing.T) {
+	var all []UtilizationSample
+	// underutilized group of four nodes
+	all = append(all, samples(model.RoleNode, "m5.large", 4, 30, 20, 25)...)
+	// a single spike doesn't change the 95th percentile
+	all = append(all, samples(model.RoleNode, "m5.large", 4, 1, 90, 90)...)
+	// single node
+	all = append(all, samples(model.RoleNode, "t3.large", 1, 30, 10, 15)...)
+	// busy group
+	all = append(all, samples(model.RoleNode, "c5.large", 3, 30, 60, 30)...)
+	// not enough samples
+	all = append(all, samples(model.RoleNode, "r5.large", 3, 10, 5, 5)...)
+	// masters are never downsized
+	all = append(all, samples(model.RoleMaster, "t3.medium", 3, 30, 5, 5)...)
+
+	recs := recommend(all, map[nodeGroup]float64{
+		{model.RoleNode, "m5.large"}: 0.1,
+	})
+	require.Len(t, recs, 2)
+
+	require.Equal(t, "m5.large", recs[0].Size)
+	require.Equal(t, ActionRemoveNodes, recs[0].Action)
+	require.Equal(t, 31, recs[0].Samples)
+	require.Equal(t, 25.0, recs[0].MemoryPercent)
+	require.Equal(t, 2, recs[0].RecommendedNodes)
+	require.Equal(t, 146.0, recs[0].MonthlySavings)
+
+	require.Equal(t, "t3.large", recs[1].Size)
+	require.Equal(t, ActionDownsize, recs[1].Action)
+	require.Equal(t, 1, recs[1].RecommendedNodes)
+}
+
+func TestHandler_collectUtilization(t *testing.T) {
+	k := &model.Kube{
+		ID: "kube-1",
+		Masters: map[string]*model.Machine{
+			"master-1": {Name: "master-1", Size: "t3.medium"},
+		},
+		Nodes: map[string]*model.Machine{
+			"node-1": {Name: "node-1", Size: "m5.large", PrivateIp: "10.0.0.1"},
+			"node-2": {Name: "node-2", Size: "m5.large", PrivateIp: "10.0.0.2"},
+		},
+	}
+	nodes := `{"items":[
+{"metadata":{"name":"master-1"},"status":{"allocatable":{"cpu":"2","memory":"4Gi"}}},
+{"metadata":{"name":"ip-10-0-0-1.ec2.internal"},"status":{"allocatable":{"cpu":"2","memory":"4Gi"}}},
+{"metadata":{"name":"ip-10-0-0-2.ec2.internal"},"status":{"allocatable":{"cpu":"2","memory":"4Gi"}}}]}`
+	usage := `{"items":[
+{"metadata":{"name":"master-1"},"timestamp":"2019-05-01T10:00:00Z","usage":{"cpu":"1","memory":"1Gi"}},
+{"metadata":{"name":"ip-10-0-0-1.ec2.internal"},"timestamp":"2019-05-01T10:00:00Z","usage":{"cpu":"500m","memory":"1Gi"}},
+{"metadata":{"name":"ip-10-0-0-2.ec2.internal"},"timestamp":"2019-05-01T10:00:00Z","usage":{"cpu":"1500m","memory":"3Gi"}}]}`
+
+	svc := new(kubeServiceMock)
+	svc.On("ListResources", mock.Anything, "kube-1", "nodes", ResourceListOptions{}).
+		Return([]byte(nodes), nil)
+	svc.On("ListResources", mock.Anything, "kube-1", nodeMetricsResource, ResourceListOptions{}).
+		Return([]byte(usage), nil)
+	svc.On("Get", mock.Anything, "kube-1").Return(k, nil)
+
+	repo := memory.NewInMemoryRepository()
+	h := NewHandler(svc, nil, nil, nil, nil, nil, repo, nil, "")
+
+	now := time.Now()
+	old, _ := json.Marshal([]UtilizationSample{
+		{Time: now.Add(-8 * 24 * time.Hour).Unix(), Role: model.RoleNode, Size: "m5.large"},
+		{Time: now.Add(-time.Hour).Unix(), Role: model.RoleNode, Size: "m5.large"},
+	})
+	require.NoError(t, repo.Put(context.Background(), UtilizationStoragePrefix, "kube-1", old))
+
+	require.NoError(t, h.collectUtilization(context.Background(), k, now))
+
+	stored, err := h.utilizationSamples(context.Background(), "kube-1")
+	require.NoError(t, err)
+	require.Len(t, stored, 3)
+
+	for _, s := range stored[1:] {
+		require.Equal(t, now.Unix(), s.Time)
+		switch s.Role {
+		case model.RoleMaster:
+			require.Equal(t, 1, s.Nodes)
+			require.Equal(t, 50.0, s.CPUPercent)
+		case model.RoleNode:
+			require.Equal(t, "m5.large", s.Size)
+			require.Equal(t, 2, s.Nodes)
+			require.Equal(t, 50.0, s.CPUPercent)
+			require.Equal(t, 50.0, s.MemoryPercent)
+		}
+	}
+
+	rr := metricsRequest(h, "/kubes/kube-1/recommendations")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "[]\n", rr.Body.String())
+}
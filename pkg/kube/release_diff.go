@@ -0,0 +1,250 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/asaskevich/govalidator.v8"
+	"k8s.io/helm/pkg/helm"
+	"k8s.io/helm/pkg/proto/hapi/release"
+	"k8s.io/helm/pkg/releaseutil"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// Changes of resources of a release.
+const (
+	ResourceAdded   = "added"
+	ResourceRemoved = "removed"
+	ResourceChanged = "changed"
+)
+
+// ReleaseUpgradeInput is a chart and values a release is upgraded to.
+type ReleaseUpgradeInput struct {
+	ChartName    string `json:"chartName" valid:"required"`
+	ChartVersion string `json:"chartVersion"`
+	RepoName     string `json:"repoName" valid:"required"`
+	Values       string `json:"values"`
+	// ReuseValues merges values with the ones of the live release.
+	ReuseValues bool `json:"reuseValues"`
+}
+
+// ReleaseDiff is what an upgrade changes in the live release.
+type ReleaseDiff struct {
+	Name                string `json:"name"`
+	Namespace           string `json:"namespace"`
+	Chart               string `json:"chart"`
+	CurrentChartVersion string `json:"currentChartVersion"`
+	ChartVersion        string `json:"chartVersion"`
+	// Values is the unified diff of the values, it's empty if they are equal.
+	Values    string         `json:"values,omitempty"`
+	Resources []ResourceDiff `json:"resources"`
+}
+
+// ResourceDiff is a manifest the upgrade adds, removes or changes.
+type ResourceDiff struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Change    string `json:"change"`
+	// Diff is the unified diff of the manifest.
+	Diff string `json:"diff"`
+}
+
+// PreviewUpgrade renders the release with the chart and values with a dry run
+// upgrade and compares it with the live release, nothing is changed.
+func (s Service) PreviewUpgrade(ctx context.Context, kubeID, rlsName string, inp *ReleaseUpgradeInput) (*ReleaseDiff, error) {
+	if inp == nil {
+		return nil, errors.Wrap(sgerrors.ErrNilEntity, "upgrade input")
+	}
+
+	chrt, err := s.chrtGetter.GetChart(ctx, inp.RepoName, inp.ChartName, inp.ChartVersion)
+	if err != nil {
+		return nil, errors.Wrap(err, "get chart")
+	}
+
+	kube, err := s.Get(ctx, kubeID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get kube")
+	}
+	kprx, err := s.helmClient(kube)
+	if err != nil {
+		return nil, errors.Wrap(err, "build helm proxy")
+	}
+
+	current, err := kprx.ReleaseContent(rlsName)
+	if err != nil {
+		return nil, errors.Wrap(err, "get release details")
+	}
+	proposed, err := kprx.UpdateReleaseFromChart(
+		rlsName,
+		chrt,
+		helm.UpgradeDryRun(true),
+		helm.UpdateValueOverrides([]byte(inp.Values)),
+		helm.ReuseValues(inp.ReuseValues),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "render upgrade")
+	}
+
+	return diffReleases(current.GetRelease(), proposed.GetRelease()), nil
+}
+
+func diffReleases(current, proposed *release.Release) *ReleaseDiff {
+	return &ReleaseDiff{
+		Name:                current.GetName(),
+		Namespace:           current.GetNamespace(),
+		Chart:               proposed.GetChart().GetMetadata().GetName(),
+		CurrentChartVersion: current.GetChart().GetMetadata().GetVersion(),
+		ChartVersion:        proposed.GetChart().GetMetadata().GetVersion(),
+		Values:              unifiedDiff("values.yaml", current.GetConfig().GetRaw(), proposed.GetConfig().GetRaw()),
+		Resources:           diffManifests(current.GetManifest(), proposed.GetManifest()),
+	}
+}
+
+// manifestHead identifies a resource of a rendered manifest.
+type manifestHead struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+}
+
+type manifestResource struct {
+	manifestHead
+	content string
+}
+
+func (r manifestResource) key() string {
+	return strings.Join([]string{r.Kind, r.Metadata.Namespace, r.Metadata.Name}, "/")
+}
+
+// diffManifests compares resources of the manifests by kind, namespace and
+// name, unchanged resources are left out.
+func diffManifests(current, proposed string) []ResourceDiff {
+	before := splitManifest(current)
+	after := splitManifest(proposed)
+
+	diffs := make([]ResourceDiff, 0)
+	for key, res := range after {
+		old, ok := before[key]
+		switch {
+		case !ok:
+			diffs = append(diffs, resourceDiff(res, ResourceAdded, "", res.content))
+		case old.content != res.content:
+			diffs = append(diffs, resourceDiff(res, ResourceChanged, old.content, res.content))
+		}
+	}
+	for key, res := range before {
+		if _, ok := after[key]; !ok {
+			diffs = append(diffs, resourceDiff(res, ResourceRemoved, res.content, ""))
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].Kind != diffs[j].Kind {
+			return diffs[i].Kind < diffs[j].Kind
+		}
+		if diffs[i].Namespace != diffs[j].Namespace {
+			return diffs[i].Namespace < diffs[j].Namespace
+		}
+		return diffs[i].Name < diffs[j].Name
+	})
+	return diffs
+}
+
+func resourceDiff(res manifestResource, change, before, after string) ResourceDiff {
+	return ResourceDiff{
+		Kind:      res.Kind,
+		Name:      res.Metadata.Name,
+		Namespace: res.Metadata.Namespace,
+		Change:    change,
+		Diff:      unifiedDiff(fmt.Sprintf("%s/%s", strings.ToLower(res.Kind), res.Metadata.Name), before, after),
+	}
+}
+
+// splitManifest returns resources of the manifest by their keys, documents
+// without a kind, e.g. empty templates, are skipped.
+func splitManifest(manifest string) map[string]manifestResource {
+	resources := make(map[string]manifestResource)
+	for _, doc := range releaseutil.SplitManifests(manifest) {
+		res := manifestResource{content: doc}
+		if err := yaml.Unmarshal([]byte(doc), &res.manifestHead); err != nil {
+			logrus.Debugf("release diff: skip manifest: %v", err)
+			continue
+		}
+		if res.Kind == "" {
+			continue
+		}
+		resources[res.key()] = res
+	}
+	return resources
+}
+
+func unifiedDiff(name, before, after string) string {
+	if before == after {
+		return ""
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(ensureNewline(before)),
+		B:        difflib.SplitLines(ensureNewline(after)),
+		FromFile: "current/" + name,
+		ToFile:   "proposed/" + name,
+		Context:  3,
+	})
+	if err != nil {
+		logrus.Debugf("release diff: %s: %v", name, err)
+	}
+	return diff
+}
+
+func ensureNewline(s string) string {
+	if s == "" || strings.HasSuffix(s, "\n") {
+		return s
+	}
+	return s + "\n"
+}
+
+func (h *Handler) previewRelease(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	kubeID := vars["kubeID"]
+	rlsName := vars["releaseName"]
+
+	inp := &ReleaseUpgradeInput{}
+	if err := json.NewDecoder(r.Body).Decode(inp); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+	if ok, err := govalidator.ValidateStruct(inp); !ok {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	diff, err := h.svc.PreviewUpgrade(r.Context(), kubeID, rlsName, inp)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, inp.ChartName, err)
+			return
+		}
+		logrus.Errorf("helm: preview %s release: %s cluster: %s", rlsName, kubeID, err)
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(diff); err != nil {
+		logrus.Errorf("helm: preview %s release: %s cluster: write response: %s", rlsName, kubeID, err)
+	}
+}
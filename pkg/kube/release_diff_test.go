@@ -0,0 +1,240 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"k8s.io/helm/pkg/proto/hapi/chart"
+	"k8s.io/helm/pkg/proto/hapi/release"
+	"k8s.io/helm/pkg/proto/hapi/services"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/sghelm/proxy"
+	"github.com/supergiant/control/pkg/testutils/storage"
+)
+
+const (
+	currentManifest = `
+---
+# Source: app/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+spec:
+  ports:
+  - port: 80
+---
+# Source: app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+spec:
+  replicas: 1
+---
+# Source: app/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+`
+	proposedManifest = `
+---
+# Source: app/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+spec:
+  ports:
+  - port: 80
+---
+# Source: app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+spec:
+  replicas: 3
+---
+# Source: app/templates/secret.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+---
+# Source: app/templates/empty.yaml
+`
+)
+
+func TestDiffManifests(t *testing.T) {
+	diffs := diffManifests(currentManifest, proposedManifest)
+
+	require.Len(t, diffs, 3)
+	require.Equal(t, "ConfigMap", diffs[0].Kind)
+	require.Equal(t, ResourceRemoved, diffs[0].Change)
+	require.Equal(t, "Deployment", diffs[1].Kind)
+	require.Equal(t, "default", diffs[1].Namespace)
+	require.Equal(t, ResourceChanged, diffs[1].Change)
+	require.Contains(t, diffs[1].Diff, "-  replicas: 1\n+  replicas: 3\n")
+	require.Equal(t, "Secret", diffs[2].Kind)
+	require.Equal(t, ResourceAdded, diffs[2].Change)
+	require.True(t, strings.HasPrefix(diffs[2].Diff, "--- current/secret/app\n+++ proposed/secret/app\n"))
+
+	require.Empty(t, diffManifests(currentManifest, currentManifest))
+}
+
+func TestService_PreviewUpgrade(t *testing.T) {
+	current := &release.Release{
+		Name:      "app",
+		Namespace: "default",
+		Chart:     &chart.Chart{Metadata: &chart.Metadata{Name: "app", Version: "1.0.0"}},
+		Config:    &chart.Config{Raw: "replicas: 1\n"},
+		Manifest:  currentManifest,
+	}
+	proposed := &release.Release{
+		Name:      "app",
+		Namespace: "default",
+		Chart:     &chart.Chart{Metadata: &chart.Metadata{Name: "app", Version: "1.1.0"}},
+		Config:    &chart.Config{Raw: "replicas: 3\n"},
+		Manifest:  proposedManifest,
+	}
+
+	tcs := []struct {
+		name        string
+		inp         *ReleaseUpgradeInput
+		chartErr    error
+		helm        *fakeHelmProxy
+		expectedErr error
+	}{
+		{
+			name:        "nil input",
+			expectedErr: sgerrors.ErrNilEntity,
+		},
+		{
+			name:        "chart not found",
+			inp:         &ReleaseUpgradeInput{},
+			chartErr:    sgerrors.ErrNotFound,
+			expectedErr: sgerrors.ErrNotFound,
+		},
+		{
+			name:        "render error",
+			inp:         &ReleaseUpgradeInput{},
+			helm:        &fakeHelmProxy{err: errFake},
+			expectedErr: errFake,
+		},
+		{
+			name: "diff",
+			inp:  &ReleaseUpgradeInput{},
+			helm: &fakeHelmProxy{
+				getReleaseResp: &services.GetReleaseContentResponse{Release: current},
+				updateRlsResp:  &services.UpdateReleaseResponse{Release: proposed},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		svc := Service{
+			storage:    &storage.Fake{Item: []byte("{}")},
+			chrtGetter: &fakeChartGetter{chrt: &chart.Chart{}, err: tc.chartErr},
+			newHelmProxyFn: func(kube *model.Kube) (proxy.Interface, error) {
+				return tc.helm, nil
+			},
+		}
+
+		diff, err := svc.PreviewUpgrade(context.Background(), "kube", "app", tc.inp)
+		require.Equal(t, tc.expectedErr, errors.Cause(err), tc.name)
+		if err != nil {
+			continue
+		}
+
+		require.Equal(t, "app", diff.Chart, tc.name)
+		require.Equal(t, "1.0.0", diff.CurrentChartVersion, tc.name)
+		require.Equal(t, "1.1.0", diff.ChartVersion, tc.name)
+		require.Contains(t, diff.Values, "-replicas: 1\n+replicas: 3\n", tc.name)
+		require.Len(t, diff.Resources, 3, tc.name)
+	}
+}
+
+func TestHandler_previewRelease(t *testing.T) {
+	tcs := []struct {
+		name            string
+		body            string
+		kubeSvc         *kubeServiceMock
+		expectedStatus  int
+		expectedErrCode sgerrors.ErrorCode
+	}{
+		{
+			name:            "invalid json",
+			body:            "{",
+			kubeSvc:         &kubeServiceMock{},
+			expectedStatus:  http.StatusBadRequest,
+			expectedErrCode: sgerrors.InvalidJSON,
+		},
+		{
+			name:            "no chart",
+			body:            `{"repoName":"stable"}`,
+			kubeSvc:         &kubeServiceMock{},
+			expectedStatus:  http.StatusBadRequest,
+			expectedErrCode: sgerrors.ValidationFailed,
+		},
+		{
+			name:            "chart not found",
+			body:            `{"repoName":"stable","chartName":"app"}`,
+			kubeSvc:         &kubeServiceMock{rlsErr: sgerrors.ErrNotFound},
+			expectedStatus:  http.StatusNotFound,
+			expectedErrCode: sgerrors.NotFound,
+		},
+		{
+			name:            "error",
+			body:            `{"repoName":"stable","chartName":"app"}`,
+			kubeSvc:         &kubeServiceMock{rlsErr: errFake},
+			expectedStatus:  http.StatusInternalServerError,
+			expectedErrCode: sgerrors.UnknownError,
+		},
+		{
+			name: "ok",
+			body: `{"repoName":"stable","chartName":"app"}`,
+			kubeSvc: &kubeServiceMock{rlsDiff: &ReleaseDiff{
+				Name:      "app",
+				Resources: []ResourceDiff{{Kind: "Secret", Name: "app", Change: ResourceAdded}},
+			}},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tcs {
+		h := &Handler{svc: tc.kubeSvc}
+		router := mux.NewRouter()
+		h.Register(router)
+
+		req := httptest.NewRequest(http.MethodPost, "/kubes/fake/releases/app/preview", bytes.NewBufferString(tc.body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, tc.expectedStatus, w.Code, tc.name)
+		if w.Code == http.StatusOK {
+			diff := &ReleaseDiff{}
+			require.NoError(t, json.NewDecoder(w.Body).Decode(diff), tc.name)
+			require.Equal(t, tc.kubeSvc.rlsDiff, diff, tc.name)
+			continue
+		}
+
+		apiErr := &message.Message{}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(apiErr), tc.name)
+		require.Equal(t, tc.expectedErrCode, apiErr.ErrorCode, tc.name)
+	}
+}
@@ -0,0 +1,344 @@
+package kube
+
+import (
+	"context"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/kubeconfig"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/user"
+)
+
+// etcd rejects objects larger than 1.5MB
+const maxResourceSize = 3 << 20
+
+var patchTypes = map[string]types.PatchType{
+	string(types.MergePatchType):          types.MergePatchType,
+	string(types.JSONPatchType):           types.JSONPatchType,
+	string(types.StrategicMergePatchType): types.StrategicMergePatchType,
+}
+
+// ResourceListOptions narrow down and paginate a list of kubernetes objects.
+type ResourceListOptions struct {
+	Namespace     string
+	LabelSelector string
+	FieldSelector string
+	// Limit is a page size, the next page is requested with the continue
+	// token from the list metadata.
+	Limit    int64
+	Continue string
+}
+
+// ListResources returns a raw list of objects of any resource served by the cluster.
+func (s Service) ListResources(ctx context.Context, kubeID, resource string, opts ResourceListOptions) ([]byte, error) {
+	client, name, err := s.resourceClient(ctx, kubeID, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	req := client.Get().Context(ctx).Resource(name).Namespace(opts.Namespace)
+	if opts.LabelSelector != "" {
+		req.Param("labelSelector", opts.LabelSelector)
+	}
+	if opts.FieldSelector != "" {
+		req.Param("fieldSelector", opts.FieldSelector)
+	}
+	if opts.Limit > 0 {
+		req.Param("limit", strconv.FormatInt(opts.Limit, 10))
+	}
+	if opts.Continue != "" {
+		req.Param("continue", opts.Continue)
+	}
+
+	raw, err := req.DoRaw()
+	return raw, errors.Wrapf(err, "list %s", resource)
+}
+
+// CreateResource creates the object from its json representation.
+func (s Service) CreateResource(ctx context.Context, kubeID, resource, ns string, obj []byte, dryRun bool) ([]byte, error) {
+	client, name, err := s.resourceClient(ctx, kubeID, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	req := client.Post().Context(ctx).Resource(name).Namespace(ns).Body(obj)
+	raw, err := withDryRun(req, dryRun).DoRaw()
+	return raw, errors.Wrapf(err, "create %s", resource)
+}
+
+// PatchResource updates the object with a json, merge or strategic merge patch.
+func (s Service) PatchResource(ctx context.Context, kubeID, resource, ns, name string, pt types.PatchType, patch []byte, dryRun bool) ([]byte, error) {
+	client, resourceName, err := s.resourceClient(ctx, kubeID, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	req := client.Patch(pt).Context(ctx).Resource(resourceName).Namespace(ns).Name(name).Body(patch)
+	raw, err := withDryRun(req, dryRun).DoRaw()
+	return raw, errors.Wrapf(err, "patch %s %s", resource, name)
+}
+
+// DeleteResource deletes the object, dependent objects are collected in background.
+func (s Service) DeleteResource(ctx context.Context, kubeID, resource, ns, name string, dryRun bool) ([]byte, error) {
+	client, resourceName, err := s.resourceClient(ctx, kubeID, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	req := client.Delete().Context(ctx).Resource(resourceName).Namespace(ns).Name(name).
+		Param("propagationPolicy", string(metav1.DeletePropagationBackground))
+	raw, err := withDryRun(req, dryRun).DoRaw()
+	return raw, errors.Wrapf(err, "delete %s %s", resource, name)
+}
+
+// resourceClient returns a client for the group of the resource. Resources
+// of the same name from different groups are told apart with the group
+// suffix, e.g. certificates.cert-manager.io, the kind of resource can be
+// used instead of its name.
+func (s Service) resourceClient(ctx context.Context, kubeID, resource string) (rest.Interface, string, error) {
+	kube, err := s.Get(ctx, kubeID)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "get kube")
+	}
+
+	lists, err := s.serverResources(kube)
+	if err != nil {
+		return nil, "", err
+	}
+
+	gv, name, ok := findResource(lists, resource)
+	if !ok {
+		return nil, "", errors.Wrapf(sgerrors.ErrNotFound, "resource %s", resource)
+	}
+
+	client, err := s.clientForGroupFn(kube, gv)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "get kube client")
+	}
+
+	return client, name, nil
+}
+
+func (s Service) serverResources(kube *model.Kube) ([]*metav1.APIResourceList, error) {
+	client, err := s.discoveryClientFn(kube)
+	if err != nil {
+		return nil, errors.Wrap(err, "get discovery client")
+	}
+
+	lists, err := client.ServerResources()
+	// groups of unavailable aggregated apis are skipped
+	if err != nil && !(discovery.IsGroupDiscoveryFailedError(err) && len(lists) > 0) {
+		return nil, errors.Wrap(err, "get resources")
+	}
+
+	return lists, nil
+}
+
+func findResource(lists []*metav1.APIResourceList, resource string) (schema.GroupVersion, string, bool) {
+	for _, list := range lists {
+		if list == nil {
+			continue
+		}
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+
+		for _, r := range list.APIResources {
+			// subresources are like pods/log
+			if strings.Contains(r.Name, "/") {
+				continue
+			}
+			if r.Name == resource || r.Name+"."+gv.Group == resource || strings.EqualFold(r.Kind, resource) {
+				return gv, r.Name, true
+			}
+		}
+	}
+
+	return schema.GroupVersion{}, "", false
+}
+
+func withDryRun(req *rest.Request, dryRun bool) *rest.Request {
+	if dryRun {
+		req.Param("dryRun", metav1.DryRunAll)
+	}
+	return req
+}
+
+func discoveryClient(k *model.Kube) (ServerResourceGetter, error) {
+	return kubeconfig.DiscoveryClient(k)
+}
+
+func (h *Handler) createResource(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+	resource := vars["resource"]
+
+	if !h.canModifyResources(w, r) {
+		return
+	}
+
+	obj, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxResourceSize))
+	if err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	raw, err := h.svc.CreateResource(r.Context(), kubeID, resource,
+		r.URL.Query().Get("namespace"), obj, isDryRun(r))
+	if err != nil {
+		sendResourceError(w, resource, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if _, err = w.Write(raw); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// patchResource applies the patch of the request content type,
+// it's a merge patch when the type isn't set.
+func (h *Handler) patchResource(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+	resource := vars["resource"]
+	name := vars["name"]
+
+	if !h.canModifyResources(w, r) {
+		return
+	}
+
+	pt := types.MergePatchType
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if pt = patchTypes[mediaType]; err != nil || pt == "" {
+			message.SendValidationFailed(w, errors.Errorf("unsupported patch type %q", ct))
+			return
+		}
+	}
+
+	patch, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxResourceSize))
+	if err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	raw, err := h.svc.PatchResource(r.Context(), kubeID, resource,
+		r.URL.Query().Get("namespace"), name, pt, patch, isDryRun(r))
+	if err != nil {
+		sendResourceError(w, name, err)
+		return
+	}
+
+	if _, err = w.Write(raw); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) deleteResource(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+	resource := vars["resource"]
+	name := vars["name"]
+
+	if !h.canModifyResources(w, r) {
+		return
+	}
+
+	raw, err := h.svc.DeleteResource(r.Context(), kubeID, resource,
+		r.URL.Query().Get("namespace"), name, isDryRun(r))
+	if err != nil {
+		sendResourceError(w, name, err)
+		return
+	}
+
+	if _, err = w.Write(raw); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// canModifyResources rejects requests of users with the view role.
+func (h *Handler) canModifyResources(w http.ResponseWriter, r *http.Request) bool {
+	id, ok := api.IdentityFrom(r.Context())
+	if !ok {
+		http.Error(w, "unknown user", http.StatusForbidden)
+		return false
+	}
+	if identityRole(id) == user.RoleView {
+		http.Error(w, "modifying resources is not allowed for the view role", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
+func listOptions(r *http.Request) (ResourceListOptions, bool, error) {
+	q := r.URL.Query()
+	opts := ResourceListOptions{
+		Namespace:     q.Get("namespace"),
+		LabelSelector: q.Get("labelSelector"),
+		FieldSelector: q.Get("fieldSelector"),
+		Continue:      q.Get("continue"),
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || limit < 0 {
+			return opts, false, errors.Errorf("invalid limit %q", v)
+		}
+		opts.Limit = limit
+	}
+
+	paginated := opts.LabelSelector != "" || opts.FieldSelector != "" ||
+		opts.Limit > 0 || opts.Continue != ""
+	return opts, paginated, nil
+}
+
+func isDryRun(r *http.Request) bool {
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryRun"))
+	return dryRun
+}
+
+// sendResourceError passes errors of the cluster api with their status
+// since they describe what's wrong with the object.
+func sendResourceError(w http.ResponseWriter, entity string, err error) {
+	if sgerrors.IsNotFound(err) {
+		message.SendNotFound(w, entity, err)
+		return
+	}
+
+	status, ok := errors.Cause(err).(apierrors.APIStatus)
+	if !ok {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	switch s := status.Status(); {
+	case apierrors.IsNotFound(err):
+		message.SendNotFound(w, entity, err)
+	case apierrors.IsAlreadyExists(err), apierrors.IsConflict(err):
+		message.SendAlreadyExists(w, entity, err)
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err):
+		message.SendValidationFailed(w, err)
+	case s.Code >= http.StatusBadRequest:
+		message.SendMessage(w, message.New(s.Message, err.Error(), sgerrors.UnknownError, ""), int(s.Code))
+	default:
+		message.SendUnknownError(w, err)
+	}
+}
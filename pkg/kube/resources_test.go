@@ -0,0 +1,305 @@
+package kube
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/user"
+)
+
+var testResourceLists = []*metav1.APIResourceList{
+	{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "pods", Kind: "Pod", Namespaced: true},
+			{Name: "pods/log", Kind: "Pod", Namespaced: true},
+			{Name: "namespaces", Kind: "Namespace"},
+		},
+	},
+	{
+		GroupVersion: "cert-manager.io/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "certificates", Kind: "Certificate", Namespaced: true},
+		},
+	},
+	{
+		GroupVersion: "certificates.k8s.io/v1beta1",
+		APIResources: []metav1.APIResource{
+			{Name: "certificatesigningrequests", Kind: "CertificateSigningRequest"},
+		},
+	},
+}
+
+func TestFindResource(t *testing.T) {
+	for _, tc := range []struct {
+		resource string
+		gv       schema.GroupVersion
+		name     string
+		found    bool
+	}{
+		{"pods", schema.GroupVersion{Version: "v1"}, "pods", true},
+		{"certificates", schema.GroupVersion{Group: "cert-manager.io", Version: "v1"}, "certificates", true},
+		{"certificates.cert-manager.io", schema.GroupVersion{Group: "cert-manager.io", Version: "v1"}, "certificates", true},
+		{"Pod", schema.GroupVersion{Version: "v1"}, "pods", true},
+		{"certificatesigningrequest", schema.GroupVersion{Group: "certificates.k8s.io", Version: "v1beta1"}, "certificatesigningrequests", true},
+		{"pods/log", schema.GroupVersion{}, "", false},
+		{"deployments", schema.GroupVersion{}, "", false},
+	} {
+		gv, name, found := findResource(testResourceLists, tc.resource)
+		require.Equal(t, tc.found, found, tc.resource)
+		require.Equal(t, tc.gv, gv, tc.resource)
+		require.Equal(t, tc.name, name, tc.resource)
+	}
+}
+
+type recordedRequest struct {
+	method      string
+	path        string
+	query       map[string][]string
+	contentType string
+	body        string
+}
+
+func resourceService(t *testing.T, status int, resp string) (Service, *recordedRequest, func()) {
+	rec := &recordedRequest{}
+	m := &sync.Mutex{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		m.Lock()
+		*rec = recordedRequest{
+			method:      r.Method,
+			path:        r.URL.Path,
+			query:       r.URL.Query(),
+			contentType: r.Header.Get("Content-Type"),
+			body:        string(body),
+		}
+		m.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(resp))
+	}))
+
+	storage := new(testutils.MockStorage)
+	storage.On("Get", mock.Anything, mock.Anything, mock.Anything).
+		Return([]byte(`{"id":"kube-1"}`), nil)
+
+	svc := Service{
+		storage: storage,
+		discoveryClientFn: func(k *model.Kube) (ServerResourceGetter, error) {
+			return &mockServerResourceGetter{resources: testResourceLists}, nil
+		},
+		clientForGroupFn: func(k *model.Kube, gv schema.GroupVersion) (rest.Interface, error) {
+			apiPath := "/apis"
+			if gv.Group == "" {
+				apiPath = "/api"
+			}
+			return rest.RESTClientFor(&rest.Config{
+				Host:    srv.URL,
+				APIPath: apiPath,
+				ContentConfig: rest.ContentConfig{
+					GroupVersion:         &gv,
+					NegotiatedSerializer: scheme.Codecs,
+				},
+			})
+		},
+	}
+
+	return svc, rec, srv.Close
+}
+
+func TestService_ListResources(t *testing.T) {
+	svc, rec, done := resourceService(t, http.StatusOK, `{"items":[]}`)
+	defer done()
+
+	raw, err := svc.ListResources(context.Background(), "kube-1", "pods", ResourceListOptions{
+		Namespace:     "default",
+		LabelSelector: "app=nginx",
+		Limit:         10,
+		Continue:      "token",
+	})
+	require.NoError(t, err)
+	require.Equal(t, `{"items":[]}`, string(raw))
+
+	require.Equal(t, http.MethodGet, rec.method)
+	require.Equal(t, "/api/v1/namespaces/default/pods", rec.path)
+	require.Equal(t, []string{"app=nginx"}, rec.query["labelSelector"])
+	require.Equal(t, []string{"10"}, rec.query["limit"])
+	require.Equal(t, []string{"token"}, rec.query["continue"])
+	require.Nil(t, rec.query["fieldSelector"])
+
+	_, err = svc.ListResources(context.Background(), "kube-1", "deployments", ResourceListOptions{})
+	require.True(t, sgerrors.IsNotFound(err))
+}
+
+func TestService_CreateResource(t *testing.T) {
+	svc, rec, done := resourceService(t, http.StatusCreated, `{}`)
+	defer done()
+
+	obj := `{"apiVersion":"cert-manager.io/v1","kind":"Certificate"}`
+	_, err := svc.CreateResource(context.Background(), "kube-1",
+		"certificates", "default", []byte(obj), true)
+	require.NoError(t, err)
+
+	require.Equal(t, http.MethodPost, rec.method)
+	require.Equal(t, "/apis/cert-manager.io/v1/namespaces/default/certificates", rec.path)
+	require.Equal(t, []string{"All"}, rec.query["dryRun"])
+	require.Equal(t, obj, rec.body)
+}
+
+func TestService_PatchResource(t *testing.T) {
+	svc, rec, done := resourceService(t, http.StatusOK, `{}`)
+	defer done()
+
+	_, err := svc.PatchResource(context.Background(), "kube-1", "namespaces", "",
+		"dev", types.MergePatchType, []byte(`{"metadata":{"labels":{"a":"b"}}}`), false)
+	require.NoError(t, err)
+
+	require.Equal(t, http.MethodPatch, rec.method)
+	require.Equal(t, "/api/v1/namespaces/dev", rec.path)
+	require.Equal(t, string(types.MergePatchType), rec.contentType)
+	require.Nil(t, rec.query["dryRun"])
+}
+
+func TestService_DeleteResource(t *testing.T) {
+	svc, rec, done := resourceService(t, http.StatusNotFound,
+		`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`)
+	defer done()
+
+	_, err := svc.DeleteResource(context.Background(), "kube-1", "pods", "default", "nginx", false)
+	require.True(t, apierrors.IsNotFound(errors.Cause(err)))
+
+	require.Equal(t, http.MethodDelete, rec.method)
+	require.Equal(t, "/api/v1/namespaces/default/pods/nginx", rec.path)
+	require.Equal(t, []string{"Background"}, rec.query["propagationPolicy"])
+}
+
+func resourceRequest(h *Handler, role, method, url, contentType, body string) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	h.Register(router)
+
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req = req.WithContext(api.WithIdentity(req.Context(), api.Identity{Login: "alice", Role: role}))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestHandler_createResource(t *testing.T) {
+	conflict := apierrors.NewAlreadyExists(schema.GroupResource{Resource: "namespaces"}, "dev")
+
+	for _, tc := range []struct {
+		name           string
+		role           string
+		err            error
+		expectedStatus int
+	}{
+		{"view role", user.RoleView, nil, http.StatusForbidden},
+		{"created", user.RoleEdit, nil, http.StatusCreated},
+		{"already exists", user.RoleAdmin, conflict, http.StatusConflict},
+		{"unknown resource", user.RoleAdmin, sgerrors.ErrNotFound, http.StatusNotFound},
+	} {
+		svc := new(kubeServiceMock)
+		svc.On("CreateResource", mock.Anything, "kube-1", "namespaces", "",
+			[]byte(`{"kind":"Namespace"}`), true).Return([]byte(`{}`), tc.err)
+		h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+		rr := resourceRequest(h, tc.role, http.MethodPost,
+			"/kubes/kube-1/resources/namespaces?dryRun=true", "", `{"kind":"Namespace"}`)
+		require.Equal(t, tc.expectedStatus, rr.Code, tc.name)
+	}
+}
+
+func TestHandler_patchResource(t *testing.T) {
+	invalid := apierrors.NewInvalid(schema.GroupKind{Kind: "Pod"}, "nginx", nil)
+
+	for _, tc := range []struct {
+		name           string
+		contentType    string
+		patchType      types.PatchType
+		err            error
+		expectedStatus int
+	}{
+		{"merge patch by default", "", types.MergePatchType, nil, http.StatusOK},
+		{"json patch", "application/json-patch+json", types.JSONPatchType, nil, http.StatusOK},
+		{"strategic merge patch", "application/strategic-merge-patch+json; charset=utf-8",
+			types.StrategicMergePatchType, nil, http.StatusOK},
+		{"unsupported patch", "application/yaml", "", nil, http.StatusBadRequest},
+		{"invalid object", "", types.MergePatchType, invalid, http.StatusBadRequest},
+	} {
+		svc := new(kubeServiceMock)
+		svc.On("PatchResource", mock.Anything, "kube-1", "pods", "default", "nginx",
+			tc.patchType, []byte(`{}`), false).Return([]byte(`{}`), tc.err)
+		h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+		rr := resourceRequest(h, user.RoleEdit, http.MethodPatch,
+			"/kubes/kube-1/resources/pods/nginx?namespace=default", tc.contentType, `{}`)
+		require.Equal(t, tc.expectedStatus, rr.Code, tc.name)
+	}
+}
+
+func TestHandler_deleteResource(t *testing.T) {
+	svc := new(kubeServiceMock)
+	svc.On("DeleteResource", mock.Anything, "kube-1", "pods", "default", "nginx", false).
+		Return([]byte(`{}`), nil)
+	h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+	rr := resourceRequest(h, user.RoleView, http.MethodDelete,
+		"/kubes/kube-1/resources/pods/nginx?namespace=default", "", "")
+	require.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = resourceRequest(h, user.RoleAdmin, http.MethodDelete,
+		"/kubes/kube-1/resources/pods/nginx?namespace=default", "", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_getResource_paginated(t *testing.T) {
+	svc := new(kubeServiceMock)
+	svc.On("ListResources", mock.Anything, "kube-1", "pods", ResourceListOptions{
+		Namespace:     "default",
+		LabelSelector: "app=nginx",
+		Limit:         50,
+	}).Return([]byte(`{"items":[]}`), nil)
+	svc.On(serviceGetKubeResources, mock.Anything, "kube-1", "pods", "default", "nginx").
+		Return([]byte(`{}`), nil)
+	h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+	rr := resourceRequest(h, user.RoleView, http.MethodGet,
+		"/kubes/kube-1/resources/pods?namespace=default&labelSelector=app%3Dnginx&limit=50", "", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, `{"items":[]}`, rr.Body.String())
+
+	rr = resourceRequest(h, user.RoleView, http.MethodGet,
+		"/kubes/kube-1/resources/pods/nginx?namespace=default", "", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, `{}`, rr.Body.String())
+
+	rr = resourceRequest(h, user.RoleView, http.MethodGet,
+		"/kubes/kube-1/resources/pods?limit=-1", "", "")
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,92 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/asaskevich/govalidator.v8"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/workflows"
+)
+
+func (h *Handler) upgradeRuntime(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+
+	inp := &RuntimeUpgradeInput{}
+	if err := json.NewDecoder(r.Body).Decode(inp); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	ok, err := govalidator.ValidateStruct(inp)
+	if !ok {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if k.State != model.StateOperational {
+		w.WriteHeader(http.StatusNoContent)
+		logrus.Infof("Cluster %s is not operational", k.ID)
+		return
+	}
+
+	if !checkMaintenance(w, r, k) {
+		return
+	}
+
+	config, err := h.newKubeConfig(r.Context(), k)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, k.ProfileID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	config.Kube.DockerVersion = inp.Version
+	tasks := h.makeMachineTasks(config, k, workflows.RuntimeUpgrade)
+
+	go func() {
+		if err := h.kubeProvisioner.UpgradeRuntime(context.Background(), k, tasks, config); err != nil {
+			logrus.Errorf("upgrade container runtime of cluster %s caused %v", kubeID, err)
+			return
+		}
+
+		if err := h.setDockerVersion(context.Background(), kubeID, inp.Version); err != nil {
+			logrus.Errorf("update docker version of cluster %s caused %v", kubeID, err)
+		}
+	}()
+
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(mapNode2Task(tasks)); err != nil {
+		logrus.Errorf("Error encoding task map %v", err)
+	}
+}
+
+func (h *Handler) setDockerVersion(ctx context.Context, kubeID, version string) error {
+	k, err := h.svc.Get(ctx, kubeID)
+	if err != nil {
+		return err
+	}
+
+	k.DockerVersion = version
+	return h.svc.Create(ctx, k)
+}
@@ -0,0 +1,124 @@
+package kube
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/cron"
+	"github.com/supergiant/control/pkg/model"
+)
+
+const defaultScheduleCheckInterval = time.Minute
+
+// Scheduler starts maintenance jobs of clusters according to their schedules.
+type Scheduler struct {
+	svc  Interface
+	name string
+	// jobs returns scheduled jobs of a cluster
+	jobs func(*model.Kube) []scheduledJob
+	// startJob starts the job of the cluster when it's due
+	startJob func(ctx context.Context, k *model.Kube, job string, now time.Time)
+	interval time.Duration
+	started  time.Time
+}
+
+// scheduledJob is a job of a cluster that runs on a cron expression.
+type scheduledJob struct {
+	name     string
+	schedule string
+	// lastRun is the unix time of the last run of the job, 0 if it hasn't run
+	lastRun int64
+}
+
+// newScheduler creates a scheduler of a single job of each cluster, the time
+// of the last run is saved before the job is run.
+func newScheduler(svc Interface, name string, schedule func(*model.Kube) (string, *int64),
+	run func(context.Context, *model.Kube) (map[string]string, error)) *Scheduler {
+	s := &Scheduler{
+		svc:      svc,
+		name:     name,
+		interval: defaultScheduleCheckInterval,
+		started:  time.Now(),
+	}
+	s.jobs = func(k *model.Kube) []scheduledJob {
+		spec, lastRun := schedule(k)
+		if spec == "" {
+			return nil
+		}
+		return []scheduledJob{{name: name, schedule: spec, lastRun: *lastRun}}
+	}
+	s.startJob = func(ctx context.Context, k *model.Kube, _ string, now time.Time) {
+		// save the run first, a failed job is retried on the next schedule
+		_, lastRun := schedule(k)
+		*lastRun = now.Unix()
+		if err := svc.Create(ctx, k); err != nil {
+			logrus.Errorf("%s scheduler: update kube %s: %v", name, k.ID, err)
+			return
+		}
+
+		logrus.Infof("%s scheduler: start kube %s", name, k.ID)
+		if _, err := run(ctx, k); err != nil {
+			logrus.Errorf("%s scheduler: kube %s: %v", name, k.ID, err)
+		}
+	}
+	return s
+}
+
+// Run checks cluster schedules until the context is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.check(ctx, now)
+		}
+	}
+}
+
+func (s *Scheduler) check(ctx context.Context, now time.Time) {
+	kubes, err := s.svc.ListAll(ctx)
+	if err != nil {
+		logrus.Errorf("%s scheduler: list kubes: %v", s.name, err)
+		return
+	}
+
+	for i := range kubes {
+		k := &kubes[i]
+		if k.State != model.StateOperational {
+			continue
+		}
+
+		for _, job := range s.jobs(k) {
+			if s.due(k, job, now) {
+				s.startJob(ctx, k, job.name, now)
+			}
+		}
+	}
+}
+
+// due reports whether the job has to be started, runs are counted from the
+// last one or from the start of the scheduler.
+func (s *Scheduler) due(k *model.Kube, job scheduledJob, now time.Time) bool {
+	schedule, err := cron.Parse(job.schedule)
+	if err != nil {
+		logrus.Errorf("%s scheduler: kube %s: %s: %v", s.name, k.ID, job.name, err)
+		return false
+	}
+
+	last := s.started
+	if job.lastRun > 0 {
+		last = time.Unix(job.lastRun, 0)
+	}
+
+	if next := schedule.Next(last); next.IsZero() || next.After(now) {
+		return false
+	}
+	// the run is due, it's deferred until the maintenance window opens
+	return k.Maintenance.Contains(now)
+}
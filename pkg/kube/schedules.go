@@ -0,0 +1,442 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/supergiant/control/pkg/cron"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows"
+)
+
+// unschedulableWorkflows create or delete machines and clusters, they are
+// started by their endpoints only.
+var unschedulableWorkflows = map[string]bool{
+	workflows.Infra:                true,
+	workflows.AwsInfra:             true,
+	workflows.DigitalOceanInfra:    true,
+	workflows.GCEInfra:             true,
+	workflows.AzureInfra:           true,
+	workflows.FakeInfra:            true,
+	workflows.ProvisionMaster:      true,
+	workflows.ProvisionNode:        true,
+	workflows.ProvisionWindowsNode: true,
+	workflows.PostProvision:        true,
+	workflows.DeleteNode:           true,
+	workflows.DeleteMaster:         true,
+	workflows.DeleteCluster:        true,
+	workflows.ImportCluster:        true,
+	workflows.BakeImage:            true,
+}
+
+func validateSchedule(s *model.ScheduledTask) error {
+	if errs := validation.IsDNS1123Label(s.Name); len(errs) > 0 {
+		return errors.Wrapf(sgerrors.ErrInvalidJson, "name %q: %s", s.Name, errs[0])
+	}
+	if workflows.GetWorkflow(s.Workflow) == nil {
+		return errors.Wrapf(sgerrors.ErrInvalidJson, "unknown workflow %q", s.Workflow)
+	}
+	if unschedulableWorkflows[s.Workflow] {
+		return errors.Wrapf(sgerrors.ErrInvalidJson, "workflow %s can't be scheduled", s.Workflow)
+	}
+	if _, err := cron.Parse(s.Schedule); err != nil {
+		return errors.Wrap(sgerrors.ErrInvalidJson, err.Error())
+	}
+
+	switch s.Target {
+	case "":
+		s.Target = model.ScheduleTargetMaster
+	case model.ScheduleTargetMaster, model.ScheduleTargetMachines:
+	default:
+		return errors.Wrapf(sgerrors.ErrInvalidJson, "unknown target %q, targets: %s, %s",
+			s.Target, model.ScheduleTargetMaster, model.ScheduleTargetMachines)
+	}
+	return nil
+}
+
+// AddSchedule saves the scheduled task, its first run is counted from now on.
+func (h *Handler) AddSchedule(ctx context.Context, k *model.Kube, s model.ScheduledTask) (*model.ScheduledTask, error) {
+	if err := validateSchedule(&s); err != nil {
+		return nil, err
+	}
+	if k.Schedule(s.Name) != nil {
+		return nil, errors.Wrapf(sgerrors.ErrAlreadyExists, "schedule %s", s.Name)
+	}
+
+	s.LastRun = nil
+	k.Schedules = append(k.Schedules, s)
+	if err := h.svc.Create(ctx, k); err != nil {
+		return nil, errors.Wrapf(err, "update kube %s", k.ID)
+	}
+	return &s, nil
+}
+
+// UpdateSchedule replaces the scheduled task of the name, the status of
+// its last run is kept.
+func (h *Handler) UpdateSchedule(ctx context.Context, k *model.Kube, name string, s model.ScheduledTask) (*model.ScheduledTask, error) {
+	current := k.Schedule(name)
+	if current == nil {
+		return nil, errors.Wrapf(sgerrors.ErrNotFound, "schedule %s", name)
+	}
+
+	s.Name = name
+	if err := validateSchedule(&s); err != nil {
+		return nil, err
+	}
+
+	s.LastRun = current.LastRun
+	*current = s
+	if err := h.svc.Create(ctx, k); err != nil {
+		return nil, errors.Wrapf(err, "update kube %s", k.ID)
+	}
+	return &s, nil
+}
+
+// RemoveSchedule deletes the scheduled task, the run in progress isn't stopped.
+func (h *Handler) RemoveSchedule(ctx context.Context, k *model.Kube, name string) error {
+	if k.Schedule(name) == nil {
+		return errors.Wrapf(sgerrors.ErrNotFound, "schedule %s", name)
+	}
+
+	schedules := make([]model.ScheduledTask, 0, len(k.Schedules))
+	for _, s := range k.Schedules {
+		if s.Name != name {
+			schedules = append(schedules, s)
+		}
+	}
+	k.Schedules = schedules
+
+	return errors.Wrapf(h.svc.Create(ctx, k), "update kube %s", k.ID)
+}
+
+// newScheduledTasks creates tasks of the workflow of the schedule, machine
+// tasks are in the order they are run: masters first.
+func (h *Handler) newScheduledTasks(ctx context.Context, k *model.Kube, s *model.ScheduledTask) ([]*workflows.Task, error) {
+	config, err := h.newKubeConfig(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Target == model.ScheduleTargetMachines {
+		taskMap := h.makeMachineTasks(config, k, s.Workflow)
+		tasks := append(taskMap[workflows.MasterTask], taskMap[workflows.NodeTask]...)
+		if len(tasks) == 0 {
+			return nil, errors.Wrapf(sgerrors.ErrNotFound, "linux machines of kube %s", k.ID)
+		}
+		return tasks, nil
+	}
+
+	master := config.GetMaster()
+	if master == nil {
+		return nil, errors.Wrapf(sgerrors.ErrNotFound, "master of kube %s", k.ID)
+	}
+	config.Node = *master
+	config.IsMaster = true
+
+	task, err := workflows.NewTask(config, s.Workflow, h.repo)
+	if err != nil {
+		return nil, errors.Wrap(err, "new task")
+	}
+	return []*workflows.Task{task}, nil
+}
+
+// runScheduledTasks runs tasks one by one, it stops at the first failure.
+func (h *Handler) runScheduledTasks(ctx context.Context, tasks []*workflows.Task) error {
+	for _, task := range tasks {
+		writer, err := h.getWriter(util.MakeFileName(task.ID))
+		if err != nil {
+			return errors.Wrapf(err, "task %s: get writer", task.ID)
+		}
+
+		if err = <-task.Run(ctx, *task.Config, writer); err != nil {
+			return errors.Wrapf(err, "task %s", task.ID)
+		}
+	}
+	return nil
+}
+
+func (h *Handler) listWorkflowSchedules(w http.ResponseWriter, r *http.Request) {
+	k, ok := h.getKubeOrSend(w, r)
+	if !ok {
+		return
+	}
+
+	schedules := k.Schedules
+	if schedules == nil {
+		schedules = []model.ScheduledTask{}
+	}
+	if err := json.NewEncoder(w).Encode(schedules); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) getWorkflowSchedule(w http.ResponseWriter, r *http.Request) {
+	k, ok := h.getKubeOrSend(w, r)
+	if !ok {
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	s := k.Schedule(name)
+	if s == nil {
+		message.SendNotFound(w, name, errors.Wrapf(sgerrors.ErrNotFound, "schedule %s", name))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(s); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) addWorkflowSchedule(w http.ResponseWriter, r *http.Request) {
+	s := model.ScheduledTask{}
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	k, ok := h.getKubeOrSend(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := h.AddSchedule(r.Context(), k, s)
+	if err != nil {
+		sendScheduleError(w, k, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(res); err != nil {
+		logrus.Errorf("Error encoding schedule %v", err)
+	}
+}
+
+func (h *Handler) updateWorkflowSchedule(w http.ResponseWriter, r *http.Request) {
+	s := model.ScheduledTask{}
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	k, ok := h.getKubeOrSend(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := h.UpdateSchedule(r.Context(), k, mux.Vars(r)["name"], s)
+	if err != nil {
+		sendScheduleError(w, k, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(res); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) removeWorkflowSchedule(w http.ResponseWriter, r *http.Request) {
+	k, ok := h.getKubeOrSend(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.RemoveSchedule(r.Context(), k, mux.Vars(r)["name"]); err != nil {
+		sendScheduleError(w, k, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func sendScheduleError(w http.ResponseWriter, k *model.Kube, err error) {
+	switch cause := errors.Cause(err); {
+	case cause == sgerrors.ErrInvalidJson:
+		message.SendValidationFailed(w, err)
+	case cause == sgerrors.ErrAlreadyExists:
+		message.SendAlreadyExists(w, k.ID, err)
+	case sgerrors.IsNotFound(err):
+		message.SendNotFound(w, k.ID, err)
+	default:
+		logrus.Errorf("schedules of kube %s: %v", k.ID, err)
+		message.SendUnknownError(w, err)
+	}
+}
+
+// WorkflowScheduler starts workflows of scheduled tasks of clusters, a run
+// that is due while the previous run of the task is in progress is skipped.
+type WorkflowScheduler struct {
+	*Scheduler
+	h *Handler
+
+	m       sync.Mutex
+	running map[string]bool
+	wg      sync.WaitGroup
+}
+
+func NewWorkflowScheduler(h *Handler) *WorkflowScheduler {
+	s := &WorkflowScheduler{
+		h:       h,
+		running: make(map[string]bool),
+	}
+	s.Scheduler = &Scheduler{
+		svc:  h.svc,
+		name: "workflow",
+		jobs: workflowJobs,
+		startJob: func(ctx context.Context, k *model.Kube, name string, now time.Time) {
+			s.start(ctx, k.ID, name, now)
+		},
+		interval: defaultScheduleCheckInterval,
+		started:  time.Now(),
+	}
+	return s
+}
+
+// workflowJobs returns scheduled tasks of the cluster that aren't suspended,
+// skipped runs count as runs.
+func workflowJobs(k *model.Kube) []scheduledJob {
+	jobs := make([]scheduledJob, 0, len(k.Schedules))
+	for _, task := range k.Schedules {
+		if task.Suspended {
+			continue
+		}
+
+		job := scheduledJob{name: task.Name, schedule: task.Schedule}
+		if run := task.LastRun; run != nil {
+			job.lastRun = run.StartedAt
+			if run.SkippedAt > run.StartedAt {
+				job.lastRun = run.SkippedAt
+			}
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+func (s *WorkflowScheduler) start(ctx context.Context, kubeID, name string, now time.Time) {
+	key := kubeID + "/" + name
+	if !s.acquire(key) {
+		logrus.Warnf("workflow scheduler: kube %s: schedule %s: skip the run, the previous one is in progress", kubeID, name)
+		s.updateRun(ctx, kubeID, name, func(run *model.ScheduledRun) {
+			run.Skipped++
+			run.SkippedAt = now.Unix()
+		})
+		return
+	}
+
+	k, err := s.h.svc.Get(ctx, kubeID)
+	if err != nil {
+		s.release(key)
+		logrus.Errorf("workflow scheduler: get kube %s: %v", kubeID, err)
+		return
+	}
+	task := k.Schedule(name)
+	if task == nil {
+		s.release(key)
+		return
+	}
+
+	run := &model.ScheduledRun{
+		StartedAt: now.Unix(),
+		State:     model.RunRunning,
+	}
+	tasks, err := s.h.newScheduledTasks(ctx, k, task)
+	if err != nil {
+		run.State = model.RunFailed
+		run.FinishedAt = now.Unix()
+		run.Error = err.Error()
+	}
+	for _, t := range tasks {
+		run.TaskIDs = append(run.TaskIDs, t.ID)
+	}
+	if task.LastRun != nil && task.LastRun.State == model.RunRunning {
+		// the run has been interrupted by a restart of control, it's replaced
+		logrus.Warnf("workflow scheduler: kube %s: schedule %s: run of %s hasn't finished",
+			kubeID, name, time.Unix(task.LastRun.StartedAt, 0))
+	}
+	task.LastRun = run
+
+	if k.Tasks == nil {
+		k.Tasks = make(map[string][]string)
+	}
+	k.Tasks[task.Workflow] = append(k.Tasks[task.Workflow], run.TaskIDs...)
+
+	// save the run first, a failed run is retried on the next schedule
+	if err := s.h.svc.Create(ctx, k); err != nil {
+		s.release(key)
+		logrus.Errorf("workflow scheduler: update kube %s: %v", kubeID, err)
+		return
+	}
+	if run.State == model.RunFailed {
+		s.release(key)
+		logrus.Errorf("workflow scheduler: kube %s: schedule %s: %s", kubeID, name, run.Error)
+		return
+	}
+
+	logrus.Infof("workflow scheduler: kube %s: start %s of schedule %s", kubeID, task.Workflow, name)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.release(key)
+
+		err := s.h.runScheduledTasks(ctx, tasks)
+		s.updateRun(ctx, kubeID, name, func(run *model.ScheduledRun) {
+			run.FinishedAt = time.Now().Unix()
+			run.State = model.RunSucceeded
+			if err != nil {
+				run.State = model.RunFailed
+				run.Error = err.Error()
+			}
+		})
+		if err != nil {
+			logrus.Errorf("workflow scheduler: kube %s: schedule %s: %v", kubeID, name, err)
+		}
+	}()
+}
+
+// updateRun changes the last run of the task of the saved cluster.
+func (s *WorkflowScheduler) updateRun(ctx context.Context, kubeID, name string, update func(*model.ScheduledRun)) {
+	k, err := s.h.svc.Get(ctx, kubeID)
+	if err != nil {
+		logrus.Errorf("workflow scheduler: get kube %s: %v", kubeID, err)
+		return
+	}
+
+	task := k.Schedule(name)
+	if task == nil || task.LastRun == nil {
+		return
+	}
+	update(task.LastRun)
+
+	if err := s.h.svc.Create(ctx, k); err != nil {
+		logrus.Errorf("workflow scheduler: update kube %s: %v", kubeID, err)
+	}
+}
+
+func (s *WorkflowScheduler) acquire(key string) bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.running[key] {
+		return false
+	}
+	s.running[key] = true
+	return true
+}
+
+func (s *WorkflowScheduler) release(key string) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	delete(s.running, key)
+}
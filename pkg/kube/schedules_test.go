@@ -0,0 +1,154 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage/memory"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// blockingStep runs until it's released.
+type blockingStep struct {
+	release chan struct{}
+}
+
+func (s blockingStep) Run(context.Context, io.Writer, *steps.Config) error {
+	<-s.release
+	return nil
+}
+
+func (blockingStep) Name() string                                             { return "block" }
+func (blockingStep) Description() string                                      { return "" }
+func (blockingStep) Depends() []string                                        { return nil }
+func (blockingStep) Rollback(context.Context, io.Writer, *steps.Config) error { return nil }
+
+func scheduleHandler(k *model.Kube) *Handler {
+	svc := new(kubeServiceMock)
+	svc.On("Get", mock.Anything, k.ID).Return(k, nil)
+	svc.On("Get", mock.Anything, mock.Anything).Return(nil, sgerrors.ErrNotFound)
+	svc.On("Create", mock.Anything, mock.Anything).Return(nil)
+	svc.On("ListAll", mock.Anything).Return([]model.Kube{*k}, nil)
+	profiles := new(mockProfileService)
+	profiles.On("Get", mock.Anything, k.ProfileID).Return(&profile.Profile{
+		Provider: clouds.DigitalOcean,
+	}, nil)
+
+	h := NewHandler(svc, nil, profiles, nil, nil, nil, memory.NewInMemoryRepository(), nil, "")
+	h.getWriter = func(string) (io.WriteCloser, error) {
+		return &bufferCloser{}, nil
+	}
+	return h
+}
+
+func TestHandler_workflowSchedules(t *testing.T) {
+	workflows.Init()
+
+	k := specKube()
+	h := scheduleHandler(k)
+
+	for _, body := range []string{
+		`{`,
+		`{"name":"Patch","workflow":"OSPatch","schedule":"0 3 * * 6"}`,
+		`{"name":"patch","workflow":"unknown","schedule":"0 3 * * 6"}`,
+		`{"name":"patch","workflow":"DeleteCluster","schedule":"0 3 * * 6"}`,
+		`{"name":"patch","workflow":"OSPatch","schedule":"0 3 * *"}`,
+		`{"name":"patch","workflow":"OSPatch","schedule":"0 3 * * 6","target":"nodes"}`,
+	} {
+		rr := specRequest(h, http.MethodPost, "/kubes/kube-1/schedules", body)
+		require.Equal(t, http.StatusBadRequest, rr.Code, body)
+	}
+
+	body := `{"name":"patch","workflow":"OSPatch","schedule":"0 3 * * 6","target":"machines"}`
+	rr := specRequest(h, http.MethodPost, "/kubes/kube-1/schedules", body)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	rr = specRequest(h, http.MethodPost, "/kubes/kube-1/schedules", body)
+	require.Equal(t, http.StatusConflict, rr.Code)
+	rr = specRequest(h, http.MethodPost, "/kubes/kube-2/schedules", body)
+	require.Equal(t, http.StatusNotFound, rr.Code)
+
+	k.Schedules[0].LastRun = &model.ScheduledRun{StartedAt: 1, State: model.RunSucceeded}
+	rr = specRequest(h, http.MethodPut, "/kubes/kube-1/schedules/patch", `{"workflow":"Compliance","schedule":"0 4 * * *"}`)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	rr = specRequest(h, http.MethodPut, "/kubes/kube-1/schedules/scan", `{"workflow":"Compliance","schedule":"0 4 * * *"}`)
+	require.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = specRequest(h, http.MethodGet, "/kubes/kube-1/schedules", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	schedules := []model.ScheduledTask{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&schedules))
+	require.Len(t, schedules, 1)
+	require.Equal(t, "patch", schedules[0].Name)
+	require.Equal(t, workflows.Compliance, schedules[0].Workflow)
+	require.Equal(t, model.ScheduleTargetMaster, schedules[0].Target)
+	require.Equal(t, model.RunSucceeded, schedules[0].LastRun.State)
+
+	rr = specRequest(h, http.MethodDelete, "/kubes/kube-1/schedules/patch", "")
+	require.Equal(t, http.StatusNoContent, rr.Code)
+	rr = specRequest(h, http.MethodGet, "/kubes/kube-1/schedules/patch", "")
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestWorkflowScheduler_start(t *testing.T) {
+	workflows.Init()
+	step := blockingStep{release: make(chan struct{})}
+	workflows.RegisterWorkFlow(workflows.OSPatch, []steps.Step{step})
+
+	k := specKube()
+	k.Schedules = []model.ScheduledTask{
+		{Name: "patch", Workflow: workflows.OSPatch, Schedule: "0 3 * * *", Target: model.ScheduleTargetMachines},
+		{Name: "scan", Workflow: workflows.Compliance, Schedule: "0 3 * * *", Suspended: true},
+	}
+	s := NewWorkflowScheduler(scheduleHandler(k))
+	s.started = time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	due := func(now time.Time) []string {
+		var names []string
+		for _, job := range workflowJobs(k) {
+			if s.due(k, job, now) {
+				names = append(names, job.name)
+			}
+		}
+		return names
+	}
+
+	now := time.Date(2019, 6, 1, 3, 0, 0, 0, time.UTC)
+	require.Empty(t, due(now.Add(-time.Minute)))
+	// suspended tasks aren't run
+	require.Equal(t, []string{"patch"}, due(now))
+
+	s.check(context.Background(), now)
+	run := k.Schedules[0].LastRun
+	require.NotNil(t, run)
+	require.Equal(t, model.RunRunning, run.State)
+	// the master first, then the node
+	require.Len(t, run.TaskIDs, 2)
+	require.Equal(t, run.TaskIDs, k.Tasks[workflows.OSPatch])
+	require.Nil(t, k.Schedules[1].LastRun)
+
+	// the next run is skipped while the first one is in progress
+	next := now.Add(24 * time.Hour)
+	require.Equal(t, []string{"patch"}, due(next))
+	s.check(context.Background(), next)
+	require.Equal(t, 1, k.Schedules[0].LastRun.Skipped)
+	require.Empty(t, due(next))
+
+	close(step.release)
+	s.wg.Wait()
+	run = k.Schedules[0].LastRun
+	require.Equal(t, model.RunSucceeded, run.State, run.Error)
+	require.Equal(t, now.Unix(), run.StartedAt)
+	require.NotZero(t, run.FinishedAt)
+}
@@ -0,0 +1,173 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+)
+
+const (
+	searchParallelism    = 8
+	searchClusterTimeout = 15 * time.Second
+)
+
+// SearchResult is a set of objects found on all clusters, clusters that
+// couldn't be searched are listed with their errors.
+type SearchResult struct {
+	Items  []SearchItem  `json:"items"`
+	Errors []SearchError `json:"errors"`
+}
+
+// SearchItem is an object with the cluster that runs it.
+type SearchItem struct {
+	KubeID   string          `json:"kubeId"`
+	KubeName string          `json:"kubeName"`
+	Object   json.RawMessage `json:"object"`
+}
+
+type SearchError struct {
+	KubeID   string `json:"kubeId"`
+	KubeName string `json:"kubeName"`
+	Error    string `json:"error"`
+}
+
+// searchResources lists objects of the kind on all operational clusters.
+// Clusters are queried concurrently, a slow or unreachable cluster doesn't
+// fail the search but is reported in errors.
+func (h *Handler) searchResources(w http.ResponseWriter, r *http.Request) {
+	resource := r.URL.Query().Get("kind")
+	if resource == "" {
+		resource = r.URL.Query().Get("resource")
+	}
+	if resource == "" {
+		message.SendValidationFailed(w, errors.New("kind is required"))
+		return
+	}
+
+	opts, _, err := listOptions(r)
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	kubes, err := h.visibleKubes(r.Context())
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	var (
+		m      sync.Mutex
+		result = SearchResult{
+			Items:  make([]SearchItem, 0),
+			Errors: make([]SearchError, 0),
+		}
+	)
+	eachOperationalKube(kubes, func(k *model.Kube) {
+		items, err := h.searchKube(r.Context(), k, resource, opts)
+
+		m.Lock()
+		defer m.Unlock()
+		if err != nil {
+			result.Errors = append(result.Errors, SearchError{
+				KubeID:   k.ID,
+				KubeName: k.Name,
+				Error:    err.Error(),
+			})
+			return
+		}
+		for _, obj := range items {
+			result.Items = append(result.Items, SearchItem{
+				KubeID:   k.ID,
+				KubeName: k.Name,
+				Object:   obj,
+			})
+		}
+	})
+
+	// objects of a cluster keep the order returned by its api
+	sort.SliceStable(result.Items, func(i, j int) bool {
+		return result.Items[i].KubeName < result.Items[j].KubeName
+	})
+	sort.Slice(result.Errors, func(i, j int) bool {
+		return result.Errors[i].KubeName < result.Errors[j].KubeName
+	})
+
+	if err = json.NewEncoder(w).Encode(result); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// eachOperationalKube calls fn concurrently for operational kubes,
+// at most searchParallelism clusters are queried at once.
+func eachOperationalKube(kubes []model.Kube, fn func(k *model.Kube)) {
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, searchParallelism)
+	)
+	for i := range kubes {
+		k := &kubes[i]
+		if k.State != model.StateOperational {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			fn(k)
+		}()
+	}
+	wg.Wait()
+}
+
+func (h *Handler) searchKube(ctx context.Context, k *model.Kube, resource string, opts ResourceListOptions) ([]json.RawMessage, error) {
+	list := struct {
+		Items []json.RawMessage `json:"items"`
+	}{}
+	if err := h.listKubeResources(ctx, k, resource, opts, &list); err != nil {
+		return nil, err
+	}
+
+	return list.Items, nil
+}
+
+// listKubeResources decodes the list of objects into v, the cluster
+// is given searchClusterTimeout to respond.
+func (h *Handler) listKubeResources(ctx context.Context, k *model.Kube, resource string, opts ResourceListOptions, v interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, searchClusterTimeout)
+	defer cancel()
+
+	type listResult struct {
+		raw []byte
+		err error
+	}
+	// discovery requests don't take a context
+	done := make(chan listResult, 1)
+	go func() {
+		raw, err := h.svc.ListResources(ctx, k.ID, resource, opts)
+		done <- listResult{raw, err}
+	}()
+
+	var res listResult
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		return errors.Wrapf(ctx.Err(), "list %s", resource)
+	}
+	if res.err != nil {
+		return res.err
+	}
+
+	return errors.Wrapf(json.Unmarshal(res.raw, v), "decode %s", resource)
+}
@@ -0,0 +1,57 @@
+package kube
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/model"
+)
+
+func TestHandler_searchResources(t *testing.T) {
+	opts := ResourceListOptions{LabelSelector: "app=nginx"}
+
+	svc := new(kubeServiceMock)
+	svc.On("ListAll", mock.Anything).Return([]model.Kube{
+		{ID: "b", Name: "beta", State: model.StateOperational},
+		{ID: "a", Name: "alpha", State: model.StateOperational},
+		{ID: "c", Name: "gamma", State: model.StateOperational},
+		{ID: "d", Name: "delta", State: model.StateProvisioning},
+	}, nil)
+	svc.On("ListResources", mock.Anything, "a", "Pod", opts).
+		Return([]byte(`{"items":[{"name":"nginx-1"},{"name":"nginx-2"}]}`), nil)
+	svc.On("ListResources", mock.Anything, "b", "Pod", opts).
+		Return([]byte(`{"items":[{"name":"nginx-3"}]}`), nil)
+	svc.On("ListResources", mock.Anything, "c", "Pod", opts).
+		Return(nil, errors.New("connection refused"))
+	h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+	router := mux.NewRouter()
+	h.Register(router)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/resources?labelSelector=app%3Dnginx", nil))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/resources?kind=Pod&labelSelector=app%3Dnginx", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	res := &SearchResult{}
+	require.Nil(t, json.NewDecoder(rr.Body).Decode(res))
+	require.Equal(t, []SearchItem{
+		{KubeID: "a", KubeName: "alpha", Object: json.RawMessage(`{"name":"nginx-1"}`)},
+		{KubeID: "a", KubeName: "alpha", Object: json.RawMessage(`{"name":"nginx-2"}`)},
+		{KubeID: "b", KubeName: "beta", Object: json.RawMessage(`{"name":"nginx-3"}`)},
+	}, res.Items)
+	require.Equal(t, []SearchError{
+		{KubeID: "c", KubeName: "gamma", Error: "connection refused"},
+	}, res.Errors)
+	svc.AssertNotCalled(t, "ListResources", mock.Anything, "d", mock.Anything, mock.Anything)
+}
@@ -15,8 +15,10 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	kubejson "k8s.io/apimachinery/pkg/runtime/serializer/json"
 	"k8s.io/apimachinery/pkg/runtime/serializer/versioning"
+	"k8s.io/apimachinery/pkg/types"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
+	clientcmddapi "k8s.io/client-go/tools/clientcmd/api"
 	clientcmdlatest "k8s.io/client-go/tools/clientcmd/api/latest"
 	"k8s.io/helm/pkg/helm"
 	"k8s.io/helm/pkg/proto/hapi/chart"
@@ -37,6 +39,20 @@ const (
 
 	DefaultStoragePrefix = "/supergiant/kubes/"
 
+	ComplianceStoragePrefix = "/supergiant/compliance/"
+
+	SSHKeyAuditStoragePrefix = "/supergiant/sshaudit/"
+
+	KubeconfigStoragePrefix = "/supergiant/kubeconfigs/"
+
+	TerminalStoragePrefix = "/supergiant/terminals/"
+
+	UtilizationStoragePrefix = "/supergiant/utilization/"
+
+	DriftStoragePrefix = "/supergiant/drift/"
+
+	InventoryStoragePrefix = "/supergiant/inventory/"
+
 	releaseInstallTimeout = 300
 )
 
@@ -51,16 +67,23 @@ type Interface interface {
 	Create(ctx context.Context, k *model.Kube) error
 	Get(ctx context.Context, name string) (*model.Kube, error)
 	ListAll(ctx context.Context) ([]model.Kube, error)
+	List(ctx context.Context, opts storage.ListOptions) ([]model.Kube, string, error)
 	Delete(ctx context.Context, name string) error
 	KubeConfigFor(ctx context.Context, kname, user string) ([]byte, error)
 	ListKubeResources(ctx context.Context, kname string) ([]byte, error)
 	GetKubeResources(ctx context.Context, kname, resource, ns, name string) ([]byte, error)
+	ListResources(ctx context.Context, kname, resource string, opts ResourceListOptions) ([]byte, error)
+	CreateResource(ctx context.Context, kname, resource, ns string, obj []byte, dryRun bool) ([]byte, error)
+	PatchResource(ctx context.Context, kname, resource, ns, name string, pt types.PatchType, patch []byte, dryRun bool) ([]byte, error)
+	DeleteResource(ctx context.Context, kname, resource, ns, name string, dryRun bool) ([]byte, error)
 	ListNodes(ctx context.Context, k *model.Kube, role string) ([]corev1.Node, error)
 	GetCerts(ctx context.Context, kname, cname string) (*Bundle, error)
 	InstallRelease(ctx context.Context, kname string, rls *ReleaseInput) (*release.Release, error)
 	ListReleases(ctx context.Context, kname, ns, offset string, limit int) ([]*model.ReleaseInfo, error)
 	ReleaseDetails(ctx context.Context, kname, rlsName string) (*release.Release, error)
 	DeleteRelease(ctx context.Context, kname, rlsName string, purge bool) (*model.ReleaseInfo, error)
+	PreviewUpgrade(ctx context.Context, kname, rlsName string, inp *ReleaseUpgradeInput) (*ReleaseDiff, error)
+	ScanDeprecatedAPIs(ctx context.Context, kname, targetVersion string) (*DeprecationReport, error)
 }
 
 // ChartGetter interface is a wrapper for GetChart function.
@@ -88,12 +111,13 @@ type Service struct {
 // NewService constructs a Service.
 func NewService(prefix string, s storage.Interface, chrtGetter ChartGetter) *Service {
 	return &Service{
-		clientForGroupFn: kubeconfig.RestClientForGroupVersion,
-		corev1ClientFn:   kubeconfig.CoreV1Client,
-		newHelmProxyFn:   helmProxyFrom,
-		chrtGetter:       chrtGetter,
-		prefix:           prefix,
-		storage:          s,
+		discoveryClientFn: discoveryClient,
+		clientForGroupFn:  kubeconfig.RestClientForGroupVersion,
+		corev1ClientFn:    kubeconfig.CoreV1Client,
+		newHelmProxyFn:    helmProxyFrom,
+		chrtGetter:        chrtGetter,
+		prefix:            prefix,
+		storage:           s,
 	}
 }
 
@@ -153,6 +177,23 @@ func (s Service) ListAll(ctx context.Context) ([]model.Kube, error) {
 	return kubes, nil
 }
 
+// List returns a page of kubes and the continue token of the next one.
+func (s Service) List(ctx context.Context, opts storage.ListOptions) ([]model.Kube, string, error) {
+	rawKubes, next, err := storage.List(ctx, s.storage, s.prefix, opts)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "storage: list")
+	}
+
+	kubes := make([]model.Kube, len(rawKubes))
+	for i, v := range rawKubes {
+		if err = json.Unmarshal(v, &kubes[i]); err != nil {
+			return nil, "", errors.Wrap(err, "unmarshal")
+		}
+	}
+
+	return kubes, next, nil
+}
+
 // Delete deletes a kube with a specified name.
 func (s Service) Delete(ctx context.Context, kubeID string) error {
 	return s.storage.Delete(ctx, s.prefix, kubeID)
@@ -245,6 +286,11 @@ func (s Service) KubeConfigFor(ctx context.Context, kubeID, user string) ([]byte
 		return nil, err
 	}
 
+	return encodeKubeconfig(kubeconfig)
+}
+
+// encodeKubeconfig serializes a kubeconfig the way kubectl stores it.
+func encodeKubeconfig(kubeconfig clientcmddapi.Config) ([]byte, error) {
 	serializer := kubejson.NewSerializer(kubejson.DefaultMetaFactory, clientcmdlatest.Scheme, clientcmdlatest.Scheme, false)
 	codec := versioning.NewDefaultingCodecForScheme(
 		clientcmdlatest.Scheme,
@@ -393,14 +439,9 @@ func (s Service) helmClient(k *model.Kube) (proxy.Interface, error) {
 }
 
 func (s Service) resourcesGroupInfo(kube *model.Kube) (map[string]schema.GroupVersion, error) {
-	client, err := s.discoveryClientFn(kube)
-	if err != nil {
-		return nil, errors.Wrap(err, "get discovery client")
-	}
-
-	apiResourceLists, err := client.ServerResources()
+	apiResourceLists, err := s.serverResources(kube)
 	if err != nil {
-		return nil, errors.Wrap(err, "get resources")
+		return nil, err
 	}
 
 	resourcesGroupInfo := map[string]schema.GroupVersion{}
@@ -61,6 +61,7 @@ type fakeHelmProxy struct {
 	getReleaseResp    *services.GetReleaseContentResponse
 	listReleaseResp   *services.ListReleasesResponse
 	uninstReleaseResp *services.UninstallReleaseResponse
+	updateRlsResp     *services.UpdateReleaseResponse
 }
 
 func (p *fakeHelmProxy) InstallReleaseFromChart(chart *chart.Chart, namespace string, opts ...helm.InstallOption) (*services.InstallReleaseResponse, error) {
@@ -72,6 +73,9 @@ func (p *fakeHelmProxy) ListReleases(opts ...helm.ReleaseListOption) (*services.
 func (p *fakeHelmProxy) ReleaseContent(rlsName string, opts ...helm.ContentOption) (*services.GetReleaseContentResponse, error) {
 	return p.getReleaseResp, p.err
 }
+func (p *fakeHelmProxy) UpdateReleaseFromChart(rlsName string, chart *chart.Chart, opts ...helm.UpdateOption) (*services.UpdateReleaseResponse, error) {
+	return p.updateRlsResp, p.err
+}
 func (p *fakeHelmProxy) DeleteRelease(rlsName string, opts ...helm.DeleteOption) (*services.UninstallReleaseResponse, error) {
 	return p.uninstReleaseResp, p.err
 }
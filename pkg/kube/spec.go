@@ -0,0 +1,433 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/clusterspec"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/ratelimit"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/tracing"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/statuses"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	maxSpecSize = 1 << 20
+
+	defaultServicesCIDR = "10.3.0.0/16"
+)
+
+// taskPollInterval is how often tasks started by specs are checked to
+// release their context.
+const taskPollInterval = time.Second * 10
+
+// isSpec reports whether the request body is a YAML cluster spec.
+func isSpec(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/yaml", "application/x-yaml", "text/yaml":
+		return true
+	}
+
+	return false
+}
+
+// getSpec exports the cluster as a declarative spec.
+func (h *Handler) getSpec(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	p, err := h.profileSvc.Get(r.Context(), k.ProfileID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, k.ProfileID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	data, err := clusterspec.FromKube(k, p).Marshal()
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(data)
+}
+
+// importSpec creates the cluster of the spec or reconciles the existing one.
+func (h *Handler) importSpec(w http.ResponseWriter, r *http.Request) {
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxSpecSize))
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	c, err := clusterspec.Parse(data)
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryRun"))
+	res, err := h.ApplySpec(r.Context(), c, dryRun)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, c.Metadata.Name, err)
+			return
+		}
+		if errors.Cause(err) == api.ErrTeamNotAllowed {
+			message.SendValidationFailed(w, err)
+			return
+		}
+		if sgerrors.IsQuotaExceeded(err) {
+			message.SendQuotaExceeded(w, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if res.Created || len(res.Tasks) > 0 {
+		w.WriteHeader(http.StatusAccepted)
+	}
+	if err = json.NewEncoder(w).Encode(res); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// ApplySpec creates the cluster of the spec if it doesn't exist. For an existing
+// operational cluster missing worker nodes and addons are added, changes that
+// remove machines or addons or touch masters are reported as pending. Applied
+// specs are saved as revisions of the cluster.
+func (h *Handler) ApplySpec(ctx context.Context, c *clusterspec.Cluster, dryRun bool) (*SpecResult, error) {
+	res, err := h.applySpec(ctx, c, dryRun)
+	if err != nil || dryRun {
+		return res, err
+	}
+	h.recordSpec(ctx, res.ClusterID, c, "")
+	return res, nil
+}
+
+func (h *Handler) applySpec(ctx context.Context, c *clusterspec.Cluster, dryRun bool) (*SpecResult, error) {
+	k, err := h.findSpecKube(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	if k == nil {
+		return h.createFromSpec(ctx, c, dryRun)
+	}
+
+	p, err := h.profileSvc.Get(ctx, k.ProfileID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get profile %s", k.ProfileID)
+	}
+
+	// machines of a cluster being provisioned or deleted aren't known yet
+	operational := k.State == model.StateOperational
+	res := &SpecResult{ClusterID: k.ID}
+	var nodeProfiles []profile.NodeProfile
+	for _, change := range clusterspec.Diff(c, clusterspec.FromKube(k, p)) {
+		if !operational || change.Role != model.RoleNode || change.Desired < change.Current {
+			res.Pending = append(res.Pending, change)
+			continue
+		}
+
+		res.Changes = append(res.Changes, change)
+		for i := change.Current; i < change.Desired; i++ {
+			nodeProfiles = append(nodeProfiles, change.Machine)
+		}
+	}
+
+	installed := make(map[string]bool, len(k.Addons))
+	for _, addon := range k.Addons {
+		installed[addon] = true
+	}
+	for _, addon := range c.Spec.Addons {
+		if !installed[addon] {
+			res.Addons = append(res.Addons, addon)
+		}
+		delete(installed, addon)
+	}
+	for addon := range installed {
+		res.PendingAddons = append(res.PendingAddons, addon)
+	}
+	sort.Strings(res.PendingAddons)
+	if !operational {
+		res.PendingAddons = append(res.PendingAddons, res.Addons...)
+		res.Addons = nil
+	}
+
+	if dryRun || (len(nodeProfiles) == 0 && len(res.Addons) == 0) {
+		return res, nil
+	}
+	res.Tasks = make(map[string][]string)
+
+	if len(res.Addons) > 0 {
+		taskID, err := h.installAddons(ctx, k, p, res.Addons)
+		if err != nil {
+			return nil, err
+		}
+		res.Tasks[workflows.InstallAddons] = []string{taskID}
+	}
+
+	if len(nodeProfiles) == 0 {
+		return res, nil
+	}
+
+	config, err := steps.NewConfigFromKube(p, k)
+	if err != nil {
+		return nil, errors.Wrap(err, "build provisioning config")
+	}
+	if err = h.fillCredentials(ctx, k.AccountName, config); err != nil {
+		return nil, err
+	}
+
+	provisionCtx, cancel := context.WithTimeout(tracing.Detach(ctx), time.Minute*60)
+	tasks, err := h.nodeProvisioner.ProvisionNodes(provisionCtx, nodeProfiles, k, config)
+	if err != nil {
+		cancel()
+		return nil, errors.Wrap(err, "provision nodes")
+	}
+	go h.cancelAfterTasks(provisionCtx, cancel, tasks)
+
+	if k.Tasks == nil {
+		k.Tasks = make(map[string][]string)
+	}
+	k.Tasks[workflows.NodeTask] = append(k.Tasks[workflows.NodeTask], tasks...)
+	if err = h.svc.Create(ctx, k); err != nil {
+		return nil, errors.Wrapf(err, "update kube %s", k.ID)
+	}
+	res.Tasks[workflows.NodeTask] = tasks
+
+	return res, nil
+}
+
+// installAddons runs the addons on a master and adds them to the cluster.
+func (h *Handler) installAddons(ctx context.Context, k *model.Kube, p *profile.Profile, addons []string) (string, error) {
+	config, err := steps.NewConfigFromKube(p, k)
+	if err != nil {
+		return "", errors.Wrap(err, "build addons config")
+	}
+	if err = util.LoadCloudSpecificDataFromKube(k, config); err != nil {
+		return "", errors.Wrap(err, "load cloud specific data")
+	}
+	// addons like external-dns use credentials of the cloud account
+	if err = h.fillCredentials(ctx, k.AccountName, config); err != nil {
+		return "", err
+	}
+
+	master := config.GetMaster()
+	if master == nil {
+		return "", errors.Wrap(sgerrors.ErrNotFound, "master node")
+	}
+	config.Node = *master
+	config.IsMaster = true
+	config.Kube.Addons = addons
+
+	task, err := workflows.NewTask(config, workflows.InstallAddons, h.repo)
+	if err != nil {
+		return "", errors.Wrap(err, "create addons task")
+	}
+
+	writer, err := h.getWriter(util.MakeFileName(task.ID))
+	if err != nil {
+		return "", errors.Wrap(err, "create task log")
+	}
+
+	k.Addons = append(k.Addons, addons...)
+	if err = h.svc.Create(ctx, k); err != nil {
+		return "", errors.Wrapf(err, "update kube %s", k.ID)
+	}
+
+	go func() {
+		if err := <-task.Run(context.Background(), *config, writer); err != nil {
+			logrus.Errorf("install addons %v to cluster %s: %v", addons, k.ID, err)
+		}
+	}()
+
+	return task.ID, nil
+}
+
+func (h *Handler) createFromSpec(ctx context.Context, c *clusterspec.Cluster, dryRun bool) (*SpecResult, error) {
+	res := &SpecResult{Created: true}
+	for _, g := range c.Spec.NodeGroups {
+		res.Changes = append(res.Changes, clusterspec.Change{
+			Group:   g.Name,
+			Role:    g.Role,
+			Size:    clusterspec.Size(g.Machine),
+			Desired: g.Count,
+			Machine: g.Machine,
+		})
+	}
+
+	clusterProfile := c.Profile()
+	if clusterProfile.K8SServicesCIDR == "" {
+		clusterProfile.K8SServicesCIDR = defaultServicesCIDR
+	}
+
+	scope := api.ScopeFrom(ctx)
+	acc, err := h.accountService.Get(ctx, c.Spec.Account)
+	// accounts of other teams look like missing ones
+	if err == nil && !scope.AllowsLabeled(acc.Team, acc.Labels) {
+		err = sgerrors.ErrNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "get cloud account %s", c.Spec.Account)
+	}
+	if clusterProfile.Team, err = scope.TeamFor(clusterProfile.Team); err != nil {
+		return nil, err
+	}
+
+	config, err := steps.NewConfig(c.Metadata.Name, c.Spec.Account, clusterProfile)
+	if err != nil {
+		return nil, errors.Wrap(err, "build provisioning config")
+	}
+	if dryRun {
+		return res, nil
+	}
+	owner, release, err := ratelimit.Reserve(ctx, h.quotas)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	res.ClusterID = config.Kube.ID
+	config.Kube.Owner = owner
+	config.Kube.Team = clusterProfile.Team
+	config.Kube.Labels = clusterProfile.Labels
+	if err = util.FillCloudAccountCredentials(acc, config); err != nil {
+		return nil, errors.Wrap(err, "fill cloud account")
+	}
+
+	provisionCtx, cancel := context.WithTimeout(tracing.Detach(ctx), config.Timeout)
+	clusterProfile.ID = uuid.New()[:8]
+	taskMap, err := h.kubeProvisioner.ProvisionCluster(provisionCtx, &clusterProfile, config)
+	if err != nil {
+		cancel()
+		return nil, errors.Wrap(err, "provision cluster")
+	}
+
+	if err := h.profileSvc.Create(provisionCtx, &clusterProfile); err != nil {
+		logrus.Debugf("Error creating profile %s", clusterProfile.ID)
+	}
+
+	res.Tasks = make(map[string][]string, len(taskMap))
+	var taskIDs []string
+	for role, taskSet := range taskMap {
+		for _, task := range taskSet {
+			res.Tasks[role] = append(res.Tasks[role], task.ID)
+			taskIDs = append(taskIDs, task.ID)
+		}
+	}
+	go h.cancelAfterTasks(provisionCtx, cancel, taskIDs)
+
+	return res, nil
+}
+
+// cancelAfterTasks releases the context the tasks run with once none of
+// them is running, or when the context is done. Tasks that aren't stored
+// yet are taken for running.
+func (h *Handler) cancelAfterTasks(ctx context.Context, cancel context.CancelFunc, taskIDs []string) {
+	defer cancel()
+
+	ticker := time.NewTicker(h.taskPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if !h.anyTaskRunning(ctx, taskIDs) {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *Handler) anyTaskRunning(ctx context.Context, taskIDs []string) bool {
+	for _, id := range taskIDs {
+		data, err := h.repo.Get(ctx, workflows.Prefix, id)
+		if err != nil {
+			return true
+		}
+
+		task := workflows.Task{}
+		if err = json.Unmarshal(data, &task); err != nil {
+			return true
+		}
+		switch task.Status {
+		case statuses.Todo, statuses.Executing:
+			return true
+		}
+	}
+
+	return false
+}
+
+// findSpecKube returns the cluster with the id or name of the spec, it's nil
+// when the cluster doesn't exist or isn't in the scope of the context.
+func (h *Handler) findSpecKube(ctx context.Context, c *clusterspec.Cluster) (*model.Kube, error) {
+	scope := api.ScopeFrom(ctx)
+	if c.Metadata.ID != "" {
+		k, err := h.svc.Get(ctx, c.Metadata.ID)
+		if err != nil && !sgerrors.IsNotFound(err) {
+			return nil, err
+		}
+		if k != nil && scope.AllowsLabeled(k.Team, k.Labels) {
+			return k, nil
+		}
+	}
+
+	kubes, err := h.visibleKubes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range kubes {
+		if kubes[i].Name == c.Metadata.Name {
+			return &kubes[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (h *Handler) fillCredentials(ctx context.Context, accountName string, config *steps.Config) error {
+	acc, err := h.accountService.Get(ctx, accountName)
+	if err != nil {
+		return errors.Wrapf(err, "get cloud account %s", accountName)
+	}
+
+	return errors.Wrap(util.FillCloudAccountCredentials(acc, config), "fill cloud account")
+}
@@ -0,0 +1,168 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/clusterspec"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/revision"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// recordSpec saves the applied spec as the latest revision of the cluster,
+// failures don't fail the apply.
+func (h *Handler) recordSpec(ctx context.Context, kubeID string, c *clusterspec.Cluster, comment string) {
+	if kubeID == "" {
+		return
+	}
+	data, err := c.Marshal()
+	if err == nil {
+		_, err = h.revisions.Record(ctx, revision.KindSpec, kubeID, data, comment)
+	}
+	if err != nil {
+		logrus.Warnf("kubes: %s cluster: record revision of spec: %v", kubeID, err)
+	}
+}
+
+// listSpecRevisions returns all applied specs of the cluster.
+func (h *Handler) listSpecRevisions(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+	if _, err := h.svc.Get(r.Context(), kubeID); err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	revisions, err := h.revisions.List(r.Context(), revision.KindSpec, kubeID)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(revisions); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// getSpecRevision returns an applied spec of the cluster.
+func (h *Handler) getSpecRevision(w http.ResponseWriter, r *http.Request) {
+	rev, ok := h.specRevision(w, r)
+	if !ok {
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(rev); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// revertSpec applies the spec of the revision again, like an import changes
+// that remove machines or touch masters are reported as pending.
+func (h *Handler) revertSpec(w http.ResponseWriter, r *http.Request) {
+	rev, ok := h.specRevision(w, r)
+	if !ok {
+		return
+	}
+
+	c, err := clusterspec.Parse([]byte(rev.Document))
+	if err != nil {
+		message.SendUnknownError(w, errors.Wrapf(err, "parse revision %d", rev.Number))
+		return
+	}
+	// the spec is applied to this cluster even if it has been renamed
+	c.Metadata.ID = rev.ID
+
+	res, err := h.applySpec(r.Context(), c, false)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, rev.ID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+	h.recordSpec(r.Context(), res.ClusterID, c, fmt.Sprintf("revert to revision %d", rev.Number))
+
+	if len(res.Tasks) > 0 {
+		w.WriteHeader(http.StatusAccepted)
+	}
+	if err = json.NewEncoder(w).Encode(res); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// diffSpec compares two applied specs of the cluster, the latest one and
+// the one before it by default.
+func (h *Handler) diffSpec(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+	from, to, err := revision.ParseRange(r.URL.Query())
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	if _, err = h.svc.Get(r.Context(), kubeID); err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	diff, err := h.revisions.Diff(r.Context(), revision.KindSpec, kubeID, from, to)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, "revision", err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(diff); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) specRevision(w http.ResponseWriter, r *http.Request) (*revision.Revision, bool) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+
+	number, err := strconv.Atoi(vars["number"])
+	if err != nil || number < 1 {
+		message.SendValidationFailed(w, sgerrors.WithField(errors.Errorf("invalid revision %q", vars["number"]), "number"))
+		return nil, false
+	}
+
+	if _, err = h.svc.Get(r.Context(), kubeID); err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return nil, false
+		}
+		message.SendUnknownError(w, err)
+		return nil, false
+	}
+
+	rev, err := h.revisions.Get(r.Context(), revision.KindSpec, kubeID, number)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, "revision", err)
+			return nil, false
+		}
+		message.SendUnknownError(w, err)
+		return nil, false
+	}
+	return rev, true
+}
@@ -0,0 +1,335 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/clusterspec"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/revision"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage/memory"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/statuses"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const testClusterSpec = `
+apiVersion: control.supergiant.io/v1
+kind: Cluster
+metadata:
+  name: test
+spec:
+  account: do
+  profile:
+    provider: digitalocean
+    region: fra1
+  nodeGroups:
+  - name: masters
+    role: master
+    count: 1
+    machine:
+      size: s-2vcpu-4gb
+  - name: workers
+    role: node
+    count: 3
+    machine:
+      size: s-2vcpu-4gb
+`
+
+func specRequest(h *Handler, method, url, body string) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	h.Register(router)
+
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/yaml")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func specKube() *model.Kube {
+	return &model.Kube{
+		ID:          "kube-1",
+		Name:        "test",
+		AccountName: "do",
+		ProfileID:   "profile-1",
+		Provider:    clouds.DigitalOcean,
+		State:       model.StateOperational,
+		Masters: map[string]*model.Machine{
+			"master-1": {Name: "master-1", Role: model.RoleMaster, Size: "s-2vcpu-4gb"},
+		},
+		Nodes: map[string]*model.Machine{
+			"node-1": {Name: "node-1", Role: model.RoleNode, Size: "s-2vcpu-4gb"},
+		},
+		Tasks: map[string][]string{},
+	}
+}
+
+func TestHandler_getSpec(t *testing.T) {
+	svc := new(kubeServiceMock)
+	svc.On("Get", mock.Anything, "kube-1").Return(specKube(), nil)
+	profiles := new(mockProfileService)
+	profiles.On("Get", mock.Anything, "profile-1").Return(&profile.Profile{
+		Provider: clouds.DigitalOcean,
+		Region:   "fra1",
+	}, nil)
+	h := NewHandler(svc, nil, profiles, nil, nil, nil, nil, nil, "")
+
+	rr := specRequest(h, http.MethodGet, "/kubes/kube-1/spec", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	c, err := clusterspec.Parse(rr.Body.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, "kube-1", c.Metadata.ID)
+	require.Equal(t, "do", c.Spec.Account)
+	require.Len(t, c.Spec.NodeGroups, 2)
+}
+
+func TestHandler_importSpecCreate(t *testing.T) {
+	svc := new(kubeServiceMock)
+	svc.On("ListAll", mock.Anything).Return([]model.Kube{}, nil)
+	accounts := new(accServiceMock)
+	accounts.On("Get", mock.Anything, "do").Return(&model.CloudAccount{
+		Provider:    clouds.DigitalOcean,
+		Credentials: map[string]string{},
+	}, nil)
+	profiles := new(mockProfileService)
+	profiles.On("Create", mock.Anything, mock.Anything).Return(nil)
+	provisioner := new(mockProvisioner)
+	provisioner.On("ProvisionCluster", mock.Anything, mock.Anything, mock.Anything).
+		Return(map[string][]*workflows.Task{
+			"master": {{ID: "task-1"}},
+		}, nil)
+	h := NewHandler(svc, accounts, profiles, nil, provisioner, nil, memory.NewInMemoryRepository(), nil, "")
+
+	rr := specRequest(h, http.MethodPost, "/kubes/import", testClusterSpec)
+	require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+
+	res := &SpecResult{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(res))
+	require.True(t, res.Created)
+	require.Equal(t, []string{"task-1"}, res.Tasks["master"])
+
+	p := provisioner.Calls[0].Arguments.Get(1).(*profile.Profile)
+	require.Len(t, p.MasterProfiles, 1)
+	require.Len(t, p.NodesProfiles, 3)
+}
+
+type fakeQuotas struct {
+	err error
+}
+
+func (q fakeQuotas) ReserveProvision(ctx context.Context, owner string) (func(), error) {
+	return func() {}, q.err
+}
+
+func TestHandler_ApplySpecQuotas(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		quotaErr    error
+		expectedErr error
+	}{
+		{"quota exceeded", sgerrors.ErrQuotaExceeded, sgerrors.ErrQuotaExceeded},
+		{"within quota", nil, nil},
+	} {
+		svc := new(kubeServiceMock)
+		svc.On("ListAll", mock.Anything).Return([]model.Kube{}, nil)
+		accounts := new(accServiceMock)
+		accounts.On("Get", mock.Anything, "do").Return(&model.CloudAccount{
+			Provider:    clouds.DigitalOcean,
+			Credentials: map[string]string{},
+		}, nil)
+		profiles := new(mockProfileService)
+		profiles.On("Create", mock.Anything, mock.Anything).Return(nil)
+		provisioner := new(mockProvisioner)
+		provisioner.On("ProvisionCluster", mock.Anything, mock.Anything, mock.Anything).
+			Return(map[string][]*workflows.Task{}, nil)
+		h := NewHandler(svc, accounts, profiles, nil, provisioner, nil, memory.NewInMemoryRepository(), nil, "")
+		h.SetQuotas(fakeQuotas{err: tc.quotaErr})
+
+		c, err := clusterspec.Parse([]byte(testClusterSpec))
+		require.NoError(t, err)
+
+		ctx := api.WithIdentity(context.Background(), api.Identity{Login: "user"})
+		_, err = h.ApplySpec(ctx, c, false)
+		if tc.expectedErr != nil {
+			require.Equal(t, tc.expectedErr, errors.Cause(err), tc.name)
+			provisioner.AssertNotCalled(t, "ProvisionCluster", mock.Anything, mock.Anything, mock.Anything)
+			continue
+		}
+		require.NoError(t, err, tc.name)
+
+		config := provisioner.Calls[0].Arguments.Get(2).(*steps.Config)
+		require.Equal(t, "user", config.Kube.Owner, tc.name)
+	}
+}
+
+func TestHandler_importSpecReconcile(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		url            string
+		expectedStatus int
+		expectedTasks  []string
+	}{
+		{"dry run", "/kubes/import?dryRun=true", http.StatusOK, nil},
+		{"scale up", "/kubes/import", http.StatusAccepted, []string{"task-1", "task-2"}},
+	} {
+		k := specKube()
+		svc := new(kubeServiceMock)
+		svc.On("ListAll", mock.Anything).Return([]model.Kube{*k}, nil)
+		svc.On("Create", mock.Anything, mock.Anything).Return(nil)
+		accounts := new(accServiceMock)
+		accounts.On("Get", mock.Anything, "do").Return(&model.CloudAccount{
+			Provider:    clouds.DigitalOcean,
+			Credentials: map[string]string{},
+		}, nil)
+		profiles := new(mockProfileService)
+		profiles.On("Get", mock.Anything, "profile-1").Return(&profile.Profile{
+			Provider: clouds.DigitalOcean,
+		}, nil)
+		nodes := new(mockNodeProvisioner)
+		nodes.On("ProvisionNodes", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return([]string{"task-1", "task-2"}, nil)
+		h := NewHandler(svc, accounts, profiles, nodes, nil, nil, memory.NewInMemoryRepository(), nil, "")
+
+		rr := specRequest(h, http.MethodPost, tc.url, testClusterSpec)
+		require.Equal(t, tc.expectedStatus, rr.Code, tc.name)
+
+		res := &SpecResult{}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(res))
+		require.False(t, res.Created, tc.name)
+		require.Len(t, res.Changes, 1, tc.name)
+		require.Equal(t, 3, res.Changes[0].Desired, tc.name)
+		require.Equal(t, tc.expectedTasks, res.Tasks[workflows.NodeTask], tc.name)
+
+		if tc.expectedTasks != nil {
+			added := nodes.Calls[0].Arguments.Get(1).([]profile.NodeProfile)
+			require.Len(t, added, 2, tc.name)
+		}
+	}
+}
+
+func TestHandler_ApplySpecAddons(t *testing.T) {
+	k := specKube()
+	k.Addons = []string{"legacy"}
+	svc := new(kubeServiceMock)
+	svc.On("ListAll", mock.Anything).Return([]model.Kube{*k}, nil)
+	profiles := new(mockProfileService)
+	profiles.On("Get", mock.Anything, "profile-1").Return(&profile.Profile{}, nil)
+	h := NewHandler(svc, nil, profiles, nil, nil, nil, nil, nil, "")
+
+	c, err := clusterspec.Parse([]byte(testClusterSpec + "  addons:\n  - dashboard\n"))
+	require.NoError(t, err)
+
+	res, err := h.ApplySpec(context.Background(), c, true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"dashboard"}, res.Addons)
+	require.Equal(t, []string{"legacy"}, res.PendingAddons)
+	require.Empty(t, res.Tasks)
+}
+
+func TestHandler_specRevisions(t *testing.T) {
+	k := specKube()
+	svc := new(kubeServiceMock)
+	svc.On("Get", mock.Anything, "kube-1").Return(k, nil)
+	svc.On("Get", mock.Anything, mock.Anything).Return(nil, sgerrors.ErrNotFound)
+	svc.On("ListAll", mock.Anything).Return([]model.Kube{*k}, nil)
+	svc.On("Create", mock.Anything, mock.Anything).Return(nil)
+	accounts := new(accServiceMock)
+	accounts.On("Get", mock.Anything, "do").Return(&model.CloudAccount{
+		Provider:    clouds.DigitalOcean,
+		Credentials: map[string]string{},
+	}, nil)
+	profiles := new(mockProfileService)
+	profiles.On("Get", mock.Anything, "profile-1").Return(&profile.Profile{
+		Provider: clouds.DigitalOcean,
+	}, nil)
+	nodes := new(mockNodeProvisioner)
+	nodes.On("ProvisionNodes", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]string{"task-1", "task-2"}, nil)
+	h := NewHandler(svc, accounts, profiles, nodes, nil, nil, memory.NewInMemoryRepository(), nil, "")
+
+	for _, count := range []string{"count: 3", "count: 1"} {
+		c, err := clusterspec.Parse([]byte(strings.Replace(testClusterSpec, "count: 3", count, 1)))
+		require.NoError(t, err)
+		_, err = h.ApplySpec(context.Background(), c, false)
+		require.NoError(t, err)
+	}
+
+	rr := specRequest(h, http.MethodGet, "/kubes/kube-1/spec/revisions", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	revisions := []revision.Revision{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&revisions))
+	require.Len(t, revisions, 2)
+
+	rr = specRequest(h, http.MethodGet, "/kubes/kube-1/spec/diff?from=1&to=2", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	diff := &revision.Diff{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(diff))
+	require.Contains(t, diff.Patch, "+  - count: 1")
+
+	rr = specRequest(h, http.MethodPost, "/kubes/kube-1/spec/revisions/1/revert", "")
+	require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+	res := &SpecResult{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(res))
+	require.Equal(t, 3, res.Changes[0].Desired)
+
+	latest, err := h.revisions.Get(context.Background(), revision.KindSpec, "kube-1", 0)
+	require.NoError(t, err)
+	require.Equal(t, 3, latest.Number)
+	require.Equal(t, "revert to revision 1", latest.Comment)
+
+	require.Equal(t, http.StatusNotFound, specRequest(h, http.MethodGet, "/kubes/kube-1/spec/revisions/7", "").Code)
+	require.Equal(t, http.StatusNotFound, specRequest(h, http.MethodGet, "/kubes/kube-2/spec/revisions", "").Code)
+}
+
+func TestHandler_cancelAfterTasks(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewInMemoryRepository()
+	h := NewHandler(nil, nil, nil, nil, nil, nil, repo, nil, "")
+	h.taskPollInterval = time.Millisecond
+	put := func(id string, status statuses.Status) {
+		data, err := json.Marshal(workflows.Task{ID: id, Status: status})
+		require.NoError(t, err)
+		require.NoError(t, repo.Put(ctx, workflows.Prefix, id, data))
+	}
+	put("task-1", statuses.Success)
+	put("task-2", statuses.Executing)
+
+	taskCtx, cancel := context.WithCancel(ctx)
+	released := make(chan struct{})
+	go func() {
+		h.cancelAfterTasks(taskCtx, cancel, []string{"task-1", "task-2"})
+		close(released)
+	}()
+
+	select {
+	case <-released:
+		t.Fatal("context is released while a task is running")
+	case <-time.After(time.Millisecond * 50):
+	}
+	require.NoError(t, taskCtx.Err())
+
+	put("task-2", statuses.Error)
+	select {
+	case <-released:
+	case <-time.After(time.Second * 5):
+		t.Fatal("context isn't released after tasks have finished")
+	}
+	require.Equal(t, context.Canceled, taskCtx.Err())
+}
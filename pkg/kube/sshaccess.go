@@ -0,0 +1,361 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows"
+)
+
+const (
+	managedBootstrapKey = "bootstrap"
+	managedUserKey      = "user"
+)
+
+var (
+	// ErrNoSSH is returned for clusters that are managed through ssm.
+	ErrNoSSH = errors.New("cluster has no ssh access")
+
+	operatorKeyName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._@-]*$`)
+)
+
+// StartSSHKeyAudit lists keys authorized on cluster machines in background,
+// the audit is saved when all machines have been checked.
+func (h *Handler) StartSSHKeyAudit(ctx context.Context, k *model.Kube) (map[string]string, error) {
+	tasks, err := h.sshAccessTasks(ctx, k, workflows.SSHKeyAudit, "")
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		errs := h.runAtOnce(tasks)
+
+		audit := &SSHKeyAudit{
+			KubeID:    k.ID,
+			CreatedAt: time.Now(),
+			Nodes:     make([]NodeSSHKeys, 0, len(errs)),
+		}
+		managed := managedKeys(k)
+		for task, err := range errs {
+			node := NodeSSHKeys{
+				Node:   task.Config.Node.Name,
+				TaskID: task.ID,
+				Keys:   task.Config.SSHAccessConfig.Keys,
+			}
+			if err != nil {
+				node.Error = err.Error()
+			}
+			for i := range node.Keys {
+				node.Keys[i].Managed = managed[node.Keys[i].Fingerprint]
+				if node.Keys[i].Managed == "" {
+					audit.Unmanaged++
+				}
+			}
+			audit.Nodes = append(audit.Nodes, node)
+		}
+		sort.Slice(audit.Nodes, func(i, j int) bool {
+			return audit.Nodes[i].Node < audit.Nodes[j].Node
+		})
+
+		data, err := json.Marshal(audit)
+		if err == nil {
+			err = h.repo.Put(context.Background(), SSHKeyAuditStoragePrefix, k.ID, data)
+		}
+		if err != nil {
+			logrus.Errorf("save ssh key audit of cluster %s caused %v", k.ID, err)
+		}
+	}()
+
+	return mapNode2Task(tasks), nil
+}
+
+// AddOperatorKey saves the operator key of the cluster and authorizes it on
+// cluster machines in background, machines created later get it as well.
+func (h *Handler) AddOperatorKey(ctx context.Context, k *model.Kube, req OperatorKeyRequest) (*OperatorKeyTasks, error) {
+	if !operatorKeyName.MatchString(req.Name) {
+		return nil, sgerrors.WithField(errors.Wrapf(sgerrors.ErrInvalidJson, "invalid key name %q", req.Name), "name")
+	}
+	fingerprint, err := util.Fingerprint(req.PublicKey)
+	if err != nil {
+		return nil, sgerrors.WithField(errors.Wrapf(sgerrors.ErrInvalidJson, "parse public key: %v", err), "publicKey")
+	}
+	for _, key := range k.SSHConfig.OperatorKeys {
+		if key.Name == req.Name {
+			return nil, errors.Wrapf(sgerrors.ErrAlreadyExists, "operator key %s", req.Name)
+		}
+	}
+	if name := managedKeys(k)[fingerprint]; name != "" {
+		return nil, errors.Wrapf(sgerrors.ErrAlreadyExists, "public key is authorized as %s", name)
+	}
+
+	key := model.OperatorKey{
+		Name:        req.Name,
+		PublicKey:   strings.TrimSpace(req.PublicKey),
+		Fingerprint: fingerprint,
+		CreatedAt:   time.Now().Unix(),
+	}
+	if id, ok := api.IdentityFrom(ctx); ok {
+		key.AddedBy = id.Login
+	}
+
+	tasks, err := h.sshAccessTasks(ctx, k, workflows.SSHKeyAuthorize, key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	k.SSHConfig.OperatorKeys = append(k.SSHConfig.OperatorKeys, key)
+	if err = h.svc.Create(ctx, k); err != nil {
+		return nil, errors.Wrapf(err, "update kube %s", k.ID)
+	}
+
+	go h.logAccessErrors(k.ID, "authorize operator key "+key.Name, tasks)
+
+	return &OperatorKeyTasks{
+		Key:   key,
+		Tasks: mapNode2Task(tasks),
+	}, nil
+}
+
+// RemoveOperatorKey forgets the operator key and revokes it on cluster
+// machines in background.
+func (h *Handler) RemoveOperatorKey(ctx context.Context, k *model.Kube, name string) (*OperatorKeyTasks, error) {
+	keys := make([]model.OperatorKey, 0, len(k.SSHConfig.OperatorKeys))
+	var removed *model.OperatorKey
+	for i, key := range k.SSHConfig.OperatorKeys {
+		if key.Name == name {
+			removed = &k.SSHConfig.OperatorKeys[i]
+			continue
+		}
+		keys = append(keys, key)
+	}
+	if removed == nil {
+		return nil, errors.Wrapf(sgerrors.ErrNotFound, "operator key %s", name)
+	}
+
+	tasks, err := h.sshAccessTasks(ctx, k, workflows.SSHKeyRevoke, removed.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &OperatorKeyTasks{
+		Key:   *removed,
+		Tasks: mapNode2Task(tasks),
+	}
+	k.SSHConfig.OperatorKeys = keys
+	if err = h.svc.Create(ctx, k); err != nil {
+		return nil, errors.Wrapf(err, "update kube %s", k.ID)
+	}
+
+	go h.logAccessErrors(k.ID, "revoke operator key "+name, tasks)
+
+	return result, nil
+}
+
+func (h *Handler) sshAccessTasks(ctx context.Context, k *model.Kube, workflow, publicKey string) (map[string][]*workflows.Task, error) {
+	if k.State != model.StateOperational {
+		return nil, ErrNotOperational
+	}
+	if k.RunnerType == runner.SSM {
+		return nil, errors.Wrapf(ErrNoSSH, "cluster %s is managed through ssm", k.ID)
+	}
+
+	config, err := h.newKubeConfig(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+	config.SSHAccessConfig.PublicKey = publicKey
+
+	return h.makeMachineTasks(config, k, workflow), nil
+}
+
+// runAtOnce runs tasks on all machines in parallel, ssh access tasks don't
+// change machines so they aren't rolled one by one.
+func (h *Handler) runAtOnce(tasks map[string][]*workflows.Task) map[*workflows.Task]error {
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+		errs = make(map[*workflows.Task]error)
+	)
+
+	for _, taskSet := range tasks {
+		for _, task := range taskSet {
+			wg.Add(1)
+			go func(task *workflows.Task) {
+				defer wg.Done()
+
+				writer, err := h.getWriter(util.MakeFileName(task.ID))
+				if err == nil {
+					err = <-task.Run(context.Background(), *task.Config, writer)
+				}
+
+				lock.Lock()
+				errs[task] = err
+				lock.Unlock()
+			}(task)
+		}
+	}
+	wg.Wait()
+
+	return errs
+}
+
+func (h *Handler) logAccessErrors(kubeID, action string, tasks map[string][]*workflows.Task) {
+	for task, err := range h.runAtOnce(tasks) {
+		if err != nil {
+			logrus.Errorf("%s on machine %s of cluster %s caused %v", action, task.Config.Node.Name, kubeID, err)
+		}
+	}
+}
+
+// managedKeys maps fingerprints of keys known to control to their names.
+func managedKeys(k *model.Kube) map[string]string {
+	managed := make(map[string]string)
+
+	bootstrapKey := k.SSHConfig.BootstrapPublicKey
+	if bootstrapKey == "" {
+		// imported clusters may have a private key only
+		bootstrapKey, _ = util.PublicKey(k.SSHConfig.BootstrapPrivateKey)
+	}
+	for name, publicKey := range map[string]string{
+		managedBootstrapKey: bootstrapKey,
+		managedUserKey:      k.SSHConfig.PublicKey,
+	} {
+		if fingerprint, err := util.Fingerprint(publicKey); err == nil {
+			managed[fingerprint] = name
+		}
+	}
+	for _, key := range k.SSHConfig.OperatorKeys {
+		managed[key.Fingerprint] = key.Name
+	}
+
+	return managed
+}
+
+func (h *Handler) auditSSHKeys(w http.ResponseWriter, r *http.Request) {
+	k, ok := h.getKubeOrSend(w, r)
+	if !ok {
+		return
+	}
+
+	tasks, err := h.StartSSHKeyAudit(r.Context(), k)
+	if err != nil {
+		sendSSHAccessError(w, k, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	if err = json.NewEncoder(w).Encode(tasks); err != nil {
+		logrus.Errorf("Error encoding task map %v", err)
+	}
+}
+
+func (h *Handler) getSSHKeyAudit(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	data, err := h.repo.Get(r.Context(), SSHKeyAuditStoragePrefix, kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	audit := &SSHKeyAudit{}
+	if err = json.Unmarshal(data, audit); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(audit); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) listOperatorKeys(w http.ResponseWriter, r *http.Request) {
+	k, ok := h.getKubeOrSend(w, r)
+	if !ok {
+		return
+	}
+
+	keys := k.SSHConfig.OperatorKeys
+	if keys == nil {
+		keys = []model.OperatorKey{}
+	}
+	if err := json.NewEncoder(w).Encode(keys); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) addOperatorKey(w http.ResponseWriter, r *http.Request) {
+	req := OperatorKeyRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	k, ok := h.getKubeOrSend(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := h.AddOperatorKey(r.Context(), k, req)
+	if err != nil {
+		sendSSHAccessError(w, k, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	if err = json.NewEncoder(w).Encode(res); err != nil {
+		logrus.Errorf("Error encoding task map %v", err)
+	}
+}
+
+func (h *Handler) removeOperatorKey(w http.ResponseWriter, r *http.Request) {
+	k, ok := h.getKubeOrSend(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := h.RemoveOperatorKey(r.Context(), k, mux.Vars(r)["name"])
+	if err != nil {
+		sendSSHAccessError(w, k, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	if err = json.NewEncoder(w).Encode(res); err != nil {
+		logrus.Errorf("Error encoding task map %v", err)
+	}
+}
+
+func sendSSHAccessError(w http.ResponseWriter, k *model.Kube, err error) {
+	switch cause := errors.Cause(err); {
+	case cause == ErrNotOperational, cause == ErrNoSSH, cause == sgerrors.ErrInvalidJson:
+		message.SendValidationFailed(w, err)
+	case cause == sgerrors.ErrAlreadyExists:
+		message.SendAlreadyExists(w, k.ID, err)
+	case sgerrors.IsNotFound(err):
+		message.SendNotFound(w, k.ID, err)
+	default:
+		logrus.Errorf("ssh access of kube %s: %v", k.ID, err)
+		message.SendUnknownError(w, err)
+	}
+}
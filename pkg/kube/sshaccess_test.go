@@ -0,0 +1,103 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage/memory"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	testOperatorKey         = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAILEScYmxoKdpFF5UfnDKJ0X09qaicLRBnBPilo7GdUDf alice@laptop"
+	testOperatorFingerprint = "SHA256:fD2yRVYLblwj3w0zsPqkPwdnWQLlzUtFAEPbCfUnjN4"
+)
+
+// auditStep reports the operator key and a key unknown to control.
+type auditStep struct{}
+
+func (auditStep) Run(_ context.Context, _ io.Writer, cfg *steps.Config) error {
+	cfg.SSHAccessConfig.Keys = []model.AuthorizedKey{
+		{File: "/root/.ssh/authorized_keys", Fingerprint: testOperatorFingerprint},
+		{File: "/root/.ssh/authorized_keys", Fingerprint: "SHA256:" + cfg.Node.Name},
+	}
+	return nil
+}
+
+func (auditStep) Name() string                                             { return "audit" }
+func (auditStep) Description() string                                      { return "" }
+func (auditStep) Depends() []string                                        { return nil }
+func (auditStep) Rollback(context.Context, io.Writer, *steps.Config) error { return nil }
+
+func TestHandler_sshAccess(t *testing.T) {
+	workflows.Init()
+	workflows.RegisterWorkFlow(workflows.SSHKeyAuthorize, []steps.Step{auditStep{}})
+	workflows.RegisterWorkFlow(workflows.SSHKeyRevoke, []steps.Step{auditStep{}})
+	workflows.RegisterWorkFlow(workflows.SSHKeyAudit, []steps.Step{auditStep{}})
+
+	k := specKube()
+	svc := new(kubeServiceMock)
+	svc.On("Get", mock.Anything, "kube-1").Return(k, nil)
+	svc.On("Get", mock.Anything, mock.Anything).Return(nil, sgerrors.ErrNotFound)
+	svc.On("Create", mock.Anything, mock.Anything).Return(nil)
+	profiles := new(mockProfileService)
+	profiles.On("Get", mock.Anything, "profile-1").Return(&profile.Profile{}, nil)
+	h := NewHandler(svc, nil, profiles, nil, nil, nil, memory.NewInMemoryRepository(), nil, "")
+	h.getWriter = func(string) (io.WriteCloser, error) {
+		return &bufferCloser{}, nil
+	}
+
+	for _, tc := range []struct {
+		method string
+		url    string
+		body   string
+		code   int
+	}{
+		{http.MethodPost, "/kubes/kube-1/ssh/operatorkeys", `{"name":"alice","publicKey":"ssh-rsa broken"}`, http.StatusBadRequest},
+		{http.MethodPost, "/kubes/kube-1/ssh/operatorkeys", `{"name":"../alice","publicKey":"` + testOperatorKey + `"}`, http.StatusBadRequest},
+		{http.MethodPost, "/kubes/kube-1/ssh/operatorkeys", `{"name":"alice","publicKey":"` + testOperatorKey + `"}`, http.StatusAccepted},
+		{http.MethodPost, "/kubes/kube-1/ssh/operatorkeys", `{"name":"bob","publicKey":"` + testOperatorKey + `"}`, http.StatusConflict},
+		{http.MethodDelete, "/kubes/kube-1/ssh/operatorkeys/bob", "", http.StatusNotFound},
+		{http.MethodGet, "/kubes/kube-1/ssh/audit", "", http.StatusNotFound},
+		{http.MethodPost, "/kubes/kube-1/ssh/audit", "", http.StatusAccepted},
+		{http.MethodPost, "/kubes/unknown/ssh/audit", "", http.StatusNotFound},
+	} {
+		rr := specRequest(h, tc.method, tc.url, tc.body)
+		require.Equal(t, tc.code, rr.Code, tc.method+" "+tc.url+" "+rr.Body.String())
+	}
+
+	require.Len(t, k.SSHConfig.OperatorKeys, 1)
+	require.Equal(t, testOperatorFingerprint, k.SSHConfig.OperatorKeys[0].Fingerprint)
+
+	audit := &SSHKeyAudit{}
+	for i := 0; i < 100; i++ {
+		rr := specRequest(h, http.MethodGet, "/kubes/kube-1/ssh/audit", "")
+		if rr.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(audit))
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Len(t, audit.Nodes, 2)
+	require.Equal(t, "master-1", audit.Nodes[0].Node)
+	require.Equal(t, "alice", audit.Nodes[0].Keys[0].Managed)
+	require.Equal(t, 2, audit.Unmanaged)
+
+	rr := specRequest(h, http.MethodDelete, "/kubes/kube-1/ssh/operatorkeys/alice", "")
+	require.Equal(t, http.StatusAccepted, rr.Code)
+	res := &OperatorKeyTasks{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(res))
+	require.Len(t, res.Tasks, 2)
+	require.Empty(t, k.SSHConfig.OperatorKeys)
+}
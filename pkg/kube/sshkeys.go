@@ -0,0 +1,143 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func (h *Handler) rotateSSHKey(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if k.RunnerType == runner.SSM {
+		message.SendValidationFailed(w, errors.Errorf("cluster %s is managed through ssm and has no ssh keys", k.ID))
+		return
+	}
+
+	rotationTasks, err := h.StartSSHKeyRotation(r.Context(), k)
+	if err != nil {
+		if errors.Cause(err) == ErrNotOperational {
+			w.WriteHeader(http.StatusNoContent)
+			logrus.Infof("Cluster %s is not operational", k.ID)
+			return
+		}
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, k.ProfileID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(rotationTasks); err != nil {
+		logrus.Errorf("Error encoding task map %v", err)
+	}
+}
+
+// StartSSHKeyRotation replaces the bootstrap ssh key of cluster machines
+// with a newly generated one in background.
+func (h *Handler) StartSSHKeyRotation(ctx context.Context, k *model.Kube) (*SSHKeyRotationTasks, error) {
+	if k.State != model.StateOperational {
+		return nil, ErrNotOperational
+	}
+
+	config, err := h.newKubeConfig(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+
+	oldPublicKey := k.SSHConfig.BootstrapPublicKey
+	if oldPublicKey == "" {
+		// imported clusters may have a private key only
+		oldPublicKey, err = util.PublicKey(config.Kube.SSHConfig.BootstrapPrivateKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "get current public key")
+		}
+	}
+
+	privateKey, publicKey, err := util.NewKeyPair()
+	if err != nil {
+		return nil, errors.Wrap(err, "generate ssh key")
+	}
+
+	config.SSHKeyRotationConfig = steps.SSHKeyRotationConfig{
+		PrivateKey:   privateKey,
+		PublicKey:    publicKey,
+		OldPublicKey: oldPublicKey,
+	}
+
+	if k.Provider == clouds.AWS {
+		acc, err := h.accountService.Get(ctx, k.AccountName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get cloud account %s", k.AccountName)
+		}
+
+		if err := util.FillCloudAccountCredentials(acc, config); err != nil {
+			return nil, errors.Wrap(err, "fill cloud account credentials")
+		}
+	}
+
+	// the old key is removed over connections made with the new one
+	removeConfig := *config
+	removeConfig.Kube.SSHConfig.BootstrapPrivateKey = privateKey
+	removeConfig.Kube.SSHConfig.BootstrapPublicKey = publicKey
+
+	addTasks := h.makeMachineTasks(config, k, workflows.SSHKeyAdd)
+	removeTasks := h.makeMachineTasks(&removeConfig, k, workflows.SSHKeyRemove)
+
+	result := &SSHKeyRotationTasks{
+		Add:    mapNode2Task(addTasks),
+		Remove: mapNode2Task(removeTasks),
+	}
+
+	if k.Provider == clouds.AWS {
+		importTask, err := workflows.NewTask(config, workflows.SSHKeyPairImport, h.repo)
+		if err != nil {
+			return nil, errors.Wrap(err, "create key pair import task")
+		}
+		addTasks[workflows.ClusterTask] = []*workflows.Task{importTask}
+		result.ImportKeyPair = importTask.ID
+
+		if removeConfig.AWSConfig.KeyPairName != "" {
+			deleteTask, err := workflows.NewTask(&removeConfig, workflows.SSHKeyPairDelete, h.repo)
+			if err != nil {
+				return nil, errors.Wrap(err, "create key pair delete task")
+			}
+			removeTasks[workflows.ClusterTask] = []*workflows.Task{deleteTask}
+			result.DeleteKeyPair = deleteTask.ID
+		}
+	}
+
+	go func() {
+		if err := h.kubeProvisioner.RotateSSHKey(context.Background(), k, addTasks, removeTasks, config); err != nil {
+			logrus.Errorf("rotate ssh key of cluster %s caused %v", k.ID, err)
+		}
+	}()
+
+	return result, nil
+}
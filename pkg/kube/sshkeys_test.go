@@ -0,0 +1,175 @@
+package kube
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestHandler_rotateSSHKey(t *testing.T) {
+	workflows.Init()
+	workflows.RegisterWorkFlow(workflows.SSHKeyAdd, []steps.Step{})
+	workflows.RegisterWorkFlow(workflows.SSHKeyRemove, []steps.Step{})
+	workflows.RegisterWorkFlow(workflows.SSHKeyPairImport, []steps.Step{})
+	workflows.RegisterWorkFlow(workflows.SSHKeyPairDelete, []steps.Step{})
+
+	operational := func(provider clouds.Name, publicKey string) *model.Kube {
+		return &model.Kube{
+			ID:          "kube",
+			State:       model.StateOperational,
+			Provider:    provider,
+			AccountName: "account",
+			SSHConfig: model.SSHConfig{
+				BootstrapPrivateKey: "old private",
+				BootstrapPublicKey:  publicKey,
+			},
+			Masters: map[string]*model.Machine{
+				"master": {Name: "master", Role: model.RoleMaster},
+			},
+			Nodes: map[string]*model.Machine{
+				"node": {Name: "node", Role: model.RoleNode},
+			},
+			CloudSpec: map[string]string{
+				clouds.AwsKeyPairName:            "old-keypair",
+				clouds.AwsSshBootstrapPrivateKey: "old private",
+			},
+		}
+	}
+
+	tcs := []struct {
+		serviceKube  *model.Kube
+		serviceError error
+
+		expectedStatus  int
+		expectKeyPair   bool
+		expectRotation  bool
+		expectedErrCode sgerrors.ErrorCode
+	}{
+		{ // TC#1
+			serviceError:    sgerrors.ErrNotFound,
+			expectedStatus:  http.StatusNotFound,
+			expectedErrCode: sgerrors.NotFound,
+		},
+		{ // TC#2
+			serviceKube: &model.Kube{
+				ID:    "kube",
+				State: model.StateProvisioning,
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{ // TC#3
+			serviceKube:     operational(clouds.DigitalOcean, ""),
+			expectedStatus:  http.StatusInternalServerError,
+			expectedErrCode: sgerrors.UnknownError,
+		},
+		{ // TC#4
+			serviceKube:    operational(clouds.DigitalOcean, "ssh-rsa AAAAold"),
+			expectedStatus: http.StatusAccepted,
+			expectRotation: true,
+		},
+		{ // TC#5
+			serviceKube:    operational(clouds.AWS, "ssh-rsa AAAAold"),
+			expectedStatus: http.StatusAccepted,
+			expectKeyPair:  true,
+			expectRotation: true,
+		},
+	}
+
+	for i, tc := range tcs {
+		svc := new(kubeServiceMock)
+		svc.On(serviceGet, mock.Anything, mock.Anything).Return(tc.serviceKube, tc.serviceError)
+
+		kubeProfile := &profile.Profile{}
+		if tc.serviceKube != nil {
+			kubeProfile.Provider = tc.serviceKube.Provider
+		}
+		profileSvc := new(mockProfileService)
+		profileSvc.On("Get", mock.Anything, mock.Anything).Return(kubeProfile, nil)
+
+		accService := new(accServiceMock)
+		accService.On("Get", mock.Anything, mock.Anything).Return(&model.CloudAccount{
+			Provider:    clouds.AWS,
+			Credentials: map[string]string{},
+		}, nil)
+
+		repo := new(testutils.MockStorage)
+		repo.On(testutils.StoragePut, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+		rotated := make(chan mock.Arguments, 1)
+		provisioner := new(mockProvisioner)
+		provisioner.On("RotateSSHKey", mock.Anything, mock.Anything,
+			mock.Anything, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { rotated <- args }).
+			Return(nil)
+
+		h := NewHandler(svc, accService, profileSvc, nil, provisioner, nil, repo, nil, "")
+
+		req, err := http.NewRequest(http.MethodPost, "/kubes/kube/ssh/rotate", nil)
+		require.Equalf(t, nil, err, "TC#%d: create request: %v", i+1, err)
+		rr := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		h.Register(router)
+		router.ServeHTTP(rr, req)
+
+		require.Equalf(t, tc.expectedStatus, rr.Code, "TC#%d %s", i+1, rr.Body.String())
+
+		if tc.expectedErrCode != sgerrors.ErrorCode(0) {
+			m := new(message.Message)
+			err = json.NewDecoder(rr.Body).Decode(m)
+			require.Equalf(t, nil, err, "TC#%d", i+1)
+			require.Equalf(t, tc.expectedErrCode, m.ErrorCode, "TC#%d", i+1)
+			continue
+		}
+
+		if !tc.expectRotation {
+			continue
+		}
+
+		resp := SSHKeyRotationTasks{}
+		err = json.NewDecoder(rr.Body).Decode(&resp)
+		require.Equalf(t, nil, err, "TC#%d", i+1)
+		require.Lenf(t, resp.Add, 2, "TC#%d", i+1)
+		require.Lenf(t, resp.Remove, 2, "TC#%d", i+1)
+		require.Equalf(t, tc.expectKeyPair, resp.ImportKeyPair != "", "TC#%d", i+1)
+		require.Equalf(t, tc.expectKeyPair, resp.DeleteKeyPair != "", "TC#%d", i+1)
+
+		var args mock.Arguments
+		select {
+		case args = <-rotated:
+		case <-time.After(time.Second):
+			t.Fatalf("TC#%d: rotation has not been started", i+1)
+		}
+
+		config := args.Get(4).(*steps.Config)
+		rotation := config.SSHKeyRotationConfig
+		require.Equalf(t, "ssh-rsa AAAAold", rotation.OldPublicKey, "TC#%d", i+1)
+		require.NotEmptyf(t, rotation.PrivateKey, "TC#%d", i+1)
+		require.Equalf(t, "old private", config.Kube.SSHConfig.BootstrapPrivateKey,
+			"TC#%d: new key must be added over the old connection", i+1)
+
+		removeTasks := args.Get(3).(map[string][]*workflows.Task)
+		for _, task := range removeTasks[workflows.MasterTask] {
+			require.Equalf(t, rotation.PrivateKey, task.Config.Kube.SSHConfig.BootstrapPrivateKey,
+				"TC#%d: old key must be removed over the new connection", i+1)
+		}
+		for _, task := range removeTasks[workflows.ClusterTask] {
+			require.Equalf(t, "old-keypair", task.Config.AWSConfig.KeyPairName, "TC#%d", i+1)
+		}
+	}
+}
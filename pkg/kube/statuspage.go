@@ -0,0 +1,316 @@
+package kube
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/settings"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/timeline"
+	"github.com/supergiant/control/pkg/workflows/statuses"
+)
+
+// incidentsPeriod is how far back the status page reports incidents.
+const incidentsPeriod = time.Hour * 24 * 7
+
+// Status summarizes the cluster for its status page.
+type Status string
+
+const (
+	StatusOperational  Status = "operational"
+	StatusDegraded     Status = "degraded"
+	StatusMaintenance  Status = "maintenance"
+	StatusProvisioning Status = "provisioning"
+	StatusDown         Status = "down"
+)
+
+// incidentTypes are timeline events the status page reports.
+var incidentTypes = map[timeline.Type]bool{
+	timeline.ClusterFailed:          true,
+	timeline.NodeFailed:             true,
+	timeline.NodeProblem:            true,
+	timeline.UpgradeCanaryFailed:    true,
+	timeline.UpgradeFailed:          true,
+	timeline.ControlPlaneRolledBack: true,
+	timeline.RepairStarted:          true,
+}
+
+// StatusPageToken is returned when the status page is enabled, the token
+// isn't shown again.
+type StatusPageToken struct {
+	Token string `json:"token"`
+	// Path of the page, the token is passed in the token parameter or as
+	// a bearer token.
+	Path string `json:"path"`
+}
+
+// ClusterStatus is the public status of the cluster, it's built from the
+// state saved by control and doesn't reach the cluster.
+type ClusterStatus struct {
+	Name        string            `json:"name"`
+	Status      Status            `json:"status"`
+	K8SVersion  string            `json:"k8sVersion"`
+	Nodes       NodesStatus       `json:"nodes"`
+	Maintenance MaintenanceStatus `json:"maintenance"`
+	Incidents   []Incident        `json:"incidents"`
+	UpdatedAt   time.Time         `json:"updatedAt"`
+}
+
+// NodesStatus counts machines of the cluster, problems are conditions
+// reported by node problem checks.
+type NodesStatus struct {
+	Total    int           `json:"total"`
+	Ready    int           `json:"ready"`
+	Problems []NodeProblem `json:"problems"`
+}
+
+type NodeProblem struct {
+	Node   string `json:"node"`
+	Type   string `json:"type"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// MaintenanceStatus tells whether the maintenance window is open and which
+// tasks run on the cluster.
+type MaintenanceStatus struct {
+	InWindow bool `json:"inWindow"`
+	// NextWindow is set when a window is configured and it's closed.
+	NextWindow *time.Time        `json:"nextWindow,omitempty"`
+	Tasks      []MaintenanceTask `json:"tasks"`
+}
+
+type MaintenanceTask struct {
+	Type      string     `json:"type"`
+	StartedAt time.Time  `json:"startedAt"`
+	Percent   int        `json:"percent"`
+	ETA       *time.Time `json:"eta,omitempty"`
+}
+
+// Incident is a failure of the cluster from its timeline.
+type Incident struct {
+	Type    timeline.Type `json:"type"`
+	Message string        `json:"message"`
+	Time    time.Time     `json:"time"`
+}
+
+// RegisterPublic registers routes that don't need control accounts.
+func (h *Handler) RegisterPublic(r *mux.Router) {
+	r.HandleFunc("/status/{kubeID}", h.feature(settings.FeatureStatusPages, h.getStatusPage)).Methods(http.MethodGet)
+}
+
+// enableStatusPage enables the status page of the cluster with a new token,
+// the previous token stops working.
+func (h *Handler) enableStatusPage(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	b := make([]byte, 24)
+	if _, err = rand.Read(b); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+	token := hex.EncodeToString(b)
+
+	k.StatusPage = model.StatusPage{
+		Enabled:   true,
+		TokenHash: hashToken(token),
+	}
+	if err = h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	resp := StatusPageToken{
+		Token: token,
+		Path:  "/status/" + k.ID,
+	}
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) disableStatusPage(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	k.StatusPage = model.StatusPage{}
+	if err = h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getStatusPage returns the status of the cluster to requests with the token
+// of its page. Clusters without the page and wrong tokens look the same, so
+// IDs of clusters can't be probed.
+func (h *Handler) getStatusPage(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	token := r.URL.Query().Get("token")
+	if auth := r.Header.Get("Authorization"); token == "" && strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil && !sgerrors.IsNotFound(err) {
+		message.SendUnknownError(w, err)
+		return
+	}
+	if err != nil || !k.StatusPage.Enabled || token == "" ||
+		subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(k.StatusPage.TokenHash)) != 1 {
+		message.SendNotFound(w, kubeID, sgerrors.ErrNotFound)
+		return
+	}
+
+	status, err := h.clusterStatus(r.Context(), k, time.Now())
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	if err = json.NewEncoder(w).Encode(status); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) clusterStatus(ctx context.Context, k *model.Kube, now time.Time) (*ClusterStatus, error) {
+	status := &ClusterStatus{
+		Name:       k.Name,
+		K8SVersion: k.K8SVersion,
+		Nodes: NodesStatus{
+			Problems: make([]NodeProblem, 0),
+		},
+		Maintenance: MaintenanceStatus{
+			InWindow: k.Maintenance.Contains(now),
+			Tasks:    make([]MaintenanceTask, 0),
+		},
+		Incidents: make([]Incident, 0),
+		UpdatedAt: now,
+	}
+	if !status.Maintenance.InWindow {
+		if next := k.Maintenance.Next(now); !next.IsZero() {
+			status.Maintenance.NextWindow = &next
+		}
+	}
+
+	for _, machines := range []map[string]*model.Machine{k.Masters, k.Nodes} {
+		for _, m := range machines {
+			status.Nodes.Total++
+			if m.State == model.MachineStateActive && len(m.Conditions) == 0 {
+				status.Nodes.Ready++
+			}
+			for _, c := range m.Conditions {
+				status.Nodes.Problems = append(status.Nodes.Problems, NodeProblem{
+					Node:   m.Name,
+					Type:   c.Type,
+					Reason: c.Reason,
+				})
+			}
+		}
+	}
+	sort.Slice(status.Nodes.Problems, func(i, j int) bool {
+		a, b := status.Nodes.Problems[i], status.Nodes.Problems[j]
+		if a.Node != b.Node {
+			return a.Node < b.Node
+		}
+		return a.Type < b.Type
+	})
+
+	tasks, err := h.getKubeTasks(ctx, k.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get tasks")
+	}
+	for _, t := range tasks {
+		if t.Status != statuses.Executing {
+			continue
+		}
+		status.Maintenance.Tasks = append(status.Maintenance.Tasks, MaintenanceTask{
+			Type:      t.Type,
+			StartedAt: t.CreatedAt,
+			Percent:   t.Progress.Percent,
+			ETA:       t.Progress.ETA,
+		})
+	}
+	sort.Slice(status.Maintenance.Tasks, func(i, j int) bool {
+		return status.Maintenance.Tasks[i].StartedAt.Before(status.Maintenance.Tasks[j].StartedAt)
+	})
+
+	events, err := h.timeline.List(ctx, k.ID, now.Add(-incidentsPeriod), time.Time{})
+	if err != nil {
+		// the rest of the status is still useful
+		logrus.Warnf("kubes: %s cluster: list incidents: %v", k.ID, err)
+	}
+	for i := len(events) - 1; i >= 0; i-- {
+		if e := events[i]; incidentTypes[e.Type] {
+			status.Incidents = append(status.Incidents, Incident{
+				Type:    e.Type,
+				Message: e.Message,
+				Time:    e.Time,
+			})
+		}
+	}
+
+	status.Status = summarize(k, status)
+	return status, nil
+}
+
+// summarize returns the status of the cluster, running tasks of operational
+// clusters are maintenance.
+func summarize(k *model.Kube, status *ClusterStatus) Status {
+	switch k.State {
+	case model.StateProvisioning, model.StateImporting:
+		return StatusProvisioning
+	case model.StateFailed, model.StateDeleting:
+		return StatusDown
+	case model.StateUpgrading:
+		return StatusMaintenance
+	}
+
+	switch {
+	case len(status.Maintenance.Tasks) > 0:
+		return StatusMaintenance
+	case status.Nodes.Ready < status.Nodes.Total:
+		return StatusDegraded
+	}
+	return StatusOperational
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
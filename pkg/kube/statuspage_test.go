@@ -0,0 +1,154 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/settings"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage/memory"
+	"github.com/supergiant/control/pkg/timeline"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/statuses"
+)
+
+func TestHandler_statusPage(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewInMemoryRepository()
+
+	k := specKube()
+	k.K8SVersion = "1.14.1"
+	k.Masters["master-1"].State = model.MachineStateActive
+	k.Nodes["node-1"].State = model.MachineStateActive
+	k.Tasks[workflows.Upgrade] = []string{"task-1"}
+	svc := new(kubeServiceMock)
+	svc.On("Get", mock.Anything, "kube-1").Return(k, nil)
+	svc.On("Get", mock.Anything, mock.Anything).Return(nil, sgerrors.ErrNotFound)
+	svc.On("Create", mock.Anything, mock.Anything).Return(nil)
+	h := NewHandler(svc, nil, nil, nil, nil, nil, repo, nil, "")
+
+	router := mux.NewRouter()
+	h.Register(router)
+	h.RegisterPublic(router)
+	do := func(method, url string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, url, nil))
+		return rr
+	}
+
+	// the page is off by default
+	require.Equal(t, http.StatusNotFound, do(http.MethodGet, "/status/kube-1?token=guess").Code)
+
+	rr := do(http.MethodPost, "/kubes/kube-1/statuspage")
+	require.Equal(t, http.StatusOK, rr.Code)
+	page := StatusPageToken{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&page))
+	require.Equal(t, "/status/kube-1", page.Path)
+	require.True(t, k.StatusPage.Enabled)
+	require.NotContains(t, k.StatusPage.TokenHash, page.Token)
+
+	require.Equal(t, http.StatusNotFound, do(http.MethodGet, "/status/kube-1?token=guess").Code)
+	require.Equal(t, http.StatusNotFound, do(http.MethodGet, "/status/kube-2?token="+page.Token).Code)
+
+	status := ClusterStatus{}
+	rr = do(http.MethodGet, page.Path+"?token="+page.Token)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&status))
+	require.Equal(t, StatusOperational, status.Status)
+	require.Equal(t, NodesStatus{Total: 2, Ready: 2, Problems: []NodeProblem{}}, status.Nodes)
+	require.True(t, status.Maintenance.InWindow)
+
+	req := httptest.NewRequest(http.MethodGet, page.Path, nil)
+	req.Header.Set("Authorization", "Bearer "+page.Token)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// a problem of a node, a running upgrade and an incident
+	k.Nodes["node-1"].Conditions = []model.NodeCondition{{Type: "KernelDeadlock", Reason: "DockerHung"}}
+	task, err := json.Marshal(workflows.Task{ID: "task-1", Type: workflows.Upgrade, Status: statuses.Executing,
+		Progress: workflows.Progress{Percent: 40}})
+	require.NoError(t, err)
+	require.NoError(t, repo.Put(ctx, workflows.Prefix, "task-1", task))
+	require.NoError(t, h.timeline.Record(ctx, &timeline.Event{KubeID: "kube-1", Type: timeline.NodeAdded}))
+	require.NoError(t, h.timeline.Record(ctx, &timeline.Event{KubeID: "kube-1", Type: timeline.NodeFailed, Message: "node node-2 has failed"}))
+
+	report, err := h.clusterStatus(ctx, k, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, StatusMaintenance, report.Status)
+	require.Equal(t, 1, report.Nodes.Ready)
+	require.Equal(t, []NodeProblem{{Node: "node-1", Type: "KernelDeadlock", Reason: "DockerHung"}}, report.Nodes.Problems)
+	require.Len(t, report.Maintenance.Tasks, 1)
+	require.Equal(t, 40, report.Maintenance.Tasks[0].Percent)
+	require.Len(t, report.Incidents, 1)
+	require.Equal(t, "node node-2 has failed", report.Incidents[0].Message)
+
+	require.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/kubes/kube-1/statuspage").Code)
+	require.False(t, k.StatusPage.Enabled)
+	require.Equal(t, http.StatusNotFound, do(http.MethodGet, page.Path+"?token="+page.Token).Code)
+}
+
+func TestSummarize(t *testing.T) {
+	for _, tc := range []struct {
+		state    model.KubeState
+		status   ClusterStatus
+		expected Status
+	}{
+		{model.StateProvisioning, ClusterStatus{}, StatusProvisioning},
+		{model.StateFailed, ClusterStatus{}, StatusDown},
+		{model.StateUpgrading, ClusterStatus{}, StatusMaintenance},
+		{model.StateOperational, ClusterStatus{Nodes: NodesStatus{Total: 3, Ready: 2}}, StatusDegraded},
+		{model.StateOperational, ClusterStatus{Nodes: NodesStatus{Total: 3, Ready: 3}}, StatusOperational},
+	} {
+		require.Equal(t, tc.expected, summarize(&model.Kube{State: tc.state}, &tc.status), string(tc.state))
+	}
+}
+
+func TestHandler_statusPageFeature(t *testing.T) {
+	k := specKube()
+	svc := new(kubeServiceMock)
+	svc.On("Get", mock.Anything, "kube-1").Return(k, nil)
+	svc.On("Create", mock.Anything, mock.Anything).Return(nil)
+	h := NewHandler(svc, nil, nil, nil, nil, nil, memory.NewInMemoryRepository(), nil, "")
+
+	flags := settings.NewManager(memory.NewInMemoryRepository(), settings.Settings{
+		SpawnInterval: "5s",
+		SSHRetry:      settings.Retry{Attempts: 1},
+		Features:      map[string]bool{settings.FeatureStatusPages: true},
+	})
+	h.SetFeatureFlags(flags.Enabled)
+
+	router := mux.NewRouter()
+	h.Register(router)
+	h.RegisterPublic(router)
+	do := func(method, url string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, url, nil))
+		return rr
+	}
+
+	rr := do(http.MethodPost, "/kubes/kube-1/statuspage")
+	require.Equal(t, http.StatusOK, rr.Code)
+	page := StatusPageToken{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&page))
+	require.Equal(t, http.StatusOK, do(http.MethodGet, page.Path+"?token="+page.Token).Code)
+
+	s := flags.Get()
+	s.Features[settings.FeatureStatusPages] = false
+	require.NoError(t, flags.Update(context.Background(), s))
+	require.Equal(t, http.StatusNotFound, do(http.MethodGet, page.Path+"?token="+page.Token).Code)
+	require.Equal(t, http.StatusNotFound, do(http.MethodPost, "/kubes/kube-1/statuspage").Code)
+
+	s.Features[settings.FeatureStatusPages] = true
+	require.NoError(t, flags.Update(context.Background(), s))
+	require.Equal(t, http.StatusOK, do(http.MethodGet, page.Path+"?token="+page.Token).Code)
+}
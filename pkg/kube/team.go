@@ -0,0 +1,104 @@
+package kube
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/labels"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// ScopeMiddleware hides kubes of other teams from requests to routes of
+// kubes, they are not found for users that aren't members of their teams.
+func (h *Handler) ScopeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		kubeID, ok := mux.Vars(r)["kubeID"]
+		scope := api.ScopeFrom(r.Context())
+		if !ok || scope.Admin {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// handlers respond to errors
+		k, err := h.svc.Get(r.Context(), kubeID)
+		if err == nil && !scope.AllowsLabeled(k.Team, k.Labels) {
+			message.SendNotFound(w, kubeID, sgerrors.ErrNotFound)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setTeam moves the kube to another team.
+func (h *Handler) setTeam(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+	req := &api.TeamRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	k.Team = req.Team
+	if err = h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(req); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// setLabels replaces labels of the kube.
+func (h *Handler) setLabels(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+	req := &api.LabelsRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+	if err := labels.Validate(req.Labels); err != nil {
+		message.SendValidationFailed(w, sgerrors.WithField(err, "labels"))
+		return
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+	// labels grant access to other teams, members of other teams can't change them
+	if !api.ScopeFrom(r.Context()).Allows(k.Team) {
+		http.Error(w, "labels are changed by members of the team", http.StatusForbidden)
+		return
+	}
+
+	k.Labels = req.Labels
+	if err = h.svc.Create(r.Context(), k); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(req); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
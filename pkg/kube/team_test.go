@@ -0,0 +1,153 @@
+package kube
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/clusterspec"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
+	"github.com/supergiant/control/pkg/storage/memory"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func teamRequest(h *Handler, scope api.Scope, method, url, body string) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	router.Use(h.ScopeMiddleware)
+	h.Register(router)
+
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	req = req.WithContext(api.WithScope(req.Context(), scope))
+	if scope.Admin {
+		req = req.WithContext(api.WithIdentity(req.Context(), api.Identity{Login: "root"}))
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestHandler_ScopeMiddleware(t *testing.T) {
+	k := specKube()
+	k.Team = "b"
+	svc := new(kubeServiceMock)
+	svc.On("Get", mock.Anything, "kube-1").Return(k, nil)
+	h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+	rr := teamRequest(h, api.Scope{Teams: []string{"a"}}, http.MethodGet, "/kubes/kube-1", "")
+	require.Equal(t, http.StatusNotFound, rr.Code)
+	rr = teamRequest(h, api.Scope{Teams: []string{"a", "b"}}, http.MethodGet, "/kubes/kube-1", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	rr = teamRequest(h, api.Scope{Admin: true}, http.MethodGet, "/kubes/kube-1", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestHandler_listKubesScope(t *testing.T) {
+	svc := new(kubeServiceMock)
+	svc.On(serviceList, mock.Anything, mock.MatchedBy(func(opts storage.ListOptions) bool {
+		return opts.Filter != nil && opts.Filter([]byte(`{"team":"a"}`)) && !opts.Filter([]byte(`{"team":"b"}`))
+	})).Return([]model.Kube{}, "", nil)
+	h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+	rr := teamRequest(h, api.Scope{Teams: []string{"a"}}, http.MethodGet, "/kubes", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestHandler_setTeam(t *testing.T) {
+	k := specKube()
+	svc := new(kubeServiceMock)
+	svc.On("Get", mock.Anything, "kube-1").Return(k, nil)
+	svc.On("Create", mock.Anything, mock.Anything).Return(nil)
+	h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+	rr := teamRequest(h, api.Scope{Teams: []string{"a"}}, http.MethodPut, "/kubes/kube-1/team", `{"team":"a"}`)
+	require.Equal(t, http.StatusForbidden, rr.Code)
+	svc.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+
+	rr = teamRequest(h, api.Scope{Admin: true}, http.MethodPut, "/kubes/kube-1/team", `{"team":"a"}`)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, "a", k.Team)
+}
+
+func TestHandler_visibleKubes(t *testing.T) {
+	svc := new(kubeServiceMock)
+	svc.On("ListAll", mock.Anything).Return([]model.Kube{
+		{ID: "kube-a", Team: "a"},
+		{ID: "kube-b", Team: "b"},
+		{ID: "kube-c", Team: "c", Labels: map[string]string{"env": "dev"}},
+		{ID: "kube-shared"},
+	}, nil)
+	h := NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, "")
+
+	ids := func(scope api.Scope) []string {
+		kubes, err := h.visibleKubes(api.WithScope(context.Background(), scope))
+		require.NoError(t, err)
+		ids := make([]string, 0, len(kubes))
+		for _, k := range kubes {
+			ids = append(ids, k.ID)
+		}
+		return ids
+	}
+
+	require.Equal(t, []string{"kube-a", "kube-shared"}, ids(api.Scope{Teams: []string{"a"}}))
+	require.Equal(t, []string{"kube-a", "kube-c", "kube-shared"},
+		ids(api.Scope{Teams: []string{"a"}, Selectors: []map[string]string{{"env": "dev"}}}))
+	require.Len(t, ids(api.Scope{Admin: true}), 4)
+}
+
+func TestHandler_ApplySpecScope(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		accountTeam string
+		expectedErr error
+	}{
+		{"other team account", "b", sgerrors.ErrNotFound},
+		{"team account", "a", nil},
+	} {
+		// the cluster with the name of the spec belongs to another team
+		k := specKube()
+		k.Team = "b"
+		svc := new(kubeServiceMock)
+		svc.On("ListAll", mock.Anything).Return([]model.Kube{*k}, nil)
+		accounts := new(accServiceMock)
+		accounts.On("Get", mock.Anything, "do").Return(&model.CloudAccount{
+			Provider:    clouds.DigitalOcean,
+			Team:        tc.accountTeam,
+			Credentials: map[string]string{},
+		}, nil)
+		profiles := new(mockProfileService)
+		profiles.On("Create", mock.Anything, mock.Anything).Return(nil)
+		provisioner := new(mockProvisioner)
+		provisioner.On("ProvisionCluster", mock.Anything, mock.Anything, mock.Anything).
+			Return(map[string][]*workflows.Task{}, nil)
+		h := NewHandler(svc, accounts, profiles, nil, provisioner, nil, memory.NewInMemoryRepository(), nil, "")
+
+		c, err := clusterspec.Parse([]byte(testClusterSpec))
+		require.NoError(t, err)
+
+		ctx := api.WithScope(context.Background(), api.Scope{Teams: []string{"a"}})
+		res, err := h.ApplySpec(ctx, c, false)
+		if tc.expectedErr != nil {
+			require.Equal(t, tc.expectedErr, errors.Cause(err), tc.name)
+			provisioner.AssertNotCalled(t, "ProvisionCluster", mock.Anything, mock.Anything, mock.Anything)
+			continue
+		}
+		require.NoError(t, err, tc.name)
+		require.True(t, res.Created, tc.name)
+
+		config := provisioner.Calls[0].Arguments.Get(2).(*steps.Config)
+		require.Equal(t, "a", config.Kube.Team, tc.name)
+	}
+}
@@ -0,0 +1,356 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/rest"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/runner/ssh"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/terminal"
+	"github.com/supergiant/control/pkg/user"
+)
+
+const (
+	TerminalNode = "node"
+	TerminalPod  = "pod"
+
+	defaultTerminalCols = 80
+	defaultTerminalRows = 24
+)
+
+var terminalUpgrader = websocket.Upgrader{
+	HandshakeTimeout: time.Second * 10,
+	// TODO: check origin when the UI is served from a known address
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// openNodeTerminal opens a shell on the machine over ssh with the cluster
+// key, so operators don't need to download it. The session is recorded.
+func (h *Handler) openNodeTerminal(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+	nodeName := vars["nodename"]
+
+	id, ok := api.IdentityFrom(r.Context())
+	if !ok {
+		http.Error(w, "unknown user", http.StatusForbidden)
+		return
+	}
+	// a node shell gives root access to the cluster
+	if identityRole(id) != user.RoleAdmin {
+		http.Error(w, "node terminal is allowed for admins only", http.StatusForbidden)
+		return
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	m := k.Masters[nodeName]
+	if m == nil {
+		m = k.Nodes[nodeName]
+	}
+	if m == nil {
+		message.SendNotFound(w, nodeName, sgerrors.ErrNotFound)
+		return
+	}
+	if m.OperatingSystem == model.OSWindows {
+		http.Error(w, "terminal is not supported for windows machines", http.StatusNotImplemented)
+		return
+	}
+
+	size := terminalSize(r)
+	stream, err := h.nodeTerminal(r.Context(), k, m, size)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	h.serveTerminal(w, r, &TerminalSession{
+		KubeID: kubeID,
+		User:   id.Login,
+		Kind:   TerminalNode,
+		Target: m.Name,
+	}, stream, size)
+}
+
+// openPodTerminal runs a shell in the pod container with permissions of the user role.
+func (h *Handler) openPodTerminal(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+	namespace := vars["namespace"]
+	pod := vars["pod"]
+	container := r.URL.Query().Get("container")
+
+	command := r.URL.Query()["command"]
+	if len(command) == 0 {
+		command = []string{"/bin/sh"}
+	}
+
+	id, ok := api.IdentityFrom(r.Context())
+	if !ok {
+		http.Error(w, "unknown user", http.StatusForbidden)
+		return
+	}
+
+	k, err := h.svc.Get(r.Context(), kubeID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, kubeID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	cfg, err := h.impersonatedConfig(k, id)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	size := terminalSize(r)
+	stream, err := h.podTerminal(cfg, namespace, pod, container, command, size)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	target := namespace + "/" + pod
+	if container != "" {
+		target += "/" + container
+	}
+	h.serveTerminal(w, r, &TerminalSession{
+		KubeID: kubeID,
+		User:   id.Login,
+		Kind:   TerminalPod,
+		Target: target,
+	}, stream, size)
+}
+
+func (h *Handler) serveTerminal(w http.ResponseWriter, r *http.Request, s *TerminalSession, stream terminal.Stream, size terminal.Size) {
+	defer stream.Close()
+
+	s.ID = uuid.New()
+	s.StartedAt = time.Now().UTC()
+
+	f, err := h.getWriter(terminalRecordingName(s.ID))
+	if err != nil {
+		message.SendUnknownError(w, errors.Wrap(err, "create recording"))
+		return
+	}
+	rec, err := terminal.NewRecorder(f, size, s.Kind+" "+s.Target)
+	if err != nil {
+		f.Close()
+		message.SendUnknownError(w, errors.Wrap(err, "create recording"))
+		return
+	}
+	defer rec.Close()
+
+	if err := h.saveTerminalSession(r.Context(), s); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	conn, err := terminalUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logrus.Errorf("kubes: %s cluster: terminal %s: upgrade connection: %v", s.KubeID, s.ID, err)
+		return
+	}
+	defer conn.Close()
+
+	logrus.Infof("kubes: %s cluster: %s opened terminal %s to %s %s", s.KubeID, s.User, s.ID, s.Kind, s.Target)
+	if err := terminal.Serve(conn, stream, rec); err != nil {
+		logrus.Errorf("kubes: %s cluster: terminal %s: %v", s.KubeID, s.ID, err)
+	}
+	if err := rec.Close(); err != nil {
+		logrus.Errorf("kubes: %s cluster: terminal %s: write recording: %v", s.KubeID, s.ID, err)
+	}
+
+	s.EndedAt = time.Now().UTC()
+	// the request context is done when the client disconnects
+	if err := h.saveTerminalSession(context.Background(), s); err != nil {
+		logrus.Errorf("kubes: %s cluster: terminal %s: %v", s.KubeID, s.ID, err)
+	}
+}
+
+// listTerminalSessions returns recorded sessions, users see their own sessions only.
+func (h *Handler) listTerminalSessions(w http.ResponseWriter, r *http.Request) {
+	kubeID := mux.Vars(r)["kubeID"]
+
+	id, ok := api.IdentityFrom(r.Context())
+	if !ok {
+		http.Error(w, "unknown user", http.StatusForbidden)
+		return
+	}
+
+	data, err := h.repo.GetAll(r.Context(), terminalsPrefix(kubeID))
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	sessions := make([]*TerminalSession, 0, len(data))
+	for _, raw := range data {
+		s := &TerminalSession{}
+		if err := json.Unmarshal(raw, s); err != nil {
+			message.SendUnknownError(w, err)
+			return
+		}
+
+		if identityRole(id) == user.RoleAdmin || s.User == id.Login {
+			sessions = append(sessions, s)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartedAt.Before(sessions[j].StartedAt)
+	})
+
+	if err = json.NewEncoder(w).Encode(sessions); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// getTerminalRecording returns the session recording in the asciicast v2 format.
+func (h *Handler) getTerminalRecording(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kubeID := vars["kubeID"]
+	sessionID := vars["sessionID"]
+
+	id, ok := api.IdentityFrom(r.Context())
+	if !ok {
+		http.Error(w, "unknown user", http.StatusForbidden)
+		return
+	}
+
+	raw, err := h.repo.Get(r.Context(), terminalsPrefix(kubeID), sessionID)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, sessionID, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	s := &TerminalSession{}
+	if err := json.Unmarshal(raw, s); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if identityRole(id) != user.RoleAdmin && s.User != id.Login {
+		http.Error(w, "recordings of another user are not allowed", http.StatusForbidden)
+		return
+	}
+
+	f, err := h.openRecording(terminalRecordingName(s.ID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			message.SendNotFound(w, sessionID, sgerrors.ErrNotFound)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", "application/x-asciicast")
+	if _, err := io.Copy(w, f); err != nil {
+		logrus.Errorf("kubes: %s cluster: send recording %s: %v", kubeID, sessionID, err)
+	}
+}
+
+func (h *Handler) saveTerminalSession(ctx context.Context, s *TerminalSession) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return errors.Wrap(err, "marshal")
+	}
+
+	return h.repo.Put(ctx, terminalsPrefix(s.KubeID), s.ID, data)
+}
+
+func terminalsPrefix(kubeID string) string {
+	return TerminalStoragePrefix + kubeID + "/"
+}
+
+func terminalRecordingName(sessionID string) string {
+	return "terminal-" + sessionID + ".cast"
+}
+
+func terminalSize(r *http.Request) terminal.Size {
+	size := terminal.Size{
+		Cols: defaultTerminalCols,
+		Rows: defaultTerminalRows,
+	}
+	if cols, err := strconv.Atoi(r.URL.Query().Get("cols")); err == nil && cols > 0 {
+		size.Cols = cols
+	}
+	if rows, err := strconv.Atoi(r.URL.Query().Get("rows")); err == nil && rows > 0 {
+		size.Rows = rows
+	}
+
+	return size
+}
+
+func nodeTerminal(ctx context.Context, k *model.Kube, m *model.Machine, size terminal.Size) (terminal.Stream, error) {
+	sshConfig := k.SSHConfig.For(*m)
+	r, err := ssh.NewRunner(ssh.Config{
+		Host:    m.PublicIp,
+		Port:    sshConfig.Port,
+		User:    sshConfig.User,
+		Timeout: sshConfig.Timeout,
+		Key:     []byte(sshConfig.BootstrapPrivateKey),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "setup runner")
+	}
+
+	t, err := r.(*ssh.Runner).Terminal(ctx, size.Cols, size.Rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func podTerminal(cfg *rest.Config, namespace, pod, container string, command []string, size terminal.Size) (terminal.Stream, error) {
+	s, err := terminal.Exec(cfg, namespace, pod, container, command, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func openRecording(logDir string) func(string) (io.ReadCloser, error) {
+	return func(name string) (io.ReadCloser, error) {
+		return os.Open(path.Join(logDir, name))
+	}
+}
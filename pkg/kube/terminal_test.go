@@ -0,0 +1,154 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/terminal"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/user"
+)
+
+type echoStream struct {
+	r *io.PipeReader
+	w *io.PipeWriter
+}
+
+func newEchoStream() *echoStream {
+	r, w := io.Pipe()
+	return &echoStream{r: r, w: w}
+}
+
+func (s *echoStream) Read(p []byte) (int, error) { return s.r.Read(p) }
+
+func (s *echoStream) Write(p []byte) (int, error) {
+	go s.w.Write(p)
+	return len(p), nil
+}
+
+func (s *echoStream) Resize(int, int) error { return nil }
+
+func (s *echoStream) Close() error { return s.w.Close() }
+
+func terminalServer(h *Handler, identity api.Identity) *httptest.Server {
+	router := mux.NewRouter()
+	h.Register(router)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		router.ServeHTTP(w, r.WithContext(api.WithIdentity(r.Context(), identity)))
+	}))
+}
+
+func TestHandler_openNodeTerminal(t *testing.T) {
+	svc := new(kubeServiceMock)
+	svc.On(serviceGet, mock.Anything, "kube").Return(&model.Kube{
+		ID: "kube",
+		Nodes: map[string]*model.Machine{
+			"node-1": {Name: "node-1", PublicIp: "10.0.0.1"},
+			"win-1":  {Name: "win-1", OperatingSystem: model.OSWindows},
+		},
+	}, nil)
+
+	sessions := make(chan TerminalSession, 2)
+	repo := new(testutils.MockStorage)
+	repo.On(testutils.StoragePut, mock.Anything, terminalsPrefix("kube"), mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			s := TerminalSession{}
+			json.Unmarshal(args.Get(3).([]byte), &s)
+			sessions <- s
+		}).Return(nil)
+
+	h := NewHandler(svc, nil, nil, nil, nil, nil, repo, nil, "")
+	recording := &bufferCloser{}
+	h.getWriter = func(name string) (io.WriteCloser, error) {
+		return recording, nil
+	}
+	h.nodeTerminal = func(_ context.Context, _ *model.Kube, m *model.Machine, size terminal.Size) (terminal.Stream, error) {
+		require.Equal(t, "10.0.0.1", m.PublicIp)
+		require.Equal(t, terminal.Size{Cols: 120, Rows: defaultTerminalRows}, size)
+		return newEchoStream(), nil
+	}
+
+	viewer := terminalServer(h, api.Identity{Login: "bob", Role: user.RoleView})
+	defer viewer.Close()
+	resp, err := http.Get(viewer.URL + "/kubes/kube/machines/node-1/terminal")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	admin := terminalServer(h, api.Identity{Login: "alice", Role: user.RoleAdmin})
+	defer admin.Close()
+	for name, status := range map[string]int{"node-2": http.StatusNotFound, "win-1": http.StatusNotImplemented} {
+		resp, err := http.Get(admin.URL + "/kubes/kube/machines/" + name + "/terminal")
+		require.NoError(t, err)
+		require.Equal(t, status, resp.StatusCode, name)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(
+		"ws"+strings.TrimPrefix(admin.URL, "http")+"/kubes/kube/machines/node-1/terminal?cols=120", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	started := <-sessions
+	require.Equal(t, "alice", started.User)
+	require.Equal(t, TerminalNode, started.Kind)
+	require.Equal(t, "node-1", started.Target)
+	require.True(t, started.EndedAt.IsZero())
+
+	require.NoError(t, conn.WriteJSON(terminal.Message{Type: terminal.MessageInput, Data: "hostname"}))
+	_, out, err := conn.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, "hostname", string(out))
+
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+
+	ended := <-sessions
+	require.Equal(t, started.ID, ended.ID)
+	require.False(t, ended.EndedAt.IsZero())
+	require.Contains(t, recording.String(), `"o","hostname"`)
+}
+
+func TestHandler_getTerminalRecording(t *testing.T) {
+	session, err := json.Marshal(TerminalSession{ID: "s1", KubeID: "kube", User: "alice"})
+	require.NoError(t, err)
+
+	repo := new(testutils.MockStorage)
+	repo.On(testutils.StorageGet, mock.Anything, terminalsPrefix("kube"), "s1").Return(session, nil)
+
+	h := NewHandler(nil, nil, nil, nil, nil, nil, repo, nil, "")
+	h.openRecording = func(name string) (io.ReadCloser, error) {
+		require.Equal(t, "terminal-s1.cast", name)
+		return ioutil.NopCloser(strings.NewReader("{\"version\":2}\n")), nil
+	}
+
+	for _, tc := range []struct {
+		identity api.Identity
+		status   int
+	}{
+		{api.Identity{Login: "alice", Role: user.RoleView}, http.StatusOK},
+		{api.Identity{Login: "bob", Role: user.RoleAdmin}, http.StatusOK},
+		{api.Identity{Login: "bob", Role: user.RoleView}, http.StatusForbidden},
+	} {
+		srv := terminalServer(h, tc.identity)
+		resp, err := http.Get(srv.URL + "/kubes/kube/terminals/s1/recording")
+		srv.Close()
+		require.NoError(t, err)
+		require.Equal(t, tc.status, resp.StatusCode, tc.identity.Login)
+
+		if tc.status == http.StatusOK {
+			require.Equal(t, "application/x-asciicast", resp.Header.Get("Content-Type"))
+		}
+	}
+}
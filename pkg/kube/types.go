@@ -1,5 +1,14 @@
 package kube
 
+import (
+	"time"
+
+	"github.com/supergiant/control/pkg/clusterspec"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/pricing"
+	"github.com/supergiant/control/pkg/workflows/statuses"
+)
+
 type ReleaseInput struct {
 	Name         string `json:"name"`
 	Namespace    string `json:"namespace"`
@@ -8,3 +17,337 @@ type ReleaseInput struct {
 	RepoName     string `json:"repoName" valid:"required"`
 	Values       string `json:"values"`
 }
+
+// RuntimeUpgradeInput holds a container runtime version to upgrade to.
+type RuntimeUpgradeInput struct {
+	Version string `json:"version" valid:"required"`
+}
+
+// CertificateExpiry describes a cluster certificate nearing expiration.
+type CertificateExpiry struct {
+	KubeID   string    `json:"kubeId"`
+	KubeName string    `json:"kubeName"`
+	Name     string    `json:"name"`
+	Machine  string    `json:"machine,omitempty"`
+	Subject  string    `json:"subject"`
+	NotAfter time.Time `json:"notAfter"`
+	DaysLeft int       `json:"daysLeft"`
+	Expired  bool      `json:"expired"`
+}
+
+// NodeMetrics is a resource usage of the node reported by metrics-server
+// and its allocatable capacity, cpu is in millicores and memory is in bytes.
+type NodeMetrics struct {
+	Name           string    `json:"name"`
+	CPUUsage       int64     `json:"cpuUsage"`
+	CPUCapacity    int64     `json:"cpuCapacity"`
+	CPUPercent     float64   `json:"cpuPercent"`
+	MemoryUsage    int64     `json:"memoryUsage"`
+	MemoryCapacity int64     `json:"memoryCapacity"`
+	MemoryPercent  float64   `json:"memoryPercent"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// PodMetrics is a resource usage of the pod with requests and limits
+// of its containers summed up.
+type PodMetrics struct {
+	Namespace     string    `json:"namespace"`
+	Name          string    `json:"name"`
+	Node          string    `json:"node,omitempty"`
+	CPUUsage      int64     `json:"cpuUsage"`
+	CPURequest    int64     `json:"cpuRequest"`
+	CPULimit      int64     `json:"cpuLimit"`
+	MemoryUsage   int64     `json:"memoryUsage"`
+	MemoryRequest int64     `json:"memoryRequest"`
+	MemoryLimit   int64     `json:"memoryLimit"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// ClusterCapacity sums usage and allocatable capacity of cluster nodes.
+type ClusterCapacity struct {
+	KubeID         string  `json:"kubeId,omitempty"`
+	KubeName       string  `json:"kubeName,omitempty"`
+	Nodes          int     `json:"nodes"`
+	CPUUsage       int64   `json:"cpuUsage"`
+	CPUCapacity    int64   `json:"cpuCapacity"`
+	CPUPercent     float64 `json:"cpuPercent"`
+	MemoryUsage    int64   `json:"memoryUsage"`
+	MemoryCapacity int64   `json:"memoryCapacity"`
+	MemoryPercent  float64 `json:"memoryPercent"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// CapacityReport is a capacity of every cluster and their total.
+type CapacityReport struct {
+	Total    ClusterCapacity   `json:"total"`
+	Clusters []ClusterCapacity `json:"clusters"`
+}
+
+// CapacityPlan is requested, limited and used resources of a node group
+// against its allocatable capacity, cpu is in millicores and memory is in
+// bytes. Plans without a role and a size sum up whole clusters.
+type CapacityPlan struct {
+	KubeID   string     `json:"kubeId,omitempty"`
+	KubeName string     `json:"kubeName,omitempty"`
+	Role     model.Role `json:"role,omitempty"`
+	Size     string     `json:"size,omitempty"`
+	Nodes    int        `json:"nodes"`
+
+	CPUAllocatable int64 `json:"cpuAllocatable"`
+	CPURequests    int64 `json:"cpuRequests"`
+	CPULimits      int64 `json:"cpuLimits"`
+	CPUUsage       int64 `json:"cpuUsage"`
+	// CPUHeadroom is allocatable cpu that isn't requested by pods.
+	CPUHeadroom        int64   `json:"cpuHeadroom"`
+	CPURequestsPercent float64 `json:"cpuRequestsPercent"`
+	CPULimitsPercent   float64 `json:"cpuLimitsPercent"`
+
+	MemoryAllocatable     int64   `json:"memoryAllocatable"`
+	MemoryRequests        int64   `json:"memoryRequests"`
+	MemoryLimits          int64   `json:"memoryLimits"`
+	MemoryUsage           int64   `json:"memoryUsage"`
+	MemoryHeadroom        int64   `json:"memoryHeadroom"`
+	MemoryRequestsPercent float64 `json:"memoryRequestsPercent"`
+	MemoryLimitsPercent   float64 `json:"memoryLimitsPercent"`
+
+	// Overcommitted groups have limits over their allocatable cpu or memory,
+	// their pods are throttled or evicted when they use them up.
+	Overcommitted bool   `json:"overcommitted"`
+	Error         string `json:"error,omitempty"`
+}
+
+// CapacityPlanReport is a capacity plan of every selected cluster, of their
+// node groups and their total.
+type CapacityPlanReport struct {
+	GeneratedAt time.Time      `json:"generatedAt"`
+	Total       CapacityPlan   `json:"total"`
+	Clusters    []CapacityPlan `json:"clusters"`
+	Groups      []CapacityPlan `json:"groups"`
+}
+
+// ComponentVersion is the version of a component control manages against
+// its desired version, node components are reported for every node.
+type ComponentVersion struct {
+	KubeID   string `json:"kubeId"`
+	KubeName string `json:"kubeName"`
+	// Node is empty for components of the cluster, e.g. the CNI or addons.
+	Node      string `json:"node,omitempty"`
+	Component string `json:"component"`
+	Desired   string `json:"desired"`
+	Actual    string `json:"actual"`
+	// Straggler components have missed an upgrade.
+	Straggler bool `json:"straggler"`
+}
+
+// ClusterVersions are versions of components of a cluster.
+type ClusterVersions struct {
+	KubeID   string `json:"kubeId"`
+	KubeName string `json:"kubeName"`
+	Version  string `json:"version"`
+	// Target is the patch version the catalog upgrades the cluster to, the
+	// version of its release channel or the latest patch of its minor.
+	Target     string             `json:"target,omitempty"`
+	Components []ComponentVersion `json:"components"`
+	Stragglers int                `json:"stragglers"`
+	Error      string             `json:"error,omitempty"`
+}
+
+// VersionDriftReport compares versions of components of every selected
+// cluster against the catalog.
+type VersionDriftReport struct {
+	GeneratedAt time.Time         `json:"generatedAt"`
+	Clusters    []ClusterVersions `json:"clusters"`
+	// Stragglers are components of all clusters that have missed an upgrade.
+	Stragglers []ComponentVersion `json:"stragglers"`
+}
+
+// BudgetStatus is a budget of the cluster with the projected spend.
+type BudgetStatus struct {
+	model.Budget
+	Projection *pricing.Projection `json:"projection,omitempty"`
+	Exceeded   bool                `json:"exceeded"`
+}
+
+// BudgetAlert is sent to the budget webhook when the projected
+// spend exceeds the budget.
+type BudgetAlert struct {
+	KubeID      string  `json:"kubeId"`
+	KubeName    string  `json:"kubeName"`
+	Month       string  `json:"month"`
+	Budget      float64 `json:"budget"`
+	MonthToDate float64 `json:"monthToDate"`
+	Projected   float64 `json:"projected"`
+	Currency    string  `json:"currency"`
+}
+
+// UtilizationSample is a usage of node group resources at a moment,
+// nodes of the same role and size make up a group.
+type UtilizationSample struct {
+	Time          int64      `json:"time"`
+	Role          model.Role `json:"role"`
+	Size          string     `json:"size"`
+	Nodes         int        `json:"nodes"`
+	CPUPercent    float64    `json:"cpuPercent"`
+	MemoryPercent float64    `json:"memoryPercent"`
+}
+
+// Recommendation suggests downsizing of an underutilized node group.
+type Recommendation struct {
+	Role             model.Role `json:"role"`
+	Size             string     `json:"size"`
+	Nodes            int        `json:"nodes"`
+	Samples          int        `json:"samples"`
+	CPUPercent       float64    `json:"cpuPercent"`
+	MemoryPercent    float64    `json:"memoryPercent"`
+	Action           string     `json:"action"`
+	RecommendedNodes int        `json:"recommendedNodes"`
+	MonthlySavings   float64    `json:"monthlySavings"`
+	Reason           string     `json:"reason"`
+}
+
+// SSHKeyRotationTasks maps machine names to tasks of both ssh key rotation phases.
+type SSHKeyRotationTasks struct {
+	Add           map[string]string `json:"add"`
+	Remove        map[string]string `json:"remove"`
+	ImportKeyPair string            `json:"importKeyPair,omitempty"`
+	DeleteKeyPair string            `json:"deleteKeyPair,omitempty"`
+}
+
+// OperatorKeyRequest authorizes a public key of an operator on machines of
+// a cluster.
+type OperatorKeyRequest struct {
+	Name      string `json:"name"`
+	PublicKey string `json:"publicKey"`
+}
+
+// OperatorKeyTasks maps machine names to tasks that authorize or revoke
+// the operator key.
+type OperatorKeyTasks struct {
+	Key   model.OperatorKey `json:"key"`
+	Tasks map[string]string `json:"tasks"`
+}
+
+// SSHKeyAudit lists keys authorized on machines of a cluster.
+type SSHKeyAudit struct {
+	KubeID    string        `json:"kubeId"`
+	CreatedAt time.Time     `json:"createdAt"`
+	Nodes     []NodeSSHKeys `json:"nodes"`
+	// Unmanaged is the number of keys that aren't known to control.
+	Unmanaged int `json:"unmanaged"`
+}
+
+type NodeSSHKeys struct {
+	Node   string                `json:"node"`
+	TaskID string                `json:"taskId"`
+	Keys   []model.AuthorizedKey `json:"keys"`
+	Error  string                `json:"error,omitempty"`
+}
+
+// KubeconfigCredential is a short-lived client certificate issued to a control user.
+type KubeconfigCredential struct {
+	ID        string    `json:"id"`
+	KubeID    string    `json:"kubeId"`
+	User      string    `json:"user"`
+	Role      string    `json:"role"`
+	Serial    string    `json:"serial"`
+	IssuedAt  time.Time `json:"issuedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Revoked   bool      `json:"revoked"`
+}
+
+// TerminalSession is a recorded web terminal session of a control user.
+type TerminalSession struct {
+	ID     string `json:"id"`
+	KubeID string `json:"kubeId"`
+	User   string `json:"user"`
+	// Kind is a node or a pod, Target is a machine name or namespace/pod/container
+	Kind      string    `json:"kind"`
+	Target    string    `json:"target"`
+	StartedAt time.Time `json:"startedAt"`
+	EndedAt   time.Time `json:"endedAt,omitempty"`
+}
+
+// ComplianceReport is a result of the kube-bench run against a cluster.
+type ComplianceReport struct {
+	KubeID    string          `json:"kubeId"`
+	TaskID    string          `json:"taskId"`
+	Status    statuses.Status `json:"status"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Error     string          `json:"error,omitempty"`
+	// Report is a raw kube-bench json output
+	Report string `json:"report"`
+}
+
+// SpecResult describes how a cluster spec has been applied, Changes are node
+// groups being scaled up and Addons are being installed. Pending ones have to
+// be changed manually, e.g. masters, machines or addons to be removed.
+type SpecResult struct {
+	ClusterID     string               `json:"clusterId,omitempty"`
+	Created       bool                 `json:"created"`
+	Changes       []clusterspec.Change `json:"changes,omitempty"`
+	Pending       []clusterspec.Change `json:"pending,omitempty"`
+	Addons        []string             `json:"addons,omitempty"`
+	PendingAddons []string             `json:"pendingAddons,omitempty"`
+	Tasks         map[string][]string  `json:"tasks,omitempty"`
+}
+
+// DriftItem is a difference between the cluster kept by control and its
+// actual cloud resources or kubernetes nodes.
+type DriftItem struct {
+	Kind     string `json:"kind"`
+	Resource string `json:"resource"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+	// Remediable items are fixed in the auto remediate mode.
+	Remediable bool `json:"remediable"`
+	Remediated bool `json:"remediated,omitempty"`
+}
+
+// DriftReport is a result of the drift check of a cluster.
+type DriftReport struct {
+	KubeID    string      `json:"kubeId"`
+	CheckedAt time.Time   `json:"checkedAt"`
+	Drifted   bool        `json:"drifted"`
+	Items     []DriftItem `json:"items"`
+	// Errors are checks or remediations that have failed.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// FirewallRuleState is a firewall rule added through the API.
+type FirewallRuleState struct {
+	model.FirewallRule
+	// Applied is false when the rule is missing in the security group,
+	// e.g. it has been removed in the cloud console.
+	Applied bool `json:"applied"`
+}
+
+// FirewallRules are ingress rules of security groups of a cluster.
+type FirewallRules struct {
+	Rules []FirewallRuleState `json:"rules"`
+	// Other are cidr rules that haven't been added through the API, e.g.
+	// rules created during provisioning or in the cloud console.
+	Other []model.FirewallRule `json:"other"`
+}
+
+// InventoryItem is a machine that control, the cloud provider and kubernetes
+// don't agree on.
+type InventoryItem struct {
+	// Name is a machine or node name, an instance id if the instance has no name.
+	Name       string     `json:"name"`
+	Status     string     `json:"status"`
+	Role       model.Role `json:"role,omitempty"`
+	InstanceID string     `json:"instanceId,omitempty"`
+	Reason     string     `json:"reason"`
+	// Actions resolve the item, they are adopt or cleanup.
+	Actions  []string `json:"actions"`
+	Resolved string   `json:"resolved,omitempty"`
+}
+
+// InventoryReport is a result of the reconciliation of cluster machines.
+type InventoryReport struct {
+	KubeID    string          `json:"kubeId"`
+	CheckedAt time.Time       `json:"checkedAt"`
+	Items     []InventoryItem `json:"items"`
+	// Errors are cloud or kubernetes lookups that have failed.
+	Errors []string `json:"errors,omitempty"`
+}
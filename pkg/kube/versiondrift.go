@@ -0,0 +1,335 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/labels"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/storage"
+	"github.com/supergiant/control/pkg/versions"
+)
+
+// Components of nodes, versions of other components are named by the catalog.
+const (
+	ComponentKubernetes = "kubernetes"
+	ComponentKubelet    = "kubelet"
+	ComponentKubeProxy  = "kube-proxy"
+)
+
+// componentWorkload is a kube-system workload that runs a component of the
+// catalog, names of workloads match by prefix, e.g. kube-flannel-ds-amd64.
+type componentWorkload struct {
+	resource string
+	name     string
+}
+
+var componentWorkloads = map[string]componentWorkload{
+	"Calico":  {"daemonsets", "calico-node"},
+	"Flannel": {"daemonsets", "kube-flannel-ds"},
+	"Weave":   {"daemonsets", "weave-net"},
+	"coredns": {"deployments", "coredns"},
+	"helm":    {"deployments", "tiller-deploy"},
+}
+
+// getVersionDrift reports versions of kubelets, container runtimes, the CNI
+// and addons of clusters selected by labels against versions of the catalog,
+// stragglers are listed separately.
+func (h *Handler) getVersionDrift(w http.ResponseWriter, r *http.Request) {
+	opts, err := storage.ParseListOptions(r.URL.Query())
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	kubes, err := h.svc.ListAll(r.Context())
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	scope := api.ScopeFrom(r.Context())
+	selected := make([]model.Kube, 0, len(kubes))
+	for _, k := range kubes {
+		if scope.AllowsLabeled(k.Team, k.Labels) && labels.Matches(k.Labels, opts.Labels) {
+			selected = append(selected, k)
+		}
+	}
+
+	report := h.versionDrift(r.Context(), selected, versions.Default())
+	if err = json.NewEncoder(w).Encode(report); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// versionDrift collects versions of operational clusters, clusters that
+// can't be reached are reported with the error.
+func (h *Handler) versionDrift(ctx context.Context, kubes []model.Kube, catalog *versions.Catalog) *VersionDriftReport {
+	var (
+		m      sync.Mutex
+		report = &VersionDriftReport{
+			GeneratedAt: time.Now(),
+			Clusters:    make([]ClusterVersions, 0),
+			Stragglers:  make([]ComponentVersion, 0),
+		}
+	)
+	eachOperationalKube(kubes, func(k *model.Kube) {
+		cluster := h.clusterVersions(ctx, k, catalog)
+
+		m.Lock()
+		report.Clusters = append(report.Clusters, cluster)
+		for _, c := range cluster.Components {
+			if c.Straggler {
+				report.Stragglers = append(report.Stragglers, c)
+			}
+		}
+		m.Unlock()
+	})
+
+	sort.Slice(report.Clusters, func(i, j int) bool {
+		return report.Clusters[i].KubeName < report.Clusters[j].KubeName
+	})
+	sort.SliceStable(report.Stragglers, func(i, j int) bool {
+		a, b := report.Stragglers[i], report.Stragglers[j]
+		if a.KubeName != b.KubeName {
+			return a.KubeName < b.KubeName
+		}
+		if a.Node != b.Node {
+			return a.Node < b.Node
+		}
+		return a.Component < b.Component
+	})
+
+	return report
+}
+
+func (h *Handler) clusterVersions(ctx context.Context, k *model.Kube, catalog *versions.Catalog) ClusterVersions {
+	cluster := ClusterVersions{
+		KubeID:     k.ID,
+		KubeName:   k.Name,
+		Version:    k.K8SVersion,
+		Components: make([]ComponentVersion, 0),
+	}
+	component := func(node, name, desired, actual string, straggler bool) {
+		cluster.Components = append(cluster.Components, ComponentVersion{
+			KubeID:    k.ID,
+			KubeName:  k.Name,
+			Node:      node,
+			Component: name,
+			Desired:   desired,
+			Actual:    actual,
+			Straggler: straggler,
+		})
+		if straggler {
+			cluster.Stragglers++
+		}
+	}
+
+	// node versions are compared with the cluster version even if the
+	// catalog doesn't have its release anymore
+	release, err := catalog.Release(k.K8SVersion)
+	if err != nil {
+		cluster.Error = err.Error()
+	} else {
+		cluster.Target = release.Latest()
+		if k.Channel.Name != "" {
+			if target, err := catalog.ChannelVersion(k.Channel.Name); err == nil {
+				cluster.Target = target
+			}
+		}
+		component("", ComponentKubernetes, cluster.Target, k.K8SVersion,
+			!versionMatches(k.K8SVersion, cluster.Target))
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := h.listKubeResources(ctx, k, "nodes", ResourceListOptions{}, nodes); err != nil {
+		cluster.Error = err.Error()
+		return cluster
+	}
+	sort.Slice(nodes.Items, func(i, j int) bool {
+		return nodes.Items[i].Name < nodes.Items[j].Name
+	})
+	for _, node := range nodes.Items {
+		info := node.Status.NodeInfo
+		component(node.Name, ComponentKubelet, k.K8SVersion, info.KubeletVersion,
+			!versionMatches(info.KubeletVersion, k.K8SVersion))
+		if info.KubeProxyVersion != "" {
+			component(node.Name, ComponentKubeProxy, k.K8SVersion, info.KubeProxyVersion,
+				!versionMatches(info.KubeProxyVersion, k.K8SVersion))
+		}
+
+		runtime, version := splitRuntimeVersion(info.ContainerRuntimeVersion)
+		if desired := desiredRuntimes(k, release, runtime); len(desired) > 0 {
+			component(node.Name, runtime, strings.Join(desired, ", "), version,
+				!anyVersionMatches(version, desired))
+		}
+	}
+
+	if release == nil {
+		return cluster
+	}
+
+	images, err := h.systemWorkloadImages(ctx, k)
+	if err != nil {
+		cluster.Error = err.Error()
+		return cluster
+	}
+
+	cni := k.Networking.Provider
+	if cni == "" {
+		cni = k.Networking.Manager
+	}
+	desired := map[string]string{}
+	if v, ok := release.CNI[cni]; ok {
+		desired[cni] = v
+	}
+	for addon, v := range release.Addons {
+		desired[addon] = v
+	}
+
+	names := make([]string, 0, len(desired))
+	for name := range desired {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		workload, ok := componentWorkloads[name]
+		if !ok {
+			continue
+		}
+		actual, ok := images[workload]
+		if !ok {
+			// the component isn't installed
+			continue
+		}
+		component("", name, desired[name], actual, !versionMatches(actual, desired[name]))
+	}
+
+	return cluster
+}
+
+// systemWorkloadImages returns image tags of the first containers of
+// kube-system workloads that run components of the catalog.
+func (h *Handler) systemWorkloadImages(ctx context.Context, k *model.Kube) (map[componentWorkload]string, error) {
+	opts := ResourceListOptions{Namespace: metav1.NamespaceSystem}
+	daemonSets := &appsv1.DaemonSetList{}
+	if err := h.listKubeResources(ctx, k, "daemonsets", opts, daemonSets); err != nil {
+		return nil, err
+	}
+	deployments := &appsv1.DeploymentList{}
+	if err := h.listKubeResources(ctx, k, "deployments", opts, deployments); err != nil {
+		return nil, err
+	}
+
+	images := make(map[componentWorkload]string)
+	add := func(resource string, meta metav1.ObjectMeta, spec corev1.PodSpec) {
+		if len(spec.Containers) == 0 {
+			return
+		}
+		for _, workload := range componentWorkloads {
+			if workload.resource == resource && strings.HasPrefix(meta.Name, workload.name) {
+				images[workload] = imageTag(spec.Containers[0].Image)
+			}
+		}
+	}
+	for _, ds := range daemonSets.Items {
+		add("daemonsets", ds.ObjectMeta, ds.Spec.Template.Spec)
+	}
+	for _, d := range deployments.Items {
+		add("deployments", d.ObjectMeta, d.Spec.Template.Spec)
+	}
+	return images, nil
+}
+
+// desiredRuntimes returns the docker version of the cluster or versions of
+// the runtime compatible with the release.
+func desiredRuntimes(k *model.Kube, release *versions.Release, runtime string) []string {
+	if runtime == versions.Docker && k.DockerVersion != "" {
+		return []string{k.DockerVersion}
+	}
+	if release == nil {
+		return nil
+	}
+	return release.Runtimes[runtime]
+}
+
+// splitRuntimeVersion splits the runtime version of the node info, e.g.
+// docker://18.6.1.
+func splitRuntimeVersion(v string) (string, string) {
+	parts := strings.SplitN(v, "://", 2)
+	if len(parts) != 2 {
+		return "", v
+	}
+	return parts[0], parts[1]
+}
+
+func imageTag(image string) string {
+	if i := strings.LastIndex(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
+	}
+	return "latest"
+}
+
+func anyVersionMatches(actual string, desired []string) bool {
+	for _, d := range desired {
+		if versionMatches(actual, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// versionMatches reports whether the actual version is the desired one or
+// one of its patches. Numbers are compared without leading zeros, the v
+// prefix and suffixes, e.g. v18.6.1-ce matches 18.06.
+func versionMatches(actual, desired string) bool {
+	a, d := versionNumbers(actual), versionNumbers(desired)
+	if a == nil || d == nil {
+		return actual == desired
+	}
+	if len(a) < len(d) {
+		return false
+	}
+	for i := range d {
+		if a[i] != d[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func versionNumbers(v string) []int {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	if v == "" {
+		return nil
+	}
+
+	parts := strings.Split(v, ".")
+	numbers := make([]int, 0, len(parts))
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers
+}
@@ -0,0 +1,87 @@
+package kube
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/supergiant/control/pkg/model"
+)
+
+const (
+	versionDriftNodes = `{"items":[
+{"metadata":{"name":"node-1"},"status":{"nodeInfo":{"kubeletVersion":"v1.14.3","kubeProxyVersion":"v1.14.3","containerRuntimeVersion":"docker://18.9.7"}}},
+{"metadata":{"name":"node-2"},"status":{"nodeInfo":{"kubeletVersion":"v1.14.1","kubeProxyVersion":"v1.14.1","containerRuntimeVersion":"docker://17.3.2"}}}
+]}`
+	versionDriftDaemonSets = `{"items":[
+{"metadata":{"name":"calico-node"},"spec":{"template":{"spec":{"containers":[{"name":"calico-node","image":"calico/node:v3.7.2"}]}}}}
+]}`
+	versionDriftDeployments = `{"items":[
+{"metadata":{"name":"coredns"},"spec":{"template":{"spec":{"containers":[{"name":"coredns","image":"k8s.gcr.io/coredns:1.2.6"}]}}}}
+]}`
+)
+
+func TestHandler_getVersionDrift(t *testing.T) {
+	svc := new(kubeServiceMock)
+	svc.On("ListAll", mock.Anything).Return([]model.Kube{
+		{
+			ID: "a", Name: "alpha", State: model.StateOperational, K8SVersion: "1.14.3",
+			Networking: model.Networking{Provider: "Calico"},
+		},
+		{ID: "b", Name: "beta", State: model.StateProvisioning, K8SVersion: "1.13.7"},
+	}, nil)
+	system := ResourceListOptions{Namespace: metav1.NamespaceSystem}
+	svc.On("ListResources", mock.Anything, "a", "nodes", ResourceListOptions{}).
+		Return([]byte(versionDriftNodes), nil)
+	svc.On("ListResources", mock.Anything, "a", "daemonsets", system).
+		Return([]byte(versionDriftDaemonSets), nil)
+	svc.On("ListResources", mock.Anything, "a", "deployments", system).
+		Return([]byte(versionDriftDeployments), nil)
+
+	rr := metricsRequest(NewHandler(svc, nil, nil, nil, nil, nil, nil, nil, ""), "/kubes/versions/drift")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	report := &VersionDriftReport{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(report))
+	require.Len(t, report.Clusters, 1)
+
+	alpha := report.Clusters[0]
+	require.Empty(t, alpha.Error)
+	require.Equal(t, "1.14.3", alpha.Target)
+	// kubernetes, kubelet, kube-proxy and docker of two nodes, calico and coredns
+	require.Len(t, alpha.Components, 9)
+	require.Equal(t, 4, alpha.Stragglers)
+
+	stragglers := make([]string, 0, len(report.Stragglers))
+	for _, c := range report.Stragglers {
+		stragglers = append(stragglers, c.Node+"/"+c.Component)
+	}
+	require.Equal(t, []string{"/coredns", "node-2/docker", "node-2/kube-proxy", "node-2/kubelet"}, stragglers)
+	require.Equal(t, "1.3.1", report.Stragglers[0].Desired)
+	require.Equal(t, "1.2.6", report.Stragglers[0].Actual)
+}
+
+func TestVersionMatches(t *testing.T) {
+	for _, tc := range []struct {
+		actual, desired string
+		matches         bool
+	}{
+		{"v1.14.3", "1.14.3", true},
+		{"v1.14.1", "1.14.3", false},
+		{"18.6.1", "18.06", true},
+		{"18.9.7-ce", "18.06", false},
+		{"v0.11.0-amd64", "0.11.0", true},
+		{"v3.7.2", "3.7", true},
+		{"3", "3.7", false},
+		{"latest", "1.3.1", false},
+	} {
+		require.Equal(t, tc.matches, versionMatches(tc.actual, tc.desired), "%s %s", tc.actual, tc.desired)
+	}
+
+	require.Equal(t, "v3.7.2", imageTag("calico/node:v3.7.2"))
+	require.Equal(t, "latest", imageTag("registry:5000/calico/node"))
+}
@@ -12,6 +12,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes/scheme"
+	certificatesclient "k8s.io/client-go/kubernetes/typed/certificates/v1beta1"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -49,7 +50,8 @@ func RestClientForGroupVersion(k *model.Kube, gv schema.GroupVersion) (rest.Inte
 	return rest.RESTClientFor(cfg)
 }
 
-func discoveryClient(k *model.Kube) (*discovery.DiscoveryClient, error) {
+// DiscoveryClient returns a client for resources served by the cluster.
+func DiscoveryClient(k *model.Kube) (*discovery.DiscoveryClient, error) {
 	cfg, err := NewConfigFor(k)
 	if err != nil {
 		return nil, err
@@ -65,8 +67,27 @@ func CoreV1Client(k *model.Kube) (corev1client.CoreV1Interface, error) {
 	return corev1client.NewForConfig(cfg)
 }
 
+// CertificatesClient returns a client for certificate signing requests of the cluster.
+func CertificatesClient(k *model.Kube) (certificatesclient.CertificatesV1beta1Interface, error) {
+	cfg, err := NewConfigFor(k)
+	if err != nil {
+		return nil, err
+	}
+	return certificatesclient.NewForConfig(cfg)
+}
+
 // adminKubeConfig returns a cluster-admin kubeconfig for provided cluster.
 func AdminKubeConfig(k *model.Kube) (clientcmddapi.Config, error) {
+	if k == nil {
+		return clientcmddapi.Config{}, errors.Wrap(sgerrors.ErrNotFound, "master nodes")
+	}
+
+	return UserKubeConfig(k, "admin", []byte(k.Auth.AdminCert), []byte(k.Auth.AdminKey))
+}
+
+// UserKubeConfig returns a kubeconfig for provided cluster that authenticates
+// with the user client certificate.
+func UserKubeConfig(k *model.Kube, user string, cert, key []byte) (clientcmddapi.Config, error) {
 	// TODO: this should be an address of the master load balancer
 	if k == nil || (k.ExternalDNSName == "" && len(k.Masters) == 0) {
 		// TODO: use another base error, not ErrNotFound
@@ -89,9 +110,9 @@ func AdminKubeConfig(k *model.Kube) (clientcmddapi.Config, error) {
 	// TODO: add validation
 	return clientcmddapi.Config{
 		AuthInfos: map[string]*clientcmddapi.AuthInfo{
-			adminContext(k.Name): {
-				ClientCertificateData: []byte(k.Auth.AdminCert),
-				ClientKeyData:         []byte(k.Auth.AdminKey),
+			userContext(user, k.Name): {
+				ClientCertificateData: cert,
+				ClientKeyData:         key,
 			},
 		},
 		Clusters: map[string]*clientcmddapi.Cluster{
@@ -101,12 +122,12 @@ func AdminKubeConfig(k *model.Kube) (clientcmddapi.Config, error) {
 			},
 		},
 		Contexts: map[string]*clientcmddapi.Context{
-			adminContext(k.Name): {
-				AuthInfo: adminContext(k.Name),
+			userContext(user, k.Name): {
+				AuthInfo: userContext(user, k.Name),
 				Cluster:  k.Name,
 			},
 		},
-		CurrentContext: adminContext(k.Name),
+		CurrentContext: userContext(user, k.Name),
 	}, nil
 }
 
@@ -119,6 +140,6 @@ func setGroupDefaults(config *rest.Config, gv schema.GroupVersion) {
 	}
 }
 
-func adminContext(clusterName string) string {
-	return "admin@" + clusterName
+func userContext(user, clusterName string) string {
+	return user + "@" + clusterName
 }
@@ -71,7 +71,7 @@ func TestDiscoveryClient(t *testing.T) {
 	}
 
 	for _, testCase := range testCases {
-		client, err := discoveryClient(testCase.kube)
+		client, err := DiscoveryClient(testCase.kube)
 
 		if errors.Cause(err) != testCase.expectedErr {
 			t.Errorf("expected error %v actual %v",
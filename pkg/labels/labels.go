@@ -0,0 +1,53 @@
+// Package labels checks labels of kubes, cloud accounts and profiles and
+// selects them by label selectors, labels group resources e.g. by
+// environment.
+package labels
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Validate checks names and values of labels have the syntax of kubernetes
+// labels, e.g. env=prod or example.com/tier=web.
+func Validate(labels map[string]string) error {
+	for name, value := range labels {
+		if errs := validation.IsQualifiedName(name); len(errs) > 0 {
+			return errors.Errorf("invalid label name %q: %s", name, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return errors.Errorf("invalid value %q of label %s: %s", value, name, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the labels have all values of the selector, an
+// empty selector matches any labels.
+func Matches(labels, selector map[string]string) bool {
+	for name, want := range selector {
+		if v, ok := labels[name]; !ok || v != want {
+			return false
+		}
+	}
+	return true
+}
+
+// Merge returns labels of the base with labels of the overlay, labels of
+// the overlay take precedence.
+func Merge(base, overlay map[string]string) map[string]string {
+	if len(base) == 0 && len(overlay) == 0 {
+		return nil
+	}
+
+	res := make(map[string]string, len(base)+len(overlay))
+	for name, value := range base {
+		res[name] = value
+	}
+	for name, value := range overlay {
+		res[name] = value
+	}
+	return res
+}
@@ -0,0 +1,29 @@
+package labels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate(nil))
+	require.NoError(t, Validate(map[string]string{"env": "prod", "example.com/tier": "web", "empty": ""}))
+	require.Error(t, Validate(map[string]string{"env prod": "x"}))
+	require.Error(t, Validate(map[string]string{"env": "prod/eu"}))
+}
+
+func TestMatches(t *testing.T) {
+	labels := map[string]string{"env": "prod", "tier": "web"}
+
+	require.True(t, Matches(labels, nil))
+	require.True(t, Matches(labels, map[string]string{"env": "prod"}))
+	require.False(t, Matches(labels, map[string]string{"env": "dev"}))
+	require.False(t, Matches(nil, map[string]string{"env": "prod"}))
+}
+
+func TestMerge(t *testing.T) {
+	require.Nil(t, Merge(nil, map[string]string{}))
+	require.Equal(t, map[string]string{"env": "dev", "tier": "web"},
+		Merge(map[string]string{"env": "prod", "tier": "web"}, map[string]string{"env": "dev"}))
+}
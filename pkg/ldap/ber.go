@@ -0,0 +1,218 @@
+package ldap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// BER classes and universal tags of LDAP messages, RFC 4511 allows the
+// definite length form only.
+const (
+	classUniversal   byte = 0x00
+	classApplication byte = 0x40
+	classContext     byte = 0x80
+
+	tagBoolean     = 1
+	tagInteger     = 2
+	tagOctetString = 4
+	tagEnumerated  = 10
+	tagSequence    = 16
+	tagSet         = 17
+
+	maxPacketSize = 16 << 20
+)
+
+var errMalformed = errors.New("malformed BER packet")
+
+// packet is a BER element, constructed packets have children instead of
+// a value.
+type packet struct {
+	class       byte
+	constructed bool
+	tag         int
+	value       []byte
+	children    []*packet
+}
+
+func newConstructed(class byte, tag int, children ...*packet) *packet {
+	return &packet{class: class, constructed: true, tag: tag, children: children}
+}
+
+func newSequence(children ...*packet) *packet {
+	return newConstructed(classUniversal, tagSequence, children...)
+}
+
+func newString(class byte, tag int, s string) *packet {
+	return &packet{class: class, tag: tag, value: []byte(s)}
+}
+
+func newOctetString(s string) *packet {
+	return newString(classUniversal, tagOctetString, s)
+}
+
+func newInteger(class byte, tag int, v int64) *packet {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(v))
+	// drop leading bytes that repeat the sign
+	for len(b) > 1 && (b[0] == 0 && b[1]&0x80 == 0 || b[0] == 0xff && b[1]&0x80 != 0) {
+		b = b[1:]
+	}
+	return &packet{class: class, tag: tag, value: b}
+}
+
+func newBoolean(class byte, tag int, v bool) *packet {
+	p := &packet{class: class, tag: tag, value: []byte{0}}
+	if v {
+		p.value[0] = 0xff
+	}
+	return p
+}
+
+func (p *packet) is(class byte, tag int) bool {
+	return p.class == class && p.tag == tag
+}
+
+func (p *packet) str() string {
+	return string(p.value)
+}
+
+func (p *packet) int() int64 {
+	var v int64
+	for i, b := range p.value {
+		if i == 0 && b&0x80 != 0 {
+			v = -1
+		}
+		v = v<<8 | int64(b)
+	}
+	return v
+}
+
+func (p *packet) bool() bool {
+	return len(p.value) > 0 && p.value[0] != 0
+}
+
+// encode returns the BER encoding of the packet, tags are below 31.
+func (p *packet) encode() []byte {
+	value := p.value
+	if p.constructed {
+		value = nil
+		for _, c := range p.children {
+			value = append(value, c.encode()...)
+		}
+	}
+
+	id := p.class | byte(p.tag)
+	if p.constructed {
+		id |= 0x20
+	}
+
+	return append(append([]byte{id}, encodeLength(len(value))...), value...)
+}
+
+func encodeLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+
+	var b []byte
+	for ; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	return append([]byte{0x80 | byte(len(b))}, b...)
+}
+
+// readPacket reads a packet from the stream.
+func readPacket(r *bufio.Reader) (*packet, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	n := int(header[1])
+	if n&0x80 != 0 {
+		size := n & 0x7f
+		if size == 0 || size > 4 {
+			return nil, errMalformed
+		}
+		lb := make([]byte, size)
+		if _, err := io.ReadFull(r, lb); err != nil {
+			return nil, err
+		}
+		header = append(header, lb...)
+		n = 0
+		for _, b := range lb {
+			n = n<<8 | int(b)
+		}
+	}
+	if n > maxPacketSize {
+		return nil, errors.Errorf("packet of %d bytes is too large", n)
+	}
+
+	data := make([]byte, len(header)+n)
+	copy(data, header)
+	if _, err := io.ReadFull(r, data[len(header):]); err != nil {
+		return nil, err
+	}
+
+	p, rest, err := parsePacket(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errMalformed
+	}
+	return p, nil
+}
+
+// parsePacket decodes the first packet of the data and returns the rest.
+func parsePacket(data []byte) (*packet, []byte, error) {
+	if len(data) < 2 {
+		return nil, nil, errMalformed
+	}
+
+	p := &packet{
+		class:       data[0] & 0xc0,
+		constructed: data[0]&0x20 != 0,
+		tag:         int(data[0] & 0x1f),
+	}
+	if p.tag == 0x1f {
+		// high tag numbers aren't used by LDAP
+		return nil, nil, errMalformed
+	}
+
+	n, i := int(data[1]), 2
+	if n&0x80 != 0 {
+		size := n & 0x7f
+		if size == 0 || size > 4 || len(data) < 2+size {
+			return nil, nil, errMalformed
+		}
+		n = 0
+		for _, b := range data[2 : 2+size] {
+			n = n<<8 | int(b)
+		}
+		i += size
+	}
+	if n < 0 || len(data)-i < n {
+		return nil, nil, errMalformed
+	}
+
+	value, rest := data[i:i+n], data[i+n:]
+	if !p.constructed {
+		p.value = value
+		return p, rest, nil
+	}
+
+	for len(value) > 0 {
+		c, r, err := parsePacket(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		p.children = append(p.children, c)
+		value = r
+	}
+
+	return p, rest, nil
+}
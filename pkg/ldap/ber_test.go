@@ -0,0 +1,38 @@
+package ldap
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacket(t *testing.T) {
+	for _, v := range []int64{0, 1, 127, 128, 255, 256, -1, -128, -129, 1 << 40} {
+		p, rest, err := parsePacket(newInteger(classUniversal, tagInteger, v).encode())
+		require.NoError(t, err)
+		require.Empty(t, rest)
+		require.Equal(t, v, p.int(), "%d", v)
+	}
+
+	require.Equal(t, []byte{0x02, 0x01, 0x7f}, newInteger(classUniversal, tagInteger, 127).encode())
+	require.Equal(t, []byte{0x02, 0x02, 0x00, 0x80}, newInteger(classUniversal, tagInteger, 128).encode())
+
+	long := strings.Repeat("a", 300)
+	msg := newSequence(newInteger(classUniversal, tagInteger, 7),
+		newConstructed(classApplication, opBindRequest, newOctetString(long), newBoolean(classContext, 4, true)))
+
+	p, err := readPacket(bufio.NewReader(bytes.NewReader(msg.encode())))
+	require.NoError(t, err)
+	require.True(t, p.is(classUniversal, tagSequence))
+	require.Equal(t, int64(7), p.children[0].int())
+	require.True(t, p.children[1].is(classApplication, opBindRequest))
+	require.True(t, p.children[1].constructed)
+	require.Equal(t, long, p.children[1].children[0].str())
+	require.True(t, p.children[1].children[1].bool())
+
+	_, _, err = parsePacket([]byte{0x30, 0x05, 0x04})
+	require.Equal(t, errMalformed, err)
+}
@@ -0,0 +1,294 @@
+package ldap
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// Protocol operations and result codes of RFC 4511.
+const (
+	opBindRequest      = 0
+	opBindResponse     = 1
+	opUnbindRequest    = 2
+	opSearchRequest    = 3
+	opSearchEntry      = 4
+	opSearchDone       = 5
+	opSearchReference  = 19
+	opExtendedRequest  = 23
+	opExtendedResponse = 24
+	resultSuccess      = 0
+	resultNoSuchObject = 32
+	resultInvalidCreds = 49
+	scopeWholeSubtree  = 2
+	derefNever         = 0
+	oidStartTLS        = "1.3.6.1.4.1.1466.20037"
+	noAttributes       = "1.1"
+	defaultPort        = "389"
+	defaultTLSPort     = "636"
+	schemeLDAP         = "ldap"
+	schemeLDAPS        = "ldaps"
+)
+
+// Entry is a search result.
+type Entry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// ResultError is a failed result of an operation.
+type ResultError struct {
+	Code    int64
+	Message string
+}
+
+func (e *ResultError) Error() string {
+	return fmt.Sprintf("ldap result code %d: %s", e.Code, e.Message)
+}
+
+// conn is a connection to a directory, operations are sequential.
+type conn struct {
+	c       net.Conn
+	r       *bufio.Reader
+	msgID   int64
+	timeout time.Duration
+}
+
+func dial(cfg Config) (*conn, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse url")
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		port := defaultPort
+		if u.Scheme == schemeLDAPS {
+			port = defaultTLSPort
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	var nc net.Conn
+	if u.Scheme == schemeLDAPS {
+		nc, err = tls.DialWithDialer(dialer, "tcp", host, tlsConfig)
+	} else {
+		nc, err = dialer.Dial("tcp", host)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "dial %s", host)
+	}
+
+	c := &conn{
+		c:       nc,
+		r:       bufio.NewReader(nc),
+		timeout: cfg.Timeout,
+	}
+	if cfg.StartTLS && u.Scheme == schemeLDAP {
+		if err = c.startTLS(tlsConfig); err != nil {
+			c.close()
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+func (c *conn) startTLS(config *tls.Config) error {
+	req := newConstructed(classApplication, opExtendedRequest,
+		newString(classContext, 0, oidStartTLS))
+	resp, err := c.roundTrip(req, opExtendedResponse)
+	if err != nil {
+		return errors.Wrap(err, "start tls")
+	}
+	if err = result(resp); err != nil {
+		return errors.Wrap(err, "start tls")
+	}
+
+	tc := tls.Client(c.c, config)
+	c.c.SetDeadline(time.Now().Add(c.timeout))
+	if err = tc.Handshake(); err != nil {
+		return errors.Wrap(err, "tls handshake")
+	}
+	c.c, c.r = tc, bufio.NewReader(tc)
+
+	return nil
+}
+
+// bind authenticates the connection with the password of the DN, invalid
+// credentials are sgerrors.ErrInvalidCredentials.
+func (c *conn) bind(dn, password string) error {
+	req := newConstructed(classApplication, opBindRequest,
+		newInteger(classUniversal, tagInteger, 3),
+		newOctetString(dn),
+		newString(classContext, 0, password))
+
+	resp, err := c.roundTrip(req, opBindResponse)
+	if err != nil {
+		return errors.Wrap(err, "bind")
+	}
+
+	err = result(resp)
+	if e, ok := err.(*ResultError); ok && e.Code == resultInvalidCreds {
+		return sgerrors.ErrInvalidCredentials
+	}
+	return err
+}
+
+// search returns entries of the subtree of the base that match the filter.
+func (c *conn) search(base, filter string, attrs []string) ([]Entry, error) {
+	f, err := compileFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(attrs) == 0 {
+		attrs = []string{noAttributes}
+	}
+
+	attributes := newSequence()
+	for _, a := range attrs {
+		attributes.children = append(attributes.children, newOctetString(a))
+	}
+	req := newConstructed(classApplication, opSearchRequest,
+		newOctetString(base),
+		newInteger(classUniversal, tagEnumerated, scopeWholeSubtree),
+		newInteger(classUniversal, tagEnumerated, derefNever),
+		newInteger(classUniversal, tagInteger, 0),
+		newInteger(classUniversal, tagInteger, int64(c.timeout/time.Second)),
+		newBoolean(classUniversal, tagBoolean, false),
+		f,
+		attributes)
+
+	id, err := c.send(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "search")
+	}
+
+	entries := make([]Entry, 0)
+	for {
+		op, err := c.receive(id)
+		if err != nil {
+			return nil, errors.Wrap(err, "search")
+		}
+
+		switch {
+		case op.is(classApplication, opSearchEntry):
+			e, err := parseEntry(op)
+			if err != nil {
+				return nil, errors.Wrap(err, "search")
+			}
+			entries = append(entries, e)
+		case op.is(classApplication, opSearchReference):
+			// referrals to other servers aren't followed
+		case op.is(classApplication, opSearchDone):
+			err = result(op)
+			if e, ok := err.(*ResultError); ok && e.Code == resultNoSuchObject {
+				return entries, nil
+			}
+			return entries, err
+		default:
+			return nil, errors.Wrapf(errMalformed, "search: unexpected operation %d", op.tag)
+		}
+	}
+}
+
+func (c *conn) close() error {
+	// unbind has no response
+	c.send(&packet{class: classApplication, tag: opUnbindRequest})
+	return c.c.Close()
+}
+
+func (c *conn) roundTrip(req *packet, respTag int) (*packet, error) {
+	id, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.receive(id)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.is(classApplication, respTag) {
+		return nil, errors.Wrapf(errMalformed, "unexpected operation %d", resp.tag)
+	}
+	return resp, nil
+}
+
+func (c *conn) send(op *packet) (int64, error) {
+	c.msgID++
+	msg := newSequence(newInteger(classUniversal, tagInteger, c.msgID), op)
+
+	c.c.SetDeadline(time.Now().Add(c.timeout))
+	if _, err := c.c.Write(msg.encode()); err != nil {
+		return 0, err
+	}
+	return c.msgID, nil
+}
+
+// receive returns the operation of the next message of the request.
+func (c *conn) receive(id int64) (*packet, error) {
+	for {
+		c.c.SetDeadline(time.Now().Add(c.timeout))
+		msg, err := readPacket(c.r)
+		if err != nil {
+			return nil, err
+		}
+		if !msg.is(classUniversal, tagSequence) || len(msg.children) < 2 {
+			return nil, errMalformed
+		}
+
+		// unsolicited notifications have message ID 0
+		if msg.children[0].int() == id {
+			return msg.children[1], nil
+		}
+		if msg.children[0].int() == 0 {
+			return nil, errors.New("connection is closed by the server")
+		}
+	}
+}
+
+// result returns the error of an LDAPResult.
+func result(op *packet) error {
+	if len(op.children) < 3 {
+		return errMalformed
+	}
+
+	if code := op.children[0].int(); code != resultSuccess {
+		return &ResultError{Code: code, Message: op.children[2].str()}
+	}
+	return nil
+}
+
+func parseEntry(op *packet) (Entry, error) {
+	if len(op.children) < 2 {
+		return Entry{}, errMalformed
+	}
+
+	e := Entry{
+		DN:         op.children[0].str(),
+		Attributes: make(map[string][]string),
+	}
+	for _, attr := range op.children[1].children {
+		if len(attr.children) < 2 {
+			return Entry{}, errMalformed
+		}
+		name := attr.children[0].str()
+		for _, v := range attr.children[1].children {
+			e.Attributes[name] = append(e.Attributes[name], v.str())
+		}
+	}
+
+	return e, nil
+}
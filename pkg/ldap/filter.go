@@ -0,0 +1,218 @@
+package ldap
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Filter choices of search requests.
+const (
+	filterAnd            = 0
+	filterOr             = 1
+	filterNot            = 2
+	filterEquality       = 3
+	filterSubstrings     = 4
+	filterGreaterOrEqual = 5
+	filterLessOrEqual    = 6
+	filterPresent        = 7
+	filterApprox         = 8
+	filterExtensible     = 9
+)
+
+// EscapeFilter escapes special characters of a value of a filter, e.g. of
+// logins and DNs of users.
+func EscapeFilter(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\', '*', '(', ')', 0:
+			b.WriteByte('\\')
+			b.WriteString(hex.EncodeToString([]byte{c}))
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// compileFilter encodes the RFC 4515 string representation of a filter,
+// e.g. (&(objectClass=person)(uid=bob)).
+func compileFilter(s string) (*packet, error) {
+	p, rest, err := parseFilter(strings.TrimSpace(s))
+	if err != nil {
+		return nil, errors.Wrapf(err, "filter %s", s)
+	}
+	if rest != "" {
+		return nil, errors.Errorf("filter %s: unexpected %q", s, rest)
+	}
+	return p, nil
+}
+
+func parseFilter(s string) (*packet, string, error) {
+	if !strings.HasPrefix(s, "(") {
+		return nil, "", errors.New("filter must start with (")
+	}
+	s = s[1:]
+	if s == "" {
+		return nil, "", errors.New("unexpected end")
+	}
+
+	switch s[0] {
+	case '&', '|':
+		tag := filterAnd
+		if s[0] == '|' {
+			tag = filterOr
+		}
+		p := newConstructed(classContext, tag)
+		s = s[1:]
+		for strings.HasPrefix(s, "(") {
+			c, rest, err := parseFilter(s)
+			if err != nil {
+				return nil, "", err
+			}
+			p.children = append(p.children, c)
+			s = rest
+		}
+		return closeFilter(p, s)
+	case '!':
+		c, rest, err := parseFilter(s[1:])
+		if err != nil {
+			return nil, "", err
+		}
+		return closeFilter(newConstructed(classContext, filterNot, c), rest)
+	}
+
+	end := strings.IndexByte(s, ')')
+	if end < 0 {
+		return nil, "", errors.New("missing )")
+	}
+	p, err := parseItem(s[:end])
+	if err != nil {
+		return nil, "", err
+	}
+	return p, s[end+1:], nil
+}
+
+func closeFilter(p *packet, s string) (*packet, string, error) {
+	if !strings.HasPrefix(s, ")") {
+		return nil, "", errors.New("missing )")
+	}
+	return p, s[1:], nil
+}
+
+// parseItem encodes a simple filter, e.g. uid=bob, cn=adm*, mail=* or
+// member:1.2.840.113556.1.4.1941:=cn=bob,dc=example,dc=com.
+func parseItem(s string) (*packet, error) {
+	eq := strings.IndexByte(s, '=')
+	if eq <= 0 {
+		return nil, errors.Errorf("invalid item %q", s)
+	}
+	attr, raw := s[:eq], s[eq+1:]
+
+	tag := filterEquality
+	switch attr[len(attr)-1] {
+	case '>':
+		tag, attr = filterGreaterOrEqual, attr[:len(attr)-1]
+	case '<':
+		tag, attr = filterLessOrEqual, attr[:len(attr)-1]
+	case '~':
+		tag, attr = filterApprox, attr[:len(attr)-1]
+	case ':':
+		return parseExtensible(attr[:len(attr)-1], raw)
+	}
+
+	if tag == filterEquality && raw == "*" {
+		return newString(classContext, filterPresent, attr), nil
+	}
+	if tag == filterEquality && strings.Contains(raw, "*") {
+		return parseSubstrings(attr, raw)
+	}
+
+	value, err := unescapeFilter(raw)
+	if err != nil {
+		return nil, err
+	}
+	return newConstructed(classContext, tag, newOctetString(attr), newOctetString(value)), nil
+}
+
+func parseSubstrings(attr, raw string) (*packet, error) {
+	parts := strings.Split(raw, "*")
+	subs := newSequence()
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		value, err := unescapeFilter(part)
+		if err != nil {
+			return nil, err
+		}
+
+		tag := 1 // any
+		switch i {
+		case 0:
+			tag = 0 // initial
+		case len(parts) - 1:
+			tag = 2 // final
+		}
+		subs.children = append(subs.children, newString(classContext, tag, value))
+	}
+
+	return newConstructed(classContext, filterSubstrings, newOctetString(attr), subs), nil
+}
+
+func parseExtensible(attr, raw string) (*packet, error) {
+	value, err := unescapeFilter(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(attr, ":")
+	rule, dn := "", false
+	for _, part := range parts[1:] {
+		if strings.EqualFold(part, "dn") {
+			dn = true
+		} else if part != "" {
+			rule = part
+		}
+	}
+
+	p := newConstructed(classContext, filterExtensible)
+	if rule != "" {
+		p.children = append(p.children, newString(classContext, 1, rule))
+	}
+	if parts[0] != "" {
+		p.children = append(p.children, newString(classContext, 2, parts[0]))
+	}
+	p.children = append(p.children, newString(classContext, 3, value))
+	if dn {
+		p.children = append(p.children, newBoolean(classContext, 4, true))
+	}
+
+	return p, nil
+}
+
+func unescapeFilter(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+2 >= len(s) {
+			return "", errors.Errorf("invalid escape in %q", s)
+		}
+		c, err := hex.DecodeString(s[i+1 : i+3])
+		if err != nil {
+			return "", errors.Errorf("invalid escape in %q", s)
+		}
+		b.Write(c)
+		i += 2
+	}
+	return b.String(), nil
+}
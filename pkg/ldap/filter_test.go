@@ -0,0 +1,49 @@
+package ldap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscapeFilter(t *testing.T) {
+	require.Equal(t, `bob`, EscapeFilter("bob"))
+	require.Equal(t, `\2a\29\28uid=\5c\00`, EscapeFilter("*)(uid=\\\x00"))
+}
+
+func TestCompileFilter(t *testing.T) {
+	p, err := compileFilter("(&(objectClass=person)(|(uid=bob)(!(mail=*)))(cn=a*b*c)(member:1.2.840.113556.1.4.1941:=cn=x\\2cy))")
+	require.NoError(t, err)
+	require.True(t, p.is(classContext, filterAnd))
+	require.Len(t, p.children, 4)
+
+	eq := p.children[0]
+	require.True(t, eq.is(classContext, filterEquality))
+	require.Equal(t, "objectClass", eq.children[0].str())
+	require.Equal(t, "person", eq.children[1].str())
+
+	or := p.children[1]
+	require.True(t, or.is(classContext, filterOr))
+	not := or.children[1]
+	require.True(t, not.is(classContext, filterNot))
+	require.True(t, not.children[0].is(classContext, filterPresent))
+	require.Equal(t, "mail", not.children[0].str())
+
+	subs := p.children[2]
+	require.True(t, subs.is(classContext, filterSubstrings))
+	require.Len(t, subs.children[1].children, 3)
+	for i, tag := range []int{0, 1, 2} {
+		require.Equal(t, tag, subs.children[1].children[i].tag)
+	}
+
+	ext := p.children[3]
+	require.True(t, ext.is(classContext, filterExtensible))
+	require.Equal(t, "1.2.840.113556.1.4.1941", ext.children[0].str())
+	require.Equal(t, "member", ext.children[1].str())
+	require.Equal(t, "cn=x,y", ext.children[2].str())
+
+	for _, f := range []string{"", "uid=bob", "(uid=bob", "(&(uid=bob)", "(=bob)", "(uid=\\zz)", "(uid=bob))"} {
+		_, err := compileFilter(f)
+		require.Error(t, err, f)
+	}
+}
@@ -0,0 +1,296 @@
+// Package ldap authenticates users of LDAP directories and Active Directory,
+// users get roles of groups they are members of. It implements the subset
+// of LDAPv3 that binds and searches, connections of the service account
+// are pooled.
+package ldap
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/user"
+)
+
+const (
+	DefaultUserFilter  = "(uid=%s)"
+	DefaultGroupFilter = "(member=%s)"
+	DefaultPoolSize    = 4
+	DefaultTimeout     = time.Second * 10
+
+	// groups of groups are followed this deep
+	maxNesting = 10
+)
+
+// roles in order of privileges
+var roles = []string{user.RoleView, user.RoleEdit, user.RoleAdmin}
+
+// Config of the directory, LDAP is off when the URL is empty.
+type Config struct {
+	// URL of the directory, e.g. ldaps://ldap.example.com or
+	// ldap://dc.example.com:389 with StartTLS.
+	URL                string
+	StartTLS           bool
+	InsecureSkipVerify bool
+
+	// BindDN and BindPassword of the service account that searches users
+	// and groups, searches are anonymous when BindDN is empty.
+	BindDN       string
+	BindPassword string
+
+	// UserFilter selects a user of the BaseDN by login, e.g.
+	// (sAMAccountName=%s) in Active Directory.
+	BaseDN     string
+	UserFilter string
+
+	// GroupFilter selects groups of the GroupBaseDN by DNs of members,
+	// GroupBaseDN is the BaseDN when it's empty.
+	GroupBaseDN string
+	GroupFilter string
+	// NestedGroups resolves groups that are members of groups.
+	NestedGroups bool
+
+	// GroupRoles are roles of DNs of groups, users get the role with most
+	// privileges of their groups or the DefaultRole. Users without a role
+	// can't log in.
+	GroupRoles  map[string]string
+	DefaultRole string
+
+	// PoolSize is the number of idle connections that are kept.
+	PoolSize int
+	Timeout  time.Duration
+}
+
+// ParseGroupRoles parses roles of groups of the role=DN;role=DN form, e.g.
+// admin=cn=admins,ou=groups,dc=example,dc=com.
+func ParseGroupRoles(s string) (map[string]string, error) {
+	groupRoles := make(map[string]string)
+	for _, pair := range strings.Split(s, ";") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[1] == "" || rank(kv[0]) < 0 {
+			return nil, errors.Errorf("invalid group role %q", pair)
+		}
+		groupRoles[kv[1]] = kv[0]
+	}
+
+	return groupRoles, nil
+}
+
+// Provider authenticates users of the directory.
+type Provider struct {
+	cfg        Config
+	groupRoles map[string]string
+	pool       *pool
+}
+
+// New returns a provider of the directory, connections are dialed when
+// users log in.
+func New(cfg Config) (*Provider, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, errors.Wrap(err, "ldap url")
+	}
+	if u.Scheme != schemeLDAP && u.Scheme != schemeLDAPS || u.Hostname() == "" {
+		return nil, errors.Errorf("ldap url %s must be ldap://host or ldaps://host", cfg.URL)
+	}
+	if cfg.BaseDN == "" {
+		return nil, errors.New("ldap base DN is required")
+	}
+	if cfg.DefaultRole != "" && rank(cfg.DefaultRole) < 0 {
+		return nil, errors.Errorf("unknown ldap default role %s", cfg.DefaultRole)
+	}
+
+	if cfg.UserFilter == "" {
+		cfg.UserFilter = DefaultUserFilter
+	}
+	if cfg.GroupFilter == "" {
+		cfg.GroupFilter = DefaultGroupFilter
+	}
+	if cfg.GroupBaseDN == "" {
+		cfg.GroupBaseDN = cfg.BaseDN
+	}
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = DefaultPoolSize
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+
+	// DNs are compared case insensitively
+	groupRoles := make(map[string]string, len(cfg.GroupRoles))
+	for dn, role := range cfg.GroupRoles {
+		if rank(role) < 0 {
+			return nil, errors.Errorf("unknown role %s of group %s", role, dn)
+		}
+		groupRoles[normalizeDN(dn)] = role
+	}
+
+	p := &Provider{
+		cfg:        cfg,
+		groupRoles: groupRoles,
+	}
+	p.pool = newPool(cfg.PoolSize, p.dial)
+
+	return p, nil
+}
+
+// Authenticate checks the password of the user and returns the role of the
+// user, unknown users and users without a role have invalid credentials.
+func (p *Provider) Authenticate(ctx context.Context, login, password string) (string, error) {
+	// directories bind anonymously without passwords
+	if login == "" || password == "" {
+		return "", sgerrors.ErrInvalidCredentials
+	}
+
+	c, err := p.pool.get()
+	if err != nil {
+		return "", err
+	}
+	broken := true
+	defer func() { p.pool.put(c, broken) }()
+
+	entries, err := c.search(p.cfg.BaseDN, fmt.Sprintf(p.cfg.UserFilter, EscapeFilter(login)), nil)
+	if err != nil {
+		return "", errors.Wrapf(err, "ldap: search user %s", login)
+	}
+	switch len(entries) {
+	case 0:
+		broken = false
+		return "", sgerrors.ErrInvalidCredentials
+	case 1:
+	default:
+		broken = false
+		return "", errors.Errorf("ldap: %d users match login %s", len(entries), login)
+	}
+	userDN := entries[0].DN
+
+	// the connection is bound as the service account again after the user
+	err = c.bind(userDN, password)
+	if sgerrors.IsInvalidCredentials(err) {
+		if err = p.bindService(c); err == nil {
+			broken = false
+			return "", sgerrors.ErrInvalidCredentials
+		}
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "ldap: bind %s", userDN)
+	}
+	if err = p.bindService(c); err != nil {
+		return "", err
+	}
+
+	groups, err := p.groups(c, userDN)
+	if err != nil {
+		return "", errors.Wrapf(err, "ldap: groups of %s", userDN)
+	}
+	broken = false
+
+	role := p.role(groups)
+	if role == "" {
+		logrus.Infof("ldap: user %s has no role", login)
+		return "", sgerrors.ErrInvalidCredentials
+	}
+
+	return role, nil
+}
+
+// Close closes idle connections.
+func (p *Provider) Close() {
+	p.pool.close()
+}
+
+func (p *Provider) dial() (*conn, error) {
+	c, err := dial(p.cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "ldap")
+	}
+
+	if err = p.bindService(c); err != nil {
+		c.close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (p *Provider) bindService(c *conn) error {
+	if p.cfg.BindDN == "" {
+		return nil
+	}
+
+	if err := c.bind(p.cfg.BindDN, p.cfg.BindPassword); err != nil {
+		return errors.Wrapf(err, "ldap: bind %s", p.cfg.BindDN)
+	}
+	return nil
+}
+
+// groups returns DNs of groups of the member, groups of groups are
+// included when nested groups are resolved.
+func (p *Provider) groups(c *conn, member string) ([]string, error) {
+	seen := make(map[string]bool)
+	groups := make([]string, 0)
+
+	members := []string{member}
+	for depth := 0; len(members) > 0 && depth < maxNesting; depth++ {
+		next := make([]string, 0)
+		for _, m := range members {
+			entries, err := c.search(p.cfg.GroupBaseDN, fmt.Sprintf(p.cfg.GroupFilter, EscapeFilter(m)), nil)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, e := range entries {
+				if dn := normalizeDN(e.DN); !seen[dn] {
+					seen[dn] = true
+					groups = append(groups, e.DN)
+					next = append(next, e.DN)
+				}
+			}
+		}
+
+		if !p.cfg.NestedGroups {
+			break
+		}
+		members = next
+	}
+
+	return groups, nil
+}
+
+// role returns the role with most privileges of the groups.
+func (p *Provider) role(groups []string) string {
+	role := p.cfg.DefaultRole
+	for _, g := range groups {
+		if r, ok := p.groupRoles[normalizeDN(g)]; ok && rank(r) > rank(role) {
+			role = r
+		}
+	}
+	return role
+}
+
+func rank(role string) int {
+	for i, r := range roles {
+		if r == role {
+			return i
+		}
+	}
+	return -1
+}
+
+func normalizeDN(dn string) string {
+	parts := strings.Split(dn, ",")
+	for i := range parts {
+		parts[i] = strings.ToLower(strings.TrimSpace(parts[i]))
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,213 @@
+package ldap
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/user"
+)
+
+type fakeEntry struct {
+	password string
+	attrs    map[string][]string
+}
+
+// fakeDirectory serves binds and searches of the entries.
+type fakeDirectory struct {
+	entries map[string]fakeEntry
+
+	mu    sync.Mutex
+	dials int
+	binds []string
+}
+
+func (d *fakeDirectory) serve(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			d.mu.Lock()
+			d.dials++
+			d.mu.Unlock()
+			go d.handle(c)
+		}
+	}()
+
+	return "ldap://" + l.Addr().String()
+}
+
+func (d *fakeDirectory) handle(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+
+	for {
+		msg, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		id, op := msg.children[0].int(), msg.children[1]
+
+		reply := func(ops ...*packet) {
+			for _, op := range ops {
+				c.Write(newSequence(newInteger(classUniversal, tagInteger, id), op).encode())
+			}
+		}
+
+		switch op.tag {
+		case opBindRequest:
+			dn, password := op.children[1].str(), op.children[2].str()
+			d.mu.Lock()
+			d.binds = append(d.binds, dn)
+			d.mu.Unlock()
+
+			code := int64(resultSuccess)
+			if e, ok := d.entries[dn]; !ok || e.password != password {
+				code = resultInvalidCreds
+			}
+			reply(ldapResult(opBindResponse, code))
+		case opSearchRequest:
+			base := strings.ToLower(op.children[0].str())
+			var ops []*packet
+			for dn, e := range d.entries {
+				if strings.HasSuffix(strings.ToLower(dn), base) && matchFilter(op.children[6], e.attrs) {
+					ops = append(ops, newConstructed(classApplication, opSearchEntry, newOctetString(dn), newSequence()))
+				}
+			}
+			reply(append(ops, ldapResult(opSearchDone, resultSuccess))...)
+		case opUnbindRequest:
+			return
+		}
+	}
+}
+
+func ldapResult(tag int, code int64) *packet {
+	return newConstructed(classApplication, tag,
+		newInteger(classUniversal, tagEnumerated, code), newOctetString(""), newOctetString(""))
+}
+
+func matchFilter(f *packet, attrs map[string][]string) bool {
+	switch f.tag {
+	case filterAnd:
+		for _, c := range f.children {
+			if !matchFilter(c, attrs) {
+				return false
+			}
+		}
+		return true
+	case filterOr:
+		for _, c := range f.children {
+			if matchFilter(c, attrs) {
+				return true
+			}
+		}
+		return false
+	case filterEquality:
+		for _, v := range attrs[f.children[0].str()] {
+			if strings.EqualFold(v, f.children[1].str()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func directory() *fakeDirectory {
+	return &fakeDirectory{entries: map[string]fakeEntry{
+		"cn=svc,dc=example,dc=com": {password: "svc"},
+		"uid=alice,ou=people,dc=example,dc=com": {password: "alice", attrs: map[string][]string{"uid": {"alice"}}},
+		"uid=bob,ou=people,dc=example,dc=com":   {password: "bob", attrs: map[string][]string{"uid": {"bob"}}},
+		"uid=eve,ou=people,dc=example,dc=com":   {password: "eve", attrs: map[string][]string{"uid": {"eve"}}},
+		"cn=devs,ou=groups,dc=example,dc=com": {attrs: map[string][]string{
+			"member": {"uid=alice,ou=people,dc=example,dc=com", "uid=bob,ou=people,dc=example,dc=com"},
+		}},
+		"cn=ops,ou=groups,dc=example,dc=com": {attrs: map[string][]string{
+			"member": {"cn=devs,ou=groups,dc=example,dc=com"},
+		}},
+		"cn=admins,ou=groups,dc=example,dc=com": {attrs: map[string][]string{
+			"member": {"uid=alice,ou=people,dc=example,dc=com", "cn=admins,ou=groups,dc=example,dc=com"},
+		}},
+	}}
+}
+
+func TestProvider(t *testing.T) {
+	d := directory()
+	groupRoles, err := ParseGroupRoles("admin=CN=Admins,OU=Groups,DC=example,DC=com; edit=cn=ops,ou=groups,dc=example,dc=com")
+	require.NoError(t, err)
+
+	cfg := Config{
+		URL:          d.serve(t),
+		BindDN:       "cn=svc,dc=example,dc=com",
+		BindPassword: "svc",
+		BaseDN:       "ou=people,dc=example,dc=com",
+		GroupBaseDN:  "ou=groups,dc=example,dc=com",
+		GroupRoles:   groupRoles,
+		PoolSize:     1,
+	}
+	p, err := New(cfg)
+	require.NoError(t, err)
+	defer p.Close()
+
+	for _, tc := range []struct {
+		login, password string
+		role            string
+		err             error
+	}{
+		{"alice", "alice", user.RoleAdmin, nil},
+		{"alice", "wrong", "", sgerrors.ErrInvalidCredentials},
+		{"alice", "", "", sgerrors.ErrInvalidCredentials},
+		{"unknown", "alice", "", sgerrors.ErrInvalidCredentials},
+		{"*", "alice", "", sgerrors.ErrInvalidCredentials},
+		// ops is a group of devs
+		{"bob", "bob", "", sgerrors.ErrInvalidCredentials},
+		{"eve", "eve", "", sgerrors.ErrInvalidCredentials},
+	} {
+		role, err := p.Authenticate(context.Background(), tc.login, tc.password)
+		require.Equal(t, tc.err, err, tc.login)
+		require.Equal(t, tc.role, role, tc.login)
+	}
+
+	// connections of the service account are reused
+	require.Equal(t, 1, d.dials)
+	require.Equal(t, "cn=svc,dc=example,dc=com", d.binds[len(d.binds)-1])
+
+	cfg.NestedGroups = true
+	cfg.DefaultRole = user.RoleView
+	p, err = New(cfg)
+	require.NoError(t, err)
+	defer p.Close()
+
+	role, err := p.Authenticate(context.Background(), "bob", "bob")
+	require.NoError(t, err)
+	require.Equal(t, user.RoleEdit, role)
+	role, err = p.Authenticate(context.Background(), "eve", "eve")
+	require.NoError(t, err)
+	require.Equal(t, user.RoleView, role)
+}
+
+func TestNew(t *testing.T) {
+	for _, cfg := range []Config{
+		{URL: "http://ldap.example.com", BaseDN: "dc=example,dc=com"},
+		{URL: "ldap://", BaseDN: "dc=example,dc=com"},
+		{URL: "ldap://ldap.example.com"},
+		{URL: "ldap://ldap.example.com", BaseDN: "dc=example,dc=com", DefaultRole: "root"},
+		{URL: "ldap://ldap.example.com", BaseDN: "dc=example,dc=com", GroupRoles: map[string]string{"cn=x": "root"}},
+	} {
+		_, err := New(cfg)
+		require.Error(t, err, cfg.URL)
+	}
+
+	_, err := ParseGroupRoles("root=cn=admins")
+	require.Error(t, err)
+}
@@ -0,0 +1,62 @@
+package ldap
+
+import (
+	"sync"
+)
+
+// pool keeps idle connections bound as the service account.
+type pool struct {
+	dial func() (*conn, error)
+	size int
+
+	mu   sync.Mutex
+	idle []*conn
+}
+
+func newPool(size int, dial func() (*conn, error)) *pool {
+	return &pool{
+		dial: dial,
+		size: size,
+	}
+}
+
+// get returns an idle connection or dials a new one.
+func (p *pool) get() (*conn, error) {
+	p.mu.Lock()
+	if n := len(p.idle); n > 0 {
+		c := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		return c, nil
+	}
+	p.mu.Unlock()
+
+	return p.dial()
+}
+
+// put returns the connection to the pool, broken connections and
+// connections above the size of the pool are closed.
+func (p *pool) put(c *conn, broken bool) {
+	p.mu.Lock()
+	if !broken && len(p.idle) < p.size {
+		p.idle = append(p.idle, c)
+		c = nil
+	}
+	p.mu.Unlock()
+
+	if c != nil {
+		c.close()
+	}
+}
+
+// close closes idle connections.
+func (p *pool) close() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	for _, c := range idle {
+		c.close()
+	}
+}
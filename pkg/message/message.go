@@ -3,9 +3,14 @@ package message
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"gopkg.in/asaskevich/govalidator.v8"
 
 	"github.com/supergiant/control/pkg/sgerrors"
 )
@@ -20,6 +25,14 @@ type Message struct {
 	ErrorCode sgerrors.ErrorCode `json:"errorCode"`
 	// MoreInfo should be a link to supergiant documentation to display common problems
 	MoreInfo string `json:"moreInfo"`
+	// Retryable tells the client that the same request may succeed later
+	Retryable bool `json:"retryable"`
+	// Field is the field of the request that is invalid
+	Field string `json:"field,omitempty"`
+	// Step is the step of the workflow that has failed
+	Step string `json:"step,omitempty"`
+	// Violations are all invalid fields of the request
+	Violations sgerrors.Violations `json:"violations,omitempty"`
 }
 
 func New(userMessage string, devMessage string, code sgerrors.ErrorCode, moreInfo string) Message {
@@ -28,8 +41,21 @@ func New(userMessage string, devMessage string, code sgerrors.ErrorCode, moreInf
 		DevMessage:  devMessage,
 		ErrorCode:   code,
 		MoreInfo:    moreInfo,
+		Retryable:   code.Retryable(),
 	}
 }
+
+// withDetail adds the offending field and step of the error to the message.
+func (m Message) withDetail(err error) Message {
+	if d := sgerrors.DetailOf(err); d != nil {
+		m.Field = d.Field
+		m.Step = d.Step
+	}
+	if v, ok := errors.Cause(err).(sgerrors.Violations); ok {
+		m.Violations = v
+	}
+	return m
+}
 func SendMessage(w http.ResponseWriter, msg Message, status int) {
 	data, err := json.Marshal(msg)
 	if err != nil {
@@ -43,7 +69,7 @@ func SendMessage(w http.ResponseWriter, msg Message, status int) {
 }
 
 func SendInvalidJSON(w http.ResponseWriter, err error) {
-	msg := New("User has sent data in malformed format", err.Error(), sgerrors.InvalidJSON, "")
+	msg := New("User has sent data in malformed format", err.Error(), sgerrors.InvalidJSON, "").withDetail(err)
 	data, err := json.Marshal(msg)
 	if err != nil {
 		logrus.Errorf("failed to marshall message: %v", err)
@@ -57,7 +83,10 @@ func SendInvalidJSON(w http.ResponseWriter, err error) {
 
 // SendValidationFailed - this is special case where frontend should parse dev message and present it on the UI
 func SendValidationFailed(w http.ResponseWriter, err error) {
-	msg := New("Validation Failed", err.Error(), sgerrors.ValidationFailed, "")
+	msg := New("Validation Failed", err.Error(), sgerrors.ValidationFailed, "").withDetail(err)
+	if msg.Field == "" {
+		msg.Field = invalidField(err)
+	}
 	data, err := json.Marshal(msg)
 	if err != nil {
 		logrus.Errorf("failed to marshall message: %v", err)
@@ -70,7 +99,7 @@ func SendValidationFailed(w http.ResponseWriter, err error) {
 }
 
 func SendUnknownError(w http.ResponseWriter, err error) {
-	msg := New("Internal error occurred, please consult administrator", err.Error(), sgerrors.UnknownError, "")
+	msg := New("Internal error occurred, please consult administrator", err.Error(), sgerrors.UnknownError, "").withDetail(err)
 
 	data, err := json.Marshal(msg)
 	if err != nil {
@@ -84,7 +113,7 @@ func SendUnknownError(w http.ResponseWriter, err error) {
 }
 
 func SendNotFound(w http.ResponseWriter, entityName string, err error) {
-	msg := New(fmt.Sprintf("No such %s", entityName), err.Error(), sgerrors.NotFound, "")
+	msg := New(fmt.Sprintf("No such %s", entityName), err.Error(), sgerrors.NotFound, "").withDetail(err)
 
 	data, err := json.Marshal(msg)
 	if err != nil {
@@ -98,7 +127,7 @@ func SendNotFound(w http.ResponseWriter, entityName string, err error) {
 }
 
 func SendAlreadyExists(w http.ResponseWriter, entityName string, err error) {
-	msg := New(fmt.Sprintf("%s already exists", entityName), err.Error(), sgerrors.AlreadyExists, "")
+	msg := New(fmt.Sprintf("%s already exists", entityName), err.Error(), sgerrors.AlreadyExists, "").withDetail(err)
 
 	data, err := json.Marshal(msg)
 	if err != nil {
@@ -113,7 +142,7 @@ func SendAlreadyExists(w http.ResponseWriter, entityName string, err error) {
 
 func SendInvalidCredentials(w http.ResponseWriter, err error) {
 	msg := New("Credentials are bad for cloud provider",
-		err.Error(), sgerrors.InvalidCredentials, "")
+		err.Error(), sgerrors.InvalidCredentials, "").withDetail(err)
 
 	data, err := json.Marshal(msg)
 	if err != nil {
@@ -125,3 +154,135 @@ func SendInvalidCredentials(w http.ResponseWriter, err error) {
 	w.WriteHeader(http.StatusBadRequest)
 	w.Write(data)
 }
+
+func SendProtected(w http.ResponseWriter, entityName string, err error) {
+	msg := New(fmt.Sprintf("%s is protected from deletion", entityName), err.Error(), sgerrors.Protected, "").withDetail(err)
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		logrus.Errorf("failed to marshall message: %v", err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	w.Write(data)
+}
+
+func SendQuotaExceeded(w http.ResponseWriter, err error) {
+	msg := New("Quota is exceeded, please consult administrator", err.Error(), sgerrors.QuotaExceeded, "").withDetail(err)
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		logrus.Errorf("failed to marshall message: %v", err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	w.Write(data)
+}
+
+func SendRateLimited(w http.ResponseWriter, err error) {
+	msg := New("Too many requests, please retry later", err.Error(), sgerrors.RateLimited, "").withDetail(err)
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		logrus.Errorf("failed to marshall message: %v", err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	w.Write(data)
+}
+
+// SendTwoFactorRequired asks the client for a second factor of the login,
+// or to enroll one when it's required for the role of the user.
+func SendTwoFactorRequired(w http.ResponseWriter, err error) {
+	msg := New("Second factor is required, please provide the code of the authenticator", err.Error(),
+		sgerrors.TwoFactorRequired, "").withDetail(err)
+	if errors.Cause(err) == sgerrors.ErrTwoFactorEnrollment {
+		msg = New("Second factor is required for the role, please enroll an authenticator", err.Error(),
+			sgerrors.TwoFactorEnrollment, "").withDetail(err)
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		logrus.Errorf("failed to marshall message: %v", err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	w.Write(data)
+}
+
+// SendAccountLocked rejects logins of a locked account, the client may
+// retry after the duration of the lock.
+func SendAccountLocked(w http.ResponseWriter, retryAfter time.Duration, err error) {
+	msg := New("Account is locked after failed logins, please retry later", err.Error(),
+		sgerrors.AccountLocked, "").withDetail(err)
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		logrus.Errorf("failed to marshall message: %v", err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	w.WriteHeader(http.StatusTooManyRequests)
+	w.Write(data)
+}
+
+// SendPasswordExpired asks the user to change the password before logging in.
+func SendPasswordExpired(w http.ResponseWriter, err error) {
+	msg := New("Password has expired, please change it", err.Error(), sgerrors.PasswordExpired, "").withDetail(err)
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		logrus.Errorf("failed to marshall message: %v", err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	w.Write(data)
+}
+
+// SendOutsideMaintenance rejects a disruptive workflow that is started
+// outside of the maintenance window, it may be retried when the window opens.
+func SendOutsideMaintenance(w http.ResponseWriter, opens time.Time, err error) {
+	msg := New("Cluster is outside of its maintenance window, please retry when it opens or force the action",
+		err.Error(), sgerrors.OutsideMaintenance, "").withDetail(err)
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		logrus.Errorf("failed to marshall message: %v", err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !opens.IsZero() {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(opens).Seconds()))))
+	}
+	w.WriteHeader(http.StatusConflict)
+	w.Write(data)
+}
+
+// invalidField returns the first field that has failed the validation of
+// govalidator.
+func invalidField(err error) string {
+	switch v := errors.Cause(err).(type) {
+	case govalidator.Error:
+		return v.Name
+	case govalidator.Errors:
+		for _, e := range v {
+			if name := invalidField(e); name != "" {
+				return name
+			}
+		}
+	}
+	return ""
+}
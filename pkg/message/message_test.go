@@ -10,6 +10,7 @@ import (
 
 	"github.com/json-iterator/go"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/asaskevich/govalidator.v8"
 
 	"github.com/supergiant/control/pkg/sgerrors"
 )
@@ -261,3 +262,32 @@ func TestSendInvalidCredentials(t *testing.T) {
 			errMsg, msg2.DevMessage)
 	}
 }
+
+func TestSendErrorDetail(t *testing.T) {
+	rr := httptest.NewRecorder()
+	SendValidationFailed(rr, govalidator.Errors{govalidator.Error{Name: "region", Err: errors.New("required")}})
+
+	msg := &Message{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), msg))
+	require.Equal(t, sgerrors.ValidationFailed, msg.ErrorCode)
+	require.Equal(t, "region", msg.Field)
+	require.False(t, msg.Retryable)
+
+	rr = httptest.NewRecorder()
+	SendRateLimited(rr, sgerrors.WithStep(sgerrors.ErrRateLimited, "provision"))
+
+	msg = &Message{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), msg))
+	require.Equal(t, sgerrors.RateLimited, msg.ErrorCode)
+	require.Equal(t, "provision", msg.Step)
+	require.True(t, msg.Retryable)
+
+	rr = httptest.NewRecorder()
+	violations := sgerrors.Violations{{Field: "cidr", Message: "invalid"}, {Field: "region", Message: "unknown"}}
+	SendValidationFailed(rr, violations)
+
+	msg = &Message{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), msg))
+	require.Equal(t, "cidr", msg.Field)
+	require.Equal(t, violations, msg.Violations)
+}
@@ -10,4 +10,21 @@ type CloudAccount struct {
 	Name        string            `json:"name" valid:"required, length(1|32)"`
 	Provider    clouds.Name       `json:"provider" valid:"in(aws|digitalocean|gce|azure)"`
 	Credentials map[string]string `json:"credentials" valid:"optional"`
+	// DNS hosts zones of records of clusters of the account.
+	DNS AccountDNS `json:"dns" valid:"-"`
+	// Team the account belongs to, accounts without a team are shared.
+	Team string `json:"team,omitempty" valid:"-"`
+	// Labels group accounts, e.g. {"env": "prod"}.
+	Labels map[string]string `json:"labels,omitempty" valid:"-"`
+}
+
+// AccountDNS selects the DNS provider of the account.
+type AccountDNS struct {
+	// Provider is route53, google, azure, digitalocean or cloudflare, zones
+	// are in the DNS of the cloud of the account when it's empty.
+	Provider string `json:"provider,omitempty"`
+	// Credentials of the provider, e.g. {"apiToken": "..."} of cloudflare.
+	// Credentials of the account are used for the DNS of its cloud if
+	// they are empty.
+	Credentials map[string]string `json:"credentials,omitempty"`
 }
@@ -49,9 +49,29 @@ type Kube struct {
 	InternalDNSName string `json:"internalDNSName"`
 	BootstrapToken  string `json:"bootstrapToken"`
 
+	// EndpointNames are DNS names of records that point to the external
+	// endpoint of the API, they are in the DNS provider of the cloud account.
+	EndpointNames []string `json:"endpointNames,omitempty"`
+
+	StaticIPs profile.StaticIPs `json:"staticIps"`
+	// FreeStaticIPs are static ips of deleted masters, new masters take
+	// them before new addresses are reserved. They are released with the kube.
+	FreeStaticIPs []StaticIP `json:"freeStaticIps,omitempty"`
+	// Naming templates of cloud resources, machines added later are named
+	// with them as well.
+	Naming profile.Naming `json:"naming"`
+
 	CloudSpec profile.CloudSpecificSettings `json:"cloudSpec" valid:"-"`
 
 	ProfileID string `json:"profileId"`
+	// Owner is the login of the user who provisioned the kube, quotas of
+	// users are applied to kubes they own.
+	Owner string `json:"owner,omitempty"`
+	// Team the kube belongs to, kubes without a team are shared.
+	Team string `json:"team,omitempty" valid:"-"`
+	// Labels group kubes, e.g. {"env": "prod"}, they select kubes of lists,
+	// fleet actions and notification routes.
+	Labels map[string]string `json:"labels,omitempty" valid:"-"`
 
 	Masters map[string]*Machine `json:"masters"`
 	Nodes   map[string]*Machine `json:"nodes"`
@@ -59,10 +79,311 @@ type Kube struct {
 	Tasks map[string][]string `json:"tasks"`
 
 	SSHConfig SSHConfig `json:"sshConfig"`
+	// WinRMConfig is set for clusters that have windows nodes.
+	WinRMConfig WinRMConfig `json:"winrmConfig"`
 
 	UserData         string              `json:"userData"`
 	ExposedAddresses []profile.Addresses `json:"exposedAddresses"`
 	Addons           []string            `json:"addons,omitempty"`
+
+	AuditLog      profile.AuditLog    `json:"auditLog"`
+	CISHardening  bool                `json:"cisHardening"`
+	Admission     profile.Admission   `json:"admission"`
+	DNS           profile.DNS         `json:"dns"`
+	ExternalDNS   profile.ExternalDNS `json:"externalDns"`
+	CertManager   profile.CertManager `json:"certManager"`
+	Ingress       profile.Ingress     `json:"ingress"`
+	Sysctl        map[string]string   `json:"sysctl"`
+	KernelModules []string            `json:"kernelModules"`
+	NTPServers    []string            `json:"ntpServers"`
+
+	RegistryCredentials profile.RegistryCredentials `json:"registryCredentials"`
+
+	Certificates profile.Certificates `json:"certificates"`
+	RunnerType   string               `json:"runnerType"`
+
+	OSPatch OSPatch `json:"osPatch"`
+
+	EtcdMaintenance EtcdMaintenance `json:"etcdMaintenance"`
+
+	Maintenance MaintenanceWindow `json:"maintenance"`
+
+	Channel ReleaseChannel `json:"channel"`
+
+	Budget Budget `json:"budget"`
+
+	Drift DriftPolicy `json:"drift"`
+	// FirewallRules are ingress rules added to security groups of the cluster
+	// after provisioning, drift detection reports the missing ones.
+	FirewallRules []FirewallRule `json:"firewallRules,omitempty"`
+
+	Protection DeletionProtection `json:"protection"`
+
+	// StatusPage shares the status of the cluster with users without
+	// control accounts.
+	StatusPage StatusPage `json:"statusPage"`
+
+	Backup Backup `json:"backup"`
+
+	// Schedules run workflows on the cluster on cron expressions.
+	Schedules []ScheduledTask `json:"schedules,omitempty"`
+
+	// IngressEndpoint is a hostname or an ip of the load balancer of the
+	// ingress controller.
+	IngressEndpoint string `json:"ingressEndpoint,omitempty"`
+}
+
+// Backup configures Velero that backs up resources of the cluster to object
+// storage of its cloud provider.
+type Backup struct {
+	// Bucket of backups, Velero isn't installed when it's empty.
+	Bucket string `json:"bucket"`
+	// Prefix of backups of the cluster in the bucket, it's the kube ID by default.
+	Prefix string `json:"prefix"`
+	// Region of the bucket, e.g. nyc3 for DigitalOcean Spaces.
+	Region string `json:"region"`
+	// S3URL is an endpoint of S3 compatible storage.
+	S3URL string `json:"s3Url"`
+	// ResourceGroup and StorageAccount of the Azure blob container.
+	ResourceGroup  string `json:"resourceGroup"`
+	StorageAccount string `json:"storageAccount"`
+	// Version of Velero.
+	Version string `json:"version"`
+}
+
+// ReleaseChannel subscribes the cluster to patch versions of its kubernetes
+// minor version, they are upgraded to in the maintenance window.
+type ReleaseChannel struct {
+	// Name is the minor version and the channel, e.g. "1.14 stable", the
+	// cluster isn't subscribed when it is empty.
+	Name string `json:"name"`
+	// Paused opts the cluster out of upgrades, the subscription is kept.
+	Paused bool `json:"paused"`
+	// Pending is the patch version the cluster waits to be upgraded to.
+	Pending string `json:"pending,omitempty"`
+	// LastUpgrade is a unix time of the last upgrade started by the channel.
+	LastUpgrade int64 `json:"lastUpgrade,omitempty"`
+}
+
+// OSPatch configures os package upgrades of cluster machines.
+type OSPatch struct {
+	// Schedule is a cron expression, e.g. "0 3 * * 6", patching isn't
+	// scheduled when it is empty.
+	Schedule string `json:"schedule"`
+	// LivePatch skips a reboot when kernel live patching is active on a machine.
+	LivePatch bool `json:"livePatch"`
+	// LastRun is a unix time of the last scheduled patching.
+	LastRun int64 `json:"lastRun"`
+}
+
+// EtcdMaintenance configures periodic health checks and defragmentation
+// of etcd members running on cluster masters.
+type EtcdMaintenance struct {
+	// Schedule is a cron expression of the maintenance window start,
+	// maintenance isn't scheduled when it is empty.
+	Schedule string `json:"schedule"`
+	// Window is a maximum duration of the maintenance in minutes.
+	Window int `json:"window"`
+	// DefragThreshold is a percent of unused db space that triggers defragmentation.
+	DefragThreshold int `json:"defragThreshold"`
+	// QuotaBytes is the etcd backend quota.
+	QuotaBytes int64 `json:"quotaBytes"`
+	// AlertThreshold is a percent of the quota a db size is alerted at.
+	AlertThreshold int `json:"alertThreshold"`
+	// LastRun is a unix time of the last scheduled maintenance.
+	LastRun int64 `json:"lastRun"`
+	// Members holds the last known status of etcd members by machine name.
+	Members map[string]EtcdMemberStatus `json:"members"`
+}
+
+// Budget configures alerts about a projected monthly spend of the cluster.
+type Budget struct {
+	// Monthly is a limit in USD, alerts are disabled when it's zero.
+	Monthly float64 `json:"monthly"`
+	// WebhookURL receives a json alert and SlackWebhookURL
+	// a message for a Slack incoming webhook.
+	WebhookURL      string `json:"webhookUrl"`
+	SlackWebhookURL string `json:"slackWebhookUrl"`
+	// AlertedMonth is the last month alerts were sent for, e.g. "2019-05",
+	// a budget is alerted once a month.
+	AlertedMonth string `json:"alertedMonth"`
+}
+
+// DeletionProtection guards the cluster against accidental deletion.
+type DeletionProtection struct {
+	// Protected clusters aren't deleted until the flag is cleared.
+	Protected bool `json:"protected"`
+	// ConfirmDelete requires the cluster name in the confirm parameter
+	// of requests that delete the cluster or its machines.
+	ConfirmDelete bool `json:"confirmDelete"`
+}
+
+// StatusPage is a read-only page of health, maintenance and incidents of the
+// cluster, its requests carry the token of the page instead of credentials.
+type StatusPage struct {
+	Enabled bool `json:"enabled"`
+	// TokenHash is the hex SHA-256 of the token, the token itself is only
+	// returned when the page is enabled.
+	TokenHash string `json:"tokenHash,omitempty"`
+}
+
+// ProtectedMachine returns a name of a protected machine of the cluster,
+// it's empty when none of the machines is protected.
+func (k *Kube) ProtectedMachine() string {
+	for _, machines := range []map[string]*Machine{k.Masters, k.Nodes} {
+		for name, m := range machines {
+			if m != nil && m.Protected {
+				return name
+			}
+		}
+	}
+
+	return ""
+}
+
+// FreeStaticIP returns a static ip of a deleted master that no master has
+// taken yet, it's nil if there are none.
+func (k *Kube) FreeStaticIP() *StaticIP {
+	for i := range k.FreeStaticIPs {
+		ip := k.FreeStaticIPs[i]
+		if k.staticIPOwner(ip.ID) == "" {
+			return &ip
+		}
+	}
+	return nil
+}
+
+// TakeStaticIP removes the static ip from free ones.
+func (k *Kube) TakeStaticIP(id string) {
+	free := k.FreeStaticIPs[:0]
+	for _, ip := range k.FreeStaticIPs {
+		if ip.ID != id {
+			free = append(free, ip)
+		}
+	}
+	k.FreeStaticIPs = free
+}
+
+// AllStaticIPs returns static ips of masters and free ones.
+func (k *Kube) AllStaticIPs() []StaticIP {
+	ips := make([]StaticIP, 0, len(k.FreeStaticIPs)+len(k.Masters))
+	for _, m := range k.Masters {
+		if m != nil && m.StaticIP != nil {
+			ips = append(ips, *m.StaticIP)
+		}
+	}
+	for _, ip := range k.FreeStaticIPs {
+		if k.staticIPOwner(ip.ID) == "" {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+func (k *Kube) staticIPOwner(id string) string {
+	for name, m := range k.Masters {
+		if m != nil && m.StaticIP != nil && m.StaticIP.ID == id {
+			return name
+		}
+	}
+	return ""
+}
+
+// DriftPolicy configures drift detection of the cluster.
+type DriftPolicy struct {
+	// AutoRemediate restores instance tags, node labels and firewall rules
+	// when they drift.
+	AutoRemediate bool `json:"autoRemediate"`
+}
+
+// Security groups of firewall rules.
+const (
+	FirewallMasters = "masters"
+	FirewallNodes   = "nodes"
+)
+
+// FirewallRule allows ingress traffic from the cidr to masters or nodes.
+type FirewallRule struct {
+	Name string `json:"name"`
+	// Group is either masters or nodes.
+	Group string `json:"group"`
+	// Protocol is tcp, udp, icmp or -1 for all protocols.
+	Protocol    string `json:"protocol"`
+	FromPort    int64  `json:"fromPort"`
+	ToPort      int64  `json:"toPort"`
+	CIDR        string `json:"cidr"`
+	Description string `json:"description,omitempty"`
+}
+
+// FirewallRule returns the rule with the name, it's nil if there is none.
+func (k *Kube) FirewallRule(name string) *FirewallRule {
+	for i := range k.FirewallRules {
+		if k.FirewallRules[i].Name == name {
+			return &k.FirewallRules[i]
+		}
+	}
+	return nil
+}
+
+// Targets of scheduled tasks.
+const (
+	ScheduleTargetMaster   = "master"
+	ScheduleTargetMachines = "machines"
+)
+
+// States of runs of scheduled tasks.
+const (
+	RunRunning   = "running"
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+)
+
+// ScheduledTask runs a workflow on the cluster on a cron expression, e.g.
+// etcd backups, os patching, compliance scans or image refreshes.
+type ScheduledTask struct {
+	// Name is unique among schedules of the cluster.
+	Name     string `json:"name"`
+	Workflow string `json:"workflow"`
+	// Schedule is a cron expression, e.g. "0 3 * * 6".
+	Schedule string `json:"schedule"`
+	// Target is master to run the workflow on a master or machines to run
+	// it on linux machines one by one, it's master by default.
+	Target string `json:"target,omitempty"`
+	// Suspended schedules don't start runs, their last run is kept.
+	Suspended bool          `json:"suspended"`
+	LastRun   *ScheduledRun `json:"lastRun,omitempty"`
+}
+
+// ScheduledRun is the status of the last run of a scheduled task.
+type ScheduledRun struct {
+	// StartedAt and FinishedAt are unix times.
+	StartedAt  int64    `json:"startedAt"`
+	FinishedAt int64    `json:"finishedAt,omitempty"`
+	State      string   `json:"state"`
+	TaskIDs    []string `json:"taskIds,omitempty"`
+	Error      string   `json:"error,omitempty"`
+	// Skipped counts runs that were due while the run was in progress,
+	// SkippedAt is a unix time of the last one.
+	Skipped   int   `json:"skipped,omitempty"`
+	SkippedAt int64 `json:"skippedAt,omitempty"`
+}
+
+// Schedule returns the scheduled task with the name, it's nil if there is none.
+func (k *Kube) Schedule(name string) *ScheduledTask {
+	for i := range k.Schedules {
+		if k.Schedules[i].Name == name {
+			return &k.Schedules[i]
+		}
+	}
+	return nil
+}
+
+type EtcdMemberStatus struct {
+	DBSize       int64  `json:"dbSize"`
+	DBSizeInUse  int64  `json:"dbSizeInUse"`
+	Defragmented bool   `json:"defragmented"`
+	CheckedAt    int64  `json:"checkedAt"`
+	Alert        string `json:"alert,omitempty"`
 }
 
 type SSHConfig struct {
@@ -72,6 +393,53 @@ type SSHConfig struct {
 	BootstrapPublicKey  string `json:"bootstrapPublicKey"`
 	PublicKey           string `json:"publicKey"`
 	Timeout             int    `json:"timeout"`
+	// OperatorKeys are authorized on every machine of the cluster in
+	// addition to the bootstrap and user keys.
+	OperatorKeys []OperatorKey `json:"operatorKeys,omitempty"`
+	// Escalation is how commands gain root privileges when the user isn't
+	// root, see runner.Escalation*.
+	Escalation   string `json:"escalation,omitempty"`
+	SudoPassword string `json:"sudoPassword,omitempty"`
+}
+
+// For returns the config of the machine, the user and the port of its node
+// profile override ones of the kube.
+func (c SSHConfig) For(m Machine) SSHConfig {
+	if m.SSHUser != "" {
+		c.User = m.SSHUser
+	}
+	if m.SSHPort != "" {
+		c.Port = m.SSHPort
+	}
+	return c
+}
+
+// OperatorKey is a public key of a person with ssh access to cluster machines.
+type OperatorKey struct {
+	Name        string `json:"name"`
+	PublicKey   string `json:"publicKey"`
+	Fingerprint string `json:"fingerprint"`
+	AddedBy     string `json:"addedBy,omitempty"`
+	CreatedAt   int64  `json:"createdAt"`
+}
+
+// AuthorizedKey is a key of an authorized_keys file of a machine.
+type AuthorizedKey struct {
+	File        string `json:"file"`
+	Type        string `json:"type"`
+	Fingerprint string `json:"fingerprint"`
+	Comment     string `json:"comment,omitempty"`
+	// Managed names the key if it's known to control, e.g. bootstrap or
+	// a name of an operator key.
+	Managed string `json:"managed,omitempty"`
+}
+
+// WinRMConfig holds credentials of the WinRM listeners
+// that are configured on windows machines.
+type WinRMConfig struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+	Port     int    `json:"port"`
 }
 
 // Auth holds all possible auth parameters.
@@ -87,6 +455,8 @@ type Auth struct {
 	AdminCert      string             `json:"adminCert"`
 	AdminKey       string             `json:"adminKey"`
 	CertificateKey string             `json:"certificateKey"`
+	EtcdCACert     string             `json:"etcdCACert"`
+	EtcdCAKey      string             `json:"etcdCAKey"`
 	StaticAuth     profile.StaticAuth `json:"staticAuth"`
 }
 
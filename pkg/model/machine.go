@@ -25,6 +25,8 @@ const (
 
 	RoleMaster Role = "master"
 	RoleNode   Role = "node"
+
+	OSWindows = "windows"
 )
 
 type Machine struct {
@@ -41,6 +43,44 @@ type Machine struct {
 	State            MachineState `json:"state"`
 	Name             string       `json:"name"`
 	SelfLink         string       `json:"selfLink"`
+	// OperatingSystem is empty for linux machines
+	OperatingSystem string `json:"os,omitempty"`
+	// Image the machine was created from, families of node profiles are
+	// resolved to it, so the machine can be reproduced.
+	Image string `json:"image,omitempty"`
+	// Protected machines aren't deleted until the flag is cleared.
+	Protected bool `json:"protected"`
+	// Conditions are problems reported for the kubernetes node of the machine
+	// by the kubelet and node-problem-detector, healthy nodes have none.
+	Conditions []NodeCondition `json:"conditions,omitempty"`
+	// StaticIP is the public address of the machine when it's a master of
+	// a kube with static ips, the address stays with the kube when the
+	// machine is deleted.
+	StaticIP *StaticIP `json:"staticIp,omitempty"`
+	// SSHUser and SSHPort are set when the node profile of the machine
+	// overrides ssh settings of the kube.
+	SSHUser string `json:"sshUser,omitempty"`
+	SSHPort string `json:"sshPort,omitempty"`
+	// NodeGroup of the node profile the machine was created from.
+	NodeGroup string `json:"nodeGroup,omitempty"`
+}
+
+// StaticIP is a public address that is reserved in the cloud, it isn't
+// released when the machine it's attached to is replaced.
+type StaticIP struct {
+	// ID is the allocation id of an elastic ip on AWS and the name of the
+	// address on GCE, reserved ips of DigitalOcean are their addresses.
+	ID      string `json:"id"`
+	Address string `json:"address"`
+}
+
+// NodeCondition is a problem of a kubernetes node, e.g. KernelDeadlock.
+type NodeCondition struct {
+	Type    string `json:"type"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Since is when the problem was first observed.
+	Since int64 `json:"since"`
 }
 
 func (m Machine) String() string {
@@ -0,0 +1,152 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// MaintenanceWindow limits when disruptive workflows run on the cluster:
+// upgrades, os patching, runtime upgrades and etcd maintenance. They are
+// deferred until the window opens unless they are forced.
+type MaintenanceWindow struct {
+	// Days the window opens on, e.g. ["sat", "sun"], it opens every day
+	// when it's empty.
+	Days []string `json:"days"`
+	// Start and End are hh:mm of the local time of the window, it spans
+	// midnight when End is before Start and lasts a day when they are equal.
+	// Disruptive workflows aren't limited when Start is empty.
+	Start string `json:"start"`
+	End   string `json:"end"`
+	// Timezone is an IANA name, e.g. Europe/Berlin, it's UTC by default.
+	Timezone string `json:"timezone"`
+}
+
+// IsSet tells whether disruptive workflows are limited by the window.
+func (w MaintenanceWindow) IsSet() bool {
+	return w.Start != ""
+}
+
+// Validate checks days, hours and the timezone of the window.
+func (w MaintenanceWindow) Validate() error {
+	if !w.IsSet() {
+		if w.End != "" || len(w.Days) > 0 {
+			return errors.New("start of the window is required")
+		}
+		return nil
+	}
+
+	for _, day := range w.Days {
+		if _, ok := weekdays[strings.ToLower(day)]; !ok {
+			return errors.Errorf("unknown day %q", day)
+		}
+	}
+	if _, err := clock(w.Start); err != nil {
+		return errors.Wrap(err, "start")
+	}
+	if _, err := clock(w.End); err != nil {
+		return errors.Wrap(err, "end")
+	}
+	if _, err := time.LoadLocation(w.Timezone); err != nil {
+		return errors.Wrapf(err, "timezone %q", w.Timezone)
+	}
+	return nil
+}
+
+// Contains tells whether the window is open at the time, an unset window
+// is always open.
+func (w MaintenanceWindow) Contains(t time.Time) bool {
+	if !w.IsSet() {
+		return true
+	}
+
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return false
+	}
+	start, err := clock(w.Start)
+	if err != nil {
+		return false
+	}
+	end, err := clock(w.End)
+	if err != nil {
+		return false
+	}
+
+	t = t.In(loc)
+	now := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	switch {
+	case start == end:
+		return w.opensOn(t.Weekday())
+	case start < end:
+		return start <= now && now < end && w.opensOn(t.Weekday())
+	}
+	// the window spans midnight, it's open since the start of the day or
+	// till the end on the next day
+	if now >= start {
+		return w.opensOn(t.Weekday())
+	}
+	return now < end && w.opensOn(t.AddDate(0, 0, -1).Weekday())
+}
+
+// Next returns the time the window opens at after t, it's t when the window
+// is open. The zero time is returned when the window never opens.
+func (w MaintenanceWindow) Next(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return time.Time{}
+	}
+	start, err := clock(w.Start)
+	if err != nil {
+		return time.Time{}
+	}
+
+	local := t.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	for i := 0; i <= 7; i++ {
+		day := midnight.AddDate(0, 0, i)
+		if !w.opensOn(day.Weekday()) {
+			continue
+		}
+		if opens := day.Add(start); opens.After(t) {
+			return opens
+		}
+	}
+	return time.Time{}
+}
+
+func (w MaintenanceWindow) opensOn(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if weekdays[strings.ToLower(d)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// clock parses hh:mm to a duration since midnight.
+func clock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, errors.Errorf("%q isn't hh:mm", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
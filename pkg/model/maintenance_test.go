@@ -0,0 +1,72 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaintenanceWindow_Contains(t *testing.T) {
+	// 2019-06-01 is a saturday
+	sat := func(hour, min int) time.Time {
+		return time.Date(2019, 6, 1, hour, min, 0, 0, time.UTC)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		window   MaintenanceWindow
+		at       time.Time
+		expected bool
+	}{
+		{"unset", MaintenanceWindow{}, sat(12, 0), true},
+		{"within", MaintenanceWindow{Start: "02:00", End: "04:00"}, sat(3, 0), true},
+		{"end", MaintenanceWindow{Start: "02:00", End: "04:00"}, sat(4, 0), false},
+		{"another day", MaintenanceWindow{Days: []string{"sun"}, Start: "02:00", End: "04:00"}, sat(3, 0), false},
+		{"whole day", MaintenanceWindow{Days: []string{"Sat"}, Start: "00:00", End: "00:00"}, sat(18, 0), true},
+		{"before midnight", MaintenanceWindow{Days: []string{"sat"}, Start: "22:00", End: "02:00"}, sat(23, 0), true},
+		{"after midnight", MaintenanceWindow{Days: []string{"fri"}, Start: "22:00", End: "02:00"}, sat(1, 0), true},
+		{"after midnight of another day", MaintenanceWindow{Days: []string{"sat"}, Start: "22:00", End: "02:00"}, sat(1, 0), false},
+		// 03:00 UTC is 05:00 in Berlin in summer
+		{"timezone", MaintenanceWindow{Start: "04:00", End: "06:00", Timezone: "Europe/Berlin"}, sat(3, 0), true},
+	} {
+		if actual := tc.window.Contains(tc.at); actual != tc.expected {
+			t.Errorf("%s: expected %v actual %v", tc.name, tc.expected, actual)
+		}
+	}
+}
+
+func TestMaintenanceWindow_Next(t *testing.T) {
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	w := MaintenanceWindow{Days: []string{"sun"}, Start: "02:00", End: "04:00"}
+	if next := w.Next(now); !next.Equal(time.Date(2019, 6, 2, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected next window %v", next)
+	}
+
+	w = MaintenanceWindow{Start: "02:00", End: "04:00"}
+	if next := w.Next(now); !next.Equal(time.Date(2019, 6, 2, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected next window %v", next)
+	}
+
+	if next := (MaintenanceWindow{}).Next(now); !next.Equal(now) {
+		t.Errorf("unset window must be open, next %v", next)
+	}
+}
+
+func TestMaintenanceWindow_Validate(t *testing.T) {
+	for _, w := range []MaintenanceWindow{
+		{End: "04:00"},
+		{Start: "2am", End: "04:00"},
+		{Start: "02:00", End: "24:30"},
+		{Days: []string{"someday"}, Start: "02:00", End: "04:00"},
+		{Start: "02:00", End: "04:00", Timezone: "Mars/Olympus"},
+	} {
+		if err := w.Validate(); err == nil {
+			t.Errorf("window %+v must be invalid", w)
+		}
+	}
+
+	w := MaintenanceWindow{Days: []string{"sat", "sun"}, Start: "22:00", End: "02:00", Timezone: "America/New_York"}
+	if err := w.Validate(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
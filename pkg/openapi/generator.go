@@ -0,0 +1,191 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	jsonType       = "application/json"
+	bearerAuth     = "bearerAuth"
+	errorsResponse = "default"
+)
+
+var pathParam = regexp.MustCompile(`{([^}:]+)(:[^}]*)?}`)
+
+// Doc documents an operation, Request and Response are values of the models
+// of the request and the response bodies, they are optional. Query are
+// parameters of the query.
+type Doc struct {
+	Summary  string
+	Tags     []string
+	Query    []Parameter
+	Request  interface{}
+	Response interface{}
+}
+
+// Generator builds the specification of the routes of a router.
+type Generator struct {
+	info          Info
+	errorModel    interface{}
+	securedPrefix string
+	docs          map[string]Doc
+}
+
+// NewGenerator returns a generator, errorModel is the body of error responses.
+func NewGenerator(info Info, errorModel interface{}) *Generator {
+	return &Generator{
+		info:       info,
+		errorModel: errorModel,
+		docs:       make(map[string]Doc),
+	}
+}
+
+// Secure requires bearer tokens for paths under the prefix.
+func (g *Generator) Secure(prefix string) {
+	g.securedPrefix = prefix
+}
+
+// Describe documents the operation of the route with the path template.
+func (g *Generator) Describe(method, path string, doc Doc) {
+	g.docs[method+" "+path] = doc
+}
+
+// Generate returns the specification of all routes of the router, routes
+// that match path prefixes aren't REST endpoints and are skipped.
+func (g *Generator) Generate(router *mux.Router) (*Document, error) {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    g.info,
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			SecuritySchemes: map[string]SecurityScheme{
+				bearerAuth: {
+					Type:         "http",
+					Scheme:       "bearer",
+					BearerFormat: "JWT",
+				},
+			},
+		},
+	}
+	schemas := newRegistry()
+
+	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			// subrouters without paths
+			return nil
+		}
+		if re, err := route.GetPathRegexp(); err != nil || !strings.HasSuffix(re, "$") {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil || len(methods) == 0 {
+			if route.GetHandler() == nil {
+				return nil
+			}
+			methods = []string{http.MethodGet}
+		}
+
+		path := pathParam.ReplaceAllString(tpl, "{$1}")
+		for _, method := range methods {
+			item, ok := doc.Paths[path]
+			if !ok {
+				item = make(PathItem)
+				doc.Paths[path] = item
+			}
+			item[strings.ToLower(method)] = g.operation(schemas, method, tpl, path)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	doc.Components.Schemas = schemas.schemas
+
+	return doc, nil
+}
+
+func (g *Generator) operation(schemas *registry, method, tpl, path string) *Operation {
+	d := g.docs[method+" "+tpl]
+	op := &Operation{
+		OperationID: operationID(method, path),
+		Summary:     d.Summary,
+		Tags:        d.Tags,
+		Responses:   make(map[string]Response),
+	}
+	if len(op.Tags) == 0 {
+		op.Tags = []string{tag(strings.TrimPrefix(path, g.securedPrefix))}
+	}
+
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     m[1],
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	for _, p := range d.Query {
+		p.In = "query"
+		op.Parameters = append(op.Parameters, p)
+	}
+
+	if d.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]MediaType{
+				jsonType: {Schema: schemas.schemaOf(reflect.TypeOf(d.Request))},
+			},
+		}
+	}
+
+	ok := Response{Description: "OK"}
+	if d.Response != nil {
+		ok.Content = map[string]MediaType{
+			jsonType: {Schema: schemas.schemaOf(reflect.TypeOf(d.Response))},
+		}
+	}
+	op.Responses["200"] = ok
+	if g.errorModel != nil {
+		op.Responses[errorsResponse] = Response{
+			Description: "Error",
+			Content: map[string]MediaType{
+				jsonType: {Schema: schemas.schemaOf(reflect.TypeOf(g.errorModel))},
+			},
+		}
+	}
+
+	if g.securedPrefix != "" && strings.HasPrefix(path, g.securedPrefix) {
+		op.Security = []map[string][]string{{bearerAuth: {}}}
+	}
+
+	return op
+}
+
+// operationID is the method and the path in camel case, e.g. getKubesKubeID.
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '_' || r == '.'
+	}) {
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+
+	return id
+}
+
+// tag is the first segment of the path.
+func tag(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if parts[0] == "" {
+		return "default"
+	}
+
+	return parts[0]
+}
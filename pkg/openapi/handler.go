@@ -0,0 +1,80 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/message"
+)
+
+const uiPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<title>Supergiant Control API</title>
+	<link rel="stylesheet" href="https://cdnjs.cloudflare.com/ajax/libs/swagger-ui/3.24.0/swagger-ui.css">
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://cdnjs.cloudflare.com/ajax/libs/swagger-ui/3.24.0/swagger-ui-bundle.js"></script>
+	<script type="text/javascript">
+		window.onload = function() {
+			SwaggerUIBundle({
+				url: "/swagger.json",
+				dom_id: "#swagger-ui",
+			});
+		};
+	</script>
+</body>
+</html>`
+
+// Handler serves the specification and the UI to browse it.
+type Handler struct {
+	generator *Generator
+	router    *mux.Router
+
+	once sync.Once
+	spec []byte
+	err  error
+}
+
+// NewHandler returns a handler of the specification of the router, it's
+// generated on the first request when all routes are registered.
+func NewHandler(generator *Generator, router *mux.Router) *Handler {
+	return &Handler{
+		generator: generator,
+		router:    router,
+	}
+}
+
+func (h *Handler) Register(r *mux.Router) {
+	r.HandleFunc("/swagger.json", h.GetSpec).Methods(http.MethodGet)
+	r.HandleFunc("/swagger", h.GetUI).Methods(http.MethodGet)
+}
+
+// GetSpec returns the OpenAPI specification.
+func (h *Handler) GetSpec(w http.ResponseWriter, r *http.Request) {
+	h.once.Do(func() {
+		var doc *Document
+		if doc, h.err = h.generator.Generate(h.router); h.err == nil {
+			h.spec, h.err = json.MarshalIndent(doc, "", "  ")
+		}
+	})
+	if h.err != nil {
+		logrus.Errorf("openapi: generate specification: %v", h.err)
+		message.SendUnknownError(w, h.err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(h.spec)
+}
+
+// GetUI returns the page of swagger UI.
+func (h *Handler) GetUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(uiPage))
+}
@@ -0,0 +1,115 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+type node struct {
+	Name     string            `json:"name"`
+	Children []*node           `json:"children"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Created  time.Time         `json:"created"`
+	Data     []byte            `json:"data"`
+	Ignored  string            `json:"-"`
+	NoTag    int
+	internal string
+	base
+}
+
+type base struct {
+	ID string `json:"id"`
+}
+
+type apiError struct {
+	Message string `json:"message"`
+}
+
+func noop(http.ResponseWriter, *http.Request) {}
+
+func testRouter() *mux.Router {
+	r := mux.NewRouter()
+	r.HandleFunc("/version", noop)
+	api := r.PathPrefix("/v1/api").Subrouter()
+	api.HandleFunc("/nodes", noop).Methods(http.MethodGet, http.MethodPost)
+	api.HandleFunc("/nodes/{id}", noop).Methods(http.MethodGet)
+	api.HandleFunc("/nodes/{id:[0-9]+}/children", noop).Methods(http.MethodGet)
+	r.PathPrefix("/").HandlerFunc(noop)
+
+	return r
+}
+
+func TestGenerate(t *testing.T) {
+	g := NewGenerator(Info{Title: "test", Version: "v1"}, apiError{})
+	g.Secure("/v1/api")
+	g.Describe(http.MethodPost, "/v1/api/nodes", Doc{Summary: "Create a node", Request: node{}})
+	g.Describe(http.MethodGet, "/v1/api/nodes/{id}", Doc{Response: &node{}})
+	g.Describe(http.MethodGet, "/v1/api/nodes", Doc{Query: []Parameter{{Name: "limit", Schema: &Schema{Type: "integer"}}}})
+
+	doc, err := g.Generate(testRouter())
+	require.NoError(t, err)
+
+	require.Len(t, doc.Paths, 4)
+	require.Contains(t, doc.Paths, "/version")
+	require.NotContains(t, doc.Paths, "/")
+	require.Len(t, doc.Paths["/v1/api/nodes"], 2)
+
+	create := doc.Paths["/v1/api/nodes"]["post"]
+	require.Equal(t, "postV1ApiNodes", create.OperationID)
+	require.Equal(t, "Create a node", create.Summary)
+	require.Equal(t, []string{"nodes"}, create.Tags)
+	require.Equal(t, "#/components/schemas/openapi.node", create.RequestBody.Content[jsonType].Schema.Ref)
+	require.Equal(t, "#/components/schemas/openapi.apiError", create.Responses[errorsResponse].Content[jsonType].Schema.Ref)
+	require.Len(t, create.Security, 1)
+	require.Equal(t, []Parameter{{Name: "limit", In: "query", Schema: &Schema{Type: "integer"}}},
+		doc.Paths["/v1/api/nodes"]["get"].Parameters)
+	require.Empty(t, doc.Paths["/version"]["get"].Security)
+
+	children := doc.Paths["/v1/api/nodes/{id}/children"]["get"]
+	require.Equal(t, []Parameter{{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}}}, children.Parameters)
+
+	s := doc.Components.Schemas["openapi.node"]
+	require.NotNil(t, s)
+	require.Equal(t, "#/components/schemas/openapi.node", s.Properties["children"].Items.Ref)
+	require.Equal(t, "string", s.Properties["labels"].AdditionalProperties.Type)
+	require.Equal(t, "date-time", s.Properties["created"].Format)
+	require.Equal(t, "byte", s.Properties["data"].Format)
+	require.Equal(t, "integer", s.Properties["NoTag"].Type)
+	require.Equal(t, "string", s.Properties["id"].Type)
+	require.Len(t, s.Properties, 7)
+
+	ids := make(map[string]bool)
+	for _, item := range doc.Paths {
+		for _, op := range item {
+			require.False(t, ids[op.OperationID], op.OperationID)
+			ids[op.OperationID] = true
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := testRouter()
+	h := NewHandler(NewGenerator(Info{Title: "test"}, nil), r)
+	router := mux.NewRouter()
+	h.Register(router)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/swagger.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	doc := &Document{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), doc))
+	require.Equal(t, Version, doc.OpenAPI)
+	require.Contains(t, doc.Paths, "/v1/api/nodes/{id}")
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/swagger", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "/swagger.json")
+}
@@ -0,0 +1,156 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textType      = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// registry keeps schemas of named structs as components, they are
+// referenced by other schemas.
+type registry struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newRegistry() *registry {
+	return &registry{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// schemaOf returns the schema of values of t encoded to json, it's nil for
+// types that can't be encoded.
+func (r *registry) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType):
+		// the encoding is custom
+		return &Schema{}
+	case t.Implements(textType) || reflect.PtrTo(t).Implements(textType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		items := r.schemaOf(t.Elem())
+		if items == nil {
+			return nil
+		}
+		return &Schema{Type: "array", Items: items}
+	case reflect.Map:
+		values := r.schemaOf(t.Elem())
+		if values == nil {
+			return nil
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t)
+		}
+		return r.ref(t)
+	}
+
+	// channels, functions and complex numbers aren't encoded
+	return nil
+}
+
+func (r *registry) ref(t reflect.Type) *Schema {
+	name, ok := r.names[t]
+	if !ok {
+		name = r.name(t)
+		r.names[t] = name
+		// the placeholder stops recursion of self referencing types
+		r.schemas[name] = &Schema{}
+		*r.schemas[name] = *r.structSchema(t)
+	}
+
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// name is the package name and the type name, the import path is used when
+// types of different packages have the same names.
+func (r *registry) name(t reflect.Type) string {
+	name := path.Base(t.PkgPath()) + "." + t.Name()
+	if _, taken := r.schemas[name]; taken {
+		name = strings.Replace(t.PkgPath(), "/", ".", -1) + "." + t.Name()
+	}
+
+	return name
+}
+
+func (r *registry) structSchema(t reflect.Type) *Schema {
+	s := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		// fields of embedded structs are promoted
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			embedded := r.structSchema(ft)
+			for k, v := range embedded.Properties {
+				if _, ok := s.Properties[k]; !ok {
+					s.Properties[k] = v
+				}
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			// unexported
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		if fs := r.schemaOf(f.Type); fs != nil {
+			s.Properties[name] = fs
+		}
+	}
+
+	return s
+}
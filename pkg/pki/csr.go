@@ -0,0 +1,108 @@
+package pki
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/storage"
+)
+
+// CSRStoragePrefix is used to keep private keys of pending certificate signing requests.
+const CSRStoragePrefix = "/supergiant/pki/csr/"
+
+// CSR is a certificate signing request for a cluster CA that is signed by an offline CA.
+type CSR struct {
+	ID         string    `json:"id"`
+	CommonName string    `json:"commonName"`
+	Request    string    `json:"csr"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type pendingCSR struct {
+	CSR
+	Key string `json:"key"`
+}
+
+// CSRService keeps certificate signing requests until signed certificates are provided.
+type CSRService struct {
+	repository storage.Interface
+}
+
+func NewCSRService(repository storage.Interface) *CSRService {
+	return &CSRService{
+		repository: repository,
+	}
+}
+
+// Create generates a private key and a CSR for a cluster CA.
+func (s *CSRService) Create(ctx context.Context, commonName string) (*CSR, error) {
+	if commonName == "" {
+		commonName = DefaultCACommonName
+	}
+
+	csr, key, err := NewCACSR(commonName)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &pendingCSR{
+		CSR: CSR{
+			ID:         uuid.New(),
+			CommonName: commonName,
+			Request:    string(csr),
+			CreatedAt:  time.Now().UTC(),
+		},
+		Key: string(key),
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal")
+	}
+	if err = s.repository.Put(ctx, CSRStoragePrefix, p.ID, data); err != nil {
+		return nil, errors.Wrap(err, "save csr")
+	}
+
+	return &p.CSR, nil
+}
+
+// Get returns a CSR without its private key.
+func (s *CSRService) Get(ctx context.Context, id string) (*CSR, error) {
+	p, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &p.CSR, nil
+}
+
+// Complete builds a cluster CA from the certificate signed for the CSR.
+func (s *CSRService) Complete(ctx context.Context, id string, signedPEM []byte) (*PairPEM, []byte, error) {
+	p, err := s.get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return NewCAPairFromSigned(signedPEM, []byte(p.Key))
+}
+
+// Delete removes the CSR and its private key.
+func (s *CSRService) Delete(ctx context.Context, id string) error {
+	return s.repository.Delete(ctx, CSRStoragePrefix, id)
+}
+
+func (s *CSRService) get(ctx context.Context, id string) (*pendingCSR, error) {
+	data, err := s.repository.Get(ctx, CSRStoragePrefix, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "get csr")
+	}
+
+	p := &pendingCSR{}
+	if err = json.Unmarshal(data, p); err != nil {
+		return nil, errors.Wrap(err, "unmarshal")
+	}
+	return p, nil
+}
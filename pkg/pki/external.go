@@ -0,0 +1,146 @@
+package pki
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// CertificateRequestBlockType is a possible value for pem.Block.Type.
+	CertificateRequestBlockType = "CERTIFICATE REQUEST"
+
+	// DefaultCACommonName is the common name kubeadm uses for a cluster CA.
+	DefaultCACommonName = "kubernetes"
+
+	intermediateCADuration = duration365d * 10
+)
+
+// NewIntermediateCAPair creates a cluster CA signed by the parent CA, so certificates
+// of the cluster chain to the parent. The parent key is used only for signing
+// and isn't a part of the result.
+func NewIntermediateCAPair(commonName string, parentEncoded *PairPEM) (*PairPEM, error) {
+	parent, err := Decode(parentEncoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode parent ca cert/key")
+	}
+	if !parent.Cert.IsCA {
+		return nil, ErrInvalidCA
+	}
+
+	key, err := newPrivateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "generate private key")
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).SetInt64(math.MaxInt64))
+	if err != nil {
+		return nil, err
+	}
+
+	notAfter := time.Now().Add(intermediateCADuration).UTC()
+	if parent.Cert.NotAfter.Before(notAfter) {
+		notAfter = parent.Cert.NotAfter
+	}
+
+	certTmpl := x509.Certificate{
+		Subject:               pkix.Name{CommonName: commonName},
+		SerialNumber:          serial,
+		NotBefore:             time.Now().UTC(),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDERBytes, err := x509.CreateCertificate(rand.Reader, &certTmpl, parent.Cert, key.Public(), parent.Key)
+	if err != nil {
+		return nil, errors.Wrap(err, "sign intermediate ca")
+	}
+	cert, err := x509.ParseCertificate(certDERBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse intermediate ca")
+	}
+
+	return &PairPEM{
+		Cert:     encodeCertPEM(cert),
+		Key:      encodePrivateKeyPEM(key),
+		CertHash: certHash(cert),
+	}, nil
+}
+
+// NewCACSR creates a private key and a certificate signing request for an intermediate
+// cluster CA, it is used when the parent CA key isn't available to control, e.g. for
+// Vault PKI or fully offline CAs.
+func NewCACSR(commonName string) (csrPEM []byte, keyPEM []byte, err error) {
+	key, err := newPrivateKey()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "generate private key")
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: commonName},
+	}, key)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "create certificate request")
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: CertificateRequestBlockType, Bytes: csr}),
+		encodePrivateKeyPEM(key),
+		nil
+}
+
+// NewCAPairFromSigned builds a cluster CA from a certificate signed by an external CA
+// for a CSR created with NewCACSR. The signed certificate may be followed by its chain,
+// the chain is returned separately.
+func NewCAPairFromSigned(signedPEM, keyPEM []byte) (*PairPEM, []byte, error) {
+	block, chain := pem.Decode(bytes.TrimSpace(signedPEM))
+	if block == nil || block.Type != CertificateBlockType {
+		return nil, nil, errors.New("decode signed certificate pem")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "parse signed certificate")
+	}
+	if !cert.IsCA {
+		return nil, nil, ErrInvalidCA
+	}
+
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, nil, errors.New("decode private key pem")
+	}
+	key, err := x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "parse private key")
+	}
+
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok || pub.N.Cmp(key.N) != 0 || pub.E != key.E {
+		return nil, nil, errors.New("signed certificate doesn't match the private key")
+	}
+
+	return &PairPEM{
+		Cert:     encodeCertPEM(cert),
+		Key:      encodePrivateKeyPEM(key),
+		CertHash: certHash(cert),
+	}, bytes.TrimSpace(chain), nil
+}
+
+// certHash returns a kubeadm discovery hash of the certificate public key.
+func certHash(cert *x509.Certificate) string {
+	spkiHash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return fmt.Sprintf("sha256:%s", strings.ToLower(hex.EncodeToString(spkiHash[:])))
+}
@@ -0,0 +1,168 @@
+package pki
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage/memory"
+)
+
+func TestNewIntermediateCAPair(t *testing.T) {
+	parent, err := NewCAPair(nil)
+	require.Nil(t, err)
+
+	admin, err := NewAdminPair(parent)
+	require.Nil(t, err)
+
+	_, err = NewIntermediateCAPair(DefaultCACommonName, admin)
+	require.Equal(t, ErrInvalidCA, err)
+
+	ca, err := NewIntermediateCAPair(DefaultCACommonName, parent)
+	require.Nil(t, err)
+	require.True(t, strings.HasPrefix(ca.CertHash, "sha256:"))
+
+	// leaf certificates must chain to the parent CA
+	user, err := NewUserPair("user", nil, ca)
+	require.Nil(t, err)
+	requireChain(t, user.Cert, ca.Cert, parent.Cert)
+}
+
+func TestNewCAPairFromSigned(t *testing.T) {
+	parent, err := NewCAPair(nil)
+	require.Nil(t, err)
+
+	csr, key, err := NewCACSR(DefaultCACommonName)
+	require.Nil(t, err)
+
+	signed := signCSR(t, csr, parent)
+
+	ca, chain, err := NewCAPairFromSigned(append(signed, parent.Cert...), key)
+	require.Nil(t, err)
+	require.Equal(t, strings.TrimSpace(string(parent.Cert)), string(chain))
+	requireChain(t, ca.Cert, ca.Cert, parent.Cert)
+
+	_, otherKey, err := NewCACSR(DefaultCACommonName)
+	require.Nil(t, err)
+	_, _, err = NewCAPairFromSigned(signed, otherKey)
+	require.NotNil(t, err, "signed cert and key mismatch")
+
+	_, _, err = NewCAPairFromSigned([]byte("cert"), key)
+	require.NotNil(t, err, "invalid pem")
+}
+
+func TestVaultSigner_SignIntermediate(t *testing.T) {
+	parent, err := NewCAPair(nil)
+	require.Nil(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/corp-pki/root/sign-intermediate" || r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+
+		req := &vaultSignRequest{}
+		require.Nil(t, json.NewDecoder(r.Body).Decode(req))
+
+		resp := &vaultSignResponse{}
+		resp.Data.Certificate = string(signCSR(t, []byte(req.CSR), parent))
+		resp.Data.IssuingCA = string(parent.Cert)
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	csr, key, err := NewCACSR(DefaultCACommonName)
+	require.Nil(t, err)
+
+	signer := &VaultSigner{Address: srv.URL, Token: "token", Mount: "/corp-pki/"}
+	cert, chain, err := signer.SignIntermediate(context.Background(), DefaultCACommonName, csr)
+	require.Nil(t, err)
+	require.Equal(t, string(parent.Cert), string(chain))
+
+	_, _, err = NewCAPairFromSigned(cert, key)
+	require.Nil(t, err)
+
+	signer.Token = "wrong"
+	_, _, err = signer.SignIntermediate(context.Background(), DefaultCACommonName, csr)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "permission denied")
+}
+
+func TestCSRService(t *testing.T) {
+	parent, err := NewCAPair(nil)
+	require.Nil(t, err)
+
+	svc := NewCSRService(memory.NewInMemoryRepository())
+
+	csr, err := svc.Create(context.Background(), "")
+	require.Nil(t, err)
+	require.Equal(t, DefaultCACommonName, csr.CommonName)
+
+	got, err := svc.Get(context.Background(), csr.ID)
+	require.Nil(t, err)
+	require.Equal(t, csr.Request, got.Request)
+
+	ca, _, err := svc.Complete(context.Background(), csr.ID, signCSR(t, []byte(csr.Request), parent))
+	require.Nil(t, err)
+	requireChain(t, ca.Cert, ca.Cert, parent.Cert)
+
+	require.Nil(t, svc.Delete(context.Background(), csr.ID))
+	_, err = svc.Get(context.Background(), csr.ID)
+	require.True(t, sgerrors.IsNotFound(err))
+}
+
+// signCSR issues a CA certificate for the csr like an external CA does.
+func signCSR(t *testing.T, csrPEM []byte, parentEncoded *PairPEM) []byte {
+	parent, err := Decode(parentEncoded)
+	require.Nil(t, err)
+
+	block, _ := pem.Decode(csrPEM)
+	require.NotNil(t, block)
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	require.Nil(t, err)
+
+	tmpl := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: csr.Subject.CommonName},
+		SerialNumber:          big.NewInt(2),
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent.Cert, csr.PublicKey, parent.Key)
+	require.Nil(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: CertificateBlockType, Bytes: der})
+}
+
+func requireChain(t *testing.T, leafPEM, intermediatePEM, rootPEM []byte) {
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(rootPEM))
+	intermediates := x509.NewCertPool()
+	require.True(t, intermediates.AppendCertsFromPEM(intermediatePEM))
+
+	block, _ := pem.Decode(leafPEM)
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	require.Nil(t, err)
+
+	_, err = leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	require.Nil(t, err)
+}
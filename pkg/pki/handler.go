@@ -0,0 +1,108 @@
+package pki
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+type csrCreateRequest struct {
+	CommonName string `json:"commonName"`
+}
+
+// Handler exports certificate signing requests for offline CAs.
+type Handler struct {
+	service *CSRService
+}
+
+func NewHandler(service *CSRService) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+func (h *Handler) Register(r *mux.Router) {
+	r.HandleFunc("/pki/csr", h.createCSR).Methods(http.MethodPost)
+	r.HandleFunc("/pki/csr/{id}", h.getCSR).Methods(http.MethodGet)
+	r.HandleFunc("/pki/csr/{id}/pem", h.downloadCSR).Methods(http.MethodGet)
+	r.HandleFunc("/pki/csr/{id}", h.deleteCSR).Methods(http.MethodDelete)
+}
+
+func (h *Handler) createCSR(w http.ResponseWriter, r *http.Request) {
+	req := &csrCreateRequest{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			message.SendInvalidJSON(w, err)
+			return
+		}
+	}
+
+	csr, err := h.service.Create(r.Context(), req.CommonName)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(csr); err != nil {
+		logrus.Errorf("pki: create csr: write response: %s", err)
+	}
+}
+
+func (h *Handler) getCSR(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	csr, err := h.service.Get(r.Context(), id)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, id, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(csr); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) downloadCSR(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	csr, err := h.service.Get(r.Context(), id)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, id, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pkcs10")
+	w.Header().Set("Content-Disposition", "attachment; filename="+csr.ID+".csr")
+	if _, err = w.Write([]byte(csr.Request)); err != nil {
+		logrus.Errorf("pki: download csr %s: write response: %s", id, err)
+	}
+}
+
+func (h *Handler) deleteCSR(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, id, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,95 @@
+package pki
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+// DefaultCertValidity is a lifetime kubeadm uses for component certificates.
+const DefaultCertValidity = duration365d
+
+// Reissue signs a copy of the existing certificate with a new key and lifetime,
+// subject and usages are kept and extra SANs are added to the ones it has.
+// Extra SANs are parsed as IP addresses or used as DNS names otherwise.
+func Reissue(certPEM []byte, extraSANs []string, ttl time.Duration, caEncoded *PairPEM) (*PairPEM, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != CertificateBlockType {
+		return nil, errors.New("decode certificate pem")
+	}
+	old, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse certificate")
+	}
+
+	ca, err := Decode(caEncoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode ca cert/key")
+	}
+
+	key, err := newPrivateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "create private key")
+	}
+
+	cfg := certutil.Config{
+		CommonName:   old.Subject.CommonName,
+		Organization: old.Subject.Organization,
+		AltNames:     altNames(old, extraSANs),
+		Usages:       old.ExtKeyUsage,
+	}
+	if len(cfg.Usages) == 0 {
+		cfg.Usages = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	}
+
+	cert, err := newSignedCert(cfg, key, ca.Cert, ca.Key, ttl)
+	if err != nil {
+		return nil, errors.Wrap(err, "sign certificate")
+	}
+
+	return Encode(&Pair{
+		Cert: cert,
+		Key:  key,
+	})
+}
+
+func altNames(cert *x509.Certificate, extra []string) certutil.AltNames {
+	names := certutil.AltNames{
+		DNSNames: append([]string{}, cert.DNSNames...),
+		IPs:      append([]net.IP{}, cert.IPAddresses...),
+	}
+
+	for _, san := range extra {
+		if ip := net.ParseIP(san); ip != nil {
+			if !containsIP(names.IPs, ip) {
+				names.IPs = append(names.IPs, ip)
+			}
+		} else if san != "" && !containsString(names.DNSNames, san) {
+			names.DNSNames = append(names.DNSNames, san)
+		}
+	}
+
+	return names
+}
+
+func containsIP(ips []net.IP, ip net.IP) bool {
+	for _, v := range ips {
+		if v.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -7,6 +7,7 @@ import (
 	"crypto/x509/pkix"
 	"math"
 	"math/big"
+	"net"
 	"time"
 
 	"github.com/pkg/errors"
@@ -26,6 +27,11 @@ func NewAdminPair(ca *PairPEM) (*PairPEM, error) {
 
 // NewUserPair creates certificates for a kubernetes user.
 func NewUserPair(userName string, userGroups []string, caEncoded *PairPEM) (*PairPEM, error) {
+	return NewUserPairWithTTL(userName, userGroups, duration365d, caEncoded)
+}
+
+// NewUserPairWithTTL creates certificates for a kubernetes user that expire after the ttl.
+func NewUserPairWithTTL(userName string, userGroups []string, ttl time.Duration, caEncoded *PairPEM) (*PairPEM, error) {
 	ca, err := Decode(caEncoded)
 	if err != nil {
 		return nil, errors.Wrap(err, "decode ca cert/key")
@@ -41,7 +47,48 @@ func NewUserPair(userName string, userGroups []string, caEncoded *PairPEM) (*Pai
 		Organization: userGroups,
 		Usages:       []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
 	}
-	cert, err := newSignedCert(cfg, key, ca.Cert, ca.Key)
+	cert, err := newSignedCert(cfg, key, ca.Cert, ca.Key, ttl)
+	if err != nil {
+		return nil, errors.Wrap(err, "sign certificate")
+	}
+
+	return Encode(&Pair{
+		Cert: cert,
+		Key:  key,
+	})
+}
+
+// NewEtcdPeerPair creates a certificate for an etcd member that is valid
+// both for peer and client connections on the given addresses.
+func NewEtcdPeerPair(name string, ips []string, caEncoded *PairPEM) (*PairPEM, error) {
+	ca, err := Decode(caEncoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode ca cert/key")
+	}
+
+	key, err := newPrivateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "create private key")
+	}
+
+	altNames := certutil.AltNames{
+		DNSNames: []string{name, "localhost"},
+		IPs:      []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	for _, ip := range ips {
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			return nil, errors.Errorf("invalid ip address %q", ip)
+		}
+		altNames.IPs = append(altNames.IPs, parsed)
+	}
+
+	cfg := certutil.Config{
+		CommonName: name,
+		AltNames:   altNames,
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	cert, err := newSignedCert(cfg, key, ca.Cert, ca.Key, duration365d)
 	if err != nil {
 		return nil, errors.Wrap(err, "sign certificate")
 	}
@@ -53,7 +100,7 @@ func NewUserPair(userName string, userGroups []string, caEncoded *PairPEM) (*Pai
 }
 
 // newSignedCert creates a signed certificate using the given CA certificate and key
-func newSignedCert(cfg certutil.Config, key crypto.Signer, caCert *x509.Certificate, caKey crypto.Signer) (*x509.Certificate, error) {
+func newSignedCert(cfg certutil.Config, key crypto.Signer, caCert *x509.Certificate, caKey crypto.Signer, ttl time.Duration) (*x509.Certificate, error) {
 	serial, err := rand.Int(rand.Reader, new(big.Int).SetInt64(math.MaxInt64))
 	if err != nil {
 		return nil, err
@@ -74,7 +121,7 @@ func newSignedCert(cfg certutil.Config, key crypto.Signer, caCert *x509.Certific
 		IPAddresses:  cfg.AltNames.IPs,
 		SerialNumber: serial,
 		NotBefore:    caCert.NotBefore,
-		NotAfter:     time.Now().Add(duration365d).UTC(),
+		NotAfter:     time.Now().Add(ttl).UTC(),
 		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
 		ExtKeyUsage:  cfg.Usages,
 	}
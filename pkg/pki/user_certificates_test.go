@@ -1,6 +1,9 @@
 package pki
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestNewAdminPair(t *testing.T) {
 	cert, key, _ := newCertificateAuthority()
@@ -44,3 +47,65 @@ func TestNewUserPair(t *testing.T) {
 		t.Errorf("pair pem must not be nil")
 	}
 }
+
+func TestNewEtcdPeerPair(t *testing.T) {
+	cert, key, _ := newCertificateAuthority()
+
+	pemPair, _ := Encode(&Pair{
+		Cert: cert,
+		Key:  key,
+	})
+
+	pairPem, err := NewEtcdPeerPair("master-1",
+		[]string{"10.0.0.1"}, pemPair)
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	pair, err := Decode(pairPem)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if err := pair.Cert.VerifyHostname("10.0.0.1"); err != nil {
+		t.Errorf("peer ip must be in certificate SANs: %v", err)
+	}
+
+	if err := pair.Cert.VerifyHostname("127.0.0.1"); err != nil {
+		t.Errorf("loopback must be in certificate SANs: %v", err)
+	}
+
+	if _, err := NewEtcdPeerPair("master-1",
+		[]string{"not-an-ip"}, pemPair); err == nil {
+		t.Errorf("error expected for invalid ip")
+	}
+}
+
+func TestNewUserPairWithTTL(t *testing.T) {
+	cert, key, _ := newCertificateAuthority()
+
+	pemPair, _ := Encode(&Pair{
+		Cert: cert,
+		Key:  key,
+	})
+
+	pairPem, err := NewUserPairWithTTL("user", []string{"group"}, time.Hour, pemPair)
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	pair, err := Decode(pairPem)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if pair.Cert.NotAfter.After(time.Now().Add(time.Hour)) {
+		t.Errorf("certificate must expire in an hour, actual %v", pair.Cert.NotAfter)
+	}
+
+	if pair.Cert.Subject.CommonName != "user" || pair.Cert.Subject.Organization[0] != "group" {
+		t.Errorf("wrong certificate subject %v", pair.Cert.Subject)
+	}
+}
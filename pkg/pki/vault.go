@@ -0,0 +1,101 @@
+package pki
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// VaultSigner signs intermediate CAs with the Vault PKI secrets engine.
+// https://www.vaultproject.io/api/secret/pki/index.html#sign-intermediate
+type VaultSigner struct {
+	Address string
+	Token   string
+	// Mount is a path the PKI secrets engine is mounted at, "pki" by default.
+	Mount string
+	// TTL of the intermediate CA, e.g. "43800h".
+	TTL string
+
+	Client *http.Client
+}
+
+type vaultSignRequest struct {
+	CSR        string `json:"csr"`
+	CommonName string `json:"common_name"`
+	TTL        string `json:"ttl,omitempty"`
+	Format     string `json:"format"`
+}
+
+type vaultSignResponse struct {
+	Data struct {
+		Certificate string   `json:"certificate"`
+		IssuingCA   string   `json:"issuing_ca"`
+		CAChain     []string `json:"ca_chain"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+// SignIntermediate returns a certificate signed by Vault for the CSR and its chain.
+func (v *VaultSigner) SignIntermediate(ctx context.Context, commonName string, csr []byte) ([]byte, []byte, error) {
+	if v.Address == "" || v.Token == "" {
+		return nil, nil, errors.New("vault address and token are required")
+	}
+
+	mount := strings.Trim(v.Mount, "/")
+	if mount == "" {
+		mount = "pki"
+	}
+
+	body, err := json.Marshal(vaultSignRequest{
+		CSR:        string(csr),
+		CommonName: commonName,
+		TTL:        v.TTL,
+		Format:     "pem",
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/root/sign-intermediate", strings.TrimRight(v.Address, "/"), mount)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "build vault request")
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "vault sign intermediate")
+	}
+	defer resp.Body.Close()
+
+	signResp := &vaultSignResponse{}
+	if err = json.NewDecoder(resp.Body).Decode(signResp); err != nil {
+		return nil, nil, errors.Wrapf(err, "vault sign intermediate: decode response with status %d", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, errors.Errorf("vault sign intermediate: status %d: %s",
+			resp.StatusCode, strings.Join(signResp.Errors, ", "))
+	}
+	if signResp.Data.Certificate == "" {
+		return nil, nil, errors.New("vault sign intermediate: empty certificate")
+	}
+
+	chain := signResp.Data.CAChain
+	if len(chain) == 0 && signResp.Data.IssuingCA != "" {
+		chain = []string{signResp.Data.IssuingCA}
+	}
+
+	return []byte(signResp.Data.Certificate), []byte(strings.Join(chain, "\n")), nil
+}
@@ -0,0 +1,466 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: plugin.proto
+
+package pluginpb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type DescribeRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DescribeRequest) Reset()         { *m = DescribeRequest{} }
+func (m *DescribeRequest) String() string { return proto.CompactTextString(m) }
+func (*DescribeRequest) ProtoMessage()    {}
+func (*DescribeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{0}
+}
+
+func (m *DescribeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DescribeRequest.Unmarshal(m, b)
+}
+func (m *DescribeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DescribeRequest.Marshal(b, m, deterministic)
+}
+func (m *DescribeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DescribeRequest.Merge(m, src)
+}
+func (m *DescribeRequest) XXX_Size() int {
+	return xxx_messageInfo_DescribeRequest.Size(m)
+}
+func (m *DescribeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DescribeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DescribeRequest proto.InternalMessageInfo
+
+type DescribeResponse struct {
+	Steps                []*Step  `protobuf:"bytes,1,rep,name=steps,proto3" json:"steps,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DescribeResponse) Reset()         { *m = DescribeResponse{} }
+func (m *DescribeResponse) String() string { return proto.CompactTextString(m) }
+func (*DescribeResponse) ProtoMessage()    {}
+func (*DescribeResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{1}
+}
+
+func (m *DescribeResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DescribeResponse.Unmarshal(m, b)
+}
+func (m *DescribeResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DescribeResponse.Marshal(b, m, deterministic)
+}
+func (m *DescribeResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DescribeResponse.Merge(m, src)
+}
+func (m *DescribeResponse) XXX_Size() int {
+	return xxx_messageInfo_DescribeResponse.Size(m)
+}
+func (m *DescribeResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_DescribeResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DescribeResponse proto.InternalMessageInfo
+
+func (m *DescribeResponse) GetSteps() []*Step {
+	if m != nil {
+		return m.Steps
+	}
+	return nil
+}
+
+type Step struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description          string   `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Depends              []string `protobuf:"bytes,3,rep,name=depends,proto3" json:"depends,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Step) Reset()         { *m = Step{} }
+func (m *Step) String() string { return proto.CompactTextString(m) }
+func (*Step) ProtoMessage()    {}
+func (*Step) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{2}
+}
+
+func (m *Step) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Step.Unmarshal(m, b)
+}
+func (m *Step) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Step.Marshal(b, m, deterministic)
+}
+func (m *Step) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Step.Merge(m, src)
+}
+func (m *Step) XXX_Size() int {
+	return xxx_messageInfo_Step.Size(m)
+}
+func (m *Step) XXX_DiscardUnknown() {
+	xxx_messageInfo_Step.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Step proto.InternalMessageInfo
+
+func (m *Step) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Step) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
+func (m *Step) GetDepends() []string {
+	if m != nil {
+		return m.Depends
+	}
+	return nil
+}
+
+type StepRequest struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// config of the task in JSON.
+	Config               []byte   `protobuf:"bytes,2,opt,name=config,proto3" json:"config,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StepRequest) Reset()         { *m = StepRequest{} }
+func (m *StepRequest) String() string { return proto.CompactTextString(m) }
+func (*StepRequest) ProtoMessage()    {}
+func (*StepRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{3}
+}
+
+func (m *StepRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StepRequest.Unmarshal(m, b)
+}
+func (m *StepRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StepRequest.Marshal(b, m, deterministic)
+}
+func (m *StepRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StepRequest.Merge(m, src)
+}
+func (m *StepRequest) XXX_Size() int {
+	return xxx_messageInfo_StepRequest.Size(m)
+}
+func (m *StepRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StepRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StepRequest proto.InternalMessageInfo
+
+func (m *StepRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *StepRequest) GetConfig() []byte {
+	if m != nil {
+		return m.Config
+	}
+	return nil
+}
+
+type Output struct {
+	Data                 []byte   `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Output) Reset()         { *m = Output{} }
+func (m *Output) String() string { return proto.CompactTextString(m) }
+func (*Output) ProtoMessage()    {}
+func (*Output) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{4}
+}
+
+func (m *Output) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Output.Unmarshal(m, b)
+}
+func (m *Output) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Output.Marshal(b, m, deterministic)
+}
+func (m *Output) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Output.Merge(m, src)
+}
+func (m *Output) XXX_Size() int {
+	return xxx_messageInfo_Output.Size(m)
+}
+func (m *Output) XXX_DiscardUnknown() {
+	xxx_messageInfo_Output.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Output proto.InternalMessageInfo
+
+func (m *Output) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*DescribeRequest)(nil), "supergiant.plugin.v1.DescribeRequest")
+	proto.RegisterType((*DescribeResponse)(nil), "supergiant.plugin.v1.DescribeResponse")
+	proto.RegisterType((*Step)(nil), "supergiant.plugin.v1.Step")
+	proto.RegisterType((*StepRequest)(nil), "supergiant.plugin.v1.StepRequest")
+	proto.RegisterType((*Output)(nil), "supergiant.plugin.v1.Output")
+}
+
+func init() { proto.RegisterFile("plugin.proto", fileDescriptor_22a625af4bc1cc87) }
+
+var fileDescriptor_22a625af4bc1cc87 = []byte{
+	// 284 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x92, 0xcb, 0x4b, 0xc3, 0x40,
+	0x10, 0xc6, 0x49, 0x53, 0x63, 0x3a, 0x09, 0xa8, 0x8b, 0x48, 0x28, 0x3d, 0xc4, 0x80, 0x92, 0x53,
+	0xa8, 0xf5, 0xe4, 0x55, 0x7a, 0xf0, 0x22, 0xca, 0x0a, 0x82, 0xde, 0xf2, 0x18, 0x43, 0x30, 0xee,
+	0xae, 0xd9, 0x5d, 0xcf, 0xfe, 0xe9, 0xd2, 0x49, 0x8a, 0x22, 0x41, 0x2f, 0xbd, 0xcd, 0xf3, 0x37,
+	0xfb, 0x7d, 0x2c, 0x84, 0xaa, 0xb5, 0x75, 0x23, 0x32, 0xd5, 0x49, 0x23, 0xd9, 0xb1, 0xb6, 0x0a,
+	0xbb, 0xba, 0xc9, 0x85, 0xc9, 0x86, 0xc6, 0xc7, 0x45, 0x72, 0x04, 0x07, 0x6b, 0xd4, 0x65, 0xd7,
+	0x14, 0xc8, 0xf1, 0xdd, 0xa2, 0x36, 0xc9, 0x1a, 0x0e, 0xbf, 0x4b, 0x5a, 0x49, 0xa1, 0x91, 0x2d,
+	0x61, 0x4f, 0x1b, 0x54, 0x3a, 0x72, 0x62, 0x37, 0x0d, 0x56, 0xf3, 0x6c, 0x0c, 0x96, 0x3d, 0x18,
+	0x54, 0xbc, 0x1f, 0x4c, 0x1e, 0x61, 0xba, 0x49, 0x19, 0x83, 0xa9, 0xc8, 0xdf, 0x30, 0x72, 0x62,
+	0x27, 0x9d, 0x71, 0x8a, 0x59, 0x0c, 0x41, 0x45, 0x17, 0x94, 0x69, 0xa4, 0x88, 0x26, 0xd4, 0xfa,
+	0x59, 0x62, 0x11, 0xec, 0x57, 0xa8, 0x50, 0x54, 0x3a, 0x72, 0x63, 0x37, 0x9d, 0xf1, 0x6d, 0x9a,
+	0x5c, 0x41, 0x40, 0x67, 0xfa, 0xc7, 0x8e, 0xe2, 0x4f, 0xc0, 0x2b, 0xa5, 0x78, 0x69, 0x6a, 0x22,
+	0x87, 0x7c, 0xc8, 0x92, 0x05, 0x78, 0x77, 0xd6, 0x28, 0x4b, 0x5b, 0x55, 0x6e, 0x72, 0xda, 0x0a,
+	0x39, 0xc5, 0xab, 0xcf, 0x09, 0xc0, 0x86, 0x7c, 0x4f, 0x72, 0xd8, 0x13, 0xf8, 0x5b, 0x17, 0xd8,
+	0xd9, 0xb8, 0xdc, 0x5f, 0xc6, 0xcd, 0xcf, 0xff, 0x1b, 0x1b, 0xcc, 0xbc, 0x01, 0x97, 0x5b, 0xc1,
+	0x4e, 0xff, 0x30, 0x71, 0x20, 0x2e, 0xc6, 0x47, 0x7a, 0x15, 0x4b, 0x87, 0xdd, 0x82, 0xcf, 0x65,
+	0xdb, 0x16, 0x79, 0xf9, 0xba, 0x03, 0xdc, 0x35, 0x3c, 0xfb, 0x7d, 0x55, 0x15, 0x85, 0x47, 0xbf,
+	0xe6, 0xf2, 0x6b, 0x00, 0x13, 0x1b, 0x9b, 0xf8, 0x45, 0x02, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// StepPluginClient is the client API for StepPlugin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type StepPluginClient interface {
+	// Describe returns steps of the plugin.
+	Describe(ctx context.Context, in *DescribeRequest, opts ...grpc.CallOption) (*DescribeResponse, error)
+	// Run runs the step, its output is streamed back until it's finished.
+	Run(ctx context.Context, in *StepRequest, opts ...grpc.CallOption) (StepPlugin_RunClient, error)
+	// Rollback reverts the step that has failed.
+	Rollback(ctx context.Context, in *StepRequest, opts ...grpc.CallOption) (StepPlugin_RollbackClient, error)
+}
+
+type stepPluginClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewStepPluginClient(cc *grpc.ClientConn) StepPluginClient {
+	return &stepPluginClient{cc}
+}
+
+func (c *stepPluginClient) Describe(ctx context.Context, in *DescribeRequest, opts ...grpc.CallOption) (*DescribeResponse, error) {
+	out := new(DescribeResponse)
+	err := c.cc.Invoke(ctx, "/supergiant.plugin.v1.StepPlugin/Describe", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stepPluginClient) Run(ctx context.Context, in *StepRequest, opts ...grpc.CallOption) (StepPlugin_RunClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_StepPlugin_serviceDesc.Streams[0], "/supergiant.plugin.v1.StepPlugin/Run", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &stepPluginRunClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type StepPlugin_RunClient interface {
+	Recv() (*Output, error)
+	grpc.ClientStream
+}
+
+type stepPluginRunClient struct {
+	grpc.ClientStream
+}
+
+func (x *stepPluginRunClient) Recv() (*Output, error) {
+	m := new(Output)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *stepPluginClient) Rollback(ctx context.Context, in *StepRequest, opts ...grpc.CallOption) (StepPlugin_RollbackClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_StepPlugin_serviceDesc.Streams[1], "/supergiant.plugin.v1.StepPlugin/Rollback", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &stepPluginRollbackClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type StepPlugin_RollbackClient interface {
+	Recv() (*Output, error)
+	grpc.ClientStream
+}
+
+type stepPluginRollbackClient struct {
+	grpc.ClientStream
+}
+
+func (x *stepPluginRollbackClient) Recv() (*Output, error) {
+	m := new(Output)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// StepPluginServer is the server API for StepPlugin service.
+type StepPluginServer interface {
+	// Describe returns steps of the plugin.
+	Describe(context.Context, *DescribeRequest) (*DescribeResponse, error)
+	// Run runs the step, its output is streamed back until it's finished.
+	Run(*StepRequest, StepPlugin_RunServer) error
+	// Rollback reverts the step that has failed.
+	Rollback(*StepRequest, StepPlugin_RollbackServer) error
+}
+
+func RegisterStepPluginServer(s *grpc.Server, srv StepPluginServer) {
+	s.RegisterService(&_StepPlugin_serviceDesc, srv)
+}
+
+func _StepPlugin_Describe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DescribeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StepPluginServer).Describe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/supergiant.plugin.v1.StepPlugin/Describe",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StepPluginServer).Describe(ctx, req.(*DescribeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StepPlugin_Run_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StepRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StepPluginServer).Run(m, &stepPluginRunServer{stream})
+}
+
+type StepPlugin_RunServer interface {
+	Send(*Output) error
+	grpc.ServerStream
+}
+
+type stepPluginRunServer struct {
+	grpc.ServerStream
+}
+
+func (x *stepPluginRunServer) Send(m *Output) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _StepPlugin_Rollback_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StepRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StepPluginServer).Rollback(m, &stepPluginRollbackServer{stream})
+}
+
+type StepPlugin_RollbackServer interface {
+	Send(*Output) error
+	grpc.ServerStream
+}
+
+type stepPluginRollbackServer struct {
+	grpc.ServerStream
+}
+
+func (x *stepPluginRollbackServer) Send(m *Output) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _StepPlugin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "supergiant.plugin.v1.StepPlugin",
+	HandlerType: (*StepPluginServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Describe",
+			Handler:    _StepPlugin_Describe_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Run",
+			Handler:       _StepPlugin_Run_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Rollback",
+			Handler:       _StepPlugin_Rollback_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "plugin.proto",
+}
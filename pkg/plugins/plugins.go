@@ -0,0 +1,298 @@
+// Package plugins runs workflow steps implemented by out-of-process plugins,
+// so custom steps are added without forking control. Plugins are executables
+// of the plugins directory, control starts them on startup and calls them
+// over gRPC, definitions of the service are in the proto directory.
+//
+// A plugin implements steps.Step and serves its steps with Serve:
+//
+//	func main() {
+//		plugins.Serve(&RegisterInCMDBStep{})
+//	}
+package plugins
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/supergiant/control/pkg/plugins/pluginpb"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	// ProtocolVersion of the handshake and of the gRPC service.
+	ProtocolVersion = 1
+
+	// MagicCookieKey and MagicCookieValue are set in the environment of
+	// plugins, so plugins started by mistake refuse to run.
+	MagicCookieKey   = "SUPERGIANT_PLUGIN_MAGIC_COOKIE"
+	MagicCookieValue = "c1d5a3e0-supergiant-step-plugin"
+
+	// handshakeTimeout is how long a plugin has to print its address.
+	handshakeTimeout = time.Second * 10
+)
+
+// Manager keeps plugins running, they are killed on Close.
+type Manager struct {
+	plugins []*plugin
+}
+
+type plugin struct {
+	name    string
+	cmd     *exec.Cmd
+	network string
+	address string
+	conn    *grpc.ClientConn
+	client  pluginpb.StepPluginClient
+}
+
+// Load starts executables of the directory and registers their steps.
+// Plugins that fail to start are skipped, their steps can't replace built-in
+// steps or steps of other plugins.
+func Load(ctx context.Context, dir string) (*Manager, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "read plugins directory")
+	}
+
+	m := &Manager{}
+	for _, f := range files {
+		if f.IsDir() || f.Mode()&0111 == 0 {
+			continue
+		}
+
+		p, err := start(ctx, filepath.Join(dir, f.Name()))
+		if err != nil {
+			logrus.Errorf("plugins: start %s: %v", f.Name(), err)
+			continue
+		}
+		m.plugins = append(m.plugins, p)
+
+		resp, err := p.client.Describe(ctx, &pluginpb.DescribeRequest{})
+		if err != nil {
+			logrus.Errorf("plugins: describe %s: %v", p.name, err)
+			continue
+		}
+		for _, info := range resp.GetSteps() {
+			if steps.GetStep(info.GetName()) != nil {
+				logrus.Errorf("plugins: %s step of %s is already registered", info.GetName(), p.name)
+				continue
+			}
+
+			steps.RegisterStep(info.GetName(), &Step{
+				plugin: p.name,
+				info:   info,
+				client: p.client,
+			})
+			logrus.Infof("plugins: %s step of %s has been registered", info.GetName(), p.name)
+		}
+	}
+
+	return m, nil
+}
+
+// Close kills plugins, steps of the plugins fail after that.
+func (m *Manager) Close() {
+	if m == nil {
+		return
+	}
+
+	for _, p := range m.plugins {
+		p.stop()
+	}
+	m.plugins = nil
+}
+
+func start(ctx context.Context, path string) (*plugin, error) {
+	p := &plugin{
+		name: filepath.Base(path),
+		cmd:  exec.Command(path),
+	}
+	log := logrus.WithField("plugin", p.name)
+
+	p.cmd.Env = append(os.Environ(), MagicCookieKey+"="+MagicCookieValue)
+	p.cmd.Stderr = log.WriterLevel(logrus.InfoLevel)
+	stdout, err := p.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = p.cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(stdout)
+	if p.network, p.address, err = readHandshake(r, handshakeTimeout); err != nil {
+		p.stop()
+		return nil, errors.Wrap(err, "handshake")
+	}
+	// the rest of the output is logged, plugins block on the full pipe otherwise
+	go io.Copy(log.WriterLevel(logrus.InfoLevel), r)
+
+	dialCtx, cancel := context.WithTimeout(ctx, handshakeTimeout)
+	defer cancel()
+	p.conn, err = grpc.DialContext(dialCtx, p.address,
+		grpc.WithInsecure(),
+		grpc.WithBlock(),
+		grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+			return net.DialTimeout(p.network, addr, timeout)
+		}))
+	if err != nil {
+		p.stop()
+		return nil, errors.Wrapf(err, "dial %s %s", p.network, p.address)
+	}
+	p.client = pluginpb.NewStepPluginClient(p.conn)
+
+	return p, nil
+}
+
+func (p *plugin) stop() {
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	if p.cmd.Process != nil {
+		p.cmd.Process.Kill()
+		p.cmd.Wait()
+	}
+	// sockets of killed plugins are left behind
+	if p.network == "unix" {
+		os.Remove(p.address)
+	}
+}
+
+// readHandshake reads the first line of the plugin output, it's
+// <protocol version>|<network>|<address>, e.g. 1|unix|/tmp/plugin.sock.
+func readHandshake(r *bufio.Reader, timeout time.Duration) (string, string, error) {
+	lines := make(chan string, 1)
+	errs := make(chan error, 1)
+	go func() {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			errs <- errors.Wrap(err, "read")
+			return
+		}
+		lines <- line
+	}()
+
+	var line string
+	select {
+	case line = <-lines:
+	case err := <-errs:
+		return "", "", err
+	case <-time.After(timeout):
+		return "", "", errors.Errorf("plugin hasn't printed its address in %s", timeout)
+	}
+
+	parts := strings.Split(strings.TrimSpace(line), "|")
+	if len(parts) != 3 {
+		return "", "", errors.Errorf("unexpected handshake %q", line)
+	}
+	if v, err := strconv.Atoi(parts[0]); err != nil || v != ProtocolVersion {
+		return "", "", errors.Errorf("protocol version %s isn't supported, it must be %d", parts[0], ProtocolVersion)
+	}
+	switch parts[1] {
+	case "tcp", "unix":
+	default:
+		return "", "", errors.Errorf("network %q isn't supported", parts[1])
+	}
+
+	return parts[1], parts[2], nil
+}
+
+// Step runs a step of a plugin. The plugin gets a copy of the config,
+// changes of the config made by the plugin aren't returned.
+type Step struct {
+	plugin string
+	info   *pluginpb.Step
+	client pluginpb.StepPluginClient
+}
+
+func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	req, err := s.request(config)
+	if err != nil {
+		return err
+	}
+
+	stream, err := s.client.Run(ctx, req)
+	if err != nil {
+		return s.wrap(err)
+	}
+	return s.wrap(copyOutput(out, stream))
+}
+
+func (s *Step) Rollback(ctx context.Context, out io.Writer, config *steps.Config) error {
+	req, err := s.request(config)
+	if err != nil {
+		return err
+	}
+
+	stream, err := s.client.Rollback(ctx, req)
+	if err != nil {
+		return s.wrap(err)
+	}
+	return s.wrap(copyOutput(out, stream))
+}
+
+func (s *Step) Name() string {
+	return s.info.GetName()
+}
+
+func (s *Step) Description() string {
+	return s.info.GetDescription()
+}
+
+func (s *Step) Depends() []string {
+	return s.info.GetDepends()
+}
+
+func (s *Step) request(config *steps.Config) (*pluginpb.StepRequest, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal config")
+	}
+
+	return &pluginpb.StepRequest{
+		Name:   s.info.GetName(),
+		Config: data,
+	}, nil
+}
+
+// wrap returns the error message of the plugin without the gRPC status.
+func (s *Step) wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	return errors.Errorf("plugin %s: %s", s.plugin, status.Convert(err).Message())
+}
+
+type outputStream interface {
+	Recv() (*pluginpb.Output, error)
+}
+
+func copyOutput(out io.Writer, stream outputStream) error {
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err = out.Write(msg.GetData()); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package plugins
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// echoStep prints the cluster name, it fails for clusters named fail.
+type echoStep struct {
+	name string
+}
+
+func (s *echoStep) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	if config.Kube.Name == "fail" {
+		return errors.New("cmdb is unavailable")
+	}
+	_, err := fmt.Fprintf(out, "registered %s", config.Kube.Name)
+	return err
+}
+
+func (s *echoStep) Rollback(ctx context.Context, out io.Writer, config *steps.Config) error {
+	_, err := fmt.Fprintf(out, "unregistered %s", config.Kube.Name)
+	return err
+}
+
+func (s *echoStep) Name() string {
+	return s.name
+}
+
+func (s *echoStep) Description() string {
+	return "Register the cluster in the CMDB"
+}
+
+func (s *echoStep) Depends() []string {
+	return []string{"poststart"}
+}
+
+// TestMain runs the test binary as a plugin when it's started by Load.
+func TestMain(m *testing.M) {
+	if os.Getenv(MagicCookieKey) == MagicCookieValue {
+		Serve(&echoStep{name: os.Getenv("TEST_PLUGIN_STEP")})
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func writePlugin(t *testing.T, dir, name, step string) {
+	script := fmt.Sprintf("#!/bin/sh\nTEST_PLUGIN_STEP=%s exec %s\n", step, os.Args[0])
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0755))
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plugins")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	steps.RegisterStep("builtinStep", &echoStep{name: "builtinStep"})
+	writePlugin(t, dir, "cmdb", "cmdbRegister")
+	writePlugin(t, dir, "duplicate", "builtinStep")
+	// files that aren't executable aren't plugins
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README"), []byte("docs"), 0644))
+
+	m, err := Load(context.Background(), dir)
+	require.NoError(t, err)
+	defer m.Close()
+	require.Len(t, m.plugins, 2)
+
+	require.IsType(t, &echoStep{}, steps.GetStep("builtinStep"), "built-in steps mustn't be replaced")
+	step := steps.GetStep("cmdbRegister")
+	require.IsType(t, &Step{}, step)
+	require.Equal(t, "Register the cluster in the CMDB", step.Description())
+	require.Equal(t, []string{"poststart"}, step.Depends())
+
+	out := &bytes.Buffer{}
+	require.NoError(t, step.Run(context.Background(), out, &steps.Config{Kube: model.Kube{Name: "prod"}}))
+	require.Equal(t, "registered prod", out.String())
+
+	out.Reset()
+	err = step.Run(context.Background(), out, &steps.Config{Kube: model.Kube{Name: "fail"}})
+	require.EqualError(t, err, "plugin cmdb: cmdb is unavailable")
+
+	require.NoError(t, step.Rollback(context.Background(), out, &steps.Config{Kube: model.Kube{Name: "fail"}}))
+	require.Equal(t, "unregistered fail", out.String())
+
+	m.Close()
+	require.Error(t, step.Run(context.Background(), out, &steps.Config{}),
+		"steps of killed plugins must fail")
+}
+
+func TestLoadMissingDir(t *testing.T) {
+	_, err := Load(context.Background(), "/nonexistent/plugins")
+	require.Error(t, err)
+}
+
+func TestReadHandshake(t *testing.T) {
+	for _, tc := range []struct {
+		line    string
+		network string
+		address string
+		err     string
+	}{
+		{line: "1|unix|/tmp/plugin.sock\n", network: "unix", address: "/tmp/plugin.sock"},
+		{line: "1|tcp|127.0.0.1:4321\n", network: "tcp", address: "127.0.0.1:4321"},
+		{line: "2|unix|/tmp/plugin.sock\n", err: "protocol version"},
+		{line: "1|udp|127.0.0.1:4321\n", err: "network"},
+		{line: "hello\n", err: "unexpected handshake"},
+		{line: "1|unix", err: "read"},
+	} {
+		network, address, err := readHandshake(bufio.NewReader(strings.NewReader(tc.line)), time.Second)
+		if tc.err != "" {
+			require.Error(t, err, tc.line)
+			require.Contains(t, err.Error(), tc.err, tc.line)
+			continue
+		}
+		require.NoError(t, err, tc.line)
+		require.Equal(t, tc.network, network)
+		require.Equal(t, tc.address, address)
+	}
+
+	r, w := io.Pipe()
+	defer w.Close()
+	_, _, err := readHandshake(bufio.NewReader(r), time.Millisecond*10)
+	require.Error(t, err, "plugins that don't print addresses must time out")
+}
@@ -0,0 +1,132 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/supergiant/control/pkg/plugins/pluginpb"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// Serve serves the steps to control until the plugin is killed, it's called
+// from main of plugins. Plugins that aren't started by control exit.
+func Serve(pluginSteps ...steps.Step) {
+	if os.Getenv(MagicCookieKey) != MagicCookieValue {
+		fmt.Fprintln(os.Stderr, "this is a plugin of supergiant control, "+
+			"it's started by control from its plugins directory")
+		os.Exit(1)
+	}
+
+	// only the user of the plugin can connect to its socket
+	socket := filepath.Join(os.TempDir(), fmt.Sprintf("sg-plugin-%d.sock", os.Getpid()))
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "listen %s: %v\n", socket, err)
+		os.Exit(1)
+	}
+	if err = os.Chmod(socket, 0600); err != nil {
+		fmt.Fprintf(os.Stderr, "chmod %s: %v\n", socket, err)
+		os.Exit(1)
+	}
+
+	srv := grpc.NewServer()
+	pluginpb.RegisterStepPluginServer(srv, NewServer(pluginSteps...))
+
+	fmt.Printf("%d|%s|%s\n", ProtocolVersion, l.Addr().Network(), l.Addr().String())
+	if err = srv.Serve(l); err != nil {
+		fmt.Fprintf(os.Stderr, "serve: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// Server implements the StepPlugin service with steps of the plugin.
+type Server struct {
+	steps []steps.Step
+}
+
+func NewServer(pluginSteps ...steps.Step) *Server {
+	return &Server{
+		steps: pluginSteps,
+	}
+}
+
+func (s *Server) Describe(ctx context.Context, req *pluginpb.DescribeRequest) (*pluginpb.DescribeResponse, error) {
+	resp := &pluginpb.DescribeResponse{}
+	for _, step := range s.steps {
+		resp.Steps = append(resp.Steps, &pluginpb.Step{
+			Name:        step.Name(),
+			Description: step.Description(),
+			Depends:     step.Depends(),
+		})
+	}
+	return resp, nil
+}
+
+func (s *Server) Run(req *pluginpb.StepRequest, stream pluginpb.StepPlugin_RunServer) error {
+	step, config, err := s.prepare(req)
+	if err != nil {
+		return err
+	}
+
+	if err = step.Run(stream.Context(), &outputWriter{send: stream.Send}, config); err != nil {
+		return status.Error(codes.Unknown, err.Error())
+	}
+	return nil
+}
+
+func (s *Server) Rollback(req *pluginpb.StepRequest, stream pluginpb.StepPlugin_RollbackServer) error {
+	step, config, err := s.prepare(req)
+	if err != nil {
+		return err
+	}
+
+	if err = step.Rollback(stream.Context(), &outputWriter{send: stream.Send}, config); err != nil {
+		return status.Error(codes.Unknown, err.Error())
+	}
+	return nil
+}
+
+func (s *Server) prepare(req *pluginpb.StepRequest) (steps.Step, *steps.Config, error) {
+	var step steps.Step
+	for _, candidate := range s.steps {
+		if candidate.Name() == req.GetName() {
+			step = candidate
+			break
+		}
+	}
+	if step == nil {
+		return nil, nil, status.Errorf(codes.NotFound, "step %s isn't served by the plugin", req.GetName())
+	}
+
+	config := &steps.Config{}
+	if err := json.Unmarshal(req.GetConfig(), config); err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "decode config: %v", err)
+	}
+	return step, config, nil
+}
+
+// outputWriter streams output of the step to control.
+type outputWriter struct {
+	send func(*pluginpb.Output) error
+}
+
+var _ io.Writer = &outputWriter{}
+
+func (w *outputWriter) Write(p []byte) (int, error) {
+	// the buffer is reused by callers while the message is being sent
+	data := make([]byte, len(p))
+	copy(data, p)
+	if err := w.send(&pluginpb.Output{Data: data}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,127 @@
+// Package pricing estimates costs of clusters from on-demand prices of
+// machine sizes.
+package pricing
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+const defaultCacheTTL = 24 * time.Hour
+
+// Catalog returns hourly on-demand prices of machine sizes in a region.
+type Catalog interface {
+	Prices(ctx context.Context, region string) (map[string]float64, error)
+}
+
+// StaticCatalog is a price list of a provider. Prices of the default region
+// are multiplied by the regional factor for other regions.
+type StaticCatalog struct {
+	Sizes   map[string]float64
+	Regions map[string]float64
+}
+
+func (c StaticCatalog) Prices(ctx context.Context, region string) (map[string]float64, error) {
+	factor, ok := c.Regions[region]
+	if !ok {
+		factor = 1
+	}
+
+	prices := make(map[string]float64, len(c.Sizes))
+	for size, price := range c.Sizes {
+		prices[size] = price * factor
+	}
+
+	return prices, nil
+}
+
+// cachedCatalog keeps prices of a region for the ttl.
+type cachedCatalog struct {
+	catalog Catalog
+	ttl     time.Duration
+
+	m       sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	prices    map[string]float64
+	expiresAt time.Time
+}
+
+// Cached returns a catalog that asks c for prices of a region once per ttl.
+func Cached(c Catalog, ttl time.Duration) Catalog {
+	return &cachedCatalog{
+		catalog: c,
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+func (c *cachedCatalog) Prices(ctx context.Context, region string) (map[string]float64, error) {
+	c.m.Lock()
+	e, ok := c.entries[region]
+	c.m.Unlock()
+	if ok && time.Now().Before(e.expiresAt) {
+		return e.prices, nil
+	}
+
+	prices, err := c.catalog.Prices(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	c.m.Lock()
+	c.entries[region] = cacheEntry{
+		prices:    prices,
+		expiresAt: time.Now().Add(c.ttl),
+	}
+	c.m.Unlock()
+
+	return prices, nil
+}
+
+// Catalogs are price catalogs of providers.
+type Catalogs struct {
+	m        sync.RWMutex
+	catalogs map[clouds.Name]Catalog
+}
+
+// NewCatalogs returns the builtin catalogs.
+func NewCatalogs() *Catalogs {
+	c := &Catalogs{
+		catalogs: make(map[clouds.Name]Catalog),
+	}
+	for provider, catalog := range builtinCatalogs {
+		c.Set(provider, catalog)
+	}
+
+	return c
+}
+
+// Set replaces the catalog of the provider, e.g. with prices under
+// a discount agreement.
+func (c *Catalogs) Set(provider clouds.Name, catalog Catalog) {
+	c.m.Lock()
+	c.catalogs[provider] = Cached(catalog, defaultCacheTTL)
+	c.m.Unlock()
+}
+
+// Prices returns prices of the provider in the region.
+func (c *Catalogs) Prices(ctx context.Context, provider clouds.Name, region string) (map[string]float64, error) {
+	c.m.RLock()
+	catalog, ok := c.catalogs[clouds.Name(strings.ToLower(string(provider)))]
+	c.m.RUnlock()
+	if !ok {
+		return nil, errors.Wrapf(sgerrors.ErrNotFound, "price catalog of %s", provider)
+	}
+
+	return catalog.Prices(ctx, region)
+}
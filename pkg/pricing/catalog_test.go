@@ -0,0 +1,70 @@
+package pricing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+type countingCatalog struct {
+	calls int
+}
+
+func (c *countingCatalog) Prices(ctx context.Context, region string) (map[string]float64, error) {
+	c.calls++
+	return map[string]float64{"small": float64(c.calls)}, nil
+}
+
+func TestStaticCatalog_Prices(t *testing.T) {
+	c := StaticCatalog{
+		Sizes:   map[string]float64{"small": 0.1},
+		Regions: map[string]float64{"eu": 1.5},
+	}
+
+	prices, err := c.Prices(context.Background(), "us")
+	require.NoError(t, err)
+	require.Equal(t, 0.1, prices["small"])
+
+	prices, err = c.Prices(context.Background(), "eu")
+	require.NoError(t, err)
+	require.InDelta(t, 0.15, prices["small"], 1e-9)
+}
+
+func TestCached(t *testing.T) {
+	counting := &countingCatalog{}
+	c := Cached(counting, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		prices, err := c.Prices(context.Background(), "us")
+		require.NoError(t, err)
+		require.Equal(t, 1.0, prices["small"])
+	}
+	c.Prices(context.Background(), "eu")
+	require.Equal(t, 2, counting.calls)
+
+	expired := Cached(counting, 0)
+	expired.Prices(context.Background(), "us")
+	expired.Prices(context.Background(), "us")
+	require.Equal(t, 4, counting.calls)
+}
+
+func TestCatalogs_Prices(t *testing.T) {
+	c := NewCatalogs()
+
+	prices, err := c.Prices(context.Background(), clouds.DigitalOcean, "fra1")
+	require.NoError(t, err)
+	require.NotZero(t, prices["s-2vcpu-4gb"])
+
+	_, err = c.Prices(context.Background(), clouds.Packet, "ewr1")
+	require.True(t, sgerrors.IsNotFound(err))
+
+	c.Set(clouds.Packet, StaticCatalog{Sizes: map[string]float64{"t1.small.x86": 0.07}})
+	prices, err = c.Prices(context.Background(), clouds.Packet, "ewr1")
+	require.NoError(t, err)
+	require.Equal(t, 0.07, prices["t1.small.x86"])
+}
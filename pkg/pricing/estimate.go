@@ -0,0 +1,215 @@
+package pricing
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+)
+
+const (
+	Currency      = "USD"
+	HoursPerMonth = 730
+)
+
+// Default estimates costs with the builtin catalogs.
+var Default = NewEstimator(NewCatalogs())
+
+// Group is a cost of machines of the same role and size.
+type Group struct {
+	Role        model.Role `json:"role"`
+	Size        string     `json:"size"`
+	Count       int        `json:"count"`
+	HourlyPrice float64    `json:"hourlyPrice"`
+	MonthlyCost float64    `json:"monthlyCost"`
+	// AccruedCost is spent by running machines since their creation.
+	AccruedCost float64 `json:"accruedCost,omitempty"`
+	// UnknownPrice is set for sizes missing in the catalog,
+	// they aren't counted in costs.
+	UnknownPrice bool `json:"unknownPrice,omitempty"`
+}
+
+// Estimate is a monthly cost of a cluster.
+type Estimate struct {
+	Provider    clouds.Name `json:"provider"`
+	Region      string      `json:"region"`
+	Currency    string      `json:"currency"`
+	Groups      []Group     `json:"groups"`
+	HourlyCost  float64     `json:"hourlyCost"`
+	MonthlyCost float64     `json:"monthlyCost"`
+	AccruedCost float64     `json:"accruedCost,omitempty"`
+	// Partial is set when prices of some sizes are unknown.
+	Partial bool `json:"partial"`
+}
+
+// Projection is a spend of the cluster in the current month.
+type Projection struct {
+	Month       string  `json:"month"`
+	MonthToDate float64 `json:"monthToDate"`
+	// Projected is the spend by the end of the month if machines keep running.
+	Projected float64 `json:"projected"`
+	Partial   bool    `json:"partial"`
+}
+
+type Estimator struct {
+	catalogs *Catalogs
+	now      func() time.Time
+}
+
+func NewEstimator(catalogs *Catalogs) *Estimator {
+	return &Estimator{
+		catalogs: catalogs,
+		now:      time.Now,
+	}
+}
+
+type machine struct {
+	role      model.Role
+	size      string
+	createdAt int64
+}
+
+// EstimateProfile estimates a cost of the cluster before it's provisioned.
+func (e *Estimator) EstimateProfile(ctx context.Context, p *profile.Profile) (*Estimate, error) {
+	machines := make([]machine, 0, len(p.MasterProfiles)+len(p.NodesProfiles))
+	for _, np := range p.MasterProfiles {
+		machines = append(machines, machine{role: model.RoleMaster, size: profileSize(np)})
+	}
+	for _, np := range p.NodesProfiles {
+		machines = append(machines, machine{role: model.RoleNode, size: profileSize(np)})
+	}
+
+	return e.estimate(ctx, p.Provider, p.Region, machines)
+}
+
+// EstimateKube estimates a cost of machines of the cluster and sums up
+// how much they have cost since they were created.
+func (e *Estimator) EstimateKube(ctx context.Context, k *model.Kube) (*Estimate, error) {
+	machines := make([]machine, 0, len(k.Masters)+len(k.Nodes))
+	for _, group := range []map[string]*model.Machine{k.Masters, k.Nodes} {
+		for _, m := range group {
+			role := m.Role
+			if role == "" {
+				_, isMaster := k.Masters[m.Name]
+				role = model.ToRole(isMaster)
+			}
+			machines = append(machines, machine{role: role, size: m.Size, createdAt: m.CreatedAt})
+		}
+	}
+
+	return e.estimate(ctx, k.Provider, k.Region, machines)
+}
+
+// ProjectKube projects a monthly spend of the cluster from the cost of its
+// machines since the month start and the current hourly cost.
+func (e *Estimator) ProjectKube(ctx context.Context, k *model.Kube) (*Projection, error) {
+	prices, err := e.catalogs.Prices(ctx, k.Provider, k.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	now := e.now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := monthStart.AddDate(0, 1, 0)
+
+	p := &Projection{Month: now.Format("2006-01")}
+	var hourly float64
+	for _, group := range []map[string]*model.Machine{k.Masters, k.Nodes} {
+		for _, m := range group {
+			price, ok := prices[m.Size]
+			if !ok {
+				p.Partial = true
+				continue
+			}
+
+			since := monthStart
+			if created := time.Unix(m.CreatedAt, 0); m.CreatedAt > 0 && created.After(since) {
+				since = created
+			}
+			p.MonthToDate += math.Max(now.Sub(since).Hours(), 0) * price
+			hourly += price
+		}
+	}
+	p.Projected = round(p.MonthToDate + monthEnd.Sub(now).Hours()*hourly)
+	p.MonthToDate = round(p.MonthToDate)
+
+	return p, nil
+}
+
+func (e *Estimator) estimate(ctx context.Context, provider clouds.Name, region string, machines []machine) (*Estimate, error) {
+	prices, err := e.catalogs.Prices(ctx, provider, region)
+	if err != nil {
+		return nil, err
+	}
+
+	est := &Estimate{
+		Provider: provider,
+		Region:   region,
+		Currency: Currency,
+		Groups:   make([]Group, 0),
+	}
+
+	groups := make(map[machine]*Group)
+	now := e.now()
+	for _, m := range machines {
+		key := machine{role: m.role, size: m.size}
+		g, ok := groups[key]
+		if !ok {
+			price, known := prices[m.size]
+			g = &Group{
+				Role:         m.role,
+				Size:         m.size,
+				HourlyPrice:  price,
+				UnknownPrice: !known,
+			}
+			groups[key] = g
+		}
+
+		g.Count++
+		if m.createdAt > 0 {
+			hours := now.Sub(time.Unix(m.createdAt, 0)).Hours()
+			g.AccruedCost += math.Max(hours, 0) * g.HourlyPrice
+		}
+	}
+
+	for _, g := range groups {
+		g.MonthlyCost = round(g.HourlyPrice * float64(g.Count) * HoursPerMonth)
+		g.AccruedCost = round(g.AccruedCost)
+
+		est.Partial = est.Partial || g.UnknownPrice
+		est.HourlyCost += g.HourlyPrice * float64(g.Count)
+		est.MonthlyCost += g.MonthlyCost
+		est.AccruedCost += g.AccruedCost
+		est.Groups = append(est.Groups, *g)
+	}
+	est.HourlyCost = round(est.HourlyCost)
+	est.MonthlyCost = round(est.MonthlyCost)
+	est.AccruedCost = round(est.AccruedCost)
+
+	sort.Slice(est.Groups, func(i, j int) bool {
+		if est.Groups[i].Role != est.Groups[j].Role {
+			return est.Groups[i].Role == model.RoleMaster
+		}
+		return est.Groups[i].Size < est.Groups[j].Size
+	})
+
+	return est, nil
+}
+
+// profileSize is a machine size of the node profile, azure
+// profiles keep it under their own key.
+func profileSize(np profile.NodeProfile) string {
+	if size := np["size"]; size != "" {
+		return size
+	}
+
+	return np["vmSize"]
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,101 @@
+package pricing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+)
+
+func testEstimator() *Estimator {
+	catalogs := NewCatalogs()
+	catalogs.Set(clouds.AWS, StaticCatalog{
+		Sizes: map[string]float64{
+			"m5.large":  0.1,
+			"t3.medium": 0.04,
+		},
+	})
+
+	return NewEstimator(catalogs)
+}
+
+func TestEstimator_EstimateProfile(t *testing.T) {
+	est, err := testEstimator().EstimateProfile(context.Background(), &profile.Profile{
+		Provider: clouds.AWS,
+		Region:   "us-east-1",
+		MasterProfiles: []profile.NodeProfile{
+			{"size": "t3.medium"},
+		},
+		NodesProfiles: []profile.NodeProfile{
+			{"size": "m5.large"},
+			{"size": "m5.large"},
+			{"size": "x1.32xlarge"},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []Group{
+		{Role: model.RoleMaster, Size: "t3.medium", Count: 1, HourlyPrice: 0.04, MonthlyCost: 29.2},
+		{Role: model.RoleNode, Size: "m5.large", Count: 2, HourlyPrice: 0.1, MonthlyCost: 146},
+		{Role: model.RoleNode, Size: "x1.32xlarge", Count: 1, UnknownPrice: true},
+	}, est.Groups)
+	require.Equal(t, 0.24, est.HourlyCost)
+	require.Equal(t, 175.2, est.MonthlyCost)
+	require.Equal(t, Currency, est.Currency)
+	require.True(t, est.Partial)
+}
+
+func TestEstimator_EstimateKube(t *testing.T) {
+	now := time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)
+	e := testEstimator()
+	e.now = func() time.Time { return now }
+
+	est, err := e.EstimateKube(context.Background(), &model.Kube{
+		Provider: clouds.AWS,
+		Masters: map[string]*model.Machine{
+			"master-1": {Name: "master-1", Size: "t3.medium", CreatedAt: now.Add(-100 * time.Hour).Unix()},
+		},
+		Nodes: map[string]*model.Machine{
+			"node-1": {Name: "node-1", Role: model.RoleNode, Size: "m5.large", CreatedAt: now.Add(-10 * time.Hour).Unix()},
+			"node-2": {Name: "node-2", Role: model.RoleNode, Size: "m5.large", CreatedAt: now.Add(-20 * time.Hour).Unix()},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, est.Groups, 2)
+	require.Equal(t, model.RoleMaster, est.Groups[0].Role)
+	require.Equal(t, 4.0, est.Groups[0].AccruedCost)
+	require.Equal(t, 2, est.Groups[1].Count)
+	require.Equal(t, 3.0, est.Groups[1].AccruedCost)
+	require.Equal(t, 7.0, est.AccruedCost)
+	require.Equal(t, 175.2, est.MonthlyCost)
+	require.False(t, est.Partial)
+}
+
+func TestEstimator_ProjectKube(t *testing.T) {
+	e := testEstimator()
+	e.now = func() time.Time { return time.Date(2019, 4, 11, 0, 0, 0, 0, time.UTC) }
+
+	p, err := e.ProjectKube(context.Background(), &model.Kube{
+		Provider: clouds.AWS,
+		Nodes: map[string]*model.Machine{
+			// created before the month
+			"node-1": {Name: "node-1", Size: "m5.large", CreatedAt: time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC).Unix()},
+			"node-2": {Name: "node-2", Size: "m5.large", CreatedAt: time.Date(2019, 4, 6, 0, 0, 0, 0, time.UTC).Unix()},
+			"node-3": {Name: "node-3", Size: "x1.32xlarge"},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "2019-04", p.Month)
+	// 10 days and 5 days of 0.1 per hour
+	require.Equal(t, 36.0, p.MonthToDate)
+	// 20 days left for both nodes
+	require.Equal(t, 132.0, p.Projected)
+	require.True(t, p.Partial)
+}
@@ -0,0 +1,139 @@
+package pricing
+
+import "github.com/supergiant/control/pkg/clouds"
+
+// Approximate on-demand list prices in USD of linux machines,
+// they're meant for estimates only.
+var builtinCatalogs = map[clouds.Name]Catalog{
+	// us-east-1
+	clouds.AWS: StaticCatalog{
+		Sizes: map[string]float64{
+			"t2.micro":   0.0116,
+			"t2.small":   0.023,
+			"t2.medium":  0.0464,
+			"t2.large":   0.0928,
+			"t2.xlarge":  0.1856,
+			"t3.micro":   0.0104,
+			"t3.small":   0.0208,
+			"t3.medium":  0.0416,
+			"t3.large":   0.0832,
+			"t3.xlarge":  0.1664,
+			"t3.2xlarge": 0.3328,
+			"m4.large":   0.1,
+			"m4.xlarge":  0.2,
+			"m4.2xlarge": 0.4,
+			"m5.large":   0.096,
+			"m5.xlarge":  0.192,
+			"m5.2xlarge": 0.384,
+			"m5.4xlarge": 0.768,
+			"c5.large":   0.085,
+			"c5.xlarge":  0.17,
+			"c5.2xlarge": 0.34,
+			"r5.large":   0.126,
+			"r5.xlarge":  0.252,
+			"r5.2xlarge": 0.504,
+		},
+		Regions: map[string]float64{
+			"us-east-2":      1,
+			"us-west-1":      1.17,
+			"us-west-2":      1,
+			"ca-central-1":   1.1,
+			"eu-west-1":      1.1,
+			"eu-west-2":      1.15,
+			"eu-central-1":   1.17,
+			"ap-south-1":     1.05,
+			"ap-southeast-1": 1.25,
+			"ap-southeast-2": 1.25,
+			"ap-northeast-1": 1.28,
+			"sa-east-1":      1.6,
+		},
+	},
+	// prices are the same in all regions
+	clouds.DigitalOcean: StaticCatalog{
+		Sizes: map[string]float64{
+			"s-1vcpu-1gb":  0.00744,
+			"s-1vcpu-2gb":  0.01488,
+			"s-1vcpu-3gb":  0.02232,
+			"s-2vcpu-2gb":  0.02232,
+			"s-3vcpu-1gb":  0.02232,
+			"s-2vcpu-4gb":  0.02976,
+			"s-4vcpu-8gb":  0.05952,
+			"s-6vcpu-16gb": 0.11905,
+			"s-8vcpu-32gb": 0.2381,
+			"c-2":          0.0595,
+			"c-4":          0.119,
+			"512mb":        0.00744,
+			"1gb":          0.00744,
+			"2gb":          0.01488,
+			"4gb":          0.02976,
+			"8gb":          0.05952,
+			"16gb":         0.11905,
+		},
+	},
+	// us-central1
+	clouds.GCE: StaticCatalog{
+		Sizes: map[string]float64{
+			"f1-micro":       0.0076,
+			"g1-small":       0.0257,
+			"n1-standard-1":  0.0475,
+			"n1-standard-2":  0.095,
+			"n1-standard-4":  0.19,
+			"n1-standard-8":  0.38,
+			"n1-highmem-2":   0.1184,
+			"n1-highmem-4":   0.2368,
+			"n1-highcpu-2":   0.0709,
+			"n1-highcpu-4":   0.1418,
+			"e2-small":       0.0168,
+			"e2-medium":      0.0335,
+			"e2-standard-2":  0.067,
+			"e2-standard-4":  0.134,
+			"e2-standard-8":  0.268,
+			"n2-standard-2":  0.0971,
+			"n2-standard-4":  0.1942,
+			"n2-standard-8":  0.3885,
+			"n2-standard-16": 0.7769,
+		},
+		Regions: map[string]float64{
+			"us-east1":        1,
+			"us-west1":        1,
+			"us-west2":        1.2,
+			"europe-west1":    1.1,
+			"europe-west2":    1.29,
+			"europe-west3":    1.29,
+			"asia-east1":      1.16,
+			"asia-northeast1": 1.28,
+			"asia-southeast1": 1.23,
+		},
+	},
+	// eastus
+	clouds.Azure: StaticCatalog{
+		Sizes: map[string]float64{
+			"Standard_B1s":    0.0104,
+			"Standard_B1ms":   0.0207,
+			"Standard_B2s":    0.0416,
+			"Standard_B2ms":   0.0832,
+			"Standard_B4ms":   0.166,
+			"Standard_A2_v2":  0.091,
+			"Standard_D2_v3":  0.096,
+			"Standard_D4_v3":  0.192,
+			"Standard_D2s_v3": 0.096,
+			"Standard_D4s_v3": 0.192,
+			"Standard_D8s_v3": 0.384,
+			"Standard_DS1_v2": 0.073,
+			"Standard_DS2_v2": 0.146,
+			"Standard_F2s_v2": 0.085,
+			"Standard_F4s_v2": 0.169,
+		},
+		Regions: map[string]float64{
+			"eastus2":       1,
+			"westus":        1.08,
+			"westus2":       1,
+			"centralus":     1.08,
+			"northeurope":   1.06,
+			"westeurope":    1.15,
+			"uksouth":       1.16,
+			"southeastasia": 1.2,
+			"japaneast":     1.29,
+		},
+	},
+}
@@ -0,0 +1,119 @@
+package profile
+
+import (
+	"regexp"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Pod security standards.
+// https://kubernetes.io/docs/concepts/security/pod-security-standards/
+const (
+	LevelPrivileged = "privileged"
+	LevelBaseline   = "baseline"
+	LevelRestricted = "restricted"
+)
+
+var (
+	// podSecurityVersion is the first release the PodSecurity admission is enabled by default.
+	podSecurityVersion = semver.MustParse("1.23.0")
+
+	pluginName = regexp.MustCompile(`^[A-Za-z]+$`)
+)
+
+// Admission holds kube-apiserver admission control settings.
+type Admission struct {
+	// EnablePlugins and DisablePlugins change the default admission
+	// plugins of kube-apiserver, e.g. EventRateLimit or AlwaysPullImages.
+	EnablePlugins  []string `json:"enablePlugins"`
+	DisablePlugins []string `json:"disablePlugins"`
+
+	// PodSecurity configures the PodSecurity admission of kubernetes 1.23+.
+	PodSecurity PodSecurity `json:"podSecurity"`
+}
+
+// PodSecurityLevels hold a pod security standard pods are checked against
+// for each of the admission modes.
+type PodSecurityLevels struct {
+	Enforce string `json:"enforce"`
+	Audit   string `json:"audit"`
+	Warn    string `json:"warn"`
+}
+
+// PodSecurity holds cluster defaults of the PodSecurity admission.
+// https://kubernetes.io/docs/concepts/security/pod-security-admission/
+type PodSecurity struct {
+	// Defaults apply to namespaces without pod-security.kubernetes.io labels.
+	Defaults PodSecurityLevels `json:"defaults"`
+	// ExemptNamespaces aren't checked, kube-system is always exempt.
+	ExemptNamespaces []string `json:"exemptNamespaces"`
+	// Namespaces override the defaults, they are created and labeled when
+	// the cluster is bootstrapped.
+	Namespaces map[string]PodSecurityLevels `json:"namespaces"`
+}
+
+// IsSet tells whether any of the pod security settings is set.
+func (p PodSecurity) IsSet() bool {
+	return p.Defaults != PodSecurityLevels{} || len(p.ExemptNamespaces) > 0 || len(p.Namespaces) > 0
+}
+
+// SupportsPodSecurity tells whether the PodSecurity admission is available
+// in the kubernetes version.
+func SupportsPodSecurity(k8sVersion string) bool {
+	v, err := semver.NewVersion(k8sVersion)
+	if err != nil {
+		return false
+	}
+	return !v.LessThan(podSecurityVersion)
+}
+
+// Validate checks admission settings of clusters of the kubernetes version.
+func (a Admission) Validate(k8sVersion string) error {
+	for _, names := range [][]string{a.EnablePlugins, a.DisablePlugins} {
+		for _, name := range names {
+			if !pluginName.MatchString(name) {
+				return errors.Errorf("admission plugin %q: invalid name", name)
+			}
+		}
+	}
+
+	p := a.PodSecurity
+	if !p.IsSet() {
+		return nil
+	}
+	if !SupportsPodSecurity(k8sVersion) {
+		return errors.Errorf("pod security admission requires kubernetes %s or newer", podSecurityVersion)
+	}
+
+	if err := p.Defaults.validate(); err != nil {
+		return errors.Wrap(err, "pod security defaults")
+	}
+	for _, ns := range p.ExemptNamespaces {
+		if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+			return errors.Errorf("exempt namespace %q: %v", ns, errs)
+		}
+	}
+	for ns, levels := range p.Namespaces {
+		if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+			return errors.Errorf("namespace %q: %v", ns, errs)
+		}
+		if err := levels.validate(); err != nil {
+			return errors.Wrapf(err, "namespace %s", ns)
+		}
+	}
+
+	return nil
+}
+
+func (l PodSecurityLevels) validate() error {
+	for _, level := range []string{l.Enforce, l.Audit, l.Warn} {
+		switch level {
+		case "", LevelPrivileged, LevelBaseline, LevelRestricted:
+		default:
+			return errors.Errorf("unknown level %q", level)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package profile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdmission_Validate(t *testing.T) {
+	require.NoError(t, Admission{EnablePlugins: []string{"EventRateLimit"}}.Validate("1.15.1"))
+	require.Error(t, Admission{EnablePlugins: []string{"EventRateLimit; rm -rf /"}}.Validate("1.15.1"))
+
+	a := Admission{PodSecurity: PodSecurity{
+		Defaults:   PodSecurityLevels{Enforce: LevelBaseline},
+		Namespaces: map[string]PodSecurityLevels{"apps": {Warn: LevelRestricted}},
+	}}
+	require.NoError(t, a.Validate("1.23.0"))
+	require.Error(t, a.Validate("1.16.2"), "pod security admission isn't available")
+
+	a.PodSecurity.Namespaces["apps"] = PodSecurityLevels{Enforce: "strict"}
+	require.Error(t, a.Validate("1.24.1"))
+
+	a.PodSecurity.Namespaces = map[string]PodSecurityLevels{"Apps": {}}
+	require.Error(t, a.Validate("1.24.1"))
+}
@@ -0,0 +1,79 @@
+package profile
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultCanaryTimeout limits verification of canary nodes when the
+// timeout isn't set.
+const DefaultCanaryTimeout = 10 * time.Minute
+
+// UpgradeCanary upgrades canary worker nodes right after masters and
+// verifies the cluster, other nodes are upgraded only if it's healthy.
+type UpgradeCanary struct {
+	Enabled bool `json:"enabled"`
+	// NodeGroup of canary nodes, one node is the canary when it's empty.
+	NodeGroup string `json:"nodeGroup,omitempty"`
+	// AddonHealth waits for deployments and daemon sets of kube-system
+	// to roll out.
+	AddonHealth bool `json:"addonHealth"`
+	// SmokeTestJob is a manifest of a Job that must complete, e.g. tests of
+	// applications of the cluster. It's run in the default namespace
+	// unless the manifest sets one.
+	SmokeTestJob string `json:"smokeTestJob,omitempty"`
+	// Timeout of each check in seconds, DefaultCanaryTimeout when it's 0.
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// Validate checks the node group, the timeout and the smoke test job.
+func (c UpgradeCanary) Validate() error {
+	if c.NodeGroup != "" {
+		if errs := validation.IsDNS1123Label(c.NodeGroup); len(errs) > 0 {
+			return errors.Errorf("node group %q: %s", c.NodeGroup, errs[0])
+		}
+	}
+	if c.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+	if c.SmokeTestJob != "" {
+		if _, err := c.Job(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Job returns the smoke test job, the name and the namespace of the job
+// are checked since they are passed to kubectl.
+func (c UpgradeCanary) Job() (*batchv1.Job, error) {
+	job := &batchv1.Job{}
+	if err := yaml.Unmarshal([]byte(c.SmokeTestJob), job); err != nil {
+		return nil, errors.Wrap(err, "parse smoke test job")
+	}
+	if job.Kind != "Job" {
+		return nil, errors.Errorf("smoke test job is a %q, not a Job", job.Kind)
+	}
+	if errs := validation.IsDNS1123Subdomain(job.Name); len(errs) > 0 {
+		return nil, errors.Errorf("smoke test job name %q: %s", job.Name, errs[0])
+	}
+	if job.Namespace == "" {
+		job.Namespace = "default"
+	}
+	if errs := validation.IsDNS1123Label(job.Namespace); len(errs) > 0 {
+		return nil, errors.Errorf("smoke test job namespace %q: %s", job.Namespace, errs[0])
+	}
+	return job, nil
+}
+
+// TimeoutDuration returns the timeout of each check.
+func (c UpgradeCanary) TimeoutDuration() time.Duration {
+	if c.Timeout == 0 {
+		return DefaultCanaryTimeout
+	}
+	return time.Duration(c.Timeout) * time.Second
+}
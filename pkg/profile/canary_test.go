@@ -0,0 +1,42 @@
+package profile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const smokeTestJob = `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: smoke-test
+spec:
+  template:
+    spec:
+      containers:
+      - name: test
+        image: busybox
+        command: ["wget", "-q", "-O-", "http://web"]
+      restartPolicy: Never
+`
+
+func TestUpgradeCanary_Validate(t *testing.T) {
+	require.NoError(t, UpgradeCanary{}.Validate())
+	require.NoError(t, UpgradeCanary{Enabled: true, NodeGroup: "canary", SmokeTestJob: smokeTestJob}.Validate())
+
+	require.Error(t, UpgradeCanary{NodeGroup: "Canary Nodes"}.Validate())
+	require.Error(t, UpgradeCanary{Timeout: -1}.Validate())
+	require.Error(t, UpgradeCanary{SmokeTestJob: "kind: Pod\nmetadata:\n  name: test\n"}.Validate())
+	require.Error(t, UpgradeCanary{SmokeTestJob: "kind: Job\nmetadata:\n  name: test;reboot\n"}.Validate())
+}
+
+func TestUpgradeCanary_Job(t *testing.T) {
+	job, err := UpgradeCanary{SmokeTestJob: smokeTestJob}.Job()
+	require.NoError(t, err)
+	require.Equal(t, "smoke-test", job.Name)
+	require.Equal(t, "default", job.Namespace)
+
+	require.Equal(t, DefaultCanaryTimeout, UpgradeCanary{}.TimeoutDuration())
+	require.Equal(t, time.Minute, UpgradeCanary{Timeout: 60}.TimeoutDuration())
+}
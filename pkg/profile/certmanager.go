@@ -0,0 +1,40 @@
+package profile
+
+import (
+	"net/mail"
+
+	"github.com/pkg/errors"
+)
+
+// ACME challenges cert-manager solves to prove control of domains.
+const (
+	ChallengeHTTP01 = "http01"
+	ChallengeDNS01  = "dns01"
+)
+
+// CertManager configures the cert-manager addon and Let's Encrypt cluster
+// issuers that it's bootstrapped with.
+type CertManager struct {
+	// Email is the ACME account one, Let's Encrypt sends expiration
+	// notices to it.
+	Email string `json:"email"`
+	// Challenge is http01 or dns01, http01 is solved by the ingress
+	// controller of the cluster and dns01 in the DNS of the cloud account.
+	// It's http01 by default.
+	Challenge string `json:"challenge"`
+}
+
+// Validate checks the cert-manager settings.
+func (c CertManager) Validate() error {
+	if c.Email != "" {
+		if _, err := mail.ParseAddress(c.Email); err != nil {
+			return errors.Wrapf(err, "email %q", c.Email)
+		}
+	}
+
+	switch c.Challenge {
+	case "", ChallengeHTTP01, ChallengeDNS01:
+		return nil
+	}
+	return errors.Errorf("unknown challenge %q", c.Challenge)
+}
@@ -0,0 +1,15 @@
+package profile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertManager_Validate(t *testing.T) {
+	require.NoError(t, CertManager{}.Validate())
+	require.NoError(t, CertManager{Email: "ops@example.com", Challenge: ChallengeDNS01}.Validate())
+
+	require.Error(t, CertManager{Email: "ops"}.Validate())
+	require.Error(t, CertManager{Challenge: "tls-alpn-01"}.Validate())
+}
@@ -0,0 +1,120 @@
+package profile
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// DNS holds settings of CoreDNS, the cluster DNS kubeadm installs.
+type DNS struct {
+	// Replicas of CoreDNS, kubeadm runs two of them by default. It's
+	// ignored when CoreDNS is autoscaled.
+	Replicas int `json:"replicas"`
+	// Autoscale scales CoreDNS with the number of nodes and cores of the cluster.
+	Autoscale DNSAutoscale `json:"autoscale"`
+
+	// StubDomains map domains to their nameservers, e.g.
+	// "consul.local": ["10.150.0.1"].
+	StubDomains map[string][]string `json:"stubDomains"`
+	// Upstreams resolve names outside of the cluster and stub domains,
+	// resolvers of the masters are used if it's empty.
+	Upstreams []string `json:"upstreams"`
+
+	// NodeLocalCache runs NodeLocal DNSCache on every node.
+	// https://kubernetes.io/docs/tasks/administer-cluster/nodelocaldns/
+	NodeLocalCache bool `json:"nodeLocalCache"`
+}
+
+// DNSAutoscale holds linear parameters of cluster-proportional-autoscaler.
+// https://github.com/kubernetes-sigs/cluster-proportional-autoscaler#linear-mode
+type DNSAutoscale struct {
+	Enabled         bool `json:"enabled"`
+	CoresPerReplica int  `json:"coresPerReplica"`
+	NodesPerReplica int  `json:"nodesPerReplica"`
+	Min             int  `json:"min"`
+	Max             int  `json:"max"`
+}
+
+// IsSet tells whether CoreDNS differs from the one installed by kubeadm.
+func (d DNS) IsSet() bool {
+	return d.Replicas > 0 || d.Autoscale.Enabled || len(d.StubDomains) > 0 ||
+		len(d.Upstreams) > 0 || d.NodeLocalCache
+}
+
+// Validate checks the cluster DNS settings.
+func (d DNS) Validate() error {
+	if d.Replicas < 0 {
+		return errors.Errorf("replicas %d: must not be negative", d.Replicas)
+	}
+
+	a := d.Autoscale
+	if a.CoresPerReplica < 0 || a.NodesPerReplica < 0 || a.Min < 0 || a.Max < 0 {
+		return errors.New("autoscale parameters must not be negative")
+	}
+	if a.Max > 0 && a.Min > a.Max {
+		return errors.Errorf("autoscale min %d is greater than max %d", a.Min, a.Max)
+	}
+
+	for domain, servers := range d.StubDomains {
+		if errs := validation.IsDNS1123Subdomain(domain); len(errs) > 0 {
+			return errors.Errorf("stub domain %q: %v", domain, errs)
+		}
+		if len(servers) == 0 {
+			return errors.Errorf("stub domain %s: nameservers are required", domain)
+		}
+		if err := validateNameservers(servers); err != nil {
+			return errors.Wrapf(err, "stub domain %s", domain)
+		}
+	}
+
+	return errors.Wrap(validateNameservers(d.Upstreams), "upstreams")
+}
+
+// validateNameservers checks addresses of nameservers, they are IPs with
+// optional ports, e.g. 10.0.0.2 or 10.0.0.2:5353.
+func validateNameservers(servers []string) error {
+	for _, s := range servers {
+		host := s
+		if h, _, err := net.SplitHostPort(s); err == nil {
+			host = h
+		}
+		if net.ParseIP(host) == nil {
+			return errors.Errorf("nameserver %q isn't an ip address", s)
+		}
+	}
+	return nil
+}
+
+// external-dns policies.
+const (
+	ExternalDNSSync       = "sync"
+	ExternalDNSUpsertOnly = "upsert-only"
+)
+
+// ExternalDNS configures the external-dns addon that creates records of
+// services and ingresses in the DNS of the cloud provider.
+type ExternalDNS struct {
+	// DomainFilters limit the zones that are managed, e.g. example.com.
+	// All zones of the cloud account are managed if it's empty.
+	DomainFilters []string `json:"domainFilters"`
+	// Policy is sync or upsert-only, records are never deleted in the
+	// latter case. It's upsert-only by default.
+	Policy string `json:"policy"`
+}
+
+// Validate checks the external-dns settings.
+func (e ExternalDNS) Validate() error {
+	for _, domain := range e.DomainFilters {
+		if errs := validation.IsDNS1123Subdomain(domain); len(errs) > 0 {
+			return errors.Errorf("domain filter %q: %v", domain, errs)
+		}
+	}
+
+	switch e.Policy {
+	case "", ExternalDNSSync, ExternalDNSUpsertOnly:
+		return nil
+	}
+	return errors.Errorf("unknown policy %q", e.Policy)
+}
@@ -0,0 +1,33 @@
+package profile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDNS_Validate(t *testing.T) {
+	dns := DNS{
+		Upstreams:   []string{"8.8.8.8", "[fd00::1]:53"},
+		StubDomains: map[string][]string{"consul.local": {"10.150.0.1:8600"}},
+		Autoscale:   DNSAutoscale{Enabled: true, Min: 2, Max: 10},
+	}
+	require.NoError(t, dns.Validate())
+
+	dns.Upstreams = []string{"dns.google"}
+	require.Error(t, dns.Validate())
+
+	dns.Upstreams = nil
+	dns.StubDomains["Consul_Local"] = []string{"10.150.0.1"}
+	require.Error(t, dns.Validate())
+
+	require.Error(t, DNS{Autoscale: DNSAutoscale{Min: 5, Max: 2}}.Validate())
+}
+
+func TestExternalDNS_Validate(t *testing.T) {
+	require.NoError(t, ExternalDNS{}.Validate())
+	require.NoError(t, ExternalDNS{DomainFilters: []string{"example.com"}, Policy: ExternalDNSSync}.Validate())
+
+	require.Error(t, ExternalDNS{DomainFilters: []string{"example.com --registry=noop"}}.Validate())
+	require.Error(t, ExternalDNS{Policy: "create-only"}.Validate())
+}
@@ -2,14 +2,23 @@ package profile
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/asaskevich/govalidator.v8"
 
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/labels"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/revision"
 	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
 )
 
 type Handler struct {
@@ -24,8 +33,15 @@ func NewHandler(service *Service) *Handler {
 
 func (h *Handler) Register(r *mux.Router) {
 	r.HandleFunc("/kubeprofiles/{id}", h.GetProfile).Methods(http.MethodGet)
+	r.HandleFunc("/kubeprofiles/{id}/resolved", h.GetResolved).Methods(http.MethodGet)
 	r.HandleFunc("/kubeprofiles", h.CreateProfile).Methods(http.MethodPost)
 	r.HandleFunc("/kubeprofiles", h.GetProfiles).Methods(http.MethodGet)
+	r.HandleFunc("/kubeprofiles/{id}/team", api.AdminOnly(h.SetTeam)).Methods(http.MethodPut)
+	r.HandleFunc("/kubeprofiles/{id}/labels", h.SetLabels).Methods(http.MethodPut)
+	r.HandleFunc("/kubeprofiles/{id}/revisions", h.ListRevisions).Methods(http.MethodGet)
+	r.HandleFunc("/kubeprofiles/{id}/revisions/{number}", h.GetRevision).Methods(http.MethodGet)
+	r.HandleFunc("/kubeprofiles/{id}/revisions/{number}/revert", h.RevertRevision).Methods(http.MethodPost)
+	r.HandleFunc("/kubeprofiles/{id}/diff", h.DiffRevisions).Methods(http.MethodGet)
 }
 
 func (h *Handler) GetProfile(w http.ResponseWriter, r *http.Request) {
@@ -42,6 +58,10 @@ func (h *Handler) GetProfile(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if !api.ScopeFrom(r.Context()).AllowsLabeled(kubeProfile.Team, kubeProfile.Labels) {
+		http.Error(w, sgerrors.ErrNotFound.Error(), http.StatusNotFound)
+		return
+	}
 	if err := json.NewEncoder(w).Encode(kubeProfile); err != nil {
 		logrus.Error(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -64,6 +84,28 @@ func (h *Handler) CreateProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := profile.Validate(); err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	if profile.Team, err = api.ScopeFrom(r.Context()).TeamFor(profile.Team); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if profile.Base != "" {
+		if _, err := Visible(h.service.Get)(r.Context(), profile.Base); err != nil {
+			if sgerrors.IsNotFound(err) {
+				message.SendValidationFailed(w, sgerrors.WithField(errors.Errorf("base profile %s not found", profile.Base), "base"))
+				return
+			}
+			logrus.Error(err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	if err := h.service.Create(r.Context(), profile); err != nil {
 		logrus.Error(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -73,16 +115,294 @@ func (h *Handler) CreateProfile(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 }
 
+// GetProfiles returns visible profiles selected, ordered and paged by list
+// options of the request, e.g. labelSelector=env=prod.
 func (h *Handler) GetProfiles(w http.ResponseWriter, r *http.Request) {
+	opts, err := storage.ParseListOptions(r.URL.Query())
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
 	profiles, err := h.service.GetAll(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	if err := json.NewEncoder(w).Encode(profiles); err != nil {
+	scope := api.ScopeFrom(r.Context())
+	visible := make([][]byte, 0, len(profiles))
+	for _, p := range profiles {
+		if !scope.AllowsLabeled(p.Team, p.Labels) {
+			continue
+		}
+		data, err := json.Marshal(p)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		visible = append(visible, data)
+	}
+
+	page, next, err := storage.Page(visible, opts)
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	resp := make([]json.RawMessage, 0, len(page))
+	for _, v := range page {
+		resp = append(resp, v)
+	}
+	if next != "" {
+		w.Header().Set(storage.ContinueHeader, next)
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// SetTeam moves the profile to another team.
+func (h *Handler) SetTeam(w http.ResponseWriter, r *http.Request) {
+	req := &api.TeamRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	kubeProfile, err := h.service.Get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		logrus.Error(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+
+	kubeProfile.Team = req.Team
+	if err := h.service.Create(r.Context(), kubeProfile); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(req); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// SetLabels replaces labels of the profile, kubes that are already
+// provisioned from it keep their labels.
+func (h *Handler) SetLabels(w http.ResponseWriter, r *http.Request) {
+	req := &api.LabelsRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := labels.Validate(req.Labels); err != nil {
+		message.SendValidationFailed(w, sgerrors.WithField(err, "labels"))
+		return
+	}
+
+	kubeProfile, err := Visible(h.service.Get)(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// labels grant access to other teams, members of other teams can't change them
+	if !api.ScopeFrom(r.Context()).Allows(kubeProfile.Team) {
+		http.Error(w, "labels are changed by members of the team", http.StatusForbidden)
+		return
+	}
+
+	kubeProfile.Labels = req.Labels
+	if err := h.service.Create(r.Context(), kubeProfile); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(req); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// GetResolved returns the profile with its bases merged and variables
+// substituted as it's provisioned, variables of the request are passed as
+// var=name=value query parameters.
+func (h *Handler) GetResolved(w http.ResponseWriter, r *http.Request) {
+	get := Visible(h.service.Get)
+	kubeProfile, err := get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, mux.Vars(r)["id"], err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	vars := make(map[string]string)
+	for _, v := range r.URL.Query()["var"] {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			message.SendValidationFailed(w, sgerrors.WithField(errors.Errorf("variable %q isn't name=value", v), "var"))
+			return
+		}
+		vars[kv[0]] = kv[1]
+	}
+
+	resolved, err := Resolve(r.Context(), get, kubeProfile, vars)
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(resolved); err != nil {
+		logrus.Error(err)
+	}
+}
+
+// ListRevisions returns all saved revisions of the profile.
+func (h *Handler) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if !h.visible(w, r, id) {
+		return
+	}
+
+	revisions, err := h.service.revisions.List(r.Context(), revision.KindProfile, id)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(revisions); err != nil {
+		logrus.Error(err)
+	}
+}
+
+// GetRevision returns a revision of the profile.
+func (h *Handler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	rev, ok := h.revision(w, r, id)
+	if !ok {
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(rev); err != nil {
+		logrus.Error(err)
+	}
+}
+
+// RevertRevision saves the profile of the revision as its latest revision.
+func (h *Handler) RevertRevision(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	rev, ok := h.revision(w, r, id)
+	if !ok {
+		return
+	}
+
+	current, err := h.service.Get(r.Context(), id)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	kubeProfile := &Profile{}
+	if err := json.Unmarshal([]byte(rev.Document), kubeProfile); err != nil {
+		message.SendUnknownError(w, errors.Wrapf(err, "decode revision %d", rev.Number))
+		return
+	}
+	// reverts don't move the profile to another team
+	kubeProfile.ID, kubeProfile.Team = id, current.Team
+	if err := kubeProfile.Validate(); err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	if err := h.service.save(r.Context(), kubeProfile, fmt.Sprintf("revert to revision %d", rev.Number)); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(kubeProfile); err != nil {
+		logrus.Error(err)
+	}
+}
+
+// DiffRevisions compares two revisions of the profile, the latest revision
+// and the one before it by default.
+func (h *Handler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if !h.visible(w, r, id) {
+		return
+	}
+
+	from, to, err := revision.ParseRange(r.URL.Query())
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	diff, err := h.service.revisions.Diff(r.Context(), revision.KindProfile, id, from, to)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, "revision", err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(diff); err != nil {
+		logrus.Error(err)
+	}
+}
+
+// visible responds with not found if the profile isn't in the scope.
+func (h *Handler) visible(w http.ResponseWriter, r *http.Request, id string) bool {
+	_, err := Visible(h.service.Get)(r.Context(), id)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, id, err)
+			return false
+		}
+		message.SendUnknownError(w, err)
+		return false
+	}
+	return true
+}
+
+func (h *Handler) revision(w http.ResponseWriter, r *http.Request, id string) (*revision.Revision, bool) {
+	if !h.visible(w, r, id) {
+		return nil, false
+	}
+
+	number, err := strconv.Atoi(mux.Vars(r)["number"])
+	if err != nil || number < 1 {
+		message.SendValidationFailed(w, sgerrors.WithField(errors.Errorf("invalid revision %q", mux.Vars(r)["number"]), "number"))
+		return nil, false
+	}
+
+	rev, err := h.service.revisions.Get(r.Context(), revision.KindProfile, id, number)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, "revision", err)
+			return nil, false
+		}
+		message.SendUnknownError(w, err)
+		return nil, false
+	}
+	return rev, true
 }
@@ -2,6 +2,7 @@ package profile
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -11,9 +12,12 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"gopkg.in/asaskevich/govalidator.v8"
 
+	"github.com/supergiant/control/pkg/api"
 	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/revision"
 	"github.com/supergiant/control/pkg/sgerrors"
 	"github.com/supergiant/control/pkg/testutils"
 )
@@ -60,7 +64,7 @@ func TestKubeProfileEndpointCreateProfileSuccess(t *testing.T) {
 		Arch:                  "amd64",
 		OperatingSystem:       "linux",
 		UbuntuVersion:         "xenial",
-		DockerVersion:         "1.18.1",
+		DockerVersion:         "18.06.1",
 		FlannelVersion:        "0.9.0",
 		NetworkType:           "vxlan",
 		CIDR:                  "10.0.0.1/24",
@@ -74,6 +78,7 @@ func TestKubeProfileEndpointCreateProfileSuccess(t *testing.T) {
 	data, _ := json.Marshal(kubeProfile)
 	mockRepo.On("Put", mock.Anything, mock.Anything,
 		mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("GetAll", mock.Anything, mock.Anything).Return([][]byte{}, nil)
 	svc := NewService("prefix", mockRepo)
 	endpoint := &Handler{
 		service: svc,
@@ -97,6 +102,7 @@ func TestKubeProfileEndpointCreateProfileErrJson(t *testing.T) {
 	data := []byte(`{`)
 	mockRepo.On("Put", mock.Anything, mock.Anything,
 		mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("GetAll", mock.Anything, mock.Anything).Return([][]byte{}, nil)
 	svc := NewService("prefix", mockRepo)
 	endpoint := &Handler{
 		service: svc,
@@ -133,7 +139,7 @@ func TestKubeProfileEndpointCreateProfileInternalError(t *testing.T) {
 		Arch:            "amd64",
 		OperatingSystem: "linux",
 		UbuntuVersion:   "xenial",
-		DockerVersion:   "1.18.1",
+		DockerVersion:   "18.06.1",
 		FlannelVersion:  "0.9.0",
 		NetworkType:     "vxlan",
 		CIDR:            "10.0.0.1/24",
@@ -177,7 +183,7 @@ func TestHandler_Register(t *testing.T) {
 	r := mux.NewRouter()
 	h := Handler{}
 	h.Register(r)
-	expectedRouteCount := 3
+	expectedRouteCount := 10
 	routes := []*mux.Route{}
 
 	walkFn := func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
@@ -215,7 +221,7 @@ func TestGetProfile(t *testing.T) {
 		Arch:            "amd64",
 		OperatingSystem: "linux",
 		UbuntuVersion:   "xenial",
-		DockerVersion:   "1.18.1",
+		DockerVersion:   "18.06.1",
 		FlannelVersion:  "0.9.0",
 		NetworkType:     "vxlan",
 		CIDR:            "10.0.0.1/24",
@@ -340,3 +346,96 @@ func TestService_GetAll(t *testing.T) {
 		}
 	}
 }
+
+func TestHandler_Revisions(t *testing.T) {
+	svc := testProfiles(t)
+	h := NewHandler(svc)
+	router := mux.NewRouter()
+	h.Register(router)
+	request := func(method, url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, url, nil))
+		return rec
+	}
+
+	p, err := svc.Get(context.Background(), "base")
+	require.NoError(t, err)
+	p.K8SVersion = "1.15.0"
+	require.NoError(t, svc.Create(context.Background(), p))
+
+	rec := request(http.MethodGet, "/kubeprofiles/base/revisions")
+	require.Equal(t, http.StatusOK, rec.Code)
+	revisions := []revision.Revision{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&revisions))
+	require.Len(t, revisions, 2)
+
+	rec = request(http.MethodGet, "/kubeprofiles/base/diff")
+	require.Equal(t, http.StatusOK, rec.Code)
+	diff := &revision.Diff{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(diff))
+	require.Contains(t, diff.Patch, `+  "K8SVersion": "1.15.0"`)
+
+	rec = request(http.MethodPost, "/kubeprofiles/base/revisions/1/revert")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	p, err = svc.Get(context.Background(), "base")
+	require.NoError(t, err)
+	require.Equal(t, "1.14.1", p.K8SVersion)
+
+	latest, err := svc.revisions.Get(context.Background(), revision.KindProfile, "base", 0)
+	require.NoError(t, err)
+	require.Equal(t, 3, latest.Number)
+	require.Equal(t, "revert to revision 1", latest.Comment)
+
+	require.Equal(t, http.StatusNotFound, request(http.MethodGet, "/kubeprofiles/base/revisions/7").Code)
+	require.Equal(t, http.StatusBadRequest, request(http.MethodGet, "/kubeprofiles/base/revisions/x").Code)
+	require.Equal(t, http.StatusNotFound, request(http.MethodGet, "/kubeprofiles/unknown/revisions").Code)
+}
+
+func TestHandler_Labels(t *testing.T) {
+	h := NewHandler(testProfiles(t))
+	router := mux.NewRouter()
+	h.Register(router)
+	request := func(method, url, body string, scope api.Scope) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, bytes.NewBufferString(body))
+		req = req.WithContext(api.WithScope(req.Context(), scope))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	member := api.Scope{Teams: []string{"ops"}}
+
+	rec := request(http.MethodPut, "/kubeprofiles/prod/labels", `{"labels": {"env": "prod eu"}}`, member)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = request(http.MethodPut, "/kubeprofiles/prod/labels", `{"labels": {"env": "prod"}}`, member)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// profiles of other teams are visible by selectors, but their labels aren't changed
+	staging := api.Scope{Selectors: []map[string]string{{"env": "staging"}}}
+	rec = request(http.MethodPut, "/kubeprofiles/other/labels", `{"labels": {"env": "staging"}}`, api.Scope{Admin: true})
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = request(http.MethodGet, "/kubeprofiles/other", "", staging)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = request(http.MethodPut, "/kubeprofiles/other/labels", `{"labels": {"env": "dev"}}`, staging)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestHandler_GetProfilesByLabels(t *testing.T) {
+	repo := &testutils.MockStorage{}
+	repo.On("GetAll", mock.Anything, mock.Anything).Return([][]byte{
+		[]byte(`{"id": "prod", "labels": {"env": "prod"}}`),
+		[]byte(`{"id": "dev"}`),
+		[]byte(`{"id": "other", "team": "other", "labels": {"env": "prod"}}`),
+	}, nil)
+	h := NewHandler(&Service{prefix: "prefix", kubeProfileStorage: repo})
+
+	req := httptest.NewRequest(http.MethodGet, "/kubeprofiles?labelSelector=env=prod", nil)
+	req = req.WithContext(api.WithScope(req.Context(), api.Scope{Teams: []string{"ops"}}))
+	rec := httptest.NewRecorder()
+	h.GetProfiles(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	profiles := []Profile{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&profiles))
+	require.Len(t, profiles, 1)
+	require.Equal(t, "prod", profiles[0].ID)
+}
@@ -0,0 +1,231 @@
+package profile
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+)
+
+// DefaultInstanceName is the template of instance names when it isn't set.
+const DefaultInstanceName = "{{lower .ClusterName}}-{{.Role}}-{{.ID}}"
+
+// DefaultSecurityGroupName is the template of security group names when it
+// isn't set.
+const DefaultSecurityGroupName = "{{.ClusterID}}-{{.Role}}s-secgroup"
+
+// Naming holds text/template templates of names of cloud resources that
+// are created for the cluster, e.g. "{{.ClusterName}}-{{.NodeGroup}}-{{.Index}}".
+// Templates get NameVars, lower and upper functions change the case.
+type Naming struct {
+	// Instance names machines, it must tell machines apart with the ID or
+	// the Index together with the Role or the NodeGroup.
+	Instance string `json:"instance,omitempty"`
+	// VPC names the VPC of the cluster with the Name tag, AWS only.
+	VPC string `json:"vpc,omitempty"`
+	// SecurityGroup names security groups of masters and nodes, AWS only,
+	// the Role tells them apart.
+	SecurityGroup string `json:"securityGroup,omitempty"`
+}
+
+// NameVars are variables of naming templates.
+type NameVars struct {
+	ClusterName string
+	ClusterID   string
+	Region      string
+	// Role is master or node.
+	Role string
+	// NodeGroup is the nodeGroup of the node profile or the role.
+	NodeGroup string
+	// Index of the machine among machines of the role.
+	Index int
+	// ID is the short id of the task that creates the machine.
+	ID string
+}
+
+// nameRule limits names of a resource of a provider.
+type nameRule struct {
+	maxLen int
+	re     *regexp.Regexp
+	desc   string
+}
+
+var (
+	hostnameRule = nameRule{253, regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9.-]*[a-zA-Z0-9])?$`), "letters, digits, dots and dashes"}
+	gceRule      = nameRule{63, regexp.MustCompile(`^[a-z]([-a-z0-9]*[a-z0-9])?$`), "lowercase letters, digits and dashes, starting with a letter"}
+	azureRule    = nameRule{64, regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?$`), "letters, digits and dashes"}
+	awsTagRule   = nameRule{255, regexp.MustCompile(`^.+$`), "any characters"}
+	// https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_CreateSecurityGroup.html
+	awsGroupRule = nameRule{255, regexp.MustCompile(`^[a-zA-Z0-9 ._\-:/()#,@\[\]+=&;{}!$*]+$`), "letters, digits, spaces and ._-:/()#,@[]+=&;{}!$*"}
+)
+
+// nameRules of resources by providers, names of providers that aren't
+// listed aren't checked.
+var nameRules = map[clouds.Name]map[string]nameRule{
+	clouds.AWS: {
+		"instance":      awsTagRule,
+		"vpc":           awsTagRule,
+		"securityGroup": awsGroupRule,
+	},
+	clouds.GCE: {
+		"instance": gceRule,
+	},
+	clouds.DigitalOcean: {
+		"instance": hostnameRule,
+	},
+	clouds.Azure: {
+		"instance": azureRule,
+	},
+}
+
+var nameFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// InstanceName returns the name of the machine.
+func (n Naming) InstanceName(vars NameVars) (string, error) {
+	return renderName("instance", orDefault(n.Instance, DefaultInstanceName), vars)
+}
+
+// VPCName returns the Name tag of the VPC, it's empty if VPC isn't set.
+func (n Naming) VPCName(vars NameVars) (string, error) {
+	if n.VPC == "" {
+		return "", nil
+	}
+	return renderName("vpc", n.VPC, vars)
+}
+
+// SecurityGroupName returns the name of the security group of the role.
+func (n Naming) SecurityGroupName(vars NameVars) (string, error) {
+	return renderName("securityGroup", orDefault(n.SecurityGroup, DefaultSecurityGroupName), vars)
+}
+
+// Validate checks that templates can be rendered, names of the example
+// cluster fit limits of the provider and instance names are unique.
+func (n Naming) Validate(provider clouds.Name) error {
+	return n.ValidateFor(provider, NameVars{
+		ClusterName: "cluster",
+		ClusterID:   "a1b2c3d4",
+		Region:      "region-1",
+	})
+}
+
+// ValidateFor checks names of the cluster of vars, the role, the index and
+// the id of vars are replaced with examples.
+func (n Naming) ValidateFor(provider clouds.Name, vars NameVars) error {
+	rules := nameRules[provider]
+	for _, resource := range []struct {
+		name     string
+		template string
+	}{
+		{"vpc", n.VPC},
+		{"securityGroup", n.SecurityGroup},
+	} {
+		if _, ok := rules[resource.name]; resource.template != "" && !ok {
+			return errors.Errorf("%s names aren't supported on %s", resource.name, provider)
+		}
+	}
+
+	index := vars.Index
+	if index < 99 {
+		index = 99
+	}
+	sample := func(role string, index int, id string) NameVars {
+		v := vars
+		v.Role, v.NodeGroup, v.Index, v.ID = role, role, index, id
+		return v
+	}
+
+	master, err := n.checkName(provider, "instance", n.InstanceName, sample("master", index, "ffff"))
+	if err != nil {
+		return err
+	}
+	otherID, err := n.InstanceName(sample("master", index, "eeee"))
+	if err != nil {
+		return err
+	}
+	node, err := n.checkName(provider, "instance", n.InstanceName, sample("node", index, "ffff"))
+	if err != nil {
+		return err
+	}
+	otherIndex, err := n.InstanceName(sample("master", index-1, "ffff"))
+	if err != nil {
+		return err
+	}
+	if master == otherID && (master == otherIndex || master == node) {
+		return errors.New("instance names must differ by the ID or by the Index and the Role or the NodeGroup")
+	}
+
+	if n.VPC != "" {
+		if _, err := n.checkName(provider, "vpc", n.VPCName, vars); err != nil {
+			return err
+		}
+	}
+
+	masters, err := n.checkName(provider, "securityGroup", n.SecurityGroupName, sample("master", 0, ""))
+	if err != nil {
+		return err
+	}
+	nodes, err := n.SecurityGroupName(sample("node", 0, ""))
+	if err != nil {
+		return err
+	}
+	if masters == nodes {
+		return errors.New("security group names must differ by the Role")
+	}
+
+	return nil
+}
+
+func (n Naming) checkName(provider clouds.Name, resource string, render func(NameVars) (string, error), vars NameVars) (string, error) {
+	name, err := render(vars)
+	if err != nil {
+		return "", err
+	}
+
+	rule, ok := nameRules[provider][resource]
+	if !ok {
+		return name, nil
+	}
+	if provider == clouds.GCE {
+		// instance names are lowercased on gce
+		name = strings.ToLower(name)
+	}
+	if len(name) > rule.maxLen {
+		return "", errors.Errorf("%s name %q is longer than %d characters of %s", resource, name, rule.maxLen, provider)
+	}
+	if !rule.re.MatchString(name) {
+		return "", errors.Errorf("%s name %q of %s may contain %s only", resource, name, provider, rule.desc)
+	}
+	return name, nil
+}
+
+func renderName(resource, text string, vars NameVars) (string, error) {
+	tpl, err := template.New(resource).Funcs(nameFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", errors.Wrapf(err, "parse %s name", resource)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := tpl.Execute(buf, vars); err != nil {
+		return "", errors.Wrapf(err, "render %s name", resource)
+	}
+
+	name := strings.TrimSpace(buf.String())
+	if name == "" {
+		return "", errors.Errorf("%s name of %q is empty", resource, text)
+	}
+	return name, nil
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,110 @@
+package profile
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clouds"
+)
+
+func TestNaming_InstanceName(t *testing.T) {
+	vars := NameVars{
+		ClusterName: "Prod",
+		ClusterID:   "a1b2c3d4",
+		Region:      "us-east-1",
+		Role:        "node",
+		NodeGroup:   "gpu",
+		Index:       2,
+		ID:          "ab12",
+	}
+
+	name, err := Naming{}.InstanceName(vars)
+	require.NoError(t, err)
+	require.Equal(t, "prod-node-ab12", name)
+
+	name, err = Naming{Instance: "{{upper .Region}}-{{.ClusterName}}-{{.NodeGroup}}-{{printf \"%03d\" .Index}}"}.InstanceName(vars)
+	require.NoError(t, err)
+	require.Equal(t, "US-EAST-1-Prod-gpu-002", name)
+
+	name, err = Naming{}.SecurityGroupName(NameVars{ClusterID: "a1b2c3d4", Role: "master"})
+	require.NoError(t, err)
+	require.Equal(t, "a1b2c3d4-masters-secgroup", name)
+
+	name, err = Naming{}.VPCName(vars)
+	require.NoError(t, err)
+	require.Empty(t, name)
+
+	_, err = Naming{Instance: "{{.Zone}}"}.InstanceName(vars)
+	require.Error(t, err)
+}
+
+func TestNaming_Validate(t *testing.T) {
+	testCases := []struct {
+		description string
+		provider    clouds.Name
+		naming      Naming
+		expectedErr string
+	}{
+		{
+			description: "defaults",
+			provider:    clouds.GCE,
+		},
+		{
+			description: "index and role",
+			provider:    clouds.AWS,
+			naming: Naming{
+				Instance:      "{{.ClusterName}}-{{.Role}}-{{.Index}}",
+				VPC:           "vpc-{{.ClusterName}}-{{.Region}}",
+				SecurityGroup: "sg {{.ClusterName}} {{.Role}}",
+			},
+		},
+		{
+			description: "invalid template",
+			provider:    clouds.AWS,
+			naming:      Naming{Instance: "{{.ClusterName"},
+			expectedErr: "parse instance name",
+		},
+		{
+			description: "not unique",
+			provider:    clouds.AWS,
+			naming:      Naming{Instance: "{{.ClusterName}}-{{.Index}}"},
+			expectedErr: "instance names must differ",
+		},
+		{
+			description: "same security groups",
+			provider:    clouds.AWS,
+			naming:      Naming{SecurityGroup: "{{.ClusterName}}"},
+			expectedErr: "security group names must differ",
+		},
+		{
+			description: "underscore on gce",
+			provider:    clouds.GCE,
+			naming:      Naming{Instance: "{{.ClusterName}}_{{.ID}}"},
+			expectedErr: "may contain lowercase letters",
+		},
+		{
+			description: "too long on azure",
+			provider:    clouds.Azure,
+			naming:      Naming{Instance: strings.Repeat("a", 60) + "-{{.ID}}"},
+			expectedErr: "longer than 64 characters",
+		},
+		{
+			description: "vpc on digitalocean",
+			provider:    clouds.DigitalOcean,
+			naming:      Naming{VPC: "{{.ClusterName}}"},
+			expectedErr: "vpc names aren't supported on digitalocean",
+		},
+	}
+
+	for _, testCase := range testCases {
+		err := testCase.naming.Validate(testCase.provider)
+		if testCase.expectedErr == "" {
+			require.NoError(t, err, testCase.description)
+			continue
+		}
+		require.Error(t, err, testCase.description)
+		require.Contains(t, err.Error(), testCase.expectedErr, testCase.description)
+	}
+}
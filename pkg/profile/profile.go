@@ -1,9 +1,27 @@
 package profile
 
-import "github.com/supergiant/control/pkg/clouds"
+import (
+	"context"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/runner"
+)
 
 type Profile struct {
 	ID string `json:"id" valid:"required"`
+	// Team the profile belongs to, profiles without a team are shared.
+	Team string `json:"team,omitempty" valid:"-"`
+	// Labels group profiles, kubes provisioned from the profile get them.
+	Labels map[string]string `json:"labels,omitempty" valid:"-"`
+	// Base is the id of the profile this one is an overlay of, settings
+	// that aren't set are inherited from it when a cluster is provisioned.
+	Base string `json:"base,omitempty" valid:"-"`
+	// Variables are substituted for ${name} references in settings of the
+	// profile and its bases, e.g. {"env": "prod"}.
+	Variables map[string]string `json:"variables,omitempty" valid:"-"`
 
 	MasterProfiles []NodeProfile `json:"masterProfiles" valid:"-"`
 	NodesProfiles  []NodeProfile `json:"nodesProfiles" valid:"-"`
@@ -45,16 +63,255 @@ type Profile struct {
 	// by cloud provider security groups.
 	ExposedAddresses []Addresses `json:"exposedAddresses" valid:"-"`
 	Addons           []string    `json:"addons,omitempty" valid:"-"`
+
+	// AuditLog configures kube-apiserver audit logging on master nodes.
+	AuditLog AuditLog `json:"auditLog" valid:"-"`
+	// CISHardening applies CIS Kubernetes Benchmark settings during provisioning.
+	CISHardening bool `json:"cisHardening" valid:"-"`
+	// Admission selects admission plugins and pod security defaults of kube-apiserver.
+	Admission Admission `json:"admission" valid:"-"`
+	// DNS tunes CoreDNS of the cluster.
+	DNS DNS `json:"dns" valid:"-"`
+	// ExternalDNS configures the external-dns addon.
+	ExternalDNS ExternalDNS `json:"externalDns" valid:"-"`
+	// CertManager configures the cert-manager addon.
+	CertManager CertManager `json:"certManager" valid:"-"`
+	// Ingress selects an ingress controller of the cluster.
+	Ingress Ingress `json:"ingress" valid:"-"`
+	// RegistryCredentials enable pulls from private registries of the cloud
+	// with identities of the machines.
+	RegistryCredentials RegistryCredentials `json:"registryCredentials" valid:"-"`
+	// Sysctl overrides kernel parameters that are set on every node.
+	Sysctl map[string]string `json:"sysctl" valid:"-"`
+	// KernelModules are loaded on every node in addition to the default ones.
+	KernelModules []string `json:"kernelModules" valid:"-"`
+	// NTPServers replaces the default chrony pool, e.g. for air-gapped environments.
+	NTPServers []string `json:"ntpServers" valid:"-"`
+	// ExternalCA makes the cluster CA an intermediate of an existing CA.
+	ExternalCA ExternalCA `json:"externalCA" valid:"-"`
+	// Certificates overrides lifetimes and SANs of cluster component certificates.
+	Certificates Certificates `json:"certificates" valid:"-"`
+	// RunnerType selects how commands are executed on machines, "ssh" by
+	// default or "ssm" to use AWS Systems Manager.
+	RunnerType string `json:"runnerType" valid:"-"`
+	// StaticIPs keep public addresses of masters when they are replaced.
+	StaticIPs StaticIPs `json:"staticIps" valid:"-"`
+	// SSH overrides the user and the port of machines for images that
+	// disallow root logins, node profiles can override them again.
+	SSH SSHSettings `json:"ssh" valid:"-"`
+	// NodeBootstrap limits how many worker nodes are provisioned at once.
+	NodeBootstrap NodeBootstrap `json:"nodeBootstrap" valid:"-"`
+	// Naming overrides names of instances, VPCs and security groups to
+	// follow naming conventions of the organization.
+	Naming Naming `json:"naming" valid:"-"`
+	// UpgradeCanary verifies the cluster with canary nodes before other
+	// nodes are upgraded.
+	UpgradeCanary UpgradeCanary `json:"upgradeCanary" valid:"-"`
+}
+
+// Validate checks settings that struct tags can't describe, all violations
+// are returned as sgerrors.Violations.
+func (p *Profile) Validate() error {
+	return p.Check(context.Background(), nil)
 }
 
+// Node profile keys that are used to configure a kubelet.
+const (
+	// KubeletArgsKey holds space separated kubelet flags,
+	// e.g. "--eviction-hard=memory.available<500Mi --max-pods=60"
+	KubeletArgsKey = "kubeletArgs"
+	// NodeLabelsKey holds node labels, e.g. "disktype=ssd,gpu=true"
+	NodeLabelsKey = "labels"
+	// NodeTaintsKey holds node taints, e.g. "nvidia.com/gpu=true:NoSchedule"
+	NodeTaintsKey = "taints"
+	// NodeOSKey selects an operating system of a worker node, "windows"
+	// nodes are provisioned from Windows Server images.
+	NodeOSKey = "os"
+	// NodeImageKey pins the machine image of the node, e.g. an AMI ID.
+	NodeImageKey = "image"
+	// NodeImageFamilyKey holds a family like "ubuntu-22.04-lts" that is
+	// resolved to its latest image in the region when the node is created.
+	NodeImageFamilyKey = "imageFamily"
+	// SSHUserKey and SSHPortKey override ssh settings of the profile for
+	// machines of the node profile.
+	SSHUserKey = "sshUser"
+	SSHPortKey = "sshPort"
+	// NodeGroupKey names the group of machines of the node profile in
+	// naming templates, the role of the machine is used when it's empty.
+	NodeGroupKey = "nodeGroup"
+)
+
 type NodeProfile map[string]string
 type CloudSpecificSettings map[string]string
 
+// Ingress controllers.
+const (
+	IngressNginx   = "nginx"
+	IngressTraefik = "traefik"
+)
+
+// Ingress selects an ingress controller that is installed to the cluster.
+type Ingress struct {
+	// Controller is nginx or traefik, no controller is installed if it's empty.
+	Controller string `json:"controller"`
+	// Annotations of the controller service are merged over the load
+	// balancer defaults of the provider.
+	Annotations map[string]string `json:"annotations"`
+}
+
+// StaticIPs reserves public addresses of the API endpoint: elastic ips on
+// AWS, static addresses on GCE and reserved ips on DigitalOcean. Load
+// balancers of the API keep their addresses on GCE and DigitalOcean, AWS
+// classic load balancers have no addresses, so only masters need them.
+type StaticIPs struct {
+	// Masters attaches a static ip to every master.
+	Masters bool `json:"masters"`
+}
+
+// SSHSettings of machines, empty settings keep defaults of the provider,
+// e.g. the ubuntu user on AWS.
+type SSHSettings struct {
+	User string `json:"user,omitempty"`
+	Port string `json:"port,omitempty"`
+	// Escalation is how commands gain root privileges: "sudo" without a
+	// password, "sudo-password" when sudo asks for the SudoPassword or
+	// "none" for root users of images without sudo. Sudo of the user is
+	// detected on machines when it's empty.
+	Escalation   string `json:"escalation,omitempty"`
+	SudoPassword string `json:"sudoPassword,omitempty"`
+}
+
+// Validate checks the port and the escalation.
+func (s SSHSettings) Validate() error {
+	if err := ValidateSSHPort(s.Port); err != nil {
+		return err
+	}
+
+	switch s.Escalation {
+	case "", runner.EscalationSudo, runner.EscalationNone:
+	case runner.EscalationSudoPassword:
+		if s.SudoPassword == "" {
+			return errors.Errorf("%s escalation requires sudoPassword", runner.EscalationSudoPassword)
+		}
+	default:
+		return errors.Errorf("unknown escalation %q", s.Escalation)
+	}
+	return nil
+}
+
+// ValidateSSHPort checks that the port is empty or a valid tcp port.
+func ValidateSSHPort(port string) error {
+	if port == "" {
+		return nil
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return errors.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
+// Failure policies of worker nodes provisioned with the cluster.
+const (
+	// BestEffort provisions all nodes, the cluster is created without
+	// nodes that failed.
+	BestEffort = "best-effort"
+	// FailFast cancels nodes that are being provisioned when one of them
+	// fails, the cluster fails.
+	FailFast = "fail-fast"
+)
+
+// DefaultNodeParallelism is the number of nodes provisioned at once.
+const DefaultNodeParallelism = 10
+
+// NodeBootstrap settings of worker nodes provisioned with the cluster.
+type NodeBootstrap struct {
+	// Parallelism is the number of nodes provisioned at once,
+	// DefaultNodeParallelism when it's 0.
+	Parallelism int `json:"parallelism,omitempty"`
+	// FailurePolicy is BestEffort by default or FailFast.
+	FailurePolicy string `json:"failurePolicy,omitempty"`
+}
+
+// Validate checks the parallelism and the failure policy.
+func (b NodeBootstrap) Validate() error {
+	if b.Parallelism < 0 {
+		return errors.New("parallelism must not be negative")
+	}
+	switch b.FailurePolicy {
+	case "", BestEffort, FailFast:
+	default:
+		return errors.Errorf("unknown failure policy %q, policies: %s, %s", b.FailurePolicy, BestEffort, FailFast)
+	}
+	return nil
+}
+
+// Limit returns the number of nodes provisioned at once.
+func (b NodeBootstrap) Limit() int {
+	if b.Parallelism == 0 {
+		return DefaultNodeParallelism
+	}
+	return b.Parallelism
+}
+
 // Addresses uses cidr to define an ip list.
 type Addresses struct {
 	CIDR string `json:"cidr"`
 }
 
+// AuditLog holds kube-apiserver audit logging settings.
+// https://kubernetes.io/docs/tasks/debug-application-cluster/audit/
+type AuditLog struct {
+	Enabled bool `json:"enabled"`
+	// Level is one of Metadata, Request or RequestResponse.
+	Level string `json:"level"`
+
+	// Log rotation settings for the audit log file on a master node.
+	MaxAge    int `json:"maxAge"`
+	MaxBackup int `json:"maxBackup"`
+	MaxSize   int `json:"maxSize"`
+
+	// WebhookURL is an optional backend that receives audit events.
+	WebhookURL string `json:"webhookUrl"`
+}
+
+// Certificates holds settings of apiserver, etcd and kubelet certificates.
+type Certificates struct {
+	// ValidityDays is a lifetime of the certificates, kubeadm defaults are used if it's zero.
+	ValidityDays int `json:"validityDays"`
+
+	// Extra subject alternative names, DNS names or IP addresses,
+	// e.g. corporate DNS names or virtual IPs of load balancers.
+	APIServerSANs []string `json:"apiServerSANs"`
+	EtcdSANs      []string `json:"etcdSANs"`
+	KubeletSANs   []string `json:"kubeletSANs"`
+}
+
+// ExternalCA defines a parent CA for the cluster CA, only one of its sources is used.
+// The cluster CA is self-signed when none of them is set.
+type ExternalCA struct {
+	// Cert and Key of the parent CA, the key is used only to sign the cluster CA.
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+
+	// Vault signs the cluster CA with the Vault PKI secrets engine.
+	Vault VaultPKI `json:"vault"`
+
+	// CSRID refers to a CSR exported with POST /pki/csr and SignedCert is
+	// the certificate issued for it by an offline CA.
+	CSRID      string `json:"csrId"`
+	SignedCert string `json:"signedCert"`
+}
+
+// VaultPKI holds settings of the Vault PKI secrets engine.
+// https://www.vaultproject.io/docs/secrets/pki/index.html
+type VaultPKI struct {
+	Address string `json:"address"`
+	Token   string `json:"token"`
+	// Mount is a path of the secrets engine, "pki" by default.
+	Mount string `json:"mount"`
+	TTL   string `json:"ttl"`
+}
+
 // StaticAuth represents tokens and basic authentication credentials.
 type StaticAuth struct {
 	BasicAuth []BasicAuthUser `json:"basicAuth"`
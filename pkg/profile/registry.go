@@ -0,0 +1,20 @@
+package profile
+
+// RegistryCredentials make kubelet of every node pull images of private
+// registries of the cloud with the identity of the machine, so pods don't
+// need imagePullSecrets.
+type RegistryCredentials struct {
+	// ECR authenticates to Amazon ECR with the instance profile of the node.
+	ECR bool `json:"ecr"`
+	// GCR authenticates to Google Container Registry with the service
+	// account of the instance.
+	GCR bool `json:"gcr"`
+	// ACR authenticates to Azure Container Registry with the system assigned
+	// identity of the vm, the identity needs the AcrPull role on the registry.
+	ACR bool `json:"acr"`
+}
+
+// IsSet tells whether any credential provider is enabled.
+func (r RegistryCredentials) IsSet() bool {
+	return r.ECR || r.GCR || r.ACR
+}
@@ -0,0 +1,283 @@
+package profile
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/labels"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/versions"
+)
+
+// Network providers that are installed by the network step.
+const (
+	NetworkFlannel = "Flannel"
+	NetworkCalico  = "Calico"
+	NetworkWeave   = "Weave"
+)
+
+// networkVersions are kubernetes versions the manifests of network providers
+// work with, their daemon sets use extensions/v1beta1 that is removed in 1.16.
+var networkVersions = map[string]string{
+	NetworkFlannel: ">= 1.9, < 1.16",
+	NetworkCalico:  ">= 1.10, < 1.16",
+	NetworkWeave:   ">= 1.8, < 1.16",
+}
+
+// dockershimRemoved is the kubernetes version that can't run on docker.
+var dockershimRemoved = semver.MustParse("1.24.0")
+
+// SizeLister returns machine sizes that are available in the region, e.g.
+// of the cloud account the cluster is provisioned with.
+type SizeLister func(ctx context.Context, region string) ([]string, error)
+
+// Check validates settings that depend on each other and on the provider,
+// all violations are returned at once as sgerrors.Violations. Machine sizes
+// are checked only if sizes is set.
+func (p *Profile) Check(ctx context.Context, sizes SizeLister) error {
+	var v sgerrors.Violations
+
+	checkSettings(p, &v)
+	checkNetworks(p, &v)
+	checkVersions(p, &v)
+	checkMasters(p, &v)
+	checkImages(p, &v)
+	checkSSH(p, &v)
+	if sizes != nil {
+		checkSizes(ctx, p, sizes, &v)
+	}
+
+	return v.Err()
+}
+
+func checkSettings(p *Profile, v *sgerrors.Violations) {
+	if err := labels.Validate(p.Labels); err != nil {
+		v.Add("labels", err.Error())
+	}
+	if err := p.Admission.Validate(p.K8SVersion); err != nil {
+		v.Add("admission", err.Error())
+	}
+	if err := p.DNS.Validate(); err != nil {
+		v.Add("dns", err.Error())
+	}
+	if err := p.ExternalDNS.Validate(); err != nil {
+		v.Add("externalDns", err.Error())
+	}
+	if err := p.CertManager.Validate(); err != nil {
+		v.Add("certManager", err.Error())
+	}
+	if err := p.NodeBootstrap.Validate(); err != nil {
+		v.Add("nodeBootstrap", err.Error())
+	}
+	if err := p.Naming.Validate(p.Provider); err != nil {
+		v.Add("naming", err.Error())
+	}
+	if err := p.UpgradeCanary.Validate(); err != nil {
+		v.Add("upgradeCanary", err.Error())
+	}
+	switch p.Ingress.Controller {
+	case "", IngressNginx, IngressTraefik:
+	default:
+		v.Add("ingress.controller", fmt.Sprintf("unknown controller %q", p.Ingress.Controller))
+	}
+	switch p.Provider {
+	case clouds.AWS, clouds.GCE, clouds.DigitalOcean:
+	default:
+		if p.StaticIPs.Masters {
+			v.Add("staticIps.masters", fmt.Sprintf("static ips aren't supported on %s", p.Provider))
+		}
+	}
+	for _, registry := range []struct {
+		flag     string
+		enabled  bool
+		provider clouds.Name
+	}{
+		{"ecr", p.RegistryCredentials.ECR, clouds.AWS},
+		{"gcr", p.RegistryCredentials.GCR, clouds.GCE},
+		{"acr", p.RegistryCredentials.ACR, clouds.Azure},
+	} {
+		if registry.enabled && p.Provider != registry.provider {
+			v.Add("registryCredentials."+registry.flag,
+				fmt.Sprintf("%s credentials are available on %s only", registry.flag, registry.provider))
+		}
+	}
+}
+
+// checkNetworks checks that the pod and the service networks don't overlap.
+func checkNetworks(p *Profile, v *sgerrors.Violations) {
+	parse := func(field, cidr string) *net.IPNet {
+		if cidr == "" {
+			return nil
+		}
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			v.Add(field, fmt.Sprintf("invalid cidr %q", cidr))
+			return nil
+		}
+		return n
+	}
+
+	pods := parse("cidr", p.CIDR)
+	services := parse("k8sServicesCIDR", p.K8SServicesCIDR)
+	if pods != nil && services != nil && overlap(pods, services) {
+		v.Add("k8sServicesCIDR", fmt.Sprintf("%s overlaps with the pod network %s", services, pods))
+	}
+
+	for i, addr := range p.ExposedAddresses {
+		parse(fmt.Sprintf("exposedAddresses[%d].cidr", i), addr.CIDR)
+	}
+}
+
+func overlap(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// checkVersions checks that the network provider and the container runtime
+// support the kubernetes version.
+func checkVersions(p *Profile, v *sgerrors.Violations) {
+	constraint, known := networkVersions[p.NetworkProvider]
+	if p.NetworkProvider != "" && !known {
+		names := make([]string, 0, len(networkVersions))
+		for name := range networkVersions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		v.Add("networkProvider", fmt.Sprintf("unknown provider %q, providers: %s",
+			p.NetworkProvider, strings.Join(names, ", ")))
+	}
+
+	if p.K8SVersion == "" {
+		return
+	}
+	version, err := semver.NewVersion(p.K8SVersion)
+	if err != nil {
+		v.Add("K8SVersion", fmt.Sprintf("invalid version %q", p.K8SVersion))
+		return
+	}
+
+	if known {
+		c, err := semver.NewConstraint(constraint)
+		if err == nil && !c.Check(version) {
+			v.Add("K8SVersion", fmt.Sprintf("%s isn't supported by %s, supported versions: %s",
+				p.K8SVersion, p.NetworkProvider, constraint))
+		}
+	}
+	if !version.LessThan(dockershimRemoved) {
+		v.Add("K8SVersion", fmt.Sprintf("%s can't run on docker, kubernetes supports it before %s",
+			p.K8SVersion, dockershimRemoved))
+	}
+
+	catalog := versions.Default()
+	release, err := catalog.Release(p.K8SVersion)
+	if err != nil {
+		v.Add("K8SVersion", fmt.Sprintf("%s isn't in the version catalog, supported versions: %s",
+			p.K8SVersion, strings.Join(catalog.Minors(), ", ")))
+		return
+	}
+	if p.DockerVersion != "" && !release.RuntimeCompatible(versions.Docker, p.DockerVersion) {
+		v.Add("dockerVersion", fmt.Sprintf("docker %s isn't compatible with kubernetes %s, compatible versions: %s",
+			p.DockerVersion, release.Minor, strings.Join(release.Runtimes[versions.Docker], ", ")))
+	}
+}
+
+// checkMasters checks that etcd of highly available clusters has a quorum
+// when a master is lost.
+func checkMasters(p *Profile, v *sgerrors.Violations) {
+	if n := len(p.MasterProfiles); n > 1 && n%2 == 0 {
+		v.Add("masterProfiles", fmt.Sprintf("%d masters can't keep a quorum of etcd, use an odd number", n))
+	}
+}
+
+// checkImages checks that image families of node profiles are known to the
+// provider, GCE also takes names of its own families.
+func checkImages(p *Profile, v *sgerrors.Violations) {
+	for _, group := range []struct {
+		field string
+		nodes []NodeProfile
+	}{
+		{"masterProfiles", p.MasterProfiles},
+		{"nodesProfiles", p.NodesProfiles},
+	} {
+		for i, np := range group.nodes {
+			name := np[NodeImageFamilyKey]
+			if name == "" {
+				continue
+			}
+			field := fmt.Sprintf("%s[%d].%s", group.field, i, NodeImageFamilyKey)
+			family, ok := clouds.FindImageFamily(name)
+			switch {
+			case !ok && p.Provider != clouds.GCE:
+				v.Add(field, fmt.Sprintf("unknown image family %q, families: %s",
+					name, strings.Join(clouds.ImageFamilies(), ", ")))
+			case ok && !family.Supports(p.Provider):
+				v.Add(field, fmt.Sprintf("image family %s isn't available on %s", name, p.Provider))
+			}
+		}
+	}
+}
+
+// checkSSH checks ssh settings of the profile and ports of node profiles.
+func checkSSH(p *Profile, v *sgerrors.Violations) {
+	if err := p.SSH.Validate(); err != nil {
+		v.Add("ssh", err.Error())
+	}
+	for _, group := range []struct {
+		field string
+		nodes []NodeProfile
+	}{
+		{"masterProfiles", p.MasterProfiles},
+		{"nodesProfiles", p.NodesProfiles},
+	} {
+		for i, np := range group.nodes {
+			if err := ValidateSSHPort(np[SSHPortKey]); err != nil {
+				v.Add(fmt.Sprintf("%s[%d].%s", group.field, i, SSHPortKey), err.Error())
+			}
+		}
+	}
+}
+
+// checkSizes checks that machine sizes are available in the region, the
+// check is skipped if the sizes can't be listed.
+func checkSizes(ctx context.Context, p *Profile, sizes SizeLister, v *sgerrors.Violations) {
+	available, err := sizes(ctx, p.Region)
+	if sgerrors.IsNotFound(err) {
+		v.Add("region", fmt.Sprintf("unknown region %q", p.Region))
+		return
+	}
+	if err != nil {
+		logrus.Warnf("profile: list machine sizes of %s: %v", p.Region, err)
+		return
+	}
+	if len(available) == 0 {
+		return
+	}
+
+	known := make(map[string]bool, len(available))
+	for _, size := range available {
+		known[size] = true
+	}
+	for _, group := range []struct {
+		field string
+		nodes []NodeProfile
+	}{
+		{"masterProfiles", p.MasterProfiles},
+		{"nodesProfiles", p.NodesProfiles},
+	} {
+		for i, np := range group.nodes {
+			key := "size"
+			if np[key] == "" {
+				key = "vmSize"
+			}
+			if size := np[key]; size != "" && !known[size] {
+				v.Add(fmt.Sprintf("%s[%d].%s", group.field, i, key), fmt.Sprintf("%s isn't available in %s", size, p.Region))
+			}
+		}
+	}
+}
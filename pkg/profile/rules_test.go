@@ -0,0 +1,133 @@
+package profile
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+func TestCheck(t *testing.T) {
+	valid := &Profile{
+		K8SVersion:      "1.14.1",
+		NetworkProvider: NetworkFlannel,
+		CIDR:            "10.0.0.0/16",
+		K8SServicesCIDR: "10.3.0.0/16",
+		MasterProfiles:  []NodeProfile{{"size": "m4.large"}},
+		NodesProfiles:   []NodeProfile{{"size": "m4.large"}, {"size": "m5.large"}},
+	}
+	require.NoError(t, valid.Check(context.Background(), nil))
+
+	p := &Profile{
+		K8SVersion:          "1.16.2",
+		NetworkProvider:     NetworkCalico,
+		CIDR:                "10.0.0.0/8",
+		K8SServicesCIDR:     "10.3.0.0/16",
+		ExposedAddresses:    []Addresses{{CIDR: "0.0.0.0/0"}, {CIDR: "invalid"}},
+		MasterProfiles:      []NodeProfile{{}, {}},
+		Ingress:             Ingress{Controller: "haproxy"},
+		RegistryCredentials: RegistryCredentials{ECR: true},
+		StaticIPs:           StaticIPs{Masters: true},
+		Provider:            clouds.Azure,
+	}
+	err := p.Check(context.Background(), nil)
+	require.Equal(t, sgerrors.Violations{
+		{Field: "ingress.controller", Message: `unknown controller "haproxy"`},
+		{Field: "staticIps.masters", Message: "static ips aren't supported on azure"},
+		{Field: "registryCredentials.ecr", Message: "ecr credentials are available on aws only"},
+		{Field: "k8sServicesCIDR", Message: "10.3.0.0/16 overlaps with the pod network 10.0.0.0/8"},
+		{Field: "exposedAddresses[1].cidr", Message: `invalid cidr "invalid"`},
+		{Field: "K8SVersion", Message: "1.16.2 isn't supported by Calico, supported versions: >= 1.10, < 1.16"},
+		{Field: "K8SVersion", Message: "1.16.2 isn't in the version catalog, supported versions: 1.11, 1.12, 1.13, 1.14, 1.15"},
+		{Field: "masterProfiles", Message: "2 masters can't keep a quorum of etcd, use an odd number"},
+	}, err)
+	require.Equal(t, "ingress.controller", sgerrors.DetailOf(err).Field)
+
+	err = (&Profile{K8SVersion: "1.25.0", NetworkProvider: "Cilium"}).Check(context.Background(), nil)
+	require.Len(t, err, 3)
+
+	// docker has to be compatible with the kubernetes version
+	valid.DockerVersion = "17.03.2"
+	require.Equal(t, sgerrors.Violations{
+		{Field: "dockerVersion", Message: "docker 17.03.2 isn't compatible with kubernetes 1.14, compatible versions: 18.06, 18.09"},
+	}, valid.Check(context.Background(), nil))
+}
+
+func TestCheckSizes(t *testing.T) {
+	p := &Profile{
+		Region:         "fra1",
+		MasterProfiles: []NodeProfile{{"size": "s-2vcpu-4gb"}},
+		NodesProfiles:  []NodeProfile{{"size": "s-2vcpu-4gb"}, {"vmSize": "Standard_A1"}},
+	}
+
+	sizes := func(ctx context.Context, region string) ([]string, error) {
+		if region != "fra1" {
+			return nil, errors.Wrap(sgerrors.ErrNotFound, region)
+		}
+		return []string{"s-2vcpu-4gb"}, nil
+	}
+	require.Equal(t, sgerrors.Violations{
+		{Field: "nodesProfiles[1].vmSize", Message: "Standard_A1 isn't available in fra1"},
+	}, p.Check(context.Background(), sizes))
+
+	p.Region = "nyc1"
+	p.NodesProfiles = p.NodesProfiles[:1]
+	require.Equal(t, sgerrors.Violations{
+		{Field: "region", Message: `unknown region "nyc1"`},
+	}, p.Check(context.Background(), sizes))
+
+	// sizes that can't be listed aren't checked
+	require.NoError(t, p.Check(context.Background(), func(context.Context, string) ([]string, error) {
+		return nil, errors.New("timeout")
+	}))
+}
+
+func TestCheckImages(t *testing.T) {
+	p := &Profile{
+		Provider:       clouds.AWS,
+		MasterProfiles: []NodeProfile{{NodeImageFamilyKey: "ubuntu-22.04-lts"}},
+		NodesProfiles:  []NodeProfile{{NodeImageKey: "ami-1234"}, {NodeImageFamilyKey: "debian-10"}},
+	}
+	err := p.Check(context.Background(), nil)
+	require.Equal(t, sgerrors.Violations{
+		{Field: "nodesProfiles[1].imageFamily", Message: `unknown image family "debian-10", families: ` +
+			"ubuntu-16.04-lts, ubuntu-18.04-lts, ubuntu-20.04-lts, ubuntu-22.04-lts"},
+	}, err)
+
+	// families of GCE are passed through
+	p.Provider = clouds.GCE
+	require.NoError(t, p.Check(context.Background(), nil))
+
+	p.Provider = clouds.Azure
+	require.Len(t, p.Check(context.Background(), nil), 2)
+}
+
+func TestCheckSSH(t *testing.T) {
+	p := &Profile{
+		SSH:           SSHSettings{User: "admin", Port: "2222", Escalation: "sudo-password"},
+		NodesProfiles: []NodeProfile{{SSHPortKey: "22"}, {SSHPortKey: "ssh"}},
+	}
+	require.Equal(t, sgerrors.Violations{
+		{Field: "ssh", Message: "sudo-password escalation requires sudoPassword"},
+		{Field: "nodesProfiles[1].sshPort", Message: `invalid port "ssh"`},
+	}, p.Check(context.Background(), nil))
+
+	p.SSH.SudoPassword = "secret"
+	p.NodesProfiles = p.NodesProfiles[:1]
+	require.NoError(t, p.Check(context.Background(), nil))
+}
+
+func TestCheckNodeBootstrap(t *testing.T) {
+	p := &Profile{NodeBootstrap: NodeBootstrap{Parallelism: -1}}
+	require.Equal(t, sgerrors.Violations{
+		{Field: "nodeBootstrap", Message: "parallelism must not be negative"},
+	}, p.Check(context.Background(), nil))
+
+	p.NodeBootstrap = NodeBootstrap{FailurePolicy: FailFast}
+	require.NoError(t, p.Check(context.Background(), nil))
+	require.Equal(t, DefaultNodeParallelism, p.NodeBootstrap.Limit())
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/supergiant/control/pkg/revision"
 	"github.com/supergiant/control/pkg/storage"
 )
 
@@ -14,12 +15,14 @@ const DefaultKubeProfilePreifx = "/supergiant/profile"
 type Service struct {
 	prefix             string
 	kubeProfileStorage storage.Interface
+	revisions          *revision.Service
 }
 
 func NewService(prefix string, s storage.Interface) *Service {
 	return &Service{
 		prefix:             prefix,
 		kubeProfileStorage: s,
+		revisions:          revision.NewService(revision.DefaultStoragePrefix, s),
 	}
 }
 
@@ -42,21 +45,34 @@ func (s *Service) Get(ctx context.Context, profileId string) (*Profile, error) {
 	return profile, nil
 }
 
+// Create saves the profile and records its revision.
 func (s *Service) Create(ctx context.Context, profile *Profile) error {
+	return s.save(ctx, profile, "")
+}
+
+func (s *Service) save(ctx context.Context, profile *Profile, comment string) error {
 	profileData, err := json.Marshal(profile)
 
 	if err != nil {
 		return err
 	}
 
-	return s.kubeProfileStorage.Put(ctx, s.prefix, profile.ID, profileData)
+	if err = s.kubeProfileStorage.Put(ctx, s.prefix, profile.ID, profileData); err != nil {
+		return err
+	}
+
+	document, err := revision.Indent(profile)
+	if err != nil {
+		return err
+	}
+	if _, err = s.revisions.Record(ctx, revision.KindProfile, profile.ID, document, comment); err != nil {
+		logrus.Warnf("record revision of profile %s: %v", profile.ID, err)
+	}
+	return nil
 }
 
 func (s *Service) GetAll(ctx context.Context) ([]Profile, error) {
-	var (
-		profiles []Profile
-		profile  Profile
-	)
+	var profiles []Profile
 
 	profilesData, err := s.kubeProfileStorage.GetAll(ctx, s.prefix)
 
@@ -65,6 +81,8 @@ func (s *Service) GetAll(ctx context.Context) ([]Profile, error) {
 	}
 
 	for _, profileData := range profilesData {
+		// profiles are decoded into new values, so they don't share maps
+		var profile Profile
 		err = json.Unmarshal(profileData, &profile)
 
 		if err != nil {
@@ -37,6 +37,7 @@ func TestKubeProfileServiceGet(t *testing.T) {
 		service := Service{
 			prefix,
 			m,
+			nil,
 		}
 
 		profile, err := service.Get(context.Background(), "fake_id")
@@ -83,6 +84,7 @@ func TestKubeProfileServiceCreate(t *testing.T) {
 		service := Service{
 			prefix,
 			m,
+			nil,
 		}
 
 		err := service.Create(context.Background(), testCase.profile)
@@ -117,6 +119,7 @@ func TestKubeProfileServiceGetAll(t *testing.T) {
 		service := Service{
 			prefix,
 			m,
+			nil,
 		}
 
 		profiles, err := service.GetAll(context.Background())
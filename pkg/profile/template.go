@@ -0,0 +1,196 @@
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// maxBases limits the depth of inheritance of profiles.
+const maxBases = 8
+
+// ClusterNameVar is set to the name of the provisioned cluster, variables
+// of the request may override it.
+const ClusterNameVar = "clusterName"
+
+// variableRe matches references to variables, e.g. ${region}.
+var variableRe = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_.-]*)\}`)
+
+// Getter returns a stored profile.
+type Getter func(ctx context.Context, id string) (*Profile, error)
+
+// Visible hides profiles of teams outside of the scope of the context.
+func Visible(get Getter) Getter {
+	return func(ctx context.Context, id string) (*Profile, error) {
+		p, err := get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if !api.ScopeFrom(ctx).AllowsLabeled(p.Team, p.Labels) {
+			return nil, sgerrors.ErrNotFound
+		}
+		return p, nil
+	}
+}
+
+// Resolve merges the base profiles of the profile under it and substitutes
+// its variables. Settings of an overlay replace the settings of its base,
+// maps are merged key by key, zero values and empty lists are inherited.
+// Variables of the request override variables of the profiles.
+func Resolve(ctx context.Context, get Getter, p *Profile, vars map[string]string) (*Profile, error) {
+	chain := []*Profile{p}
+	seen := map[string]bool{p.ID: true}
+	for cur := p; cur.Base != ""; {
+		if get == nil {
+			return nil, sgerrors.WithField(errors.New("base profiles aren't supported"), "base")
+		}
+		if seen[cur.Base] {
+			return nil, sgerrors.WithField(errors.Errorf("profile %s inherits from itself", cur.Base), "base")
+		}
+		if len(chain) > maxBases {
+			return nil, sgerrors.WithField(errors.Errorf("more than %d base profiles", maxBases), "base")
+		}
+
+		base, err := get(ctx, cur.Base)
+		if err != nil {
+			return nil, sgerrors.WithField(errors.Wrapf(err, "get base profile %s", cur.Base), "base")
+		}
+		seen[cur.Base] = true
+		chain = append(chain, base)
+		cur = base
+	}
+
+	res := &Profile{}
+	for i := len(chain) - 1; i >= 0; i-- {
+		// profiles are copied, so the result doesn't share maps with them
+		c, err := clone(chain[i])
+		if err != nil {
+			return nil, err
+		}
+		merge(reflect.ValueOf(res).Elem(), reflect.ValueOf(c).Elem())
+	}
+	res.ID, res.Team = p.ID, p.Team
+
+	for k, v := range vars {
+		if res.Variables == nil {
+			res.Variables = make(map[string]string, len(vars))
+		}
+		res.Variables[k] = v
+	}
+
+	// variables aren't substituted in themselves
+	variables := res.Variables
+	res.Variables = nil
+	missing := make(map[string]bool)
+	substitute(reflect.ValueOf(res).Elem(), variables, missing)
+	res.Variables = variables
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, sgerrors.WithField(errors.Errorf("undefined variables: %s", strings.Join(names, ", ")), "variables")
+	}
+
+	return res, nil
+}
+
+func clone(p *Profile) (*Profile, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, errors.Wrap(err, "copy profile")
+	}
+	c := &Profile{}
+	return c, errors.Wrap(json.Unmarshal(data, c), "copy profile")
+}
+
+// merge sets the non zero values of src to dst.
+func merge(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Struct:
+		for i := 0; i < src.NumField(); i++ {
+			if src.Type().Field(i).PkgPath == "" {
+				merge(dst.Field(i), src.Field(i))
+			}
+		}
+	case reflect.Map:
+		if src.Len() == 0 {
+			return
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMap(src.Type()))
+		}
+		for _, k := range src.MapKeys() {
+			dst.SetMapIndex(k, src.MapIndex(k))
+		}
+	case reflect.Slice:
+		if src.Len() > 0 {
+			dst.Set(src)
+		}
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.New(src.Type().Elem()))
+		}
+		merge(dst.Elem(), src.Elem())
+	default:
+		if !reflect.DeepEqual(src.Interface(), reflect.Zero(src.Type()).Interface()) {
+			dst.Set(src)
+		}
+	}
+}
+
+// substitute replaces references to variables in strings of v, names of
+// undefined variables are added to missing.
+func substitute(v reflect.Value, vars map[string]string, missing map[string]bool) {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(expand(v.String(), vars, missing))
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				substitute(v.Field(i), vars, missing)
+			}
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			// values of maps aren't addressable
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(k))
+			substitute(elem, vars, missing)
+			v.SetMapIndex(k, elem)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			substitute(v.Index(i), vars, missing)
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			substitute(v.Elem(), vars, missing)
+		}
+	}
+}
+
+func expand(s string, vars map[string]string, missing map[string]bool) string {
+	return variableRe.ReplaceAllStringFunc(s, func(ref string) string {
+		name := variableRe.FindStringSubmatch(ref)[1]
+		value, ok := vars[name]
+		if !ok {
+			missing[name] = true
+			return ref
+		}
+		return value
+	})
+}
@@ -0,0 +1,114 @@
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage/memory"
+)
+
+func testProfiles(t *testing.T) *Service {
+	svc := NewService(DefaultKubeProfilePreifx, memory.NewInMemoryRepository())
+	for _, p := range []*Profile{
+		{
+			ID:         "base",
+			Provider:   clouds.AWS,
+			Region:     "${region}",
+			K8SVersion: "1.14.1",
+			Sysctl:     map[string]string{"vm.max_map_count": "262144"},
+			NodesProfiles: []NodeProfile{
+				{"size": "m4.large", NodeLabelsKey: "env=${env}"},
+			},
+			Variables: map[string]string{"region": "us-east-1", "env": "dev"},
+		},
+		{
+			ID:        "prod",
+			Base:      "base",
+			Sysctl:    map[string]string{"fs.file-max": "100000"},
+			Variables: map[string]string{"env": "prod"},
+		},
+		{ID: "loop1", Base: "loop2"},
+		{ID: "loop2", Base: "loop1"},
+		{ID: "other", Team: "other", Region: "eu-west-1"},
+	} {
+		require.NoError(t, svc.Create(context.Background(), p))
+	}
+	return svc
+}
+
+func TestResolve(t *testing.T) {
+	svc := testProfiles(t)
+	overlay, err := svc.Get(context.Background(), "prod")
+	require.NoError(t, err)
+
+	p, err := Resolve(context.Background(), svc.Get, overlay, map[string]string{"region": "eu-central-1"})
+	require.NoError(t, err)
+	require.Equal(t, "prod", p.ID)
+	require.Equal(t, clouds.AWS, p.Provider)
+	require.Equal(t, "eu-central-1", p.Region)
+	require.Equal(t, "1.14.1", p.K8SVersion)
+	require.Equal(t, map[string]string{"vm.max_map_count": "262144", "fs.file-max": "100000"}, p.Sysctl)
+	require.Equal(t, "env=prod", p.NodesProfiles[0][NodeLabelsKey])
+	require.Equal(t, "eu-central-1", p.Variables["region"])
+
+	// the stored base isn't changed
+	base, err := svc.Get(context.Background(), "base")
+	require.NoError(t, err)
+	require.Equal(t, "${region}", base.Region)
+}
+
+func TestResolveErrors(t *testing.T) {
+	svc := testProfiles(t)
+
+	_, err := Resolve(context.Background(), svc.Get, &Profile{Region: "${missing}", Zone: "${zone}"}, nil)
+	require.EqualError(t, err, "undefined variables: missing, zone")
+	require.Equal(t, "variables", sgerrors.DetailOf(err).Field)
+
+	_, err = Resolve(context.Background(), svc.Get, &Profile{Base: "loop1"}, nil)
+	require.Error(t, err)
+	require.Equal(t, "base", sgerrors.DetailOf(err).Field)
+
+	_, err = Resolve(context.Background(), svc.Get, &Profile{Base: "unknown"}, nil)
+	require.True(t, sgerrors.IsNotFound(err))
+
+	_, err = Resolve(context.Background(), nil, &Profile{Base: "base"}, nil)
+	require.Error(t, err)
+
+	ctx := api.WithScope(context.Background(), api.Scope{Teams: []string{"dev"}})
+	_, err = Resolve(ctx, Visible(svc.Get), &Profile{Base: "other"}, nil)
+	require.True(t, sgerrors.IsNotFound(err))
+}
+
+func TestGetResolved(t *testing.T) {
+	h := NewHandler(testProfiles(t))
+	router := mux.NewRouter()
+	h.Register(router)
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/kubeprofiles/prod/resolved?var=region=ap-south-1", nil)
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	p := &Profile{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(p))
+	require.Equal(t, "ap-south-1", p.Region)
+
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/kubeprofiles/loop1/resolved", nil)
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/kubeprofiles/prod/resolved?var=region", nil)
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
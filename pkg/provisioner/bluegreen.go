@@ -0,0 +1,259 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/timeline"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// replaceMasters upgrades the control plane blue/green: masters of the next
+// version join the cluster and its etcd next to old masters, the cluster is
+// verified through a new master, then old masters leave load balancers and
+// etcd and are deleted. New masters are deleted when one of them fails to
+// join or the verification fails, old masters keep serving the cluster.
+func (tp *TaskProvisioner) replaceMasters(ctx context.Context, k *model.Kube, nextVersion string,
+	tasks []*workflows.Task, config *steps.Config) error {
+	oldMasters := sortedMachines(k.Masters)
+	if len(oldMasters) == 0 || len(tasks) == 0 {
+		return errors.Wrap(sgerrors.ErrNotFound, "master")
+	}
+
+	token, err := tp.refreshJoinToken(ctx, config, oldMasters[0])
+	if err != nil {
+		return errors.Wrap(err, "refresh join token")
+	}
+	config.Kube.BootstrapToken = token
+
+	taken := machineNames(k)
+	newMasters := make([]*model.Machine, 0, len(tasks))
+	for i, task := range tasks {
+		var masterProfile profile.NodeProfile
+		if i < len(config.UpgradeConfig.MasterProfiles) {
+			masterProfile = config.UpgradeConfig.MasterProfiles[i]
+		}
+
+		master, err := tp.addMaster(ctx, task, config, masterProfile, len(k.Masters)+i, taken)
+		if master != nil {
+			newMasters = append(newMasters, master)
+		}
+		if err != nil {
+			err = errors.Wrapf(err, "add master of task %s", task.ID)
+			tp.rollbackMasters(ctx, k, newMasters, config, err)
+			return err
+		}
+	}
+
+	verifyConfig := *config
+	// new masters run kube-system workloads of the cluster
+	verifyConfig.UpgradeCanary.AddonHealth = true
+	taskID, err := tp.verifyUpgrade(ctx, &verifyConfig, newMasters[0])
+	if err != nil {
+		err = errors.Wrapf(err, "verification task %s", taskID)
+		tp.rollbackMasters(ctx, k, newMasters, config, err)
+		return err
+	}
+
+	if err := tp.retireMasters(ctx, k.ID, oldMasters, config); err != nil {
+		tp.recordEvent(ctx, &timeline.Event{
+			KubeID:  k.ID,
+			Type:    timeline.UpgradeFailed,
+			Message: fmt.Sprintf("old masters haven't been deleted: %v", err),
+		})
+		return errors.Wrap(err, "delete old masters")
+	}
+
+	if err := tp.applyClusterVersion(ctx, newMasters[0], config); err != nil {
+		tp.recordEvent(ctx, &timeline.Event{
+			KubeID:  k.ID,
+			Type:    timeline.UpgradeFailed,
+			Message: fmt.Sprintf("cluster configuration hasn't been upgraded to %s: %v", nextVersion, err),
+		})
+		return errors.Wrap(err, "upgrade cluster configuration")
+	}
+
+	tp.recordEvent(ctx, &timeline.Event{
+		KubeID:  k.ID,
+		Type:    timeline.ControlPlaneReplaced,
+		Message: fmt.Sprintf("%d masters of %s have replaced old masters, verified by task %s", len(newMasters), nextVersion, taskID),
+	})
+	return nil
+}
+
+// refreshJoinToken creates a bootstrap token on the master and uploads
+// certificates of the control plane, both expire after the cluster has
+// been provisioned.
+func (tp *TaskProvisioner) refreshJoinToken(ctx context.Context, config *steps.Config, master *model.Machine) (string, error) {
+	cfg := *config
+	cfg.Node = *master
+	cfg.IsMaster = true
+	cfg.IsBootstrap = true
+
+	task, err := workflows.NewTask(&cfg, workflows.JoinToken, tp.repository)
+	if err != nil {
+		return "", errors.Wrap(err, "new task")
+	}
+
+	writer, err := tp.getWriter(util.MakeFileName(task.ID))
+	if err != nil {
+		return "", errors.Wrap(err, "get writer")
+	}
+
+	if err := <-task.Run(ctx, cfg, writer); err != nil {
+		return "", errors.Wrapf(err, "task %s", task.ID)
+	}
+	return task.Config.Kube.BootstrapToken, nil
+}
+
+// addMaster provisions a master that joins the cluster, the master is
+// returned once its machine has been created even if the task fails.
+func (tp *TaskProvisioner) addMaster(ctx context.Context, task *workflows.Task, config *steps.Config,
+	masterProfile profile.NodeProfile, index int, taken map[string]bool) (*model.Machine, error) {
+	writer, err := tp.getWriter(util.MakeFileName(task.ID))
+	if err != nil {
+		return nil, errors.Wrap(err, "get writer")
+	}
+
+	if err := FillNodeCloudSpecificData(config.Provider, masterProfile, task.Config); err != nil {
+		return nil, errors.Wrap(err, "fill master profile data to config")
+	}
+
+	task.Config.Kube.BootstrapToken = config.Kube.BootstrapToken
+	task.Config.TaskID = task.ID
+	task.Config.IsMaster = true
+	task.Config.IsBootstrap = false
+	task.Config.NodeIndex = index
+	if err := nextNodeIndex(task.Config, taken); err != nil {
+		return nil, errors.Wrap(err, "name master")
+	}
+
+	tp.rateLimiter.Take()
+	log.Infof("Add master of task %s to cluster %s", task.ID, config.Kube.ID)
+	err = <-task.Run(ctx, *task.Config, writer)
+	if task.Config.Node.Name == "" {
+		return nil, err
+	}
+
+	master := task.Config.Node
+	return &master, err
+}
+
+// rollbackMasters deletes new masters of the failed upgrade.
+func (tp *TaskProvisioner) rollbackMasters(ctx context.Context, k *model.Kube, masters []*model.Machine,
+	config *steps.Config, cause error) {
+	log.Errorf("blue/green upgrade of %s has failed: %v", k.ID, cause)
+
+	msg := fmt.Sprintf("new masters have been deleted, old masters are kept: %v", cause)
+	if err := tp.retireMasters(ctx, k.ID, masters, config); err != nil {
+		log.Errorf("delete new masters of %s: %v", k.ID, err)
+		msg = fmt.Sprintf("old masters are kept, new masters have to be deleted manually: %v", cause)
+	}
+
+	tp.recordEvent(ctx, &timeline.Event{
+		KubeID:  k.ID,
+		Type:    timeline.ControlPlaneRolledBack,
+		Message: msg,
+	})
+}
+
+// retireMasters deletes masters one by one, so the etcd quorum is checked
+// before each of them leaves.
+func (tp *TaskProvisioner) retireMasters(ctx context.Context, kubeID string, masters []*model.Machine, config *steps.Config) error {
+	for _, m := range masters {
+		if err := tp.retireMaster(ctx, kubeID, m, config); err != nil {
+			return errors.Wrapf(err, "delete master %s", m.Name)
+		}
+	}
+	return nil
+}
+
+func (tp *TaskProvisioner) retireMaster(ctx context.Context, kubeID string, m *model.Machine, config *steps.Config) error {
+	k, err := tp.kubeService.Get(ctx, kubeID)
+	if err != nil {
+		return errors.Wrapf(err, "get cluster %s", kubeID)
+	}
+
+	cfg := *config
+	cfg.Node = *m
+	cfg.IsMaster = true
+	cfg.IsBootstrap = false
+	cfg.DrainConfig = steps.DrainConfig{PrivateIP: m.PrivateIp}
+	// the etcd member is removed through one of the other masters
+	cfg.Masters = steps.NewMap(k.Masters)
+
+	task, err := workflows.NewTask(&cfg, workflows.DeleteMaster, tp.repository)
+	if err != nil {
+		return errors.Wrap(err, "new task")
+	}
+
+	writer, err := tp.getWriter(util.MakeFileName(task.ID))
+	if err != nil {
+		return errors.Wrap(err, "get writer")
+	}
+
+	log.Infof("Delete master %s of cluster %s", m.Name, kubeID)
+	if err := <-task.Run(ctx, cfg, writer); err != nil {
+		return errors.Wrapf(err, "task %s", task.ID)
+	}
+
+	if k, err = tp.kubeService.Get(ctx, kubeID); err != nil {
+		return errors.Wrapf(err, "get cluster %s", kubeID)
+	}
+	delete(k.Masters, m.Name)
+	if m.StaticIP != nil {
+		k.FreeStaticIPs = append(k.FreeStaticIPs, *m.StaticIP)
+	}
+	if err := tp.kubeService.Create(ctx, k); err != nil {
+		return errors.Wrapf(err, "update cluster %s", kubeID)
+	}
+
+	tp.recordEvent(ctx, &timeline.Event{
+		KubeID:  kubeID,
+		Type:    timeline.NodeRemoved,
+		Object:  m.Name,
+		Message: fmt.Sprintf("%s %s has been removed from the cluster", m.Role, m.Name),
+	})
+	return nil
+}
+
+// applyClusterVersion upgrades the cluster configuration and addons of
+// kubeadm through the new master, its control plane is of the version already.
+func (tp *TaskProvisioner) applyClusterVersion(ctx context.Context, master *model.Machine, config *steps.Config) error {
+	cfg := *config
+	cfg.Node = *master
+	cfg.IsMaster = true
+	cfg.IsBootstrap = true
+
+	task, err := workflows.NewTask(&cfg, workflows.Upgrade, tp.repository)
+	if err != nil {
+		return errors.Wrap(err, "new task")
+	}
+
+	writer, err := tp.getWriter(util.MakeFileName(task.ID))
+	if err != nil {
+		return errors.Wrap(err, "get writer")
+	}
+
+	return tp.upgradeMachine(task, writer)
+}
+
+func sortedMachines(machines map[string]*model.Machine) []*model.Machine {
+	result := make([]*model.Machine, 0, len(machines))
+	for _, m := range machines {
+		result = append(result, m)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
@@ -0,0 +1,152 @@
+package provisioner
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/storage/memory"
+	"github.com/supergiant/control/pkg/timeline"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type funcStep struct {
+	mockStep
+	run func(cfg *steps.Config) error
+}
+
+func (s *funcStep) Run(_ context.Context, _ io.Writer, cfg *steps.Config) error {
+	return s.run(cfg)
+}
+
+func TestTaskProvisioner_replaceMasters(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		verifyErr   error
+		deleted     []string
+		masters     []string
+		event       timeline.Type
+	}{
+		{
+			description: "success",
+			deleted:     []string{"master-a", "master-b"},
+			masters:     []string{"kube-master-2", "kube-master-3"},
+			event:       timeline.ControlPlaneReplaced,
+		},
+		{
+			description: "rollback",
+			verifyErr:   errors.New("addons are unhealthy"),
+			deleted:     []string{"kube-master-2", "kube-master-3"},
+			masters:     []string{"master-a", "master-b"},
+			event:       timeline.ControlPlaneRolledBack,
+		},
+	} {
+		repository := memory.NewInMemoryRepository()
+		k := &model.Kube{
+			ID:       "1234",
+			Name:     "kube",
+			Provider: clouds.Fake,
+			Masters: map[string]*model.Machine{
+				"master-b": {Name: "master-b", Role: model.RoleMaster, State: model.MachineStateActive},
+				"master-a": {Name: "master-a", Role: model.RoleMaster, State: model.MachineStateActive},
+			},
+			Naming: profile.Naming{Instance: "{{.ClusterName}}-{{.Role}}-{{.Index}}"},
+		}
+		saved := *k
+		saved.Masters = map[string]*model.Machine{
+			"master-a": k.Masters["master-a"],
+			"master-b": k.Masters["master-b"],
+			// new masters are saved by the cluster monitor
+			"kube-master-2": {Name: "kube-master-2"},
+			"kube-master-3": {Name: "kube-master-3"},
+		}
+		svc := &mockKubeService{data: map[string]model.Kube{k.ID: saved}}
+		events := timeline.NewService(timeline.DefaultStoragePrefix, repository)
+		tp := &TaskProvisioner{
+			kubeService: svc,
+			repository:  repository,
+			timeline:    events,
+			rateLimiter: NewRateLimiter(time.Millisecond),
+			getWriter: func(string) (io.WriteCloser, error) {
+				return &bufferCloser{ioutil.Discard, nil}, nil
+			},
+		}
+
+		var deleted []string
+		workflows.Init()
+		workflows.RegisterWorkFlow(workflows.JoinToken, []steps.Step{&funcStep{run: func(cfg *steps.Config) error {
+			if cfg.Node.Name != "master-a" || !cfg.IsBootstrap {
+				return errors.Errorf("token is created on %s", cfg.Node.Name)
+			}
+			cfg.Kube.BootstrapToken = "fresh"
+			return nil
+		}}})
+		workflows.RegisterWorkFlow(workflows.ProvisionMaster, []steps.Step{&funcStep{run: func(cfg *steps.Config) error {
+			if cfg.Kube.BootstrapToken != "fresh" {
+				return errors.New("master joins with an old token")
+			}
+			name, err := cfg.InstanceName()
+			if err != nil {
+				return err
+			}
+			cfg.Node = model.Machine{ID: name, Name: name, Role: model.RoleMaster, State: model.MachineStateActive}
+			return nil
+		}}})
+		workflows.RegisterWorkFlow(workflows.UpgradeVerify, []steps.Step{&funcStep{run: func(cfg *steps.Config) error {
+			if !cfg.UpgradeCanary.AddonHealth {
+				return errors.New("addons aren't checked")
+			}
+			return tc.verifyErr
+		}}})
+		workflows.RegisterWorkFlow(workflows.DeleteMaster, []steps.Step{&funcStep{run: func(cfg *steps.Config) error {
+			deleted = append(deleted, cfg.Node.Name)
+			return nil
+		}}})
+		workflows.RegisterWorkFlow(workflows.Upgrade, []steps.Step{&mockStep{}})
+
+		config, err := steps.NewConfigFromKube(&profile.Profile{Provider: clouds.Fake}, k)
+		require.NoError(t, err, tc.description)
+		config.UpgradeConfig.Strategy = steps.UpgradeBlueGreen
+		go func() {
+			for range config.NodeChan() {
+			}
+		}()
+
+		tasks := make([]*workflows.Task, 0, len(k.Masters))
+		for range k.Masters {
+			cfg := *config
+			task, err := workflows.NewTask(&cfg, workflows.ProvisionMaster, repository)
+			require.NoError(t, err, tc.description)
+			tasks = append(tasks, task)
+		}
+
+		err = tp.replaceMasters(context.Background(), k, "1.15.1", tasks, config)
+		if tc.verifyErr != nil {
+			require.Error(t, err, tc.description)
+		} else {
+			require.NoError(t, err, tc.description)
+		}
+		require.Equal(t, tc.deleted, deleted, tc.description)
+
+		updated, err := svc.Get(context.Background(), k.ID)
+		require.NoError(t, err, tc.description)
+		names := make([]string, 0, len(updated.Masters))
+		for _, m := range sortedMachines(updated.Masters) {
+			names = append(names, m.Name)
+		}
+		require.Equal(t, tc.masters, names, tc.description)
+
+		recorded, err := events.List(context.Background(), k.ID, time.Time{}, time.Time{})
+		require.NoError(t, err, tc.description)
+		require.Equal(t, tc.event, recorded[len(recorded)-1].Type, tc.description)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 	"github.com/pborman/uuid"
@@ -13,10 +14,15 @@ import (
 	"gopkg.in/asaskevich/govalidator.v8"
 
 	"github.com/supergiant/control/pkg/account"
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/labels"
 	"github.com/supergiant/control/pkg/message"
 	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/pricing"
 	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/ratelimit"
 	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/tracing"
 	"github.com/supergiant/control/pkg/util"
 	"github.com/supergiant/control/pkg/workflows"
 	"github.com/supergiant/control/pkg/workflows/steps"
@@ -43,12 +49,22 @@ type Handler struct {
 	profileService ProfileCreater
 	kubeGetter     KubeGetter
 	provisioner    ClusterProvisioner
+	quotas         ratelimit.Reserver
+	getProfile     profile.Getter
+	// sizes lists machine sizes of the account in the region
+	sizes func(context.Context, *model.CloudAccount, string) ([]string, error)
+
+	estimate func(context.Context, *profile.Profile) (*pricing.Estimate, error)
 }
 
 type ProvisionRequest struct {
 	ClusterName      string          `json:"clusterName" valid:"matches(^[A-Za-z0-9-]+$)"`
 	Profile          profile.Profile `json:"profile" valid:"-"`
 	CloudAccountName string          `json:"cloudAccountName" valid:"-"`
+	// Variables override variables of the profile and its bases.
+	Variables map[string]string `json:"variables,omitempty" valid:"-"`
+	// Labels of the kube, they override labels of the profile.
+	Labels map[string]string `json:"labels,omitempty" valid:"-"`
 }
 
 type ProvisionResponse struct {
@@ -56,6 +72,15 @@ type ProvisionResponse struct {
 	Tasks     map[string][]string `json:"tasks"`
 }
 
+// ProvisionPlan is returned instead of provisioning the cluster on a dry run.
+type ProvisionPlan struct {
+	ClusterName string            `json:"clusterName"`
+	Masters     int               `json:"masters"`
+	Nodes       int               `json:"nodes"`
+	Cost        *pricing.Estimate `json:"cost,omitempty"`
+	CostError   string            `json:"costError,omitempty"`
+}
+
 type ClusterProvisioner interface {
 	ProvisionCluster(context.Context, *profile.Profile, *steps.Config) (map[string][]*workflows.Task, error)
 }
@@ -69,9 +94,22 @@ func NewHandler(kubeService KubeGetter,
 		profileService: profileSvc,
 		accountGetter:  cloudAccountService,
 		provisioner:    provisioner,
+		sizes:          account.SizesIn,
+		estimate:       pricing.Default.EstimateProfile,
 	}
 }
 
+// SetQuotas applies quotas of users to provisioning, clusters are provisioned
+// without limits if it's not set.
+func (h *Handler) SetQuotas(quotas ratelimit.Reserver) {
+	h.quotas = quotas
+}
+
+// SetProfiles lets provisioned profiles inherit from stored base profiles.
+func (h *Handler) SetProfiles(get profile.Getter) {
+	h.getProfile = get
+}
+
 func (h *Handler) Register(m *mux.Router) {
 	m.HandleFunc("/provision", h.Provision).Methods(http.MethodPost)
 }
@@ -93,20 +131,35 @@ func (h *Handler) Provision(w http.ResponseWriter, r *http.Request) {
 		message.SendValidationFailed(w, err)
 		return
 	}
-
-	if req.Profile.K8SServicesCIDR == "" {
-		req.Profile.K8SServicesCIDR = DefaultK8SServicesCIDR
+	if err = labels.Validate(req.Labels); err != nil {
+		message.SendValidationFailed(w, sgerrors.WithField(err, "labels"))
+		return
 	}
 
-	config, err := steps.NewConfig(req.ClusterName, req.CloudAccountName, req.Profile)
-
+	vars := map[string]string{profile.ClusterNameVar: req.ClusterName}
+	for k, v := range req.Variables {
+		vars[k] = v
+	}
+	var get profile.Getter
+	if h.getProfile != nil {
+		get = profile.Visible(h.getProfile)
+	}
+	resolved, err := profile.Resolve(r.Context(), get, &req.Profile, vars)
 	if err != nil {
-		logrus.Errorf("build provisioning config: %s", err)
-		message.SendUnknownError(w, err)
+		message.SendValidationFailed(w, err)
 		return
 	}
+	req.Profile = *resolved
+
+	if req.Profile.K8SServicesCIDR == "" {
+		req.Profile.K8SServicesCIDR = DefaultK8SServicesCIDR
+	}
 
+	scope := api.ScopeFrom(r.Context())
 	acc, err := h.accountGetter.Get(r.Context(), req.CloudAccountName)
+	if err == nil && !scope.AllowsLabeled(acc.Team, acc.Labels) {
+		err = sgerrors.ErrNotFound
+	}
 
 	if err != nil {
 		if sgerrors.IsNotFound(err) {
@@ -118,6 +171,31 @@ func (h *Handler) Provision(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var sizes profile.SizeLister
+	if h.sizes != nil {
+		sizes = func(ctx context.Context, region string) ([]string, error) {
+			return h.sizes(ctx, acc, region)
+		}
+	}
+	if err := req.Profile.Check(r.Context(), sizes); err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	// the kube belongs to the team of the profile
+	if req.Profile.Team, err = scope.TeamFor(req.Profile.Team); err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	config, err := steps.NewConfig(req.ClusterName, req.CloudAccountName, req.Profile)
+
+	if err != nil {
+		logrus.Errorf("build provisioning config: %s", err)
+		message.SendUnknownError(w, err)
+		return
+	}
+
 	// Fill config with appropriate cloud account credentials
 	err = util.FillCloudAccountCredentials(acc, config)
 
@@ -127,6 +205,11 @@ func (h *Handler) Provision(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryRun")); dryRun {
+		h.sendPlan(w, r, req)
+		return
+	}
+
 	// Assign ID to profile
 	id := uuid.New()
 
@@ -138,7 +221,21 @@ func (h *Handler) Provision(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, _ := context.WithTimeout(context.Background(), config.Timeout)
+	owner, release, err := ratelimit.Reserve(r.Context(), h.quotas)
+	if err != nil {
+		if sgerrors.IsQuotaExceeded(err) {
+			message.SendQuotaExceeded(w, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+	defer release()
+	config.Kube.Owner = owner
+	config.Kube.Team = req.Profile.Team
+	config.Kube.Labels = labels.Merge(req.Profile.Labels, req.Labels)
+
+	ctx, _ := context.WithTimeout(tracing.Detach(r.Context()), config.Timeout)
 	taskMap, err := h.provisioner.ProvisionCluster(ctx, &req.Profile, config)
 
 	if err != nil {
@@ -147,6 +244,10 @@ func (h *Handler) Provision(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Don't keep credentials of the external CA
+	req.Profile.ExternalCA.Key = ""
+	req.Profile.ExternalCA.Vault.Token = ""
+
 	if err := h.profileService.Create(r.Context(), &req.Profile); err != nil {
 		logrus.Debugf("Error creating profile %s", req.Profile.ID)
 	}
@@ -176,3 +277,23 @@ func (h *Handler) Provision(w http.ResponseWriter, r *http.Request) {
 		logrus.Error(errors.Wrap(err, "marshal json"))
 	}
 }
+
+// sendPlan describes the cluster that would be provisioned with its estimated cost.
+func (h *Handler) sendPlan(w http.ResponseWriter, r *http.Request, req *ProvisionRequest) {
+	plan := ProvisionPlan{
+		ClusterName: req.ClusterName,
+		Masters:     len(req.Profile.MasterProfiles),
+		Nodes:       len(req.Profile.NodesProfiles),
+	}
+
+	est, err := h.estimate(r.Context(), &req.Profile)
+	if err != nil {
+		plan.CostError = err.Error()
+	}
+	plan.Cost = est
+
+	if err = json.NewEncoder(w).Encode(&plan); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logrus.Error(errors.Wrap(err, "marshal json"))
+	}
+}
@@ -14,8 +14,10 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/supergiant/control/pkg/account"
+	"github.com/supergiant/control/pkg/api"
 	"github.com/supergiant/control/pkg/clouds"
 	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/pricing"
 	"github.com/supergiant/control/pkg/profile"
 	"github.com/supergiant/control/pkg/sgerrors"
 	"github.com/supergiant/control/pkg/workflows"
@@ -87,6 +89,8 @@ func TestProvisionHandler(t *testing.T) {
 		"test",
 		profile.Profile{},
 		"1234",
+		nil,
+		nil,
 	}
 
 	validBody, _ := json.Marshal(p)
@@ -222,6 +226,173 @@ func TestProvisionHandler(t *testing.T) {
 	}
 }
 
+func TestProvisionHandler_dryRun(t *testing.T) {
+	body, _ := json.Marshal(&ProvisionRequest{
+		ClusterName: "test",
+		Profile: profile.Profile{
+			Provider:       clouds.DigitalOcean,
+			MasterProfiles: []profile.NodeProfile{{"size": "s-2vcpu-4gb"}},
+			NodesProfiles:  []profile.NodeProfile{{"size": "s-2vcpu-4gb"}, {"size": "s-2vcpu-4gb"}},
+		},
+		CloudAccountName: "1234",
+	})
+
+	provisioner := &mockProvisioner{}
+	accGetter := &mockAccountGetter{
+		get: func(context.Context, string) (*model.CloudAccount, error) {
+			return &model.CloudAccount{Provider: clouds.DigitalOcean}, nil
+		},
+	}
+	handler := Handler{
+		provisioner:   provisioner,
+		accountGetter: accGetter,
+		estimate:      pricing.Default.EstimateProfile,
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, "/provision?dryRun=true", bytes.NewBuffer(body))
+	rec := httptest.NewRecorder()
+	handler.Provision(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Wrong status code expected %d actual %d", http.StatusOK, rec.Code)
+	}
+
+	plan := ProvisionPlan{}
+	if err := json.NewDecoder(rec.Body).Decode(&plan); err != nil {
+		t.Fatalf("Unepxpected error while decoding response %v", err)
+	}
+	if plan.Masters != 1 || plan.Nodes != 2 {
+		t.Errorf("Wrong machine count in plan %+v", plan)
+	}
+	if plan.Cost == nil || plan.Cost.MonthlyCost == 0 {
+		t.Errorf("Plan must have a cost estimate %+v", plan)
+	}
+}
+
+type mockQuotas struct {
+	owner    string
+	err      error
+	released bool
+}
+
+func (m *mockQuotas) ReserveProvision(ctx context.Context, owner string) (func(), error) {
+	m.owner = owner
+	return func() { m.released = true }, m.err
+}
+
+func TestProvisionHandler_quotas(t *testing.T) {
+	body, _ := json.Marshal(&ProvisionRequest{
+		ClusterName:      "test",
+		CloudAccountName: "1234",
+	})
+
+	for _, quotaErr := range []error{nil, errors.Wrap(sgerrors.ErrQuotaExceeded, "max clusters")} {
+		var owner string
+		quotas := &mockQuotas{err: quotaErr}
+		handler := Handler{
+			provisioner: &mockProvisioner{
+				provisionCluster: func(ctx context.Context, p *profile.Profile, config *steps.Config) (map[string][]*workflows.Task, error) {
+					owner = config.Kube.Owner
+					return nil, nil
+				},
+			},
+			accountGetter: &mockAccountGetter{
+				get: func(context.Context, string) (*model.CloudAccount, error) {
+					return &model.CloudAccount{Provider: clouds.DigitalOcean}, nil
+				},
+			},
+			profileService: &mockProfileCreator{},
+		}
+		handler.SetQuotas(quotas)
+		handler.profileService.(*mockProfileCreator).On("Create", mock.Anything, mock.Anything).Return(nil)
+
+		req, _ := http.NewRequest(http.MethodPost, "/provision", bytes.NewBuffer(body))
+		req = req.WithContext(api.WithIdentity(req.Context(), api.Identity{Login: "user"}))
+		rec := httptest.NewRecorder()
+		handler.Provision(rec, req)
+
+		if quotas.owner != "user" {
+			t.Errorf("Wrong owner of quotas %s", quotas.owner)
+		}
+		if quotaErr != nil {
+			if rec.Code != http.StatusForbidden {
+				t.Errorf("Wrong status code expected %d actual %d", http.StatusForbidden, rec.Code)
+			}
+			continue
+		}
+		if rec.Code != http.StatusAccepted || owner != "user" || !quotas.released {
+			t.Errorf("Wrong provisioning code %d owner %s released %v", rec.Code, owner, quotas.released)
+		}
+	}
+}
+
+func TestProvisionHandler_baseProfile(t *testing.T) {
+	body, _ := json.Marshal(&ProvisionRequest{
+		ClusterName:      "test",
+		CloudAccountName: "1234",
+		Profile: profile.Profile{
+			Base:      "base",
+			Variables: map[string]string{"region": "fra1"},
+		},
+		Variables: map[string]string{"size": "s-2vcpu-4gb"},
+	})
+
+	var provisioned *profile.Profile
+	handler := Handler{
+		provisioner: &mockProvisioner{
+			provisionCluster: func(ctx context.Context, p *profile.Profile, config *steps.Config) (map[string][]*workflows.Task, error) {
+				provisioned = p
+				return nil, nil
+			},
+		},
+		accountGetter: &mockAccountGetter{
+			get: func(context.Context, string) (*model.CloudAccount, error) {
+				return &model.CloudAccount{Provider: clouds.DigitalOcean}, nil
+			},
+		},
+		profileService: &mockProfileCreator{},
+	}
+	handler.SetProfiles(func(ctx context.Context, id string) (*profile.Profile, error) {
+		if id != "base" {
+			return nil, sgerrors.ErrNotFound
+		}
+		return &profile.Profile{
+			ID:            "base",
+			Provider:      clouds.DigitalOcean,
+			Region:        "${region}",
+			NodesProfiles: []profile.NodeProfile{{"size": "${size}", "name": "${clusterName}-node"}},
+		}, nil
+	})
+	handler.profileService.(*mockProfileCreator).On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	req, _ := http.NewRequest(http.MethodPost, "/provision", bytes.NewBuffer(body))
+	rec := httptest.NewRecorder()
+	handler.Provision(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Wrong status code expected %d actual %d: %s", http.StatusAccepted, rec.Code, rec.Body.String())
+	}
+	if provisioned.Region != "fra1" {
+		t.Errorf("Wrong region %s", provisioned.Region)
+	}
+	if node := provisioned.NodesProfiles[0]; node["size"] != "s-2vcpu-4gb" || node["name"] != "test-node" {
+		t.Errorf("Wrong node profile %v", node)
+	}
+
+	body, _ = json.Marshal(&ProvisionRequest{
+		ClusterName:      "test",
+		CloudAccountName: "1234",
+		Profile:          profile.Profile{Base: "unknown"},
+	})
+	req, _ = http.NewRequest(http.MethodPost, "/provision", bytes.NewBuffer(body))
+	rec = httptest.NewRecorder()
+	handler.Provision(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Wrong status code expected %d actual %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
 func TestNewHandler(t *testing.T) {
 	accSvc := &account.Service{}
 	kubeSvc := &mockKubeService{}
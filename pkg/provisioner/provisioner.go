@@ -3,23 +3,30 @@ package provisioner
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
 	"k8s.io/kubernetes/cmd/kubeadm/app/phases/copycerts"
 
 	"github.com/supergiant/control/pkg/clouds"
 	"github.com/supergiant/control/pkg/model"
 	"github.com/supergiant/control/pkg/pki"
 	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/queue"
 	"github.com/supergiant/control/pkg/runner/dry"
+	"github.com/supergiant/control/pkg/runner/winrm"
 	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/storage"
 	"github.com/supergiant/control/pkg/storage/memory"
+	"github.com/supergiant/control/pkg/timeline"
+	"github.com/supergiant/control/pkg/tracing"
 	"github.com/supergiant/control/pkg/util"
 	"github.com/supergiant/control/pkg/workflows"
 	"github.com/supergiant/control/pkg/workflows/statuses"
@@ -27,6 +34,11 @@ import (
 	"github.com/supergiant/control/pkg/workflows/steps/configmap"
 )
 
+var log = sglog.Logger(sglog.Provisioner)
+
+// ProvisionJob is the kind of queued jobs that provision clusters.
+const ProvisionJob = "provisionCluster"
+
 type KubeService interface {
 	Create(ctx context.Context, k *model.Kube) error
 	Get(ctx context.Context, name string) (*model.Kube, error)
@@ -45,6 +57,36 @@ type TaskProvisioner struct {
 	// Cancel map - map of KubeID -> cancel function
 	// that cancels
 	cancelMap map[string]func()
+
+	timeline *timeline.Service
+
+	// queue runs provisioning of clusters when it's set
+	queue *provisionQueue
+}
+
+// provisionQueue runs provisioning by workers of the queue, profiles and
+// accounts restore provisioning that outlived the process.
+type provisionQueue struct {
+	*queue.Queue
+	profiles profile.Getter
+	accounts AccountGetter
+
+	// prepared are provisionings queued by this process
+	m        sync.Mutex
+	prepared map[string]*provisioning
+}
+
+// provisioning is a cluster prepared for provisioning, its tasks are run
+// by a worker of the queue.
+type provisioning struct {
+	ctx     context.Context
+	taskMap map[string][]*workflows.Task
+	profile *profile.Profile
+	span    *trace.Span
+}
+
+type provisionJob struct {
+	KubeID string `json:"kubeId"`
 }
 
 func NewProvisioner(repository storage.Interface, kubeService KubeService,
@@ -52,12 +94,26 @@ func NewProvisioner(repository storage.Interface, kubeService KubeService,
 	return &TaskProvisioner{
 		kubeService: kubeService,
 		repository:  repository,
+		timeline:    timeline.NewService(timeline.DefaultStoragePrefix, repository),
 		getWriter:   util.GetWriterFunc(logDir),
 		rateLimiter: NewRateLimiter(spawnInterval),
 		cancelMap:   make(map[string]func()),
 	}
 }
 
+// UseQueue runs provisioning of clusters by workers of the queue, so bursts
+// of requests wait for free workers. Clusters that were queued or being
+// provisioned when control stopped are restored from storage.
+func (tp *TaskProvisioner) UseQueue(q *queue.Queue, profiles profile.Getter, accounts AccountGetter) {
+	tp.queue = &provisionQueue{
+		Queue:    q,
+		profiles: profiles,
+		accounts: accounts,
+		prepared: make(map[string]*provisioning),
+	}
+	q.Handle(ProvisionJob, tp.runProvisionJob)
+}
+
 type bufferCloser struct {
 	io.Writer
 	err error
@@ -70,16 +126,42 @@ func (b *bufferCloser) Close() error {
 // ProvisionCluster runs provisionCluster process among nodes
 // that have been provided for provisionCluster
 func (tp *TaskProvisioner) ProvisionCluster(parentContext context.Context,
-	clusterProfile *profile.Profile, config *steps.Config) (map[string][]*workflows.Task, error) {
+	clusterProfile *profile.Profile, config *steps.Config) (_ map[string][]*workflows.Task, err error) {
+	// the span lasts until all tasks of the cluster are finished
+	parentContext, span := tracing.StartSpan(parentContext, "provision cluster",
+		trace.StringAttribute("kube.name", config.Kube.Name),
+		trace.StringAttribute("provider", string(clusterProfile.Provider)))
+	defer func() {
+		if err != nil {
+			tracing.End(span, err)
+		}
+	}()
+
 	if err := util.BootstrapKeys(config); err != nil {
 		return nil, errors.Wrap(err, "bootstrap keys")
 	}
 
-	if err := bootstrapCerts(config); err != nil {
+	ca, err := tp.clusterCA(parentContext, config, clusterProfile.ExternalCA)
+	if err != nil {
+		return nil, errors.Wrap(err, "bootstrap CA for provisioning")
+	}
+
+	if err := bootstrapCerts(config, ca); err != nil {
 		return nil, errors.Wrap(err, "bootstrap certs")
 	}
 
-	taskMap := tp.prepare(config, len(clusterProfile.MasterProfiles), len(clusterProfile.NodesProfiles))
+	if err := bootstrapWinRM(clusterProfile, config); err != nil {
+		return nil, errors.Wrap(err, "bootstrap winrm")
+	}
+
+	// the signed CA key is a part of the cluster now
+	if clusterProfile.ExternalCA.CSRID != "" {
+		if err := pki.NewCSRService(tp.repository).Delete(parentContext, clusterProfile.ExternalCA.CSRID); err != nil {
+			log.Warnf("delete csr %s: %v", clusterProfile.ExternalCA.CSRID, err)
+		}
+	}
+
+	taskMap := tp.prepare(config, len(clusterProfile.MasterProfiles), clusterProfile.NodesProfiles)
 	clusterTask := taskMap[workflows.ClusterTask][0]
 
 	// Get clusterID from taskID
@@ -95,14 +177,17 @@ func (tp *TaskProvisioner) ProvisionCluster(parentContext context.Context,
 	tp.cancelMap[config.Kube.ID] = cancel
 
 	// TODO(stgleb): Make node names from task id before provisioning starts
-	masters, nodes := nodesFromProfile(config.Kube.Name,
+	masters, nodes, err := nodesFromProfile(&config.Kube,
 		taskMap[workflows.MasterTask], taskMap[workflows.NodeTask],
 		clusterProfile)
+	if err != nil {
+		return nil, errors.Wrap(err, "plan machines")
+	}
 
 	// Gather all task ids
 	taskIds := grabTaskIds(taskMap)
 	// Save cluster before provisioning
-	err := tp.buildInitialCluster(ctx, clusterProfile, masters, nodes,
+	err = tp.buildInitialCluster(ctx, clusterProfile, masters, nodes,
 		config, taskIds)
 
 	if err != nil {
@@ -112,14 +197,39 @@ func (tp *TaskProvisioner) ProvisionCluster(parentContext context.Context,
 	// monitor cluster state in separate goroutine
 	go tp.monitorClusterState(ctx, config.Kube.ID, config.NodeChan(),
 		config.KubeStateChan(), config.ConfigChan())
-	go tp.provision(ctx, taskMap, clusterProfile)
+	if tp.queue != nil {
+		if err := tp.enqueue(ctx, config.Kube.ID, &provisioning{
+			ctx:     ctx,
+			taskMap: taskMap,
+			profile: clusterProfile,
+			span:    span,
+		}); err != nil {
+			cancel()
+			return nil, errors.Wrap(err, "enqueue provisioning")
+		}
+	} else {
+		go func() {
+			tp.provision(ctx, taskMap, clusterProfile)
+			span.End()
+		}()
+	}
 	// Move cluster to provisioning state
 	config.KubeStateChan() <- model.StateProvisioning
 
 	return taskMap, nil
 }
 
-func (tp *TaskProvisioner) ProvisionNodes(parentContext context.Context, nodeProfiles []profile.NodeProfile, kube *model.Kube, config *steps.Config) ([]string, error) {
+func (tp *TaskProvisioner) ProvisionNodes(parentContext context.Context, nodeProfiles []profile.NodeProfile, kube *model.Kube, config *steps.Config) (_ []string, err error) {
+	parentContext, span := tracing.StartSpan(parentContext, "provision nodes",
+		trace.StringAttribute("kube.id", kube.ID),
+		trace.Int64Attribute("nodes", int64(len(nodeProfiles))))
+	defer func() { tracing.End(span, err) }()
+
+	// windows machines are reached with credentials generated along with the cluster
+	if hasWindowsNodes(nodeProfiles) && kube.WinRMConfig.Password == "" {
+		return nil, errors.New("cluster created without windows node support")
+	}
+
 	if len(kube.Masters) != 0 {
 		for key := range kube.Masters {
 			config.AddMaster(kube.Masters[key])
@@ -141,6 +251,8 @@ func (tp *TaskProvisioner) ProvisionNodes(parentContext context.Context, nodePro
 		config.NodeChan(), config.KubeStateChan(), config.ConfigChan())
 
 	tasks := make([]string, 0, len(nodeProfiles))
+	taken := machineNames(kube)
+	config.NodeIndex = len(kube.Nodes)
 
 	// TODO(stgleb): do this in async to avoid blocking the UI
 	for _, nodeProfile := range nodeProfiles {
@@ -148,7 +260,7 @@ func (tp *TaskProvisioner) ProvisionNodes(parentContext context.Context, nodePro
 		tp.rateLimiter.Take()
 
 		// Take node workflow for the provider
-		t, err := workflows.NewTask(config, workflows.ProvisionNode, tp.repository)
+		t, err := workflows.NewTask(config, nodeWorkflow(nodeProfile), tp.repository)
 		if err != nil {
 			return nil, errors.Wrap(sgerrors.ErrNotFound, "workflow")
 		}
@@ -170,7 +282,11 @@ func (tp *TaskProvisioner) ProvisionNodes(parentContext context.Context, nodePro
 
 		// Put task id to config so that create instance step can use this id when generate node name
 		config.TaskID = t.ID
+		if err := nextNodeIndex(config, taken); err != nil {
+			return nil, errors.Wrap(err, "name node")
+		}
 		errChan := t.Run(ctx, *config, writer)
+		config.NodeIndex++
 
 		go func(task *workflows.Task, cfg *steps.Config, errChan chan error) {
 			err = <-errChan
@@ -179,7 +295,7 @@ func (tp *TaskProvisioner) ProvisionNodes(parentContext context.Context, nodePro
 				task.Config.Node.State = model.MachineStateError
 				task.Config.AddNode(&task.Config.Node)
 				task.Config.NodeChan() <- task.Config.Node
-				logrus.Errorf("add node to cluster %s caused an error %v", kube.ID, err)
+				log.Errorf("add node to cluster %s caused an error %v", kube.ID, err)
 				return
 			}
 		}(t, config, errChan)
@@ -188,6 +304,12 @@ func (tp *TaskProvisioner) ProvisionNodes(parentContext context.Context, nodePro
 	return tasks, nil
 }
 
+// SetSpawnInterval changes the interval between cloud API calls that
+// create machines.
+func (tp *TaskProvisioner) SetSpawnInterval(interval time.Duration) {
+	tp.rateLimiter.SetInterval(interval)
+}
+
 func (tp *TaskProvisioner) Cancel(clusterID string) error {
 	if cancelFunc := tp.cancelMap[clusterID]; cancelFunc != nil {
 		cancelFunc()
@@ -205,13 +327,13 @@ func (tp *TaskProvisioner) RestartClusterProvisioning(parentCtx context.Context,
 	ctx, cancel := context.WithTimeout(context.Background(),
 		time.Minute*30)
 	tp.cancelMap[config.Kube.ID] = cancel
-	logrus.Debugf("Deserialize tasks")
+	log.Debugf("Deserialize tasks")
 
 	// Deserialize tasks and put them to map
 	taskMap, err := tp.deserializeClusterTasks(ctx, config, taskIdMap)
 
 	if err != nil {
-		logrus.Errorf("Restart cluster provisioning %v", err)
+		log.Errorf("Restart cluster provisioning %v", err)
 		return errors.Wrapf(err, "Restart cluster provisioning")
 	}
 
@@ -227,10 +349,104 @@ func (tp *TaskProvisioner) RestartClusterProvisioning(parentCtx context.Context,
 	return nil
 }
 
+func (tp *TaskProvisioner) enqueue(ctx context.Context, kubeID string, p *provisioning) error {
+	q := tp.queue
+	q.m.Lock()
+	q.prepared[kubeID] = p
+	q.m.Unlock()
+
+	if _, err := q.Enqueue(ctx, ProvisionJob, provisionJob{KubeID: kubeID}); err != nil {
+		q.m.Lock()
+		delete(q.prepared, kubeID)
+		q.m.Unlock()
+		return err
+	}
+	return nil
+}
+
+// runProvisionJob provisions the queued cluster, failures of provisioning
+// are reported by the cluster state and aren't retried by the queue.
+func (tp *TaskProvisioner) runProvisionJob(ctx context.Context, payload []byte) error {
+	job := provisionJob{}
+	if err := json.Unmarshal(payload, &job); err != nil {
+		log.Errorf("skip malformed provisioning job: %v", err)
+		return nil
+	}
+
+	q := tp.queue
+	q.m.Lock()
+	p := q.prepared[job.KubeID]
+	delete(q.prepared, job.KubeID)
+	q.m.Unlock()
+
+	if p == nil {
+		return tp.restoreProvisioning(ctx, job.KubeID)
+	}
+
+	tp.provision(p.ctx, p.taskMap, p.profile)
+	p.span.End()
+	if workflows.ShuttingDown() {
+		// the job stays in the queue to resume the cluster after restart
+		return workflows.ErrInterrupted
+	}
+	return nil
+}
+
+// restoreProvisioning resumes provisioning of a cluster queued by another
+// process, the config is restored from the cluster like on restart.
+func (tp *TaskProvisioner) restoreProvisioning(ctx context.Context, kubeID string) error {
+	k, err := tp.kubeService.Get(ctx, kubeID)
+	if sgerrors.IsNotFound(err) {
+		log.Infof("cluster %s has been deleted before provisioning", kubeID)
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "get kube %s", kubeID)
+	}
+	if k.State == model.StateOperational || k.State == model.StateDeleting {
+		return nil
+	}
+
+	clusterProfile, err := tp.queue.profiles(ctx, k.ProfileID)
+	if err != nil {
+		return errors.Wrapf(err, "get profile %s", k.ProfileID)
+	}
+	config, err := steps.NewConfigFromKube(clusterProfile, k)
+	if err != nil {
+		return errors.Wrap(err, "new config")
+	}
+	if err = util.LoadCloudSpecificDataFromKube(k, config); err != nil {
+		return errors.Wrap(err, "load cloud specific data")
+	}
+	acc, err := tp.queue.accounts.Get(ctx, k.AccountName)
+	if err != nil {
+		return errors.Wrapf(err, "get cloud account %s", k.AccountName)
+	}
+	if err = util.FillCloudAccountCredentials(acc, config); err != nil {
+		return errors.Wrap(err, "fill cloud account credentials")
+	}
+
+	provisionCtx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	tp.cancelMap[k.ID] = cancel
+	taskMap, err := tp.deserializeClusterTasks(provisionCtx, config, k.Tasks)
+	if err != nil {
+		cancel()
+		return errors.Wrap(err, "restore tasks")
+	}
+
+	log.Infof("resume provisioning of cluster %s", k.ID)
+	go tp.monitorClusterState(provisionCtx, k.ID,
+		config.NodeChan(), config.KubeStateChan(), config.ConfigChan())
+	config.KubeStateChan() <- model.StateProvisioning
+	tp.provision(provisionCtx, taskMap, clusterProfile)
+	if workflows.ShuttingDown() {
+		return workflows.ErrInterrupted
+	}
+	return nil
+}
+
 func (tp *TaskProvisioner) UpgradeCluster(parentCtx context.Context, nextVersion string, k *model.Kube,
 	tasks map[string][]*workflows.Task, config *steps.Config) {
-	bootstrapTask := tasks[workflows.MasterTask][0]
-	masterTasks := tasks[workflows.MasterTask][1:]
 	nodeTasks := tasks[workflows.NodeTask]
 
 	go tp.monitorClusterState(parentCtx, k.ID, config.NodeChan(),
@@ -238,49 +454,45 @@ func (tp *TaskProvisioner) UpgradeCluster(parentCtx context.Context, nextVersion
 
 	// TODO(stgleb): uncomment this once UI handle Upgrading state of the cluster
 	//config.KubeStateChan() <- model.StateUpgrading
-	logrus.Infof("Upgrade from %s to %s", k.K8SVersion, nextVersion)
-	bootstrapTask.Config.IsBootstrap = true
-	fileName := util.MakeFileName(bootstrapTask.ID)
-	writer, err := tp.getWriter(fileName)
-
-	if err != nil {
-		logrus.Errorf("error creating writer %v", err)
+	log.Infof("Upgrade from %s to %s", k.K8SVersion, nextVersion)
+	if config.UpgradeConfig.Strategy == steps.UpgradeBlueGreen {
+		if err := tp.replaceMasters(parentCtx, k, nextVersion, tasks[workflows.MasterTask], config); err != nil {
+			log.Errorf("replace masters of %s: %v", k.ID, err)
+			return
+		}
+	} else if err := tp.upgradeMasters(tasks[workflows.MasterTask]); err != nil {
+		log.Errorf("upgrade masters of %s: %v", k.ID, err)
 		return
 	}
 
-	logrus.Infof("upgrade bootstrap node %v", bootstrapTask.Config.Node)
-	tp.upgradeMachine(bootstrapTask, writer)
-
-	for i := 0; i < len(masterTasks); i++ {
-		masterTask := masterTasks[i]
-		fileName := util.MakeFileName(masterTask.ID)
-		writer, err := tp.getWriter(fileName)
-
-		if err != nil {
-			logrus.Errorf("error creating writer %v", err)
+	canaries, nodeTasks := canaryTasks(nodeTasks, config.UpgradeCanary)
+	if len(canaries) > 0 {
+		if err := tp.upgradeNodes(canaries, config); err != nil {
+			tp.abortUpgrade(parentCtx, k, nextVersion, err)
 			return
 		}
 
-		logrus.Infof("Upgrade master node %v", masterTask.Config.Node)
-		go tp.upgradeMachine(masterTask, writer)
-	}
-
-	for i := 0; i < len(nodeTasks); i++ {
-		nodeTask := nodeTasks[i]
-
-		fileName := util.MakeFileName(nodeTask.ID)
-		writer, err := tp.getWriter(fileName)
-
+		taskID, err := tp.verifyUpgrade(parentCtx, config, config.GetMaster())
 		if err != nil {
-			logrus.Errorf("error creating writer %v", err)
+			tp.abortUpgrade(parentCtx, k, nextVersion, errors.Wrapf(err, "verification task %s", taskID))
 			return
 		}
+		tp.recordEvent(parentCtx, &timeline.Event{
+			KubeID:  k.ID,
+			Type:    timeline.UpgradeCanaryPassed,
+			Message: fmt.Sprintf("%d canary nodes have been upgraded to %s and verified by task %s", len(canaries), nextVersion, taskID),
+		})
+	}
 
-		logrus.Infof("Upgrade worker node %v", nodeTask.Config.Node)
-		tp.upgradeMachine(nodeTask, writer)
-		config.KubeStateChan() <- model.StateOperational
-		config.ConfigChan() <- config
+	if err := tp.upgradeNodes(nodeTasks, config); err != nil {
+		log.Errorf("upgrade nodes of %s: %v", k.ID, err)
 	}
+
+	tp.recordEvent(parentCtx, &timeline.Event{
+		KubeID:  k.ID,
+		Type:    timeline.UpgradeFinished,
+		Message: fmt.Sprintf("cluster has been upgraded from %s to %s", k.K8SVersion, nextVersion),
+	})
 }
 
 // provision do actual provisioning of master and worker nodes
@@ -290,12 +502,12 @@ func (tp *TaskProvisioner) provision(ctx context.Context,
 
 	config := preProvisionTask[0].Config
 	if preProvisionTask != nil && len(preProvisionTask) > 0 {
-		logrus.Debugf("preprovision task %s",
+		log.Debugf("preprovision task %s",
 			preProvisionTask[0].ID)
 
 		if preProvisionErr := tp.preProvision(ctx, preProvisionTask[0], config); preProvisionErr != nil {
 			config.KubeStateChan() <- model.StateFailed
-			logrus.Errorf("Pre provisioning cluster %v", preProvisionErr)
+			log.Errorf("Pre provisioning cluster %v", preProvisionErr)
 			return
 		}
 
@@ -316,29 +528,29 @@ func (tp *TaskProvisioner) provision(ctx context.Context,
 	bootstrapTask := taskMap[workflows.MasterTask][0]
 	taskMap[workflows.MasterTask] = taskMap[workflows.MasterTask][1:]
 
-	logrus.Debug("Provision bootstrap node")
+	log.Debug("Provision bootstrap node")
 	err := tp.bootstrapMaster(ctx, clusterProfile, config, bootstrapTask)
 
 	if err != nil {
 		config.KubeStateChan() <- model.StateFailed
-		logrus.Errorf("provisioning bootstrap node has been failed")
+		log.Errorf("provisioning bootstrap node has been failed")
 		return
 	}
 
 	config = bootstrapTask.Config
 	config.IsBootstrap = false
-	logrus.Debug("Provision masters")
+	log.Debug("Provision masters")
 	err = tp.provisionMasters(ctx, clusterProfile,
 		config, taskMap[workflows.MasterTask])
 
 	if err != nil {
 		config.KubeStateChan() <- model.StateFailed
-		logrus.Errorf("master cluster deployment has been failed")
+		log.Errorf("master cluster deployment has been failed")
 		return
 	}
 
 	// Save cluster state when masters are provisioned
-	logrus.Infof("master provisioning for cluster"+
+	log.Infof("master provisioning for cluster"+
 		"%s has finished successfully",
 		config.Kube.ID)
 
@@ -349,7 +561,7 @@ func (tp *TaskProvisioner) provision(ctx context.Context,
 
 	if err != nil {
 		config.KubeStateChan() <- model.StateFailed
-		logrus.Errorf("Node provision has failed with %v", err)
+		log.Errorf("Node provision has failed with %v", err)
 		return
 	}
 
@@ -360,18 +572,18 @@ func (tp *TaskProvisioner) provision(ctx context.Context,
 
 		if err != nil {
 			config.KubeStateChan() <- model.StateFailed
-			logrus.Errorf("cluster task %s has finished with error %v", clusterTask.ID, err)
+			log.Errorf("cluster task %s has finished with error %v", clusterTask.ID, err)
 		} else {
 			config.KubeStateChan() <- model.StateOperational
-			logrus.Infof("cluster-task %s has finished", clusterTask.ID)
+			log.Infof("cluster-task %s has finished", clusterTask.ID)
 		}
 	}
-	logrus.Infof("cluster %s deployment has finished",
+	log.Infof("cluster %s deployment has finished",
 		config.Kube.ID)
 }
 
 // prepare creates all tasks for provisioning according to cloud provider
-func (tp *TaskProvisioner) prepare(config *steps.Config, masterCount, nodeCount int) map[string][]*workflows.Task {
+func (tp *TaskProvisioner) prepare(config *steps.Config, masterCount int, nodeProfiles []profile.NodeProfile) map[string][]*workflows.Task {
 	var (
 		infraTask   *workflows.Task
 		clusterTask *workflows.Task
@@ -379,12 +591,12 @@ func (tp *TaskProvisioner) prepare(config *steps.Config, masterCount, nodeCount
 	)
 
 	masterTasks := make([]*workflows.Task, 0, masterCount)
-	nodeTasks := make([]*workflows.Task, 0, nodeCount)
+	nodeTasks := make([]*workflows.Task, 0, len(nodeProfiles))
 	//some clouds (e.g. AWS) requires running tasks before provisioning nodes (creating a VPC, Subnets, SecGroups, etc)
 	infraTask, err = workflows.NewTask(config, fmt.Sprintf("%s%s", config.Provider, workflows.Infra), tp.repository)
 	if err != nil {
 		// We can't go further without pre provision task
-		logrus.Errorf("create pre provision task has finished with %v", err)
+		log.Errorf("create pre provision task has finished with %v", err)
 		return nil
 	}
 
@@ -392,16 +604,16 @@ func (tp *TaskProvisioner) prepare(config *steps.Config, masterCount, nodeCount
 	for i := 0; i < masterCount; i++ {
 		t, err := workflows.NewTask(config, workflows.ProvisionMaster, tp.repository)
 		if err != nil {
-			logrus.Errorf("Failed to set up task for %s workflow", workflows.ProvisionMaster)
+			log.Errorf("Failed to set up task for %s workflow", workflows.ProvisionMaster)
 			continue
 		}
 		masterTasks = append(masterTasks, t)
 	}
 
-	for i := 0; i < nodeCount; i++ {
-		t, err := workflows.NewTask(config, workflows.ProvisionNode, tp.repository)
+	for _, nodeProfile := range nodeProfiles {
+		t, err := workflows.NewTask(config, nodeWorkflow(nodeProfile), tp.repository)
 		if err != nil {
-			logrus.Errorf("Failed to set up task for %s workflow", workflows.ProvisionNode)
+			log.Errorf("Failed to set up task for %s workflow", nodeWorkflow(nodeProfile))
 			continue
 		}
 		t.Config = config
@@ -410,7 +622,7 @@ func (tp *TaskProvisioner) prepare(config *steps.Config, masterCount, nodeCount
 
 	clusterTask, err = workflows.NewTask(config, workflows.PostProvision, tp.repository)
 	if err != nil {
-		logrus.Errorf("Failed to set up task for %s workflow", workflows.PostProvision)
+		log.Errorf("Failed to set up task for %s workflow", workflows.PostProvision)
 		return nil
 	}
 
@@ -434,7 +646,7 @@ func (tp *TaskProvisioner) preProvision(ctx context.Context, preProvisionTask *w
 	out, err := tp.getWriter(fileName)
 
 	if err != nil {
-		logrus.Errorf("Error getting writer for %s", fileName)
+		log.Errorf("Error getting writer for %s", fileName)
 		return err
 	}
 
@@ -443,12 +655,12 @@ func (tp *TaskProvisioner) preProvision(ctx context.Context, preProvisionTask *w
 	config.ConfigChan() <- preProvisionTask.Config
 
 	if err != nil {
-		logrus.Errorf("pre provision task %s has finished with error %v",
+		log.Errorf("pre provision task %s has finished with error %v",
 			preProvisionTask.ID, err)
 		config.KubeStateChan() <- model.StateFailed
 	}
 
-	logrus.Infof("pre provision task %s has finished", preProvisionTask.ID)
+	log.Infof("pre provision task %s has finished", preProvisionTask.ID)
 
 	return err
 }
@@ -461,7 +673,7 @@ func (tp *TaskProvisioner) bootstrapMaster(ctx context.Context,
 	out, err := tp.getWriter(fileName)
 
 	if err != nil {
-		logrus.Errorf("Error getting writer for %s", fileName)
+		log.Errorf("Error getting writer for %s", fileName)
 		return errors.Wrapf(err, "Error getting writer for %s", fileName)
 	}
 
@@ -479,6 +691,7 @@ func (tp *TaskProvisioner) bootstrapMaster(ctx context.Context,
 	}
 
 	bootstrapTask.Config.TaskID = bootstrapTask.ID
+	bootstrapTask.Config.NodeIndex = 0
 	bootstrapTask.Config.IsBootstrap = true
 	bootstrapTask.Config.IsMaster = true
 
@@ -486,13 +699,13 @@ func (tp *TaskProvisioner) bootstrapMaster(ctx context.Context,
 	rootConfig.ConfigChan() <- bootstrapTask.Config
 
 	if err != nil {
-		logrus.Errorf("bootstrap task %s has finished with error %v", bootstrapTask.ID, err)
+		log.Errorf("bootstrap task %s has finished with error %v", bootstrapTask.ID, err)
 		return errors.Wrapf(err, "master bootstrap task %s has finished with error %v", bootstrapTask.ID, err)
 	} else {
-		logrus.Infof("bootstrap %s has finished", bootstrapTask.ID)
+		log.Infof("bootstrap %s has finished", bootstrapTask.ID)
 	}
 
-	logrus.Infof("bootstrap task %s has finished", bootstrapTask.ID)
+	log.Infof("bootstrap task %s has finished", bootstrapTask.ID)
 
 	return nil
 }
@@ -531,21 +744,23 @@ func (tp *TaskProvisioner) provisionMasters(ctx context.Context,
 		out, err := tp.getWriter(fileName)
 
 		if err != nil {
-			logrus.Errorf("Error getting writer for %s", fileName)
+			log.Errorf("Error getting writer for %s", fileName)
 		}
 
 		if err := MergeConfig(rootConfig, masterTask.Config); err != nil {
 			return errors.Wrapf(err, "merge pre provision config to bootstrap task config")
 		}
 
-		// Fulfill task config with data about provider specific node configuration
-		p := profile.MasterProfiles[index]
+		// Fulfill task config with data about provider specific node configuration,
+		// the first master profile is taken by the bootstrap master
+		p := profile.MasterProfiles[index+1]
 
 		err = FillNodeCloudSpecificData(profile.Provider, p, masterTask.Config)
 
 		if err != nil {
 			return errors.Wrap(err, "fill master profile data to config")
 		}
+		masterTask.Config.NodeIndex = index + 1
 
 		go func(t *workflows.Task) {
 			defer wg.Done()
@@ -560,9 +775,9 @@ func (tp *TaskProvisioner) provisionMasters(ctx context.Context,
 			errChan <- err
 
 			if err != nil {
-				logrus.Errorf("master task %s has finished with error %v", t.ID, err)
+				log.Errorf("master task %s has finished with error %v", t.ID, err)
 			} else {
-				logrus.Infof("master-task %s has finished", t.ID)
+				log.Infof("master-task %s has finished", t.ID)
 			}
 		}(masterTask)
 	}
@@ -575,9 +790,22 @@ func (tp *TaskProvisioner) provisionMasters(ctx context.Context,
 	return <-errChan
 }
 
-func (tp *TaskProvisioner) provisionNodes(ctx context.Context, profile *profile.Profile, rootConfig *steps.Config, tasks []*workflows.Task) error {
-	wg := sync.WaitGroup{}
-	wg.Add(len(tasks))
+// provisionNodes runs node tasks with at most Parallelism of node bootstrap
+// settings at once. Failed nodes are reported together, the error is
+// returned only when the failure policy is fail-fast, the first failure
+// cancels other nodes then.
+func (tp *TaskProvisioner) provisionNodes(parentCtx context.Context, clusterProfile *profile.Profile, rootConfig *steps.Config, tasks []*workflows.Task) error {
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	failFast := clusterProfile.NodeBootstrap.FailurePolicy == profile.FailFast
+	slots := make(chan struct{}, clusterProfile.NodeBootstrap.Limit())
+
+	var (
+		wg     sync.WaitGroup
+		m      sync.Mutex
+		failed []string
+	)
 
 	// ProvisionCluster nodes
 	for index, nodeTask := range tasks {
@@ -586,6 +814,14 @@ func (tp *TaskProvisioner) provisionNodes(ctx context.Context, profile *profile.
 			continue
 		}
 
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
 		// Take token that allows perform action with Cloud Provider API
 		tp.rateLimiter.Take()
 
@@ -593,45 +829,69 @@ func (tp *TaskProvisioner) provisionNodes(ctx context.Context, profile *profile.
 		out, err := tp.getWriter(fileName)
 
 		if err != nil {
-			logrus.Errorf("Error getting writer for %s", fileName)
+			log.Errorf("Error getting writer for %s", fileName)
+			cancel()
+			wg.Wait()
 			return errors.Wrapf(err, "Error getting writer for %s", fileName)
 		}
 
 		// Fulfill task config with data about provider specific node configuration
-		p := profile.NodesProfiles[index]
+		p := clusterProfile.NodesProfiles[index]
 		if err := MergeConfig(rootConfig, nodeTask.Config); err != nil {
-			logrus.Errorf("merge pre provision config to bootstrap task config caused %v", err)
+			log.Errorf("merge pre provision config to bootstrap task config caused %v", err)
 		}
 
-		if err := FillNodeCloudSpecificData(profile.Provider, p, nodeTask.Config); err != nil {
+		if err := FillNodeCloudSpecificData(clusterProfile.Provider, p, nodeTask.Config); err != nil {
+			cancel()
+			wg.Wait()
 			return errors.Wrapf(err, "fill nodes profile caused")
 		}
 
 		// Put task id to config so that create instance step can use this id when generate node name
 		nodeTask.Config.TaskID = nodeTask.ID
+		nodeTask.Config.NodeIndex = index
 
+		wg.Add(1)
 		go func(t *workflows.Task) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
 			t.Config.IsMaster = false
 			t.Config.IsBootstrap = false
-			result := t.Run(ctx, *t.Config, out)
-			err = <-result
-
-			if err != nil {
+			if err := <-t.Run(ctx, *t.Config, out); err != nil {
 				// Put node to error state
 				t.Config.Node.State = model.MachineStateError
 				t.Config.AddNode(&t.Config.Node)
 				t.Config.NodeChan() <- t.Config.Node
 
-				logrus.Errorf("node task %s has finished with error %v", t.ID, err)
+				log.Errorf("node task %s has finished with error %v", t.ID, err)
+				m.Lock()
+				failed = append(failed, fmt.Sprintf("%s: %v", t.ID, err))
+				m.Unlock()
+
+				if failFast {
+					cancel()
+				}
 			} else {
-				logrus.Infof("node-task %s has finished", t.ID)
+				log.Infof("node-task %s has finished", t.ID)
 			}
-
-			wg.Done()
 		}(nodeTask)
 	}
 
 	wg.Wait()
+
+	if err := parentCtx.Err(); err != nil {
+		return err
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+
+	err := errors.Errorf("%d of %d nodes failed: %s", len(failed), len(tasks), strings.Join(failed, "; "))
+	if failFast {
+		return err
+	}
+	log.Errorf("cluster %s is provisioned without nodes: %v", rootConfig.Kube.ID, err)
 	return nil
 }
 
@@ -650,7 +910,7 @@ func (tp *TaskProvisioner) waitCluster(ctx context.Context, clusterTask *workflo
 
 	// Get master node to run cluster task
 	if master := config.GetMaster(); master != nil {
-		logrus.Printf("Change one master %v to another %v", *master, cfg.Node)
+		log.Printf("Change one master %v to another %v", *master, cfg.Node)
 		cfg.Node = *master
 	} else {
 		return errors.New("No master found, cluster deployment failed")
@@ -727,22 +987,71 @@ func (t *TaskProvisioner) loadCloudSpecificData(ctx context.Context, config *ste
 	k, err := t.kubeService.Get(ctx, config.Kube.ID)
 
 	if err != nil {
-		logrus.Errorf("get kube caused %v", err)
+		log.Errorf("get kube caused %v", err)
 		return err
 	}
 
 	return util.LoadCloudSpecificDataFromKube(k, config)
 }
 
-func bootstrapCerts(config *steps.Config) error {
-	ca, err := pki.NewCAPair([]byte(config.Kube.Auth.ParentCert))
+// clusterCA returns an intermediate of the external CA if it's configured
+// for the cluster or a new self-signed CA otherwise.
+func (tp *TaskProvisioner) clusterCA(ctx context.Context, config *steps.Config, ext profile.ExternalCA) (*pki.PairPEM, error) {
+	var (
+		ca    *pki.PairPEM
+		chain []byte
+		err   error
+	)
+
+	switch {
+	case ext.Key != "":
+		ca, err = pki.NewIntermediateCAPair(pki.DefaultCACommonName, &pki.PairPEM{
+			Cert: []byte(ext.Cert),
+			Key:  []byte(ext.Key),
+		})
+		chain = []byte(ext.Cert)
+	case ext.Vault.Address != "":
+		var csr, key, signed []byte
+		if csr, key, err = pki.NewCACSR(pki.DefaultCACommonName); err != nil {
+			return nil, err
+		}
+		signer := &pki.VaultSigner{
+			Address: ext.Vault.Address,
+			Token:   ext.Vault.Token,
+			Mount:   ext.Vault.Mount,
+			TTL:     ext.Vault.TTL,
+		}
+		if signed, chain, err = signer.SignIntermediate(ctx, pki.DefaultCACommonName, csr); err != nil {
+			return nil, err
+		}
+		ca, _, err = pki.NewCAPairFromSigned(signed, key)
+	case ext.CSRID != "":
+		ca, chain, err = pki.NewCSRService(tp.repository).Complete(ctx, ext.CSRID, []byte(ext.SignedCert))
+	default:
+		return pki.NewCAPair([]byte(config.Kube.Auth.ParentCert))
+	}
 	if err != nil {
-		return errors.Wrap(err, "bootstrap CA for provisioning")
+		return nil, err
 	}
+
+	config.Kube.Auth.ParentCert = string(chain)
+	return ca, nil
+}
+
+func bootstrapCerts(config *steps.Config, ca *pki.PairPEM) error {
+	var err error
 	config.Kube.Auth.CACert = string(ca.Cert)
 	config.Kube.Auth.CAKey = string(ca.Key)
 	config.Kube.Auth.CACertHash = ca.CertHash
 
+	// etcd CA is kept on the control side to sign certificates for new etcd peers
+	etcdCA, err := pki.NewCAPair(nil)
+	if err != nil {
+		return errors.Wrap(err, "bootstrap etcd CA")
+	}
+	config.Kube.Auth.EtcdCACert = string(etcdCA.Cert)
+	config.Kube.Auth.EtcdCAKey = string(etcdCA.Key)
+
 	if config.Kube.Auth.CertificateKey, err = copycerts.CreateCertificateKey(); err != nil {
 		return errors.Wrap(err, "create certificate key")
 	}
@@ -757,6 +1066,35 @@ func bootstrapCerts(config *steps.Config) error {
 	return nil
 }
 
+// bootstrapWinRM generates credentials of windows machines when the profile has
+// windows node groups, nodes added later may be windows ones only in this case.
+func bootstrapWinRM(clusterProfile *profile.Profile, config *steps.Config) error {
+	if hasWindowsNodes(clusterProfile.MasterProfiles) {
+		return errors.New("windows masters are not supported")
+	}
+
+	if !hasWindowsNodes(clusterProfile.NodesProfiles) {
+		return nil
+	}
+
+	if clusterProfile.Provider != clouds.AWS {
+		return errors.Errorf("windows nodes are not supported on %s", clusterProfile.Provider)
+	}
+
+	password, err := randomPassword()
+	if err != nil {
+		return errors.Wrap(err, "generate password")
+	}
+
+	config.Kube.WinRMConfig = model.WinRMConfig{
+		User:     windowsUser,
+		Password: password,
+		Port:     winrm.DefaultHTTPSPort,
+	}
+
+	return nil
+}
+
 // TODO(stgleb): move it out of the provisioner
 // All cluster state changes during provisioning must be made in this function
 func (tp *TaskProvisioner) monitorClusterState(ctx context.Context,
@@ -768,56 +1106,61 @@ func (tp *TaskProvisioner) monitorClusterState(ctx context.Context,
 			k, err := tp.kubeService.Get(ctx, clusterID)
 
 			if err != nil {
-				logrus.Errorf("cluster monitor: update kube state caused %v", err)
+				log.Errorf("cluster monitor: update kube state caused %v", err)
 				continue
 			}
 
+			machines := k.Nodes
 			if n.Role == model.RoleMaster {
-				k.Masters[n.Name] = &n
-			} else {
-				k.Nodes[n.Name] = &n
+				machines = k.Masters
+			}
+			tp.recordMachineEvent(ctx, k.ID, machines[n.Name], n)
+			machines[n.Name] = &n
+			if n.StaticIP != nil {
+				k.TakeStaticIP(n.StaticIP.ID)
 			}
 
 			err = tp.kubeService.Create(ctx, k)
 
 			if err != nil {
-				logrus.Errorf("cluster monitor: update kube state caused %v", err)
+				log.Errorf("cluster monitor: update kube state caused %v", err)
 				continue
 			}
 		case state := <-kubeStateChan:
-			logrus.Debugf("monitor: get kube %s", clusterID)
+			log.Debugf("monitor: get kube %s", clusterID)
 			k, err := tp.kubeService.Get(ctx, clusterID)
 
 			if err != nil {
-				logrus.Errorf("cluster monitor: update kube state caused %v", err)
+				log.Errorf("cluster monitor: update kube state caused %v", err)
 				continue
 			}
 
+			tp.recordStateEvent(ctx, k, state)
 			k.State = state
-			logrus.Debugf("monitor: update kube %s with state %s",
+			log.Debugf("monitor: update kube %s with state %s",
 				k.ID, state)
 			err = tp.kubeService.Create(ctx, k)
 
 			if err != nil {
-				logrus.Errorf("cluster monitor: update kube state caused %v", err)
+				log.Errorf("cluster monitor: update kube state caused %v", err)
 				continue
 			}
 		case config := <-configChan:
-			logrus.Debugf("update kube %s with config", clusterID)
+			log.Debugf("update kube %s with config", clusterID)
 			k, err := tp.kubeService.Get(ctx, clusterID)
 
 			if err != nil {
-				logrus.Errorf("cluster monitor: update kube state caused %v", err)
+				log.Errorf("cluster monitor: update kube state caused %v", err)
 				continue
 			}
 
-			logrus.Debugf("update kube %s with config", k.ID)
+			log.Debugf("update kube %s with config", k.ID)
 			util.UpdateKubeWithCloudSpecificData(k, config)
 
 			err = tp.kubeService.Create(ctx, k)
 
 			if err != nil {
-				logrus.Errorf("cluster monitor: update kube state caused %v", err)
+				log.Errorf("cluster monitor: update kube state caused %v", err)
 				continue
 			}
 		case <-ctx.Done():
@@ -826,6 +1169,50 @@ func (tp *TaskProvisioner) monitorClusterState(ctx context.Context,
 	}
 }
 
+// recordStateEvent adds provisioning and failures of the cluster to its timeline.
+func (tp *TaskProvisioner) recordStateEvent(ctx context.Context, k *model.Kube, state model.KubeState) {
+	e := &timeline.Event{KubeID: k.ID}
+	switch {
+	case state == model.StateProvisioning && k.State != model.StateProvisioning:
+		e.Type, e.Message = timeline.ProvisionStarted, "cluster provisioning has started"
+	case state == model.StateOperational && k.State == model.StateProvisioning:
+		e.Type, e.Message = timeline.ProvisionFinished, "cluster provisioning has finished"
+	case state == model.StateFailed && k.State != model.StateFailed:
+		e.Type, e.Message = timeline.ClusterFailed, fmt.Sprintf("cluster has failed while %s", k.State)
+	default:
+		return
+	}
+	tp.recordEvent(ctx, e)
+}
+
+// recordMachineEvent adds machines that joined or failed to the cluster timeline.
+func (tp *TaskProvisioner) recordMachineEvent(ctx context.Context, kubeID string, prev *model.Machine, n model.Machine) {
+	if prev != nil && prev.State == n.State {
+		return
+	}
+
+	e := &timeline.Event{KubeID: kubeID, Object: n.Name}
+	switch n.State {
+	case model.MachineStateActive:
+		// upgraded machines become active again
+		if prev != nil && prev.State == model.MachineStateUpgrading {
+			return
+		}
+		e.Type, e.Message = timeline.NodeAdded, fmt.Sprintf("%s %s has joined the cluster", n.Role, n.Name)
+	case model.MachineStateError:
+		e.Type, e.Message = timeline.NodeFailed, fmt.Sprintf("%s %s has failed", n.Role, n.Name)
+	default:
+		return
+	}
+	tp.recordEvent(ctx, e)
+}
+
+func (tp *TaskProvisioner) recordEvent(ctx context.Context, e *timeline.Event) {
+	if err := tp.timeline.Record(ctx, e); err != nil {
+		log.Warnf("record %s event of kube %s: %v", e.Type, e.KubeID, err)
+	}
+}
+
 func (tp *TaskProvisioner) deserializeClusterTasks(ctx context.Context, kubeConfig *steps.Config, taskIdMap map[string][]string) (map[string][]*workflows.Task, error) {
 	taskMap := make(map[string][]*workflows.Task)
 
@@ -834,14 +1221,14 @@ func (tp *TaskProvisioner) deserializeClusterTasks(ctx context.Context, kubeConf
 			data, err := tp.repository.Get(ctx, workflows.Prefix, taskId)
 
 			if err != nil {
-				logrus.Debugf("error getting task %s %v", taskId, err)
+				log.Debugf("error getting task %s %v", taskId, err)
 				return nil, errors.Wrapf(err, "task id %s not found %b", taskId, err)
 			}
 
 			task, err := workflows.DeserializeTask(data, tp.repository)
 
 			if err != nil {
-				logrus.Debugf("error deserializing task %s %v", taskId, err)
+				log.Debugf("error deserializing task %s %v", taskId, err)
 				return nil, errors.Wrapf(err, "error deserializing task %s %v", taskId, err)
 			}
 
@@ -851,7 +1238,11 @@ func (tp *TaskProvisioner) deserializeClusterTasks(ctx context.Context, kubeConf
 				return nil, errors.Wrapf(err, "deserialize task")
 			}
 
-			logrus.Infof("deserialize task id %s", task.ID)
+			if err = workflows.FillKeys(task, &kubeConfig.Kube); err != nil {
+				return nil, errors.Wrapf(err, "fill keys of task %s", taskId)
+			}
+
+			log.Infof("deserialize task id %s", task.ID)
 			taskMap[taskSet] = append(taskMap[taskSet], task)
 		}
 	}
@@ -859,7 +1250,167 @@ func (tp *TaskProvisioner) deserializeClusterTasks(ctx context.Context, kubeConf
 	return taskMap, nil
 }
 
-func (tp *TaskProvisioner) upgradeMachine(task *workflows.Task, writer io.WriteCloser) {
+// PatchCluster upgrades os packages of cluster machines one by one,
+// it stops on the first failed machine to keep the rest of the cluster running.
+func (tp *TaskProvisioner) PatchCluster(ctx context.Context, k *model.Kube,
+	tasks map[string][]*workflows.Task, config *steps.Config) error {
+	log.Infof("Patch cluster %s", k.ID)
+	return tp.rollingUpdate(ctx, k, tasks, config)
+}
+
+// UpgradeRuntime upgrades a container runtime of cluster machines one by one.
+func (tp *TaskProvisioner) UpgradeRuntime(ctx context.Context, k *model.Kube,
+	tasks map[string][]*workflows.Task, config *steps.Config) error {
+	log.Infof("Upgrade container runtime of cluster %s to %s", k.ID, config.Kube.DockerVersion)
+	return tp.rollingUpdate(ctx, k, tasks, config)
+}
+
+// MaintainEtcd checks and defragments etcd members of cluster masters one by one.
+func (tp *TaskProvisioner) MaintainEtcd(ctx context.Context, k *model.Kube,
+	tasks map[string][]*workflows.Task, config *steps.Config) error {
+	log.Infof("Start etcd maintenance of cluster %s", k.ID)
+	return tp.rollingUpdate(ctx, k, tasks, config)
+}
+
+// RotateSSHKey replaces the bootstrap ssh key of cluster machines. The new key
+// is authorized and verified on every machine before it is stored and the old
+// key is revoked only after that, a failed rotation leaves the old key working.
+func (tp *TaskProvisioner) RotateSSHKey(ctx context.Context, k *model.Kube,
+	addTasks, removeTasks map[string][]*workflows.Task, config *steps.Config) error {
+	log.Infof("Rotate ssh key of cluster %s", k.ID)
+	go tp.monitorClusterState(ctx, k.ID, config.NodeChan(),
+		config.KubeStateChan(), config.ConfigChan())
+
+	if err := tp.updateMachines(ctx, addTasks); err != nil {
+		return errors.Wrap(err, "authorize new key")
+	}
+
+	keyPairName := k.CloudSpec[clouds.AwsKeyPairName]
+	for _, task := range addTasks[workflows.ClusterTask] {
+		if err := tp.runClusterTask(ctx, task); err != nil {
+			return errors.Wrap(err, "import new key")
+		}
+		keyPairName = task.Config.AWSConfig.KeyPairName
+	}
+
+	saved, err := tp.kubeService.Get(ctx, k.ID)
+	if err != nil {
+		return errors.Wrapf(err, "get cluster %s", k.ID)
+	}
+
+	saved.SSHConfig.BootstrapPrivateKey = config.SSHKeyRotationConfig.PrivateKey
+	saved.SSHConfig.BootstrapPublicKey = config.SSHKeyRotationConfig.PublicKey
+	if saved.Provider == clouds.AWS {
+		if saved.CloudSpec == nil {
+			saved.CloudSpec = make(map[string]string)
+		}
+		saved.CloudSpec[clouds.AwsKeyPairName] = keyPairName
+		saved.CloudSpec[clouds.AwsSshBootstrapPrivateKey] = config.SSHKeyRotationConfig.PrivateKey
+	}
+
+	if err := tp.kubeService.Create(ctx, saved); err != nil {
+		return errors.Wrapf(err, "save new key of cluster %s", k.ID)
+	}
+
+	if err := tp.updateMachines(ctx, removeTasks); err != nil {
+		return errors.Wrap(err, "revoke old key")
+	}
+
+	for _, task := range removeTasks[workflows.ClusterTask] {
+		if err := tp.runClusterTask(ctx, task); err != nil {
+			return errors.Wrap(err, "delete old key")
+		}
+	}
+
+	return nil
+}
+
+// rollingUpdate runs machine tasks sequentially masters first,
+// it stops on the first failed machine to keep the rest of the cluster running.
+func (tp *TaskProvisioner) rollingUpdate(ctx context.Context, k *model.Kube,
+	tasks map[string][]*workflows.Task, config *steps.Config) error {
+	go tp.monitorClusterState(ctx, k.ID, config.NodeChan(),
+		config.KubeStateChan(), config.ConfigChan())
+
+	return tp.updateMachines(ctx, tasks)
+}
+
+func (tp *TaskProvisioner) updateMachines(ctx context.Context, tasks map[string][]*workflows.Task) error {
+	for _, taskSet := range []string{workflows.MasterTask, workflows.NodeTask} {
+		for _, task := range tasks[taskSet] {
+			writer, err := tp.getWriter(util.MakeFileName(task.ID))
+			if err != nil {
+				return errors.Wrapf(err, "get writer for task %s", task.ID)
+			}
+
+			log.Infof("Update machine %v", task.Config.Node)
+			if err := tp.updateMachine(ctx, task, writer); err != nil {
+				return errors.Wrapf(err, "update machine %s", task.Config.Node.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (tp *TaskProvisioner) runClusterTask(ctx context.Context, task *workflows.Task) error {
+	writer, err := tp.getWriter(util.MakeFileName(task.ID))
+	if err != nil {
+		return errors.Wrapf(err, "get writer for task %s", task.ID)
+	}
+
+	return <-task.Run(ctx, *task.Config, writer)
+}
+
+func (tp *TaskProvisioner) updateMachine(ctx context.Context, task *workflows.Task, writer io.WriteCloser) error {
+	task.Config.Node.State = model.MachineStateUpgrading
+	task.Config.NodeChan() <- task.Config.Node
+
+	err := <-task.Run(ctx, *task.Config, writer)
+
+	if err != nil {
+		task.Config.Node.State = model.MachineStateError
+	} else {
+		task.Config.Node.State = model.MachineStateActive
+	}
+	task.Config.NodeChan() <- task.Config.Node
+
+	return err
+}
+
+// upgradeMasters upgrades the bootstrap master in place, other masters are
+// upgraded after it at once.
+func (tp *TaskProvisioner) upgradeMasters(tasks []*workflows.Task) error {
+	bootstrapTask := tasks[0]
+	bootstrapTask.Config.IsBootstrap = true
+	fileName := util.MakeFileName(bootstrapTask.ID)
+	writer, err := tp.getWriter(fileName)
+
+	if err != nil {
+		return errors.Wrap(err, "error creating writer")
+	}
+
+	log.Infof("upgrade bootstrap node %v", bootstrapTask.Config.Node)
+	if err := tp.upgradeMachine(bootstrapTask, writer); err != nil {
+		log.Errorf("upgrade bootstrap node %s: %v", bootstrapTask.Config.Node.Name, err)
+	}
+
+	for _, masterTask := range tasks[1:] {
+		fileName := util.MakeFileName(masterTask.ID)
+		writer, err := tp.getWriter(fileName)
+
+		if err != nil {
+			return errors.Wrap(err, "error creating writer")
+		}
+
+		log.Infof("Upgrade master node %v", masterTask.Config.Node)
+		go tp.upgradeMachine(masterTask, writer)
+	}
+
+	return nil
+}
+
+func (tp *TaskProvisioner) upgradeMachine(task *workflows.Task, writer io.WriteCloser) error {
 	task.Config.Node.State = model.MachineStateUpgrading
 	task.Config.NodeChan() <- task.Config.Node
 
@@ -869,9 +1420,100 @@ func (tp *TaskProvisioner) upgradeMachine(task *workflows.Task, writer io.WriteC
 	if err != nil {
 		task.Config.Node.State = model.MachineStateError
 		task.Config.NodeChan() <- task.Config.Node
-		logrus.Errorf("task %s has finished with error %v", task.ID, err)
+		log.Errorf("task %s has finished with error %v", task.ID, err)
+		return err
 	}
 
 	task.Config.Node.State = model.MachineStateActive
 	task.Config.NodeChan() <- task.Config.Node
+	return nil
+}
+
+// upgradeNodes upgrades worker nodes one by one, other nodes are upgraded
+// when one of them fails and the first error is returned.
+func (tp *TaskProvisioner) upgradeNodes(tasks []*workflows.Task, config *steps.Config) error {
+	var first error
+	for _, nodeTask := range tasks {
+		fileName := util.MakeFileName(nodeTask.ID)
+		writer, err := tp.getWriter(fileName)
+
+		if err != nil {
+			log.Errorf("error creating writer %v", err)
+			return err
+		}
+
+		log.Infof("Upgrade worker node %v", nodeTask.Config.Node)
+		if err = tp.upgradeMachine(nodeTask, writer); err != nil && first == nil {
+			first = errors.Wrapf(err, "upgrade node %s", nodeTask.Config.Node.Name)
+		}
+		config.KubeStateChan() <- model.StateOperational
+		config.ConfigChan() <- config
+	}
+
+	return first
+}
+
+// canaryTasks splits upgrade tasks of worker nodes into canaries and the
+// rest. Canaries are nodes of the canary group, the node with the first
+// name is the canary when the group isn't set.
+func canaryTasks(tasks []*workflows.Task, canary profile.UpgradeCanary) ([]*workflows.Task, []*workflows.Task) {
+	if !canary.Enabled || len(tasks) == 0 {
+		return nil, tasks
+	}
+
+	if canary.NodeGroup == "" {
+		first := 0
+		for i, t := range tasks {
+			if t.Config.Node.Name < tasks[first].Config.Node.Name {
+				first = i
+			}
+		}
+
+		rest := make([]*workflows.Task, 0, len(tasks)-1)
+		rest = append(rest, tasks[:first]...)
+		return []*workflows.Task{tasks[first]}, append(rest, tasks[first+1:]...)
+	}
+
+	var canaries, rest []*workflows.Task
+	for _, t := range tasks {
+		if t.Config.Node.NodeGroup == canary.NodeGroup {
+			canaries = append(canaries, t)
+		} else {
+			rest = append(rest, t)
+		}
+	}
+	return canaries, rest
+}
+
+// verifyUpgrade runs checks of the canary settings on the master, the id
+// of the verification task is returned to find its logs.
+func (tp *TaskProvisioner) verifyUpgrade(ctx context.Context, config *steps.Config, master *model.Machine) (string, error) {
+	if master == nil {
+		return "", errors.Wrap(sgerrors.ErrNotFound, "master")
+	}
+	config.Node = *master
+	config.IsMaster = true
+
+	task, err := workflows.NewTask(config, workflows.UpgradeVerify, tp.repository)
+	if err != nil {
+		return "", errors.Wrap(err, "new task")
+	}
+
+	writer, err := tp.getWriter(util.MakeFileName(task.ID))
+	if err != nil {
+		return task.ID, errors.Wrap(err, "get writer")
+	}
+
+	return task.ID, <-task.Run(ctx, *config, writer)
+}
+
+// abortUpgrade stops the upgrade of the cluster before nodes that aren't
+// canaries are upgraded.
+func (tp *TaskProvisioner) abortUpgrade(ctx context.Context, k *model.Kube, nextVersion string, err error) {
+	log.Errorf("canary nodes of %s failed the upgrade to %s: %v", k.ID, nextVersion, err)
+	tp.recordEvent(ctx, &timeline.Event{
+		KubeID:  k.ID,
+		Type:    timeline.UpgradeCanaryFailed,
+		Message: fmt.Sprintf("upgrade to %s has been stopped, canary nodes have failed: %v", nextVersion, err),
+	})
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"io/ioutil"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -11,12 +12,17 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/supergiant/control/pkg/clouds"
 	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/pki"
 	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/queue"
 	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage/memory"
 	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/timeline"
 	"github.com/supergiant/control/pkg/workflows"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
@@ -113,6 +119,8 @@ func TestProvisionCluster(t *testing.T) {
 		},
 		NewRateLimiter(time.Nanosecond * 1),
 		make(map[string]func()),
+		nil,
+		nil,
 	}
 
 	workflows.Init()
@@ -175,6 +183,76 @@ func TestProvisionCluster(t *testing.T) {
 	}
 }
 
+func TestProvisionClusterQueued(t *testing.T) {
+	repository := memory.NewInMemoryRepository()
+	svc := &mockKubeService{
+		data: make(map[string]model.Kube),
+	}
+	tp := NewProvisioner(repository, svc, time.Nanosecond, "")
+	tp.getWriter = func(string) (io.WriteCloser, error) {
+		return &bufferCloser{ioutil.Discard, nil}, nil
+	}
+	q := queue.New(repository, 1)
+	tp.UseQueue(q, nil, nil)
+
+	workflows.Init()
+	for _, w := range []string{workflows.ProvisionMaster, workflows.ProvisionNode,
+		workflows.PostProvision, workflows.DigitalOceanInfra} {
+		workflows.RegisterWorkFlow(w, []steps.Step{&mockStep{}})
+	}
+
+	p := &profile.Profile{
+		Provider: clouds.DigitalOcean,
+		MasterProfiles: []profile.NodeProfile{
+			{"size": "s-1vcpu-2gb", "image": "ubuntu-18-04-x64"},
+		},
+		NodesProfiles: []profile.NodeProfile{
+			{"size": "s-2vcpu-4gb", "image": "ubuntu-18-04-x64"},
+		},
+	}
+	cfg, err := steps.NewConfig("test", "", *p)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err = tp.ProvisionCluster(ctx, p, cfg)
+	require.NoError(t, err)
+	kubeID := cfg.Kube.ID
+
+	// tasks wait for a worker
+	s, err := q.Stats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, s.Pending)
+	require.Equal(t, ProvisionJob, s.Jobs[0].Kind)
+
+	go q.Run(ctx)
+	deadline := time.Now().Add(time.Second * 5)
+	for {
+		// mock steps don't report masters, so the cluster fails
+		k, _ := svc.Get(ctx, kubeID)
+		s, err = q.Stats(ctx)
+		require.NoError(t, err)
+		if k.State == model.StateFailed && len(s.Jobs) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("cluster is %s, %d jobs are queued", k.State, len(s.Jobs))
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func TestRunProvisionJobDeletedKube(t *testing.T) {
+	tp := NewProvisioner(memory.NewInMemoryRepository(), &mockKubeService{
+		data:     make(map[string]model.Kube),
+		getError: sgerrors.ErrNotFound,
+	}, time.Nanosecond, "")
+	tp.UseQueue(queue.New(memory.NewInMemoryRepository(), 1), nil, nil)
+
+	// the job of a cluster that was queued by another process is dropped
+	require.NoError(t, tp.runProvisionJob(context.Background(), []byte(`{"kubeId":"deleted"}`)))
+}
+
 func TestProvisionNodes(t *testing.T) {
 	repository := &testutils.MockStorage{}
 	repository.On("Put", mock.Anything,
@@ -217,6 +295,8 @@ func TestProvisionNodes(t *testing.T) {
 		},
 		NewRateLimiter(time.Nanosecond * 1),
 		make(map[string]func()),
+		nil,
+		nil,
 	}
 
 	workflows.Init()
@@ -273,6 +353,143 @@ func TestProvisionNodes(t *testing.T) {
 
 }
 
+// bootstrapStep tracks how many nodes are bootstrapped at once, the node
+// of the failing task fails.
+type bootstrapStep struct {
+	m       sync.Mutex
+	running int
+	max     int
+	ran     int
+	failing string
+}
+
+func (s *bootstrapStep) Run(ctx context.Context, _ io.Writer, cfg *steps.Config) error {
+	s.m.Lock()
+	s.running++
+	s.ran++
+	if s.running > s.max {
+		s.max = s.running
+	}
+	s.m.Unlock()
+
+	defer func() {
+		s.m.Lock()
+		s.running--
+		s.m.Unlock()
+	}()
+
+	if cfg.TaskID == s.failing {
+		return errors.New("bootstrap failed")
+	}
+
+	select {
+	case <-time.After(time.Millisecond * 20):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *bootstrapStep) Name() string {
+	return "bootstrap"
+}
+
+func (s *bootstrapStep) Description() string {
+	return ""
+}
+
+func (s *bootstrapStep) Depends() []string {
+	return nil
+}
+
+func (s *bootstrapStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func TestProvisionNodesParallelism(t *testing.T) {
+	repository := &testutils.MockStorage{}
+	repository.On("Put", mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything).
+		Return(nil)
+	repository.On("Get", mock.Anything, mock.Anything,
+		mock.Anything).Return(nil, sgerrors.ErrNotFound)
+
+	provisioner := TaskProvisioner{
+		&mockKubeService{data: map[string]model.Kube{}},
+		repository,
+		func(string) (io.WriteCloser, error) {
+			return &bufferCloser{ioutil.Discard, nil}, nil
+		},
+		NewRateLimiter(time.Nanosecond * 1),
+		make(map[string]func()),
+		nil,
+		nil,
+	}
+
+	testCases := []struct {
+		description string
+		bootstrap   profile.NodeBootstrap
+		fail        bool
+		maxRunning  int
+		expectedErr bool
+	}{
+		{
+			description: "bounded",
+			bootstrap:   profile.NodeBootstrap{Parallelism: 2},
+			maxRunning:  2,
+		},
+		{
+			description: "best effort",
+			bootstrap:   profile.NodeBootstrap{Parallelism: 3},
+			fail:        true,
+			maxRunning:  3,
+		},
+		{
+			description: "fail fast",
+			bootstrap:   profile.NodeBootstrap{Parallelism: 1, FailurePolicy: profile.FailFast},
+			fail:        true,
+			maxRunning:  1,
+			expectedErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		step := &bootstrapStep{}
+		workflows.Init()
+		workflows.RegisterWorkFlow(workflows.ProvisionNode, []steps.Step{step})
+
+		p := &profile.Profile{
+			Provider:      clouds.DigitalOcean,
+			NodesProfiles: make([]profile.NodeProfile, 6),
+			NodeBootstrap: testCase.bootstrap,
+		}
+		rootConfig, err := steps.NewConfig("test", "", *p)
+		require.NoError(t, err)
+
+		tasks := make([]*workflows.Task, 0, len(p.NodesProfiles))
+		for range p.NodesProfiles {
+			cfg, err := steps.NewConfig("test", "", *p)
+			require.NoError(t, err)
+			task, err := workflows.NewTask(cfg, workflows.ProvisionNode, repository)
+			require.NoError(t, err)
+			tasks = append(tasks, task)
+		}
+		if testCase.fail {
+			step.failing = tasks[0].ID
+		}
+
+		err = provisioner.provisionNodes(context.Background(), p, rootConfig, tasks)
+		if testCase.expectedErr {
+			require.Error(t, err, testCase.description)
+			require.Equal(t, 1, step.ran, "%s: nodes after the failure must not run", testCase.description)
+		} else {
+			require.NoError(t, err, testCase.description)
+			require.Equal(t, len(tasks), step.ran, testCase.description)
+		}
+		require.True(t, step.max <= testCase.maxRunning, "%s: %d nodes ran at once", testCase.description, step.max)
+	}
+}
+
 func TestRestartProvisionClusterSuccess(t *testing.T) {
 	repository := &testutils.MockStorage{}
 	repository.On("Put", mock.Anything,
@@ -311,6 +528,8 @@ func TestRestartProvisionClusterSuccess(t *testing.T) {
 		},
 		NewRateLimiter(time.Nanosecond * 1),
 		make(map[string]func()),
+		nil,
+		nil,
 	}
 
 	workflows.Init()
@@ -384,6 +603,8 @@ func TestRestartProvisionClusterError(t *testing.T) {
 		},
 		NewRateLimiter(time.Nanosecond * 1),
 		make(map[string]func()),
+		nil,
+		nil,
 	}
 
 	workflows.Init()
@@ -724,3 +945,315 @@ func TestBuildInitialCluster(t *testing.T) {
 		}
 	}
 }
+
+func TestTaskProvisioner_clusterCA(t *testing.T) {
+	parent, err := pki.NewCAPair(nil)
+	if err != nil {
+		t.Fatalf("create parent CA: %v", err)
+	}
+
+	tp := &TaskProvisioner{
+		repository: memory.NewInMemoryRepository(),
+	}
+
+	config := &steps.Config{}
+	ca, err := tp.clusterCA(context.Background(), config, profile.ExternalCA{})
+	if err != nil || ca == nil {
+		t.Fatalf("self-signed CA: %v", err)
+	}
+	if config.Kube.Auth.ParentCert != "" {
+		t.Errorf("unexpected parent cert for self-signed CA")
+	}
+
+	ca, err = tp.clusterCA(context.Background(), config, profile.ExternalCA{
+		Cert: string(parent.Cert),
+		Key:  string(parent.Key),
+	})
+	if err != nil {
+		t.Fatalf("intermediate CA: %v", err)
+	}
+	if config.Kube.Auth.ParentCert != string(parent.Cert) {
+		t.Errorf("parent cert must be kept in the kube auth")
+	}
+	if string(ca.Key) == string(parent.Key) {
+		t.Errorf("parent key must not be used as cluster CA key")
+	}
+
+	_, err = tp.clusterCA(context.Background(), config, profile.ExternalCA{
+		CSRID:      "unknown",
+		SignedCert: string(parent.Cert),
+	})
+	if !sgerrors.IsNotFound(err) {
+		t.Errorf("expected not found error for unknown csr, actual %v", err)
+	}
+}
+
+type keyPairStep struct {
+	mockStep
+	err error
+}
+
+func (s *keyPairStep) Run(_ context.Context, _ io.Writer, cfg *steps.Config) error {
+	cfg.AWSConfig.KeyPairName = "rotated"
+	return s.err
+}
+
+func TestTaskProvisioner_RotateSSHKey(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		addErr      error
+		expectKey   string
+	}{
+		{
+			description: "success",
+			expectKey:   "new",
+		},
+		{
+			description: "old key is kept",
+			addErr:      errors.New("error"),
+			expectKey:   "old",
+		},
+	} {
+		t.Log(tc.description)
+
+		repository := memory.NewInMemoryRepository()
+		k := &model.Kube{
+			ID:        "1234",
+			Provider:  clouds.AWS,
+			SSHConfig: model.SSHConfig{BootstrapPrivateKey: "old"},
+			Masters: map[string]*model.Machine{
+				"master": {Name: "master", Role: model.RoleMaster},
+			},
+			Nodes: map[string]*model.Machine{
+				"node": {Name: "node", Role: model.RoleNode},
+			},
+			CloudSpec: map[string]string{
+				clouds.AwsKeyPairName:            "old",
+				clouds.AwsSshBootstrapPrivateKey: "old",
+			},
+		}
+		svc := &mockKubeService{data: map[string]model.Kube{k.ID: *k}}
+		tp := &TaskProvisioner{
+			kubeService: svc,
+			repository:  repository,
+			getWriter: func(string) (io.WriteCloser, error) {
+				return &bufferCloser{ioutil.Discard, nil}, nil
+			},
+		}
+
+		workflows.Init()
+		workflows.RegisterWorkFlow(workflows.SSHKeyAdd, []steps.Step{&keyPairStep{err: tc.addErr}})
+		workflows.RegisterWorkFlow(workflows.SSHKeyRemove, []steps.Step{&mockStep{}})
+		workflows.RegisterWorkFlow(workflows.SSHKeyPairImport, []steps.Step{&keyPairStep{}})
+
+		config, err := steps.NewConfigFromKube(&profile.Profile{}, k)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		config.SSHKeyRotationConfig = steps.SSHKeyRotationConfig{
+			PrivateKey: "new",
+			PublicKey:  "new",
+		}
+
+		tasks := func(workflow string, withCluster bool) map[string][]*workflows.Task {
+			result := map[string][]*workflows.Task{}
+			for taskSet, machine := range map[string]*model.Machine{
+				workflows.MasterTask: k.Masters["master"],
+				workflows.NodeTask:   k.Nodes["node"],
+			} {
+				task, _ := workflows.NewTask(config, workflow, repository)
+				cfg := *config
+				cfg.Node = *machine
+				task.Config = &cfg
+				result[taskSet] = []*workflows.Task{task}
+			}
+			if withCluster {
+				task, _ := workflows.NewTask(config, workflows.SSHKeyPairImport, repository)
+				result[workflows.ClusterTask] = []*workflows.Task{task}
+			}
+			return result
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		err = tp.RotateSSHKey(ctx, k, tasks(workflows.SSHKeyAdd, true),
+			tasks(workflows.SSHKeyRemove, false), config)
+		cancel()
+
+		if tc.addErr == nil && err != nil {
+			t.Errorf("unexpected error %v", err)
+		}
+		if tc.addErr != nil && err == nil {
+			t.Errorf("error expected")
+		}
+
+		saved, _ := svc.Get(context.Background(), k.ID)
+		if saved.SSHConfig.BootstrapPrivateKey != tc.expectKey {
+			t.Errorf("expected key %s actual %s", tc.expectKey, saved.SSHConfig.BootstrapPrivateKey)
+		}
+		if tc.addErr == nil && saved.CloudSpec[clouds.AwsKeyPairName] != "rotated" {
+			t.Errorf("key pair name must be updated, actual %s", saved.CloudSpec[clouds.AwsKeyPairName])
+		}
+		if saved.CloudSpec[clouds.AwsSshBootstrapPrivateKey] != tc.expectKey {
+			t.Errorf("expected aws key %s actual %s", tc.expectKey, saved.CloudSpec[clouds.AwsSshBootstrapPrivateKey])
+		}
+	}
+}
+
+func TestBootstrapWinRM(t *testing.T) {
+	windows := profile.NodeProfile{profile.NodeOSKey: model.OSWindows}
+
+	for _, tc := range []struct {
+		description string
+		profile     *profile.Profile
+		enabled     bool
+		errMsg      string
+	}{
+		{
+			description: "linux nodes",
+			profile: &profile.Profile{
+				Provider:      clouds.AWS,
+				NodesProfiles: []profile.NodeProfile{{}},
+			},
+		},
+		{
+			description: "windows nodes",
+			profile: &profile.Profile{
+				Provider:      clouds.AWS,
+				NodesProfiles: []profile.NodeProfile{{}, windows},
+			},
+			enabled: true,
+		},
+		{
+			description: "windows master",
+			profile: &profile.Profile{
+				Provider:       clouds.AWS,
+				MasterProfiles: []profile.NodeProfile{windows},
+			},
+			errMsg: "windows masters are not supported",
+		},
+		{
+			description: "unsupported provider",
+			profile: &profile.Profile{
+				Provider:      clouds.DigitalOcean,
+				NodesProfiles: []profile.NodeProfile{windows},
+			},
+			errMsg: "not supported on digitalocean",
+		},
+	} {
+		config := &steps.Config{}
+		err := bootstrapWinRM(tc.profile, config)
+
+		if tc.errMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.errMsg) {
+				t.Errorf("%s: error must contain %s, got %v", tc.description, tc.errMsg, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tc.description, err)
+			continue
+		}
+
+		if enabled := config.Kube.WinRMConfig.Password != ""; enabled != tc.enabled {
+			t.Errorf("%s: winrm enabled expected %v actual %v", tc.description, tc.enabled, enabled)
+		}
+	}
+}
+
+func TestProvisionNodesWindowsNotSupported(t *testing.T) {
+	provisioner := TaskProvisioner{
+		cancelMap: make(map[string]func()),
+	}
+
+	k := &model.Kube{
+		Masters: map[string]*model.Machine{
+			"1": {ID: "1"},
+		},
+	}
+
+	_, err := provisioner.ProvisionNodes(context.Background(),
+		[]profile.NodeProfile{{profile.NodeOSKey: model.OSWindows}}, k, &steps.Config{})
+	if err == nil || !strings.Contains(err.Error(), "without windows node support") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestTaskProvisioner_recordEvents(t *testing.T) {
+	events := timeline.NewService(timeline.DefaultStoragePrefix, memory.NewInMemoryRepository())
+	tp := &TaskProvisioner{timeline: events}
+	ctx := context.Background()
+	k := &model.Kube{ID: "kube", State: model.StateOperational}
+
+	tp.recordStateEvent(ctx, k, model.StateProvisioning)
+	k.State = model.StateProvisioning
+	tp.recordStateEvent(ctx, k, model.StateProvisioning)
+
+	tp.recordMachineEvent(ctx, k.ID, &model.Machine{State: model.MachineStateProvisioning},
+		model.Machine{Name: "node-1", Role: model.RoleNode, State: model.MachineStateActive})
+	tp.recordMachineEvent(ctx, k.ID, &model.Machine{State: model.MachineStateUpgrading},
+		model.Machine{Name: "node-2", Role: model.RoleNode, State: model.MachineStateActive})
+	tp.recordMachineEvent(ctx, k.ID, nil,
+		model.Machine{Name: "node-3", Role: model.RoleNode, State: model.MachineStateError})
+
+	tp.recordStateEvent(ctx, k, model.StateFailed)
+
+	recorded, err := events.List(ctx, k.ID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+
+	types := make([]timeline.Type, 0, len(recorded))
+	for _, e := range recorded {
+		types = append(types, e.Type)
+	}
+	require.ElementsMatch(t, []timeline.Type{timeline.ProvisionStarted, timeline.NodeAdded,
+		timeline.NodeFailed, timeline.ClusterFailed}, types)
+}
+
+func TestCanaryTasks(t *testing.T) {
+	task := func(name, group string) *workflows.Task {
+		return &workflows.Task{
+			ID:     name,
+			Config: &steps.Config{Node: model.Machine{Name: name, NodeGroup: group}},
+		}
+	}
+	tasks := []*workflows.Task{task("node-c", "gpu"), task("node-a", "default"), task("node-b", "gpu")}
+
+	testCases := []struct {
+		description string
+		canary      profile.UpgradeCanary
+		canaries    []string
+		rest        []string
+	}{
+		{
+			description: "disabled",
+			canary:      profile.UpgradeCanary{NodeGroup: "gpu"},
+			rest:        []string{"node-c", "node-a", "node-b"},
+		},
+		{
+			description: "first node",
+			canary:      profile.UpgradeCanary{Enabled: true},
+			canaries:    []string{"node-a"},
+			rest:        []string{"node-c", "node-b"},
+		},
+		{
+			description: "node group",
+			canary:      profile.UpgradeCanary{Enabled: true, NodeGroup: "gpu"},
+			canaries:    []string{"node-c", "node-b"},
+			rest:        []string{"node-a"},
+		},
+	}
+
+	ids := func(tasks []*workflows.Task) []string {
+		var out []string
+		for _, t := range tasks {
+			out = append(out, t.ID)
+		}
+		return out
+	}
+
+	for _, testCase := range testCases {
+		canaries, rest := canaryTasks(tasks, testCase.canary)
+		require.Equal(t, testCase.canaries, ids(canaries), testCase.description)
+		require.Equal(t, testCase.rest, ids(rest), testCase.description)
+	}
+}
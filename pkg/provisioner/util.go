@@ -1,9 +1,12 @@
 package provisioner
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -17,20 +20,47 @@ import (
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
+const windowsUser = "Administrator"
+
 type RateLimiter struct {
+	m      sync.RWMutex
 	bucket *time.Ticker
+	// reset is closed when the interval is changed
+	reset chan struct{}
 }
 
 func NewRateLimiter(interval time.Duration) *RateLimiter {
 	return &RateLimiter{
 		bucket: time.NewTicker(interval),
+		reset:  make(chan struct{}),
 	}
 }
 
 // Take either returns giving calling code ability to execute or blocks until
 // bucket is full again
 func (r *RateLimiter) Take() {
-	<-r.bucket.C
+	for {
+		r.m.RLock()
+		bucket, reset := r.bucket, r.reset
+		r.m.RUnlock()
+
+		select {
+		case <-bucket.C:
+			return
+		case <-reset:
+		}
+	}
+}
+
+// SetInterval changes the interval the bucket is filled with.
+func (r *RateLimiter) SetInterval(interval time.Duration) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.bucket.Stop()
+	r.bucket = time.NewTicker(interval)
+	close(r.reset)
+	r.reset = make(chan struct{})
 }
 
 // Fill cloud account specific data gets data from the map and puts to particular cloud provider config
@@ -39,19 +69,42 @@ func FillNodeCloudSpecificData(provider clouds.Name, nodeProfile profile.NodePro
 		config.IsMaster, _ = strconv.ParseBool(nodeProfile["isMaster"])
 	}
 
+	config.NodeOS = nodeProfile[profile.NodeOSKey]
+	// images are set for every node, so they don't leak to the next node
+	// provisioned with the config
+	config.NodeImage = nodeProfile[profile.NodeImageKey]
+	config.NodeImageFamily = nodeProfile[profile.NodeImageFamilyKey]
+	config.NodeSSHUser = nodeProfile[profile.SSHUserKey]
+	config.NodeSSHPort = nodeProfile[profile.SSHPortKey]
+	config.NodeGroup = nodeProfile[profile.NodeGroupKey]
+	params := make(map[string]string, len(nodeProfile))
+	for k, v := range nodeProfile {
+		if k != profile.NodeImageKey && k != profile.NodeImageFamilyKey {
+			params[k] = v
+		}
+	}
+
+	kubeletConfig, err := parseKubeletConfig(nodeProfile)
+	if err != nil {
+		return errors.Wrap(err, "parse kubelet settings")
+	}
+	config.KubeletConfig = kubeletConfig
+
 	switch provider {
 	case clouds.AWS:
-		return util.BindParams(nodeProfile, &config.AWSConfig)
+		return util.BindParams(params, &config.AWSConfig)
 	case clouds.GCE:
-		return util.BindParams(nodeProfile, &config.GCEConfig)
+		return util.BindParams(params, &config.GCEConfig)
 	case clouds.DigitalOcean:
-		return util.BindParams(nodeProfile, &config.DigitalOceanConfig)
+		return util.BindParams(params, &config.DigitalOceanConfig)
 	case clouds.Packet:
-		return util.BindParams(nodeProfile, &config.PacketConfig)
+		return util.BindParams(params, &config.PacketConfig)
 	case clouds.OpenStack:
-		return util.BindParams(nodeProfile, &config.OSConfig)
+		return util.BindParams(params, &config.OSConfig)
 	case clouds.Azure:
-		return util.BindParams(nodeProfile, &config.AzureConfig)
+		return util.BindParams(params, &config.AzureConfig)
+	case clouds.Fake:
+		return util.BindParams(params, &config.FakeConfig)
 	default:
 		return sgerrors.ErrUnknownProvider
 	}
@@ -59,6 +112,125 @@ func FillNodeCloudSpecificData(provider clouds.Name, nodeProfile profile.NodePro
 	return nil
 }
 
+// nodeWorkflow returns the provisioning workflow for the operating system of the node.
+func nodeWorkflow(nodeProfile profile.NodeProfile) string {
+	if nodeProfile[profile.NodeOSKey] == model.OSWindows {
+		return workflows.ProvisionWindowsNode
+	}
+
+	return workflows.ProvisionNode
+}
+
+func hasWindowsNodes(nodeProfiles []profile.NodeProfile) bool {
+	for _, p := range nodeProfiles {
+		if p[profile.NodeOSKey] == model.OSWindows {
+			return true
+		}
+	}
+
+	return false
+}
+
+// randomPassword satisfies the complexity requirements of windows passwords.
+func randomPassword() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return "Sg1-" + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func parseKubeletConfig(nodeProfile profile.NodeProfile) (steps.KubeletConfig, error) {
+	cfg := steps.KubeletConfig{}
+
+	for _, arg := range strings.Fields(nodeProfile[profile.KubeletArgsKey]) {
+		arg = strings.TrimLeft(arg, "-")
+		// boolean flags may omit a value
+		if !strings.Contains(arg, "=") {
+			arg += "=true"
+		}
+
+		key, value, err := splitPair(arg, "=")
+		if err != nil {
+			return cfg, errors.Wrapf(err, "kubelet flag %s", arg)
+		}
+		if cfg.ExtraArgs == nil {
+			cfg.ExtraArgs = make(map[string]string)
+		}
+		cfg.ExtraArgs[key] = value
+	}
+
+	for _, label := range splitList(nodeProfile[profile.NodeLabelsKey]) {
+		key, value, err := splitPair(label, "=")
+		if err != nil {
+			return cfg, errors.Wrapf(err, "node label %s", label)
+		}
+		if cfg.Labels == nil {
+			cfg.Labels = make(map[string]string)
+		}
+		cfg.Labels[key] = value
+	}
+
+	for _, taint := range splitList(nodeProfile[profile.NodeTaintsKey]) {
+		if err := validateTaint(taint); err != nil {
+			return cfg, err
+		}
+		cfg.Taints = append(cfg.Taints, taint)
+	}
+
+	return cfg, nil
+}
+
+// validateTaint checks a taint has the key=value:Effect or key:Effect form.
+func validateTaint(taint string) error {
+	i := strings.LastIndex(taint, ":")
+	if i < 1 {
+		return errors.Errorf("taint %s: effect is missing", taint)
+	}
+
+	switch taint[i+1:] {
+	case "NoSchedule", "PreferNoSchedule", "NoExecute":
+	default:
+		return errors.Errorf("taint %s: unknown effect %s", taint, taint[i+1:])
+	}
+
+	return checkUnsafe(taint)
+}
+
+func splitPair(s, sep string) (string, string, error) {
+	parts := strings.SplitN(s, sep, 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", errors.Errorf("%s is not a key%svalue pair", s, sep)
+	}
+
+	if err := checkUnsafe(s); err != nil {
+		return "", "", err
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// checkUnsafe rejects values that break the shell quoting of provisioning scripts.
+func checkUnsafe(s string) error {
+	if strings.ContainsAny(s, "\"'`$\\ ") {
+		return errors.Errorf("%s contains unsafe characters", s)
+	}
+
+	return nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+
+	return out
+}
+
 func MergeConfig(source *steps.Config, destination *steps.Config) error {
 	switch source.Provider {
 	case clouds.AWS:
@@ -113,6 +285,8 @@ func MergeConfig(source *steps.Config, destination *steps.Config) error {
 		if err != nil {
 			return errors.Wrapf(err, "Merge config")
 		}
+	case clouds.Fake:
+		destination.FakeConfig = source.FakeConfig
 	default:
 		return sgerrors.ErrUnknownProvider
 	}
@@ -131,55 +305,100 @@ func MergeConfig(source *steps.Config, destination *steps.Config) error {
 	destination.Kube.BootstrapToken = source.Kube.BootstrapToken
 	destination.IsBootstrap = source.IsBootstrap
 	destination.Kube.K8SVersion = source.Kube.K8SVersion
+	// credentials of DNS providers aren't stored with tasks
+	destination.DNSConfig = source.DNSConfig
 
 	return nil
 }
 
-func nodesFromProfile(clusterName string, masterTasks, nodeTasks []*workflows.Task, profile *profile.Profile) (map[string]*model.Machine, map[string]*model.Machine) {
+func nodesFromProfile(k *model.Kube, masterTasks, nodeTasks []*workflows.Task, profile *profile.Profile) (map[string]*model.Machine, map[string]*model.Machine, error) {
 	masters := make(map[string]*model.Machine)
 	nodes := make(map[string]*model.Machine)
 
 	for index, p := range profile.MasterProfiles {
-		taskId := masterTasks[index].ID
-		name := util.MakeNodeName(clusterName, taskId, true)
-
-		// TODO(stgleb): check if we can lowercase node names for all nodes
-		if profile.Provider == clouds.GCE {
-			name = strings.ToLower(name)
-		}
-		n := &model.Machine{
-			TaskID:   taskId,
-			Name:     name,
-			Provider: profile.Provider,
-			Region:   profile.Region,
-			State:    model.MachineStatePlanned,
+		n, err := plannedMachine(k, profile, p, masterTasks[index].ID, true, index)
+		if err != nil {
+			return nil, nil, err
 		}
-
-		util.BindParams(p, n)
 		masters[n.Name] = n
 	}
 
 	for index, p := range profile.NodesProfiles {
-		taskId := nodeTasks[index].ID
-		name := util.MakeNodeName(clusterName, taskId[:4], false)
-
-		// TODO(stgleb): check if we can lowercase node names for all nodes
-		if profile.Provider == clouds.GCE {
-			name = strings.ToLower(name)
+		n, err := plannedMachine(k, profile, p, nodeTasks[index].ID, false, index)
+		if err != nil {
+			return nil, nil, err
 		}
-		n := &model.Machine{
-			TaskID:   taskId,
-			Name:     name,
-			Provider: profile.Provider,
-			Region:   profile.Region,
-			State:    model.MachineStatePlanned,
+		nodes[n.Name] = n
+	}
+
+	return masters, nodes, nil
+}
+
+// plannedMachine returns the machine the task creates, it's named the same
+// way the create instance step of the provider names it.
+func plannedMachine(k *model.Kube, clusterProfile *profile.Profile, p profile.NodeProfile, taskId string, isMaster bool, index int) (*model.Machine, error) {
+	cfg := &steps.Config{
+		Kube: model.Kube{
+			ID:     k.ID,
+			Name:   k.Name,
+			Region: clusterProfile.Region,
+			Naming: clusterProfile.Naming,
+		},
+		TaskID:    taskId,
+		IsMaster:  isMaster,
+		NodeGroup: p[profile.NodeGroupKey],
+		NodeIndex: index,
+	}
+	name, err := cfg.InstanceName()
+	if err != nil {
+		return nil, errors.Wrapf(err, "name machine of task %s", taskId)
+	}
+
+	// TODO(stgleb): check if we can lowercase node names for all nodes
+	if clusterProfile.Provider == clouds.GCE {
+		name = strings.ToLower(name)
+	}
+	n := &model.Machine{
+		TaskID:   taskId,
+		Name:     name,
+		Provider: clusterProfile.Provider,
+		Region:   clusterProfile.Region,
+		State:    model.MachineStatePlanned,
+	}
+
+	util.BindParams(p, n)
+	return n, nil
+}
+
+// machineNames returns lowercased names of machines of the kube.
+func machineNames(k *model.Kube) map[string]bool {
+	names := make(map[string]bool, len(k.Masters)+len(k.Nodes))
+	for _, machines := range []map[string]*model.Machine{k.Masters, k.Nodes} {
+		for _, m := range machines {
+			names[strings.ToLower(m.Name)] = true
 		}
+	}
+	return names
+}
 
-		util.BindParams(p, n)
-		nodes[n.Name] = n
+// nextNodeIndex moves the index of the node being added past indexes that
+// name it after machines of the kube, e.g. when nodes were deleted, and
+// takes the name.
+func nextNodeIndex(config *steps.Config, taken map[string]bool) error {
+	for tries := 0; tries <= len(taken); tries++ {
+		name, err := config.InstanceName()
+		if err != nil {
+			return err
+		}
+		name = strings.ToLower(name)
+		if !taken[name] {
+			taken[name] = true
+			return nil
+		}
+		config.NodeIndex++
 	}
 
-	return masters, nodes
+	return errors.Wrapf(sgerrors.ErrAlreadyExists, "machine names of task %s", config.TaskID)
 }
 
 func grabTaskIds(taskMap map[string][]*workflows.Task) map[string][]string {
@@ -1,7 +1,9 @@
 package provisioner
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/supergiant/control/pkg/clouds"
 	"github.com/supergiant/control/pkg/model"
@@ -41,7 +43,10 @@ func TestNodesFromProfile(t *testing.T) {
 	}
 
 	masterTasks, nodeTasks := []*workflows.Task{{ID: "1234"}}, []*workflows.Task{{ID: "5678"}, {ID: "4321"}}
-	masters, nodes := nodesFromProfile(cfg.Kube.ID, masterTasks, nodeTasks, p)
+	masters, nodes, err := nodesFromProfile(&cfg.Kube, masterTasks, nodeTasks, p)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
 
 	if len(masters) != len(p.MasterProfiles) {
 		t.Errorf("Wrong master node count expected %d actual %d",
@@ -54,6 +59,72 @@ func TestNodesFromProfile(t *testing.T) {
 	}
 }
 
+func TestNodesFromProfileNaming(t *testing.T) {
+	p := &profile.Profile{
+		Provider:       clouds.GCE,
+		Region:         "europe-west1",
+		MasterProfiles: []profile.NodeProfile{{}},
+		NodesProfiles:  []profile.NodeProfile{{profile.NodeGroupKey: "GPU"}, {}},
+		Naming: profile.Naming{
+			Instance: "{{.Region}}-{{.ClusterName}}-{{.NodeGroup}}-{{.Index}}",
+		},
+	}
+	k := &model.Kube{ID: "a1b2c3d4", Name: "prod"}
+
+	masterTasks, nodeTasks := []*workflows.Task{{ID: "1234"}}, []*workflows.Task{{ID: "5678"}, {ID: "4321"}}
+	masters, nodes, err := nodesFromProfile(k, masterTasks, nodeTasks, p)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, name := range []string{"europe-west1-prod-master-0"} {
+		if masters[name] == nil {
+			t.Errorf("master %s not found in %v", name, masters)
+		}
+	}
+	for _, name := range []string{"europe-west1-prod-gpu-0", "europe-west1-prod-node-1"} {
+		if nodes[name] == nil {
+			t.Errorf("node %s not found in %v", name, nodes)
+		}
+	}
+
+	p.Naming.Instance = "{{.Zone}}"
+	if _, _, err = nodesFromProfile(k, masterTasks, nodeTasks, p); err == nil {
+		t.Error("error expected for an unknown variable")
+	}
+}
+
+func TestNextNodeIndex(t *testing.T) {
+	k := &model.Kube{
+		Name: "prod",
+		Nodes: map[string]*model.Machine{
+			"1": {Name: "prod-node-1"},
+			"2": {Name: "prod-node-2"},
+		},
+		Naming: profile.Naming{Instance: "{{.ClusterName}}-{{.Role}}-{{.Index}}"},
+	}
+	config := &steps.Config{Kube: *k, TaskID: "abcd", NodeIndex: len(k.Nodes)}
+	taken := machineNames(k)
+
+	// node 0 was deleted, new nodes don't take names of remaining ones
+	for _, expected := range []int{3, 4} {
+		if err := nextNodeIndex(config, taken); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if config.NodeIndex != expected {
+			t.Errorf("wrong index expected %d actual %d", expected, config.NodeIndex)
+		}
+		config.NodeIndex++
+	}
+
+	// names that don't depend on the index can't be moved
+	config.Kube.Naming.Instance = "{{.ClusterName}}-{{.Role}}-{{.ID}}"
+	taken["prod-node-abcd"] = true
+	if err := nextNodeIndex(config, taken); err == nil {
+		t.Error("error expected for a taken name")
+	}
+}
+
 func TestGrabTaskIds(t *testing.T) {
 	clusterTsk := &workflows.Task{
 		ID: "1234",
@@ -88,3 +159,138 @@ func TestGrabTaskIds(t *testing.T) {
 			len(masterTasks)+len(nodeTasks)+1, len(taskIds))
 	}
 }
+
+func TestParseKubeletConfig(t *testing.T) {
+	testCases := []struct {
+		description string
+		nodeProfile profile.NodeProfile
+		expected    steps.KubeletConfig
+		expectedErr bool
+	}{
+		{
+			description: "empty",
+			nodeProfile: profile.NodeProfile{},
+		},
+		{
+			description: "all settings",
+			nodeProfile: profile.NodeProfile{
+				profile.KubeletArgsKey: "--eviction-hard=memory.available<500Mi,nodefs.available<10% --rotate-server-certificates",
+				profile.NodeLabelsKey:  "disktype=ssd, gpu=true",
+				profile.NodeTaintsKey:  "nvidia.com/gpu=true:NoSchedule,dedicated:NoExecute",
+			},
+			expected: steps.KubeletConfig{
+				ExtraArgs: map[string]string{
+					"eviction-hard":              "memory.available<500Mi,nodefs.available<10%",
+					"rotate-server-certificates": "true",
+				},
+				Labels: map[string]string{
+					"disktype": "ssd",
+					"gpu":      "true",
+				},
+				Taints: []string{"nvidia.com/gpu=true:NoSchedule", "dedicated:NoExecute"},
+			},
+		},
+		{
+			description: "invalid label",
+			nodeProfile: profile.NodeProfile{
+				profile.NodeLabelsKey: "disktype",
+			},
+			expectedErr: true,
+		},
+		{
+			description: "unknown taint effect",
+			nodeProfile: profile.NodeProfile{
+				profile.NodeTaintsKey: "gpu=true:Never",
+			},
+			expectedErr: true,
+		},
+		{
+			description: "unsafe value",
+			nodeProfile: profile.NodeProfile{
+				profile.KubeletArgsKey: "--node-ip=$(hostname)",
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase.description)
+		cfg, err := parseKubeletConfig(testCase.nodeProfile)
+
+		if testCase.expectedErr {
+			if err == nil {
+				t.Errorf("error must not be nil")
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("unexpected error %v", err)
+			continue
+		}
+
+		if !reflect.DeepEqual(cfg, testCase.expected) {
+			t.Errorf("wrong kubelet config expected %+v actual %+v", testCase.expected, cfg)
+		}
+	}
+}
+
+func TestNodeWorkflow(t *testing.T) {
+	if w := nodeWorkflow(profile.NodeProfile{"size": "t2.medium"}); w != workflows.ProvisionNode {
+		t.Errorf("wrong workflow %s for linux node", w)
+	}
+
+	windowsProfile := profile.NodeProfile{profile.NodeOSKey: model.OSWindows}
+	if w := nodeWorkflow(windowsProfile); w != workflows.ProvisionWindowsNode {
+		t.Errorf("wrong workflow %s for windows node", w)
+	}
+
+	config := &steps.Config{}
+	if err := FillNodeCloudSpecificData(clouds.AWS, windowsProfile, config); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if config.NodeOS != model.OSWindows {
+		t.Errorf("wrong node os %s", config.NodeOS)
+	}
+}
+
+func TestFillNodeImage(t *testing.T) {
+	config := &steps.Config{AWSConfig: steps.AWSConfig{ImageID: "ami-cluster"}}
+	if err := FillNodeCloudSpecificData(clouds.AWS, profile.NodeProfile{
+		profile.NodeImageKey: "ami-1234",
+		"size":               "m4.large",
+	}, config); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if config.NodeImage != "ami-1234" || config.AWSConfig.InstanceType != "m4.large" {
+		t.Errorf("wrong image %s size %s", config.NodeImage, config.AWSConfig.InstanceType)
+	}
+
+	// images of a node don't leak to the next one
+	if err := FillNodeCloudSpecificData(clouds.AWS, profile.NodeProfile{
+		profile.NodeImageFamilyKey: "ubuntu-22.04-lts",
+	}, config); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if config.NodeImage != "" || config.NodeImageFamily != "ubuntu-22.04-lts" || config.AWSConfig.ImageID != "ami-cluster" {
+		t.Errorf("wrong image %s family %s cluster image %s",
+			config.NodeImage, config.NodeImageFamily, config.AWSConfig.ImageID)
+	}
+}
+
+func TestRateLimiterSetInterval(t *testing.T) {
+	r := NewRateLimiter(time.Hour)
+
+	done := make(chan struct{})
+	go func() {
+		r.Take()
+		close(done)
+	}()
+
+	r.SetInterval(time.Millisecond)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("take is blocked by the previous interval")
+	}
+}
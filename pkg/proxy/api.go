@@ -0,0 +1,172 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/rest"
+)
+
+// APIProxy forwards requests to the kubernetes API server. Connection upgrades
+// used by exec, attach and port-forward are passed through as is, so SPDY and
+// WebSocket streams work the same way as with a direct connection.
+type APIProxy struct {
+	target   *url.URL
+	proxy    *httputil.ReverseProxy
+	upgrades http.RoundTripper
+	logger   logrus.FieldLogger
+}
+
+func NewAPIProxy(cfg *rest.Config, logger logrus.FieldLogger) (*APIProxy, error) {
+	target, err := url.Parse(cfg.Host)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse api server address")
+	}
+
+	tr, err := rest.TransportFor(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "build transport")
+	}
+
+	upgrades, err := upgradeTransport(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "build upgrade transport")
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = tr
+	// watches and logs -f are streamed
+	proxy.FlushInterval = -1
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = target.Host
+	}
+
+	return &APIProxy{
+		target:   target,
+		proxy:    proxy,
+		upgrades: upgrades,
+		logger:   logger,
+	}, nil
+}
+
+// ServeHTTP sends the request to the path of the request URL on the API server.
+func (p *APIProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// credentials of control users must not reach the cluster
+	r.Header.Del("Authorization")
+	for h := range r.Header {
+		if strings.HasPrefix(h, "Impersonate-") {
+			r.Header.Del(h)
+		}
+	}
+	if q := r.URL.Query(); q.Get("token") != "" {
+		q.Del("token")
+		r.URL.RawQuery = q.Encode()
+	}
+
+	// the content type is set by the api middleware, it comes from the cluster here
+	w.Header().Del("Content-Type")
+
+	if httpstream.IsUpgradeRequest(r) {
+		p.serveUpgrade(w, r)
+		return
+	}
+
+	p.proxy.ServeHTTP(w, r)
+}
+
+func (p *APIProxy) serveUpgrade(w http.ResponseWriter, r *http.Request) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "connection upgrade is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	out := r.WithContext(r.Context())
+	out.URL = &url.URL{
+		Scheme:   p.target.Scheme,
+		Host:     p.target.Host,
+		Path:     strings.TrimSuffix(p.target.Path, "/") + r.URL.Path,
+		RawQuery: r.URL.RawQuery,
+	}
+	out.Host = p.target.Host
+	out.RequestURI = ""
+
+	resp, err := p.upgrades.RoundTrip(out)
+	if err != nil {
+		p.logger.Errorf("upgrade %s: %v", r.URL.Path, err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		defer resp.Body.Close()
+		for k, v := range resp.Header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+		return
+	}
+
+	backend, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		http.Error(w, "api server connection can't be upgraded", http.StatusBadGateway)
+		return
+	}
+	defer backend.Close()
+
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		p.logger.Errorf("upgrade %s: hijack connection: %v", r.URL.Path, err)
+		return
+	}
+	defer conn.Close()
+
+	// server timeouts are meant for api calls, not for interactive sessions
+	conn.SetDeadline(time.Time{})
+
+	resp.Body = nil
+	if err := resp.Write(conn); err != nil {
+		p.logger.Errorf("upgrade %s: write response: %v", r.URL.Path, err)
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(backend, buf.Reader)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(conn, backend)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// upgradeTransport talks HTTP/1.1 to the API server since connections
+// can't be upgraded over HTTP/2.
+func upgradeTransport(cfg *rest.Config) (http.RoundTripper, error) {
+	tlsConfig, err := rest.TLSConfigFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		tlsConfig.NextProtos = []string{"http/1.1"}
+	}
+
+	return rest.HTTPWrappersForConfig(cfg, &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		TLSClientConfig:     tlsConfig,
+		TLSHandshakeTimeout: 10 * time.Second,
+	})
+}
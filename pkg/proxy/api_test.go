@@ -0,0 +1,105 @@
+package proxy
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+)
+
+func newAPIServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			http.Error(w, "control token must not be forwarded", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("X-Impersonate-User", r.Header.Get("Impersonate-User"))
+
+		if r.URL.Path != "/api/v1/namespaces/default/pods/app/exec" {
+			w.Header().Set("Content-Type", "text/plain")
+			io.WriteString(w, r.URL.Path+"?"+r.URL.RawQuery)
+			return
+		}
+
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		io.WriteString(conn, "HTTP/1.1 101 Switching Protocols\r\n"+
+			"Connection: Upgrade\r\nUpgrade: SPDY/3.1\r\n\r\n")
+		line, _ := buf.ReadString('\n')
+		io.WriteString(conn, "echo: "+line)
+	}))
+}
+
+func newFrontend(t *testing.T, api *httptest.Server) *httptest.Server {
+	p, err := NewAPIProxy(&rest.Config{
+		Host: api.URL,
+		Impersonate: rest.ImpersonationConfig{
+			UserName: "alice",
+		},
+	}, logrus.New())
+	require.NoError(t, err)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		p.ServeHTTP(w, r)
+	}))
+}
+
+func TestAPIProxy_Request(t *testing.T) {
+	api := newAPIServer(t)
+	defer api.Close()
+	frontend := newFrontend(t, api)
+	defer frontend.Close()
+
+	req, err := http.NewRequest(http.MethodGet, frontend.URL+"/api/v1/pods?token=secret&watch=1", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Impersonate-User", "admin")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	require.Equal(t, "/api/v1/pods?watch=1", string(body))
+	require.Equal(t, "alice", resp.Header.Get("X-Impersonate-User"))
+	require.Equal(t, []string{"text/plain"}, resp.Header["Content-Type"])
+}
+
+func TestAPIProxy_Upgrade(t *testing.T) {
+	api := newAPIServer(t)
+	defer api.Close()
+	frontend := newFrontend(t, api)
+	defer frontend.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(frontend.URL, "http://"))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	io.WriteString(conn, "POST /api/v1/namespaces/default/pods/app/exec?command=sh HTTP/1.1\r\n"+
+		"Host: control\r\nAuthorization: Bearer secret\r\n"+
+		"Connection: Upgrade\r\nUpgrade: SPDY/3.1\r\n\r\n")
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	require.Equal(t, "SPDY/3.1", resp.Header.Get("Upgrade"))
+
+	io.WriteString(conn, "ls\n")
+	line, err := r.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "echo: ls\n", line)
+}
@@ -0,0 +1,36 @@
+package queue
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/message"
+)
+
+type Handler struct {
+	queue *Queue
+}
+
+func NewHandler(q *Queue) *Handler {
+	return &Handler{queue: q}
+}
+
+func (h *Handler) Register(r *mux.Router) {
+	r.HandleFunc("/admin/queue", api.AdminOnly(h.Get)).Methods(http.MethodGet)
+}
+
+// Get returns jobs of the queue with counts by their states.
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	s, err := h.queue.Stats(r.Context())
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(s); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
@@ -0,0 +1,378 @@
+// Package queue runs jobs kept in the repository by a pool of workers, jobs
+// outlive requests that enqueue them and are retried after crashes. Bursts of
+// jobs wait in the queue until workers are free.
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+
+	"github.com/supergiant/control/pkg/storage"
+)
+
+const (
+	DefaultStoragePrefix = "/supergiant/queue/"
+	DefaultWorkers       = 4
+	DefaultMaxAttempts   = 3
+
+	pollInterval = time.Second * 10
+	// retryBackoff is multiplied by the number of failed attempts
+	retryBackoff = time.Minute
+)
+
+type State string
+
+const (
+	StatePending State = "pending"
+	StateRunning State = "running"
+	// StateFailed jobs have run out of attempts, they are kept for inspection.
+	StateFailed State = "failed"
+)
+
+var (
+	// MeasureDepth is the number of jobs waiting for workers.
+	MeasureDepth = stats.Int64("supergiant/queue/depth", "Number of jobs waiting for workers", stats.UnitDimensionless)
+
+	DepthView = &view.View{
+		Name:        "supergiant/queue/depth",
+		Description: "Number of jobs waiting for workers",
+		Measure:     MeasureDepth,
+		Aggregation: view.LastValue(),
+	}
+)
+
+// Job is a stored unit of work, the payload is decoded by the handler of
+// its kind.
+type Job struct {
+	ID        string          `json:"id"`
+	Kind      string          `json:"kind"`
+	Payload   json.RawMessage `json:"payload"`
+	State     State           `json:"state"`
+	Attempts  int             `json:"attempts"`
+	Error     string          `json:"error,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+	// NotBefore delays the retry of a failed attempt.
+	NotBefore time.Time `json:"notBefore,omitempty"`
+}
+
+// Stats summarize jobs of the queue.
+type Stats struct {
+	Pending int   `json:"pending"`
+	Running int   `json:"running"`
+	Failed  int   `json:"failed"`
+	Jobs    []Job `json:"jobs"`
+}
+
+// HandlerFunc runs jobs of a kind, jobs are retried when it fails.
+type HandlerFunc func(ctx context.Context, payload []byte) error
+
+// Queue dispatches stored jobs to workers in the order they are enqueued.
+type Queue struct {
+	repo        storage.Interface
+	prefix      string
+	workers     int
+	maxAttempts int
+
+	m        sync.RWMutex
+	handlers map[string]HandlerFunc
+
+	// hold stops dispatching while it returns true
+	hold func() bool
+	// wake starts dispatching without waiting for the poll interval
+	wake chan struct{}
+	now  func() time.Time
+}
+
+// New returns a queue that runs jobs by the number of workers at once.
+func New(repo storage.Interface, workers int) *Queue {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	if err := view.Register(DepthView); err != nil {
+		logrus.Errorf("queue: register depth view: %v", err)
+	}
+
+	return &Queue{
+		repo:        repo,
+		prefix:      DefaultStoragePrefix,
+		workers:     workers,
+		maxAttempts: DefaultMaxAttempts,
+		handlers:    make(map[string]HandlerFunc),
+		wake:        make(chan struct{}, 1),
+		now:         time.Now,
+	}
+}
+
+// Handle sets the handler of jobs of the kind.
+func (q *Queue) Handle(kind string, fn HandlerFunc) {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	q.handlers[kind] = fn
+}
+
+func (q *Queue) handler(kind string) HandlerFunc {
+	q.m.RLock()
+	defer q.m.RUnlock()
+
+	return q.handlers[kind]
+}
+
+// HoldWhile stops dispatching jobs while hold returns true, e.g. on shutdown,
+// held jobs stay in the queue for the next process.
+func (q *Queue) HoldWhile(hold func() bool) {
+	q.hold = hold
+}
+
+func (q *Queue) held() bool {
+	return q.hold != nil && q.hold()
+}
+
+// Enqueue stores a job with the payload, it runs once a worker is free.
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload interface{}) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal payload")
+	}
+
+	now := q.now()
+	job := &Job{
+		ID:        uuid.New(),
+		Kind:      kind,
+		Payload:   data,
+		State:     StatePending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err = q.put(ctx, job); err != nil {
+		return nil, err
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	q.recordDepth(ctx)
+
+	return job, nil
+}
+
+// Jobs returns stored jobs in the order they are enqueued.
+func (q *Queue) Jobs(ctx context.Context) ([]Job, error) {
+	values, err := q.repo.GetAll(ctx, q.prefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "list jobs")
+	}
+
+	jobs := make([]Job, 0, len(values))
+	for _, v := range values {
+		if len(v) == 0 {
+			continue
+		}
+		job := Job{}
+		if err = json.Unmarshal(v, &job); err != nil {
+			logrus.Warnf("queue: skip malformed job: %v", err)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].CreatedAt.Equal(jobs[j].CreatedAt) {
+			return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+
+	return jobs, nil
+}
+
+// Stats counts jobs by their states.
+func (q *Queue) Stats(ctx context.Context) (*Stats, error) {
+	jobs, err := q.Jobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Stats{Jobs: jobs}
+	for _, job := range jobs {
+		switch job.State {
+		case StatePending:
+			s.Pending++
+		case StateRunning:
+			s.Running++
+		case StateFailed:
+			s.Failed++
+		}
+	}
+	return s, nil
+}
+
+// Run dispatches jobs to workers until the context is done, jobs that were
+// running when the previous process stopped are run again.
+func (q *Queue) Run(ctx context.Context) {
+	if err := q.recover(ctx); err != nil {
+		logrus.Errorf("queue: recover running jobs: %v", err)
+	}
+
+	jobs := make(chan *Job)
+	wg := sync.WaitGroup{}
+	for i := 0; i < q.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				q.work(ctx, job)
+			}
+		}()
+	}
+	defer func() {
+		close(jobs)
+		wg.Wait()
+	}()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		q.dispatch(ctx, jobs)
+
+		select {
+		case <-ticker.C:
+		case <-q.wake:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// recover returns jobs interrupted by a crash to the queue, the interrupted
+// attempt is counted.
+func (q *Queue) recover(ctx context.Context) error {
+	jobs, err := q.Jobs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+		if job.State != StateRunning {
+			continue
+		}
+
+		logrus.Infof("queue: job %s of %s has been interrupted, retry it", job.ID, job.Kind)
+		job.State = StatePending
+		job.UpdatedAt = q.now()
+		if err = q.put(ctx, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dispatch hands pending jobs to workers, it blocks while all workers are busy.
+func (q *Queue) dispatch(ctx context.Context, workers chan<- *Job) {
+	jobs, err := q.Jobs(ctx)
+	if err != nil {
+		logrus.Errorf("queue: %v", err)
+		return
+	}
+	q.record(ctx, jobs)
+
+	for i := range jobs {
+		job := &jobs[i]
+		if job.State != StatePending || job.NotBefore.After(q.now()) {
+			continue
+		}
+		if q.held() {
+			return
+		}
+
+		// the job is marked running before it's taken by a worker, so
+		// it isn't dispatched twice
+		job.State = StateRunning
+		job.Attempts++
+		job.UpdatedAt = q.now()
+		if err = q.put(ctx, job); err != nil {
+			logrus.Errorf("queue: %v", err)
+			return
+		}
+
+		select {
+		case workers <- job:
+		case <-ctx.Done():
+			job.State = StatePending
+			job.Attempts--
+			if err = q.put(context.Background(), job); err != nil {
+				logrus.Errorf("queue: %v", err)
+			}
+			return
+		}
+	}
+}
+
+func (q *Queue) work(ctx context.Context, job *Job) {
+	err := errors.Errorf("no handler of %s jobs", job.Kind)
+	if fn := q.handler(job.Kind); fn != nil {
+		err = fn(ctx, job.Payload)
+	}
+	defer q.recordDepth(context.Background())
+
+	if err == nil {
+		if err = q.repo.Delete(context.Background(), q.prefix, job.ID); err != nil {
+			logrus.Errorf("queue: delete job %s: %v", job.ID, err)
+		}
+		return
+	}
+
+	job.Error = err.Error()
+	job.UpdatedAt = q.now()
+	if job.Attempts >= q.maxAttempts {
+		logrus.Errorf("queue: job %s of %s has failed after %d attempts: %v", job.ID, job.Kind, job.Attempts, err)
+		job.State = StateFailed
+	} else {
+		logrus.Warnf("queue: attempt %d of job %s of %s has failed: %v", job.Attempts, job.ID, job.Kind, err)
+		job.State = StatePending
+		job.NotBefore = job.UpdatedAt.Add(retryBackoff * time.Duration(job.Attempts))
+	}
+
+	if err = q.put(context.Background(), job); err != nil {
+		logrus.Errorf("queue: %v", err)
+	}
+}
+
+func (q *Queue) recordDepth(ctx context.Context) {
+	jobs, err := q.Jobs(ctx)
+	if err != nil {
+		logrus.Errorf("queue: %v", err)
+		return
+	}
+	q.record(ctx, jobs)
+}
+
+func (q *Queue) record(ctx context.Context, jobs []Job) {
+	depth := 0
+	for _, job := range jobs {
+		if job.State == StatePending {
+			depth++
+		}
+	}
+	stats.Record(ctx, MeasureDepth.M(int64(depth)))
+}
+
+func (q *Queue) put(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return errors.Wrapf(err, "marshal job %s", job.ID)
+	}
+	return errors.Wrapf(q.repo.Put(ctx, q.prefix, job.ID, data), "put job %s", job.ID)
+}
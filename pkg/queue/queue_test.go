@@ -0,0 +1,160 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"github.com/supergiant/control/pkg/storage/memory"
+)
+
+func depth(t *testing.T) int64 {
+	rows, err := view.RetrieveData(DepthView.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	return int64(rows[0].Data.(*view.LastValueData).Value)
+}
+
+func TestQueue_Run(t *testing.T) {
+	q := New(memory.NewInMemoryRepository(), 2)
+	ctx := context.Background()
+
+	done := make(chan string, 3)
+	q.Handle("echo", func(ctx context.Context, payload []byte) error {
+		done <- string(payload)
+		return nil
+	})
+	q.Handle("flaky", func(ctx context.Context, payload []byte) error {
+		done <- "flaky"
+		return errors.New("unavailable")
+	})
+
+	for _, name := range []string{"a", "b"} {
+		_, err := q.Enqueue(ctx, "echo", name)
+		require.NoError(t, err)
+	}
+	flaky, err := q.Enqueue(ctx, "flaky", nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), depth(t))
+
+	runCtx, cancel := context.WithCancel(ctx)
+	stopped := make(chan struct{})
+	go func() {
+		q.Run(runCtx)
+		close(stopped)
+	}()
+
+	received := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		select {
+		case payload := <-done:
+			received[payload] = true
+		case <-time.After(time.Second * 5):
+			t.Fatal("jobs haven't run")
+		}
+	}
+	require.Equal(t, map[string]bool{`"a"`: true, `"b"`: true, "flaky": true}, received)
+
+	cancel()
+	<-stopped
+
+	// succeeded jobs are removed, the failed one waits for a retry
+	jobs, err := q.Jobs(ctx)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	require.Equal(t, flaky.ID, jobs[0].ID)
+	require.Equal(t, StatePending, jobs[0].State)
+	require.Equal(t, 1, jobs[0].Attempts)
+	require.Equal(t, "unavailable", jobs[0].Error)
+	require.True(t, jobs[0].NotBefore.After(time.Now()))
+	q.recordDepth(ctx)
+	require.Equal(t, int64(1), depth(t))
+}
+
+func TestQueue_work(t *testing.T) {
+	q := New(memory.NewInMemoryRepository(), 1)
+	ctx := context.Background()
+	q.Handle("flaky", func(ctx context.Context, payload []byte) error {
+		return errors.New("unavailable")
+	})
+
+	job, err := q.Enqueue(ctx, "flaky", nil)
+	require.NoError(t, err)
+	_, err = q.Enqueue(ctx, "unknown", nil)
+	require.NoError(t, err)
+
+	jobs := make(chan *Job, 2)
+	offset := time.Duration(0)
+	for attempt := 1; attempt <= DefaultMaxAttempts; attempt++ {
+		q.dispatch(ctx, jobs)
+		for len(jobs) > 0 {
+			q.work(ctx, <-jobs)
+		}
+		// retries are delayed
+		offset += retryBackoff * DefaultMaxAttempts
+		q.now = func() time.Time { return time.Now().Add(offset) }
+	}
+
+	s, err := q.Stats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 0, s.Pending)
+	require.Equal(t, 2, s.Failed)
+	require.Equal(t, job.ID, s.Jobs[0].ID)
+	require.Equal(t, DefaultMaxAttempts, s.Jobs[0].Attempts)
+	require.Contains(t, s.Jobs[1].Error, "no handler")
+}
+
+func TestQueue_HoldWhile(t *testing.T) {
+	q := New(memory.NewInMemoryRepository(), 1)
+	ctx := context.Background()
+	hold := true
+	q.HoldWhile(func() bool { return hold })
+
+	_, err := q.Enqueue(ctx, "provision", nil)
+	require.NoError(t, err)
+
+	jobs := make(chan *Job, 1)
+	q.dispatch(ctx, jobs)
+	require.Len(t, jobs, 0)
+
+	hold = false
+	q.dispatch(ctx, jobs)
+	require.Len(t, jobs, 1)
+}
+
+func TestQueue_recover(t *testing.T) {
+	repo := memory.NewInMemoryRepository()
+	ctx := context.Background()
+
+	// the process crashes while the job is running
+	crashed := New(repo, 1)
+	job, err := crashed.Enqueue(ctx, "provision", "kube")
+	require.NoError(t, err)
+	crashed.dispatch(ctx, make(chan *Job, 1))
+
+	jobs, err := crashed.Jobs(ctx)
+	require.NoError(t, err)
+	require.Equal(t, StateRunning, jobs[0].State)
+
+	q := New(repo, 1)
+	done := make(chan string, 1)
+	q.Handle("provision", func(ctx context.Context, payload []byte) error {
+		done <- string(payload)
+		return nil
+	})
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go q.Run(runCtx)
+
+	select {
+	case payload := <-done:
+		require.Equal(t, `"kube"`, payload)
+	case <-time.After(time.Second * 5):
+		t.Fatalf("job %s hasn't been retried", job.ID)
+	}
+}
@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/settings"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// Reserver reserves provisions of clusters for their owners.
+type Reserver interface {
+	ReserveProvision(ctx context.Context, owner string) (func(), error)
+}
+
+// Reserve reserves the provision of a cluster for the user of the context,
+// the user owns the cluster. Clusters aren't limited without quotas or
+// users, e.g. ones created by gitops in background.
+func Reserve(ctx context.Context, quotas Reserver) (owner string, release func(), err error) {
+	identity, _ := api.IdentityFrom(ctx)
+	if quotas == nil || identity.Login == "" {
+		return identity.Login, func() {}, nil
+	}
+
+	release, err = quotas.ReserveProvision(ctx, identity.Login)
+	if err != nil {
+		return "", nil, err
+	}
+	return identity.Login, release, nil
+}
+
+// KubeLister lists kubes to count kubes of owners.
+type KubeLister interface {
+	ListAll(ctx context.Context) ([]model.Kube, error)
+}
+
+// Quotas limit kubes of each owner.
+type Quotas struct {
+	kubes KubeLister
+
+	mu     sync.Mutex
+	quotas settings.Quotas
+	// provisions of owners that don't have kube records yet
+	pending map[string]int
+}
+
+// NewQuotas returns unlimited quotas until they are set.
+func NewQuotas(kubes KubeLister) *Quotas {
+	return &Quotas{
+		kubes:   kubes,
+		pending: make(map[string]int),
+	}
+}
+
+// Set changes quotas of owners.
+func (q *Quotas) Set(quotas settings.Quotas) {
+	q.mu.Lock()
+	q.quotas = quotas
+	q.mu.Unlock()
+}
+
+// ReserveProvision checks quotas of the owner allow one more kube, the
+// provision is counted until release is called, it's called when the kube
+// record is created or provisioning failed to start.
+func (q *Quotas) ReserveProvision(ctx context.Context, owner string) (func(), error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	release := func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+
+		if q.pending[owner]--; q.pending[owner] <= 0 {
+			delete(q.pending, owner)
+		}
+	}
+
+	if q.quotas.MaxClusters == 0 && q.quotas.MaxConcurrentProvisions == 0 {
+		q.pending[owner]++
+		return release, nil
+	}
+
+	kubes, err := q.kubes.ListAll(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "list kubes")
+	}
+
+	clusters, provisions := q.pending[owner], q.pending[owner]
+	for _, k := range kubes {
+		if k.Owner != owner {
+			continue
+		}
+		clusters++
+		if k.State == model.StateProvisioning {
+			provisions++
+		}
+	}
+
+	if max := q.quotas.MaxClusters; max > 0 && clusters >= max {
+		return nil, errors.Wrapf(sgerrors.ErrQuotaExceeded, "%s owns %d of %d clusters", owner, clusters, max)
+	}
+	if max := q.quotas.MaxConcurrentProvisions; max > 0 && provisions >= max {
+		return nil, errors.Wrapf(sgerrors.ErrQuotaExceeded, "%s provisions %d of %d clusters at once", owner, provisions, max)
+	}
+
+	q.pending[owner]++
+	return release, nil
+}
@@ -0,0 +1,174 @@
+// Package ratelimit limits API requests of users and tokens and applies
+// quotas of users to expensive operations.
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/settings"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+const (
+	LimitHeader     = "X-RateLimit-Limit"
+	RemainingHeader = "X-RateLimit-Remaining"
+	RetryHeader     = "Retry-After"
+
+	sweepInterval = time.Minute
+)
+
+// bucket of tokens of a user or an API token, a request takes a token.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter limits requests of each user and of each API token, users have
+// tokens of their own so leaked tokens don't use up their limits.
+type Limiter struct {
+	mu        sync.Mutex
+	limit     settings.RateLimit
+	buckets   map[string]*bucket
+	lastSweep time.Time
+
+	now func() time.Time
+}
+
+// NewLimiter returns a limiter that allows all requests until the limit is set.
+func NewLimiter() *Limiter {
+	return &Limiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// SetLimit changes the limit of users and tokens.
+func (l *Limiter) SetLimit(limit settings.RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if limit == l.limit {
+		return
+	}
+	l.limit = limit
+	l.buckets = make(map[string]*bucket)
+}
+
+// Middleware rejects requests above the limit with 429 Too Many Requests,
+// responses have headers with the limit and how many requests remain.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys := make([]string, 0, 2)
+		if id, ok := api.IdentityFrom(r.Context()); ok {
+			keys = append(keys, "user:"+id.Login)
+		}
+		if token := bearerToken(r); token != "" {
+			sum := sha256.Sum256([]byte(token))
+			keys = append(keys, "token:"+hex.EncodeToString(sum[:]))
+		}
+
+		ok, remaining, retry, limit := l.allow(keys)
+		if limit.RequestsPerSecond == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set(LimitHeader, strconv.Itoa(limit.Burst))
+		w.Header().Set(RemainingHeader, strconv.Itoa(remaining))
+		if !ok {
+			w.Header().Set(RetryHeader, strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+			message.SendRateLimited(w, sgerrors.ErrRateLimited)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allow takes a token of each key if all of them have tokens, it returns
+// the least number of remaining tokens and when the request can be retried.
+func (l *Limiter) allow(keys []string) (bool, int, time.Duration, settings.RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit := l.limit
+	if limit.RequestsPerSecond == 0 || len(keys) == 0 {
+		return true, 0, 0, limit
+	}
+
+	now := l.now()
+	l.sweep(now)
+
+	ok, remaining, retry := true, limit.Burst, time.Duration(0)
+	buckets := make([]*bucket, 0, len(keys))
+	for _, key := range keys {
+		b, exists := l.buckets[key]
+		if !exists {
+			b = &bucket{tokens: float64(limit.Burst), last: now}
+			l.buckets[key] = b
+		}
+		l.refill(b, now)
+		buckets = append(buckets, b)
+
+		if b.tokens < 1 {
+			ok = false
+			if wait := time.Duration((1 - b.tokens) / limit.RequestsPerSecond * float64(time.Second)); wait > retry {
+				retry = wait
+			}
+		}
+	}
+
+	for _, b := range buckets {
+		if ok {
+			b.tokens--
+		}
+		if n := int(b.tokens); n < remaining {
+			remaining = n
+		}
+	}
+
+	return ok, remaining, retry, limit
+}
+
+func (l *Limiter) refill(b *bucket, now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * l.limit.RequestsPerSecond
+	if max := float64(l.limit.Burst); b.tokens > max {
+		b.tokens = max
+	}
+	b.last = now
+}
+
+// sweep forgets full buckets, they are the same as new ones.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if l.refill(b, now); b.tokens >= float64(l.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func bearerToken(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); h != "" {
+		if parts := strings.SplitN(h, " ", 2); len(parts) == 2 {
+			return parts[1]
+		}
+		return ""
+	}
+
+	// websockets
+	return r.URL.Query().Get("token")
+}
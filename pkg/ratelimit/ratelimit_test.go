@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/settings"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+func request(h http.Handler, login, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/kubes", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req = req.WithContext(api.WithIdentity(req.Context(), api.Identity{Login: login}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestLimiter(t *testing.T) {
+	now := time.Now()
+	l := NewLimiter()
+	l.now = func() time.Time { return now }
+	h := l.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	// unlimited
+	for i := 0; i < 10; i++ {
+		rec := request(h, "user", "a")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Empty(t, rec.Header().Get(LimitHeader))
+	}
+
+	l.SetLimit(settings.RateLimit{RequestsPerSecond: 1, Burst: 2})
+	rec := request(h, "user", "a")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "2", rec.Header().Get(LimitHeader))
+	require.Equal(t, "1", rec.Header().Get(RemainingHeader))
+	require.Equal(t, http.StatusOK, request(h, "user", "a").Code)
+
+	rec = request(h, "user", "a")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "1", rec.Header().Get(RetryHeader))
+	require.Equal(t, "0", rec.Header().Get(RemainingHeader))
+
+	// the user is limited with other tokens and the token with other users
+	require.Equal(t, http.StatusTooManyRequests, request(h, "user", "b").Code)
+	require.Equal(t, http.StatusTooManyRequests, request(h, "admin", "a").Code)
+	require.Equal(t, http.StatusOK, request(h, "admin", "b").Code)
+
+	now = now.Add(time.Second)
+	require.Equal(t, http.StatusOK, request(h, "user", "a").Code)
+	require.Equal(t, http.StatusTooManyRequests, request(h, "user", "a").Code)
+
+	// full buckets are forgotten
+	now = now.Add(time.Hour)
+	request(h, "user", "a")
+	require.Len(t, l.buckets, 2)
+}
+
+type kubeLister []model.Kube
+
+func (k kubeLister) ListAll(context.Context) ([]model.Kube, error) {
+	return k, nil
+}
+
+func TestQuotas(t *testing.T) {
+	q := NewQuotas(kubeLister{
+		{Owner: "user", State: model.StateOperational},
+		{Owner: "user", State: model.StateProvisioning},
+		{Owner: "admin", State: model.StateProvisioning},
+		{State: model.StateProvisioning},
+	})
+
+	release, err := q.ReserveProvision(context.Background(), "user")
+	require.NoError(t, err)
+	release()
+
+	q.Set(settings.Quotas{MaxConcurrentProvisions: 2})
+	release, err = q.ReserveProvision(context.Background(), "user")
+	require.NoError(t, err)
+	_, err = q.ReserveProvision(context.Background(), "user")
+	require.True(t, sgerrors.IsQuotaExceeded(err), err)
+	release()
+	release, err = q.ReserveProvision(context.Background(), "user")
+	require.NoError(t, err)
+	release()
+
+	q.Set(settings.Quotas{MaxClusters: 2})
+	_, err = q.ReserveProvision(context.Background(), "user")
+	require.True(t, sgerrors.IsQuotaExceeded(err), err)
+	_, err = q.ReserveProvision(context.Background(), "admin")
+	require.NoError(t, err)
+}
+
+func TestReserve(t *testing.T) {
+	q := NewQuotas(kubeLister{{Owner: "user"}})
+	q.Set(settings.Quotas{MaxClusters: 1})
+
+	ctx := api.WithIdentity(context.Background(), api.Identity{Login: "user"})
+	_, _, err := Reserve(ctx, q)
+	require.True(t, sgerrors.IsQuotaExceeded(err), err)
+
+	owner, release, err := Reserve(api.WithIdentity(context.Background(), api.Identity{Login: "admin"}), q)
+	require.NoError(t, err)
+	require.Equal(t, "admin", owner)
+	release()
+
+	// clusters of gitops have no owner
+	owner, release, err = Reserve(context.Background(), q)
+	require.NoError(t, err)
+	require.Empty(t, owner)
+	release()
+
+	owner, _, err = Reserve(ctx, nil)
+	require.NoError(t, err)
+	require.Equal(t, "user", owner)
+}
@@ -0,0 +1,118 @@
+// Package redact masks secrets of configs before they leave control.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Mask replaces redacted values.
+const Mask = "[REDACTED]"
+
+// JSON masks values of sensitive keys of the JSON document, e.g. keys, tokens
+// and passwords, certificates and public keys are kept.
+func JSON(data []byte) ([]byte, error) {
+	v, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(Value(v))
+}
+
+// Fields masks fields of the JSON document given by dotted paths,
+// e.g. "config.awsConfig.secret_key".
+func Fields(data []byte, paths ...string) ([]byte, error) {
+	v, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range paths {
+		keys := strings.Split(p, ".")
+		obj, ok := v.(map[string]interface{})
+		for _, key := range keys[:len(keys)-1] {
+			if !ok {
+				break
+			}
+			obj, ok = obj[key].(map[string]interface{})
+		}
+		last := keys[len(keys)-1]
+		if ok && obj[last] != nil {
+			obj[last] = maskAll(obj[last])
+		}
+	}
+
+	return json.Marshal(v)
+}
+
+// decode keeps numbers as they are, e.g. int64 of durations.
+func decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// Value masks values of sensitive keys of the decoded JSON value.
+func Value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if IsSensitive(key) {
+				v[key] = maskAll(value)
+				continue
+			}
+			v[key] = Value(value)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = Value(v[i])
+		}
+	}
+
+	return v
+}
+
+// IsSensitive reports whether values of the key are secrets.
+func IsSensitive(key string) bool {
+	k := strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+
+	switch {
+	case strings.HasSuffix(k, "publickey"), strings.Contains(k, "keypair"),
+		strings.HasSuffix(k, "uri"), strings.HasSuffix(k, "url"):
+		return false
+	case strings.Contains(k, "password"), strings.Contains(k, "secret"),
+		strings.Contains(k, "token"), strings.Contains(k, "privatekey"),
+		strings.Contains(k, "credential"), strings.HasSuffix(k, "key"):
+		return true
+	}
+
+	return false
+}
+
+func maskAll(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		if v == "" {
+			return v
+		}
+		return Mask
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = maskAll(value)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = maskAll(v[i])
+		}
+	}
+
+	return v
+}
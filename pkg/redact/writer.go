@@ -0,0 +1,147 @@
+package redact
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const maxLine = 64 * 1024
+
+var (
+	// patterns of secrets in command output, the last group of a pattern is masked.
+	patterns = []*regexp.Regexp{
+		// aws access key ids
+		regexp.MustCompile(`()\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`),
+		// kubeadm bootstrap tokens
+		regexp.MustCompile(`()\b([a-z0-9]{6}\.[a-z0-9]{16})\b`),
+		// digitalocean personal access tokens
+		regexp.MustCompile(`()\b(dop_v1_[a-f0-9]{64})\b`),
+		// bearer tokens of http headers
+		regexp.MustCompile(`(?i)(bearer\s+)([A-Za-z0-9\-._~+/]+=*)`),
+		// flags, variables and settings, e.g. --token=..., AWS_SECRET_ACCESS_KEY=..., password: ...
+		regexp.MustCompile(`(?i)((?:secret[_-]?access[_-]?key|secret|password|passwd|token|api[_-]?key)["']?\s*[=:]\s*["']?|--(?:token|password|secret)\s+)([^\s"',]+)`),
+	}
+
+	privateKeyBegin = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)
+	privateKeyEnd   = regexp.MustCompile(`-----END [A-Z ]*PRIVATE KEY-----`)
+)
+
+// String masks secrets that match known patterns, private keys of
+// the text are replaced as a whole.
+func String(s string) string {
+	var out strings.Builder
+	inKey := false
+	for _, line := range strings.SplitAfter(s, "\n") {
+		var masked string
+		masked, inKey = maskLine(line, inKey)
+		out.WriteString(masked)
+	}
+
+	return out.String()
+}
+
+// maskLine masks the line, inKey is true for lines of a private key.
+func maskLine(line string, inKey bool) (string, bool) {
+	if inKey {
+		if loc := privateKeyEnd.FindStringIndex(line); loc != nil {
+			return line[loc[0]:], false
+		}
+		return "", true
+	}
+
+	if loc := privateKeyBegin.FindStringIndex(line); loc != nil {
+		head, rest := maskPatterns(line[:loc[0]])+line[loc[0]:loc[1]], line[loc[1]:]
+		// keys of JSON documents and flags are on a single line
+		if end := privateKeyEnd.FindStringIndex(rest); end != nil {
+			return head + Mask + rest[end[0]:], false
+		}
+		return head + "\n" + Mask + "\n", true
+	}
+
+	return maskPatterns(line), false
+}
+
+func maskPatterns(s string) string {
+	for _, p := range patterns {
+		s = p.ReplaceAllString(s, "${1}"+Mask)
+	}
+
+	return s
+}
+
+// Writer masks secrets of the output written line by line to the underlying writer.
+type Writer struct {
+	m     sync.Mutex
+	w     io.Writer
+	buf   []byte
+	inKey bool
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := w.writeLine(string(w.buf[:i+1])); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+
+	// very long lines are written without waiting for the end of line
+	if len(w.buf) > maxLine {
+		if err := w.flush(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Close writes the rest of the output and closes the underlying writer if it's a closer.
+func (w *Writer) Close() error {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	if err := w.flush(); err != nil {
+		return err
+	}
+	if c, ok := w.w.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+func (w *Writer) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := w.writeLine(string(w.buf))
+	w.buf = w.buf[:0]
+
+	return err
+}
+
+func (w *Writer) writeLine(line string) error {
+	var masked string
+	masked, w.inKey = maskLine(line, w.inKey)
+	if masked == "" {
+		return nil
+	}
+
+	_, err := io.WriteString(w.w, masked)
+	return err
+}
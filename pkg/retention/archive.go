@@ -0,0 +1,130 @@
+package retention
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/pkg/errors"
+)
+
+const defaultS3Region = "us-east-1"
+
+// Archiver keeps records of pruned tasks.
+type Archiver interface {
+	Archive(ctx context.Context, name string, data []byte) error
+}
+
+// NewArchiver returns the archiver of the URL, the schemes are:
+//
+//	file:///var/lib/control/archive
+//	s3://bucket/prefix?region=eu-west-1
+//	s3://bucket/prefix?endpoint=https://minio.local:9000
+//
+// S3 credentials are looked up the same way as by the AWS CLI.
+func NewArchiver(rawURL string) (Archiver, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse archive url")
+	}
+
+	switch u.Scheme {
+	case "file", "":
+		return &dirArchiver{dir: u.Path}, nil
+	case "s3":
+		return newS3Archiver(u)
+	}
+
+	return nil, errors.Errorf("unsupported archive %s, file or s3 is expected", u.Scheme)
+}
+
+// dirArchiver writes records to a directory, e.g. mounted from network storage.
+type dirArchiver struct {
+	dir string
+}
+
+func (a *dirArchiver) Archive(_ context.Context, name string, data []byte) error {
+	if err := os.MkdirAll(a.dir, 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(a.dir, name), data, 0600)
+}
+
+// s3Archiver puts records to a bucket of S3 or of an S3 compatible storage.
+type s3Archiver struct {
+	endpoint string
+	bucket   string
+	prefix   string
+	region   string
+
+	signer *v4.Signer
+	client *http.Client
+}
+
+func newS3Archiver(u *url.URL) (*s3Archiver, error) {
+	if u.Host == "" {
+		return nil, errors.New("archive bucket is required")
+	}
+
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, errors.Wrap(err, "aws session")
+	}
+
+	region := u.Query().Get("region")
+	if region == "" {
+		region = defaultS3Region
+	}
+	endpoint := u.Query().Get("endpoint")
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+
+	return &s3Archiver{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		bucket:   u.Host,
+		prefix:   strings.Trim(u.Path, "/"),
+		region:   region,
+		signer:   v4.NewSigner(sess.Config.Credentials),
+		client:   &http.Client{Timeout: time.Minute},
+	}, nil
+}
+
+func (a *s3Archiver) Archive(ctx context.Context, name string, data []byte) error {
+	key := path.Join(a.prefix, name)
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/%s/%s", a.endpoint, a.bucket, key), nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.ContentLength = int64(len(data))
+
+	body := bytes.NewReader(data)
+	if _, err = a.signer.Sign(req, body, "s3", a.region, time.Now()); err != nil {
+		return errors.Wrap(err, "sign request")
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("put %s/%s: %s %s", a.bucket, key, resp.Status, msg)
+	}
+
+	return nil
+}
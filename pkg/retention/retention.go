@@ -0,0 +1,249 @@
+// Package retention prunes finished task records and their logs, expired
+// tasks are archived before they are deleted when an archive is configured.
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/kube"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/storage"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/statuses"
+)
+
+const defaultInterval = time.Hour
+
+// Policy defines how long finished tasks are kept, tasks are kept
+// forever when neither MaxAge nor MaxCount is set.
+type Policy struct {
+	// MaxAge is an age of finished tasks to prune.
+	MaxAge time.Duration
+	// MaxCount is a number of the newest finished tasks to keep.
+	MaxCount int
+	// Interval between prune runs, an hour by default.
+	Interval time.Duration
+	// Archive is a URL of the archive of pruned tasks, e.g. file:///var/lib/control/archive
+	// or s3://bucket/tasks?region=us-east-1, tasks aren't archived when it's empty.
+	Archive string
+}
+
+// Enabled reports whether tasks are pruned.
+func (p Policy) Enabled() bool {
+	return p.MaxAge > 0 || p.MaxCount > 0
+}
+
+// Pruner deletes tasks that expired by the policy.
+type Pruner struct {
+	repo     storage.Interface
+	logDir   string
+	policy   Policy
+	archiver Archiver
+
+	now func() time.Time
+}
+
+// task is a part of the task record the policy is applied to.
+type task struct {
+	ID        string          `json:"id"`
+	Status    statuses.Status `json:"status"`
+	CreatedAt time.Time       `json:"createdAt"`
+
+	data []byte
+}
+
+func NewPruner(repo storage.Interface, logDir string, policy Policy) (*Pruner, error) {
+	if policy.Interval == 0 {
+		policy.Interval = defaultInterval
+	}
+
+	var archiver Archiver
+	if policy.Archive != "" {
+		var err error
+		if archiver, err = NewArchiver(policy.Archive); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Pruner{
+		repo:     repo,
+		logDir:   logDir,
+		policy:   policy,
+		archiver: archiver,
+		now:      time.Now,
+	}, nil
+}
+
+func (p *Pruner) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.policy.Interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := p.Prune(ctx); err != nil {
+			logrus.Errorf("retention: prune tasks: %v", err)
+		} else if n > 0 {
+			logrus.Infof("retention: pruned %d tasks", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Prune deletes expired tasks and returns their number. A task is kept
+// when it can't be archived, so it's retried on the next run.
+func (p *Pruner) Prune(ctx context.Context) (int, error) {
+	tasks, err := p.finishedTasks(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	pruned := 0
+	for i, t := range tasks {
+		if !p.expired(i, t) {
+			continue
+		}
+
+		if err := p.archive(ctx, t); err != nil {
+			logrus.Errorf("retention: archive task %s: %v", t.ID, err)
+			continue
+		}
+
+		if err := p.repo.Delete(ctx, workflows.Prefix, t.ID); err != nil {
+			logrus.Errorf("retention: delete task %s: %v", t.ID, err)
+			continue
+		}
+		if err := os.Remove(p.logFile(t.ID)); err != nil && !os.IsNotExist(err) {
+			logrus.Warnf("retention: delete log of task %s: %v", t.ID, err)
+		}
+		pruned++
+	}
+
+	return pruned, nil
+}
+
+// finishedTasks returns tasks that aren't running, the newest come first.
+// Interrupted tasks are resumed on start and tasks of existing kubes are
+// shown with them, so they are kept.
+func (p *Pruner) finishedTasks(ctx context.Context) ([]*task, error) {
+	referenced, err := p.kubeTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := p.repo.GetAll(ctx, workflows.Prefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "list tasks")
+	}
+
+	tasks := make([]*task, 0, len(records))
+	for _, data := range records {
+		t := &task{data: data}
+		if err := json.Unmarshal(data, t); err != nil {
+			logrus.Warnf("retention: decode task: %v", err)
+			continue
+		}
+
+		switch t.Status {
+		case statuses.Todo, statuses.Executing, statuses.Interrupted:
+			continue
+		}
+		if referenced[t.ID] {
+			continue
+		}
+
+		// tasks created before retention have the time of their log only
+		if t.CreatedAt.IsZero() {
+			if info, err := os.Stat(p.logFile(t.ID)); err == nil {
+				t.CreatedAt = info.ModTime()
+			}
+		}
+		tasks = append(tasks, t)
+	}
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].CreatedAt.After(tasks[j].CreatedAt)
+	})
+
+	return tasks, nil
+}
+
+// kubeTasks returns IDs of tasks of kubes and their machines.
+func (p *Pruner) kubeTasks(ctx context.Context) (map[string]bool, error) {
+	records, err := p.repo.GetAll(ctx, kube.DefaultStoragePrefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "list kubes")
+	}
+
+	ids := make(map[string]bool)
+	for _, data := range records {
+		k := &model.Kube{}
+		if err := json.Unmarshal(data, k); err != nil {
+			logrus.Warnf("retention: decode kube: %v", err)
+			continue
+		}
+
+		for _, taskIDs := range k.Tasks {
+			for _, id := range taskIDs {
+				ids[id] = true
+			}
+		}
+		for _, machines := range []map[string]*model.Machine{k.Masters, k.Nodes} {
+			for _, m := range machines {
+				if m != nil && m.TaskID != "" {
+					ids[m.TaskID] = true
+				}
+			}
+		}
+	}
+
+	return ids, nil
+}
+
+// expired reports whether the i-th newest finished task is pruned.
+func (p *Pruner) expired(i int, t *task) bool {
+	if p.policy.MaxCount > 0 && i >= p.policy.MaxCount {
+		return true
+	}
+
+	return p.policy.MaxAge > 0 && !t.CreatedAt.IsZero() &&
+		p.now().Sub(t.CreatedAt) > p.policy.MaxAge
+}
+
+// archive writes the task record and its log to the archive.
+func (p *Pruner) archive(ctx context.Context, t *task) error {
+	if p.archiver == nil {
+		return nil
+	}
+
+	if err := p.archiver.Archive(ctx, t.ID+".json", t.data); err != nil {
+		return errors.Wrap(err, "record")
+	}
+
+	log, err := ioutil.ReadFile(p.logFile(t.ID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrap(err, "read log")
+	}
+
+	return errors.Wrap(p.archiver.Archive(ctx, util.MakeFileName(t.ID), log), "log")
+}
+
+func (p *Pruner) logFile(taskID string) string {
+	return filepath.Join(p.logDir, util.MakeFileName(taskID))
+}
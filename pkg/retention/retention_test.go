@@ -0,0 +1,154 @@
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/kube"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/storage/memory"
+	"github.com/supergiant/control/pkg/workflows"
+	"github.com/supergiant/control/pkg/workflows/statuses"
+)
+
+type failingArchiver struct{}
+
+func (failingArchiver) Archive(context.Context, string, []byte) error {
+	return os.ErrPermission
+}
+
+func putTask(t *testing.T, repo *memory.InMemoryRepository, logDir, id string, status statuses.Status, createdAt time.Time) {
+	data, err := json.Marshal(task{ID: id, Status: status, CreatedAt: createdAt})
+	require.NoError(t, err)
+	require.NoError(t, repo.Put(context.Background(), workflows.Prefix, id, data))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(logDir, id+".log"), []byte("log of "+id), 0600))
+}
+
+func TestPruner_Prune(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		name     string
+		policy   Policy
+		archiver Archiver
+		expected []string
+	}{
+		{"max age", Policy{MaxAge: time.Hour * 24}, nil, []string{"running", "interrupted", "kube", "machine", "new", "day"}},
+		{"max count", Policy{MaxCount: 1}, nil, []string{"running", "interrupted", "kube", "machine", "new"}},
+		{"not archived", Policy{MaxCount: 1}, failingArchiver{}, []string{"running", "interrupted", "kube", "machine", "new", "day", "old"}},
+	} {
+		logDir, err := ioutil.TempDir("", "retention")
+		require.NoError(t, err)
+		defer os.RemoveAll(logDir)
+
+		repo := memory.NewInMemoryRepository()
+		putTask(t, repo, logDir, "running", statuses.Executing, now.Add(-time.Hour*24*30))
+		putTask(t, repo, logDir, "new", statuses.Success, now.Add(-time.Hour))
+		putTask(t, repo, logDir, "day", statuses.Error, now.Add(-time.Hour*23))
+		putTask(t, repo, logDir, "old", statuses.Success, now.Add(-time.Hour*24*7))
+		putTask(t, repo, logDir, "interrupted", statuses.Interrupted, now.Add(-time.Hour*24*7))
+		// tasks of existing kubes
+		putTask(t, repo, logDir, "kube", statuses.Success, now.Add(-time.Hour*24*7))
+		putTask(t, repo, logDir, "machine", statuses.Error, now.Add(-time.Hour*24*7))
+		k, err := json.Marshal(model.Kube{
+			ID:      "kube-1",
+			Tasks:   map[string][]string{"cluster": {"kube"}},
+			Masters: map[string]*model.Machine{"master-1": {TaskID: "machine"}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, repo.Put(context.Background(), kube.DefaultStoragePrefix, "kube-1", k))
+
+		p, err := NewPruner(repo, logDir, tc.policy)
+		require.NoError(t, err, tc.name)
+		p.archiver = tc.archiver
+
+		_, err = p.Prune(context.Background())
+		require.NoError(t, err, tc.name)
+
+		for _, id := range []string{"running", "interrupted", "kube", "machine", "new", "day", "old"} {
+			_, err := repo.Get(context.Background(), workflows.Prefix, id)
+			_, logErr := os.Stat(filepath.Join(logDir, id+".log"))
+			kept := contains(tc.expected, id)
+			require.Equal(t, kept, err == nil, "%s: task %s", tc.name, id)
+			require.Equal(t, kept, logErr == nil, "%s: log %s", tc.name, id)
+		}
+	}
+}
+
+func TestPruner_PruneArchive(t *testing.T) {
+	logDir, err := ioutil.TempDir("", "retention")
+	require.NoError(t, err)
+	defer os.RemoveAll(logDir)
+	archiveDir := filepath.Join(logDir, "archive")
+
+	repo := memory.NewInMemoryRepository()
+	putTask(t, repo, logDir, "old", statuses.Success, time.Now().Add(-time.Hour*48))
+
+	p, err := NewPruner(repo, logDir, Policy{MaxAge: time.Hour, Archive: "file://" + archiveDir})
+	require.NoError(t, err)
+
+	n, err := p.Prune(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	record, err := ioutil.ReadFile(filepath.Join(archiveDir, "old.json"))
+	require.NoError(t, err)
+	require.Contains(t, string(record), `"id":"old"`)
+	log, err := ioutil.ReadFile(filepath.Join(archiveDir, "old.log"))
+	require.NoError(t, err)
+	require.Equal(t, "log of old", string(log))
+}
+
+func TestS3Archiver_Archive(t *testing.T) {
+	var path, sha string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		sha = r.Header.Get("X-Amz-Content-Sha256")
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256") {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+
+	os.Setenv("AWS_ACCESS_KEY_ID", "id")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	defer os.Unsetenv("AWS_ACCESS_KEY_ID")
+	defer os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+
+	u, err := url.Parse("s3://audit/tasks/?endpoint=" + srv.URL)
+	require.NoError(t, err)
+	a, err := newS3Archiver(u)
+	require.NoError(t, err)
+
+	require.NoError(t, a.Archive(context.Background(), "task-1.json", []byte("{}")))
+	require.Equal(t, "/audit/tasks/task-1.json", path)
+	require.NotEmpty(t, sha)
+}
+
+func TestNewArchiver(t *testing.T) {
+	_, err := NewArchiver("ftp://host/tasks")
+	require.Error(t, err)
+
+	a, err := NewArchiver("file:///var/lib/control/archive")
+	require.NoError(t, err)
+	require.Equal(t, &dirArchiver{dir: "/var/lib/control/archive"}, a)
+}
+
+func contains(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
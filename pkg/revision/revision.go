@@ -0,0 +1,245 @@
+// Package revision keeps every revision of profiles and cluster specs, so
+// changes made before a cluster broke can be found and reverted.
+package revision
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
+)
+
+// DefaultStoragePrefix keeps revisions, revisions of an object are stored
+// under its own prefix.
+const DefaultStoragePrefix = "/supergiant/revisions/"
+
+// Kind is a type of objects with revisions.
+type Kind string
+
+const (
+	KindProfile Kind = "profile"
+	KindSpec    Kind = "spec"
+)
+
+// systemAuthor made changes without a user, e.g. by gitops.
+const systemAuthor = "system"
+
+// Revision is a saved version of an object.
+type Revision struct {
+	Number    int       `json:"number"`
+	Kind      Kind      `json:"kind"`
+	ID        string    `json:"id"`
+	Author    string    `json:"author"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Comment tells why the revision was made, e.g. a revert.
+	Comment string `json:"comment,omitempty"`
+	// Document is the object, JSON for profiles and YAML for cluster specs.
+	Document string `json:"document"`
+}
+
+// Diff is a unified diff of the documents of two revisions.
+type Diff struct {
+	From  int    `json:"from"`
+	To    int    `json:"to"`
+	Patch string `json:"patch"`
+}
+
+// Service keeps revisions of objects.
+type Service struct {
+	prefix     string
+	repository storage.Interface
+
+	// numbers of revisions are assigned one at a time
+	m sync.Mutex
+}
+
+func NewService(prefix string, repository storage.Interface) *Service {
+	return &Service{
+		prefix:     prefix,
+		repository: repository,
+	}
+}
+
+// Record saves the document as the next revision of the object by the user
+// of the context, nothing is saved if the document hasn't changed. It's a
+// no-op for a service without storage.
+func (s *Service) Record(ctx context.Context, kind Kind, id string, document []byte, comment string) (*Revision, error) {
+	if s == nil || s.repository == nil {
+		return nil, nil
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	revisions, err := s.List(ctx, kind, id)
+	if err != nil {
+		return nil, err
+	}
+	number := 1
+	if n := len(revisions); n > 0 {
+		last := revisions[n-1]
+		if last.Document == string(document) {
+			return &last, nil
+		}
+		number = last.Number + 1
+	}
+
+	author := systemAuthor
+	if user, ok := api.IdentityFrom(ctx); ok && user.Login != "" {
+		author = user.Login
+	}
+	r := &Revision{
+		Number:    number,
+		Kind:      kind,
+		ID:        id,
+		Author:    author,
+		CreatedAt: time.Now().UTC(),
+		Comment:   comment,
+		Document:  string(document),
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal")
+	}
+	// keys are ordered by numbers of revisions
+	key := fmt.Sprintf("%010d", r.Number)
+	return r, errors.Wrap(s.repository.Put(ctx, s.objectPrefix(kind, id), key, data), "save revision")
+}
+
+// List returns revisions of the object ordered by their numbers.
+func (s *Service) List(ctx context.Context, kind Kind, id string) ([]Revision, error) {
+	rawRevisions, err := s.repository.GetAll(ctx, s.objectPrefix(kind, id))
+	if err != nil {
+		return nil, errors.Wrap(err, "get revisions")
+	}
+
+	revisions := make([]Revision, 0, len(rawRevisions))
+	for _, data := range rawRevisions {
+		if len(data) == 0 {
+			continue
+		}
+		r := Revision{}
+		if err = json.Unmarshal(data, &r); err != nil {
+			logrus.Warnf("revision: decode revision of %s %s: %v", kind, id, err)
+			continue
+		}
+		revisions = append(revisions, r)
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Number < revisions[j].Number
+	})
+
+	return revisions, nil
+}
+
+// Get returns the revision of the object, the latest one if number is 0.
+func (s *Service) Get(ctx context.Context, kind Kind, id string, number int) (*Revision, error) {
+	if number > 0 {
+		data, err := s.repository.Get(ctx, s.objectPrefix(kind, id), fmt.Sprintf("%010d", number))
+		if err != nil {
+			return nil, errors.Wrapf(err, "get revision %d of %s %s", number, kind, id)
+		}
+		r := &Revision{}
+		return r, errors.Wrap(json.Unmarshal(data, r), "decode revision")
+	}
+
+	revisions, err := s.List(ctx, kind, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, errors.Wrapf(sgerrors.ErrNotFound, "revisions of %s %s", kind, id)
+	}
+	return &revisions[len(revisions)-1], nil
+}
+
+// Diff compares two revisions of the object, to is the latest revision if
+// it's 0 and from is the one before to if it's 0.
+func (s *Service) Diff(ctx context.Context, kind Kind, id string, from, to int) (*Diff, error) {
+	b, err := s.Get(ctx, kind, id, to)
+	if err != nil {
+		return nil, err
+	}
+	if from == 0 {
+		from = b.Number - 1
+	}
+
+	a := &Revision{Number: from}
+	if from > 0 {
+		if a, err = s.Get(ctx, kind, id, from); err != nil {
+			return nil, err
+		}
+	}
+
+	patch, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        lines(a.Document),
+		B:        lines(b.Document),
+		FromFile: fmt.Sprintf("%s/%s@%d", kind, id, a.Number),
+		ToFile:   fmt.Sprintf("%s/%s@%d", kind, id, b.Number),
+		Context:  3,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "diff revisions")
+	}
+
+	return &Diff{
+		From:  a.Number,
+		To:    b.Number,
+		Patch: patch,
+	}, nil
+}
+
+// ParseRange reads the from and to revisions of a diff query, missing
+// revisions are 0.
+func ParseRange(query url.Values) (from, to int, err error) {
+	for _, p := range []struct {
+		name string
+		v    *int
+	}{{"from", &from}, {"to", &to}} {
+		s := query.Get(p.name)
+		if s == "" {
+			continue
+		}
+		if *p.v, err = strconv.Atoi(s); err != nil || *p.v < 1 {
+			return 0, 0, sgerrors.WithField(errors.Errorf("invalid revision %q", s), p.name)
+		}
+	}
+	return from, to, nil
+}
+
+func (s *Service) objectPrefix(kind Kind, id string) string {
+	return s.prefix + string(kind) + "/" + id + "/"
+}
+
+// Indent formats JSON documents, so their diffs are line by line.
+func Indent(v interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, errors.Wrap(err, "marshal document")
+	}
+	return buf.Bytes(), nil
+}
+
+func lines(document string) []string {
+	if document == "" {
+		return nil
+	}
+	return difflib.SplitLines(strings.TrimSuffix(document, "\n"))
+}
@@ -0,0 +1,95 @@
+package revision
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage/memory"
+)
+
+func TestService_Record(t *testing.T) {
+	svc := NewService(DefaultStoragePrefix, memory.NewInMemoryRepository())
+	ctx := api.WithIdentity(context.Background(), api.Identity{Login: "alice"})
+
+	r, err := svc.Record(ctx, KindProfile, "p", []byte("a: 1\n"), "")
+	require.NoError(t, err)
+	require.Equal(t, 1, r.Number)
+	require.Equal(t, "alice", r.Author)
+
+	// unchanged documents aren't saved again
+	r, err = svc.Record(ctx, KindProfile, "p", []byte("a: 1\n"), "")
+	require.NoError(t, err)
+	require.Equal(t, 1, r.Number)
+
+	r, err = svc.Record(context.Background(), KindProfile, "p", []byte("a: 2\n"), "revert")
+	require.NoError(t, err)
+	require.Equal(t, 2, r.Number)
+	require.Equal(t, systemAuthor, r.Author)
+
+	_, err = svc.Record(ctx, KindSpec, "p", []byte("b: 1\n"), "")
+	require.NoError(t, err)
+
+	revisions, err := svc.List(ctx, KindProfile, "p")
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	require.Equal(t, "revert", revisions[1].Comment)
+
+	latest, err := svc.Get(ctx, KindProfile, "p", 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, latest.Number)
+
+	_, err = svc.Get(ctx, KindProfile, "unknown", 0)
+	require.True(t, sgerrors.IsNotFound(err))
+
+	var nilService *Service
+	r, err = nilService.Record(ctx, KindProfile, "p", nil, "")
+	require.NoError(t, err)
+	require.Nil(t, r)
+}
+
+func TestService_Diff(t *testing.T) {
+	svc := NewService(DefaultStoragePrefix, memory.NewInMemoryRepository())
+	ctx := context.Background()
+	for _, doc := range []string{"a: 1\nb: 1\n", "a: 1\nb: 2\n", "a: 3\nb: 2\n"} {
+		_, err := svc.Record(ctx, KindSpec, "kube", []byte(doc), "")
+		require.NoError(t, err)
+	}
+
+	diff, err := svc.Diff(ctx, KindSpec, "kube", 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, diff.From)
+	require.Equal(t, 3, diff.To)
+	require.Contains(t, diff.Patch, "-a: 1")
+	require.Contains(t, diff.Patch, "+a: 3")
+	require.NotContains(t, diff.Patch, "-b: 1")
+
+	diff, err = svc.Diff(ctx, KindSpec, "kube", 1, 3)
+	require.NoError(t, err)
+	require.Contains(t, diff.Patch, "-b: 1")
+
+	// the first revision is compared with nothing
+	diff, err = svc.Diff(ctx, KindSpec, "kube", 0, 1)
+	require.NoError(t, err)
+	require.Equal(t, 0, diff.From)
+	require.True(t, strings.Contains(diff.Patch, "+a: 1"))
+
+	_, err = svc.Diff(ctx, KindSpec, "kube", 0, 7)
+	require.True(t, sgerrors.IsNotFound(err))
+}
+
+func TestParseRange(t *testing.T) {
+	from, to, err := ParseRange(url.Values{"from": {"2"}})
+	require.NoError(t, err)
+	require.Equal(t, 2, from)
+	require.Equal(t, 0, to)
+
+	_, _, err = ParseRange(url.Values{"to": {"x"}})
+	require.Error(t, err)
+	require.Equal(t, "to", sgerrors.DetailOf(err).Field)
+}
@@ -3,6 +3,8 @@ package runner
 import (
 	"context"
 	"io"
+	"regexp"
+	"sort"
 
 	"github.com/pkg/errors"
 )
@@ -20,6 +22,13 @@ type Command struct {
 
 	Out io.Writer
 	Err io.Writer
+
+	// Sudo runs the whole script as root, so commands of the script don't
+	// need sudo.
+	Sudo bool
+	// Env is set for the script, the variables are kept when the script
+	// runs as root.
+	Env map[string]string
 }
 
 //  TODO(stgleb): Use single io.Writer for gathering command output
@@ -29,9 +38,41 @@ func NewCommand(ctx context.Context, script string, out, err io.Writer) (*Comman
 	}
 
 	return &Command{
-		ctx,
-		script,
-		out,
-		err,
+		Ctx:    ctx,
+		Script: script,
+		Out:    out,
+		Err:    err,
 	}, nil
 }
+
+var envName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// EnvNames returns names of the variables sorted, names that can't be set
+// in a shell are rejected.
+func EnvNames(env map[string]string) ([]string, error) {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		if !envName.MatchString(name) {
+			return nil, errors.Errorf("invalid variable name %q", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// EnvAssignments returns shell assignments of the variables sorted by names,
+// values are quoted.
+func EnvAssignments(env map[string]string) ([]string, error) {
+	names, err := EnvNames(env)
+	if err != nil {
+		return nil, err
+	}
+
+	assignments := make([]string, 0, len(names))
+	for _, name := range names {
+		assignments = append(assignments, name+"="+Quote(env[name]))
+	}
+	return assignments, nil
+}
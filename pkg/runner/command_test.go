@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"reflect"
 	"testing"
 )
 
@@ -43,3 +44,22 @@ func TestNewCommand(t *testing.T) {
 		}
 	}
 }
+
+func TestEnvAssignments(t *testing.T) {
+	assignments, err := EnvAssignments(map[string]string{
+		"NO_PROXY": "localhost",
+		"HOME":     "/home/it's",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := []string{`HOME='/home/it'\''s'`, `NO_PROXY='localhost'`}
+	if !reflect.DeepEqual(assignments, expected) {
+		t.Errorf("expected %v actual %v", expected, assignments)
+	}
+
+	if _, err := EnvAssignments(map[string]string{"$(id)": ""}); err == nil {
+		t.Errorf("error expected for invalid name")
+	}
+}
@@ -0,0 +1,167 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxOutput is the size of output tail kept in results,
+// the whole output is still written to the command writers.
+const maxOutput = 16 * 1024
+
+// Result describes a finished command.
+type Result struct {
+	Command   string        `json:"command"`
+	ExitCode  int           `json:"exitCode"`
+	Stdout    string        `json:"stdout"`
+	Stderr    string        `json:"stderr"`
+	StartedAt time.Time     `json:"startedAt"`
+	Duration  time.Duration `json:"duration"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// Success reports whether the command exited with a zero status.
+func (r *Result) Success() bool {
+	return r.Error == "" && r.ExitCode == 0
+}
+
+// ExitError is returned by runners for commands that exit with a non-zero status.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("command exited with code %d", e.Code)
+}
+
+func (e *ExitError) ExitStatus() int {
+	return e.Code
+}
+
+// ExitCode returns exit status of the command that finished with the error,
+// it's -1 when the command didn't finish.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	switch e := errors.Cause(err).(type) {
+	case interface{ ExitStatus() int }:
+		return e.ExitStatus()
+	case *exec.ExitError:
+		return e.ExitCode()
+	}
+
+	return -1
+}
+
+// Exec runs the command and returns its result, the error
+// of a failed command describes the exit code and stderr.
+func Exec(r Runner, cmd *Command) (*Result, error) {
+	if rec, ok := r.(*Recorder); ok {
+		return rec.exec(cmd)
+	}
+
+	return execute(r, cmd)
+}
+
+func execute(r Runner, cmd *Command) (*Result, error) {
+	stdout, stderr := &tailBuffer{}, &tailBuffer{}
+	res := &Result{
+		Command:   cmd.Script,
+		StartedAt: time.Now(),
+	}
+
+	err := r.Run(&Command{
+		Ctx:    cmd.Ctx,
+		Script: cmd.Script,
+		Out:    tee(cmd.Out, stdout),
+		Err:    tee(cmd.Err, stderr),
+		Sudo:   cmd.Sudo,
+		Env:    cmd.Env,
+	})
+
+	res.Duration = time.Since(res.StartedAt)
+	res.Stdout = stdout.String()
+	res.Stderr = stderr.String()
+	res.ExitCode = ExitCode(err)
+
+	if err != nil {
+		res.Error = err.Error()
+		if line := lastLine(res.Stderr); line != "" {
+			return res, errors.Wrapf(err, "%s", line)
+		}
+		return res, err
+	}
+
+	return res, nil
+}
+
+// Recorder is a Runner that passes results of all commands to the callback.
+type Recorder struct {
+	Runner
+	record func(Result)
+}
+
+func NewRecorder(r Runner, record func(Result)) *Recorder {
+	return &Recorder{
+		Runner: r,
+		record: record,
+	}
+}
+
+func (r *Recorder) Run(cmd *Command) error {
+	_, err := r.exec(cmd)
+	return err
+}
+
+func (r *Recorder) exec(cmd *Command) (*Result, error) {
+	res, err := execute(r.Runner, cmd)
+	r.record(*res)
+
+	return res, err
+}
+
+func tee(w io.Writer, buf *tailBuffer) io.Writer {
+	if w == nil {
+		return buf
+	}
+
+	return io.MultiWriter(w, buf)
+}
+
+// tailBuffer keeps the last maxOutput bytes written to it.
+type tailBuffer struct {
+	m    sync.Mutex
+	data []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	b.data = append(b.data, p...)
+	if len(b.data) > maxOutput {
+		b.data = b.data[len(b.data)-maxOutput:]
+	}
+
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	return string(b.data)
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
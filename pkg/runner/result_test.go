@@ -0,0 +1,84 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRunner struct {
+	stdout string
+	stderr string
+	err    error
+	script string
+}
+
+func (f *fakeRunner) Run(cmd *Command) error {
+	f.script = cmd.Script
+	io.WriteString(cmd.Out, f.stdout)
+	io.WriteString(cmd.Err, f.stderr)
+	return f.err
+}
+
+func TestExec(t *testing.T) {
+	out := &bytes.Buffer{}
+	cmd, err := NewCommand(context.Background(), "kubeadm join", out, out)
+	require.NoError(t, err)
+
+	res, err := Exec(&fakeRunner{
+		stdout: "preflight\n",
+		stderr: "warning\nerror: port 10250 is in use\n",
+		err:    errors.Wrap(&ExitError{Code: 1}, "run"),
+	}, cmd)
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "port 10250 is in use")
+	require.Equal(t, 1, ExitCode(err))
+	require.Equal(t, "kubeadm join", res.Command)
+	require.Equal(t, 1, res.ExitCode)
+	require.Equal(t, "preflight\n", res.Stdout)
+	require.Contains(t, res.Stderr, "warning")
+	require.False(t, res.Success())
+	require.Contains(t, out.String(), "preflight", "output must be passed to the writers")
+
+	res, err = Exec(&fakeRunner{stdout: "ok"}, cmd)
+	require.NoError(t, err)
+	require.True(t, res.Success())
+}
+
+func TestRecorder(t *testing.T) {
+	var results []Result
+	r := NewRecorder(&fakeRunner{stdout: "ok"}, func(res Result) {
+		results = append(results, res)
+	})
+
+	cmd, err := NewCommand(context.Background(), "uptime", &bytes.Buffer{}, &bytes.Buffer{})
+	require.NoError(t, err)
+
+	require.NoError(t, r.Run(cmd))
+	_, err = Exec(r, cmd)
+	require.NoError(t, err)
+
+	require.Len(t, results, 2)
+	require.Equal(t, "ok", results[1].Stdout)
+}
+
+func TestExitCode(t *testing.T) {
+	require.Equal(t, 0, ExitCode(nil))
+	require.Equal(t, -1, ExitCode(errors.New("connection refused")))
+	require.Equal(t, 3, ExitCode(errors.Wrap(&ExitError{Code: 3}, "winrm")))
+}
+
+func TestTailBuffer(t *testing.T) {
+	b := &tailBuffer{}
+	b.Write([]byte(strings.Repeat("a", maxOutput)))
+	b.Write([]byte("end"))
+
+	require.Len(t, b.String(), maxOutput)
+	require.True(t, strings.HasSuffix(b.String(), "end"))
+}
@@ -1,6 +1,26 @@
 package runner
 
+// Types of runners that execute commands on cluster machines.
+const (
+	SSH = "ssh"
+	// SSM runs commands through AWS Systems Manager, machines don't
+	// need an open ssh port and keys.
+	SSM = "ssm"
+)
+
 // Runner is interface for running command in different environment
 type Runner interface {
 	Run(command *Command) error
 }
+
+// Ways commands of scripts gain root privileges, scripts call sudo for
+// privileged commands and the runner makes it work for the user.
+const (
+	// EscalationSudo runs sudo as is, the user has passwordless sudo.
+	EscalationSudo = "sudo"
+	// EscalationSudoPassword gives sudo the password of the user.
+	EscalationSudoPassword = "sudo-password"
+	// EscalationNone runs commands without sudo, the user is root on
+	// images that have no sudo.
+	EscalationNone = "none"
+)
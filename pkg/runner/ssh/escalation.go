@@ -0,0 +1,180 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/runner"
+)
+
+// noSudo drops options of sudo and runs the command as the user.
+const noSudo = `sudo() {
+  while [ "$#" -gt 0 ]; do
+    case "$1" in
+      --) shift; break ;;
+      -u|-g|-C|-h|-p|-r|-t|-U) shift 2 ;;
+      -*) shift ;;
+      *) break ;;
+    esac
+  done
+  "$@"
+}
+export -f sudo 2>/dev/null || true
+`
+
+// probeScript reports how the user gains root privileges on the machine.
+const probeScript = `if [ "$(id -u)" -eq 0 ]; then
+  if command -v sudo >/dev/null 2>&1; then echo root; else echo root-nosudo; fi
+elif sudo -n true >/dev/null 2>&1; then
+  echo nopasswd
+else
+  echo password
+fi`
+
+// escalation is how scripts of the user gain root privileges.
+type escalation struct {
+	// root users run scripts as root without sudo
+	root bool
+	// preamble is prepended to scripts to set up sudo
+	preamble string
+}
+
+var (
+	detectedMu sync.Mutex
+	// detected escalations by pool keys, sudo of a machine doesn't change
+	// between steps
+	detected = make(map[string]escalation)
+)
+
+// escalation returns the configured escalation, sudo of users other than
+// root is probed on the machine when it isn't configured.
+func (r *Runner) escalation(ctx context.Context) (escalation, error) {
+	if r.escalationMode != "" || r.user == "root" {
+		return escalationOf(r.escalationMode, r.user, r.sudoPassword)
+	}
+
+	detectedMu.Lock()
+	esc, ok := detected[r.poolKey]
+	detectedMu.Unlock()
+	if ok {
+		return esc, nil
+	}
+
+	out := &bytes.Buffer{}
+	if err := r.run(ctx, probeScript, out, out); err != nil {
+		return escalation{}, errors.Wrapf(err, "ssh: probe sudo of %s", r.user)
+	}
+
+	esc, err := r.probed(strings.TrimSpace(out.String()))
+	if err != nil {
+		return escalation{}, err
+	}
+
+	detectedMu.Lock()
+	detected[r.poolKey] = esc
+	detectedMu.Unlock()
+
+	return esc, nil
+}
+
+// probed returns the escalation of the probe result.
+func (r *Runner) probed(result string) (escalation, error) {
+	switch result {
+	case "root":
+		return escalation{root: true}, nil
+	case "root-nosudo":
+		return escalationOf(runner.EscalationNone, r.user, "")
+	case "nopasswd":
+		return escalationOf(runner.EscalationSudo, r.user, "")
+	case "password":
+		if r.sudoPassword == "" {
+			return escalation{}, errors.Errorf("ssh: sudo of %s requires a password, it isn't set", r.user)
+		}
+		return escalationOf(runner.EscalationSudoPassword, r.user, r.sudoPassword)
+	}
+
+	return escalation{}, errors.Errorf("ssh: unexpected sudo probe result %q", result)
+}
+
+// escalationOf returns the escalation of the mode, scripts of passwordless
+// sudo are run as is.
+func escalationOf(mode, user, password string) (escalation, error) {
+	switch mode {
+	case "", runner.EscalationSudo:
+		return escalation{root: user == "root"}, nil
+	case runner.EscalationNone:
+		return escalation{root: true, preamble: noSudo}, nil
+	case runner.EscalationSudoPassword:
+		if password == "" {
+			return escalation{}, errors.Errorf("%s escalation requires a password", runner.EscalationSudoPassword)
+		}
+		if strings.ContainsAny(password, "\r\n") {
+			return escalation{}, errors.New("sudo password must be a single line")
+		}
+
+		// the askpass helper is readable by the user only and removed
+		// when the script exits
+		return escalation{preamble: fmt.Sprintf(`SUDO_ASKPASS=$(mktemp)
+trap 'rm -f "$SUDO_ASKPASS"' EXIT
+chmod 700 "$SUDO_ASKPASS"
+cat > "$SUDO_ASKPASS" <<'SG_ASKPASS'
+#!/bin/sh
+printf '%%s\n' %s
+SG_ASKPASS
+export SUDO_ASKPASS
+sudo() { command sudo -A "$@"; }
+export -f sudo 2>/dev/null || true
+`, runner.Quote(password))}, nil
+	}
+
+	return escalation{}, errors.Errorf("unknown escalation %q", mode)
+}
+
+// command returns the command line of the script. Scripts that run as root
+// are saved to a temporary file, so commands of the script can read stdin,
+// variables of the command are passed with env, since sudo resets them.
+func (esc escalation) command(cmd *runner.Command) (string, error) {
+	env, err := runner.EnvAssignments(cmd.Env)
+	if err != nil {
+		return "", err
+	}
+
+	if !cmd.Sudo {
+		exports := ""
+		if len(env) > 0 {
+			exports = "export " + strings.Join(env, " ") + "\n"
+		}
+		return esc.preamble + exports + cmd.Script, nil
+	}
+
+	args := append([]string{"env"}, env...)
+	args = append(args, "bash", `"$SG_SCRIPT"`)
+	if !esc.root {
+		args = append([]string{"sudo", "-H"}, args...)
+	}
+
+	return esc.preamble + fmt.Sprintf(`SG_SCRIPT=$(mktemp)
+base64 -d > "$SG_SCRIPT" <<'SG_SCRIPT_EOF'
+%s
+SG_SCRIPT_EOF
+%s
+SG_RC=$?
+rm -f "$SG_SCRIPT"
+exit $SG_RC
+`, wrap(base64.StdEncoding.EncodeToString([]byte(cmd.Script)), 76), strings.Join(args, " ")), nil
+}
+
+func wrap(s string, width int) string {
+	lines := make([]string, 0, len(s)/width+1)
+	for len(s) > width {
+		lines = append(lines, s[:width])
+		s = s[width:]
+	}
+	return strings.Join(append(lines, s), "\n")
+}
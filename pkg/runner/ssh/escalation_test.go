@@ -0,0 +1,168 @@
+package ssh
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/runner"
+)
+
+func TestEscalation_command(t *testing.T) {
+	dir, err := ioutil.TempDir("", "escalation")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// fake sudo prints the password it gets from the askpass helper and
+	// marks commands it runs
+	fake := `#!/bin/sh
+if [ "$1" = "-A" ]; then shift; "$SUDO_ASKPASS"; fi
+[ "$1" = "-H" ] && shift
+echo root:
+exec "$@"
+`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "sudo"), []byte(fake), 0755))
+
+	testCases := []struct {
+		description string
+		mode        string
+		user        string
+		password    string
+		sudo        bool
+		env         map[string]string
+		script      string
+		expected    string
+		expectedErr bool
+	}{
+		{
+			description: "sudo",
+			user:        "ubuntu",
+			script:      "echo hi",
+			expected:    "hi\n",
+		},
+		{
+			description: "none",
+			mode:        runner.EscalationNone,
+			script:      "sudo -H -u root echo hi",
+			expected:    "hi\n",
+		},
+		{
+			description: "password",
+			mode:        runner.EscalationSudoPassword,
+			password:    "pa'ss $x",
+			script:      "sudo echo hi\nbash -c 'sudo echo sub'",
+			expected:    "pa'ss $x\nroot:\nhi\npa'ss $x\nroot:\nsub\n",
+		},
+		{
+			description: "env",
+			user:        "ubuntu",
+			env:         map[string]string{"NAME": "it's"},
+			script:      "echo \"$NAME\"",
+			expected:    "it's\n",
+		},
+		{
+			description: "script as root keeps env",
+			user:        "ubuntu",
+			sudo:        true,
+			env:         map[string]string{"NAME": "it's"},
+			script:      "echo \"$NAME\"\nread line || echo no stdin",
+			expected:    "root:\nit's\nno stdin\n",
+		},
+		{
+			description: "script of root",
+			user:        "root",
+			sudo:        true,
+			script:      "echo hi",
+			expected:    "hi\n",
+		},
+		{
+			description: "invalid env",
+			env:         map[string]string{"A-B": "1"},
+			script:      "echo hi",
+			expectedErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		esc, err := escalationOf(testCase.mode, testCase.user, testCase.password)
+		require.NoError(t, err, testCase.description)
+
+		script, err := esc.command(&runner.Command{
+			Script: testCase.script,
+			Sudo:   testCase.sudo,
+			Env:    testCase.env,
+		})
+		if testCase.expectedErr {
+			require.Error(t, err, testCase.description)
+			continue
+		}
+		require.NoError(t, err, testCase.description)
+
+		cmd := exec.Command("bash", "-c", script)
+		cmd.Env = append(os.Environ(), "PATH="+dir+":"+os.Getenv("PATH"))
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "%s: %s", testCase.description, out)
+		require.Equal(t, testCase.expected, string(out), testCase.description)
+	}
+}
+
+func TestEscalationOf(t *testing.T) {
+	_, err := escalationOf(runner.EscalationSudoPassword, "ubuntu", "")
+	require.Error(t, err)
+
+	_, err = escalationOf("doas", "ubuntu", "")
+	require.Error(t, err)
+}
+
+func TestRunner_probed(t *testing.T) {
+	r := &Runner{user: "ec2-user"}
+
+	esc, err := r.probed("nopasswd")
+	require.NoError(t, err)
+	require.Equal(t, escalation{}, esc)
+
+	esc, err = r.probed("root-nosudo")
+	require.NoError(t, err)
+	require.True(t, esc.root)
+	require.Equal(t, noSudo, esc.preamble)
+
+	_, err = r.probed("password")
+	require.Error(t, err, "password isn't set")
+
+	r.sudoPassword = "secret"
+	esc, err = r.probed("password")
+	require.NoError(t, err)
+	require.Contains(t, esc.preamble, "SUDO_ASKPASS")
+}
+
+func TestRunner_escalation(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.listener.Close()
+
+	pool := NewPool()
+	defer pool.Close()
+
+	// the test server echoes the probe, so sudo of the user is unknown
+	r := srv.runner(t, pool)
+	r.user = "ubuntu"
+	_, err := r.escalation(context.Background())
+	require.Error(t, err)
+
+	detectedMu.Lock()
+	detected[r.poolKey] = escalation{root: true}
+	detectedMu.Unlock()
+	defer func() {
+		detectedMu.Lock()
+		delete(detected, r.poolKey)
+		detectedMu.Unlock()
+	}()
+
+	esc, err := r.escalation(context.Background())
+	require.NoError(t, err)
+	require.True(t, esc.root, "probed escalation is cached")
+}
@@ -0,0 +1,204 @@
+package ssh
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	keepAliveInterval = time.Second * 30
+	idleTimeout       = time.Minute * 5
+	// sshd accepts 10 sessions per connection by default (MaxSessions)
+	maxSessions = 8
+)
+
+// DefaultPool is shared by all ssh runners, steps of a workflow
+// run their commands over the same connection to the node.
+var DefaultPool = NewPool()
+
+type dialFn func(ctx context.Context) (*ssh.Client, error)
+
+// Pool keeps a multiplexed connection per node. Connections are checked with
+// keepalive requests, broken ones are dropped and dialed again on the next use.
+type Pool struct {
+	m     sync.Mutex
+	conns map[string]*conn
+
+	keepAlive   time.Duration
+	idleTimeout time.Duration
+	maxSessions int
+}
+
+type conn struct {
+	client   *ssh.Client
+	sessions chan struct{}
+	closed   chan struct{}
+	once     sync.Once
+
+	// guarded by the pool mutex
+	active   int
+	lastUsed time.Time
+}
+
+func NewPool() *Pool {
+	return &Pool{
+		conns:       make(map[string]*conn),
+		keepAlive:   keepAliveInterval,
+		idleTimeout: idleTimeout,
+		maxSessions: maxSessions,
+	}
+}
+
+// session opens a session on the connection for the key. A connection that
+// has been broken since the last use is dialed again once.
+func (p *Pool) session(ctx context.Context, key string, dial dialFn) (*ssh.Session, func(), error) {
+	var err error
+
+	for attempt := 0; attempt < 2; attempt++ {
+		var c *conn
+		c, err = p.get(ctx, key, dial)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "ssh: establishing connection")
+		}
+
+		if err = p.acquire(ctx, c); err != nil {
+			return nil, nil, err
+		}
+
+		var s *ssh.Session
+		s, err = c.client.NewSession()
+		if err == nil {
+			return s, func() { p.release(c) }, nil
+		}
+
+		p.release(c)
+		p.evict(key, c)
+		logrus.Debugf("ssh: session on %s failed, reconnecting: %v", key, err)
+	}
+
+	return nil, nil, errors.Wrap(err, "ssh: creating new session")
+}
+
+func (p *Pool) get(ctx context.Context, key string, dial dialFn) (*conn, error) {
+	p.m.Lock()
+	c, ok := p.conns[key]
+	p.m.Unlock()
+	if ok {
+		return c, nil
+	}
+
+	client, err := dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+	// another runner could connect to the node meanwhile
+	if c, ok := p.conns[key]; ok {
+		client.Close()
+		return c, nil
+	}
+
+	c = &conn{
+		client:   client,
+		sessions: make(chan struct{}, p.maxSessions),
+		closed:   make(chan struct{}),
+		lastUsed: time.Now(),
+	}
+	p.conns[key] = c
+
+	go p.keepConn(key, c)
+	go func() {
+		client.Wait()
+		p.evict(key, c)
+	}()
+
+	return c, nil
+}
+
+func (p *Pool) acquire(ctx context.Context, c *conn) error {
+	select {
+	case c.sessions <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	p.m.Lock()
+	c.active++
+	p.m.Unlock()
+
+	return nil
+}
+
+func (p *Pool) release(c *conn) {
+	p.m.Lock()
+	c.active--
+	c.lastUsed = time.Now()
+	p.m.Unlock()
+
+	<-c.sessions
+}
+
+// keepConn sends keepalive requests and closes the connection when
+// the node doesn't answer or nobody used it for the idle timeout.
+func (p *Pool) keepConn(key string, c *conn) {
+	ticker := time.NewTicker(p.keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-ticker.C:
+		}
+
+		p.m.Lock()
+		idle := c.active == 0 && time.Since(c.lastUsed) > p.idleTimeout
+		p.m.Unlock()
+
+		if idle {
+			logrus.Debugf("ssh: close idle connection to %s", key)
+			p.evict(key, c)
+			return
+		}
+
+		if _, _, err := c.client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+			logrus.Debugf("ssh: keepalive to %s failed: %v", key, err)
+			p.evict(key, c)
+			return
+		}
+	}
+}
+
+func (p *Pool) evict(key string, c *conn) {
+	p.m.Lock()
+	if p.conns[key] == c {
+		delete(p.conns, key)
+	}
+	p.m.Unlock()
+
+	c.once.Do(func() {
+		close(c.closed)
+		c.client.Close()
+	})
+}
+
+// Close closes all connections of the pool.
+func (p *Pool) Close() {
+	p.m.Lock()
+	conns := make(map[string]*conn, len(p.conns))
+	for key, c := range p.conns {
+		conns[key] = c
+	}
+	p.m.Unlock()
+
+	for key, c := range conns {
+		p.evict(key, c)
+	}
+}
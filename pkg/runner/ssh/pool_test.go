@@ -0,0 +1,288 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/supergiant/control/pkg/runner"
+)
+
+// testServer is a sshd that echoes executed commands.
+type testServer struct {
+	listener net.Listener
+	conns    int32
+
+	m        sync.Mutex
+	commands []string
+	files    map[string][]byte
+}
+
+func newTestServer(t *testing.T) *testServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(ssh.ConnMetadata, ssh.PublicKey) (*ssh.Permissions, error) {
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := &testServer{listener: l, files: make(map[string][]byte)}
+	go func() {
+		for {
+			nConn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&srv.conns, 1)
+			go srv.serve(nConn, config)
+		}
+	}()
+
+	return srv
+}
+
+func (s *testServer) serve(nConn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(nConn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newCh := range chans {
+		ch, chReqs, err := newCh.Accept()
+		if err != nil {
+			continue
+		}
+
+		go func() {
+			for req := range chReqs {
+				switch req.Type {
+				case "subsystem":
+					req.Reply(true, nil)
+					go s.sftp(ch)
+					continue
+				case "pty-req", "window-change":
+					s.m.Lock()
+					s.commands = append(s.commands, req.Type)
+					s.m.Unlock()
+					req.Reply(true, nil)
+					continue
+				case "shell":
+					req.Reply(true, nil)
+					go io.Copy(ch, ch)
+					continue
+				}
+
+				if req.Type != "exec" {
+					req.Reply(false, nil)
+					continue
+				}
+
+				var exec struct{ Command string }
+				ssh.Unmarshal(req.Payload, &exec)
+				s.m.Lock()
+				s.commands = append(s.commands, exec.Command)
+				s.m.Unlock()
+				req.Reply(true, nil)
+				ch.Write([]byte(exec.Command))
+				ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+				ch.Close()
+			}
+		}()
+	}
+}
+
+// sftp serves requests needed to write a file.
+func (s *testServer) sftp(ch ssh.Channel) {
+	c := &sftpClient{w: ch, r: ch}
+	reply := func(typ byte, id uint32, fields ...interface{}) {
+		buf := &bytes.Buffer{}
+		putUint32(buf, id)
+		for _, f := range fields {
+			switch v := f.(type) {
+			case uint32:
+				putUint32(buf, v)
+			case string:
+				putString(buf, v)
+			}
+		}
+		c.send(typ, buf.Bytes())
+	}
+
+	var path string
+	for {
+		typ, data, err := c.recv()
+		if err != nil {
+			return
+		}
+
+		r := bytes.NewReader(data)
+		id, _ := getUint32(r)
+		switch typ {
+		case fxpInit:
+			c.send(fxpVersion, data)
+		case fxpOpen:
+			path, _ = getString(r)
+			reply(fxpHandle, id, "handle-1")
+		case fxpWrite:
+			getString(r)
+			var offset uint64
+			binary.Read(r, binary.BigEndian, &offset)
+			chunk, _ := getString(r)
+
+			s.m.Lock()
+			s.files[path] = append(s.files[path][:offset], chunk...)
+			s.m.Unlock()
+			reply(fxpStatus, id, uint32(fxOK), "", "")
+		case fxpClose:
+			reply(fxpStatus, id, uint32(fxOK), "", "")
+			ch.Close()
+			return
+		}
+	}
+}
+
+func (s *testServer) runner(t *testing.T, pool *Pool) *Runner {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	host, port, err := net.SplitHostPort(s.listener.Addr().String())
+	require.NoError(t, err)
+
+	r, err := NewRunner(Config{
+		Host: host,
+		Port: port,
+		User: "root",
+		Key: pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(key),
+		}),
+	})
+	require.NoError(t, err)
+
+	sshRunner := r.(*Runner)
+	sshRunner.pool = pool
+	return sshRunner
+}
+
+func run(t *testing.T, r runner.Runner, script string) string {
+	out := &bytes.Buffer{}
+	cmd, err := runner.NewCommand(context.Background(), script, out, ioutil.Discard)
+	require.NoError(t, err)
+	require.NoError(t, r.Run(cmd))
+
+	return out.String()
+}
+
+func TestPool_Reuse(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.listener.Close()
+
+	pool := NewPool()
+	defer pool.Close()
+
+	r := srv.runner(t, pool)
+	// runners of different steps for the same node
+	other := *r
+
+	require.Equal(t, "uptime", run(t, r, "uptime"))
+	require.Equal(t, "hostname", run(t, &other, "hostname"))
+	require.Equal(t, "whoami", run(t, r, "whoami"))
+	require.Equal(t, int32(1), atomic.LoadInt32(&srv.conns))
+}
+
+func TestPool_Reconnect(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.listener.Close()
+
+	pool := NewPool()
+	defer pool.Close()
+
+	r := srv.runner(t, pool)
+	run(t, r, "uptime")
+
+	// the connection is broken between steps
+	pool.m.Lock()
+	pool.conns[r.poolKey].client.Close()
+	pool.m.Unlock()
+
+	require.Equal(t, "hostname", run(t, r, "hostname"))
+	require.Equal(t, int32(2), atomic.LoadInt32(&srv.conns))
+}
+
+func TestRunner_Upload(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.listener.Close()
+
+	pool := NewPool()
+	defer pool.Close()
+
+	data := []byte(strings.Repeat("key: \"$value`id`\"\n", 5000))
+	err := srv.runner(t, pool).Upload(context.Background(), runner.File{
+		Path:  "/etc/kubernetes/audit/policy.yaml",
+		Data:  data,
+		Mode:  0600,
+		Owner: "root:root",
+	})
+	require.NoError(t, err)
+
+	srv.m.Lock()
+	defer srv.m.Unlock()
+
+	require.Len(t, srv.files, 1)
+	for tmp, uploaded := range srv.files {
+		require.Equal(t, data, uploaded)
+		require.Equal(t, []string{
+			"sudo install -D -m 0600 -o root -g root " + tmp +
+				" '/etc/kubernetes/audit/policy.yaml' && rm -f " + tmp,
+		}, srv.commands)
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&srv.conns))
+}
+
+func TestPool_Idle(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.listener.Close()
+
+	pool := NewPool()
+	pool.keepAlive = time.Millisecond * 10
+	pool.idleTimeout = time.Millisecond * 20
+
+	r := srv.runner(t, pool)
+	run(t, r, "uptime")
+
+	for i := 0; i < 100; i++ {
+		pool.m.Lock()
+		n := len(pool.conns)
+		pool.m.Unlock()
+
+		if n == 0 {
+			return
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	t.Error("idle connection must be closed")
+}
@@ -1,10 +1,16 @@
 package ssh
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/pborman/uuid"
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/ssh"
 
@@ -13,8 +19,26 @@ import (
 
 const (
 	DefaultPort = "22"
+
+	DefaultDialAttempts = 5
+	DefaultDialInterval = time.Second * 10
+)
+
+var (
+	dialMu       sync.RWMutex
+	dialAttempts = DefaultDialAttempts
+	dialInterval = DefaultDialInterval
 )
 
+// SetDialRetry changes the number of attempts to connect to a machine and
+// the first interval between them, the interval doubles after each attempt.
+func SetDialRetry(attempts int, interval time.Duration) {
+	dialMu.Lock()
+	defer dialMu.Unlock()
+
+	dialAttempts, dialInterval = attempts, interval
+}
+
 // Config is a set of params needed to create valid ssh.ClientConfig
 type Config struct {
 	Host    string `json:"host"`
@@ -22,6 +46,11 @@ type Config struct {
 	User    string `json:"user"`
 	Timeout int    `json:"timeout"`
 	Key     []byte `json:"key"`
+	// Escalation is how sudo of scripts gains root privileges, one of
+	// runner.Escalation*, sudo of users other than root is detected on
+	// the machine when it's empty.
+	Escalation   string `json:"escalation"`
+	SudoPassword string `json:"sudoPassword"`
 }
 
 // Runner is implementation of runner interface for ssh
@@ -29,6 +58,16 @@ type Runner struct {
 	host    string
 	port    string
 	sshConf *ssh.ClientConfig
+	user    string
+
+	// escalationMode is one of runner.Escalation*, sudo is probed on the
+	// machine when it's empty
+	escalationMode string
+	sudoPassword   string
+
+	// connections are shared by runners with the same pool key
+	pool    *Pool
+	poolKey string
 }
 
 // NewRunner creates ssh runner object. It requires two io.Writer
@@ -43,16 +82,32 @@ func NewRunner(config Config) (runner.Runner, error) {
 		return nil, err
 	}
 
-	r := &Runner{host: config.Host, port: config.Port, sshConf: sshConfig}
+	if config.Escalation != "" {
+		if _, err := escalationOf(config.Escalation, config.User, config.SudoPassword); err != nil {
+			return nil, err
+		}
+	}
+
+	r := &Runner{
+		host:           config.Host,
+		port:           config.Port,
+		user:           config.User,
+		sshConf:        sshConfig,
+		escalationMode: config.Escalation,
+		sudoPassword:   config.SudoPassword,
+		pool:           DefaultPool,
+	}
 	if r.port == "" {
 		r.port = DefaultPort
 	}
+	// a rotated key must not reuse connections authenticated with the old one
+	r.poolKey = fmt.Sprintf("%s@%s:%s/%x", config.User, r.host, r.port, sha256.Sum256(config.Key))
 
 	return r, nil
 }
 
-//TODO(stgleb): Add  more context like env variables?
-// Run executes a single command on ssh session.
+// Run executes a single command on ssh session, sudo of the user is set up
+// before the script.
 //
 // The returned error is nil if the command runs, has no problems
 // copying stdin, stdout, and stderr, and exits with a zero exit
@@ -62,30 +117,37 @@ func (r *Runner) Run(cmd *runner.Command) (err error) {
 		return nil
 	}
 
-	c, err := connectionWithBackOff(cmd.Ctx, r.host, r.port, r.sshConf,
-		time.Second*10, 5)
-
+	esc, err := r.escalation(cmd.Ctx)
 	if err != nil {
-		return errors.Wrap(err, "ssh: establishing connection")
+		return err
 	}
+	script, err := esc.command(cmd)
+	if err != nil {
+		return err
+	}
+
+	return r.run(cmd.Ctx, script, cmd.Out, cmd.Err)
+}
 
-	session, err := c.NewSession()
+func (r *Runner) run(ctx context.Context, script string, stdout, stderr io.Writer) error {
+	session, release, err := r.pool.session(ctx, r.poolKey, r.dial)
 	if err != nil {
-		return errors.Wrap(err, "ssh: creating new session")
+		return err
 	}
+	defer release()
 	defer session.Close()
 
-	session.Stdout = cmd.Out
-	session.Stderr = cmd.Err
+	session.Stdout = stdout
+	session.Stderr = stderr
 
 	waitCh := make(chan error)
 	go func() {
-		waitCh <- session.Run(cmd.Script)
+		waitCh <- session.Run(script)
 	}()
 
 	select {
-	case <-cmd.Ctx.Done():
-		if cmd.Ctx.Err() == context.Canceled {
+	case <-ctx.Done():
+		if ctx.Err() == context.Canceled {
 			session.Signal(ssh.SIGKILL)
 			session.Close()
 		}
@@ -97,3 +159,73 @@ func (r *Runner) Run(cmd *runner.Command) (err error) {
 	// We can close session multiple times
 	return session.Close()
 }
+
+// Upload copies the file to the machine over sftp, it's moved to
+// the target path with sudo to write where the user has no access.
+func (r *Runner) Upload(ctx context.Context, f runner.File) error {
+	tmp := fmt.Sprintf("/tmp/supergiant-%s", uuid.New()[:8])
+
+	if err := r.sftp(ctx, tmp, f.Data); err != nil {
+		return errors.Wrapf(err, "ssh: upload %s", f.Path)
+	}
+
+	out := &bytes.Buffer{}
+	cmd, err := runner.NewCommand(ctx, runner.InstallCommand(tmp, f), out, out)
+	if err != nil {
+		return err
+	}
+
+	if _, err := runner.Exec(r, cmd); err != nil {
+		return errors.Wrapf(err, "ssh: install %s", f.Path)
+	}
+
+	return nil
+}
+
+func (r *Runner) sftp(ctx context.Context, path string, data []byte) error {
+	session, release, err := r.pool.session(ctx, r.poolKey, r.dial)
+	if err != nil {
+		return err
+	}
+	defer release()
+	defer session.Close()
+
+	w, err := session.StdinPipe()
+	if err != nil {
+		return err
+	}
+	rd, err := session.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := session.RequestSubsystem("sftp"); err != nil {
+		return errors.Wrap(err, "request sftp subsystem")
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		c, err := newSFTPClient(w, rd)
+		if err == nil {
+			err = c.writeFile(path, data, 0600)
+		}
+		errCh <- err
+	}()
+
+	select {
+	case <-ctx.Done():
+		session.Close()
+		return ctx.Err()
+	case err := <-errCh:
+		return err
+	}
+}
+
+func (r *Runner) dial(ctx context.Context) (*ssh.Client, error) {
+	dialMu.RLock()
+	attempts, interval := dialAttempts, dialInterval
+	dialMu.RUnlock()
+
+	return connectionWithBackOff(ctx, r.host, r.port, r.sshConf,
+		interval, attempts)
+}
@@ -0,0 +1,222 @@
+package ssh
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// Subset of SFTP version 3 (draft-ietf-secsh-filexfer-02)
+// needed to write a file.
+const (
+	sftpVersion = 3
+
+	fxpInit    = 1
+	fxpVersion = 2
+	fxpOpen    = 3
+	fxpClose   = 4
+	fxpWrite   = 6
+	fxpStatus  = 101
+	fxpHandle  = 102
+
+	fxfWrite = 0x02
+	fxfCreat = 0x08
+	fxfTrunc = 0x10
+
+	attrPermissions = 0x04
+
+	fxOK = 0
+
+	// servers must accept writes of 32768 bytes at least
+	sftpChunkSize = 32 * 1024
+)
+
+type sftpClient struct {
+	w  io.Writer
+	r  io.Reader
+	id uint32
+}
+
+func newSFTPClient(w io.Writer, r io.Reader) (*sftpClient, error) {
+	c := &sftpClient{w: w, r: r}
+
+	buf := &bytes.Buffer{}
+	putUint32(buf, sftpVersion)
+	if err := c.send(fxpInit, buf.Bytes()); err != nil {
+		return nil, errors.Wrap(err, "sftp: init")
+	}
+
+	typ, _, err := c.recv()
+	if err != nil {
+		return nil, errors.Wrap(err, "sftp: version")
+	}
+	if typ != fxpVersion {
+		return nil, errors.Errorf("sftp: unexpected packet %d instead of version", typ)
+	}
+
+	return c, nil
+}
+
+// writeFile creates or truncates the file and writes data to it.
+func (c *sftpClient) writeFile(path string, data []byte, mode os.FileMode) error {
+	id := c.nextID()
+	buf := &bytes.Buffer{}
+	putUint32(buf, id)
+	putString(buf, path)
+	putUint32(buf, fxfWrite|fxfCreat|fxfTrunc)
+	putUint32(buf, attrPermissions)
+	putUint32(buf, uint32(mode.Perm()))
+	if err := c.send(fxpOpen, buf.Bytes()); err != nil {
+		return errors.Wrapf(err, "sftp: open %s", path)
+	}
+
+	handle, err := c.handle(id)
+	if err != nil {
+		return errors.Wrapf(err, "sftp: open %s", path)
+	}
+
+	for offset := 0; offset < len(data); offset += sftpChunkSize {
+		end := offset + sftpChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		id := c.nextID()
+		buf := &bytes.Buffer{}
+		putUint32(buf, id)
+		putString(buf, handle)
+		binary.Write(buf, binary.BigEndian, uint64(offset))
+		putString(buf, string(data[offset:end]))
+		if err := c.send(fxpWrite, buf.Bytes()); err != nil {
+			return errors.Wrapf(err, "sftp: write %s", path)
+		}
+		if err := c.status(id); err != nil {
+			return errors.Wrapf(err, "sftp: write %s", path)
+		}
+	}
+
+	id = c.nextID()
+	buf = &bytes.Buffer{}
+	putUint32(buf, id)
+	putString(buf, handle)
+	if err := c.send(fxpClose, buf.Bytes()); err != nil {
+		return errors.Wrapf(err, "sftp: close %s", path)
+	}
+
+	return errors.Wrapf(c.status(id), "sftp: close %s", path)
+}
+
+func (c *sftpClient) nextID() uint32 {
+	c.id++
+	return c.id
+}
+
+func (c *sftpClient) send(typ byte, payload []byte) error {
+	buf := &bytes.Buffer{}
+	putUint32(buf, uint32(len(payload)+1))
+	buf.WriteByte(typ)
+	buf.Write(payload)
+
+	_, err := c.w.Write(buf.Bytes())
+	return err
+}
+
+func (c *sftpClient) recv() (byte, []byte, error) {
+	var length uint32
+	if err := binary.Read(c.r, binary.BigEndian, &length); err != nil {
+		return 0, nil, err
+	}
+	if length == 0 {
+		return 0, nil, errors.New("empty packet")
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(c.r, data); err != nil {
+		return 0, nil, err
+	}
+
+	return data[0], data[1:], nil
+}
+
+// handle reads the handle of the opened file from the response to the request.
+func (c *sftpClient) handle(id uint32) (string, error) {
+	typ, data, err := c.recv()
+	if err != nil {
+		return "", err
+	}
+
+	switch typ {
+	case fxpHandle:
+		r := bytes.NewReader(data)
+		if respID, err := getUint32(r); err != nil || respID != id {
+			return "", errors.Errorf("unexpected response id for request %d", id)
+		}
+		return getString(r)
+	case fxpStatus:
+		return "", statusError(id, data)
+	}
+
+	return "", errors.Errorf("unexpected packet %d", typ)
+}
+
+func (c *sftpClient) status(id uint32) error {
+	typ, data, err := c.recv()
+	if err != nil {
+		return err
+	}
+	if typ != fxpStatus {
+		return errors.Errorf("unexpected packet %d instead of status", typ)
+	}
+
+	return statusError(id, data)
+}
+
+func statusError(id uint32, data []byte) error {
+	r := bytes.NewReader(data)
+	if respID, err := getUint32(r); err != nil || respID != id {
+		return errors.Errorf("unexpected response id for request %d", id)
+	}
+
+	code, err := getUint32(r)
+	if err != nil {
+		return err
+	}
+	if code == fxOK {
+		return nil
+	}
+
+	msg, _ := getString(r)
+	return errors.Errorf("status %d: %s", code, msg)
+}
+
+func putUint32(buf *bytes.Buffer, v uint32) {
+	binary.Write(buf, binary.BigEndian, v)
+}
+
+func putString(buf *bytes.Buffer, s string) {
+	putUint32(buf, uint32(len(s)))
+	buf.WriteString(s)
+}
+
+func getUint32(r io.Reader) (uint32, error) {
+	var v uint32
+	err := binary.Read(r, binary.BigEndian, &v)
+	return v, err
+}
+
+func getString(r io.Reader) (string, error) {
+	n, err := getUint32(r)
+	if err != nil {
+		return "", err
+	}
+
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,87 @@
+package ssh
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// Terminal is an interactive shell on the machine.
+type Terminal struct {
+	client  *ssh.Client
+	session *ssh.Session
+	stdin   io.WriteCloser
+	stdout  io.Reader
+}
+
+// Terminal opens a login shell with a pseudo terminal. It uses a dedicated
+// connection, interactive sessions must not hold sessions of the pool.
+func (r *Runner) Terminal(ctx context.Context, cols, rows int) (*Terminal, error) {
+	client, err := r.dial(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "ssh: establishing connection")
+	}
+
+	t, err := newTerminal(client, cols, rows)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func newTerminal(client *ssh.Client, cols, rows int) (*Terminal, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, errors.Wrap(err, "ssh: creating new session")
+	}
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	// stderr is merged to stdout by the pseudo terminal
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	modes := ssh.TerminalModes{
+		ssh.ECHO:          1,
+		ssh.TTY_OP_ISPEED: 14400,
+		ssh.TTY_OP_OSPEED: 14400,
+	}
+	if err := session.RequestPty("xterm", rows, cols, modes); err != nil {
+		return nil, errors.Wrap(err, "ssh: request pty")
+	}
+	if err := session.Shell(); err != nil {
+		return nil, errors.Wrap(err, "ssh: start shell")
+	}
+
+	return &Terminal{
+		client:  client,
+		session: session,
+		stdin:   stdin,
+		stdout:  stdout,
+	}, nil
+}
+
+func (t *Terminal) Read(p []byte) (int, error) {
+	return t.stdout.Read(p)
+}
+
+func (t *Terminal) Write(p []byte) (int, error) {
+	return t.stdin.Write(p)
+}
+
+func (t *Terminal) Resize(cols, rows int) error {
+	return t.session.WindowChange(rows, cols)
+}
+
+func (t *Terminal) Close() error {
+	t.session.Close()
+	return t.client.Close()
+}
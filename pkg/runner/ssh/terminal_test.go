@@ -0,0 +1,50 @@
+package ssh
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunner_Terminal(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.listener.Close()
+
+	pool := NewPool()
+	defer pool.Close()
+
+	term, err := srv.runner(t, pool).Terminal(context.Background(), 120, 40)
+	require.NoError(t, err)
+	defer term.Close()
+
+	_, err = term.Write([]byte("uptime\n"))
+	require.NoError(t, err)
+
+	out := make([]byte, len("uptime\n"))
+	_, err = io.ReadFull(term, out)
+	require.NoError(t, err)
+	require.Equal(t, "uptime\n", string(out))
+
+	require.NoError(t, term.Resize(80, 24))
+	// interactive sessions don't use pooled connections
+	require.Empty(t, pool.conns)
+
+	// window changes don't wait for replies
+	for i := 0; i < 100; i++ {
+		srv.m.Lock()
+		n := len(srv.commands)
+		srv.m.Unlock()
+
+		if n == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	srv.m.Lock()
+	defer srv.m.Unlock()
+	require.Equal(t, []string{"pty-req", "window-change"}, srv.commands)
+}
@@ -0,0 +1,130 @@
+package ssm
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	awsclient "github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/private/protocol/jsonrpc"
+)
+
+// NOTE: the vendored aws sdk doesn't include the ssm service, client
+// implements operations used by the runner over the sdk json protocol.
+const (
+	serviceName  = "ssm"
+	serviceID    = "SSM"
+	apiVersion   = "2014-11-06"
+	targetPrefix = "AmazonSSM"
+)
+
+type client struct {
+	*awsclient.Client
+}
+
+func newClient(p awsclient.ConfigProvider, cfgs ...*aws.Config) *client {
+	c := p.ClientConfig(serviceName, cfgs...)
+	if c.SigningNameDerived || len(c.SigningName) == 0 {
+		c.SigningName = serviceName
+	}
+
+	svc := &client{
+		Client: awsclient.New(
+			*c.Config,
+			metadata.ClientInfo{
+				ServiceName:   serviceName,
+				ServiceID:     serviceID,
+				SigningName:   c.SigningName,
+				SigningRegion: c.SigningRegion,
+				Endpoint:      c.Endpoint,
+				APIVersion:    apiVersion,
+				JSONVersion:   "1.1",
+				TargetPrefix:  targetPrefix,
+			},
+			c.Handlers,
+		),
+	}
+
+	svc.Handlers.Sign.PushBackNamed(v4.SignRequestHandler)
+	svc.Handlers.Build.PushBackNamed(jsonrpc.BuildHandler)
+	svc.Handlers.Unmarshal.PushBackNamed(jsonrpc.UnmarshalHandler)
+	svc.Handlers.UnmarshalMeta.PushBackNamed(jsonrpc.UnmarshalMetaHandler)
+	svc.Handlers.UnmarshalError.PushBackNamed(jsonrpc.UnmarshalErrorHandler)
+
+	return svc
+}
+
+func (c *client) send(ctx aws.Context, name string, input, output interface{}) error {
+	req := c.NewRequest(&request.Operation{
+		Name:       name,
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}, input, output)
+	req.SetContext(ctx)
+
+	return req.Send()
+}
+
+func (c *client) SendCommand(ctx aws.Context, input *sendCommandInput) (*sendCommandOutput, error) {
+	output := &sendCommandOutput{}
+	return output, c.send(ctx, "SendCommand", input, output)
+}
+
+func (c *client) GetCommandInvocation(ctx aws.Context, input *getCommandInvocationInput) (*getCommandInvocationOutput, error) {
+	output := &getCommandInvocationOutput{}
+	return output, c.send(ctx, "GetCommandInvocation", input, output)
+}
+
+func (c *client) CancelCommand(ctx aws.Context, input *cancelCommandInput) error {
+	return c.send(ctx, "CancelCommand", input, &cancelCommandOutput{})
+}
+
+type sendCommandInput struct {
+	_ struct{} `type:"structure"`
+
+	DocumentName   *string              `type:"string"`
+	InstanceIds    []*string            `type:"list"`
+	Parameters     map[string][]*string `type:"map"`
+	TimeoutSeconds *int64               `type:"integer"`
+	Comment        *string              `type:"string"`
+}
+
+type sendCommandOutput struct {
+	_ struct{} `type:"structure"`
+
+	Command *command `type:"structure"`
+}
+
+type command struct {
+	_ struct{} `type:"structure"`
+
+	CommandId *string `type:"string"`
+}
+
+type getCommandInvocationInput struct {
+	_ struct{} `type:"structure"`
+
+	CommandId  *string `type:"string"`
+	InstanceId *string `type:"string"`
+}
+
+type getCommandInvocationOutput struct {
+	_ struct{} `type:"structure"`
+
+	Status                *string `type:"string"`
+	StatusDetails         *string `type:"string"`
+	ResponseCode          *int64  `type:"integer"`
+	StandardOutputContent *string `type:"string"`
+	StandardErrorContent  *string `type:"string"`
+}
+
+type cancelCommandInput struct {
+	_ struct{} `type:"structure"`
+
+	CommandId   *string   `type:"string"`
+	InstanceIds []*string `type:"list"`
+}
+
+type cancelCommandOutput struct {
+	_ struct{} `type:"structure"`
+}
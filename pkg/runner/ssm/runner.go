@@ -0,0 +1,243 @@
+package ssm
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/runner"
+)
+
+const (
+	documentName = "AWS-RunShellScript"
+
+	// deliveryTimeout is how long ssm tries to deliver a command, an agent
+	// of a new instance needs some time to start and register.
+	deliveryTimeout  = 600
+	executionTimeout = time.Hour * 2
+	pollInterval     = time.Second * 2
+
+	statusSuccess = "Success"
+
+	errInvocationDoesNotExist = "InvocationDoesNotExist"
+	errInvalidInstanceID      = "InvalidInstanceId"
+)
+
+var (
+	ErrInstanceNotSpecified = errors.New("instance id not specified")
+	ErrInvalidCredentials   = errors.New("aws credentials not specified")
+)
+
+// Config is a set of params needed to send commands to an aws instance.
+type Config struct {
+	InstanceID string `json:"instanceId"`
+	Region     string `json:"region"`
+	KeyID      string `json:"keyId"`
+	Secret     string `json:"secret"`
+	// User runs commands instead of root, it keeps scripts
+	// working the same way as over ssh.
+	User string `json:"user"`
+}
+
+type commandService interface {
+	SendCommand(aws.Context, *sendCommandInput) (*sendCommandOutput, error)
+	GetCommandInvocation(aws.Context, *getCommandInvocationInput) (*getCommandInvocationOutput, error)
+	CancelCommand(aws.Context, *cancelCommandInput) error
+}
+
+// Runner is implementation of runner interface for AWS Systems Manager.
+// Output of a command is written when the command finishes, ssm returns
+// only first 24000 characters of it.
+type Runner struct {
+	instanceID   string
+	user         string
+	svc          commandService
+	pollInterval time.Duration
+}
+
+// NewRunner creates ssm runner object, the instance must run an ssm agent
+// and have an instance profile that allows the agent to connect to ssm.
+func NewRunner(config Config) (runner.Runner, error) {
+	if strings.TrimSpace(config.InstanceID) == "" {
+		return nil, ErrInstanceNotSpecified
+	}
+
+	if config.KeyID == "" || config.Secret == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String(config.Region),
+		Credentials: credentials.NewStaticCredentials(config.KeyID, config.Secret, ""),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "ssm: create session")
+	}
+
+	return &Runner{
+		instanceID:   config.InstanceID,
+		user:         config.User,
+		svc:          newClient(sess),
+		pollInterval: pollInterval,
+	}, nil
+}
+
+// Run sends a script to the instance and waits until it finishes.
+//
+// The returned error is nil if the command was delivered and exited
+// with a zero exit status.
+func (r *Runner) Run(cmd *runner.Command) error {
+	if cmd == nil || strings.TrimSpace(cmd.Script) == "" {
+		return nil
+	}
+
+	if cmd.Ctx == nil {
+		return runner.ErrNilContext
+	}
+
+	env, err := runner.EnvAssignments(cmd.Env)
+	if err != nil {
+		return errors.Wrap(err, "ssm: command env")
+	}
+
+	commandID, err := r.send(cmd.Ctx, r.wrap(cmd.Script, env, cmd.Sudo))
+	if err != nil {
+		return errors.Wrap(err, "ssm: send command")
+	}
+
+	for {
+		select {
+		case <-cmd.Ctx.Done():
+			// the context is already done, cancel with a fresh one
+			cancelErr := r.svc.CancelCommand(context.Background(), &cancelCommandInput{
+				CommandId:   aws.String(commandID),
+				InstanceIds: []*string{aws.String(r.instanceID)},
+			})
+			if cancelErr != nil {
+				return errors.Wrapf(cmd.Ctx.Err(), "ssm: cancel command %s: %v", commandID, cancelErr)
+			}
+			return cmd.Ctx.Err()
+		case <-time.After(r.pollInterval):
+		}
+
+		out, err := r.svc.GetCommandInvocation(cmd.Ctx, &getCommandInvocationInput{
+			CommandId:  aws.String(commandID),
+			InstanceId: aws.String(r.instanceID),
+		})
+		if err != nil {
+			// an invocation appears some time after a command is sent
+			if isErrCode(err, errInvocationDoesNotExist) {
+				continue
+			}
+			return errors.Wrapf(err, "ssm: get command %s", commandID)
+		}
+
+		if !isDone(aws.StringValue(out.Status)) {
+			continue
+		}
+
+		if err := writeOutput(cmd, out); err != nil {
+			return errors.Wrap(err, "ssm: write output")
+		}
+
+		if aws.StringValue(out.Status) != statusSuccess {
+			return errors.Wrapf(&runner.ExitError{Code: int(aws.Int64Value(out.ResponseCode))},
+				"ssm: command %s %s", commandID, strings.ToLower(aws.StringValue(out.StatusDetails)))
+		}
+
+		return nil
+	}
+}
+
+func (r *Runner) send(ctx context.Context, command string) (string, error) {
+	timeout := executionTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	input := &sendCommandInput{
+		DocumentName: aws.String(documentName),
+		InstanceIds:  []*string{aws.String(r.instanceID)},
+		Parameters: map[string][]*string{
+			"commands":         {aws.String(command)},
+			"executionTimeout": {aws.String(fmt.Sprintf("%d", int64(timeout.Seconds())+1))},
+		},
+		TimeoutSeconds: aws.Int64(deliveryTimeout),
+		Comment:        aws.String("supergiant"),
+	}
+
+	for {
+		out, err := r.svc.SendCommand(ctx, input)
+		if err == nil {
+			if out.Command == nil || out.Command.CommandId == nil {
+				return "", errors.New("empty command id")
+			}
+			return *out.Command.CommandId, nil
+		}
+
+		// an instance isn't known to ssm until its agent registers
+		if !isErrCode(err, errInvalidInstanceID) {
+			return "", err
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", errors.Wrapf(err, "instance %s", r.instanceID)
+		case <-time.After(r.pollInterval * 5):
+		}
+	}
+}
+
+// wrap makes a command that runs the script with bash as the runner user,
+// the script is encoded to avoid quoting issues. The agent runs commands as
+// root, so scripts that need root aren't run as the user.
+func (r *Runner) wrap(script string, env []string, asRoot bool) string {
+	encoded := base64.StdEncoding.EncodeToString([]byte(script))
+
+	run := append([]string{"env"}, env...)
+	run = append(run, "bash", "${SCRIPT}")
+	if !asRoot && r.user != "" && r.user != "root" {
+		run = append([]string{"sudo", "-H", "-u", r.user}, run...)
+	}
+
+	return strings.Join([]string{
+		"SCRIPT=$(mktemp)",
+		fmt.Sprintf("echo %s | base64 -d > ${SCRIPT}", encoded),
+		"chmod 755 ${SCRIPT}",
+		strings.Join(run, " "),
+		"RC=$?",
+		"rm -f ${SCRIPT}",
+		"exit ${RC}",
+	}, "\n")
+}
+
+func writeOutput(cmd *runner.Command, out *getCommandInvocationOutput) error {
+	if _, err := io.WriteString(cmd.Out, aws.StringValue(out.StandardOutputContent)); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(cmd.Err, aws.StringValue(out.StandardErrorContent))
+	return err
+}
+
+func isDone(status string) bool {
+	switch status {
+	case "Pending", "InProgress", "Delayed", "Cancelling":
+		return false
+	}
+	return true
+}
+
+func isErrCode(err error, code string) bool {
+	awsErr, ok := errors.Cause(err).(awserr.Error)
+	return ok && awsErr.Code() == code
+}
@@ -0,0 +1,213 @@
+package ssm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/runner"
+)
+
+type fakeService struct {
+	sendErrs    []error
+	invocations []*getCommandInvocationOutput
+	getErrs     []error
+
+	sent      *sendCommandInput
+	cancelled bool
+}
+
+func (f *fakeService) SendCommand(_ aws.Context, input *sendCommandInput) (*sendCommandOutput, error) {
+	f.sent = input
+	if len(f.sendErrs) > 0 {
+		err := f.sendErrs[0]
+		f.sendErrs = f.sendErrs[1:]
+		return nil, err
+	}
+	return &sendCommandOutput{Command: &command{CommandId: aws.String("cmd-1")}}, nil
+}
+
+func (f *fakeService) GetCommandInvocation(aws.Context, *getCommandInvocationInput) (*getCommandInvocationOutput, error) {
+	if len(f.getErrs) > 0 {
+		err := f.getErrs[0]
+		f.getErrs = f.getErrs[1:]
+		return nil, err
+	}
+	out := f.invocations[0]
+	if len(f.invocations) > 1 {
+		f.invocations = f.invocations[1:]
+	}
+	return out, nil
+}
+
+func (f *fakeService) CancelCommand(aws.Context, *cancelCommandInput) error {
+	f.cancelled = true
+	return nil
+}
+
+func invocation(status, stdout string, code int64) *getCommandInvocationOutput {
+	return &getCommandInvocationOutput{
+		Status:                aws.String(status),
+		StatusDetails:         aws.String(status),
+		ResponseCode:          aws.Int64(code),
+		StandardOutputContent: aws.String(stdout),
+		StandardErrorContent:  aws.String(""),
+	}
+}
+
+func TestNewRunner(t *testing.T) {
+	_, err := NewRunner(Config{})
+	require.Equal(t, ErrInstanceNotSpecified, err)
+
+	_, err = NewRunner(Config{InstanceID: "i-1"})
+	require.Equal(t, ErrInvalidCredentials, err)
+
+	r, err := NewRunner(Config{InstanceID: "i-1", Region: "us-east-1", KeyID: "key", Secret: "secret"})
+	require.Nil(t, err)
+	require.NotNil(t, r)
+}
+
+func TestRunner_Run(t *testing.T) {
+	tcs := []struct {
+		description string
+		svc         *fakeService
+		expectedOut string
+		expectErr   bool
+	}{
+		{
+			description: "success",
+			svc: &fakeService{
+				getErrs: []error{awserr.New(errInvocationDoesNotExist, "", nil)},
+				invocations: []*getCommandInvocationOutput{
+					invocation("InProgress", "", -1),
+					invocation(statusSuccess, "hello", 0),
+				},
+			},
+			expectedOut: "hello",
+		},
+		{
+			description: "agent is not registered yet",
+			svc: &fakeService{
+				sendErrs:    []error{awserr.New(errInvalidInstanceID, "", nil)},
+				invocations: []*getCommandInvocationOutput{invocation(statusSuccess, "hello", 0)},
+			},
+			expectedOut: "hello",
+		},
+		{
+			description: "send error",
+			svc: &fakeService{
+				sendErrs: []error{errors.New("access denied")},
+			},
+			expectErr: true,
+		},
+		{
+			description: "command failed",
+			svc: &fakeService{
+				invocations: []*getCommandInvocationOutput{invocation("Failed", "output", 1)},
+			},
+			expectedOut: "output",
+			expectErr:   true,
+		},
+	}
+
+	for _, tc := range tcs {
+		r := &Runner{
+			instanceID:   "i-1",
+			user:         "ubuntu",
+			svc:          tc.svc,
+			pollInterval: time.Millisecond,
+		}
+
+		out := &bytes.Buffer{}
+		cmd, err := runner.NewCommand(context.Background(), "echo hello", out, out)
+		require.Nil(t, err)
+
+		err = r.Run(cmd)
+		require.Equalf(t, tc.expectErr, err != nil, "%s: %v", tc.description, err)
+		require.Equalf(t, tc.expectedOut, out.String(), tc.description)
+	}
+}
+
+func TestRunner_RunCancel(t *testing.T) {
+	svc := &fakeService{
+		invocations: []*getCommandInvocationOutput{invocation("InProgress", "", -1)},
+	}
+	r := &Runner{
+		instanceID:   "i-1",
+		svc:          svc,
+		pollInterval: time.Millisecond,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+
+	cmd, err := runner.NewCommand(ctx, "sleep 100", ioutil.Discard, ioutil.Discard)
+	require.Nil(t, err)
+
+	require.Equal(t, context.DeadlineExceeded, errors.Cause(r.Run(cmd)))
+	require.True(t, svc.cancelled, "command must be cancelled")
+}
+
+func TestRunner_wrap(t *testing.T) {
+	script := "echo \"$HOME\" 'quoted'"
+	r := &Runner{user: "ubuntu"}
+
+	wrapped := r.wrap(script, nil, false)
+	require.Contains(t, wrapped, base64.StdEncoding.EncodeToString([]byte(script)))
+	require.Contains(t, wrapped, "sudo -H -u ubuntu env bash")
+
+	// scripts that need root run as root with their variables
+	wrapped = r.wrap(script, []string{"HOME='/root'"}, true)
+	require.NotContains(t, wrapped, "sudo")
+	require.Contains(t, wrapped, "env HOME='/root' bash ${SCRIPT}")
+
+	r.user = "root"
+	require.NotContains(t, r.wrap(script, nil, false), "sudo")
+}
+
+func TestClient_SendCommand(t *testing.T) {
+	var (
+		target string
+		body   map[string]interface{}
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target = r.Header.Get("X-Amz-Target")
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"Command":{"CommandId":"cmd-1"}}`))
+	}))
+	defer srv.Close()
+
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(srv.URL),
+		Credentials: credentials.NewStaticCredentials("key", "secret", ""),
+	})
+	require.Nil(t, err)
+
+	out, err := newClient(sess).SendCommand(context.Background(), &sendCommandInput{
+		DocumentName: aws.String(documentName),
+		InstanceIds:  []*string{aws.String("i-1")},
+		Parameters: map[string][]*string{
+			"commands": {aws.String("uptime")},
+		},
+	})
+	require.Nil(t, err)
+	require.Equal(t, "cmd-1", aws.StringValue(out.Command.CommandId))
+	require.Equal(t, "AmazonSSM.SendCommand", target)
+	require.Equal(t, documentName, body["DocumentName"])
+	require.Equal(t, map[string]interface{}{"commands": []interface{}{"uptime"}}, body["Parameters"])
+}
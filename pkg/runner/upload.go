@@ -0,0 +1,106 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var ownerRe = regexp.MustCompile(`^[a-z_][a-z0-9_.-]*(:[a-z_][a-z0-9_.-]*)?$`)
+
+// File is a file to be written on a machine.
+type File struct {
+	Path string
+	Data []byte
+	Mode os.FileMode
+	// Owner is user or user:group of the file, it's root when empty
+	Owner string
+}
+
+// Validate checks that the file can be written safely.
+func (f File) Validate() error {
+	if !path.IsAbs(f.Path) || path.Clean(f.Path) != f.Path {
+		return errors.Errorf("file path %q must be absolute and clean", f.Path)
+	}
+
+	if f.Owner != "" && !ownerRe.MatchString(f.Owner) {
+		return errors.Errorf("invalid file owner %q", f.Owner)
+	}
+
+	return nil
+}
+
+// Uploader is implemented by runners that copy files to machines natively.
+type Uploader interface {
+	Upload(ctx context.Context, f File) error
+}
+
+// Upload writes the file on the machine. Runners that can't copy files get it
+// with a shell command that carries base64 encoded content, so the data is
+// written as is without shell expansions.
+func Upload(ctx context.Context, r Runner, f File) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+
+	target := r
+	if rec, ok := r.(*Recorder); ok {
+		target = rec.Runner
+	}
+
+	if u, ok := target.(Uploader); ok {
+		return u.Upload(ctx, f)
+	}
+
+	script := fmt.Sprintf(`set -e
+TMP=$(mktemp)
+echo '%s' | base64 -d > "$TMP"
+%s
+`, base64.StdEncoding.EncodeToString(f.Data), InstallCommand(`"$TMP"`, f))
+
+	return runScript(ctx, r, script)
+}
+
+// InstallCommand moves the uploaded file from the temporary path to its place
+// with sudo, parent directories are created.
+func InstallCommand(tmp string, f File) string {
+	mode := f.Mode
+	if mode == 0 {
+		mode = 0644
+	}
+
+	args := []string{"sudo", "install", "-D", fmt.Sprintf("-m %04o", mode.Perm())}
+	if f.Owner != "" {
+		parts := strings.SplitN(f.Owner, ":", 2)
+		args = append(args, "-o "+parts[0])
+		if len(parts) == 2 {
+			args = append(args, "-g "+parts[1])
+		}
+	}
+	args = append(args, tmp, Quote(f.Path))
+
+	return fmt.Sprintf("%s && rm -f %s", strings.Join(args, " "), tmp)
+}
+
+// Quote quotes the string for a POSIX shell.
+func Quote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+func runScript(ctx context.Context, r Runner, script string) error {
+	out := &bytes.Buffer{}
+	cmd, err := NewCommand(ctx, script, out, out)
+	if err != nil {
+		return err
+	}
+
+	_, err = Exec(r, cmd)
+	return err
+}
@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"context"
+	"encoding/base64"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_Validate(t *testing.T) {
+	require.NoError(t, File{Path: "/etc/kubernetes/audit/policy.yaml", Owner: "root:root"}.Validate())
+	require.Error(t, File{Path: "policy.yaml"}.Validate())
+	require.Error(t, File{Path: "/etc/../root/.ssh/authorized_keys"}.Validate())
+	require.Error(t, File{Path: "/etc/policy.yaml", Owner: "root; reboot"}.Validate())
+}
+
+func TestUpload(t *testing.T) {
+	data := "password: \"$ecret`id`\"\nquote: 'EOF'\n"
+	r := &fakeRunner{}
+
+	err := Upload(context.Background(), NewRecorder(r, func(Result) {}), File{
+		Path:  "/etc/supergiant/it's values.yaml",
+		Data:  []byte(data),
+		Mode:  0600,
+		Owner: "ubuntu:admin",
+	})
+	require.NoError(t, err)
+
+	m := regexp.MustCompile(`echo '([A-Za-z0-9+/=]+)' \| base64 -d`).FindStringSubmatch(r.script)
+	require.NotNil(t, m, r.script)
+	decoded, err := base64.StdEncoding.DecodeString(m[1])
+	require.NoError(t, err)
+	require.Equal(t, data, string(decoded))
+
+	require.Contains(t, r.script, `sudo install -D -m 0600 -o ubuntu -g admin "$TMP" '/etc/supergiant/it'\''s values.yaml'`)
+}
+
+type fakeUploader struct {
+	fakeRunner
+	files []File
+}
+
+func (f *fakeUploader) Upload(ctx context.Context, file File) error {
+	f.files = append(f.files, file)
+	return nil
+}
+
+func TestUploadUploader(t *testing.T) {
+	u := &fakeUploader{}
+
+	err := Upload(context.Background(), NewRecorder(u, func(Result) {}), File{
+		Path: "/etc/chrony/chrony.conf",
+		Data: []byte("server 0.pool.ntp.org"),
+	})
+	require.NoError(t, err)
+	require.Len(t, u.files, 1)
+	require.Empty(t, u.script, "native upload must be used")
+}
@@ -0,0 +1,255 @@
+package winrm
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+// WS-Management actions and uris of the windows remote shell.
+// https://docs.microsoft.com/en-us/openspecs/windows_protocols/ms-wsmv
+const (
+	actionCreate  = "http://schemas.xmlsoap.org/ws/2004/09/transfer/Create"
+	actionDelete  = "http://schemas.xmlsoap.org/ws/2004/09/transfer/Delete"
+	actionCommand = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/Command"
+	actionReceive = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/Receive"
+	actionSignal  = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/Signal"
+
+	resourceShell    = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/cmd"
+	signalTerminate  = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/signal/terminate"
+	commandStateDone = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/CommandState/Done"
+
+	// faultTimedOut is returned by Receive when a command had no output
+	// during the operation timeout, the command is still running.
+	faultTimedOut = "2150858793"
+
+	operationTimeout = time.Second * 60
+	maxEnvelopeSize  = 153600
+)
+
+type client struct {
+	url      string
+	user     string
+	password string
+	http     *http.Client
+}
+
+func newClient(config Config) *client {
+	scheme := "http"
+	if config.HTTPS {
+		scheme = "https"
+	}
+
+	return &client{
+		url:      fmt.Sprintf("%s://%s:%d/wsman", scheme, config.Host, config.Port),
+		user:     config.User,
+		password: config.Password,
+		http: &http.Client{
+			// a receive request is held by the server up to the operation timeout
+			Timeout: operationTimeout + time.Second*30,
+			Transport: &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{
+					InsecureSkipVerify: config.Insecure,
+				},
+			},
+		},
+	}
+}
+
+type stream struct {
+	Name string `xml:"Name,attr"`
+	Data string `xml:",chardata"`
+}
+
+type commandState struct {
+	State    string `xml:"State,attr"`
+	ExitCode int    `xml:"ExitCode"`
+}
+
+type response struct {
+	ShellID    string       `xml:"Body>Shell>ShellId"`
+	SelectorID string       `xml:"Body>ResourceCreated>ReferenceParameters>SelectorSet>Selector"`
+	CommandID  string       `xml:"Body>CommandResponse>CommandId"`
+	Streams    []stream     `xml:"Body>ReceiveResponse>Stream"`
+	State      commandState `xml:"Body>ReceiveResponse>CommandState"`
+}
+
+func (c *client) createShell(ctx context.Context) (string, error) {
+	body := `<rsp:Shell><rsp:InputStreams>stdin</rsp:InputStreams>` +
+		`<rsp:OutputStreams>stdout stderr</rsp:OutputStreams></rsp:Shell>`
+	options := map[string]string{
+		"WINRS_NOPROFILE": "FALSE",
+		"WINRS_CODEPAGE":  "65001",
+	}
+
+	resp, err := c.do(ctx, actionCreate, "", options, body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.ShellID != "" {
+		return resp.ShellID, nil
+	}
+	if resp.SelectorID != "" {
+		return resp.SelectorID, nil
+	}
+
+	return "", errors.New("empty shell id")
+}
+
+func (c *client) deleteShell(ctx context.Context, shellID string) error {
+	_, err := c.do(ctx, actionDelete, shellID, nil, "")
+	return err
+}
+
+func (c *client) command(ctx context.Context, shellID, command string, args ...string) (string, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString(`<rsp:CommandLine><rsp:Command>`)
+	xml.EscapeText(buf, []byte(command))
+	buf.WriteString(`</rsp:Command>`)
+	for _, arg := range args {
+		buf.WriteString(`<rsp:Arguments>`)
+		xml.EscapeText(buf, []byte(arg))
+		buf.WriteString(`</rsp:Arguments>`)
+	}
+	buf.WriteString(`</rsp:CommandLine>`)
+
+	options := map[string]string{
+		"WINRS_CONSOLEMODE_STDIN": "TRUE",
+		"WINRS_SKIP_CMD_SHELL":    "FALSE",
+	}
+
+	resp, err := c.do(ctx, actionCommand, shellID, options, buf.String())
+	if err != nil {
+		return "", err
+	}
+
+	if resp.CommandID == "" {
+		return "", errors.New("empty command id")
+	}
+
+	return resp.CommandID, nil
+}
+
+// receive returns output of a command that has been produced since the
+// previous call, the timedOut flag is set if there was no output at all.
+func (c *client) receive(ctx context.Context, shellID, commandID string) (resp *response, timedOut bool, err error) {
+	body := fmt.Sprintf(`<rsp:Receive><rsp:DesiredStream CommandId="%s">stdout stderr</rsp:DesiredStream></rsp:Receive>`, commandID)
+
+	resp, err = c.do(ctx, actionReceive, shellID, nil, body)
+	if err != nil {
+		if errors.Cause(err) == errTimedOut {
+			return nil, true, nil
+		}
+		return nil, false, err
+	}
+
+	return resp, false, nil
+}
+
+func (c *client) signal(ctx context.Context, shellID, commandID string) error {
+	body := fmt.Sprintf(`<rsp:Signal CommandId="%s"><rsp:Code>%s</rsp:Code></rsp:Signal>`, commandID, signalTerminate)
+
+	_, err := c.do(ctx, actionSignal, shellID, nil, body)
+	return err
+}
+
+var errTimedOut = errors.New("operation timed out")
+
+func (c *client) do(ctx context.Context, action, shellID string, options map[string]string, body string) (*response, error) {
+	req, err := http.NewRequest(http.MethodPost, c.url, strings.NewReader(c.envelope(action, shellID, options, body)))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.SetBasicAuth(c.user, c.password)
+	req.Header.Set("Content-Type", "application/soap+xml;charset=UTF-8")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read response")
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return nil, ErrUnauthorized
+	case resp.StatusCode != http.StatusOK:
+		if bytes.Contains(data, []byte(faultTimedOut)) {
+			return nil, errTimedOut
+		}
+		return nil, errors.Errorf("unexpected response %s: %s", resp.Status, faultReason(data))
+	}
+
+	r := &response{}
+	if err := xml.Unmarshal(data, r); err != nil {
+		return nil, errors.Wrap(err, "decode response")
+	}
+
+	return r, nil
+}
+
+func (c *client) envelope(action, shellID string, options map[string]string, body string) string {
+	buf := &bytes.Buffer{}
+	buf.WriteString(`<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope"` +
+		` xmlns:a="http://schemas.xmlsoap.org/ws/2004/08/addressing"` +
+		` xmlns:w="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd"` +
+		` xmlns:rsp="http://schemas.microsoft.com/wbem/wsman/1/windows/shell"><env:Header>`)
+	buf.WriteString(`<a:To>`)
+	xml.EscapeText(buf, []byte(c.url))
+	buf.WriteString(`</a:To>`)
+	buf.WriteString(`<a:ReplyTo><a:Address env:mustUnderstand="true">` +
+		`http://schemas.xmlsoap.org/ws/2004/08/addressing/role/anonymous</a:Address></a:ReplyTo>`)
+	fmt.Fprintf(buf, `<w:MaxEnvelopeSize env:mustUnderstand="true">%d</w:MaxEnvelopeSize>`, maxEnvelopeSize)
+	fmt.Fprintf(buf, `<a:MessageID>uuid:%s</a:MessageID>`, uuid.New())
+	buf.WriteString(`<w:Locale xml:lang="en-US" env:mustUnderstand="false"/>`)
+	fmt.Fprintf(buf, `<w:OperationTimeout>PT%dS</w:OperationTimeout>`, int(operationTimeout.Seconds()))
+	fmt.Fprintf(buf, `<w:ResourceURI env:mustUnderstand="true">%s</w:ResourceURI>`, resourceShell)
+	fmt.Fprintf(buf, `<a:Action env:mustUnderstand="true">%s</a:Action>`, action)
+
+	if shellID != "" {
+		fmt.Fprintf(buf, `<w:SelectorSet><w:Selector Name="ShellId">%s</w:Selector></w:SelectorSet>`, shellID)
+	}
+
+	if len(options) > 0 {
+		buf.WriteString(`<w:OptionSet>`)
+		for name, value := range options {
+			fmt.Fprintf(buf, `<w:Option Name="%s">%s</w:Option>`, name, value)
+		}
+		buf.WriteString(`</w:OptionSet>`)
+	}
+
+	buf.WriteString(`</env:Header><env:Body>`)
+	buf.WriteString(body)
+	buf.WriteString(`</env:Body></env:Envelope>`)
+
+	return buf.String()
+}
+
+// faultReason extracts a human readable message from a soap fault.
+func faultReason(data []byte) string {
+	fault := struct {
+		Reason string `xml:"Body>Fault>Reason>Text"`
+	}{}
+
+	if err := xml.Unmarshal(data, &fault); err != nil || fault.Reason == "" {
+		return string(data)
+	}
+
+	return strings.TrimSpace(fault.Reason)
+}
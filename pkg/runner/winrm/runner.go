@@ -0,0 +1,261 @@
+package winrm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/runner"
+)
+
+const (
+	DefaultPort      = 5985
+	DefaultHTTPSPort = 5986
+
+	// chunkSize keeps upload commands below the 8191 characters
+	// limit of a cmd.exe command line.
+	chunkSize = 6000
+
+	// a windows machine restarts while its features are installed,
+	// connections are retried until it gets back.
+	defaultConnectTimeout = time.Minute * 10
+	retryInterval         = time.Second * 10
+)
+
+var (
+	ErrHostNotSpecified   = errors.New("host not specified")
+	ErrInvalidCredentials = errors.New("winrm credentials not specified")
+	ErrUnauthorized       = errors.New("unauthorized")
+)
+
+// Config is a set of params needed to connect to a WinRM listener.
+type Config struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	User     string `json:"user"`
+	Password string `json:"password"`
+	HTTPS    bool   `json:"https"`
+	// Insecure skips verification of the listener certificate,
+	// provisioned machines use self-signed ones.
+	Insecure bool `json:"insecure"`
+	// Timeout in seconds to wait for the machine to accept connections
+	Timeout int `json:"timeout"`
+}
+
+// Runner is implementation of runner interface for WinRM, scripts
+// are executed with PowerShell.
+type Runner struct {
+	host           string
+	client         *client
+	connectTimeout time.Duration
+	retryInterval  time.Duration
+}
+
+// NewRunner creates winrm runner object, the machine must have a listener
+// that accepts basic authentication.
+func NewRunner(config Config) (runner.Runner, error) {
+	if strings.TrimSpace(config.Host) == "" {
+		return nil, ErrHostNotSpecified
+	}
+
+	if config.User == "" || config.Password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	if config.Port == 0 {
+		config.Port = DefaultPort
+		if config.HTTPS {
+			config.Port = DefaultHTTPSPort
+		}
+	}
+
+	timeout := defaultConnectTimeout
+	if config.Timeout > 0 {
+		timeout = time.Duration(config.Timeout) * time.Second
+	}
+
+	return &Runner{
+		host:           config.Host,
+		client:         newClient(config),
+		connectTimeout: timeout,
+		retryInterval:  retryInterval,
+	}, nil
+}
+
+// Run uploads the PowerShell script to the machine and executes it.
+//
+// The returned error is nil if the script runs and exits
+// with a zero exit status.
+func (r *Runner) Run(cmd *runner.Command) error {
+	if cmd == nil || strings.TrimSpace(cmd.Script) == "" {
+		return nil
+	}
+
+	if cmd.Ctx == nil {
+		return runner.ErrNilContext
+	}
+
+	shellID, err := r.connect(cmd.Ctx)
+	if err != nil {
+		return errors.Wrapf(err, "winrm: create shell on %s", r.host)
+	}
+	// the shell lives on the machine until it's deleted
+	defer r.client.deleteShell(context.Background(), shellID)
+
+	script, err := withEnv(cmd.Script, cmd.Env)
+	if err != nil {
+		return errors.Wrap(err, "winrm: command env")
+	}
+
+	// a script doesn't fit a command line, it's saved to a file in chunks
+	name := "supergiant-" + uuid.New()[:8]
+	encoded := base64.StdEncoding.EncodeToString([]byte(script))
+	for _, chunk := range chunks(encoded, chunkSize) {
+		code, err := r.execute(cmd.Ctx, shellID, ioutil.Discard, cmd.Err,
+			fmt.Sprintf(`echo %s>>"%%TEMP%%\%s.b64"`, chunk, name))
+		if err != nil {
+			return errors.Wrap(err, "winrm: upload script")
+		}
+		if code != 0 {
+			return errors.Errorf("winrm: upload script exited with code %d", code)
+		}
+	}
+
+	code, err := r.execute(cmd.Ctx, shellID, cmd.Out, cmd.Err, "powershell",
+		"-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass",
+		"-EncodedCommand", encodePowerShell(loader(name)))
+	if err != nil {
+		if err == cmd.Ctx.Err() {
+			return err
+		}
+		return errors.Wrap(err, "winrm: run script")
+	}
+	if code != 0 {
+		return errors.Wrap(&runner.ExitError{Code: code}, "winrm: script")
+	}
+
+	return nil
+}
+
+func (r *Runner) connect(ctx context.Context) (string, error) {
+	deadline := time.Now().Add(r.connectTimeout)
+
+	for {
+		shellID, err := r.client.createShell(ctx)
+		if err == nil || time.Now().After(deadline) {
+			return shellID, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", errors.Wrap(err, ctx.Err().Error())
+		case <-time.After(r.retryInterval):
+		}
+	}
+}
+
+// execute runs the command in the shell and copies its output until it finishes.
+func (r *Runner) execute(ctx context.Context, shellID string, stdout, stderr io.Writer, command string, args ...string) (int, error) {
+	commandID, err := r.client.command(ctx, shellID, command, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		resp, timedOut, err := r.client.receive(ctx, shellID, commandID)
+		if err != nil {
+			if ctx.Err() == nil {
+				return 0, err
+			}
+
+			// the context is already done, terminate with a fresh one
+			if signalErr := r.client.signal(context.Background(), shellID, commandID); signalErr != nil {
+				return 0, errors.Wrapf(ctx.Err(), "terminate command %s: %v", commandID, signalErr)
+			}
+			return 0, ctx.Err()
+		}
+
+		if timedOut {
+			continue
+		}
+
+		for _, s := range resp.Streams {
+			data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s.Data))
+			if err != nil {
+				return 0, errors.Wrapf(err, "decode %s", s.Name)
+			}
+
+			w := stdout
+			if s.Name == "stderr" {
+				w = stderr
+			}
+			if _, err := w.Write(data); err != nil {
+				return 0, err
+			}
+		}
+
+		if resp.State.State == commandStateDone {
+			return resp.State.ExitCode, nil
+		}
+	}
+}
+
+// loader decodes an uploaded script and runs it with exit code of the script.
+func loader(name string) string {
+	return fmt.Sprintf(`$ErrorActionPreference = 'Stop'
+$path = Join-Path $env:TEMP '%s'
+$data = (Get-Content "$path.b64") -join ''
+[IO.File]::WriteAllText("$path.ps1", [Text.Encoding]::UTF8.GetString([Convert]::FromBase64String($data)))
+Remove-Item "$path.b64"
+& "$path.ps1"
+$code = $LASTEXITCODE
+Remove-Item "$path.ps1"
+exit $code
+`, name)
+}
+
+// encodePowerShell encodes a script for the -EncodedCommand
+// PowerShell flag that expects base64 of UTF-16LE text.
+func encodePowerShell(script string) string {
+	codes := utf16.Encode([]rune(script))
+	buf := make([]byte, len(codes)*2)
+	for i, c := range codes {
+		binary.LittleEndian.PutUint16(buf[i*2:], c)
+	}
+
+	return base64.StdEncoding.EncodeToString(buf)
+}
+
+// withEnv sets variables of the command at the start of the script, windows
+// users are administrators, so scripts don't need sudo.
+func withEnv(script string, env map[string]string) (string, error) {
+	names, err := runner.EnvNames(env)
+	if err != nil {
+		return "", err
+	}
+
+	lines := make([]string, 0, len(names)+1)
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("$env:%s = '%s'", name, strings.Replace(env[name], "'", "''", -1)))
+	}
+	return strings.Join(append(lines, script), "\n"), nil
+}
+
+func chunks(s string, size int) []string {
+	out := make([]string, 0, len(s)/size+1)
+	for len(s) > size {
+		out = append(out, s[:size])
+		s = s[size:]
+	}
+
+	return append(out, s)
+}
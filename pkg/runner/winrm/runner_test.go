@@ -0,0 +1,235 @@
+package winrm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/runner"
+)
+
+var (
+	commandRe   = regexp.MustCompile(`<rsp:Command>(.*)</rsp:Command>`)
+	argumentsRe = regexp.MustCompile(`<rsp:Arguments>([^<]*)</rsp:Arguments>`)
+	chunkRe     = regexp.MustCompile(`^echo ([A-Za-z0-9+/=]+)&gt;&gt;`)
+)
+
+// fakeServer is a WinRM listener that records uploaded scripts.
+type fakeServer struct {
+	m sync.Mutex
+
+	exitCode  int
+	timeouts  int
+	stdout    string
+	upload    string
+	commands  int
+	encoded   string
+	signalled bool
+	deleted   bool
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if user, password, ok := r.BasicAuth(); !ok || user != "Administrator" || password != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	data, _ := ioutil.ReadAll(r.Body)
+	body := string(data)
+
+	switch {
+	case strings.Contains(body, actionCreate):
+		fmt.Fprint(w, envelope(`<rsp:Shell><rsp:ShellId>shell-1</rsp:ShellId></rsp:Shell>`))
+	case strings.Contains(body, actionCommand):
+		command := commandRe.FindStringSubmatch(body)[1]
+		if m := chunkRe.FindStringSubmatch(command); m != nil {
+			f.upload += m[1]
+		} else {
+			args := argumentsRe.FindAllStringSubmatch(body, -1)
+			f.encoded = args[len(args)-1][1]
+		}
+		f.commands++
+		fmt.Fprint(w, envelope(fmt.Sprintf(`<rsp:CommandResponse><rsp:CommandId>cmd-%d</rsp:CommandId></rsp:CommandResponse>`, f.commands)))
+	case strings.Contains(body, actionReceive):
+		if f.encoded == "" {
+			fmt.Fprint(w, done(0))
+			return
+		}
+		if f.timeouts > 0 {
+			f.timeouts--
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, envelope(`<s:Fault><s:Detail><f:WSManFault Code="`+faultTimedOut+`"/></s:Detail></s:Fault>`))
+			return
+		}
+		fmt.Fprint(w, envelope(fmt.Sprintf(`<rsp:ReceiveResponse>`+
+			`<rsp:Stream Name="stdout" CommandId="cmd">%s</rsp:Stream>`+
+			`<rsp:Stream Name="stdout" CommandId="cmd" End="true"></rsp:Stream>`+
+			`<rsp:CommandState CommandId="cmd" State="%s"><rsp:ExitCode>%d</rsp:ExitCode></rsp:CommandState>`+
+			`</rsp:ReceiveResponse>`, base64.StdEncoding.EncodeToString([]byte(f.stdout)), commandStateDone, f.exitCode)))
+	case strings.Contains(body, actionSignal):
+		f.signalled = true
+		fmt.Fprint(w, envelope(""))
+	case strings.Contains(body, actionDelete):
+		f.deleted = true
+		fmt.Fprint(w, envelope(""))
+	}
+}
+
+func envelope(body string) string {
+	return `<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"` +
+		` xmlns:rsp="http://schemas.microsoft.com/wbem/wsman/1/windows/shell"` +
+		` xmlns:f="http://schemas.microsoft.com/wbem/wsman/1/wsmanfault"><s:Body>` + body + `</s:Body></s:Envelope>`
+}
+
+func done(code int) string {
+	return envelope(fmt.Sprintf(`<rsp:ReceiveResponse><rsp:CommandState CommandId="cmd" State="%s">`+
+		`<rsp:ExitCode>%d</rsp:ExitCode></rsp:CommandState></rsp:ReceiveResponse>`, commandStateDone, code))
+}
+
+func newTestRunner(t *testing.T, srv *httptest.Server, password string) *Runner {
+	host, port, err := net.SplitHostPort(strings.TrimPrefix(srv.URL, "http://"))
+	require.NoError(t, err)
+	p, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	r, err := NewRunner(Config{
+		Host:     host,
+		Port:     p,
+		User:     "Administrator",
+		Password: password,
+		Timeout:  1,
+	})
+	require.NoError(t, err)
+
+	winrmRunner := r.(*Runner)
+	winrmRunner.retryInterval = time.Millisecond
+	return winrmRunner
+}
+
+func decodePowerShell(t *testing.T, s string) string {
+	data, err := base64.StdEncoding.DecodeString(s)
+	require.NoError(t, err)
+
+	codes := make([]uint16, len(data)/2)
+	for i := range codes {
+		codes[i] = binary.LittleEndian.Uint16(data[i*2:])
+	}
+
+	return string(utf16.Decode(codes))
+}
+
+func TestNewRunner(t *testing.T) {
+	_, err := NewRunner(Config{User: "Administrator", Password: "secret"})
+	require.Equal(t, ErrHostNotSpecified, err)
+
+	_, err = NewRunner(Config{Host: "10.20.30.40", User: "Administrator"})
+	require.Equal(t, ErrInvalidCredentials, err)
+
+	r, err := NewRunner(Config{Host: "10.20.30.40", User: "Administrator", Password: "secret", HTTPS: true})
+	require.NoError(t, err)
+	require.Equal(t, "https://10.20.30.40:5986/wsman", r.(*Runner).client.url)
+}
+
+func TestRunner_Run(t *testing.T) {
+	script := strings.Repeat("Write-Output 'hello'\n", 1000)
+
+	for _, tc := range []struct {
+		description string
+		exitCode    int
+		password    string
+		errMsg      string
+	}{
+		{
+			description: "success",
+			password:    "secret",
+		},
+		{
+			description: "exit code",
+			exitCode:    3,
+			password:    "secret",
+			errMsg:      "exited with code 3",
+		},
+		{
+			description: "unauthorized",
+			password:    "wrong",
+			errMsg:      ErrUnauthorized.Error(),
+		},
+	} {
+		fake := &fakeServer{
+			exitCode: tc.exitCode,
+			timeouts: 2,
+			stdout:   "hello",
+		}
+		srv := httptest.NewServer(fake)
+
+		out := &bytes.Buffer{}
+		cmd, err := runner.NewCommand(context.Background(), script, out, out)
+		require.NoError(t, err)
+
+		err = newTestRunner(t, srv, tc.password).Run(cmd)
+		srv.Close()
+
+		if tc.errMsg != "" {
+			require.Error(t, err, tc.description)
+			require.Contains(t, err.Error(), tc.errMsg, tc.description)
+			continue
+		}
+
+		require.NoError(t, err, tc.description)
+		require.Equal(t, "hello", out.String(), tc.description)
+		require.True(t, fake.deleted, tc.description)
+		require.True(t, fake.commands > 2, "script must be uploaded in chunks")
+
+		uploaded, err := base64.StdEncoding.DecodeString(fake.upload)
+		require.NoError(t, err)
+		require.Equal(t, script, string(uploaded), tc.description)
+		require.Contains(t, decodePowerShell(t, fake.encoded), "supergiant-", tc.description)
+	}
+}
+
+func TestRunner_RunCancel(t *testing.T) {
+	fake := &fakeServer{
+		timeouts: 1 << 20,
+	}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+
+	cmd, err := runner.NewCommand(ctx, "Start-Sleep 100", ioutil.Discard, ioutil.Discard)
+	require.NoError(t, err)
+
+	err = newTestRunner(t, srv, "secret").Run(cmd)
+	require.Equal(t, context.DeadlineExceeded, err)
+	require.True(t, fake.signalled)
+}
+
+func TestRunner_RunEmpty(t *testing.T) {
+	r := &Runner{}
+	require.NoError(t, r.Run(nil))
+	require.NoError(t, r.Run(&runner.Command{Script: " "}))
+}
+
+func TestChunks(t *testing.T) {
+	require.Equal(t, []string{"abc", "def", "g"}, chunks("abcdefg", 3))
+	require.Equal(t, []string{"abc"}, chunks("abc", 3))
+}
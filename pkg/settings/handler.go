@@ -0,0 +1,102 @@
+package settings
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// Handler is a http controller of runtime settings.
+type Handler struct {
+	manager *Manager
+}
+
+func NewHandler(manager *Manager) *Handler {
+	return &Handler{
+		manager: manager,
+	}
+}
+
+func (h *Handler) Register(r *mux.Router) {
+	r.HandleFunc("/admin/settings", h.Get).Methods(http.MethodGet)
+	r.HandleFunc("/admin/settings", api.AdminOnly(h.Update)).Methods(http.MethodPut)
+	r.HandleFunc("/admin/features", h.GetFeatures).Methods(http.MethodGet)
+	r.HandleFunc("/admin/features/{name}", api.AdminOnly(h.SetFeature)).Methods(http.MethodPut)
+}
+
+// Get returns the current settings.
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	if err := json.NewEncoder(w).Encode(h.manager.Get()); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// Update replaces the settings, they are applied right away.
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	s := h.manager.Get()
+	// settings that aren't in the request are kept
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+	if err := s.Validate(); err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	if err := h.manager.Update(r.Context(), s); err != nil {
+		logrus.Errorf("settings: update: %v", err)
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(s); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// GetFeatures returns feature flags.
+func (h *Handler) GetFeatures(w http.ResponseWriter, r *http.Request) {
+	if err := json.NewEncoder(w).Encode(h.manager.Get().Features); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// FeatureRequest turns a feature on or off.
+type FeatureRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// SetFeature turns the feature on or off.
+func (h *Handler) SetFeature(w http.ResponseWriter, r *http.Request) {
+	req := &FeatureRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	if !KnownFeature(name) {
+		message.SendNotFound(w, name, sgerrors.ErrNotFound)
+		return
+	}
+
+	s := h.manager.Get()
+	s.Features[name] = req.Enabled
+
+	if err := h.manager.Update(r.Context(), s); err != nil {
+		logrus.Errorf("settings: set feature: %v", err)
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(s.Features); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
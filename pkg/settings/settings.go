@@ -0,0 +1,302 @@
+// Package settings keeps runtime settings of control, they are changed
+// through the admin API without restarts and survive them in storage.
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/labels"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
+	"github.com/supergiant/control/pkg/user"
+)
+
+const (
+	DefaultStoragePrefix = "/supergiant/settings/"
+	key                  = "runtime"
+)
+
+// Features that are turned on or off at runtime.
+const (
+	// FeatureTerminals are web terminals of machines and pods.
+	FeatureTerminals = "terminals"
+	// FeatureStatusPages are public status pages of clusters.
+	FeatureStatusPages = "statusPages"
+)
+
+// Features are names of all feature flags.
+var Features = []string{FeatureTerminals, FeatureStatusPages}
+
+// Settings of control, durations are strings like "10s" or "1h".
+type Settings struct {
+	// LogLevels of components, e.g. {"default": "info", "steps": "debug"}.
+	LogLevels map[string]string `json:"logLevels,omitempty"`
+	// SpawnInterval between cloud API calls that create machines,
+	// it limits how many machines are created at once.
+	SpawnInterval string `json:"spawnInterval"`
+	// SSHRetry is used to connect to machines.
+	SSHRetry Retry `json:"sshRetry"`
+	// RegionsCacheTTL of regions of cloud accounts, they aren't cached when it's 0.
+	RegionsCacheTTL string `json:"regionsCacheTTL"`
+	// Features that are turned on or off.
+	Features map[string]bool `json:"features,omitempty"`
+	// RateLimit of API requests of each user and token.
+	RateLimit RateLimit `json:"rateLimit"`
+	// Quotas of expensive operations of each user.
+	Quotas Quotas `json:"quotas"`
+	// TwoFactorRoles are roles of users that need second factors for
+	// password logins.
+	TwoFactorRoles []string `json:"twoFactorRoles,omitempty"`
+	// PasswordPolicy of local users.
+	PasswordPolicy user.PasswordPolicy `json:"passwordPolicy"`
+	// Lockout of logins after failed attempts.
+	Lockout user.LockoutPolicy `json:"lockout"`
+	// Notifications route alerts of kubes to webhooks by labels of kubes.
+	Notifications []NotificationRoute `json:"notifications,omitempty"`
+}
+
+// NotificationRoute sends alerts of kubes whose labels match its selector to
+// its webhooks, e.g. alerts of {"env": "prod"} kubes to an on-call channel.
+// Routes with an empty selector get alerts of all kubes.
+type NotificationRoute struct {
+	Name            string            `json:"name"`
+	Selector        map[string]string `json:"selector,omitempty"`
+	WebhookURL      string            `json:"webhookUrl,omitempty"`
+	SlackWebhookURL string            `json:"slackWebhookUrl,omitempty"`
+}
+
+// Validate checks the route has a valid selector and webhooks.
+func (r NotificationRoute) Validate() error {
+	if err := labels.Validate(r.Selector); err != nil {
+		return errors.Wrap(err, "selector")
+	}
+	if r.WebhookURL == "" && r.SlackWebhookURL == "" {
+		return errors.New("webhookUrl or slackWebhookUrl is required")
+	}
+	for _, u := range []string{r.WebhookURL, r.SlackWebhookURL} {
+		if u == "" {
+			continue
+		}
+		if parsed, err := url.Parse(u); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			return errors.Errorf("invalid webhook url %q", u)
+		}
+	}
+	return nil
+}
+
+// RateLimit of requests, requests aren't limited when RequestsPerSecond is 0.
+type RateLimit struct {
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	// Burst of requests that are allowed above the rate.
+	Burst int `json:"burst"`
+}
+
+// Quotas of users, 0 is unlimited.
+type Quotas struct {
+	// MaxClusters a user owns.
+	MaxClusters int `json:"maxClusters"`
+	// MaxConcurrentProvisions of clusters of a user.
+	MaxConcurrentProvisions int `json:"maxConcurrentProvisions"`
+}
+
+// Retry policy, the interval doubles after each attempt.
+type Retry struct {
+	Attempts int    `json:"attempts"`
+	Interval string `json:"interval"`
+}
+
+// Validate checks the settings are applicable.
+func (s Settings) Validate() error {
+	for name, value := range map[string]string{
+		"spawnInterval":     s.SpawnInterval,
+		"sshRetry.interval": s.SSHRetry.Interval,
+		"regionsCacheTTL":   s.RegionsCacheTTL,
+	} {
+		if _, err := duration(value); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+	if d, _ := duration(s.SpawnInterval); d <= 0 {
+		return errors.New("spawnInterval must be positive")
+	}
+	if s.SSHRetry.Attempts < 1 {
+		return errors.New("sshRetry.attempts must be at least 1")
+	}
+	if s.RateLimit.RequestsPerSecond < 0 || s.RateLimit.Burst < 0 {
+		return errors.New("rateLimit must not be negative")
+	}
+	if s.RateLimit.RequestsPerSecond > 0 && s.RateLimit.Burst < 1 {
+		return errors.New("rateLimit.burst must be at least 1")
+	}
+	if s.Quotas.MaxClusters < 0 || s.Quotas.MaxConcurrentProvisions < 0 {
+		return errors.New("quotas must not be negative")
+	}
+	for _, role := range s.TwoFactorRoles {
+		if role != user.RoleAdmin && role != user.RoleEdit && role != user.RoleView {
+			return errors.Errorf("unknown role %s of twoFactorRoles", role)
+		}
+	}
+	if err := s.PasswordPolicy.Validate(); err != nil {
+		return errors.Wrap(err, "passwordPolicy")
+	}
+	if err := s.Lockout.Validate(); err != nil {
+		return errors.Wrap(err, "lockout")
+	}
+	for i, route := range s.Notifications {
+		if err := route.Validate(); err != nil {
+			return errors.Wrapf(err, "notifications[%d]", i)
+		}
+	}
+	for name := range s.Features {
+		if !KnownFeature(name) {
+			return errors.Errorf("unknown feature %s", name)
+		}
+	}
+	for component, level := range s.LogLevels {
+		if _, err := logrus.ParseLevel(level); err != nil {
+			return errors.Wrapf(err, "log level of %s", component)
+		}
+	}
+
+	return nil
+}
+
+// KnownFeature reports whether the feature has a flag.
+func KnownFeature(name string) bool {
+	for _, feature := range Features {
+		if feature == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Duration parses a duration setting, an empty one is 0.
+func Duration(value string) time.Duration {
+	d, _ := duration(value)
+	return d
+}
+
+func duration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	return time.ParseDuration(value)
+}
+
+// Manager keeps the current settings and applies them to subsystems.
+type Manager struct {
+	m          sync.RWMutex
+	current    Settings
+	appliers   []func(Settings)
+	repository storage.Interface
+}
+
+// NewManager returns a manager with the default settings, they are used
+// as long as no settings are saved.
+func NewManager(repository storage.Interface, defaults Settings) *Manager {
+	return &Manager{
+		current:    defaults,
+		repository: repository,
+	}
+}
+
+// OnChange registers the function that applies settings, it's called with
+// the current settings and then each time they are changed.
+func (m *Manager) OnChange(apply func(Settings)) {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	m.appliers = append(m.appliers, apply)
+	apply(m.current)
+}
+
+// Load reads saved settings and applies them.
+func (m *Manager) Load(ctx context.Context) error {
+	data, err := m.repository.Get(ctx, DefaultStoragePrefix, key)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrap(err, "read settings")
+	}
+
+	// settings that aren't saved keep their defaults
+	s := m.Get()
+	if err = json.Unmarshal(data, &s); err != nil {
+		return errors.Wrap(err, "unmarshal settings")
+	}
+	if err = s.Validate(); err != nil {
+		return errors.Wrap(err, "saved settings")
+	}
+
+	m.m.Lock()
+	defer m.m.Unlock()
+	m.apply(s)
+
+	return nil
+}
+
+// Get returns a copy of the current settings.
+func (m *Manager) Get() Settings {
+	m.m.RLock()
+	defer m.m.RUnlock()
+
+	s := m.current
+	s.LogLevels = make(map[string]string, len(m.current.LogLevels))
+	for component, level := range m.current.LogLevels {
+		s.LogLevels[component] = level
+	}
+	s.Features = make(map[string]bool, len(m.current.Features))
+	for name, enabled := range m.current.Features {
+		s.Features[name] = enabled
+	}
+	s.Notifications = append([]NotificationRoute(nil), m.current.Notifications...)
+
+	return s
+}
+
+// Update validates, saves and applies the settings.
+func (m *Manager) Update(ctx context.Context, s Settings) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return errors.Wrap(err, "marshal settings")
+	}
+
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	if err = m.repository.Put(ctx, DefaultStoragePrefix, key, data); err != nil {
+		return errors.Wrap(err, "save settings")
+	}
+	m.apply(s)
+
+	return nil
+}
+
+// Enabled reports whether the feature is turned on.
+func (m *Manager) Enabled(feature string) bool {
+	m.m.RLock()
+	defer m.m.RUnlock()
+
+	return m.current.Features[feature]
+}
+
+func (m *Manager) apply(s Settings) {
+	m.current = s
+	for _, apply := range m.appliers {
+		apply(s)
+	}
+}
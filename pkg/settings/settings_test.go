@@ -0,0 +1,143 @@
+package settings
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/storage/memory"
+	"github.com/supergiant/control/pkg/user"
+)
+
+var defaults = Settings{
+	SpawnInterval: "5s",
+	SSHRetry: Retry{
+		Attempts: 5,
+		Interval: "10s",
+	},
+}
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		modify func(*Settings)
+		valid  bool
+	}{
+		{"defaults", func(*Settings) {}, true},
+		{"cache ttl", func(s *Settings) { s.RegionsCacheTTL = "1h" }, true},
+		{"bad duration", func(s *Settings) { s.RegionsCacheTTL = "hour" }, false},
+		{"no spawn interval", func(s *Settings) { s.SpawnInterval = "" }, false},
+		{"no ssh attempts", func(s *Settings) { s.SSHRetry.Attempts = 0 }, false},
+		{"log level", func(s *Settings) { s.LogLevels = map[string]string{"steps": "debug"} }, true},
+		{"bad log level", func(s *Settings) { s.LogLevels = map[string]string{"steps": "loud"} }, false},
+		{"feature", func(s *Settings) { s.Features = map[string]bool{FeatureTerminals: false} }, true},
+		{"unknown feature", func(s *Settings) { s.Features = map[string]bool{"windows": true} }, false},
+		{"rate limit", func(s *Settings) { s.RateLimit = RateLimit{RequestsPerSecond: 10, Burst: 20} }, true},
+		{"rate limit without burst", func(s *Settings) { s.RateLimit.RequestsPerSecond = 10 }, false},
+		{"negative rate limit", func(s *Settings) { s.RateLimit.RequestsPerSecond = -1 }, false},
+		{"quotas", func(s *Settings) { s.Quotas = Quotas{MaxClusters: 3, MaxConcurrentProvisions: 1} }, true},
+		{"negative quota", func(s *Settings) { s.Quotas.MaxClusters = -1 }, false},
+		{"two factor roles", func(s *Settings) { s.TwoFactorRoles = []string{"admin", "edit"} }, true},
+		{"unknown two factor role", func(s *Settings) { s.TwoFactorRoles = []string{"root"} }, false},
+		{"password policy", func(s *Settings) {
+			s.PasswordPolicy = user.PasswordPolicy{MinLength: 12, RequireDigit: true, MaxAge: "2160h"}
+		}, true},
+		{"long min password length", func(s *Settings) { s.PasswordPolicy.MinLength = 32 }, false},
+		{"invalid password max age", func(s *Settings) { s.PasswordPolicy.MaxAge = "90 days" }, false},
+		{"lockout", func(s *Settings) { s.Lockout = user.DefaultLockoutPolicy }, true},
+		{"lockout without duration", func(s *Settings) { s.Lockout = user.LockoutPolicy{MaxAttempts: 3} }, false},
+		{"short max lockout", func(s *Settings) {
+			s.Lockout = user.LockoutPolicy{MaxAttempts: 3, Duration: "1h", MaxDuration: "1m"}
+		}, false},
+		{"notification route", func(s *Settings) {
+			s.Notifications = []NotificationRoute{{Selector: map[string]string{"env": "prod"}, SlackWebhookURL: "https://hooks"}}
+		}, true},
+		{"notification route without webhooks", func(s *Settings) {
+			s.Notifications = []NotificationRoute{{Selector: map[string]string{"env": "prod"}}}
+		}, false},
+		{"notification route with invalid selector", func(s *Settings) {
+			s.Notifications = []NotificationRoute{{Selector: map[string]string{"env": "prod eu"}, WebhookURL: "https://hooks"}}
+		}, false},
+	} {
+		s := defaults
+		tc.modify(&s)
+		require.Equal(t, tc.valid, s.Validate() == nil, tc.name)
+	}
+}
+
+func TestManager(t *testing.T) {
+	repo := memory.NewInMemoryRepository()
+	m := NewManager(repo, defaults)
+	require.NoError(t, m.Load(context.Background()))
+
+	var applied []Settings
+	m.OnChange(func(s Settings) {
+		applied = append(applied, s)
+	})
+	require.Len(t, applied, 1)
+	require.Equal(t, "5s", applied[0].SpawnInterval)
+
+	s := m.Get()
+	s.SpawnInterval = "1m"
+	s.Features[FeatureTerminals] = true
+	require.Error(t, m.Update(context.Background(), Settings{}))
+	require.NoError(t, m.Update(context.Background(), s))
+	require.Len(t, applied, 2)
+	require.Equal(t, time.Minute, Duration(applied[1].SpawnInterval))
+	require.True(t, m.Enabled(FeatureTerminals))
+	require.False(t, m.Enabled(FeatureStatusPages))
+
+	// the copy doesn't change the current settings
+	m.Get().Features[FeatureTerminals] = false
+	require.True(t, m.Enabled(FeatureTerminals))
+
+	// settings survive restarts
+	restarted := NewManager(repo, defaults)
+	require.NoError(t, restarted.Load(context.Background()))
+	require.Equal(t, "1m", restarted.Get().SpawnInterval)
+	require.True(t, restarted.Enabled(FeatureTerminals))
+}
+
+func TestHandler(t *testing.T) {
+	m := NewManager(memory.NewInMemoryRepository(), defaults)
+	router := mux.NewRouter()
+	NewHandler(m).Register(router)
+
+	for _, tc := range []struct {
+		name   string
+		role   string
+		method string
+		url    string
+		body   string
+		status int
+	}{
+		{"get", "view", http.MethodGet, "/admin/settings", "", http.StatusOK},
+		{"update", "admin", http.MethodPut, "/admin/settings", `{"regionsCacheTTL": "10m"}`, http.StatusOK},
+		{"update by viewer", "view", http.MethodPut, "/admin/settings", `{"regionsCacheTTL": "1m"}`, http.StatusForbidden},
+		{"invalid", "admin", http.MethodPut, "/admin/settings", `{"spawnInterval": "0s"}`, http.StatusBadRequest},
+		{"invalid json", "admin", http.MethodPut, "/admin/settings", `{`, http.StatusBadRequest},
+		{"feature", "", http.MethodPut, "/admin/features/statusPages", `{"enabled": true}`, http.StatusOK},
+		{"unknown feature", "admin", http.MethodPut, "/admin/features/windows", `{"enabled": true}`, http.StatusNotFound},
+		{"feature by editor", "edit", http.MethodPut, "/admin/features/terminals", `{"enabled": true}`, http.StatusForbidden},
+		{"features", "view", http.MethodGet, "/admin/features", "", http.StatusOK},
+	} {
+		req := httptest.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+		req = req.WithContext(api.WithIdentity(req.Context(), api.Identity{Login: "user", Role: tc.role}))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		require.Equal(t, tc.status, rec.Code, tc.name)
+	}
+
+	s := m.Get()
+	require.Equal(t, "10m", s.RegionsCacheTTL)
+	require.Equal(t, "5s", s.SpawnInterval)
+	require.Equal(t, map[string]bool{FeatureStatusPages: true}, s.Features)
+}
@@ -18,4 +18,13 @@ const (
 	NilEntity           ErrorCode = 1011
 	TimeoutExceeded     ErrorCode = 1012
 	RawError            ErrorCode = 1013
+	Protected           ErrorCode = 1014
+	QuotaExceeded       ErrorCode = 1015
+	RateLimited         ErrorCode = 1016
+	TwoFactorRequired   ErrorCode = 1017
+	TwoFactorEnrollment ErrorCode = 1018
+	AccountLocked       ErrorCode = 1019
+	PasswordExpired     ErrorCode = 1020
+	SessionRevoked      ErrorCode = 1021
+	OutsideMaintenance  ErrorCode = 1022
 )
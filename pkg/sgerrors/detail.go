@@ -0,0 +1,109 @@
+package sgerrors
+
+import (
+	"context"
+)
+
+// Detail is the machine readable form of an error, it's returned by the API
+// and saved in failed steps of tasks, so clients can tell errors apart by
+// the code instead of the message.
+type Detail struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+	// Retryable errors may succeed when the same request is sent later.
+	Retryable bool `json:"retryable"`
+	// Field of the request that is invalid.
+	Field string `json:"field,omitempty"`
+	// Step of the workflow that has failed.
+	Step string `json:"step,omitempty"`
+}
+
+// Retryable reports whether errors of the code are transient.
+func (c ErrorCode) Retryable() bool {
+	switch c {
+	case TimeoutExceeded, RateLimited, AccountLocked, OutsideMaintenance:
+		return true
+	}
+	return false
+}
+
+// annotated adds the offending field or step to an error, Cause returns the
+// error, so the Is helpers keep working.
+type annotated struct {
+	err   error
+	field string
+	step  string
+}
+
+func (a *annotated) Error() string {
+	return a.err.Error()
+}
+
+func (a *annotated) Cause() error {
+	return a.err
+}
+
+// WithField marks the field of the request that caused the error.
+func WithField(err error, field string) error {
+	if err == nil {
+		return nil
+	}
+	return &annotated{err: err, field: field}
+}
+
+// WithStep marks the step of the workflow that returned the error.
+func WithStep(err error, step string) error {
+	if err == nil {
+		return nil
+	}
+	return &annotated{err: err, step: step}
+}
+
+// CodeOf returns the code of the error or of its cause, errors without a
+// code are UnknownError.
+func CodeOf(err error) ErrorCode {
+	return DetailOf(err).Code
+}
+
+// DetailOf returns the detail of the error, the outermost field and step
+// annotations win.
+func DetailOf(err error) *Detail {
+	if err == nil {
+		return nil
+	}
+
+	d := &Detail{
+		Code:    UnknownError,
+		Message: err.Error(),
+	}
+	for e := err; e != nil; {
+		switch v := e.(type) {
+		case *annotated:
+			if d.Field == "" {
+				d.Field = v.field
+			}
+			if d.Step == "" {
+				d.Step = v.step
+			}
+		case *Error:
+			d.Code = v.Code
+		case Violations:
+			d.Code = ValidationFailed
+			if d.Field == "" && len(v) > 0 {
+				d.Field = v[0].Field
+			}
+		}
+		if e == context.DeadlineExceeded {
+			d.Code = TimeoutExceeded
+		}
+
+		c, ok := e.(interface{ Cause() error })
+		if !ok {
+			break
+		}
+		e = c.Cause()
+	}
+	d.Retryable = d.Code.Retryable()
+
+	return d
+}
@@ -0,0 +1,35 @@
+package sgerrors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetailOf(t *testing.T) {
+	require.Nil(t, DetailOf(nil))
+
+	d := DetailOf(errors.Wrap(WithField(ErrQuotaExceeded, "nodeGroups"), "provision"))
+	require.Equal(t, &Detail{
+		Code:    QuotaExceeded,
+		Message: "provision: quota exceeded",
+		Field:   "nodeGroups",
+	}, d)
+
+	d = DetailOf(WithStep(errors.Wrap(context.DeadlineExceeded, "wait for node"), "kubelet"))
+	require.Equal(t, TimeoutExceeded, d.Code)
+	require.True(t, d.Retryable)
+	require.Equal(t, "kubelet", d.Step)
+
+	require.Equal(t, RateLimited, CodeOf(errors.Wrap(ErrRateLimited, "list")))
+	require.Equal(t, UnknownError, CodeOf(errors.New("unknown")))
+}
+
+func TestAnnotatedCause(t *testing.T) {
+	err := WithStep(WithField(ErrNotFound, "id"), "step")
+	require.True(t, IsNotFound(err))
+	require.Equal(t, ErrNotFound.Error(), err.Error())
+	require.Nil(t, WithField(nil, "id"))
+}
@@ -32,6 +32,15 @@ var (
 	ErrNilEntity           = New("nil entity", NilEntity)
 	ErrTimeoutExceeded     = New("timeout exceeded", TimeoutExceeded)
 	ErrRawError            = New("error", RawError)
+	ErrProtected           = New("deletion protection is enabled", Protected)
+	ErrQuotaExceeded       = New("quota exceeded", QuotaExceeded)
+	ErrRateLimited         = New("rate limit exceeded", RateLimited)
+	ErrTwoFactorRequired   = New("second factor is required", TwoFactorRequired)
+	ErrTwoFactorEnrollment = New("second factor enrollment is required", TwoFactorEnrollment)
+	ErrAccountLocked       = New("account is locked after failed logins", AccountLocked)
+	ErrPasswordExpired     = New("password has expired", PasswordExpired)
+	ErrSessionRevoked      = New("session has been revoked", SessionRevoked)
+	ErrOutsideMaintenance  = New("outside of the maintenance window", OutsideMaintenance)
 )
 
 func IsNotFound(err error) bool {
@@ -57,3 +66,30 @@ func IsUnknownProvider(err error) bool {
 func IsUnsupportedProvider(err error) bool {
 	return errors.Cause(err) == ErrUnsupportedProvider
 }
+
+func IsProtected(err error) bool {
+	return errors.Cause(err) == ErrProtected
+}
+
+func IsQuotaExceeded(err error) bool {
+	return errors.Cause(err) == ErrQuotaExceeded
+}
+
+func IsAccountLocked(err error) bool {
+	return errors.Cause(err) == ErrAccountLocked
+}
+
+func IsPasswordExpired(err error) bool {
+	return errors.Cause(err) == ErrPasswordExpired
+}
+
+func IsOutsideMaintenance(err error) bool {
+	return errors.Cause(err) == ErrOutsideMaintenance
+}
+
+// IsTwoFactor reports whether a login needs a second factor or its
+// enrollment.
+func IsTwoFactor(err error) bool {
+	cause := errors.Cause(err)
+	return cause == ErrTwoFactorRequired || cause == ErrTwoFactorEnrollment
+}
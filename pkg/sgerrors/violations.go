@@ -0,0 +1,41 @@
+package sgerrors
+
+import (
+	"strings"
+)
+
+// Violation is a field of a request that breaks a rule.
+type Violation struct {
+	// Field is the path of the field, e.g. nodesProfiles[0].size.
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Violations are returned all at once, so they can be fixed before the
+// request is sent again.
+type Violations []Violation
+
+func (v Violations) Error() string {
+	msgs := make([]string, 0, len(v))
+	for _, violation := range v {
+		if violation.Field == "" {
+			msgs = append(msgs, violation.Message)
+			continue
+		}
+		msgs = append(msgs, violation.Field+": "+violation.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Add appends a violation of the field.
+func (v *Violations) Add(field, msg string) {
+	*v = append(*v, Violation{Field: field, Message: msg})
+}
+
+// Err returns the violations as an error, nil if there are none.
+func (v Violations) Err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
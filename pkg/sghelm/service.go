@@ -20,7 +20,8 @@ import (
 const (
 	readmeFileName = "readme.md"
 
-	repoPrefix = "/helm/repositories/"
+	// RepositoryStoragePrefix keeps helm repositories with their credentials.
+	RepositoryStoragePrefix = "/helm/repositories/"
 )
 
 var _ Servicer = &Service{}
@@ -81,7 +82,7 @@ func (s Service) CreateRepo(ctx context.Context, e *repo.Entry) (*model.Reposito
 	if err != nil {
 		return nil, errors.Wrap(err, "marshal index file")
 	}
-	if err = s.storage.Put(ctx, repoPrefix, e.Name, rawJSON); err != nil {
+	if err = s.storage.Put(ctx, RepositoryStoragePrefix, e.Name, rawJSON); err != nil {
 		return nil, errors.Wrap(err, "storage")
 	}
 
@@ -116,7 +117,7 @@ func (s Service) UpdateRepo(ctx context.Context, name string, opts *repo.Entry)
 	if err != nil {
 		return nil, errors.Wrap(err, "marshal index file")
 	}
-	if err = s.storage.Put(ctx, repoPrefix, name, rawJSON); err != nil {
+	if err = s.storage.Put(ctx, RepositoryStoragePrefix, name, rawJSON); err != nil {
 		return nil, errors.Wrap(err, "storage")
 	}
 
@@ -125,7 +126,7 @@ func (s Service) UpdateRepo(ctx context.Context, name string, opts *repo.Entry)
 
 // GetRepo retrieves the repository index file for provided nam.
 func (s Service) GetRepo(ctx context.Context, repoName string) (*model.RepositoryInfo, error) {
-	res, err := s.storage.Get(ctx, repoPrefix, repoName)
+	res, err := s.storage.Get(ctx, RepositoryStoragePrefix, repoName)
 	if err != nil {
 		return nil, errors.Wrap(err, "storage")
 	}
@@ -144,7 +145,7 @@ func (s Service) GetRepo(ctx context.Context, repoName string) (*model.Repositor
 
 // ListRepos retrieves all helm repositories from the storage.
 func (s Service) ListRepos(ctx context.Context) ([]model.RepositoryInfo, error) {
-	rawRepos, err := s.storage.GetAll(ctx, repoPrefix)
+	rawRepos, err := s.storage.GetAll(ctx, RepositoryStoragePrefix)
 	if err != nil {
 		return nil, errors.Wrap(err, "storage")
 	}
@@ -168,7 +169,7 @@ func (s Service) DeleteRepo(ctx context.Context, repoName string) (*model.Reposi
 	if err != nil {
 		return nil, errors.Wrap(err, "get repository")
 	}
-	return hrepo, s.storage.Delete(ctx, repoPrefix, repoName)
+	return hrepo, s.storage.Delete(ctx, RepositoryStoragePrefix, repoName)
 }
 
 func (s Service) GetChartData(ctx context.Context, repoName, chartName, chartVersion string) (*model.ChartData, error) {
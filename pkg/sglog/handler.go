@@ -0,0 +1,57 @@
+package sglog
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/message"
+)
+
+// Handler changes log levels of components at runtime.
+type Handler struct{}
+
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+func (h *Handler) Register(r *mux.Router) {
+	r.HandleFunc("/admin/loglevels", h.GetLevels).Methods(http.MethodGet)
+	r.HandleFunc("/admin/loglevels", api.AdminOnly(h.SetLevels)).Methods(http.MethodPut)
+}
+
+// GetLevels returns levels of components, e.g. {"default": "info", "steps": "debug"}.
+func (h *Handler) GetLevels(w http.ResponseWriter, r *http.Request) {
+	if err := json.NewEncoder(w).Encode(Levels()); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// SetLevels sets levels of the components of the request, levels of
+// other components are kept.
+func (h *Handler) SetLevels(w http.ResponseWriter, r *http.Request) {
+	levels := map[string]string{}
+	if err := json.NewDecoder(r.Body).Decode(&levels); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	// levels are validated before any of them is set
+	for component, level := range levels {
+		if _, err := logrus.ParseLevel(level); err != nil {
+			message.SendValidationFailed(w, errors.Wrapf(err, "component %s", component))
+			return
+		}
+	}
+	for component, level := range levels {
+		SetLevel(component, level)
+	}
+
+	if err := json.NewEncoder(w).Encode(Levels()); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
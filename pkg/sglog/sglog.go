@@ -0,0 +1,144 @@
+// Package sglog configures logging of control, log entries carry fields of
+// the cluster, task and step they belong to, and levels of components can be
+// changed at runtime.
+package sglog
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Fields of log entries.
+const (
+	ClusterID = "cluster_id"
+	TaskID    = "task_id"
+	Step      = "step"
+	Component = "component"
+)
+
+// Default is the component of the standard logger, other components
+// inherit its level until their own level is set.
+const Default = "default"
+
+// Components of control that have own log levels.
+const (
+	Steps       = "steps"
+	Provisioner = "provisioner"
+)
+
+type contextKey int
+
+const entryKey contextKey = iota
+
+var (
+	m          sync.RWMutex
+	components = map[string]*logrus.Logger{}
+)
+
+// Configure sets the level and the format, txt or json, of logs.
+func Configure(level, format string) {
+	l, err := logrus.ParseLevel(level)
+	if err != nil {
+		// set logLevel to INFO by default
+		l = logrus.InfoLevel
+	}
+
+	var formatter logrus.Formatter
+	switch strings.TrimSpace(format) {
+	case "json":
+		formatter = &logrus.JSONFormatter{}
+	default:
+		formatter = &logrus.TextFormatter{
+			FullTimestamp: true,
+		}
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	logrus.SetLevel(l)
+	logrus.SetFormatter(formatter)
+	for _, logger := range components {
+		logger.SetLevel(l)
+		logger.Formatter = formatter
+	}
+}
+
+// Logger returns the logger of the component.
+func Logger(component string) *logrus.Entry {
+	return logrus.NewEntry(logger(component)).WithField(Component, component)
+}
+
+func logger(component string) *logrus.Logger {
+	if component == Default || component == "" {
+		return logrus.StandardLogger()
+	}
+
+	m.RLock()
+	logger := components[component]
+	m.RUnlock()
+	if logger != nil {
+		return logger
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	if logger = components[component]; logger == nil {
+		std := logrus.StandardLogger()
+		logger = logrus.New()
+		logger.Out = std.Out
+		logger.Formatter = std.Formatter
+		logger.Hooks = std.Hooks
+		logger.SetLevel(std.GetLevel())
+		components[component] = logger
+	}
+
+	return logger
+}
+
+// Levels returns log levels of components.
+func Levels() map[string]string {
+	m.RLock()
+	defer m.RUnlock()
+
+	levels := map[string]string{
+		Default: logrus.GetLevel().String(),
+	}
+	for name, logger := range components {
+		levels[name] = logger.GetLevel().String()
+	}
+
+	return levels
+}
+
+// SetLevel sets the log level of the component.
+func SetLevel(component, level string) error {
+	l, err := logrus.ParseLevel(level)
+	if err != nil {
+		return errors.Wrapf(err, "component %s", component)
+	}
+	logger(component).SetLevel(l)
+
+	return nil
+}
+
+// WithEntry returns a copy of the context that carries the log entry.
+func WithEntry(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, entryKey, entry)
+}
+
+// FromContext returns the log entry of the context, e.g. one with the cluster,
+// task and step of the step being run, or the default logger if there is none.
+func FromContext(ctx context.Context) *logrus.Entry {
+	if ctx != nil {
+		if entry, ok := ctx.Value(entryKey).(*logrus.Entry); ok {
+			return entry
+		}
+	}
+
+	return logrus.NewEntry(logrus.StandardLogger())
+}
@@ -0,0 +1,98 @@
+package sglog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/api"
+)
+
+func TestConfigure(t *testing.T) {
+	out := logrus.StandardLogger().Out
+	buf := &bytes.Buffer{}
+	logrus.SetOutput(buf)
+	defer logrus.SetOutput(out)
+
+	Configure("debug", "json")
+	defer Configure("info", "txt")
+
+	Logger("configure").WithField(TaskID, "1234").Debug("started")
+
+	entry := map[string]string{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "started", entry["msg"])
+	require.Equal(t, "debug", entry["level"])
+	require.Equal(t, "configure", entry[Component])
+	require.Equal(t, "1234", entry[TaskID])
+}
+
+func TestSetLevel(t *testing.T) {
+	Configure("info", "txt")
+	buf := &bytes.Buffer{}
+	Logger("quiet").Logger.Out = buf
+	Logger("verbose").Logger.Out = buf
+
+	require.NoError(t, SetLevel("quiet", "error"))
+	require.NoError(t, SetLevel("verbose", "debug"))
+	require.Error(t, SetLevel("verbose", "loud"))
+
+	Logger("quiet").Info("hidden")
+	Logger("verbose").Debug("shown")
+
+	require.NotContains(t, buf.String(), "hidden")
+	require.Contains(t, buf.String(), "shown")
+	require.Equal(t, "error", Levels()["quiet"])
+	require.Equal(t, "debug", Levels()["verbose"])
+	require.Equal(t, "info", Levels()[Default])
+}
+
+func TestFromContext(t *testing.T) {
+	entry := Logger(Steps).WithField(Step, "ssh")
+
+	require.Equal(t, entry, FromContext(WithEntry(context.Background(), entry)))
+	require.Equal(t, logrus.StandardLogger(), FromContext(context.Background()).Logger)
+}
+
+func TestHandler(t *testing.T) {
+	router := mux.NewRouter()
+	NewHandler().Register(router)
+
+	for _, tc := range []struct {
+		name   string
+		role   string
+		body   string
+		status int
+		level  string
+	}{
+		{"admin", "admin", `{"handler": "debug"}`, http.StatusOK, "debug"},
+		{"user created before roles", "", `{"handler": "warning"}`, http.StatusOK, "warning"},
+		{"viewer", "view", `{"handler": "error"}`, http.StatusForbidden, "warning"},
+		{"unknown level", "admin", `{"handler": "error", "other": "loud"}`, http.StatusBadRequest, "warning"},
+		{"invalid json", "admin", `{`, http.StatusBadRequest, "warning"},
+	} {
+		req := httptest.NewRequest(http.MethodPut, "/admin/loglevels", strings.NewReader(tc.body))
+		req = req.WithContext(api.WithIdentity(req.Context(), api.Identity{Login: "user", Role: tc.role}))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		require.Equal(t, tc.status, rec.Code, tc.name)
+		require.Equal(t, tc.level, Levels()["handler"], tc.name)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/loglevels", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	levels := map[string]string{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&levels))
+	require.Equal(t, "warning", levels["handler"])
+}
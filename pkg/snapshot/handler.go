@@ -0,0 +1,93 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// ExportRequest sets the passphrase that seals secrets of the snapshot.
+type ExportRequest struct {
+	Passphrase string `json:"passphrase"`
+}
+
+type RestoreRequest struct {
+	Passphrase string    `json:"passphrase"`
+	Snapshot   *Snapshot `json:"snapshot"`
+}
+
+// RestoreResponse counts restored entries by sections.
+type RestoreResponse struct {
+	Restored map[string]int `json:"restored"`
+}
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) Register(r *mux.Router) {
+	r.HandleFunc("/admin/snapshot", api.AdminOnly(h.Export)).Methods(http.MethodPost)
+	r.HandleFunc("/admin/snapshot/restore", api.AdminOnly(h.Restore)).Methods(http.MethodPost)
+}
+
+// Export returns the snapshot of the state of control.
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	req := &ExportRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	snap, err := h.svc.Export(r.Context(), req.Passphrase)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="control-snapshot.json"`)
+	if err = json.NewEncoder(w).Encode(snap); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// Restore writes the snapshot into a fresh install.
+func (h *Handler) Restore(w http.ResponseWriter, r *http.Request) {
+	req := &RestoreRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	restored, err := h.svc.Restore(r.Context(), req.Snapshot, req.Passphrase)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(RestoreResponse{Restored: restored}); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func sendError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Cause(err) == sgerrors.ErrInvalidJson:
+		message.SendValidationFailed(w, err)
+	case sgerrors.IsInvalidCredentials(err):
+		message.SendInvalidCredentials(w, err)
+	case sgerrors.IsAlreadyExists(err):
+		message.SendAlreadyExists(w, "state", err)
+	default:
+		message.SendUnknownError(w, err)
+	}
+}
@@ -0,0 +1,331 @@
+// Package snapshot exports the state of control to a portable document and
+// restores it into a fresh install, e.g. for disaster recovery or migrations
+// between environments. Values of sections with secrets, e.g. users with
+// password hashes, cloud accounts and kubes with their keys, are sealed with
+// a key derived from a passphrase.
+package snapshot
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/supergiant/control/pkg/account"
+	"github.com/supergiant/control/pkg/acme"
+	"github.com/supergiant/control/pkg/fleet"
+	"github.com/supergiant/control/pkg/idempotency"
+	"github.com/supergiant/control/pkg/images"
+	"github.com/supergiant/control/pkg/kube"
+	"github.com/supergiant/control/pkg/pki"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/queue"
+	"github.com/supergiant/control/pkg/revision"
+	"github.com/supergiant/control/pkg/settings"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/sghelm"
+	"github.com/supergiant/control/pkg/storage"
+	"github.com/supergiant/control/pkg/timeline"
+	"github.com/supergiant/control/pkg/user"
+	"github.com/supergiant/control/pkg/workflows"
+)
+
+const (
+	// Version of the snapshot format.
+	Version = 1
+
+	MinPassphraseLength = 8
+
+	saltLength = 16
+	keyLength  = 32
+)
+
+// section is a part of the state stored with the prefix.
+type section struct {
+	name   string
+	prefix string
+	sealed bool
+	// exclusive sections must be empty before a restore, they would be
+	// mixed with ones of the snapshot.
+	exclusive bool
+}
+
+// sections are restored in this order, every storage prefix of services is
+// either a section or skipped.
+var sections = []section{
+	{name: "settings", prefix: settings.DefaultStoragePrefix},
+	{name: "teams", prefix: user.DefaultTeamStoragePrefix},
+	{name: "users", prefix: user.DefaultStoragePrefix, sealed: true},
+	{name: "totp", prefix: user.DefaultTOTPStoragePrefix, sealed: true},
+	{name: "accounts", prefix: account.DefaultStoragePrefix, sealed: true, exclusive: true},
+	{name: "acme", prefix: acme.DefaultStoragePrefix, sealed: true},
+	{name: "csrs", prefix: pki.CSRStoragePrefix, sealed: true},
+	{name: "profiles", prefix: profile.DefaultKubeProfilePreifx},
+	{name: "images", prefix: images.DefaultStoragePrefix},
+	{name: "helmRepositories", prefix: sghelm.RepositoryStoragePrefix, sealed: true},
+	{name: "kubes", prefix: kube.DefaultStoragePrefix, sealed: true, exclusive: true},
+	{name: "kubeconfigs", prefix: kube.KubeconfigStoragePrefix},
+	{name: "revisions", prefix: revision.DefaultStoragePrefix},
+	// configs of tasks have keys of kubes, e.g. the bootstrap private key
+	{name: "tasks", prefix: workflows.Prefix, sealed: true},
+	{name: "stepStats", prefix: workflows.StepStatsPrefix},
+	{name: "events", prefix: timeline.DefaultStoragePrefix},
+	{name: "fleetRuns", prefix: fleet.DefaultStoragePrefix},
+	{name: "compliance", prefix: kube.ComplianceStoragePrefix},
+	{name: "drift", prefix: kube.DriftStoragePrefix},
+	{name: "inventory", prefix: kube.InventoryStoragePrefix},
+	{name: "utilization", prefix: kube.UtilizationStoragePrefix},
+	{name: "sshAudit", prefix: kube.SSHKeyAuditStoragePrefix},
+	// recordings of terminals may have typed secrets
+	{name: "terminals", prefix: kube.TerminalStoragePrefix, sealed: true},
+}
+
+// skipped prefixes keep state that is of no use in another install:
+// sessions of users, replies of retried requests and queued jobs that
+// would be run again.
+var skipped = []string{
+	user.DefaultSessionStoragePrefix,
+	idempotency.DefaultStoragePrefix,
+	queue.DefaultStoragePrefix,
+}
+
+// Snapshot is the exported state of control.
+type Snapshot struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Salt derives the key of sealed values from the passphrase.
+	Salt     []byte    `json:"salt"`
+	Sections []Section `json:"sections"`
+}
+
+// Section holds stored values of a kind, e.g. accounts.
+type Section struct {
+	Name    string  `json:"name"`
+	Sealed  bool    `json:"sealed,omitempty"`
+	Entries []Entry `json:"entries"`
+}
+
+// Entry is a stored value with its key, values of sealed sections are
+// encrypted.
+type Entry struct {
+	Key    string          `json:"key"`
+	Value  json.RawMessage `json:"value,omitempty"`
+	Sealed []byte          `json:"sealed,omitempty"`
+}
+
+// Service exports and restores snapshots of the repository.
+type Service struct {
+	repo storage.Interface
+	now  func() time.Time
+}
+
+func NewService(repo storage.Interface) *Service {
+	return &Service{
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+// Export reads all sections of the state, secrets are sealed with the
+// passphrase.
+func (s *Service) Export(ctx context.Context, passphrase string) (*Snapshot, error) {
+	if len(passphrase) < MinPassphraseLength {
+		return nil, errors.Wrapf(sgerrors.ErrInvalidJson, "passphrase must be at least %d characters", MinPassphraseLength)
+	}
+	lister, err := s.lister()
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &Snapshot{
+		Version:   Version,
+		CreatedAt: s.now(),
+		Salt:      make([]byte, saltLength),
+		Sections:  make([]Section, 0, len(sections)),
+	}
+	if _, err = io.ReadFull(rand.Reader, snap.Salt); err != nil {
+		return nil, errors.Wrap(err, "generate salt")
+	}
+	aead, err := newAEAD(passphrase, snap.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sec := range sections {
+		out := Section{
+			Name:    sec.name,
+			Sealed:  sec.sealed,
+			Entries: make([]Entry, 0),
+		}
+		err = lister.ListFrom(ctx, sec.prefix, "", 0, func(key string, value []byte) {
+			if err != nil {
+				return
+			}
+			if !json.Valid(value) {
+				err = errors.Errorf("%s %s isn't json", sec.name, key)
+				return
+			}
+
+			e := Entry{Key: key}
+			if sec.sealed {
+				e.Sealed, err = seal(aead, value, additionalData(sec.name, key))
+			} else {
+				e.Value = value
+			}
+			out.Entries = append(out.Entries, e)
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "export %s", sec.name)
+		}
+		snap.Sections = append(snap.Sections, out)
+	}
+
+	return snap, nil
+}
+
+// Restore writes the snapshot into a fresh install that has no accounts and
+// kubes, entries with the same keys, e.g. the admin user, are replaced.
+// The snapshot is decrypted before anything is written, so a wrong
+// passphrase leaves the state as is. Control is restarted afterwards to
+// reload settings.
+func (s *Service) Restore(ctx context.Context, snap *Snapshot, passphrase string) (map[string]int, error) {
+	if snap == nil || snap.Version != Version {
+		return nil, errors.Wrap(sgerrors.ErrInvalidJson, "unsupported snapshot version")
+	}
+	lister, err := s.lister()
+	if err != nil {
+		return nil, err
+	}
+	if err = s.checkFresh(ctx, lister); err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(passphrase, snap.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string][]Entry, len(snap.Sections))
+	for _, in := range snap.Sections {
+		sec, ok := findSection(in.Name)
+		if !ok {
+			return nil, errors.Wrapf(sgerrors.ErrInvalidJson, "unknown section %s", in.Name)
+		}
+		if _, ok = values[sec.name]; ok {
+			return nil, errors.Wrapf(sgerrors.ErrInvalidJson, "duplicate section %s", in.Name)
+		}
+
+		entries := make([]Entry, 0, len(in.Entries))
+		for _, e := range in.Entries {
+			if e.Key == "" {
+				return nil, errors.Wrapf(sgerrors.ErrInvalidJson, "%s entry without a key", sec.name)
+			}
+			if sec.sealed {
+				if e.Value, err = open(aead, e.Sealed, additionalData(sec.name, e.Key)); err != nil {
+					return nil, errors.Wrapf(err, "%s %s", sec.name, e.Key)
+				}
+			}
+			if !json.Valid(e.Value) {
+				return nil, errors.Wrapf(sgerrors.ErrInvalidJson, "%s %s isn't json", sec.name, e.Key)
+			}
+			entries = append(entries, e)
+		}
+		values[sec.name] = entries
+	}
+
+	restored := make(map[string]int, len(values))
+	for _, sec := range sections {
+		for _, e := range values[sec.name] {
+			if err = s.repo.Put(ctx, sec.prefix, e.Key, e.Value); err != nil {
+				return restored, errors.Wrapf(err, "restore %s %s", sec.name, e.Key)
+			}
+			restored[sec.name]++
+		}
+	}
+
+	return restored, nil
+}
+
+// checkFresh fails when the install has accounts or kubes, they would be
+// mixed with ones of the snapshot.
+func (s *Service) checkFresh(ctx context.Context, lister storage.Lister) error {
+	for _, sec := range sections {
+		if !sec.exclusive {
+			continue
+		}
+
+		found := false
+		err := lister.ListFrom(ctx, sec.prefix, "", 1, func(string, []byte) {
+			found = true
+		})
+		if err != nil {
+			return errors.Wrapf(err, "list %s", sec.name)
+		}
+		if found {
+			return errors.Wrapf(sgerrors.ErrAlreadyExists, "control isn't a fresh install, it has %s", sec.name)
+		}
+	}
+	return nil
+}
+
+func (s *Service) lister() (storage.Lister, error) {
+	lister, ok := s.repo.(storage.Lister)
+	if !ok {
+		return nil, errors.New("storage doesn't list keys")
+	}
+	return lister, nil
+}
+
+func findSection(name string) (section, bool) {
+	for _, sec := range sections {
+		if sec.name == name {
+			return sec, true
+		}
+	}
+	return section{}, false
+}
+
+func newAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	if len(salt) != saltLength {
+		return nil, errors.Wrap(sgerrors.ErrInvalidJson, "invalid salt")
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, keyLength)
+	if err != nil {
+		return nil, errors.Wrap(err, "derive key")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// additionalData binds sealed values to their keys, so they can't be swapped.
+func additionalData(section, key string) []byte {
+	return []byte(section + "/" + key)
+}
+
+func seal(aead cipher.AEAD, value, data []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Wrap(err, "generate nonce")
+	}
+	return aead.Seal(nonce, nonce, value, data), nil
+}
+
+func open(aead cipher.AEAD, sealed, data []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.Wrap(sgerrors.ErrInvalidJson, "sealed value is too short")
+	}
+	value, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], data)
+	if err != nil {
+		return nil, errors.Wrap(sgerrors.ErrInvalidCredentials, "wrong passphrase")
+	}
+	return value, nil
+}
@@ -87,3 +87,31 @@ func (e *ETCDRepository) GetAll(ctx context.Context, prefix string) ([][]byte, e
 	}
 	return result, nil
 }
+
+func (e *ETCDRepository) ListFrom(ctx context.Context, prefix, after string, limit int, fn func(string, []byte)) error {
+	cl, err := e.GetClient()
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to the etcd")
+	}
+	defer cl.Close()
+
+	start := prefix
+	if after != "" {
+		// the smallest key after the last one
+		start = prefix + after + "\x00"
+	}
+	kv := clientv3.NewKV(cl)
+	r, err := kv.Get(ctx, start,
+		clientv3.WithRange(clientv3.GetPrefixRangeEnd(prefix)),
+		clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend),
+		clientv3.WithLimit(int64(limit)))
+	if err != nil {
+		return errors.Wrap(err, "failed to read from the etcd")
+	}
+
+	for _, v := range r.Kvs {
+		fn(string(v.Key[len(prefix):]), v.Value)
+	}
+
+	return nil
+}
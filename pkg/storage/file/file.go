@@ -110,3 +110,27 @@ func (i *FileRepository) GetAll(ctx context.Context, prefix string) ([][]byte, e
 
 	return values, nil
 }
+
+func (i *FileRepository) ListFrom(ctx context.Context, prefix, after string, limit int, fn func(string, []byte)) error {
+	return i.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketName))
+		cursor := bucket.Cursor()
+		prefixBytes := []byte(prefix)
+		start := []byte(prefix + after)
+
+		k, v := cursor.Seek(start)
+		if after != "" && bytes.Equal(k, start) {
+			k, v = cursor.Next()
+		}
+		for n := 0; k != nil && bytes.HasPrefix(k, prefixBytes); k, v = cursor.Next() {
+			if limit > 0 && n == limit {
+				break
+			}
+			n++
+			// values are valid only in transactions
+			fn(string(k[len(prefixBytes):]), append([]byte(nil), v...))
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,347 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// pageBatch is how many values are read at once to fill filtered pages.
+	pageBatch = 100
+
+	// ContinueHeader is the response header with the continue token of
+	// the next page.
+	ContinueHeader = "X-Continue"
+)
+
+// ErrInvalidContinue is returned for continue tokens of other lists.
+var ErrInvalidContinue = errors.New("invalid continue token")
+
+// Lister is implemented by storages that read values in the order of keys,
+// lists are paged by them without reading all values.
+type Lister interface {
+	// ListFrom calls fn with up to limit values with the prefix whose keys
+	// are after the key, keys are without the prefix. All values are read
+	// if the limit is 0.
+	ListFrom(ctx context.Context, prefix, after string, limit int, fn func(key string, value []byte)) error
+}
+
+// ListOptions select, order and page values of lists, values are json objects.
+type ListOptions struct {
+	// Limit of values of a page, all values are returned if it's 0.
+	Limit int
+	// Continue is the token of the page returned with the previous one.
+	Continue string
+	// Fields select values whose fields have these values, fields of nested
+	// objects are dotted, e.g. "state" or "cloudSpec.vpcId".
+	Fields map[string]string
+	// Labels select values whose labels have these values.
+	Labels map[string]string
+	// Sort orders values by fields, in descending order when they are
+	// prefixed with "-". Values are in the order of keys by default.
+	Sort []string
+	// Filter selects values in addition to fields and labels if it's set.
+	Filter func(value []byte) bool
+}
+
+// ParseListOptions reads list options from query parameters: limit,
+// continue, sort=name,-state, fieldSelector=state=operational,region=fra1
+// and labelSelector=env=prod.
+func ParseListOptions(query url.Values) (ListOptions, error) {
+	opts := ListOptions{
+		Continue: query.Get("continue"),
+	}
+
+	if s := query.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 0 {
+			return opts, errors.Errorf("invalid limit %q", s)
+		}
+		opts.Limit = limit
+	}
+	if s := query.Get("sort"); s != "" {
+		opts.Sort = strings.Split(s, ",")
+	}
+
+	var err error
+	if opts.Fields, err = parseSelector(query.Get("fieldSelector")); err != nil {
+		return opts, errors.Wrap(err, "fieldSelector")
+	}
+	if opts.Labels, err = parseSelector(query.Get("labelSelector")); err != nil {
+		return opts, errors.Wrap(err, "labelSelector")
+	}
+
+	return opts, nil
+}
+
+func parseSelector(s string) (map[string]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	selector := make(map[string]string)
+	for _, term := range strings.Split(s, ",") {
+		kv := strings.SplitN(term, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, errors.Errorf("invalid term %q", term)
+		}
+		selector[kv[0]] = kv[1]
+	}
+
+	return selector, nil
+}
+
+// continueToken is encoded in continue tokens, keys are used for pages in
+// the order of keys and offsets for sorted ones.
+type continueToken struct {
+	After  string `json:"after,omitempty"`
+	Offset int    `json:"offset,omitempty"`
+}
+
+func (t continueToken) encode() string {
+	data, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeToken(s string) (continueToken, error) {
+	t := continueToken{}
+	if s == "" {
+		return t, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return t, ErrInvalidContinue
+	}
+	if err = json.Unmarshal(data, &t); err != nil || t.Offset < 0 {
+		return t, ErrInvalidContinue
+	}
+
+	return t, nil
+}
+
+// List returns a page of values with the prefix and the continue token of
+// the next page, the token is empty for the last page.
+func List(ctx context.Context, repository Interface, prefix string, opts ListOptions) ([][]byte, string, error) {
+	token, err := decodeToken(opts.Continue)
+	if err != nil {
+		return nil, "", err
+	}
+
+	lister, ok := repository.(Lister)
+	if !ok || len(opts.Sort) > 0 || token.Offset > 0 {
+		values, err := repository.GetAll(ctx, prefix)
+		if err != nil {
+			return nil, "", err
+		}
+		return Page(values, opts)
+	}
+
+	return listByKeys(ctx, lister, prefix, token.After, opts)
+}
+
+func listByKeys(ctx context.Context, lister Lister, prefix, after string, opts ListOptions) ([][]byte, string, error) {
+	batch := pageBatch
+	if opts.Limit > 0 && opts.Limit < batch && len(opts.Fields) == 0 && len(opts.Labels) == 0 && opts.Filter == nil {
+		// one more shows if there is the next page
+		batch = opts.Limit + 1
+	}
+
+	values := make([][]byte, 0, opts.Limit)
+	for {
+		read, full := 0, false
+		err := lister.ListFrom(ctx, prefix, after, batch, func(key string, value []byte) {
+			if full = opts.Limit > 0 && len(values) == opts.Limit; full {
+				return
+			}
+			read++
+			after = key
+
+			if matches(value, opts) {
+				values = append(values, value)
+			}
+		})
+		if err != nil {
+			return nil, "", err
+		}
+
+		if full {
+			return values, continueToken{After: after}.encode(), nil
+		}
+		if read < batch {
+			return values, "", nil
+		}
+	}
+}
+
+// Page selects, orders and pages values that are read already.
+func Page(values [][]byte, opts ListOptions) ([][]byte, string, error) {
+	token, err := decodeToken(opts.Continue)
+	if err != nil {
+		return nil, "", err
+	}
+	if token.After != "" {
+		return nil, "", ErrInvalidContinue
+	}
+
+	selected := make([][]byte, 0, len(values))
+	for _, v := range values {
+		if len(v) > 0 && matches(v, opts) {
+			selected = append(selected, v)
+		}
+	}
+	if len(opts.Sort) > 0 {
+		sortValues(selected, opts.Sort)
+	}
+
+	if token.Offset > len(selected) {
+		return nil, "", ErrInvalidContinue
+	}
+	selected = selected[token.Offset:]
+	if opts.Limit > 0 && len(selected) > opts.Limit {
+		next := continueToken{Offset: token.Offset + opts.Limit}
+		return selected[:opts.Limit], next.encode(), nil
+	}
+
+	return selected, "", nil
+}
+
+func matches(value []byte, opts ListOptions) bool {
+	if opts.Filter != nil && !opts.Filter(value) {
+		return false
+	}
+	if len(opts.Fields) == 0 && len(opts.Labels) == 0 {
+		return true
+	}
+
+	obj := make(map[string]interface{})
+	if err := json.Unmarshal(value, &obj); err != nil {
+		return false
+	}
+
+	for field, want := range opts.Fields {
+		v, ok := lookup(obj, field)
+		if !ok || format(v) != want {
+			return false
+		}
+	}
+
+	labels, ok := lookup(obj, "labels")
+	if !ok {
+		// kubernetes objects
+		labels, _ = lookup(obj, "metadata.labels")
+	}
+	for name, want := range opts.Labels {
+		m, _ := labels.(map[string]interface{})
+		if v, ok := m[name]; !ok || format(v) != want {
+			return false
+		}
+	}
+
+	return true
+}
+
+func lookup(obj map[string]interface{}, field string) (interface{}, bool) {
+	var v interface{} = obj
+	for _, part := range strings.Split(field, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+
+	return v, true
+}
+
+func format(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+
+	return fmt.Sprint(v)
+}
+
+func sortValues(values [][]byte, fields []string) {
+	objs := make([]map[string]interface{}, len(values))
+	for i, v := range values {
+		json.Unmarshal(v, &objs[i])
+	}
+
+	idx := make([]int, len(values))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		for _, field := range fields {
+			desc := strings.HasPrefix(field, "-")
+			field = strings.TrimPrefix(field, "-")
+			a, _ := lookup(objs[idx[i]], field)
+			b, _ := lookup(objs[idx[j]], field)
+
+			c := compare(a, b)
+			if c == 0 {
+				continue
+			}
+			if desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+
+	sorted := make([][]byte, len(values))
+	for i, j := range idx {
+		sorted[i] = values[j]
+	}
+	copy(values, sorted)
+}
+
+// compare orders missing values first, numbers and booleans by their
+// values and everything else by their text.
+func compare(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	if x, ok := a.(float64); ok {
+		if y, ok := b.(float64); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	if x, ok := a.(bool); ok {
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0
+			case !x:
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return strings.Compare(format(a), format(b))
+}
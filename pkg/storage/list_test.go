@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/storage/file"
+	"github.com/supergiant/control/pkg/storage/memory"
+)
+
+const listPrefix = "/list/"
+
+func fillRepository(t *testing.T, repo Interface) {
+	for i := 0; i < 7; i++ {
+		state := "operational"
+		if i%2 == 1 {
+			state = "failed"
+		}
+		value := fmt.Sprintf(`{"name":"kube-%d","state":%q,"size":%d,"labels":{"env":"env-%d"}}`, i, state, 10-i, i%3)
+		require.NoError(t, repo.Put(context.Background(), listPrefix, fmt.Sprintf("kube-%d", i), []byte(value)))
+	}
+	require.NoError(t, repo.Put(context.Background(), "/other/", "kube-0", []byte(`{}`)))
+}
+
+func names(t *testing.T, repo Interface, opts ListOptions) ([]string, string) {
+	values, next, err := List(context.Background(), repo, listPrefix, opts)
+	require.NoError(t, err)
+
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		obj := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal(v, &obj))
+		result = append(result, obj["name"].(string))
+	}
+
+	return result, next
+}
+
+func allPages(t *testing.T, repo Interface, opts ListOptions) []string {
+	result := make([]string, 0)
+	for {
+		page, next := names(t, repo, opts)
+		require.True(t, opts.Limit == 0 || len(page) <= opts.Limit)
+		result = append(result, page...)
+		if next == "" {
+			return result
+		}
+		opts.Continue = next
+	}
+}
+
+func TestList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "list")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fileRepo, err := file.NewFileRepository(filepath.Join(dir, "list.db"))
+	require.NoError(t, err)
+
+	for name, repo := range map[string]Interface{
+		"memory": memory.NewInMemoryRepository(),
+		"file":   fileRepo,
+	} {
+		t.Run(name, func(t *testing.T) {
+			fillRepository(t, repo)
+
+			page, next := names(t, repo, ListOptions{Limit: 3})
+			require.Equal(t, []string{"kube-0", "kube-1", "kube-2"}, page)
+			require.NotEmpty(t, next)
+
+			require.Equal(t, []string{"kube-0", "kube-1", "kube-2", "kube-3", "kube-4", "kube-5", "kube-6"},
+				allPages(t, repo, ListOptions{Limit: 2}))
+			require.Equal(t, []string{"kube-1", "kube-3", "kube-5"},
+				allPages(t, repo, ListOptions{Limit: 2, Fields: map[string]string{"state": "failed"}}))
+			require.Equal(t, []string{"kube-6"},
+				allPages(t, repo, ListOptions{Fields: map[string]string{"size": "4", "labels.env": "env-0"}}))
+			require.Equal(t, []string{"kube-2", "kube-5"},
+				allPages(t, repo, ListOptions{Limit: 1, Labels: map[string]string{"env": "env-2"}}))
+			require.Equal(t, []string{"kube-6", "kube-4", "kube-2", "kube-0", "kube-5", "kube-3", "kube-1"},
+				allPages(t, repo, ListOptions{Limit: 3, Sort: []string{"-state", "size"}}))
+
+			page, next = names(t, repo, ListOptions{Limit: 7})
+			require.Len(t, page, 7)
+			require.Empty(t, next)
+		})
+	}
+}
+
+func TestListInvalidContinue(t *testing.T) {
+	repo := memory.NewInMemoryRepository()
+	fillRepository(t, repo)
+
+	for _, token := range []string{"#", "bm90IGpzb24", continueToken{Offset: 100}.encode()} {
+		_, _, err := List(context.Background(), repo, listPrefix, ListOptions{Continue: token})
+		require.Equal(t, ErrInvalidContinue, err, token)
+	}
+
+	// pages in the order of keys can't be sorted
+	_, next := names(t, repo, ListOptions{Limit: 1})
+	_, _, err := List(context.Background(), repo, listPrefix, ListOptions{Continue: next, Sort: []string{"name"}})
+	require.Equal(t, ErrInvalidContinue, err)
+}
+
+func TestParseListOptions(t *testing.T) {
+	query, _ := url.ParseQuery("limit=10&continue=abc&sort=name,-state&fieldSelector=state=operational,cloudSpec.region=fra1&labelSelector=env=prod")
+	opts, err := ParseListOptions(query)
+	require.NoError(t, err)
+	require.Equal(t, ListOptions{
+		Limit:    10,
+		Continue: "abc",
+		Sort:     []string{"name", "-state"},
+		Fields:   map[string]string{"state": "operational", "cloudSpec.region": "fra1"},
+		Labels:   map[string]string{"env": "prod"},
+	}, opts)
+
+	for _, q := range []string{"limit=-1", "limit=ten", "fieldSelector=state", "labelSelector==prod"} {
+		query, _ := url.ParseQuery(q)
+		_, err := ParseListOptions(query)
+		require.Error(t, err, q)
+	}
+}
@@ -2,6 +2,7 @@ package memory
 
 import (
 	"context"
+	"sort"
 	"strings"
 	"sync"
 
@@ -62,3 +63,25 @@ func (i *InMemoryRepository) GetAll(ctx context.Context, prefix string) ([][]byt
 
 	return allKeys, nil
 }
+
+func (i *InMemoryRepository) ListFrom(ctx context.Context, prefix, after string, limit int, fn func(string, []byte)) error {
+	i.m.RLock()
+	defer i.m.RUnlock()
+
+	keys := make([]string, 0)
+	for key := range i.data {
+		if strings.HasPrefix(key, prefix) && key[len(prefix):] > after {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	for _, key := range keys {
+		fn(key[len(prefix):], i.data[key])
+	}
+
+	return nil
+}
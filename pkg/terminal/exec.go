@@ -0,0 +1,167 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+// Channels of the kubernetes exec websocket protocol,
+// every message starts with the channel byte.
+const (
+	execProtocol = "v4.channel.k8s.io"
+
+	stdinChannel  = 0
+	stdoutChannel = 1
+	stderrChannel = 2
+	errorChannel  = 3
+	resizeChannel = 4
+)
+
+// ExecStream is a shell in a pod container that is started with the pods/exec API.
+type ExecStream struct {
+	conn *websocket.Conn
+
+	wm  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Exec runs the command in the container with a pseudo terminal. Requests are
+// authenticated with the rest config, including impersonation of the user.
+func Exec(cfg *rest.Config, namespace, pod, container string, command []string, size Size) (*ExecStream, error) {
+	u, err := url.Parse(cfg.Host)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse api server address")
+	}
+	u.Scheme = strings.Replace(u.Scheme, "http", "ws", 1)
+	u.Path = fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/exec", namespace, pod)
+
+	q := url.Values{}
+	q.Set("stdin", "true")
+	q.Set("stdout", "true")
+	q.Set("tty", "true")
+	if container != "" {
+		q.Set("container", container)
+	}
+	for _, c := range command {
+		q.Add("command", c)
+	}
+	u.RawQuery = q.Encode()
+
+	tlsConfig, err := rest.TLSConfigFor(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "build tls config")
+	}
+
+	header := http.Header{}
+	if cfg.BearerToken != "" {
+		header.Set("Authorization", "Bearer "+cfg.BearerToken)
+	}
+	if cfg.Impersonate.UserName != "" {
+		header.Set("Impersonate-User", cfg.Impersonate.UserName)
+		for _, g := range cfg.Impersonate.Groups {
+			header.Add("Impersonate-Group", g)
+		}
+	}
+
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		TLSClientConfig:  tlsConfig,
+		HandshakeTimeout: time.Second * 10,
+		Subprotocols:     []string{execProtocol},
+	}
+	conn, resp, err := dialer.Dial(u.String(), header)
+	if err != nil {
+		if resp != nil {
+			return nil, errors.Wrapf(err, "exec %s/%s: %s", namespace, pod, resp.Status)
+		}
+		return nil, errors.Wrapf(err, "exec %s/%s", namespace, pod)
+	}
+
+	s := &ExecStream{conn: conn}
+	if err := s.Resize(size.Cols, size.Rows); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *ExecStream) Read(p []byte) (int, error) {
+	for s.buf.Len() == 0 {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				return 0, io.EOF
+			}
+			return 0, err
+		}
+		if len(data) == 0 {
+			continue
+		}
+
+		switch data[0] {
+		case stdoutChannel, stderrChannel:
+			s.buf.Write(data[1:])
+		case errorChannel:
+			return 0, execStatus(data[1:])
+		}
+	}
+
+	return s.buf.Read(p)
+}
+
+func (s *ExecStream) Write(p []byte) (int, error) {
+	if err := s.send(stdinChannel, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *ExecStream) Resize(cols, rows int) error {
+	data, err := json.Marshal(struct {
+		Width  int
+		Height int
+	}{cols, rows})
+	if err != nil {
+		return err
+	}
+
+	return s.send(resizeChannel, data)
+}
+
+func (s *ExecStream) Close() error {
+	return s.conn.Close()
+}
+
+func (s *ExecStream) send(channel byte, p []byte) error {
+	s.wm.Lock()
+	defer s.wm.Unlock()
+
+	s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return s.conn.WriteMessage(websocket.BinaryMessage, append([]byte{channel}, p...))
+}
+
+// execStatus converts the status sent when the command exits.
+func execStatus(data []byte) error {
+	status := metav1.Status{}
+	if err := json.Unmarshal(data, &status); err != nil {
+		return errors.Wrap(err, "decode exec status")
+	}
+	if status.Status == metav1.StatusSuccess {
+		return io.EOF
+	}
+
+	return errors.Errorf("command failed: %s", status.Message)
+}
@@ -0,0 +1,77 @@
+package terminal
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestExec(t *testing.T) {
+	var req *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		conn, err := (&websocket.Upgrader{Subprotocols: []string{execProtocol}}).Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			switch data[0] {
+			case resizeChannel:
+				conn.WriteMessage(websocket.BinaryMessage, append([]byte{stdoutChannel}, data[1:]...))
+			case stdinChannel:
+				if string(data[1:]) == "exit" {
+					status, _ := json.Marshal(metav1.Status{Status: metav1.StatusFailure, Message: "exit code 1"})
+					conn.WriteMessage(websocket.BinaryMessage, append([]byte{errorChannel}, status...))
+					continue
+				}
+				conn.WriteMessage(websocket.BinaryMessage, append([]byte{stderrChannel}, data[1:]...))
+			}
+		}
+	}))
+	defer srv.Close()
+
+	s, err := Exec(&rest.Config{
+		Host: srv.URL,
+		Impersonate: rest.ImpersonationConfig{
+			UserName: "alice",
+			Groups:   []string{"supergiant:proxy:edit"},
+		},
+	}, "default", "app", "nginx", []string{"/bin/sh", "-i"}, Size{80, 24})
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.Equal(t, "/api/v1/namespaces/default/pods/app/exec", req.URL.Path)
+	require.Equal(t, []string{"/bin/sh", "-i"}, req.URL.Query()["command"])
+	require.Equal(t, "nginx", req.URL.Query().Get("container"))
+	require.Equal(t, "true", req.URL.Query().Get("tty"))
+	require.Equal(t, "alice", req.Header.Get("Impersonate-User"))
+	require.Equal(t, []string{"supergiant:proxy:edit"}, req.Header["Impersonate-Group"])
+
+	buf := make([]byte, 64)
+	n, err := s.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, `{"Width":80,"Height":24}`, string(buf[:n]))
+
+	_, err = s.Write([]byte("ls"))
+	require.NoError(t, err)
+	n, err = s.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "ls", string(buf[:n]))
+
+	_, err = s.Write([]byte("exit"))
+	require.NoError(t, err)
+	_, err = ioutil.ReadAll(s)
+	require.EqualError(t, err, "command failed: exit code 1")
+}
@@ -0,0 +1,86 @@
+package terminal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Recorder writes a session in the asciicast v2 format, so recordings
+// can be replayed with asciinema. Only the output is recorded, typed
+// commands appear in it as echoed by the terminal while secrets entered
+// at prompts without echo don't.
+type Recorder struct {
+	m     sync.Mutex
+	w     io.WriteCloser
+	start time.Time
+	err   error
+}
+
+type castHeader struct {
+	Version   int    `json:"version"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Timestamp int64  `json:"timestamp"`
+	Title     string `json:"title,omitempty"`
+}
+
+func NewRecorder(w io.WriteCloser, size Size, title string) (*Recorder, error) {
+	r := &Recorder{
+		w:     w,
+		start: time.Now(),
+	}
+
+	header, err := json.Marshal(castHeader{
+		Version:   2,
+		Width:     size.Cols,
+		Height:    size.Rows,
+		Timestamp: r.start.Unix(),
+		Title:     title,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(w, "%s\n", header); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *Recorder) Output(p []byte) {
+	r.event("o", string(p))
+}
+
+func (r *Recorder) Resize(cols, rows int) {
+	r.event("r", fmt.Sprintf("%dx%d", cols, rows))
+}
+
+func (r *Recorder) event(typ, data string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.err != nil {
+		return
+	}
+
+	line, err := json.Marshal([]interface{}{time.Since(r.start).Seconds(), typ, data})
+	if err == nil {
+		_, err = fmt.Fprintf(r.w, "%s\n", line)
+	}
+	r.err = err
+}
+
+// Close finishes the recording, it returns the first error of writing events.
+func (r *Recorder) Close() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if err := r.w.Close(); err != nil && r.err == nil {
+		r.err = err
+	}
+
+	return r.err
+}
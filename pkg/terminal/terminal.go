@@ -0,0 +1,101 @@
+package terminal
+
+import (
+	"io"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+const (
+	MessageInput  = "input"
+	MessageResize = "resize"
+
+	writeTimeout = time.Second * 10
+)
+
+// Size is a terminal window size in characters.
+type Size struct {
+	Cols int `json:"cols"`
+	Rows int `json:"rows"`
+}
+
+// Stream is a remote shell attached to a pseudo terminal.
+type Stream interface {
+	io.ReadWriteCloser
+	Resize(cols, rows int) error
+}
+
+// Message is sent by the browser, it carries either user input or a new window size.
+type Message struct {
+	Type string `json:"type"`
+	Data string `json:"data,omitempty"`
+	Cols int    `json:"cols,omitempty"`
+	Rows int    `json:"rows,omitempty"`
+}
+
+// Serve pipes the websocket connection to the stream until either side
+// closes. Output is sent in binary messages and recorded.
+func Serve(conn *websocket.Conn, s Stream, rec *Recorder) error {
+	done := make(chan error, 2)
+
+	go func() {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := s.Read(buf)
+			if n > 0 {
+				rec.Output(buf[:n])
+
+				conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+				if err := conn.WriteMessage(websocket.BinaryMessage, buf[:n]); err != nil {
+					done <- errors.Wrap(err, "write output")
+					return
+				}
+			}
+			if err == io.EOF {
+				done <- nil
+				return
+			}
+			if err != nil {
+				done <- errors.Wrap(err, "read output")
+				return
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			m := Message{}
+			if err := conn.ReadJSON(&m); err != nil {
+				if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					err = nil
+				}
+				done <- err
+				return
+			}
+
+			switch m.Type {
+			case MessageInput:
+				if _, err := s.Write([]byte(m.Data)); err != nil {
+					done <- errors.Wrap(err, "write input")
+					return
+				}
+			case MessageResize:
+				if m.Cols > 0 && m.Rows > 0 {
+					rec.Resize(m.Cols, m.Rows)
+					s.Resize(m.Cols, m.Rows)
+				}
+			}
+		}
+	}()
+
+	err := <-done
+	s.Close()
+
+	conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	conn.WriteMessage(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+
+	return err
+}
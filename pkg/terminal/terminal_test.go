@@ -0,0 +1,114 @@
+package terminal
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStream echoes input in upper case until "exit" is typed.
+type fakeStream struct {
+	r *io.PipeReader
+	w *io.PipeWriter
+
+	m       sync.Mutex
+	resizes []Size
+	closed  bool
+}
+
+func newFakeStream() *fakeStream {
+	r, w := io.Pipe()
+	return &fakeStream{r: r, w: w}
+}
+
+func (s *fakeStream) Read(p []byte) (int, error) {
+	return s.r.Read(p)
+}
+
+func (s *fakeStream) Write(p []byte) (int, error) {
+	if string(p) == "exit" {
+		s.w.Close()
+		return len(p), nil
+	}
+	go s.w.Write([]byte(strings.ToUpper(string(p))))
+	return len(p), nil
+}
+
+func (s *fakeStream) Resize(cols, rows int) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.resizes = append(s.resizes, Size{cols, rows})
+	return nil
+}
+
+func (s *fakeStream) Close() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.closed = true
+	return nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+func TestServe(t *testing.T) {
+	stream := newFakeStream()
+	cast := &strings.Builder{}
+	rec, err := NewRecorder(nopCloser{cast}, Size{80, 24}, "node-1")
+	require.NoError(t, err)
+
+	served := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		served <- Serve(conn, stream, rec)
+	}))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.WriteJSON(Message{Type: MessageResize, Cols: 120, Rows: 40}))
+	require.NoError(t, conn.WriteJSON(Message{Type: MessageInput, Data: "uptime"}))
+
+	typ, out, err := conn.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, websocket.BinaryMessage, typ)
+	require.Equal(t, "UPTIME", string(out))
+
+	require.NoError(t, conn.WriteJSON(Message{Type: MessageInput, Data: "exit"}))
+	require.NoError(t, <-served)
+	require.NoError(t, rec.Close())
+
+	stream.m.Lock()
+	require.True(t, stream.closed)
+	require.Equal(t, []Size{{120, 40}}, stream.resizes)
+	stream.m.Unlock()
+
+	lines := bufio.NewScanner(strings.NewReader(cast.String()))
+	require.True(t, lines.Scan())
+	header := castHeader{}
+	require.NoError(t, json.Unmarshal(lines.Bytes(), &header))
+	require.Equal(t, castHeader{Version: 2, Width: 80, Height: 24, Timestamp: header.Timestamp, Title: "node-1"}, header)
+
+	var events []string
+	for lines.Scan() {
+		var event []interface{}
+		require.NoError(t, json.Unmarshal(lines.Bytes(), &event))
+		events = append(events, event[1].(string)+":"+event[2].(string))
+	}
+	require.Equal(t, []string{"r:120x40", "o:UPTIME"}, events)
+}
@@ -0,0 +1,297 @@
+// Package terraform describes cloud resources of a cluster as a terraform
+// configuration with import blocks, so they can be managed by terraform.
+package terraform
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+const header = `Cloud resources of the %s cluster (%s) created by supergiant control.
+Import blocks need terraform 1.5 or newer. Attributes that control doesn't
+keep are left out, run terraform plan after import to find them.`
+
+// config is a list of top level blocks.
+type config struct {
+	blocks  []*block
+	imports []*block
+}
+
+func (c *config) block(typ string, labels ...string) *block {
+	b := &block{typ: typ, labels: labels}
+	c.blocks = append(c.blocks, b)
+	return b
+}
+
+// resource adds the resource with an import block of the existing object,
+// resources without id aren't added.
+func (c *config) resource(typ, resourceName, id string) *block {
+	b := &block{typ: "resource", labels: []string{typ, resourceName}}
+	if id == "" {
+		return b
+	}
+	c.blocks = append(c.blocks, b)
+
+	c.imports = append(c.imports, (&block{typ: "import"}).
+		set("to", Ref(typ+"."+resourceName)).
+		set("id", id))
+	return b
+}
+
+func (c *config) bytes() []byte {
+	buf := &bytes.Buffer{}
+	for _, b := range append(c.blocks, c.imports...) {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		b.write(buf, "")
+	}
+	return buf.Bytes()
+}
+
+// Export returns the configuration of cloud resources of the cluster.
+func Export(k *model.Kube) ([]byte, error) {
+	c := &config{}
+	c.block("terraform").comment = fmt.Sprintf(header, k.Name, k.ID)
+
+	switch k.Provider {
+	case clouds.AWS:
+		exportAWS(c, k)
+	case clouds.DigitalOcean:
+		exportDigitalOcean(c, k)
+	case clouds.GCE:
+		exportGCE(c, k)
+	case clouds.Azure:
+		exportAzure(c, k)
+	default:
+		return nil, errors.Wrapf(sgerrors.ErrUnsupportedProvider, "terraform export of %s", k.Provider)
+	}
+
+	return c.bytes(), nil
+}
+
+func exportAWS(c *config, k *model.Kube) {
+	spec := k.CloudSpec
+	c.blocks[0].add((&block{typ: "required_providers"}).
+		set("aws", map[string]string{"source": "hashicorp/aws"}))
+	c.block("provider", "aws").set("region", k.Region)
+
+	tags := map[string]string{
+		clouds.TagClusterID:         k.ID,
+		clouds.TagKubernetesCluster: k.Name,
+	}
+	vpc := Ref("aws_vpc.cluster.id")
+
+	c.resource("aws_vpc", "cluster", spec[clouds.AwsVpcID]).
+		setIf("cidr_block", spec[clouds.AwsVpcCIDR]).
+		set("tags", tags)
+	c.resource("aws_internet_gateway", "cluster", spec[clouds.AwsInternetGateWayID]).
+		set("vpc_id", vpc)
+	c.resource("aws_route_table", "cluster", spec[clouds.AwsRouteTableID]).
+		set("vpc_id", vpc)
+
+	for _, az := range sortedKeys(k.Subnets) {
+		c.resource("aws_subnet", name(az), k.Subnets[az]).
+			set("vpc_id", vpc).
+			set("availability_zone", az).
+			set("map_public_ip_on_launch", true)
+	}
+
+	c.resource("aws_security_group", "masters", spec[clouds.AwsMastersSecGroupID]).
+		set("vpc_id", vpc)
+	c.resource("aws_security_group", "nodes", spec[clouds.AwsNodesSecgroupID]).
+		set("vpc_id", vpc)
+
+	for resourceName, profile := range map[string]string{
+		"masters": spec[clouds.AwsMasterInstanceProfile],
+		"nodes":   spec[clouds.AwsNodeInstanceProfile],
+	} {
+		c.resource("aws_iam_instance_profile", resourceName, profile).
+			set("name", profile)
+	}
+
+	if keyPair := spec[clouds.AwsKeyPairName]; keyPair != "" && k.SSHConfig.BootstrapPublicKey != "" {
+		c.resource("aws_key_pair", "bootstrap", keyPair).
+			set("key_name", keyPair).
+			set("public_key", strings.TrimSpace(k.SSHConfig.BootstrapPublicKey))
+	}
+
+	for resourceName, lb := range map[string]string{
+		"external": spec[clouds.AwsExternalLoadBalancerName],
+		"internal": spec[clouds.AwsInternalLoadBalancerName],
+	} {
+		c.resource("aws_elb", resourceName, lb).
+			set("name", lb).
+			set("internal", resourceName == "internal")
+	}
+
+	for _, m := range machines(k) {
+		profile := Ref("aws_iam_instance_profile.nodes.name")
+		sg := Ref("aws_security_group.nodes.id")
+		if m.Role == model.RoleMaster {
+			profile = Ref("aws_iam_instance_profile.masters.name")
+			sg = Ref("aws_security_group.masters.id")
+		}
+
+		b := c.resource("aws_instance", name(m.Name), m.ID).
+			setIf("ami", spec[clouds.AwsImageID]).
+			set("instance_type", m.Size).
+			setIf("availability_zone", m.AvailabilityZone)
+		if subnet := k.Subnets[m.AvailabilityZone]; subnet != "" {
+			b.set("subnet_id", Ref("aws_subnet."+name(m.AvailabilityZone)+".id"))
+		}
+		if spec[clouds.AwsMastersSecGroupID] != "" {
+			b.set("vpc_security_group_ids", Ref("["+string(sg)+"]"))
+		}
+		if spec[clouds.AwsMasterInstanceProfile] != "" {
+			b.set("iam_instance_profile", profile)
+		}
+		if spec[clouds.AwsKeyPairName] != "" {
+			b.set("key_name", spec[clouds.AwsKeyPairName])
+		}
+		b.set("tags", map[string]string{
+			clouds.TagNodeName:          m.Name,
+			clouds.TagClusterID:         k.ID,
+			clouds.TagKubernetesCluster: k.Name,
+		})
+	}
+}
+
+func exportDigitalOcean(c *config, k *model.Kube) {
+	c.blocks[0].add((&block{typ: "required_providers"}).
+		set("digitalocean", map[string]string{"source": "digitalocean/digitalocean"}))
+	c.block("provider", "digitalocean")
+
+	for _, m := range machines(k) {
+		c.resource("digitalocean_droplet", name(m.Name), m.ID).
+			set("name", m.Name).
+			set("region", m.Region).
+			set("size", m.Size).
+			set("tags", []string{k.ID})
+	}
+
+	for resourceName, lb := range map[string]string{
+		"external": k.CloudSpec[clouds.DigitalOceanExternalLoadBalancerID],
+		"internal": k.CloudSpec[clouds.DigitalOceanInternalLoadBalancerID],
+	} {
+		c.resource("digitalocean_loadbalancer", resourceName, lb).
+			set("region", k.Region)
+	}
+}
+
+func exportGCE(c *config, k *model.Kube) {
+	spec := k.CloudSpec
+	c.blocks[0].add((&block{typ: "required_providers"}).
+		set("google", map[string]string{"source": "hashicorp/google"}))
+	c.block("provider", "google").set("region", k.Region)
+
+	network := spec[clouds.GCENetworkName]
+	c.resource("google_compute_network", "cluster", network).
+		set("name", network)
+
+	for _, m := range machines(k) {
+		zone := m.AvailabilityZone
+		if zone == "" {
+			zone = k.Zone
+		}
+		c.resource("google_compute_instance", name(m.Name), zone+"/"+m.Name).
+			set("name", m.Name).
+			set("machine_type", m.Size).
+			set("zone", zone)
+	}
+
+	regional := func(n string) string {
+		if n == "" {
+			return ""
+		}
+		return k.Region + "/" + n
+	}
+
+	tp := spec[clouds.GCETargetPoolName]
+	c.resource("google_compute_target_pool", "cluster", regional(tp)).
+		set("name", tp)
+	hc := spec[clouds.GCEHealthCheckName]
+	c.resource("google_compute_health_check", "cluster", hc).
+		set("name", hc)
+	bs := spec[clouds.GCEBackendServiceName]
+	c.resource("google_compute_region_backend_service", "cluster", regional(bs)).
+		set("name", bs).
+		set("region", k.Region)
+
+	for resourceName, keys := range map[string][2]string{
+		"external": {clouds.GCEExternalIPAddressName, clouds.GCEExternalForwardingRuleName},
+		"internal": {clouds.GCEInternalIPAddressName, clouds.GCEInternalForwardingRuleName},
+	} {
+		address := spec[keys[0]]
+		c.resource("google_compute_address", resourceName, regional(address)).
+			set("name", address).
+			set("address_type", strings.ToUpper(resourceName))
+		rule := spec[keys[1]]
+		c.resource("google_compute_forwarding_rule", resourceName, regional(rule)).
+			set("name", rule)
+	}
+}
+
+func exportAzure(c *config, k *model.Kube) {
+	c.blocks[0].add((&block{typ: "required_providers"}).
+		set("azurerm", map[string]string{"source": "hashicorp/azurerm"}))
+	c.block("provider", "azurerm").add(&block{typ: "features"})
+
+	group := ""
+	for _, m := range machines(k) {
+		// ids are /subscriptions/{id}/resourceGroups/{group}/providers/...
+		parts := strings.Split(m.ID, "/")
+		if group == "" && len(parts) > 4 {
+			group = strings.Join(parts[:5], "/")
+			c.resource("azurerm_resource_group", "cluster", group).
+				set("name", parts[4]).
+				set("location", k.Region)
+		}
+
+		c.resource("azurerm_linux_virtual_machine", name(m.Name), m.ID).
+			set("name", m.Name).
+			set("resource_group_name", Ref("azurerm_resource_group.cluster.name")).
+			set("location", k.Region).
+			set("size", m.Size)
+	}
+}
+
+// machines returns masters and nodes sorted by name.
+func machines(k *model.Kube) []*model.Machine {
+	list := make([]*model.Machine, 0, len(k.Masters)+len(k.Nodes))
+	for _, group := range []map[string]*model.Machine{k.Masters, k.Nodes} {
+		for _, m := range group {
+			role := m.Role
+			if role == "" {
+				_, isMaster := k.Masters[m.Name]
+				role = model.ToRole(isMaster)
+			}
+			machine := *m
+			machine.Role = role
+			list = append(list, &machine)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
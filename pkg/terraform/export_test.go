@@ -0,0 +1,84 @@
+package terraform
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+func TestExport_AWS(t *testing.T) {
+	k := &model.Kube{
+		ID:       "kube-1",
+		Name:     "test",
+		Provider: clouds.AWS,
+		Region:   "us-east-1",
+		CloudSpec: map[string]string{
+			clouds.AwsVpcID:                    "vpc-1",
+			clouds.AwsVpcCIDR:                  "10.2.0.0/16",
+			clouds.AwsMastersSecGroupID:        "sg-1",
+			clouds.AwsNodesSecgroupID:          "sg-2",
+			clouds.AwsExternalLoadBalancerName: "test-ext",
+		},
+		Subnets: map[string]string{"us-east-1a": "subnet-1"},
+		Masters: map[string]*model.Machine{
+			"master-1": {ID: "i-1", Name: "master-1", Role: model.RoleMaster, Size: "m4.large", AvailabilityZone: "us-east-1a"},
+		},
+		Nodes: map[string]*model.Machine{
+			"node-1": {ID: "i-2", Name: "node-1", Role: model.RoleNode, Size: "m4.large", AvailabilityZone: "us-east-1a"},
+		},
+	}
+
+	data, err := Export(k)
+	require.NoError(t, err)
+	hcl := string(data)
+
+	for _, expected := range []string{
+		`resource "aws_vpc" "cluster" {`,
+		`  cidr_block = "10.2.0.0/16"`,
+		`resource "aws_subnet" "us-east-1a" {`,
+		`  vpc_id = aws_vpc.cluster.id`,
+		`resource "aws_instance" "master-1" {`,
+		`  vpc_security_group_ids = [aws_security_group.masters.id]`,
+		`  subnet_id              = aws_subnet.us-east-1a.id`,
+		`resource "aws_elb" "external" {`,
+		"import {\n  to = aws_instance.node-1\n  id = \"i-2\"\n}",
+		"import {\n  to = aws_vpc.cluster\n  id = \"vpc-1\"\n}",
+	} {
+		require.Contains(t, hcl, expected)
+	}
+
+	// resources without ids haven't been created
+	require.NotContains(t, hcl, "aws_internet_gateway")
+	require.NotContains(t, hcl, `"internal"`)
+	// imports follow resources
+	require.True(t, strings.LastIndex(hcl, "resource ") < strings.Index(hcl, "import {"))
+}
+
+func TestExport_DigitalOcean(t *testing.T) {
+	data, err := Export(&model.Kube{
+		ID:       "kube-1",
+		Provider: clouds.DigitalOcean,
+		Region:   "fra1",
+		Nodes: map[string]*model.Machine{
+			"node-1": {ID: "1234", Name: "node-1", Region: "fra1", Size: "s-2vcpu-4gb"},
+		},
+	})
+	require.NoError(t, err)
+	require.Contains(t, string(data), `resource "digitalocean_droplet" "node-1" {`)
+	require.Contains(t, string(data), `  id = "1234"`)
+}
+
+func TestExport_Unsupported(t *testing.T) {
+	_, err := Export(&model.Kube{Provider: clouds.OpenStack})
+	require.True(t, sgerrors.IsUnsupportedProvider(err))
+}
+
+func TestName(t *testing.T) {
+	require.Equal(t, "node_1_local", name("node.1.local"))
+	require.Equal(t, "_1node", name("1node"))
+}
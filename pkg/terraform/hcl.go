@@ -0,0 +1,126 @@
+package terraform
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// Ref is an expression written as is, e.g. aws_vpc.cluster.id.
+type Ref string
+
+type attr struct {
+	key   string
+	value interface{}
+}
+
+// block is a resource, provider or import block of a configuration.
+type block struct {
+	typ     string
+	labels  []string
+	comment string
+	attrs   []attr
+	blocks  []*block
+}
+
+func (b *block) set(key string, value interface{}) *block {
+	b.attrs = append(b.attrs, attr{key, value})
+	return b
+}
+
+// setIf sets string values that aren't empty.
+func (b *block) setIf(key string, value string) *block {
+	if value == "" {
+		return b
+	}
+	return b.set(key, value)
+}
+
+func (b *block) add(nested *block) *block {
+	b.blocks = append(b.blocks, nested)
+	return b
+}
+
+func (b *block) write(buf *bytes.Buffer, indent string) {
+	if b.comment != "" {
+		for _, line := range strings.Split(b.comment, "\n") {
+			fmt.Fprintf(buf, "%s# %s\n", indent, line)
+		}
+	}
+
+	buf.WriteString(indent + b.typ)
+	for _, l := range b.labels {
+		buf.WriteString(" " + strconv.Quote(l))
+	}
+	buf.WriteString(" {\n")
+
+	width := 0
+	for _, a := range b.attrs {
+		if len(a.key) > width {
+			width = len(a.key)
+		}
+	}
+	for _, a := range b.attrs {
+		fmt.Fprintf(buf, "%s  %-*s = %s\n", indent, width, a.key, value(a.value, indent+"  "))
+	}
+
+	for i, nested := range b.blocks {
+		if i > 0 || len(b.attrs) > 0 {
+			buf.WriteString("\n")
+		}
+		nested.write(buf, indent+"  ")
+	}
+	buf.WriteString(indent + "}\n")
+}
+
+func value(v interface{}, indent string) string {
+	switch v := v.(type) {
+	case Ref:
+		return string(v)
+	case string:
+		return strconv.Quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case []string:
+		quoted := make([]string, 0, len(v))
+		for _, s := range v {
+			quoted = append(quoted, strconv.Quote(s))
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	case map[string]string:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		buf := &bytes.Buffer{}
+		buf.WriteString("{\n")
+		for _, k := range keys {
+			fmt.Fprintf(buf, "%s  %s = %s\n", indent, strconv.Quote(k), strconv.Quote(v[k]))
+		}
+		buf.WriteString(indent + "}")
+		return buf.String()
+	}
+
+	return strconv.Quote(fmt.Sprint(v))
+}
+
+// name makes a resource name of s, names start with a letter
+// or underscore and consist of letters, digits, underscores and dashes.
+func name(s string) string {
+	s = invalidNameChars.ReplaceAllString(s, "_")
+	if s == "" || (s[0] >= '0' && s[0] <= '9') || s[0] == '-' {
+		s = "_" + s
+	}
+	return s
+}
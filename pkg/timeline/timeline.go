@@ -0,0 +1,204 @@
+package timeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/storage"
+)
+
+// DefaultStoragePrefix keeps events of clusters, events of a cluster are
+// stored under its own prefix.
+const DefaultStoragePrefix = "/supergiant/events/"
+
+// Source tells what reported the event.
+type Source string
+
+const (
+	SourceControl    Source = "control"
+	SourceKubernetes Source = "kubernetes"
+)
+
+// Type is a reason of the event, kubernetes events keep their own reasons.
+type Type string
+
+const (
+	ProvisionStarted  Type = "ProvisionStarted"
+	ProvisionFinished Type = "ProvisionFinished"
+	ClusterFailed     Type = "ClusterFailed"
+	NodeAdded         Type = "NodeAdded"
+	NodeFailed        Type = "NodeFailed"
+	NodeRemoved       Type = "NodeRemoved"
+	NodeProblem       Type = "NodeProblem"
+	UpgradeScheduled  Type = "UpgradeScheduled"
+	UpgradeStarted    Type = "UpgradeStarted"
+	UpgradeFinished   Type = "UpgradeFinished"
+	// UpgradeCanaryPassed and UpgradeCanaryFailed report verification of
+	// canary nodes, other nodes aren't upgraded when it fails.
+	UpgradeCanaryPassed Type = "UpgradeCanaryPassed"
+	UpgradeCanaryFailed Type = "UpgradeCanaryFailed"
+	UpgradeFailed       Type = "UpgradeFailed"
+	// ControlPlaneReplaced and ControlPlaneRolledBack report the blue/green
+	// upgrade of masters.
+	ControlPlaneReplaced   Type = "ControlPlaneReplaced"
+	ControlPlaneRolledBack Type = "ControlPlaneRolledBack"
+	RepairStarted          Type = "RepairStarted"
+	CertificateRotated     Type = "CertificateRotated"
+	// ClusterDeleted and TaskFailed are exported only, the timeline of a
+	// deleted cluster is gone and failures of tasks are kept with tasks.
+	ClusterDeleted Type = "ClusterDeleted"
+	TaskFailed     Type = "TaskFailed"
+)
+
+// Exporter receives recorded events, e.g. to publish them to a message bus.
+// ExportEvent is called synchronously, so it mustn't block.
+type Exporter interface {
+	ExportEvent(e *Event)
+}
+
+var exporters = struct {
+	sync.RWMutex
+	m map[Exporter]struct{}
+}{m: make(map[Exporter]struct{})}
+
+// RegisterExporter adds the exporter of events of all services.
+func RegisterExporter(e Exporter) {
+	exporters.Lock()
+	defer exporters.Unlock()
+
+	exporters.m[e] = struct{}{}
+}
+
+func UnregisterExporter(e Exporter) {
+	exporters.Lock()
+	defer exporters.Unlock()
+
+	delete(exporters.m, e)
+}
+
+// Export hands the event to registered exporters without recording it.
+func Export(e *Event) {
+	fill(e)
+
+	exporters.RLock()
+	defer exporters.RUnlock()
+
+	for exporter := range exporters.m {
+		exporter.ExportEvent(e)
+	}
+}
+
+// Event is a significant change of the cluster.
+type Event struct {
+	ID     string `json:"id"`
+	KubeID string `json:"kubeId"`
+	Source Source `json:"source"`
+	Type   Type   `json:"type"`
+	// Object the event is about, a machine name or kind/namespace/name
+	// of a kubernetes object.
+	Object  string    `json:"object,omitempty"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// Service keeps the timeline of clusters.
+type Service struct {
+	prefix     string
+	repository storage.Interface
+}
+
+func NewService(prefix string, repository storage.Interface) *Service {
+	return &Service{
+		prefix:     prefix,
+		repository: repository,
+	}
+}
+
+// Record adds the event to the cluster timeline, it's a no-op for a service
+// without storage, so components record events unconditionally.
+func (s *Service) Record(ctx context.Context, e *Event) error {
+	if s == nil || s.repository == nil {
+		return nil
+	}
+
+	fill(e)
+	data, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrap(err, "marshal")
+	}
+
+	// keys are ordered by time of events
+	key := fmt.Sprintf("%020d-%s", e.Time.UnixNano(), e.ID)
+	if err = s.repository.Put(ctx, s.kubePrefix(e.KubeID), key, data); err != nil {
+		return errors.Wrap(err, "save event")
+	}
+
+	Export(e)
+	return nil
+}
+
+func fill(e *Event) {
+	if e.ID == "" {
+		e.ID = uuid.New()
+	}
+	if e.Source == "" {
+		e.Source = SourceControl
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+}
+
+// List returns events of the cluster in the time range ordered by time,
+// a zero bound doesn't limit the range.
+func (s *Service) List(ctx context.Context, kubeID string, from, to time.Time) ([]Event, error) {
+	rawEvents, err := s.repository.GetAll(ctx, s.kubePrefix(kubeID))
+	if err != nil {
+		return nil, errors.Wrap(err, "get events")
+	}
+
+	events := make([]Event, 0, len(rawEvents))
+	for _, data := range rawEvents {
+		e := Event{}
+		if err = json.Unmarshal(data, &e); err != nil {
+			logrus.Warnf("timeline: decode event of kube %s: %v", kubeID, err)
+			continue
+		}
+		if InRange(e.Time, from, to) {
+			events = append(events, e)
+		}
+	}
+
+	Sort(events)
+	return events, nil
+}
+
+func (s *Service) kubePrefix(kubeID string) string {
+	return s.prefix + kubeID + "/"
+}
+
+// InRange tells whether t is within [from, to], zero bounds are open.
+func InRange(t, from, to time.Time) bool {
+	if !from.IsZero() && t.Before(from) {
+		return false
+	}
+	if !to.IsZero() && t.After(to) {
+		return false
+	}
+	return true
+}
+
+// Sort orders events by time.
+func Sort(events []Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+}
@@ -0,0 +1,78 @@
+package timeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/storage/memory"
+)
+
+func TestService_RecordList(t *testing.T) {
+	svc := NewService(DefaultStoragePrefix, memory.NewInMemoryRepository())
+	ctx := context.Background()
+	start := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	for i, typ := range []Type{NodeAdded, ProvisionStarted, ProvisionFinished} {
+		require.NoError(t, svc.Record(ctx, &Event{
+			KubeID: "kube",
+			Type:   typ,
+			// events come in a random order
+			Time: start.Add(time.Duration(2-i) * time.Minute),
+		}))
+	}
+	require.NoError(t, svc.Record(ctx, &Event{KubeID: "other", Type: NodeAdded, Time: start}))
+
+	events, err := svc.List(ctx, "kube", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	require.Equal(t, ProvisionFinished, events[0].Type)
+	require.Equal(t, NodeAdded, events[2].Type)
+	require.Equal(t, SourceControl, events[0].Source)
+	require.NotEmpty(t, events[0].ID)
+
+	events, err = svc.List(ctx, "kube", start.Add(time.Minute), start.Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, ProvisionStarted, events[0].Type)
+}
+
+func TestService_RecordNoStorage(t *testing.T) {
+	var svc *Service
+	require.NoError(t, svc.Record(context.Background(), &Event{KubeID: "kube"}))
+	require.NoError(t, NewService(DefaultStoragePrefix, nil).Record(context.Background(), &Event{KubeID: "kube"}))
+}
+
+type recorder struct {
+	events []Event
+}
+
+func (r *recorder) ExportEvent(e *Event) {
+	r.events = append(r.events, *e)
+}
+
+func TestRegisterExporter(t *testing.T) {
+	exporter := &recorder{}
+	RegisterExporter(exporter)
+	defer UnregisterExporter(exporter)
+
+	svc := NewService(DefaultStoragePrefix, memory.NewInMemoryRepository())
+	require.NoError(t, svc.Record(context.Background(), &Event{KubeID: "kube", Type: NodeRemoved}))
+	Export(&Event{KubeID: "kube", Type: ClusterDeleted})
+
+	require.Len(t, exporter.events, 2)
+	require.Equal(t, NodeRemoved, exporter.events[0].Type)
+	require.NotEmpty(t, exporter.events[1].ID)
+	require.False(t, exporter.events[1].Time.IsZero())
+
+	// exported only events aren't recorded
+	events, err := svc.List(context.Background(), "kube", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	UnregisterExporter(exporter)
+	Export(&Event{KubeID: "kube", Type: ClusterDeleted})
+	require.Len(t, exporter.events, 2)
+}
@@ -0,0 +1,165 @@
+// Package tracing traces API requests, workflow steps and cloud API calls.
+// Spans are exported with the OpenCensus protocol to an agent, e.g. the
+// OpenTelemetry collector, that forwards them to OTLP backends or Jaeger.
+// Registered views of metrics, e.g. the depth of the queue, are exported too.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"contrib.go.opencensus.io/exporter/ocagent"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/plugin/ochttp/propagation/tracecontext"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/trace"
+)
+
+const defaultServiceName = "supergiant-control"
+
+// Config of the trace exporter, tracing is off when Endpoint is empty.
+type Config struct {
+	// Endpoint is an address of the agent, e.g. otel-collector:55678.
+	Endpoint    string
+	ServiceName string
+	// SampleRate is a probability of tracing a request, all requests
+	// are traced when it's 0.
+	SampleRate float64
+}
+
+// Init registers the exporter of spans and views, the returned function flushes
+// and stops it.
+func Init(cfg Config) (func(), error) {
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = defaultServiceName
+	}
+
+	exporter, err := ocagent.NewExporter(
+		ocagent.WithInsecure(),
+		ocagent.WithAddress(cfg.Endpoint),
+		ocagent.WithServiceName(cfg.ServiceName),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "trace exporter %s", cfg.Endpoint)
+	}
+	trace.RegisterExporter(exporter)
+	view.RegisterExporter(exporter)
+
+	sampler := trace.AlwaysSample()
+	if cfg.SampleRate > 0 && cfg.SampleRate < 1 {
+		sampler = trace.ProbabilitySampler(cfg.SampleRate)
+	}
+	trace.ApplyConfig(trace.Config{DefaultSampler: sampler})
+
+	return func() {
+		trace.UnregisterExporter(exporter)
+		view.UnregisterExporter(exporter)
+		exporter.Flush()
+		exporter.Stop()
+	}, nil
+}
+
+// Middleware starts a span of the request named by its route, a parent
+// span is taken from the W3C traceparent header.
+func Middleware(next http.Handler) http.Handler {
+	return &ochttp.Handler{
+		Handler:     next,
+		Propagation: &tracecontext.HTTPFormat{},
+		FormatSpanName: func(r *http.Request) string {
+			if route := mux.CurrentRoute(r); route != nil {
+				if tpl, err := route.GetPathTemplate(); err == nil {
+					return r.Method + " " + tpl
+				}
+			}
+			return r.Method + " " + r.URL.Path
+		},
+	}
+}
+
+// Detach returns a background context with the span of ctx, it's used to
+// trace work that outlives the request, e.g. provisioning.
+func Detach(ctx context.Context) context.Context {
+	span := trace.FromContext(ctx)
+	if span == nil {
+		return context.Background()
+	}
+
+	return trace.NewContext(context.Background(), span)
+}
+
+// StartSpan starts a span, it's a child of a span of ctx if there is one.
+func StartSpan(ctx context.Context, name string, attrs ...trace.Attribute) (context.Context, *trace.Span) {
+	ctx, span := trace.StartSpan(ctx, name)
+	span.AddAttributes(attrs...)
+
+	return ctx, span
+}
+
+// End sets the status of the span by the error and ends it.
+func End(span *trace.Span, err error) {
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()})
+	}
+	span.End()
+}
+
+// Client returns a copy of c that traces requests to the API of the cloud,
+// e.g. of GCE, spans are named by the cloud, the method and the host.
+func Client(cloud string, c *http.Client) *http.Client {
+	traced := &http.Client{}
+	if c != nil {
+		*traced = *c
+	}
+	base := traced.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	traced.Transport = &ochttp.Transport{
+		Base:        base,
+		Propagation: &tracecontext.HTTPFormat{},
+		FormatSpanName: func(r *http.Request) string {
+			return cloud + " " + r.Method + " " + r.URL.Host
+		},
+	}
+
+	return traced
+}
+
+// AWS traces calls of clients with the handlers, e.g. of session.Handlers.
+func AWS(handlers *request.Handlers) {
+	handlers.Validate.PushFrontNamed(request.NamedHandler{
+		Name: "tracing.Start",
+		Fn: func(r *request.Request) {
+			ctx, span := trace.StartSpan(r.Context(),
+				fmt.Sprintf("aws %s.%s", r.ClientInfo.ServiceName, r.Operation.Name),
+				trace.WithSpanKind(trace.SpanKindClient))
+			span.AddAttributes(trace.StringAttribute("aws.region", stringValue(r.Config.Region)))
+			r.SetContext(ctx)
+		},
+	})
+	handlers.Complete.PushBackNamed(request.NamedHandler{
+		Name: "tracing.End",
+		Fn: func(r *request.Request) {
+			span := trace.FromContext(r.Context())
+			if span == nil {
+				return
+			}
+			if r.HTTPResponse != nil {
+				span.AddAttributes(trace.Int64Attribute("http.status_code", int64(r.HTTPResponse.StatusCode)))
+			}
+			span.AddAttributes(trace.Int64Attribute("aws.retries", int64(r.RetryCount)))
+			End(span, r.Error)
+		},
+	})
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
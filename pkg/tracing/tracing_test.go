@@ -0,0 +1,170 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
+)
+
+type spanRecorder struct {
+	m     sync.Mutex
+	spans []*trace.SpanData
+}
+
+func (r *spanRecorder) ExportSpan(s *trace.SpanData) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.spans = append(r.spans, s)
+}
+
+func (r *spanRecorder) find(name string) *trace.SpanData {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for _, s := range r.spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func record() (*spanRecorder, func()) {
+	r := &spanRecorder{}
+	trace.RegisterExporter(r)
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
+
+	return r, func() { trace.UnregisterExporter(r) }
+}
+
+func TestMiddleware(t *testing.T) {
+	rec, stop := record()
+	defer stop()
+
+	var inner *trace.Span
+	router := mux.NewRouter()
+	router.Use(Middleware)
+	api := router.PathPrefix("/v1/api").Subrouter()
+	api.HandleFunc("/kubes/{kubeID}", func(w http.ResponseWriter, r *http.Request) {
+		inner = trace.FromContext(r.Context())
+	}).Methods(http.MethodGet)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/api/kubes/abcd", nil)
+	req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	span := rec.find("GET /v1/api/kubes/{kubeID}")
+	require.NotNil(t, span)
+	require.NotNil(t, inner)
+	require.Equal(t, span.SpanID, inner.SpanContext().SpanID)
+	require.Equal(t, "0af7651916cd43dd8448eb211c80319c", span.TraceID.String())
+}
+
+func TestEnd(t *testing.T) {
+	rec, stop := record()
+	defer stop()
+
+	ctx, parent := StartSpan(context.Background(), "task cluster")
+	_, span := StartSpan(ctx, "step ssh", trace.StringAttribute("node", "master-1"))
+	End(span, errors.New("connection refused"))
+	End(parent, nil)
+
+	step := rec.find("step ssh")
+	require.NotNil(t, step)
+	require.Equal(t, parent.SpanContext().SpanID, step.ParentSpanID)
+	require.Equal(t, "master-1", step.Attributes["node"])
+	require.Equal(t, int32(trace.StatusCodeUnknown), step.Status.Code)
+	require.Equal(t, "connection refused", step.Status.Message)
+
+	task := rec.find("task cluster")
+	require.NotNil(t, task)
+	require.Equal(t, int32(trace.StatusCodeOK), task.Status.Code)
+}
+
+func TestDetach(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx, span := StartSpan(ctx, "request")
+	defer span.End()
+
+	detached := Detach(ctx)
+	cancel()
+
+	require.NoError(t, detached.Err())
+	require.Equal(t, span, trace.FromContext(detached))
+	require.Nil(t, trace.FromContext(Detach(context.Background())))
+}
+
+func TestAWS(t *testing.T) {
+	rec, stop := record()
+	defer stop()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(srv.URL),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		MaxRetries:  aws.Int(0),
+	})
+	require.NoError(t, err)
+	AWS(&sess.Handlers)
+
+	ctx, parent := StartSpan(context.Background(), "step aws_create_instance")
+	_, err = ec2.New(sess).DescribeInstancesWithContext(ctx, &ec2.DescribeInstancesInput{})
+	parent.End()
+	require.Error(t, err)
+
+	span := rec.find("aws ec2.DescribeInstances")
+	require.NotNil(t, span)
+	require.Equal(t, parent.SpanContext().SpanID, span.ParentSpanID)
+	require.Equal(t, trace.SpanKindClient, span.SpanKind)
+	require.Equal(t, "us-east-1", span.Attributes["aws.region"])
+	require.Equal(t, int64(http.StatusForbidden), span.Attributes["http.status_code"])
+	require.NotEqual(t, int32(trace.StatusCodeOK), span.Status.Code)
+}
+
+func TestClient(t *testing.T) {
+	rec, stop := record()
+	defer stop()
+
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	base := &http.Client{Timeout: time.Minute}
+	c := Client("digitalocean", base)
+	require.Equal(t, time.Minute, c.Timeout)
+	require.Nil(t, base.Transport)
+
+	ctx, parent := StartSpan(context.Background(), "step do_create_instance")
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/v2/droplets", nil)
+	require.NoError(t, err)
+	resp, err := c.Do(req.WithContext(ctx))
+	require.NoError(t, err)
+	resp.Body.Close()
+	parent.End()
+
+	span := rec.find("digitalocean GET " + req.URL.Host)
+	require.NotNil(t, span)
+	require.Equal(t, parent.SpanContext().SpanID, span.ParentSpanID)
+	require.Equal(t, trace.SpanKindClient, span.SpanKind)
+	require.Equal(t, int64(http.StatusNotFound), span.Attributes["http.status_code"])
+	require.Contains(t, traceparent, parent.SpanContext().TraceID.String())
+}
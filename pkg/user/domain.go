@@ -6,11 +6,29 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	RoleAdmin = "admin"
+	RoleEdit  = "edit"
+	RoleView  = "view"
+)
+
 // User is the representation of supergiant user
 type User struct {
 	Login             string `json:"login" valid:"required, length(1|32)"`
 	EncryptedPassword []byte `json:"encrypted_password" valid:"-"`
 	Password          string `json:"password" valid:"required, length(8|24), printableascii"`
+	// Role limits access of the user to clusters, users without a role are admins.
+	Role string `json:"role" valid:"optional, in(admin|edit|view)"`
+	// PasswordChangedAt is the unix time of the last change of the password.
+	PasswordChangedAt int64 `json:"passwordChangedAt,omitempty" valid:"-"`
+}
+
+// GetRole returns the user role taking users created before roles into account.
+func (u *User) GetRole() string {
+	if u.Role == "" {
+		return RoleAdmin
+	}
+	return u.Role
 }
 
 func (u *User) encryptPassword() error {
@@ -1,37 +1,74 @@
 package user
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"gopkg.in/asaskevich/govalidator.v8"
 
 	"github.com/supergiant/control/pkg/message"
 	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
 )
 
 type TokenIssuer interface {
-	Issue(string) (string, error)
+	Issue(userID, role string) (string, error)
+}
+
+// Provider authenticates users of an external directory, e.g. LDAP, and
+// returns their roles.
+type Provider interface {
+	Authenticate(ctx context.Context, login, password string) (string, error)
 }
 
 type Handler struct {
 	userService  *Service
 	tokenService TokenIssuer
+	providers    []Provider
+	twoFactor    *TwoFactorService
+	sessions     *SessionService
+	lockout      *Lockout
 }
 
 type AuthRequest struct {
 	Login    string `json:"login"`
 	Password string `json:"password"`
+	// Code of the authenticator or a recovery code when the second factor
+	// is enabled.
+	Code string `json:"code,omitempty"`
+}
+
+// PasswordRequest changes the password of a user, users change expired
+// passwords with it before logins.
+type PasswordRequest struct {
+	AuthRequest
+	NewPassword string `json:"newPassword"`
 }
 
 func NewHandler(userService *Service, tokenService TokenIssuer) *Handler {
 	return &Handler{
 		userService:  userService,
 		tokenService: tokenService,
+		lockout:      NewLockout(),
 	}
 }
 
+// SetLockoutPolicy locks logins after failed attempts, logins aren't locked
+// by default.
+func (h *Handler) SetLockoutPolicy(p LockoutPolicy) {
+	h.lockout.SetPolicy(p)
+}
+
+// AddProvider authenticates users that aren't local with the provider,
+// providers are asked in the order they are added.
+func (h *Handler) AddProvider(p Provider) {
+	h.providers = append(h.providers, p)
+}
+
 func enableCors(w http.ResponseWriter) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 }
@@ -48,15 +85,13 @@ func (h *Handler) Authenticate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.userService.Authenticate(r.Context(), ar.Login, ar.Password); err != nil {
-		if sgerrors.IsInvalidCredentials(err) {
-			http.Error(w, sgerrors.ErrInvalidCredentials.Error(), http.StatusForbidden)
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	role, ok := h.login(w, r, ar, false)
+	if !ok || !h.verifySecondFactor(w, r, ar, role) {
 		return
 	}
+	h.lockout.Succeed(ar.Login)
 
-	if token, err := h.tokenService.Issue(ar.Login); err == nil {
+	if token, err := h.issue(r, ar.Login, role); err == nil {
 		w.Header().Set("Authorization", token)
 		w.Header().Set("Access-Control-Expose-Headers", "Authorization")
 		return
@@ -66,6 +101,96 @@ func (h *Handler) Authenticate(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ChangePassword replaces the password of a local user with the current
+// password and the second factor, sessions of the user are revoked.
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	enableCors(w)
+
+	var req PasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	role, ok := h.login(w, r, req.AuthRequest, true)
+	if !ok || !h.verifySecondFactor(w, r, req.AuthRequest, role) {
+		return
+	}
+	h.lockout.Succeed(req.Login)
+
+	if err := h.userService.ChangePassword(r.Context(), req.Login, req.Password, req.NewPassword); err != nil {
+		switch {
+		case errors.Cause(err) == ErrWeakPassword:
+			message.SendValidationFailed(w, err)
+		case sgerrors.IsNotFound(err):
+			message.SendValidationFailed(w, errors.New("passwords of users of providers are changed in their directories"))
+		default:
+			message.SendUnknownError(w, err)
+		}
+		return
+	}
+
+	if h.sessions != nil {
+		if _, err := h.sessions.RevokeAll(r.Context(), req.Login); err != nil {
+			message.SendUnknownError(w, err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// login authenticates the user of the request and responds to failed
+// attempts, expired passwords are allowed to change them.
+func (h *Handler) login(w http.ResponseWriter, r *http.Request, ar AuthRequest, allowExpired bool) (string, bool) {
+	if d := h.lockout.Locked(ar.Login); d > 0 {
+		message.SendAccountLocked(w, d, sgerrors.ErrAccountLocked)
+		return "", false
+	}
+
+	role, err := h.authenticate(r.Context(), ar.Login, ar.Password)
+	switch {
+	case err == nil, allowExpired && sgerrors.IsPasswordExpired(err):
+		return role, true
+	case sgerrors.IsInvalidCredentials(err):
+		h.fail(w, ar.Login)
+	case sgerrors.IsPasswordExpired(err):
+		message.SendPasswordExpired(w, err)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+	return "", false
+}
+
+// verifySecondFactor checks the code of the user when second factors are set.
+func (h *Handler) verifySecondFactor(w http.ResponseWriter, r *http.Request, ar AuthRequest, role string) bool {
+	if h.twoFactor == nil {
+		return true
+	}
+
+	err := h.twoFactor.Verify(r.Context(), ar.Login, role, ar.Code)
+	switch {
+	case err == nil:
+		return true
+	case sgerrors.IsTwoFactor(err):
+		message.SendTwoFactorRequired(w, err)
+	case sgerrors.IsInvalidCredentials(err):
+		h.fail(w, ar.Login)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+	return false
+}
+
+// fail counts the failed attempt of the login and rejects it.
+func (h *Handler) fail(w http.ResponseWriter, login string) {
+	if d := h.lockout.Fail(login); d > 0 {
+		message.SendAccountLocked(w, d, sgerrors.ErrAccountLocked)
+		return
+	}
+	http.Error(w, sgerrors.ErrInvalidCredentials.Error(), http.StatusForbidden)
+}
+
 func (h *Handler) RegisterRootUser(w http.ResponseWriter, r *http.Request) {
 	var user User
 	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
@@ -87,6 +212,10 @@ func (h *Handler) RegisterRootUser(w http.ResponseWriter, r *http.Request) {
 
 	if coldstart {
 		if err := h.userService.Create(r.Context(), &user); err != nil {
+			if errors.Cause(err) == ErrWeakPassword {
+				message.SendValidationFailed(w, err)
+				return
+			}
 			message.SendUnknownError(w, err)
 			return
 		}
@@ -131,8 +260,16 @@ func (h *Handler) Create(rw http.ResponseWriter, r *http.Request) {
 		http.Error(rw, err.Error(), http.StatusBadRequest)
 		return
 	}
+	// users without a role are admins, new users get the least access
+	if user.Role == "" {
+		user.Role = RoleView
+	}
 
 	if err := h.userService.Create(r.Context(), &user); err != nil {
+		if errors.Cause(err) == ErrWeakPassword {
+			message.SendValidationFailed(rw, err)
+			return
+		}
 		if sgerrors.IsAlreadyExists(err) {
 			msg := message.New(fmt.Sprintf("login %s is already occupied", user.Login), "", sgerrors.EntityAlreadyExists, "")
 			message.SendMessage(rw, msg, http.StatusBadRequest)
@@ -142,3 +279,60 @@ func (h *Handler) Create(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// List returns users without passwords.
+func (h *Handler) List(rw http.ResponseWriter, r *http.Request) {
+	opts, err := storage.ParseListOptions(r.URL.Query())
+	if err != nil {
+		message.SendValidationFailed(rw, err)
+		return
+	}
+
+	users, next, err := h.userService.List(r.Context(), opts)
+	if err != nil {
+		if err == ErrPasswordField || err == storage.ErrInvalidContinue {
+			message.SendValidationFailed(rw, err)
+			return
+		}
+		message.SendUnknownError(rw, err)
+		return
+	}
+
+	if next != "" {
+		rw.Header().Set(storage.ContinueHeader, next)
+	}
+	if err = json.NewEncoder(rw).Encode(users); err != nil {
+		message.SendUnknownError(rw, err)
+	}
+}
+
+// authenticate returns the role of a local user or of a user of providers,
+// local users take precedence. The role of a local user is returned with
+// sgerrors.ErrPasswordExpired too.
+func (h *Handler) authenticate(ctx context.Context, login, password string) (string, error) {
+	err := h.userService.Authenticate(ctx, login, password)
+	if err == nil || sgerrors.IsPasswordExpired(err) {
+		user, gerr := h.userService.Get(ctx, login)
+		if gerr != nil {
+			return "", gerr
+		}
+		return user.GetRole(), err
+	}
+	if !sgerrors.IsNotFound(err) || len(h.providers) == 0 {
+		return "", err
+	}
+
+	err = sgerrors.ErrInvalidCredentials
+	for _, p := range h.providers {
+		role, perr := p.Authenticate(ctx, login, password)
+		if perr == nil {
+			return role, nil
+		}
+		if !sgerrors.IsInvalidCredentials(perr) {
+			logrus.Errorf("authenticate %s: %v", login, perr)
+			err = perr
+		}
+	}
+
+	return "", err
+}
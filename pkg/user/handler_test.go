@@ -2,9 +2,11 @@ package user
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -13,6 +15,7 @@ import (
 
 	"github.com/supergiant/control/pkg/jwt"
 	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage/memory"
 	"github.com/supergiant/control/pkg/testutils"
 )
 
@@ -20,8 +23,8 @@ type mockTokenIssuer struct {
 	mock.Mock
 }
 
-func (m *mockTokenIssuer) Issue(userId string) (string, error) {
-	args := m.Called(userId)
+func (m *mockTokenIssuer) Issue(userId, role string) (string, error) {
+	args := m.Called(userId, role)
 	val, ok := args.Get(0).(string)
 	if !ok {
 		return "", args.Error(1)
@@ -78,7 +81,7 @@ func TestEndpoint_Authenticate(t *testing.T) {
 		storage := new(testutils.MockStorage)
 
 		ts := &mockTokenIssuer{}
-		ts.On("Issue", mock.Anything).
+		ts.On("Issue", mock.Anything, mock.Anything).
 			Return("test", testCase.tokenIssueError)
 		userEndpoint := NewHandler(NewService(DefaultStoragePrefix, storage), ts)
 		handler := http.HandlerFunc(userEndpoint.Authenticate)
@@ -159,3 +162,111 @@ func TestEndpoint_Create(t *testing.T) {
 		require.Equal(t, testCase.expectedCode, rec.Code)
 	}
 }
+
+func TestEndpoint_CreateDefaultRole(t *testing.T) {
+	svc := NewService(DefaultStoragePrefix, memory.NewInMemoryRepository())
+	h := NewHandler(svc, jwt.NewTokenService(64, []byte("secret")))
+
+	for login, body := range map[string]string{
+		"viewer": `{"login":"viewer","password":"1234567890"}`,
+		"editor": `{"login":"editor","password":"1234567890","role":"edit"}`,
+	} {
+		rec := httptest.NewRecorder()
+		h.Create(rec, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body)))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		u, err := svc.Get(context.Background(), login)
+		require.NoError(t, err)
+		require.NotEqual(t, RoleAdmin, u.GetRole(), login)
+	}
+}
+
+func TestEndpoint_List(t *testing.T) {
+	tt := []struct {
+		query         string
+		expectedCode  int
+		expectedUsers []string
+	}{
+		{
+			expectedCode:  http.StatusOK,
+			expectedUsers: []string{"admin", "viewer"},
+		},
+		{
+			query:         "?fieldSelector=role=view",
+			expectedCode:  http.StatusOK,
+			expectedUsers: []string{"viewer"},
+		},
+		{
+			query:        "?fieldSelector=encrypted_password=secret",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			query:        "?sort=-password",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			query:        "?limit=-1",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	storage := new(testutils.MockStorage)
+	storage.On("GetAll", mock.Anything, DefaultStoragePrefix).Return([][]byte{
+		(&User{Login: "admin", EncryptedPassword: []byte("secret")}).ToJSON(),
+		(&User{Login: "viewer", EncryptedPassword: []byte("secret"), Role: RoleView}).ToJSON(),
+	}, nil)
+	handler := http.HandlerFunc(NewHandler(NewService(DefaultStoragePrefix, storage), nil).List)
+
+	for _, testCase := range tt {
+		req, err := http.NewRequest(http.MethodGet, "/users"+testCase.query, nil)
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, testCase.expectedCode, rec.Code, testCase.query)
+
+		if rec.Code == http.StatusOK {
+			users := make([]*User, 0)
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&users))
+
+			logins := make([]string, 0, len(users))
+			for _, u := range users {
+				require.Empty(t, u.EncryptedPassword)
+				logins = append(logins, u.Login)
+			}
+			require.Equal(t, testCase.expectedUsers, logins, testCase.query)
+		}
+	}
+}
+
+type fakeProvider struct{}
+
+func (fakeProvider) Authenticate(ctx context.Context, login, password string) (string, error) {
+	if login == "ldap" && password == "secret" {
+		return RoleView, nil
+	}
+	return "", sgerrors.ErrInvalidCredentials
+}
+
+func TestEndpoint_AuthenticateProvider(t *testing.T) {
+	storage := new(testutils.MockStorage)
+	storage.On("Get", mock.Anything, mock.Anything, mock.Anything).Return(nil, sgerrors.ErrNotFound)
+	ts := &mockTokenIssuer{}
+	ts.On("Issue", "ldap", RoleView).Return("test", nil)
+
+	h := NewHandler(NewService(DefaultStoragePrefix, storage), ts)
+	h.AddProvider(fakeProvider{})
+
+	for _, tc := range []struct {
+		body         string
+		expectedCode int
+	}{
+		{`{"login":"ldap","password":"secret"}`, http.StatusOK},
+		{`{"login":"ldap","password":"wrong"}`, http.StatusForbidden},
+	} {
+		rec := httptest.NewRecorder()
+		h.Authenticate(rec, httptest.NewRequest(http.MethodPost, "/auth", strings.NewReader(tc.body)))
+		require.Equal(t, tc.expectedCode, rec.Code, tc.body)
+	}
+	ts.AssertNumberOfCalls(t, "Issue", 1)
+}
@@ -0,0 +1,263 @@
+package user
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// maxTracked logins of failed attempts, logins whose locks are over are
+// forgotten beyond it.
+const maxTracked = 10000
+
+// ErrWeakPassword is returned for passwords that don't comply with the policy.
+var ErrWeakPassword = errors.New("password doesn't comply with the policy")
+
+var (
+	DefaultPasswordPolicy = PasswordPolicy{
+		MinLength: 8,
+	}
+	DefaultLockoutPolicy = LockoutPolicy{
+		MaxAttempts: 5,
+		Duration:    "1m",
+		MaxDuration: "1h",
+	}
+)
+
+// PasswordPolicy of local users, durations are strings like "2160h".
+type PasswordPolicy struct {
+	// MinLength of passwords, they are 8 to 24 characters long anyway.
+	MinLength        int  `json:"minLength"`
+	RequireUppercase bool `json:"requireUppercase"`
+	RequireLowercase bool `json:"requireLowercase"`
+	RequireDigit     bool `json:"requireDigit"`
+	RequireSymbol    bool `json:"requireSymbol"`
+	// MaxAge of passwords, users change expired passwords before logins.
+	// Passwords don't expire when it's empty.
+	MaxAge string `json:"maxAge"`
+}
+
+// Validate checks the policy is applicable.
+func (p PasswordPolicy) Validate() error {
+	if p.MinLength < 0 || p.MinLength > 24 {
+		return errors.New("minLength must be between 0 and 24")
+	}
+	if d, err := parseDuration(p.MaxAge); err != nil || d < 0 {
+		return errors.Errorf("invalid maxAge %s", p.MaxAge)
+	}
+	return nil
+}
+
+// Check returns ErrWeakPassword with the reason when the password doesn't
+// comply with the policy.
+func (p PasswordPolicy) Check(password string) error {
+	if len(password) < p.MinLength {
+		return errors.Wrapf(ErrWeakPassword, "password must be at least %d characters long", p.MinLength)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, c := range password {
+		switch {
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsDigit(c):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	var missing []string
+	if p.RequireUppercase && !upper {
+		missing = append(missing, "an uppercase letter")
+	}
+	if p.RequireLowercase && !lower {
+		missing = append(missing, "a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		missing = append(missing, "a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		missing = append(missing, "a symbol")
+	}
+	if len(missing) > 0 {
+		return errors.Wrapf(ErrWeakPassword, "password must contain %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// Expired reports whether the password changed at the time has expired,
+// passwords of users created before the policy don't have the time and
+// don't expire.
+func (p PasswordPolicy) Expired(changedAt int64, now time.Time) bool {
+	maxAge, _ := parseDuration(p.MaxAge)
+	if maxAge == 0 || changedAt == 0 {
+		return false
+	}
+	return now.Sub(time.Unix(changedAt, 0)) > maxAge
+}
+
+// LockoutPolicy of logins, logins are locked for Duration after MaxAttempts
+// failed attempts in a row. The lock doubles up to MaxDuration with each
+// failed attempt after it until a login succeeds.
+type LockoutPolicy struct {
+	// MaxAttempts before the lock, logins aren't locked when it's 0.
+	MaxAttempts int    `json:"maxAttempts"`
+	Duration    string `json:"duration"`
+	MaxDuration string `json:"maxDuration"`
+}
+
+// Validate checks the policy is applicable.
+func (p LockoutPolicy) Validate() error {
+	if p.MaxAttempts < 0 {
+		return errors.New("maxAttempts must not be negative")
+	}
+	d, err := parseDuration(p.Duration)
+	if err != nil {
+		return errors.Wrap(err, "duration")
+	}
+	max, err := parseDuration(p.MaxDuration)
+	if err != nil {
+		return errors.Wrap(err, "maxDuration")
+	}
+	if p.MaxAttempts > 0 && d <= 0 {
+		return errors.New("duration must be positive")
+	}
+	if max != 0 && max < d {
+		return errors.New("maxDuration must not be less than duration")
+	}
+	return nil
+}
+
+type attempts struct {
+	failures int
+	// locks in a row, each one is twice as long as the previous one
+	locks int
+	until time.Time
+	last  time.Time
+}
+
+// Lockout counts failed logins and locks logins under attack.
+type Lockout struct {
+	mu          sync.Mutex
+	maxAttempts int
+	duration    time.Duration
+	maxDuration time.Duration
+	attempts    map[string]*attempts
+
+	now func() time.Time
+}
+
+// NewLockout returns a lockout that doesn't lock logins until the policy is set.
+func NewLockout() *Lockout {
+	return &Lockout{
+		attempts: make(map[string]*attempts),
+		now:      time.Now,
+	}
+}
+
+// SetPolicy applies the policy to further attempts.
+func (l *Lockout) SetPolicy(p LockoutPolicy) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.maxAttempts = p.MaxAttempts
+	l.duration, _ = parseDuration(p.Duration)
+	l.maxDuration, _ = parseDuration(p.MaxDuration)
+	if l.maxDuration < l.duration {
+		l.maxDuration = l.duration
+	}
+}
+
+// Locked returns how long the login is locked for, it's 0 when the login
+// isn't locked.
+func (l *Lockout) Locked(login string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	a, ok := l.attempts[login]
+	if !ok || l.maxAttempts == 0 {
+		return 0
+	}
+	if d := a.until.Sub(l.now()); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// Fail counts a failed attempt of the login and returns how long the login
+// is locked for after it.
+func (l *Lockout) Fail(login string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.maxAttempts == 0 {
+		return 0
+	}
+
+	now := l.now()
+	a, ok := l.attempts[login]
+	if !ok || l.forgotten(a, now) {
+		if len(l.attempts) >= maxTracked {
+			l.prune(now)
+		}
+		a = &attempts{}
+		l.attempts[login] = a
+	}
+	a.last = now
+
+	if a.failures++; a.failures < l.maxAttempts {
+		return 0
+	}
+
+	d := l.duration << uint(a.locks)
+	if d > l.maxDuration || d <= 0 {
+		d = l.maxDuration
+	} else {
+		a.locks++
+	}
+	a.until = now.Add(d)
+	// the next failed attempt after the lock locks the login again
+	a.failures = l.maxAttempts - 1
+
+	return d
+}
+
+// Succeed forgets failed attempts of the login.
+func (l *Lockout) Succeed(login string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.attempts, login)
+}
+
+// forgotten reports whether attempts are old enough to start over, e.g.
+// typos of the password a week ago don't count.
+func (l *Lockout) forgotten(a *attempts, now time.Time) bool {
+	last := a.last
+	if a.until.After(last) {
+		last = a.until
+	}
+	return now.Sub(last) > l.maxDuration
+}
+
+func (l *Lockout) prune(now time.Time) {
+	for login, a := range l.attempts {
+		if l.forgotten(a, now) {
+			delete(l.attempts, login)
+		}
+	}
+}
+
+func parseDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(value)
+}
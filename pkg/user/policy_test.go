@@ -0,0 +1,105 @@
+package user
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage/memory"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	p := PasswordPolicy{
+		MinLength:        10,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+	}
+
+	for password, valid := range map[string]bool{
+		"Passw0rd!":    false,
+		"password12!":  false,
+		"PASSWORD12!":  false,
+		"Password!!!":  false,
+		"Password1234": false,
+		"Password123!": true,
+	} {
+		err := p.Check(password)
+		require.Equal(t, valid, err == nil, password)
+		if err != nil {
+			require.Equal(t, ErrWeakPassword, errors.Cause(err), password)
+		}
+	}
+
+	p.MaxAge = "24h"
+	now := time.Now()
+	require.False(t, p.Expired(now.Add(-time.Hour).Unix(), now))
+	require.True(t, p.Expired(now.Add(-25*time.Hour).Unix(), now))
+	// users created before the policy
+	require.False(t, p.Expired(0, now))
+}
+
+func TestService_ChangePassword(t *testing.T) {
+	ctx := context.Background()
+	s := NewService(DefaultStoragePrefix, memory.NewInMemoryRepository())
+	s.SetPasswordPolicy(PasswordPolicy{MinLength: 8, RequireDigit: true, MaxAge: "24h"})
+
+	require.Equal(t, ErrWeakPassword, errors.Cause(s.Create(ctx, &User{Login: "bob", Password: "password"})))
+	require.NoError(t, s.Create(ctx, &User{Login: "bob", Password: "password1"}))
+	require.NoError(t, s.Authenticate(ctx, "bob", "password1"))
+
+	u, err := s.Get(ctx, "bob")
+	require.NoError(t, err)
+	u.PasswordChangedAt = time.Now().Add(-48 * time.Hour).Unix()
+	require.NoError(t, s.repository.Put(ctx, DefaultStoragePrefix, "bob", u.ToJSON()))
+	require.Equal(t, sgerrors.ErrPasswordExpired, s.Authenticate(ctx, "bob", "password1"))
+
+	require.Equal(t, sgerrors.ErrInvalidCredentials, s.ChangePassword(ctx, "bob", "wrong", "password2"))
+	require.Equal(t, ErrWeakPassword, errors.Cause(s.ChangePassword(ctx, "bob", "password1", "password1")))
+	require.Equal(t, ErrWeakPassword, errors.Cause(s.ChangePassword(ctx, "bob", "password1", "password")))
+	require.NoError(t, s.ChangePassword(ctx, "bob", "password1", "password2"))
+	require.NoError(t, s.Authenticate(ctx, "bob", "password2"))
+	require.Equal(t, sgerrors.ErrInvalidCredentials, s.Authenticate(ctx, "bob", "password1"))
+}
+
+func TestLockout(t *testing.T) {
+	now := time.Now()
+	l := NewLockout()
+	l.now = func() time.Time { return now }
+
+	// logins aren't locked without the policy
+	for i := 0; i < 10; i++ {
+		require.Zero(t, l.Fail("bob"))
+	}
+
+	l.SetPolicy(LockoutPolicy{MaxAttempts: 3, Duration: "1m", MaxDuration: "3m"})
+	l.Succeed("bob")
+	require.Zero(t, l.Fail("bob"))
+	require.Zero(t, l.Fail("bob"))
+	require.Equal(t, time.Minute, l.Fail("bob"))
+	require.Equal(t, time.Minute, l.Locked("bob"))
+	require.Zero(t, l.Locked("alice"))
+
+	// locks double with each failed attempt after them up to the max
+	now = now.Add(time.Minute)
+	require.Zero(t, l.Locked("bob"))
+	require.Equal(t, 2*time.Minute, l.Fail("bob"))
+	now = now.Add(2 * time.Minute)
+	require.Equal(t, 3*time.Minute, l.Fail("bob"))
+	now = now.Add(3 * time.Minute)
+	require.Equal(t, 3*time.Minute, l.Fail("bob"))
+
+	// successful logins and old attempts start over
+	now = now.Add(3 * time.Minute)
+	l.Succeed("bob")
+	require.Zero(t, l.Fail("bob"))
+	now = now.Add(time.Hour)
+	require.Zero(t, l.Fail("bob"))
+	require.Zero(t, l.Fail("bob"))
+	require.Equal(t, time.Minute, l.Fail("bob"))
+}
@@ -2,7 +2,11 @@ package user
 
 import (
 	"context"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/pkg/errors"
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/supergiant/control/pkg/sgerrors"
@@ -11,10 +15,16 @@ import (
 
 const DefaultStoragePrefix = "/supergiant/user/"
 
+// ErrPasswordField is returned when users are listed by passwords.
+var ErrPasswordField = errors.New("users can't be listed by passwords")
+
 // Service contains business logic related to users
 type Service struct {
 	storagePrefix string
 	repository    storage.Interface
+
+	mu     sync.RWMutex
+	policy PasswordPolicy
 }
 
 // NewService is a constructor function for user.Service
@@ -22,18 +32,39 @@ func NewService(storagePrefix string, repository storage.Interface) *Service {
 	return &Service{
 		storagePrefix: storagePrefix,
 		repository:    repository,
+		policy:        DefaultPasswordPolicy,
 	}
 }
 
+// SetPasswordPolicy applies the policy to passwords that are set and to
+// logins after it.
+func (s *Service) SetPasswordPolicy(p PasswordPolicy) {
+	s.mu.Lock()
+	s.policy = p
+	s.mu.Unlock()
+}
+
+// PasswordPolicy returns the current password policy.
+func (s *Service) PasswordPolicy() PasswordPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.policy
+}
+
 // Create is used to register new user
 func (s *Service) Create(ctx context.Context, user *User) error {
 	if user == nil {
 		return sgerrors.ErrNilValue
 	}
+	if err := s.PasswordPolicy().Check(user.Password); err != nil {
+		return err
+	}
 	err := user.encryptPassword()
 	if err != nil {
 		return err
 	}
+	user.PasswordChangedAt = time.Now().Unix()
 
 	if _, err := s.repository.Get(ctx, s.storagePrefix, user.Login); err != nil {
 		if !sgerrors.IsNotFound(err) {
@@ -44,29 +75,76 @@ func (s *Service) Create(ctx context.Context, user *User) error {
 	return err
 }
 
-// Authenticate checks if password stored in db is the same as in request
+// Authenticate checks if password stored in db is the same as in request,
+// sgerrors.ErrPasswordExpired is returned for right passwords that have
+// expired.
 func (s *Service) Authenticate(ctx context.Context, username, password string) error {
+	user, err := s.authenticate(ctx, username, password)
+	if err != nil {
+		return err
+	}
+
+	if s.PasswordPolicy().Expired(user.PasswordChangedAt, time.Now()) {
+		return sgerrors.ErrPasswordExpired
+	}
+	return nil
+}
+
+// ChangePassword replaces the password of the user, expired passwords are
+// changed too.
+func (s *Service) ChangePassword(ctx context.Context, login, password, newPassword string) error {
+	user, err := s.authenticate(ctx, login, password)
+	if err != nil {
+		return err
+	}
+	if newPassword == password {
+		return errors.Wrap(ErrWeakPassword, "new password must differ from the current one")
+	}
+	if err = s.PasswordPolicy().Check(newPassword); err != nil {
+		return err
+	}
+
+	user.Password = newPassword
+	if err = user.encryptPassword(); err != nil {
+		return err
+	}
+	user.PasswordChangedAt = time.Now().Unix()
+
+	return s.repository.Put(ctx, s.storagePrefix, user.Login, user.ToJSON())
+}
+
+func (s *Service) authenticate(ctx context.Context, username, password string) (*User, error) {
 	if username == "" || password == "" {
-		return sgerrors.ErrInvalidCredentials
+		return nil, sgerrors.ErrInvalidCredentials
 	}
 
 	rawJSON, err := s.repository.Get(ctx, s.storagePrefix, username)
 	if err != nil {
 		//If user doesn't exists we still want Forbidden instead of Not Found
 		if sgerrors.IsNotFound(err) {
-			return sgerrors.ErrNotFound
+			return nil, sgerrors.ErrNotFound
 		}
-		return err
+		return nil, err
 	}
 	user, err := FromJSON(rawJSON)
 	if err != nil {
-		return sgerrors.ErrInvalidJson
+		return nil, sgerrors.ErrInvalidJson
 	}
 
 	if err := bcrypt.CompareHashAndPassword(user.EncryptedPassword, []byte(password)); err != nil {
-		return sgerrors.ErrInvalidCredentials
+		return nil, sgerrors.ErrInvalidCredentials
 	}
-	return nil
+	return user, nil
+}
+
+// Get returns a user by login
+func (s *Service) Get(ctx context.Context, login string) (*User, error) {
+	rawJSON, err := s.repository.Get(ctx, s.storagePrefix, login)
+	if err != nil {
+		return nil, err
+	}
+
+	return FromJSON(rawJSON)
 }
 
 func (s *Service) GetAll(ctx context.Context) ([]*User, error) {
@@ -86,6 +164,36 @@ func (s *Service) GetAll(ctx context.Context) ([]*User, error) {
 	return usrs, nil
 }
 
+// List returns a page of users without passwords and the continue token of
+// the next one, users can't be selected or sorted by passwords.
+func (s *Service) List(ctx context.Context, opts storage.ListOptions) ([]*User, string, error) {
+	fields := append([]string(nil), opts.Sort...)
+	for field := range opts.Fields {
+		fields = append(fields, field)
+	}
+	for _, field := range fields {
+		if field = strings.TrimPrefix(field, "-"); field == "password" || field == "encrypted_password" {
+			return nil, "", ErrPasswordField
+		}
+	}
+
+	res, next, err := storage.List(ctx, s.repository, s.storagePrefix, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	usrs := make([]*User, 0, len(res))
+	for _, v := range res {
+		u, err := FromJSON(v)
+		if err != nil {
+			return nil, "", err
+		}
+		u.Password, u.EncryptedPassword = "", nil
+		usrs = append(usrs, u)
+	}
+	return usrs, next, nil
+}
+
 //IsColdStart tells if any users are registered.
 func (s *Service) IsColdStart(ctx context.Context) (bool, error) {
 	users, err := s.GetAll(ctx)
@@ -166,8 +166,8 @@ func TestService_Authenticate(t *testing.T) {
 		}
 
 		svc := Service{
-			"prefix",
-			mockRepo,
+			storagePrefix: "prefix",
+			repository:    mockRepo,
 		}
 
 		err := svc.Authenticate(context.Background(),
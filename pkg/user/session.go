@@ -0,0 +1,257 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
+)
+
+const DefaultSessionStoragePrefix = "/supergiant/session/"
+
+// Session is a login of a user, tokens of the session are rejected once
+// it's revoked.
+type Session struct {
+	ID         string `json:"id"`
+	Login      string `json:"login"`
+	Role       string `json:"role"`
+	CreatedAt  int64  `json:"createdAt"`
+	ExpiresAt  int64  `json:"expiresAt"`
+	RemoteAddr string `json:"remoteAddr"`
+	UserAgent  string `json:"userAgent"`
+}
+
+// SessionIssuer issues tokens of sessions.
+type SessionIssuer interface {
+	IssueSession(userID, role, sessionID string) (string, error)
+}
+
+// SessionService keeps active sessions of users.
+type SessionService struct {
+	storagePrefix string
+	repository    storage.Interface
+	ttl           time.Duration
+}
+
+// NewSessionService is a constructor function for user.SessionService, ttl
+// is the lifetime of tokens of sessions.
+func NewSessionService(storagePrefix string, repository storage.Interface, ttl time.Duration) *SessionService {
+	return &SessionService{
+		storagePrefix: storagePrefix,
+		repository:    repository,
+		ttl:           ttl,
+	}
+}
+
+// Create starts a session of the user.
+func (s *SessionService) Create(ctx context.Context, login, role, remoteAddr, userAgent string) (*Session, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &Session{
+		ID:         hex.EncodeToString(b),
+		Login:      login,
+		Role:       role,
+		CreatedAt:  now.Unix(),
+		ExpiresAt:  now.Add(s.ttl).Unix(),
+		RemoteAddr: remoteAddr,
+		UserAgent:  userAgent,
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.repository.Put(ctx, s.storagePrefix, session.ID, data); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Check returns sgerrors.ErrSessionRevoked when the session isn't active.
+func (s *SessionService) Check(ctx context.Context, id string) error {
+	session, err := s.get(ctx, id)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			return sgerrors.ErrSessionRevoked
+		}
+		return err
+	}
+	if session.ExpiresAt < time.Now().Unix() {
+		return sgerrors.ErrSessionRevoked
+	}
+	return nil
+}
+
+// List returns a page of active sessions, e.g. sessions of a user with
+// fieldSelector=login=alice. Expired sessions are removed.
+func (s *SessionService) List(ctx context.Context, opts storage.ListOptions) ([]*Session, string, error) {
+	res, next, err := storage.List(ctx, s.repository, s.storagePrefix, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now().Unix()
+	sessions := make([]*Session, 0, len(res))
+	for _, v := range res {
+		session := &Session{}
+		if err = json.Unmarshal(v, session); err != nil {
+			return nil, "", sgerrors.ErrInvalidJson
+		}
+		if session.ExpiresAt < now {
+			if err = s.repository.Delete(ctx, s.storagePrefix, session.ID); err != nil {
+				return nil, "", errors.Wrapf(err, "delete expired session %s", session.ID)
+			}
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, next, nil
+}
+
+// Revoke ends the session of the user.
+func (s *SessionService) Revoke(ctx context.Context, login, id string) error {
+	session, err := s.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if session.Login != login {
+		return sgerrors.ErrNotFound
+	}
+	return s.repository.Delete(ctx, s.storagePrefix, id)
+}
+
+// RevokeAll ends sessions of the user and returns how many of them were active.
+func (s *SessionService) RevokeAll(ctx context.Context, login string) (int, error) {
+	sessions, _, err := s.List(ctx, storage.ListOptions{
+		Fields: map[string]string{"login": login},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, session := range sessions {
+		if err = s.repository.Delete(ctx, s.storagePrefix, session.ID); err != nil {
+			return 0, errors.Wrapf(err, "revoke session %s", session.ID)
+		}
+	}
+	return len(sessions), nil
+}
+
+func (s *SessionService) get(ctx context.Context, id string) (*Session, error) {
+	data, err := s.repository.Get(ctx, s.storagePrefix, id)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, sgerrors.ErrNotFound
+	}
+
+	session := &Session{}
+	if err = json.Unmarshal(data, session); err != nil {
+		return nil, sgerrors.ErrInvalidJson
+	}
+	return session, nil
+}
+
+// SetSessions issues tokens of sessions that users and admins revoke, the
+// token issuer of the handler must be a SessionIssuer.
+func (h *Handler) SetSessions(s *SessionService) {
+	h.sessions = s
+}
+
+// RegisterSessions adds routes of sessions, self allows users to manage
+// their own sessions, admin allows admins to list sessions of all users.
+func (h *Handler) RegisterSessions(protected *mux.Router, self, admin func(http.HandlerFunc) http.HandlerFunc) {
+	protected.HandleFunc("/sessions", admin(h.ListSessions)).Methods(http.MethodGet)
+	protected.HandleFunc("/users/{login}/sessions", self(h.ListSessions)).Methods(http.MethodGet)
+	protected.HandleFunc("/users/{login}/sessions", self(h.RevokeSessions)).Methods(http.MethodDelete)
+	protected.HandleFunc("/users/{login}/sessions/{id}", self(h.RevokeSession)).Methods(http.MethodDelete)
+}
+
+// ListSessions returns active sessions of the user of the route or of all
+// users.
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	opts, err := storage.ParseListOptions(r.URL.Query())
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+	if login, ok := mux.Vars(r)["login"]; ok {
+		if opts.Fields == nil {
+			opts.Fields = make(map[string]string)
+		}
+		opts.Fields["login"] = login
+	}
+
+	sessions, next, err := h.sessions.List(r.Context(), opts)
+	if err != nil {
+		if err == storage.ErrInvalidContinue {
+			message.SendValidationFailed(w, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if next != "" {
+		w.Header().Set(storage.ContinueHeader, next)
+	}
+	if err = json.NewEncoder(w).Encode(sessions); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.sessions.Revoke(r.Context(), vars["login"], vars["id"]); err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, "session", err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeSessions logs the user out everywhere.
+func (h *Handler) RevokeSessions(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.sessions.RevokeAll(r.Context(), mux.Vars(r)["login"]); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// issue returns a token of a new session of the user when sessions are set.
+func (h *Handler) issue(r *http.Request, login, role string) (string, error) {
+	if h.sessions == nil {
+		return h.tokenService.Issue(login, role)
+	}
+
+	issuer, ok := h.tokenService.(SessionIssuer)
+	if !ok {
+		return "", errors.New("token issuer doesn't support sessions")
+	}
+
+	session, err := h.sessions.Create(r.Context(), login, role, r.RemoteAddr, r.UserAgent())
+	if err != nil {
+		return "", errors.Wrap(err, "create session")
+	}
+	return issuer.IssueSession(login, role, session.ID)
+}
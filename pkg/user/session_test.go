@@ -0,0 +1,144 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/jwt"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
+	"github.com/supergiant/control/pkg/storage/memory"
+)
+
+func TestSessionService(t *testing.T) {
+	ctx := context.Background()
+	s := NewSessionService(DefaultSessionStoragePrefix, memory.NewInMemoryRepository(), time.Hour)
+
+	bob, err := s.Create(ctx, "bob", RoleEdit, "10.0.0.1:1234", "cli")
+	require.NoError(t, err)
+	_, err = s.Create(ctx, "bob", RoleEdit, "", "")
+	require.NoError(t, err)
+	alice, err := s.Create(ctx, "alice", RoleAdmin, "", "")
+	require.NoError(t, err)
+	require.NoError(t, s.Check(ctx, bob.ID))
+	require.Equal(t, sgerrors.ErrSessionRevoked, s.Check(ctx, "unknown"))
+
+	sessions, _, err := s.List(ctx, storage.ListOptions{Fields: map[string]string{"login": "bob"}})
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+
+	// users revoke only their own sessions
+	require.True(t, sgerrors.IsNotFound(s.Revoke(ctx, "bob", alice.ID)))
+	require.NoError(t, s.Revoke(ctx, "bob", bob.ID))
+	require.Equal(t, sgerrors.ErrSessionRevoked, s.Check(ctx, bob.ID))
+
+	n, err := s.RevokeAll(ctx, "bob")
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.NoError(t, s.Check(ctx, alice.ID))
+
+	// expired sessions are removed
+	s.ttl = -time.Second
+	expired, err := s.Create(ctx, "bob", RoleEdit, "", "")
+	require.NoError(t, err)
+	require.Equal(t, sgerrors.ErrSessionRevoked, s.Check(ctx, expired.ID))
+	sessions, _, err = s.List(ctx, storage.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, alice.ID, sessions[0].ID)
+}
+
+func TestEndpoint_Sessions(t *testing.T) {
+	repo := memory.NewInMemoryRepository()
+	users := NewService(DefaultStoragePrefix, repo)
+	require.NoError(t, users.Create(context.Background(), &User{Login: "bob", Password: "password", Role: RoleEdit}))
+
+	ts := jwt.NewTokenService(60, []byte("secret"))
+	h := NewHandler(users, ts)
+	sessions := NewSessionService(DefaultSessionStoragePrefix, repo, time.Minute)
+	h.SetSessions(sessions)
+	h.SetLockoutPolicy(LockoutPolicy{MaxAttempts: 2, Duration: "1m"})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/auth", h.Authenticate)
+	router.HandleFunc("/auth/password", h.ChangePassword)
+	allow := func(h http.HandlerFunc) http.HandlerFunc { return h }
+	h.RegisterSessions(router, allow, allow)
+
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, url, strings.NewReader(body)))
+		return rec
+	}
+	list := func(url string) []Session {
+		rec := do(http.MethodGet, url, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var s []Session
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&s))
+		return s
+	}
+
+	rec := do(http.MethodPost, "/auth", `{"login":"bob","password":"password"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	claims, err := ts.Validate(rec.Header().Get("Authorization"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/auth", `{"login":"bob","password":"password"}`).Code)
+
+	bob := list("/users/bob/sessions")
+	require.Len(t, bob, 2)
+	require.Empty(t, list("/users/alice/sessions"))
+	require.Len(t, list("/sessions"), 2)
+	require.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/users/alice/sessions/"+bob[0].ID, "").Code)
+	require.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/users/bob/sessions/"+bob[0].ID, "").Code)
+	require.Len(t, list("/users/bob/sessions"), 1)
+
+	// changes of passwords revoke sessions
+	rec = do(http.MethodPost, "/auth/password", `{"login":"bob","password":"password","newPassword":"short"}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, http.StatusNoContent,
+		do(http.MethodPost, "/auth/password", `{"login":"bob","password":"password","newPassword":"password2"}`).Code)
+	require.Empty(t, list("/users/bob/sessions"))
+	require.Equal(t, sgerrors.ErrSessionRevoked, sessions.Check(context.Background(), claims["session_id"].(string)))
+
+	// failed logins lock the account
+	require.Equal(t, http.StatusForbidden, do(http.MethodPost, "/auth", `{"login":"bob","password":"password"}`).Code)
+	rec = do(http.MethodPost, "/auth", `{"login":"bob","password":"password"}`)
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "60", rec.Header().Get("Retry-After"))
+	m := &message.Message{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(m))
+	require.Equal(t, sgerrors.AccountLocked, m.ErrorCode)
+	require.Equal(t, http.StatusTooManyRequests, do(http.MethodPost, "/auth", `{"login":"bob","password":"password2"}`).Code)
+}
+
+func TestEndpoint_AuthenticatePasswordExpired(t *testing.T) {
+	repo := memory.NewInMemoryRepository()
+	users := NewService(DefaultStoragePrefix, repo)
+	u := &User{Login: "bob", Password: "password"}
+	require.NoError(t, u.encryptPassword())
+	u.PasswordChangedAt = time.Now().Add(-2 * time.Hour).Unix()
+	require.NoError(t, repo.Put(context.Background(), DefaultStoragePrefix, u.Login, u.ToJSON()))
+	users.SetPasswordPolicy(PasswordPolicy{MaxAge: "1h"})
+
+	ts := &mockTokenIssuer{}
+	ts.On("Issue", mock.Anything, mock.Anything).Return("token", nil)
+	h := NewHandler(users, ts)
+
+	rec := httptest.NewRecorder()
+	h.Authenticate(rec, httptest.NewRequest(http.MethodPost, "/auth", strings.NewReader(`{"login":"bob","password":"password"}`)))
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	m := &message.Message{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(m))
+	require.Equal(t, sgerrors.PasswordExpired, m.ErrorCode)
+	ts.AssertNotCalled(t, "Issue", mock.Anything, mock.Anything)
+}
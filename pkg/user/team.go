@@ -0,0 +1,290 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"gopkg.in/asaskevich/govalidator.v8"
+
+	"github.com/supergiant/control/pkg/labels"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
+)
+
+const DefaultTeamStoragePrefix = "/supergiant/team/"
+
+// Team owns cloud accounts, profiles and kubes, users see resources of
+// teams they are members of. Teams are grouped by organizations.
+type Team struct {
+	Name         string   `json:"name" valid:"required, matches(^[a-z0-9-]+$), length(1|32)"`
+	Organization string   `json:"organization" valid:"optional, matches(^[a-z0-9-]+$)"`
+	Members      []string `json:"members" valid:"-"`
+	// Selector grants members access to resources of other teams whose
+	// labels match it, e.g. {"env": "staging"}.
+	Selector map[string]string `json:"selector,omitempty" valid:"-"`
+}
+
+// HasMember reports whether the user is a member of the team.
+func (t *Team) HasMember(login string) bool {
+	for _, m := range t.Members {
+		if m == login {
+			return true
+		}
+	}
+	return false
+}
+
+// TeamService keeps teams and their members.
+type TeamService struct {
+	storagePrefix string
+	repository    storage.Interface
+}
+
+// NewTeamService is a constructor function for user.TeamService
+func NewTeamService(storagePrefix string, repository storage.Interface) *TeamService {
+	return &TeamService{
+		storagePrefix: storagePrefix,
+		repository:    repository,
+	}
+}
+
+// Create adds a team, names of teams are unique.
+func (s *TeamService) Create(ctx context.Context, team *Team) error {
+	if team == nil {
+		return sgerrors.ErrNilValue
+	}
+	if _, err := s.Get(ctx, team.Name); !sgerrors.IsNotFound(err) {
+		if err != nil {
+			return err
+		}
+		return sgerrors.ErrAlreadyExists
+	}
+
+	return s.Update(ctx, team)
+}
+
+// Update replaces the team.
+func (s *TeamService) Update(ctx context.Context, team *Team) error {
+	data, err := json.Marshal(team)
+	if err != nil {
+		return err
+	}
+
+	return s.repository.Put(ctx, s.storagePrefix, team.Name, data)
+}
+
+// Get returns a team by name.
+func (s *TeamService) Get(ctx context.Context, name string) (*Team, error) {
+	data, err := s.repository.Get(ctx, s.storagePrefix, name)
+	if err != nil {
+		return nil, err
+	}
+
+	team := &Team{}
+	if err = json.Unmarshal(data, team); err != nil {
+		return nil, sgerrors.ErrInvalidJson
+	}
+	return team, nil
+}
+
+// List returns a page of teams and the continue token of the next one.
+func (s *TeamService) List(ctx context.Context, opts storage.ListOptions) ([]*Team, string, error) {
+	res, next, err := storage.List(ctx, s.repository, s.storagePrefix, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	teams := make([]*Team, 0, len(res))
+	for _, v := range res {
+		team := &Team{}
+		if err = json.Unmarshal(v, team); err != nil {
+			return nil, "", sgerrors.ErrInvalidJson
+		}
+		teams = append(teams, team)
+	}
+	return teams, next, nil
+}
+
+// Delete removes a team, resources of the team are left without a team.
+func (s *TeamService) Delete(ctx context.Context, name string) error {
+	return s.repository.Delete(ctx, s.storagePrefix, name)
+}
+
+// TeamsOf returns sorted names of teams the user is a member of.
+func (s *TeamService) TeamsOf(ctx context.Context, login string) ([]string, error) {
+	teams, _, err := s.List(ctx, storage.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0)
+	for _, t := range teams {
+		if t.HasMember(login) {
+			names = append(names, t.Name)
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// SelectorsOf returns label selectors of teams the user is a member of.
+func (s *TeamService) SelectorsOf(ctx context.Context, login string) ([]map[string]string, error) {
+	teams, _, err := s.List(ctx, storage.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	selectors := make([]map[string]string, 0)
+	for _, t := range teams {
+		if len(t.Selector) > 0 && t.HasMember(login) {
+			selectors = append(selectors, t.Selector)
+		}
+	}
+
+	return selectors, nil
+}
+
+// TeamHandler manages teams, its routes are for admins.
+type TeamHandler struct {
+	service *TeamService
+}
+
+func NewTeamHandler(service *TeamService) *TeamHandler {
+	return &TeamHandler{
+		service: service,
+	}
+}
+
+// Register adds routes of teams, wrap is applied to each of them, e.g. to
+// allow them to admins only.
+func (h *TeamHandler) Register(r *mux.Router, wrap func(http.HandlerFunc) http.HandlerFunc) {
+	r.HandleFunc("/teams", wrap(h.List)).Methods(http.MethodGet)
+	r.HandleFunc("/teams", wrap(h.Create)).Methods(http.MethodPost)
+	r.HandleFunc("/teams/{name}", wrap(h.Get)).Methods(http.MethodGet)
+	r.HandleFunc("/teams/{name}", wrap(h.Update)).Methods(http.MethodPut)
+	r.HandleFunc("/teams/{name}", wrap(h.Delete)).Methods(http.MethodDelete)
+}
+
+// List returns teams, e.g. teams of an organization with
+// fieldSelector=organization=acme.
+func (h *TeamHandler) List(w http.ResponseWriter, r *http.Request) {
+	opts, err := storage.ParseListOptions(r.URL.Query())
+	if err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+
+	teams, next, err := h.service.List(r.Context(), opts)
+	if err != nil {
+		if err == storage.ErrInvalidContinue {
+			message.SendValidationFailed(w, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if next != "" {
+		w.Header().Set(storage.ContinueHeader, next)
+	}
+	if err = json.NewEncoder(w).Encode(teams); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *TeamHandler) Create(w http.ResponseWriter, r *http.Request) {
+	team := &Team{}
+	if err := json.NewDecoder(r.Body).Decode(team); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+	if ok, err := govalidator.ValidateStruct(team); !ok {
+		message.SendValidationFailed(w, err)
+		return
+	}
+	if err := labels.Validate(team.Selector); err != nil {
+		message.SendValidationFailed(w, sgerrors.WithField(err, "selector"))
+		return
+	}
+
+	if err := h.service.Create(r.Context(), team); err != nil {
+		if sgerrors.IsAlreadyExists(err) {
+			message.SendAlreadyExists(w, team.Name, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(team); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *TeamHandler) Get(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	team, err := h.service.Get(r.Context(), name)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, name, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(team); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// Update replaces the organization, members and selector of the team.
+func (h *TeamHandler) Update(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if _, err := h.service.Get(r.Context(), name); err != nil {
+		if sgerrors.IsNotFound(err) {
+			message.SendNotFound(w, name, err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	team := &Team{}
+	if err := json.NewDecoder(r.Body).Decode(team); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+	team.Name = name
+	if ok, err := govalidator.ValidateStruct(team); !ok {
+		message.SendValidationFailed(w, err)
+		return
+	}
+	if err := labels.Validate(team.Selector); err != nil {
+		message.SendValidationFailed(w, sgerrors.WithField(err, "selector"))
+		return
+	}
+
+	if err := h.service.Update(r.Context(), team); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(team); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+func (h *TeamHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Delete(r.Context(), mux.Vars(r)["name"]); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,83 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
+	"github.com/supergiant/control/pkg/storage/memory"
+)
+
+func TestTeamService(t *testing.T) {
+	ctx := context.Background()
+	s := NewTeamService(DefaultTeamStoragePrefix, memory.NewInMemoryRepository())
+
+	require.NoError(t, s.Create(ctx, &Team{Name: "ops", Members: []string{"bob", "alice"}}))
+	require.NoError(t, s.Create(ctx, &Team{Name: "dev", Organization: "acme", Members: []string{"alice"},
+		Selector: map[string]string{"env": "staging"}}))
+	require.True(t, sgerrors.IsAlreadyExists(s.Create(ctx, &Team{Name: "ops"})))
+
+	teams, err := s.TeamsOf(ctx, "alice")
+	require.NoError(t, err)
+	require.Equal(t, []string{"dev", "ops"}, teams)
+
+	teams, err = s.TeamsOf(ctx, "eve")
+	require.NoError(t, err)
+	require.Empty(t, teams)
+
+	selectors, err := s.SelectorsOf(ctx, "alice")
+	require.NoError(t, err)
+	require.Equal(t, []map[string]string{{"env": "staging"}}, selectors)
+
+	list, _, err := s.List(ctx, storage.ListOptions{Fields: map[string]string{"organization": "acme"}})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, "dev", list[0].Name)
+
+	require.NoError(t, s.Delete(ctx, "dev"))
+	_, err = s.Get(ctx, "dev")
+	require.True(t, sgerrors.IsNotFound(err))
+}
+
+func TestTeamHandler(t *testing.T) {
+	s := NewTeamService(DefaultTeamStoragePrefix, memory.NewInMemoryRepository())
+	router := mux.NewRouter()
+	NewTeamHandler(s).Register(router, func(h http.HandlerFunc) http.HandlerFunc { return h })
+
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, url, strings.NewReader(body)))
+		return rec
+	}
+
+	require.Equal(t, http.StatusCreated, do(http.MethodPost, "/teams", `{"name":"ops","members":["bob"]}`).Code)
+	require.Equal(t, http.StatusConflict, do(http.MethodPost, "/teams", `{"name":"ops"}`).Code)
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/teams", `{"name":"Ops!"}`).Code)
+
+	rec := do(http.MethodPut, "/teams/ops", `{"name":"other","members":["alice"]}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, http.StatusNotFound, do(http.MethodPut, "/teams/dev", `{}`).Code)
+
+	rec = do(http.MethodGet, "/teams/ops", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	team := &Team{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(team))
+	require.Equal(t, &Team{Name: "ops", Members: []string{"alice"}}, team)
+
+	rec = do(http.MethodGet, "/teams", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	teams := []Team{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&teams))
+	require.Len(t, teams, 1)
+
+	require.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/teams/ops", "").Code)
+	require.Equal(t, http.StatusNotFound, do(http.MethodGet, "/teams/ops", "").Code)
+}
@@ -0,0 +1,463 @@
+package user
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
+)
+
+const (
+	DefaultTOTPStoragePrefix = "/supergiant/totp/"
+	DefaultTOTPIssuer        = "Supergiant"
+
+	totpPeriod   = 30
+	totpDigits   = 6
+	totpSkew     = 1
+	secretSize   = 20
+	recoverySize = 10
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTP is the second factor of a user, RFC 6238 codes of authenticator apps
+// or recovery codes are required for password logins once it's enabled.
+type TOTP struct {
+	Secret string `json:"secret"`
+	// Enabled after the user confirms the enrollment with a code.
+	Enabled bool `json:"enabled"`
+	// RecoveryCodes are hashes of codes that are left, each code is used
+	// once instead of a code of the authenticator.
+	RecoveryCodes []string `json:"recoveryCodes"`
+	// LastCounter is the time step of the last used code, codes are used once.
+	LastCounter int64 `json:"lastCounter"`
+}
+
+// Enrollment is shown to the user once, ProvisioningURI is rendered as a
+// QR code that authenticator apps scan.
+type Enrollment struct {
+	Secret          string   `json:"secret"`
+	ProvisioningURI string   `json:"provisioningUri"`
+	RecoveryCodes   []string `json:"recoveryCodes"`
+}
+
+// TwoFactorStatus of a user.
+type TwoFactorStatus struct {
+	Enabled           bool `json:"enabled"`
+	Required          bool `json:"required"`
+	RecoveryCodesLeft int  `json:"recoveryCodesLeft"`
+}
+
+// TwoFactorService keeps second factors of users and the policy of roles
+// that require them.
+type TwoFactorService struct {
+	storagePrefix string
+	repository    storage.Interface
+	issuer        string
+
+	mu       sync.RWMutex
+	required map[string]bool
+
+	now func() time.Time
+}
+
+// NewTwoFactorService is a constructor function for user.TwoFactorService
+func NewTwoFactorService(storagePrefix string, repository storage.Interface) *TwoFactorService {
+	return &TwoFactorService{
+		storagePrefix: storagePrefix,
+		repository:    repository,
+		issuer:        DefaultTOTPIssuer,
+		required:      make(map[string]bool),
+		now:           time.Now,
+	}
+}
+
+// SetRequiredRoles requires second factors of password logins of users of
+// the roles.
+func (s *TwoFactorService) SetRequiredRoles(roles []string) {
+	required := make(map[string]bool, len(roles))
+	for _, r := range roles {
+		required[r] = true
+	}
+
+	s.mu.Lock()
+	s.required = required
+	s.mu.Unlock()
+}
+
+// Required reports whether users of the role need a second factor.
+func (s *TwoFactorService) Required(role string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.required[role]
+}
+
+// Enroll generates a secret and recovery codes of the user, the second
+// factor is enabled when the enrollment is confirmed. Enabled second
+// factors are disabled before new enrollments.
+func (s *TwoFactorService) Enroll(ctx context.Context, login string) (*Enrollment, error) {
+	t, err := s.get(ctx, login)
+	if err != nil {
+		return nil, err
+	}
+	if t != nil && t.Enabled {
+		return nil, sgerrors.ErrAlreadyExists
+	}
+
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	e := &Enrollment{
+		Secret:        encoding.EncodeToString(secret),
+		RecoveryCodes: make([]string, 0, recoverySize),
+	}
+	t = &TOTP{
+		Secret: e.Secret,
+	}
+	for i := 0; i < recoverySize; i++ {
+		code, err := recoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		e.RecoveryCodes = append(e.RecoveryCodes, code)
+		t.RecoveryCodes = append(t.RecoveryCodes, hashCode(code))
+	}
+
+	label := url.PathEscape(s.issuer + ":" + login)
+	params := url.Values{
+		"secret": {e.Secret},
+		"issuer": {s.issuer},
+		"period": {fmt.Sprint(totpPeriod)},
+		"digits": {fmt.Sprint(totpDigits)},
+	}
+	e.ProvisioningURI = "otpauth://totp/" + label + "?" + params.Encode()
+
+	if err := s.put(ctx, login, t); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Confirm enables the enrolled second factor with a code of the
+// authenticator.
+func (s *TwoFactorService) Confirm(ctx context.Context, login, code string) error {
+	t, err := s.get(ctx, login)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return sgerrors.ErrNotFound
+	}
+	if !s.verifyTOTP(t, code) {
+		return sgerrors.ErrInvalidCredentials
+	}
+
+	t.Enabled = true
+	return s.put(ctx, login, t)
+}
+
+// Verify checks the second factor of a login, a code is required when the
+// second factor of the user is enabled or the role requires it. Pending
+// enrollments are confirmed by their first code.
+func (s *TwoFactorService) Verify(ctx context.Context, login, role, code string) error {
+	t, err := s.get(ctx, login)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case t != nil && t.Enabled:
+		if code == "" {
+			return sgerrors.ErrTwoFactorRequired
+		}
+		if s.verifyTOTP(t, code) || t.useRecoveryCode(code) {
+			return s.put(ctx, login, t)
+		}
+		return sgerrors.ErrInvalidCredentials
+	case t != nil && code != "":
+		if !s.verifyTOTP(t, code) {
+			return sgerrors.ErrInvalidCredentials
+		}
+		t.Enabled = true
+		return s.put(ctx, login, t)
+	case s.Required(role):
+		return sgerrors.ErrTwoFactorEnrollment
+	}
+
+	return nil
+}
+
+// Disable removes the second factor of the user.
+func (s *TwoFactorService) Disable(ctx context.Context, login string) error {
+	err := s.repository.Delete(ctx, s.storagePrefix, login)
+	if sgerrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// Status returns the second factor status of the user.
+func (s *TwoFactorService) Status(ctx context.Context, login, role string) (*TwoFactorStatus, error) {
+	t, err := s.get(ctx, login)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &TwoFactorStatus{
+		Required: s.Required(role),
+	}
+	if t != nil {
+		status.Enabled = t.Enabled
+		status.RecoveryCodesLeft = len(t.RecoveryCodes)
+	}
+	return status, nil
+}
+
+// verifyTOTP checks the code against time steps around now, used steps
+// are rejected.
+func (s *TwoFactorService) verifyTOTP(t *TOTP, code string) bool {
+	secret, err := encoding.DecodeString(t.Secret)
+	if err != nil || len(code) != totpDigits {
+		return false
+	}
+
+	counter := s.now().Unix() / totpPeriod
+	for c := counter - totpSkew; c <= counter+totpSkew; c++ {
+		if c <= t.LastCounter {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totp(secret, c)), []byte(code)) == 1 {
+			t.LastCounter = c
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *TOTP) useRecoveryCode(code string) bool {
+	h := hashCode(code)
+	for i, c := range t.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(c), []byte(h)) == 1 {
+			t.RecoveryCodes = append(t.RecoveryCodes[:i], t.RecoveryCodes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *TwoFactorService) get(ctx context.Context, login string) (*TOTP, error) {
+	data, err := s.repository.Get(ctx, s.storagePrefix, login)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	t := &TOTP{}
+	if err = json.Unmarshal(data, t); err != nil {
+		return nil, sgerrors.ErrInvalidJson
+	}
+	return t, nil
+}
+
+func (s *TwoFactorService) put(ctx context.Context, login string, t *TOTP) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return s.repository.Put(ctx, s.storagePrefix, login, data)
+}
+
+// totp returns the RFC 4226 code of the counter.
+func totp(secret []byte, counter int64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(counter))
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// recoveryCode returns a code like 4fq2k-x7mbd.
+func recoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(encoding.EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(code))))
+	return hex.EncodeToString(sum[:])
+}
+
+// CodeRequest carries a code of the authenticator.
+type CodeRequest struct {
+	Code string `json:"code"`
+}
+
+// SetTwoFactor requires second factors of password logins when they are
+// enabled by users or required for their roles.
+func (h *Handler) SetTwoFactor(s *TwoFactorService) {
+	h.twoFactor = s
+}
+
+// RegisterTwoFactor adds routes of second factors of users, self allows
+// users to manage their own second factors, admin allows admins to reset
+// them.
+func (h *Handler) RegisterTwoFactor(public, protected *mux.Router, self, admin func(http.HandlerFunc) http.HandlerFunc) {
+	public.HandleFunc("/auth/totp", h.EnrollAtLogin).Methods(http.MethodPost)
+	protected.HandleFunc("/users/{login}/totp", self(h.TwoFactorStatus)).Methods(http.MethodGet)
+	protected.HandleFunc("/users/{login}/totp", self(h.EnrollTwoFactor)).Methods(http.MethodPost)
+	protected.HandleFunc("/users/{login}/totp", self(h.ConfirmTwoFactor)).Methods(http.MethodPut)
+	protected.HandleFunc("/users/{login}/totp", self(h.DisableTwoFactor)).Methods(http.MethodDelete)
+	protected.HandleFunc("/users/{login}/totp/reset", admin(h.ResetTwoFactor)).Methods(http.MethodPost)
+}
+
+// EnrollAtLogin enrolls a second factor of a user with the password, users
+// of roles that require second factors enroll them before the first login.
+func (h *Handler) EnrollAtLogin(w http.ResponseWriter, r *http.Request) {
+	enableCors(w)
+
+	var ar AuthRequest
+	if err := json.NewDecoder(r.Body).Decode(&ar); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	if _, ok := h.login(w, r, ar, true); !ok {
+		return
+	}
+
+	h.enroll(w, r, ar.Login)
+}
+
+func (h *Handler) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	h.enroll(w, r, mux.Vars(r)["login"])
+}
+
+func (h *Handler) enroll(w http.ResponseWriter, r *http.Request, login string) {
+	e, err := h.twoFactor.Enroll(r.Context(), login)
+	if err != nil {
+		if sgerrors.IsAlreadyExists(err) {
+			message.SendAlreadyExists(w, "second factor", err)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(e); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// ConfirmTwoFactor enables the enrolled second factor.
+func (h *Handler) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	req := &CodeRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	login := mux.Vars(r)["login"]
+	if err := h.twoFactor.Confirm(r.Context(), login, req.Code); err != nil {
+		switch {
+		case sgerrors.IsNotFound(err):
+			message.SendNotFound(w, "second factor", err)
+		case sgerrors.IsInvalidCredentials(err):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			message.SendUnknownError(w, err)
+		}
+		return
+	}
+
+	h.TwoFactorStatus(w, r)
+}
+
+// DisableTwoFactor removes the second factor with a code of it.
+func (h *Handler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	req := &CodeRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+
+	login := mux.Vars(r)["login"]
+	if err := h.twoFactor.Verify(r.Context(), login, "", req.Code); err != nil {
+		if sgerrors.IsTwoFactor(err) || sgerrors.IsInvalidCredentials(err) {
+			http.Error(w, sgerrors.ErrInvalidCredentials.Error(), http.StatusForbidden)
+			return
+		}
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	h.ResetTwoFactor(w, r)
+}
+
+// ResetTwoFactor removes the second factor of a user that lost it.
+func (h *Handler) ResetTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if err := h.twoFactor.Disable(r.Context(), mux.Vars(r)["login"]); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) TwoFactorStatus(w http.ResponseWriter, r *http.Request) {
+	login := mux.Vars(r)["login"]
+
+	// users of providers aren't local
+	role := ""
+	if u, err := h.userService.Get(r.Context(), login); err == nil {
+		role = u.GetRole()
+	}
+
+	status, err := h.twoFactor.Status(r.Context(), login, role)
+	if err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(status); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
@@ -0,0 +1,139 @@
+package user
+
+import (
+	"context"
+	"encoding/base32"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage/memory"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 test vectors truncated to 6 digits
+	secret := []byte("12345678901234567890")
+	require.Equal(t, "287082", totp(secret, 59/totpPeriod))
+	require.Equal(t, "081804", totp(secret, 1111111109/totpPeriod))
+	require.Equal(t, "050471", totp(secret, 1111111111/totpPeriod))
+}
+
+func code(t *testing.T, e *Enrollment, at time.Time) string {
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(e.Secret)
+	require.NoError(t, err)
+	return totp(secret, at.Unix()/totpPeriod)
+}
+
+func TestTwoFactorService(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	s := NewTwoFactorService(DefaultTOTPStoragePrefix, memory.NewInMemoryRepository())
+	s.now = func() time.Time { return now }
+
+	// no second factor
+	require.NoError(t, s.Verify(ctx, "bob", RoleEdit, ""))
+	s.SetRequiredRoles([]string{RoleAdmin})
+	require.Equal(t, sgerrors.ErrTwoFactorEnrollment, s.Verify(ctx, "bob", RoleAdmin, ""))
+
+	e, err := s.Enroll(ctx, "bob")
+	require.NoError(t, err)
+	require.Len(t, e.RecoveryCodes, recoverySize)
+	require.True(t, strings.HasPrefix(e.ProvisioningURI, "otpauth://totp/Supergiant:bob?"), e.ProvisioningURI)
+	require.Contains(t, e.ProvisioningURI, "secret="+e.Secret)
+
+	// pending enrollments aren't required for other roles
+	require.NoError(t, s.Verify(ctx, "bob", RoleEdit, ""))
+	wrong := "000000"
+	if code(t, e, now) == wrong {
+		wrong = "111111"
+	}
+	require.Equal(t, sgerrors.ErrInvalidCredentials, s.Confirm(ctx, "bob", wrong))
+	require.NoError(t, s.Confirm(ctx, "bob", code(t, e, now)))
+
+	_, err = s.Enroll(ctx, "bob")
+	require.True(t, sgerrors.IsAlreadyExists(err))
+
+	require.Equal(t, sgerrors.ErrTwoFactorRequired, s.Verify(ctx, "bob", RoleEdit, ""))
+	// codes are used once
+	require.Equal(t, sgerrors.ErrInvalidCredentials, s.Verify(ctx, "bob", RoleEdit, code(t, e, now)))
+	now = now.Add(time.Second * totpPeriod)
+	require.NoError(t, s.Verify(ctx, "bob", RoleEdit, code(t, e, now)))
+
+	require.NoError(t, s.Verify(ctx, "bob", RoleEdit, strings.ToUpper(e.RecoveryCodes[3])))
+	require.Equal(t, sgerrors.ErrInvalidCredentials, s.Verify(ctx, "bob", RoleEdit, e.RecoveryCodes[3]))
+
+	status, err := s.Status(ctx, "bob", RoleAdmin)
+	require.NoError(t, err)
+	require.Equal(t, &TwoFactorStatus{Enabled: true, Required: true, RecoveryCodesLeft: recoverySize - 1}, status)
+
+	require.NoError(t, s.Disable(ctx, "bob"))
+	require.NoError(t, s.Disable(ctx, "bob"))
+	require.NoError(t, s.Verify(ctx, "bob", RoleEdit, ""))
+}
+
+func TestEndpoint_AuthenticateTwoFactor(t *testing.T) {
+	repo := memory.NewInMemoryRepository()
+	users := NewService(DefaultStoragePrefix, repo)
+	require.NoError(t, users.Create(context.Background(), &User{Login: "bob", Password: "password", Role: RoleAdmin}))
+
+	ts := &mockTokenIssuer{}
+	ts.On("Issue", "bob", RoleAdmin).Return("token", nil)
+	h := NewHandler(users, ts)
+	h.SetTwoFactor(NewTwoFactorService(DefaultTOTPStoragePrefix, repo))
+	h.twoFactor.SetRequiredRoles([]string{RoleAdmin})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/auth", h.Authenticate)
+	allow := func(h http.HandlerFunc) http.HandlerFunc { return h }
+	h.RegisterTwoFactor(router, router, allow, allow)
+
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, url, strings.NewReader(body)))
+		return rec
+	}
+	errorCode := func(rec *httptest.ResponseRecorder) sgerrors.ErrorCode {
+		m := &message.Message{}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(m))
+		return m.ErrorCode
+	}
+
+	rec := do(http.MethodPost, "/auth", `{"login":"bob","password":"password"}`)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Equal(t, sgerrors.TwoFactorEnrollment, errorCode(rec))
+
+	require.Equal(t, http.StatusForbidden, do(http.MethodPost, "/auth/totp", `{"login":"bob","password":"wrong"}`).Code)
+	rec = do(http.MethodPost, "/auth/totp", `{"login":"bob","password":"password"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	e := &Enrollment{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(e))
+
+	// the first code confirms the enrollment
+	body, _ := json.Marshal(AuthRequest{Login: "bob", Password: "password", Code: code(t, e, time.Now())})
+	rec = do(http.MethodPost, "/auth", string(body))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, "token", rec.Header().Get("Authorization"))
+
+	rec = do(http.MethodPost, "/auth", `{"login":"bob","password":"password"}`)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Equal(t, sgerrors.TwoFactorRequired, errorCode(rec))
+	rec = do(http.MethodPost, "/auth", `{"login":"bob","password":"password","code":"`+e.RecoveryCodes[0]+`"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	require.Equal(t, http.StatusConflict, do(http.MethodPost, "/users/bob/totp", "").Code)
+	rec = do(http.MethodGet, "/users/bob/totp", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `"enabled":true`)
+
+	require.Equal(t, http.StatusForbidden, do(http.MethodDelete, "/users/bob/totp", `{"code":"123"}`).Code)
+	require.Equal(t, http.StatusNoContent, do(http.MethodPost, "/users/bob/totp/reset", "").Code)
+	ts.AssertNumberOfCalls(t, "Issue", 2)
+}
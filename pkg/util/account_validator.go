@@ -3,6 +3,7 @@ package util
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -14,12 +15,14 @@ import (
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/jwt"
 	compute "google.golang.org/api/compute/v1"
-	"google.golang.org/api/dns/v1"
+	clouddns "google.golang.org/api/dns/v1"
 
 	"github.com/supergiant/control/pkg/clouds"
 	"github.com/supergiant/control/pkg/clouds/digitaloceansdk"
+	"github.com/supergiant/control/pkg/dns"
 	"github.com/supergiant/control/pkg/model"
 	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/tracing"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -48,6 +51,13 @@ func NewCloudAccountValidator() *CloudAccountValidatorImpl {
 }
 
 func (v *CloudAccountValidatorImpl) ValidateCredentials(cloudAccount *model.CloudAccount) error {
+	// the DNS of the cloud is optional, other providers must be complete
+	if cloudAccount.DNS.Provider != "" {
+		if _, _, err := dns.SettingsOf(cloudAccount); err != nil {
+			return err
+		}
+	}
+
 	switch cloudAccount.Provider {
 	case clouds.DigitalOcean:
 		return v.digitalOcean(cloudAccount.Credentials)
@@ -57,6 +67,8 @@ func (v *CloudAccountValidatorImpl) ValidateCredentials(cloudAccount *model.Clou
 		return v.gce(cloudAccount.Credentials)
 	case clouds.Azure:
 		return v.azure(cloudAccount.Credentials)
+	case clouds.Fake:
+		return validateFakeCredentials(cloudAccount.Credentials)
 	}
 
 	return sgerrors.ErrUnsupportedProvider
@@ -74,7 +86,7 @@ func validateDigitalOceanCredentials(creds map[string]string) error {
 		AccessToken: config.AccessToken,
 	}
 	oauthClient := oauth2.NewClient(oauth2.NoContext, ts)
-	client := godo.NewClient(oauthClient)
+	client := godo.NewClient(tracing.Client("digitalocean", oauthClient))
 
 	_, _, err = client.Droplets.List(context.Background(), new(godo.ListOptions))
 
@@ -112,7 +124,7 @@ func validateGCECredentials(creds map[string]string) error {
 	clientScopes := []string{
 		compute.ComputeScope,
 		compute.CloudPlatformScope,
-		dns.NdevClouddnsReadwriteScope,
+		clouddns.NdevClouddnsReadwriteScope,
 		compute.DevstorageFullControlScope,
 	}
 
@@ -123,7 +135,7 @@ func validateGCECredentials(creds map[string]string) error {
 		TokenURL:   creds[clouds.GCETokenURI],
 	}
 
-	client := conf.Client(context.Background())
+	client := tracing.Client("gce", conf.Client(context.Background()))
 
 	computeService, err := compute.New(client)
 	if err != nil {
@@ -161,3 +173,19 @@ func validateAzureCredentials(creds map[string]string) error {
 
 	return nil
 }
+
+// validateFakeCredentials checks faults of the fake cloud, it has no credentials.
+func validateFakeCredentials(creds map[string]string) error {
+	config := &steps.FakeConfig{}
+	if err := BindParams(creds, config); err != nil {
+		return err
+	}
+
+	if config.Latency != "" {
+		if _, err := time.ParseDuration(config.Latency); err != nil {
+			return errors.Wrapf(ErrInvalidCredentials, "fake: latency %q", config.Latency)
+		}
+	}
+
+	return nil
+}
@@ -3,7 +3,10 @@ package util
 import (
 	"testing"
 
+	"github.com/pkg/errors"
+
 	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/dns"
 	"github.com/supergiant/control/pkg/model"
 	"github.com/supergiant/control/pkg/sgerrors"
 )
@@ -96,6 +99,19 @@ func TestValidateCredentials(t *testing.T) {
 			},
 			expectedError: sgerrors.ErrInvalidCredentials,
 		},
+		{
+			description: "cloudflare dns without a token",
+			cloudAccount: &model.CloudAccount{
+				Name:        "test",
+				Provider:    clouds.AWS,
+				Credentials: map[string]string{},
+				DNS:         model.AccountDNS{Provider: dns.Cloudflare},
+			},
+			getCreds: func(map[string]string) error {
+				return nil
+			},
+			expectedError: sgerrors.ErrInvalidCredentials,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -108,7 +124,7 @@ func TestValidateCredentials(t *testing.T) {
 
 		err := validator.ValidateCredentials(testCase.cloudAccount)
 
-		if err != testCase.expectedError {
+		if errors.Cause(err) != testCase.expectedError {
 			t.Errorf("Expected error %v actual %v", testCase.expectedError, err)
 		}
 	}
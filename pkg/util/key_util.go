@@ -27,6 +27,12 @@ func BootstrapKeys(config *steps.Config) error {
 	return nil
 }
 
+// NewKeyPair creates a ssh key pair like the one used for provisioning,
+// it returns a PEM encoded private key and an authorized_keys public key.
+func NewKeyPair() (string, string, error) {
+	return generateKeyPair(keySize)
+}
+
 func generateKeyPair(size int) (string, string, error) {
 	privateKey, err := rsa.GenerateKey(rand.Reader, size)
 
@@ -73,3 +79,24 @@ func generatePublicKey(publicKey *rsa.PublicKey) ([]byte, error) {
 
 	return pubKeyBytes, nil
 }
+
+// PublicKey returns an authorized_keys public key of a PEM encoded private key.
+func PublicKey(privateKey string) (string, error) {
+	signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(ssh.MarshalAuthorizedKey(signer.PublicKey())), nil
+}
+
+// Fingerprint returns the SHA256 fingerprint of an authorized_keys public key.
+func Fingerprint(publicKey string) (string, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+	if err != nil {
+		return "", err
+	}
+
+	return ssh.FingerprintSHA256(key), nil
+}
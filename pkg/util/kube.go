@@ -22,6 +22,9 @@ func UpdateKubeWithCloudSpecificData(k *model.Kube, config *steps.Config) {
 	k.Auth.CACertHash = config.Kube.Auth.CACertHash
 	k.Auth.CertificateKey = config.Kube.Auth.CertificateKey
 	k.Auth.CACertHash = config.Kube.Auth.CACertHash
+	if config.Kube.IngressEndpoint != "" {
+		k.IngressEndpoint = config.Kube.IngressEndpoint
+	}
 
 	// Save cloudSpecificData in kube
 	switch config.Provider {
@@ -14,7 +14,9 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/dns"
 	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/redact"
 	"github.com/supergiant/control/pkg/sgerrors"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
@@ -108,6 +110,14 @@ func FillCloudAccountCredentials(cloudAccount *model.CloudAccount, config *steps
 		return err
 	}
 
+	// accounts without a DNS provider can't be used by DNS addons
+	if provider, creds, err := dns.SettingsOf(cloudAccount); err == nil {
+		config.DNSConfig = steps.DNSConfig{
+			Provider:    provider,
+			Credentials: creds,
+		}
+	}
+
 	// TODO(stgleb):  Add support for other cloud providers
 	switch cloudAccount.Provider {
 	case clouds.AWS:
@@ -118,6 +128,8 @@ func FillCloudAccountCredentials(cloudAccount *model.CloudAccount, config *steps
 		return BindParams(cloudAccount.Credentials, &config.GCEConfig)
 	case clouds.Azure:
 		return BindParams(cloudAccount.Credentials, &config.AzureConfig)
+	case clouds.Fake:
+		return BindParams(cloudAccount.Credentials, &config.FakeConfig)
 	default:
 		return sgerrors.ErrUnknownProvider
 	}
@@ -133,7 +145,12 @@ func GetRandomNode(nodeMap map[string]*model.Machine) *model.Machine {
 
 func GetWriterFunc(logDir string) func(string) (io.WriteCloser, error) {
 	return func(name string) (io.WriteCloser, error) {
-		return os.OpenFile(path.Join(logDir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+		f, err := os.OpenFile(path.Join(logDir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+		if err != nil {
+			return f, err
+		}
+
+		return redact.NewWriter(f), nil
 	}
 }
 
@@ -206,6 +223,7 @@ func LoadCloudSpecificDataFromKube(k *model.Kube, config *steps.Config) error {
 		config.AzureConfig.Location = k.Region
 		config.AzureConfig.VNetCIDR = k.CloudSpec[clouds.AzureVNetCIDR]
 		config.AzureConfig.VolumeSize = k.CloudSpec[clouds.AzureVolumeSize]
+	case clouds.Fake:
 	default:
 		return errors.Wrapf(sgerrors.ErrUnsupportedProvider, "Load cloud specific data from kube %s", k.ID)
 	}
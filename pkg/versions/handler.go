@@ -0,0 +1,49 @@
+package versions
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/message"
+)
+
+// Handler serves the matrix of supported versions.
+type Handler struct {
+	catalog *Catalog
+}
+
+func NewHandler(c *Catalog) *Handler {
+	return &Handler{
+		catalog: c,
+	}
+}
+
+func (h *Handler) Register(r *mux.Router) {
+	r.HandleFunc("/versions", h.getMatrix).Methods(http.MethodGet)
+	r.HandleFunc("/versions/{version}", h.getRelease).Methods(http.MethodGet)
+}
+
+func (h *Handler) getMatrix(w http.ResponseWriter, r *http.Request) {
+	if err := json.NewEncoder(w).Encode(h.catalog.Matrix()); err != nil {
+		logrus.Errorf("versions: get matrix: write response: %v", err)
+	}
+}
+
+// getRelease returns components of the minor of the version, e.g. 1.14.3
+// and 1.14 return the same release.
+func (h *Handler) getRelease(w http.ResponseWriter, r *http.Request) {
+	version := mux.Vars(r)["version"]
+
+	release, err := h.catalog.Release(version)
+	if err != nil {
+		message.SendNotFound(w, version, err)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(release); err != nil {
+		logrus.Errorf("versions: get release %s: write response: %v", version, err)
+	}
+}
@@ -0,0 +1,34 @@
+package versions
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	router := mux.NewRouter()
+	NewHandler(NewCatalog(Builtin())).Register(router)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/versions", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	m := Matrix{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&m))
+	require.Len(t, m.Releases, 5)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/versions/1.14.1", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	r := Release{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&r))
+	require.Equal(t, "3.3.10", r.Etcd)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/versions/1.9", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
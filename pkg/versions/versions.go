@@ -0,0 +1,433 @@
+// Package versions is the catalog of kubernetes minor versions the control
+// plane provisions and versions of etcd, network providers, container
+// runtimes and addons that are compatible with them.
+package versions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Docker is the container runtime of the matrix that nodes run.
+const Docker = "docker"
+
+// Release channels clusters subscribe to, e.g. "1.14 stable". Stable is
+// the default channel of a minor version.
+const (
+	Stable = "stable"
+	Rapid  = "rapid"
+)
+
+const defaultFeedInterval = 24 * time.Hour
+
+// ErrUnsupported is returned for kubernetes versions, components and
+// upgrades that aren't in the catalog.
+var ErrUnsupported = errors.New("unsupported version")
+
+// Release is a kubernetes minor version with versions of its components.
+type Release struct {
+	// Minor is the kubernetes minor version, e.g. 1.14.
+	Minor string `json:"minor"`
+	// Patches are versions of the minor that are provisioned, the last one
+	// is the target of upgrades.
+	Patches []string `json:"patches"`
+	Etcd    string   `json:"etcd"`
+	// CNI maps network providers to the versions of their manifests, other
+	// providers can't run on the minor.
+	CNI map[string]string `json:"cni"`
+	// Runtimes map container runtimes to their compatible versions, e.g.
+	// docker to 18.06 and 18.09.
+	Runtimes map[string][]string `json:"runtimes"`
+	// Addons map addons to their versions, e.g. coredns or helm.
+	Addons map[string]string `json:"addons,omitempty"`
+	// Channels map release channels to patch versions clusters subscribed
+	// to them are upgraded to, channels that aren't set follow the latest patch.
+	Channels map[string]string `json:"channels,omitempty"`
+}
+
+// Latest returns the newest patch version of the release.
+func (r Release) Latest() string {
+	return r.Patches[len(r.Patches)-1]
+}
+
+// ChannelVersion returns the patch version of the release channel.
+func (r Release) ChannelVersion(channel string) (string, error) {
+	if channel != Stable && channel != Rapid {
+		return "", errors.Wrapf(ErrUnsupported, "unknown channel %q, channels: %s, %s", channel, Stable, Rapid)
+	}
+	if v := r.Channels[channel]; v != "" {
+		return v, nil
+	}
+	return r.Latest(), nil
+}
+
+// RuntimeCompatible reports whether the version of the container runtime
+// runs the release, versions match by prefix, e.g. 18.06.3 matches 18.06.
+func (r Release) RuntimeCompatible(runtime, version string) bool {
+	for _, v := range r.Runtimes[runtime] {
+		if version == v || strings.HasPrefix(version, v+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Matrix is the set of supported releases, it's the format of the feed.
+type Matrix struct {
+	Releases []Release `json:"releases"`
+}
+
+// Validate checks releases are unique and have patches and etcd versions.
+func (m Matrix) Validate() error {
+	if len(m.Releases) == 0 {
+		return errors.New("matrix has no releases")
+	}
+
+	minors := make(map[string]bool, len(m.Releases))
+	for _, r := range m.Releases {
+		if _, err := parseMinor(r.Minor); err != nil {
+			return errors.Wrapf(err, "release %q", r.Minor)
+		}
+		if minors[r.Minor] {
+			return errors.Errorf("release %s is defined twice", r.Minor)
+		}
+		minors[r.Minor] = true
+
+		if len(r.Patches) == 0 || r.Etcd == "" {
+			return errors.Errorf("release %s needs patches and an etcd version", r.Minor)
+		}
+		for _, patch := range r.Patches {
+			if minor, err := minorOf(patch); err != nil || minor != r.Minor {
+				return errors.Errorf("release %s: invalid patch %q", r.Minor, patch)
+			}
+		}
+		for channel, patch := range r.Channels {
+			if _, err := r.ChannelVersion(channel); err != nil {
+				return errors.Wrapf(err, "release %s", r.Minor)
+			}
+			if !contains(r.Patches, patch) {
+				return errors.Errorf("release %s: %s channel follows unknown patch %q", r.Minor, channel, patch)
+			}
+		}
+	}
+	return nil
+}
+
+// Builtin returns the matrix of versions the control plane is released with.
+func Builtin() Matrix {
+	cni := func(flannel, calico, weave string) map[string]string {
+		return map[string]string{"Flannel": flannel, "Calico": calico, "Weave": weave}
+	}
+
+	return Matrix{
+		Releases: []Release{
+			{
+				Minor:    "1.11",
+				Patches:  []string{"1.11.5"},
+				Etcd:     "3.2.18",
+				CNI:      cni("0.10.0", "3.1", "2.4"),
+				Runtimes: map[string][]string{Docker: {"17.03", "18.06"}},
+				Addons:   map[string]string{"coredns": "1.1.3", "helm": "2.11.0"},
+			},
+			{
+				Minor:    "1.12",
+				Patches:  []string{"1.12.7"},
+				Etcd:     "3.2.24",
+				CNI:      cni("0.10.0", "3.3", "2.5"),
+				Runtimes: map[string][]string{Docker: {"17.03", "18.06"}},
+				Addons:   map[string]string{"coredns": "1.2.2", "helm": "2.11.0"},
+			},
+			{
+				Minor:    "1.13",
+				Patches:  []string{"1.13.7"},
+				Etcd:     "3.2.24",
+				CNI:      cni("0.11.0", "3.5", "2.5"),
+				Runtimes: map[string][]string{Docker: {"18.06", "18.09"}},
+				Addons:   map[string]string{"coredns": "1.2.6", "helm": "2.13.1"},
+			},
+			{
+				Minor:    "1.14",
+				Patches:  []string{"1.14.1", "1.14.3"},
+				Etcd:     "3.3.10",
+				CNI:      cni("0.11.0", "3.7", "2.5"),
+				Runtimes: map[string][]string{Docker: {"18.06", "18.09"}},
+				Addons:   map[string]string{"coredns": "1.3.1", "helm": "2.14.1"},
+			},
+			{
+				Minor:    "1.15",
+				Patches:  []string{"1.15.1"},
+				Etcd:     "3.3.10",
+				CNI:      cni("0.11.0", "3.8", "2.5"),
+				Runtimes: map[string][]string{Docker: {"18.06", "18.09"}},
+				Addons:   map[string]string{"coredns": "1.3.1", "helm": "2.14.1"},
+			},
+		},
+	}
+}
+
+// Catalog holds the matrix of supported versions, it's safe for concurrent
+// use and is updated at runtime from a feed.
+type Catalog struct {
+	m        sync.RWMutex
+	matrix   Matrix
+	handlers []func(Matrix)
+
+	client *http.Client
+}
+
+var defaultCatalog = NewCatalog(Builtin())
+
+// Default returns the catalog that profiles and upgrades are checked with.
+func Default() *Catalog {
+	return defaultCatalog
+}
+
+// NewCatalog returns a catalog of the matrix, the matrix is expected to
+// be valid.
+func NewCatalog(m Matrix) *Catalog {
+	c := &Catalog{
+		client: &http.Client{Timeout: time.Minute},
+	}
+	c.matrix = sorted(m)
+	return c
+}
+
+// Matrix returns releases of the catalog ordered by their minor versions.
+func (c *Catalog) Matrix() Matrix {
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	releases := make([]Release, len(c.matrix.Releases))
+	copy(releases, c.matrix.Releases)
+	return Matrix{Releases: releases}
+}
+
+// Set replaces the matrix of the catalog if it's valid, handlers are
+// called with the new matrix.
+func (c *Catalog) Set(m Matrix) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	c.m.Lock()
+	c.matrix = sorted(m)
+	handlers := c.handlers
+	c.m.Unlock()
+
+	for _, handler := range handlers {
+		handler(c.Matrix())
+	}
+	return nil
+}
+
+// OnChange registers the handler of matrices that replace the current one.
+func (c *Catalog) OnChange(handler func(Matrix)) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.handlers = append(c.handlers, handler)
+}
+
+// Release returns the release of the minor of the kubernetes version.
+func (c *Catalog) Release(version string) (*Release, error) {
+	minor, err := minorOf(version)
+	if err != nil {
+		return nil, errors.Wrapf(ErrUnsupported, "invalid version %q", version)
+	}
+
+	c.m.RLock()
+	defer c.m.RUnlock()
+	for _, r := range c.matrix.Releases {
+		if r.Minor == minor {
+			return &r, nil
+		}
+	}
+	return nil, errors.Wrapf(ErrUnsupported, "kubernetes %s isn't in the catalog, supported versions: %s",
+		minor, strings.Join(c.minorsLocked(), ", "))
+}
+
+// ChannelVersion returns the patch version of the release channel, e.g.
+// "1.14 stable", the minor version alone subscribes to its stable channel.
+func (c *Catalog) ChannelVersion(name string) (string, error) {
+	minor, channel, err := ParseChannel(name)
+	if err != nil {
+		return "", err
+	}
+
+	release, err := c.Release(minor)
+	if err != nil {
+		return "", err
+	}
+	return release.ChannelVersion(channel)
+}
+
+// ParseChannel splits the channel name to the minor version and the channel.
+func ParseChannel(name string) (string, string, error) {
+	fields := strings.Fields(name)
+	if len(fields) == 0 || len(fields) > 2 {
+		return "", "", errors.Wrapf(ErrUnsupported, "invalid channel %q, e.g. \"1.14 stable\"", name)
+	}
+	if _, err := parseMinor(fields[0]); err != nil {
+		return "", "", errors.Wrapf(ErrUnsupported, "invalid channel %q: %v", name, err)
+	}
+
+	channel := Stable
+	if len(fields) == 2 {
+		channel = fields[1]
+	}
+	return fields[0], channel, nil
+}
+
+// Versions returns the latest patch version of every release in order.
+func (c *Catalog) Versions() []string {
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	versions := make([]string, 0, len(c.matrix.Releases))
+	for _, r := range c.matrix.Releases {
+		versions = append(versions, r.Latest())
+	}
+	return versions
+}
+
+// CheckUpgrade refuses upgrades to versions that aren't in the catalog,
+// downgrades and upgrades that skip minor versions. The network provider
+// of the cluster has to run on the target version.
+func (c *Catalog) CheckUpgrade(from, to, networkProvider string) error {
+	current, err := semver.NewVersion(from)
+	if err != nil {
+		return errors.Wrapf(ErrUnsupported, "invalid version %q", from)
+	}
+	target, err := semver.NewVersion(to)
+	if err != nil {
+		return errors.Wrapf(ErrUnsupported, "invalid version %q", to)
+	}
+
+	release, err := c.Release(to)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case target.LessThan(current):
+		return errors.Wrapf(ErrUnsupported, "can't downgrade from %s to %s", from, to)
+	case target.Major() != current.Major() || target.Minor() > current.Minor()+1:
+		return errors.Wrapf(ErrUnsupported, "can't upgrade from %s to %s, minor versions can't be skipped", from, to)
+	}
+
+	if networkProvider != "" {
+		if _, ok := release.CNI[networkProvider]; !ok {
+			return errors.Wrapf(ErrUnsupported, "%s doesn't run on kubernetes %s", networkProvider, release.Minor)
+		}
+	}
+	return nil
+}
+
+// Fetch replaces the matrix with the one of the JSON feed.
+func (c *Catalog) Fetch(ctx context.Context, url string) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("feed responded with %s", resp.Status)
+	}
+
+	m := Matrix{}
+	if err = json.NewDecoder(resp.Body).Decode(&m); err != nil {
+		return errors.Wrap(err, "decode feed")
+	}
+	return errors.Wrap(c.Set(m), "feed")
+}
+
+// Watch fetches the feed on every interval until the context is done, the
+// current matrix is kept when the feed can't be fetched.
+func (c *Catalog) Watch(ctx context.Context, url string, interval time.Duration) {
+	if interval == 0 {
+		interval = defaultFeedInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.Fetch(ctx, url); err != nil {
+			logrus.Errorf("versions: fetch %s: %v", url, err)
+		} else {
+			logrus.Debugf("versions: catalog has been updated from %s", url)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Minors returns minor versions of the releases in order.
+func (c *Catalog) Minors() []string {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.minorsLocked()
+}
+
+func (c *Catalog) minorsLocked() []string {
+	minors := make([]string, 0, len(c.matrix.Releases))
+	for _, r := range c.matrix.Releases {
+		minors = append(minors, r.Minor)
+	}
+	return minors
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sorted(m Matrix) Matrix {
+	releases := make([]Release, len(m.Releases))
+	copy(releases, m.Releases)
+	sort.SliceStable(releases, func(i, j int) bool {
+		a, _ := parseMinor(releases[i].Minor)
+		b, _ := parseMinor(releases[j].Minor)
+		return a.LessThan(b)
+	})
+	return Matrix{Releases: releases}
+}
+
+func parseMinor(minor string) (*semver.Version, error) {
+	if strings.Count(minor, ".") != 1 {
+		return nil, errors.Errorf("invalid minor version %q", minor)
+	}
+	return semver.NewVersion(minor)
+}
+
+// minorOf returns the major and minor parts of the version, e.g. 1.14.
+func minorOf(version string) (string, error) {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d.%d", v.Major(), v.Minor()), nil
+}
@@ -0,0 +1,118 @@
+package versions
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltin(t *testing.T) {
+	require.NoError(t, Builtin().Validate())
+
+	c := NewCatalog(Builtin())
+	require.Equal(t, []string{"1.11.5", "1.12.7", "1.13.7", "1.14.3", "1.15.1"}, c.Versions())
+
+	r, err := c.Release("1.14.1")
+	require.NoError(t, err)
+	require.Equal(t, "1.14", r.Minor)
+	require.True(t, r.RuntimeCompatible(Docker, "18.06.3"))
+	require.False(t, r.RuntimeCompatible(Docker, "18.1"))
+
+	_, err = c.Release("1.16.0")
+	require.Equal(t, ErrUnsupported, errors.Cause(err))
+	require.Contains(t, err.Error(), "supported versions: 1.11, 1.12, 1.13, 1.14, 1.15")
+}
+
+func TestMatrix_Validate(t *testing.T) {
+	release := Release{Minor: "1.14", Patches: []string{"1.14.3"}, Etcd: "3.3.10"}
+	require.NoError(t, Matrix{Releases: []Release{release}}.Validate())
+
+	for _, m := range []Matrix{
+		{},
+		{Releases: []Release{release, release}},
+		{Releases: []Release{{Minor: "1.14.3", Patches: []string{"1.14.3"}, Etcd: "3.3.10"}}},
+		{Releases: []Release{{Minor: "1.14", Patches: []string{"1.15.1"}, Etcd: "3.3.10"}}},
+		{Releases: []Release{{Minor: "1.14", Patches: []string{"1.14.3"}}}},
+	} {
+		require.Error(t, m.Validate())
+	}
+}
+
+func TestCatalog_CheckUpgrade(t *testing.T) {
+	c := NewCatalog(Builtin())
+
+	require.NoError(t, c.CheckUpgrade("1.13.7", "1.14.3", "Flannel"))
+	require.NoError(t, c.CheckUpgrade("1.14.1", "1.14.3", ""))
+
+	for _, tc := range []struct {
+		from, to, network string
+	}{
+		{"1.13.7", "1.15.1", ""},
+		{"1.14.3", "1.13.7", ""},
+		{"1.15.1", "1.16.0", ""},
+		{"invalid", "1.14.3", ""},
+		{"1.13.7", "1.14.3", "Cilium"},
+	} {
+		err := c.CheckUpgrade(tc.from, tc.to, tc.network)
+		require.Equal(t, ErrUnsupported, errors.Cause(err), "%s to %s", tc.from, tc.to)
+	}
+}
+
+func TestCatalog_ChannelVersion(t *testing.T) {
+	m := Builtin()
+	m.Releases[3].Channels = map[string]string{Stable: "1.14.1"}
+	c := NewCatalog(m)
+
+	for name, expected := range map[string]string{
+		"1.14":        "1.14.1",
+		"1.14 stable": "1.14.1",
+		"1.14 rapid":  "1.14.3",
+		"1.15 stable": "1.15.1",
+	} {
+		v, err := c.ChannelVersion(name)
+		require.NoError(t, err, name)
+		require.Equal(t, expected, v, name)
+	}
+
+	for _, name := range []string{"", "1.14.1 stable", "1.14 beta", "1.16 stable", "1.14 stable now"} {
+		_, err := c.ChannelVersion(name)
+		require.Equal(t, ErrUnsupported, errors.Cause(err), name)
+	}
+
+	// channels follow patches of the release
+	m.Releases[3].Channels = map[string]string{Stable: "1.14.2"}
+	require.Error(t, m.Validate())
+
+	var changed []Matrix
+	c.OnChange(func(m Matrix) { changed = append(changed, m) })
+	require.NoError(t, c.Set(Builtin()))
+	require.Len(t, changed, 1)
+	require.Len(t, changed[0].Releases, 5)
+}
+
+func TestCatalog_Fetch(t *testing.T) {
+	feed := `{"releases": [
+		{"minor": "1.16", "patches": ["1.16.2"], "etcd": "3.3.15"},
+		{"minor": "1.15", "patches": ["1.15.1", "1.15.5"], "etcd": "3.3.10"}
+	]}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/invalid" {
+			w.Write([]byte(`{"releases": []}`))
+			return
+		}
+		w.Write([]byte(feed))
+	}))
+	defer srv.Close()
+
+	c := NewCatalog(Builtin())
+	require.NoError(t, c.Fetch(context.Background(), srv.URL))
+	require.Equal(t, []string{"1.15.5", "1.16.2"}, c.Versions())
+
+	// invalid matrices are refused and the current one is kept
+	require.Error(t, c.Fetch(context.Background(), srv.URL+"/invalid"))
+	require.Equal(t, []string{"1.15.5", "1.16.2"}, c.Versions())
+}
@@ -0,0 +1,197 @@
+package workflows
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/redact"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/statuses"
+)
+
+// taskRecord is a stored task with the config kept as is for redaction.
+type taskRecord struct {
+	ID           string          `json:"id"`
+	Type         string          `json:"type"`
+	Status       statuses.Status `json:"status"`
+	StepStatuses []StepStatus    `json:"stepsStatuses"`
+	CreatedAt    time.Time       `json:"createdAt"`
+	Config       json.RawMessage `json:"config,omitempty"`
+}
+
+// GetLogsArchive returns a tar.gz of the task log, logs of its steps and
+// the config of the task with secrets redacted.
+func (h *TaskHandler) GetLogsArchive(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	data, err := h.repository.Get(r.Context(), Prefix, id)
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	record := &taskRecord{}
+	if err = json.Unmarshal(data, record); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var log []byte
+	if h.readLog != nil {
+		if log, err = h.readLog(id); err != nil && !os.IsNotExist(err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	files, err := archiveFiles(record, log)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"task-%s.tar.gz\"", id))
+	if err = writeArchive(w, "task-"+id, record.CreatedAt, files); err != nil {
+		logrus.Errorf("write logs archive of task %s: %v", id, err)
+	}
+}
+
+type archiveFile struct {
+	name string
+	data []byte
+}
+
+func archiveFiles(record *taskRecord, log []byte) ([]archiveFile, error) {
+	config := []byte("{}")
+	if len(record.Config) > 0 {
+		redacted, err := redact.JSON(record.Config)
+		if err != nil {
+			return nil, err
+		}
+		buf := &bytes.Buffer{}
+		if err = json.Indent(buf, redacted, "", "\t"); err != nil {
+			return nil, err
+		}
+		config = buf.Bytes()
+	}
+
+	// the config is written redacted to its own file
+	task := *record
+	task.Config = nil
+	taskData, err := json.MarshalIndent(task, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+
+	files := []archiveFile{
+		{"task.json", taskData},
+		{"config.json", config},
+		{"task.log", log},
+	}
+
+	stepLogs := splitLog(log, record.StepStatuses)
+	for i, status := range record.StepStatuses {
+		buf := &bytes.Buffer{}
+		fmt.Fprintf(buf, "step: %s\nstatus: %s\n", status.StepName, status.Status)
+		if status.ErrMsg != "" {
+			fmt.Fprintf(buf, "error: %s\n", status.ErrMsg)
+		}
+		buf.WriteString("\n")
+		buf.Write(stepLogs[i])
+
+		for _, res := range status.Results {
+			fmt.Fprintf(buf, "\n$ %s\nexit code: %d, duration: %s\n", res.Command, res.ExitCode, res.Duration)
+			if res.Error != "" {
+				fmt.Fprintf(buf, "error: %s\n", res.Error)
+			}
+			if res.Stdout != "" {
+				fmt.Fprintf(buf, "stdout:\n%s\n", res.Stdout)
+			}
+			if res.Stderr != "" {
+				fmt.Fprintf(buf, "stderr:\n%s\n", res.Stderr)
+			}
+		}
+
+		files = append(files, archiveFile{
+			name: fmt.Sprintf("steps/%02d-%s.log", i+1, status.StepName),
+			data: buf.Bytes(),
+		})
+	}
+
+	return files, nil
+}
+
+// splitLog splits the task log by "[step] - started" lines the task writes
+// before each step, lines before the first step are left out.
+func splitLog(log []byte, stepStatuses []StepStatus) [][]byte {
+	stepLogs := make([][]byte, len(stepStatuses))
+
+	current, next := -1, 0
+	scanner := bufio.NewScanner(bytes.NewReader(log))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if next < len(stepStatuses) &&
+			strings.Contains(line, fmt.Sprintf("[%s] - started", stepStatuses[next].StepName)) {
+			current, next = next, next+1
+		}
+		if current >= 0 {
+			stepLogs[current] = append(stepLogs[current], line+"\n"...)
+		}
+	}
+
+	return stepLogs
+}
+
+func writeArchive(w http.ResponseWriter, dir string, modTime time.Time, files []archiveFile) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	if modTime.IsZero() {
+		modTime = time.Now()
+	}
+	for _, f := range files {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    dir + "/" + f.name,
+			Mode:    0600,
+			Size:    int64(len(f.data)),
+			ModTime: modTime,
+		}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
+
+func readLogFunc(logDir string) func(string) ([]byte, error) {
+	return func(id string) ([]byte, error) {
+		return ioutil.ReadFile(path.Join(logDir, util.MakeFileName(id)))
+	}
+}
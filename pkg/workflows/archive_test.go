@@ -0,0 +1,99 @@
+package workflows
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/supergiant/control/pkg/storage/memory"
+)
+
+const testTaskRecord = `{
+	"id": "task-1",
+	"type": "Master",
+	"status": "error",
+	"config": {"awsConfig": {"secret_key": "s3cr3t"}, "clusterName": "prod"},
+	"stepsStatuses": [
+		{"stepName": "ssh", "status": "success"},
+		{"stepName": "kubelet", "status": "error", "errorMessage": "exit 1",
+			"results": [{"command": "systemctl start kubelet", "exitCode": 1, "stderr": "failed"}]}
+	]
+}`
+
+const testTaskLog = `connecting
+[ssh] - started
+[ssh] - success
+[kubelet] - started
+[kubelet] - failed: exit 1
+`
+
+func TestTaskHandler_GetLogsArchive(t *testing.T) {
+	repo := memory.NewInMemoryRepository()
+	repo.Put(context.Background(), Prefix, "task-1", []byte(testTaskRecord))
+
+	h := &TaskHandler{
+		repository: repo,
+		readLog: func(string) ([]byte, error) {
+			return []byte(testTaskLog), nil
+		},
+	}
+	router := mux.NewRouter()
+	h.Register(router)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tasks/task-2/logs/archive", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d actual %d", http.StatusNotFound, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tasks/task-1/logs/archive", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d actual %d", http.StatusOK, rec.Code)
+	}
+
+	gz, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := ioutil.ReadAll(tr)
+		files[hdr.Name] = string(data)
+	}
+
+	if len(files) != 5 {
+		t.Errorf("expected 5 files actual %d", len(files))
+	}
+	if config := files["task-task-1/config.json"]; strings.Contains(config, "s3cr3t") ||
+		!strings.Contains(config, "prod") {
+		t.Errorf("config must be redacted %s", config)
+	}
+	if step := files["task-task-1/steps/01-ssh.log"]; strings.Contains(step, "kubelet") ||
+		!strings.Contains(step, "[ssh] - success") {
+		t.Errorf("wrong ssh step log %s", step)
+	}
+	if step := files["task-task-1/steps/02-kubelet.log"]; !strings.Contains(step, "$ systemctl start kubelet") ||
+		!strings.Contains(step, "failed") {
+		t.Errorf("wrong kubelet step log %s", step)
+	}
+	if log := files["task-task-1/task.log"]; log != testTaskLog {
+		t.Errorf("wrong task log %s", log)
+	}
+}
@@ -0,0 +1,44 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/storage"
+)
+
+// Diagnostic is output collected for a failed task outside of its steps,
+// e.g. the console output of the machine that never became reachable.
+type Diagnostic struct {
+	Name        string    `json:"name"`
+	Content     string    `json:"content"`
+	CollectedAt time.Time `json:"collectedAt"`
+}
+
+// AttachDiagnostic saves the diagnostic with the stored task, the previous
+// diagnostic with the same name is replaced.
+func AttachDiagnostic(ctx context.Context, repository storage.Interface, taskID string, d Diagnostic) error {
+	data, err := repository.Get(ctx, Prefix, taskID)
+	if err != nil {
+		return errors.Wrapf(err, "get task %s", taskID)
+	}
+
+	// the task isn't run, so runners of DeserializeTask aren't needed
+	t := &Task{}
+	if err = json.Unmarshal(data, t); err != nil {
+		return errors.Wrapf(err, "unmarshal task %s", taskID)
+	}
+	t.repository = repository
+
+	for i := range t.Diagnostics {
+		if t.Diagnostics[i].Name == d.Name {
+			t.Diagnostics[i] = d
+			return t.sync(ctx)
+		}
+	}
+	t.Diagnostics = append(t.Diagnostics, d)
+	return t.sync(ctx)
+}
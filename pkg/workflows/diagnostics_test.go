@@ -0,0 +1,39 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage/memory"
+	"github.com/supergiant/control/pkg/workflows/statuses"
+)
+
+func TestAttachDiagnostic(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewInMemoryRepository()
+	require.NoError(t, repo.Put(ctx, Prefix, "task-1", []byte(testTaskRecord)))
+
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, AttachDiagnostic(ctx, repo, "task-1", Diagnostic{Name: "console", Content: "booting", CollectedAt: now}))
+	require.NoError(t, AttachDiagnostic(ctx, repo, "task-1", Diagnostic{Name: "console", Content: "kernel panic", CollectedAt: now}))
+	require.NoError(t, AttachDiagnostic(ctx, repo, "task-1", Diagnostic{Name: "cloud-init", Content: "done", CollectedAt: now}))
+
+	data, err := repo.Get(ctx, Prefix, "task-1")
+	require.NoError(t, err)
+	task := Task{}
+	require.NoError(t, json.Unmarshal(data, &task))
+	require.Equal(t, statuses.Error, task.Status)
+	require.Equal(t, []Diagnostic{
+		{Name: "console", Content: "kernel panic", CollectedAt: now},
+		{Name: "cloud-init", Content: "done", CollectedAt: now},
+	}, task.Diagnostics)
+	require.NotContains(t, string(data), "s3cr3t")
+
+	err = AttachDiagnostic(ctx, repo, "task-2", Diagnostic{Name: "console"})
+	require.True(t, sgerrors.IsNotFound(err))
+}
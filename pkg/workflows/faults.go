@@ -0,0 +1,235 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/message"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// ErrInjected is returned by steps that fail because of a fault.
+var ErrInjected = errors.New("injected failure")
+
+// Fault fails or hangs invocations of a step, it verifies rollback, retry
+// and resume of workflows before they are relied on. Faults are injected
+// only in chaos mode.
+type Fault struct {
+	Step string `json:"step"`
+	// Invocation is the first faulty invocation of the step counting from
+	// the time the fault is set, it's the next one when 0.
+	Invocation int `json:"invocation,omitempty"`
+	// Times is a number of faulty invocations, it's 1 when 0 and every
+	// invocation after the first faulty one when negative.
+	Times int `json:"times,omitempty"`
+	// Hang delays faulty invocations for the duration, e.g. 10m, the step
+	// runs after that unless Fail is set.
+	Hang string `json:"hang,omitempty"`
+	Fail bool   `json:"fail,omitempty"`
+
+	// Invocations of the step since the fault has been set.
+	Invocations int `json:"invocations"`
+}
+
+// Validate checks the fault does something to a registered step.
+func (f *Fault) Validate() error {
+	if steps.GetStep(f.Step) == nil {
+		return errors.Errorf("unknown step %q", f.Step)
+	}
+	if f.Invocation < 0 {
+		return errors.New("invocation must not be negative")
+	}
+	if !f.Fail && f.Hang == "" {
+		return errors.New("fault must fail or hang the step")
+	}
+	if f.Hang != "" {
+		if d, err := time.ParseDuration(f.Hang); err != nil || d <= 0 {
+			return errors.New("hang must be a positive duration, e.g. 10m")
+		}
+	}
+	return nil
+}
+
+// faulty tells whether the nth invocation is affected by the fault.
+func (f *Fault) faulty(n int) bool {
+	first := f.Invocation
+	if first == 0 {
+		first = 1
+	}
+	times := f.Times
+	if times == 0 {
+		times = 1
+	}
+
+	if n < first {
+		return false
+	}
+	return times < 0 || n < first+times
+}
+
+var faults = struct {
+	sync.Mutex
+	enabled bool
+	byStep  map[string]*Fault
+}{
+	byStep: make(map[string]*Fault),
+}
+
+// EnableFaults turns the chaos mode on, faults aren't injected otherwise.
+func EnableFaults(enabled bool) {
+	faults.Lock()
+	defer faults.Unlock()
+
+	faults.enabled = enabled
+}
+
+// SetFault replaces the fault of the step, invocations are counted anew.
+func SetFault(f Fault) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+
+	faults.Lock()
+	defer faults.Unlock()
+
+	f.Invocations = 0
+	faults.byStep[f.Step] = &f
+	return nil
+}
+
+// RemoveFault removes the fault of the step.
+func RemoveFault(step string) error {
+	faults.Lock()
+	defer faults.Unlock()
+
+	if _, ok := faults.byStep[step]; !ok {
+		return sgerrors.ErrNotFound
+	}
+	delete(faults.byStep, step)
+	return nil
+}
+
+// ClearFaults removes faults of all steps.
+func ClearFaults() {
+	faults.Lock()
+	defer faults.Unlock()
+
+	faults.byStep = make(map[string]*Fault)
+}
+
+// Faults returns faults ordered by step.
+func Faults() []Fault {
+	faults.Lock()
+	defer faults.Unlock()
+
+	list := make([]Fault, 0, len(faults.byStep))
+	for _, f := range faults.byStep {
+		list = append(list, *f)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Step < list[j].Step
+	})
+	return list
+}
+
+// injectFault counts the invocation of the step and applies its fault, the
+// hang is cut short when the context is done, e.g. on shutdown.
+func injectFault(ctx context.Context, step string) error {
+	faults.Lock()
+	f, ok := faults.byStep[step]
+	if !faults.enabled || !ok {
+		faults.Unlock()
+		return nil
+	}
+	f.Invocations++
+	n := f.Invocations
+	fault := *f
+	faults.Unlock()
+
+	if !fault.faulty(n) {
+		return nil
+	}
+
+	if fault.Hang != "" {
+		hang, _ := time.ParseDuration(fault.Hang)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(hang):
+		}
+	}
+
+	if fault.Fail {
+		return errors.Wrapf(ErrInjected, "step %s invocation %d", step, n)
+	}
+	return nil
+}
+
+// FaultHandler manages faults of the chaos mode.
+type FaultHandler struct{}
+
+func NewFaultHandler() *FaultHandler {
+	return &FaultHandler{}
+}
+
+func (h *FaultHandler) Register(r *mux.Router) {
+	r.HandleFunc("/admin/faults", api.AdminOnly(h.List)).Methods(http.MethodGet)
+	r.HandleFunc("/admin/faults", api.AdminOnly(h.Clear)).Methods(http.MethodDelete)
+	r.HandleFunc("/admin/faults/{step}", api.AdminOnly(h.Set)).Methods(http.MethodPut)
+	r.HandleFunc("/admin/faults/{step}", api.AdminOnly(h.Remove)).Methods(http.MethodDelete)
+}
+
+// List returns faults with invocations of their steps.
+func (h *FaultHandler) List(w http.ResponseWriter, r *http.Request) {
+	if err := json.NewEncoder(w).Encode(Faults()); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// Set injects the fault into the step of the path.
+func (h *FaultHandler) Set(w http.ResponseWriter, r *http.Request) {
+	f := Fault{}
+	if err := json.NewDecoder(r.Body).Decode(&f); err != nil {
+		message.SendInvalidJSON(w, err)
+		return
+	}
+	f.Step = mux.Vars(r)["step"]
+
+	if err := f.Validate(); err != nil {
+		message.SendValidationFailed(w, err)
+		return
+	}
+	if err := SetFault(f); err != nil {
+		message.SendUnknownError(w, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(f); err != nil {
+		message.SendUnknownError(w, err)
+	}
+}
+
+// Remove stops injecting the fault into the step of the path.
+func (h *FaultHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	step := mux.Vars(r)["step"]
+	if err := RemoveFault(step); err != nil {
+		message.SendNotFound(w, step, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Clear removes all faults.
+func (h *FaultHandler) Clear(w http.ResponseWriter, r *http.Request) {
+	ClearFaults()
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,158 @@
+package workflows
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestFault_faulty(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		fault  Fault
+		faulty []bool
+	}{
+		{
+			name:   "next invocation",
+			fault:  Fault{},
+			faulty: []bool{true, false, false},
+		},
+		{
+			name:   "third invocation",
+			fault:  Fault{Invocation: 3},
+			faulty: []bool{false, false, true, false},
+		},
+		{
+			name:   "two times",
+			fault:  Fault{Invocation: 2, Times: 2},
+			faulty: []bool{false, true, true, false},
+		},
+		{
+			name:   "every invocation",
+			fault:  Fault{Invocation: 2, Times: -1},
+			faulty: []bool{false, true, true, true},
+		},
+	} {
+		for i, expected := range tc.faulty {
+			require.Equal(t, expected, tc.fault.faulty(i+1), "%s: invocation %d", tc.name, i+1)
+		}
+	}
+}
+
+func TestFault_Validate(t *testing.T) {
+	steps.RegisterStep("faultyStep", &MockStep{name: "faultyStep"})
+
+	require.NoError(t, (&Fault{Step: "faultyStep", Fail: true}).Validate())
+	require.NoError(t, (&Fault{Step: "faultyStep", Hang: "1m"}).Validate())
+	require.Error(t, (&Fault{Step: "unknown", Fail: true}).Validate())
+	require.Error(t, (&Fault{Step: "faultyStep"}).Validate())
+	require.Error(t, (&Fault{Step: "faultyStep", Hang: "forever"}).Validate())
+	require.Error(t, (&Fault{Step: "faultyStep", Fail: true, Invocation: -1}).Validate())
+}
+
+func TestInjectFault(t *testing.T) {
+	steps.RegisterStep("faultyStep", &MockStep{name: "faultyStep"})
+	defer ClearFaults()
+
+	require.NoError(t, SetFault(Fault{Step: "faultyStep", Fail: true}))
+
+	EnableFaults(false)
+	require.NoError(t, injectFault(context.Background(), "faultyStep"),
+		"faults aren't injected out of chaos mode")
+
+	EnableFaults(true)
+	defer EnableFaults(false)
+
+	err := injectFault(context.Background(), "faultyStep")
+	require.Equal(t, ErrInjected, errors.Cause(err))
+	require.NoError(t, injectFault(context.Background(), "faultyStep"))
+	require.NoError(t, injectFault(context.Background(), "otherStep"))
+	require.Equal(t, 2, Faults()[0].Invocations)
+
+	require.NoError(t, SetFault(Fault{Step: "faultyStep", Hang: "1h"}))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	require.Equal(t, context.DeadlineExceeded, injectFault(ctx, "faultyStep"),
+		"hang must end with the context")
+}
+
+func TestTaskRunInjectedFault(t *testing.T) {
+	s := &MockRepository{
+		storage: make(map[string][]byte),
+	}
+
+	step := &MockStep{name: "faultyStep"}
+	wf := []steps.Step{
+		&MockStep{name: "step1"},
+		step,
+	}
+	workflowMap = make(map[string]Workflow)
+	RegisterWorkFlow("mock", wf)
+	steps.RegisterStep(step.name, step)
+
+	EnableFaults(true)
+	defer EnableFaults(false)
+	defer ClearFaults()
+	require.NoError(t, SetFault(Fault{Step: step.name, Fail: true}))
+
+	task, err := NewTask(&steps.Config{}, "mock", s)
+	require.NoError(t, err)
+
+	err = <-task.Run(context.Background(), steps.Config{}, &bufferCloser{})
+	require.Equal(t, ErrInjected, errors.Cause(err))
+	require.True(t, step.rollback, "faulty step must be rolled back")
+	require.Equal(t, 0, step.counter, "faulty step mustn't run")
+
+	err = <-task.Run(context.Background(), steps.Config{}, &bufferCloser{})
+	require.NoError(t, err, "restarted task must succeed")
+	require.Equal(t, 1, step.counter)
+}
+
+func TestFaultHandler(t *testing.T) {
+	steps.RegisterStep("faultyStep", &MockStep{name: "faultyStep"})
+	defer ClearFaults()
+
+	h := NewFaultHandler()
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/faults", h.List).Methods(http.MethodGet)
+	router.HandleFunc("/admin/faults", h.Clear).Methods(http.MethodDelete)
+	router.HandleFunc("/admin/faults/{step}", h.Set).Methods(http.MethodPut)
+	router.HandleFunc("/admin/faults/{step}", h.Remove).Methods(http.MethodDelete)
+
+	for _, tc := range []struct {
+		method   string
+		path     string
+		body     string
+		expected int
+	}{
+		{http.MethodPut, "/admin/faults/faultyStep", `{"invocation": 2, "fail": true}`, http.StatusOK},
+		{http.MethodPut, "/admin/faults/unknown", `{"fail": true}`, http.StatusBadRequest},
+		{http.MethodPut, "/admin/faults/faultyStep", `{`, http.StatusBadRequest},
+		{http.MethodGet, "/admin/faults", "", http.StatusOK},
+		{http.MethodDelete, "/admin/faults/faultyStep", "", http.StatusNoContent},
+		{http.MethodDelete, "/admin/faults/faultyStep", "", http.StatusNotFound},
+		{http.MethodDelete, "/admin/faults", "", http.StatusNoContent},
+	} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body))
+		router.ServeHTTP(rec, req)
+		require.Equal(t, tc.expected, rec.Code, "%s %s", tc.method, tc.path)
+
+		if tc.method == http.MethodGet {
+			faults := make([]Fault, 0)
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&faults))
+			require.Len(t, faults, 1)
+			require.Equal(t, Fault{Step: "faultyStep", Invocation: 2, Fail: true}, faults[0])
+		}
+	}
+}
@@ -2,7 +2,7 @@ package workflows
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
 	"html/template"
 	"io"
 	"net/http"
@@ -14,13 +14,17 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/hpcloud/tail"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
 	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/redact"
 	"github.com/supergiant/control/pkg/runner"
 	"github.com/supergiant/control/pkg/runner/ssh"
+	"github.com/supergiant/control/pkg/sgerrors"
 	"github.com/supergiant/control/pkg/storage"
 	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/statuses"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -31,8 +35,10 @@ type cloudAccountGetter interface {
 type TaskHandler struct {
 	runnerFactory func(config ssh.Config) (runner.Runner, error)
 	getTail       func(string) (*tail.Tail, error)
+	readLog       func(string) ([]byte, error)
 
 	cloudAccGetter cloudAccountGetter
+	kubes          KubeGetter
 	repository     storage.Interface
 	getWriter      func(string) (io.WriteCloser, error)
 }
@@ -58,34 +64,42 @@ func NewTaskHandler(repository storage.Interface, runnerFactory func(config ssh.
 		repository:     repository,
 		cloudAccGetter: getter,
 		getWriter:      util.GetWriterFunc(logDir),
-		getTail: func(id string) (*tail.Tail, error) {
-			t, err := tail.TailFile(path.Join(logDir, util.MakeFileName(id)),
-				tail.Config{
-					Follow:    true,
-					MustExist: true,
-					Location: &tail.SeekInfo{
-						Offset: 0,
-						Whence: io.SeekStart,
-					},
-					Logger:      tail.DiscardingLogger,
-					MaxLineSize: 160,
-				})
-
-			if err != nil {
-				return nil, err
-			}
+		readLog:        readLogFunc(logDir),
+		getTail:        TailLogFunc(logDir),
+	}
+}
 
-			return t, nil
-		},
+// TailLogFunc returns a function that follows the log of a task from its
+// beginning, the log must exist.
+func TailLogFunc(logDir string) func(string) (*tail.Tail, error) {
+	return func(id string) (*tail.Tail, error) {
+		t, err := tail.TailFile(path.Join(logDir, util.MakeFileName(id)),
+			tail.Config{
+				Follow:    true,
+				MustExist: true,
+				Location: &tail.SeekInfo{
+					Offset: 0,
+					Whence: io.SeekStart,
+				},
+				Logger:      tail.DiscardingLogger,
+				MaxLineSize: 160,
+			})
+
+		if err != nil {
+			return nil, err
+		}
+
+		return t, nil
 	}
 }
 
 func (h *TaskHandler) Register(m *mux.Router) {
-	m.HandleFunc("/tasks/{id}", h.GetTask).Methods(http.MethodGet)
+	m.HandleFunc("/tasks/{id}", h.scoped(h.GetTask)).Methods(http.MethodGet)
 	m.HandleFunc("/tasks/{id}/restart",
-		h.RestartTask).Methods(http.MethodPost)
-	m.HandleFunc("/tasks/{id}/logs", h.StreamLogs).Methods(http.MethodGet)
-	m.HandleFunc("/tasks/{id}/logs/ws", h.GetLogs).Methods(http.MethodGet)
+		h.scoped(h.RestartTask)).Methods(http.MethodPost)
+	m.HandleFunc("/tasks/{id}/logs", h.scoped(h.StreamLogs)).Methods(http.MethodGet)
+	m.HandleFunc("/tasks/{id}/logs/ws", h.scoped(h.GetLogs)).Methods(http.MethodGet)
+	m.HandleFunc("/tasks/{id}/logs/archive", h.scoped(h.GetLogsArchive)).Methods(http.MethodGet)
 }
 
 func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
@@ -104,6 +118,20 @@ func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	data, err = withProgress(data, time.Now())
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err = redact.JSON(data)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	w.Write(data)
 }
 
@@ -125,25 +153,122 @@ func (h *TaskHandler) RestartTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	task, err := DeserializeTask(data, h.repository)
+	task, err := h.restore(r.Context(), data)
 
 	if err != nil {
-		logrus.Debugf("error deserializing task %s %v", id, err)
+		logrus.Debugf("error restoring task %s %v", id, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	fileName := util.MakeFileName(id)
-	writer, err := h.getWriter(fileName)
+	if err = h.start(task); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logrus.Errorf("Start task %s %v", id, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ResumeInterrupted runs tasks that were interrupted by the previous
+// shutdown from their first step that hasn't succeeded, it returns the
+// number of resumed tasks.
+func (h *TaskHandler) ResumeInterrupted(ctx context.Context) (int, error) {
+	records, err := h.repository.GetAll(ctx, Prefix)
 
 	if err != nil {
-		http.Error(w, fmt.Sprintf("get writer %v", err), http.StatusInternalServerError)
-		logrus.Errorf("Get writer %v", err)
-		return
+		return 0, errors.Wrap(err, "list tasks")
+	}
+
+	resumed := 0
+	for _, data := range records {
+		var state struct {
+			ID     string          `json:"id"`
+			Status statuses.Status `json:"status"`
+		}
+
+		if err := json.Unmarshal(data, &state); err != nil || state.Status != statuses.Interrupted {
+			continue
+		}
+
+		task, err := h.restore(ctx, data)
+
+		if err != nil {
+			logrus.Errorf("resume task %s: %v", state.ID, err)
+			continue
+		}
+
+		if err = h.start(task); err != nil {
+			logrus.Errorf("resume task %s: %v", state.ID, err)
+			continue
+		}
+		resumed++
+	}
+
+	return resumed, nil
+}
+
+// restore deserializes the stored task with credentials of its cloud account.
+func (h *TaskHandler) restore(ctx context.Context, data []byte) (*Task, error) {
+	task, err := DeserializeTask(data, h.repository)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "deserialize task")
+	}
+
+	// credentials aren't stored with the task
+	if task.Config != nil && task.Config.CloudAccountName != "" && h.cloudAccGetter != nil {
+		acc, err := h.cloudAccGetter.Get(ctx, task.Config.CloudAccountName)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "get cloud account")
+		}
+
+		if err = util.FillCloudAccountCredentials(acc, task.Config); err != nil {
+			return nil, errors.Wrap(err, "fill cloud account")
+		}
+	}
+
+	if task.Config != nil {
+		if err = h.fillKeys(ctx, task); err != nil {
+			return nil, err
+		}
+	}
+
+	return task, nil
+}
+
+// fillKeys sets keys of the task from its kube.
+func (h *TaskHandler) fillKeys(ctx context.Context, task *Task) error {
+	k := &model.Kube{}
+	if id := task.Config.Kube.ID; h.kubes != nil && id != "" {
+		current, err := h.kubes.Get(ctx, id)
+		if err != nil && !sgerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "get kube %s", id)
+		}
+		if err == nil {
+			k = current
+		}
+	}
+
+	return errors.Wrap(FillKeys(task, k), "fill keys")
+}
+
+// start runs the task in background, its output is appended to its log.
+func (h *TaskHandler) start(task *Task) error {
+	if task.Config == nil {
+		return errors.Errorf("task %s has no config", task.ID)
+	}
+
+	writer, err := h.getWriter(util.MakeFileName(task.ID))
+
+	if err != nil {
+		return errors.Wrap(err, "get writer")
 	}
 
 	task.Run(context.Background(), *task.Config, writer)
-	w.WriteHeader(http.StatusAccepted)
+
+	return nil
 }
 
 // NOTE(stgleb): This is made for testing purposes and example, remove when UI is done.
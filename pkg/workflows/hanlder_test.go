@@ -9,16 +9,20 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/hpcloud/tail"
 	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
 
 	"github.com/supergiant/control/pkg/clouds"
 	"github.com/supergiant/control/pkg/model"
 	"github.com/supergiant/control/pkg/runner"
 	"github.com/supergiant/control/pkg/runner/ssh"
+	"github.com/supergiant/control/pkg/storage/memory"
 	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/statuses"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -179,6 +183,64 @@ dZM6MSCYh9kcT0pi2FPmY9iXba9kx4XAnf+0YB5xCz9QSMk4W5xSTBs=
 	}
 }
 
+func TestTaskHandler_ResumeInterrupted(t *testing.T) {
+	repository := memory.NewInMemoryRepository()
+	h := TaskHandler{
+		repository: repository,
+		getWriter: func(id string) (io.WriteCloser, error) {
+			return &bufferCloser{}, nil
+		},
+	}
+
+	done := &MockStep{name: "done"}
+	next := &MockStep{name: "next"}
+	RegisterWorkFlow("resumed", []steps.Step{done, next})
+
+	for id, status := range map[string]statuses.Status{
+		"interrupted": statuses.Interrupted,
+		"failed":      statuses.Error,
+	} {
+		data, err := json.Marshal(&Task{
+			ID:     id,
+			Type:   "resumed",
+			Status: status,
+			Config: &steps.Config{},
+			StepStatuses: []StepStatus{
+				{Status: statuses.Success, StepName: "done"},
+				{Status: statuses.Todo, StepName: "next"},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		repository.Put(context.Background(), Prefix, id, data)
+	}
+
+	n, err := h.ResumeInterrupted(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("expected one resumed task, actual %d %v", n, err)
+	}
+
+	deadline := time.Now().Add(time.Second * 5)
+	for {
+		data, _ := repository.Get(context.Background(), Prefix, "interrupted")
+		task := &Task{}
+		json.Unmarshal(data, task)
+		if task.Status == statuses.Success {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("task hasn't been resumed, status %s", task.Status)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if done.counter != 0 || next.counter != 1 {
+		t.Errorf("task must be resumed from the step that hasn't succeeded, runs %d %d",
+			done.counter, next.counter)
+	}
+}
+
 func TestTaskHandler_GetLogs(t *testing.T) {
 	rec := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodGet, "/tasks/abcd/logs/ws", nil)
@@ -260,3 +322,33 @@ func TestNewTaskHandler(t *testing.T) {
 		t.Errorf("Handler must not be nil")
 	}
 }
+
+func TestTaskHandler_restoreFillsKeys(t *testing.T) {
+	repository := &MockRepository{
+		storage: make(map[string][]byte),
+	}
+
+	task := newTask("test", nil, repository)
+	task.Config = &steps.Config{}
+	task.Config.Kube.ID = "kube-1"
+	task.Config.Kube.SSHConfig.BootstrapPrivateKey = "old private key"
+	task.Config.NodeToken = "abcdef.0123456789abcdef"
+	task.Config.SSHKeyRotationConfig.PrivateKey = "new private key"
+	require.NoError(t, task.sync(context.Background()))
+
+	data := string(repository.storage[Prefix+task.ID])
+	for _, secret := range []string{"old private key", "new private key", "abcdef.0123456789abcdef"} {
+		require.NotContains(t, data, secret)
+	}
+
+	h := &TaskHandler{repository: repository}
+	h.SetKubes(fakeKubes{"kube-1": {
+		ID:        "kube-1",
+		SSHConfig: model.SSHConfig{BootstrapPrivateKey: "current private key"},
+	}})
+	restored, err := h.restore(context.Background(), repository.storage[Prefix+task.ID])
+	require.NoError(t, err)
+	require.Equal(t, "current private key", restored.Config.Kube.SSHConfig.BootstrapPrivateKey)
+	require.Empty(t, restored.Config.NodeToken)
+	require.Empty(t, restored.Config.SSHKeyRotationConfig.PrivateKey)
+}
@@ -0,0 +1,220 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage"
+	"github.com/supergiant/control/pkg/workflows/statuses"
+)
+
+const (
+	// StepStatsPrefix keeps average durations of workflow steps.
+	StepStatsPrefix = "step_stats"
+
+	// averageWindow is a number of the last runs an average mostly depends
+	// on, so it follows steps that get faster or slower over time.
+	averageWindow = 20
+)
+
+// Progress of the task, the ETA is estimated from average durations of
+// the steps when they are known.
+type Progress struct {
+	// Step is a 1-based index of the current step.
+	Step       int `json:"step"`
+	TotalSteps int `json:"totalSteps"`
+	Percent    int `json:"percent"`
+	// RemainingSeconds and ETA are set for executing tasks.
+	RemainingSeconds int64      `json:"remainingSeconds,omitempty"`
+	ETA              *time.Time `json:"eta,omitempty"`
+}
+
+type stepStats struct {
+	Runs    int64         `json:"runs"`
+	Average time.Duration `json:"average"`
+}
+
+var (
+	statsMu sync.RWMutex
+	// statsRepository keeps durations of steps, they aren't tracked until it's set.
+	statsRepository storage.Interface
+)
+
+// InitStepStats sets the storage of average durations of workflow steps.
+func InitStepStats(repository storage.Interface) {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	statsRepository = repository
+}
+
+func stepStatsRepository() storage.Interface {
+	statsMu.RLock()
+	defer statsMu.RUnlock()
+
+	return statsRepository
+}
+
+func stepStatsKey(taskType, step string) string {
+	return taskType + ":" + step
+}
+
+func getStepStats(ctx context.Context, repo storage.Interface, taskType, step string) (*stepStats, error) {
+	stats := &stepStats{}
+	data, err := repo.Get(ctx, StepStatsPrefix, stepStatsKey(taskType, step))
+	if err != nil {
+		if sgerrors.IsNotFound(err) {
+			return stats, nil
+		}
+		return nil, err
+	}
+	if len(data) == 0 {
+		return stats, nil
+	}
+
+	if err = json.Unmarshal(data, stats); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal stats of step %s", step)
+	}
+	return stats, nil
+}
+
+// recordDuration adds a duration of a successful step run to its average.
+func recordDuration(ctx context.Context, taskType, step string, d time.Duration) error {
+	repo := stepStatsRepository()
+	if repo == nil {
+		return nil
+	}
+
+	stats, err := getStepStats(ctx, repo, taskType, step)
+	if err != nil {
+		return err
+	}
+
+	stats.Runs++
+	n := stats.Runs
+	if n > averageWindow {
+		n = averageWindow
+	}
+	stats.Average += (d - stats.Average) / time.Duration(n)
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	return repo.Put(ctx, StepStatsPrefix, stepStatsKey(taskType, step), data)
+}
+
+// loadAverages sets average durations of the task steps.
+func (t *Task) loadAverages(ctx context.Context) error {
+	repo := stepStatsRepository()
+	if repo == nil {
+		return nil
+	}
+
+	for i := range t.StepStatuses {
+		stats, err := getStepStats(ctx, repo, t.Type, t.StepStatuses[i].StepName)
+		if err != nil {
+			return err
+		}
+		t.StepStatuses[i].AverageSeconds = stats.Average.Seconds()
+	}
+	return nil
+}
+
+// progress estimates the task progress at the time. Steps that have never
+// finished are assumed to take the mean time of the known ones.
+func (t *Task) progress(now time.Time) Progress {
+	p := Progress{TotalSteps: len(t.StepStatuses)}
+	if p.TotalSteps == 0 {
+		return p
+	}
+
+	var known, total, done float64
+	var knownSteps int
+	for _, s := range t.StepStatuses {
+		if s.AverageSeconds > 0 {
+			known += s.AverageSeconds
+			knownSteps++
+		}
+	}
+	fallback := 1.0
+	if knownSteps > 0 {
+		fallback = known / float64(knownSteps)
+	}
+
+	current := -1
+	var remaining float64
+	for i, s := range t.StepStatuses {
+		expected := s.AverageSeconds
+		if expected <= 0 {
+			expected = fallback
+		}
+		total += expected
+
+		switch {
+		case s.Status == statuses.Success:
+			done += expected
+		case current < 0:
+			current = i
+			elapsed := 0.0
+			if s.Status == statuses.Executing && s.StartedAt != nil {
+				elapsed = now.Sub(*s.StartedAt).Seconds()
+			}
+			if elapsed > expected {
+				// the step is late, it's about to finish
+				elapsed = expected
+			}
+			done += elapsed
+			remaining += expected - elapsed
+		default:
+			remaining += expected
+		}
+	}
+
+	p.Step = current + 1
+	if current < 0 {
+		p.Step = p.TotalSteps
+	}
+	p.Percent = int(done * 100 / total)
+
+	if t.Status == statuses.Executing && knownSteps > 0 {
+		p.RemainingSeconds = int64(remaining)
+		eta := now.Add(time.Duration(remaining * float64(time.Second))).Truncate(time.Second)
+		p.ETA = &eta
+	}
+	return p
+}
+
+// withProgress updates the progress of the stored task to the time, so it
+// moves on within long steps. The rest of the task is kept as is.
+func withProgress(data []byte, now time.Time) ([]byte, error) {
+	task := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &task); err != nil {
+		return nil, errors.Wrap(err, "unmarshal task")
+	}
+
+	t := &Task{}
+	if raw, ok := task["status"]; ok {
+		if err := json.Unmarshal(raw, &t.Status); err != nil {
+			return nil, errors.Wrap(err, "unmarshal task status")
+		}
+	}
+	if raw, ok := task["stepsStatuses"]; ok {
+		if err := json.Unmarshal(raw, &t.StepStatuses); err != nil {
+			return nil, errors.Wrap(err, "unmarshal step statuses")
+		}
+	}
+
+	progress, err := json.Marshal(t.progress(now))
+	if err != nil {
+		return nil, err
+	}
+	task["progress"] = progress
+
+	return json.Marshal(task)
+}
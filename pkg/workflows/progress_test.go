@@ -0,0 +1,100 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/workflows/statuses"
+)
+
+func TestTask_progress(t *testing.T) {
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	started := now.Add(-time.Minute)
+
+	task := &Task{
+		Status: statuses.Executing,
+		StepStatuses: []StepStatus{
+			{Status: statuses.Success, StepName: "ssh", AverageSeconds: 60},
+			{Status: statuses.Executing, StepName: "docker", AverageSeconds: 180, StartedAt: &started},
+			{Status: statuses.Todo, StepName: "kubelet"},
+			{Status: statuses.Todo, StepName: "kubeadm", AverageSeconds: 120},
+		},
+	}
+
+	p := task.progress(now)
+	require.Equal(t, 2, p.Step)
+	require.Equal(t, 4, p.TotalSteps)
+	// kubelet is expected to take the mean of known steps, 120s,
+	// 60s of ssh and 60s of docker of 480s are done
+	require.Equal(t, 25, p.Percent)
+	require.Equal(t, int64(360), p.RemainingSeconds)
+	require.NotNil(t, p.ETA)
+	require.Equal(t, now.Add(6*time.Minute), *p.ETA)
+
+	task.Status = statuses.Success
+	for i := range task.StepStatuses {
+		task.StepStatuses[i].Status = statuses.Success
+	}
+	p = task.progress(now)
+	require.Equal(t, 4, p.Step)
+	require.Equal(t, 100, p.Percent)
+	require.Nil(t, p.ETA)
+}
+
+func TestTask_progressUnknownDurations(t *testing.T) {
+	task := &Task{
+		Status: statuses.Executing,
+		StepStatuses: []StepStatus{
+			{Status: statuses.Success},
+			{Status: statuses.Executing},
+			{Status: statuses.Todo},
+			{Status: statuses.Todo},
+		},
+	}
+
+	p := task.progress(time.Now())
+	require.Equal(t, 2, p.Step)
+	require.Equal(t, 25, p.Percent)
+	require.Nil(t, p.ETA, "ETA can't be estimated without durations of steps")
+}
+
+func TestRecordDuration(t *testing.T) {
+	repository := &MockRepository{storage: map[string][]byte{}}
+	InitStepStats(repository)
+	defer InitStepStats(nil)
+
+	ctx := context.Background()
+	require.NoError(t, recordDuration(ctx, "master", "docker", time.Minute))
+	require.NoError(t, recordDuration(ctx, "master", "docker", 3*time.Minute))
+
+	task := &Task{Type: "master", StepStatuses: []StepStatus{{StepName: "docker"}, {StepName: "kubelet"}}}
+	require.NoError(t, task.loadAverages(ctx))
+	require.Equal(t, float64(120), task.StepStatuses[0].AverageSeconds)
+	require.Zero(t, task.StepStatuses[1].AverageSeconds)
+}
+
+func TestWithProgress(t *testing.T) {
+	now := time.Now()
+	started := now.Add(-time.Minute)
+	data, err := json.Marshal(&Task{
+		ID:     "1234",
+		Status: statuses.Executing,
+		StepStatuses: []StepStatus{
+			{Status: statuses.Executing, AverageSeconds: 240, StartedAt: &started},
+		},
+	})
+	require.NoError(t, err)
+
+	data, err = withProgress(data, now)
+	require.NoError(t, err)
+
+	task := &Task{}
+	require.NoError(t, json.Unmarshal(data, task))
+	require.Equal(t, "1234", task.ID)
+	require.Equal(t, 25, task.Progress.Percent)
+	require.Equal(t, int64(180), task.Progress.RemainingSeconds)
+}
@@ -0,0 +1,77 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+)
+
+// KubeGetter gets kubes that tasks run for.
+type KubeGetter interface {
+	Get(context.Context, string) (*model.Kube, error)
+}
+
+// InScope reports whether the task is in the scope of the context, tasks
+// belong to the teams of their kubes. Tasks of deleted kubes are scoped by
+// the kube they were created for.
+func InScope(ctx context.Context, kubes KubeGetter, task *Task) (bool, error) {
+	scope := api.ScopeFrom(ctx)
+	if scope.Admin || task.Config == nil {
+		return true, nil
+	}
+
+	k := &task.Config.Kube
+	if kubes != nil && k.ID != "" {
+		current, err := kubes.Get(ctx, k.ID)
+		if err != nil && !sgerrors.IsNotFound(err) {
+			return false, err
+		}
+		if err == nil {
+			k = current
+		}
+	}
+
+	return scope.AllowsLabeled(k.Team, k.Labels), nil
+}
+
+// SetKubes scopes tasks by teams of their kubes, keys of restarted tasks
+// are filled from them.
+func (h *TaskHandler) SetKubes(kubes KubeGetter) {
+	h.kubes = kubes
+}
+
+// scoped hides tasks of kubes of other teams, they are not found for users
+// that aren't members of their teams.
+func (h *TaskHandler) scoped(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if api.ScopeFrom(r.Context()).Admin {
+			next(w, r)
+			return
+		}
+
+		ok := false
+		data, err := h.repository.Get(r.Context(), Prefix, mux.Vars(r)["id"])
+		if err == nil {
+			task := &Task{}
+			if err = json.Unmarshal(data, task); err == nil {
+				ok, err = InScope(r.Context(), h.kubes, task)
+			}
+		}
+		if err != nil && !sgerrors.IsNotFound(err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,80 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/api"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/storage/memory"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type fakeKubes map[string]*model.Kube
+
+func (f fakeKubes) Get(ctx context.Context, id string) (*model.Kube, error) {
+	if k, ok := f[id]; ok {
+		return k, nil
+	}
+	return nil, sgerrors.ErrNotFound
+}
+
+func TestInScope(t *testing.T) {
+	kubes := fakeKubes{"kube-1": {ID: "kube-1", Team: "b"}}
+	ctx := api.WithScope(context.Background(), api.Scope{Teams: []string{"a"}})
+
+	for _, tc := range []struct {
+		name     string
+		kube     model.Kube
+		expected bool
+	}{
+		{"kube of other team", model.Kube{ID: "kube-1", Team: "a"}, false},
+		{"deleted kube of team", model.Kube{ID: "kube-2", Team: "a"}, true},
+		{"deleted kube of other team", model.Kube{ID: "kube-2", Team: "b"}, false},
+	} {
+		ok, err := InScope(ctx, kubes, &Task{Config: &steps.Config{Kube: tc.kube}})
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expected, ok, tc.name)
+	}
+
+	ok, err := InScope(context.Background(), kubes, &Task{Config: &steps.Config{Kube: model.Kube{ID: "kube-1"}}})
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestTaskHandler_scoped(t *testing.T) {
+	repo := memory.NewInMemoryRepository()
+	data, err := json.Marshal(&Task{ID: "task-1", Config: &steps.Config{Kube: model.Kube{ID: "kube-1"}}})
+	require.NoError(t, err)
+	require.NoError(t, repo.Put(context.Background(), Prefix, "task-1", data))
+
+	h := &TaskHandler{repository: repo}
+	h.SetKubes(fakeKubes{"kube-1": {ID: "kube-1", Team: "b"}})
+	router := mux.NewRouter()
+	router.HandleFunc("/tasks/{id}", h.scoped(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, tc := range []struct {
+		name           string
+		id             string
+		scope          api.Scope
+		expectedStatus int
+	}{
+		{"team", "task-1", api.Scope{Teams: []string{"b"}}, http.StatusOK},
+		{"other team", "task-1", api.Scope{Teams: []string{"a"}}, http.StatusNotFound},
+		{"missing task", "task-2", api.Scope{Teams: []string{"b"}}, http.StatusNotFound},
+		{"admin", "task-2", api.Scope{Admin: true}, http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/tasks/"+tc.id, nil)
+		req = req.WithContext(api.WithScope(req.Context(), tc.scope))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		require.Equal(t, tc.expectedStatus, rr.Code, tc.name)
+	}
+}
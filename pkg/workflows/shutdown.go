@@ -0,0 +1,126 @@
+package workflows
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// cancelGrace is how long cancelled steps have to return and persist their state.
+const cancelGrace = time.Second * 30
+
+var (
+	// ErrShuttingDown is returned for tasks started while control shuts down.
+	ErrShuttingDown = errors.New("control is shutting down")
+	// ErrInterrupted is returned for tasks stopped by shutdown, they are resumable.
+	ErrInterrupted = errors.New("task has been interrupted by shutdown")
+)
+
+// IsInterrupted reports whether the task has been stopped by shutdown.
+func IsInterrupted(err error) bool {
+	return errors.Cause(err) == ErrInterrupted
+}
+
+// drainer tracks running tasks to let them reach a checkpoint on shutdown,
+// checkpoints are boundaries between steps.
+type drainer struct {
+	m        sync.Mutex
+	draining bool
+	running  sync.WaitGroup
+
+	// ctx is cancelled when the shutdown deadline is exceeded
+	ctx    context.Context
+	cancel func()
+}
+
+var running = newDrainer()
+
+func newDrainer() *drainer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &drainer{
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// begin registers a task, it fails once shutdown has started.
+func (d *drainer) begin() bool {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if d.draining {
+		return false
+	}
+	d.running.Add(1)
+
+	return true
+}
+
+func (d *drainer) done() {
+	d.running.Done()
+}
+
+func (d *drainer) isDraining() bool {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	return d.draining
+}
+
+// context returns a copy of ctx that is also cancelled when the shutdown
+// deadline is exceeded, cancel releases it.
+func (d *drainer) context(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-d.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
+
+func (d *drainer) shutdown(ctx context.Context) error {
+	d.m.Lock()
+	d.draining = true
+	d.m.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		d.running.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+	}
+
+	// steps that are still running are cancelled and their tasks are
+	// interrupted at the step too
+	d.cancel()
+	select {
+	case <-stopped:
+		return nil
+	case <-time.After(cancelGrace):
+		return errors.New("tasks haven't stopped after cancellation")
+	}
+}
+
+// ShuttingDown reports whether shutdown has started, tasks aren't started
+// anymore and running ones are interrupted.
+func ShuttingDown() bool {
+	return running.isDraining()
+}
+
+// Shutdown stops starting new tasks and waits until running ones reach
+// the next step, their state is persisted as interrupted. Steps that
+// don't finish by the deadline of ctx are cancelled.
+func Shutdown(ctx context.Context) error {
+	return running.shutdown(ctx)
+}
@@ -0,0 +1,118 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/workflows/statuses"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type blockingStep struct {
+	MockStep
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingStep) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	close(s.started)
+	select {
+	case <-s.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func newBlockingStep(name string) *blockingStep {
+	return &blockingStep{
+		MockStep: MockStep{name: name},
+		started:  make(chan struct{}),
+		release:  make(chan struct{}),
+	}
+}
+
+func waitDraining(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if running.isDraining() {
+			return
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	t.Fatal("shutdown hasn't started")
+}
+
+func TestShutdownCheckpoint(t *testing.T) {
+	running = newDrainer()
+	defer func() { running = newDrainer() }()
+
+	s := &MockRepository{
+		storage: make(map[string][]byte),
+	}
+	step1 := newBlockingStep("step1")
+	step2 := &MockStep{name: "step2"}
+	workflowMap = make(map[string]Workflow)
+	RegisterWorkFlow("mock", []steps.Step{step1, step2})
+	task, err := NewTask(&steps.Config{}, "mock", s)
+	require.NoError(t, err)
+
+	errChan := task.Run(context.Background(), steps.Config{}, &bufferCloser{})
+	<-step1.started
+
+	stopped := make(chan error)
+	go func() {
+		stopped <- Shutdown(context.Background())
+	}()
+	waitDraining(t)
+
+	// the running step finishes, the next one isn't started
+	close(step1.release)
+	require.True(t, IsInterrupted(<-errChan))
+	require.NoError(t, <-stopped)
+	require.Equal(t, 0, step2.counter)
+
+	saved := &Task{}
+	require.NoError(t, json.Unmarshal(s.storage[Prefix+task.ID], saved))
+	require.Equal(t, statuses.Interrupted, saved.Status)
+	require.Equal(t, statuses.Success, saved.StepStatuses[0].Status)
+	require.Equal(t, statuses.Todo, saved.StepStatuses[1].Status)
+
+	// new tasks aren't started
+	task, err = NewTask(&steps.Config{}, "mock", s)
+	require.NoError(t, err)
+	require.Equal(t, ErrShuttingDown, <-task.Run(context.Background(), steps.Config{}, &bufferCloser{}))
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	running = newDrainer()
+	defer func() { running = newDrainer() }()
+
+	s := &MockRepository{
+		storage: make(map[string][]byte),
+	}
+	step := newBlockingStep("step1")
+	workflowMap = make(map[string]Workflow)
+	RegisterWorkFlow("mock", []steps.Step{step})
+	task, err := NewTask(&steps.Config{}, "mock", s)
+	require.NoError(t, err)
+
+	errChan := task.Run(context.Background(), steps.Config{}, &bufferCloser{})
+	<-step.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	require.NoError(t, Shutdown(ctx))
+	require.True(t, IsInterrupted(<-errChan))
+
+	// the cancelled step is run again on resume
+	saved := &Task{}
+	require.NoError(t, json.Unmarshal(s.storage[Prefix+task.ID], saved))
+	require.Equal(t, statuses.Interrupted, saved.Status)
+	require.Equal(t, statuses.Todo, saved.StepStatuses[0].Status)
+	require.False(t, step.rollback)
+}
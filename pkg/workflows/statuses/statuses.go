@@ -8,4 +8,7 @@ const (
 	Success   Status = "success"
 	Error     Status = "error"
 	Cancelled Status = "cancelled"
+	// Interrupted tasks were stopped by shutdown of control between
+	// steps, they are resumed from the first step that hasn't succeeded.
+	Interrupted Status = "interrupted"
 )
@@ -0,0 +1,150 @@
+package amazon
+
+import (
+	"context"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sglog"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const StepApplyFirewallRules = "aws_apply_firewall_rules"
+
+const (
+	errCodeDuplicatePermission = "InvalidPermission.Duplicate"
+	errCodePermissionNotFound  = "InvalidPermission.NotFound"
+)
+
+type firewallService interface {
+	AuthorizeSecurityGroupIngressWithContext(aws.Context, *ec2.AuthorizeSecurityGroupIngressInput, ...request.Option) (*ec2.AuthorizeSecurityGroupIngressOutput, error)
+	RevokeSecurityGroupIngressWithContext(aws.Context, *ec2.RevokeSecurityGroupIngressInput, ...request.Option) (*ec2.RevokeSecurityGroupIngressOutput, error)
+}
+
+// ApplyFirewallRulesStep authorizes and revokes ingress rules of the masters
+// and nodes security groups. Rules that are already in the desired state are
+// skipped, so the step can be repeated.
+type ApplyFirewallRulesStep struct {
+	getSvc func(steps.AWSConfig) (firewallService, error)
+}
+
+// InitApplyFirewallRules adds the step to the registry
+func InitApplyFirewallRules(fn GetEC2Fn) {
+	steps.RegisterStep(StepApplyFirewallRules, NewApplyFirewallRulesStep(fn))
+}
+
+func NewApplyFirewallRulesStep(fn GetEC2Fn) *ApplyFirewallRulesStep {
+	return &ApplyFirewallRulesStep{
+		getSvc: func(cfg steps.AWSConfig) (firewallService, error) {
+			EC2, err := fn(cfg)
+			if err != nil {
+				return nil, errors.Wrap(ErrAuthorization, err.Error())
+			}
+
+			return EC2, nil
+		},
+	}
+}
+
+func (s *ApplyFirewallRulesStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
+	log := util.GetLogger(w)
+
+	svc, err := s.getSvc(cfg.AWSConfig)
+	if err != nil {
+		sglog.FromContext(ctx).Errorf("[%s] - failed to authorize in AWS: %v", s.Name(), err)
+		return errors.Wrap(err, StepApplyFirewallRules)
+	}
+
+	for _, rule := range cfg.FirewallConfig.Revoke {
+		groupID, err := securityGroupOf(cfg.AWSConfig, rule.Group)
+		if err != nil {
+			return errors.Wrapf(err, "%s revoke %s", StepApplyFirewallRules, rule.Name)
+		}
+
+		_, err = svc.RevokeSecurityGroupIngressWithContext(ctx, &ec2.RevokeSecurityGroupIngressInput{
+			GroupId:       aws.String(groupID),
+			IpPermissions: []*ec2.IpPermission{ipPermission(rule)},
+		})
+		if err != nil && !isAWSCode(err, errCodePermissionNotFound) {
+			return errors.Wrapf(err, "%s revoke %s", StepApplyFirewallRules, rule.Name)
+		}
+		log.Infof("[%s] - rule %s has been revoked in %s", s.Name(), rule.Name, groupID)
+	}
+
+	for _, rule := range cfg.FirewallConfig.Authorize {
+		groupID, err := securityGroupOf(cfg.AWSConfig, rule.Group)
+		if err != nil {
+			return errors.Wrapf(err, "%s authorize %s", StepApplyFirewallRules, rule.Name)
+		}
+
+		_, err = svc.AuthorizeSecurityGroupIngressWithContext(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
+			GroupId:       aws.String(groupID),
+			IpPermissions: []*ec2.IpPermission{ipPermission(rule)},
+		})
+		if err != nil && !isAWSCode(err, errCodeDuplicatePermission) {
+			return errors.Wrapf(err, "%s authorize %s", StepApplyFirewallRules, rule.Name)
+		}
+		log.Infof("[%s] - rule %s has been authorized in %s", s.Name(), rule.Name, groupID)
+	}
+
+	return nil
+}
+
+func securityGroupOf(cfg steps.AWSConfig, group string) (string, error) {
+	var groupID string
+	switch group {
+	case model.FirewallMasters:
+		groupID = cfg.MastersSecurityGroupID
+	case model.FirewallNodes:
+		groupID = cfg.NodesSecurityGroupID
+	default:
+		return "", errors.Errorf("unknown group %q", group)
+	}
+
+	if groupID == "" {
+		return "", errors.Errorf("%s security group isn't known", group)
+	}
+	return groupID, nil
+}
+
+func ipPermission(rule model.FirewallRule) *ec2.IpPermission {
+	ipRange := &ec2.IpRange{CidrIp: aws.String(rule.CIDR)}
+	if rule.Description != "" {
+		ipRange.Description = aws.String(rule.Description)
+	}
+
+	return &ec2.IpPermission{
+		IpProtocol: aws.String(rule.Protocol),
+		FromPort:   aws.Int64(rule.FromPort),
+		ToPort:     aws.Int64(rule.ToPort),
+		IpRanges:   []*ec2.IpRange{ipRange},
+	}
+}
+
+func isAWSCode(err error, code string) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == code
+}
+
+func (*ApplyFirewallRulesStep) Name() string {
+	return StepApplyFirewallRules
+}
+
+func (*ApplyFirewallRulesStep) Description() string {
+	return "Authorize and revoke ingress rules of security groups"
+}
+
+func (*ApplyFirewallRulesStep) Depends() []string {
+	return nil
+}
+
+func (*ApplyFirewallRulesStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
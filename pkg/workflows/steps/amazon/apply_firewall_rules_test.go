@@ -0,0 +1,113 @@
+package amazon
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type fakeFirewallService struct {
+	authorized []*ec2.AuthorizeSecurityGroupIngressInput
+	revoked    []*ec2.RevokeSecurityGroupIngressInput
+	err        error
+}
+
+func (f *fakeFirewallService) AuthorizeSecurityGroupIngressWithContext(_ aws.Context, input *ec2.AuthorizeSecurityGroupIngressInput, _ ...request.Option) (*ec2.AuthorizeSecurityGroupIngressOutput, error) {
+	f.authorized = append(f.authorized, input)
+	return &ec2.AuthorizeSecurityGroupIngressOutput{}, f.err
+}
+
+func (f *fakeFirewallService) RevokeSecurityGroupIngressWithContext(_ aws.Context, input *ec2.RevokeSecurityGroupIngressInput, _ ...request.Option) (*ec2.RevokeSecurityGroupIngressOutput, error) {
+	f.revoked = append(f.revoked, input)
+	return &ec2.RevokeSecurityGroupIngressOutput{}, f.err
+}
+
+func TestApplyFirewallRulesStep_Run(t *testing.T) {
+	office := model.FirewallRule{Name: "office", Group: model.FirewallMasters, Protocol: "tcp", FromPort: 443, ToPort: 443, CIDR: "10.0.0.0/8", Description: "office"}
+	nodePorts := model.FirewallRule{Name: "nodeports", Group: model.FirewallNodes, Protocol: "tcp", FromPort: 30000, ToPort: 32767, CIDR: "0.0.0.0/0"}
+
+	testCases := []struct {
+		description string
+		err         error
+		rule        model.FirewallRule
+		expectErr   bool
+	}{
+		{
+			description: "success",
+			rule:        nodePorts,
+		},
+		{
+			description: "already applied",
+			err:         awserr.New(errCodeDuplicatePermission, "duplicate", nil),
+			rule:        nodePorts,
+		},
+		{
+			description: "error",
+			err:         awserr.New("UnauthorizedOperation", "denied", nil),
+			rule:        nodePorts,
+			expectErr:   true,
+		},
+		{
+			description: "unknown group",
+			rule:        model.FirewallRule{Name: "all", Group: "all"},
+			expectErr:   true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		svc := &fakeFirewallService{err: testCase.err}
+		step := &ApplyFirewallRulesStep{
+			getSvc: func(steps.AWSConfig) (firewallService, error) {
+				return svc, nil
+			},
+		}
+
+		cfg := &steps.Config{
+			AWSConfig: steps.AWSConfig{
+				MastersSecurityGroupID: "sg-1",
+				NodesSecurityGroupID:   "sg-2",
+			},
+			FirewallConfig: steps.FirewallConfig{
+				Authorize: []model.FirewallRule{testCase.rule},
+			},
+		}
+		err := step.Run(context.Background(), &bytes.Buffer{}, cfg)
+		if testCase.expectErr {
+			require.Error(t, err, testCase.description)
+			continue
+		}
+		require.NoError(t, err, testCase.description)
+		require.Len(t, svc.authorized, 1, testCase.description)
+		require.Equal(t, "sg-2", aws.StringValue(svc.authorized[0].GroupId), testCase.description)
+	}
+
+	svc := &fakeFirewallService{}
+	step := &ApplyFirewallRulesStep{
+		getSvc: func(steps.AWSConfig) (firewallService, error) {
+			return svc, nil
+		},
+	}
+	cfg := &steps.Config{
+		AWSConfig:      steps.AWSConfig{MastersSecurityGroupID: "sg-1"},
+		FirewallConfig: steps.FirewallConfig{Revoke: []model.FirewallRule{office}},
+	}
+	require.NoError(t, step.Run(context.Background(), &bytes.Buffer{}, cfg))
+	require.Equal(t, []*ec2.RevokeSecurityGroupIngressInput{{
+		GroupId: aws.String("sg-1"),
+		IpPermissions: []*ec2.IpPermission{{
+			IpProtocol: aws.String("tcp"),
+			FromPort:   aws.Int64(443),
+			ToPort:     aws.Int64(443),
+			IpRanges:   []*ec2.IpRange{{CidrIp: aws.String("10.0.0.0/8"), Description: aws.String("office")}},
+		}},
+	}}, svc.revoked)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -44,7 +45,7 @@ func NewAssociateRouteTableStep(ec2fn GetEC2Fn) *AssociateRouteTableStep {
 }
 
 func (s *AssociateRouteTableStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
-	logrus.Debugf(StepAssociateRouteTable)
+	sglog.FromContext(ctx).Debugf(StepAssociateRouteTable)
 
 	if cfg.AWSConfig.RouteTableAssociationIDs == nil {
 		cfg.AWSConfig.RouteTableAssociationIDs = make(map[string]string)
@@ -53,13 +54,13 @@ func (s *AssociateRouteTableStep) Run(ctx context.Context, w io.Writer, cfg *ste
 	associater, err := s.getRouteTableSvc(cfg.AWSConfig)
 
 	if err != nil {
-		logrus.Debugf("error getting associater service %v", err)
+		sglog.FromContext(ctx).Debugf("error getting associater service %v", err)
 		return errors.Wrapf(err, "error getting associater service %s",
 			StepAssociateRouteTable)
 	}
 
 	for az, subnet := range cfg.AWSConfig.Subnets {
-		logrus.Debugf("Associate route table %s with subnet %s",
+		sglog.FromContext(ctx).Debugf("Associate route table %s with subnet %s",
 			cfg.AWSConfig.RouteTableID, subnet)
 
 		// Associate route table with subnet
@@ -71,7 +72,7 @@ func (s *AssociateRouteTableStep) Run(ctx context.Context, w io.Writer, cfg *ste
 
 		// Skip it since by default route table is associated with default subnet
 		if err != nil {
-			logrus.Debugf("error associating route table %s with subnet %s in az %s %v",
+			sglog.FromContext(ctx).Debugf("error associating route table %s with subnet %s in az %s %v",
 				cfg.AWSConfig.RouteTableID,
 				subnet,
 				az,
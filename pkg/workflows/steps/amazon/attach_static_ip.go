@@ -0,0 +1,130 @@
+package amazon
+
+import (
+	"context"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sglog"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const StepAttachStaticIP = "aws_attach_static_ip"
+
+type addressService interface {
+	AllocateAddressWithContext(aws.Context, *ec2.AllocateAddressInput, ...request.Option) (*ec2.AllocateAddressOutput, error)
+	AssociateAddressWithContext(aws.Context, *ec2.AssociateAddressInput, ...request.Option) (*ec2.AssociateAddressOutput, error)
+	CreateTagsWithContext(aws.Context, *ec2.CreateTagsInput, ...request.Option) (*ec2.CreateTagsOutput, error)
+}
+
+// AttachStaticIPStep associates an elastic ip with the created master, a
+// free elastic ip of the cluster is taken before a new one is allocated.
+// Elastic ips are tagged with the cluster, so they are released with it.
+type AttachStaticIPStep struct {
+	getSvc func(steps.AWSConfig) (addressService, error)
+}
+
+// InitAttachStaticIP adds the step to the registry
+func InitAttachStaticIP(fn GetEC2Fn) {
+	steps.RegisterStep(StepAttachStaticIP, NewAttachStaticIPStep(fn))
+}
+
+func NewAttachStaticIPStep(fn GetEC2Fn) *AttachStaticIPStep {
+	return &AttachStaticIPStep{
+		getSvc: func(cfg steps.AWSConfig) (addressService, error) {
+			EC2, err := fn(cfg)
+			if err != nil {
+				return nil, errors.Wrap(ErrAuthorization, err.Error())
+			}
+
+			return EC2, nil
+		},
+	}
+}
+
+func (s *AttachStaticIPStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
+	log := util.GetLogger(w)
+
+	svc, err := s.getSvc(cfg.AWSConfig)
+	if err != nil {
+		sglog.FromContext(ctx).Errorf("[%s] - failed to authorize in AWS: %v", s.Name(), err)
+		return errors.Wrap(err, StepAttachStaticIP)
+	}
+
+	ip := cfg.Kube.FreeStaticIP()
+	if ip != nil {
+		if err = associate(ctx, svc, ip.ID, cfg.Node.ID); err != nil {
+			// another master may have taken it
+			log.Infof("[%s] - associate elastic ip %s caused %v", s.Name(), ip.Address, err)
+			ip = nil
+		}
+	}
+
+	if ip == nil {
+		out, err := svc.AllocateAddressWithContext(ctx, &ec2.AllocateAddressInput{
+			Domain: aws.String(ec2.DomainTypeVpc),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "%s allocate address", StepAttachStaticIP)
+		}
+		ip = &model.StaticIP{
+			ID:      aws.StringValue(out.AllocationId),
+			Address: aws.StringValue(out.PublicIp),
+		}
+
+		if _, err = svc.CreateTagsWithContext(ctx, &ec2.CreateTagsInput{
+			Resources: []*string{out.AllocationId},
+			Tags: []*ec2.Tag{
+				{Key: aws.String(clouds.TagClusterID), Value: aws.String(cfg.Kube.ID)},
+				{Key: aws.String(clouds.TagKubernetesCluster), Value: aws.String(cfg.Kube.Name)},
+				{Key: aws.String(clouds.TagNodeName), Value: aws.String(cfg.Node.Name)},
+			},
+		}); err != nil {
+			return errors.Wrapf(err, "%s tag address %s", StepAttachStaticIP, ip.Address)
+		}
+
+		if err = associate(ctx, svc, ip.ID, cfg.Node.ID); err != nil {
+			return errors.Wrapf(err, "%s associate address %s", StepAttachStaticIP, ip.Address)
+		}
+	}
+
+	log.Infof("[%s] - elastic ip %s is associated with %s", s.Name(), ip.Address, cfg.Node.Name)
+	cfg.Node.StaticIP = ip
+	cfg.Node.PublicIp = ip.Address
+	cfg.NodeChan() <- cfg.Node
+	cfg.AddMaster(&cfg.Node)
+
+	return nil
+}
+
+func associate(ctx context.Context, svc addressService, allocationID, instanceID string) error {
+	_, err := svc.AssociateAddressWithContext(ctx, &ec2.AssociateAddressInput{
+		AllocationId:       aws.String(allocationID),
+		InstanceId:         aws.String(instanceID),
+		AllowReassociation: aws.Bool(false),
+	})
+	return err
+}
+
+func (*AttachStaticIPStep) Name() string {
+	return StepAttachStaticIP
+}
+
+func (*AttachStaticIPStep) Description() string {
+	return "Associate an elastic ip with the master"
+}
+
+func (*AttachStaticIPStep) Depends() []string {
+	return nil
+}
+
+func (*AttachStaticIPStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
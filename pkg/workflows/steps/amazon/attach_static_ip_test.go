@@ -0,0 +1,105 @@
+package amazon
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type mockAddressService struct {
+	mock.Mock
+}
+
+func (m *mockAddressService) AllocateAddressWithContext(ctx aws.Context, input *ec2.AllocateAddressInput, opts ...request.Option) (*ec2.AllocateAddressOutput, error) {
+	args := m.Called(ctx, input)
+	val, ok := args.Get(0).(*ec2.AllocateAddressOutput)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return val, args.Error(1)
+}
+
+func (m *mockAddressService) AssociateAddressWithContext(ctx aws.Context, input *ec2.AssociateAddressInput, opts ...request.Option) (*ec2.AssociateAddressOutput, error) {
+	args := m.Called(ctx, input)
+	val, ok := args.Get(0).(*ec2.AssociateAddressOutput)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return val, args.Error(1)
+}
+
+func (m *mockAddressService) CreateTagsWithContext(ctx aws.Context, input *ec2.CreateTagsInput, opts ...request.Option) (*ec2.CreateTagsOutput, error) {
+	args := m.Called(ctx, input)
+	val, ok := args.Get(0).(*ec2.CreateTagsOutput)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return val, args.Error(1)
+}
+
+func TestAttachStaticIPStep_Run(t *testing.T) {
+	testCases := []struct {
+		description string
+		free        []model.StaticIP
+		freeErr     error
+		expected    model.StaticIP
+	}{
+		{
+			description: "allocate",
+			expected:    model.StaticIP{ID: "eipalloc-new", Address: "3.3.3.3"},
+		},
+		{
+			description: "take a free address",
+			free:        []model.StaticIP{{ID: "eipalloc-free", Address: "1.1.1.1"}},
+			expected:    model.StaticIP{ID: "eipalloc-free", Address: "1.1.1.1"},
+		},
+		{
+			description: "free address is taken",
+			free:        []model.StaticIP{{ID: "eipalloc-free", Address: "1.1.1.1"}},
+			freeErr:     errors.New("Resource.AlreadyAssociated"),
+			expected:    model.StaticIP{ID: "eipalloc-new", Address: "3.3.3.3"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase.description)
+		svc := &mockAddressService{}
+		svc.On("AllocateAddressWithContext", mock.Anything, mock.Anything).
+			Return(&ec2.AllocateAddressOutput{AllocationId: aws.String("eipalloc-new"), PublicIp: aws.String("3.3.3.3")}, nil)
+		svc.On("CreateTagsWithContext", mock.Anything, mock.Anything).Return(&ec2.CreateTagsOutput{}, nil)
+		svc.On("AssociateAddressWithContext", mock.Anything, mock.MatchedBy(func(input *ec2.AssociateAddressInput) bool {
+			return aws.StringValue(input.AllocationId) == "eipalloc-free"
+		})).Return(&ec2.AssociateAddressOutput{}, testCase.freeErr)
+		svc.On("AssociateAddressWithContext", mock.Anything, mock.MatchedBy(func(input *ec2.AssociateAddressInput) bool {
+			return aws.StringValue(input.AllocationId) == "eipalloc-new" && aws.StringValue(input.InstanceId) == "i-1"
+		})).Return(&ec2.AssociateAddressOutput{}, nil)
+
+		cfg, err := steps.NewConfig("test", "", profile.Profile{})
+		require.NoError(t, err)
+		cfg.Kube.FreeStaticIPs = testCase.free
+		cfg.Node = model.Machine{ID: "i-1", Name: "master-1", PublicIp: "2.2.2.2"}
+		go func() {
+			<-cfg.NodeChan()
+		}()
+
+		step := &AttachStaticIPStep{
+			getSvc: func(steps.AWSConfig) (addressService, error) {
+				return svc, nil
+			},
+		}
+		require.NoError(t, step.Run(context.Background(), &bytes.Buffer{}, cfg), testCase.description)
+		require.Equal(t, &testCase.expected, cfg.Node.StaticIP, testCase.description)
+		require.Equal(t, testCase.expected.Address, cfg.Node.PublicIp, testCase.description)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/iam/iamiface"
 	"github.com/sirupsen/logrus"
 
+	"github.com/supergiant/control/pkg/tracing"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -28,6 +29,7 @@ func GetEC2(cfg steps.AWSConfig) (ec2iface.EC2API, error) {
 	if err != nil {
 		return nil, err
 	}
+	tracing.AWS(&sess.Handlers)
 	return ec2.New(sess), nil
 }
 
@@ -44,6 +46,7 @@ func GetIAM(cfg steps.AWSConfig) (iamiface.IAMAPI, error) {
 	if err != nil {
 		return nil, err
 	}
+	tracing.AWS(&sess.Handlers)
 	return iam.New(sess), nil
 }
 
@@ -61,5 +64,6 @@ func GetELB(cfg steps.AWSConfig) (*elb.ELB, error) {
 	if err != nil {
 		return nil, err
 	}
+	tracing.AWS(&sess.Handlers)
 	return elb.New(sess), nil
 }
@@ -0,0 +1,108 @@
+package amazon
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/sglog"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const StepCreateImage = "aws_create_image"
+
+type imageCreator interface {
+	CreateImageWithContext(aws.Context, *ec2.CreateImageInput, ...request.Option) (*ec2.CreateImageOutput, error)
+	WaitUntilImageAvailableWithContext(aws.Context, *ec2.DescribeImagesInput, ...request.WaiterOption) error
+	CreateTagsWithContext(aws.Context, *ec2.CreateTagsInput, ...request.Option) (*ec2.CreateTagsOutput, error)
+}
+
+// CreateImageStep snapshots the created machine into an AMI that is tagged
+// with versions of the installed software.
+type CreateImageStep struct {
+	getSvc func(steps.AWSConfig) (imageCreator, error)
+}
+
+// InitCreateImage adds the step to the registry
+func InitCreateImage(fn GetEC2Fn) {
+	steps.RegisterStep(StepCreateImage, NewCreateImageStep(fn))
+}
+
+func NewCreateImageStep(fn GetEC2Fn) *CreateImageStep {
+	return &CreateImageStep{
+		getSvc: func(cfg steps.AWSConfig) (imageCreator, error) {
+			EC2, err := fn(cfg)
+			if err != nil {
+				return nil, errors.Wrap(ErrAuthorization, err.Error())
+			}
+
+			return EC2, nil
+		},
+	}
+}
+
+func (s *CreateImageStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
+	log := util.GetLogger(w)
+
+	svc, err := s.getSvc(cfg.AWSConfig)
+	if err != nil {
+		sglog.FromContext(ctx).Errorf("[%s] - failed to authorize in AWS: %v", s.Name(), err)
+		return errors.Wrap(err, StepCreateImage)
+	}
+
+	out, err := svc.CreateImageWithContext(ctx, &ec2.CreateImageInput{
+		InstanceId:  aws.String(cfg.Node.ID),
+		Name:        aws.String(cfg.BakeConfig.Name),
+		Description: aws.String(fmt.Sprintf("kubernetes %s docker %s", cfg.Kube.K8SVersion, cfg.Kube.DockerVersion)),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "create image of instance %s", cfg.Node.ID)
+	}
+	imageID := aws.StringValue(out.ImageId)
+	log.Infof("[%s] - waiting for image %s of instance %s", s.Name(), imageID, cfg.Node.ID)
+
+	if err = svc.WaitUntilImageAvailableWithContext(ctx, &ec2.DescribeImagesInput{
+		ImageIds: []*string{aws.String(imageID)},
+	}); err != nil {
+		return errors.Wrapf(err, "wait for image %s", imageID)
+	}
+
+	if _, err = svc.CreateTagsWithContext(ctx, &ec2.CreateTagsInput{
+		Resources: []*string{aws.String(imageID)},
+		Tags: []*ec2.Tag{
+			{Key: aws.String(clouds.TagNodeName), Value: aws.String(cfg.BakeConfig.Name)},
+			{Key: aws.String(clouds.TagK8SVersion), Value: aws.String(cfg.Kube.K8SVersion)},
+			{Key: aws.String(clouds.TagDockerVersion), Value: aws.String(cfg.Kube.DockerVersion)},
+		},
+	}); err != nil {
+		return errors.Wrapf(err, "tag image %s", imageID)
+	}
+
+	cfg.BakeConfig.ImageID = imageID
+	log.Infof("[%s] - image %s is available", s.Name(), imageID)
+
+	return nil
+}
+
+func (*CreateImageStep) Name() string {
+	return StepCreateImage
+}
+
+func (*CreateImageStep) Description() string {
+	return "Create an AMI of the machine"
+}
+
+func (*CreateImageStep) Depends() []string {
+	return nil
+}
+
+func (*CreateImageStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
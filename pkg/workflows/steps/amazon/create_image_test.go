@@ -0,0 +1,112 @@
+package amazon
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type mockImageCreator struct {
+	mock.Mock
+}
+
+func (m *mockImageCreator) CreateImageWithContext(ctx aws.Context, input *ec2.CreateImageInput, opts ...request.Option) (*ec2.CreateImageOutput, error) {
+	args := m.Called(ctx, input)
+	val, ok := args.Get(0).(*ec2.CreateImageOutput)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return val, args.Error(1)
+}
+
+func (m *mockImageCreator) WaitUntilImageAvailableWithContext(ctx aws.Context, input *ec2.DescribeImagesInput, opts ...request.WaiterOption) error {
+	args := m.Called(ctx, input)
+	return args.Error(0)
+}
+
+func (m *mockImageCreator) CreateTagsWithContext(ctx aws.Context, input *ec2.CreateTagsInput, opts ...request.Option) (*ec2.CreateTagsOutput, error) {
+	args := m.Called(ctx, input)
+	val, ok := args.Get(0).(*ec2.CreateTagsOutput)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return val, args.Error(1)
+}
+
+func TestCreateImageStep_Run(t *testing.T) {
+	testCases := []struct {
+		description string
+		getSvcErr   error
+		createErr   error
+		waitErr     error
+		errMsg      string
+	}{
+		{
+			description: "get service error",
+			getSvcErr:   errors.New("auth"),
+			errMsg:      "auth",
+		},
+		{
+			description: "create error",
+			createErr:   errors.New("create"),
+			errMsg:      "create",
+		},
+		{
+			description: "wait error",
+			waitErr:     errors.New("wait"),
+			errMsg:      "wait",
+		},
+		{
+			description: "success",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase.description)
+		svc := &mockImageCreator{}
+		svc.On("CreateImageWithContext", mock.Anything, mock.MatchedBy(func(input *ec2.CreateImageInput) bool {
+			return aws.StringValue(input.InstanceId) == "i-1" && aws.StringValue(input.Name) == "golden"
+		})).Return(&ec2.CreateImageOutput{ImageId: aws.String("ami-1")}, testCase.createErr)
+		svc.On("WaitUntilImageAvailableWithContext", mock.Anything, mock.Anything).Return(testCase.waitErr)
+		svc.On("CreateTagsWithContext", mock.Anything, mock.MatchedBy(func(input *ec2.CreateTagsInput) bool {
+			for _, tag := range input.Tags {
+				if aws.StringValue(tag.Key) == clouds.TagK8SVersion {
+					return aws.StringValue(tag.Value) == "1.14.1"
+				}
+			}
+			return false
+		})).Return(&ec2.CreateTagsOutput{}, nil)
+
+		step := &CreateImageStep{
+			getSvc: func(steps.AWSConfig) (imageCreator, error) {
+				return svc, testCase.getSvcErr
+			},
+		}
+		cfg := &steps.Config{
+			Kube:       model.Kube{K8SVersion: "1.14.1", DockerVersion: "18.06.3"},
+			Node:       model.Machine{ID: "i-1"},
+			BakeConfig: steps.BakeConfig{Name: "golden"},
+		}
+
+		err := step.Run(context.Background(), &bytes.Buffer{}, cfg)
+		if testCase.errMsg != "" {
+			require.Error(t, err, testCase.description)
+			require.Contains(t, err.Error(), testCase.errMsg, testCase.description)
+			require.Empty(t, cfg.BakeConfig.ImageID, testCase.description)
+			continue
+		}
+		require.NoError(t, err, testCase.description)
+		require.Equal(t, "ami-1", cfg.BakeConfig.ImageID)
+	}
+}
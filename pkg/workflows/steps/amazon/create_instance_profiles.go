@@ -10,9 +10,9 @@ import (
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/iam/iamiface"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
 	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -90,7 +90,18 @@ const (
         "elasticloadbalancing:RegisterTargets",
         "elasticloadbalancing:SetLoadBalancerPoliciesOfListener",
         "iam:CreateServiceLinkedRole",
-        "kms:DescribeKey"
+        "kms:DescribeKey",
+        "ssm:UpdateInstanceInformation",
+        "ssmmessages:CreateControlChannel",
+        "ssmmessages:CreateDataChannel",
+        "ssmmessages:OpenControlChannel",
+        "ssmmessages:OpenDataChannel",
+        "ec2messages:AcknowledgeMessage",
+        "ec2messages:DeleteMessage",
+        "ec2messages:FailMessage",
+        "ec2messages:GetEndpoint",
+        "ec2messages:GetMessages",
+        "ec2messages:SendReply"
       ],
       "Resource": [
         "*"
@@ -113,7 +124,18 @@ const (
                   "ecr:GetRepositoryPolicy",
                   "ecr:DescribeRepositories",
                   "ecr:ListImages",
-                  "ecr:BatchGetImage"
+                  "ecr:BatchGetImage",
+                  "ssm:UpdateInstanceInformation",
+                  "ssmmessages:CreateControlChannel",
+                  "ssmmessages:CreateDataChannel",
+                  "ssmmessages:OpenControlChannel",
+                  "ssmmessages:OpenDataChannel",
+                  "ec2messages:AcknowledgeMessage",
+                  "ec2messages:DeleteMessage",
+                  "ec2messages:FailMessage",
+                  "ec2messages:GetEndpoint",
+                  "ec2messages:GetMessages",
+                  "ec2messages:SendReply"
               ],
               "Resource": "*"
           } 
@@ -154,13 +176,13 @@ func (s StepCreateInstanceProfiles) Run(ctx context.Context, w io.Writer, cfg *s
 	if err != nil {
 		return errors.Wrapf(err, "%s: failed to authorize in AWS: %v", s.Name(), err)
 	}
-	logrus.Infof("%s: set up %s instance profile", s.Name(), cfg.AWSConfig.MastersInstanceProfile)
+	sglog.FromContext(ctx).Infof("%s: set up %s instance profile", s.Name(), cfg.AWSConfig.MastersInstanceProfile)
 
 	cfg.AWSConfig.NodesInstanceProfile, err = ensureIAMProfile(ctx, iamS, cfg.Kube.ID, string(model.RoleNode))
 	if err != nil {
 		return errors.Wrapf(err, "%s: failed to authorize in AWS: %v", s.Name(), err)
 	}
-	logrus.Infof("%s: set up %s instance profile", s.Name(), cfg.AWSConfig.NodesInstanceProfile)
+	sglog.FromContext(ctx).Infof("%s: set up %s instance profile", s.Name(), cfg.AWSConfig.NodesInstanceProfile)
 
 	return nil
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -49,11 +50,11 @@ func NewCreateInternetGatewayStep(ec2fn GetEC2Fn) *CreateInternetGatewayStep {
 }
 
 func (s *CreateInternetGatewayStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
-	logrus.Debugf(StepCreateInternetGateway)
+	sglog.FromContext(ctx).Debugf(StepCreateInternetGateway)
 
 	// Internet gateway already exists
 	if cfg.AWSConfig.InternetGatewayID != "" {
-		logrus.Debugf("use internet gateway %s",
+		sglog.FromContext(ctx).Debugf("use internet gateway %s",
 			cfg.AWSConfig.InternetGatewayID)
 		return nil
 	} else {
@@ -96,12 +97,12 @@ func (s *CreateInternetGatewayStep) Run(ctx context.Context, w io.Writer, cfg *s
 		_, err = svc.CreateTags(tagInput)
 
 		if err != nil {
-			logrus.Errorf("Error tagging route table %s %v",
+			sglog.FromContext(ctx).Errorf("Error tagging route table %s %v",
 				cfg.AWSConfig.RouteTableID, err)
 			return err
 		}
 
-		logrus.Debugf("Attach Internet GW %s to VPC %s",
+		sglog.FromContext(ctx).Debugf("Attach Internet GW %s to VPC %s",
 			cfg.AWSConfig.InternetGatewayID, cfg.AWSConfig.VPCID)
 		// Attach GW to VPC
 		attachGw := &ec2.AttachInternetGatewayInput{
@@ -109,7 +110,7 @@ func (s *CreateInternetGatewayStep) Run(ctx context.Context, w io.Writer, cfg *s
 			InternetGatewayId: aws.String(cfg.AWSConfig.InternetGatewayID),
 		}
 		if _, err := svc.AttachInternetGateway(attachGw); err != nil && !strings.Contains(err.Error(), "already has an internet gateway attached") {
-			logrus.Errorf("Error attaching GW %s to VPC %s", cfg.AWSConfig.InternetGatewayID, cfg.AWSConfig.VPCID)
+			sglog.FromContext(ctx).Errorf("Error attaching GW %s to VPC %s", cfg.AWSConfig.InternetGatewayID, cfg.AWSConfig.VPCID)
 			return err
 		}
 	}
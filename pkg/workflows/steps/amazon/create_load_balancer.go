@@ -14,6 +14,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/util"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
@@ -66,7 +67,7 @@ func (s *CreateLoadBalancerStep) Run(ctx context.Context, out io.Writer, cfg *st
 	svc, err := s.getLoadBalancerService(cfg.AWSConfig)
 
 	if err != nil {
-		logrus.Errorf("error getting ELB service %v", err)
+		sglog.FromContext(ctx).Errorf("error getting ELB service %v", err)
 		return errors.Wrapf(err, "error getting ELB service %s",
 			StepCreateLoadBalancer)
 	}
@@ -111,12 +112,12 @@ func (s *CreateLoadBalancerStep) Run(ctx context.Context, out io.Writer, cfg *st
 		})
 
 		if err != nil {
-			logrus.Debugf("create external load balancer %v",
+			sglog.FromContext(ctx).Debugf("create external load balancer %v",
 				err)
 			return errors.Wrapf(err, "create load balancer %s", StepCreateLoadBalancer)
 		}
 
-		logrus.Infof("Created load external balancer %s with dns name %s", *externalLoadBalancerName, *output.DNSName)
+		sglog.FromContext(ctx).Infof("Created load external balancer %s with dns name %s", *externalLoadBalancerName, *output.DNSName)
 
 		cfg.Kube.ExternalDNSName = *output.DNSName
 		cfg.AWSConfig.ExternalLoadBalancerName = *externalLoadBalancerName
@@ -167,12 +168,12 @@ func (s *CreateLoadBalancerStep) Run(ctx context.Context, out io.Writer, cfg *st
 		})
 
 		if err != nil {
-			logrus.Debugf("create internal load balancer %v",
+			sglog.FromContext(ctx).Debugf("create internal load balancer %v",
 				err)
 			return errors.Wrapf(err, "create internal load balancer %s", StepCreateLoadBalancer)
 		}
 
-		logrus.Infof("Created load internal balancer %s with dns name %s", *internalLoadBalancerName, *output.DNSName)
+		sglog.FromContext(ctx).Infof("Created load internal balancer %s with dns name %s", *internalLoadBalancerName, *output.DNSName)
 
 		cfg.Kube.InternalDNSName = *output.DNSName
 		cfg.AWSConfig.InternalLoadBalancerName = *internalLoadBalancerName
@@ -192,7 +193,7 @@ func (s *CreateLoadBalancerStep) Run(ctx context.Context, out io.Writer, cfg *st
 				break
 			}
 			time.Sleep(s.timeout)
-			logrus.Debugf("connect to load balancer %s with %v", cfg.Kube.InternalDNSName, err)
+			sglog.FromContext(ctx).Debugf("connect to load balancer %s with %v", cfg.Kube.InternalDNSName, err)
 		}
 	}
 
@@ -200,7 +201,7 @@ func (s *CreateLoadBalancerStep) Run(ctx context.Context, out io.Writer, cfg *st
 		return errors.Wrap(err, "error waiting for load balancer to come up")
 	}
 
-	logrus.Debugf("Configure health check for %s", cfg.AWSConfig.ExternalLoadBalancerName)
+	sglog.FromContext(ctx).Debugf("Configure health check for %s", cfg.AWSConfig.ExternalLoadBalancerName)
 	healthCheckInput := &elb.ConfigureHealthCheckInput{
 		LoadBalancerName: aws.String(cfg.AWSConfig.ExternalLoadBalancerName),
 		HealthCheck: &elb.HealthCheck{
@@ -213,10 +214,10 @@ func (s *CreateLoadBalancerStep) Run(ctx context.Context, out io.Writer, cfg *st
 	}
 
 	if _, err := svc.ConfigureHealthCheck(healthCheckInput); err != nil {
-		logrus.Errorf("error configuring health check for %v  %s", err, cfg.AWSConfig.ExternalLoadBalancerName)
+		sglog.FromContext(ctx).Errorf("error configuring health check for %v  %s", err, cfg.AWSConfig.ExternalLoadBalancerName)
 	}
 
-	logrus.Debugf("Configure health check for %s", cfg.AWSConfig.InternalLoadBalancerName)
+	sglog.FromContext(ctx).Debugf("Configure health check for %s", cfg.AWSConfig.InternalLoadBalancerName)
 	healthCheckInput = &elb.ConfigureHealthCheckInput{
 		LoadBalancerName: aws.String(cfg.AWSConfig.InternalLoadBalancerName),
 		HealthCheck: &elb.HealthCheck{
@@ -229,7 +230,7 @@ func (s *CreateLoadBalancerStep) Run(ctx context.Context, out io.Writer, cfg *st
 	}
 
 	if _, err := svc.ConfigureHealthCheck(healthCheckInput); err != nil {
-		logrus.Errorf("error configuring health check for %v %s", err, cfg.AWSConfig.InternalLoadBalancerName)
+		sglog.FromContext(ctx).Errorf("error configuring health check for %v %s", err, cfg.AWSConfig.InternalLoadBalancerName)
 	}
 
 	return nil
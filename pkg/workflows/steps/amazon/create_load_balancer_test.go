@@ -48,6 +48,15 @@ func (m *mockELBService) ConfigureHealthCheck(input *elb.ConfigureHealthCheckInp
 	return val, args.Error(1)
 }
 
+func (m *mockELBService) DeregisterInstancesFromLoadBalancerWithContext(ctx aws.Context, input *elb.DeregisterInstancesFromLoadBalancerInput, opts ...request.Option) (*elb.DeregisterInstancesFromLoadBalancerOutput, error) {
+	args := m.Called(ctx, input, opts)
+	val, ok := args.Get(0).(*elb.DeregisterInstancesFromLoadBalancerOutput)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return val, args.Error(1)
+}
+
 func (m *mockELBService) RegisterInstancesWithLoadBalancerWithContext(ctx aws.Context, input *elb.RegisterInstancesWithLoadBalancerInput, opts ...request.Option) (*elb.RegisterInstancesWithLoadBalancerOutput, error) {
 	args := m.Called(ctx, input, opts)
 	val, ok := args.Get(0).(*elb.RegisterInstancesWithLoadBalancerOutput)
@@ -2,6 +2,7 @@ package amazon
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"strconv"
@@ -10,10 +11,11 @@ import (
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
 	"github.com/supergiant/control/pkg/clouds"
 	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/runner/winrm"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/util"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
@@ -23,6 +25,7 @@ const (
 )
 
 type instanceService interface {
+	ImageFinder
 	RunInstancesWithContext(aws.Context, *ec2.RunInstancesInput, ...request.Option) (*ec2.Reservation, error)
 	DescribeInstancesWithContext(aws.Context, *ec2.DescribeInstancesInput, ...request.Option) (*ec2.DescribeInstancesOutput, error)
 	WaitUntilInstanceRunningWithContext(aws.Context, *ec2.DescribeInstancesInput, ...request.WaiterOption) error
@@ -57,16 +60,25 @@ func (s *StepCreateInstance) Run(ctx context.Context, w io.Writer, cfg *steps.Co
 	// TODO: reuse sessions
 	ec2Svc, err := s.getSvc(cfg.AWSConfig)
 	if err != nil {
-		logrus.Errorf("[%s] - failed to authorize in AWS: %v", s.Name(), err)
+		sglog.FromContext(ctx).Errorf("[%s] - failed to authorize in AWS: %v", s.Name(), err)
 		return errors.Wrap(ErrAuthorization, err.Error())
 	}
 
+	if err = findNodeAMI(ctx, ec2Svc, cfg); err != nil {
+		sglog.FromContext(ctx).Errorf("[%s] - failed to find the image of the node: %v", s.Name(), err)
+		return errors.Wrap(err, "find image")
+	}
+	log.Infof("[%s] - using image %s", s.Name(), cfg.AWSConfig.ImageID)
+
 	role := model.RoleMaster
 	if !cfg.IsMaster {
 		role = model.RoleNode
 	}
 
-	nodeName := util.MakeNodeName(cfg.Kube.Name, cfg.TaskID, cfg.IsMaster)
+	nodeName, err := cfg.InstanceName()
+	if err != nil {
+		return errors.Wrap(err, "name instance")
+	}
 
 	cfg.Node = model.Machine{
 		Name:     nodeName,
@@ -76,6 +88,9 @@ func (s *StepCreateInstance) Run(ctx context.Context, w io.Writer, cfg *steps.Co
 		Size:     cfg.AWSConfig.InstanceType,
 		Provider: clouds.AWS,
 		State:    model.MachineStatePlanned,
+		Image:    cfg.AWSConfig.ImageID,
+
+		OperatingSystem: cfg.NodeOS,
 	}
 
 	// Update node state in cluster
@@ -156,6 +171,12 @@ func (s *StepCreateInstance) Run(ctx context.Context, w io.Writer, cfg *steps.Co
 		},
 	}
 
+	// windows machines are reached with WinRM that is configured on first boot
+	if cfg.NodeOS == model.OSWindows {
+		runInstanceInput.UserData = aws.String(base64.StdEncoding.EncodeToString(
+			[]byte(windowsUserData(cfg.Kube.WinRMConfig))))
+	}
+
 	res, err := ec2Svc.RunInstancesWithContext(ctx, runInstanceInput)
 	if err != nil {
 		cfg.Node.State = model.MachineStateError
@@ -173,6 +194,9 @@ func (s *StepCreateInstance) Run(ctx context.Context, w io.Writer, cfg *steps.Co
 		Provider: clouds.AWS,
 		Size:     cfg.AWSConfig.InstanceType,
 		State:    model.MachineStateBuilding,
+		Image:    cfg.AWSConfig.ImageID,
+
+		OperatingSystem: cfg.NodeOS,
 	}
 
 	// Update node state in cluster
@@ -200,17 +224,17 @@ func (s *StepCreateInstance) Run(ctx context.Context, w io.Writer, cfg *steps.Co
 			},
 		},
 	}
-	logrus.Debugf("Wait until instance %s running", nodeName)
+	sglog.FromContext(ctx).Debugf("Wait until instance %s running", nodeName)
 	err = ec2Svc.WaitUntilInstanceRunningWithContext(ctx, lookup)
 
 	if err != nil {
-		logrus.Errorf("Error waiting instance %s cluster %s running %v",
+		sglog.FromContext(ctx).Errorf("Error waiting instance %s cluster %s running %v",
 			nodeName, cfg.Kube.ID, err)
 		return errors.Wrapf(err, "Error waiting instance %s cluster-id %s",
 			nodeName, cfg.Kube.ID)
 	}
 
-	logrus.Debugf("Instance running %s", nodeName)
+	sglog.FromContext(ctx).Debugf("Instance running %s", nodeName)
 
 	out, err := ec2Svc.DescribeInstancesWithContext(ctx, lookup)
 
@@ -237,7 +261,7 @@ func (s *StepCreateInstance) Run(ctx context.Context, w io.Writer, cfg *steps.Co
 	cfg.Node.ID = *instance.InstanceId
 	cfg.Node.State = model.MachineStateProvisioning
 
-	logrus.Infof("Machine created %v", cfg.Node)
+	sglog.FromContext(ctx).Infof("Machine created %v", cfg.Node)
 	cfg.NodeChan() <- cfg.Node
 	if cfg.IsMaster {
 		cfg.AddMaster(&cfg.Node)
@@ -251,6 +275,27 @@ func (s *StepCreateInstance) Run(ctx context.Context, w io.Writer, cfg *steps.Co
 	return nil
 }
 
+// windowsUserData sets the administrator password and enables
+// a HTTPS WinRM listener with basic authentication.
+func windowsUserData(cfg model.WinRMConfig) string {
+	port := cfg.Port
+	if port == 0 {
+		port = winrm.DefaultHTTPSPort
+	}
+
+	return fmt.Sprintf(`<powershell>
+net user %s '%s'
+$cert = New-SelfSignedCertificate -DnsName $env:COMPUTERNAME -CertStoreLocation Cert:\LocalMachine\My
+Remove-Item -Path WSMan:\localhost\Listener\* -Recurse -Force -ErrorAction SilentlyContinue
+New-Item -Path WSMan:\localhost\Listener -Transport HTTPS -Address * -Port %d -CertificateThumbPrint $cert.Thumbprint -Force
+Set-Item -Path WSMan:\localhost\Service\Auth\Basic -Value $true
+Set-Item -Path WSMan:\localhost\MaxTimeoutms -Value 1800000
+New-NetFirewallRule -DisplayName 'WinRM HTTPS' -Direction Inbound -Protocol TCP -LocalPort %d -Action Allow
+Restart-Service WinRM
+</powershell>
+`, cfg.User, cfg.Password, port, port)
+}
+
 func (s *StepCreateInstance) Rollback(ctx context.Context, w io.Writer, cfg *steps.Config) error {
 	return nil
 }
@@ -15,6 +15,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/supergiant/control/pkg/model"
 	"github.com/supergiant/control/pkg/profile"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
@@ -43,6 +44,16 @@ func (m *mockEC2) DescribeInstancesWithContext(ctx aws.Context,
 	return val, args.Error(1)
 }
 
+func (m *mockEC2) DescribeImagesWithContext(ctx aws.Context,
+	req *ec2.DescribeImagesInput, opts ...request.Option) (*ec2.DescribeImagesOutput, error) {
+	args := m.Called(ctx, req, opts)
+	val, ok := args.Get(0).(*ec2.DescribeImagesOutput)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return val, args.Error(1)
+}
+
 func (m *mockEC2) WaitUntilInstanceRunningWithContext(ctx aws.Context,
 	req *ec2.DescribeInstancesInput, opts ...request.WaiterOption) error {
 	args := m.Called(ctx, req, opts)
@@ -334,3 +345,21 @@ func TestStepCreateInstance_Rollback(t *testing.T) {
 		t.Errorf("Unexpected error %v", err)
 	}
 }
+
+func TestWindowsUserData(t *testing.T) {
+	data := windowsUserData(model.WinRMConfig{
+		User:     "Administrator",
+		Password: "Sg1-secret",
+	})
+
+	for _, expected := range []string{
+		"net user Administrator 'Sg1-secret'",
+		"-Transport HTTPS -Address * -Port 5986",
+		`WSMan:\localhost\Service\Auth\Basic -Value $true`,
+		"-LocalPort 5986",
+	} {
+		if !strings.Contains(data, expected) {
+			t.Errorf("%s not found in %s", expected, data)
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -49,7 +50,7 @@ func NewCreateRouteTableStep(ec2fn GetEC2Fn) *CreateRouteTableStep {
 }
 
 func (s *CreateRouteTableStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
-	logrus.Debugf(StepCreateRouteTable)
+	sglog.FromContext(ctx).Debugf(StepCreateRouteTable)
 
 	//  route table already exists
 	if cfg.AWSConfig.RouteTableID != "" {
@@ -59,7 +60,7 @@ func (s *CreateRouteTableStep) Run(ctx context.Context, w io.Writer, cfg *steps.
 	svc, err := s.getService(cfg.AWSConfig)
 
 	if err != nil {
-		logrus.Errorf("error getting service on step %s %v",
+		sglog.FromContext(ctx).Errorf("error getting service on step %s %v",
 			StepCreateRouteTable, err)
 		return errors.Wrapf(err, "error getting service on step %s",
 			StepCreateRouteTable)
@@ -70,12 +71,12 @@ func (s *CreateRouteTableStep) Run(ctx context.Context, w io.Writer, cfg *steps.
 	})
 
 	if err != nil {
-		logrus.Errorf("Error creating route table %v", err)
+		sglog.FromContext(ctx).Errorf("Error creating route table %v", err)
 		return err
 	}
 
 	cfg.AWSConfig.RouteTableID = *createResp.RouteTable.RouteTableId
-	logrus.Infof("Create route table %s", cfg.AWSConfig.RouteTableID)
+	sglog.FromContext(ctx).Infof("Create route table %s", cfg.AWSConfig.RouteTableID)
 
 	// Tag route table
 	ec2Tags := []*ec2.Tag{
@@ -101,7 +102,7 @@ func (s *CreateRouteTableStep) Run(ctx context.Context, w io.Writer, cfg *steps.
 	_, err = svc.CreateTags(input)
 
 	if err != nil {
-		logrus.Errorf("Error tagging route table %s %v",
+		sglog.FromContext(ctx).Errorf("Error tagging route table %s %v",
 			cfg.AWSConfig.RouteTableID, err)
 		return err
 	}
@@ -114,7 +115,7 @@ func (s *CreateRouteTableStep) Run(ctx context.Context, w io.Writer, cfg *steps.
 	})
 
 	if err != nil {
-		logrus.Debugf("Error creating rule for internet gateway %v", err)
+		sglog.FromContext(ctx).Debugf("Error creating rule for internet gateway %v", err)
 		return err
 	}
 
@@ -9,9 +9,12 @@ import (
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
+	"github.com/supergiant/control/pkg/model"
 	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/runner/winrm"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/util"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
@@ -52,15 +55,18 @@ func (s *CreateSecurityGroupsStep) Run(ctx context.Context, w io.Writer, cfg *st
 	svc, err := s.getSvc(cfg.AWSConfig)
 
 	if err != nil {
-		logrus.Errorf("%s: Getting service caused %v",
+		sglog.FromContext(ctx).Errorf("%s: Getting service caused %v",
 			StepCreateSecurityGroups, err)
 		return errors.Wrapf(err, "%s get service", StepCreateSecurityGroups)
 	}
 
-	logrus.Debugf("Create security groups for VPC %s",
+	sglog.FromContext(ctx).Debugf("Create security groups for VPC %s",
 		cfg.AWSConfig.VPCID)
 	if cfg.AWSConfig.MastersSecurityGroupID == "" {
-		groupName := fmt.Sprintf("%s-masters-secgroup", cfg.Kube.ID)
+		groupName, err := securityGroupName(cfg, model.RoleMaster)
+		if err != nil {
+			return errors.Wrapf(err, "%s name security group", StepCreateSecurityGroups)
+		}
 
 		log.Infof("[%s] - masters security groups not specified, will create a new one...", s.Name())
 		out, err := svc.CreateSecurityGroupWithContext(ctx, &ec2.CreateSecurityGroupInput{
@@ -76,7 +82,10 @@ func (s *CreateSecurityGroupsStep) Run(ctx context.Context, w io.Writer, cfg *st
 	}
 	//If there is no security group, create it
 	if cfg.AWSConfig.NodesSecurityGroupID == "" {
-		groupName := fmt.Sprintf("%s-nodes-secgroup", cfg.Kube.ID)
+		groupName, err := securityGroupName(cfg, model.RoleNode)
+		if err != nil {
+			return errors.Wrapf(err, "%s name security group", StepCreateSecurityGroups)
+		}
 
 		log.Infof("[%s] - node security groups not specified, will create a new one...", s.Name())
 		out, err := svc.CreateSecurityGroupWithContext(ctx, &ec2.CreateSecurityGroupInput{
@@ -91,33 +100,46 @@ func (s *CreateSecurityGroupsStep) Run(ctx context.Context, w io.Writer, cfg *st
 		}
 	}
 
-	logrus.Debugf("Security groups %s %s has been created",
+	sglog.FromContext(ctx).Debugf("Security groups %s %s has been created",
 		cfg.AWSConfig.MastersSecurityGroupID, cfg.AWSConfig.NodesSecurityGroupID)
 
-	logrus.Debugf("Authorize SSH between groups")
-	//In order to deploy the kubernetes cluster supergiant needs to open port 22
-	if err := s.authorizeSSH(ctx, svc, cfg.AWSConfig.MastersSecurityGroupID); err != nil {
-		logrus.Errorf("authorize ssh for masters caused %v", err)
-		return errors.Wrapf(err, "%s authorize ssh for masters",
-			StepCreateSecurityGroups)
+	//In order to deploy the kubernetes cluster supergiant needs to open port 22,
+	//machines managed through ssm don't accept ssh connections at all
+	if cfg.Kube.RunnerType != runner.SSM {
+		sglog.FromContext(ctx).Debugf("Authorize SSH between groups")
+		if err := s.authorizeSSH(ctx, svc, cfg.AWSConfig.MastersSecurityGroupID); err != nil {
+			sglog.FromContext(ctx).Errorf("authorize ssh for masters caused %v", err)
+			return errors.Wrapf(err, "%s authorize ssh for masters",
+				StepCreateSecurityGroups)
+		}
+
+		if err := s.authorizeSSH(ctx, svc, cfg.AWSConfig.NodesSecurityGroupID); err != nil {
+			sglog.FromContext(ctx).Errorf("authorize ssh for nodes caused %v", err)
+			return errors.Wrapf(err, "%s authorize ssh for nodes",
+				StepCreateSecurityGroups)
+		}
 	}
 
-	if err := s.authorizeSSH(ctx, svc, cfg.AWSConfig.NodesSecurityGroupID); err != nil {
-		logrus.Errorf("authorize ssh for nodes caused %v", err)
-		return errors.Wrapf(err, "%s authorize ssh for nodes",
-			StepCreateSecurityGroups)
+	// windows nodes are managed with WinRM over HTTPS
+	if cfg.Kube.WinRMConfig.Password != "" {
+		sglog.FromContext(ctx).Debugf("Authorize WinRM for nodes")
+		if err := s.authorizeWinRM(ctx, svc, cfg.AWSConfig.NodesSecurityGroupID, cfg.Kube.WinRMConfig.Port); err != nil {
+			sglog.FromContext(ctx).Errorf("authorize winrm for nodes caused %v", err)
+			return errors.Wrapf(err, "%s authorize winrm for nodes",
+				StepCreateSecurityGroups)
+		}
 	}
 
-	logrus.Debugf("Allow traffic between groups")
+	sglog.FromContext(ctx).Debugf("Allow traffic between groups")
 	//Open ports between master <-> node security groups
 	// nodes to nodes
 	if err := s.allowAllTraffic(ctx, svc, cfg); err != nil {
 		return err
 	}
 
-	logrus.Debugf("Whitelist addresses SG and provided addresses")
+	sglog.FromContext(ctx).Debugf("Whitelist addresses SG and provided addresses")
 	if err := s.whiteListAddresses(ctx, svc, cfg.AWSConfig.MastersSecurityGroupID, cfg.Kube.ExposedAddresses, cfg.Kube.APIServerPort); err != nil {
-		logrus.Errorf("[%s] - failed to whitelist addresses in master security group: %v", s.Name(), err)
+		sglog.FromContext(ctx).Errorf("[%s] - failed to whitelist addresses in master security group: %v", s.Name(), err)
 		return errors.Wrapf(err, "%s failed whitelisting addresses", s.Name())
 	}
 
@@ -136,6 +158,22 @@ func (s *CreateSecurityGroupsStep) authorizeSSH(ctx context.Context, EC2 secGrou
 	return err
 }
 
+func (s *CreateSecurityGroupsStep) authorizeWinRM(ctx context.Context, EC2 secGroupService, groupID string, port int) error {
+	if port == 0 {
+		port = winrm.DefaultHTTPSPort
+	}
+
+	_, err := EC2.AuthorizeSecurityGroupIngressWithContext(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
+		GroupId:    aws.String(groupID),
+		FromPort:   aws.Int64(int64(port)),
+		ToPort:     aws.Int64(int64(port)),
+		CidrIp:     aws.String("0.0.0.0/0"),
+		IpProtocol: aws.String("tcp"),
+	})
+
+	return err
+}
+
 func (s *CreateSecurityGroupsStep) allowAllTraffic(ctx context.Context, EC2 secGroupService, cfg *steps.Config) error {
 	_, err := EC2.AuthorizeSecurityGroupIngressWithContext(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
 		GroupId: aws.String(cfg.AWSConfig.MastersSecurityGroupID),
@@ -234,6 +272,13 @@ func (*CreateSecurityGroupsStep) Depends() []string {
 	return nil
 }
 
+// securityGroupName returns the name of the security group of the role.
+func securityGroupName(cfg *steps.Config, role model.Role) (string, error) {
+	vars := cfg.NameVars()
+	vars.Role, vars.NodeGroup = string(role), string(role)
+	return cfg.Kube.Naming.SecurityGroupName(vars)
+}
+
 func (*CreateSecurityGroupsStep) Rollback(context.Context, io.Writer, *steps.Config) error {
 	return nil
 }
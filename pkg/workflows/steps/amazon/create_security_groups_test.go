@@ -14,6 +14,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/supergiant/control/pkg/runner"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -239,6 +240,85 @@ func TestCreateSecurityGroupsStep_Run(t *testing.T) {
 	}
 }
 
+func TestCreateSecurityGroupsStep_RunSSM(t *testing.T) {
+	svc := &mockSecurityGroupSvc{}
+	svc.On("CreateSecurityGroupWithContext",
+		mock.Anything, mock.Anything, mock.Anything).
+		Return(&ec2.CreateSecurityGroupOutput{
+			GroupId: aws.String("groupID"),
+		}, nil)
+	svc.On("AuthorizeSecurityGroupIngressWithContext",
+		mock.Anything, mock.Anything, mock.Anything).
+		Return(&ec2.AuthorizeSecurityGroupIngressOutput{}, nil)
+
+	config := &steps.Config{
+		AWSConfig: steps.AWSConfig{
+			VPCID: "1234",
+		},
+	}
+	config.Kube.RunnerType = runner.SSM
+
+	step := &CreateSecurityGroupsStep{
+		getSvc: func(config steps.AWSConfig) (secGroupService, error) {
+			return svc, nil
+		},
+		findOutboundIP: func() (string, error) {
+			return "10.20.30.40", nil
+		},
+	}
+
+	if err := step.Run(context.Background(), &bytes.Buffer{}, config); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, call := range svc.Calls {
+		req, ok := call.Arguments.Get(1).(*ec2.AuthorizeSecurityGroupIngressInput)
+		if ok && req.FromPort != nil && *req.FromPort == 22 {
+			t.Errorf("ssh port must not be opened for ssm runner")
+		}
+	}
+}
+
+func TestCreateSecurityGroupsStep_RunWinRM(t *testing.T) {
+	svc := &mockSecurityGroupSvc{}
+	svc.On("CreateSecurityGroupWithContext",
+		mock.Anything, mock.Anything, mock.Anything).
+		Return(&ec2.CreateSecurityGroupOutput{
+			GroupId: aws.String("groupID"),
+		}, nil)
+	svc.On("AuthorizeSecurityGroupIngressWithContext",
+		mock.Anything, mock.Anything, mock.Anything).
+		Return(&ec2.AuthorizeSecurityGroupIngressOutput{}, nil)
+
+	config := &steps.Config{
+		AWSConfig: steps.AWSConfig{
+			VPCID: "1234",
+		},
+	}
+	config.Kube.WinRMConfig.Password = "secret"
+
+	step := &CreateSecurityGroupsStep{
+		getSvc: func(config steps.AWSConfig) (secGroupService, error) {
+			return svc, nil
+		},
+		findOutboundIP: func() (string, error) {
+			return "10.20.30.40", nil
+		},
+	}
+
+	if err := step.Run(context.Background(), &bytes.Buffer{}, config); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, call := range svc.Calls {
+		req, ok := call.Arguments.Get(1).(*ec2.AuthorizeSecurityGroupIngressInput)
+		if ok && req.FromPort != nil && *req.FromPort == 5986 {
+			return
+		}
+	}
+	t.Errorf("winrm port must be opened for windows nodes")
+}
+
 func TestInitCreateSecurityGroups(t *testing.T) {
 	InitCreateSecurityGroups(GetEC2)
 
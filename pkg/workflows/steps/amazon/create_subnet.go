@@ -15,6 +15,7 @@ import (
 
 	"github.com/supergiant/control/pkg/account"
 	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -74,7 +75,7 @@ func (s *CreateSubnetsStep) Run(ctx context.Context, w io.Writer, cfg *steps.Con
 	svc, err := s.getSvc(cfg.AWSConfig)
 
 	if err != nil {
-		logrus.Errorf("%s error getting service %v",
+		sglog.FromContext(ctx).Errorf("%s error getting service %v",
 			StepCreateSubnets, err)
 		return errors.Wrapf(err, "%s error getting service",
 			StepCreateSubnets)
@@ -83,7 +84,7 @@ func (s *CreateSubnetsStep) Run(ctx context.Context, w io.Writer, cfg *steps.Con
 	zoneGetter, err := s.zoneGetterFactory(ctx, s.accountGetter, cfg)
 
 	if err != nil {
-		logrus.Errorf("Create zone getter caused error %v", err)
+		sglog.FromContext(ctx).Errorf("Create zone getter caused error %v", err)
 		return errors.Wrapf(err, "create subnets for vpc %s", cfg.AWSConfig.VPCID)
 	}
 
@@ -92,14 +93,14 @@ func (s *CreateSubnetsStep) Run(ctx context.Context, w io.Writer, cfg *steps.Con
 		cfg.AWSConfig.Subnets = make(map[string]string)
 	}
 
-	logrus.Debugf(cfg.AWSConfig.VPCCIDR)
-	logrus.Debugf("Create subnet in VPC %s", cfg.AWSConfig.VPCID)
+	sglog.FromContext(ctx).Debugf(cfg.AWSConfig.VPCCIDR)
+	sglog.FromContext(ctx).Debugf("Create subnet in VPC %s", cfg.AWSConfig.VPCID)
 
-	logrus.Debugf("get zones for region %s", cfg.AWSConfig.Region)
+	sglog.FromContext(ctx).Debugf("get zones for region %s", cfg.AWSConfig.Region)
 	zones, err := zoneGetter.GetZones(ctx, *cfg)
 
 	if err != nil {
-		logrus.Errorf("Error getting zones for region %s",
+		sglog.FromContext(ctx).Errorf("Error getting zones for region %s",
 			cfg.AWSConfig.Region)
 		return errors.Wrapf(err, "Error getting zone for region %s",
 			cfg.AWSConfig.Region)
@@ -110,18 +111,18 @@ func (s *CreateSubnetsStep) Run(ctx context.Context, w io.Writer, cfg *steps.Con
 		_, cidrIP, err := net.ParseCIDR(cfg.AWSConfig.VPCCIDR)
 
 		if err != nil {
-			logrus.Errorf("Error parsing VPC cidr %s",
+			sglog.FromContext(ctx).Errorf("Error parsing VPC cidr %s",
 				cfg.AWSConfig.VPCCIDR)
 			return errors.Wrapf(err, "Error parsing VPC cidr %s",
 				cfg.AWSConfig.VPCCIDR)
 		}
 
-		logrus.Info(cidrIP)
+		sglog.FromContext(ctx).Info(cidrIP)
 		subnetCidr, err := cidr.Subnet(cidrIP, 8, rand.Int()%256)
-		logrus.Debugf("Subnet cidr %s", subnetCidr)
+		sglog.FromContext(ctx).Debugf("Subnet cidr %s", subnetCidr)
 
 		if err != nil {
-			logrus.Debugf("Calculating subnet cidr caused %s", err.Error())
+			sglog.FromContext(ctx).Debugf("Calculating subnet cidr caused %s", err.Error())
 			return errors.Wrapf(err, "%s Calculating subnet"+
 				" cidr caused error", StepCreateSubnets)
 		}
@@ -133,7 +134,7 @@ func (s *CreateSubnetsStep) Run(ctx context.Context, w io.Writer, cfg *steps.Con
 		}
 		out, err := svc.CreateSubnetWithContext(ctx, input)
 		if err != nil {
-			logrus.Debugf("Create subnet cause error %s", err.Error())
+			sglog.FromContext(ctx).Debugf("Create subnet cause error %s", err.Error())
 			return errors.Wrap(ErrCreateSubnet, err.Error())
 		}
 
@@ -147,7 +148,7 @@ func (s *CreateSubnetsStep) Run(ctx context.Context, w io.Writer, cfg *steps.Con
 		_, err = svc.ModifySubnetAttributeWithContext(ctx, modifyReq)
 
 		if err != nil {
-			logrus.Debugf("Modify subnet cause error %s", err.Error())
+			sglog.FromContext(ctx).Debugf("Modify subnet cause error %s", err.Error())
 			return errors.Wrap(ErrCreateSubnet, err.Error())
 		}
 
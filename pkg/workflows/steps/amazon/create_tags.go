@@ -10,6 +10,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -46,12 +47,12 @@ func NewCreateTagsStep(ec2fn GetEC2Fn) *CreateTagsStep {
 }
 
 func (s *CreateTagsStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
-	logrus.Debugf(StepCreateTags)
+	sglog.FromContext(ctx).Debugf(StepCreateTags)
 
 	svc, err := s.getService(cfg.AWSConfig)
 
 	if err != nil {
-		logrus.Errorf("error getting service on step %s %v",
+		sglog.FromContext(ctx).Errorf("error getting service on step %s %v",
 			StepCreateTags, err)
 		return errors.Wrapf(err, "error getting service on step %s",
 			StepCreateTags)
@@ -86,7 +87,7 @@ func (s *CreateTagsStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config
 	_, err = svc.CreateTags(input)
 
 	if err != nil {
-		logrus.Debugf("Error creating tags for aws entities %v", err)
+		sglog.FromContext(ctx).Debugf("Error creating tags for aws entities %v", err)
 		return err
 	}
 
@@ -6,9 +6,10 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/util"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
@@ -52,6 +53,10 @@ func (c *CreateVPCStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config)
 		}
 		cfg.AWSConfig.VPCID = *out.Vpc.VpcId
 
+		if err = tagVPCName(ctx, EC2, cfg); err != nil {
+			return errors.Wrap(ErrCreateVPC, err.Error())
+		}
+
 		vpcattr := &ec2.ModifyVpcAttributeInput{
 			EnableDnsHostnames: &ec2.AttributeBooleanValue{
 				Value: aws.Bool(true),
@@ -69,7 +74,7 @@ func (c *CreateVPCStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config)
 		err = EC2.WaitUntilVpcExistsWithContext(ctx, desc)
 
 		if err != nil {
-			logrus.Debugf("error waiting for vpc %s %s",
+			sglog.FromContext(ctx).Debugf("error waiting for vpc %s %s",
 				cfg.AWSConfig.VPCID, err.Error())
 			return errors.Wrapf(err, "create vpc error wait")
 		}
@@ -108,6 +113,25 @@ func (c *CreateVPCStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config)
 	return nil
 }
 
+// tagVPCName names the VPC after the naming template of the kube.
+func tagVPCName(ctx context.Context, EC2 ec2iface.EC2API, cfg *steps.Config) error {
+	name, err := cfg.Kube.Naming.VPCName(cfg.NameVars())
+	if err != nil || name == "" {
+		return err
+	}
+
+	_, err = EC2.CreateTagsWithContext(ctx, &ec2.CreateTagsInput{
+		Resources: []*string{aws.String(cfg.AWSConfig.VPCID)},
+		Tags: []*ec2.Tag{
+			{
+				Key:   aws.String("Name"),
+				Value: aws.String(name),
+			},
+		},
+	})
+	return err
+}
+
 func (*CreateVPCStep) Name() string {
 	return StepCreateVPC
 }
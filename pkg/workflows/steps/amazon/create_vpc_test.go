@@ -23,9 +23,15 @@ type fakeEC2VPC struct {
 	createVPCOutput   *ec2.CreateVpcOutput
 	describeVPCOutput *ec2.DescribeVpcsOutput
 	modifyVPCOut      *ec2.ModifyVpcAttributeOutput
+	tags              *ec2.CreateTagsInput
 	err               error
 }
 
+func (f *fakeEC2VPC) CreateTagsWithContext(_ aws.Context, input *ec2.CreateTagsInput, _ ...request.Option) (*ec2.CreateTagsOutput, error) {
+	f.tags = input
+	return &ec2.CreateTagsOutput{}, f.err
+}
+
 func (f *fakeEC2VPC) CreateVpcWithContext(aws.Context, *ec2.CreateVpcInput, ...request.Option) (*ec2.CreateVpcOutput, error) {
 	return f.createVPCOutput, f.err
 }
@@ -179,6 +185,31 @@ func TestCreateVPCStep_Run(t *testing.T) {
 	}
 }
 
+func TestCreateVPCStep_Naming(t *testing.T) {
+	cfg, err := steps.NewConfig("prod", "TEST", profile.Profile{
+		Region:   "us-east-1",
+		Provider: clouds.AWS,
+		Naming:   profile.Naming{VPC: "vpc-{{.ClusterName}}-{{.Region}}"},
+	})
+	require.NoError(t, err)
+
+	svc := &fakeEC2VPC{
+		createVPCOutput: &ec2.CreateVpcOutput{
+			Vpc: &ec2.Vpc{
+				VpcId: aws.String("vpc-1"),
+			},
+		},
+	}
+	step := NewCreateVPCStep(func(steps.AWSConfig) (ec2iface.EC2API, error) {
+		return svc, nil
+	})
+	require.NoError(t, step.Run(context.Background(), &bytes.Buffer{}, cfg))
+
+	require.NotNil(t, svc.tags)
+	require.Equal(t, "vpc-1", aws.StringValue(svc.tags.Resources[0]))
+	require.Equal(t, "vpc-prod-us-east-1", aws.StringValue(svc.tags.Tags[0].Value))
+}
+
 func TestInitCreateVPC(t *testing.T) {
 	InitCreateVPC(GetEC2)
 
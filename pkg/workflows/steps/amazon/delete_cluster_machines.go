@@ -8,9 +8,9 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
 	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/util"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
@@ -42,13 +42,13 @@ func NewDeleteClusterInstances(fn GetEC2Fn) *DeleteClusterMachines {
 
 func (s *DeleteClusterMachines) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
 	log := util.GetLogger(w)
-	logrus.Infof("[%s] - deleting cluster %s machines",
+	sglog.FromContext(ctx).Infof("[%s] - deleting cluster %s machines",
 		s.Name(), cfg.Kube.Name)
 
 	svc, err := s.getSvc(cfg.AWSConfig)
 
 	if err != nil {
-		logrus.Errorf("%s Error getting service %v",
+		sglog.FromContext(ctx).Errorf("%s Error getting service %v",
 			DeleteClusterMachinesStepName, err)
 		return errors.Wrapf(err, "%s error getting service",
 			DeleteClusterMachinesStepName)
@@ -80,7 +80,7 @@ func (s *DeleteClusterMachines) Run(ctx context.Context, w io.Writer, cfg *steps
 		}
 	}
 	if len(instanceIDS) == 0 {
-		logrus.Infof("[%s] - no nodes in k8s cluster %s", s.Name(), cfg.Kube.Name)
+		sglog.FromContext(ctx).Infof("[%s] - no nodes in k8s cluster %s", s.Name(), cfg.Kube.Name)
 		return nil
 	}
 
@@ -89,7 +89,7 @@ func (s *DeleteClusterMachines) Run(ctx context.Context, w io.Writer, cfg *steps
 	})
 
 	if err != nil {
-		logrus.Error(ErrDeleteCluster, err.Error())
+		sglog.FromContext(ctx).Error(ErrDeleteCluster, err.Error())
 		return errors.Wrap(ErrDeleteCluster, err.Error())
 	}
 
@@ -98,11 +98,11 @@ func (s *DeleteClusterMachines) Run(ctx context.Context, w io.Writer, cfg *steps
 	})
 
 	if err != nil {
-		logrus.Error(ErrDeleteCluster, err.Error())
+		sglog.FromContext(ctx).Error(ErrDeleteCluster, err.Error())
 	}
 
 	log.Infof("[%s] - completed", s.Name())
-	logrus.Infof("[%s] Deleted AWS cluster %s",
+	sglog.FromContext(ctx).Infof("[%s] Deleted AWS cluster %s",
 		s.Name(), cfg.Kube.Name)
 
 	return nil
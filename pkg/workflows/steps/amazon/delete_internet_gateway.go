@@ -7,8 +7,8 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -43,17 +43,17 @@ func NewDeleteInernetGateway(fn GetEC2Fn) *DeleteInternetGateway {
 
 func (s *DeleteInternetGateway) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
 	if cfg.AWSConfig.InternetGatewayID == "" {
-		logrus.Debug("Skip deleting empty Internet GW")
+		sglog.FromContext(ctx).Debug("Skip deleting empty Internet GW")
 		return nil
 	}
 
 	svc, err := s.getIGWService(cfg.AWSConfig)
 	if err != nil {
-		logrus.Errorf("Error while getting IGW deleter %v", err)
+		sglog.FromContext(ctx).Errorf("Error while getting IGW deleter %v", err)
 		return errors.Wrap(ErrAuthorization, err.Error())
 	}
 
-	logrus.Debugf("Detach internet gateway %s from vpc %s",
+	sglog.FromContext(ctx).Debugf("Detach internet gateway %s from vpc %s",
 		cfg.AWSConfig.InternetGatewayID, cfg.AWSConfig.VPCID)
 	_, err = svc.DetachInternetGateway(&ec2.DetachInternetGatewayInput{
 		InternetGatewayId: aws.String(cfg.AWSConfig.InternetGatewayID),
@@ -61,12 +61,12 @@ func (s *DeleteInternetGateway) Run(ctx context.Context, w io.Writer, cfg *steps
 	})
 
 	if err != nil {
-		logrus.Debugf("Detach internet gateway %s from vpc %s caused %v",
+		sglog.FromContext(ctx).Debugf("Detach internet gateway %s from vpc %s caused %v",
 			cfg.AWSConfig.InternetGatewayID, cfg.AWSConfig.VPCID, err)
 		return errors.Wrapf(err, "Detach internet gateway")
 	}
 
-	logrus.Debugf("Delete internet gateway %s from vpc %s",
+	sglog.FromContext(ctx).Debugf("Delete internet gateway %s from vpc %s",
 		cfg.AWSConfig.InternetGatewayID, cfg.AWSConfig.VPCID)
 
 	_, err = svc.DeleteInternetGateway(&ec2.DeleteInternetGatewayInput{
@@ -74,11 +74,11 @@ func (s *DeleteInternetGateway) Run(ctx context.Context, w io.Writer, cfg *steps
 	})
 
 	if err != nil {
-		logrus.Debugf("DeleteInternetGateway caused %s", err.Error())
+		sglog.FromContext(ctx).Debugf("DeleteInternetGateway caused %s", err.Error())
 		return errors.Wrapf(err, "DeleteInternetGateway")
 	}
 
-	logrus.Debugf("Internet gateway %s was deleted from vpc %s",
+	sglog.FromContext(ctx).Debugf("Internet gateway %s was deleted from vpc %s",
 		cfg.AWSConfig.InternetGatewayID, cfg.AWSConfig.VPCID)
 	return nil
 }
@@ -8,8 +8,8 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -49,14 +49,14 @@ func NewDeleteKeyPairStep(fn GetEC2Fn) *DeleteKeyPair {
 
 func (s *DeleteKeyPair) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
 	if cfg.AWSConfig.KeyPairName == "" || cfg.AWSConfig.KeyID == "" {
-		logrus.Debugf("Skip deleting empty key pair")
+		sglog.FromContext(ctx).Debugf("Skip deleting empty key pair")
 		return nil
 	}
 
 	svc, err := s.getSvc(cfg.AWSConfig)
 
 	if err != nil {
-		logrus.Errorf("Error getting EC2 key service %v", err)
+		sglog.FromContext(ctx).Errorf("Error getting EC2 key service %v", err)
 		return errors.Wrap(ErrAuthorization, err.Error())
 	}
 
@@ -66,14 +66,14 @@ func (s *DeleteKeyPair) Run(ctx context.Context, w io.Writer, cfg *steps.Config)
 	)
 
 	for i := 0; i < deleteKeyPairAttemptCount; i++ {
-		logrus.Debugf("Delete Key pair %s %s in vpc %s",
+		sglog.FromContext(ctx).Debugf("Delete Key pair %s %s in vpc %s",
 			cfg.AWSConfig.KeyPairName, cfg.AWSConfig.KeyID, cfg.AWSConfig.VPCID)
 		_, deleteErr = svc.DeleteKeyPair(&ec2.DeleteKeyPairInput{
 			KeyName: aws.String(cfg.AWSConfig.KeyPairName),
 		})
 
 		if deleteErr != nil {
-			logrus.Debugf("Delete Key pair %s caused %s retry in %v ",
+			sglog.FromContext(ctx).Debugf("Delete Key pair %s caused %s retry in %v ",
 				cfg.AWSConfig.KeyPairName, deleteErr.Error(), timeout)
 			time.Sleep(timeout)
 			timeout = timeout * 2
@@ -10,6 +10,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -48,7 +49,7 @@ func NewDeleteLoadBalancerStep(getELBFn GetELBFn) *DeleteLoadBalancerStep {
 func (s *DeleteLoadBalancerStep) Run(ctx context.Context, out io.Writer, cfg *steps.Config) error {
 	svc, err := s.getLoadBalancerService(cfg.AWSConfig)
 	if err != nil {
-		logrus.Errorf("error getting ELB service %v", err)
+		sglog.FromContext(ctx).Errorf("error getting ELB service %v", err)
 		return errors.Wrapf(err, "error getting ELB service %s",
 			DeleteLoadBalancerStepName)
 	}
@@ -58,7 +59,7 @@ func (s *DeleteLoadBalancerStep) Run(ctx context.Context, out io.Writer, cfg *st
 			LoadBalancerName: aws.String(cfg.AWSConfig.ExternalLoadBalancerName),
 		})
 		if err != nil {
-			logrus.Errorf("error deleting external loadbalancer %s %v", cfg.AWSConfig.ExternalLoadBalancerName, err)
+			sglog.FromContext(ctx).Errorf("error deleting external loadbalancer %s %v", cfg.AWSConfig.ExternalLoadBalancerName, err)
 			return errors.Wrapf(err, "error deleteing external Load balancer %s %s", cfg.AWSConfig.ExternalLoadBalancerName,
 				DeleteLoadBalancerStepName)
 		}
@@ -69,7 +70,7 @@ func (s *DeleteLoadBalancerStep) Run(ctx context.Context, out io.Writer, cfg *st
 			LoadBalancerName: aws.String(cfg.AWSConfig.InternalLoadBalancerName),
 		})
 		if err != nil {
-			logrus.Errorf("error deleting internal loadbalancer %s %v", cfg.AWSConfig.InternalLoadBalancerName, err)
+			sglog.FromContext(ctx).Errorf("error deleting internal loadbalancer %s %v", cfg.AWSConfig.InternalLoadBalancerName, err)
 			return errors.Wrapf(err, "error deleteing internal Load balancer %s %s", cfg.AWSConfig.InternalLoadBalancerName,
 				DeleteLoadBalancerStepName)
 		}
@@ -9,9 +9,10 @@ import (
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
 	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/util"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
@@ -48,16 +49,19 @@ func NewDeleteNode(fn GetEC2Fn) *DeleteNodeStep {
 
 func (s *DeleteNodeStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
 	log := util.GetLogger(w)
-	logrus.Infof("[%s] - deleting node %s", s.Name(), cfg.Node.Name)
+	sglog.FromContext(ctx).Infof("[%s] - deleting node %s", s.Name(), cfg.Node.Name)
+	if cfg.Node.Protected {
+		return errors.Wrapf(sgerrors.ErrProtected, "%s node %s", DeleteNodeStepName, cfg.Node.Name)
+	}
 
 	svc, err := s.getSvc(cfg.AWSConfig)
 
 	if err != nil {
-		logrus.Errorf("Error getting service %v", err)
+		sglog.FromContext(ctx).Errorf("Error getting service %v", err)
 		return errors.Wrap(ErrAuthorization, err.Error())
 	}
 
-	logrus.Debugf("Get instance by name filter %s", cfg.Node.Name)
+	sglog.FromContext(ctx).Debugf("Get instance by name filter %s", cfg.Node.Name)
 	describeInstanceOutput, err := svc.DescribeInstancesWithContext(ctx, &ec2.DescribeInstancesInput{
 		Filters: []*ec2.Filter{
 			{
@@ -71,7 +75,7 @@ func (s *DeleteNodeStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config
 		return errors.Wrap(ErrDeleteNode, err.Error())
 	}
 
-	logrus.Debugf("Got %d instance outputs",
+	sglog.FromContext(ctx).Debugf("Got %d instance outputs",
 		len(describeInstanceOutput.Reservations))
 	instanceIDS := make([]string, 0)
 	spotRequestIDs := make([]string, 0)
@@ -86,12 +90,12 @@ func (s *DeleteNodeStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config
 	}
 
 	if len(instanceIDS) == 0 {
-		logrus.Infof("[%s] - node %s not found in cluster %s",
+		sglog.FromContext(ctx).Infof("[%s] - node %s not found in cluster %s",
 			s.Name(), cfg.Node.Name, cfg.Kube.Name)
 		return nil
 	}
 
-	logrus.Debugf("Node to be deleted Name: %s AWS id: %v",
+	sglog.FromContext(ctx).Debugf("Node to be deleted Name: %s AWS id: %v",
 		cfg.Node.Name, instanceIDS)
 	_, err = svc.TerminateInstancesWithContext(ctx,
 		&ec2.TerminateInstancesInput{
@@ -108,7 +112,7 @@ func (s *DeleteNodeStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config
 		})
 
 	if err != nil {
-		logrus.Errorf("cancel spot requests caused %v", err)
+		sglog.FromContext(ctx).Errorf("cancel spot requests caused %v", err)
 	}
 
 	log.Infof("[%s] - finished successfully", s.Name())
@@ -13,6 +13,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/supergiant/control/pkg/sgerrors"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -234,3 +235,24 @@ func TestDeleteNodeStep_Description(t *testing.T) {
 			"Deletes node in aws cluster actual %s", desc)
 	}
 }
+
+func TestDeleteNodeStep_RunProtected(t *testing.T) {
+	svc := &mockInstanceDeleter{}
+	step := &DeleteNodeStep{
+		getSvc: func(steps.AWSConfig) (instanceDeleter, error) {
+			return svc, nil
+		},
+	}
+
+	cfg := &steps.Config{}
+	cfg.Node.Name = "node-1"
+	cfg.Node.Protected = true
+
+	err := step.Run(context.Background(), &bytes.Buffer{}, cfg)
+	if !sgerrors.IsProtected(err) {
+		t.Errorf("protected error expected, got %v", err)
+	}
+	if len(svc.Calls) > 0 {
+		t.Errorf("protected node must not be terminated")
+	}
+}
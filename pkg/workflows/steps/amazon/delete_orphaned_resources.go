@@ -0,0 +1,324 @@
+package amazon
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/sglog"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	DeleteOrphanedResourcesStepName = "aws_delete_orphaned_resources"
+
+	LeftoverNetworkInterface = "networkInterface"
+	LeftoverSecurityGroup    = "securityGroup"
+	LeftoverAddress          = "elasticIp"
+	LeftoverVolume           = "volume"
+
+	// pvcNameTag is put on ebs volumes that kubernetes provisions for claims.
+	pvcNameTag = "kubernetes.io/created-for/pvc/name"
+)
+
+var (
+	deleteOrphanTimeout      = time.Second * 5
+	deleteOrphanAttemptCount = 5
+)
+
+type orphanedResourcesService interface {
+	DescribeNetworkInterfacesWithContext(aws.Context, *ec2.DescribeNetworkInterfacesInput, ...request.Option) (*ec2.DescribeNetworkInterfacesOutput, error)
+	DeleteNetworkInterfaceWithContext(aws.Context, *ec2.DeleteNetworkInterfaceInput, ...request.Option) (*ec2.DeleteNetworkInterfaceOutput, error)
+	DescribeSecurityGroupsWithContext(aws.Context, *ec2.DescribeSecurityGroupsInput, ...request.Option) (*ec2.DescribeSecurityGroupsOutput, error)
+	DeleteSecurityGroupWithContext(aws.Context, *ec2.DeleteSecurityGroupInput, ...request.Option) (*ec2.DeleteSecurityGroupOutput, error)
+	DescribeAddressesWithContext(aws.Context, *ec2.DescribeAddressesInput, ...request.Option) (*ec2.DescribeAddressesOutput, error)
+	ReleaseAddressWithContext(aws.Context, *ec2.ReleaseAddressInput, ...request.Option) (*ec2.ReleaseAddressOutput, error)
+	DescribeVolumesWithContext(aws.Context, *ec2.DescribeVolumesInput, ...request.Option) (*ec2.DescribeVolumesOutput, error)
+	DeleteVolumeWithContext(aws.Context, *ec2.DeleteVolumeInput, ...request.Option) (*ec2.DeleteVolumeOutput, error)
+}
+
+// DeleteOrphanedResources deletes resources that kubernetes and cni plugins
+// create outside of control: detached network interfaces and security
+// groups of service load balancers in the vpc, elastic ips and ebs volumes
+// of persistent volume claims. Resources that can't be deleted are recorded
+// in the delete config, so the rest of the cluster is still deleted.
+type DeleteOrphanedResources struct {
+	getSvc func(steps.AWSConfig) (orphanedResourcesService, error)
+}
+
+func InitDeleteOrphanedResources(fn GetEC2Fn) {
+	steps.RegisterStep(DeleteOrphanedResourcesStepName, NewDeleteOrphanedResources(fn))
+}
+
+func NewDeleteOrphanedResources(fn GetEC2Fn) *DeleteOrphanedResources {
+	return &DeleteOrphanedResources{
+		getSvc: func(cfg steps.AWSConfig) (orphanedResourcesService, error) {
+			EC2, err := fn(cfg)
+
+			if err != nil {
+				return nil, errors.Wrap(ErrAuthorization, err.Error())
+			}
+
+			return EC2, nil
+		},
+	}
+}
+
+func (s *DeleteOrphanedResources) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
+	log := util.GetLogger(w)
+	svc, err := s.getSvc(cfg.AWSConfig)
+
+	if err != nil {
+		return errors.Wrapf(err, "%s get service", DeleteOrphanedResourcesStepName)
+	}
+
+	for _, cleanup := range []struct {
+		kind string
+		fn   func(context.Context, orphanedResourcesService, *steps.Config) (map[string]error, error)
+	}{
+		{LeftoverNetworkInterface, deleteNetworkInterfaces},
+		{LeftoverSecurityGroup, deleteServiceSecurityGroups},
+		{LeftoverAddress, releaseAddresses},
+		{LeftoverVolume, deleteVolumes},
+	} {
+		failed, err := cleanup.fn(ctx, svc, cfg)
+		if err != nil {
+			return errors.Wrapf(err, "%s list %s", DeleteOrphanedResourcesStepName, cleanup.kind)
+		}
+		for id, err := range failed {
+			log.Errorf("[%s] - delete %s %s caused %v", s.Name(), cleanup.kind, id, err)
+			cfg.DeleteConfig.AddLeftover(cleanup.kind, id, err.Error())
+		}
+	}
+
+	sglog.FromContext(ctx).Debugf("Deleting orphaned resources of cluster %s finished", cfg.Kube.ID)
+	return nil
+}
+
+func (*DeleteOrphanedResources) Name() string {
+	return DeleteOrphanedResourcesStepName
+}
+
+func (*DeleteOrphanedResources) Depends() []string {
+	return []string{DeleteClusterMachinesStepName, DeleteSecurityGroupsStepName}
+}
+
+func (*DeleteOrphanedResources) Description() string {
+	return "Delete network interfaces, elastic ips and volumes left by kubernetes"
+}
+
+func (*DeleteOrphanedResources) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func deleteNetworkInterfaces(ctx context.Context, svc orphanedResourcesService, cfg *steps.Config) (map[string]error, error) {
+	failed := make(map[string]error)
+	if cfg.AWSConfig.VPCID == "" {
+		return failed, nil
+	}
+
+	output, err := svc.DescribeNetworkInterfacesWithContext(ctx, &ec2.DescribeNetworkInterfacesInput{
+		Filters: []*ec2.Filter{
+			vpcFilter(cfg.AWSConfig.VPCID),
+			{
+				Name:   aws.String("status"),
+				Values: aws.StringSlice([]string{ec2.NetworkInterfaceStatusAvailable}),
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, eni := range output.NetworkInterfaces {
+		_, err = svc.DeleteNetworkInterfaceWithContext(ctx, &ec2.DeleteNetworkInterfaceInput{
+			NetworkInterfaceId: eni.NetworkInterfaceId,
+		})
+		if err != nil {
+			failed[aws.StringValue(eni.NetworkInterfaceId)] = err
+		}
+	}
+
+	return failed, nil
+}
+
+// deleteServiceSecurityGroups deletes groups of the vpc besides the default
+// one, groups of control are deleted already. Network interfaces of deleted
+// load balancers are released asynchronously, so deletion is retried.
+func deleteServiceSecurityGroups(ctx context.Context, svc orphanedResourcesService, cfg *steps.Config) (map[string]error, error) {
+	failed := make(map[string]error)
+	if cfg.AWSConfig.VPCID == "" {
+		return failed, nil
+	}
+
+	output, err := svc.DescribeSecurityGroupsWithContext(ctx, &ec2.DescribeSecurityGroupsInput{
+		Filters: []*ec2.Filter{vpcFilter(cfg.AWSConfig.VPCID)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range output.SecurityGroups {
+		if aws.StringValue(group.GroupName) == "default" {
+			continue
+		}
+		err = retryOrphan(ctx, func() error {
+			_, err := svc.DeleteSecurityGroupWithContext(ctx, &ec2.DeleteSecurityGroupInput{
+				GroupId: group.GroupId,
+			})
+			return err
+		})
+		if err != nil {
+			failed[aws.StringValue(group.GroupId)] = err
+		}
+	}
+
+	return failed, nil
+}
+
+// releaseAddresses releases elastic ips tagged with the cluster id, they are
+// disassociated when instances are terminated.
+func releaseAddresses(ctx context.Context, svc orphanedResourcesService, cfg *steps.Config) (map[string]error, error) {
+	failed := make(map[string]error)
+
+	output, err := svc.DescribeAddressesWithContext(ctx, &ec2.DescribeAddressesInput{
+		Filters: clusterTagFilters(cfg.Kube.ID, cfg.Kube.Name)[0],
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, address := range output.Addresses {
+		err = retryOrphan(ctx, func() error {
+			_, err := svc.ReleaseAddressWithContext(ctx, &ec2.ReleaseAddressInput{
+				AllocationId: address.AllocationId,
+			})
+			return err
+		})
+		if err != nil {
+			failed[aws.StringValue(address.PublicIp)] = err
+		}
+	}
+
+	return failed, nil
+}
+
+// deleteVolumes deletes volumes of persistent volume claims in zones of the
+// cluster and volumes tagged with the cluster id. Volumes of terminated
+// instances are detached asynchronously, so deletion is retried.
+func deleteVolumes(ctx context.Context, svc orphanedResourcesService, cfg *steps.Config) (map[string]error, error) {
+	failed := make(map[string]error)
+
+	for _, f := range volumeFilters(cfg) {
+		output, err := svc.DescribeVolumesWithContext(ctx, &ec2.DescribeVolumesInput{
+			Filters: f,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, volume := range output.Volumes {
+			err = retryOrphan(ctx, func() error {
+				_, err := svc.DeleteVolumeWithContext(ctx, &ec2.DeleteVolumeInput{
+					VolumeId: volume.VolumeId,
+				})
+				return err
+			})
+			if err != nil {
+				failed[aws.StringValue(volume.VolumeId)] = err
+			}
+		}
+	}
+
+	return failed, nil
+}
+
+// clusterTagFilters match resources tagged by control with the cluster id
+// and by the cloud provider of kubernetes with the cluster name.
+func clusterTagFilters(clusterID, clusterName string) [][]*ec2.Filter {
+	return [][]*ec2.Filter{
+		{
+			{
+				Name:   aws.String("tag:" + clouds.TagClusterID),
+				Values: aws.StringSlice([]string{clusterID}),
+			},
+		},
+		{
+			{
+				Name:   aws.String("tag:" + clouds.TagKubernetesCluster),
+				Values: aws.StringSlice([]string{clusterName}),
+			},
+		},
+		{
+			{
+				Name:   aws.String("tag-key"),
+				Values: aws.StringSlice([]string{"kubernetes.io/cluster/" + clusterName}),
+			},
+		},
+	}
+}
+
+// volumeFilters match volumes tagged with the cluster id and volumes of
+// claims in zones of the cluster. Kubernetes tags volumes with the cluster
+// name only, zones keep volumes of other clusters with the same name.
+func volumeFilters(cfg *steps.Config) [][]*ec2.Filter {
+	filters := clusterTagFilters(cfg.Kube.ID, cfg.Kube.Name)
+	zones := make([]string, 0, len(cfg.AWSConfig.Subnets))
+	for zone := range cfg.AWSConfig.Subnets {
+		zones = append(zones, zone)
+	}
+	if len(zones) == 0 {
+		return filters[:1]
+	}
+
+	for i := 1; i < len(filters); i++ {
+		filters[i] = append(filters[i],
+			&ec2.Filter{
+				Name:   aws.String("tag-key"),
+				Values: aws.StringSlice([]string{pvcNameTag}),
+			},
+			&ec2.Filter{
+				Name:   aws.String("availability-zone"),
+				Values: aws.StringSlice(zones),
+			},
+		)
+	}
+
+	return filters
+}
+
+func vpcFilter(vpcID string) *ec2.Filter {
+	return &ec2.Filter{
+		Name:   aws.String("vpc-id"),
+		Values: aws.StringSlice([]string{vpcID}),
+	}
+}
+
+func retryOrphan(ctx context.Context, fn func() error) error {
+	var (
+		err     error
+		timeout = deleteOrphanTimeout
+	)
+
+	for i := 0; i < deleteOrphanAttemptCount; i++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(timeout):
+		}
+		timeout = timeout * 2
+	}
+
+	return err
+}
@@ -0,0 +1,184 @@
+package amazon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// mockCleanupEC2 fakes describe and delete calls of the cluster clean up.
+type mockCleanupEC2 struct {
+	ec2iface.EC2API
+	mock.Mock
+}
+
+func (m *mockCleanupEC2) call(name string, req interface{}) (interface{}, error) {
+	args := m.MethodCalled(name, req)
+	return args.Get(0), args.Error(1)
+}
+
+func (m *mockCleanupEC2) DescribeInstancesWithContext(_ aws.Context, req *ec2.DescribeInstancesInput, _ ...request.Option) (*ec2.DescribeInstancesOutput, error) {
+	out, err := m.call("DescribeInstances", req.Filters)
+	val, _ := out.(*ec2.DescribeInstancesOutput)
+	return val, err
+}
+
+func (m *mockCleanupEC2) DescribeNetworkInterfacesWithContext(_ aws.Context, req *ec2.DescribeNetworkInterfacesInput, _ ...request.Option) (*ec2.DescribeNetworkInterfacesOutput, error) {
+	out, err := m.call("DescribeNetworkInterfaces", req.Filters)
+	val, _ := out.(*ec2.DescribeNetworkInterfacesOutput)
+	return val, err
+}
+
+func (m *mockCleanupEC2) DeleteNetworkInterfaceWithContext(_ aws.Context, req *ec2.DeleteNetworkInterfaceInput, _ ...request.Option) (*ec2.DeleteNetworkInterfaceOutput, error) {
+	_, err := m.call("DeleteNetworkInterface", aws.StringValue(req.NetworkInterfaceId))
+	return &ec2.DeleteNetworkInterfaceOutput{}, err
+}
+
+func (m *mockCleanupEC2) DescribeSecurityGroupsWithContext(_ aws.Context, req *ec2.DescribeSecurityGroupsInput, _ ...request.Option) (*ec2.DescribeSecurityGroupsOutput, error) {
+	out, err := m.call("DescribeSecurityGroups", req.Filters)
+	val, _ := out.(*ec2.DescribeSecurityGroupsOutput)
+	return val, err
+}
+
+func (m *mockCleanupEC2) DeleteSecurityGroupWithContext(_ aws.Context, req *ec2.DeleteSecurityGroupInput, _ ...request.Option) (*ec2.DeleteSecurityGroupOutput, error) {
+	_, err := m.call("DeleteSecurityGroup", aws.StringValue(req.GroupId))
+	return &ec2.DeleteSecurityGroupOutput{}, err
+}
+
+func (m *mockCleanupEC2) DescribeAddressesWithContext(_ aws.Context, req *ec2.DescribeAddressesInput, _ ...request.Option) (*ec2.DescribeAddressesOutput, error) {
+	out, err := m.call("DescribeAddresses", req.Filters)
+	val, _ := out.(*ec2.DescribeAddressesOutput)
+	return val, err
+}
+
+func (m *mockCleanupEC2) ReleaseAddressWithContext(_ aws.Context, req *ec2.ReleaseAddressInput, _ ...request.Option) (*ec2.ReleaseAddressOutput, error) {
+	_, err := m.call("ReleaseAddress", aws.StringValue(req.AllocationId))
+	return &ec2.ReleaseAddressOutput{}, err
+}
+
+func (m *mockCleanupEC2) DescribeVolumesWithContext(_ aws.Context, req *ec2.DescribeVolumesInput, _ ...request.Option) (*ec2.DescribeVolumesOutput, error) {
+	out, err := m.call("DescribeVolumes", req.Filters)
+	val, _ := out.(*ec2.DescribeVolumesOutput)
+	return val, err
+}
+
+func (m *mockCleanupEC2) DeleteVolumeWithContext(_ aws.Context, req *ec2.DeleteVolumeInput, _ ...request.Option) (*ec2.DeleteVolumeOutput, error) {
+	_, err := m.call("DeleteVolume", aws.StringValue(req.VolumeId))
+	return &ec2.DeleteVolumeOutput{}, err
+}
+
+func (m *mockCleanupEC2) DescribeVpcsWithContext(_ aws.Context, req *ec2.DescribeVpcsInput, _ ...request.Option) (*ec2.DescribeVpcsOutput, error) {
+	out, err := m.call("DescribeVpcs", req.Filters)
+	val, _ := out.(*ec2.DescribeVpcsOutput)
+	return val, err
+}
+
+// hasFilter matches filters of describe calls by one of them.
+func hasFilter(name, value string) interface{} {
+	return mock.MatchedBy(func(filters []*ec2.Filter) bool {
+		for _, f := range filters {
+			for _, v := range f.Values {
+				if aws.StringValue(f.Name) == name && aws.StringValue(v) == value {
+					return true
+				}
+			}
+		}
+		return false
+	})
+}
+
+func cleanupConfig() *steps.Config {
+	cfg := &steps.Config{
+		AWSConfig: steps.AWSConfig{
+			VPCID:   "vpc-1",
+			Subnets: map[string]string{"us-east-1a": "subnet-1"},
+		},
+	}
+	cfg.Kube.ID = "kube-id"
+	cfg.Kube.Name = "kube"
+	return cfg
+}
+
+func TestInitDeleteOrphanedResources(t *testing.T) {
+	InitDeleteOrphanedResources(GetEC2)
+
+	if s := steps.GetStep(DeleteOrphanedResourcesStepName); s == nil {
+		t.Errorf("Step %s not found", DeleteOrphanedResourcesStepName)
+	}
+}
+
+func TestDeleteOrphanedResources_Run(t *testing.T) {
+	deleteOrphanTimeout = 0
+
+	svc := &mockCleanupEC2{}
+	svc.On("DescribeNetworkInterfaces", hasFilter("status", ec2.NetworkInterfaceStatusAvailable)).
+		Return(&ec2.DescribeNetworkInterfacesOutput{
+			NetworkInterfaces: []*ec2.NetworkInterface{{NetworkInterfaceId: aws.String("eni-1")}},
+		}, nil)
+	svc.On("DeleteNetworkInterface", "eni-1").Return(nil, nil)
+	svc.On("DescribeSecurityGroups", hasFilter("vpc-id", "vpc-1")).
+		Return(&ec2.DescribeSecurityGroupsOutput{
+			SecurityGroups: []*ec2.SecurityGroup{
+				{GroupId: aws.String("sg-default"), GroupName: aws.String("default")},
+				{GroupId: aws.String("sg-elb"), GroupName: aws.String("k8s-elb-a1")},
+			},
+		}, nil)
+	svc.On("DeleteSecurityGroup", "sg-elb").Return(nil, errors.New("DependencyViolation")).Once()
+	svc.On("DeleteSecurityGroup", "sg-elb").Return(nil, nil)
+	svc.On("DescribeAddresses", hasFilter("tag:supergiant.io/cluster-id", "kube-id")).
+		Return(&ec2.DescribeAddressesOutput{
+			Addresses: []*ec2.Address{{AllocationId: aws.String("eipalloc-1"), PublicIp: aws.String("1.2.3.4")}},
+		}, nil)
+	svc.On("ReleaseAddress", "eipalloc-1").Return(nil, nil)
+	svc.On("DescribeVolumes", hasFilter("tag:KubernetesCluster", "kube")).
+		Return(&ec2.DescribeVolumesOutput{
+			Volumes: []*ec2.Volume{{VolumeId: aws.String("vol-pvc")}},
+		}, nil)
+	svc.On("DescribeVolumes", mock.Anything).Return(&ec2.DescribeVolumesOutput{}, nil)
+	svc.On("DeleteVolume", "vol-pvc").Return(nil, errors.New("VolumeInUse"))
+
+	step := &DeleteOrphanedResources{
+		getSvc: func(steps.AWSConfig) (orphanedResourcesService, error) {
+			return svc, nil
+		},
+	}
+	cfg := cleanupConfig()
+
+	require.NoError(t, step.Run(context.Background(), &bytes.Buffer{}, cfg))
+	svc.AssertCalled(t, "ReleaseAddress", "eipalloc-1")
+	svc.AssertNotCalled(t, "DeleteSecurityGroup", "sg-default")
+	svc.AssertNumberOfCalls(t, "DeleteSecurityGroup", 2)
+	svc.AssertNumberOfCalls(t, "DeleteVolume", deleteOrphanAttemptCount)
+	require.Equal(t, []steps.Leftover{
+		{Kind: LeftoverVolume, ID: "vol-pvc", Reason: "VolumeInUse"},
+	}, cfg.DeleteConfig.Leftovers)
+
+	// volumes are matched by the cluster name in zones of the cluster only
+	svc.AssertCalled(t, "DescribeVolumes", hasFilter("availability-zone", "us-east-1a"))
+	svc.AssertCalled(t, "DescribeVolumes", hasFilter("tag-key", pvcNameTag))
+}
+
+func TestDeleteOrphanedResources_RunDescribeError(t *testing.T) {
+	svc := &mockCleanupEC2{}
+	svc.On("DescribeNetworkInterfaces", mock.Anything).Return(nil, errors.New("throttled"))
+
+	step := &DeleteOrphanedResources{
+		getSvc: func(steps.AWSConfig) (orphanedResourcesService, error) {
+			return svc, nil
+		},
+	}
+
+	err := step.Run(context.Background(), &bytes.Buffer{}, cleanupConfig())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "throttled")
+}
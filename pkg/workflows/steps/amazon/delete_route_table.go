@@ -8,8 +8,8 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -46,14 +46,14 @@ func NewDeleteRouteTableStep(fn GetEC2Fn) *DeleteRouteTable {
 }
 func (s *DeleteRouteTable) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
 	if cfg.AWSConfig.RouteTableID == "" {
-		logrus.Debug("Skip deleting empty route table")
+		sglog.FromContext(ctx).Debug("Skip deleting empty route table")
 		return nil
 	}
 
 	svc, err := s.getSvc(cfg.AWSConfig)
 
 	if err != nil {
-		logrus.Errorf("Error getting delete service %v", err)
+		sglog.FromContext(ctx).Errorf("Error getting delete service %v", err)
 		return errors.Wrap(ErrAuthorization, err.Error())
 	}
 
@@ -64,14 +64,14 @@ func (s *DeleteRouteTable) Run(ctx context.Context, w io.Writer, cfg *steps.Conf
 
 	// Disassociating of route table and subnets can take a while, we need to be patient
 	for i := 0; i < deleteRouteAttemptCount; i++ {
-		logrus.Debugf("Delete route table %s from VPC %s",
+		sglog.FromContext(ctx).Debugf("Delete route table %s from VPC %s",
 			cfg.AWSConfig.RouteTableID, cfg.AWSConfig.VPCID)
 		_, deleteErr = svc.DeleteRouteTable(&ec2.DeleteRouteTableInput{
 			RouteTableId: aws.String(cfg.AWSConfig.RouteTableID),
 		})
 
 		if deleteErr != nil {
-			logrus.Debugf("Delete route table %s caused %s sleep for %v",
+			sglog.FromContext(ctx).Debugf("Delete route table %s caused %s sleep for %v",
 				cfg.AWSConfig.RouteTableID, deleteErr.Error(), timeout)
 			time.Sleep(timeout)
 			timeout = timeout * 2
@@ -9,9 +9,9 @@ import (
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
 	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -54,14 +54,14 @@ func NewDeleteSecurityGroupService(fn GetEC2Fn) *DeleteSecurityGroup {
 func (s *DeleteSecurityGroup) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
 	if cfg.AWSConfig.MastersSecurityGroupID == "" ||
 		cfg.AWSConfig.NodesSecurityGroupID == "" {
-		logrus.Debug("Skip deleting empty security groups")
+		sglog.FromContext(ctx).Debug("Skip deleting empty security groups")
 		return nil
 	}
 
 	svc, err := s.getSvc(cfg.AWSConfig)
 
 	if err != nil {
-		logrus.Errorf("Getting service caused %v", err)
+		sglog.FromContext(ctx).Errorf("Getting service caused %v", err)
 		return errors.Wrapf(err, "%s get service",
 			DeleteSecurityGroupsStepName)
 	}
@@ -70,20 +70,20 @@ func (s *DeleteSecurityGroup) Run(ctx context.Context, w io.Writer, cfg *steps.C
 		cfg.AWSConfig.MastersSecurityGroupID, svc)
 
 	if err != nil {
-		logrus.Debugf("get master security group ID %v", err)
+		sglog.FromContext(ctx).Debugf("get master security group ID %v", err)
 		return errors.Wrapf(err, "get master security group ID")
 	}
 
-	logrus.Debugf("Master group name %s", masterGroupName)
+	sglog.FromContext(ctx).Debugf("Master group name %s", masterGroupName)
 	nodeGroupName, err := s.getSecurityGroupNameByID(
 		cfg.AWSConfig.NodesSecurityGroupID, svc)
 
 	if err != nil {
-		logrus.Debugf("get node security group ID %v", err)
+		sglog.FromContext(ctx).Debugf("get node security group ID %v", err)
 		return errors.Wrapf(err, "get node security group ID")
 	}
 
-	logrus.Debugf("Revoking dependent Node Security Group ingress rules %s", nodeGroupName)
+	sglog.FromContext(ctx).Debugf("Revoking dependent Node Security Group ingress rules %s", nodeGroupName)
 
 	// Decouple security groups from each other
 	revokeInput := &ec2.RevokeSecurityGroupIngressInput{
@@ -112,7 +112,7 @@ func (s *DeleteSecurityGroup) Run(ctx context.Context, w io.Writer, cfg *steps.C
 		},
 	}
 
-	logrus.Debugf("Revoking dependent Master Security Group  %s ingress rules",
+	sglog.FromContext(ctx).Debugf("Revoking dependent Master Security Group  %s ingress rules",
 		cfg.AWSConfig.MastersSecurityGroupID)
 	_, err = svc.RevokeSecurityGroupIngressWithContext(ctx, revokeInput)
 
@@ -151,41 +151,41 @@ func (s *DeleteSecurityGroup) Run(ctx context.Context, w io.Writer, cfg *steps.C
 	_, err = svc.RevokeSecurityGroupIngressWithContext(ctx, revokeInput)
 
 	if err != nil {
-		logrus.Debugf("revoke relation between node and master "+
+		sglog.FromContext(ctx).Debugf("revoke relation between node and master "+
 			"security group caused %s caused %s",
 			cfg.AWSConfig.MastersSecurityGroupID, err.Error())
 		return errors.Wrapf(err, "find security group %s",
 			cfg.AWSConfig.NodesSecurityGroupID)
 	}
 
-	logrus.Debugf("Dependencies between security groups has been revoked")
+	sglog.FromContext(ctx).Debugf("Dependencies between security groups has been revoked")
 	var deleteErr error
 	var timeout = deleteSecGroupTimeout
 
 	// Delete master security group first
 	for i := 0; i < deleteSecGroupAttemptCount; i++ {
-		logrus.Debugf("Delete master security group %s", cfg.AWSConfig.MastersSecurityGroupID)
+		sglog.FromContext(ctx).Debugf("Delete master security group %s", cfg.AWSConfig.MastersSecurityGroupID)
 		reqMaster := &ec2.DeleteSecurityGroupInput{
 			GroupId: aws.String(cfg.AWSConfig.MastersSecurityGroupID),
 		}
 		_, deleteErr = svc.DeleteSecurityGroupWithContext(ctx, reqMaster)
 
 		if deleteErr != nil {
-			logrus.Debugf("delete master security group %s caused %s",
+			sglog.FromContext(ctx).Debugf("delete master security group %s caused %s",
 				cfg.AWSConfig.MastersSecurityGroupID, deleteErr.Error())
 		} else {
-			logrus.Debugf("master security group %s has been deleted",
+			sglog.FromContext(ctx).Debugf("master security group %s has been deleted",
 				cfg.AWSConfig.MastersSecurityGroupID)
 			break
 		}
 
-		logrus.Debugf("Sleep for %v", timeout)
+		sglog.FromContext(ctx).Debugf("Sleep for %v", timeout)
 		time.Sleep(timeout)
 		timeout = timeout * 2
 	}
 
 	if deleteErr != nil {
-		logrus.Errorf("Delete master security group %s", DeleteSecurityGroupsStepName)
+		sglog.FromContext(ctx).Errorf("Delete master security group %s", DeleteSecurityGroupsStepName)
 		return errors.Wrapf(deleteErr, "%s delete master security group",
 			DeleteSecurityGroupsStepName)
 	}
@@ -197,32 +197,32 @@ func (s *DeleteSecurityGroup) Run(ctx context.Context, w io.Writer, cfg *steps.C
 			GroupId: aws.String(cfg.AWSConfig.NodesSecurityGroupID),
 		}
 
-		logrus.Debugf("Delete node security group %s",
+		sglog.FromContext(ctx).Debugf("Delete node security group %s",
 			cfg.AWSConfig.NodesSecurityGroupID)
 		_, deleteErr = svc.DeleteSecurityGroupWithContext(ctx, reqNode)
 
 		if deleteErr != nil {
-			logrus.Debugf("delete node security group %s %s",
+			sglog.FromContext(ctx).Debugf("delete node security group %s %s",
 				cfg.AWSConfig.NodesSecurityGroupID, deleteErr.Error())
 		} else {
-			logrus.Debugf("node security group %s has been deleted",
+			sglog.FromContext(ctx).Debugf("node security group %s has been deleted",
 				cfg.AWSConfig.NodesSecurityGroupID)
 			break
 		}
 
-		logrus.Debugf("Sleep for %v", timeout)
+		sglog.FromContext(ctx).Debugf("Sleep for %v", timeout)
 		time.Sleep(timeout)
 		timeout = timeout * 2
 	}
 
 	if deleteErr != nil {
-		logrus.Errorf("Delete node security group %s", DeleteSecurityGroupsStepName)
+		sglog.FromContext(ctx).Errorf("Delete node security group %s", DeleteSecurityGroupsStepName)
 		return errors.Wrapf(deleteErr, "%s delete node security group",
 			DeleteSecurityGroupsStepName)
 	}
 
 	// Don't fail even if something not get deleted
-	logrus.Debugf("Deleting security group finished")
+	sglog.FromContext(ctx).Debugf("Deleting security group finished")
 	return nil
 }
 
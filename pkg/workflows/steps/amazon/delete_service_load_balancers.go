@@ -0,0 +1,128 @@
+package amazon
+
+import (
+	"context"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/sglog"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	DeleteServiceLoadBalancersStepName = "aws_delete_service_load_balancers"
+
+	LeftoverLoadBalancer = "loadBalancer"
+)
+
+type serviceLoadBalancerService interface {
+	DescribeLoadBalancersWithContext(aws.Context, *elb.DescribeLoadBalancersInput, ...request.Option) (*elb.DescribeLoadBalancersOutput, error)
+	DeleteLoadBalancerWithContext(aws.Context, *elb.DeleteLoadBalancerInput, ...request.Option) (*elb.DeleteLoadBalancerOutput, error)
+}
+
+// DeleteServiceLoadBalancers deletes load balancers that kubernetes created
+// for services, they are the only ones left in the vpc of the cluster after
+// the master load balancers have been deleted.
+type DeleteServiceLoadBalancers struct {
+	getSvc func(steps.AWSConfig) (serviceLoadBalancerService, error)
+}
+
+func InitDeleteServiceLoadBalancers(fn GetELBFn) {
+	steps.RegisterStep(DeleteServiceLoadBalancersStepName, NewDeleteServiceLoadBalancers(fn))
+}
+
+func NewDeleteServiceLoadBalancers(fn GetELBFn) *DeleteServiceLoadBalancers {
+	return &DeleteServiceLoadBalancers{
+		getSvc: func(cfg steps.AWSConfig) (serviceLoadBalancerService, error) {
+			ELB, err := fn(cfg)
+
+			if err != nil {
+				return nil, errors.Wrap(ErrAuthorization, err.Error())
+			}
+
+			return ELB, nil
+		},
+	}
+}
+
+func (s *DeleteServiceLoadBalancers) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
+	if cfg.AWSConfig.VPCID == "" {
+		sglog.FromContext(ctx).Debug("Skip deleting service load balancers of empty VPC")
+		return nil
+	}
+
+	log := util.GetLogger(w)
+	svc, err := s.getSvc(cfg.AWSConfig)
+
+	if err != nil {
+		return errors.Wrapf(err, "%s get service", DeleteServiceLoadBalancersStepName)
+	}
+
+	names, err := serviceLoadBalancers(ctx, svc, cfg.AWSConfig)
+	if err != nil {
+		return errors.Wrapf(err, "%s describe load balancers", DeleteServiceLoadBalancersStepName)
+	}
+
+	for _, name := range names {
+		log.Infof("[%s] - delete load balancer %s", s.Name(), name)
+		_, err = svc.DeleteLoadBalancerWithContext(ctx, &elb.DeleteLoadBalancerInput{
+			LoadBalancerName: aws.String(name),
+		})
+		if err != nil {
+			// subnets and the vpc can't be deleted while it exists, the
+			// verification reports it
+			log.Errorf("[%s] - delete load balancer %s caused %v", s.Name(), name, err)
+			cfg.DeleteConfig.AddLeftover(LeftoverLoadBalancer, name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (*DeleteServiceLoadBalancers) Name() string {
+	return DeleteServiceLoadBalancersStepName
+}
+
+func (*DeleteServiceLoadBalancers) Depends() []string {
+	return []string{DeleteLoadBalancerStepName}
+}
+
+func (*DeleteServiceLoadBalancers) Description() string {
+	return "Delete load balancers of kubernetes services"
+}
+
+func (*DeleteServiceLoadBalancers) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+// serviceLoadBalancers lists load balancers of the vpc that control didn't
+// create.
+func serviceLoadBalancers(ctx context.Context, svc serviceLoadBalancerService, cfg steps.AWSConfig) ([]string, error) {
+	names := make([]string, 0)
+	input := &elb.DescribeLoadBalancersInput{}
+	for {
+		output, err := svc.DescribeLoadBalancersWithContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, lb := range output.LoadBalancerDescriptions {
+			name := aws.StringValue(lb.LoadBalancerName)
+			if aws.StringValue(lb.VPCId) != cfg.VPCID ||
+				name == cfg.ExternalLoadBalancerName || name == cfg.InternalLoadBalancerName {
+				continue
+			}
+			names = append(names, name)
+		}
+
+		if aws.StringValue(output.NextMarker) == "" {
+			return names, nil
+		}
+		input.Marker = output.NextMarker
+	}
+}
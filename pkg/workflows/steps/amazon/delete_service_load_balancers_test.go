@@ -0,0 +1,108 @@
+package amazon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type mockServiceLBService struct {
+	mock.Mock
+}
+
+func (m *mockServiceLBService) DescribeLoadBalancersWithContext(ctx aws.Context,
+	req *elb.DescribeLoadBalancersInput, opts ...request.Option) (*elb.DescribeLoadBalancersOutput, error) {
+	args := m.Called(ctx, req, opts)
+	val, ok := args.Get(0).(*elb.DescribeLoadBalancersOutput)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return val, args.Error(1)
+}
+
+func (m *mockServiceLBService) DeleteLoadBalancerWithContext(ctx aws.Context,
+	req *elb.DeleteLoadBalancerInput, opts ...request.Option) (*elb.DeleteLoadBalancerOutput, error) {
+	args := m.Called(ctx, req, opts)
+	val, ok := args.Get(0).(*elb.DeleteLoadBalancerOutput)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return val, args.Error(1)
+}
+
+func lbDescription(name, vpcID string) *elb.LoadBalancerDescription {
+	return &elb.LoadBalancerDescription{
+		LoadBalancerName: aws.String(name),
+		VPCId:            aws.String(vpcID),
+	}
+}
+
+func TestInitDeleteServiceLoadBalancers(t *testing.T) {
+	InitDeleteServiceLoadBalancers(GetELB)
+
+	if s := steps.GetStep(DeleteServiceLoadBalancersStepName); s == nil {
+		t.Errorf("Step %s not found", DeleteServiceLoadBalancersStepName)
+	}
+}
+
+func TestDeleteServiceLoadBalancers_Run(t *testing.T) {
+	svc := &mockServiceLBService{}
+	svc.On("DescribeLoadBalancersWithContext", mock.Anything,
+		&elb.DescribeLoadBalancersInput{}, mock.Anything).
+		Return(&elb.DescribeLoadBalancersOutput{
+			LoadBalancerDescriptions: []*elb.LoadBalancerDescription{
+				lbDescription("external", "vpc-1"),
+				lbDescription("a1", "vpc-1"),
+				lbDescription("other", "vpc-2"),
+			},
+			NextMarker: aws.String("page-2"),
+		}, nil)
+	svc.On("DescribeLoadBalancersWithContext", mock.Anything,
+		&elb.DescribeLoadBalancersInput{Marker: aws.String("page-2")}, mock.Anything).
+		Return(&elb.DescribeLoadBalancersOutput{
+			LoadBalancerDescriptions: []*elb.LoadBalancerDescription{
+				lbDescription("a2", "vpc-1"),
+			},
+		}, nil)
+	svc.On("DeleteLoadBalancerWithContext", mock.Anything,
+		&elb.DeleteLoadBalancerInput{LoadBalancerName: aws.String("a1")}, mock.Anything).
+		Return(&elb.DeleteLoadBalancerOutput{}, nil)
+	svc.On("DeleteLoadBalancerWithContext", mock.Anything,
+		&elb.DeleteLoadBalancerInput{LoadBalancerName: aws.String("a2")}, mock.Anything).
+		Return(nil, errors.New("throttled"))
+
+	step := &DeleteServiceLoadBalancers{
+		getSvc: func(steps.AWSConfig) (serviceLoadBalancerService, error) {
+			return svc, nil
+		},
+	}
+	cfg := &steps.Config{
+		AWSConfig: steps.AWSConfig{
+			VPCID:                    "vpc-1",
+			ExternalLoadBalancerName: "external",
+		},
+	}
+
+	require.NoError(t, step.Run(context.Background(), &bytes.Buffer{}, cfg))
+	svc.AssertNumberOfCalls(t, "DeleteLoadBalancerWithContext", 2)
+	require.Equal(t, []steps.Leftover{
+		{Kind: LeftoverLoadBalancer, ID: "a2", Reason: "throttled"},
+	}, cfg.DeleteConfig.Leftovers)
+
+	step.getSvc = func(steps.AWSConfig) (serviceLoadBalancerService, error) {
+		return nil, errors.New("auth")
+	}
+	require.Error(t, step.Run(context.Background(), &bytes.Buffer{}, cfg))
+
+	// nothing to delete without a vpc
+	require.NoError(t, step.Run(context.Background(), &bytes.Buffer{}, &steps.Config{}))
+}
@@ -7,8 +7,8 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -43,20 +43,20 @@ func NewDeleteSubnets(fn GetEC2Fn) *DeleteSubnets {
 
 func (s *DeleteSubnets) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
 	if len(cfg.AWSConfig.Subnets) == 0 {
-		logrus.Debug("Skip deleting empty subnets")
+		sglog.FromContext(ctx).Debug("Skip deleting empty subnets")
 		return nil
 	}
 
 	svc, err := s.getSvc(cfg.AWSConfig)
 
 	if err != nil {
-		logrus.Errorf("Error getting delete subnets service %v", err)
+		sglog.FromContext(ctx).Errorf("Error getting delete subnets service %v", err)
 		return errors.Wrapf(ErrAuthorization, "%s %v",
 			DeleteSubnetsStepName, err.Error())
 	}
 
 	for az, subnet := range cfg.AWSConfig.Subnets {
-		logrus.Debugf("Delete subnet %s in az %s", subnet, az)
+		sglog.FromContext(ctx).Debugf("Delete subnet %s in az %s", subnet, az)
 		descReq := &ec2.DeleteSubnetInput{
 			SubnetId: aws.String(subnet),
 		}
@@ -64,7 +64,7 @@ func (s *DeleteSubnets) Run(ctx context.Context, w io.Writer, cfg *steps.Config)
 		_, err = svc.DeleteSubnet(descReq)
 
 		if err != nil {
-			logrus.Debugf("DeleteSubnet caused %s", err.Error())
+			sglog.FromContext(ctx).Debugf("DeleteSubnet caused %s", err.Error())
 		}
 	}
 
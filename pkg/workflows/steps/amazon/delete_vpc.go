@@ -9,8 +9,8 @@ import (
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -50,14 +50,14 @@ func NewDeleteVPC(fn GetEC2Fn) *DeleteVPC {
 
 func (s *DeleteVPC) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
 	if cfg.AWSConfig.VPCID == "" {
-		logrus.Debug("Skip deleting empty VPC")
+		sglog.FromContext(ctx).Debug("Skip deleting empty VPC")
 		return nil
 	}
 
 	svc, err := s.getSvc(cfg.AWSConfig)
 
 	if err != nil {
-		logrus.Errorf("Error getting service %v", err)
+		sglog.FromContext(ctx).Errorf("Error getting service %v", err)
 		return errors.Wrap(ErrAuthorization, err.Error())
 	}
 
@@ -71,11 +71,11 @@ func (s *DeleteVPC) Run(ctx context.Context, w io.Writer, cfg *steps.Config) err
 			VpcId: aws.String(cfg.AWSConfig.VPCID),
 		}
 
-		logrus.Debugf("Delete VPC ID: %s", cfg.AWSConfig.VPCID)
+		sglog.FromContext(ctx).Debugf("Delete VPC ID: %s", cfg.AWSConfig.VPCID)
 		_, deleteErr = svc.DeleteVpcWithContext(ctx, req)
 
 		if deleteErr != nil {
-			logrus.Debugf("Delete VPC %s caused %s retry in %v ",
+			sglog.FromContext(ctx).Debugf("Delete VPC %s caused %s retry in %v ",
 				cfg.AWSConfig.VPCID, deleteErr.Error(), timeout)
 			time.Sleep(timeout)
 			timeout = timeout * 2
@@ -0,0 +1,98 @@
+package amazon
+
+import (
+	"context"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/sglog"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const DeregisterInstanceStepName = "deregister_instance"
+
+type LoadBalancerDeregister interface {
+	DeregisterInstancesFromLoadBalancerWithContext(aws.Context, *elb.DeregisterInstancesFromLoadBalancerInput, ...request.Option) (*elb.DeregisterInstancesFromLoadBalancerOutput, error)
+}
+
+// DeregisterInstanceStep removes a master from load balancers of the cluster,
+// so api requests aren't sent to the master that is being deleted.
+type DeregisterInstanceStep struct {
+	getLoadBalancerService func(cfg steps.AWSConfig) (LoadBalancerDeregister, error)
+}
+
+// InitDeregisterInstance adds the step to the registry
+func InitDeregisterInstance(getELBFn GetELBFn) {
+	steps.RegisterStep(DeregisterInstanceStepName, NewDeregisterInstanceStep(getELBFn))
+}
+
+func NewDeregisterInstanceStep(getELBFn GetELBFn) *DeregisterInstanceStep {
+	return &DeregisterInstanceStep{
+		getLoadBalancerService: func(cfg steps.AWSConfig) (LoadBalancerDeregister, error) {
+			elbInstance, err := getELBFn(cfg)
+
+			if err != nil {
+				logrus.Errorf("[%s] - failed to authorize in AWS: %v",
+					DeregisterInstanceStepName, err)
+				return nil, errors.Wrap(ErrAuthorization, err.Error())
+			}
+
+			return elbInstance, nil
+		},
+	}
+}
+
+func (s *DeregisterInstanceStep) Run(ctx context.Context, out io.Writer, cfg *steps.Config) error {
+	svc, err := s.getLoadBalancerService(cfg.AWSConfig)
+
+	if err != nil {
+		sglog.FromContext(ctx).Errorf("error getting ELB service %v", err)
+		return errors.Wrapf(err, "error getting ELB service %s",
+			DeregisterInstanceStepName)
+	}
+
+	for _, lbName := range []string{cfg.AWSConfig.ExternalLoadBalancerName, cfg.AWSConfig.InternalLoadBalancerName} {
+		if lbName == "" {
+			continue
+		}
+
+		sglog.FromContext(ctx).Infof("Deregister instance Name: %s ID: %s from load balancer: %s",
+			cfg.Node.Name, cfg.Node.ID, lbName)
+		_, err = svc.DeregisterInstancesFromLoadBalancerWithContext(ctx, &elb.DeregisterInstancesFromLoadBalancerInput{
+			LoadBalancerName: aws.String(lbName),
+			Instances: []*elb.Instance{
+				{
+					InstanceId: aws.String(cfg.Node.ID),
+				},
+			},
+		})
+
+		if err != nil {
+			return errors.Wrapf(err, "deregister instance %s from load balancer %s",
+				cfg.Node.ID, lbName)
+		}
+	}
+
+	return nil
+}
+
+func (s *DeregisterInstanceStep) Name() string {
+	return DeregisterInstanceStepName
+}
+
+func (s *DeregisterInstanceStep) Description() string {
+	return "Deregister master from external and internal Load balancers"
+}
+
+func (s *DeregisterInstanceStep) Depends() []string {
+	return nil
+}
+
+func (s *DeregisterInstanceStep) Rollback(ctx context.Context, out io.Writer, cfg *steps.Config) error {
+	return nil
+}
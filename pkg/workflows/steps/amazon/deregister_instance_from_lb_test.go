@@ -0,0 +1,88 @@
+package amazon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestDeregisterInstanceStep_Run(t *testing.T) {
+	testCases := []struct {
+		description string
+
+		getSvcErr error
+		internal  string
+
+		deregisterErr error
+		calls         int
+
+		errMsg string
+	}{
+		{
+			description: "error getting ELB svc",
+			getSvcErr:   errors.New("error1"),
+			errMsg:      "error1",
+		},
+		{
+			description:   "error deregistering",
+			deregisterErr: errors.New("error2"),
+			calls:         1,
+			errMsg:        "error2",
+		},
+		{
+			description: "external only",
+			calls:       1,
+		},
+		{
+			description: "success",
+			internal:    "internal",
+			calls:       2,
+		},
+	}
+
+	for _, testCase := range testCases {
+		svc := new(mockELBService)
+		svc.On("DeregisterInstancesFromLoadBalancerWithContext", mock.Anything,
+			mock.Anything, mock.Anything).Return(&elb.DeregisterInstancesFromLoadBalancerOutput{}, testCase.deregisterErr)
+
+		step := &DeregisterInstanceStep{
+			getLoadBalancerService: func(cfg steps.AWSConfig) (LoadBalancerDeregister, error) {
+				return svc, testCase.getSvcErr
+			},
+		}
+
+		config := &steps.Config{
+			Node: model.Machine{ID: "i-1234", Name: "master-1"},
+			AWSConfig: steps.AWSConfig{
+				ExternalLoadBalancerName: "external",
+				InternalLoadBalancerName: testCase.internal,
+			},
+		}
+
+		err := step.Run(context.Background(), &bytes.Buffer{}, config)
+
+		if testCase.errMsg == "" && err != nil {
+			t.Errorf("%s: unexpected error %v", testCase.description, err)
+		}
+		if testCase.errMsg != "" && (err == nil || !strings.Contains(err.Error(), testCase.errMsg)) {
+			t.Errorf("%s: wrong error must contain %s actual %v", testCase.description, testCase.errMsg, err)
+		}
+		svc.AssertNumberOfCalls(t, "DeregisterInstancesFromLoadBalancerWithContext", testCase.calls)
+	}
+}
+
+func TestInitDeregisterInstance(t *testing.T) {
+	InitDeregisterInstance(GetELB)
+
+	if s := steps.GetStep(DeregisterInstanceStepName); s == nil {
+		t.Errorf("Step %s not found", DeregisterInstanceStepName)
+	}
+}
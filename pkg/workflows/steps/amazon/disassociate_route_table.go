@@ -7,8 +7,8 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -45,7 +45,7 @@ func (s *DisassociateRouteTable) Run(ctx context.Context, w io.Writer, cfg *step
 	svc, err := s.getSvc(cfg.AWSConfig)
 
 	if err != nil {
-		logrus.Errorf("%s error getting service %v",
+		sglog.FromContext(ctx).Errorf("%s error getting service %v",
 			DisassociateRouteTableStepName, err)
 		return errors.Wrapf(err, "Step %s getting service error",
 			DisassociateRouteTableStepName)
@@ -63,7 +63,7 @@ func (s *DisassociateRouteTable) Run(ctx context.Context, w io.Writer, cfg *step
 		_, err = svc.DisassociateRouteTable(disReq)
 
 		if err != nil {
-			logrus.Debugf("DisassociateRouteTable caused %s", err.Error())
+			sglog.FromContext(ctx).Debugf("DisassociateRouteTable caused %s", err.Error())
 		}
 	}
 
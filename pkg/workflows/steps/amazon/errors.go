@@ -12,4 +12,5 @@ var (
 	ErrNoPublicIP     = errors.New("aws: no public IP assigned")
 	ErrDeleteCluster  = errors.New("aws: delete cluster")
 	ErrDeleteNode     = errors.New("aws: delete node")
+	ErrLeftovers      = errors.New("aws: cluster resources are left")
 )
@@ -12,6 +12,9 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/util"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
@@ -50,27 +53,27 @@ func (s *FindAMIStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config) e
 	finder, err := s.getImageService(cfg.AWSConfig)
 
 	if err != nil {
-		logrus.Errorf("[%s] - failed to authorize in AWS: %v",
+		sglog.FromContext(ctx).Errorf("[%s] - failed to authorize in AWS: %v",
 			s.Name(), err)
 		return errors.Wrap(err, StepFindAMI)
 	}
 
 	err = s.FindAMI(ctx, w, finder, cfg)
-	logrus.Debugf("Found image id %s", cfg.AWSConfig.ImageID)
+	sglog.FromContext(ctx).Debugf("Found image id %s", cfg.AWSConfig.ImageID)
 
 	if err != nil {
-		logrus.Errorf("[%s] - failed to find AMI for Ubuntu: %v",
+		sglog.FromContext(ctx).Errorf("[%s] - failed to find AMI for Ubuntu: %v",
 			s.Name(), err)
 		return errors.Wrap(err, "failed to find AMI")
 	}
 
 	if err == nil && (cfg.AWSConfig.ImageID == "" || cfg.AWSConfig.DeviceName == "") {
-		logrus.Debugf("[%s] - can't find supported image", s.Name())
+		sglog.FromContext(ctx).Debugf("[%s] - can't find supported image", s.Name())
 		return errors.New(fmt.Sprintf("[%s] - can't find "+
 			"supported image or device name", s.Name()))
 	}
 
-	logrus.Debugf("Use image id %s root device name %s", cfg.AWSConfig.ImageID, cfg.AWSConfig.DeviceName)
+	sglog.FromContext(ctx).Debugf("Use image id %s root device name %s", cfg.AWSConfig.ImageID, cfg.AWSConfig.DeviceName)
 
 	return nil
 }
@@ -148,10 +151,55 @@ func (s *FindAMIStep) FindAMI(ctx context.Context, w io.Writer, finder ImageFind
 		logMessage := fmt.Sprintf("[%s] - using AMI (ID: %s) %s with root device name %s",
 			s.Name(), *img.ImageId, *img.Description, *img.RootDeviceName)
 		log.Info(logMessage)
-		logrus.Info(logMessage)
+		sglog.FromContext(ctx).Info(logMessage)
 
 		break
 	}
 
 	return nil
 }
+
+// findNodeAMI sets the pinned image of the node or the latest image of its
+// family in the region with the root device of the image. The image of the
+// cluster is kept if the node has neither.
+func findNodeAMI(ctx context.Context, finder ImageFinder, cfg *steps.Config) error {
+	input := &ec2.DescribeImagesInput{}
+	switch {
+	case cfg.NodeImage != "":
+		input.ImageIds = []*string{aws.String(cfg.NodeImage)}
+	case cfg.NodeImageFamily != "":
+		family, ok := clouds.FindImageFamily(cfg.NodeImageFamily)
+		if !ok || !family.Supports(clouds.AWS) {
+			return errors.Wrapf(sgerrors.ErrNotFound, "image family %s", cfg.NodeImageFamily)
+		}
+		input.Owners = []*string{aws.String(family.AWSOwner)}
+		input.Filters = []*ec2.Filter{
+			{Name: aws.String("name"), Values: []*string{aws.String(family.AWSName)}},
+			{Name: aws.String("architecture"), Values: []*string{aws.String("x86_64")}},
+			{Name: aws.String("state"), Values: []*string{aws.String(ec2.ImageStateAvailable)}},
+		}
+	default:
+		return nil
+	}
+
+	out, err := finder.DescribeImagesWithContext(ctx, input)
+	if err != nil {
+		return errors.Wrap(err, "describe images")
+	}
+
+	var latest *ec2.Image
+	for _, img := range out.Images {
+		// creation dates are ISO 8601 strings
+		if latest == nil || aws.StringValue(img.CreationDate) > aws.StringValue(latest.CreationDate) {
+			latest = img
+		}
+	}
+	if latest == nil {
+		return errors.Wrapf(sgerrors.ErrNotFound, "image %s%s in %s",
+			cfg.NodeImage, cfg.NodeImageFamily, cfg.AWSConfig.Region)
+	}
+
+	cfg.AWSConfig.ImageID = aws.StringValue(latest.ImageId)
+	cfg.AWSConfig.DeviceName = aws.StringValue(latest.RootDeviceName)
+	return nil
+}
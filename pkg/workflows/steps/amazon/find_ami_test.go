@@ -12,6 +12,7 @@ import (
 	"github.com/pkg/errors"
 	"go.uber.org/zap/buffer"
 
+	"github.com/supergiant/control/pkg/sgerrors"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -192,3 +193,38 @@ func TestInitFindAMI(t *testing.T) {
 		t.Errorf("Step must not be nil")
 	}
 }
+
+func TestFindNodeAMI(t *testing.T) {
+	svc := &mockImageService{
+		output: &ec2.DescribeImagesOutput{
+			Images: []*ec2.Image{
+				{ImageId: aws.String("ami-old"), CreationDate: aws.String("2022-04-20T09:00:00.000Z"), RootDeviceName: aws.String("/dev/sda1")},
+				{ImageId: aws.String("ami-new"), CreationDate: aws.String("2022-10-12T09:00:00.000Z"), RootDeviceName: aws.String("/dev/xvda")},
+			},
+		},
+	}
+
+	cfg := &steps.Config{AWSConfig: steps.AWSConfig{ImageID: "ami-cluster"}}
+	if err := findNodeAMI(context.Background(), svc, cfg); err != nil || cfg.AWSConfig.ImageID != "ami-cluster" {
+		t.Errorf("the image of the cluster must be kept, image %s error %v", cfg.AWSConfig.ImageID, err)
+	}
+
+	cfg.NodeImageFamily = "ubuntu-22.04-lts"
+	if err := findNodeAMI(context.Background(), svc, cfg); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if cfg.AWSConfig.ImageID != "ami-new" || cfg.AWSConfig.DeviceName != "/dev/xvda" {
+		t.Errorf("wrong image %s device %s", cfg.AWSConfig.ImageID, cfg.AWSConfig.DeviceName)
+	}
+
+	cfg.NodeImageFamily = "unknown"
+	if err := findNodeAMI(context.Background(), svc, cfg); !sgerrors.IsNotFound(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	cfg.NodeImage = "ami-missing"
+	svc.output = &ec2.DescribeImagesOutput{}
+	if err := findNodeAMI(context.Background(), svc, cfg); !sgerrors.IsNotFound(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package amazon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/sglog"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	StepFindWindowsAMI = "find_windows_machine_image"
+
+	// Windows Server images with the Containers feature published by Amazon
+	windowsImageOwner = "801119661308"
+	windowsImageName  = "Windows_Server-2019-English-Full-ContainersLatest-*"
+)
+
+type FindWindowsAMIStep struct {
+	getImageService func(config steps.AWSConfig) (ImageFinder, error)
+}
+
+func NewFindWindowsAMIStep(fn GetEC2Fn) *FindWindowsAMIStep {
+	return &FindWindowsAMIStep{
+		getImageService: func(config steps.AWSConfig) (ImageFinder, error) {
+			EC2, err := fn(config)
+			if err != nil {
+				return nil, errors.Wrap(ErrAuthorization, err.Error())
+			}
+
+			return EC2, nil
+		},
+	}
+}
+
+func InitFindWindowsAMI(fn GetEC2Fn) {
+	steps.RegisterStep(StepFindWindowsAMI, NewFindWindowsAMIStep(fn))
+}
+
+func (s *FindWindowsAMIStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
+	finder, err := s.getImageService(cfg.AWSConfig)
+	if err != nil {
+		sglog.FromContext(ctx).Errorf("[%s] - failed to authorize in AWS: %v", s.Name(), err)
+		return errors.Wrap(err, StepFindWindowsAMI)
+	}
+
+	input := &ec2.DescribeImagesInput{
+		Owners: []*string{aws.String(windowsImageOwner)},
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("name"),
+				Values: []*string{aws.String(windowsImageName)},
+			},
+			{
+				Name:   aws.String("architecture"),
+				Values: []*string{aws.String("x86_64")},
+			},
+		},
+	}
+	// an image set in the node profile is looked up for its root device
+	if cfg.AWSConfig.WindowsImageID != "" {
+		input = &ec2.DescribeImagesInput{
+			ImageIds: []*string{aws.String(cfg.AWSConfig.WindowsImageID)},
+		}
+	}
+
+	out, err := finder.DescribeImagesWithContext(ctx, input)
+	if err != nil {
+		return errors.Wrap(err, "failed to find windows AMI")
+	}
+
+	if len(out.Images) == 0 {
+		return errors.Errorf("[%s] - can't find windows image", s.Name())
+	}
+
+	images := out.Images
+	sort.Slice(images, func(i, j int) bool {
+		return aws.StringValue(images[i].CreationDate) > aws.StringValue(images[j].CreationDate)
+	})
+
+	img := images[0]
+	cfg.AWSConfig.ImageID = aws.StringValue(img.ImageId)
+	cfg.AWSConfig.DeviceName = aws.StringValue(img.RootDeviceName)
+
+	logMessage := fmt.Sprintf("[%s] - using AMI (ID: %s) %s with root device name %s",
+		s.Name(), cfg.AWSConfig.ImageID, aws.StringValue(img.Name), cfg.AWSConfig.DeviceName)
+	util.GetLogger(w).Info(logMessage)
+	sglog.FromContext(ctx).Info(logMessage)
+
+	return nil
+}
+
+func (*FindWindowsAMIStep) Name() string {
+	return StepFindWindowsAMI
+}
+
+func (*FindWindowsAMIStep) Description() string {
+	return "Step looks for the latest Windows Server Amazon Machine Image"
+}
+
+func (*FindWindowsAMIStep) Depends() []string {
+	return nil
+}
+
+func (*FindWindowsAMIStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
@@ -0,0 +1,109 @@
+package amazon
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/pkg/errors"
+	"go.uber.org/zap/buffer"
+
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestFindWindowsAMIStep_Run(t *testing.T) {
+	testCases := []struct {
+		description  string
+		getFinderErr error
+		output       *ec2.DescribeImagesOutput
+		err          error
+		errMsg       string
+		imageID      string
+	}{
+		{
+			description:  "error getting finder",
+			getFinderErr: errors.New("error obtaining image finder"),
+			errMsg:       "error obtaining image finder",
+		},
+		{
+			description: "error while getting image",
+			err:         errors.New("something went wrong"),
+			errMsg:      "something went wrong",
+		},
+		{
+			description: "image not found",
+			output:      &ec2.DescribeImagesOutput{},
+			errMsg:      "can't find windows image",
+		},
+		{
+			description: "latest image",
+			output: &ec2.DescribeImagesOutput{
+				Images: []*ec2.Image{
+					{
+						ImageId:        aws.String("ami-old"),
+						CreationDate:   aws.String("2020-01-15T07:11:34.000Z"),
+						RootDeviceName: aws.String("/dev/sda1"),
+					},
+					{
+						ImageId:        aws.String("ami-new"),
+						CreationDate:   aws.String("2021-03-10T07:11:34.000Z"),
+						RootDeviceName: aws.String("/dev/sda1"),
+					},
+				},
+			},
+			imageID: "ami-new",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase.description)
+		svc := &mockImageService{
+			output: testCase.output,
+			err:    testCase.err,
+		}
+
+		step := &FindWindowsAMIStep{
+			getImageService: func(config steps.AWSConfig) (ImageFinder, error) {
+				return svc, testCase.getFinderErr
+			},
+		}
+
+		config := &steps.Config{}
+		err := step.Run(context.Background(), &buffer.Buffer{}, config)
+
+		if testCase.errMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), testCase.errMsg) {
+				t.Errorf("Not found expected message %s in err %v", testCase.errMsg, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+			continue
+		}
+
+		if config.AWSConfig.ImageID != testCase.imageID {
+			t.Errorf("Wrong image id expected %s actual %s",
+				testCase.imageID, config.AWSConfig.ImageID)
+		}
+
+		if config.AWSConfig.DeviceName != "/dev/sda1" {
+			t.Errorf("Wrong device name %s", config.AWSConfig.DeviceName)
+		}
+	}
+}
+
+func TestNewFindWindowsAMIStep(t *testing.T) {
+	step := NewFindWindowsAMIStep(GetEC2)
+
+	if step.getImageService == nil {
+		t.Error("getImageService must not be nil")
+	}
+
+	if step.Name() != StepFindWindowsAMI {
+		t.Errorf("Wrong step name %s", step.Name())
+	}
+}
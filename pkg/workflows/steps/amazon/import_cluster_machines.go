@@ -8,11 +8,11 @@ import (
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
 	"github.com/supergiant/control/pkg/clouds"
 	"github.com/supergiant/control/pkg/model"
 	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -50,10 +50,10 @@ func InitImportClusterStep(fn GetEC2Fn) {
 }
 
 func (s ImportClusterStep) Run(ctx context.Context, out io.Writer, cfg *steps.Config) error {
-	logrus.Info(ImportClusterMachinesStepName)
+	sglog.FromContext(ctx).Info(ImportClusterMachinesStepName)
 	ec2Svc, err := s.getSvc(cfg.AWSConfig)
 	if err != nil {
-		logrus.Errorf("[%s] - failed to authorize in AWS: %v", s.Name(), err)
+		sglog.FromContext(ctx).Errorf("[%s] - failed to authorize in AWS: %v", s.Name(), err)
 		return errors.Wrap(ErrAuthorization, err.Error())
 	}
 
@@ -4,13 +4,14 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/util"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
@@ -50,13 +51,13 @@ func NewImportKeyPairStep(fn GetEC2Fn) *KeyPairStep {
 
 //Verifies that a key exists,
 func (s *KeyPairStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
-	logrus.Info(ImportKeyPairStepName)
+	sglog.FromContext(ctx).Info(ImportKeyPairStepName)
 	log := util.GetLogger(w)
 
 	svc, err := s.getSvc(cfg.AWSConfig)
 
 	if err != nil {
-		logrus.Errorf("Getting service caused %v", err)
+		sglog.FromContext(ctx).Errorf("Getting service caused %v", err)
 		return errors.Wrapf(err, "%s caused error when getting service",
 			ImportKeyPairStepName)
 	}
@@ -71,11 +72,20 @@ func (s *KeyPairStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config) e
 		cfg.Kube.Name,
 		cfg.Kube.ID[:4]),
 		false)
+	publicKey := cfg.Kube.SSHConfig.BootstrapPublicKey
+
+	// A rotated key gets a new name, the key pair of the previous
+	// key is still used by the cluster until rotation finishes.
+	if cfg.SSHKeyRotationConfig.PublicKey != "" {
+		bootstrapKeyPairName = fmt.Sprintf("%s-%d", bootstrapKeyPairName, time.Now().Unix())
+		publicKey = cfg.SSHKeyRotationConfig.PublicKey
+	}
+
 	log.Infof("[%s] - importing cluster bootstrap key as keypair %s",
 		s.Name(), bootstrapKeyPairName)
 	req := &ec2.ImportKeyPairInput{
 		KeyName:           &bootstrapKeyPairName,
-		PublicKeyMaterial: []byte(cfg.Kube.SSHConfig.BootstrapPublicKey),
+		PublicKeyMaterial: []byte(publicKey),
 	}
 
 	output, err := svc.ImportKeyPairWithContext(ctx, req)
@@ -97,7 +107,7 @@ func (s *KeyPairStep) Run(ctx context.Context, w io.Writer, cfg *steps.Config) e
 	err = svc.WaitUntilKeyPairExists(describeInput)
 
 	if err != nil {
-		logrus.Debugf("WaitUntilKeyPairExists caused %s", err.Error())
+		sglog.FromContext(ctx).Debugf("WaitUntilKeyPairExists caused %s", err.Error())
 		return errors.Wrap(err, fmt.Sprintf("wait until key pair found %s",
 			bootstrapKeyPairName))
 	}
@@ -214,3 +214,53 @@ func TestKeyPairStep_Name(t *testing.T) {
 			ImportKeyPairStepName, name)
 	}
 }
+
+func TestImportKeyPair_RunRotation(t *testing.T) {
+	var req *ec2.ImportKeyPairInput
+	svc := &mockKeyPairSvc{}
+	svc.On("ImportKeyPairWithContext",
+		mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			req = args.Get(1).(*ec2.ImportKeyPairInput)
+		}).
+		Return(&ec2.ImportKeyPairOutput{
+			KeyFingerprint: aws.String("fingerprint"),
+			KeyName:        aws.String("rotated"),
+		}, nil)
+	svc.On("WaitUntilKeyPairExists", mock.Anything).Return(nil)
+
+	config := &steps.Config{
+		Kube: model.Kube{
+			Name: "test",
+			ID:   "12345678",
+			SSHConfig: model.SSHConfig{
+				BootstrapPublicKey: "old",
+			},
+		},
+		SSHKeyRotationConfig: steps.SSHKeyRotationConfig{
+			PublicKey: "new",
+		},
+	}
+
+	step := KeyPairStep{
+		getSvc: func(steps.AWSConfig) (keyImporter, error) {
+			return svc, nil
+		},
+	}
+
+	if err := step.Run(context.Background(), &bytes.Buffer{}, config); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if string(req.PublicKeyMaterial) != "new" {
+		t.Errorf("rotated public key must be imported, actual %s", req.PublicKeyMaterial)
+	}
+
+	if !strings.HasPrefix(*req.KeyName, "test-1234-") {
+		t.Errorf("rotated key pair must have a unique name, actual %s", *req.KeyName)
+	}
+
+	if config.AWSConfig.KeyPairName != "rotated" {
+		t.Errorf("key pair name must be updated, actual %s", config.AWSConfig.KeyPairName)
+	}
+}
@@ -10,7 +10,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -45,10 +45,10 @@ func InitImportRouteTablesStep(fn GetEC2Fn) {
 }
 
 func (s ImportRouteTablesStep) Run(ctx context.Context, out io.Writer, cfg *steps.Config) error {
-	logrus.Info(ImporRouteTablesStepName)
+	sglog.FromContext(ctx).Info(ImporRouteTablesStepName)
 	ec2Svc, err := s.getSvc(cfg.AWSConfig)
 	if err != nil {
-		logrus.Errorf("[%s] - failed to authorize in AWS: %v", s.Name(), err)
+		sglog.FromContext(ctx).Errorf("[%s] - failed to authorize in AWS: %v", s.Name(), err)
 		return errors.Wrap(ErrAuthorization, err.Error())
 	}
 
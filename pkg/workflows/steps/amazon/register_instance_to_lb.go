@@ -10,6 +10,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -49,12 +50,12 @@ func (s *RegisterInstanceStep) Run(ctx context.Context, out io.Writer, cfg *step
 	svc, err := s.getLoadBalancerService(cfg.AWSConfig)
 
 	if err != nil {
-		logrus.Errorf("error getting ELB service %v", err)
+		sglog.FromContext(ctx).Errorf("error getting ELB service %v", err)
 		return errors.Wrapf(err, "error getting ELB service %s",
 			RegisterInstanceStepName)
 	}
 
-	logrus.Infof("Register instance Name: %s ID: %s to external load balancer: %s",
+	sglog.FromContext(ctx).Infof("Register instance Name: %s ID: %s to external load balancer: %s",
 		cfg.Node.Name, cfg.Node.ID, cfg.AWSConfig.ExternalLoadBalancerName)
 	_, err = svc.RegisterInstancesWithLoadBalancerWithContext(ctx, &elb.RegisterInstancesWithLoadBalancerInput{
 		LoadBalancerName: aws.String(cfg.AWSConfig.ExternalLoadBalancerName),
@@ -66,13 +67,13 @@ func (s *RegisterInstanceStep) Run(ctx context.Context, out io.Writer, cfg *step
 	})
 
 	if err != nil {
-		logrus.Errorf("error registering instance %s to external loadbalancer %s %v", cfg.Node.ID, cfg.AWSConfig.ExternalLoadBalancerName, err)
+		sglog.FromContext(ctx).Errorf("error registering instance %s to external loadbalancer %s %v", cfg.Node.ID, cfg.AWSConfig.ExternalLoadBalancerName, err)
 		return errors.Wrapf(err, "registering instance %s to load balancer Load balancer %s %s",
 			cfg.Node.ID, cfg.AWSConfig.ExternalLoadBalancerName,
 			DeleteLoadBalancerStepName)
 	}
 
-	logrus.Infof("Register instance Name: %s ID: %s to internal load balancer: %s",
+	sglog.FromContext(ctx).Infof("Register instance Name: %s ID: %s to internal load balancer: %s",
 		cfg.Node.Name, cfg.Node.ID, cfg.AWSConfig.ExternalLoadBalancerName)
 	_, err = svc.RegisterInstancesWithLoadBalancerWithContext(ctx, &elb.RegisterInstancesWithLoadBalancerInput{
 		LoadBalancerName: aws.String(cfg.AWSConfig.InternalLoadBalancerName),
@@ -84,7 +85,7 @@ func (s *RegisterInstanceStep) Run(ctx context.Context, out io.Writer, cfg *step
 	})
 
 	if err != nil {
-		logrus.Errorf("error registering instance %s to internal loadbalancer %s %v", cfg.Node.ID, cfg.AWSConfig.ExternalLoadBalancerName, err)
+		sglog.FromContext(ctx).Errorf("error registering instance %s to internal loadbalancer %s %v", cfg.Node.ID, cfg.AWSConfig.ExternalLoadBalancerName, err)
 		return errors.Wrapf(err, "registering instance %s to internal load balancer Load balancer %s %s",
 			cfg.Node.ID, cfg.AWSConfig.ExternalLoadBalancerName,
 			DeleteLoadBalancerStepName)
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/supergiant/control/pkg/sglog"
 )
 
 var (
@@ -34,7 +36,7 @@ func FindOutboundIP(ctx context.Context, findExternalIP func() (string, error))
 			if err == nil {
 				return publicIP, nil
 			} else {
-				logrus.Debugf("attempt #%d Error getting public IP sleep for %v",
+				sglog.FromContext(ctx).Debugf("attempt #%d Error getting public IP sleep for %v",
 					i+1, timeout)
 				time.Sleep(timeout)
 				timeout = timeout * 2
@@ -0,0 +1,214 @@
+package amazon
+
+import (
+	"context"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	VerifyClusterDeletedStepName = "aws_verify_cluster_deleted"
+
+	LeftoverInstance = "instance"
+	LeftoverVPC      = "vpc"
+
+	notDeletedReason = "not deleted"
+)
+
+type verifyDeletedService interface {
+	DescribeInstancesWithContext(aws.Context, *ec2.DescribeInstancesInput, ...request.Option) (*ec2.DescribeInstancesOutput, error)
+	DescribeNetworkInterfacesWithContext(aws.Context, *ec2.DescribeNetworkInterfacesInput, ...request.Option) (*ec2.DescribeNetworkInterfacesOutput, error)
+	DescribeAddressesWithContext(aws.Context, *ec2.DescribeAddressesInput, ...request.Option) (*ec2.DescribeAddressesOutput, error)
+	DescribeVolumesWithContext(aws.Context, *ec2.DescribeVolumesInput, ...request.Option) (*ec2.DescribeVolumesOutput, error)
+	DescribeVpcsWithContext(aws.Context, *ec2.DescribeVpcsInput, ...request.Option) (*ec2.DescribeVpcsOutput, error)
+}
+
+// VerifyClusterDeleted lists resources of the cluster that still exist, they
+// replace leftovers of the delete config and fail the step. It runs after
+// the clean up, even a failed one, so the report tells what to remove
+// manually.
+type VerifyClusterDeleted struct {
+	getEC2 func(steps.AWSConfig) (verifyDeletedService, error)
+	getELB func(steps.AWSConfig) (serviceLoadBalancerService, error)
+}
+
+func InitVerifyClusterDeleted(ec2Fn GetEC2Fn, elbFn GetELBFn) {
+	steps.RegisterStep(VerifyClusterDeletedStepName, NewVerifyClusterDeleted(ec2Fn, elbFn))
+}
+
+func NewVerifyClusterDeleted(ec2Fn GetEC2Fn, elbFn GetELBFn) *VerifyClusterDeleted {
+	return &VerifyClusterDeleted{
+		getEC2: func(cfg steps.AWSConfig) (verifyDeletedService, error) {
+			EC2, err := ec2Fn(cfg)
+
+			if err != nil {
+				return nil, errors.Wrap(ErrAuthorization, err.Error())
+			}
+
+			return EC2, nil
+		},
+		getELB: func(cfg steps.AWSConfig) (serviceLoadBalancerService, error) {
+			ELB, err := elbFn(cfg)
+
+			if err != nil {
+				return nil, errors.Wrap(ErrAuthorization, err.Error())
+			}
+
+			return ELB, nil
+		},
+	}
+}
+
+func (s *VerifyClusterDeleted) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
+	log := util.GetLogger(w)
+
+	ec2Svc, err := s.getEC2(cfg.AWSConfig)
+	if err != nil {
+		return errors.Wrapf(err, "%s get service", VerifyClusterDeletedStepName)
+	}
+	elbSvc, err := s.getELB(cfg.AWSConfig)
+	if err != nil {
+		return errors.Wrapf(err, "%s get service", VerifyClusterDeletedStepName)
+	}
+
+	found, err := existingResources(ctx, ec2Svc, elbSvc, cfg)
+	if err != nil {
+		return errors.Wrapf(err, "%s list resources", VerifyClusterDeletedStepName)
+	}
+
+	// keep reasons of failed deletions
+	reasons := make(map[string]string)
+	for _, l := range cfg.DeleteConfig.Leftovers {
+		reasons[l.Kind+"/"+l.ID] = l.Reason
+	}
+	cfg.DeleteConfig.Leftovers = nil
+	for _, l := range found {
+		if reason := reasons[l.Kind+"/"+l.ID]; reason != "" {
+			l.Reason = reason
+		}
+		cfg.DeleteConfig.AddLeftover(l.Kind, l.ID, l.Reason)
+	}
+
+	if len(cfg.DeleteConfig.Leftovers) == 0 {
+		log.Infof("[%s] - all resources of cluster %s are deleted", s.Name(), cfg.Kube.Name)
+		return nil
+	}
+
+	for _, l := range cfg.DeleteConfig.Leftovers {
+		log.Warnf("[%s] - %s %s is left: %s", s.Name(), l.Kind, l.ID, l.Reason)
+	}
+	return errors.Wrapf(ErrLeftovers, "%d resources have to be deleted manually",
+		len(cfg.DeleteConfig.Leftovers))
+}
+
+func (*VerifyClusterDeleted) Name() string {
+	return VerifyClusterDeletedStepName
+}
+
+func (*VerifyClusterDeleted) Depends() []string {
+	return nil
+}
+
+func (*VerifyClusterDeleted) Description() string {
+	return "Report resources of the cluster that are left"
+}
+
+func (*VerifyClusterDeleted) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func existingResources(ctx context.Context, ec2Svc verifyDeletedService, elbSvc serviceLoadBalancerService, cfg *steps.Config) ([]steps.Leftover, error) {
+	found := make([]steps.Leftover, 0)
+	add := func(kind string, id *string) {
+		found = append(found, steps.Leftover{Kind: kind, ID: aws.StringValue(id), Reason: notDeletedReason})
+	}
+
+	instances, err := ec2Svc.DescribeInstancesWithContext(ctx, &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("tag:" + clouds.TagClusterID),
+				Values: aws.StringSlice([]string{cfg.Kube.ID}),
+			},
+			{
+				Name: aws.String("instance-state-name"),
+				Values: aws.StringSlice([]string{ec2.InstanceStateNamePending, ec2.InstanceStateNameRunning,
+					ec2.InstanceStateNameStopping, ec2.InstanceStateNameStopped}),
+			},
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "describe instances")
+	}
+	for _, res := range instances.Reservations {
+		for _, instance := range res.Instances {
+			add(LeftoverInstance, instance.InstanceId)
+		}
+	}
+
+	for _, f := range volumeFilters(cfg) {
+		volumes, err := ec2Svc.DescribeVolumesWithContext(ctx, &ec2.DescribeVolumesInput{
+			Filters: append(f, &ec2.Filter{
+				Name:   aws.String("status"),
+				Values: aws.StringSlice([]string{ec2.VolumeStateCreating, ec2.VolumeStateAvailable, ec2.VolumeStateInUse}),
+			}),
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "describe volumes")
+		}
+		for _, volume := range volumes.Volumes {
+			add(LeftoverVolume, volume.VolumeId)
+		}
+	}
+
+	addresses, err := ec2Svc.DescribeAddressesWithContext(ctx, &ec2.DescribeAddressesInput{
+		Filters: clusterTagFilters(cfg.Kube.ID, cfg.Kube.Name)[0],
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "describe addresses")
+	}
+	for _, address := range addresses.Addresses {
+		add(LeftoverAddress, address.PublicIp)
+	}
+
+	if cfg.AWSConfig.VPCID == "" {
+		return found, nil
+	}
+
+	names, err := serviceLoadBalancers(ctx, elbSvc, steps.AWSConfig{VPCID: cfg.AWSConfig.VPCID})
+	if err != nil {
+		return nil, errors.Wrap(err, "describe load balancers")
+	}
+	for i := range names {
+		add(LeftoverLoadBalancer, &names[i])
+	}
+
+	enis, err := ec2Svc.DescribeNetworkInterfacesWithContext(ctx, &ec2.DescribeNetworkInterfacesInput{
+		Filters: []*ec2.Filter{vpcFilter(cfg.AWSConfig.VPCID)},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "describe network interfaces")
+	}
+	for _, eni := range enis.NetworkInterfaces {
+		add(LeftoverNetworkInterface, eni.NetworkInterfaceId)
+	}
+
+	vpcs, err := ec2Svc.DescribeVpcsWithContext(ctx, &ec2.DescribeVpcsInput{
+		Filters: []*ec2.Filter{vpcFilter(cfg.AWSConfig.VPCID)},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "describe vpc")
+	}
+	for _, vpc := range vpcs.Vpcs {
+		add(LeftoverVPC, vpc.VpcId)
+	}
+
+	return found, nil
+}
@@ -0,0 +1,83 @@
+package amazon
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestInitVerifyClusterDeleted(t *testing.T) {
+	InitVerifyClusterDeleted(GetEC2, GetELB)
+
+	if s := steps.GetStep(VerifyClusterDeletedStepName); s == nil {
+		t.Errorf("Step %s not found", VerifyClusterDeletedStepName)
+	}
+}
+
+func TestVerifyClusterDeleted_Run(t *testing.T) {
+	ec2Svc := &mockCleanupEC2{}
+	ec2Svc.On("DescribeInstances", mock.Anything).Return(&ec2.DescribeInstancesOutput{}, nil)
+	ec2Svc.On("DescribeVolumes", hasFilter("tag:KubernetesCluster", "kube")).
+		Return(&ec2.DescribeVolumesOutput{
+			Volumes: []*ec2.Volume{{VolumeId: aws.String("vol-pvc")}},
+		}, nil)
+	ec2Svc.On("DescribeVolumes", mock.Anything).Return(&ec2.DescribeVolumesOutput{}, nil)
+	ec2Svc.On("DescribeAddresses", mock.Anything).Return(&ec2.DescribeAddressesOutput{}, nil)
+	ec2Svc.On("DescribeNetworkInterfaces", mock.Anything).Return(&ec2.DescribeNetworkInterfacesOutput{}, nil)
+	ec2Svc.On("DescribeVpcs", mock.Anything).Return(&ec2.DescribeVpcsOutput{
+		Vpcs: []*ec2.Vpc{{VpcId: aws.String("vpc-1")}},
+	}, nil)
+	elbSvc := &mockServiceLBService{}
+	elbSvc.On("DescribeLoadBalancersWithContext", mock.Anything, mock.Anything, mock.Anything).
+		Return(&elb.DescribeLoadBalancersOutput{}, nil)
+
+	step := &VerifyClusterDeleted{
+		getEC2: func(steps.AWSConfig) (verifyDeletedService, error) {
+			return ec2Svc, nil
+		},
+		getELB: func(steps.AWSConfig) (serviceLoadBalancerService, error) {
+			return elbSvc, nil
+		},
+	}
+	cfg := cleanupConfig()
+	cfg.DeleteConfig.AddLeftover(LeftoverVolume, "vol-pvc", "VolumeInUse")
+	// deleted by a retry of a later step
+	cfg.DeleteConfig.AddLeftover(LeftoverLoadBalancer, "a1", "throttled")
+
+	err := step.Run(context.Background(), &bytes.Buffer{}, cfg)
+	require.Equal(t, ErrLeftovers, errors.Cause(err))
+	require.Equal(t, []steps.Leftover{
+		{Kind: LeftoverVolume, ID: "vol-pvc", Reason: "VolumeInUse"},
+		{Kind: LeftoverVPC, ID: "vpc-1", Reason: notDeletedReason},
+	}, cfg.DeleteConfig.Leftovers)
+}
+
+func TestVerifyClusterDeleted_RunNothingLeft(t *testing.T) {
+	ec2Svc := &mockCleanupEC2{}
+	ec2Svc.On("DescribeInstances", mock.Anything).Return(&ec2.DescribeInstancesOutput{}, nil)
+	ec2Svc.On("DescribeVolumes", mock.Anything).Return(&ec2.DescribeVolumesOutput{}, nil)
+	ec2Svc.On("DescribeAddresses", mock.Anything).Return(&ec2.DescribeAddressesOutput{}, nil)
+
+	step := &VerifyClusterDeleted{
+		getEC2: func(steps.AWSConfig) (verifyDeletedService, error) {
+			return ec2Svc, nil
+		},
+		getELB: func(steps.AWSConfig) (serviceLoadBalancerService, error) {
+			return &mockServiceLBService{}, nil
+		},
+	}
+	cfg := cleanupConfig()
+	cfg.AWSConfig.VPCID = ""
+
+	require.NoError(t, step.Run(context.Background(), &bytes.Buffer{}, cfg))
+	require.Empty(t, cfg.DeleteConfig.Leftovers)
+}
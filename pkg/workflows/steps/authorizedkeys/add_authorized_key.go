@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"github.com/supergiant/control/pkg/clouds"
 	"io"
+	"strings"
 	"text/template"
 
 	"github.com/pkg/errors"
 
+	"github.com/supergiant/control/pkg/model"
 	"github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/util"
 	"github.com/supergiant/control/pkg/workflows/steps"
@@ -39,13 +41,13 @@ func (s *Step) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
 	log := util.GetLogger(w)
 
 	log.Infof("[%s] - adding user's public key to the node", s.Name())
-	if cfg == nil || cfg.Kube.SSHConfig.PublicKey != "" {
+	if publicKeys := authorizedKeys(cfg.Kube.SSHConfig); publicKeys != "" {
 		err := steps.RunTemplate(ctx, s.script, cfg.Runner, w, struct {
 			PublicKey          string
 			BootstrapPublicKey string
 			UserName           string
 		}{
-			PublicKey:          cfg.Kube.SSHConfig.PublicKey,
+			PublicKey:          publicKeys,
 			BootstrapPublicKey: cfg.Kube.SSHConfig.BootstrapPublicKey,
 			UserName:           clouds.OSUser,
 		})
@@ -59,6 +61,20 @@ func (s *Step) Run(ctx context.Context, w io.Writer, cfg *steps.Config) error {
 	return nil
 }
 
+// authorizedKeys returns the user key and operator keys of the cluster, one
+// key per line.
+func authorizedKeys(cfg model.SSHConfig) string {
+	keys := make([]string, 0, len(cfg.OperatorKeys)+1)
+	if key := strings.TrimSpace(cfg.PublicKey); key != "" {
+		keys = append(keys, key)
+	}
+	for _, operatorKey := range cfg.OperatorKeys {
+		keys = append(keys, strings.TrimSpace(operatorKey.PublicKey))
+	}
+
+	return strings.Join(keys, "\n")
+}
+
 func (*Step) Name() string {
 	return StepName
 }
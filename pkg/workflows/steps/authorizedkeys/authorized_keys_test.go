@@ -73,6 +73,29 @@ func TestAuthorizedkeys(t *testing.T) {
 	}
 }
 
+func TestAuthorizedKeysOperatorKeys(t *testing.T) {
+	tpl := template.Must(template.New(StepName).Parse("{{ .PublicKey }}"))
+	output := new(bytes.Buffer)
+
+	cfg, err := steps.NewConfig("", "", profile.Profile{})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	cfg.Runner = &fakeRunner{}
+	cfg.Kube.SSHConfig.OperatorKeys = []model.OperatorKey{
+		{Name: "alice", PublicKey: "ssh-rsa AAAAalice alice\n"},
+	}
+
+	if err = (&Step{tpl}).Run(context.Background(), output, cfg); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	// operator keys are authorized without a user key
+	if !strings.Contains(output.String(), "ssh-rsa AAAAalice alice") {
+		t.Errorf("operator key not found in %s", output.String())
+	}
+}
+
 func TestAuthorizedKeysErr(t *testing.T) {
 	errMsg := "error has occurred"
 
@@ -10,30 +10,35 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/tracing"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
 func NSGClientFor(a autorest.Authorizer, subscriptionID string) (SecurityGroupInterface, autorest.Client) {
 	nsgClient := network.NewSecurityGroupsClient(subscriptionID)
 	nsgClient.Authorizer = a
+	nsgClient.Sender = tracing.Client("azure", nil)
 	return nsgClient, nsgClient.Client
 }
 
 func SubnetClientFor(a autorest.Authorizer, subscriptionID string) SubnetGetter {
 	subnetClient := network.NewSubnetsClient(subscriptionID)
 	subnetClient.Authorizer = a
+	subnetClient.Sender = tracing.Client("azure", nil)
 	return subnetClient
 }
 
 func VNetClientFor(a autorest.Authorizer, subscriptionID string) (VirtualNetworkCreator, autorest.Client) {
 	vnetClient := network.NewVirtualNetworksClient(subscriptionID)
 	vnetClient.Authorizer = a
+	vnetClient.Sender = tracing.Client("azure", nil)
 	return vnetClient, vnetClient.Client
 }
 
 func GroupsClientFor(a autorest.Authorizer, subscriptionID string) GroupsInterface {
 	gclient := resources.NewGroupsClient(subscriptionID)
 	gclient.Authorizer = a
+	gclient.Sender = tracing.Client("azure", nil)
 	return gclient
 }
 
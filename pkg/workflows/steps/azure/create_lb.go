@@ -9,9 +9,9 @@ import (
 	"github.com/Azure/go-autorest/autorest"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
 	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -60,7 +60,7 @@ func (s *CreateLBStep) Run(ctx context.Context, output io.Writer, config *steps.
 	config.Kube.ExternalDNSName = addr
 	config.Kube.InternalDNSName = addr
 
-	logrus.Debugf("azure: %s lb has been created", to.String(lb.Name))
+	sglog.FromContext(ctx).Debugf("azure: %s lb has been created", to.String(lb.Name))
 	return nil
 }
 
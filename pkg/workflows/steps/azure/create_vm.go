@@ -12,12 +12,12 @@ import (
 	"github.com/Azure/go-autorest/autorest"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
 	"github.com/supergiant/control/pkg/clouds"
 	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
 	"github.com/supergiant/control/pkg/sgerrors"
-	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -56,7 +56,10 @@ func (s *CreateVMStep) Run(ctx context.Context, output io.Writer, config *steps.
 	// TODO: set user with config
 	config.Kube.SSHConfig.User = clouds.OSUser
 
-	vmName := util.MakeNodeName(config.Kube.Name, config.TaskID, config.IsMaster)
+	vmName, err := config.InstanceName()
+	if err != nil {
+		return errors.Wrap(err, "name vm")
+	}
 
 	config.Node = model.Machine{
 		Name:     vmName,
@@ -87,7 +90,7 @@ func (s *CreateVMStep) Run(ctx context.Context, output io.Writer, config *steps.
 		config.AddNode(&config.Node)
 	}
 
-	logrus.Debugf("Machine created %s/%s", config.Kube.Name, config.Node.Name)
+	sglog.FromContext(ctx).Debugf("Machine created %s/%s", config.Kube.Name, config.Node.Name)
 	return nil
 }
 
@@ -159,6 +162,7 @@ func (s *CreateVMStep) setupVM(ctx context.Context, config *steps.Config, vmName
 		vmName,
 		compute.VirtualMachine{
 			Location: to.StringPtr(config.AzureConfig.Location),
+			Identity: toIdentity(config.Kube.RegistryCredentials),
 			VirtualMachineProperties: &compute.VirtualMachineProperties{
 				AvailabilitySet: &compute.SubResource{
 					ID: as.ID,
@@ -350,6 +354,17 @@ func toPublicKeys(bootstrapPKey, pkey string) *[]compute.SSHPublicKey {
 	return &keys
 }
 
+// toIdentity assigns an identity to vms that pull images from ACR, kubelet
+// gets registry tokens with it.
+func toIdentity(creds profile.RegistryCredentials) *compute.VirtualMachineIdentity {
+	if !creds.ACR {
+		return nil
+	}
+	return &compute.VirtualMachineIdentity{
+		Type: compute.ResourceIdentityTypeSystemAssigned,
+	}
+}
+
 func (s *CreateVMStep) getPublicIP(ctx context.Context, a autorest.Authorizer, subsID, groupName, ipName string) (string, error) {
 	ip, err := s.sdk.PublicAddressesClient(a, subsID).Get(ctx, groupName, ipName, "")
 	if err != nil {
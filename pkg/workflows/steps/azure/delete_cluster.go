@@ -5,9 +5,9 @@ import (
 	"io"
 
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
 	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -41,7 +41,7 @@ func (s DeleteClusterStep) Run(ctx context.Context, output io.Writer, config *st
 
 	// All cluster resources have been added to the this resources group.
 	name := toResourceGroupName(config.Kube.ID, config.Kube.Name)
-	logrus.Debugf("deleting %s azure resource group", name)
+	sglog.FromContext(ctx).Debugf("deleting %s azure resource group", name)
 	f, err := groupsClient.Delete(ctx, name)
 	if err != nil {
 		return errors.Wrap(err, "delete cluster: delete resource group")
@@ -52,7 +52,7 @@ func (s DeleteClusterStep) Run(ctx context.Context, output io.Writer, config *st
 		return errors.Wrapf(err, "delete %s resource group", name)
 	}
 
-	logrus.Debugf("%s azure resource group has been deleted", name)
+	sglog.FromContext(ctx).Debugf("%s azure resource group has been deleted", name)
 	return nil
 }
 
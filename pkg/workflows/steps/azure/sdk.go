@@ -7,6 +7,8 @@ import (
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-11-01/network"
 	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
 	"github.com/Azure/go-autorest/autorest"
+
+	"github.com/supergiant/control/pkg/tracing"
 )
 
 type GroupsInterface interface {
@@ -64,47 +66,55 @@ func NewSDK() SDK {
 func (s SDK) RestClient(a autorest.Authorizer, subscriptionID string) autorest.Client {
 	gclient := resources.NewGroupsClient(subscriptionID)
 	gclient.Authorizer = a
+	gclient.Sender = tracing.Client("azure", nil)
 	return gclient.Client
 }
 
 func (s SDK) NICClient(a autorest.Authorizer, subscriptionID string) NICInterface {
 	nicClient := network.NewInterfacesClient(subscriptionID)
 	nicClient.Authorizer = a
+	nicClient.Sender = tracing.Client("azure", nil)
 	return nicClient
 }
 
 func (s SDK) PublicAddressesClient(a autorest.Authorizer, subscriptionID string) PublicAddressInterface {
 	ipClient := network.NewPublicIPAddressesClient(subscriptionID)
 	ipClient.Authorizer = a
+	ipClient.Sender = tracing.Client("azure", nil)
 	return ipClient
 }
 
 func (s SDK) SubnetClient(a autorest.Authorizer, subscriptionID string) SubnetGetter {
 	subnetClient := network.NewSubnetsClient(subscriptionID)
 	subnetClient.Authorizer = a
+	subnetClient.Sender = tracing.Client("azure", nil)
 	return subnetClient
 }
 
 func (s SDK) NSGClient(a autorest.Authorizer, subscriptionID string) SecurityGroupInterface {
 	nsgClient := network.NewSecurityGroupsClient(subscriptionID)
 	nsgClient.Authorizer = a
+	nsgClient.Sender = tracing.Client("azure", nil)
 	return nsgClient
 }
 
 func (s SDK) VMClient(a autorest.Authorizer, subscriptionID string) VMInterface {
 	vmclient := compute.NewVirtualMachinesClient(subscriptionID)
 	vmclient.Authorizer = a
+	vmclient.Sender = tracing.Client("azure", nil)
 	return vmclient
 }
 
 func (s SDK) LBClient(a autorest.Authorizer, subscriptionID string) network.LoadBalancersClient {
 	lbclient := network.NewLoadBalancersClient(subscriptionID)
 	lbclient.Authorizer = a
+	lbclient.Sender = tracing.Client("azure", nil)
 	return lbclient
 }
 
 func (s SDK) AvailabilitySetClient(a autorest.Authorizer, subscriptionID string) compute.AvailabilitySetsClient {
 	aslient := compute.NewAvailabilitySetsClient(subscriptionID)
 	aslient.Authorizer = a
+	aslient.Sender = tracing.Client("azure", nil)
 	return aslient
 }
@@ -2,12 +2,14 @@ package azure
 
 import (
 	"context"
+	"net/http"
 	"testing"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-11-01/network"
 	"github.com/Azure/go-autorest/autorest"
 	"github.com/stretchr/testify/require"
+	"go.opencensus.io/plugin/ochttp"
 )
 
 var (
@@ -92,6 +94,9 @@ func TestSDK(t *testing.T) {
 
 	restclient := sdk.RestClient(autorest.NullAuthorizer{}, "id")
 	require.NotNil(t, restclient.Authorizer)
+	sender, ok := restclient.Sender.(*http.Client)
+	require.True(t, ok)
+	require.IsType(t, &ochttp.Transport{}, sender.Transport)
 
 	nicclient := sdk.NICClient(autorest.NullAuthorizer{}, "id")
 	require.NotNil(t, nicclient)
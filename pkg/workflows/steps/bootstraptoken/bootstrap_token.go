@@ -7,13 +7,19 @@ import (
 	"text/template"
 
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
+	"github.com/supergiant/control/pkg/sglog"
 	tm "github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
-const StepName = "bootstrap_token"
+const (
+	StepName = "bootstrap_token"
+
+	// MasterTokenTTL matches the lifetime of certificates uploaded for
+	// masters, nodes join with tokens of their own.
+	MasterTokenTTL = "2h"
+)
 
 type Step struct {
 	script *template.Template
@@ -50,18 +56,20 @@ func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) err
 			return errors.Wrapf(err, "generate bootstrap token")
 		}
 
-		logrus.Debug("Create bootstrap token")
+		sglog.FromContext(ctx).Debug("Create bootstrap token")
 		// NOTE(stgleb): Reuse KubeadmConfig.Token field to avoid
 		err = steps.RunTemplate(ctx, s.script, config.Runner, out, struct {
 			IsBootstrap    bool
 			Token          string
 			CertificateKey string
 			IsImport       bool
+			TTL            string
 		}{
 			IsBootstrap:    config.IsBootstrap,
 			Token:          config.Kube.BootstrapToken,
 			CertificateKey: config.Kube.Auth.CertificateKey,
 			IsImport:       config.IsImport,
+			TTL:            MasterTokenTTL,
 		})
 
 		if err != nil {
@@ -0,0 +1,192 @@
+package bootstraptoken
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
+
+	"github.com/supergiant/control/pkg/kubeconfig"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sglog"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	NodeStepName   = "bootstrap_token_node"
+	RevokeStepName = "bootstrap_token_revoke"
+
+	// NodeTokenTTL is the time a machine has to join the cluster.
+	NodeTokenTTL = 30 * time.Minute
+	// NodeTokenGroup is the group kubeadm allows to join nodes and to get
+	// kubelet certificates.
+	NodeTokenGroup = "system:bootstrappers:kubeadm:default-node-token"
+	// DescriptionPrefix marks tokens minted by supergiant.
+	DescriptionPrefix = "supergiant: "
+)
+
+type clientFn func(k *model.Kube) (corev1client.CoreV1Interface, error)
+
+// NodeStep mints a short-lived bootstrap token the machine joins with.
+type NodeStep struct {
+	newClient clientFn
+}
+
+// RevokeStep deletes the bootstrap token of the machine once it joined.
+type RevokeStep struct {
+	newClient clientFn
+}
+
+func InitNode() {
+	steps.RegisterStep(NodeStepName, &NodeStep{newClient: kubeconfig.CoreV1Client})
+	steps.RegisterStep(RevokeStepName, &RevokeStep{newClient: kubeconfig.CoreV1Client})
+}
+
+// NewSecret returns the secret of the bootstrap token that expires after the ttl.
+func NewSecret(token, description string, ttl time.Duration, groups ...string) (*corev1.Secret, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, errors.New("malformed bootstrap token")
+	}
+
+	data := map[string]string{
+		bootstrapapi.BootstrapTokenIDKey:               parts[0],
+		bootstrapapi.BootstrapTokenSecretKey:           parts[1],
+		bootstrapapi.BootstrapTokenDescriptionKey:      description,
+		bootstrapapi.BootstrapTokenExpirationKey:       time.Now().Add(ttl).UTC().Format(time.RFC3339),
+		bootstrapapi.BootstrapTokenUsageAuthentication: "true",
+		bootstrapapi.BootstrapTokenUsageSigningKey:     "true",
+	}
+	if len(groups) > 0 {
+		data[bootstrapapi.BootstrapTokenExtraGroupsKey] = strings.Join(groups, ",")
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      bootstrapapi.BootstrapTokenSecretPrefix + parts[0],
+			Namespace: metav1.NamespaceSystem,
+		},
+		Type:       corev1.SecretTypeBootstrapToken,
+		StringData: data,
+	}, nil
+}
+
+// clusterClient returns a client of the cluster the machine joins, masters
+// of the config are used while the kube has none saved yet.
+func clusterClient(newClient clientFn, config *steps.Config) (corev1client.SecretInterface, error) {
+	k := config.Kube
+	if len(k.Masters) == 0 {
+		k.Masters = config.GetMasters()
+	}
+
+	client, err := newClient(&k)
+	if err != nil {
+		return nil, errors.Wrap(err, "get kube client")
+	}
+	return client.Secrets(metav1.NamespaceSystem), nil
+}
+
+func (s *NodeStep) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	// masters join with the token of the bootstrap master
+	if config.IsMaster {
+		return nil
+	}
+
+	token, err := GenerateBootstrapToken()
+	if err != nil {
+		return errors.Wrap(err, "generate bootstrap token")
+	}
+	secret, err := NewSecret(token, DescriptionPrefix+"join of "+config.Node.Name, NodeTokenTTL, NodeTokenGroup)
+	if err != nil {
+		return err
+	}
+
+	if config.Simulated() {
+		config.NodeToken = token
+		return nil
+	}
+
+	secrets, err := clusterClient(s.newClient, config)
+	if err != nil {
+		return errors.Wrapf(err, "%s step", NodeStepName)
+	}
+	if _, err = secrets.Create(secret); err != nil {
+		return errors.Wrapf(err, "%s step: create token", NodeStepName)
+	}
+
+	sglog.FromContext(ctx).Debugf("bootstrap token %s expires in %s", secret.Name, NodeTokenTTL)
+	config.NodeToken = token
+	return nil
+}
+
+// Rollback deletes the token of a machine that hasn't joined.
+func (s *NodeStep) Rollback(ctx context.Context, out io.Writer, config *steps.Config) error {
+	if config.Simulated() {
+		return nil
+	}
+	return revoke(s.newClient, config)
+}
+
+func (s *NodeStep) Name() string {
+	return NodeStepName
+}
+
+func (s *NodeStep) Description() string {
+	return "create a short-lived bootstrap token for the machine"
+}
+
+func (s *NodeStep) Depends() []string {
+	return nil
+}
+
+func (s *RevokeStep) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	if config.Simulated() {
+		return nil
+	}
+	if err := revoke(s.newClient, config); err != nil {
+		return errors.Wrapf(err, "%s step", RevokeStepName)
+	}
+	return nil
+}
+
+func (s *RevokeStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *RevokeStep) Name() string {
+	return RevokeStepName
+}
+
+func (s *RevokeStep) Description() string {
+	return "delete the bootstrap token of the machine"
+}
+
+func (s *RevokeStep) Depends() []string {
+	return []string{NodeStepName}
+}
+
+func revoke(newClient clientFn, config *steps.Config) error {
+	if config.NodeToken == "" {
+		return nil
+	}
+
+	secrets, err := clusterClient(newClient, config)
+	if err != nil {
+		return err
+	}
+
+	id := strings.Split(config.NodeToken, ".")[0]
+	err = secrets.Delete(bootstrapapi.BootstrapTokenSecretPrefix+id, &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "delete token %s", id)
+	}
+	config.NodeToken = ""
+	return nil
+}
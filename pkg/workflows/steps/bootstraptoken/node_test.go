@@ -0,0 +1,64 @@
+package bootstraptoken
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestNewSecret(t *testing.T) {
+	secret, err := NewSecret("abcdef.0123456789abcdef", "join", time.Hour, NodeTokenGroup)
+	require.NoError(t, err)
+	require.Equal(t, "bootstrap-token-abcdef", secret.Name)
+	require.Equal(t, corev1.SecretTypeBootstrapToken, secret.Type)
+	require.Equal(t, NodeTokenGroup, secret.StringData[bootstrapapi.BootstrapTokenExtraGroupsKey])
+
+	exp, err := time.Parse(time.RFC3339, secret.StringData[bootstrapapi.BootstrapTokenExpirationKey])
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(time.Hour), exp, time.Minute)
+
+	_, err = NewSecret("abcdef", "join", time.Hour)
+	require.Error(t, err)
+}
+
+func TestNodeStep(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	newClient := func(*model.Kube) (corev1client.CoreV1Interface, error) {
+		return clientset.CoreV1(), nil
+	}
+
+	cfg := &steps.Config{Kube: model.Kube{BootstrapToken: "master.token"}}
+	cfg.Node.Name = "worker-1"
+	require.NoError(t, (&NodeStep{newClient: newClient}).Run(context.Background(), ioutil.Discard, cfg))
+	require.NotEmpty(t, cfg.NodeToken)
+	require.Equal(t, cfg.NodeToken, cfg.JoinToken())
+	require.Equal(t, "master.token", cfg.Kube.BootstrapToken)
+
+	secrets, err := clientset.CoreV1().Secrets(metav1.NamespaceSystem).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, secrets.Items, 1)
+	require.Contains(t, secrets.Items[0].StringData[bootstrapapi.BootstrapTokenDescriptionKey], "worker-1")
+
+	require.NoError(t, (&RevokeStep{newClient: newClient}).Run(context.Background(), ioutil.Discard, cfg))
+	require.Empty(t, cfg.NodeToken)
+	require.Equal(t, "master.token", cfg.JoinToken())
+
+	secrets, err = clientset.CoreV1().Secrets(metav1.NamespaceSystem).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, secrets.Items)
+
+	cfg.IsMaster = true
+	require.NoError(t, (&NodeStep{newClient: newClient}).Run(context.Background(), ioutil.Discard, cfg))
+	require.Empty(t, cfg.NodeToken, "masters join with the master token")
+}
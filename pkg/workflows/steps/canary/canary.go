@@ -0,0 +1,95 @@
+package canary
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	tm "github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const StepName = "canary"
+
+type Config struct {
+	AddonHealth bool
+	// Timeout of each check in seconds.
+	Timeout   int
+	Job       string
+	JobName   string
+	Namespace string
+}
+
+// Step verifies the cluster on a master after canary nodes are upgraded:
+// addons of kube-system must roll out and the smoke test job must complete.
+type Step struct {
+	script *template.Template
+}
+
+func Init() {
+	tpl, err := tm.GetTemplate(StepName)
+
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", StepName))
+	}
+
+	steps.RegisterStep(StepName, New(tpl))
+}
+
+func New(script *template.Template) *Step {
+	return &Step{
+		script: script,
+	}
+}
+
+func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	cfg, err := toStepCfg(config)
+	if err != nil {
+		return errors.Wrap(err, "canary step")
+	}
+
+	if err = steps.RunTemplate(ctx, s.script, config.Runner, out, cfg); err != nil {
+		return errors.Wrap(err, "verify canary nodes")
+	}
+
+	return nil
+}
+
+func (s *Step) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *Step) Name() string {
+	return StepName
+}
+
+func (s *Step) Description() string {
+	return "Verify the cluster after canary nodes are upgraded"
+}
+
+func (s *Step) Depends() []string {
+	return nil
+}
+
+func toStepCfg(c *steps.Config) (Config, error) {
+	canary := c.UpgradeCanary
+	cfg := Config{
+		AddonHealth: canary.AddonHealth,
+		Timeout:     int(canary.TimeoutDuration().Seconds()),
+	}
+
+	if canary.SmokeTestJob != "" {
+		job, err := canary.Job()
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Job = canary.SmokeTestJob
+		cfg.JobName = job.Name
+		cfg.Namespace = job.Namespace
+	}
+
+	return cfg, nil
+}
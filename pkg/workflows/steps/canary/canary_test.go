@@ -0,0 +1,73 @@
+package canary
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestCanary(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(StepName)
+
+	if tpl == nil {
+		t.Fatal("template not found")
+	}
+
+	output := &bytes.Buffer{}
+	config := &steps.Config{
+		UpgradeCanary: profile.UpgradeCanary{
+			Enabled:     true,
+			AddonHealth: true,
+			SmokeTestJob: `kind: Job
+metadata:
+  name: smoke
+  namespace: apps
+`,
+			Timeout: 120,
+		},
+		Runner: &testutils.MockRunner{},
+	}
+
+	err = New(tpl).Run(context.Background(), output, config)
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, expected := range []string{
+		"rollout status $NAME --timeout=120s",
+		"-n apps apply -f -",
+		"name: smoke",
+		"wait --for=condition=complete job/smoke --timeout=120s",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("%s not found in output %s", expected, output.String())
+		}
+	}
+}
+
+func TestCanaryInvalidJob(t *testing.T) {
+	config := &steps.Config{
+		UpgradeCanary: profile.UpgradeCanary{
+			SmokeTestJob: "kind: Pod",
+		},
+		Runner: &testutils.MockRunner{},
+	}
+
+	tpl, _ := templatemanager.GetTemplate(StepName)
+	if err := New(tpl).Run(context.Background(), &bytes.Buffer{}, config); err == nil {
+		t.Error("error expected for a pod")
+	}
+}
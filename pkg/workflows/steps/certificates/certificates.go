@@ -12,12 +12,20 @@ import (
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
-const StepName = "certificates"
+const (
+	StepName        = "certificates"
+	ReissueStepName = "certificates_reissue"
+
+	readTemplateName  = "certificates_read"
+	writeTemplateName = "certificates_write"
+)
 
 type Config struct {
 	IsBootstrap bool
 	CACert      string
 	CAKey       string
+	EtcdCACert  string
+	EtcdCAKey   string
 }
 
 type Step struct {
@@ -35,6 +43,17 @@ func Init() {
 	}
 
 	steps.RegisterStep(StepName, New(tpl))
+
+	readTpl, err := tm.GetTemplate(readTemplateName)
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", readTemplateName))
+	}
+	writeTpl, err := tm.GetTemplate(writeTemplateName)
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", writeTemplateName))
+	}
+
+	steps.RegisterStep(ReissueStepName, NewReissue(readTpl, writeTpl))
 }
 
 func New(tpl *template.Template) *Step {
@@ -69,5 +88,7 @@ func toStepCfg(c *steps.Config) Config {
 		IsBootstrap: c.IsBootstrap,
 		CACert:      c.Kube.Auth.CACert,
 		CAKey:       c.Kube.Auth.CAKey,
+		EtcdCACert:  c.Kube.Auth.EtcdCACert,
+		EtcdCAKey:   c.Kube.Auth.EtcdCAKey,
 	}
 }
@@ -0,0 +1,239 @@
+package certificates
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/pki"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	certMarker = "certificate file"
+
+	apiserverCert  = "/etc/kubernetes/pki/apiserver.crt"
+	etcdServerCert = "/etc/kubernetes/pki/etcd/server.crt"
+	etcdPeerCert   = "/etc/kubernetes/pki/etcd/peer.crt"
+	// kubelets of masters serve the certificate of the kubelet step
+	kubeletMasterCert = "/etc/kubernetes/pki/kubelet.crt"
+	// kubelets of nodes rotate serving certificates approved by control,
+	// the file keeps the certificate and the key
+	kubeletNodeCert = "/var/lib/kubelet/pki/kubelet-server-current.pem"
+)
+
+var certRegexp = regexp.MustCompile(`(?m)^` + certMarker + ` (\S+) ([A-Za-z0-9+/=]+)$`)
+
+type component int
+
+const (
+	apiserver component = iota
+	etcd
+	kubelet
+)
+
+// target is a certificate file that is reissued with settings of the kube.
+type target struct {
+	path      string
+	component component
+	ca        *pki.PairPEM
+	sans      []string
+	// combined files keep the key after the certificate
+	combined bool
+	// wait for the file, it's written after the certificate is approved
+	wait bool
+}
+
+// CertFile is a certificate with its key, the key is written to the
+// certificate file when KeyPath is empty.
+type CertFile struct {
+	Path    string
+	KeyPath string
+	Cert    string
+	Key     string
+	Wait    bool
+}
+
+type ReissueConfig struct {
+	Marker string
+	Certs  []CertFile
+
+	RestartEtcd      bool
+	RestartAPIServer bool
+	RestartKubelet   bool
+}
+
+// ReissueStep signs certificates generated by kubeadm and kubelet with the lifetime
+// and extra SANs from the kube settings, kubeadm doesn't allow to configure them.
+type ReissueStep struct {
+	read  *template.Template
+	write *template.Template
+}
+
+func NewReissue(read, write *template.Template) *ReissueStep {
+	return &ReissueStep{
+		read:  read,
+		write: write,
+	}
+}
+
+func (s *ReissueStep) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	targets := reissueTargets(config)
+	if len(targets) == 0 {
+		return nil
+	}
+
+	ttl := pki.DefaultCertValidity
+	if days := config.Kube.Certificates.ValidityDays; days > 0 {
+		ttl = time.Duration(days) * 24 * time.Hour
+	}
+
+	readCfg := ReissueConfig{
+		Marker: certMarker,
+	}
+	for _, t := range targets {
+		readCfg.Certs = append(readCfg.Certs, CertFile{Path: t.path, Wait: t.wait})
+	}
+
+	buf := &bytes.Buffer{}
+	if err := steps.RunTemplate(ctx, s.read, config.Runner, buf, readCfg); err != nil {
+		return errors.Wrap(err, "read certificates")
+	}
+
+	existing := make(map[string][]byte)
+	for _, m := range certRegexp.FindAllStringSubmatch(buf.String(), -1) {
+		data, err := base64.StdEncoding.DecodeString(m[2])
+		if err != nil {
+			return errors.Wrapf(err, "decode %s", m[1])
+		}
+		existing[m[1]] = data
+	}
+
+	writeCfg := ReissueConfig{}
+	for _, t := range targets {
+		data, ok := existing[t.path]
+		if !ok {
+			return errors.Errorf("certificate %s not found", t.path)
+		}
+
+		pair, err := pki.Reissue(data, t.sans, ttl, t.ca)
+		if err != nil {
+			return errors.Wrapf(err, "reissue %s", t.path)
+		}
+
+		f := CertFile{
+			Path: t.path,
+			Cert: string(pair.Cert),
+			Key:  string(pair.Key),
+		}
+		if !t.combined {
+			f.KeyPath = strings.TrimSuffix(t.path, ".crt") + ".key"
+		}
+		writeCfg.Certs = append(writeCfg.Certs, f)
+
+		switch t.component {
+		case apiserver:
+			writeCfg.RestartAPIServer = true
+		case etcd:
+			writeCfg.RestartEtcd = true
+		case kubelet:
+			writeCfg.RestartKubelet = true
+		}
+	}
+
+	if len(writeCfg.Certs) == 0 {
+		return nil
+	}
+
+	if err := steps.RunTemplate(ctx, s.write, config.Runner, out, writeCfg); err != nil {
+		return errors.Wrap(err, "write certificates")
+	}
+
+	return nil
+}
+
+func (s *ReissueStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *ReissueStep) Name() string {
+	return ReissueStepName
+}
+
+func (s *ReissueStep) Description() string {
+	return "Reissue component certificates with the configured lifetime and SANs"
+}
+
+func (s *ReissueStep) Depends() []string {
+	return nil
+}
+
+// reissueTargets returns certificates of the machine that need to be reissued. Extra SANs
+// of apiserver and etcd are set by kubeadm, so they are reissued only to change a lifetime.
+func reissueTargets(config *steps.Config) []target {
+	certs := config.Kube.Certificates
+	if certs.ValidityDays == 0 && len(certs.KubeletSANs) == 0 {
+		return nil
+	}
+
+	ca := &pki.PairPEM{
+		Cert: []byte(config.Kube.Auth.CACert),
+		Key:  []byte(config.Kube.Auth.CAKey),
+	}
+
+	var targets []target
+	if config.IsMaster && certs.ValidityDays > 0 {
+		targets = append(targets, target{
+			path:      apiserverCert,
+			component: apiserver,
+			ca:        ca,
+			sans:      certs.APIServerSANs,
+		})
+
+		// clusters don't keep the etcd CA on the control side before it was introduced
+		if config.Kube.Auth.EtcdCAKey != "" {
+			etcdCA := &pki.PairPEM{
+				Cert: []byte(config.Kube.Auth.EtcdCACert),
+				Key:  []byte(config.Kube.Auth.EtcdCAKey),
+			}
+			for _, path := range []string{etcdServerCert, etcdPeerCert} {
+				targets = append(targets, target{
+					path:      path,
+					component: etcd,
+					ca:        etcdCA,
+					sans:      certs.EtcdSANs,
+				})
+			}
+		}
+	}
+
+	sans := append([]string{}, certs.KubeletSANs...)
+	for _, ip := range []string{config.Node.PrivateIp, config.Node.PublicIp} {
+		if ip != "" {
+			sans = append(sans, ip)
+		}
+	}
+	// certificates of nodes that the kubelet rotates later are requested
+	// without the extra SANs, the rotation workflow reissues them
+	kubeletCert := target{
+		path:      kubeletMasterCert,
+		component: kubelet,
+		ca:        ca,
+		sans:      sans,
+	}
+	if !config.IsMaster {
+		kubeletCert.path = kubeletNodeCert
+		kubeletCert.combined = true
+		kubeletCert.wait = true
+	}
+	targets = append(targets, kubeletCert)
+
+	return targets
+}
@@ -0,0 +1,218 @@
+package certificates
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/pki"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// filesRunner answers the read script with certificates it has.
+type filesRunner struct {
+	files   map[string][]byte
+	scripts []string
+}
+
+func (r *filesRunner) Run(cmd *runner.Command) error {
+	r.scripts = append(r.scripts, cmd.Script)
+
+	out := &bytes.Buffer{}
+	for path, data := range r.files {
+		if strings.Contains(cmd.Script, "test -f "+path) {
+			out.WriteString(certMarker + " " + path + " " + base64.StdEncoding.EncodeToString(data) + "\n")
+		}
+	}
+
+	_, err := io.Copy(cmd.Out, out)
+	return err
+}
+
+func TestReissue(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readTpl, _ := templatemanager.GetTemplate(readTemplateName)
+	writeTpl, _ := templatemanager.GetTemplate(writeTemplateName)
+	if readTpl == nil || writeTpl == nil {
+		t.Fatal("template not found")
+	}
+
+	ca, err := pki.NewCAPair(nil)
+	if err != nil {
+		t.Fatalf("create CA: %v", err)
+	}
+	apiserverPair, err := pki.NewEtcdPeerPair("master-1", []string{"10.0.0.1"}, ca)
+	if err != nil {
+		t.Fatalf("create apiserver cert: %v", err)
+	}
+
+	kubeletPair, err := pki.NewEtcdPeerPair("master-1", []string{"10.0.0.1"}, ca)
+	if err != nil {
+		t.Fatalf("create kubelet cert: %v", err)
+	}
+
+	r := &filesRunner{
+		files: map[string][]byte{
+			apiserverCert:     apiserverPair.Cert,
+			kubeletMasterCert: kubeletPair.Cert,
+		},
+	}
+	config := &steps.Config{
+		IsMaster: true,
+		Node:     model.Machine{PrivateIp: "10.0.0.1"},
+		Kube: model.Kube{
+			Auth: model.Auth{
+				CACert: string(ca.Cert),
+				CAKey:  string(ca.Key),
+			},
+			Certificates: profile.Certificates{
+				ValidityDays:  30,
+				APIServerSANs: []string{"api.corp.example.com", "192.168.0.10"},
+			},
+		},
+		Runner: r,
+	}
+
+	err = NewReissue(readTpl, writeTpl).Run(context.Background(), &bytes.Buffer{}, config)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if len(r.scripts) != 2 {
+		t.Fatalf("expected read and write scripts, actual %d", len(r.scripts))
+	}
+	write := r.scripts[1]
+	if !strings.Contains(write, "k8s_kube-apiserver_ | xargs -r sudo docker restart") ||
+		!strings.Contains(write, "systemctl restart kubelet") || strings.Contains(write, "k8s_etcd_") {
+		t.Errorf("apiserver and kubelet must be restarted for reissued certs %s", write)
+	}
+	if !strings.Contains(write, "cat > /etc/kubernetes/pki/kubelet.key") {
+		t.Errorf("kubelet key must be written next to its certificate %s", write)
+	}
+
+	m := regexp.MustCompile(`(?s)cat > ` + apiserverCert + ` <<EOF\n(.*?)EOF`).FindStringSubmatch(write)
+	if m == nil {
+		t.Fatalf("apiserver certificate not found in %s", write)
+	}
+	block, _ := pem.Decode([]byte(m[1]))
+	if block == nil {
+		t.Fatalf("decode reissued certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("parse reissued certificate: %v", err)
+	}
+
+	if cert.NotAfter.After(time.Now().Add(31*24*time.Hour)) || cert.NotAfter.Before(time.Now().Add(29*24*time.Hour)) {
+		t.Errorf("unexpected certificate lifetime, expires at %s", cert.NotAfter)
+	}
+	for _, name := range []string{"master-1", "api.corp.example.com", "192.168.0.10", "10.0.0.1"} {
+		if err := cert.VerifyHostname(name); err != nil {
+			t.Errorf("SAN %s: %v", name, err)
+		}
+	}
+}
+
+func TestReissueDefaults(t *testing.T) {
+	r := &filesRunner{}
+	config := &steps.Config{
+		IsMaster: true,
+		Runner:   r,
+	}
+
+	err := NewReissue(nil, nil).Run(context.Background(), &bytes.Buffer{}, config)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(r.scripts) != 0 {
+		t.Errorf("certificates must not be reissued without settings")
+	}
+}
+
+func TestReissueNode(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	readTpl, _ := templatemanager.GetTemplate(readTemplateName)
+	writeTpl, _ := templatemanager.GetTemplate(writeTemplateName)
+
+	ca, err := pki.NewCAPair(nil)
+	if err != nil {
+		t.Fatalf("create CA: %v", err)
+	}
+	kubeletPair, err := pki.NewEtcdPeerPair("node-1", []string{"10.0.0.2"}, ca)
+	if err != nil {
+		t.Fatalf("create kubelet cert: %v", err)
+	}
+
+	config := &steps.Config{
+		Node: model.Machine{PrivateIp: "10.0.0.2"},
+		Kube: model.Kube{
+			Auth: model.Auth{
+				CACert: string(ca.Cert),
+				CAKey:  string(ca.Key),
+			},
+			Certificates: profile.Certificates{
+				KubeletSANs: []string{"node-1.corp.example.com"},
+			},
+		},
+	}
+
+	// the rotated certificate isn't there yet
+	config.Runner = &filesRunner{}
+	err = NewReissue(readTpl, writeTpl).Run(context.Background(), &bytes.Buffer{}, config)
+	if err == nil || !strings.Contains(err.Error(), kubeletNodeCert) {
+		t.Fatalf("expected an error of the missing certificate, actual %v", err)
+	}
+
+	r := &filesRunner{
+		files: map[string][]byte{
+			kubeletNodeCert: append(append([]byte{}, kubeletPair.Cert...), kubeletPair.Key...),
+		},
+	}
+	config.Runner = r
+	if err = NewReissue(readTpl, writeTpl).Run(context.Background(), &bytes.Buffer{}, config); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !strings.Contains(r.scripts[0], "sleep") {
+		t.Errorf("the rotated certificate must be waited for %s", r.scripts[0])
+	}
+
+	write := r.scripts[1]
+	m := regexp.MustCompile(`(?s)cat > ` + kubeletNodeCert + ` <<EOF\n(.*?)EOF`).FindStringSubmatch(write)
+	if m == nil {
+		t.Fatalf("kubelet certificate not found in %s", write)
+	}
+	block, rest := pem.Decode([]byte(m[1]))
+	if block == nil {
+		t.Fatalf("decode reissued certificate")
+	}
+	if key, _ := pem.Decode(rest); key == nil || !strings.Contains(key.Type, "PRIVATE KEY") {
+		t.Errorf("key must follow the certificate in %s", m[1])
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("parse reissued certificate: %v", err)
+	}
+	for _, name := range []string{"node-1.corp.example.com", "10.0.0.2"} {
+		if err := cert.VerifyHostname(name); err != nil {
+			t.Errorf("SAN %s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package certmanager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/template"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/dns"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+	tm "github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/upload"
+)
+
+const (
+	StepName = "cert-manager"
+
+	Version = "1.0.4"
+	// Namespace cert-manager is installed to, secrets of cluster issuers
+	// have to be there.
+	Namespace = "cert-manager"
+
+	// Cluster issuers that are created with the addon.
+	IssuerStaging    = "letsencrypt-staging"
+	IssuerProduction = "letsencrypt-prod"
+
+	issuersFile = "/etc/supergiant/cert-manager-issuers.yaml"
+	secretName  = "cert-manager-dns01"
+	secretKey   = "credentials"
+
+	// defaultAWSRegion of route53 clients, zones aren't regional.
+	defaultAWSRegion = "us-east-1"
+)
+
+var servers = map[string]string{
+	IssuerStaging:    "https://acme-staging-v02.api.letsencrypt.org/directory",
+	IssuerProduction: "https://acme-v02.api.letsencrypt.org/directory",
+}
+
+// issuers are uploaded as is
+var issuersTpl = template.Must(template.New("issuers").Parse("{{ . }}"))
+
+type Config struct {
+	Version     string
+	Namespace   string
+	IssuersFile string
+}
+
+// Step installs cert-manager and creates Let's Encrypt cluster issuers,
+// certificates of ingresses are issued by annotating them with
+// cert-manager.io/cluster-issuer.
+type Step struct {
+	script *template.Template
+}
+
+func Init() {
+	tpl, err := tm.GetTemplate(StepName)
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", StepName))
+	}
+
+	steps.RegisterStep(StepName, New(tpl))
+}
+
+func New(script *template.Template) *Step {
+	return &Step{
+		script: script,
+	}
+}
+
+func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	manifest, err := Issuers(config)
+	if err != nil {
+		return errors.Wrapf(err, "%s step", StepName)
+	}
+
+	err = upload.Write(ctx, out, config, upload.File{
+		Path:    issuersFile,
+		Mode:    0600,
+		Content: issuersTpl,
+		Data: func(*steps.Config) interface{} {
+			return string(manifest)
+		},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "%s step", StepName)
+	}
+
+	err = steps.RunTemplate(ctx, s.script, config.Runner, out, Config{
+		Version:     Version,
+		Namespace:   Namespace,
+		IssuersFile: issuersFile,
+	})
+	if err != nil {
+		return errors.Wrap(err, "install cert-manager")
+	}
+	return nil
+}
+
+// Issuers returns a manifest of the staging and production cluster issuers
+// of Let's Encrypt. The dns01 solver comes with a secret that holds
+// credentials of the cloud account.
+func Issuers(config *steps.Config) ([]byte, error) {
+	cm := config.Kube.CertManager
+	if err := cm.Validate(); err != nil {
+		return nil, errors.Wrap(sgerrors.ErrInvalidJson, err.Error())
+	}
+
+	var objects []interface{}
+	var solver map[string]interface{}
+	switch cm.Challenge {
+	case "", profile.ChallengeHTTP01:
+		class := config.Kube.Ingress.Controller
+		if class == "" {
+			return nil, errors.Wrap(sgerrors.ErrInvalidJson, "http01 challenge requires an ingress controller")
+		}
+		solver = map[string]interface{}{
+			"http01": map[string]interface{}{
+				"ingress": map[string]interface{}{"class": class},
+			},
+		}
+	case profile.ChallengeDNS01:
+		dns01, creds, err := dnsSolver(config)
+		if err != nil {
+			return nil, err
+		}
+		solver = map[string]interface{}{"dns01": dns01}
+		objects = append(objects, &corev1.Secret{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Secret",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName,
+				Namespace: Namespace,
+			},
+			StringData: map[string]string{secretKey: creds},
+		})
+	}
+
+	for _, name := range []string{IssuerStaging, IssuerProduction} {
+		acme := map[string]interface{}{
+			"server":              servers[name],
+			"privateKeySecretRef": map[string]interface{}{"name": name},
+			"solvers":             []interface{}{solver},
+		}
+		if cm.Email != "" {
+			acme["email"] = cm.Email
+		}
+		objects = append(objects, map[string]interface{}{
+			"apiVersion": "cert-manager.io/v1",
+			"kind":       "ClusterIssuer",
+			"metadata":   map[string]interface{}{"name": name},
+			"spec":       map[string]interface{}{"acme": acme},
+		})
+	}
+
+	buf := &bytes.Buffer{}
+	for _, obj := range objects {
+		raw, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshal issuers")
+		}
+		buf.WriteString("---\n")
+		buf.Write(raw)
+	}
+	return buf.Bytes(), nil
+}
+
+// dnsSolver returns the dns01 solver of the DNS provider and the credential
+// that the solver reads from the secret.
+func dnsSolver(config *steps.Config) (map[string]interface{}, string, error) {
+	secretRef := map[string]interface{}{"name": secretName, "key": secretKey}
+	creds := config.DNSConfig.Credentials
+
+	switch config.DNSConfig.Provider {
+	case dns.Route53:
+		region := config.AWSConfig.Region
+		if region == "" {
+			region = defaultAWSRegion
+		}
+		return map[string]interface{}{
+			"route53": map[string]interface{}{
+				"region":                   region,
+				"accessKeyID":              creds[clouds.AWSAccessKeyID],
+				"secretAccessKeySecretRef": secretRef,
+			},
+		}, creds[clouds.AWSSecretKey], nil
+	case dns.Google:
+		sa, err := config.DNSConfig.ServiceAccount()
+		if err != nil {
+			return nil, "", errors.Wrap(err, "service account")
+		}
+		raw, err := json.Marshal(sa)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "marshal service account")
+		}
+		return map[string]interface{}{
+			"cloudDNS": map[string]interface{}{
+				"project":                 sa.ProjectID,
+				"serviceAccountSecretRef": secretRef,
+			},
+		}, string(raw), nil
+	case dns.DigitalOcean:
+		return map[string]interface{}{
+			"digitalocean": map[string]interface{}{
+				"tokenSecretRef": secretRef,
+			},
+		}, creds[clouds.DigitalOceanAccessToken], nil
+	case dns.Cloudflare:
+		return map[string]interface{}{
+			"cloudflare": map[string]interface{}{
+				"apiTokenSecretRef": secretRef,
+			},
+		}, creds[dns.CloudflareAPIToken], nil
+	case dns.Azure:
+		if creds[dns.AzureResourceGroup] == "" {
+			return nil, "", errors.Wrapf(sgerrors.ErrInvalidCredentials, "azure dns: %s is missing", dns.AzureResourceGroup)
+		}
+		return map[string]interface{}{
+			"azureDNS": map[string]interface{}{
+				"clientID":              creds[clouds.AzureClientID],
+				"clientSecretSecretRef": secretRef,
+				"subscriptionID":        creds[clouds.AzureSubscriptionID],
+				"tenantID":              creds[clouds.AzureTenantID],
+				"resourceGroupName":     creds[dns.AzureResourceGroup],
+			},
+		}, creds[clouds.AzureClientSecret], nil
+	}
+	return nil, "", errors.Wrapf(sgerrors.ErrUnsupportedProvider, "dns01 challenge on dns of %s", config.Provider)
+}
+
+func (s *Step) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *Step) Name() string {
+	return StepName
+}
+
+func (s *Step) Description() string {
+	return "Install cert-manager and Let's Encrypt cluster issuers"
+}
+
+func (s *Step) Depends() []string {
+	return nil
+}
@@ -0,0 +1,99 @@
+package certmanager
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/dns"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestIssuers(t *testing.T) {
+	config := &steps.Config{
+		Provider: clouds.AWS,
+		DNSConfig: steps.DNSConfig{
+			Provider: dns.Route53,
+			Credentials: map[string]string{
+				clouds.AWSAccessKeyID: "key",
+				clouds.AWSSecretKey:   "secret",
+			},
+		},
+		Kube: model.Kube{
+			Ingress:     profile.Ingress{Controller: profile.IngressNginx},
+			CertManager: profile.CertManager{Email: "ops@example.com"},
+		},
+	}
+
+	manifest, err := Issuers(config)
+	require.NoError(t, err)
+	require.Contains(t, string(manifest), "name: letsencrypt-staging\n")
+	require.Contains(t, string(manifest), "name: letsencrypt-prod\n")
+	require.Contains(t, string(manifest), "class: nginx\n")
+	require.Contains(t, string(manifest), "email: ops@example.com\n")
+	require.NotContains(t, string(manifest), "kind: Secret")
+
+	config.Kube.CertManager.Challenge = profile.ChallengeDNS01
+	manifest, err = Issuers(config)
+	require.NoError(t, err)
+	require.Contains(t, string(manifest), "kind: Secret\n")
+	require.Contains(t, string(manifest), "credentials: secret\n")
+	require.Contains(t, string(manifest), "route53:\n")
+	require.Contains(t, string(manifest), "accessKeyID: key\n")
+	require.Contains(t, string(manifest), "region: us-east-1\n")
+
+	config.DNSConfig = steps.DNSConfig{
+		Provider:    dns.Cloudflare,
+		Credentials: map[string]string{dns.CloudflareAPIToken: "token"},
+	}
+	manifest, err = Issuers(config)
+	require.NoError(t, err)
+	require.Contains(t, string(manifest), "cloudflare:\n")
+	require.Contains(t, string(manifest), "credentials: token\n")
+
+	config.DNSConfig = steps.DNSConfig{Provider: dns.Azure, Credentials: map[string]string{}}
+	_, err = Issuers(config)
+	require.Equal(t, sgerrors.ErrInvalidCredentials, errors.Cause(err))
+
+	config.DNSConfig = steps.DNSConfig{}
+	_, err = Issuers(config)
+	require.True(t, sgerrors.IsUnsupportedProvider(errors.Cause(err)))
+
+	config.Kube.CertManager.Challenge = profile.ChallengeHTTP01
+	config.Kube.Ingress.Controller = ""
+	_, err = Issuers(config)
+	require.Equal(t, sgerrors.ErrInvalidJson, errors.Cause(err))
+}
+
+func TestStep_Run(t *testing.T) {
+	require.NoError(t, templatemanager.Init("../../../../templates"))
+	tpl, err := templatemanager.GetTemplate(StepName)
+	require.NoError(t, err)
+
+	cfg := &steps.Config{
+		Provider: clouds.DigitalOcean,
+		DNSConfig: steps.DNSConfig{
+			Provider:    dns.DigitalOcean,
+			Credentials: map[string]string{clouds.DigitalOceanAccessToken: "token"},
+		},
+		Runner: &testutils.MockRunner{},
+		Kube: model.Kube{
+			CertManager: profile.CertManager{Challenge: profile.ChallengeDNS01},
+		},
+	}
+
+	out := &bytes.Buffer{}
+	require.NoError(t, New(tpl).Run(context.Background(), out, cfg))
+	require.Contains(t, out.String(), "cert-manager/releases/download/v1.0.4/cert-manager.yaml")
+	require.Contains(t, out.String(), "apply -f /etc/supergiant/cert-manager-issuers.yaml")
+	require.NotContains(t, out.String(), "token", "credentials are uploaded to the issuers file")
+}
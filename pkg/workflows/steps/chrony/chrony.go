@@ -0,0 +1,111 @@
+package chrony
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	tm "github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	StepName = "chrony"
+
+	// wait for 5 minutes at most
+	syncAttempts = 30
+	// max allowed offset in seconds
+	maxOffset = "0.1"
+)
+
+var (
+	defaultServers = []string{
+		"0.ubuntu.pool.ntp.org",
+		"1.ubuntu.pool.ntp.org",
+		"2.ubuntu.pool.ntp.org",
+		"3.ubuntu.pool.ntp.org",
+	}
+
+	serverRegexp = regexp.MustCompile(`^[a-zA-Z0-9.:-]+$`)
+)
+
+type Config struct {
+	Servers      []string
+	SyncAttempts int
+	MaxOffset    string
+}
+
+// Step installs chrony and waits until the node clock is synchronized,
+// clock skew breaks certificate validation and etcd.
+type Step struct {
+	script *template.Template
+}
+
+func Init() {
+	tpl, err := tm.GetTemplate(StepName)
+
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", StepName))
+	}
+
+	steps.RegisterStep(StepName, New(tpl))
+}
+
+func New(script *template.Template) *Step {
+	return &Step{
+		script: script,
+	}
+}
+
+func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	cfg, err := toStepCfg(config)
+	if err != nil {
+		return errors.Wrap(err, "build step config")
+	}
+
+	err = steps.RunTemplate(ctx, s.script, config.Runner, out, cfg)
+	if err != nil {
+		return errors.Wrap(err, "configure chrony step")
+	}
+
+	return nil
+}
+
+func (s *Step) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *Step) Name() string {
+	return StepName
+}
+
+func (s *Step) Description() string {
+	return "Configure time synchronization with chrony"
+}
+
+func (s *Step) Depends() []string {
+	return nil
+}
+
+func toStepCfg(c *steps.Config) (Config, error) {
+	servers := c.Kube.NTPServers
+	if len(servers) == 0 {
+		servers = defaultServers
+	}
+
+	for _, server := range servers {
+		if !serverRegexp.MatchString(server) {
+			return Config{}, errors.Errorf("invalid ntp server %s", server)
+		}
+	}
+
+	return Config{
+		Servers:      servers,
+		SyncAttempts: syncAttempts,
+		MaxOffset:    maxOffset,
+	}, nil
+}
@@ -0,0 +1,108 @@
+package chrony
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestChrony(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(StepName)
+
+	if tpl == nil {
+		t.Fatal("template not found")
+	}
+
+	output := &bytes.Buffer{}
+	config := &steps.Config{
+		Kube: model.Kube{
+			NTPServers: []string{"ntp.corp.local", "10.0.0.1"},
+		},
+		Runner: &testutils.MockRunner{},
+	}
+
+	err = New(tpl).Run(context.Background(), output, config)
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, expected := range []string{
+		"server ntp.corp.local iburst",
+		"server 10.0.0.1 iburst",
+		"chronyc waitsync",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("%s not found in output %s", expected, output.String())
+		}
+	}
+
+	if strings.Contains(output.String(), defaultServers[0]) {
+		t.Errorf("default server %s must not be used", defaultServers[0])
+	}
+}
+
+func TestChronyError(t *testing.T) {
+	errMsg := "error has occurred"
+	config := &steps.Config{
+		Runner: &testutils.MockRunner{
+			Err: errors.New(errMsg),
+		},
+	}
+
+	err := New(template.Must(template.New(StepName).Parse("chrony"))).
+		Run(context.Background(), ioutil.Discard, config)
+
+	if err == nil || !strings.Contains(err.Error(), errMsg) {
+		t.Errorf("Error message expected to contain %s actual %v", errMsg, err)
+	}
+}
+
+func TestToStepCfg(t *testing.T) {
+	cfg, err := toStepCfg(&steps.Config{})
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if len(cfg.Servers) != len(defaultServers) {
+		t.Errorf("default servers expected %v actual %v", defaultServers, cfg.Servers)
+	}
+
+	_, err = toStepCfg(&steps.Config{
+		Kube: model.Kube{
+			NTPServers: []string{"pool.ntp.org; reboot"},
+		},
+	})
+
+	if err == nil {
+		t.Errorf("error must not be nil")
+	}
+}
+
+func TestInit(t *testing.T) {
+	templatemanager.SetTemplate(StepName, &template.Template{})
+	Init()
+	templatemanager.DeleteTemplate(StepName)
+
+	s := steps.GetStep(StepName)
+
+	if s == nil {
+		t.Error("Step not found")
+	}
+}
@@ -0,0 +1,106 @@
+package compliance
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/runner"
+	tm "github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	StepName = "compliance"
+
+	DefaultKubeBenchVersion = "0.0.34"
+
+	targetMaster = "master"
+	targetNode   = "node"
+)
+
+type Config struct {
+	Version string
+	Target  string
+}
+
+// Step runs kube-bench on a cluster node and stores the json
+// report in the compliance config.
+type Step struct {
+	script *template.Template
+}
+
+func Init() {
+	tpl, err := tm.GetTemplate(StepName)
+
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", StepName))
+	}
+
+	steps.RegisterStep(StepName, New(tpl))
+}
+
+func New(script *template.Template) *Step {
+	return &Step{
+		script: script,
+	}
+}
+
+func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	script := new(bytes.Buffer)
+	if err := s.script.Execute(script, toStepCfg(config)); err != nil {
+		return errors.Wrap(err, "render kube-bench script")
+	}
+
+	// NOTE: only stdout is a part of the report, docker messages go to the task log
+	report := new(bytes.Buffer)
+	cmd, err := runner.NewCommand(ctx, script.String(), report, out)
+	if err != nil {
+		return errors.Wrap(err, "compliance step")
+	}
+
+	if err := config.Runner.Run(cmd); err != nil {
+		return errors.Wrap(err, "run kube-bench")
+	}
+
+	config.ComplianceConfig.Report = report.String()
+
+	return nil
+}
+
+func (s *Step) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *Step) Name() string {
+	return StepName
+}
+
+func (s *Step) Description() string {
+	return "Run kube-bench CIS compliance check"
+}
+
+func (s *Step) Depends() []string {
+	return nil
+}
+
+func toStepCfg(c *steps.Config) Config {
+	version := c.ComplianceConfig.KubeBenchVersion
+	if version == "" {
+		version = DefaultKubeBenchVersion
+	}
+
+	target := targetNode
+	if c.IsMaster {
+		target = targetMaster
+	}
+
+	return Config{
+		Version: version,
+		Target:  target,
+	}
+}
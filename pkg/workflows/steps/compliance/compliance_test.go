@@ -0,0 +1,126 @@
+package compliance
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type fakeRunner struct {
+	stdout string
+	stderr string
+}
+
+func (f *fakeRunner) Run(command *runner.Command) error {
+	if _, err := io.WriteString(command.Out, f.stdout); err != nil {
+		return err
+	}
+	_, err := io.WriteString(command.Err, f.stderr)
+	return err
+}
+
+func TestCompliance(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(StepName)
+
+	if tpl == nil {
+		t.Fatal("template not found")
+	}
+
+	output := &bytes.Buffer{}
+	config := &steps.Config{
+		IsMaster: true,
+		Runner:   &testutils.MockRunner{},
+	}
+
+	err = New(tpl).Run(context.Background(), output, config)
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// mock runner echoes the script to stdout
+	if !strings.Contains(config.ComplianceConfig.Report, "aquasec/kube-bench:"+DefaultKubeBenchVersion+" master") {
+		t.Errorf("wrong kube-bench command %s", config.ComplianceConfig.Report)
+	}
+}
+
+func TestComplianceReport(t *testing.T) {
+	report := `{"id":"1","text":"Master Node Security Configuration"}`
+	output := &bytes.Buffer{}
+	config := &steps.Config{
+		Runner: &fakeRunner{
+			stdout: report,
+			stderr: "Unable to find image locally",
+		},
+	}
+
+	err := New(template.Must(template.New(StepName).Parse("kube-bench"))).
+		Run(context.Background(), output, config)
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if config.ComplianceConfig.Report != report {
+		t.Errorf("wrong report expected %s actual %s", report, config.ComplianceConfig.Report)
+	}
+
+	if !strings.Contains(output.String(), "Unable to find image") {
+		t.Errorf("stderr must be written to the task log")
+	}
+}
+
+func TestComplianceError(t *testing.T) {
+	errMsg := "error has occurred"
+	config := &steps.Config{
+		Runner: &testutils.MockRunner{
+			Err: errors.New(errMsg),
+		},
+	}
+
+	err := New(template.Must(template.New(StepName).Parse("kube-bench"))).
+		Run(context.Background(), &bytes.Buffer{}, config)
+
+	if err == nil || !strings.Contains(err.Error(), errMsg) {
+		t.Errorf("Error message expected to contain %s actual %v", errMsg, err)
+	}
+}
+
+func TestToStepCfg(t *testing.T) {
+	cfg := toStepCfg(&steps.Config{
+		ComplianceConfig: steps.ComplianceConfig{
+			KubeBenchVersion: "0.1.0",
+		},
+	})
+
+	if cfg.Version != "0.1.0" || cfg.Target != targetNode {
+		t.Errorf("unexpected step config %+v", cfg)
+	}
+}
+
+func TestInit(t *testing.T) {
+	templatemanager.SetTemplate(StepName, &template.Template{})
+	Init()
+	templatemanager.DeleteTemplate(StepName)
+
+	s := steps.GetStep(StepName)
+
+	if s == nil {
+		t.Error("Step not found")
+	}
+}
@@ -108,6 +108,19 @@ type AzureConfig struct {
 
 type PacketConfig struct{}
 
+// FakeConfig is a config of the fake provider, machines come from the node
+// profile and faults of the cloud from the cloud account.
+type FakeConfig struct {
+	Name   string `json:"name"`
+	Region string `json:"region"`
+	Size   string `json:"size"`
+
+	// Latency of every call of the cloud, e.g. 2s
+	Latency string `json:"latency"`
+	// FailOn lists calls of the cloud that fail, e.g. createMachine,deleteCluster
+	FailOn string `json:"failOn"`
+}
+
 type OSConfig struct{}
 
 type AWSConfig struct {
@@ -129,6 +142,8 @@ type AWSConfig struct {
 	EbsOptimized           string `json:"ebsOptimized"`
 	ImageID                string `json:"image"`
 	InstanceType           string `json:"size"`
+	// WindowsImageID overrides the latest Windows Server image for windows nodes
+	WindowsImageID string `json:"windowsImage"`
 
 	ExternalLoadBalancerName string `json:"externalLoadBalancerName"`
 	InternalLoadBalancerName string `json:"internalLoadBalancerName"`
@@ -147,6 +162,131 @@ type ApplyConfig struct {
 	Data string `json:"data"`
 }
 
+type ComplianceConfig struct {
+	KubeBenchVersion string `json:"kubeBenchVersion"`
+	// Report keeps raw kube-bench json output
+	Report string `json:"report"`
+}
+
+type EtcdMaintenanceConfig struct {
+	DefragThreshold int `json:"defragThreshold"`
+	// Status is filled by the etcd maintenance step
+	Status model.EtcdMemberStatus `json:"status"`
+}
+
+// SSHKeyRotationConfig holds a new provisioning key and the one it replaces.
+type SSHKeyRotationConfig struct {
+	PrivateKey   string `json:"privateKey"`
+	PublicKey    string `json:"publicKey"`
+	OldPublicKey string `json:"oldPublicKey"`
+}
+
+// SSHAccessConfig holds an operator key to authorize or revoke on a machine,
+// the audit step fills Keys with keys authorized on the machine.
+type SSHAccessConfig struct {
+	PublicKey string                `json:"publicKey"`
+	Keys      []model.AuthorizedKey `json:"keys"`
+}
+
+// FirewallConfig holds ingress rules to add to and remove from security
+// groups of the cluster.
+type FirewallConfig struct {
+	Authorize []model.FirewallRule `json:"authorize"`
+	Revoke    []model.FirewallRule `json:"revoke"`
+}
+
+// VeleroConfig holds the object storage Velero backs up the cluster to.
+type VeleroConfig struct {
+	model.Backup
+	// AccessKey and SecretKey of S3 compatible storage, e.g. DigitalOcean
+	// Spaces, keys of the cloud account are used when they are empty.
+	AccessKey string `json:"accessKey"`
+	SecretKey string `json:"secretKey"`
+}
+
+// BakeConfig holds the machine image that is baked from the created machine.
+type BakeConfig struct {
+	Name string `json:"name"`
+	// ImageID is set when the image is available.
+	ImageID string `json:"imageId"`
+}
+
+// DNSConfig holds the DNS provider of the cloud account and its credentials,
+// addons create records of the cluster with it.
+type DNSConfig struct {
+	Provider    string            `json:"provider"`
+	Credentials map[string]string `json:"credentials"`
+}
+
+// ServiceAccount returns the google service account of the credentials.
+func (c DNSConfig) ServiceAccount() (ServiceAccount, error) {
+	sa := ServiceAccount{}
+	raw, err := json.Marshal(c.Credentials)
+	if err != nil {
+		return sa, err
+	}
+	return sa, json.Unmarshal(raw, &sa)
+}
+
+const (
+	// DeleteGraceful drains nodes and deletes load balancer services and
+	// volumes through kubernetes before the infrastructure is deleted.
+	DeleteGraceful = "graceful"
+	// DeleteForce deletes the infrastructure right away.
+	DeleteForce = "force"
+)
+
+const (
+	// UpgradeInPlace upgrades masters of the cluster one by one.
+	UpgradeInPlace = "inPlace"
+	// UpgradeBlueGreen adds masters of the next version next to old ones,
+	// old masters are deleted only after new ones are verified.
+	UpgradeBlueGreen = "blueGreen"
+)
+
+// UpgradeConfig selects how the control plane of a cluster is upgraded.
+type UpgradeConfig struct {
+	Strategy string `json:"strategy"`
+	// MasterProfiles of masters that replace old ones in the blue/green
+	// upgrade, one per old master.
+	MasterProfiles []profile.NodeProfile `json:"masterProfiles,omitempty"`
+}
+
+// DeleteConfig selects how a cluster is deleted and collects cloud resources
+// that couldn't be deleted with it, they have to be removed manually.
+type DeleteConfig struct {
+	Mode string `json:"mode"`
+	// StepTimeout limits every graceful step, the deletion is forced when
+	// a step fails or exceeds it.
+	StepTimeout time.Duration `json:"stepTimeout"`
+
+	Leftovers []Leftover `json:"leftovers"`
+}
+
+type Leftover struct {
+	Kind   string `json:"kind"`
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// AddLeftover records the resource unless it's already known.
+func (c *DeleteConfig) AddLeftover(kind, id, reason string) {
+	for _, l := range c.Leftovers {
+		if l.Kind == kind && l.ID == id {
+			return
+		}
+	}
+	c.Leftovers = append(c.Leftovers, Leftover{Kind: kind, ID: id, Reason: reason})
+}
+
+// KubeletConfig holds node specific kubelet settings taken from a node profile.
+type KubeletConfig struct {
+	ExtraArgs map[string]string `json:"extraArgs"`
+	Labels    map[string]string `json:"labels"`
+	// Taints are in the key=value:Effect form
+	Taints []string `json:"taints"`
+}
+
 type InstallAppConfig struct {
 	Name         string `json:"name"`
 	Namespace    string `json:"namespace"`
@@ -155,6 +295,8 @@ type InstallAppConfig struct {
 	RepoName     string `json:"repoName" valid:"required"`
 	ChartRef     string `json:"chartRef"`
 	Values       string `json:"values"`
+	// Upgrade upgrades the release or installs it if it doesn't exist yet.
+	Upgrade bool `json:"upgrade"`
 }
 
 type Map struct {
@@ -189,14 +331,47 @@ type Config struct {
 	AzureConfig        AzureConfig  `json:"azureConfig"`
 	OSConfig           OSConfig     `json:"osConfig"`
 	PacketConfig       PacketConfig `json:"packetConfig"`
+	FakeConfig         FakeConfig   `json:"fakeConfig"`
 
 	DrainConfig DrainConfig `json:"drainConfig"`
 	ConfigMap   ConfigMap   `json:"configMap"`
 	ApplyConfig ApplyConfig `json:"applyConfig"`
 	InstallAppConfig   InstallAppConfig   `json:"installAppConfig"`
+	ComplianceConfig   ComplianceConfig   `json:"complianceConfig"`
+	KubeletConfig      KubeletConfig      `json:"kubeletConfig"`
+	// NodeOS is an operating system of the machine being created, empty for linux
+	NodeOS string `json:"nodeOs"`
+	// NodeImage pins the image of the machine being created, otherwise the
+	// NodeImageFamily is resolved to its latest image in the region. The image
+	// of the cluster is used if both are empty.
+	NodeImage       string `json:"nodeImage"`
+	NodeImageFamily string `json:"nodeImageFamily"`
+	// NodeSSHUser and NodeSSHPort override ssh settings of the kube for the
+	// machine being created.
+	NodeSSHUser string `json:"nodeSshUser"`
+	NodeSSHPort string `json:"nodeSshPort"`
+	// NodeGroup and NodeIndex of the machine being created are variables
+	// of naming templates of the kube.
+	NodeGroup string `json:"nodeGroup"`
+	NodeIndex int    `json:"nodeIndex"`
+
+	EtcdMaintenanceConfig EtcdMaintenanceConfig `json:"etcdMaintenanceConfig"`
+	SSHKeyRotationConfig  SSHKeyRotationConfig  `json:"sshKeyRotationConfig"`
+	SSHAccessConfig       SSHAccessConfig       `json:"sshAccessConfig"`
+	VeleroConfig          VeleroConfig          `json:"veleroConfig"`
+	BakeConfig            BakeConfig            `json:"bakeConfig"`
+	DeleteConfig          DeleteConfig          `json:"deleteConfig"`
+	DNSConfig             DNSConfig             `json:"dnsConfig"`
+	FirewallConfig        FirewallConfig        `json:"firewallConfig"`
+	// UpgradeCanary verifies the cluster after canary nodes are upgraded.
+	UpgradeCanary profile.UpgradeCanary `json:"upgradeCanary"`
+	UpgradeConfig UpgradeConfig         `json:"upgradeConfig"`
 
 	Provider clouds.Name `json:"provider"`
 
+	// NodeToken is a short-lived bootstrap token the machine joins with.
+	NodeToken string `json:"nodeToken"`
+
 	Node             model.Machine `json:"node"`
 	CloudAccountID   string        `json:"cloudAccountId" valid:"required, length(1|32)"`
 	CloudAccountName string        `json:"cloudAccountName" valid:"required, length(1|32)"`
@@ -214,6 +389,9 @@ type Config struct {
 	authorizerMux  sync.RWMutex
 	azureAthorizer autorest.Authorizer
 
+	resultsMux     sync.Mutex
+	commandResults []runner.Result
+
 	nodeChan      chan model.Machine
 	kubeStateChan chan model.KubeState
 	configChan    chan *Config
@@ -228,6 +406,15 @@ func NewConfig(clusterName, cloudAccountName string, profile profile.Profile) (*
 	if err := validateAddons(profile.Addons); err != nil {
 		return nil, err
 	}
+	if err := validateRunnerType(profile.RunnerType, profile.Provider); err != nil {
+		return nil, err
+	}
+	if err := profile.SSH.Validate(); err != nil {
+		return nil, errors.Wrap(err, "validate ssh")
+	}
+	if err := validateNaming(clusterName, profile); err != nil {
+		return nil, errors.Wrap(err, "validate naming")
+	}
 
 	var user = "root"
 
@@ -240,16 +427,19 @@ func NewConfig(clusterName, cloudAccountName string, profile profile.Profile) (*
 		user = clouds.OSUser
 	}
 
+	sshConfig := model.SSHConfig{
+		Port:      "22",
+		User:      user,
+		Timeout:   30,
+		PublicKey: profile.PublicKey,
+	}
+	applySSHSettings(&sshConfig, profile.SSH)
+
 	return &Config{
 		Kube: model.Kube{
 			Name:       clusterName,
 			K8SVersion: profile.K8SVersion,
-			SSHConfig: model.SSHConfig{
-				Port:      "22",
-				User:      user,
-				Timeout:   30,
-				PublicKey: profile.PublicKey,
-			},
+			SSHConfig:  sshConfig,
 			Auth: model.Auth{
 				Username:   profile.User,
 				Password:   profile.Password,
@@ -271,6 +461,23 @@ func NewConfig(clusterName, cloudAccountName string, profile profile.Profile) (*
 			RBACEnabled:      profile.RBACEnabled,
 			ServicesCIDR:     profile.K8SServicesCIDR,
 			Addons:           profile.Addons,
+			AuditLog:         profile.AuditLog,
+			CISHardening:     profile.CISHardening,
+			Admission:        profile.Admission,
+			DNS:              profile.DNS,
+			ExternalDNS:      profile.ExternalDNS,
+			CertManager:      profile.CertManager,
+			Ingress:          profile.Ingress,
+			Sysctl:           profile.Sysctl,
+			KernelModules:    profile.KernelModules,
+			NTPServers:       profile.NTPServers,
+			Certificates:     profile.Certificates,
+			RunnerType:       profile.RunnerType,
+			StaticIPs:        profile.StaticIPs,
+			Naming:           profile.Naming,
+			Region:           profile.Region,
+
+			RegistryCredentials: profile.RegistryCredentials,
 		},
 		Provider: profile.Provider,
 		DigitalOceanConfig: DOConfig{
@@ -393,10 +600,56 @@ func NewConfigFromKube(profile *profile.Profile, k *model.Kube) (*Config, error)
 		Timeout:   10,
 		PublicKey: profile.PublicKey,
 	}
+	applySSHSettings(&cfg.Kube.SSHConfig, profile.SSH)
 
 	return cfg, nil
 }
 
+// validateNaming checks names of resources of the cluster, the cluster id
+// isn't known yet, so an id of the same length is used. Default names are
+// left as they are.
+func validateNaming(clusterName string, p profile.Profile) error {
+	if p.Naming == (profile.Naming{}) {
+		return nil
+	}
+	return p.Naming.ValidateFor(p.Provider, profile.NameVars{
+		ClusterName: clusterName,
+		ClusterID:   "00000000",
+		Region:      p.Region,
+		Index:       len(p.MasterProfiles) + len(p.NodesProfiles),
+	})
+}
+
+// NameVars returns variables of naming templates of the machine being
+// created.
+func (c *Config) NameVars() profile.NameVars {
+	role := model.ToRole(c.IsMaster)
+	group := c.NodeGroup
+	if group == "" {
+		group = string(role)
+	}
+
+	id := c.TaskID
+	if len(id) > 4 {
+		id = id[:4]
+	}
+
+	return profile.NameVars{
+		ClusterName: c.Kube.Name,
+		ClusterID:   c.Kube.ID,
+		Region:      c.Kube.Region,
+		Role:        string(role),
+		NodeGroup:   group,
+		Index:       c.NodeIndex,
+		ID:          id,
+	}
+}
+
+// InstanceName returns the name of the machine being created.
+func (c *Config) InstanceName() (string, error) {
+	return c.Kube.Naming.InstanceName(c.NameVars())
+}
+
 // AddMaster to map of master, map is used because it is reference and can be shared among
 // goroutines that run multiple tasks of cluster deployment
 func (c *Config) AddMaster(n *model.Machine) {
@@ -412,7 +665,22 @@ func (c *Config) AddNode(n *model.Machine) {
 	c.Nodes.internal[n.ID] = n
 }
 
+// Simulated tells whether machines of the config are simulated by the fake
+// provider, scripts aren't run on them and there is no kubernetes API.
+func (c *Config) Simulated() bool {
+	return c.Provider == clouds.Fake
+}
+
 // GetMaster returns first master in master map or nil
+// JoinToken returns the bootstrap token the machine joins the cluster with,
+// masters and machines without a token of their own use the cluster token.
+func (c *Config) JoinToken() string {
+	if c.NodeToken != "" {
+		return c.NodeToken
+	}
+	return c.Kube.BootstrapToken
+}
+
 func (c *Config) GetMaster() *model.Machine {
 	// non-blocking fast path for master nodes
 	if c.IsMaster && c.Node.State == model.MachineStateActive {
@@ -519,6 +787,37 @@ func (c *Config) GetAzureAuthorizer() autorest.Authorizer {
 	return c.azureAthorizer
 }
 
+// AddCommandResult saves result of a command executed by the current step.
+func (c *Config) AddCommandResult(r runner.Result) {
+	c.resultsMux.Lock()
+	defer c.resultsMux.Unlock()
+
+	c.commandResults = append(c.commandResults, r)
+}
+
+// TakeCommandResults returns results saved since the last call.
+func (c *Config) TakeCommandResults() []runner.Result {
+	c.resultsMux.Lock()
+	defer c.resultsMux.Unlock()
+
+	results := c.commandResults
+	c.commandResults = nil
+	return results
+}
+
+// applySSHSettings overrides defaults of the provider with ssh settings of
+// the profile.
+func applySSHSettings(c *model.SSHConfig, settings profile.SSHSettings) {
+	if settings.User != "" {
+		c.User = settings.User
+	}
+	if settings.Port != "" {
+		c.Port = settings.Port
+	}
+	c.Escalation = settings.Escalation
+	c.SudoPassword = settings.SudoPassword
+}
+
 func ensurePort(p int64) int64 {
 	if p == 0 {
 		return DefaultK8SAPIPort
@@ -526,13 +825,33 @@ func ensurePort(p int64) int64 {
 	return p
 }
 
+func validateRunnerType(runnerType string, provider clouds.Name) error {
+	switch runnerType {
+	case "", runner.SSH:
+		return nil
+	case runner.SSM:
+		if provider != clouds.AWS {
+			return fmt.Errorf("validate runner: %s runner requires %s provider", runner.SSM, clouds.AWS)
+		}
+		return nil
+	}
+	return fmt.Errorf("validate runner: unknown: %s", runnerType)
+}
+
+func isRegisteredAddon(addon string) bool {
+	for _, registered := range []string{"dashboard", "external-dns", "cert-manager", "node-problem-detector"} {
+		if addon == registered {
+			return true
+		}
+	}
+	return false
+}
+
 func validateAddons(in []string) error {
 	invalid := make([]string, 0)
 	for _, addon := range in {
-		for _, registered := range []string{"dashboard"} {
-			if addon != registered {
-				invalid = append(invalid, addon)
-			}
+		if !isRegisteredAddon(addon) {
+			invalid = append(invalid, addon)
 		}
 	}
 	if len(invalid) > 0 {
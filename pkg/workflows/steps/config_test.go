@@ -2,11 +2,13 @@ package steps
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
 
 	"github.com/supergiant/control/pkg/clouds"
 	"github.com/supergiant/control/pkg/model"
 	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/runner"
 )
 
 func TestMarshalConfig(t *testing.T) {
@@ -77,6 +79,51 @@ func TestNewConfig(t *testing.T) {
 	}
 }
 
+func TestNewConfigSSH(t *testing.T) {
+	cfg, err := NewConfig("test", "", profile.Profile{Provider: clouds.AWS})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if cfg.Kube.SSHConfig.User != "ubuntu" || cfg.Kube.SSHConfig.Port != "22" {
+		t.Errorf("Wrong default ssh user and port %s:%s", cfg.Kube.SSHConfig.User, cfg.Kube.SSHConfig.Port)
+	}
+
+	p := profile.Profile{
+		Provider: clouds.AWS,
+		SSH: profile.SSHSettings{
+			User:         "admin",
+			Port:         "2222",
+			Escalation:   runner.EscalationSudoPassword,
+			SudoPassword: "secret",
+		},
+	}
+	cfg, err = NewConfig("test", "", p)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	expected := model.SSHConfig{
+		User:         "admin",
+		Port:         "2222",
+		Timeout:      30,
+		Escalation:   runner.EscalationSudoPassword,
+		SudoPassword: "secret",
+	}
+	if !reflect.DeepEqual(cfg.Kube.SSHConfig, expected) {
+		t.Errorf("Wrong ssh config expected %+v actual %+v", expected, cfg.Kube.SSHConfig)
+	}
+
+	// machines of node profiles with their own user keep the port of the kube
+	m := model.Machine{SSHUser: "core"}
+	if c := cfg.Kube.SSHConfig.For(m); c.User != "core" || c.Port != "2222" {
+		t.Errorf("Wrong ssh user and port of the machine %s:%s", c.User, c.Port)
+	}
+
+	p.SSH.Escalation = "doas"
+	if _, err = NewConfig("test", "", p); err == nil {
+		t.Errorf("Error expected for unknown escalation")
+	}
+}
+
 func TestAddMaster(t *testing.T) {
 	n := &model.Machine{
 		Role: model.RoleMaster,
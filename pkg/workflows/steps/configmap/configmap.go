@@ -76,6 +76,10 @@ func Init() {
 }
 
 func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	if config.Simulated() {
+		return nil
+	}
+
 	k8sClient, err := buildKubeClient(config)
 	if err != nil {
 		return errors.Wrap(err, "build kubernetes client")
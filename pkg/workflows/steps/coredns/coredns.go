@@ -0,0 +1,225 @@
+package coredns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/profile"
+	tm "github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/clustercheck"
+	"github.com/supergiant/control/pkg/workflows/util"
+)
+
+const (
+	StepName = "coredns"
+
+	// LocalAddress is the link-local address NodeLocal DNSCache listens on.
+	LocalAddress = "169.254.20.10"
+	// Domain is the cluster domain kubeadm configures.
+	Domain = "cluster.local"
+
+	autoscalerImage = "k8s.gcr.io/cluster-proportional-autoscaler-amd64:1.7.1"
+	nodeCacheImage  = "k8s.gcr.io/k8s-dns-node-cache:1.15.13"
+
+	defaultCoresPerReplica = 256
+	defaultNodesPerReplica = 16
+	defaultMinReplicas     = 2
+)
+
+// readyVersion is the first release that probes the ready plugin of CoreDNS.
+var readyVersion = semver.MustParse("1.16.0")
+
+type Config struct {
+	// Corefile replaces the one of kubeadm when it isn't empty, it's
+	// indented to be a value of the config map.
+	Corefile string
+	Replicas int
+
+	Autoscale       bool
+	AutoscaleParams string
+	AutoscalerImage string
+
+	NodeLocalCache bool
+	NodeCacheImage string
+	LocalAddress   string
+	DNSServer      string
+	Domain         string
+}
+
+type Step struct {
+	script *template.Template
+}
+
+func Init() {
+	tpl, err := tm.GetTemplate(StepName)
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", StepName))
+	}
+
+	steps.RegisterStep(StepName, New(tpl))
+}
+
+func New(script *template.Template) *Step {
+	return &Step{
+		script: script,
+	}
+}
+
+func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	if !config.Kube.DNS.IsSet() {
+		return nil
+	}
+
+	cfg, err := toStepCfg(config)
+	if err != nil {
+		return errors.Wrapf(err, "%s step", StepName)
+	}
+
+	if err = steps.RunTemplate(ctx, s.script, config.Runner, out, cfg); err != nil {
+		return errors.Wrap(err, "configure coredns")
+	}
+	return nil
+}
+
+func (s *Step) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *Step) Name() string {
+	return StepName
+}
+
+func (s *Step) Description() string {
+	return "Configure CoreDNS and NodeLocal DNSCache"
+}
+
+func (s *Step) Depends() []string {
+	return []string{clustercheck.StepName}
+}
+
+func toStepCfg(c *steps.Config) (Config, error) {
+	dns := c.Kube.DNS
+	cfg := Config{
+		Replicas:        dns.Replicas,
+		Autoscale:       dns.Autoscale.Enabled,
+		AutoscalerImage: autoscalerImage,
+		NodeLocalCache:  dns.NodeLocalCache,
+		NodeCacheImage:  nodeCacheImage,
+		LocalAddress:    LocalAddress,
+		Domain:          Domain,
+	}
+
+	if len(dns.StubDomains) > 0 || len(dns.Upstreams) > 0 {
+		cfg.Corefile = indent(Corefile(dns, c.Kube.K8SVersion), "    ")
+	}
+
+	if cfg.Autoscale {
+		// the autoscaler owns the replica count
+		cfg.Replicas = 0
+
+		params, err := json.Marshal(autoscaleParams(dns.Autoscale))
+		if err != nil {
+			return cfg, errors.Wrap(err, "marshal autoscale params")
+		}
+		cfg.AutoscaleParams = string(params)
+	}
+
+	if cfg.NodeLocalCache {
+		ip, err := util.GetDNSIP(c.Kube.ServicesCIDR)
+		if err != nil {
+			return cfg, errors.Wrapf(err, "get cluster dns ip from the %s subnet", c.Kube.ServicesCIDR)
+		}
+		cfg.DNSServer = ip.String()
+	}
+
+	return cfg, nil
+}
+
+// Corefile returns CoreDNS config with the stub domains and upstreams, the
+// rest of it matches the one kubeadm installs.
+func Corefile(dns profile.DNS, k8sVersion string) string {
+	upstreams := "/etc/resolv.conf"
+	if len(dns.Upstreams) > 0 {
+		upstreams = strings.Join(dns.Upstreams, " ")
+	}
+
+	b := &strings.Builder{}
+	b.WriteString(".:53 {\n    errors\n    health\n")
+	if v, err := semver.NewVersion(k8sVersion); err == nil && !v.LessThan(readyVersion) {
+		b.WriteString("    ready\n")
+	}
+	fmt.Fprintf(b, `    kubernetes %s in-addr.arpa ip6.arpa {
+       pods insecure
+       fallthrough in-addr.arpa ip6.arpa
+       ttl 30
+    }
+    prometheus :9153
+    forward . %s
+    cache 30
+    loop
+    reload
+    loadbalance
+}
+`, Domain, upstreams)
+
+	domains := make([]string, 0, len(dns.StubDomains))
+	for domain := range dns.StubDomains {
+		domains = append(domains, domain)
+	}
+	// keep the config stable to not reload CoreDNS needlessly
+	sort.Strings(domains)
+
+	for _, domain := range domains {
+		fmt.Fprintf(b, "%s:53 {\n    errors\n    cache 30\n    forward . %s\n}\n",
+			domain, strings.Join(dns.StubDomains[domain], " "))
+	}
+
+	return b.String()
+}
+
+func indent(s, prefix string) string {
+	lines := strings.SplitAfter(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+type linearParams struct {
+	CoresPerReplica           int  `json:"coresPerReplica"`
+	NodesPerReplica           int  `json:"nodesPerReplica"`
+	Min                       int  `json:"min"`
+	Max                       int  `json:"max,omitempty"`
+	PreventSinglePointFailure bool `json:"preventSinglePointFailure"`
+}
+
+func autoscaleParams(a profile.DNSAutoscale) linearParams {
+	p := linearParams{
+		CoresPerReplica:           a.CoresPerReplica,
+		NodesPerReplica:           a.NodesPerReplica,
+		Min:                       a.Min,
+		Max:                       a.Max,
+		PreventSinglePointFailure: true,
+	}
+	if p.CoresPerReplica == 0 {
+		p.CoresPerReplica = defaultCoresPerReplica
+	}
+	if p.NodesPerReplica == 0 {
+		p.NodesPerReplica = defaultNodesPerReplica
+	}
+	if p.Min == 0 {
+		p.Min = defaultMinReplicas
+	}
+	return p
+}
@@ -0,0 +1,67 @@
+package coredns
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestCorefile(t *testing.T) {
+	dns := profile.DNS{
+		Upstreams: []string{"8.8.8.8", "1.1.1.1"},
+		StubDomains: map[string][]string{
+			"consul.local": {"10.150.0.1"},
+			"acme.corp":    {"10.0.0.2:5353", "10.0.0.3"},
+		},
+	}
+
+	corefile := Corefile(dns, "1.16.2")
+	require.Contains(t, corefile, "    ready\n")
+	require.Contains(t, corefile, "forward . 8.8.8.8 1.1.1.1\n")
+	require.Contains(t, corefile, "acme.corp:53 {\n    errors\n    cache 30\n    forward . 10.0.0.2:5353 10.0.0.3\n}\nconsul.local:53")
+
+	require.NotContains(t, Corefile(profile.DNS{}, "1.15.1"), "ready")
+	require.Contains(t, Corefile(profile.DNS{}, "1.15.1"), "forward . /etc/resolv.conf\n")
+}
+
+func TestStep_Run(t *testing.T) {
+	require.NoError(t, templatemanager.Init("../../../../templates"))
+	tpl, err := templatemanager.GetTemplate(StepName)
+	require.NoError(t, err)
+
+	r := &testutils.MockRunner{}
+	cfg := &steps.Config{
+		Runner: r,
+		Kube: model.Kube{
+			K8SVersion:   "1.16.2",
+			ServicesCIDR: "10.96.0.0/12",
+			DNS: profile.DNS{
+				Replicas:       5,
+				Autoscale:      profile.DNSAutoscale{Enabled: true, Max: 10},
+				StubDomains:    map[string][]string{"consul.local": {"10.150.0.1"}},
+				NodeLocalCache: true,
+			},
+		},
+	}
+
+	out := &bytes.Buffer{}
+	require.NoError(t, New(tpl).Run(context.Background(), out, cfg))
+	require.Contains(t, out.String(), "  Corefile: |\n    .:53 {\n")
+	require.Contains(t, out.String(), "    consul.local:53 {\n")
+	require.NotContains(t, out.String(), "scale deployment coredns", "the autoscaler owns replicas")
+	require.Contains(t, out.String(), `linear: '{"coresPerReplica":256,"nodesPerReplica":16,"min":2,"max":10,"preventSinglePointFailure":true}'`)
+	require.Contains(t, out.String(), `"-localip", "169.254.20.10,10.96.0.10"`)
+
+	out.Reset()
+	cfg.Kube.DNS = profile.DNS{}
+	require.NoError(t, New(tpl).Run(context.Background(), out, cfg))
+	require.Empty(t, out.String(), "kubeadm defaults are kept")
+}
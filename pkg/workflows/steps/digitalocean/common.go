@@ -12,11 +12,13 @@ import (
 const (
 	CreateMachineStepName      = "createMachineDigitalOcean"
 	CreateLoadBalancerStepName = "createLoadBalancerDigitalOcean"
+	AttachStaticIPStepName     = "attachStaticIPDigitalOcean"
 
 	DeleteMachineStepName      = "deleteMachineDigitalOcean"
 	DeleteClusterMachines      = "deleteClusterMachineDigitalOcean"
 	DeleteDeleteKeysStepName   = "deleteKeysDigitalOcean"
 	DeleteLoadBalancerStepName = "deleteLoadBalancerDigitalOcean"
+	ReleaseStaticIPsStepName   = "releaseStaticIPsDigitalOcean"
 
 	StatusActive = "active"
 )
@@ -52,4 +54,7 @@ func Init() {
 
 	steps.RegisterStep(CreateLoadBalancerStepName, NewCreateLoadBalancerStep())
 	steps.RegisterStep(DeleteLoadBalancerStepName, NewDeleteLoadBalancerStep())
+
+	steps.RegisterStep(AttachStaticIPStepName, NewAttachStaticIPStep(time.Minute*1, time.Second*5))
+	steps.RegisterStep(ReleaseStaticIPsStepName, NewReleaseStaticIPsStep())
 }
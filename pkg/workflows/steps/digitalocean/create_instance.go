@@ -9,12 +9,12 @@ import (
 
 	"github.com/digitalocean/godo"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
 	"github.com/supergiant/control/pkg/clouds"
 	"github.com/supergiant/control/pkg/clouds/digitaloceansdk"
 	"github.com/supergiant/control/pkg/model"
 	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/util"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
@@ -41,8 +41,12 @@ func NewCreateInstanceStep(dropletTimeout, checkPeriod time.Duration) *CreateIns
 func (s *CreateInstanceStep) Run(ctx context.Context, output io.Writer, config *steps.Config) error {
 	dropletSvc, keySvc := s.getServices(config.DigitalOceanConfig.AccessToken)
 	// Node name is created from cluster name plus part of task id plus role
-	config.DigitalOceanConfig.Name = util.MakeNodeName(config.Kube.Name,
-		config.TaskID, config.IsMaster)
+	// unless the kube has a naming template
+	name, err := config.InstanceName()
+	if err != nil {
+		return errors.Wrap(err, "name droplet")
+	}
+	config.DigitalOceanConfig.Name = name
 
 	// TODO(stgleb): Move keys creation for provisioning to provisioner to be able to get
 	// this key on cluster check phase.
@@ -62,6 +66,11 @@ func (s *CreateInstanceStep) Run(ctx context.Context, output io.Writer, config *
 		tags = append(tags, fmt.Sprintf("master-%s", config.Kube.ID))
 	}
 
+	image, err := nodeImage(config)
+	if err != nil {
+		return err
+	}
+
 	dropletRequest := &godo.DropletCreateRequest{
 		Name:              config.DigitalOceanConfig.Name,
 		Region:            config.DigitalOceanConfig.Region,
@@ -69,7 +78,7 @@ func (s *CreateInstanceStep) Run(ctx context.Context, output io.Writer, config *
 		PrivateNetworking: true,
 		SSHKeys:           fingers,
 		Image: godo.DropletCreateImage{
-			Slug: config.DigitalOceanConfig.Image,
+			Slug: image,
 		},
 		Tags: tags,
 	}
@@ -122,6 +131,10 @@ func (s *CreateInstanceStep) Run(ctx context.Context, output io.Writer, config *
 				config.Node.PrivateIp = getPrivateIpPort(droplet.Networks.V4)
 				config.Node.State = model.MachineStateProvisioning
 				config.Node.Name = config.DigitalOceanConfig.Name
+				// slugs point to the latest image, the ID doesn't change
+				if droplet.Image != nil {
+					config.Node.Image = strconv.Itoa(droplet.Image.ID)
+				}
 
 				// Update node state in cluster
 				config.NodeChan() <- config.Node
@@ -132,7 +145,7 @@ func (s *CreateInstanceStep) Run(ctx context.Context, output io.Writer, config *
 					config.AddNode(&config.Node)
 				}
 
-				logrus.Infof("Node has been created %v", config.Node)
+				sglog.FromContext(ctx).Infof("Node has been created %v", config.Node)
 
 				return nil
 			}
@@ -163,7 +176,7 @@ func (s *CreateInstanceStep) Description() string {
 func (s *CreateInstanceStep) createKeys(ctx context.Context, keyService KeyService, config *steps.Config) ([]godo.DropletCreateSSHKey, error) {
 	var fingers []godo.DropletCreateSSHKey
 
-	logrus.Debugf("Step %s", CreateMachineStepName)
+	sglog.FromContext(ctx).Debugf("Step %s", CreateMachineStepName)
 
 	// Create key for provisioning
 	key, err := createKey(ctx, keyService,
@@ -198,3 +211,20 @@ func (s *CreateInstanceStep) createKeys(ctx context.Context, keyService KeyServi
 
 	return fingers, nil
 }
+
+// nodeImage returns the slug of the image of the node, the image of the
+// cluster is used unless the node pins one or sets a family.
+func nodeImage(config *steps.Config) (string, error) {
+	if config.NodeImage != "" {
+		return config.NodeImage, nil
+	}
+	if config.NodeImageFamily == "" {
+		return config.DigitalOceanConfig.Image, nil
+	}
+
+	family, ok := clouds.FindImageFamily(config.NodeImageFamily)
+	if !ok || !family.Supports(clouds.DigitalOcean) {
+		return "", errors.Wrapf(sgerrors.ErrNotFound, "image family %s", config.NodeImageFamily)
+	}
+	return family.DigitalOceanSlug, nil
+}
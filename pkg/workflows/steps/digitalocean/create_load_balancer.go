@@ -8,9 +8,9 @@ import (
 
 	"github.com/digitalocean/godo"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
 	"github.com/supergiant/control/pkg/clouds/digitaloceansdk"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/util"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
@@ -73,19 +73,19 @@ func (s *CreateLoadBalancerStep) Run(ctx context.Context, output io.Writer, conf
 	externalLoadBalancer, _, err := lbSvc.Create(ctx, req)
 
 	if err != nil {
-		logrus.Errorf("Error while creating external load balancer %v", err)
+		sglog.FromContext(ctx).Errorf("Error while creating external load balancer %v", err)
 		return errors.Wrapf(err, "Error while creating external load balancer")
 	}
 
 	config.DigitalOceanConfig.ExternalLoadBalancerID = externalLoadBalancer.ID
 
 	timeout := s.Timeout
-	logrus.Infof("Wait until External load balancer %s become active", externalLoadBalancer.ID)
+	sglog.FromContext(ctx).Infof("Wait until External load balancer %s become active", externalLoadBalancer.ID)
 	for i := 0; i < s.Attempts; i++ {
 		externalLoadBalancer, _, err = lbSvc.Get(ctx, config.DigitalOceanConfig.ExternalLoadBalancerID)
 
 		if err == nil {
-			logrus.Debugf("External Load balancer %s status %s",
+			sglog.FromContext(ctx).Debugf("External Load balancer %s status %s",
 				config.DigitalOceanConfig.ExternalLoadBalancerID, externalLoadBalancer.Status)
 		}
 
@@ -98,12 +98,12 @@ func (s *CreateLoadBalancerStep) Run(ctx context.Context, output io.Writer, conf
 	}
 
 	if err != nil {
-		logrus.Errorf("Error while getting external load balancer %v", err)
+		sglog.FromContext(ctx).Errorf("Error while getting external load balancer %v", err)
 		return errors.Wrapf(err, "Error while getting external load balancer")
 	}
 
 	if externalLoadBalancer.IP == "" {
-		logrus.Errorf("External Load balancer IP must not be empty")
+		sglog.FromContext(ctx).Errorf("External Load balancer IP must not be empty")
 		return errors.New("External Load balancer IP must not be empty")
 	}
 
@@ -146,19 +146,19 @@ func (s *CreateLoadBalancerStep) Run(ctx context.Context, output io.Writer, conf
 	internalLoadBalancer, _, err := lbSvc.Create(ctx, req)
 
 	if err != nil {
-		logrus.Errorf("Error while creating internal load balancer %v", err)
+		sglog.FromContext(ctx).Errorf("Error while creating internal load balancer %v", err)
 		return errors.Wrapf(err, "Error while creating internal load balancer")
 	}
 
 	config.DigitalOceanConfig.InternalLoadBalancerID = internalLoadBalancer.ID
-	logrus.Infof("Wait until Internal load balancer %s become active", internalLoadBalancer.ID)
+	sglog.FromContext(ctx).Infof("Wait until Internal load balancer %s become active", internalLoadBalancer.ID)
 
 	timeout = s.Timeout
 	for i := 0; i < s.Attempts; i++ {
 		internalLoadBalancer, _, err = lbSvc.Get(ctx, config.DigitalOceanConfig.InternalLoadBalancerID)
 
 		if err == nil {
-			logrus.Debugf("Internal Load balancer %s status %s",
+			sglog.FromContext(ctx).Debugf("Internal Load balancer %s status %s",
 				config.DigitalOceanConfig.InternalLoadBalancerID, internalLoadBalancer.Status)
 		}
 
@@ -171,12 +171,12 @@ func (s *CreateLoadBalancerStep) Run(ctx context.Context, output io.Writer, conf
 	}
 
 	if err != nil {
-		logrus.Errorf("Error while getting internal load balancer %v", err)
+		sglog.FromContext(ctx).Errorf("Error while getting internal load balancer %v", err)
 		return errors.Wrapf(err, "Error while getting internal load balancer")
 	}
 
 	if internalLoadBalancer.IP == "" {
-		logrus.Errorf("Internal Load balancer IP must not be empty")
+		sglog.FromContext(ctx).Errorf("Internal Load balancer IP must not be empty")
 		return errors.New("Internal Load balancer IP must not be empty")
 	}
 
@@ -6,9 +6,9 @@ import (
 	"time"
 
 	"github.com/digitalocean/godo"
-	"github.com/sirupsen/logrus"
 
 	"github.com/supergiant/control/pkg/clouds/digitaloceansdk"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -39,26 +39,26 @@ func (s *DeleteKeysStep) Run(ctx context.Context, output io.Writer, config *step
 	bootstrapFg, err := fingerprint(config.Kube.SSHConfig.BootstrapPublicKey)
 
 	if err != nil {
-		logrus.Debugf("error computing fingerprint of bootstrap key")
+		sglog.FromContext(ctx).Debugf("error computing fingerprint of bootstrap key")
 	}
 
 	resp, err := keyService.DeleteByFingerprint(ctx, bootstrapFg)
 
 	if err != nil {
-		logrus.Debugf("Delete bootstrap key status %s error %s",
+		sglog.FromContext(ctx).Debugf("Delete bootstrap key status %s error %s",
 			resp.Status, err)
 	}
 
 	publicFg, err := fingerprint(config.Kube.SSHConfig.PublicKey)
 
 	if err != nil {
-		logrus.Debugf("error computing fingerprint of public key")
+		sglog.FromContext(ctx).Debugf("error computing fingerprint of public key")
 	}
 
 	resp, err = keyService.DeleteByFingerprint(ctx, publicFg)
 
 	if err != nil {
-		logrus.Debugf("Delete bootstrap key status %s error %s",
+		sglog.FromContext(ctx).Debugf("Delete bootstrap key status %s error %s",
 			resp.Status, err)
 	}
 
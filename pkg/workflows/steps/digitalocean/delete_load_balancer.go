@@ -5,9 +5,8 @@ import (
 	"io"
 	"time"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/supergiant/control/pkg/clouds/digitaloceansdk"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -36,13 +35,13 @@ func (s *DeleteLoadBalancerStep) Run(ctx context.Context, output io.Writer, conf
 	_, err := lbSvc.Delete(ctx, config.DigitalOceanConfig.ExternalLoadBalancerID)
 
 	if err != nil {
-		logrus.Errorf("Error deleting external load balancer %s %v", config.DigitalOceanConfig.ExternalLoadBalancerID, err)
+		sglog.FromContext(ctx).Errorf("Error deleting external load balancer %s %v", config.DigitalOceanConfig.ExternalLoadBalancerID, err)
 	}
 
 	_, err = lbSvc.Delete(ctx, config.DigitalOceanConfig.InternalLoadBalancerID)
 
 	if err != nil {
-		logrus.Errorf("Error deleting internal load balancer %s %v", config.DigitalOceanConfig.InternalLoadBalancerID, err)
+		sglog.FromContext(ctx).Errorf("Error deleting internal load balancer %s %v", config.DigitalOceanConfig.InternalLoadBalancerID, err)
 	}
 
 	return nil
@@ -0,0 +1,180 @@
+package digitalocean
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds/digitaloceansdk"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sglog"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type FloatingIPService interface {
+	Create(context.Context, *godo.FloatingIPCreateRequest) (*godo.FloatingIP, *godo.Response, error)
+	Delete(context.Context, string) (*godo.Response, error)
+}
+
+type FloatingIPActionService interface {
+	Assign(context.Context, string, int) (*godo.Action, *godo.Response, error)
+	Get(context.Context, string, int) (*godo.Action, *godo.Response, error)
+}
+
+// AttachStaticIPStep assigns a floating ip to the created master, a free
+// floating ip of the cluster is taken before a new one is created.
+type AttachStaticIPStep struct {
+	timeout time.Duration
+	period  time.Duration
+
+	getServices func(string) (FloatingIPService, FloatingIPActionService)
+}
+
+func NewAttachStaticIPStep(timeout, period time.Duration) *AttachStaticIPStep {
+	return &AttachStaticIPStep{
+		timeout: timeout,
+		period:  period,
+		getServices: func(accessToken string) (FloatingIPService, FloatingIPActionService) {
+			client := digitaloceansdk.New(accessToken).GetClient()
+
+			return client.FloatingIPs, client.FloatingIPActions
+		},
+	}
+}
+
+func (s *AttachStaticIPStep) Run(ctx context.Context, output io.Writer, config *steps.Config) error {
+	log := util.GetLogger(output)
+	ipSvc, actionSvc := s.getServices(config.DigitalOceanConfig.AccessToken)
+
+	dropletID, err := strconv.Atoi(config.Node.ID)
+	if err != nil {
+		return errors.Wrapf(err, "%s droplet id %s", AttachStaticIPStepName, config.Node.ID)
+	}
+
+	ip := config.Kube.FreeStaticIP()
+	if ip != nil {
+		if err = s.assign(ctx, actionSvc, ip.Address, dropletID); err != nil {
+			// another master may have taken it
+			log.Infof("[%s] - assign floating ip %s caused %v", s.Name(), ip.Address, err)
+			ip = nil
+		}
+	}
+
+	if ip == nil {
+		// the floating ip is assigned to the droplet when it's created
+		floatingIP, _, err := ipSvc.Create(ctx, &godo.FloatingIPCreateRequest{
+			Region:    config.DigitalOceanConfig.Region,
+			DropletID: dropletID,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "%s create floating ip", AttachStaticIPStepName)
+		}
+		ip = &model.StaticIP{
+			ID:      floatingIP.IP,
+			Address: floatingIP.IP,
+		}
+	}
+
+	log.Infof("[%s] - floating ip %s is assigned to %s", s.Name(), ip.Address, config.Node.Name)
+	config.Node.StaticIP = ip
+	config.Node.PublicIp = ip.Address
+	config.NodeChan() <- config.Node
+	config.AddMaster(&config.Node)
+
+	return nil
+}
+
+func (s *AttachStaticIPStep) assign(ctx context.Context, svc FloatingIPActionService, ip string, dropletID int) error {
+	action, _, err := svc.Assign(ctx, ip, dropletID)
+	if err != nil {
+		return err
+	}
+
+	timeout := time.After(s.timeout)
+	ticker := time.NewTicker(s.period)
+	defer ticker.Stop()
+
+	for action.Status != godo.ActionCompleted {
+		if action.Status == "errored" {
+			return errors.Errorf("assign action %d errored", action.ID)
+		}
+
+		select {
+		case <-ticker.C:
+			if action, _, err = svc.Get(ctx, ip, action.ID); err != nil {
+				return err
+			}
+		case <-timeout:
+			return errors.Errorf("assign action %d timed out", action.ID)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+func (s *AttachStaticIPStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *AttachStaticIPStep) Name() string {
+	return AttachStaticIPStepName
+}
+
+func (s *AttachStaticIPStep) Depends() []string {
+	return nil
+}
+
+func (s *AttachStaticIPStep) Description() string {
+	return "Assign a floating ip to the master in Digital Ocean"
+}
+
+// ReleaseStaticIPsStep deletes floating ips of the cluster, they aren't
+// tagged in Digital Ocean, so the ids are taken from the kube.
+type ReleaseStaticIPsStep struct {
+	getServices func(string) FloatingIPService
+}
+
+func NewReleaseStaticIPsStep() *ReleaseStaticIPsStep {
+	return &ReleaseStaticIPsStep{
+		getServices: func(accessToken string) FloatingIPService {
+			client := digitaloceansdk.New(accessToken).GetClient()
+
+			return client.FloatingIPs
+		},
+	}
+}
+
+func (s *ReleaseStaticIPsStep) Run(ctx context.Context, output io.Writer, config *steps.Config) error {
+	ipSvc := s.getServices(config.DigitalOceanConfig.AccessToken)
+
+	for _, ip := range config.Kube.AllStaticIPs() {
+		if _, err := ipSvc.Delete(ctx, ip.ID); err != nil {
+			sglog.FromContext(ctx).Errorf("Error deleting floating ip %s %v", ip.Address, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *ReleaseStaticIPsStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *ReleaseStaticIPsStep) Name() string {
+	return ReleaseStaticIPsStepName
+}
+
+func (s *ReleaseStaticIPsStep) Depends() []string {
+	return nil
+}
+
+func (s *ReleaseStaticIPsStep) Description() string {
+	return "Delete floating ips of the cluster in Digital Ocean"
+}
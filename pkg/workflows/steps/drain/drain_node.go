@@ -9,18 +9,19 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
 	"github.com/supergiant/control/pkg/runner"
-	"github.com/supergiant/control/pkg/runner/ssh"
 	"github.com/supergiant/control/pkg/sgerrors"
 	tm "github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/ssh"
 )
 
 const StepName = "drain"
 
 type Step struct {
 	script    *template.Template
-	getRunner func(string, *steps.Config) (runner.Runner, error)
+	getRunner func(model.Machine, *steps.Config) (runner.Runner, error)
 }
 
 func (s *Step) Rollback(context.Context, io.Writer, *steps.Config) error {
@@ -40,28 +41,14 @@ func Init() {
 func New(script *template.Template) *Step {
 	t := &Step{
 		script: script,
-		getRunner: func(masterIp string, config *steps.Config) (runner.Runner, error) {
-			if config.Provider == clouds.AWS {
+		getRunner: func(master model.Machine, config *steps.Config) (runner.Runner, error) {
+			if config.Provider == clouds.AWS && (config.Kube.SSHConfig.User == "" || config.Kube.SSHConfig.User == "root") {
 				//on aws default user name on ubuntu images are not root but ubuntu
 				//https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/AccessingInstancesLinux.html
 				config.Kube.SSHConfig.User = "ubuntu"
 			}
 
-			cfg := ssh.Config{
-				Host:    masterIp,
-				Port:    config.Kube.SSHConfig.Port,
-				User:    config.Kube.SSHConfig.User,
-				Timeout: 10,
-				Key:     []byte(config.Kube.SSHConfig.BootstrapPrivateKey),
-			}
-
-			sshRunner, err := ssh.NewRunner(cfg)
-
-			if err != nil {
-				return nil, errors.Wrapf(err, "create ssh runner")
-			}
-
-			return sshRunner, err
+			return ssh.NewRunner(master, config)
 		},
 	}
 
@@ -75,7 +62,7 @@ func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) err
 		return errors.Wrapf(sgerrors.ErrNotFound, "master node not found")
 	}
 
-	r, err := s.getRunner(masterNode.PublicIp, config)
+	r, err := s.getRunner(*masterNode, config)
 
 	if err != nil {
 		return errors.Wrapf(err, "get runner")
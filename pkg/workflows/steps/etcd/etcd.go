@@ -0,0 +1,95 @@
+package etcd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/runner"
+	tm "github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/ssh"
+)
+
+const (
+	SyncStepName        = "etcd_member_sync"
+	RemoveStepName      = "etcd_member_remove"
+	ReplaceStepName     = "etcd_member_replace"
+	MaintenanceStepName = "etcd_maintenance"
+
+	// syncAttempts is a number of health checks made 10 seconds apart
+	// before a member is considered broken.
+	syncAttempts = 30
+)
+
+type Config struct {
+	PeerIP       string
+	Endpoints    string
+	PeerCert     string
+	PeerKey      string
+	SyncAttempts int
+
+	Marker          string
+	DefragThreshold int
+}
+
+func Init() {
+	steps.RegisterStep(SyncStepName, NewSync(getTemplate(SyncStepName)))
+	steps.RegisterStep(RemoveStepName, NewRemove(getTemplate(RemoveStepName)))
+	steps.RegisterStep(ReplaceStepName, NewReplace(getTemplate(ReplaceStepName)))
+	steps.RegisterStep(MaintenanceStepName, NewMaintenance(getTemplate(MaintenanceStepName)))
+}
+
+func getTemplate(name string) *template.Template {
+	tpl, err := tm.GetTemplate(name)
+
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", name))
+	}
+
+	return tpl
+}
+
+func peerRunner(peer model.Machine, config *steps.Config) (runner.Runner, error) {
+	if config.Provider == clouds.AWS && (config.Kube.SSHConfig.User == "" || config.Kube.SSHConfig.User == "root") {
+		//on aws default user name on ubuntu images are not root but ubuntu
+		//https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/AccessingInstancesLinux.html
+		config.Kube.SSHConfig.User = "ubuntu"
+	}
+
+	return ssh.NewRunner(peer, config)
+}
+
+// peers returns active masters except the machine the step runs for
+// sorted by name.
+func peers(config *steps.Config) []*model.Machine {
+	var result []*model.Machine
+
+	for _, m := range config.GetMasters() {
+		if m == nil || m.State != model.MachineStateActive {
+			continue
+		}
+		if m.ID == config.Node.ID || m.PrivateIp == config.Node.PrivateIp {
+			continue
+		}
+		result = append(result, m)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+func clientEndpoints(machines []*model.Machine) string {
+	endpoints := make([]string, 0, len(machines))
+	for _, m := range machines {
+		endpoints = append(endpoints, fmt.Sprintf("https://%s:2379", m.PrivateIp))
+	}
+
+	return strings.Join(endpoints, ",")
+}
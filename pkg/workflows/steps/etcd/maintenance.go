@@ -0,0 +1,75 @@
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"regexp"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const statusMarker = "etcd member status"
+
+var statusRegexp = regexp.MustCompile(statusMarker +
+	` dbSize=([0-9]+) dbSizeInUse=([0-9]+) defragmented=(true|false)`)
+
+// MaintenanceStep checks health of the etcd member of a master machine and
+// defragments its db when the unused space exceeds a threshold.
+type MaintenanceStep struct {
+	script *template.Template
+}
+
+func NewMaintenance(script *template.Template) *MaintenanceStep {
+	return &MaintenanceStep{
+		script: script,
+	}
+}
+
+func (s *MaintenanceStep) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	cfg := Config{
+		Marker:          statusMarker,
+		DefragThreshold: config.EtcdMaintenanceConfig.DefragThreshold,
+	}
+
+	buf := &bytes.Buffer{}
+	err := steps.RunTemplate(ctx, s.script, config.Runner, io.MultiWriter(out, buf), cfg)
+	if err != nil {
+		return errors.Wrap(err, "etcd maintenance step")
+	}
+
+	m := statusRegexp.FindStringSubmatch(buf.String())
+	if m == nil {
+		return errors.Errorf("etcd member status of %s not found", config.Node.Name)
+	}
+
+	status := &config.EtcdMaintenanceConfig.Status
+	// the regexp guarantees the values are numbers
+	status.DBSize, _ = strconv.ParseInt(m[1], 10, 64)
+	status.DBSizeInUse, _ = strconv.ParseInt(m[2], 10, 64)
+	status.Defragmented = m[3] == "true"
+	status.CheckedAt = time.Now().Unix()
+
+	return nil
+}
+
+func (s *MaintenanceStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *MaintenanceStep) Name() string {
+	return MaintenanceStepName
+}
+
+func (s *MaintenanceStep) Description() string {
+	return "Check etcd member health and defragment its db"
+}
+
+func (s *MaintenanceStep) Depends() []string {
+	return nil
+}
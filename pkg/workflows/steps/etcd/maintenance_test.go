@@ -0,0 +1,101 @@
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type outputRunner struct {
+	output string
+}
+
+func (r *outputRunner) Run(cmd *runner.Command) error {
+	_, err := io.Copy(cmd.Out, strings.NewReader(cmd.Script+r.output))
+	return err
+}
+
+func TestMaintenance(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(MaintenanceStepName)
+
+	if tpl == nil {
+		t.Fatal("template not found")
+	}
+
+	output := &bytes.Buffer{}
+	config := &steps.Config{
+		EtcdMaintenanceConfig: steps.EtcdMaintenanceConfig{
+			DefragThreshold: 30,
+		},
+		Runner: &outputRunner{
+			output: "etcd member status dbSize=4096 dbSizeInUse=1024 defragmented=true\n",
+		},
+	}
+
+	err = NewMaintenance(tpl).Run(context.Background(), output, config)
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if !strings.Contains(output.String(), "DB_SIZE * 30") {
+		t.Errorf("defrag threshold not found in output %s", output.String())
+	}
+
+	status := config.EtcdMaintenanceConfig.Status
+	if status.DBSize != 4096 || status.DBSizeInUse != 1024 || !status.Defragmented {
+		t.Errorf("wrong status %+v", status)
+	}
+
+	if status.CheckedAt == 0 {
+		t.Errorf("check time must be set")
+	}
+}
+
+func TestMaintenanceNoStatus(t *testing.T) {
+	tpl, _ := templatemanager.GetTemplate(MaintenanceStepName)
+	config := &steps.Config{
+		Runner: &testutils.MockRunner{},
+	}
+
+	err := NewMaintenance(tpl).Run(context.Background(), ioutil.Discard, config)
+
+	if err == nil {
+		t.Errorf("error must not be nil")
+	}
+}
+
+func TestMaintenanceError(t *testing.T) {
+	errMsg := "error has occurred"
+	tpl, _ := templatemanager.GetTemplate(MaintenanceStepName)
+	config := &steps.Config{
+		Runner: &testutils.MockRunner{
+			Err: errors.New(errMsg),
+		},
+	}
+
+	err := NewMaintenance(tpl).Run(context.Background(), ioutil.Discard, config)
+
+	if err == nil {
+		t.Fatal("error must not be nil")
+	}
+
+	if !strings.Contains(err.Error(), errMsg) {
+		t.Errorf("error message expected to contain %s actual %s", errMsg, err.Error())
+	}
+}
@@ -0,0 +1,67 @@
+package etcd
+
+import (
+	"context"
+	"io"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// RemoveStep removes the etcd member of a machine from the cluster. It runs
+// on another master, so the machine being removed may be unreachable.
+type RemoveStep struct {
+	script    *template.Template
+	getRunner func(model.Machine, *steps.Config) (runner.Runner, error)
+}
+
+func NewRemove(script *template.Template) *RemoveStep {
+	return &RemoveStep{
+		script:    script,
+		getRunner: peerRunner,
+	}
+}
+
+func (s *RemoveStep) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	masters := peers(config)
+	if len(masters) == 0 {
+		return errors.Wrapf(sgerrors.ErrNotFound, "healthy master node not found")
+	}
+
+	r, err := s.getRunner(*masters[0], config)
+	if err != nil {
+		return errors.Wrapf(err, "get runner")
+	}
+
+	cfg := Config{
+		PeerIP: config.Node.PrivateIp,
+	}
+
+	err = steps.RunTemplate(ctx, s.script, r, out, cfg)
+	if err != nil {
+		return errors.Wrap(err, "etcd member remove step")
+	}
+
+	return nil
+}
+
+func (s *RemoveStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *RemoveStep) Name() string {
+	return RemoveStepName
+}
+
+func (s *RemoveStep) Description() string {
+	return "Remove etcd member from the cluster"
+}
+
+func (s *RemoveStep) Depends() []string {
+	return nil
+}
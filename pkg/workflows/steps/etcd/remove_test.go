@@ -0,0 +1,144 @@
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func testMasters() steps.Map {
+	return steps.NewMap(map[string]*model.Machine{
+		"master-1": {
+			ID:        "1",
+			Name:      "master-1",
+			PrivateIp: "10.0.0.1",
+			PublicIp:  "1.1.1.1",
+			State:     model.MachineStateActive,
+		},
+		"master-2": {
+			ID:        "2",
+			Name:      "master-2",
+			PrivateIp: "10.0.0.2",
+			PublicIp:  "2.2.2.2",
+			State:     model.MachineStateActive,
+		},
+		"master-3": {
+			ID:        "3",
+			Name:      "master-3",
+			PrivateIp: "10.0.0.3",
+			PublicIp:  "3.3.3.3",
+			State:     model.MachineStateError,
+		},
+	})
+}
+
+func TestRemove(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(RemoveStepName)
+
+	if tpl == nil {
+		t.Fatal("template not found")
+	}
+
+	var host string
+	output := &bytes.Buffer{}
+	config := &steps.Config{
+		Node: model.Machine{
+			ID:        "1",
+			PrivateIp: "10.0.0.1",
+		},
+		Masters: testMasters(),
+	}
+
+	step := NewRemove(tpl)
+	step.getRunner = func(peer model.Machine, _ *steps.Config) (runner.Runner, error) {
+		host = peer.PublicIp
+		return &testutils.MockRunner{}, nil
+	}
+
+	err = step.Run(context.Background(), output, config)
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if host != "2.2.2.2" {
+		t.Errorf("step must run on a healthy peer, actual %s", host)
+	}
+
+	for _, expected := range []string{
+		"https://10.0.0.1:2380",
+		"grep -v \"10.0.0.1:2379\"",
+		"member remove ${MEMBER_ID}",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("%s not found in output %s", expected, output.String())
+		}
+	}
+}
+
+func TestRemoveNoPeers(t *testing.T) {
+	tpl, _ := templatemanager.GetTemplate(RemoveStepName)
+	config := &steps.Config{
+		Node: model.Machine{
+			ID:        "2",
+			PrivateIp: "10.0.0.2",
+		},
+		Masters: steps.NewMap(map[string]*model.Machine{
+			"master-2": {
+				ID:        "2",
+				Name:      "master-2",
+				PrivateIp: "10.0.0.2",
+				State:     model.MachineStateActive,
+			},
+		}),
+	}
+
+	err := NewRemove(tpl).Run(context.Background(), ioutil.Discard, config)
+
+	if err == nil {
+		t.Errorf("error must not be nil")
+	}
+}
+
+func TestRemoveError(t *testing.T) {
+	errMsg := "error has occurred"
+	tpl, _ := templatemanager.GetTemplate(RemoveStepName)
+	config := &steps.Config{
+		Node: model.Machine{
+			ID: "1",
+		},
+		Masters: testMasters(),
+	}
+
+	step := NewRemove(tpl)
+	step.getRunner = func(model.Machine, *steps.Config) (runner.Runner, error) {
+		return &testutils.MockRunner{
+			Err: errors.New(errMsg),
+		}, nil
+	}
+
+	err := step.Run(context.Background(), ioutil.Discard, config)
+
+	if err == nil {
+		t.Fatal("error must not be nil")
+	}
+
+	if !strings.Contains(err.Error(), errMsg) {
+		t.Errorf("error message expected to contain %s actual %s", errMsg, err.Error())
+	}
+}
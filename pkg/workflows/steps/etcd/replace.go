@@ -0,0 +1,77 @@
+package etcd
+
+import (
+	"context"
+	"io"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/pki"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// ReplaceStep recreates the etcd member of a master machine: the old member
+// is removed, a new one with fresh certificates and an empty data directory
+// is added and the step waits until it syncs with the cluster.
+type ReplaceStep struct {
+	script *template.Template
+}
+
+func NewReplace(script *template.Template) *ReplaceStep {
+	return &ReplaceStep{
+		script: script,
+	}
+}
+
+func (s *ReplaceStep) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	masters := peers(config)
+	if len(masters) == 0 {
+		return errors.Wrapf(sgerrors.ErrNotFound, "healthy master node not found")
+	}
+
+	cfg := Config{
+		PeerIP:       config.Node.PrivateIp,
+		Endpoints:    clientEndpoints(masters),
+		SyncAttempts: syncAttempts,
+	}
+
+	// Clusters created before etcd CA was kept on the control side
+	// get certificates generated by kubeadm on the machine.
+	if config.Kube.Auth.EtcdCACert != "" {
+		pair, err := pki.NewEtcdPeerPair(config.Node.Name,
+			[]string{config.Node.PrivateIp}, &pki.PairPEM{
+				Cert: []byte(config.Kube.Auth.EtcdCACert),
+				Key:  []byte(config.Kube.Auth.EtcdCAKey),
+			})
+		if err != nil {
+			return errors.Wrap(err, "create etcd peer certificates")
+		}
+		cfg.PeerCert = string(pair.Cert)
+		cfg.PeerKey = string(pair.Key)
+	}
+
+	err := steps.RunTemplate(ctx, s.script, config.Runner, out, cfg)
+	if err != nil {
+		return errors.Wrap(err, "etcd member replace step")
+	}
+
+	return nil
+}
+
+func (s *ReplaceStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *ReplaceStep) Name() string {
+	return ReplaceStepName
+}
+
+func (s *ReplaceStep) Description() string {
+	return "Replace etcd member of a master machine"
+}
+
+func (s *ReplaceStep) Depends() []string {
+	return nil
+}
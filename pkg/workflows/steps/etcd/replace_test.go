@@ -0,0 +1,121 @@
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/pki"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestReplace(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(ReplaceStepName)
+
+	if tpl == nil {
+		t.Fatal("template not found")
+	}
+
+	ca, err := pki.NewCAPair(nil)
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	output := &bytes.Buffer{}
+	config := &steps.Config{
+		Kube: model.Kube{
+			Auth: model.Auth{
+				EtcdCACert: string(ca.Cert),
+				EtcdCAKey:  string(ca.Key),
+			},
+		},
+		Node: model.Machine{
+			ID:        "3",
+			Name:      "master-3",
+			PrivateIp: "10.0.0.3",
+		},
+		Masters: testMasters(),
+		Runner:  &testutils.MockRunner{},
+	}
+
+	err = NewReplace(tpl).Run(context.Background(), output, config)
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, expected := range []string{
+		"ENDPOINTS=https://10.0.0.1:2379,https://10.0.0.2:2379\n",
+		"--peer-urls=https://10.0.0.3:2380",
+		"--initial-cluster-state=existing",
+		"/etc/kubernetes/pki/etcd/peer.crt <<EOF\n-----BEGIN CERTIFICATE-----",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("%s not found in output %s", expected, output.String())
+		}
+	}
+
+	if strings.Contains(output.String(), "kubeadm init phase certs") {
+		t.Errorf("certificates must not be generated by kubeadm")
+	}
+}
+
+func TestReplaceWithoutEtcdCA(t *testing.T) {
+	tpl, _ := templatemanager.GetTemplate(ReplaceStepName)
+	output := &bytes.Buffer{}
+	config := &steps.Config{
+		Node: model.Machine{
+			ID:        "1",
+			PrivateIp: "10.0.0.1",
+		},
+		Masters: testMasters(),
+		Runner:  &testutils.MockRunner{},
+	}
+
+	err := NewReplace(tpl).Run(context.Background(), output, config)
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if !strings.Contains(output.String(), "kubeadm init phase certs etcd-peer") {
+		t.Errorf("certificates must be generated by kubeadm %s", output.String())
+	}
+}
+
+func TestReplaceError(t *testing.T) {
+	errMsg := "error has occurred"
+	tpl, _ := templatemanager.GetTemplate(ReplaceStepName)
+	config := &steps.Config{
+		Node: model.Machine{
+			ID: "1",
+		},
+		Masters: testMasters(),
+		Runner: &testutils.MockRunner{
+			Err: errors.New(errMsg),
+		},
+	}
+
+	err := NewReplace(tpl).Run(context.Background(), ioutil.Discard, config)
+
+	if err == nil {
+		t.Fatal("error must not be nil")
+	}
+
+	if !strings.Contains(err.Error(), errMsg) {
+		t.Errorf("error message expected to contain %s actual %s", errMsg, err.Error())
+	}
+}
@@ -0,0 +1,57 @@
+package etcd
+
+import (
+	"context"
+	"io"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// SyncStep waits until the etcd member of a master machine
+// has caught up with the rest of the cluster.
+type SyncStep struct {
+	script *template.Template
+}
+
+func NewSync(script *template.Template) *SyncStep {
+	return &SyncStep{
+		script: script,
+	}
+}
+
+func (s *SyncStep) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	if !config.IsMaster {
+		return nil
+	}
+
+	cfg := Config{
+		PeerIP:       config.Node.PrivateIp,
+		SyncAttempts: syncAttempts,
+	}
+
+	err := steps.RunTemplate(ctx, s.script, config.Runner, out, cfg)
+	if err != nil {
+		return errors.Wrap(err, "etcd member sync step")
+	}
+
+	return nil
+}
+
+func (s *SyncStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *SyncStep) Name() string {
+	return SyncStepName
+}
+
+func (s *SyncStep) Description() string {
+	return "Wait for etcd member to sync with the cluster"
+}
+
+func (s *SyncStep) Depends() []string {
+	return nil
+}
@@ -0,0 +1,112 @@
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestSync(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(SyncStepName)
+
+	if tpl == nil {
+		t.Fatal("template not found")
+	}
+
+	output := &bytes.Buffer{}
+	config := &steps.Config{
+		IsMaster: true,
+		Node: model.Machine{
+			PrivateIp: "10.0.0.1",
+		},
+		Runner: &testutils.MockRunner{},
+	}
+
+	err = NewSync(tpl).Run(context.Background(), output, config)
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, expected := range []string{
+		"endpoint health",
+		"-ge 30",
+		"etcd member 10.0.0.1",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("%s not found in output %s", expected, output.String())
+		}
+	}
+}
+
+func TestSyncNode(t *testing.T) {
+	tpl, _ := templatemanager.GetTemplate(SyncStepName)
+	output := &bytes.Buffer{}
+	config := &steps.Config{
+		Runner: &testutils.MockRunner{},
+	}
+
+	err := NewSync(tpl).Run(context.Background(), output, config)
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if output.Len() != 0 {
+		t.Errorf("nothing must be run on a node, got %s", output.String())
+	}
+}
+
+func TestSyncError(t *testing.T) {
+	errMsg := "error has occurred"
+	tpl, _ := templatemanager.GetTemplate(SyncStepName)
+	config := &steps.Config{
+		IsMaster: true,
+		Runner: &testutils.MockRunner{
+			Err: errors.New(errMsg),
+		},
+	}
+
+	err := NewSync(tpl).Run(context.Background(), ioutil.Discard, config)
+
+	if err == nil {
+		t.Fatal("error must not be nil")
+	}
+
+	if !strings.Contains(err.Error(), errMsg) {
+		t.Errorf("error message expected to contain %s actual %s", errMsg, err.Error())
+	}
+}
+
+func TestInit(t *testing.T) {
+	templatemanager.SetTemplate(SyncStepName, &template.Template{})
+	templatemanager.SetTemplate(RemoveStepName, &template.Template{})
+	templatemanager.SetTemplate(ReplaceStepName, &template.Template{})
+	templatemanager.SetTemplate(MaintenanceStepName, &template.Template{})
+	Init()
+	templatemanager.DeleteTemplate(SyncStepName)
+	templatemanager.DeleteTemplate(RemoveStepName)
+	templatemanager.DeleteTemplate(ReplaceStepName)
+	templatemanager.DeleteTemplate(MaintenanceStepName)
+
+	for _, name := range []string{SyncStepName, RemoveStepName, ReplaceStepName, MaintenanceStepName} {
+		if s := steps.GetStep(name); s == nil {
+			t.Errorf("step %s not found", name)
+		}
+	}
+}
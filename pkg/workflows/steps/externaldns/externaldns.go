@@ -0,0 +1,229 @@
+package externaldns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/template"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/dns"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sgerrors"
+	tm "github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/upload"
+)
+
+const (
+	StepName = "external-dns"
+
+	// Namespace external-dns is installed to.
+	Namespace = "external-dns"
+
+	image      = "k8s.gcr.io/external-dns/external-dns:v0.7.3"
+	secretName = "external-dns"
+	secretFile = "/etc/supergiant/external-dns-secret.yaml"
+
+	// credentialsPath is where credentials of providers that read them from
+	// a file are mounted.
+	credentialsPath      = "/etc/external-dns"
+	googleCredentialsKey = "credentials.json"
+	azureCredentialsKey  = "azure.json"
+
+	// defaultAWSRegion of route53 clients, zones aren't regional.
+	defaultAWSRegion = "us-east-1"
+)
+
+// secret manifests are uploaded as is
+var secretTpl = template.Must(template.New("secret").Parse("{{ . }}"))
+
+type Config struct {
+	Namespace  string
+	Image      string
+	Provider   string
+	Args       []string
+	SecretName string
+	SecretFile string
+	// Env are names of variables that are taken from the secret.
+	Env []string
+	// CredentialsPath is a mount point of the secret, it's empty unless the
+	// provider reads credentials from a file.
+	CredentialsPath string
+	CredentialsFile string
+}
+
+// Step installs external-dns that manages records of services and
+// ingresses in the DNS provider of the cloud account of the cluster.
+type Step struct {
+	script *template.Template
+}
+
+func Init() {
+	tpl, err := tm.GetTemplate(StepName)
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", StepName))
+	}
+
+	steps.RegisterStep(StepName, New(tpl))
+}
+
+func New(script *template.Template) *Step {
+	return &Step{
+		script: script,
+	}
+}
+
+func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	cfg, secret, err := installConfig(config)
+	if err != nil {
+		return errors.Wrapf(err, "%s step", StepName)
+	}
+
+	err = upload.Write(ctx, out, config, upload.File{
+		Path:    secretFile,
+		Mode:    0600,
+		Content: secretTpl,
+		Data: func(*steps.Config) interface{} {
+			return string(secret)
+		},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "%s step", StepName)
+	}
+
+	if err = steps.RunTemplate(ctx, s.script, config.Runner, out, cfg); err != nil {
+		return errors.Wrap(err, "install external-dns")
+	}
+	return nil
+}
+
+// installConfig returns settings of the deployment and the manifest of the
+// secret with the credentials of the DNS provider.
+func installConfig(config *steps.Config) (Config, []byte, error) {
+	ed := config.Kube.ExternalDNS
+	if err := ed.Validate(); err != nil {
+		return Config{}, nil, errors.Wrap(sgerrors.ErrInvalidJson, err.Error())
+	}
+
+	policy := ed.Policy
+	if policy == "" {
+		policy = profile.ExternalDNSUpsertOnly
+	}
+
+	cfg := Config{
+		Namespace:  Namespace,
+		Image:      image,
+		SecretName: secretName,
+		SecretFile: secretFile,
+		Args: []string{
+			"--source=service",
+			"--source=ingress",
+			"--policy=" + policy,
+			"--registry=txt",
+			"--txt-owner-id=" + config.Kube.ID,
+		},
+	}
+	for _, domain := range ed.DomainFilters {
+		cfg.Args = append(cfg.Args, "--domain-filter="+domain)
+	}
+
+	data := map[string]string{}
+	creds := config.DNSConfig.Credentials
+	switch config.DNSConfig.Provider {
+	case dns.Route53:
+		region := config.AWSConfig.Region
+		if region == "" {
+			region = defaultAWSRegion
+		}
+		cfg.Provider = "aws"
+		data["AWS_ACCESS_KEY_ID"] = creds[clouds.AWSAccessKeyID]
+		data["AWS_SECRET_ACCESS_KEY"] = creds[clouds.AWSSecretKey]
+		data["AWS_DEFAULT_REGION"] = region
+		cfg.Env = []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_DEFAULT_REGION"}
+	case dns.DigitalOcean:
+		cfg.Provider = "digitalocean"
+		data["DO_TOKEN"] = creds[clouds.DigitalOceanAccessToken]
+		cfg.Env = []string{"DO_TOKEN"}
+	case dns.Cloudflare:
+		cfg.Provider = "cloudflare"
+		data["CF_API_TOKEN"] = creds[dns.CloudflareAPIToken]
+		cfg.Env = []string{"CF_API_TOKEN"}
+	case dns.Google:
+		sa, err := config.DNSConfig.ServiceAccount()
+		if err != nil {
+			return Config{}, nil, errors.Wrap(err, "service account")
+		}
+		raw, err := json.Marshal(sa)
+		if err != nil {
+			return Config{}, nil, errors.Wrap(err, "marshal service account")
+		}
+		cfg.Provider = "google"
+		data[googleCredentialsKey] = string(raw)
+		cfg.CredentialsPath = credentialsPath
+		cfg.CredentialsFile = credentialsPath + "/" + googleCredentialsKey
+		cfg.Args = append(cfg.Args, "--google-project="+sa.ProjectID)
+	case dns.Azure:
+		// external-dns manages zones of a single resource group
+		if creds[dns.AzureResourceGroup] == "" {
+			return Config{}, nil, errors.Wrapf(sgerrors.ErrInvalidCredentials, "azure dns: %s is missing", dns.AzureResourceGroup)
+		}
+		raw, err := json.Marshal(map[string]string{
+			"tenantId":        creds[clouds.AzureTenantID],
+			"subscriptionId":  creds[clouds.AzureSubscriptionID],
+			"aadClientId":     creds[clouds.AzureClientID],
+			"aadClientSecret": creds[clouds.AzureClientSecret],
+			"resourceGroup":   creds[dns.AzureResourceGroup],
+		})
+		if err != nil {
+			return Config{}, nil, errors.Wrap(err, "marshal azure config")
+		}
+		cfg.Provider = "azure"
+		data[azureCredentialsKey] = string(raw)
+		cfg.CredentialsPath = credentialsPath
+		cfg.CredentialsFile = credentialsPath + "/" + azureCredentialsKey
+		cfg.Args = append(cfg.Args, "--azure-config-file="+cfg.CredentialsFile)
+	default:
+		return Config{}, nil, errors.Wrapf(sgerrors.ErrUnsupportedProvider, "dns of %s", config.Provider)
+	}
+	cfg.Args = append(cfg.Args, "--provider="+cfg.Provider)
+
+	secret, err := yaml.Marshal(&corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: Namespace,
+		},
+		StringData: data,
+	})
+	if err != nil {
+		return Config{}, nil, errors.Wrap(err, "marshal secret")
+	}
+
+	return cfg, secret, nil
+}
+
+func (s *Step) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *Step) Name() string {
+	return StepName
+}
+
+func (s *Step) Description() string {
+	return "Install external-dns"
+}
+
+func (s *Step) Depends() []string {
+	return nil
+}
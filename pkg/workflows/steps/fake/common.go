@@ -0,0 +1,59 @@
+package fake
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds/fake"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	CreateMachineStepName      = "createMachineFake"
+	CreateLoadBalancerStepName = "createLoadBalancerFake"
+	DeleteMachineStepName      = "deleteMachineFake"
+	DeleteClusterStepName      = "deleteClusterFake"
+
+	// calls of the fake cloud that fail when they are listed in FailOn
+	callCreateMachine      = "createMachine"
+	callCreateLoadBalancer = "createLoadBalancer"
+	callDeleteMachine      = "deleteMachine"
+	callDeleteCluster      = "deleteCluster"
+)
+
+// ErrInjected is returned by calls of the fake cloud configured to fail.
+var ErrInjected = errors.New("injected failure")
+
+func Init() {
+	steps.RegisterStep(CreateMachineStepName, NewCreateMachineStep(fake.Default))
+	steps.RegisterStep(CreateLoadBalancerStepName, NewCreateLoadBalancerStep(fake.Default))
+	steps.RegisterStep(DeleteMachineStepName, NewDeleteMachineStep(fake.Default))
+	steps.RegisterStep(DeleteClusterStepName, NewDeleteClusterStep(fake.Default))
+}
+
+// call waits for the configured latency and fails the call when it's listed
+// in FailOn of the config.
+func call(ctx context.Context, cfg steps.FakeConfig, name string) error {
+	if cfg.Latency != "" {
+		latency, err := time.ParseDuration(cfg.Latency)
+		if err != nil {
+			return errors.Wrapf(err, "parse latency %q", cfg.Latency)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(latency):
+		}
+	}
+
+	for _, failed := range strings.Split(cfg.FailOn, ",") {
+		if strings.TrimSpace(failed) == name {
+			return errors.Wrapf(ErrInjected, "fake cloud: %s", name)
+		}
+	}
+	return nil
+}
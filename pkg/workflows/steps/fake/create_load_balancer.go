@@ -0,0 +1,47 @@
+package fake
+
+import (
+	"context"
+	"io"
+
+	"github.com/supergiant/control/pkg/clouds/fake"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type CreateLoadBalancerStep struct {
+	cloud *fake.Cloud
+}
+
+func NewCreateLoadBalancerStep(cloud *fake.Cloud) *CreateLoadBalancerStep {
+	return &CreateLoadBalancerStep{
+		cloud: cloud,
+	}
+}
+
+// Run points both API addresses of the cluster to its load balancer.
+func (s *CreateLoadBalancerStep) Run(ctx context.Context, output io.Writer, config *steps.Config) error {
+	if err := call(ctx, config.FakeConfig, callCreateLoadBalancer); err != nil {
+		return err
+	}
+
+	ip := s.cloud.LoadBalancer(config.Kube.ID)
+	config.Kube.ExternalDNSName = ip
+	config.Kube.InternalDNSName = ip
+	return nil
+}
+
+func (s *CreateLoadBalancerStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *CreateLoadBalancerStep) Name() string {
+	return CreateLoadBalancerStepName
+}
+
+func (s *CreateLoadBalancerStep) Depends() []string {
+	return nil
+}
+
+func (s *CreateLoadBalancerStep) Description() string {
+	return "Create a load balancer in the fake cloud"
+}
@@ -0,0 +1,93 @@
+package fake
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/clouds/fake"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sglog"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type CreateMachineStep struct {
+	cloud *fake.Cloud
+}
+
+func NewCreateMachineStep(cloud *fake.Cloud) *CreateMachineStep {
+	return &CreateMachineStep{
+		cloud: cloud,
+	}
+}
+
+func (s *CreateMachineStep) Run(ctx context.Context, output io.Writer, config *steps.Config) error {
+	name, err := config.InstanceName()
+	if err != nil {
+		return errors.Wrap(err, "name machine")
+	}
+	config.FakeConfig.Name = name
+
+	role := model.RoleMaster
+	if !config.IsMaster {
+		role = model.RoleNode
+	}
+
+	config.Node = model.Machine{
+		TaskID:   config.TaskID,
+		Role:     role,
+		Provider: clouds.Fake,
+		Size:     config.FakeConfig.Size,
+		Region:   config.FakeConfig.Region,
+		State:    model.MachineStateBuilding,
+		Name:     config.FakeConfig.Name,
+	}
+	config.NodeChan() <- config.Node
+
+	if err := call(ctx, config.FakeConfig, callCreateMachine); err != nil {
+		config.Node.State = model.MachineStateError
+		config.NodeChan() <- config.Node
+		return err
+	}
+
+	i := s.cloud.Create(config.Kube.ID, config.FakeConfig.Name,
+		config.FakeConfig.Region, config.FakeConfig.Size, config.IsMaster)
+
+	config.Node.ID = i.ID
+	config.Node.CreatedAt = i.CreatedAt.Unix()
+	config.Node.PublicIp = i.PublicIP
+	config.Node.PrivateIp = i.PrivateIP
+	config.Node.State = model.MachineStateProvisioning
+	config.NodeChan() <- config.Node
+
+	if config.IsMaster {
+		config.AddMaster(&config.Node)
+	} else {
+		config.AddNode(&config.Node)
+	}
+
+	sglog.FromContext(ctx).Infof("Fake machine has been created %v", config.Node)
+	return nil
+}
+
+// Rollback removes the machine, so a retry of the task creates it again.
+func (s *CreateMachineStep) Rollback(ctx context.Context, output io.Writer, config *steps.Config) error {
+	if config.FakeConfig.Name != "" {
+		s.cloud.Delete(config.Kube.ID, config.FakeConfig.Name)
+	}
+	return nil
+}
+
+func (s *CreateMachineStep) Name() string {
+	return CreateMachineStepName
+}
+
+func (s *CreateMachineStep) Depends() []string {
+	return nil
+}
+
+func (s *CreateMachineStep) Description() string {
+	return "Create a machine in the fake cloud"
+}
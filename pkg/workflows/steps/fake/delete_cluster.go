@@ -0,0 +1,46 @@
+package fake
+
+import (
+	"context"
+	"io"
+
+	"github.com/supergiant/control/pkg/clouds/fake"
+	"github.com/supergiant/control/pkg/sglog"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type DeleteClusterStep struct {
+	cloud *fake.Cloud
+}
+
+func NewDeleteClusterStep(cloud *fake.Cloud) *DeleteClusterStep {
+	return &DeleteClusterStep{
+		cloud: cloud,
+	}
+}
+
+func (s *DeleteClusterStep) Run(ctx context.Context, output io.Writer, config *steps.Config) error {
+	if err := call(ctx, config.FakeConfig, callDeleteCluster); err != nil {
+		return err
+	}
+
+	n := s.cloud.DeleteCluster(config.Kube.ID)
+	sglog.FromContext(ctx).Infof("%d fake machines of cluster %s have been deleted", n, config.Kube.ID)
+	return nil
+}
+
+func (s *DeleteClusterStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *DeleteClusterStep) Name() string {
+	return DeleteClusterStepName
+}
+
+func (s *DeleteClusterStep) Depends() []string {
+	return nil
+}
+
+func (s *DeleteClusterStep) Description() string {
+	return "Delete machines and the load balancer of the cluster in the fake cloud"
+}
@@ -0,0 +1,47 @@
+package fake
+
+import (
+	"context"
+	"io"
+
+	"github.com/supergiant/control/pkg/clouds/fake"
+	"github.com/supergiant/control/pkg/sglog"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type DeleteMachineStep struct {
+	cloud *fake.Cloud
+}
+
+func NewDeleteMachineStep(cloud *fake.Cloud) *DeleteMachineStep {
+	return &DeleteMachineStep{
+		cloud: cloud,
+	}
+}
+
+func (s *DeleteMachineStep) Run(ctx context.Context, output io.Writer, config *steps.Config) error {
+	if err := call(ctx, config.FakeConfig, callDeleteMachine); err != nil {
+		return err
+	}
+
+	if !s.cloud.Delete(config.Kube.ID, config.Node.Name) {
+		sglog.FromContext(ctx).Infof("fake machine %s has been already deleted", config.Node.Name)
+	}
+	return nil
+}
+
+func (s *DeleteMachineStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *DeleteMachineStep) Name() string {
+	return DeleteMachineStepName
+}
+
+func (s *DeleteMachineStep) Depends() []string {
+	return nil
+}
+
+func (s *DeleteMachineStep) Description() string {
+	return "Delete a machine of the fake cloud"
+}
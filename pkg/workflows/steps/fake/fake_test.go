@@ -0,0 +1,97 @@
+package fake
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/clouds/fake"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func newConfig() *steps.Config {
+	cfg := &steps.Config{
+		Provider: clouds.Fake,
+		TaskID:   "1234abcd",
+		Kube: model.Kube{
+			ID:   "kube",
+			Name: "test",
+		},
+		FakeConfig: steps.FakeConfig{
+			Region: "fake-east-1",
+			Size:   "fake-small",
+		},
+		Masters: steps.NewMap(map[string]*model.Machine{}),
+		Nodes:   steps.NewMap(map[string]*model.Machine{}),
+	}
+	cfg.SetNodeChan(make(chan model.Machine, 10))
+	return cfg
+}
+
+func TestCreateMachineStep_Run(t *testing.T) {
+	cloud := fake.New()
+	cfg := newConfig()
+	cfg.IsMaster = true
+
+	require.NoError(t, NewCreateMachineStep(cloud).Run(context.Background(), ioutil.Discard, cfg))
+	require.Equal(t, model.MachineStateProvisioning, cfg.Node.State)
+	require.Equal(t, model.RoleMaster, cfg.Node.Role)
+	require.NotEmpty(t, cfg.Node.PublicIp)
+	require.Len(t, cfg.GetMasters(), 1)
+	require.Len(t, cloud.Instances("kube"), 1)
+	require.Equal(t, model.MachineStateBuilding, (<-cfg.NodeChan()).State)
+
+	require.NoError(t, NewDeleteMachineStep(cloud).Run(context.Background(), ioutil.Discard, cfg))
+	require.Empty(t, cloud.Instances("kube"))
+}
+
+func TestCreateMachineStep_RunFailure(t *testing.T) {
+	cloud := fake.New()
+	cfg := newConfig()
+	cfg.FakeConfig.FailOn = "createLoadBalancer, createMachine"
+
+	s := NewCreateMachineStep(cloud)
+	err := s.Run(context.Background(), ioutil.Discard, cfg)
+	require.Equal(t, ErrInjected, errors.Cause(err))
+	require.Equal(t, model.MachineStateError, cfg.Node.State)
+	require.Empty(t, cloud.Instances("kube"))
+
+	require.NoError(t, s.Rollback(context.Background(), ioutil.Discard, cfg))
+}
+
+func TestCall(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	err := call(ctx, steps.FakeConfig{Latency: "1m"}, callDeleteMachine)
+	require.Equal(t, context.DeadlineExceeded, err)
+
+	require.Error(t, call(context.Background(), steps.FakeConfig{Latency: "soon"}, callDeleteMachine))
+	require.NoError(t, call(context.Background(), steps.FakeConfig{FailOn: callCreateMachine}, callDeleteMachine))
+}
+
+func TestDeleteClusterStep_Run(t *testing.T) {
+	cloud := fake.New()
+	cfg := newConfig()
+
+	require.NoError(t, NewCreateLoadBalancerStep(cloud).Run(context.Background(), ioutil.Discard, cfg))
+	require.NotEmpty(t, cfg.Kube.ExternalDNSName)
+	require.Equal(t, cfg.Kube.ExternalDNSName, cfg.Kube.InternalDNSName)
+
+	cloud.Create("kube", "master-1", "fake-east-1", "fake-small", true)
+	cloud.Create("kube", "node-1", "fake-east-1", "fake-small", false)
+
+	cfg.FakeConfig.FailOn = callDeleteCluster
+	require.Error(t, NewDeleteClusterStep(cloud).Run(context.Background(), ioutil.Discard, cfg))
+	require.Len(t, cloud.Instances("kube"), 2)
+
+	cfg.FakeConfig.FailOn = ""
+	require.NoError(t, NewDeleteClusterStep(cloud).Run(context.Background(), ioutil.Discard, cfg))
+	require.Empty(t, cloud.Instances("kube"))
+}
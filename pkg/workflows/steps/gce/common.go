@@ -17,6 +17,8 @@ type computeService struct {
 	getInstance         func(context.Context, steps.GCEConfig, string) (*compute.Instance, error)
 	setInstanceMetadata func(context.Context, steps.GCEConfig, string, *compute.Metadata) (*compute.Operation, error)
 	deleteInstance      func(string, string, string) (*compute.Operation, error)
+	deleteAccessConfig  func(context.Context, steps.GCEConfig, string) (*compute.Operation, error)
+	addAccessConfig     func(context.Context, steps.GCEConfig, string, *compute.AccessConfig) (*compute.Operation, error)
 
 	insertTargetPool           func(context.Context, steps.GCEConfig, *compute.TargetPool) (*compute.Operation, error)
 	insertAddress              func(context.Context, steps.GCEConfig, *compute.Address) (*compute.Operation, error)
@@ -54,6 +56,7 @@ func Init(getter accountGetter) {
 	createBackendService, _ := NewCreateBackendServiceStep()
 	createHealthCheck := NewCreateHealthCheckStep()
 	createNetworks := NewCreateNetworksStep()
+	attachStaticIP := NewAttachStaticIPStep(time.Second*5, time.Minute*2)
 
 	deleteCluster := NewDeleteClusterStep()
 	deleteInstanceGroup, _ := NewDeleteInstanceGroupStep()
@@ -62,6 +65,7 @@ func Init(getter accountGetter) {
 	deleteTargetPool := NewDeleteTargetPoolStep()
 	deleteIpAddress := NewDeleteIpAddressStep()
 	deleteNode := NewDeleteNodeStep()
+	releaseStaticIPs := NewReleaseStaticIPsStep()
 
 	steps.RegisterStep(CreateHealthCheckStepName, createHealthCheck)
 	steps.RegisterStep(DeleteInstanceGroupStepName, deleteInstanceGroup)
@@ -78,6 +82,8 @@ func Init(getter accountGetter) {
 	steps.RegisterStep(DeleteTargetPoolStepName, deleteTargetPool)
 	steps.RegisterStep(DeleteIpAddressStepName, deleteIpAddress)
 	steps.RegisterStep(CreateNetworksStepName, createNetworks)
+	steps.RegisterStep(AttachStaticIPStepName, attachStaticIP)
+	steps.RegisterStep(ReleaseStaticIPsStepName, releaseStaticIPs)
 }
 
 func isNotFound(err error) bool {
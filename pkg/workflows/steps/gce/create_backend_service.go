@@ -7,10 +7,10 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"google.golang.org/api/compute/v1"
 
 	"github.com/supergiant/control/pkg/clouds/gcesdk"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -46,12 +46,12 @@ func NewCreateBackendServiceStep() (*CreateBackendServiceStep, error) {
 
 func (s *CreateBackendServiceStep) Run(ctx context.Context, output io.Writer,
 	config *steps.Config) error {
-	logrus.Debugf("Step %s", CreateBackendServiceStepName)
+	sglog.FromContext(ctx).Debugf("Step %s", CreateBackendServiceStepName)
 
 	svc, err := s.getComputeSvc(ctx, config.GCEConfig)
 
 	if err != nil {
-		logrus.Errorf("Error getting service %v", err)
+		sglog.FromContext(ctx).Errorf("Error getting service %v", err)
 		return errors.Wrapf(err, "%s getting service caused", CreateBackendServiceStepName)
 	}
 
@@ -76,21 +76,21 @@ func (s *CreateBackendServiceStep) Run(ctx context.Context, output io.Writer,
 	_, err = svc.insertBackendService(ctx, config.GCEConfig, backendService)
 
 	if err != nil {
-		logrus.Errorf("error creating backend service %v", err)
+		sglog.FromContext(ctx).Errorf("error creating backend service %v", err)
 		return errors.Wrapf(err, "error creating backend service")
 	}
 
 	backendService, err = svc.getBackendService(ctx, config.GCEConfig, backendService.Name)
 
 	if err != nil {
-		logrus.Errorf("error getting backend service %v", err)
+		sglog.FromContext(ctx).Errorf("error getting backend service %v", err)
 		return errors.Wrapf(err, "error getting backend service")
 	}
 
 	config.GCEConfig.BackendServiceName = backendService.Name
 	config.GCEConfig.BackendServiceLink = backendService.SelfLink
 
-	logrus.Debugf("Created backend service name %s link %s",
+	sglog.FromContext(ctx).Debugf("Created backend service name %s link %s",
 		config.GCEConfig.BackendServiceName,
 		config.GCEConfig.BackendServiceLink)
 	// NOTE(stgleb): There is no field that signals for backend service is available so we simple sleep.
@@ -7,10 +7,10 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"google.golang.org/api/compute/v1"
 
 	"github.com/supergiant/control/pkg/clouds/gcesdk"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -48,12 +48,12 @@ func NewCreateForwardingRulesStep() *CreateForwardingRules {
 
 func (s *CreateForwardingRules) Run(ctx context.Context, output io.Writer,
 	config *steps.Config) error {
-	logrus.Debugf("Step %s", CreateForwardingRulesStepName)
+	sglog.FromContext(ctx).Debugf("Step %s", CreateForwardingRulesStepName)
 
 	svc, err := s.getComputeSvc(ctx, config.GCEConfig)
 
 	if err != nil {
-		logrus.Errorf("Error getting service %v", err)
+		sglog.FromContext(ctx).Errorf("Error getting service %v", err)
 		return errors.Wrapf(err, "%s getting service caused", CreateForwardingRulesStepName)
 	}
 
@@ -76,24 +76,24 @@ func (s *CreateForwardingRules) Run(ctx context.Context, output io.Writer,
 			break
 		}
 
-		logrus.Debugf("Error external forwarding rule %v sleep for %v", err, timeout)
+		sglog.FromContext(ctx).Debugf("Error external forwarding rule %v sleep for %v", err, timeout)
 		time.Sleep(timeout)
 		timeout = timeout * 2
 	}
 
 	if err != nil {
-		logrus.Errorf("Error creating external forwarding rule %v", err)
+		sglog.FromContext(ctx).Errorf("Error creating external forwarding rule %v", err)
 		return errors.Wrapf(err, "%s creating external forwarding rule caused", CreateForwardingRulesStepName)
 	}
 
 	externalForwardingRule, err = svc.getForwardingRule(ctx, config.GCEConfig, exName)
 
 	if err != nil {
-		logrus.Errorf("get external forwarding rule %v", err)
+		sglog.FromContext(ctx).Errorf("get external forwarding rule %v", err)
 		return errors.Wrapf(err, "get external forwarding rule")
 	}
 
-	logrus.Debugf("Created external forwarding rule %s link %s", exName, externalForwardingRule.SelfLink)
+	sglog.FromContext(ctx).Debugf("Created external forwarding rule %s link %s", exName, externalForwardingRule.SelfLink)
 	config.GCEConfig.ExternalForwardingRuleName = externalForwardingRule.Name
 
 	inName := fmt.Sprintf("inrule-%s", config.Kube.ID)
@@ -118,24 +118,24 @@ func (s *CreateForwardingRules) Run(ctx context.Context, output io.Writer,
 			break
 		}
 
-		logrus.Debugf("Error internal forwarding rule error %v sleep for %v", err, timeout)
+		sglog.FromContext(ctx).Debugf("Error internal forwarding rule error %v sleep for %v", err, timeout)
 		time.Sleep(timeout)
 		timeout = timeout * 2
 	}
 
 	if err != nil {
-		logrus.Errorf("Error creating internal forwarding rule %v", err)
+		sglog.FromContext(ctx).Errorf("Error creating internal forwarding rule %v", err)
 		return errors.Wrapf(err, "%s creating internal forwarding rule caused", CreateForwardingRulesStepName)
 	}
 
 	internalForwardingRule, err = svc.getForwardingRule(ctx, config.GCEConfig, inName)
 
 	if err != nil {
-		logrus.Errorf("get internal forwarding rule %v", err)
+		sglog.FromContext(ctx).Errorf("get internal forwarding rule %v", err)
 		return errors.Wrapf(err, "get internal forwarding rule")
 	}
 
-	logrus.Debugf("Created internal forwarding rule %s link %s", inName, internalForwardingRule.SelfLink)
+	sglog.FromContext(ctx).Debugf("Created internal forwarding rule %s link %s", inName, internalForwardingRule.SelfLink)
 	config.GCEConfig.InternalForwardingRuleName = internalForwardingRule.Name
 
 	return nil
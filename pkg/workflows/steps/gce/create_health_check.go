@@ -7,10 +7,10 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"google.golang.org/api/compute/v1"
 
 	"github.com/supergiant/control/pkg/clouds/gcesdk"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -43,12 +43,12 @@ func NewCreateHealthCheckStep() *CreateHealthCheck {
 
 func (s *CreateHealthCheck) Run(ctx context.Context, output io.Writer,
 	config *steps.Config) error {
-	logrus.Debugf("Step %s", CreateHealthCheckStepName)
+	sglog.FromContext(ctx).Debugf("Step %s", CreateHealthCheckStepName)
 
 	svc, err := s.getComputeSvc(ctx, config.GCEConfig)
 
 	if err != nil {
-		logrus.Errorf("Error getting service %v", err)
+		sglog.FromContext(ctx).Errorf("Error getting service %v", err)
 		return errors.Wrapf(err, "%s getting service caused", CreateHealthCheckStepName)
 	}
 
@@ -67,7 +67,7 @@ func (s *CreateHealthCheck) Run(ctx context.Context, output io.Writer,
 	_, err = svc.insertHealthCheck(ctx, config.GCEConfig, healthCheck)
 
 	if err != nil {
-		logrus.Errorf("Error creating external health check %v", err)
+		sglog.FromContext(ctx).Errorf("Error creating external health check %v", err)
 		return errors.Wrapf(err, "%s creating external health check caused",
 			CreateHealthCheckStepName)
 	}
@@ -78,7 +78,7 @@ func (s *CreateHealthCheck) Run(ctx context.Context, output io.Writer,
 		return errors.Wrapf(err, "Error creating health check")
 	}
 
-	logrus.Debugf("Created health check link %s", hc.SelfLink)
+	sglog.FromContext(ctx).Debugf("Created health check link %s", hc.SelfLink)
 	config.GCEConfig.HealthCheckName = hc.SelfLink
 	time.Sleep(time.Minute * 1)
 
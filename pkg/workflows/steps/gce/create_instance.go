@@ -8,14 +8,13 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"google.golang.org/api/compute/v1"
 
 	"github.com/supergiant/control/pkg/clouds"
 	"github.com/supergiant/control/pkg/clouds/gcesdk"
 	"github.com/supergiant/control/pkg/model"
 	"github.com/supergiant/control/pkg/sgerrors"
-	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -78,29 +77,27 @@ func NewCreateInstanceStep(period, timeout time.Duration) *CreateInstanceStep {
 
 func (s *CreateInstanceStep) Run(ctx context.Context, output io.Writer,
 	config *steps.Config) error {
-	logrus.Debugf("Step %s", CreateInstanceStepName)
+	sglog.FromContext(ctx).Debugf("Step %s", CreateInstanceStepName)
 
 	svc, err := s.getComputeSvc(ctx, config.GCEConfig)
 
 	if err != nil {
-		logrus.Errorf("Error getting service %v", err)
+		sglog.FromContext(ctx).Errorf("Error getting service %v", err)
 		return errors.Wrapf(err, "%s getting service caused", CreateInstanceStepName)
 	}
 
-	image, err := svc.getFromFamily(ctx, config.GCEConfig)
+	sourceImage, err := nodeImage(ctx, svc, config)
 
 	if err != nil {
-		logrus.Errorf("Error getting image from family %s %v",
-			config.GCEConfig.ImageFamily, err)
-		return errors.Wrapf(err, "Error getting image from family %s",
-			config.GCEConfig.ImageFamily)
+		sglog.FromContext(ctx).Errorf("Error getting image %v", err)
+		return err
 	}
 
 	// get master machine type.
 	instType, err := svc.getMachineTypes(ctx, config.GCEConfig)
 
 	if err != nil {
-		logrus.Errorf("Error getting machine type %v", err)
+		sglog.FromContext(ctx).Errorf("Error getting machine type %v", err)
 		return errors.Wrapf(err, "error gettting machine types")
 	}
 
@@ -112,12 +109,16 @@ func (s *CreateInstanceStep) Run(ctx context.Context, output io.Writer,
 
 	// NOTE(stgleb): Upper-case symbols are forbidden
 	// Instance name must follow regexp: (?:[a-z](?:[-a-z0-9]{0,61}[a-z0-9])?)
-	name := util.MakeNodeName(strings.ToLower(config.Kube.Name),
-		config.TaskID, config.IsMaster)
+	name, err := config.InstanceName()
+	if err != nil {
+		return errors.Wrap(err, "name instance")
+	}
+	name = strings.ToLower(name)
 
 	// TODO(stgleb): also copy user provided ssh key
 	publicKey := fmt.Sprintf("%s:%s",
-		config.Kube.SSHConfig.User, config.Kube.SSHConfig.BootstrapPublicKey)
+		config.Kube.SSHConfig.For(model.Machine{SSHUser: config.NodeSSHUser}).User,
+		config.Kube.SSHConfig.BootstrapPublicKey)
 	// Put bootstrap key to instance metadata that allows ssh connection to the node
 	metadata := &compute.Metadata{
 		Items: []*compute.MetadataItems{
@@ -155,7 +156,7 @@ func (s *CreateInstanceStep) Run(ctx context.Context, output io.Writer,
 				Type:       "PERSISTENT",
 				InitializeParams: &compute.AttachedDiskInitializeParams{
 					DiskName:    name + "-root-pd",
-					SourceImage: image.SelfLink,
+					SourceImage: sourceImage,
 					DiskSizeGb:  30,
 				},
 			},
@@ -187,14 +188,14 @@ func (s *CreateInstanceStep) Run(ctx context.Context, output io.Writer,
 	_, err = svc.insertInstance(ctx, config.GCEConfig, instance)
 
 	if err != nil {
-		logrus.Errorf("inserting instance caused %v", err)
+		sglog.FromContext(ctx).Errorf("inserting instance caused %v", err)
 		return errors.Wrapf(err, "%s inserting instance",
 			CreateInstanceStepName)
 	}
 
 	resp, err := svc.getInstance(ctx, config.GCEConfig, name)
 	if err != nil {
-		logrus.Errorf("getting instance caused %v", err)
+		sglog.FromContext(ctx).Errorf("getting instance caused %v", err)
 		return errors.Wrapf(err, "%s getting instance",
 			CreateInstanceStepName)
 	}
@@ -203,7 +204,7 @@ func (s *CreateInstanceStep) Run(ctx context.Context, output io.Writer,
 	_, err = svc.setInstanceMetadata(ctx, config.GCEConfig, name, metadata)
 
 	if err != nil {
-		logrus.Errorf("setting instance metadata caused %v", err)
+		sglog.FromContext(ctx).Errorf("setting instance metadata caused %v", err)
 		return errors.Wrapf(err, "%s setting instance metadata",
 			CreateInstanceStepName)
 	}
@@ -222,6 +223,8 @@ func (s *CreateInstanceStep) Run(ctx context.Context, output io.Writer,
 		Role:      nodeRole,
 		Provider:  clouds.GCE,
 		Size:      config.GCEConfig.Size,
+		Image:     sourceImage,
+		TaskID:    config.TaskID,
 		// Note(stgleb):  This is a hack, we put az to region, because region is
 		// cluster wide and we need az to delete instance.
 		// TODO(stgleb): consider adding AZ to node struct
@@ -259,14 +262,14 @@ func (s *CreateInstanceStep) Run(ctx context.Context, output io.Writer,
 						},
 					}
 
-					logrus.Debugf("Add instance %s to target pool %s", config.Node.Name,
+					sglog.FromContext(ctx).Debugf("Add instance %s to target pool %s", config.Node.Name,
 						config.GCEConfig.TargetPoolLink)
 
 					_, err := svc.addInstanceToTargetGroup(ctx, config.GCEConfig,
 						config.GCEConfig.TargetPoolName, addInstanceToTargetPoolReq)
 
 					if err != nil {
-						logrus.Errorf("error adding instance %s URL %s to target pool %s",
+						sglog.FromContext(ctx).Errorf("error adding instance %s URL %s to target pool %s",
 							resp.Name, resp.SelfLink, config.GCEConfig.TargetPoolName)
 					}
 
@@ -285,19 +288,19 @@ func (s *CreateInstanceStep) Run(ctx context.Context, output io.Writer,
 							},
 						}
 
-						logrus.Debugf("Add instance %s to instance group %s", config.Node.Name,
+						sglog.FromContext(ctx).Debugf("Add instance %s to instance group %s", config.Node.Name,
 							config.GCEConfig.InstanceGroupNames[config.GCEConfig.AvailabilityZone])
 						_, err = svc.addInstanceToInstanceGroup(ctx, config.GCEConfig,
 							config.GCEConfig.InstanceGroupNames[config.GCEConfig.AvailabilityZone], req)
 
 						if err != nil {
-							logrus.Errorf("error adding instance %s URL %s to instance group %s %v",
+							sglog.FromContext(ctx).Errorf("error adding instance %s URL %s to instance group %s %v",
 								resp.Name, resp.SelfLink, config.GCEConfig.InstanceGroupLinks[config.GCEConfig.AvailabilityZone], err)
 						}
 					}()
 
 					if len(resp.NetworkInterfaces) > 0 {
-						logrus.Debugf("Add instance name %s link %s with network interface %s subnetwork %s", resp.Name, resp.SelfLink,
+						sglog.FromContext(ctx).Debugf("Add instance name %s link %s with network interface %s subnetwork %s", resp.Name, resp.SelfLink,
 							resp.NetworkInterfaces[0].Network, resp.NetworkInterfaces[0].Subnetwork)
 					}
 				} else {
@@ -327,3 +330,26 @@ func (s *CreateInstanceStep) Description() string {
 func (s *CreateInstanceStep) Rollback(context.Context, io.Writer, *steps.Config) error {
 	return nil
 }
+
+// nodeImage returns the link of the pinned image of the node or the latest
+// image of its family, the family of the cluster is used by default. Names
+// that aren't families of supergiant are families of GCE.
+func nodeImage(ctx context.Context, svc *computeService, config *steps.Config) (string, error) {
+	if config.NodeImage != "" {
+		return config.NodeImage, nil
+	}
+
+	imageConfig := config.GCEConfig
+	if config.NodeImageFamily != "" {
+		imageConfig.ImageFamily = config.NodeImageFamily
+		if family, ok := clouds.FindImageFamily(config.NodeImageFamily); ok {
+			imageConfig.ImageFamily = family.GCEFamily
+		}
+	}
+
+	image, err := svc.getFromFamily(ctx, imageConfig)
+	if err != nil {
+		return "", errors.Wrapf(err, "Error getting image from family %s", imageConfig.ImageFamily)
+	}
+	return image.SelfLink, nil
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/supergiant/control/pkg/account"
 	"github.com/supergiant/control/pkg/clouds/gcesdk"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -62,12 +63,12 @@ func NewCreateInstanceGroupsStep(getter accountGetter) (*CreateInstanceGroupsSte
 func (s *CreateInstanceGroupsStep) Run(ctx context.Context, output io.Writer,
 	config *steps.Config) error {
 
-	logrus.Debugf("Step %s", CreateInstanceGroupsStepName)
+	sglog.FromContext(ctx).Debugf("Step %s", CreateInstanceGroupsStepName)
 
 	svc, err := s.getComputeSvc(ctx, config.GCEConfig)
 
 	if err != nil {
-		logrus.Errorf("Error getting service %v", err)
+		sglog.FromContext(ctx).Errorf("Error getting service %v", err)
 		return errors.Wrapf(err, "%s getting service caused", CreateInstanceGroupsStepName)
 	}
 
@@ -77,14 +78,14 @@ func (s *CreateInstanceGroupsStep) Run(ctx context.Context, output io.Writer,
 	zoneGetter, err := s.zoneGetterFactory(ctx, s.accountGetter, config)
 
 	if err != nil {
-		logrus.Errorf("Create zone getter caused error %v", err)
+		sglog.FromContext(ctx).Errorf("Create zone getter caused error %v", err)
 		return errors.Wrap(err, "Create zone getter caused")
 	}
 
 	azs, err := zoneGetter.GetZones(ctx, *config)
 
 	if err != nil {
-		logrus.Errorf("get availability zones %v", err)
+		sglog.FromContext(ctx).Errorf("get availability zones %v", err)
 		return errors.Wrap(err, "get availability zones")
 	}
 
@@ -106,21 +107,21 @@ func (s *CreateInstanceGroupsStep) Run(ctx context.Context, output io.Writer,
 		_, err = svc.insertInstanceGroup(ctx, config.GCEConfig, instanceGroup)
 
 		if err != nil {
-			logrus.Errorf("Error creating instance group %v", err)
+			sglog.FromContext(ctx).Errorf("Error creating instance group %v", err)
 			return errors.Wrapf(err, "%s creating instance group caused", CreateInstanceGroupsStepName)
 		}
 
 		instanceGroup, err = svc.getInstanceGroup(ctx, config.GCEConfig, instanceGroup.Name)
 
 		if err != nil {
-			logrus.Errorf("Error getting instance group %v", err)
+			sglog.FromContext(ctx).Errorf("Error getting instance group %v", err)
 			return errors.Wrapf(err, "%s creating getting group caused", CreateInstanceGroupsStepName)
 		}
 
 		config.GCEConfig.InstanceGroupLinks[az] = instanceGroup.SelfLink
 		config.GCEConfig.InstanceGroupNames[az] = instanceGroup.Name
 
-		logrus.Debugf("Created instance group for az %s name %s link %s and network %s",
+		sglog.FromContext(ctx).Debugf("Created instance group for az %s name %s link %s and network %s",
 			az, config.GCEConfig.InstanceGroupNames[az],
 			config.GCEConfig.InstanceGroupLinks[az],
 			instanceGroup.Network)
@@ -7,10 +7,10 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"google.golang.org/api/compute/v1"
 
 	"github.com/supergiant/control/pkg/clouds/gcesdk"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -47,17 +47,17 @@ func NewCreateAddressStep() *CreateAddressStep {
 
 func (s *CreateAddressStep) Run(ctx context.Context, output io.Writer,
 	config *steps.Config) error {
-	logrus.Debugf("Step %s", CreateIPAddressStepName)
+	sglog.FromContext(ctx).Debugf("Step %s", CreateIPAddressStepName)
 
 	svc, err := s.getComputeSvc(ctx, config.GCEConfig)
 
 	if err != nil {
-		logrus.Errorf("Error getting service %v", err)
+		sglog.FromContext(ctx).Errorf("Error getting service %v", err)
 		return errors.Wrapf(err, "%s getting service caused", CreateIPAddressStepName)
 	}
 
 	externalAddressName := fmt.Sprintf("ex-ip-%s", config.Kube.ID)
-	logrus.Debugf("create external ip address name %s", externalAddressName)
+	sglog.FromContext(ctx).Debugf("create external ip address name %s", externalAddressName)
 
 	externalAddress := &compute.Address{
 		Name:        externalAddressName,
@@ -68,7 +68,7 @@ func (s *CreateAddressStep) Run(ctx context.Context, output io.Writer,
 	_, err = svc.insertAddress(ctx, config.GCEConfig, externalAddress)
 
 	if err != nil {
-		logrus.Errorf("Error creating external ip address %v", err)
+		sglog.FromContext(ctx).Errorf("Error creating external ip address %v", err)
 		return errors.Wrapf(err, "error creating external ip address types")
 	}
 
@@ -81,7 +81,7 @@ func (s *CreateAddressStep) Run(ctx context.Context, output io.Writer,
 			config.GCEConfig.ExternalIPAddressLink = externalAddress.SelfLink
 			config.Kube.ExternalDNSName = externalAddress.Address
 			config.Kube.InternalDNSName = externalAddress.Address
-			logrus.Debugf("External IP %s", externalAddress.Address)
+			sglog.FromContext(ctx).Debugf("External IP %s", externalAddress.Address)
 			break
 		}
 
@@ -90,11 +90,11 @@ func (s *CreateAddressStep) Run(ctx context.Context, output io.Writer,
 	}
 
 	if err != nil {
-		logrus.Errorf("Error obtaining external ip address %v", err)
+		sglog.FromContext(ctx).Errorf("Error obtaining external ip address %v", err)
 		return errors.Wrapf(err, "error obtaining external ip address types")
 	}
 
-	logrus.Debugf("Save external IP address SelfLink %s", externalAddress.SelfLink)
+	sglog.FromContext(ctx).Debugf("Save external IP address SelfLink %s", externalAddress.SelfLink)
 	config.GCEConfig.ExternalIPAddressLink = externalAddress.SelfLink
 	config.GCEConfig.ExternalAddressName = externalAddress.Name
 
@@ -107,11 +107,11 @@ func (s *CreateAddressStep) Run(ctx context.Context, output io.Writer,
 		Subnetwork:  config.GCEConfig.SubnetLink,
 	}
 
-	logrus.Debugf("create internal ip address %s", internalAddressName)
+	sglog.FromContext(ctx).Debugf("create internal ip address %s", internalAddressName)
 	_, err = svc.insertAddress(ctx, config.GCEConfig, internalAddress)
 
 	if err != nil {
-		logrus.Errorf("Error creating internal ip address %v", err)
+		sglog.FromContext(ctx).Errorf("Error creating internal ip address %v", err)
 		return errors.Wrapf(err, "error creating internal ip address types")
 	}
 
@@ -123,7 +123,7 @@ func (s *CreateAddressStep) Run(ctx context.Context, output io.Writer,
 		if err == nil && internalAddress.Address != "" {
 			config.GCEConfig.InternalIPAddressLink = internalAddress.SelfLink
 			config.Kube.InternalDNSName = internalAddress.Address
-			logrus.Debugf("Internal IP %s link %s",
+			sglog.FromContext(ctx).Debugf("Internal IP %s link %s",
 				internalAddress.Address, internalAddress.SelfLink)
 			break
 		}
@@ -133,11 +133,11 @@ func (s *CreateAddressStep) Run(ctx context.Context, output io.Writer,
 	}
 
 	if err != nil {
-		logrus.Errorf("Error obtaining internal ip address %v", err)
+		sglog.FromContext(ctx).Errorf("Error obtaining internal ip address %v", err)
 		return errors.Wrapf(err, "error obtaining internal ip address types")
 	}
 
-	logrus.Debugf("Save internal IP address SelfLink %s", internalAddress.SelfLink)
+	sglog.FromContext(ctx).Debugf("Save internal IP address SelfLink %s", internalAddress.SelfLink)
 	config.GCEConfig.InternalIPAddressLink = internalAddress.SelfLink
 	config.GCEConfig.InternalAddressName = internalAddress.Name
 
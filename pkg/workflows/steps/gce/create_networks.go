@@ -3,7 +3,6 @@ package gce
 import (
 	"context"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"github.com/supergiant/control/pkg/clouds/gcesdk"
 	"github.com/supergiant/control/pkg/model"
 	"io"
@@ -12,6 +11,7 @@ import (
 
 	"google.golang.org/api/compute/v1"
 
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -62,31 +62,31 @@ func NewCreateNetworksStep() *CreateNetworksStep {
 
 func (s *CreateNetworksStep) Run(ctx context.Context, output io.Writer,
 	config *steps.Config) error {
-	logrus.Debugf("Step %s", CreateNetworksStepName)
+	sglog.FromContext(ctx).Debugf("Step %s", CreateNetworksStepName)
 
 	svc, err := s.getComputeSvc(ctx, config.GCEConfig)
 
 	if err != nil {
-		logrus.Errorf("Error getting service %v", err)
+		sglog.FromContext(ctx).Errorf("Error getting service %v", err)
 		return errors.Wrapf(err, "%s getting service caused", CreateIPAddressStepName)
 	}
 
 	network, err := svc.getNetwork(ctx, config.GCEConfig, "default")
 
 	if err != nil {
-		logrus.Errorf("Get network caused error %v", err)
+		sglog.FromContext(ctx).Errorf("Get network caused error %v", err)
 		return errors.Wrap(err, "Get network caused error")
 	}
 
 	config.GCEConfig.NetworkLink = network.SelfLink
 	config.GCEConfig.NetworkName = network.Name
 
-	logrus.Debugf("Created network name %s link %s",
+	sglog.FromContext(ctx).Debugf("Created network name %s link %s",
 		network.Name, network.SelfLink)
 	for _, subnet := range network.Subnetworks {
 		if strings.Contains(subnet, config.GCEConfig.Region) {
 			config.GCEConfig.SubnetLink = subnet
-			logrus.Debugf("Use subnet %s", subnet)
+			sglog.FromContext(ctx).Debugf("Use subnet %s", subnet)
 		}
 	}
 
@@ -6,10 +6,10 @@ import (
 	"io"
 
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"google.golang.org/api/compute/v1"
 
 	"github.com/supergiant/control/pkg/clouds/gcesdk"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -42,12 +42,12 @@ func NewCreateTargetPoolStep() *CreateTargetPoolStep {
 
 func (s *CreateTargetPoolStep) Run(ctx context.Context, output io.Writer,
 	config *steps.Config) error {
-	logrus.Debugf("Step %s", CreateTargetPullStepName)
+	sglog.FromContext(ctx).Debugf("Step %s", CreateTargetPullStepName)
 
 	svc, err := s.getComputeSvc(ctx, config.GCEConfig)
 
 	if err != nil {
-		logrus.Errorf("Error getting service %v", err)
+		sglog.FromContext(ctx).Errorf("Error getting service %v", err)
 		return errors.Wrapf(err, "%s getting service caused", CreateTargetPullStepName)
 	}
 
@@ -60,20 +60,20 @@ func (s *CreateTargetPoolStep) Run(ctx context.Context, output io.Writer,
 	_, err = svc.insertTargetPool(ctx, config.GCEConfig, targetPool)
 
 	if err != nil {
-		logrus.Errorf("Error creating target pool %v", err)
+		sglog.FromContext(ctx).Errorf("Error creating target pool %v", err)
 		return errors.Wrapf(err, "%s creating target pool", CreateTargetPullStepName)
 	}
 
 	targetPool, err = svc.getTargetPool(ctx, config.GCEConfig, targetPoolName)
 
 	if err != nil {
-		logrus.Errorf("Error getting target pool %v", err)
+		sglog.FromContext(ctx).Errorf("Error getting target pool %v", err)
 		return errors.Wrapf(err, "%s getting target pool", CreateTargetPullStepName)
 	}
 
 	config.GCEConfig.TargetPoolName = targetPoolName
 	config.GCEConfig.TargetPoolLink = targetPool.SelfLink
-	logrus.Debugf("Created target pool name %s link %s",
+	sglog.FromContext(ctx).Debugf("Created target pool name %s link %s",
 		config.GCEConfig.TargetPoolName, config.GCEConfig.TargetPoolLink)
 	return nil
 }
@@ -7,9 +7,9 @@ import (
 	"io"
 	"time"
 
-	"github.com/sirupsen/logrus"
 	"google.golang.org/api/compute/v1"
 
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -46,12 +46,12 @@ func (s *DeleteBackendServiceStep) Run(ctx context.Context, output io.Writer,
 	config *steps.Config) error {
 
 	var err error
-	logrus.Debugf("Step %s", DeleteBackendServicStepName)
+	sglog.FromContext(ctx).Debugf("Step %s", DeleteBackendServicStepName)
 
 	svc, err := s.getComputeSvc(ctx, config.GCEConfig)
 
 	if err != nil {
-		logrus.Errorf("Error getting service %v", err)
+		sglog.FromContext(ctx).Errorf("Error getting service %v", err)
 		return errors.Wrapf(err, "%s getting service caused", DeleteBackendServicStepName)
 	}
 
@@ -60,7 +60,7 @@ func (s *DeleteBackendServiceStep) Run(ctx context.Context, output io.Writer,
 	for i := 0; i < s.AttemptCount; i++ {
 		_, err = svc.deleteBackendService(ctx, config.GCEConfig, config.GCEConfig.BackendServiceName)
 		if err != nil && !isNotFound(err) {
-			logrus.Errorf("Error deleting backend service rule %v", err)
+			sglog.FromContext(ctx).Errorf("Error deleting backend service rule %v", err)
 		} else {
 			break
 		}
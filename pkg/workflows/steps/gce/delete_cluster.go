@@ -7,9 +7,9 @@ import (
 	"io"
 
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	compute "google.golang.org/api/compute/v1"
 
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -50,7 +50,7 @@ func (s *DeleteClusterStep) Run(ctx context.Context, output io.Writer, config *s
 			continue
 		}
 
-		logrus.Debugf("Delete master %s in %s", master.Name, master.Region)
+		sglog.FromContext(ctx).Debugf("Delete master %s in %s", master.Name, master.Region)
 
 		_, serr := svc.deleteInstance(config.GCEConfig.ServiceAccount.ProjectID,
 			master.Region,
@@ -66,7 +66,7 @@ func (s *DeleteClusterStep) Run(ctx context.Context, output io.Writer, config *s
 			continue
 		}
 
-		logrus.Debugf("Delete node %s in %s", node.Name, node.Region)
+		sglog.FromContext(ctx).Debugf("Delete node %s in %s", node.Name, node.Region)
 		_, serr := svc.deleteInstance(config.GCEConfig.ServiceAccount.ProjectID,
 			node.Region,
 			node.Name)
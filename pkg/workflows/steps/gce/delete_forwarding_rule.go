@@ -9,9 +9,9 @@ import (
 
 	"github.com/pkg/errors"
 
-	"github.com/sirupsen/logrus"
 	"google.golang.org/api/compute/v1"
 
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -49,12 +49,12 @@ func NewDeleteForwardingRulesStep() *DeleteForwardingRulesStep {
 
 func (s *DeleteForwardingRulesStep) Run(ctx context.Context, output io.Writer,
 	config *steps.Config) error {
-	logrus.Debugf("Step %s", DeleteForwardingRulesStepName)
+	sglog.FromContext(ctx).Debugf("Step %s", DeleteForwardingRulesStepName)
 
 	svc, err := s.getComputeSvc(ctx, config.GCEConfig)
 
 	if err != nil {
-		logrus.Errorf("Error getting service %v", err)
+		sglog.FromContext(ctx).Errorf("Error getting service %v", err)
 		return errors.Wrapf(err, "%s getting service caused", DeleteForwardingRulesStepName)
 	}
 
@@ -62,7 +62,7 @@ func (s *DeleteForwardingRulesStep) Run(ctx context.Context, output io.Writer,
 		_, err = svc.deleteForwardingRule(ctx, config.GCEConfig, config.GCEConfig.ExternalForwardingRuleName)
 
 		if err != nil {
-			logrus.Errorf("Error deleting external forwarding rule  %s %v", config.GCEConfig.ExternalForwardingRuleName, err)
+			sglog.FromContext(ctx).Errorf("Error deleting external forwarding rule  %s %v", config.GCEConfig.ExternalForwardingRuleName, err)
 		}
 
 		_, err = svc.getForwardingRule(ctx, config.GCEConfig, config.GCEConfig.ExternalForwardingRuleName)
@@ -71,19 +71,19 @@ func (s *DeleteForwardingRulesStep) Run(ctx context.Context, output io.Writer,
 			break
 		}
 
-		logrus.Debugf("Forwarding rule %s still exists retry in %v",
+		sglog.FromContext(ctx).Debugf("Forwarding rule %s still exists retry in %v",
 			config.GCEConfig.ExternalForwardingRuleName, s.timeout)
 		time.Sleep(s.timeout)
 	}
 
-	logrus.Debugf("Forwarding rule %s has been deleted",
+	sglog.FromContext(ctx).Debugf("Forwarding rule %s has been deleted",
 		config.GCEConfig.ExternalForwardingRuleName)
 
 	for i := 0; i < s.attemptCount; i++ {
 		_, err = svc.deleteForwardingRule(ctx, config.GCEConfig, config.GCEConfig.InternalForwardingRuleName)
 
 		if err != nil {
-			logrus.Errorf("Error deleting internal forwarding rule %s rule %v", config.GCEConfig.InternalForwardingRuleName, err)
+			sglog.FromContext(ctx).Errorf("Error deleting internal forwarding rule %s rule %v", config.GCEConfig.InternalForwardingRuleName, err)
 		}
 
 		_, err = svc.getForwardingRule(ctx, config.GCEConfig, config.GCEConfig.InternalForwardingRuleName)
@@ -92,12 +92,12 @@ func (s *DeleteForwardingRulesStep) Run(ctx context.Context, output io.Writer,
 			break
 		}
 
-		logrus.Debugf("Forwarding rule %s still exists retry in %v",
+		sglog.FromContext(ctx).Debugf("Forwarding rule %s still exists retry in %v",
 			config.GCEConfig.InternalForwardingRuleName, s.timeout)
 		time.Sleep(s.timeout)
 	}
 
-	logrus.Debugf("Forwarding rule %s has been deleted",
+	sglog.FromContext(ctx).Debugf("Forwarding rule %s has been deleted",
 		config.GCEConfig.InternalForwardingRuleName)
 
 	return nil
@@ -8,9 +8,9 @@ import (
 
 	"github.com/pkg/errors"
 
-	"github.com/sirupsen/logrus"
 	"google.golang.org/api/compute/v1"
 
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -48,12 +48,12 @@ func NewDeleteInstanceGroupStep() (*DeleteInstanceGroupStep, error) {
 func (s *DeleteInstanceGroupStep) Run(ctx context.Context, output io.Writer,
 	config *steps.Config) error {
 
-	logrus.Debugf("Step %s", DeleteInstanceGroupStepName)
+	sglog.FromContext(ctx).Debugf("Step %s", DeleteInstanceGroupStepName)
 
 	svc, err := s.getComputeSvc(ctx, config.GCEConfig)
 
 	if err != nil {
-		logrus.Errorf("Error getting service %v", err)
+		sglog.FromContext(ctx).Errorf("Error getting service %v", err)
 		return errors.Wrapf(err, "%s getting service caused", DeleteInstanceGroupStepName)
 	}
 
@@ -72,7 +72,7 @@ func (s *DeleteInstanceGroupStep) Run(ctx context.Context, output io.Writer,
 			_, err = svc.deleteInstanceGroup(ctx, config.GCEConfig, instanceGroupName)
 
 			if err != nil {
-				logrus.Debugf("Error deleting instance group %s %v", instanceGroupName, err)
+				sglog.FromContext(ctx).Debugf("Error deleting instance group %s %v", instanceGroupName, err)
 			} else {
 				break
 			}
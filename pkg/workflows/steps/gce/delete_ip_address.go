@@ -7,9 +7,9 @@ import (
 
 	"github.com/pkg/errors"
 
-	"github.com/sirupsen/logrus"
 	"google.golang.org/api/compute/v1"
 
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -39,25 +39,25 @@ func NewDeleteIpAddressStep() *DeleteIpAddressStep {
 func (s *DeleteIpAddressStep) Run(ctx context.Context, output io.Writer,
 	config *steps.Config) error {
 
-	logrus.Debugf("Step %s", DeleteIpAddressStepName)
+	sglog.FromContext(ctx).Debugf("Step %s", DeleteIpAddressStepName)
 
 	svc, err := s.getComputeSvc(ctx, config.GCEConfig)
 
 	if err != nil {
-		logrus.Errorf("Error getting service %v", err)
+		sglog.FromContext(ctx).Errorf("Error getting service %v", err)
 		return errors.Wrapf(err, "%s getting service caused", DeleteIpAddressStepName)
 	}
 
 	_, err = svc.deleteIpAddress(ctx, config.GCEConfig, config.GCEConfig.ExternalAddressName)
 
 	if err != nil {
-		logrus.Errorf("Error deleting external address %s %v", config.GCEConfig.ExternalAddressName, err)
+		sglog.FromContext(ctx).Errorf("Error deleting external address %s %v", config.GCEConfig.ExternalAddressName, err)
 	}
 
 	_, err = svc.deleteIpAddress(ctx, config.GCEConfig, config.GCEConfig.ExternalAddressName)
 
 	if err != nil {
-		logrus.Errorf("Error deleting internal address %s %v", config.GCEConfig.InternalAddressName, err)
+		sglog.FromContext(ctx).Errorf("Error deleting internal address %s %v", config.GCEConfig.InternalAddressName, err)
 	}
 
 	return nil
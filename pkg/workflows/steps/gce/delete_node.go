@@ -7,9 +7,9 @@ import (
 	"io"
 
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"google.golang.org/api/compute/v1"
 
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -45,7 +45,7 @@ func (s *DeleteNodeStep) Run(ctx context.Context, output io.Writer, config *step
 		return errors.Wrapf(err, "%s get service", DeleteClusterStepName)
 	}
 
-	logrus.Debugf("Delete node %s in %s",
+	sglog.FromContext(ctx).Debugf("Delete node %s in %s",
 		config.Node.Name, config.Node.Region)
 	_, serr := svc.deleteInstance(config.GCEConfig.ServiceAccount.ProjectID,
 		config.Node.Region,
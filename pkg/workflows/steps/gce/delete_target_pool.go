@@ -6,7 +6,7 @@ import (
 	"io"
 
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/workflows/steps"
 	"google.golang.org/api/compute/v1"
 )
@@ -38,19 +38,19 @@ func NewDeleteTargetPoolStep() *DeleteTargetPoolStep {
 func (s *DeleteTargetPoolStep) Run(ctx context.Context, output io.Writer,
 	config *steps.Config) error {
 
-	logrus.Debugf("Step %s", DeleteTargetPoolStepName)
+	sglog.FromContext(ctx).Debugf("Step %s", DeleteTargetPoolStepName)
 
 	svc, err := s.getComputeSvc(ctx, config.GCEConfig)
 
 	if err != nil {
-		logrus.Errorf("Error getting service %v", err)
+		sglog.FromContext(ctx).Errorf("Error getting service %v", err)
 		return errors.Wrapf(err, "%s getting service caused", DeleteTargetPoolStepName)
 	}
 
 	_, err = svc.deleteTargetPool(ctx, config.GCEConfig, config.GCEConfig.TargetPoolName)
 
 	if err != nil {
-		logrus.Errorf("Error deleting target pool %v", err)
+		sglog.FromContext(ctx).Errorf("Error deleting target pool %v", err)
 	}
 
 	return nil
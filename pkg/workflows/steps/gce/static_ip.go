@@ -0,0 +1,264 @@
+package gce
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/compute/v1"
+
+	"github.com/supergiant/control/pkg/clouds/gcesdk"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sglog"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	AttachStaticIPStepName   = "gce_attach_static_ip"
+	ReleaseStaticIPsStepName = "gce_release_static_ips"
+
+	externalAccessConfigName = "External NAT"
+	defaultNetworkInterface  = "nic0"
+)
+
+// AttachStaticIPStep replaces the ephemeral external ip of the created master
+// with a reserved one, a free reserved ip of the cluster is taken before a
+// new one is reserved.
+type AttachStaticIPStep struct {
+	checkPeriod time.Duration
+	timeout     time.Duration
+
+	getComputeSvc func(context.Context, steps.GCEConfig) (*computeService, error)
+}
+
+func NewAttachStaticIPStep(checkPeriod, timeout time.Duration) *AttachStaticIPStep {
+	return &AttachStaticIPStep{
+		checkPeriod: checkPeriod,
+		timeout:     timeout,
+		getComputeSvc: func(ctx context.Context, config steps.GCEConfig) (*computeService, error) {
+			client, err := gcesdk.GetClient(ctx, config)
+			if err != nil {
+				return nil, err
+			}
+
+			return &computeService{
+				insertAddress: func(ctx context.Context, config steps.GCEConfig, address *compute.Address) (*compute.Operation, error) {
+					return client.Addresses.Insert(config.ServiceAccount.ProjectID, config.Region, address).Do()
+				},
+				getAddress: func(ctx context.Context, config steps.GCEConfig, addressName string) (*compute.Address, error) {
+					return client.Addresses.Get(config.ServiceAccount.ProjectID, config.Region, addressName).Do()
+				},
+				getInstance: func(ctx context.Context, config steps.GCEConfig, name string) (*compute.Instance, error) {
+					return client.Instances.Get(config.ServiceAccount.ProjectID,
+						config.AvailabilityZone, name).Do()
+				},
+				deleteAccessConfig: func(ctx context.Context, config steps.GCEConfig, name string) (*compute.Operation, error) {
+					return client.Instances.DeleteAccessConfig(config.ServiceAccount.ProjectID,
+						config.AvailabilityZone, name, externalAccessConfigName, defaultNetworkInterface).Do()
+				},
+				addAccessConfig: func(ctx context.Context, config steps.GCEConfig, name string, accessConfig *compute.AccessConfig) (*compute.Operation, error) {
+					return client.Instances.AddAccessConfig(config.ServiceAccount.ProjectID,
+						config.AvailabilityZone, name, defaultNetworkInterface, accessConfig).Do()
+				},
+			}, nil
+		},
+	}
+}
+
+func (s *AttachStaticIPStep) Run(ctx context.Context, output io.Writer, config *steps.Config) error {
+	logger := sglog.FromContext(ctx)
+
+	// the zone of the master is kept in the region of the node
+	gceConfig := config.GCEConfig
+	gceConfig.AvailabilityZone = config.Node.Region
+
+	svc, err := s.getComputeSvc(ctx, gceConfig)
+	if err != nil {
+		logger.Errorf("Error getting service %v", err)
+		return errors.Wrapf(err, "%s getting service caused", AttachStaticIPStepName)
+	}
+
+	if _, err = svc.deleteAccessConfig(ctx, gceConfig, config.Node.Name); err != nil {
+		return errors.Wrapf(err, "%s delete access config", AttachStaticIPStepName)
+	}
+	if err = s.waitForNatIP(ctx, svc, gceConfig, config.Node.Name, ""); err != nil {
+		return errors.Wrapf(err, "%s remove ephemeral ip", AttachStaticIPStepName)
+	}
+
+	ip := config.Kube.FreeStaticIP()
+	if ip != nil {
+		if err = s.attach(ctx, svc, gceConfig, config.Node.Name, ip.Address); err != nil {
+			// another master may have taken it
+			logger.Infof("attach reserved ip %s caused %v", ip.Address, err)
+			ip = nil
+		}
+	}
+
+	if ip == nil {
+		if ip, err = s.reserve(ctx, svc, gceConfig, fmt.Sprintf("master-ip-%s", config.Node.Name)); err != nil {
+			return errors.Wrapf(err, "%s reserve address", AttachStaticIPStepName)
+		}
+		if err = s.attach(ctx, svc, gceConfig, config.Node.Name, ip.Address); err != nil {
+			return errors.Wrapf(err, "%s attach address %s", AttachStaticIPStepName, ip.Address)
+		}
+	}
+
+	logger.Debugf("reserved ip %s is attached to %s", ip.Address, config.Node.Name)
+	config.Node.StaticIP = ip
+	config.Node.PublicIp = ip.Address
+	config.NodeChan() <- config.Node
+	config.AddMaster(&config.Node)
+
+	return nil
+}
+
+func (s *AttachStaticIPStep) reserve(ctx context.Context, svc *computeService, config steps.GCEConfig, name string) (*model.StaticIP, error) {
+	_, err := svc.insertAddress(ctx, config, &compute.Address{
+		Name:        name,
+		Description: "Static IP address of the master",
+		AddressType: "EXTERNAL",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ticker := time.NewTicker(s.checkPeriod)
+	defer ticker.Stop()
+	after := time.After(s.timeout)
+
+	for {
+		select {
+		case <-ticker.C:
+			address, err := svc.getAddress(ctx, config, name)
+			if err == nil && address.Address != "" {
+				return &model.StaticIP{
+					ID:      address.Name,
+					Address: address.Address,
+				}, nil
+			}
+		case <-after:
+			return nil, errors.Errorf("address %s isn't reserved in %v", name, s.timeout)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (s *AttachStaticIPStep) attach(ctx context.Context, svc *computeService, config steps.GCEConfig, instance, address string) error {
+	_, err := svc.addAccessConfig(ctx, config, instance, &compute.AccessConfig{
+		Type:  "ONE_TO_ONE_NAT",
+		Name:  externalAccessConfigName,
+		NatIP: address,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.waitForNatIP(ctx, svc, config, instance, address)
+}
+
+// waitForNatIP waits until the external ip of the instance is the address,
+// the empty address means the instance has no external ip.
+func (s *AttachStaticIPStep) waitForNatIP(ctx context.Context, svc *computeService, config steps.GCEConfig, instance, address string) error {
+	ticker := time.NewTicker(s.checkPeriod)
+	defer ticker.Stop()
+	after := time.After(s.timeout)
+
+	for {
+		select {
+		case <-ticker.C:
+			resp, err := svc.getInstance(ctx, config, instance)
+			if err == nil && natIP(resp) == address {
+				return nil
+			}
+		case <-after:
+			return errors.Errorf("external ip of %s isn't %q in %v", instance, address, s.timeout)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func natIP(instance *compute.Instance) string {
+	if instance == nil || len(instance.NetworkInterfaces) == 0 {
+		return ""
+	}
+	for _, accessConfig := range instance.NetworkInterfaces[0].AccessConfigs {
+		if accessConfig.NatIP != "" {
+			return accessConfig.NatIP
+		}
+	}
+	return ""
+}
+
+func (s *AttachStaticIPStep) Name() string {
+	return AttachStaticIPStepName
+}
+
+func (s *AttachStaticIPStep) Depends() []string {
+	return nil
+}
+
+func (s *AttachStaticIPStep) Description() string {
+	return "Attach a reserved ip address to the master"
+}
+
+func (s *AttachStaticIPStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+// ReleaseStaticIPsStep deletes reserved ip addresses of the masters.
+type ReleaseStaticIPsStep struct {
+	getComputeSvc func(context.Context, steps.GCEConfig) (*computeService, error)
+}
+
+func NewReleaseStaticIPsStep() *ReleaseStaticIPsStep {
+	return &ReleaseStaticIPsStep{
+		getComputeSvc: func(ctx context.Context, config steps.GCEConfig) (*computeService, error) {
+			client, err := gcesdk.GetClient(ctx, config)
+			if err != nil {
+				return nil, err
+			}
+
+			return &computeService{
+				deleteIpAddress: func(ctx context.Context, config steps.GCEConfig, addressName string) (*compute.Operation, error) {
+					return client.Addresses.Delete(config.ServiceAccount.ProjectID, config.Region, addressName).Do()
+				},
+			}, nil
+		},
+	}
+}
+
+func (s *ReleaseStaticIPsStep) Run(ctx context.Context, output io.Writer, config *steps.Config) error {
+	svc, err := s.getComputeSvc(ctx, config.GCEConfig)
+	if err != nil {
+		sglog.FromContext(ctx).Errorf("Error getting service %v", err)
+		return errors.Wrapf(err, "%s getting service caused", ReleaseStaticIPsStepName)
+	}
+
+	for _, ip := range config.Kube.AllStaticIPs() {
+		if _, err = svc.deleteIpAddress(ctx, config.GCEConfig, ip.ID); err != nil && !isNotFound(err) {
+			sglog.FromContext(ctx).Errorf("Error deleting address %s %v", ip.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *ReleaseStaticIPsStep) Name() string {
+	return ReleaseStaticIPsStepName
+}
+
+func (s *ReleaseStaticIPsStep) Depends() []string {
+	return nil
+}
+
+func (s *ReleaseStaticIPsStep) Description() string {
+	return "Delete reserved ip addresses of masters"
+}
+
+func (s *ReleaseStaticIPsStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
@@ -0,0 +1,116 @@
+package gracefuldelete
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/template"
+	"time"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/runner"
+	tm "github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/ssh"
+)
+
+const (
+	StepName = "graceful_delete"
+
+	DefaultStepTimeout = 5 * time.Minute
+)
+
+// phases are run one by one on a master, every one is limited by the step
+// timeout of the delete config.
+var phases = []string{"drain", "services", "volumes"}
+
+type Config struct {
+	Phase string
+	// Timeout is in seconds.
+	Timeout int
+}
+
+// Step releases cloud resources of workloads through kubernetes before the
+// infrastructure of the cluster is deleted. It never fails: the deletion is
+// forced when a master can't be reached or a phase fails or times out.
+type Step struct {
+	script    *template.Template
+	getRunner func(model.Machine, *steps.Config) (runner.Runner, error)
+}
+
+func Init() {
+	tpl, err := tm.GetTemplate(StepName)
+
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", StepName))
+	}
+
+	steps.RegisterStep(StepName, New(tpl))
+}
+
+func New(script *template.Template) *Step {
+	return &Step{
+		script:    script,
+		getRunner: ssh.NewRunner,
+	}
+}
+
+func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	if config.DeleteConfig.Mode != steps.DeleteGraceful || config.DryRun || config.Simulated() {
+		return nil
+	}
+
+	log := util.GetLogger(out)
+	master := config.GetMaster()
+	if master == nil {
+		log.Warnf("[%s] - no active master, deletion is forced", s.Name())
+		return nil
+	}
+
+	r, err := s.getRunner(*master, config)
+	if err != nil {
+		log.Warnf("[%s] - connect to master %s caused %v, deletion is forced", s.Name(), master.Name, err)
+		return nil
+	}
+
+	timeout := config.DeleteConfig.StepTimeout
+	if timeout <= 0 {
+		timeout = DefaultStepTimeout
+	}
+
+	for _, phase := range phases {
+		log.Infof("[%s] - %s, timeout %v", s.Name(), phase, timeout)
+
+		// the script keeps the deadline, the context stops hung commands
+		phaseCtx, cancel := context.WithTimeout(ctx, timeout+time.Minute)
+		err = steps.RunTemplate(phaseCtx, s.script, r, out, Config{
+			Phase:   phase,
+			Timeout: int(timeout.Seconds()),
+		})
+		cancel()
+
+		if err != nil {
+			log.Warnf("[%s] - %s caused %v, deletion is forced", s.Name(), phase, err)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (s *Step) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *Step) Name() string {
+	return StepName
+}
+
+func (s *Step) Description() string {
+	return "Drain nodes and release load balancers and volumes of workloads"
+}
+
+func (s *Step) Depends() []string {
+	return nil
+}
@@ -0,0 +1,101 @@
+package gracefuldelete
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func newTestStep(t *testing.T, r runner.Runner, runnerErr error) *Step {
+	err := templatemanager.Init("../../../../templates")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(StepName)
+
+	if tpl == nil {
+		t.Fatal("template not found")
+	}
+
+	s := New(tpl)
+	s.getRunner = func(model.Machine, *steps.Config) (runner.Runner, error) {
+		return r, runnerErr
+	}
+	return s
+}
+
+func newTestConfig(mode string) *steps.Config {
+	return &steps.Config{
+		DeleteConfig: steps.DeleteConfig{
+			Mode:        mode,
+			StepTimeout: 90 * time.Second,
+		},
+		Masters: steps.NewMap(map[string]*model.Machine{
+			"master-1": {Name: "master-1", State: model.MachineStateActive},
+		}),
+	}
+}
+
+func TestGracefulDelete(t *testing.T) {
+	output := &bytes.Buffer{}
+	step := newTestStep(t, &testutils.MockRunner{}, nil)
+
+	if err := step.Run(context.Background(), output, newTestConfig(steps.DeleteGraceful)); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, expected := range []string{
+		"kubectl drain --selector='!node-role.kubernetes.io/master'",
+		"--timeout=90s",
+		"wait_gone lb_services",
+		"kubectl delete persistentvolumeclaims --all --all-namespaces",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("%s not found in output %s", expected, output.String())
+		}
+	}
+}
+
+func TestGracefulDeleteFallsBackToForce(t *testing.T) {
+	output := &bytes.Buffer{}
+	step := newTestStep(t, &testutils.MockRunner{Err: errors.New("timeout")}, nil)
+
+	if err := step.Run(context.Background(), output, newTestConfig(steps.DeleteGraceful)); err != nil {
+		t.Fatalf("failed phase must not fail the deletion %v", err)
+	}
+	if !strings.Contains(output.String(), "drain caused timeout, deletion is forced") {
+		t.Errorf("fallback not found in output %s", output.String())
+	}
+
+	output.Reset()
+	step = newTestStep(t, nil, errors.New("unreachable"))
+	if err := step.Run(context.Background(), output, newTestConfig(steps.DeleteGraceful)); err != nil {
+		t.Fatalf("unreachable master must not fail the deletion %v", err)
+	}
+	if !strings.Contains(output.String(), "deletion is forced") {
+		t.Errorf("fallback not found in output %s", output.String())
+	}
+}
+
+func TestForceDelete(t *testing.T) {
+	output := &bytes.Buffer{}
+	step := newTestStep(t, &testutils.MockRunner{}, nil)
+
+	if err := step.Run(context.Background(), output, newTestConfig(steps.DeleteForce)); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if output.Len() != 0 {
+		t.Errorf("nothing must be run %s", output.String())
+	}
+}
@@ -0,0 +1,87 @@
+package hardening
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/sglog"
+	tm "github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/poststart"
+)
+
+const (
+	StepName = "hardening"
+
+	defaultEventRecordQPS                 = 5
+	defaultStreamingConnectionIdleTimeout = "5m"
+)
+
+type Config struct {
+	IsMaster                       bool
+	EventRecordQPS                 int
+	StreamingConnectionIdleTimeout string
+}
+
+// Step applies CIS Kubernetes Benchmark settings to a provisioned node.
+type Step struct {
+	script *template.Template
+}
+
+func Init() {
+	tpl, err := tm.GetTemplate(StepName)
+
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", StepName))
+	}
+
+	steps.RegisterStep(StepName, New(tpl))
+}
+
+func New(script *template.Template) *Step {
+	return &Step{
+		script: script,
+	}
+}
+
+func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	if !config.Kube.CISHardening {
+		sglog.FromContext(ctx).Debugf("%s: cis hardening is disabled for cluster %s, skip", StepName, config.Kube.ID)
+		return nil
+	}
+
+	err := steps.RunTemplate(ctx, s.script, config.Runner, out, toStepCfg(config))
+	if err != nil {
+		return errors.Wrap(err, "cis hardening step")
+	}
+
+	return nil
+}
+
+func (s *Step) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *Step) Name() string {
+	return StepName
+}
+
+func (s *Step) Description() string {
+	return "Apply CIS Kubernetes Benchmark settings"
+}
+
+func (s *Step) Depends() []string {
+	return []string{poststart.StepName}
+}
+
+func toStepCfg(c *steps.Config) Config {
+	return Config{
+		IsMaster:                       c.IsMaster,
+		EventRecordQPS:                 defaultEventRecordQPS,
+		StreamingConnectionIdleTimeout: defaultStreamingConnectionIdleTimeout,
+	}
+}
@@ -0,0 +1,120 @@
+package hardening
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/poststart"
+)
+
+func TestHardening(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(StepName)
+
+	if tpl == nil {
+		t.Fatal("template not found")
+	}
+
+	output := &bytes.Buffer{}
+	config := &steps.Config{
+		IsMaster: true,
+		Kube: model.Kube{
+			CISHardening: true,
+		},
+		Runner: &testutils.MockRunner{},
+	}
+
+	task := New(tpl)
+	err = task.Run(context.Background(), output, config)
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, expected := range []string{
+		"readOnlyPort: 0",
+		"protectKernelDefaults: true",
+		"chmod 700 /var/lib/etcd",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("%s not found in output %s", expected, output.String())
+		}
+	}
+}
+
+func TestHardeningDisabled(t *testing.T) {
+	output := &bytes.Buffer{}
+	config := &steps.Config{
+		Runner: &testutils.MockRunner{
+			Err: errors.New("must not be called"),
+		},
+	}
+
+	task := New(template.Must(template.New(StepName).Parse("echo hardening")))
+	err := task.Run(context.Background(), output, config)
+
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	if output.Len() != 0 {
+		t.Errorf("output must be empty, actual %s", output.String())
+	}
+}
+
+func TestHardeningError(t *testing.T) {
+	errMsg := "error has occurred"
+	config := &steps.Config{
+		Kube: model.Kube{
+			CISHardening: true,
+		},
+		Runner: &testutils.MockRunner{
+			Err: errors.New(errMsg),
+		},
+	}
+
+	task := New(template.Must(template.New(StepName).Parse("echo hardening")))
+	err := task.Run(context.Background(), ioutil.Discard, config)
+
+	if err == nil {
+		t.Fatal("error must not be nil")
+	}
+
+	if !strings.Contains(err.Error(), errMsg) {
+		t.Errorf("Error message expected to contain %s actual %s", errMsg, err.Error())
+	}
+}
+
+func TestStep_Depends(t *testing.T) {
+	s := Step{}
+
+	if len(s.Depends()) != 1 || s.Depends()[0] != poststart.StepName {
+		t.Errorf("Wrong dependency list %v expected %v", s.Depends(), []string{poststart.StepName})
+	}
+}
+
+func TestInit(t *testing.T) {
+	templatemanager.SetTemplate(StepName, &template.Template{})
+	Init()
+	templatemanager.DeleteTemplate(StepName)
+
+	s := steps.GetStep(StepName)
+
+	if s == nil {
+		t.Error("Step not found")
+	}
+}
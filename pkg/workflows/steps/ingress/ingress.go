@@ -0,0 +1,232 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/kubeconfig"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sglog"
+	tm "github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/tiller"
+	"github.com/supergiant/control/pkg/workflows/steps/upload"
+)
+
+const (
+	StepName = "ingress"
+
+	valuesFile = "/etc/supergiant/ingress-values.yaml"
+
+	endpointTimeout  = 10 * time.Minute
+	endpointInterval = 10 * time.Second
+)
+
+// values are uploaded as is
+var valuesTpl = template.Must(template.New("values").Parse("{{ . }}"))
+
+// Controller is a chart of an ingress controller and the service that
+// gets the load balancer of the provider.
+type Controller struct {
+	Chart     string
+	Release   string
+	Namespace string
+	Service   string
+	// values of the chart with the service annotations
+	values func(rbac bool, annotations map[string]string) map[string]interface{}
+}
+
+var controllers = map[string]Controller{
+	profile.IngressNginx: {
+		Chart:     "stable/nginx-ingress",
+		Release:   "nginx-ingress",
+		Namespace: "ingress-nginx",
+		Service:   "nginx-ingress-controller",
+		values: func(rbac bool, annotations map[string]string) map[string]interface{} {
+			return map[string]interface{}{
+				"rbac": map[string]interface{}{"create": rbac},
+				"controller": map[string]interface{}{
+					"publishService": map[string]interface{}{"enabled": true},
+					"service": map[string]interface{}{
+						"type":        "LoadBalancer",
+						"annotations": annotations,
+					},
+				},
+			}
+		},
+	},
+	profile.IngressTraefik: {
+		Chart:     "stable/traefik",
+		Release:   "traefik",
+		Namespace: "ingress-traefik",
+		Service:   "traefik",
+		values: func(rbac bool, annotations map[string]string) map[string]interface{} {
+			return map[string]interface{}{
+				"rbac":        map[string]interface{}{"enabled": rbac},
+				"serviceType": "LoadBalancer",
+				"service":     map[string]interface{}{"annotations": annotations},
+				"kubernetes":  map[string]interface{}{"ingressEndpoint": map[string]interface{}{"useDefaultPublishedService": true}},
+			}
+		},
+	},
+}
+
+type Config struct {
+	Controller
+	ValuesFile string
+}
+
+// Step installs the ingress controller of the profile and saves the
+// endpoint of its load balancer to the kube.
+type Step struct {
+	script    *template.Template
+	newClient func(k *model.Kube) (corev1client.CoreV1Interface, error)
+
+	timeout  time.Duration
+	interval time.Duration
+}
+
+func Init() {
+	tpl, err := tm.GetTemplate(StepName)
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", StepName))
+	}
+
+	steps.RegisterStep(StepName, New(tpl))
+}
+
+func New(script *template.Template) *Step {
+	return &Step{
+		script:    script,
+		newClient: kubeconfig.CoreV1Client,
+		timeout:   endpointTimeout,
+		interval:  endpointInterval,
+	}
+}
+
+func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	ing := config.Kube.Ingress
+	if ing.Controller == "" {
+		return nil
+	}
+
+	c, ok := controllers[ing.Controller]
+	if !ok {
+		return errors.Errorf("%s step: unknown controller %q", StepName, ing.Controller)
+	}
+
+	values, err := yaml.Marshal(c.values(config.Kube.RBACEnabled, Annotations(config.Provider, ing.Annotations)))
+	if err != nil {
+		return errors.Wrapf(err, "%s step: marshal values", StepName)
+	}
+
+	err = upload.Write(ctx, out, config, upload.File{
+		Path:    valuesFile,
+		Mode:    0644,
+		Content: valuesTpl,
+		Data: func(*steps.Config) interface{} {
+			return string(values)
+		},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "%s step", StepName)
+	}
+
+	err = steps.RunTemplate(ctx, s.script, config.Runner, out, Config{Controller: c, ValuesFile: valuesFile})
+	if err != nil {
+		return errors.Wrap(err, "install ingress controller")
+	}
+
+	// simulated clusters have no load balancer services
+	if config.Simulated() {
+		return nil
+	}
+
+	endpoint, err := s.waitEndpoint(ctx, config, c)
+	if err != nil {
+		// the load balancer may come up later, the cluster is usable anyway
+		sglog.FromContext(ctx).Warnf("%s step: endpoint of %s: %v", StepName, ing.Controller, err)
+		return nil
+	}
+	config.Kube.IngressEndpoint = endpoint
+
+	return nil
+}
+
+func (s *Step) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *Step) Name() string {
+	return StepName
+}
+
+func (s *Step) Description() string {
+	return "Install the ingress controller"
+}
+
+func (s *Step) Depends() []string {
+	return []string{tiller.StepName}
+}
+
+// Annotations returns annotations of the controller service, the load
+// balancer defaults of the provider are overridden by the profile ones.
+func Annotations(provider clouds.Name, custom map[string]string) map[string]string {
+	annotations := make(map[string]string)
+	for k, v := range clouds.LoadBalancerAnnotations(provider) {
+		annotations[k] = v
+	}
+	for k, v := range custom {
+		annotations[k] = v
+	}
+	return annotations
+}
+
+// waitEndpoint waits until the provider assigns the load balancer to the
+// controller service.
+func (s *Step) waitEndpoint(ctx context.Context, config *steps.Config, c Controller) (string, error) {
+	k := config.Kube
+	if len(k.Masters) == 0 {
+		k.Masters = config.GetMasters()
+	}
+	client, err := s.newClient(&k)
+	if err != nil {
+		return "", errors.Wrap(err, "get kube client")
+	}
+
+	timeout := time.After(s.timeout)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		svc, err := client.Services(c.Namespace).Get(c.Service, metav1.GetOptions{})
+		if err == nil {
+			for _, ing := range svc.Status.LoadBalancer.Ingress {
+				if ing.Hostname != "" {
+					return ing.Hostname, nil
+				}
+				if ing.IP != "" {
+					return ing.IP, nil
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-timeout:
+			return "", errors.Errorf("load balancer of %s/%s isn't ready in %s", c.Namespace, c.Service, s.timeout)
+		case <-ticker.C:
+		}
+	}
+}
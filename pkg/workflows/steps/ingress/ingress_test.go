@@ -0,0 +1,98 @@
+package ingress
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestAnnotations(t *testing.T) {
+	a := Annotations(clouds.AWS, map[string]string{
+		"service.beta.kubernetes.io/aws-load-balancer-type":     "elb",
+		"service.beta.kubernetes.io/aws-load-balancer-internal": "true",
+	})
+	require.Equal(t, map[string]string{
+		"service.beta.kubernetes.io/aws-load-balancer-type":     "elb",
+		"service.beta.kubernetes.io/aws-load-balancer-internal": "true",
+	}, a)
+
+	require.Equal(t, "tcp", Annotations(clouds.DigitalOcean, nil)["service.beta.kubernetes.io/do-loadbalancer-protocol"])
+	require.Empty(t, Annotations(clouds.GCE, nil))
+}
+
+func TestStep_Run(t *testing.T) {
+	require.NoError(t, templatemanager.Init("../../../../templates"))
+	tpl, err := templatemanager.GetTemplate(StepName)
+	require.NoError(t, err)
+
+	clientset := fake.NewSimpleClientset(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx-ingress-controller", Namespace: "ingress-nginx"},
+		Status: corev1.ServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{Hostname: "lb.elb.amazonaws.com"}},
+			},
+		},
+	})
+
+	s := New(tpl)
+	s.newClient = func(*model.Kube) (corev1client.CoreV1Interface, error) {
+		return clientset.CoreV1(), nil
+	}
+	s.interval = time.Millisecond
+
+	cfg := &steps.Config{
+		Provider: clouds.AWS,
+		Runner:   &testutils.MockRunner{},
+		Kube: model.Kube{
+			Ingress: profile.Ingress{Controller: profile.IngressNginx},
+		},
+	}
+
+	out := &bytes.Buffer{}
+	require.NoError(t, s.Run(context.Background(), out, cfg))
+	require.Contains(t, out.String(), "helm upgrade --install nginx-ingress stable/nginx-ingress")
+	require.Contains(t, out.String(), "--namespace ingress-nginx")
+	require.Equal(t, "lb.elb.amazonaws.com", cfg.Kube.IngressEndpoint)
+}
+
+func TestStep_RunNoEndpoint(t *testing.T) {
+	require.NoError(t, templatemanager.Init("../../../../templates"))
+	tpl, err := templatemanager.GetTemplate(StepName)
+	require.NoError(t, err)
+
+	s := New(tpl)
+	s.newClient = func(*model.Kube) (corev1client.CoreV1Interface, error) {
+		return fake.NewSimpleClientset().CoreV1(), nil
+	}
+	s.timeout, s.interval = 10*time.Millisecond, time.Millisecond
+
+	cfg := &steps.Config{
+		Runner: &testutils.MockRunner{},
+		Kube: model.Kube{
+			Ingress: profile.Ingress{Controller: profile.IngressTraefik},
+		},
+	}
+
+	require.NoError(t, s.Run(context.Background(), &bytes.Buffer{}, cfg), "a pending load balancer doesn't fail provisioning")
+	require.Empty(t, cfg.Kube.IngressEndpoint)
+}
+
+func TestStep_RunNoController(t *testing.T) {
+	out := &bytes.Buffer{}
+	require.NoError(t, New(nil).Run(context.Background(), out, &steps.Config{}))
+	require.Empty(t, out.String())
+}
@@ -10,9 +10,22 @@ import (
 
 	tm "github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/upload"
 )
 
-const StepName = "install_app"
+const (
+	StepName = "install_app"
+
+	valuesFile = "/etc/supergiant/helm/override.yaml"
+)
+
+// values are uploaded as is, they often have characters special to shell
+var valuesTpl = template.Must(template.New("values").Parse("{{ .Values }}"))
+
+type Config struct {
+	steps.InstallAppConfig
+	ValuesFile string
+}
 
 type Step struct {
 	script *template.Template
@@ -41,7 +54,22 @@ func New(script *template.Template) *Step {
 }
 
 func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
-	err := steps.RunTemplate(ctx, s.script, config.Runner, out, config.InstallAppConfig)
+	err := upload.Write(ctx, out, config, upload.File{
+		Path:    valuesFile,
+		Mode:    0600,
+		Content: valuesTpl,
+		Data: func(c *steps.Config) interface{} {
+			return c.InstallAppConfig
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "install app step")
+	}
+
+	err = steps.RunTemplate(ctx, s.script, config.Runner, out, Config{
+		InstallAppConfig: config.InstallAppConfig,
+		ValuesFile:       valuesFile,
+	})
 
 	if err != nil {
 		return errors.Wrap(err, "install app step")
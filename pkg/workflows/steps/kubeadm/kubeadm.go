@@ -4,12 +4,16 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 	"text/template"
 
+	"github.com/Masterminds/semver"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
 	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/sglog"
 	tm "github.com/supergiant/control/pkg/templatemanager"
 	"github.com/supergiant/control/pkg/workflows/steps"
 	"github.com/supergiant/control/pkg/workflows/steps/docker"
@@ -17,8 +21,18 @@ import (
 
 const (
 	StepName = "kubeadm"
+
+	defaultAuditLevel     = "Metadata"
+	defaultAuditMaxAge    = 30
+	defaultAuditMaxBackup = 10
+	defaultAuditMaxSize   = 100
+
+	kubeSystemNamespace = "kube-system"
 )
 
+// podSecurityV1Version is the first release of the v1 pod security admission configuration.
+var podSecurityV1Version = semver.MustParse("1.25.0")
+
 type Config struct {
 	K8SVersion      string
 	KubeadmVersion  string
@@ -36,6 +50,25 @@ type Config struct {
 	APIServerPort   int64
 	NodeIp          string
 	ProviderID      string
+
+	AuditEnabled    bool
+	AuditLevel      string
+	AuditMaxAge     int
+	AuditMaxBackup  int
+	AuditMaxSize    int
+	AuditWebhookURL string
+
+	CISHardening bool
+
+	// Comma separated admission plugins of kube-apiserver.
+	EnableAdmissionPlugins  string
+	DisableAdmissionPlugins string
+	// PodSecurity is nil if the kubernetes version has no PodSecurity admission.
+	PodSecurity           *profile.PodSecurity
+	PodSecurityAPIVersion string
+
+	APIServerSANs []string
+	EtcdSANs      []string
 }
 
 type Step struct {
@@ -61,7 +94,7 @@ func New(script *template.Template) *Step {
 }
 
 func (t *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
-	logrus.Debugf("kubeadm step: %s cluster: isBootstrap=%t extDNS=%s intDNS=%s",
+	sglog.FromContext(ctx).Debugf("kubeadm step: %s cluster: isBootstrap=%t extDNS=%s intDNS=%s",
 		config.Kube.ID, config.IsBootstrap, config.Kube.ExternalDNSName,
 		config.Kube.InternalDNSName)
 
@@ -113,6 +146,8 @@ func toProviderID(cloudName clouds.Name, id string) string {
 }
 
 func toStepCfg(c *steps.Config) Config {
+	audit := withAuditDefaults(c.Kube.AuditLog)
+
 	return Config{
 		KubeadmVersion:  "1.15.1", // TODO(stgleb): get it from available versions once we have them
 		K8SVersion:      c.Kube.K8SVersion,
@@ -120,7 +155,7 @@ func toStepCfg(c *steps.Config) Config {
 		IsMaster:        c.IsMaster,
 		InternalDNSName: c.Kube.InternalDNSName,
 		ExternalDNSName: c.Kube.ExternalDNSName,
-		Token:           c.Kube.BootstrapToken,
+		Token:           c.JoinToken(),
 		CACertHash:      c.Kube.Auth.CACertHash,
 		CertificateKey:  c.Kube.Auth.CertificateKey,
 		CIDR:            c.Kube.Networking.CIDR,
@@ -130,5 +165,97 @@ func toStepCfg(c *steps.Config) Config {
 		APIServerPort:   c.Kube.APIServerPort,
 		NodeIp:          c.Node.PrivateIp,
 		ProviderID:      toProviderID(c.Kube.Provider, c.Node.ID),
+		AuditEnabled:    audit.Enabled,
+		AuditLevel:      audit.Level,
+		AuditMaxAge:     audit.MaxAge,
+		AuditMaxBackup:  audit.MaxBackup,
+		AuditMaxSize:    audit.MaxSize,
+		AuditWebhookURL: audit.WebhookURL,
+		CISHardening:    c.Kube.CISHardening,
+		APIServerSANs:   c.Kube.Certificates.APIServerSANs,
+		EtcdSANs:        c.Kube.Certificates.EtcdSANs,
+
+		EnableAdmissionPlugins:  strings.Join(admissionPlugins(c.Kube), ","),
+		DisableAdmissionPlugins: strings.Join(c.Kube.Admission.DisablePlugins, ","),
+		PodSecurity:             withPodSecurityDefaults(c.Kube.K8SVersion, c.Kube.Admission.PodSecurity),
+		PodSecurityAPIVersion:   podSecurityAPIVersion(c.Kube.K8SVersion),
+	}
+}
+
+// admissionPlugins returns plugins enabled by CIS hardening and the profile.
+func admissionPlugins(k model.Kube) []string {
+	plugins := make([]string, 0)
+	if k.CISHardening {
+		plugins = append(plugins, "NodeRestriction", "AlwaysPullImages")
+	}
+
+	for _, p := range k.Admission.EnablePlugins {
+		if !contains(plugins, p) {
+			plugins = append(plugins, p)
+		}
+	}
+	return plugins
+}
+
+// withPodSecurityDefaults enforces the baseline standard and warns about
+// pods that violate the restricted one unless the profile says otherwise.
+func withPodSecurityDefaults(k8sVersion string, p profile.PodSecurity) *profile.PodSecurity {
+	if !profile.SupportsPodSecurity(k8sVersion) {
+		return nil
+	}
+
+	if p.Defaults.Enforce == "" {
+		p.Defaults.Enforce = profile.LevelBaseline
+	}
+	if p.Defaults.Audit == "" {
+		p.Defaults.Audit = profile.LevelRestricted
+	}
+	if p.Defaults.Warn == "" {
+		p.Defaults.Warn = profile.LevelRestricted
+	}
+
+	exempt := []string{kubeSystemNamespace}
+	for _, ns := range p.ExemptNamespaces {
+		if !contains(exempt, ns) {
+			exempt = append(exempt, ns)
+		}
+	}
+	p.ExemptNamespaces = exempt
+
+	return &p
+}
+
+func podSecurityAPIVersion(k8sVersion string) string {
+	v, err := semver.NewVersion(k8sVersion)
+	if err == nil && !v.LessThan(podSecurityV1Version) {
+		return "v1"
+	}
+	return "v1beta1"
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func withAuditDefaults(a profile.AuditLog) profile.AuditLog {
+	switch a.Level {
+	case "Metadata", "Request", "RequestResponse":
+	default:
+		a.Level = defaultAuditLevel
+	}
+	if a.MaxAge == 0 {
+		a.MaxAge = defaultAuditMaxAge
+	}
+	if a.MaxBackup == 0 {
+		a.MaxBackup = defaultAuditMaxBackup
+	}
+	if a.MaxSize == 0 {
+		a.MaxSize = defaultAuditMaxSize
 	}
+	return a
 }
@@ -14,6 +14,7 @@ import (
 
 	"github.com/supergiant/control/pkg/clouds"
 	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
 	"github.com/supergiant/control/pkg/runner"
 	"github.com/supergiant/control/pkg/sgerrors"
 	"github.com/supergiant/control/pkg/templatemanager"
@@ -84,6 +85,109 @@ func TestKubeadm(t *testing.T) {
 	}
 }
 
+func TestKubeadmAuditLog(t *testing.T) {
+	r := &fakeRunner{}
+	err := templatemanager.Init("../../../../templates")
+	require.Nil(t, err)
+
+	tpl, _ := templatemanager.GetTemplate(StepName)
+	require.NotNil(t, tpl)
+
+	output := new(bytes.Buffer)
+	cfg := &steps.Config{
+		IsMaster:    true,
+		IsBootstrap: true,
+		Kube: model.Kube{
+			AuditLog: profile.AuditLog{
+				Enabled:    true,
+				Level:      "RequestResponse",
+				WebhookURL: "https://audit.example.com/events",
+			},
+		},
+		Runner: r,
+	}
+
+	task := &Step{
+		tpl,
+	}
+
+	err = task.Run(context.Background(), output, cfg)
+	require.Nil(t, err)
+
+	for _, expected := range []string{
+		"level: RequestResponse",
+		"audit-policy-file: /etc/kubernetes/audit/policy.yaml",
+		"server: https://audit.example.com/events",
+		"audit-webhook-config-file",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("%s not found in %s", expected, output.String())
+		}
+	}
+}
+
+func TestWithAuditDefaults(t *testing.T) {
+	a := withAuditDefaults(profile.AuditLog{
+		Enabled: true,
+		Level:   "unknown",
+		MaxAge:  7,
+	})
+
+	require.Equal(t, defaultAuditLevel, a.Level)
+	require.Equal(t, 7, a.MaxAge)
+	require.Equal(t, defaultAuditMaxBackup, a.MaxBackup)
+	require.Equal(t, defaultAuditMaxSize, a.MaxSize)
+}
+
+func TestKubeadmAdmission(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+	require.Nil(t, err)
+
+	tpl, _ := templatemanager.GetTemplate(StepName)
+	require.NotNil(t, tpl)
+
+	output := new(bytes.Buffer)
+	cfg := &steps.Config{
+		IsMaster:    true,
+		IsBootstrap: true,
+		Kube: model.Kube{
+			K8SVersion:   "1.23.4",
+			CISHardening: true,
+			Admission: profile.Admission{
+				EnablePlugins:  []string{"EventRateLimit", "NodeRestriction"},
+				DisablePlugins: []string{"DefaultStorageClass"},
+				PodSecurity: profile.PodSecurity{
+					Namespaces: map[string]profile.PodSecurityLevels{
+						"apps": {Enforce: profile.LevelRestricted},
+					},
+				},
+			},
+		},
+		Runner: &fakeRunner{},
+	}
+
+	err = (&Step{tpl}).Run(context.Background(), output, cfg)
+	require.Nil(t, err)
+
+	for _, expected := range []string{
+		"enable-admission-plugins: NodeRestriction,AlwaysPullImages,EventRateLimit",
+		"disable-admission-plugins: DefaultStorageClass",
+		"admission-control-config-file: /etc/kubernetes/admission/config.yaml",
+		"apiVersion: pod-security.admission.config.k8s.io/v1beta1",
+		"enforce: baseline",
+		"      - kube-system",
+		"label namespace apps --overwrite pod-security.kubernetes.io/enforce=restricted\n",
+	} {
+		require.Contains(t, output.String(), expected)
+	}
+
+	output.Reset()
+	cfg.Kube.K8SVersion = "1.15.1"
+	err = (&Step{tpl}).Run(context.Background(), output, cfg)
+	require.Nil(t, err)
+	require.NotContains(t, output.String(), "PodSecurity")
+}
+
 func TestStartKubeadmError(t *testing.T) {
 	errMsg := "error has occurred"
 
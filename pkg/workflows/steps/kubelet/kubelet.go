@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"sort"
+	"strings"
 	"text/template"
 
 	"github.com/pkg/errors"
@@ -41,6 +43,11 @@ type Config struct {
 	AdminKey  string `json:"adminKey"`
 	CACert    string `json:"caCert"`
 	CAKey     string `json:"caKey"`
+
+	// Node specific kubelet settings
+	ExtraArgs  map[string]string `json:"extraArgs"`
+	NodeLabels string            `json:"nodeLabels"`
+	NodeTaints string            `json:"nodeTaints"`
 }
 
 type Step struct {
@@ -116,8 +123,22 @@ func toStepCfg(c *steps.Config) (Config, error) {
 		AdminCert:        c.Kube.Auth.AdminCert,
 		AdminKey:         c.Kube.Auth.AdminKey,
 		APIServerPort:    c.Kube.APIServerPort,
-		UserName:         c.Kube.SSHConfig.User,
+		UserName:         c.Kube.SSHConfig.For(c.Node).User,
 		ServicesCIDR:     c.Kube.ServicesCIDR,
 		KubernetesSvcIP:  svcIP.String(),
+		ExtraArgs:        c.KubeletConfig.ExtraArgs,
+		NodeLabels:       joinLabels(c.KubeletConfig.Labels),
+		NodeTaints:       strings.Join(c.KubeletConfig.Taints, ","),
 	}, nil
 }
+
+func joinLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	// keep the rendered unit stable
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
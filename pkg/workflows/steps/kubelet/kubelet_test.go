@@ -61,6 +61,47 @@ func TestStartKubelet(t *testing.T) {
 	}
 }
 
+func TestStartKubeletNodeSettings(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(StepName)
+	output := new(bytes.Buffer)
+
+	cfg := &steps.Config{
+		Runner: &fakeRunner{},
+		KubeletConfig: steps.KubeletConfig{
+			ExtraArgs: map[string]string{
+				"max-pods": "60",
+			},
+			Labels: map[string]string{
+				"gpu":      "true",
+				"disktype": "ssd",
+			},
+			Taints: []string{"nvidia.com/gpu=true:NoSchedule"},
+		},
+	}
+
+	err = New(tpl).Run(context.Background(), output, cfg)
+
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	for _, expected := range []string{
+		"--node-labels=disktype=ssd,gpu=true",
+		"--register-with-taints=nvidia.com/gpu=true:NoSchedule",
+		"--max-pods=60",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("%s not found in output %s", expected, output.String())
+		}
+	}
+}
+
 func TestStartKubeletError(t *testing.T) {
 	errMsg := "error has occurred"
 
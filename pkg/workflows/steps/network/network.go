@@ -19,6 +19,8 @@ type Config struct {
 	IsBootstrap     bool
 	CIDR            string
 	NetworkProvider string
+	// WindowsNodes makes flannel vxlan compatible with the windows overlay
+	WindowsNodes bool
 }
 
 type Step struct {
@@ -75,5 +77,6 @@ func toStepCfg(c *steps.Config) Config {
 		IsBootstrap:     c.IsBootstrap,
 		CIDR:            c.Kube.Networking.CIDR,
 		NetworkProvider: c.Kube.Networking.Provider,
+		WindowsNodes:    c.Kube.WinRMConfig.Password != "",
 	}
 }
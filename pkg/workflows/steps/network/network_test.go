@@ -110,6 +110,46 @@ func TestNetworkConfig(t *testing.T) {
 	}
 }
 
+func TestNetworkWindowsNodes(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(StepName)
+
+	if tpl == nil {
+		t.Fatal("template not found")
+	}
+
+	config, err := steps.NewConfig("", "", profile.Profile{})
+
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	output := &bytes.Buffer{}
+	config.Kube.Networking = model.Networking{
+		Provider: "Flannel",
+	}
+	config.Kube.WinRMConfig.Password = "secret"
+	config.Runner = &testutils.MockRunner{}
+	config.IsBootstrap = true
+
+	task := &Step{
+		script: tpl,
+	}
+
+	if err := task.Run(context.Background(), output, config); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if !strings.Contains(output.String(), `"VNI": 4096`) {
+		t.Errorf("windows compatible vxlan settings not found in output %s", output.String())
+	}
+}
+
 func TestNetworkErrors(t *testing.T) {
 	errMsg := "error has occurred"
 
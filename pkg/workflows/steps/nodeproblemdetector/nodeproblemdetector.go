@@ -0,0 +1,94 @@
+package nodeproblemdetector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	tm "github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	StepName = "node-problem-detector"
+
+	// Namespace node-problem-detector is installed to.
+	Namespace = "kube-system"
+
+	image = "k8s.gcr.io/node-problem-detector/node-problem-detector:v0.8.7"
+)
+
+// Monitors are configs of the image that report conditions of nodes, e.g.
+// KernelDeadlock, ReadonlyFilesystem and ContainerRuntimeUnhealthy.
+var (
+	SystemLogMonitors = []string{
+		"/config/kernel-monitor.json",
+		"/config/docker-monitor.json",
+	}
+	CustomPluginMonitors = []string{
+		"/config/health-checker-kubelet.json",
+		"/config/health-checker-docker.json",
+	}
+)
+
+type Config struct {
+	Namespace            string
+	Image                string
+	SystemLogMonitors    string
+	CustomPluginMonitors string
+}
+
+// Step installs node-problem-detector that reports problems of nodes as
+// their conditions.
+type Step struct {
+	script *template.Template
+}
+
+func Init() {
+	tpl, err := tm.GetTemplate(StepName)
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", StepName))
+	}
+
+	steps.RegisterStep(StepName, New(tpl))
+}
+
+func New(script *template.Template) *Step {
+	return &Step{
+		script: script,
+	}
+}
+
+func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	cfg := Config{
+		Namespace:            Namespace,
+		Image:                image,
+		SystemLogMonitors:    strings.Join(SystemLogMonitors, ","),
+		CustomPluginMonitors: strings.Join(CustomPluginMonitors, ","),
+	}
+
+	if err := steps.RunTemplate(ctx, s.script, config.Runner, out, cfg); err != nil {
+		return errors.Wrap(err, "install node-problem-detector")
+	}
+	return nil
+}
+
+func (s *Step) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *Step) Name() string {
+	return StepName
+}
+
+func (s *Step) Description() string {
+	return "Install node-problem-detector"
+}
+
+func (s *Step) Depends() []string {
+	return nil
+}
@@ -0,0 +1,25 @@
+package nodeproblemdetector
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestStep_Run(t *testing.T) {
+	require.NoError(t, templatemanager.Init("../../../../templates"))
+	tpl, err := templatemanager.GetTemplate(StepName)
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, New(tpl).Run(context.Background(), out, &steps.Config{Runner: &testutils.MockRunner{}}))
+	require.Contains(t, out.String(), "image: "+image)
+	require.Contains(t, out.String(), "--config.system-log-monitor=/config/kernel-monitor.json,/config/docker-monitor.json\n")
+	require.Contains(t, out.String(), "rollout status daemonset/node-problem-detector")
+}
@@ -0,0 +1,150 @@
+package ospatch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/sglog"
+	tm "github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/evacuate"
+)
+
+const (
+	StepName     = "os_patch"
+	waitTplName  = "os_patch_wait"
+	rebootMarker = "reboot scheduled, boot id "
+
+	bootIDScript = "cat /proc/sys/kernel/random/boot_id"
+)
+
+var (
+	rebootTimeout = 10 * time.Minute
+	pollInterval  = 10 * time.Second
+
+	markerRegexp = regexp.MustCompile(rebootMarker + `([0-9a-f-]+)`)
+)
+
+type Config struct {
+	LivePatch    bool
+	RebootMarker string
+	PrivateIP    string
+}
+
+// Step upgrades os packages of a machine, reboots it when needed and
+// waits until the node is ready again.
+type Step struct {
+	script *template.Template
+	wait   *template.Template
+}
+
+func Init() {
+	tpl, err := tm.GetTemplate(StepName)
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", StepName))
+	}
+
+	waitTpl, err := tm.GetTemplate(waitTplName)
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", waitTplName))
+	}
+
+	steps.RegisterStep(StepName, New(tpl, waitTpl))
+}
+
+func New(script, wait *template.Template) *Step {
+	return &Step{
+		script: script,
+		wait:   wait,
+	}
+}
+
+func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	cfg := toStepCfg(config)
+
+	buf := &bytes.Buffer{}
+	err := steps.RunTemplate(ctx, s.script, config.Runner, io.MultiWriter(out, buf), cfg)
+	if err != nil {
+		return errors.Wrap(err, "os patch step")
+	}
+
+	if m := markerRegexp.FindStringSubmatch(buf.String()); m != nil {
+		sglog.FromContext(ctx).Infof("%s: wait for %s to reboot", StepName, config.Node.Name)
+		if err := waitReboot(ctx, config.Runner, out, m[1]); err != nil {
+			return errors.Wrapf(err, "wait for %s to reboot", config.Node.Name)
+		}
+	}
+
+	err = steps.RunTemplate(ctx, s.wait, config.Runner, out, cfg)
+	if err != nil {
+		return errors.Wrap(err, "wait for node to be ready")
+	}
+
+	return nil
+}
+
+func (s *Step) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *Step) Name() string {
+	return StepName
+}
+
+func (s *Step) Description() string {
+	return "Upgrade os packages and reboot"
+}
+
+func (s *Step) Depends() []string {
+	return []string{evacuate.StepName}
+}
+
+// waitReboot polls a machine until its boot id changes.
+func waitReboot(ctx context.Context, r runner.Runner, out io.Writer, bootID string) error {
+	ctx, cancel := context.WithTimeout(ctx, rebootTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "machine hasn't been rebooted")
+		case <-ticker.C:
+		}
+
+		buf := &bytes.Buffer{}
+		cmd, err := runner.NewCommand(ctx, bootIDScript, buf, out)
+		if err != nil {
+			return err
+		}
+
+		// connection errors are expected while a machine is rebooting
+		if err := r.Run(cmd); err != nil {
+			sglog.FromContext(ctx).Debugf("%s: get boot id: %v", StepName, err)
+			continue
+		}
+
+		if id := strings.TrimSpace(buf.String()); id != "" && id != bootID {
+			return nil
+		}
+	}
+}
+
+func toStepCfg(c *steps.Config) Config {
+	return Config{
+		LivePatch:    c.Kube.OSPatch.LivePatch,
+		RebootMarker: rebootMarker,
+		PrivateIP:    c.Node.PrivateIp,
+	}
+}
@@ -0,0 +1,173 @@
+package ospatch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/evacuate"
+)
+
+// fakeRunner reports a scheduled reboot and a new boot id after a few attempts.
+type fakeRunner struct {
+	bootIDs []string
+	scripts []string
+}
+
+func (f *fakeRunner) Run(cmd *runner.Command) error {
+	f.scripts = append(f.scripts, cmd.Script)
+
+	switch {
+	case cmd.Script == bootIDScript:
+		if len(f.bootIDs) == 0 {
+			return errors.New("connection refused")
+		}
+		id := f.bootIDs[0]
+		f.bootIDs = f.bootIDs[1:]
+		_, err := io.WriteString(cmd.Out, id)
+		return err
+	case strings.Contains(cmd.Script, "upgrade"):
+		_, err := io.WriteString(cmd.Out, rebootMarker+"1a2b-3c")
+		return err
+	}
+
+	return nil
+}
+
+func TestOSPatch(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(StepName)
+	waitTpl, _ := templatemanager.GetTemplate(waitTplName)
+
+	if tpl == nil || waitTpl == nil {
+		t.Fatal("template not found")
+	}
+
+	output := &bytes.Buffer{}
+	config := &steps.Config{
+		Kube: model.Kube{
+			OSPatch: model.OSPatch{LivePatch: true},
+		},
+		Node: model.Machine{
+			PrivateIp: "10.0.0.2",
+		},
+		Runner: &testutils.MockRunner{},
+	}
+
+	err = New(tpl, waitTpl).Run(context.Background(), output, config)
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, expected := range []string{
+		"apt-get -y",
+		"canonical-livepatch",
+		"grep 10.0.0.2",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("%s not found in output %s", expected, output.String())
+		}
+	}
+}
+
+func TestOSPatchReboot(t *testing.T) {
+	pollInterval = time.Millisecond
+
+	r := &fakeRunner{
+		bootIDs: []string{"1a2b-3c", "4d5e-6f"},
+	}
+	config := &steps.Config{
+		Runner: r,
+	}
+
+	s := New(template.Must(template.New(StepName).Parse("upgrade")),
+		template.Must(template.New(waitTplName).Parse("wait")))
+	err := s.Run(context.Background(), &bytes.Buffer{}, config)
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if len(r.bootIDs) != 0 {
+		t.Errorf("step must wait for the boot id to change")
+	}
+
+	if last := r.scripts[len(r.scripts)-1]; last != "wait" {
+		t.Errorf("step must wait for node after reboot, last script %s", last)
+	}
+}
+
+func TestOSPatchRebootTimeout(t *testing.T) {
+	pollInterval = time.Millisecond
+	rebootTimeout = time.Millisecond * 20
+	defer func() {
+		rebootTimeout = 10 * time.Minute
+	}()
+
+	config := &steps.Config{
+		Runner: &fakeRunner{},
+	}
+
+	s := New(template.Must(template.New(StepName).Parse("upgrade")),
+		template.Must(template.New(waitTplName).Parse("wait")))
+	err := s.Run(context.Background(), &bytes.Buffer{}, config)
+
+	if err == nil {
+		t.Errorf("error must not be nil")
+	}
+}
+
+func TestOSPatchError(t *testing.T) {
+	errMsg := "error has occurred"
+	config := &steps.Config{
+		Runner: &testutils.MockRunner{
+			Err: errors.New(errMsg),
+		},
+	}
+
+	s := New(template.Must(template.New(StepName).Parse("upgrade")),
+		template.Must(template.New(waitTplName).Parse("wait")))
+	err := s.Run(context.Background(), &bytes.Buffer{}, config)
+
+	if err == nil || !strings.Contains(err.Error(), errMsg) {
+		t.Errorf("Error message expected to contain %s actual %v", errMsg, err)
+	}
+}
+
+func TestStep_Depends(t *testing.T) {
+	s := Step{}
+
+	if len(s.Depends()) != 1 || s.Depends()[0] != evacuate.StepName {
+		t.Errorf("Wrong dependency list %v expected %v", s.Depends(), []string{evacuate.StepName})
+	}
+}
+
+func TestInit(t *testing.T) {
+	templatemanager.SetTemplate(StepName, &template.Template{})
+	templatemanager.SetTemplate(waitTplName, &template.Template{})
+	Init()
+	templatemanager.DeleteTemplate(StepName)
+	templatemanager.DeleteTemplate(waitTplName)
+
+	s := steps.GetStep(StepName)
+
+	if s == nil {
+		t.Error("Step not found")
+	}
+}
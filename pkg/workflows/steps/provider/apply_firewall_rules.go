@@ -0,0 +1,55 @@
+package provider
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/amazon"
+)
+
+const (
+	ApplyFirewallRulesStepName = "apply_firewall_rules"
+)
+
+// ApplyFirewallRules changes ingress rules of the cluster in the cloud,
+// only security groups of AWS clusters are managed by control.
+type ApplyFirewallRules struct {
+}
+
+func (s ApplyFirewallRules) Run(ctx context.Context, out io.Writer, cfg *steps.Config) error {
+	if cfg == nil {
+		return errors.New("invalid config")
+	}
+
+	var step steps.Step
+
+	switch cfg.Provider {
+	case clouds.AWS:
+		step = steps.GetStep(amazon.StepApplyFirewallRules)
+	default:
+		return errors.Wrapf(sgerrors.ErrUnsupportedProvider, "firewall rules on %s", cfg.Provider)
+	}
+
+	return step.Run(ctx, out, cfg)
+}
+
+func (s ApplyFirewallRules) Name() string {
+	return ApplyFirewallRulesStepName
+}
+
+func (s ApplyFirewallRules) Description() string {
+	return ApplyFirewallRulesStepName
+}
+
+func (s ApplyFirewallRules) Depends() []string {
+	return nil
+}
+
+func (s ApplyFirewallRules) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
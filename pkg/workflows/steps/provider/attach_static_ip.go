@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/amazon"
+	"github.com/supergiant/control/pkg/workflows/steps/digitalocean"
+	"github.com/supergiant/control/pkg/workflows/steps/gce"
+)
+
+const (
+	AttachStaticIPStepName = "attach_static_ip"
+)
+
+// AttachStaticIP attaches a static ip to the created master when the kube
+// is configured to keep master addresses across instance replacement.
+type AttachStaticIP struct {
+}
+
+func (s AttachStaticIP) Run(ctx context.Context, out io.Writer, cfg *steps.Config) error {
+	if cfg == nil {
+		return errors.New("invalid config")
+	}
+	if !cfg.IsMaster || !cfg.Kube.StaticIPs.Masters || cfg.DryRun {
+		return nil
+	}
+
+	var step steps.Step
+
+	switch cfg.Provider {
+	case clouds.AWS:
+		step = steps.GetStep(amazon.StepAttachStaticIP)
+	case clouds.DigitalOcean:
+		step = steps.GetStep(digitalocean.AttachStaticIPStepName)
+	case clouds.GCE:
+		step = steps.GetStep(gce.AttachStaticIPStepName)
+	default:
+		return errors.Wrapf(fmt.Errorf("static ips aren't supported on %s", cfg.Provider), AttachStaticIPStepName)
+	}
+
+	return step.Run(ctx, out, cfg)
+}
+
+func (s AttachStaticIP) Name() string {
+	return AttachStaticIPStepName
+}
+
+func (s AttachStaticIP) Description() string {
+	return AttachStaticIPStepName
+}
+
+func (s AttachStaticIP) Depends() []string {
+	return nil
+}
+
+func (s AttachStaticIP) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
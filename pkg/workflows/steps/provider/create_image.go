@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/amazon"
+)
+
+const (
+	CreateImageStep = "createImage"
+)
+
+// StepCreateImage bakes a machine image of the created machine.
+type StepCreateImage struct {
+}
+
+func (s StepCreateImage) Run(ctx context.Context, out io.Writer, cfg *steps.Config) error {
+	if cfg == nil {
+		return errors.New("invalid config")
+	}
+
+	step, err := createImageStepFor(cfg.Provider)
+	if err != nil {
+		return err
+	}
+	if step == nil {
+		return errors.Wrap(sgerrors.ErrRawError, "createImage step not found")
+	}
+
+	return step.Run(ctx, out, cfg)
+}
+
+func (s StepCreateImage) Name() string {
+	return CreateImageStep
+}
+
+func (s StepCreateImage) Description() string {
+	return CreateImageStep
+}
+
+func (s StepCreateImage) Depends() []string {
+	return nil
+}
+
+func (s StepCreateImage) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+// SupportsImages reports whether images can be baked on the provider.
+func SupportsImages(provider clouds.Name) bool {
+	_, err := createImageStepFor(provider)
+	return err == nil
+}
+
+func createImageStepFor(provider clouds.Name) (steps.Step, error) {
+	switch provider {
+	case clouds.AWS:
+		return steps.GetStep(amazon.StepCreateImage), nil
+	}
+	return nil, errors.Wrapf(sgerrors.ErrUnsupportedProvider, "bake images on %s", provider)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/supergiant/control/pkg/workflows/steps/amazon"
 	"github.com/supergiant/control/pkg/workflows/steps/azure"
 	"github.com/supergiant/control/pkg/workflows/steps/digitalocean"
+	"github.com/supergiant/control/pkg/workflows/steps/fake"
 	"github.com/supergiant/control/pkg/workflows/steps/gce"
 )
 
@@ -70,6 +71,8 @@ func createMachineStepFor(provider clouds.Name) (steps.Step, error) {
 		return steps.GetStep(gce.CreateInstanceStepName), nil
 	case clouds.Azure:
 		return steps.GetStep(azure.CreateVMStepName), nil
+	case clouds.Fake:
+		return steps.GetStep(fake.CreateMachineStepName), nil
 	}
 	return nil, errors.New(fmt.Sprintf("unknown provider: %s", provider))
 }
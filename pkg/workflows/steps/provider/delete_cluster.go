@@ -8,10 +8,12 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/sgerrors"
 	"github.com/supergiant/control/pkg/workflows/steps"
 	"github.com/supergiant/control/pkg/workflows/steps/amazon"
 	"github.com/supergiant/control/pkg/workflows/steps/azure"
 	"github.com/supergiant/control/pkg/workflows/steps/digitalocean"
+	"github.com/supergiant/control/pkg/workflows/steps/fake"
 	"github.com/supergiant/control/pkg/workflows/steps/gce"
 )
 
@@ -26,6 +28,12 @@ func (s DeleteCluster) Run(ctx context.Context, out io.Writer, cfg *steps.Config
 	if cfg == nil {
 		return errors.New("invalid config")
 	}
+	if cfg.Kube.Protection.Protected {
+		return errors.Wrapf(sgerrors.ErrProtected, "cluster %s", cfg.Kube.Name)
+	}
+	if name := cfg.Kube.ProtectedMachine(); name != "" {
+		return errors.Wrapf(sgerrors.ErrProtected, "machine %s", name)
+	}
 
 	steps, err := cleanUpStepsFor(cfg.Provider)
 	if err != nil {
@@ -33,9 +41,20 @@ func (s DeleteCluster) Run(ctx context.Context, out io.Writer, cfg *steps.Config
 	}
 	for _, s := range steps {
 		if err = s.Run(ctx, out, cfg); err != nil {
-			return errors.Wrap(err, DeleteClusterStepName)
+			break
+		}
+	}
+
+	// resources are verified after a failed clean up too, the report tells
+	// what has to be deleted manually
+	if verify := verifyStepFor(cfg.Provider); verify != nil {
+		if verifyErr := verify.Run(ctx, out, cfg); verifyErr != nil && err == nil {
+			err = verifyErr
 		}
 	}
+	if err != nil {
+		return errors.Wrap(err, DeleteClusterStepName)
+	}
 
 	return nil
 }
@@ -63,7 +82,9 @@ func cleanUpStepsFor(provider clouds.Name) ([]steps.Step, error) {
 		return []steps.Step{
 			steps.GetStep(amazon.DeleteClusterMachinesStepName),
 			steps.GetStep(amazon.DeleteLoadBalancerStepName),
+			steps.GetStep(amazon.DeleteServiceLoadBalancersStepName),
 			steps.GetStep(amazon.DeleteSecurityGroupsStepName),
+			steps.GetStep(amazon.DeleteOrphanedResourcesStepName),
 			steps.GetStep(amazon.DisassociateRouteTableStepName),
 			steps.GetStep(amazon.DeleteSubnetsStepName),
 			steps.GetStep(amazon.DeleteRouteTableStepName),
@@ -74,12 +95,14 @@ func cleanUpStepsFor(provider clouds.Name) ([]steps.Step, error) {
 	case clouds.DigitalOcean:
 		return []steps.Step{
 			steps.GetStep(digitalocean.DeleteClusterMachines),
+			steps.GetStep(digitalocean.ReleaseStaticIPsStepName),
 			steps.GetStep(digitalocean.DeleteDeleteKeysStepName),
 			steps.GetStep(digitalocean.DeleteLoadBalancerStepName),
 		}, nil
 	case clouds.GCE:
 		return []steps.Step{
 			steps.GetStep(gce.DeleteClusterStepName),
+			steps.GetStep(gce.ReleaseStaticIPsStepName),
 			steps.GetStep(gce.DeleteForwardingRulesStepName),
 			steps.GetStep(gce.DeleteBackendServicStepName),
 			steps.GetStep(gce.DeleteTargetPoolStepName),
@@ -91,6 +114,20 @@ func cleanUpStepsFor(provider clouds.Name) ([]steps.Step, error) {
 			steps.GetStep(azure.GetAuthorizerStepName),
 			steps.GetStep(azure.DeleteClusterStepName),
 		}, nil
+	case clouds.Fake:
+		return []steps.Step{
+			steps.GetStep(fake.DeleteClusterStepName),
+		}, nil
 	}
 	return nil, errors.New(fmt.Sprintf("unknown provider: %s", provider))
 }
+
+// verifyStepFor returns the step that reports resources which are left
+// after the clean up, it's nil if the provider has none.
+func verifyStepFor(provider clouds.Name) steps.Step {
+	switch provider {
+	case clouds.AWS:
+		return steps.GetStep(amazon.VerifyClusterDeletedStepName)
+	}
+	return nil
+}
@@ -8,10 +8,12 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/sgerrors"
 	"github.com/supergiant/control/pkg/workflows/steps"
 	"github.com/supergiant/control/pkg/workflows/steps/amazon"
 	"github.com/supergiant/control/pkg/workflows/steps/azure"
 	"github.com/supergiant/control/pkg/workflows/steps/digitalocean"
+	"github.com/supergiant/control/pkg/workflows/steps/fake"
 	"github.com/supergiant/control/pkg/workflows/steps/gce"
 )
 
@@ -26,6 +28,9 @@ func (s StepDeleteMachine) Run(ctx context.Context, out io.Writer, cfg *steps.Co
 	if cfg == nil {
 		return errors.New("invalid config")
 	}
+	if cfg.Node.Protected {
+		return errors.Wrapf(sgerrors.ErrProtected, "machine %s", cfg.Node.Name)
+	}
 
 	step, err := deleteMachineStepFor(cfg.Provider)
 	if err != nil {
@@ -61,6 +66,8 @@ func deleteMachineStepFor(provider clouds.Name) (steps.Step, error) {
 		return steps.GetStep(gce.DeleteNodeStepName), nil
 	case clouds.Azure:
 		return steps.GetStep(azure.DeleteVMStepName), nil
+	case clouds.Fake:
+		return steps.GetStep(fake.DeleteMachineStepName), nil
 	}
 	return nil, errors.New(fmt.Sprintf("unknown provider: %s", provider))
 }
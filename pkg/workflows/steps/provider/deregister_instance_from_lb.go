@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/amazon"
+)
+
+const (
+	DeregisterInstanceStepName = "deregister_instance"
+)
+
+// DeregisterInstanceFromLoadBalancer takes a master out of load balancers
+// of the cluster before it's deleted.
+type DeregisterInstanceFromLoadBalancer struct {
+}
+
+func (s *DeregisterInstanceFromLoadBalancer) Run(ctx context.Context, out io.Writer, cfg *steps.Config) error {
+	if cfg == nil {
+		return errors.New("invalid config")
+	}
+
+	var step steps.Step
+
+	switch cfg.Provider {
+	case clouds.AWS:
+		step = steps.GetStep(amazon.DeregisterInstanceStepName)
+	case clouds.DigitalOcean:
+		// Load balancing in DO is made by tags, deleted droplets leave it
+		return nil
+	case clouds.GCE:
+		return nil
+	case clouds.Azure:
+		return nil
+	case clouds.Fake:
+		return nil
+	default:
+		return errors.Wrapf(fmt.Errorf("unknown provider: %s", cfg.Provider), DeregisterInstanceStepName)
+	}
+
+	return step.Run(ctx, out, cfg)
+}
+
+func (s *DeregisterInstanceFromLoadBalancer) Name() string {
+	return DeregisterInstanceStepName
+}
+
+func (s *DeregisterInstanceFromLoadBalancer) Description() string {
+	return DeregisterInstanceStepName
+}
+
+func (s *DeregisterInstanceFromLoadBalancer) Depends() []string {
+	return nil
+}
+
+func (s *DeregisterInstanceFromLoadBalancer) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/amazon"
+)
+
+const (
+	FindWindowsImageStep = "findWindowsImage"
+)
+
+// StepFindWindowsImage selects a Windows Server image of the provider
+// for a node that is going to be created.
+type StepFindWindowsImage struct {
+}
+
+func (s StepFindWindowsImage) Run(ctx context.Context, out io.Writer, cfg *steps.Config) error {
+	if cfg == nil {
+		return errors.New("invalid config")
+	}
+
+	if cfg.DryRun {
+		return nil
+	}
+
+	step, err := findWindowsImageStepFor(cfg.Provider)
+	if err != nil {
+		return err
+	}
+	if step == nil {
+		return errors.Wrap(sgerrors.ErrRawError, "findWindowsImage step not found")
+	}
+
+	return step.Run(ctx, out, cfg)
+}
+
+func (s StepFindWindowsImage) Name() string {
+	return FindWindowsImageStep
+}
+
+func (s StepFindWindowsImage) Description() string {
+	return FindWindowsImageStep
+}
+
+func (s StepFindWindowsImage) Depends() []string {
+	return nil
+}
+
+func (s StepFindWindowsImage) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func findWindowsImageStepFor(provider clouds.Name) (steps.Step, error) {
+	switch provider {
+	case clouds.AWS:
+		return steps.GetStep(amazon.StepFindWindowsAMI), nil
+	}
+	return nil, errors.Errorf("windows nodes are not supported on %s", provider)
+}
@@ -63,6 +63,8 @@ func postStartCluster(provider clouds.Name) ([]steps.Step, error) {
 		return []steps.Step{}, nil
 	case clouds.Azure:
 		return []steps.Step{}, nil
+	case clouds.Fake:
+		return []steps.Step{}, nil
 	case clouds.GCE:
 		// TODO(stgleb): Add non-bootstrap master instances to instance groups
 		return []steps.Step{}, nil
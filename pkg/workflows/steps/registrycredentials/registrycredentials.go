@@ -0,0 +1,102 @@
+package registrycredentials
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	tm "github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/docker"
+)
+
+const (
+	StepName = "registry_credentials"
+
+	// ACRConfigFile is read by the azure credential provider of kubelet.
+	ACRConfigFile = "/etc/kubernetes/acr.json"
+	acrConfigArg  = "azure-container-registry-config"
+)
+
+type Config struct {
+	ECR bool
+	GCR bool
+	ACR bool
+
+	ACRConfigFile  string
+	TenantID       string
+	SubscriptionID string
+}
+
+// Step prepares nodes for the credential providers built into kubelet, so
+// private images of the cloud registry are pulled with the identity of the
+// machine instead of imagePullSecrets.
+type Step struct {
+	script *template.Template
+}
+
+func Init() {
+	tpl, err := tm.GetTemplate(StepName)
+
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", StepName))
+	}
+
+	steps.RegisterStep(StepName, New(tpl))
+}
+
+func New(script *template.Template) *Step {
+	return &Step{
+		script: script,
+	}
+}
+
+func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	creds := config.Kube.RegistryCredentials
+	if !creds.IsSet() {
+		return nil
+	}
+
+	err := steps.RunTemplate(ctx, s.script, config.Runner, out, Config{
+		ECR:            creds.ECR,
+		GCR:            creds.GCR,
+		ACR:            creds.ACR,
+		ACRConfigFile:  ACRConfigFile,
+		TenantID:       config.AzureConfig.TenantID,
+		SubscriptionID: config.AzureConfig.SubscriptionID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "registry credentials step")
+	}
+
+	if creds.ACR {
+		// extra args may be shared with other machines of the node group
+		args := make(map[string]string, len(config.KubeletConfig.ExtraArgs)+1)
+		for k, v := range config.KubeletConfig.ExtraArgs {
+			args[k] = v
+		}
+		args[acrConfigArg] = ACRConfigFile
+		config.KubeletConfig.ExtraArgs = args
+	}
+
+	return nil
+}
+
+func (s *Step) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *Step) Name() string {
+	return StepName
+}
+
+func (s *Step) Description() string {
+	return "Configure credential providers of cloud registries"
+}
+
+func (s *Step) Depends() []string {
+	return []string{docker.StepName}
+}
@@ -0,0 +1,91 @@
+package registrycredentials
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestRegistryCredentials(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(StepName)
+
+	if tpl == nil {
+		t.Fatal("template not found")
+	}
+
+	output := &bytes.Buffer{}
+	shared := map[string]string{"v": "2"}
+	config := &steps.Config{
+		Kube: model.Kube{
+			RegistryCredentials: profile.RegistryCredentials{ACR: true},
+		},
+		AzureConfig: steps.AzureConfig{
+			TenantID:       "tenant",
+			SubscriptionID: "subscription",
+		},
+		KubeletConfig: steps.KubeletConfig{
+			ExtraArgs: shared,
+		},
+		Runner: &testutils.MockRunner{},
+	}
+
+	if err = New(tpl).Run(context.Background(), output, config); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, expected := range []string{
+		`\"subscriptionId\": \"subscription\"`,
+		`\"useManagedIdentityExtension\": true`,
+		"sudo chmod 600 " + ACRConfigFile,
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("%s not found in output %s", expected, output.String())
+		}
+	}
+	if strings.Contains(output.String(), "security-credentials") {
+		t.Errorf("ecr must not be checked %s", output.String())
+	}
+
+	if config.KubeletConfig.ExtraArgs[acrConfigArg] != ACRConfigFile || config.KubeletConfig.ExtraArgs["v"] != "2" {
+		t.Errorf("wrong kubelet args %v", config.KubeletConfig.ExtraArgs)
+	}
+	if len(shared) != 1 {
+		t.Errorf("kubelet args of the node group must not be changed %v", shared)
+	}
+}
+
+func TestRegistryCredentialsDisabled(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(StepName)
+
+	output := &bytes.Buffer{}
+	config := &steps.Config{
+		Runner: &testutils.MockRunner{},
+	}
+
+	if err = New(tpl).Run(context.Background(), output, config); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if output.Len() != 0 {
+		t.Errorf("nothing must be run %s", output.String())
+	}
+}
@@ -0,0 +1,82 @@
+package runtimeupgrade
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	tm "github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/evacuate"
+)
+
+const StepName = "runtime_upgrade"
+
+var versionRegexp = regexp.MustCompile(`^[0-9][0-9a-z.~+:-]*$`)
+
+type Config struct {
+	Version   string
+	PrivateIP string
+}
+
+// Step upgrades docker and containerd packages on a machine
+// and waits until the node is ready.
+type Step struct {
+	script *template.Template
+}
+
+func Init() {
+	tpl, err := tm.GetTemplate(StepName)
+
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", StepName))
+	}
+
+	steps.RegisterStep(StepName, New(tpl))
+}
+
+func New(script *template.Template) *Step {
+	return &Step{
+		script: script,
+	}
+}
+
+func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	if !versionRegexp.MatchString(config.Kube.DockerVersion) {
+		return errors.Errorf("invalid docker version %q", config.Kube.DockerVersion)
+	}
+
+	err := steps.RunTemplate(ctx, s.script, config.Runner, out, toStepCfg(config))
+	if err != nil {
+		return errors.Wrap(err, "upgrade container runtime step")
+	}
+
+	return nil
+}
+
+func (s *Step) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *Step) Name() string {
+	return StepName
+}
+
+func (s *Step) Description() string {
+	return "Upgrade container runtime"
+}
+
+func (s *Step) Depends() []string {
+	return []string{evacuate.StepName}
+}
+
+func toStepCfg(c *steps.Config) Config {
+	return Config{
+		Version:   c.Kube.DockerVersion,
+		PrivateIP: c.Node.PrivateIp,
+	}
+}
@@ -0,0 +1,113 @@
+package runtimeupgrade
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/evacuate"
+)
+
+func TestRuntimeUpgrade(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(StepName)
+
+	if tpl == nil {
+		t.Fatal("template not found")
+	}
+
+	output := &bytes.Buffer{}
+	config := &steps.Config{
+		Kube: model.Kube{
+			DockerVersion: "18.09.7",
+		},
+		Node: model.Machine{
+			PrivateIp: "10.0.0.2",
+		},
+		Runner: &testutils.MockRunner{},
+	}
+
+	err = New(tpl).Run(context.Background(), output, config)
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, expected := range []string{
+		"DOCKER_VERSION=18.09.7",
+		"--format '{{.Server.Version}}'",
+		"grep 10.0.0.2",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("%s not found in output %s", expected, output.String())
+		}
+	}
+}
+
+func TestRuntimeUpgradeInvalidVersion(t *testing.T) {
+	config := &steps.Config{
+		Kube: model.Kube{
+			DockerVersion: "18.09; reboot",
+		},
+		Runner: &testutils.MockRunner{},
+	}
+
+	err := New(template.Must(template.New(StepName).Parse("upgrade"))).
+		Run(context.Background(), ioutil.Discard, config)
+
+	if err == nil {
+		t.Errorf("error must not be nil")
+	}
+}
+
+func TestRuntimeUpgradeError(t *testing.T) {
+	errMsg := "error has occurred"
+	config := &steps.Config{
+		Kube: model.Kube{
+			DockerVersion: "18.09.7",
+		},
+		Runner: &testutils.MockRunner{
+			Err: errors.New(errMsg),
+		},
+	}
+
+	err := New(template.Must(template.New(StepName).Parse("upgrade"))).
+		Run(context.Background(), ioutil.Discard, config)
+
+	if err == nil || !strings.Contains(err.Error(), errMsg) {
+		t.Errorf("Error message expected to contain %s actual %v", errMsg, err)
+	}
+}
+
+func TestStep_Depends(t *testing.T) {
+	s := Step{}
+
+	if len(s.Depends()) != 1 || s.Depends()[0] != evacuate.StepName {
+		t.Errorf("Wrong dependency list %v expected %v", s.Depends(), []string{evacuate.StepName})
+	}
+}
+
+func TestInit(t *testing.T) {
+	templatemanager.SetTemplate(StepName, &template.Template{})
+	Init()
+	templatemanager.DeleteTemplate(StepName)
+
+	s := steps.GetStep(StepName)
+
+	if s == nil {
+		t.Error("Step not found")
+	}
+}
@@ -0,0 +1,67 @@
+package ssh
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/runner/ssh"
+	"github.com/supergiant/control/pkg/runner/ssm"
+	"github.com/supergiant/control/pkg/runner/winrm"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// NewRunner creates a runner that executes commands on the machine
+// with the runner type configured for the cluster, results of the
+// commands are collected in the config.
+func NewRunner(node model.Machine, config *steps.Config) (runner.Runner, error) {
+	r, err := newRunner(node, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return runner.NewRecorder(r, config.AddCommandResult), nil
+}
+
+func newRunner(node model.Machine, config *steps.Config) (runner.Runner, error) {
+	// windows machines are always managed through winrm
+	if node.OperatingSystem == model.OSWindows {
+		r, err := winrm.NewRunner(winrm.Config{
+			Host:     node.PublicIp,
+			Port:     config.Kube.WinRMConfig.Port,
+			User:     config.Kube.WinRMConfig.User,
+			Password: config.Kube.WinRMConfig.Password,
+			HTTPS:    true,
+			// listeners use self-signed certificates made on the first boot
+			Insecure: true,
+		})
+		return r, errors.Wrap(err, "create winrm runner")
+	}
+
+	switch config.Kube.RunnerType {
+	case runner.SSM:
+		r, err := ssm.NewRunner(ssm.Config{
+			InstanceID: node.ID,
+			Region:     config.AWSConfig.Region,
+			KeyID:      config.AWSConfig.KeyID,
+			Secret:     config.AWSConfig.Secret,
+			User:       config.Kube.SSHConfig.For(node).User,
+		})
+		return r, errors.Wrap(err, "create ssm runner")
+	case "", runner.SSH:
+		sshConfig := config.Kube.SSHConfig.For(node)
+		r, err := ssh.NewRunner(ssh.Config{
+			Host:    node.PublicIp,
+			Port:    sshConfig.Port,
+			User:    sshConfig.User,
+			Timeout: sshConfig.Timeout,
+			// TODO(stgleb): Use secure storage for private keys instead carrying them in plain text
+			Key:          []byte(sshConfig.BootstrapPrivateKey),
+			Escalation:   sshConfig.Escalation,
+			SudoPassword: sshConfig.SudoPassword,
+		})
+		return r, errors.Wrap(err, "create ssh runner")
+	}
+
+	return nil, errors.Errorf("unknown runner type %s", config.Kube.RunnerType)
+}
@@ -7,7 +7,6 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/supergiant/control/pkg/runner/dry"
-	"github.com/supergiant/control/pkg/runner/ssh"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -22,23 +21,27 @@ func Init() {
 func (s *Step) Run(ctx context.Context, writer io.Writer, config *steps.Config) error {
 	var err error
 
-	if config.DryRun {
+	// simulated machines can't be reached, their scripts are only rendered
+	if config.DryRun || config.Simulated() {
 		if config.Runner == nil {
 			config.Runner = dry.NewDryRunner()
 		}
 		return nil
 	}
 
-	cfg := ssh.Config{
-		Host:    config.Node.PublicIp,
-		Port:    config.Kube.SSHConfig.Port,
-		User:    config.Kube.SSHConfig.User,
-		Timeout: config.Kube.SSHConfig.Timeout,
-		// TODO(stgleb): Use secure storage for private keys instead carrying them in plain text
-		Key: []byte(config.Kube.SSHConfig.BootstrapPrivateKey),
+	// machines being created get ssh settings and the group of their node
+	// profile, so they are kept with the machine
+	if config.NodeSSHUser != "" && config.Node.SSHUser == "" {
+		config.Node.SSHUser = config.NodeSSHUser
+	}
+	if config.NodeSSHPort != "" && config.Node.SSHPort == "" {
+		config.Node.SSHPort = config.NodeSSHPort
+	}
+	if config.NodeGroup != "" && config.Node.NodeGroup == "" {
+		config.Node.NodeGroup = config.NodeGroup
 	}
 
-	config.Runner, err = ssh.NewRunner(cfg)
+	config.Runner, err = NewRunner(config.Node, config)
 	if err != nil {
 		return errors.Wrap(err, "ssh config step")
 	}
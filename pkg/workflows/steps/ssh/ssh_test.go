@@ -7,6 +7,9 @@ import (
 
 	"github.com/supergiant/control/pkg/model"
 	"github.com/supergiant/control/pkg/profile"
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/runner/ssm"
+	"github.com/supergiant/control/pkg/runner/winrm"
 	"github.com/supergiant/control/pkg/workflows/steps"
 )
 
@@ -147,3 +150,53 @@ func TestInit(t *testing.T) {
 		t.Error("Step not found")
 	}
 }
+
+func TestNewRunner(t *testing.T) {
+	config := &steps.Config{
+		Kube: model.Kube{
+			RunnerType: runner.SSM,
+		},
+		AWSConfig: steps.AWSConfig{
+			Region: "us-east-1",
+			KeyID:  "key",
+			Secret: "secret",
+		},
+	}
+
+	if _, err := NewRunner(model.Machine{}, config); err == nil {
+		t.Errorf("ssm runner requires an instance id")
+	}
+
+	r, err := NewRunner(model.Machine{ID: "i-1"}, config)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, ok := r.(*runner.Recorder).Runner.(*ssm.Runner); !ok {
+		t.Errorf("unexpected runner type %T", r)
+	}
+
+	config.Kube.RunnerType = "unknown"
+	if _, err := NewRunner(model.Machine{ID: "i-1"}, config); err == nil {
+		t.Errorf("error expected for unknown runner type")
+	}
+
+	windows := model.Machine{
+		PublicIp:        "10.20.30.40",
+		OperatingSystem: model.OSWindows,
+	}
+	if _, err := NewRunner(windows, config); err == nil {
+		t.Errorf("winrm runner requires credentials")
+	}
+
+	config.Kube.WinRMConfig = model.WinRMConfig{
+		User:     "Administrator",
+		Password: "secret",
+	}
+	r, err = NewRunner(windows, config)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, ok := r.(*runner.Recorder).Runner.(*winrm.Runner); !ok {
+		t.Errorf("unexpected runner type %T", r)
+	}
+}
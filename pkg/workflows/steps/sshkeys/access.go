@@ -0,0 +1,198 @@
+package sshkeys
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// AuthorizeStep authorizes an operator key on a machine, the private key of
+// an operator isn't known so the key isn't verified.
+type AuthorizeStep struct {
+	script *template.Template
+}
+
+func NewAuthorize(script *template.Template) *AuthorizeStep {
+	return &AuthorizeStep{
+		script: script,
+	}
+}
+
+func (s *AuthorizeStep) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	publicKey := config.SSHAccessConfig.PublicKey
+	if strings.TrimSpace(publicKey) == "" {
+		return errors.New("operator public key is not set")
+	}
+
+	err := steps.RunTemplate(ctx, s.script, config.Runner, out, Config{
+		User:      config.Kube.SSHConfig.For(config.Node).User,
+		PublicKey: strings.TrimSpace(publicKey),
+		KeyBody:   keyBody(publicKey),
+	})
+	if err != nil {
+		return errors.Wrap(err, "ssh key authorize step")
+	}
+
+	return nil
+}
+
+func (s *AuthorizeStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *AuthorizeStep) Name() string {
+	return AuthorizeStepName
+}
+
+func (s *AuthorizeStep) Description() string {
+	return "Authorize an operator ssh key"
+}
+
+func (s *AuthorizeStep) Depends() []string {
+	return nil
+}
+
+// RevokeStep revokes an operator key on a machine, the bootstrap key can only
+// be replaced by a rotation.
+type RevokeStep struct {
+	script *template.Template
+}
+
+func NewRevoke(script *template.Template) *RevokeStep {
+	return &RevokeStep{
+		script: script,
+	}
+}
+
+func (s *RevokeStep) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	cfg := Config{
+		User:       config.Kube.SSHConfig.For(config.Node).User,
+		KeyBody:    keyBody(config.Kube.SSHConfig.BootstrapPublicKey),
+		OldKeyBody: keyBody(config.SSHAccessConfig.PublicKey),
+	}
+	if cfg.OldKeyBody == "" {
+		return errors.New("operator public key is not set")
+	}
+	if cfg.OldKeyBody == cfg.KeyBody {
+		return errors.New("bootstrap key can't be revoked")
+	}
+
+	err := steps.RunTemplate(ctx, s.script, config.Runner, out, cfg)
+	if err != nil {
+		return errors.Wrap(err, "ssh key revoke step")
+	}
+
+	return nil
+}
+
+func (s *RevokeStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *RevokeStep) Name() string {
+	return RevokeStepName
+}
+
+func (s *RevokeStep) Description() string {
+	return "Revoke an operator ssh key"
+}
+
+func (s *RevokeStep) Depends() []string {
+	return nil
+}
+
+// AuditStep lists keys of authorized_keys files of the provisioning user
+// and root.
+type AuditStep struct {
+	script *template.Template
+}
+
+func NewAudit(script *template.Template) *AuditStep {
+	return &AuditStep{
+		script: script,
+	}
+}
+
+func (s *AuditStep) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	script := new(bytes.Buffer)
+	if err := s.script.Execute(script, Config{User: config.Kube.SSHConfig.For(config.Node).User}); err != nil {
+		return errors.Wrap(err, "render ssh key audit script")
+	}
+
+	// only stdout has keys, errors go to the task log
+	keys := new(bytes.Buffer)
+	cmd, err := runner.NewCommand(ctx, script.String(), keys, out)
+	if err != nil {
+		return errors.Wrap(err, "ssh key audit step")
+	}
+	if err = config.Runner.Run(cmd); err != nil {
+		return errors.Wrap(err, "list authorized keys")
+	}
+
+	config.SSHAccessConfig.Keys = ParseAuthorizedKeys(keys.String(), out)
+	return nil
+}
+
+func (s *AuditStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *AuditStep) Name() string {
+	return AuditStepName
+}
+
+func (s *AuditStep) Description() string {
+	return "List authorized ssh keys"
+}
+
+func (s *AuditStep) Depends() []string {
+	return nil
+}
+
+// ParseAuthorizedKeys parses lines of the audit script, every line is a file
+// and an authorized key of it. Lines that can't be parsed are reported to out,
+// keys that are repeated in a file are returned once.
+func ParseAuthorizedKeys(output string, out io.Writer) []model.AuthorizedKey {
+	keys := make([]model.AuthorizedKey, 0)
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+
+		key, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(fields[1]))
+		if err != nil {
+			fmt.Fprintf(out, "skip authorized key of %s: %v\n", fields[0], err)
+			continue
+		}
+
+		authorized := model.AuthorizedKey{
+			File:        fields[0],
+			Type:        key.Type(),
+			Fingerprint: ssh.FingerprintSHA256(key),
+			Comment:     comment,
+		}
+		if seen[authorized.File+authorized.Fingerprint] {
+			continue
+		}
+		seen[authorized.File+authorized.Fingerprint] = true
+		keys = append(keys, authorized)
+	}
+
+	return keys
+}
@@ -0,0 +1,109 @@
+package sshkeys
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	operatorKey         = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAILEScYmxoKdpFF5UfnDKJ0X09qaicLRBnBPilo7GdUDf alice@laptop"
+	operatorFingerprint = "SHA256:fD2yRVYLblwj3w0zsPqkPwdnWQLlzUtFAEPbCfUnjN4"
+)
+
+func TestAuthorizeRevoke(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := testConfig()
+	config.Kube.SSHConfig.BootstrapPublicKey = oldPublicKey
+	config.SSHAccessConfig.PublicKey = operatorKey + "\n"
+
+	output := &bytes.Buffer{}
+	if err := NewAuthorize(getTemplate(AddStepName)).Run(context.Background(), output, config); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if !strings.Contains(output.String(), `echo "`+operatorKey+`"`) {
+		t.Errorf("operator key not found in output %s", output.String())
+	}
+
+	output.Reset()
+	if err := NewRevoke(getTemplate(RemoveStepName)).Run(context.Background(), output, config); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if !strings.Contains(output.String(), `grep -vF "AAAAC3NzaC1lZDI1NTE5AAAAILEScYmxoKdpFF5UfnDKJ0X09qaicLRBnBPilo7GdUDf"`) {
+		t.Errorf("operator key body not found in output %s", output.String())
+	}
+
+	config.SSHAccessConfig.PublicKey = oldPublicKey
+	if err := NewRevoke(getTemplate(RemoveStepName)).Run(context.Background(), output, config); err == nil {
+		t.Errorf("the bootstrap key must not be revoked")
+	}
+
+	config.SSHAccessConfig.PublicKey = ""
+	if err := NewAuthorize(getTemplate(AddStepName)).Run(context.Background(), output, config); err == nil {
+		t.Errorf("error expected when operator key is empty")
+	}
+}
+
+func TestAudit(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := &bytes.Buffer{}
+	config := &steps.Config{
+		Runner: &testutils.MockRunner{},
+	}
+	config.Kube.SSHConfig.User = "ubuntu"
+
+	if err := NewAudit(getTemplate(AuditStepName)).Run(context.Background(), output, config); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// the mock runner echoes the script, it has no keys
+	if len(config.SSHAccessConfig.Keys) != 0 {
+		t.Errorf("unexpected keys %v", config.SSHAccessConfig.Keys)
+	}
+}
+
+func TestParseAuthorizedKeys(t *testing.T) {
+	output := strings.Join([]string{
+		"/home/ubuntu/.ssh/authorized_keys " + operatorKey,
+		"/home/ubuntu/.ssh/authorized_keys " + operatorKey,
+		`/root/.ssh/authorized_keys no-port-forwarding,command="echo" ` + operatorKey,
+		"/root/.ssh/authorized_keys ssh-rsa broken",
+		"",
+	}, "\n")
+
+	log := &bytes.Buffer{}
+	keys := ParseAuthorizedKeys(output, log)
+
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 keys actual %v", keys)
+	}
+
+	if keys[0].Fingerprint != operatorFingerprint || keys[0].Type != "ssh-ed25519" || keys[0].Comment != "alice@laptop" {
+		t.Errorf("wrong key %v", keys[0])
+	}
+
+	if keys[1].File != "/root/.ssh/authorized_keys" {
+		t.Errorf("wrong file of the key with options %v", keys[1])
+	}
+
+	if !strings.Contains(log.String(), "skip authorized key of /root/.ssh/authorized_keys") {
+		t.Errorf("broken key must be reported %s", log.String())
+	}
+}
@@ -0,0 +1,70 @@
+package sshkeys
+
+import (
+	"context"
+	"io"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// AddStep authorizes a new public key on a machine and checks that
+// the machine accepts connections made with the new private key.
+type AddStep struct {
+	script    *template.Template
+	getRunner func(string, string, *steps.Config) (runner.Runner, error)
+}
+
+func NewAdd(script *template.Template) *AddStep {
+	return &AddStep{
+		script:    script,
+		getRunner: sshRunner,
+	}
+}
+
+func (s *AddStep) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	rotation := config.SSHKeyRotationConfig
+	if rotation.PublicKey == "" || rotation.PrivateKey == "" {
+		return errors.New("new ssh key pair is not set")
+	}
+
+	err := steps.RunTemplate(ctx, s.script, config.Runner, out, toStepCfg(config))
+	if err != nil {
+		return errors.Wrap(err, "ssh key add step")
+	}
+
+	r, err := s.getRunner(config.Node.PublicIp, rotation.PrivateKey, config)
+	if err != nil {
+		return errors.Wrapf(err, "connect to %s with the new key", config.Node.PublicIp)
+	}
+
+	cmd, err := runner.NewCommand(ctx, "echo ssh key verified", out, out)
+	if err != nil {
+		return errors.Wrap(err, "create command")
+	}
+
+	if err := r.Run(cmd); err != nil {
+		return errors.Wrapf(err, "verify new key on %s", config.Node.PublicIp)
+	}
+
+	return nil
+}
+
+func (s *AddStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *AddStep) Name() string {
+	return AddStepName
+}
+
+func (s *AddStep) Description() string {
+	return "Authorize a new ssh key and verify connectivity"
+}
+
+func (s *AddStep) Depends() []string {
+	return nil
+}
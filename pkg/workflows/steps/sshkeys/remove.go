@@ -0,0 +1,56 @@
+package sshkeys
+
+import (
+	"context"
+	"io"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// RemoveStep revokes the old public key on a machine, the step must run
+// with a runner that is connected with the new key.
+type RemoveStep struct {
+	script *template.Template
+}
+
+func NewRemove(script *template.Template) *RemoveStep {
+	return &RemoveStep{
+		script: script,
+	}
+}
+
+func (s *RemoveStep) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	cfg := toStepCfg(config)
+	if cfg.OldKeyBody == "" {
+		return errors.New("old ssh public key is not set")
+	}
+	if cfg.OldKeyBody == cfg.KeyBody {
+		return errors.New("old and new ssh keys are the same")
+	}
+
+	err := steps.RunTemplate(ctx, s.script, config.Runner, out, cfg)
+	if err != nil {
+		return errors.Wrap(err, "ssh key remove step")
+	}
+
+	return nil
+}
+
+func (s *RemoveStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *RemoveStep) Name() string {
+	return RemoveStepName
+}
+
+func (s *RemoveStep) Description() string {
+	return "Revoke the old ssh key"
+}
+
+func (s *RemoveStep) Depends() []string {
+	return nil
+}
@@ -0,0 +1,89 @@
+package sshkeys
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/runner/ssh"
+	tm "github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	AddStepName    = "ssh_key_add"
+	RemoveStepName = "ssh_key_remove"
+
+	AuthorizeStepName = "ssh_key_authorize"
+	RevokeStepName    = "ssh_key_revoke"
+	AuditStepName     = "ssh_key_audit"
+)
+
+type Config struct {
+	User       string
+	PublicKey  string
+	KeyBody    string
+	OldKeyBody string
+}
+
+func Init() {
+	steps.RegisterStep(AddStepName, NewAdd(getTemplate(AddStepName)))
+	steps.RegisterStep(RemoveStepName, NewRemove(getTemplate(RemoveStepName)))
+	// operator keys are changed with scripts of the rotation
+	steps.RegisterStep(AuthorizeStepName, NewAuthorize(getTemplate(AddStepName)))
+	steps.RegisterStep(RevokeStepName, NewRevoke(getTemplate(RemoveStepName)))
+	steps.RegisterStep(AuditStepName, NewAudit(getTemplate(AuditStepName)))
+}
+
+func getTemplate(name string) *template.Template {
+	tpl, err := tm.GetTemplate(name)
+
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", name))
+	}
+
+	return tpl
+}
+
+func toStepCfg(config *steps.Config) Config {
+	rotation := config.SSHKeyRotationConfig
+
+	return Config{
+		User:       config.Kube.SSHConfig.For(config.Node).User,
+		PublicKey:  strings.TrimSpace(rotation.PublicKey),
+		KeyBody:    keyBody(rotation.PublicKey),
+		OldKeyBody: keyBody(rotation.OldPublicKey),
+	}
+}
+
+// keyBody returns the base64 part of an authorized key, a comment of the key
+// may be changed when a cloud provider copies it to a machine.
+func keyBody(publicKey string) string {
+	fields := strings.Fields(publicKey)
+	if len(fields) < 2 {
+		return strings.TrimSpace(publicKey)
+	}
+
+	return fields[1]
+}
+
+func sshRunner(host, privateKey string, config *steps.Config) (runner.Runner, error) {
+	sshConfig := config.Kube.SSHConfig.For(config.Node)
+	cfg := ssh.Config{
+		Host:    host,
+		Port:    sshConfig.Port,
+		User:    sshConfig.User,
+		Timeout: 10,
+		Key:     []byte(privateKey),
+	}
+
+	r, err := ssh.NewRunner(cfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "create ssh runner")
+	}
+
+	return r, nil
+}
@@ -0,0 +1,190 @@
+package sshkeys
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	oldPublicKey = "ssh-rsa AAAAold old@supergiant"
+	newPublicKey = "ssh-rsa AAAAnew new@supergiant\n"
+	newPrivate   = "private"
+)
+
+func testConfig() *steps.Config {
+	return &steps.Config{
+		Kube: model.Kube{
+			SSHConfig: model.SSHConfig{
+				User: "ubuntu",
+				Port: "22",
+			},
+		},
+		Node: model.Machine{
+			PublicIp: "1.1.1.1",
+		},
+		SSHKeyRotationConfig: steps.SSHKeyRotationConfig{
+			PrivateKey:   newPrivate,
+			PublicKey:    newPublicKey,
+			OldPublicKey: oldPublicKey,
+		},
+		Runner: &testutils.MockRunner{},
+	}
+}
+
+func TestKeyBody(t *testing.T) {
+	for _, tc := range []struct {
+		key      string
+		expected string
+	}{
+		{"ssh-rsa AAAA user@host\n", "AAAA"},
+		{"ssh-rsa AAAA", "AAAA"},
+		{" AAAA ", "AAAA"},
+		{"", ""},
+	} {
+		if body := keyBody(tc.key); body != tc.expected {
+			t.Errorf("key %q: expected body %q actual %q", tc.key, tc.expected, body)
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(AddStepName)
+
+	if tpl == nil {
+		t.Fatal("template not found")
+	}
+
+	testCases := []struct {
+		description string
+		config      func() *steps.Config
+		runnerErr   error
+		verifyErr   error
+		expectErr   bool
+	}{
+		{
+			description: "success",
+			config:      testConfig,
+		},
+		{
+			description: "no new key",
+			config: func() *steps.Config {
+				c := testConfig()
+				c.SSHKeyRotationConfig.PrivateKey = ""
+				return c
+			},
+			expectErr: true,
+		},
+		{
+			description: "add key error",
+			config:      testConfig,
+			runnerErr:   errors.New("error"),
+			expectErr:   true,
+		},
+		{
+			description: "verify error",
+			config:      testConfig,
+			verifyErr:   errors.New("error"),
+			expectErr:   true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase.description)
+
+		var host, key string
+		output := &bytes.Buffer{}
+		config := testCase.config()
+		config.Runner = &testutils.MockRunner{Err: testCase.runnerErr}
+
+		step := NewAdd(tpl)
+		step.getRunner = func(ip, privateKey string, _ *steps.Config) (runner.Runner, error) {
+			host, key = ip, privateKey
+			return &testutils.MockRunner{Err: testCase.verifyErr}, nil
+		}
+
+		err = step.Run(context.Background(), output, config)
+
+		if testCase.expectErr {
+			if err == nil {
+				t.Errorf("error expected")
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("unexpected error %v", err)
+			continue
+		}
+
+		if host != "1.1.1.1" || key != newPrivate {
+			t.Errorf("connectivity must be verified with the new key, actual host %s key %s", host, key)
+		}
+
+		if !strings.Contains(output.String(), strings.TrimSpace(newPublicKey)) {
+			t.Errorf("public key not found in output %s", output.String())
+		}
+
+		if !strings.Contains(output.String(), "getent passwd ubuntu") {
+			t.Errorf("user not found in output %s", output.String())
+		}
+	}
+}
+
+func TestRemove(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(RemoveStepName)
+
+	if tpl == nil {
+		t.Fatal("template not found")
+	}
+
+	output := &bytes.Buffer{}
+	step := NewRemove(tpl)
+
+	err = step.Run(context.Background(), output, testConfig())
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if !strings.Contains(output.String(), `grep -vF "AAAAold"`) {
+		t.Errorf("old key body not found in output %s", output.String())
+	}
+
+	if strings.Contains(output.String(), "AAAAnew") {
+		t.Errorf("new key must not be removed %s", output.String())
+	}
+
+	config := testConfig()
+	config.SSHKeyRotationConfig.OldPublicKey = newPublicKey
+
+	if err := step.Run(context.Background(), output, config); err == nil {
+		t.Errorf("the only authorized key must not be removed")
+	}
+
+	config.SSHKeyRotationConfig.OldPublicKey = ""
+
+	if err := step.Run(context.Background(), output, config); err == nil {
+		t.Errorf("error expected when old key is empty")
+	}
+}
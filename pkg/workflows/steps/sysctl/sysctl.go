@@ -0,0 +1,156 @@
+package sysctl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	tm "github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const StepName = "sysctl"
+
+var (
+	defaultModules = []string{
+		"br_netfilter",
+		"overlay",
+		"nf_conntrack",
+		"ip_vs",
+		"ip_vs_rr",
+		"ip_vs_wrr",
+		"ip_vs_sh",
+	}
+
+	defaultSettings = map[string]string{
+		"net.bridge.bridge-nf-call-iptables":  "1",
+		"net.bridge.bridge-nf-call-ip6tables": "1",
+		"net.ipv4.ip_forward":                 "1",
+		"net.netfilter.nf_conntrack_max":      "1048576",
+		"fs.inotify.max_user_watches":         "524288",
+		"fs.inotify.max_user_instances":       "8192",
+		"vm.max_map_count":                    "262144",
+		"vm.swappiness":                       "0",
+	}
+
+	// versionSettings are merged on top of the defaults for a minor k8s version.
+	versionSettings = map[string]map[string]string{
+		// docker releases used by kubernetes < 1.13 leak mount points
+		// without it on RHEL based kernels
+		"1.11": {"fs.may_detach_mounts": "1"},
+		"1.12": {"fs.may_detach_mounts": "1"},
+	}
+
+	keyRegexp   = regexp.MustCompile(`^[a-zA-Z0-9_./-]+$`)
+	valueRegexp = regexp.MustCompile(`^[a-zA-Z0-9_./ -]+$`)
+)
+
+type Config struct {
+	Modules  []string
+	Settings map[string]string
+}
+
+type Step struct {
+	script *template.Template
+}
+
+func Init() {
+	tpl, err := tm.GetTemplate(StepName)
+
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", StepName))
+	}
+
+	steps.RegisterStep(StepName, New(tpl))
+}
+
+func New(script *template.Template) *Step {
+	return &Step{
+		script: script,
+	}
+}
+
+func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	cfg, err := toStepCfg(config)
+	if err != nil {
+		return errors.Wrap(err, "build step config")
+	}
+
+	err = steps.RunTemplate(ctx, s.script, config.Runner, out, cfg)
+	if err != nil {
+		return errors.Wrap(err, "sysctl step")
+	}
+
+	return nil
+}
+
+func (s *Step) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *Step) Name() string {
+	return StepName
+}
+
+func (s *Step) Description() string {
+	return "Tune kernel parameters and disable swap"
+}
+
+func (s *Step) Depends() []string {
+	return nil
+}
+
+func toStepCfg(c *steps.Config) (Config, error) {
+	settings := make(map[string]string, len(defaultSettings))
+	for k, v := range defaultSettings {
+		settings[k] = v
+	}
+	for k, v := range versionSettings[minorVersion(c.Kube.K8SVersion)] {
+		settings[k] = v
+	}
+	for k, v := range c.Kube.Sysctl {
+		if !keyRegexp.MatchString(k) || !valueRegexp.MatchString(v) {
+			return Config{}, errors.Errorf("invalid sysctl setting %s = %s", k, v)
+		}
+		settings[k] = v
+	}
+
+	modules := append([]string{}, defaultModules...)
+	for _, m := range c.Kube.KernelModules {
+		if !keyRegexp.MatchString(m) {
+			return Config{}, errors.Errorf("invalid kernel module name %s", m)
+		}
+		if !contains(modules, m) {
+			modules = append(modules, m)
+		}
+	}
+
+	return Config{
+		Modules:  modules,
+		Settings: settings,
+	}, nil
+}
+
+func minorVersion(version string) string {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) < 2 {
+		return version
+	}
+
+	return parts[0] + "." + parts[1]
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,169 @@
+package sysctl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestSysctl(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(StepName)
+
+	if tpl == nil {
+		t.Fatal("template not found")
+	}
+
+	output := &bytes.Buffer{}
+	config := &steps.Config{
+		Kube: model.Kube{
+			K8SVersion: "1.14.3",
+			Sysctl: map[string]string{
+				"vm.max_map_count": "524288",
+			},
+			KernelModules: []string{"nf_nat"},
+		},
+		Runner: &testutils.MockRunner{},
+	}
+
+	err = New(tpl).Run(context.Background(), output, config)
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, expected := range []string{
+		"vm.max_map_count = 524288",
+		"fs.inotify.max_user_watches = 524288",
+		"sudo modprobe nf_nat",
+		"swapoff -a",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("%s not found in output %s", expected, output.String())
+		}
+	}
+}
+
+func TestSysctlError(t *testing.T) {
+	errMsg := "error has occurred"
+	config := &steps.Config{
+		Runner: &testutils.MockRunner{
+			Err: errors.New(errMsg),
+		},
+	}
+
+	err := New(template.Must(template.New(StepName).Parse("sysctl"))).
+		Run(context.Background(), ioutil.Discard, config)
+
+	if err == nil || !strings.Contains(err.Error(), errMsg) {
+		t.Errorf("Error message expected to contain %s actual %v", errMsg, err)
+	}
+}
+
+func TestToStepCfg(t *testing.T) {
+	testCases := []struct {
+		description string
+		kube        model.Kube
+		expected    map[string]string
+		hasErr      bool
+	}{
+		{
+			description: "defaults",
+			kube:        model.Kube{K8SVersion: "1.15.1"},
+			expected: map[string]string{
+				"net.ipv4.ip_forward": "1",
+			},
+		},
+		{
+			description: "version specific",
+			kube:        model.Kube{K8SVersion: "1.12.7"},
+			expected: map[string]string{
+				"fs.may_detach_mounts": "1",
+			},
+		},
+		{
+			description: "override",
+			kube: model.Kube{
+				Sysctl: map[string]string{"net.ipv4.ip_forward": "0"},
+			},
+			expected: map[string]string{
+				"net.ipv4.ip_forward": "0",
+			},
+		},
+		{
+			description: "invalid value",
+			kube: model.Kube{
+				Sysctl: map[string]string{"kernel.pid_max": "$(reboot)"},
+			},
+			hasErr: true,
+		},
+		{
+			description: "invalid module",
+			kube: model.Kube{
+				KernelModules: []string{"ip_vs; reboot"},
+			},
+			hasErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase.description)
+		cfg, err := toStepCfg(&steps.Config{Kube: testCase.kube})
+
+		if testCase.hasErr {
+			if err == nil {
+				t.Errorf("error must not be nil")
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("unexpected error %v", err)
+			continue
+		}
+
+		for k, v := range testCase.expected {
+			if cfg.Settings[k] != v {
+				t.Errorf("wrong value for %s expected %s actual %s", k, v, cfg.Settings[k])
+			}
+		}
+	}
+
+	if cfg, _ := toStepCfg(&steps.Config{Kube: model.Kube{K8SVersion: "1.15.1"}}); cfg.Settings["fs.may_detach_mounts"] != "" {
+		t.Errorf("fs.may_detach_mounts must not be set for 1.15")
+	}
+}
+
+func TestStep_Depends(t *testing.T) {
+	s := Step{}
+
+	if len(s.Depends()) != 0 {
+		t.Errorf("Wrong dependency list %v", s.Depends())
+	}
+}
+
+func TestInit(t *testing.T) {
+	templatemanager.SetTemplate(StepName, &template.Template{})
+	Init()
+	templatemanager.DeleteTemplate(StepName)
+
+	s := steps.GetStep(StepName)
+
+	if s == nil {
+		t.Error("Step not found")
+	}
+}
@@ -0,0 +1,96 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/util"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// File is rendered from the step config and written to the machine.
+type File struct {
+	Path  string
+	Mode  os.FileMode
+	Owner string
+
+	Content *template.Template
+	// Data returns data for the content template, the config is used when it's nil
+	Data func(*steps.Config) interface{}
+}
+
+// Step writes files to the machine. Unlike heredocs in shell scripts
+// the content is copied as is whatever characters it has.
+type Step struct {
+	name        string
+	description string
+	files       []File
+}
+
+func New(name, description string, files ...File) *Step {
+	return &Step{
+		name:        name,
+		description: description,
+		files:       files,
+	}
+}
+
+func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	if err := Write(ctx, out, config, s.files...); err != nil {
+		return errors.Wrapf(err, "%s step", s.name)
+	}
+
+	return nil
+}
+
+// Write renders the files and uploads them with the runner of the config.
+func Write(ctx context.Context, out io.Writer, config *steps.Config, files ...File) error {
+	log := util.GetLogger(out)
+
+	for _, f := range files {
+		var data interface{} = config
+		if f.Data != nil {
+			data = f.Data(config)
+		}
+
+		buf := &bytes.Buffer{}
+		if err := f.Content.Execute(buf, data); err != nil {
+			return errors.Wrapf(err, "render %s", f.Path)
+		}
+
+		log.Infof("write %s", f.Path)
+		err := runner.Upload(ctx, config.Runner, runner.File{
+			Path:  f.Path,
+			Data:  buf.Bytes(),
+			Mode:  f.Mode,
+			Owner: f.Owner,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "upload %s", f.Path)
+		}
+	}
+
+	return nil
+}
+
+func (s *Step) Name() string {
+	return s.name
+}
+
+func (s *Step) Description() string {
+	return s.description
+}
+
+func (s *Step) Depends() []string {
+	return nil
+}
+
+func (s *Step) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
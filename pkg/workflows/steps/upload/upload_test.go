@@ -0,0 +1,83 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type fakeUploader struct {
+	files []runner.File
+	err   error
+}
+
+func (f *fakeUploader) Run(*runner.Command) error {
+	return nil
+}
+
+func (f *fakeUploader) Upload(ctx context.Context, file runner.File) error {
+	f.files = append(f.files, file)
+	return f.err
+}
+
+func TestStep_Run(t *testing.T) {
+	r := &fakeUploader{}
+	config := &steps.Config{
+		TaskID: "1234",
+		Runner: r,
+	}
+	config.InstallAppConfig.Values = `password: "$(id)"`
+
+	step := New("write_files", "", File{
+		Path:    "/etc/supergiant/cluster",
+		Content: template.Must(template.New("").Parse("{{ .TaskID }}")),
+	}, File{
+		Path:    "/etc/supergiant/values.yaml",
+		Mode:    0600,
+		Owner:   "root",
+		Content: template.Must(template.New("").Parse("{{ .Values }}")),
+		Data: func(c *steps.Config) interface{} {
+			return c.InstallAppConfig
+		},
+	})
+
+	if err := step.Run(context.Background(), &bytes.Buffer{}, config); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if len(r.files) != 2 {
+		t.Fatalf("wrong count of files %d", len(r.files))
+	}
+
+	if string(r.files[0].Data) != "1234" {
+		t.Errorf("wrong content %s", r.files[0].Data)
+	}
+
+	if f := r.files[1]; string(f.Data) != `password: "$(id)"` || f.Mode != 0600 || f.Owner != "root" {
+		t.Errorf("wrong file %+v", f)
+	}
+}
+
+func TestStep_RunError(t *testing.T) {
+	r := &fakeUploader{err: errors.New("permission denied")}
+	step := New("write_files", "", File{
+		Path:    "/etc/supergiant/cluster",
+		Content: template.Must(template.New("").Parse("{{ .TaskID }}")),
+	})
+
+	err := step.Run(context.Background(), &bytes.Buffer{}, &steps.Config{Runner: r})
+	if err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	if step.Name() != "write_files" {
+		t.Errorf("wrong step name %s", step.Name())
+	}
+}
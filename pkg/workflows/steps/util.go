@@ -10,40 +10,46 @@ import (
 )
 
 func RunTemplate(ctx context.Context, tpl *template.Template, r runner.Runner, output io.Writer, cfg interface{}) error {
-	resultChan := make(chan error)
+	_, err := RunTemplateResult(ctx, tpl, r, output, cfg)
+	return err
+}
+
+// RunTemplateResult runs the template script and returns result of the
+// command, steps can check the exit code of the failed script.
+func RunTemplateResult(ctx context.Context, tpl *template.Template, r runner.Runner, output io.Writer, cfg interface{}) (*runner.Result, error) {
+	type result struct {
+		res *runner.Result
+		err error
+	}
+	resultChan := make(chan result, 1)
 
 	go func() {
 		buffer := new(bytes.Buffer)
 		err := tpl.Execute(buffer, cfg)
 
 		if err != nil {
-			resultChan <- err
-		}
-		cmd, err := runner.NewCommand(ctx, buffer.String(), output, output)
-
-		if err != nil {
-			resultChan <- err
+			resultChan <- result{err: err}
 			return
 		}
-
-		err = r.Run(cmd)
+		cmd, err := runner.NewCommand(ctx, buffer.String(), output, output)
 
 		if err != nil {
-			resultChan <- err
+			resultChan <- result{err: err}
 			return
 		}
 
-		close(resultChan)
+		res, err := runner.Exec(r, cmd)
+		resultChan <- result{res, err}
 	}()
 
 	select {
 	case <-ctx.Done():
 		if ctx.Err() != nil {
-			return ctx.Err()
+			return nil, ctx.Err()
 		}
-	case err := <-resultChan:
-		return err
+	case r := <-resultChan:
+		return r.res, r.err
 	}
 
-	return nil
+	return nil, nil
 }
@@ -0,0 +1,243 @@
+package velero
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	tm "github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+	"github.com/supergiant/control/pkg/workflows/steps/upload"
+)
+
+const (
+	StepName = "velero"
+
+	DefaultVersion = "1.2.0"
+	// Namespace Velero is installed to.
+	Namespace = "velero"
+
+	credentialsFile = "/etc/supergiant/velero/credentials"
+)
+
+// plugins of object storage of providers, they are compatible with
+// Velero 1.2 and later.
+var plugins = map[string]string{
+	"aws":   "velero/velero-plugin-for-aws:v1.0.0",
+	"gcp":   "velero/velero-plugin-for-gcp:v1.0.0",
+	"azure": "velero/velero-plugin-for-microsoft-azure:v1.0.0",
+}
+
+// values are passed to the install command as is, they are limited to
+// characters that are safe in shell and in key=value lists.
+var valueRegexp = regexp.MustCompile(`^[a-zA-Z0-9._:/-]*$`)
+
+// credentials are uploaded as is
+var credentialsTpl = template.Must(template.New("credentials").Parse("{{ . }}"))
+
+type Config struct {
+	Version         string
+	Namespace       string
+	Provider        string
+	Plugin          string
+	Bucket          string
+	Prefix          string
+	LocationConfig  string
+	SnapshotConfig  string
+	CredentialsFile string
+}
+
+// Location is how Velero reaches the bucket of the cluster backups.
+type Location struct {
+	// Provider is the name of the Velero object storage plugin.
+	Provider string
+	Config   map[string]string
+	// Snapshots are volume snapshots of the cloud provider, they are only
+	// taken on AWS.
+	Snapshots map[string]string
+}
+
+// LocationOf returns the Velero provider and the config of the backup
+// location of a cluster of the cloud provider.
+func LocationOf(provider clouds.Name, backup model.Backup) (*Location, error) {
+	for _, v := range []string{backup.Bucket, backup.Prefix, backup.Region, backup.S3URL,
+		backup.ResourceGroup, backup.StorageAccount, backup.Version} {
+		if !valueRegexp.MatchString(v) {
+			return nil, errors.Wrapf(sgerrors.ErrInvalidJson, "invalid backup setting %q", v)
+		}
+	}
+	if backup.Bucket == "" {
+		return nil, errors.Wrap(sgerrors.ErrInvalidJson, "bucket is required")
+	}
+
+	loc := &Location{Config: map[string]string{}}
+	switch provider {
+	case clouds.AWS:
+		loc.Provider = "aws"
+		if backup.Region != "" {
+			loc.Config["region"] = backup.Region
+			loc.Snapshots = map[string]string{"region": backup.Region}
+		}
+	case clouds.DigitalOcean:
+		// Spaces are S3 compatible, volume snapshots aren't supported by the plugin
+		loc.Provider = "aws"
+		if backup.Region == "" {
+			return nil, errors.Wrap(sgerrors.ErrInvalidJson, "region of spaces is required")
+		}
+		loc.Config["region"] = backup.Region
+		if backup.S3URL == "" {
+			loc.Config["s3Url"] = fmt.Sprintf("https://%s.digitaloceanspaces.com", backup.Region)
+		}
+	case clouds.GCE:
+		loc.Provider = "gcp"
+	case clouds.Azure:
+		loc.Provider = "azure"
+		if backup.ResourceGroup == "" || backup.StorageAccount == "" {
+			return nil, errors.Wrap(sgerrors.ErrInvalidJson, "resource group and storage account are required")
+		}
+		loc.Config["resourceGroup"] = backup.ResourceGroup
+		loc.Config["storageAccount"] = backup.StorageAccount
+	default:
+		return nil, sgerrors.ErrUnsupportedProvider
+	}
+
+	if backup.S3URL != "" {
+		loc.Config["s3Url"] = backup.S3URL
+		loc.Config["s3ForcePathStyle"] = "true"
+	}
+	return loc, nil
+}
+
+type Step struct {
+	script *template.Template
+}
+
+func Init() {
+	tpl, err := tm.GetTemplate(StepName)
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", StepName))
+	}
+
+	steps.RegisterStep(StepName, New(tpl))
+}
+
+func New(script *template.Template) *Step {
+	return &Step{
+		script: script,
+	}
+}
+
+func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	cfg, creds, err := installConfig(config)
+	if err != nil {
+		return errors.Wrap(err, "velero step")
+	}
+
+	err = upload.Write(ctx, out, config, upload.File{
+		Path:    credentialsFile,
+		Mode:    0600,
+		Content: credentialsTpl,
+		Data: func(*steps.Config) interface{} {
+			return creds
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "velero step")
+	}
+
+	if err = steps.RunTemplate(ctx, s.script, config.Runner, out, cfg); err != nil {
+		return errors.Wrap(err, "velero step")
+	}
+	return nil
+}
+
+// installConfig returns settings of the install script and the credentials
+// of the object storage in the format of the provider plugin.
+func installConfig(config *steps.Config) (Config, string, error) {
+	vc := config.VeleroConfig
+	loc, err := LocationOf(config.Provider, vc.Backup)
+	if err != nil {
+		return Config{}, "", err
+	}
+
+	var creds string
+	switch {
+	case vc.AccessKey != "" && vc.SecretKey != "":
+		creds = awsCredentials(vc.AccessKey, vc.SecretKey)
+	case config.Provider == clouds.AWS:
+		creds = awsCredentials(config.AWSConfig.KeyID, config.AWSConfig.Secret)
+	case config.Provider == clouds.GCE:
+		raw, err := json.Marshal(config.GCEConfig.ServiceAccount)
+		if err != nil {
+			return Config{}, "", errors.Wrap(err, "marshal service account")
+		}
+		creds = string(raw)
+	case config.Provider == clouds.Azure:
+		creds = azureCredentials(config.AzureConfig, vc.ResourceGroup)
+	default:
+		return Config{}, "", errors.Wrap(sgerrors.ErrInvalidCredentials, "access and secret keys of the storage are required")
+	}
+
+	version := vc.Version
+	if version == "" {
+		version = DefaultVersion
+	}
+
+	return Config{
+		Version:         strings.TrimPrefix(version, "v"),
+		Namespace:       Namespace,
+		Provider:        loc.Provider,
+		Plugin:          plugins[loc.Provider],
+		Bucket:          vc.Bucket,
+		Prefix:          vc.Prefix,
+		LocationConfig:  keyValues(loc.Config),
+		SnapshotConfig:  keyValues(loc.Snapshots),
+		CredentialsFile: credentialsFile,
+	}, creds, nil
+}
+
+func awsCredentials(keyID, secret string) string {
+	return fmt.Sprintf("[default]\naws_access_key_id=%s\naws_secret_access_key=%s\n", keyID, secret)
+}
+
+func azureCredentials(cfg steps.AzureConfig, resourceGroup string) string {
+	return fmt.Sprintf("AZURE_SUBSCRIPTION_ID=%s\nAZURE_TENANT_ID=%s\nAZURE_CLIENT_ID=%s\n"+
+		"AZURE_CLIENT_SECRET=%s\nAZURE_RESOURCE_GROUP=%s\nAZURE_CLOUD_NAME=AzurePublicCloud\n",
+		cfg.SubscriptionID, cfg.TenantID, cfg.ClientID, cfg.ClientSecret, resourceGroup)
+}
+
+// keyValues joins the config sorted by key, e.g. region=us-east-1,s3Url=...
+func keyValues(m map[string]string) string {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (s *Step) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *Step) Name() string {
+	return StepName
+}
+
+func (s *Step) Description() string {
+	return "Install Velero that backs up cluster resources"
+}
+
+func (s *Step) Depends() []string {
+	return nil
+}
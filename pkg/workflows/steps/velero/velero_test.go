@@ -0,0 +1,222 @@
+package velero
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func TestLocationOf(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		provider    clouds.Name
+		backup      model.Backup
+		expected    *Location
+		expectedErr error
+	}{
+		{
+			description: "aws",
+			provider:    clouds.AWS,
+			backup:      model.Backup{Bucket: "backups", Region: "us-east-1"},
+			expected: &Location{
+				Provider:  "aws",
+				Config:    map[string]string{"region": "us-east-1"},
+				Snapshots: map[string]string{"region": "us-east-1"},
+			},
+		},
+		{
+			description: "spaces",
+			provider:    clouds.DigitalOcean,
+			backup:      model.Backup{Bucket: "backups", Region: "nyc3"},
+			expected: &Location{
+				Provider: "aws",
+				Config: map[string]string{
+					"region": "nyc3",
+					"s3Url":  "https://nyc3.digitaloceanspaces.com",
+				},
+			},
+		},
+		{
+			description: "spaces without region",
+			provider:    clouds.DigitalOcean,
+			backup:      model.Backup{Bucket: "backups"},
+			expectedErr: sgerrors.ErrInvalidJson,
+		},
+		{
+			description: "azure",
+			provider:    clouds.Azure,
+			backup:      model.Backup{Bucket: "backups", ResourceGroup: "rg", StorageAccount: "sa"},
+			expected: &Location{
+				Provider: "azure",
+				Config:   map[string]string{"resourceGroup": "rg", "storageAccount": "sa"},
+			},
+		},
+		{
+			description: "no bucket",
+			provider:    clouds.GCE,
+			expectedErr: sgerrors.ErrInvalidJson,
+		},
+		{
+			description: "unsafe value",
+			provider:    clouds.GCE,
+			backup:      model.Backup{Bucket: "backups; rm -rf /"},
+			expectedErr: sgerrors.ErrInvalidJson,
+		},
+		{
+			description: "unsupported provider",
+			provider:    clouds.OpenStack,
+			backup:      model.Backup{Bucket: "backups"},
+			expectedErr: sgerrors.ErrUnsupportedProvider,
+		},
+	} {
+		loc, err := LocationOf(tc.provider, tc.backup)
+		if tc.expectedErr != nil {
+			if errors.Cause(err) != tc.expectedErr {
+				t.Errorf("%s: expected error %v actual %v", tc.description, tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tc.description, err)
+			continue
+		}
+		if loc.Provider != tc.expected.Provider ||
+			keyValues(loc.Config) != keyValues(tc.expected.Config) ||
+			keyValues(loc.Snapshots) != keyValues(tc.expected.Snapshots) {
+			t.Errorf("%s: expected location %v actual %v", tc.description, tc.expected, loc)
+		}
+	}
+}
+
+func TestStepRun(t *testing.T) {
+	err := templatemanager.Init("../../../../templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, _ := templatemanager.GetTemplate(StepName)
+	if tpl == nil {
+		t.Fatal("template not found")
+	}
+
+	for _, tc := range []struct {
+		description string
+		config      *steps.Config
+		runnerErr   error
+		expected    []string
+		expectErr   bool
+	}{
+		{
+			description: "aws",
+			config: &steps.Config{
+				Provider: clouds.AWS,
+				AWSConfig: steps.AWSConfig{
+					KeyID:  "key",
+					Secret: "secret",
+				},
+				VeleroConfig: steps.VeleroConfig{
+					Backup: model.Backup{Bucket: "backups", Prefix: "kube", Region: "us-east-1"},
+				},
+			},
+			expected: []string{
+				"--provider aws",
+				"--plugins velero/velero-plugin-for-aws",
+				"--bucket backups",
+				"--prefix kube",
+				"--backup-location-config region=us-east-1",
+				"--snapshot-location-config region=us-east-1",
+				"velero-v" + DefaultVersion,
+			},
+		},
+		{
+			description: "spaces",
+			config: &steps.Config{
+				Provider: clouds.DigitalOcean,
+				VeleroConfig: steps.VeleroConfig{
+					Backup:    model.Backup{Bucket: "backups", Region: "nyc3", Version: "v1.3.0"},
+					AccessKey: "key",
+					SecretKey: "secret",
+				},
+			},
+			expected: []string{
+				"--backup-location-config region=nyc3,s3Url=https://nyc3.digitaloceanspaces.com",
+				"--use-volume-snapshots=false",
+				"velero-v1.3.0",
+			},
+		},
+		{
+			description: "spaces without keys",
+			config: &steps.Config{
+				Provider: clouds.DigitalOcean,
+				VeleroConfig: steps.VeleroConfig{
+					Backup: model.Backup{Bucket: "backups", Region: "nyc3"},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			description: "runner error",
+			config: &steps.Config{
+				Provider: clouds.GCE,
+				VeleroConfig: steps.VeleroConfig{
+					Backup: model.Backup{Bucket: "backups"},
+				},
+			},
+			runnerErr: errors.New("error"),
+			expectErr: true,
+		},
+	} {
+		out := &bytes.Buffer{}
+		tc.config.Runner = &testutils.MockRunner{Err: tc.runnerErr}
+
+		err := New(tpl).Run(context.Background(), out, tc.config)
+		if tc.expectErr {
+			if err == nil {
+				t.Errorf("%s: error expected", tc.description)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tc.description, err)
+			continue
+		}
+		for _, s := range tc.expected {
+			if !strings.Contains(out.String(), s) {
+				t.Errorf("%s: %q not found in output %s", tc.description, s, out.String())
+			}
+		}
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	_, creds, err := installConfig(&steps.Config{
+		Provider: clouds.Azure,
+		AzureConfig: steps.AzureConfig{
+			SubscriptionID: "sub",
+			TenantID:       "tenant",
+			ClientID:       "client",
+			ClientSecret:   "secret",
+		},
+		VeleroConfig: steps.VeleroConfig{
+			Backup: model.Backup{Bucket: "backups", ResourceGroup: "rg", StorageAccount: "sa"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	for _, s := range []string{"AZURE_SUBSCRIPTION_ID=sub", "AZURE_CLIENT_SECRET=secret", "AZURE_RESOURCE_GROUP=rg"} {
+		if !strings.Contains(creds, s) {
+			t.Errorf("%s not found in credentials %s", s, creds)
+		}
+	}
+}
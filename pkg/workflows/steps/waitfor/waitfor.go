@@ -0,0 +1,368 @@
+package waitfor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/supergiant/control/pkg/kubeconfig"
+	"github.com/supergiant/control/pkg/sglog"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	NodeReadyStepName  = "wait_node_ready"
+	NodesReadyStepName = "wait_nodes_ready"
+	ReleaseStepName    = "wait_release_ready"
+
+	DefaultTimeout = time.Minute * 10
+
+	initialInterval = time.Second * 2
+	maxInterval     = time.Second * 30
+)
+
+var crdResources = []schema.GroupVersionResource{
+	{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"},
+	{Group: "apiextensions.k8s.io", Version: "v1beta1", Resource: "customresourcedefinitions"},
+}
+
+// Clients are used by conditions to query the cluster API.
+type Clients struct {
+	Kube    kubernetes.Interface
+	Dynamic dynamic.Interface
+}
+
+// Condition reports whether the cluster has reached the expected state,
+// the message describes the current state while it hasn't.
+type Condition func(clients Clients, config *steps.Config) (bool, string, error)
+
+// Step polls the cluster API until the condition is met or the timeout expires.
+// Checks are retried with exponential backoff, errors are treated as temporary
+// since the API may be unavailable while the cluster is being changed.
+type Step struct {
+	name        string
+	description string
+	condition   Condition
+	timeout     time.Duration
+
+	interval    time.Duration
+	maxInterval time.Duration
+	getClients  func(*steps.Config) (Clients, error)
+}
+
+func Init() {
+	steps.RegisterStep(NodeReadyStepName, New(NodeReadyStepName,
+		"Wait for the node to become ready", NodeReady(), DefaultTimeout))
+	steps.RegisterStep(NodesReadyStepName, New(NodesReadyStepName,
+		"Wait for all cluster nodes to become ready", NodesReady(ClusterSize), DefaultTimeout))
+	steps.RegisterStep(ReleaseStepName, New(ReleaseStepName,
+		"Wait for workloads of the helm release to become ready", ReleaseReady(), DefaultTimeout))
+}
+
+func New(name, description string, condition Condition, timeout time.Duration) *Step {
+	return &Step{
+		name:        name,
+		description: description,
+		condition:   condition,
+		timeout:     timeout,
+		interval:    initialInterval,
+		maxInterval: maxInterval,
+		getClients:  clientsFor,
+	}
+}
+
+func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	if config.Simulated() {
+		fmt.Fprintf(out, "%s: skipped on a simulated cluster\n", s.name)
+		return nil
+	}
+
+	clients, err := s.getClients(config)
+	if err != nil {
+		return errors.Wrapf(err, "%s step: build kubernetes clients", s.name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	interval := s.interval
+	for {
+		ok, msg, err := s.condition(clients, config)
+		if ok {
+			fmt.Fprintf(out, "%s\n", msg)
+			return nil
+		}
+		if err != nil {
+			msg = err.Error()
+			sglog.FromContext(ctx).Debugf("%s: check condition: %v", s.name, err)
+		}
+		fmt.Fprintf(out, "waiting: %s\n", msg)
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "%s step: %s", s.name, msg)
+		case <-time.After(interval):
+		}
+
+		interval *= 2
+		if interval > s.maxInterval {
+			interval = s.maxInterval
+		}
+	}
+}
+
+func (s *Step) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *Step) Name() string {
+	return s.name
+}
+
+func (s *Step) Description() string {
+	return s.description
+}
+
+func (s *Step) Depends() []string {
+	return nil
+}
+
+// ClusterSize is the number of machines known for the cluster.
+func ClusterSize(config *steps.Config) int {
+	return len(config.Kube.Masters) + len(config.Kube.Nodes)
+}
+
+// NodesReady is met when at least count nodes of the cluster are ready.
+func NodesReady(count func(*steps.Config) int) Condition {
+	return func(clients Clients, config *steps.Config) (bool, string, error) {
+		nodes, err := clients.Kube.CoreV1().Nodes().List(metav1.ListOptions{})
+		if err != nil {
+			return false, "", errors.Wrap(err, "list nodes")
+		}
+
+		ready := 0
+		for _, n := range nodes.Items {
+			if isNodeReady(n) {
+				ready++
+			}
+		}
+
+		expected := count(config)
+		return ready >= expected, fmt.Sprintf("%d of %d nodes are ready", ready, expected), nil
+	}
+}
+
+// NodeReady is met when the node of the workflow is ready. The node is found
+// by its private IP address since its name depends on the cloud provider.
+func NodeReady() Condition {
+	return func(clients Clients, config *steps.Config) (bool, string, error) {
+		nodes, err := clients.Kube.CoreV1().Nodes().List(metav1.ListOptions{})
+		if err != nil {
+			return false, "", errors.Wrap(err, "list nodes")
+		}
+
+		for _, n := range nodes.Items {
+			if !hasAddress(n, config.Node.PrivateIp) && n.Name != config.Node.Name {
+				continue
+			}
+
+			if isNodeReady(n) {
+				return true, fmt.Sprintf("node %s is ready", n.Name), nil
+			}
+			return false, fmt.Sprintf("node %s is not ready", n.Name), nil
+		}
+
+		return false, fmt.Sprintf("node %s hasn't joined the cluster", config.Node.PrivateIp), nil
+	}
+}
+
+// DeploymentAvailable is met when all replicas of the deployment are updated and available.
+func DeploymentAvailable(namespace, name string) Condition {
+	return func(clients Clients, config *steps.Config) (bool, string, error) {
+		d, err := clients.Kube.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return false, "", errors.Wrapf(err, "get deployment %s/%s", namespace, name)
+		}
+
+		ok := deploymentReady(d)
+		msg := fmt.Sprintf("deployment %s/%s: %d of %d replicas are available",
+			namespace, name, d.Status.AvailableReplicas, replicas(d.Spec.Replicas))
+
+		return ok, msg, nil
+	}
+}
+
+// releaseSelectors match workloads of a release, the first one is set by
+// helm 2 era charts and the second one by charts that follow
+// the recommended labels.
+var releaseSelectors = []string{"release=%s", "app.kubernetes.io/instance=%s"}
+
+// ReleaseReady is met when deployments, stateful sets and daemon sets of the
+// helm release of the workflow are ready, releases without workloads are ready
+// right away.
+func ReleaseReady() Condition {
+	return func(clients Clients, config *steps.Config) (bool, string, error) {
+		name, namespace := config.InstallAppConfig.Name, config.InstallAppConfig.Namespace
+		if name == "" {
+			return true, "release name is generated, its workloads aren't checked", nil
+		}
+		if namespace == "" {
+			namespace = metav1.NamespaceDefault
+		}
+
+		apps := clients.Kube.AppsV1()
+		seen := make(map[string]bool)
+		total, pending := 0, make([]string, 0)
+		check := func(kind, name string, ready bool) {
+			if key := kind + "/" + name; !seen[key] {
+				seen[key] = true
+				total++
+				if !ready {
+					pending = append(pending, key)
+				}
+			}
+		}
+
+		for _, selector := range releaseSelectors {
+			opts := metav1.ListOptions{LabelSelector: fmt.Sprintf(selector, name)}
+
+			deployments, err := apps.Deployments(namespace).List(opts)
+			if err != nil {
+				return false, "", errors.Wrapf(err, "list deployments of %s", name)
+			}
+			for i := range deployments.Items {
+				check("deployment", deployments.Items[i].Name, deploymentReady(&deployments.Items[i]))
+			}
+
+			sets, err := apps.StatefulSets(namespace).List(opts)
+			if err != nil {
+				return false, "", errors.Wrapf(err, "list stateful sets of %s", name)
+			}
+			for _, s := range sets.Items {
+				ready := s.Status.ObservedGeneration >= s.Generation &&
+					s.Status.ReadyReplicas >= replicas(s.Spec.Replicas)
+				check("statefulset", s.Name, ready)
+			}
+
+			daemons, err := apps.DaemonSets(namespace).List(opts)
+			if err != nil {
+				return false, "", errors.Wrapf(err, "list daemon sets of %s", name)
+			}
+			for _, d := range daemons.Items {
+				ready := d.Status.ObservedGeneration >= d.Generation &&
+					d.Status.NumberReady >= d.Status.DesiredNumberScheduled
+				check("daemonset", d.Name, ready)
+			}
+		}
+
+		if len(pending) > 0 {
+			return false, fmt.Sprintf("release %s: %d of %d workloads aren't ready: %s",
+				name, len(pending), total, strings.Join(pending, ", ")), nil
+		}
+		return true, fmt.Sprintf("release %s: %d workloads are ready", name, total), nil
+	}
+}
+
+func deploymentReady(d *appsv1.Deployment) bool {
+	n := replicas(d.Spec.Replicas)
+	return d.Status.ObservedGeneration >= d.Generation &&
+		d.Status.UpdatedReplicas >= n &&
+		d.Status.AvailableReplicas >= n
+}
+
+// replicas returns the desired number of replicas, it's 1 when unset.
+func replicas(n *int32) int32 {
+	if n == nil {
+		return 1
+	}
+	return *n
+}
+
+// CRDEstablished is met when the custom resource definition is served by the API.
+func CRDEstablished(name string) Condition {
+	return func(clients Clients, config *steps.Config) (bool, string, error) {
+		var crd *unstructured.Unstructured
+		var err error
+		// v1beta1 is the only version on clusters prior to 1.16
+		for _, res := range crdResources {
+			crd, err = clients.Dynamic.Resource(res).Get(name, metav1.GetOptions{})
+			if err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return false, "", errors.Wrapf(err, "get crd %s", name)
+		}
+
+		conditions, _, err := unstructured.NestedSlice(crd.Object, "status", "conditions")
+		if err != nil {
+			return false, "", errors.Wrapf(err, "crd %s conditions", name)
+		}
+
+		for _, c := range conditions {
+			cond, ok := c.(map[string]interface{})
+			if ok && cond["type"] == "Established" && cond["status"] == "True" {
+				return true, fmt.Sprintf("crd %s is established", name), nil
+			}
+		}
+
+		return false, fmt.Sprintf("crd %s is not established", name), nil
+	}
+}
+
+func isNodeReady(n corev1.Node) bool {
+	for _, c := range n.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func hasAddress(n corev1.Node, ip string) bool {
+	for _, addr := range n.Status.Addresses {
+		if addr.Type == corev1.NodeInternalIP && addr.Address == ip {
+			return true
+		}
+	}
+	return false
+}
+
+func clientsFor(config *steps.Config) (Clients, error) {
+	k := config.Kube
+	// masters are stored to the kube only when the cluster is provisioned
+	if len(k.Masters) == 0 {
+		k.Masters = config.GetMasters()
+	}
+
+	cfg, err := kubeconfig.NewConfigFor(&k)
+	if err != nil {
+		return Clients{}, err
+	}
+
+	kubeClient, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return Clients{}, err
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return Clients{}, err
+	}
+
+	return Clients{
+		Kube:    kubeClient,
+		Dynamic: dynamicClient,
+	}, nil
+}
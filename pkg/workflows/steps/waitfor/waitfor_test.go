@@ -0,0 +1,234 @@
+package waitfor
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+type fakeCRDs struct {
+	dynamic.NamespaceableResourceInterface
+	crd *unstructured.Unstructured
+}
+
+func (f *fakeCRDs) Get(name string, _ metav1.GetOptions, _ ...string) (*unstructured.Unstructured, error) {
+	if f.crd == nil {
+		return nil, k8serrors.NewNotFound(schema.GroupResource{}, name)
+	}
+	return f.crd, nil
+}
+
+type fakeDynamic struct {
+	crds map[string]*fakeCRDs
+}
+
+func (f *fakeDynamic) Resource(res schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	if crds, ok := f.crds[res.Version]; ok {
+		return crds
+	}
+	return &fakeCRDs{}
+}
+
+func node(name, ip string, status corev1.ConditionStatus) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: ip},
+			},
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: status},
+			},
+		},
+	}
+}
+
+func TestNodesReady(t *testing.T) {
+	clients := Clients{Kube: fake.NewSimpleClientset(
+		node("master", "10.0.0.1", corev1.ConditionTrue),
+		node("node-1", "10.0.0.2", corev1.ConditionTrue),
+		node("node-2", "10.0.0.3", corev1.ConditionFalse),
+	)}
+
+	for _, tc := range []struct {
+		count    int
+		expected bool
+	}{
+		{count: 2, expected: true},
+		{count: 3, expected: false},
+	} {
+		ok, msg, err := NodesReady(func(*steps.Config) int { return tc.count })(clients, &steps.Config{})
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, ok, msg)
+	}
+}
+
+func TestNodeReady(t *testing.T) {
+	clients := Clients{Kube: fake.NewSimpleClientset(
+		node("ip-10-0-0-1", "10.0.0.1", corev1.ConditionTrue),
+		node("ip-10-0-0-2", "10.0.0.2", corev1.ConditionUnknown),
+	)}
+
+	for _, tc := range []struct {
+		ip       string
+		expected bool
+	}{
+		{ip: "10.0.0.1", expected: true},
+		{ip: "10.0.0.2", expected: false},
+		{ip: "10.0.0.3", expected: false},
+	} {
+		cfg := &steps.Config{Node: model.Machine{PrivateIp: tc.ip}}
+		ok, msg, err := NodeReady()(clients, cfg)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, ok, msg)
+	}
+}
+
+func TestDeploymentAvailable(t *testing.T) {
+	replicas := int32(2)
+	d := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system", Generation: 2},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 2,
+			UpdatedReplicas:    2,
+			AvailableReplicas:  1,
+		},
+	}
+	clients := Clients{Kube: fake.NewSimpleClientset(d)}
+
+	ok, _, err := DeploymentAvailable("kube-system", "coredns")(clients, &steps.Config{})
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	d.Status.AvailableReplicas = 2
+	clients = Clients{Kube: fake.NewSimpleClientset(d)}
+	ok, _, err = DeploymentAvailable("kube-system", "coredns")(clients, &steps.Config{})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	_, _, err = DeploymentAvailable("kube-system", "tiller")(clients, &steps.Config{})
+	require.Error(t, err)
+}
+
+func TestCRDEstablished(t *testing.T) {
+	crd := func(status string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"status": map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "NamesAccepted", "status": "True"},
+					map[string]interface{}{"type": "Established", "status": status},
+				},
+			},
+		}}
+	}
+
+	for _, tc := range []struct {
+		description string
+		crds        map[string]*fakeCRDs
+		expected    bool
+		hasErr      bool
+	}{
+		{
+			description: "v1 established",
+			crds:        map[string]*fakeCRDs{"v1": {crd: crd("True")}},
+			expected:    true,
+		},
+		{
+			description: "v1beta1 only",
+			crds:        map[string]*fakeCRDs{"v1beta1": {crd: crd("True")}},
+			expected:    true,
+		},
+		{
+			description: "not established",
+			crds:        map[string]*fakeCRDs{"v1": {crd: crd("False")}},
+		},
+		{
+			description: "not found",
+			hasErr:      true,
+		},
+	} {
+		clients := Clients{Dynamic: &fakeDynamic{crds: tc.crds}}
+		ok, _, err := CRDEstablished("certificates.certmanager.k8s.io")(clients, &steps.Config{})
+		require.Equal(t, tc.hasErr, err != nil, tc.description)
+		require.Equal(t, tc.expected, ok, tc.description)
+	}
+}
+
+func TestStep_Run(t *testing.T) {
+	checks := 0
+	s := New("test", "", func(Clients, *steps.Config) (bool, string, error) {
+		checks++
+		if checks < 3 {
+			return false, "", k8serrors.NewServiceUnavailable("api is restarting")
+		}
+		return true, "done", nil
+	}, time.Second)
+	s.interval = time.Millisecond
+	s.getClients = func(*steps.Config) (Clients, error) { return Clients{}, nil }
+
+	out := &bytes.Buffer{}
+	require.NoError(t, s.Run(context.Background(), out, &steps.Config{}))
+	require.Equal(t, 3, checks)
+	require.Contains(t, out.String(), "api is restarting")
+}
+
+func TestStep_RunTimeout(t *testing.T) {
+	s := New("test", "", func(Clients, *steps.Config) (bool, string, error) {
+		return false, "1 of 3 nodes are ready", nil
+	}, time.Millisecond*50)
+	s.interval = time.Millisecond * 10
+	s.maxInterval = time.Millisecond * 20
+	s.getClients = func(*steps.Config) (Clients, error) { return Clients{}, nil }
+
+	err := s.Run(context.Background(), &bytes.Buffer{}, &steps.Config{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "1 of 3 nodes are ready")
+}
+
+func TestReleaseReady(t *testing.T) {
+	replicas := int32(2)
+	d := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "apps", Labels: map[string]string{"release": "web"}},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsv1.DeploymentStatus{UpdatedReplicas: 2, AvailableReplicas: 2},
+	}
+	s := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "apps", Labels: map[string]string{"app.kubernetes.io/instance": "web"}},
+		Status:     appsv1.StatefulSetStatus{ReadyReplicas: 0},
+	}
+	other := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "apps", Labels: map[string]string{"release": "other"}},
+		Status:     appsv1.DaemonSetStatus{DesiredNumberScheduled: 1},
+	}
+	config := &steps.Config{InstallAppConfig: steps.InstallAppConfig{Name: "web", Namespace: "apps"}}
+
+	ok, msg, err := ReleaseReady()(Clients{Kube: fake.NewSimpleClientset(d, s, other)}, config)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Contains(t, msg, "statefulset/db")
+
+	s.Status.ReadyReplicas = 1
+	ok, msg, err = ReleaseReady()(Clients{Kube: fake.NewSimpleClientset(d, s, other)}, config)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Contains(t, msg, "2 workloads")
+
+	ok, _, err = ReleaseReady()(Clients{Kube: fake.NewSimpleClientset()}, &steps.Config{})
+	require.NoError(t, err)
+	require.True(t, ok)
+}
@@ -0,0 +1,71 @@
+package windows
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	restartMarker = "restart to finish"
+
+	// restartDelay keeps next steps from connecting before the machine goes down
+	restartDelay = time.Minute
+)
+
+// PrepareStep installs windows features and tools that
+// are needed to run containers on a windows node.
+type PrepareStep struct {
+	script       *template.Template
+	restartDelay time.Duration
+}
+
+func NewPrepare(script *template.Template) *PrepareStep {
+	return &PrepareStep{
+		script:       script,
+		restartDelay: restartDelay,
+	}
+}
+
+func (s *PrepareStep) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	buf := &bytes.Buffer{}
+	err := steps.RunTemplate(ctx, s.script, config.Runner, io.MultiWriter(out, buf), toStepCfg(config))
+	if err != nil {
+		return errors.Wrap(err, "windows prepare step")
+	}
+
+	if !strings.Contains(buf.String(), restartMarker) {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(s.restartDelay):
+	}
+
+	return nil
+}
+
+func (s *PrepareStep) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *PrepareStep) Name() string {
+	return PrepareStepName
+}
+
+func (s *PrepareStep) Description() string {
+	return "Install the Containers feature on a windows node"
+}
+
+func (s *PrepareStep) Depends() []string {
+	return nil
+}
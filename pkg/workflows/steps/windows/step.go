@@ -0,0 +1,51 @@
+package windows
+
+import (
+	"context"
+	"io"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+// Step runs a PowerShell script that configures a windows node.
+type Step struct {
+	name        string
+	description string
+	script      *template.Template
+}
+
+func New(name, description string, script *template.Template) *Step {
+	return &Step{
+		name:        name,
+		description: description,
+		script:      script,
+	}
+}
+
+func (s *Step) Run(ctx context.Context, out io.Writer, config *steps.Config) error {
+	err := steps.RunTemplate(ctx, s.script, config.Runner, out, toStepCfg(config))
+	if err != nil {
+		return errors.Wrapf(err, "%s step", s.name)
+	}
+
+	return nil
+}
+
+func (s *Step) Rollback(context.Context, io.Writer, *steps.Config) error {
+	return nil
+}
+
+func (s *Step) Name() string {
+	return s.name
+}
+
+func (s *Step) Description() string {
+	return s.description
+}
+
+func (s *Step) Depends() []string {
+	return []string{PrepareStepName}
+}
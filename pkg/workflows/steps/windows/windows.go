@@ -0,0 +1,96 @@
+package windows
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	tm "github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+const (
+	PrepareStepName    = "windows_prepare"
+	ContainerdStepName = "windows_containerd"
+	KubeletStepName    = "windows_kubelet"
+	CNIStepName        = "windows_cni"
+
+	containerdVersion = "1.4.4"
+	cniVersion        = "0.8.7"
+	flannelVersion    = "0.13.0"
+	nssmVersion       = "2.24"
+)
+
+type Config struct {
+	K8SVersion        string
+	ContainerdVersion string
+	CNIVersion        string
+	FlannelVersion    string
+	NSSMVersion       string
+
+	NodeName        string
+	NodeIP          string
+	InternalDNSName string
+	APIServerPort   int64
+	Token           string
+	CACertHash      string
+	CIDR            string
+	ServiceCIDR     string
+
+	ExtraArgs  map[string]string
+	NodeLabels string
+	NodeTaints string
+}
+
+func Init() {
+	steps.RegisterStep(PrepareStepName, NewPrepare(getTemplate(PrepareStepName)))
+	steps.RegisterStep(ContainerdStepName, New(ContainerdStepName,
+		"Install containerd on a windows node", getTemplate(ContainerdStepName)))
+	steps.RegisterStep(KubeletStepName, New(KubeletStepName,
+		"Join a windows node to the cluster", getTemplate(KubeletStepName)))
+	steps.RegisterStep(CNIStepName, New(CNIStepName,
+		"Run flannel and kube-proxy on a windows node", getTemplate(CNIStepName)))
+}
+
+func getTemplate(name string) *template.Template {
+	tpl, err := tm.GetTemplate(name)
+
+	if err != nil {
+		panic(fmt.Sprintf("template %s not found", name))
+	}
+
+	return tpl
+}
+
+func toStepCfg(c *steps.Config) Config {
+	return Config{
+		K8SVersion:        c.Kube.K8SVersion,
+		ContainerdVersion: containerdVersion,
+		CNIVersion:        cniVersion,
+		FlannelVersion:    flannelVersion,
+		NSSMVersion:       nssmVersion,
+		NodeName:          c.Node.Name,
+		NodeIP:            c.Node.PrivateIp,
+		InternalDNSName:   c.Kube.InternalDNSName,
+		APIServerPort:     c.Kube.APIServerPort,
+		Token:             c.JoinToken(),
+		CACertHash:        c.Kube.Auth.CACertHash,
+		CIDR:              c.Kube.Networking.CIDR,
+		ServiceCIDR:       c.Kube.ServicesCIDR,
+		ExtraArgs:         c.KubeletConfig.ExtraArgs,
+		NodeLabels:        joinLabels(c.KubeletConfig.Labels),
+		NodeTaints:        strings.Join(c.KubeletConfig.Taints, ","),
+	}
+}
+
+func joinLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	// keep the rendered config stable
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,149 @@
+package windows
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/templatemanager"
+	"github.com/supergiant/control/pkg/testutils"
+	"github.com/supergiant/control/pkg/workflows/steps"
+)
+
+func testConfig(r *testutils.MockRunner) *steps.Config {
+	return &steps.Config{
+		Kube: model.Kube{
+			K8SVersion:      "1.18.6",
+			InternalDNSName: "10.0.0.100",
+			APIServerPort:   443,
+			BootstrapToken:  "abcdef.0123456789abcdef",
+			ServicesCIDR:    "10.96.0.0/12",
+			Networking: model.Networking{
+				CIDR: "10.244.0.0/16",
+			},
+			Auth: model.Auth{
+				CACertHash: "sha256:1234",
+			},
+		},
+		Node: model.Machine{
+			Name:      "test-node-1234",
+			PrivateIp: "10.0.0.5",
+		},
+		KubeletConfig: steps.KubeletConfig{
+			Labels: map[string]string{"role": "dotnet", "disk": "ssd"},
+			Taints: []string{"os=windows:NoSchedule"},
+		},
+		Runner: r,
+	}
+}
+
+func TestSteps(t *testing.T) {
+	if err := templatemanager.Init("../../../../templates"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		expected []string
+	}{
+		{
+			name: ContainerdStepName,
+			expected: []string{
+				"containerd-1.4.4-windows-amd64.tar.gz",
+				"--register-service",
+			},
+		},
+		{
+			name: KubeletStepName,
+			expected: []string{
+				"https://dl.k8s.io/v1.18.6/bin/windows/amd64/$bin",
+				"--hostname-override=test-node-1234",
+				"node-ip: 10.0.0.5",
+				`node-labels: "disk=ssd,role=dotnet"`,
+				`register-with-taints: "os=windows:NoSchedule"`,
+				"token: abcdef.0123456789abcdef",
+				"apiServerEndpoint: 10.0.0.100:443",
+				"- sha256:1234",
+			},
+		},
+		{
+			name: CNIStepName,
+			expected: []string{
+				"cni-plugins-windows-amd64-v0.8.7.tgz",
+				`"Network": "10.244.0.0/16"`,
+				`"DestinationPrefix": "10.96.0.0/12"`,
+				"NODE_NAME=test-node-1234",
+				"--iface=10.0.0.5",
+			},
+		},
+	} {
+		output := &bytes.Buffer{}
+		step := New(tc.name, "", getTemplate(tc.name))
+
+		if err := step.Run(context.Background(), output, testConfig(&testutils.MockRunner{})); err != nil {
+			t.Fatalf("%s: unexpected error %v", tc.name, err)
+		}
+
+		for _, expected := range tc.expected {
+			if !strings.Contains(output.String(), expected) {
+				t.Errorf("%s: %s not found in output %s", tc.name, expected, output.String())
+			}
+		}
+
+		if step.Name() != tc.name {
+			t.Errorf("wrong step name %s", step.Name())
+		}
+	}
+}
+
+func TestStepError(t *testing.T) {
+	if err := templatemanager.Init("../../../../templates"); err != nil {
+		t.Fatal(err)
+	}
+
+	errMsg := "error has occurred"
+	step := New(KubeletStepName, "", getTemplate(KubeletStepName))
+	err := step.Run(context.Background(), &bytes.Buffer{}, testConfig(&testutils.MockRunner{
+		Err: errors.New(errMsg),
+	}))
+
+	if err == nil || !strings.Contains(err.Error(), errMsg) {
+		t.Errorf("error must contain %s, got %v", errMsg, err)
+	}
+}
+
+func TestPrepare(t *testing.T) {
+	if err := templatemanager.Init("../../../../templates"); err != nil {
+		t.Fatal(err)
+	}
+
+	step := NewPrepare(getTemplate(PrepareStepName))
+	step.restartDelay = time.Millisecond
+
+	output := &bytes.Buffer{}
+	if err := step.Run(context.Background(), output, testConfig(&testutils.MockRunner{})); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, expected := range []string{
+		"nssm-2.24.zip",
+		"Install-WindowsFeature -Name Containers",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("%s not found in output %s", expected, output.String())
+		}
+	}
+
+	// the mock runner echoes the script that contains the restart marker
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	step.restartDelay = time.Hour
+	if err := step.Run(ctx, &bytes.Buffer{}, testConfig(&testutils.MockRunner{})); errors.Cause(err) != context.Canceled {
+		t.Errorf("restart wait must be cancelled, got %v", err)
+	}
+}
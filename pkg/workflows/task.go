@@ -4,15 +4,24 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"runtime/debug"
+	"time"
 
 	"github.com/pborman/uuid"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
 
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/redact"
+	"github.com/supergiant/control/pkg/runner"
 	"github.com/supergiant/control/pkg/sgerrors"
+	"github.com/supergiant/control/pkg/sglog"
 	"github.com/supergiant/control/pkg/storage"
+	"github.com/supergiant/control/pkg/timeline"
+	"github.com/supergiant/control/pkg/tracing"
 	"github.com/supergiant/control/pkg/util"
 	"github.com/supergiant/control/pkg/workflows/statuses"
 	"github.com/supergiant/control/pkg/workflows/steps"
@@ -20,6 +29,23 @@ import (
 
 type TaskType string
 
+// credentialFields of cloud accounts and keys of kubes aren't stored with
+// tasks, they are filled from the cloud account and the kube when the task
+// is restarted. Tokens of nodes and new keys of rotations are lost.
+var credentialFields = []string{
+	"config.awsConfig.access_key",
+	"config.awsConfig.secret_key",
+	"config.digitalOceanConfig.accessToken",
+	"config.gceConfig.private_key_id",
+	"config.gceConfig.private_key",
+	"config.azureConfig.clientSecret",
+	"config.dnsConfig.credentials",
+	"config.kube.sshConfig.bootstrapPrivateKey",
+	"config.kube.cloudSpec." + clouds.AwsSshBootstrapPrivateKey,
+	"config.nodeToken",
+	"config.sshKeyRotationConfig.privateKey",
+}
+
 const (
 	MasterTask       = "master"
 	NodeTask         = "node"
@@ -38,6 +64,12 @@ type Task struct {
 	Config       *steps.Config   `json:"config"`
 	Status       statuses.Status `json:"status"`
 	StepStatuses []StepStatus    `json:"stepsStatuses"`
+	Progress     Progress        `json:"progress"`
+	CreatedAt    time.Time       `json:"createdAt"`
+	// Error is the failure of the last failed step
+	Error *sgerrors.Detail `json:"error,omitempty"`
+	// Diagnostics are collected after the task has failed
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
 
 	workflow   Workflow
 	repository storage.Interface
@@ -66,6 +98,10 @@ func NewTask(config *steps.Config, taskType string, repository storage.Interface
 	t.Status = statuses.Todo
 	t.Config = config
 
+	if err := t.loadAverages(context.Background()); err != nil {
+		logrus.Warnf("task %s: load step averages: %v", t.ID, err)
+	}
+
 	// Try to sync the task at first time
 	err := t.sync(context.Background())
 
@@ -78,6 +114,7 @@ func newTask(workflowType string, workflow Workflow, repository storage.Interfac
 		Type:         workflowType,
 		Status:       statuses.Todo,
 		StepStatuses: make([]StepStatus, 0, 0),
+		CreatedAt:    time.Now(),
 
 		workflow:   workflow,
 		repository: repository,
@@ -98,12 +135,19 @@ func (t *Task) Run(ctx context.Context, config steps.Config, out io.WriteCloser)
 		return errChan
 	}
 
+	if !running.begin() {
+		errChan <- ErrShuttingDown
+		return errChan
+	}
+
+	log := taskLogger(t.ID, config.Kube.ID)
 	go func() {
+		defer running.done()
 		defer func() {
 			if r := recover(); r != nil {
 				t.Status = statuses.Error
 				if err := t.sync(ctx); err != nil {
-					logrus.Errorf("sync error %v for task %s", err, t.ID)
+					log.Errorf("sync error %v for task %s", err, t.ID)
 				}
 				debug.PrintStack()
 				errChan <- errors.Errorf("provisioning failed, unexpected panic: %v ", r)
@@ -114,7 +158,7 @@ func (t *Task) Run(ctx context.Context, config steps.Config, out io.WriteCloser)
 
 		// Save task state before first step
 		if err := t.sync(ctx); err != nil {
-			logrus.Errorf("Error saving task state %v", err)
+			log.Errorf("Error saving task state %v", err)
 		}
 
 		startIndex := 0
@@ -126,25 +170,48 @@ func (t *Task) Run(ctx context.Context, config steps.Config, out io.WriteCloser)
 			}
 		}
 
-		logrus.Debugf("start task from step #%d startIndex %s",
+		log.Debugf("start task from step #%d startIndex %s",
 			startIndex, t.StepStatuses[startIndex].StepName)
 
+		// steps are cancelled when the shutdown deadline is exceeded
+		stepsCtx, cancel := running.context(ctx)
+		defer cancel()
+
 		// Start from the first step
-		err := t.startFrom(ctx, t.ID, out, startIndex)
+		taskCtx, span := tracing.StartSpan(stepsCtx, "task "+t.Type,
+			trace.StringAttribute("task.id", t.ID),
+			trace.StringAttribute("kube.id", config.Kube.ID),
+			trace.StringAttribute("provider", string(config.Provider)))
+		err := t.startFrom(taskCtx, t.ID, out, startIndex)
+		tracing.End(span, err)
 
 		if err != nil {
-			if ctx.Err() == context.Canceled {
+			if IsInterrupted(err) {
+				t.Status = statuses.Interrupted
+				// ctx may be cancelled by shutdown already
+				if err := t.sync(context.Background()); err != nil {
+					log.Errorf("failed to sync task %s to db: %v", t.ID, err)
+				}
+				log.Infof("Task %s has been interrupted by shutdown", t.ID)
+				errChan <- err
+			} else if ctx.Err() == context.Canceled {
 				t.Status = statuses.Cancelled
 				// Save task in cancelled state
 				if err := t.sync(context.Background()); err != nil {
-					logrus.Errorf("failed to sync task %s to db: %v", t.ID, err)
+					log.Errorf("failed to sync task %s to db: %v", t.ID, err)
 				}
 				errChan <- ctx.Err()
 			} else {
 				t.Status = statuses.Error
 				if err := t.sync(ctx); err != nil {
-					logrus.Errorf("failed to sync task %s to db: %v", t.ID, err)
+					log.Errorf("failed to sync task %s to db: %v", t.ID, err)
 				}
+				timeline.Export(&timeline.Event{
+					KubeID:  config.Kube.ID,
+					Type:    timeline.TaskFailed,
+					Object:  t.ID,
+					Message: fmt.Sprintf("%s task has failed: %v", t.Type, err),
+				})
 				errChan <- err
 			}
 
@@ -155,10 +222,10 @@ func (t *Task) Run(ctx context.Context, config steps.Config, out io.WriteCloser)
 		t.Status = statuses.Success
 
 		if err := t.sync(ctx); err != nil {
-			logrus.Errorf("failed to sync task %s to db: %v", t.ID, err)
+			log.Errorf("failed to sync task %s to db: %v", t.ID, err)
 		}
 
-		logrus.Infof("Task %s has finished successfully", t.ID)
+		log.Infof("Task %s has finished successfully", t.ID)
 		// Notify provisioner that task output closed with error
 		if err := out.Close(); err != nil {
 			errChan <- err
@@ -175,45 +242,90 @@ func (w *Task) startFrom(ctx context.Context, id string, out io.Writer, i int) e
 	wsLog := util.GetLogger(out)
 	for index := i; index < len(w.StepStatuses); index++ {
 		step := w.workflow[index]
+		log := taskLogger(id, w.Config.Kube.ID).WithField(sglog.Step, step.Name())
+
+		// control shuts down, the task is resumed from this step
+		if running.isDraining() {
+			wsLog.Infof("[%s] - interrupted by shutdown", step.Name())
+			return ErrInterrupted
+		}
 
 		wsLog.Infof("[%s] - started", step.Name())
-		logrus.Info(step.Name())
+		log.Info("started")
 
 		// sync to storage with task in executing state
+		started := time.Now()
 		w.Status = statuses.Executing
 		w.StepStatuses[index].Status = statuses.Executing
+		w.StepStatuses[index].StartedAt = &started
+		w.StepStatuses[index].FinishedAt = nil
 
 		if err := w.sync(ctx); err != nil {
-			logrus.Errorf("sync error %v", err)
+			log.Errorf("sync error %v", err)
 		}
 
-		if err := step.Run(ctx, out, w.Config); err != nil {
+		// results of the commands are kept with status of the step
+		w.Config.TakeCommandResults()
+		stepCtx, span := tracing.StartSpan(ctx, "step "+step.Name(),
+			trace.StringAttribute("task.id", id),
+			trace.StringAttribute("node", w.Config.Node.Name))
+		err := injectFault(stepCtx, step.Name())
+		if err == nil {
+			err = step.Run(sglog.WithEntry(stepCtx, log), out, w.Config)
+		}
+		tracing.End(span, err)
+		w.StepStatuses[index].Results = redactResults(w.Config.TakeCommandResults())
+
+		// the step has been cancelled by shutdown, it's run again on resume
+		// so it isn't rolled back
+		if err != nil && running.isDraining() && ctx.Err() != nil {
+			w.StepStatuses[index].Status = statuses.Todo
+			wsLog.Infof("[%s] - interrupted by shutdown: %v", step.Name(), err)
+			return ErrInterrupted
+		}
+
+		if err != nil {
 			// Mark step status as error
+			finished := time.Now()
 			w.StepStatuses[index].Status = statuses.Error
+			w.StepStatuses[index].FinishedAt = &finished
 			w.Status = statuses.Error
 			w.StepStatuses[index].ErrMsg = err.Error()
+			w.StepStatuses[index].Error = sgerrors.DetailOf(sgerrors.WithStep(err, step.Name()))
+			w.Error = w.StepStatuses[index].Error
 			if err := w.sync(ctx); err != nil {
-				logrus.Errorf("error syncing %v", err)
+				log.Errorf("error syncing %v", err)
 			}
 
 			wsLog.Infof("[%s] - failed: %s", step.Name(), err.Error())
 			if err2 := w.sync(ctx); err2 != nil {
-				logrus.Errorf("sync error %v for step %s", err2, step.Name())
+				log.Errorf("sync error %v for step %s", err2, step.Name())
 			}
 
-			if err3 := step.Rollback(ctx, out, w.Config); err3 != nil {
-				logrus.Errorf("rollback: step %s : %v", step.Name(), err3)
+			rollbackCtx, span := tracing.StartSpan(ctx, "rollback "+step.Name(),
+				trace.StringAttribute("task.id", id))
+			err3 := step.Rollback(sglog.WithEntry(rollbackCtx, log), out, w.Config)
+			tracing.End(span, err3)
+			if err3 != nil {
+				log.Errorf("rollback: %v", err3)
 			}
 
 			return err
 		} else {
 			wsLog.Infof("[%s] - success", step.Name())
 			// Mark step as success
+			finished := time.Now()
 			w.StepStatuses[index].Status = statuses.Success
 			w.StepStatuses[index].ErrMsg = ""
+			w.StepStatuses[index].Error = nil
+			w.Error = nil
+			w.StepStatuses[index].FinishedAt = &finished
+			if err := recordDuration(ctx, w.Type, step.Name(), finished.Sub(started)); err != nil {
+				log.Warnf("record step duration: %v", err)
+			}
 			w.Status = statuses.Success
 			if err := w.sync(ctx); err != nil {
-				logrus.Errorf("sync error %v for step %s", err, step.Name())
+				log.Errorf("sync error %v for step %s", err, step.Name())
 			}
 		}
 	}
@@ -221,8 +333,17 @@ func (w *Task) startFrom(ctx context.Context, id string, out io.Writer, i int) e
 	return nil
 }
 
+// taskLogger returns the logger of steps with fields of the task.
+func taskLogger(id, clusterID string) *logrus.Entry {
+	return sglog.Logger(sglog.Steps).WithFields(logrus.Fields{
+		sglog.TaskID:    id,
+		sglog.ClusterID: clusterID,
+	})
+}
+
 // synchronize state of workflow to storage
 func (w *Task) sync(ctx context.Context) error {
+	w.Progress = w.progress(time.Now())
 	data, err := json.Marshal(w)
 	buf := &bytes.Buffer{}
 
@@ -230,6 +351,12 @@ func (w *Task) sync(ctx context.Context) error {
 		return err
 	}
 
+	data, err = redact.Fields(data, credentialFields...)
+
+	if err != nil {
+		return err
+	}
+
 	err = json.Indent(buf, data, "", "\t")
 
 	if err != nil {
@@ -238,3 +365,14 @@ func (w *Task) sync(ctx context.Context) error {
 
 	return w.repository.Put(ctx, Prefix, w.ID, buf.Bytes())
 }
+
+// redactResults masks secrets of the command output.
+func redactResults(results []runner.Result) []runner.Result {
+	for i := range results {
+		results[i].Command = redact.String(results[i].Command)
+		results[i].Stdout = redact.String(results[i].Stdout)
+		results[i].Stderr = redact.String(results[i].Stderr)
+	}
+
+	return results
+}
@@ -2,8 +2,13 @@ package workflows
 
 import (
 	"encoding/json"
-	"github.com/supergiant/control/pkg/runner/ssh"
+
+	"github.com/supergiant/control/pkg/clouds"
+	"github.com/supergiant/control/pkg/model"
+	"github.com/supergiant/control/pkg/redact"
+	"github.com/supergiant/control/pkg/runner"
 	"github.com/supergiant/control/pkg/storage"
+	"github.com/supergiant/control/pkg/workflows/steps/ssh"
 )
 
 func DeserializeTask(data []byte, repository storage.Interface) (*Task, error) {
@@ -17,24 +22,66 @@ func DeserializeTask(data []byte, repository storage.Interface) (*Task, error) {
 	// Assign repository from task handler to task and restore workflow
 	task.repository = repository
 	task.workflow = GetWorkflow(task.Type)
+	// keys aren't stored, the runner is connected by FillKeys
+	if task.Config != nil && task.Config.Kube.SSHConfig.BootstrapPrivateKey != redact.Mask {
+		if err = task.connect(); err != nil {
+			return nil, err
+		}
+	}
+
+	return task, nil
+}
+
+// FillKeys sets keys of the task that aren't stored from its kube and
+// connects the runner with them. Tokens of nodes and new keys of rotations
+// are lost and cleared.
+func FillKeys(task *Task, k *model.Kube) error {
+	config := task.Config
+	if config == nil {
+		return nil
+	}
+
+	if config.NodeToken == redact.Mask {
+		config.NodeToken = ""
+	}
+	if config.SSHKeyRotationConfig.PrivateKey == redact.Mask {
+		config.SSHKeyRotationConfig.PrivateKey = ""
+	}
+	if config.Kube.CloudSpec[clouds.AwsSshBootstrapPrivateKey] == redact.Mask {
+		config.Kube.CloudSpec[clouds.AwsSshBootstrapPrivateKey] = k.CloudSpec[clouds.AwsSshBootstrapPrivateKey]
+	}
+	if config.Kube.SSHConfig.BootstrapPrivateKey != redact.Mask {
+		return nil
+	}
+	config.Kube.SSHConfig.BootstrapPrivateKey = k.SSHConfig.BootstrapPrivateKey
+
+	return task.connect()
+}
+
+// connect creates the runner of the machine of the task.
+func (t *Task) connect() error {
 	// NOTE(stgleb): If step has failed on machine creation state
 	// public ip will be blank and lead to error when restart
 	// TODO(stgleb): Move ssh runner creation to task Restart method
-	if task.Config != nil && task.Config.Node.PublicIp != "" {
-		cfg := ssh.Config{
-			Host:    task.Config.Node.PublicIp,
-			Port:    task.Config.Kube.SSHConfig.Port,
-			User:    task.Config.Kube.SSHConfig.User,
-			Timeout: task.Config.Kube.SSHConfig.Timeout,
-			Key:     []byte(task.Config.Kube.SSHConfig.BootstrapPrivateKey),
-		}
+	if !hasRunnerTarget(t.Config.Node, t.Config.Kube.RunnerType) {
+		return nil
+	}
 
-		task.Config.Runner, err = ssh.NewRunner(cfg)
+	r, err := ssh.NewRunner(t.Config.Node, t.Config)
+	if err != nil {
+		return err
+	}
+	t.Config.Runner = r
 
-		if err != nil {
-			return nil, err
-		}
+	return nil
+}
+
+// hasRunnerTarget reports whether the machine can be reached by the runner,
+// ssm runners address machines by instance id instead of ip address.
+func hasRunnerTarget(node model.Machine, runnerType string) bool {
+	if runnerType == runner.SSM {
+		return node.ID != ""
 	}
 
-	return task, nil
+	return node.PublicIp != ""
 }
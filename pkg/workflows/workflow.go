@@ -2,7 +2,10 @@ package workflows
 
 import (
 	"sync"
+	"time"
 
+	"github.com/supergiant/control/pkg/runner"
+	"github.com/supergiant/control/pkg/sgerrors"
 	"github.com/supergiant/control/pkg/workflows/statuses"
 	"github.com/supergiant/control/pkg/workflows/steps"
 	"github.com/supergiant/control/pkg/workflows/steps/addons"
@@ -11,29 +14,47 @@ import (
 	"github.com/supergiant/control/pkg/workflows/steps/authorizedkeys"
 	"github.com/supergiant/control/pkg/workflows/steps/azure"
 	"github.com/supergiant/control/pkg/workflows/steps/bootstraptoken"
+	"github.com/supergiant/control/pkg/workflows/steps/canary"
 	"github.com/supergiant/control/pkg/workflows/steps/certificates"
+	"github.com/supergiant/control/pkg/workflows/steps/chrony"
 	"github.com/supergiant/control/pkg/workflows/steps/cloudcontroller"
 	"github.com/supergiant/control/pkg/workflows/steps/clustercheck"
+	"github.com/supergiant/control/pkg/workflows/steps/cni"
+	"github.com/supergiant/control/pkg/workflows/steps/compliance"
 	"github.com/supergiant/control/pkg/workflows/steps/configmap"
+	"github.com/supergiant/control/pkg/workflows/steps/coredns"
 	"github.com/supergiant/control/pkg/workflows/steps/digitalocean"
 	"github.com/supergiant/control/pkg/workflows/steps/docker"
 	"github.com/supergiant/control/pkg/workflows/steps/downloadk8sbinary"
 	"github.com/supergiant/control/pkg/workflows/steps/drain"
+	"github.com/supergiant/control/pkg/workflows/steps/etcd"
 	"github.com/supergiant/control/pkg/workflows/steps/evacuate"
+	"github.com/supergiant/control/pkg/workflows/steps/fake"
 	"github.com/supergiant/control/pkg/workflows/steps/gce"
+	"github.com/supergiant/control/pkg/workflows/steps/gracefuldelete"
+	"github.com/supergiant/control/pkg/workflows/steps/hardening"
 	"github.com/supergiant/control/pkg/workflows/steps/helm"
+	"github.com/supergiant/control/pkg/workflows/steps/ingress"
 	"github.com/supergiant/control/pkg/workflows/steps/install_app"
 	"github.com/supergiant/control/pkg/workflows/steps/kubeadm"
 	"github.com/supergiant/control/pkg/workflows/steps/kubelet"
 	"github.com/supergiant/control/pkg/workflows/steps/network"
+	"github.com/supergiant/control/pkg/workflows/steps/ospatch"
 	"github.com/supergiant/control/pkg/workflows/steps/poststart"
 	"github.com/supergiant/control/pkg/workflows/steps/prometheus"
 	"github.com/supergiant/control/pkg/workflows/steps/provider"
+	"github.com/supergiant/control/pkg/workflows/steps/registrycredentials"
+	"github.com/supergiant/control/pkg/workflows/steps/runtimeupgrade"
 	"github.com/supergiant/control/pkg/workflows/steps/ssh"
+	"github.com/supergiant/control/pkg/workflows/steps/sshkeys"
 	"github.com/supergiant/control/pkg/workflows/steps/storageclass"
+	"github.com/supergiant/control/pkg/workflows/steps/sysctl"
 	"github.com/supergiant/control/pkg/workflows/steps/tiller"
 	"github.com/supergiant/control/pkg/workflows/steps/uncordon"
 	"github.com/supergiant/control/pkg/workflows/steps/upgrade"
+	"github.com/supergiant/control/pkg/workflows/steps/velero"
+	"github.com/supergiant/control/pkg/workflows/steps/waitfor"
+	"github.com/supergiant/control/pkg/workflows/steps/windows"
 )
 
 // StepStatus aggregates data that is needed to track progress
@@ -42,6 +63,16 @@ type StepStatus struct {
 	Status   statuses.Status `json:"status"`
 	StepName string          `json:"stepName"`
 	ErrMsg   string          `json:"errorMessage"`
+	// Error is the code and the details of the failure of the step
+	Error *sgerrors.Detail `json:"error,omitempty"`
+	// Results of the commands executed by the step on machines
+	Results []runner.Result `json:"results,omitempty"`
+
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	// AverageSeconds is the mean duration of the step in workflows of the
+	// same type, it's zero until the step succeeds once.
+	AverageSeconds float64 `json:"averageSeconds,omitempty"`
 }
 
 // Workflow is a template for doing some actions
@@ -56,7 +87,9 @@ const (
 	DigitalOceanInfra = "digitaloceanInfra"
 	GCEInfra          = "gceInfra"
 	AzureInfra        = "azureInfra"
+	FakeInfra         = "fakeInfra"
 	InstallApp        = "installApp"
+	InstallCatalogApp = "installCatalogApp"
 
 	ProvisionMaster = "ProvisionMaster"
 	ProvisionNode   = "ProvisionNode"
@@ -64,7 +97,32 @@ const (
 	DeleteCluster   = "DeleteCluster"
 	ImportCluster   = "ImportCluster"
 	Upgrade         = "Upgrade"
+	UpgradeVerify   = "UpgradeVerify"
+	JoinToken       = "JoinToken"
 	ApplyYaml       = "ApplyYaml"
+	Compliance      = "Compliance"
+	OSPatch         = "OSPatch"
+	RuntimeUpgrade  = "RuntimeUpgrade"
+	DeleteMaster    = "DeleteMaster"
+	EtcdReplace     = "EtcdReplace"
+	EtcdMaintenance = "EtcdMaintenance"
+	InstallAddons   = "InstallAddons"
+
+	ProvisionWindowsNode = "ProvisionWindowsNode"
+
+	SSHKeyAdd        = "SSHKeyAdd"
+	SSHKeyRemove     = "SSHKeyRemove"
+	SSHKeyPairImport = "SSHKeyPairImport"
+	SSHKeyPairDelete = "SSHKeyPairDelete"
+	SSHKeyAuthorize  = "SSHKeyAuthorize"
+	SSHKeyRevoke     = "SSHKeyRevoke"
+	SSHKeyAudit      = "SSHKeyAudit"
+
+	InstallVelero = "InstallVelero"
+
+	BakeImage = "BakeImage"
+
+	CertificatesRotate = "CertificatesRotate"
 )
 
 type WorkflowSet struct {
@@ -118,19 +176,30 @@ func Init() {
 		steps.GetStep(azure.CreateLBStepName),
 	}
 
+	fakeInfra := []steps.Step{
+		steps.GetStep(fake.CreateLoadBalancerStepName),
+	}
+
 	masterWorkflow := []steps.Step{
 		// TODO(stgleb): Provider steps should also register itsels it step map
 		provider.StepCreateMachine{},
+		provider.AttachStaticIP{},
 		&provider.RegisterInstanceToLoadBalancer{},
 		steps.GetStep(ssh.StepName),
 		steps.GetStep(authorizedkeys.StepName),
 		steps.GetStep(downloadk8sbinary.StepName),
+		steps.GetStep(sysctl.StepName),
+		steps.GetStep(chrony.StepName),
 		steps.GetStep(docker.StepName),
+		steps.GetStep(registrycredentials.StepName),
 		steps.GetStep(certificates.StepName),
 		steps.GetStep(kubeadm.StepName),
 		steps.GetStep(bootstraptoken.StepName),
 		steps.GetStep(kubelet.StepName),
+		steps.GetStep(certificates.ReissueStepName),
 		steps.GetStep(poststart.StepName),
+		steps.GetStep(etcd.SyncStepName),
+		steps.GetStep(hardening.StepName),
 		steps.GetStep(network.StepName),
 		steps.GetStep(clustercheck.StepName),
 		steps.GetStep(helm.StepName),
@@ -142,20 +211,41 @@ func Init() {
 		steps.GetStep(ssh.StepName),
 		steps.GetStep(authorizedkeys.StepName),
 		steps.GetStep(downloadk8sbinary.StepName),
+		steps.GetStep(sysctl.StepName),
+		steps.GetStep(chrony.StepName),
 		steps.GetStep(docker.StepName),
+		steps.GetStep(registrycredentials.StepName),
 		steps.GetStep(certificates.StepName),
+		steps.GetStep(bootstraptoken.NodeStepName),
 		steps.GetStep(kubeadm.StepName),
 		steps.GetStep(kubelet.StepName),
+		steps.GetStep(bootstraptoken.RevokeStepName),
+		steps.GetStep(certificates.ReissueStepName),
 		steps.GetStep(poststart.StepName),
+		steps.GetStep(hardening.StepName),
+	}
+
+	windowsNodeWorkflow := []steps.Step{
+		provider.StepFindWindowsImage{},
+		provider.StepCreateMachine{},
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(windows.PrepareStepName),
+		steps.GetStep(windows.ContainerdStepName),
+		steps.GetStep(bootstraptoken.NodeStepName),
+		steps.GetStep(windows.KubeletStepName),
+		steps.GetStep(bootstraptoken.RevokeStepName),
+		steps.GetStep(windows.CNIStepName),
 	}
 
 	postProvision := []steps.Step{
 		steps.GetStep(ssh.StepName),
 		steps.GetStep(cloudcontroller.StepName),
 		steps.GetStep(storageclass.StepName),
+		steps.GetStep(coredns.StepName),
 		steps.GetStep(tiller.StepName),
 		steps.GetStep(prometheus.StepName),
 		steps.GetStep(configmap.StepName),
+		steps.GetStep(ingress.StepName),
 		addons.Step{},
 		provider.StepPostStartCluster{},
 	}
@@ -169,7 +259,15 @@ func Init() {
 		provider.StepDeleteMachine{},
 	}
 
+	deleteMasterWorkflow := []steps.Step{
+		&provider.DeregisterInstanceFromLoadBalancer{},
+		steps.GetStep(etcd.RemoveStepName),
+		steps.GetStep(drain.StepName),
+		provider.StepDeleteMachine{},
+	}
+
 	deleteClusterWorkflow := []steps.Step{
+		steps.GetStep(gracefuldelete.StepName),
 		provider.DeleteCluster{},
 	}
 
@@ -177,9 +275,22 @@ func Init() {
 		steps.GetStep(ssh.StepName),
 		steps.GetStep(evacuate.StepName),
 		steps.GetStep(upgrade.StepName),
+		steps.GetStep(waitfor.NodeReadyStepName),
 		steps.GetStep(uncordon.StepName),
 	}
 
+	upgradeVerify := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(canary.StepName),
+	}
+
+	// a new token and uploaded certificates let masters join the cluster
+	// after it has been provisioned
+	joinToken := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(bootstraptoken.StepName),
+	}
+
 	apply := []steps.Step{
 		steps.GetStep(ssh.StepName),
 		steps.GetStep(apply.StepName),
@@ -190,6 +301,100 @@ func Init() {
 		steps.GetStep(install_app.StepName),
 	}
 
+	installCatalogApp := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(install_app.StepName),
+		steps.GetStep(waitfor.ReleaseStepName),
+	}
+
+	osPatch := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(evacuate.StepName),
+		steps.GetStep(ospatch.StepName),
+		steps.GetStep(uncordon.StepName),
+	}
+
+	runtimeUpgrade := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(evacuate.StepName),
+		steps.GetStep(runtimeupgrade.StepName),
+		steps.GetStep(uncordon.StepName),
+	}
+
+	etcdReplace := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(etcd.ReplaceStepName),
+	}
+
+	certificatesRotate := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(certificates.ReissueStepName),
+	}
+
+	etcdMaintenance := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(etcd.MaintenanceStepName),
+	}
+
+	sshKeyAdd := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(sshkeys.AddStepName),
+	}
+
+	sshKeyRemove := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(sshkeys.RemoveStepName),
+	}
+
+	sshKeyAuthorize := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(sshkeys.AuthorizeStepName),
+	}
+
+	sshKeyRevoke := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(sshkeys.RevokeStepName),
+	}
+
+	sshKeyAudit := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(sshkeys.AuditStepName),
+	}
+
+	sshKeyPairImport := []steps.Step{
+		steps.GetStep(amazon.ImportKeyPairStepName),
+	}
+
+	sshKeyPairDelete := []steps.Step{
+		steps.GetStep(amazon.DeleteKeyPairStepName),
+	}
+
+	installAddons := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		addons.Step{},
+	}
+
+	installVelero := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(velero.StepName),
+	}
+
+	// the temporary machine is deleted when the image is available
+	bakeImage := []steps.Step{
+		provider.StepCreateMachine{},
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(downloadk8sbinary.StepName),
+		steps.GetStep(docker.StepName),
+		steps.GetStep(cni.StepName),
+		provider.StepCreateImage{},
+		provider.StepDeleteMachine{},
+	}
+
+	complianceCheck := []steps.Step{
+		steps.GetStep(ssh.StepName),
+		steps.GetStep(compliance.StepName),
+	}
+
 	m.Lock()
 	defer m.Unlock()
 
@@ -197,16 +402,38 @@ func Init() {
 	workflowMap[DigitalOceanInfra] = digitalOceanInfra
 	workflowMap[GCEInfra] = gceInfra
 	workflowMap[AzureInfra] = azureInfra
+	workflowMap[FakeInfra] = fakeInfra
 
 	workflowMap[ProvisionMaster] = masterWorkflow
 	workflowMap[ProvisionNode] = nodeWorkflow
+	workflowMap[ProvisionWindowsNode] = windowsNodeWorkflow
 	workflowMap[DeleteNode] = deleteMachineWorkflow
+	workflowMap[DeleteMaster] = deleteMasterWorkflow
 	workflowMap[DeleteCluster] = deleteClusterWorkflow
 	workflowMap[PostProvision] = postProvision
 	workflowMap[ImportCluster] = importClusterWorkflow
 	workflowMap[Upgrade] = upgradeNode
+	workflowMap[UpgradeVerify] = upgradeVerify
+	workflowMap[JoinToken] = joinToken
 	workflowMap[ApplyYaml] = apply
 	workflowMap[InstallApp] = installApp
+	workflowMap[InstallCatalogApp] = installCatalogApp
+	workflowMap[Compliance] = complianceCheck
+	workflowMap[InstallAddons] = installAddons
+	workflowMap[OSPatch] = osPatch
+	workflowMap[RuntimeUpgrade] = runtimeUpgrade
+	workflowMap[EtcdReplace] = etcdReplace
+	workflowMap[EtcdMaintenance] = etcdMaintenance
+	workflowMap[CertificatesRotate] = certificatesRotate
+	workflowMap[SSHKeyAdd] = sshKeyAdd
+	workflowMap[SSHKeyRemove] = sshKeyRemove
+	workflowMap[SSHKeyPairImport] = sshKeyPairImport
+	workflowMap[SSHKeyPairDelete] = sshKeyPairDelete
+	workflowMap[SSHKeyAuthorize] = sshKeyAuthorize
+	workflowMap[SSHKeyRevoke] = sshKeyRevoke
+	workflowMap[SSHKeyAudit] = sshKeyAudit
+	workflowMap[InstallVelero] = installVelero
+	workflowMap[BakeImage] = bakeImage
 }
 
 func RegisterWorkFlow(workflowName string, workflow Workflow) {
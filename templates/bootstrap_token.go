@@ -2,7 +2,7 @@ package templates
 
 const bootstrapTokenTpl = `
 {{ if .IsBootstrap }}
-sudo kubeadm token create {{ .Token }} --ttl 0
+sudo kubeadm token create {{ .Token }} --ttl {{ .TTL }} --description "supergiant: join of masters"
 # Bind uploaded certs secret to bootstrap token
 
 {{ if not .IsImport }}
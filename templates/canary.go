@@ -0,0 +1,24 @@
+package templates
+
+const canaryTpl = `
+set -e
+{{- if .AddonHealth }}
+for KIND in deployment daemonset; do
+  for NAME in $(sudo kubectl -n kube-system get $KIND -o name); do
+    sudo kubectl -n kube-system rollout status $NAME --timeout={{ .Timeout }}s
+  done
+done
+{{- end }}
+{{- if .JobName }}
+sudo kubectl -n {{ .Namespace }} delete job {{ .JobName }} --ignore-not-found
+cat <<'SG_SMOKE_TEST_EOF' | sudo kubectl -n {{ .Namespace }} apply -f -
+{{ .Job }}
+SG_SMOKE_TEST_EOF
+if ! sudo kubectl -n {{ .Namespace }} wait --for=condition=complete job/{{ .JobName }} --timeout={{ .Timeout }}s; then
+  sudo kubectl -n {{ .Namespace }} logs job/{{ .JobName }} --tail=50 || true
+  echo "smoke test job {{ .JobName }} hasn't completed"
+  exit 1
+fi
+sudo kubectl -n {{ .Namespace }} delete job {{ .JobName }}
+{{- end }}
+`
@@ -14,5 +14,13 @@ sudo bash -c "cat > /etc/kubernetes/pki/ca.crt <<EOF
 sudo bash -c "cat > /etc/kubernetes/pki/ca.key <<EOF
 {{ .CAKey }}EOF"
 
+{{ if .EtcdCACert }}
+sudo bash -c "cat > /etc/kubernetes/pki/etcd/ca.crt <<EOF
+{{ .EtcdCACert }}EOF"
+
+sudo bash -c "cat > /etc/kubernetes/pki/etcd/ca.key <<EOF
+{{ .EtcdCAKey }}EOF"
+{{ end }}
+
 {{ end }}
 `
@@ -0,0 +1,41 @@
+package templates
+
+const certificatesReadTpl = `
+{{ range .Certs }}
+{{ if .Wait }}
+for i in $(seq 60); do sudo test -f {{ .Path }} && break; sleep 5; done
+{{ end }}
+if sudo test -f {{ .Path }}; then
+	echo "{{ $.Marker }} {{ .Path }} $(sudo base64 -w0 {{ .Path }})"
+fi
+{{ end }}
+`
+
+const certificatesWriteTpl = `
+set -e
+
+{{ range .Certs }}
+{{ if .KeyPath }}
+sudo bash -c "cat > {{ .Path }} <<EOF
+{{ .Cert }}EOF"
+sudo bash -c "cat > {{ .KeyPath }} <<EOF
+{{ .Key }}EOF"
+sudo chmod 600 {{ .KeyPath }}
+{{ else }}
+sudo bash -c "cat > {{ .Path }} <<EOF
+{{ .Cert }}{{ .Key }}EOF"
+sudo chmod 600 {{ .Path }}
+{{ end }}
+{{ end }}
+
+# Components don't reload certificates, restart them to serve the new ones
+{{ if .RestartEtcd }}
+sudo docker ps -q --filter name=k8s_etcd_ | xargs -r sudo docker restart
+{{ end }}
+{{ if .RestartAPIServer }}
+sudo docker ps -q --filter name=k8s_kube-apiserver_ | xargs -r sudo docker restart
+{{ end }}
+{{ if .RestartKubelet }}
+sudo systemctl restart kubelet
+{{ end }}
+`
@@ -0,0 +1,21 @@
+package templates
+
+const certManagerTpl = `
+set -e
+KUBECTL="sudo kubectl --kubeconfig=/etc/kubernetes/admin.conf"
+
+$KUBECTL apply --validate=false -f https://github.com/jetstack/cert-manager/releases/download/v{{ .Version }}/cert-manager.yaml
+$KUBECTL -n {{ .Namespace }} rollout status deployment cert-manager-webhook --timeout=5m
+
+# the webhook admits issuers a while after its deployment is ready
+n=0
+until $KUBECTL apply -f {{ .IssuersFile }}; do
+  n=$((n+1))
+  if [ $n -ge 30 ]; then
+    sudo rm -f {{ .IssuersFile }}
+    exit 1
+  fi
+  sleep 10
+done
+sudo rm -f {{ .IssuersFile }}
+`
@@ -0,0 +1,36 @@
+package templates
+
+const chronyTpl = `
+set -e
+
+sudo apt-get update -y
+sudo apt-get install -y chrony
+
+# chrony replaces systemd-timesyncd, only one daemon may adjust the clock
+sudo systemctl disable --now systemd-timesyncd || true
+
+sudo bash -c "cat > /etc/chrony/chrony.conf <<EOF
+{{- range .Servers }}
+server {{ . }} iburst
+{{- end }}
+keyfile /etc/chrony/chrony.keys
+driftfile /var/lib/chrony/chrony.drift
+logdir /var/log/chrony
+maxupdateskew 100.0
+rtcsync
+# step the clock on start instead of slewing, certificates must not be
+# issued while the clock is off
+makestep 1 3
+EOF"
+
+sudo systemctl enable chrony
+sudo systemctl restart chrony
+
+# Wait until the clock is synchronized, chronyc checks every 10 seconds
+if ! sudo chronyc waitsync {{ .SyncAttempts }} {{ .MaxOffset }}; then
+	echo "clock is not synchronized"
+	sudo chronyc sources
+	exit 1
+fi
+sudo chronyc tracking
+`
@@ -0,0 +1,9 @@
+package templates
+
+const complianceTpl = `
+sudo docker run --rm --pid=host \
+	-v /etc:/etc:ro \
+	-v /var:/var:ro \
+	-v $(which kubectl):/usr/local/mount-from-host/bin/kubectl:ro \
+	aquasec/kube-bench:{{ .Version }} {{ .Target }} --json
+`
@@ -0,0 +1,146 @@
+package templates
+
+// etcdctlTpl defines an etcdctl wrapper that runs the client from the image
+// of the local etcd static pod with certificates managed by kubeadm.
+const etcdctlTpl = `
+ETCD_MANIFEST=/etc/kubernetes/manifests/etcd.yaml
+ETCD_STOPPED_MANIFEST=/etc/kubernetes/etcd.yaml
+
+if sudo test -f ${ETCD_MANIFEST}; then
+	ETCD_IMAGE=$(sudo grep 'image:' ${ETCD_MANIFEST} | awk '{ print $2 }')
+elif sudo test -f ${ETCD_STOPPED_MANIFEST}; then
+	ETCD_IMAGE=$(sudo grep 'image:' ${ETCD_STOPPED_MANIFEST} | awk '{ print $2 }')
+else
+	echo "etcd is not running on this machine"
+	exit 0
+fi
+
+etcdctl() {
+	sudo docker run --rm --net host -e ETCDCTL_API=3 \
+		-v /etc/kubernetes/pki/etcd:/etc/kubernetes/pki/etcd:ro \
+		${ETCD_IMAGE} etcdctl \
+		--cacert /etc/kubernetes/pki/etcd/ca.crt \
+		--cert /etc/kubernetes/pki/etcd/server.crt \
+		--key /etc/kubernetes/pki/etcd/server.key \
+		"$@"
+}
+`
+
+// etcdWaitSyncTpl waits for the local member, a member answers a health check
+// with a quorum read only after it has caught up with the leader.
+const etcdWaitSyncTpl = `
+ATTEMPTS=0
+until etcdctl --endpoints https://127.0.0.1:2379 endpoint health; do
+	ATTEMPTS=$((ATTEMPTS + 1))
+	if [ ${ATTEMPTS} -ge {{ .SyncAttempts }} ]; then
+		echo "etcd member {{ .PeerIP }} has not synced with the cluster"
+		exit 1
+	fi
+	sleep 10
+done
+
+etcdctl --endpoints https://127.0.0.1:2379 member list
+`
+
+const etcdMemberSyncTpl = `
+set -e
+` + etcdctlTpl + etcdWaitSyncTpl
+
+const etcdMemberRemoveTpl = `
+set -e
+` + etcdctlTpl + `
+ENDPOINTS=https://127.0.0.1:2379
+
+MEMBER_ID=$(etcdctl --endpoints ${ENDPOINTS} member list | grep "https://{{ .PeerIP }}:2380" | cut -d',' -f1)
+if [ -z "${MEMBER_ID}" ]; then
+	echo "etcd member {{ .PeerIP }} not found"
+	exit 0
+fi
+
+# Refuse to remove a member if the rest of the cluster can not keep quorum
+MEMBERS=$(etcdctl --endpoints ${ENDPOINTS} member list | wc -l)
+HEALTHY=$(etcdctl --endpoints ${ENDPOINTS} endpoint health --cluster 2>&1 | grep -v "{{ .PeerIP }}:2379" | grep -c "is healthy" || true)
+QUORUM=$(( (MEMBERS - 1) / 2 + 1 ))
+
+if [ ${HEALTHY} -lt ${QUORUM} ]; then
+	echo "only ${HEALTHY} of $((MEMBERS - 1)) remaining etcd members are healthy, ${QUORUM} required"
+	exit 1
+fi
+
+etcdctl --endpoints ${ENDPOINTS} member remove ${MEMBER_ID}
+`
+
+const etcdMemberReplaceTpl = `
+set -e
+` + etcdctlTpl + `
+ENDPOINTS={{ .Endpoints }}
+
+# Stop the local member
+if sudo test -f ${ETCD_MANIFEST}; then
+	sudo mv ${ETCD_MANIFEST} ${ETCD_STOPPED_MANIFEST}
+fi
+until [ -z "$(sudo docker ps -q --filter name=k8s_etcd_)" ]; do printf '.'; sleep 5; done
+
+{{ if .PeerCert }}
+sudo bash -c "cat > /etc/kubernetes/pki/etcd/peer.crt <<EOF
+{{ .PeerCert }}EOF"
+sudo bash -c "cat > /etc/kubernetes/pki/etcd/peer.key <<EOF
+{{ .PeerKey }}EOF"
+sudo bash -c "cat > /etc/kubernetes/pki/etcd/server.crt <<EOF
+{{ .PeerCert }}EOF"
+sudo bash -c "cat > /etc/kubernetes/pki/etcd/server.key <<EOF
+{{ .PeerKey }}EOF"
+{{ else }}
+sudo rm -f /etc/kubernetes/pki/etcd/peer.* /etc/kubernetes/pki/etcd/server.*
+sudo kubeadm init phase certs etcd-peer
+sudo kubeadm init phase certs etcd-server
+{{ end }}
+sudo chmod 600 /etc/kubernetes/pki/etcd/peer.key /etc/kubernetes/pki/etcd/server.key
+
+ETCD_NAME=$(sudo grep -- '- --name=' ${ETCD_STOPPED_MANIFEST} | cut -d'=' -f2)
+
+MEMBER_ID=$(etcdctl --endpoints ${ENDPOINTS} member list | grep "https://{{ .PeerIP }}:2380" | cut -d',' -f1)
+if [ -n "${MEMBER_ID}" ]; then
+	etcdctl --endpoints ${ENDPOINTS} member remove ${MEMBER_ID}
+fi
+
+INITIAL_CLUSTER=$(etcdctl --endpoints ${ENDPOINTS} member add ${ETCD_NAME} --peer-urls=https://{{ .PeerIP }}:2380 | grep '^ETCD_INITIAL_CLUSTER=' | cut -d'"' -f2)
+if [ -z "${INITIAL_CLUSTER}" ]; then
+	echo "add etcd member ${ETCD_NAME}"
+	exit 1
+fi
+
+# Join the cluster with an empty data directory
+sudo rm -rf /var/lib/etcd/member
+sudo sed -i -e '/- --initial-cluster-state=/d' \
+	-e "s|^\( *\)- --initial-cluster=.*|\1- --initial-cluster=${INITIAL_CLUSTER}\n\1- --initial-cluster-state=existing|" \
+	${ETCD_STOPPED_MANIFEST}
+sudo mv ${ETCD_STOPPED_MANIFEST} ${ETCD_MANIFEST}
+` + etcdWaitSyncTpl
+
+const etcdMaintenanceTpl = `
+set -e
+` + etcdctlTpl + `
+ENDPOINTS=https://127.0.0.1:2379
+
+etcdctl --endpoints ${ENDPOINTS} endpoint health
+
+db_size() {
+	etcdctl --endpoints ${ENDPOINTS} endpoint status -w fields | grep "\"$1\"" | awk '{ print $3 }'
+}
+
+DB_SIZE=$(db_size DBSize)
+DB_SIZE_IN_USE=$(db_size DBSizeInUse)
+DEFRAGMENTED=false
+
+# etcd before 3.4 doesn't report the used db size, such members are always defragmented
+if [ -z "${DB_SIZE_IN_USE}" ] || [ $(( (DB_SIZE - DB_SIZE_IN_USE) * 100 )) -ge $(( DB_SIZE * {{ .DefragThreshold }} )) ]; then
+	etcdctl --endpoints ${ENDPOINTS} defrag
+	etcdctl --endpoints ${ENDPOINTS} endpoint health
+	DB_SIZE=$(db_size DBSize)
+	DB_SIZE_IN_USE=$(db_size DBSizeInUse)
+	DEFRAGMENTED=true
+fi
+
+echo "{{ .Marker }} dbSize=${DB_SIZE} dbSizeInUse=${DB_SIZE_IN_USE:-0} defragmented=${DEFRAGMENTED}"
+`
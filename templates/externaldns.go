@@ -0,0 +1,96 @@
+package templates
+
+const externalDNSTpl = `
+set -e
+KUBECTL="sudo kubectl --kubeconfig=/etc/kubernetes/admin.conf"
+
+$KUBECTL create namespace {{ .Namespace }} --dry-run=client -o yaml | $KUBECTL apply -f -
+$KUBECTL apply -f {{ .SecretFile }}
+sudo rm -f {{ .SecretFile }}
+
+cat << 'EOF' | $KUBECTL apply -f -
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: external-dns
+  namespace: {{ .Namespace }}
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: external-dns
+rules:
+- apiGroups: [""]
+  resources: ["services", "endpoints", "pods"]
+  verbs: ["get", "watch", "list"]
+- apiGroups: ["extensions", "networking.k8s.io"]
+  resources: ["ingresses"]
+  verbs: ["get", "watch", "list"]
+- apiGroups: [""]
+  resources: ["nodes"]
+  verbs: ["list", "watch"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: external-dns
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: external-dns
+subjects:
+- kind: ServiceAccount
+  name: external-dns
+  namespace: {{ .Namespace }}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: external-dns
+  namespace: {{ .Namespace }}
+  labels:
+    app: external-dns
+spec:
+  replicas: 1
+  strategy:
+    type: Recreate
+  selector:
+    matchLabels:
+      app: external-dns
+  template:
+    metadata:
+      labels:
+        app: external-dns
+    spec:
+      serviceAccountName: external-dns
+      containers:
+      - name: external-dns
+        image: {{ .Image }}
+        args:
+        {{- range .Args }}
+        - {{ printf "%q" . }}
+        {{- end }}
+        env:
+        {{- range .Env }}
+        - name: {{ . }}
+          valueFrom:
+            secretKeyRef:
+              name: {{ $.SecretName }}
+              key: {{ . }}
+        {{- end }}
+        {{- if eq .Provider "google" }}
+        - name: GOOGLE_APPLICATION_CREDENTIALS
+          value: {{ .CredentialsFile }}
+        {{- end }}
+        {{- if .CredentialsPath }}
+        volumeMounts:
+        - name: credentials
+          mountPath: {{ .CredentialsPath }}
+          readOnly: true
+      volumes:
+      - name: credentials
+        secret:
+          secretName: {{ .SecretName }}
+        {{- end }}
+EOF
+`
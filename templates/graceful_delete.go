@@ -0,0 +1,45 @@
+package templates
+
+const gracefulDeleteTpl = `
+set -e
+
+deadline=$(( $(date +%s) + {{ .Timeout }} ))
+wait_gone() {
+    while [ -n "$($1)" ]; do
+        if [ $(date +%s) -ge $deadline ]; then
+            echo "$2 are left after {{ .Timeout }}s:" >&2
+            $1 >&2
+            exit 1
+        fi
+        sleep 5
+    done
+}
+
+{{ if eq .Phase "drain" }}
+# evict pods of workers so applications stop and volumes are detached
+sudo kubectl drain --selector='!node-role.kubernetes.io/master' \
+--ignore-daemonsets --force --delete-local-data --timeout={{ .Timeout }}s
+{{ end }}
+
+{{ if eq .Phase "services" }}
+# cloud load balancers are released by the service controller
+lb_services() {
+    sudo kubectl get services --all-namespaces \
+    -o jsonpath='{range .items[?(@.spec.type=="LoadBalancer")]}{.metadata.namespace}{" "}{.metadata.name}{"\n"}{end}'
+}
+lb_services | while read namespace name; do
+    sudo kubectl delete service --namespace $namespace $name --wait=false
+done
+wait_gone lb_services "load balancer services"
+{{ end }}
+
+{{ if eq .Phase "volumes" }}
+# volumes of claims are deleted by their provisioners, retained ones are kept
+deleted_volumes() {
+    sudo kubectl get persistentvolumes \
+    -o jsonpath='{range .items[?(@.spec.persistentVolumeReclaimPolicy=="Delete")]}{.metadata.name}{"\n"}{end}'
+}
+sudo kubectl delete persistentvolumeclaims --all --all-namespaces --wait=false
+wait_gone deleted_volumes "persistent volumes"
+{{ end }}
+`
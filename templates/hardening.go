@@ -0,0 +1,53 @@
+package templates
+
+const hardeningTpl = `
+set -e
+
+# CIS Kubernetes Benchmark: control plane and worker configuration files
+for f in /etc/kubernetes/manifests/*.yaml /etc/kubernetes/admin.conf \
+	/etc/kubernetes/scheduler.conf /etc/kubernetes/controller-manager.conf \
+	/etc/kubernetes/kubelet.conf /var/lib/kubelet/config.yaml \
+	/etc/systemd/system/kubelet.service.d/10-kubeadm.conf; do
+	if [ -e "${f}" ]; then
+		sudo chmod 600 "${f}"
+		sudo chown root:root "${f}"
+	fi
+done
+
+if [ -d /etc/kubernetes/pki ]; then
+	sudo chown -R root:root /etc/kubernetes/pki
+	sudo find /etc/kubernetes/pki -name '*.crt' -exec chmod 644 {} \;
+	sudo find /etc/kubernetes/pki -name '*.key' -exec chmod 600 {} \;
+fi
+
+{{ if .IsMaster }}
+if [ -d /var/lib/etcd ]; then
+	sudo chmod 700 /var/lib/etcd
+fi
+{{ end }}
+
+# CIS Kubernetes Benchmark: kubelet
+KUBELET_CONFIG=/var/lib/kubelet/config.yaml
+if [ -f ${KUBELET_CONFIG} ]; then
+	sudo sed -i '/^readOnlyPort:/d;/^protectKernelDefaults:/d;/^eventRecordQPS:/d;/^streamingConnectionIdleTimeout:/d;/^makeIPTablesUtilChains:/d' ${KUBELET_CONFIG}
+	sudo bash -c "cat >> ${KUBELET_CONFIG} <<EOF
+readOnlyPort: 0
+protectKernelDefaults: true
+eventRecordQPS: {{ .EventRecordQPS }}
+streamingConnectionIdleTimeout: {{ .StreamingConnectionIdleTimeout }}
+makeIPTablesUtilChains: true
+EOF"
+fi
+
+# protectKernelDefaults requires kernel settings the kubelet expects
+sudo bash -c "cat > /etc/sysctl.d/90-kubelet.conf <<EOF
+vm.overcommit_memory=1
+vm.panic_on_oom=0
+kernel.panic=10
+kernel.panic_on_oops=1
+EOF"
+sudo sysctl -p /etc/sysctl.d/90-kubelet.conf
+
+sudo systemctl daemon-reload
+sudo systemctl restart kubelet
+`
@@ -0,0 +1,9 @@
+package templates
+
+const ingressTpl = `
+set -e
+sudo /usr/bin/helm upgrade --install {{ .Release }} {{ .Chart }} \
+    --namespace {{ .Namespace }} \
+    -f {{ .ValuesFile }}
+sudo rm -f {{ .ValuesFile }}
+`
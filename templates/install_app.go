@@ -2,9 +2,9 @@ package templates
 
 const installApp = `
 set -x
-sudo bash -c "cat > override.yaml <<EOF
-{{ .Values }}
-EOF"
-
-sudo helm install {{ .ChartRef }} {{ if .Name }}--name {{ .Name }}{{ end}} --namespace {{ .Namespace }} -f override.yaml --debug 
+{{ if .Upgrade }}
+sudo helm upgrade --install {{ .Name }} {{ .ChartRef }} --namespace {{ .Namespace }} -f {{ .ValuesFile }} --debug
+{{ else }}
+sudo helm install {{ .ChartRef }} {{ if .Name }}--name {{ .Name }}{{ end}} --namespace {{ .Namespace }} -f {{ .ValuesFile }} --debug 
+{{ end }}
 `
@@ -25,6 +25,62 @@ HOSTNAME="$(hostname -f)"
 sudo mkdir -p /etc/supergiant
 
 {{if .IsMaster }}
+{{ if .AuditEnabled }}
+sudo mkdir -p /etc/kubernetes/audit /var/log/kubernetes/audit
+
+sudo bash -c "cat << EOF > /etc/kubernetes/audit/policy.yaml
+apiVersion: audit.k8s.io/v1
+kind: Policy
+omitStages:
+  - RequestReceived
+rules:
+  - level: {{ .AuditLevel }}
+EOF"
+{{ if .AuditWebhookURL }}
+sudo bash -c "cat << EOF > /etc/kubernetes/audit/webhook.yaml
+apiVersion: v1
+kind: Config
+clusters:
+- name: audit-webhook
+  cluster:
+    server: {{ .AuditWebhookURL }}
+contexts:
+- name: default
+  context:
+    cluster: audit-webhook
+    user: audit-webhook
+current-context: default
+users:
+- name: audit-webhook
+  user: {}
+EOF"
+{{ end }}
+{{ end }}
+{{ if .PodSecurity }}
+sudo mkdir -p /etc/kubernetes/admission
+
+sudo bash -c "cat << EOF > /etc/kubernetes/admission/config.yaml
+apiVersion: apiserver.config.k8s.io/v1
+kind: AdmissionConfiguration
+plugins:
+- name: PodSecurity
+  configuration:
+    apiVersion: pod-security.admission.config.k8s.io/{{ .PodSecurityAPIVersion }}
+    kind: PodSecurityConfiguration
+    defaults:
+      enforce: {{ .PodSecurity.Defaults.Enforce }}
+      enforce-version: latest
+      audit: {{ .PodSecurity.Defaults.Audit }}
+      audit-version: latest
+      warn: {{ .PodSecurity.Defaults.Warn }}
+      warn-version: latest
+    exemptions:
+      namespaces:
+{{- range .PodSecurity.ExemptNamespaces }}
+      - {{ . }}
+{{- end }}
+EOF"
+{{ end }}
 {{ if .IsBootstrap }}
 
 sudo bash -c "cat << EOF > /etc/supergiant/kubeadm.conf
@@ -50,19 +106,80 @@ apiServer:
   certSANs:
   - {{ .ExternalDNSName }}
   - {{ .InternalDNSName }}
+{{- range .APIServerSANs }}
+  - {{ . }}
+{{- end }}
   extraArgs:
     authorization-mode: Node,RBAC
     {{ if .Provider }}cloud-provider: {{ .Provider }}{{ end }}
     kubelet-preferred-address-types: InternalIP,Hostname,ExternalIP
+{{ if .EnableAdmissionPlugins }}
+    enable-admission-plugins: {{ .EnableAdmissionPlugins }}
+{{ end }}
+{{ if .DisableAdmissionPlugins }}
+    disable-admission-plugins: {{ .DisableAdmissionPlugins }}
+{{ end }}
+{{ if .CISHardening }}
+    profiling: \"false\"
+{{ end }}
+{{ if .AuditEnabled }}
+    audit-policy-file: /etc/kubernetes/audit/policy.yaml
+    audit-log-path: /var/log/kubernetes/audit/audit.log
+    audit-log-maxage: \"{{ .AuditMaxAge }}\"
+    audit-log-maxbackup: \"{{ .AuditMaxBackup }}\"
+    audit-log-maxsize: \"{{ .AuditMaxSize }}\"
+    {{ if .AuditWebhookURL }}audit-webhook-config-file: /etc/kubernetes/audit/webhook.yaml{{ end }}
+{{ end }}
+{{ if .PodSecurity }}
+    admission-control-config-file: /etc/kubernetes/admission/config.yaml
+{{ end }}
+{{ if or .AuditEnabled .PodSecurity }}
+  extraVolumes:
+{{ end }}
+{{ if .AuditEnabled }}
+  - name: audit-config
+    hostPath: /etc/kubernetes/audit
+    mountPath: /etc/kubernetes/audit
+    readOnly: true
+    pathType: DirectoryOrCreate
+  - name: audit-log
+    hostPath: /var/log/kubernetes/audit
+    mountPath: /var/log/kubernetes/audit
+    pathType: DirectoryOrCreate
+{{ end }}
+{{ if .PodSecurity }}
+  - name: admission-config
+    hostPath: /etc/kubernetes/admission
+    mountPath: /etc/kubernetes/admission
+    readOnly: true
+    pathType: DirectoryOrCreate
+{{ end }}
   timeoutForControlPlane: 8m0s
 controllerManager:
   extraArgs:
     {{ if .Provider }}cloud-provider: {{ .Provider }}{{ end }}
+{{ if .CISHardening }}
+    profiling: \"false\"
+    terminated-pod-gc-threshold: \"10\"
+scheduler:
+  extraArgs:
+    profiling: \"false\"
+{{ end }}
 dns:
   type: CoreDNS
 etcd:
   local:
     dataDir: /var/lib/etcd
+{{- if .EtcdSANs }}
+    serverCertSANs:
+{{- range .EtcdSANs }}
+    - {{ . }}
+{{- end }}
+    peerCertSANs:
+{{- range .EtcdSANs }}
+    - {{ . }}
+{{- end }}
+{{- end }}
 networking:
   dnsDomain: cluster.local
   podSubnet: {{ .CIDR }}
@@ -73,6 +190,16 @@ sudo kubeadm init --ignore-preflight-errors=NumCPU \
 --node-name ${HOSTNAME} \
 --config=/etc/supergiant/kubeadm.conf \
 --upload-certs
+{{ if .PodSecurity }}
+{{ range $ns, $levels := .PodSecurity.Namespaces }}
+sudo kubectl --kubeconfig=/etc/kubernetes/admin.conf create namespace {{ $ns }} --dry-run=client -o yaml | \
+sudo kubectl --kubeconfig=/etc/kubernetes/admin.conf apply -f -
+sudo kubectl --kubeconfig=/etc/kubernetes/admin.conf label namespace {{ $ns }} --overwrite
+{{- if $levels.Enforce }} pod-security.kubernetes.io/enforce={{ $levels.Enforce }}{{ end }}
+{{- if $levels.Audit }} pod-security.kubernetes.io/audit={{ $levels.Audit }}{{ end }}
+{{- if $levels.Warn }} pod-security.kubernetes.io/warn={{ $levels.Warn }}{{ end }}
+{{ end }}
+{{ end }}
 {{ else }}
 
 sudo bash -c "cat << EOF > /etc/supergiant/kubeadm.conf
@@ -103,18 +230,79 @@ apiServer:
   certSANs:
   - {{ .ExternalDNSName }}
   - {{ .InternalDNSName }}
+{{- range .APIServerSANs }}
+  - {{ . }}
+{{- end }}
   extraArgs:
     authorization-mode: Node,RBAC
     {{ if .Provider }}cloud-provider: {{ .Provider }}{{ end }}
+{{ if .EnableAdmissionPlugins }}
+    enable-admission-plugins: {{ .EnableAdmissionPlugins }}
+{{ end }}
+{{ if .DisableAdmissionPlugins }}
+    disable-admission-plugins: {{ .DisableAdmissionPlugins }}
+{{ end }}
+{{ if .CISHardening }}
+    profiling: \"false\"
+{{ end }}
+{{ if .AuditEnabled }}
+    audit-policy-file: /etc/kubernetes/audit/policy.yaml
+    audit-log-path: /var/log/kubernetes/audit/audit.log
+    audit-log-maxage: \"{{ .AuditMaxAge }}\"
+    audit-log-maxbackup: \"{{ .AuditMaxBackup }}\"
+    audit-log-maxsize: \"{{ .AuditMaxSize }}\"
+    {{ if .AuditWebhookURL }}audit-webhook-config-file: /etc/kubernetes/audit/webhook.yaml{{ end }}
+{{ end }}
+{{ if .PodSecurity }}
+    admission-control-config-file: /etc/kubernetes/admission/config.yaml
+{{ end }}
+{{ if or .AuditEnabled .PodSecurity }}
+  extraVolumes:
+{{ end }}
+{{ if .AuditEnabled }}
+  - name: audit-config
+    hostPath: /etc/kubernetes/audit
+    mountPath: /etc/kubernetes/audit
+    readOnly: true
+    pathType: DirectoryOrCreate
+  - name: audit-log
+    hostPath: /var/log/kubernetes/audit
+    mountPath: /var/log/kubernetes/audit
+    pathType: DirectoryOrCreate
+{{ end }}
+{{ if .PodSecurity }}
+  - name: admission-config
+    hostPath: /etc/kubernetes/admission
+    mountPath: /etc/kubernetes/admission
+    readOnly: true
+    pathType: DirectoryOrCreate
+{{ end }}
   timeoutForControlPlane: 8m0s
 controllerManager:
   extraArgs:
     {{ if .Provider }}cloud-provider: {{ .Provider }}{{ end }}
+{{ if .CISHardening }}
+    profiling: \"false\"
+    terminated-pod-gc-threshold: \"10\"
+scheduler:
+  extraArgs:
+    profiling: \"false\"
+{{ end }}
 dns:
   type: CoreDNS
 etcd:
   local:
     dataDir: /var/lib/etcd
+{{- if .EtcdSANs }}
+    serverCertSANs:
+{{- range .EtcdSANs }}
+    - {{ . }}
+{{- end }}
+    peerCertSANs:
+{{- range .EtcdSANs }}
+    - {{ . }}
+{{- end }}
+{{- end }}
 networking:
   dnsDomain: cluster.local
   podSubnet: {{ .CIDR }}
@@ -40,47 +40,19 @@ sudo kubectl --kubeconfig=/home/{{ .UserName }}/.kube/config config set-credenti
 sudo kubectl --kubeconfig=/home/{{ .UserName }}/.kube/config config set-context kubernetes --cluster=kubernetes --user=kubernetes
 sudo kubectl --kubeconfig=/home/{{ .UserName }}/.kube/config config use-context kubernetes
 
-sudo openssl genrsa -out /etc/kubernetes/pki/kubelet.key 2048
-sudo openssl req -new -key /etc/kubernetes/pki/kubelet.key -out /etc/kubernetes/pki/kubelet.csr -subj "/CN=kube-worker"
-
-sudo bash -c "cat > /etc/kubernetes/pki/request.yaml <<EOF
-apiVersion: certificates.k8s.io/v1beta1
-kind: CertificateSigningRequest
-metadata:
-  name: {{ .NodeName }}
-spec:
-  groups:
-  - system:authenticated
-  request: $(cat /etc/kubernetes/pki/kubelet.csr | base64 | tr -d '\n')
-  usages:
-  - digital signature
-  - key encipherment
-  - server auth
-EOF"
-
-until $([ $(sudo kubectl --kubeconfig=/home/{{ .UserName }}/.kube/config get csr |wc -l) -ge 1 ]); do printf '.'; sleep 5; done
-
-sudo kubectl --kubeconfig=/home/{{ .UserName }}/.kube/config create -f /etc/kubernetes/pki/request.yaml
-
-until $([ $(sudo kubectl --kubeconfig=/home/{{ .UserName }}/.kube/config get csr {{ .NodeName }} |wc -l) -ge 1 ]); do printf '.'; sleep 5; done
-
-sudo kubectl --kubeconfig=/home/{{ .UserName }}/.kube/config certificate approve -f /etc/kubernetes/pki/request.yaml
-
-# Wait for csr to be approved
-until $([ $(sudo kubectl --kubeconfig=/home/{{ .UserName }}/.kube/config get csr {{ .NodeName }}|grep Approved|wc -l) -ge 1 ]); do printf '.'; sleep 5; done
-
-sudo bash -c "cat > /etc/kubernetes/pki/kubelet.crt <<EOF
-$(sudo kubectl --kubeconfig=/home/{{ .UserName }}/.kube/config get csr {{ .NodeName }} -o jsonpath='{.status.certificate}' | base64 -d)
-EOF"
-
 sudo rm /etc/kubernetes/pki/admin.key
 sudo rm /etc/kubernetes/pki/admin.crt
 {{ end }}
 
+# kubelets of nodes request serving certificates, control approves requests
+# of machines it provisioned
 sudo bash -c "cat > /etc/default/kubelet <<EOF
-KUBELET_EXTRA_ARGS=--tls-cert-file=/etc/kubernetes/pki/kubelet.crt \
---tls-private-key-file=/etc/kubernetes/pki/kubelet.key \
---rotate-certificates  --feature-gates=RotateKubeletClientCertificate=true
+KUBELET_EXTRA_ARGS={{ if .IsMaster }}--tls-cert-file=/etc/kubernetes/pki/kubelet.crt \
+--tls-private-key-file=/etc/kubernetes/pki/kubelet.key{{ else }}--rotate-server-certificates{{ end }} \
+--rotate-certificates  --feature-gates=RotateKubeletClientCertificate=true{{ if .NodeLabels }} \
+--node-labels={{ .NodeLabels }}{{ end }}{{ if .NodeTaints }} \
+--register-with-taints={{ .NodeTaints }}{{ end }}{{ range $k, $v := .ExtraArgs }} \
+--{{ $k }}={{ $v }}{{ end }}
 EOF"
 
 sudo systemctl daemon-reload
@@ -87,7 +87,9 @@ data:
     {
       "Network": "{{ .CIDR }}",
       "Backend": {
-        "Type": "vxlan"
+        "Type": "vxlan"{{ if .WindowsNodes }},
+        "VNI": 4096,
+        "Port": 4789{{ end }}
       }
     }
 ---
@@ -108,6 +110,7 @@ spec:
     spec:
       hostNetwork: true
       nodeSelector:
+        beta.kubernetes.io/os: linux
         beta.kubernetes.io/arch: amd64
       tolerations:
       - operator: Exists
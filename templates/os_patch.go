@@ -0,0 +1,55 @@
+package templates
+
+const osPatchTpl = `
+set -e
+
+BOOT_ID=$(cat /proc/sys/kernel/random/boot_id)
+REBOOT_REQUIRED=false
+
+if command -v apt-get > /dev/null; then
+	sudo apt-get update -y
+	sudo DEBIAN_FRONTEND=noninteractive apt-get -y \
+		-o Dpkg::Options::="--force-confdef" -o Dpkg::Options::="--force-confold" upgrade
+	if [ -f /var/run/reboot-required ]; then
+		REBOOT_REQUIRED=true
+	fi
+elif command -v yum > /dev/null; then
+	sudo yum -y update
+	sudo yum install -y yum-utils
+	if ! sudo needs-restarting -r > /dev/null; then
+		REBOOT_REQUIRED=true
+	fi
+else
+	echo "unsupported package manager"
+	exit 1
+fi
+
+{{ if .LivePatch }}
+if [ "${REBOOT_REQUIRED}" = true ]; then
+	if (command -v canonical-livepatch > /dev/null && sudo canonical-livepatch status > /dev/null 2>&1) || \
+		(command -v kpatch > /dev/null && sudo kpatch list | grep -q Loaded); then
+		echo "kernel live patching is active, skip reboot"
+		REBOOT_REQUIRED=false
+	fi
+fi
+{{ end }}
+
+if [ "${REBOOT_REQUIRED}" = true ]; then
+	# let the ssh session finish before the machine goes down
+	sudo systemd-run --on-active=10 /bin/systemctl reboot
+	echo "{{ .RebootMarker }}${BOOT_ID}"
+fi
+`
+
+const osPatchWaitTpl = `
+until $(sudo systemctl is-active kubelet > /dev/null); do printf '.'; sleep 5; done
+
+NODENAME=$(sudo kubectl get no -o wide|grep {{ .PrivateIP }}| awk '{ print $1 }')
+
+if [ -z $NODENAME ]
+then
+	exit 0
+fi
+
+until $(sudo kubectl get no $NODENAME | grep -q -w Ready); do printf '.'; sleep 5; done
+`
@@ -0,0 +1,43 @@
+package templates
+
+const registryCredentialsTpl = `
+set -e
+
+{{ if .ECR }}
+# kubelet gets ECR tokens with the instance profile of the machine
+if ! curl -sf http://169.254.169.254/latest/meta-data/iam/security-credentials/ > /dev/null; then
+    echo "ecr: machine has no instance profile" >&2
+    exit 1
+fi
+{{ end }}
+
+{{ if .GCR }}
+# kubelet gets GCR tokens of the service account of the instance, its
+# scopes must allow reading of storage buckets
+if ! curl -sf -H "Metadata-Flavor: Google" \
+    http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/scopes | grep -q devstorage; then
+    echo "gcr: service account of the instance can't read storage" >&2
+    exit 1
+fi
+{{ end }}
+
+{{ if .ACR }}
+# kubelet gets ACR tokens with the managed identity of the vm
+if ! curl -sf -H "Metadata: true" \
+    "http://169.254.169.254/metadata/identity/oauth2/token?api-version=2018-02-01&resource=https://management.azure.com/" > /dev/null; then
+    echo "acr: vm has no managed identity" >&2
+    exit 1
+fi
+
+sudo mkdir -p /etc/kubernetes
+sudo bash -c "cat > {{ .ACRConfigFile }} <<EOF
+{
+  \"cloud\": \"AzurePublicCloud\",
+  \"tenantId\": \"{{ .TenantID }}\",
+  \"subscriptionId\": \"{{ .SubscriptionID }}\",
+  \"useManagedIdentityExtension\": true
+}
+EOF"
+sudo chmod 600 {{ .ACRConfigFile }}
+{{ end }}
+`
@@ -0,0 +1,45 @@
+package templates
+
+const runtimeUpgradeTpl = `
+set -e
+
+DOCKER_VERSION={{ .Version }}
+
+sudo apt-get update -y
+
+FULL_DOCKER_VERSION=$(apt-cache madison docker-ce | cut -d '|' -f2 | tr -d ' ' | grep "${DOCKER_VERSION}" | head -n 1)
+if [ -z "${FULL_DOCKER_VERSION}" ]; then
+	echo "package for the ${DOCKER_VERSION} docker version not found"
+	echo "Available packages:"
+	apt-cache madison docker-ce | cut -d '|' -f2 | tr -d ' '
+	exit 1
+fi
+
+sudo apt-mark unhold docker-ce containerd.io || true
+sudo DEBIAN_FRONTEND=noninteractive apt-get install -y --allow-downgrades \
+	-o Dpkg::Options::="--force-confdef" -o Dpkg::Options::="--force-confold" \
+	docker-ce=${FULL_DOCKER_VERSION} containerd.io
+sudo apt-mark hold docker-ce containerd.io
+
+sudo systemctl daemon-reload
+sudo systemctl restart containerd docker
+sudo systemctl restart kubelet
+
+# Verify the runtime has been upgraded
+ACTUAL_VERSION=$(sudo docker version --format '{{ "{{" }}.Server.Version{{ "}}" }}')
+case "${ACTUAL_VERSION}" in
+	*${DOCKER_VERSION}*) echo "docker ${ACTUAL_VERSION} is running" ;;
+	*) echo "expected docker ${DOCKER_VERSION} actual ${ACTUAL_VERSION}"; exit 1 ;;
+esac
+
+until $(sudo systemctl is-active kubelet > /dev/null); do printf '.'; sleep 5; done
+
+NODENAME=$(sudo kubectl get no -o wide|grep {{ .PrivateIP }}| awk '{ print $1 }')
+
+if [ -z $NODENAME ]
+then
+	exit 0
+fi
+
+until $(sudo kubectl get no $NODENAME | grep -q -w Ready); do printf '.'; sleep 5; done
+`
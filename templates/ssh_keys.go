@@ -0,0 +1,43 @@
+package templates
+
+// sshAuthorizedKeysTpl lists authorized_keys files of the provisioning user and root.
+const sshAuthorizedKeysTpl = `
+USER_HOME=$(getent passwd {{ .User }} | cut -d: -f6)
+AUTHORIZED_KEYS="${USER_HOME}/.ssh/authorized_keys /root/.ssh/authorized_keys"
+`
+
+const sshKeyAddTpl = `
+set -e
+` + sshAuthorizedKeysTpl + `
+for f in ${AUTHORIZED_KEYS}; do
+	sudo mkdir -p $(dirname ${f})
+	sudo touch ${f}
+	if ! sudo grep -qF "{{ .KeyBody }}" ${f}; then
+		echo "{{ .PublicKey }}" | sudo tee -a ${f} > /dev/null
+	fi
+done
+`
+
+const sshKeyRemoveTpl = `
+set -e
+` + sshAuthorizedKeysTpl + `
+for f in ${AUTHORIZED_KEYS}; do
+	if sudo test -f ${f} && sudo grep -qF "{{ .OldKeyBody }}" ${f}; then
+		# keep file permissions and owner, only the content is replaced
+		sudo grep -vF "{{ .OldKeyBody }}" ${f} | sudo tee ${f}.new > /dev/null || true
+		sudo bash -c "cat ${f}.new > ${f}"
+		sudo rm -f ${f}.new
+	fi
+done
+`
+
+// sshKeyAuditTpl prints authorized keys prefixed with their files.
+const sshKeyAuditTpl = `
+set -e
+` + sshAuthorizedKeysTpl + `
+for f in ${AUTHORIZED_KEYS}; do
+	if sudo test -f ${f}; then
+		sudo awk -v f=${f} 'NF && $1 !~ /^#/ { print f " " $0 }' ${f}
+	fi
+done
+`
@@ -0,0 +1,26 @@
+package templates
+
+const sysctlTpl = `
+set -e
+
+# Kernel modules required by kube-proxy and the container runtime
+sudo bash -c "cat > /etc/modules-load.d/kubernetes.conf <<EOF
+{{- range .Modules }}
+{{ . }}
+{{- end }}
+EOF"
+{{ range .Modules }}
+sudo modprobe {{ . }} || echo "kernel module {{ . }} is not available"
+{{- end }}
+
+sudo bash -c "cat > /etc/sysctl.d/99-kubernetes.conf <<EOF
+{{- range $key, $value := .Settings }}
+{{ $key }} = {{ $value }}
+{{- end }}
+EOF"
+sudo sysctl --system
+
+# Disable swap now and after reboot, kubelet refuses to start with swap on
+sudo swapoff -a
+sudo sed -i '/\sswap\s/ s/^#*/#/' /etc/fstab
+`
@@ -3,17 +3,25 @@ package templates
 var Default = map[string]string{
 	"add_authorized_keys":        addAuthorizedKeysTpl,
 	"bootstrap_token":            bootstrapTokenTpl,
+	"canary":                     canaryTpl,
+	"cert-manager":               certManagerTpl,
 	"certificates":               certificatesTpl,
+	"certificates_read":          certificatesReadTpl,
+	"certificates_write":         certificatesWriteTpl,
 	"cloudcontroller":            cloudcontrollerTpl,
 	"clustercheck":               clustercheckTpl,
 	"cni":                        cniTpl,
+	"coredns":                    corednsTpl,
 	"dashboard":                  dashboardTpl,
 	"docker":                     dockerTpl,
 	"download_kubernetes_binary": downloadKubernetesBinaryTpl,
 	"drain":                      drainTpl,
+	"graceful_delete":            gracefulDeleteTpl,
+	"external-dns":               externalDNSTpl,
 	"kubeadm":                    kubeadmTpl,
 	"kubelet":                    kubelet,
 	"network":                    networkTpl,
+	"node-problem-detector":      nodeProblemDetectorTpl,
 	"poststart":                  poststartTpl,
 	"prometheus":                 prometheusTpl,
 	"storageclass":               storageclassTpl,
@@ -24,4 +32,25 @@ var Default = map[string]string{
 	"apply":                      applyTpl,
 	"install_app":                installApp,
 	"helm":                       helmTpl,
+	"ingress":                    ingressTpl,
+	"hardening":                  hardeningTpl,
+	"compliance":                 complianceTpl,
+	"sysctl":                     sysctlTpl,
+	"chrony":                     chronyTpl,
+	"registry_credentials":       registryCredentialsTpl,
+	"os_patch":                   osPatchTpl,
+	"os_patch_wait":              osPatchWaitTpl,
+	"runtime_upgrade":            runtimeUpgradeTpl,
+	"etcd_member_sync":           etcdMemberSyncTpl,
+	"etcd_member_remove":         etcdMemberRemoveTpl,
+	"etcd_member_replace":        etcdMemberReplaceTpl,
+	"etcd_maintenance":           etcdMaintenanceTpl,
+	"ssh_key_add":                sshKeyAddTpl,
+	"ssh_key_remove":             sshKeyRemoveTpl,
+	"ssh_key_audit":              sshKeyAuditTpl,
+	"velero":                     veleroTpl,
+	"windows_prepare":            windowsPrepareTpl,
+	"windows_containerd":         windowsContainerdTpl,
+	"windows_kubelet":            windowsKubeletTpl,
+	"windows_cni":                windowsCNITpl,
 }
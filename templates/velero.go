@@ -0,0 +1,32 @@
+package templates
+
+const veleroTpl = `
+set -e
+VELERO=/usr/local/bin/velero
+if ! sudo $VELERO version --client-only 2>/dev/null | grep -q "v{{ .Version }}"; then
+  curl -sSL https://github.com/vmware-tanzu/velero/releases/download/v{{ .Version }}/velero-v{{ .Version }}-linux-amd64.tar.gz -o /tmp/velero.tar.gz
+  tar -xzf /tmp/velero.tar.gz -C /tmp
+  sudo mv /tmp/velero-v{{ .Version }}-linux-amd64/velero $VELERO
+  rm -rf /tmp/velero.tar.gz /tmp/velero-v{{ .Version }}-linux-amd64
+fi
+
+sudo $VELERO install --kubeconfig /etc/kubernetes/admin.conf \
+  --namespace {{ .Namespace }} \
+  --provider {{ .Provider }} \
+  --plugins {{ .Plugin }} \
+  --bucket {{ .Bucket }} \
+  {{- if .Prefix }}
+  --prefix {{ .Prefix }} \
+  {{- end }}
+  {{- if .LocationConfig }}
+  --backup-location-config {{ .LocationConfig }} \
+  {{- end }}
+  {{- if .SnapshotConfig }}
+  --snapshot-location-config {{ .SnapshotConfig }} \
+  {{- else }}
+  --use-volume-snapshots=false \
+  {{- end }}
+  --secret-file {{ .CredentialsFile }} \
+  --wait
+sudo rm -f {{ .CredentialsFile }}
+`
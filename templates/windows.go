@@ -0,0 +1,251 @@
+package templates
+
+// windowsPreludeTpl is a common beginning of PowerShell scripts, native
+// commands don't stop a script on errors, getFile fails on http errors.
+const windowsPreludeTpl = `
+$ErrorActionPreference = "Stop"
+$ProgressPreference = "SilentlyContinue"
+
+function getFile($Url, $Path) {
+	if (-not (Test-Path $Path)) {
+		curl.exe -sSLf --retry 5 -o $Path $Url
+		if ($LASTEXITCODE -ne 0) { throw "download $Url" }
+	}
+}
+
+function addPath($Dir) {
+	$path = [Environment]::GetEnvironmentVariable("Path", [EnvironmentVariableTarget]::Machine)
+	if (-not $path.Contains($Dir)) {
+		[Environment]::SetEnvironmentVariable("Path", "$path;$Dir", [EnvironmentVariableTarget]::Machine)
+	}
+	$env:Path += ";$Dir"
+}
+
+function nssm() {
+	& C:\k\nssm.exe $args | Out-Null
+	if ($LASTEXITCODE -ne 0) { throw "nssm $args" }
+}
+`
+
+// windowsPrepareTpl installs the Containers feature, the machine
+// restarts when it's installed for the first time.
+const windowsPrepareTpl = windowsPreludeTpl + `
+New-Item -ItemType Directory -Force -Path C:\k | Out-Null
+
+if (-not (Test-Path C:\k\nssm.exe)) {
+	getFile "https://nssm.cc/release/nssm-{{ .NSSMVersion }}.zip" "$env:TEMP\nssm.zip"
+	Expand-Archive -Force "$env:TEMP\nssm.zip" $env:TEMP
+	Copy-Item "$env:TEMP\nssm-{{ .NSSMVersion }}\win64\nssm.exe" C:\k\nssm.exe
+}
+
+$feature = Install-WindowsFeature -Name Containers
+if ($feature.RestartNeeded -eq "Yes") {
+	Write-Output "restart to finish the Containers feature installation"
+	shutdown.exe /r /t 10 /c "supergiant: install Containers feature"
+}
+`
+
+const windowsContainerdTpl = windowsPreludeTpl + `
+$Dir = "$env:ProgramFiles\containerd"
+
+if (-not (Test-Path "$Dir\containerd.exe")) {
+	getFile "https://github.com/containerd/containerd/releases/download/v{{ .ContainerdVersion }}/containerd-{{ .ContainerdVersion }}-windows-amd64.tar.gz" "$env:TEMP\containerd.tar.gz"
+	tar.exe -xzf "$env:TEMP\containerd.tar.gz" -C $env:TEMP
+	if ($LASTEXITCODE -ne 0) { throw "extract containerd" }
+	New-Item -ItemType Directory -Force -Path $Dir | Out-Null
+	Copy-Item "$env:TEMP\bin\*" $Dir -Force
+}
+addPath $Dir
+
+New-Item -ItemType Directory -Force -Path C:\opt\cni\bin, C:\etc\cni\net.d | Out-Null
+$config = (& "$Dir\containerd.exe" config default) -join [Environment]::NewLine
+$config = $config -replace 'bin_dir = .*', 'bin_dir = "C:/opt/cni/bin"'
+$config = $config -replace 'conf_dir = .*', 'conf_dir = "C:/etc/cni/net.d"'
+[IO.File]::WriteAllText("$Dir\config.toml", $config)
+
+if (-not (Get-Service containerd -ErrorAction SilentlyContinue)) {
+	& "$Dir\containerd.exe" --register-service
+	if ($LASTEXITCODE -ne 0) { throw "register containerd service" }
+}
+Restart-Service containerd
+Set-Service containerd -StartupType Automatic
+`
+
+// windowsKubeletTpl joins the cluster with kubeadm, kubeadm expects a kubelet
+// service that reads flags from the files kubeadm writes like on linux.
+const windowsKubeletTpl = windowsPreludeTpl + `
+New-Item -ItemType Directory -Force -Path C:\k, C:\var\log\kubelet, C:\var\lib\kubelet\etc\kubernetes\pki, C:\etc\kubernetes\pki | Out-Null
+addPath C:\k
+
+foreach ($bin in "kubelet.exe", "kubeadm.exe", "kubectl.exe", "kube-proxy.exe") {
+	getFile "https://dl.k8s.io/v{{ .K8SVersion }}/bin/windows/amd64/$bin" "C:\k\$bin"
+}
+
+Set-Content -Path C:\k\StartKubelet.ps1 -Encoding ASCII -Value @'
+$flags = (Get-Content C:\var\lib\kubelet\kubeadm-flags.env) -replace '^KUBELET_KUBEADM_ARGS=', '' -replace '"', ''
+$kubeletArgs = $flags.Split(' ', [StringSplitOptions]::RemoveEmptyEntries) + @(
+	"--config=C:\var\lib\kubelet\config.yaml",
+	"--bootstrap-kubeconfig=C:\etc\kubernetes\bootstrap-kubelet.conf",
+	"--kubeconfig=C:\etc\kubernetes\kubelet.conf",
+	"--cert-dir=C:\var\lib\kubelet\pki",
+	"--rotate-server-certificates",
+	"--hostname-override={{ .NodeName }}",
+	"--enable-debugging-handlers",
+	"--cgroups-per-qos=false",
+	"--enforce-node-allocatable=",
+	"--resolv-conf=",
+	"--log-dir=C:\var\log\kubelet",
+	"--logtostderr=false"
+)
+& C:\k\kubelet.exe @kubeletArgs
+'@
+
+if (-not (Get-Service kubelet -ErrorAction SilentlyContinue)) {
+	nssm install kubelet (Get-Command powershell).Source "-ExecutionPolicy Bypass -NoProfile C:\k\StartKubelet.ps1"
+	nssm set kubelet DependOnService containerd
+	nssm set kubelet AppStdout C:\var\log\kubelet\kubelet.log
+	nssm set kubelet AppStderr C:\var\log\kubelet\kubelet.err.log
+}
+
+New-NetFirewallRule -Name kubelet -DisplayName "kubelet" -Enabled True -Direction Inbound -Protocol TCP -Action Allow -LocalPort 10250 -ErrorAction SilentlyContinue | Out-Null
+
+if (Test-Path C:\etc\kubernetes\kubelet.conf) {
+	Write-Output "node {{ .NodeName }} has already joined the cluster"
+	exit 0
+}
+
+Set-Content -Path C:\etc\kubernetes\kubeadm.conf -Encoding ASCII -Value @'
+apiVersion: kubeadm.k8s.io/v1beta2
+kind: JoinConfiguration
+nodeRegistration:
+  name: {{ .NodeName }}
+  criSocket: npipe:////./pipe/containerd-containerd
+  kubeletExtraArgs:
+    node-ip: {{ .NodeIP }}
+    {{- if .NodeLabels }}
+    node-labels: "{{ .NodeLabels }}"
+    {{- end }}
+    {{- if .NodeTaints }}
+    register-with-taints: "{{ .NodeTaints }}"
+    {{- end }}
+    {{- range $key, $value := .ExtraArgs }}
+    {{ $key }}: "{{ $value }}"
+    {{- end }}
+discovery:
+  bootstrapToken:
+    token: {{ .Token }}
+    apiServerEndpoint: {{ .InternalDNSName }}:{{ .APIServerPort }}
+    caCertHashes:
+    - {{ .CACertHash }}
+'@
+
+kubeadm.exe join --config C:\etc\kubernetes\kubeadm.conf
+if ($LASTEXITCODE -ne 0) { throw "kubeadm join" }
+`
+
+// windowsCNITpl runs flannel and kube-proxy as windows services, the overlay
+// network needs flannel vxlan backend with VNI 4096 and port 4789 on linux nodes.
+const windowsCNITpl = windowsPreludeTpl + `
+$CniBin = "C:\opt\cni\bin"
+$NetworkName = "vxlan0"
+
+New-Item -ItemType Directory -Force -Path $CniBin, C:\etc\cni\net.d, C:\etc\kube-flannel, C:\run\flannel, C:\var\log\flanneld, C:\var\log\kube-proxy | Out-Null
+
+getFile "https://github.com/containernetworking/plugins/releases/download/v{{ .CNIVersion }}/cni-plugins-windows-amd64-v{{ .CNIVersion }}.tgz" "$env:TEMP\cni-plugins.tgz"
+if (-not (Test-Path "$CniBin\win-overlay.exe")) {
+	tar.exe -xzf "$env:TEMP\cni-plugins.tgz" -C $CniBin
+	if ($LASTEXITCODE -ne 0) { throw "extract cni plugins" }
+}
+getFile "https://github.com/coreos/flannel/releases/download/v{{ .FlannelVersion }}/flanneld.exe" C:\k\flanneld.exe
+getFile "https://raw.githubusercontent.com/microsoft/SDN/master/Kubernetes/windows/hns.psm1" C:\k\hns.psm1
+
+# An external network keeps the host connected when flannel creates the overlay one
+Import-Module C:\k\hns.psm1 -DisableNameChecking
+if (-not (Get-HnsNetwork | Where-Object { $_.Name -eq "External" })) {
+	$adapter = (Get-NetIPAddress -IPAddress {{ .NodeIP }}).InterfaceAlias
+	New-HNSNetwork -Type Overlay -AddressPrefix "192.168.255.0/30" -Gateway "192.168.255.1" -Name "External" -AdapterName $adapter -SubnetPolicies @(@{Type = "VSID"; VSID = 9999; }) | Out-Null
+	Start-Sleep 10
+}
+
+Set-Content -Path C:\etc\kube-flannel\net-conf.json -Encoding ASCII -Value @'
+{
+  "Network": "{{ .CIDR }}",
+  "Backend": {
+    "name": "vxlan0",
+    "type": "vxlan",
+    "VNI": 4096,
+    "Port": 4789
+  }
+}
+'@
+
+Set-Content -Path C:\etc\cni\net.d\10-flannel.conf -Encoding ASCII -Value @'
+{
+  "name": "vxlan0",
+  "cniVersion": "0.2.0",
+  "type": "flannel",
+  "capabilities": {
+    "portMappings": true,
+    "dns": true
+  },
+  "delegate": {
+    "type": "win-overlay",
+    "policies": [
+      {
+        "Name": "EndpointPolicy",
+        "Value": {
+          "Type": "OutBoundNAT",
+          "ExceptionList": ["{{ .CIDR }}", "{{ .ServiceCIDR }}"]
+        }
+      },
+      {
+        "Name": "EndpointPolicy",
+        "Value": {
+          "Type": "ROUTE",
+          "DestinationPrefix": "{{ .ServiceCIDR }}",
+          "NeedEncap": true
+        }
+      }
+    ]
+  }
+}
+'@
+
+if (-not (Get-Service flanneld -ErrorAction SilentlyContinue)) {
+	nssm install flanneld C:\k\flanneld.exe
+	nssm set flanneld AppParameters "--kubeconfig-file=C:\etc\kubernetes\kubelet.conf --iface={{ .NodeIP }} --ip-masq=1 --kube-subnet-mgr=1"
+	nssm set flanneld AppEnvironmentExtra NODE_NAME={{ .NodeName }}
+	nssm set flanneld DependOnService kubelet
+	nssm set flanneld AppStdout C:\var\log\flanneld\flanneld.log
+	nssm set flanneld AppStderr C:\var\log\flanneld\flanneld.err.log
+}
+Start-Service flanneld
+
+# flannel writes the node subnet when the overlay network is ready
+$attempts = 0
+while (-not (Test-Path C:\run\flannel\subnet.env) -or -not (Get-HnsNetwork | Where-Object { $_.Name -eq $NetworkName })) {
+	$attempts++
+	if ($attempts -ge 60) { throw "flannel has not created $NetworkName network" }
+	Start-Sleep 5
+}
+
+# kube-proxy needs an address from the node subnet to masquerade service traffic
+$subnet = ((Get-Content C:\run\flannel\subnet.env | Select-String FLANNEL_SUBNET) -split '=')[1]
+$conf = '{"cniVersion": "0.2.0", "name": "' + $NetworkName + '", "ipam": {"type": "host-local", "ranges": [[{"subnet": "' + $subnet + '"}]], "dataDir": "C:/var/lib/cni/networks"}}'
+$env:CNI_COMMAND = "ADD"
+$env:CNI_CONTAINERID = "kube-proxy"
+$env:CNI_NETNS = "kube-proxy"
+$env:CNI_IFNAME = "kube-proxy"
+$env:CNI_PATH = $CniBin
+$sourceVip = ((($conf | & "$CniBin\host-local.exe") | ConvertFrom-Json).ip4.ip -split '/')[0]
+if (-not $sourceVip) { throw "allocate kube-proxy source vip" }
+
+if (-not (Get-Service kube-proxy -ErrorAction SilentlyContinue)) {
+	nssm install kube-proxy C:\k\kube-proxy.exe
+	nssm set kube-proxy DependOnService flanneld
+	nssm set kube-proxy AppStdout C:\var\log\kube-proxy\kube-proxy.log
+	nssm set kube-proxy AppStderr C:\var\log\kube-proxy\kube-proxy.err.log
+}
+nssm set kube-proxy AppParameters "--v=4 --proxy-mode=kernelspace --hostname-override={{ .NodeName }} --kubeconfig=C:\etc\kubernetes\kubelet.conf --cluster-cidr={{ .CIDR }} --network-name=$NetworkName --source-vip=$sourceVip --feature-gates=WinOverlay=true"
+Restart-Service kube-proxy
+`